@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"lemon/internal/config"
+	"lemon/internal/pkg/mongodb"
+	"lemon/internal/pkg/noveltools/providers"
+	"lemon/internal/pkg/storagefactory"
+	"lemon/internal/regression"
+	"lemon/internal/service"
+	novelService "lemon/internal/service/novel"
+)
+
+var regressionCmd = &cobra.Command{
+	Use:   "regression",
+	Short: "Run the golden novel regression suite",
+	Long: `Process the golden novel end-to-end with synthetic providers and compare the
+outputs (structure, durations, checksums) against the stored golden snapshot.
+
+The run uses a throwaway MongoDB database (<mongo.database>_regression) and a
+temporary local storage directory, both removed afterwards unless --keep-data is set.
+Exits non-zero when drift is detected. Requires MongoDB and FFmpeg.`,
+	RunE: runRegression,
+}
+
+func init() {
+	rootCmd.AddCommand(regressionCmd)
+
+	flags := regressionCmd.Flags()
+	flags.String("golden-dir", "assets/regression/golden", "golden novel directory")
+	flags.Bool("update", false, "overwrite the golden snapshot with this run's outputs")
+	flags.Float64("tolerance", 0.05, "allowed duration difference in seconds")
+	flags.Bool("keep-data", false, "keep the regression database and storage directory")
+}
+
+func runRegression(cmd *cobra.Command, args []string) error {
+	cfg := GetConfig()
+	flags := cmd.Flags()
+	goldenDir, _ := flags.GetString("golden-dir")
+	update, _ := flags.GetBool("update")
+	tolerance, _ := flags.GetFloat64("tolerance")
+	keepData, _ := flags.GetBool("keep-data")
+
+	fixture, err := regression.LoadFixture(goldenDir)
+	if err != nil {
+		return fmt.Errorf("load golden novel: %w", err)
+	}
+	expected, err := fixture.LoadExpected()
+	if err != nil && !(update && errors.Is(err, regression.ErrNoExpectedSnapshot)) {
+		if errors.Is(err, regression.ErrNoExpectedSnapshot) {
+			return fmt.Errorf("%w in %s, run with --update to create it", err, goldenDir)
+		}
+		return err
+	}
+
+	ctx := context.Background()
+
+	// 独立的数据库和存储，避免污染正式数据
+	mongoCfg := cfg.Mongo
+	mongoCfg.Database = cfg.Mongo.Database + "_regression"
+	mongoClient, err := mongodb.New(&mongoCfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect mongodb: %w", err)
+	}
+	db := mongoClient.Database()
+	if err := db.Drop(ctx); err != nil {
+		return fmt.Errorf("failed to reset regression database: %w", err)
+	}
+	if err := mongodb.EnsureIndexes(db); err != nil {
+		return fmt.Errorf("failed to ensure indexes: %w", err)
+	}
+
+	storageDir, err := os.MkdirTemp("", "lemon-regression-*")
+	if err != nil {
+		return fmt.Errorf("failed to create storage dir: %w", err)
+	}
+	defer func() {
+		if keepData {
+			log.Info().Str("database", db.Name()).Str("storage_dir", storageDir).Msg("regression data kept")
+		} else {
+			_ = db.Drop(ctx)
+			_ = os.RemoveAll(storageDir)
+		}
+		_ = mongoClient.Close(ctx)
+	}()
+
+	storage, err := storagefactory.NewStorage(ctx, &config.StorageConfig{
+		Type: "local",
+		Local: &config.LocalConfig{
+			BasePath:      storageDir,
+			BaseURL:       "http://localhost/regression",
+			PresignExpiry: 3600,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create storage: %w", err)
+	}
+
+	resourceSvc := service.NewResourceService(db, storage)
+	novelSvc, err := novelService.NewNovelService(db, resourceSvc, novelService.WithProviders(
+		providers.NewSyntheticLLMProvider(fixture.Responses),
+		providers.NewSyntheticTTSProvider(),
+		providers.NewSyntheticImageProvider(),
+		providers.NewSyntheticVideoProvider(),
+	))
+	if err != nil {
+		return fmt.Errorf("failed to create novel service: %w", err)
+	}
+
+	actual, err := regression.NewRunner(novelSvc, resourceSvc).Run(ctx, fixture)
+	if err != nil {
+		return fmt.Errorf("regression run failed: %w", err)
+	}
+
+	if update {
+		if err := fixture.SaveExpected(actual); err != nil {
+			return err
+		}
+		log.Info().Str("golden_dir", goldenDir).Int("chapters", len(actual.Chapters)).Msg("golden snapshot updated")
+		return nil
+	}
+
+	drifts := regression.Compare(expected, actual, tolerance)
+	if len(drifts) > 0 {
+		for _, d := range drifts {
+			fmt.Fprintln(cmd.OutOrStdout(), d.String())
+		}
+		return fmt.Errorf("regression drift detected: %d difference(s)", len(drifts))
+	}
+	log.Info().Int("chapters", len(actual.Chapters)).Msg("regression passed, no drift")
+	return nil
+}
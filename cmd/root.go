@@ -117,6 +117,54 @@ func setDefaults() {
 	// Redis
 	viper.SetDefault("redis.addr", "localhost:6379")
 	viper.SetDefault("redis.db", 0)
+
+	// Auth
+	viper.SetDefault("auth.enforce_novel_access", false)
+
+	// Maintenance
+	viper.SetDefault("maintenance.enabled", false)
+	viper.SetDefault("maintenance.in_flight_policy", "finish")
+
+	// Embed
+	viper.SetDefault("embed.rate_limit_per_minute", 120)
+	viper.SetDefault("embed.url_expiry", "1h")
+
+	// FFmpeg
+	viper.SetDefault("ffmpeg.require_subtitles", true)
+	viper.SetDefault("ffmpeg.probe_timeout", "15s")
+
+	// Asset cache
+	viper.SetDefault("asset_cache.lead_time", "1h")
+	viper.SetDefault("asset_cache.max_age", "72h")
+
+	// Provider response cache
+	viper.SetDefault("provider_cache.enabled", false)
+	viper.SetDefault("provider_cache.ttl", "24h")
+	viper.SetDefault("provider_cache.max_entries", 2000)
+	viper.SetDefault("provider_cache.max_bytes", 64<<20)
+
+	// Process reaper
+	viper.SetDefault("proc_reaper.enabled", true)
+	viper.SetDefault("proc_reaper.interval", "1m")
+	viper.SetDefault("proc_reaper.stale_temp_age", "12h")
+
+	// Resource garbage collection
+	viper.SetDefault("resource_gc.enabled", false)
+	viper.SetDefault("resource_gc.interval", "24h")
+	viper.SetDefault("resource_gc.retention", "720h")
+	viper.SetDefault("resource_gc.grace_period", "72h")
+	viper.SetDefault("resource_gc.dry_run", false)
+
+	// API rate limiting
+	viper.SetDefault("rate_limit.enabled", true)
+	viper.SetDefault("rate_limit.read_per_minute", 600)
+	viper.SetDefault("rate_limit.write_per_minute", 120)
+	viper.SetDefault("rate_limit.generation_per_minute", 20)
+	viper.SetDefault("rate_limit.exempt_cidrs", []string{"127.0.0.1/32", "::1/128"})
+
+	// Final video notarization
+	viper.SetDefault("notarization.tsa_url", "")
+	viper.SetDefault("notarization.tsa_timeout", "10s")
 }
 
 // GetConfig returns the global configuration
@@ -2,18 +2,35 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
 	"time"
+
+	"lemon/internal/pkg/environment"
+	"lemon/internal/pkg/storage"
 )
 
 // Config 应用配置根结构
 type Config struct {
-	Server  ServerConfig  `mapstructure:"server"`
-	AI      AIConfig      `mapstructure:"ai"`
-	Log     LogConfig     `mapstructure:"log"`
-	Mongo   MongoConfig   `mapstructure:"mongo"`
-	Redis   RedisConfig   `mapstructure:"redis"`
-	Auth    AuthConfig    `mapstructure:"auth"`
-	Storage StorageConfig `mapstructure:"storage"`
+	Environment   string              `mapstructure:"environment"` // 部署环境：dev, staging, prod（为空时为 dev），决定使用哪一套供应商凭据
+	Server        ServerConfig        `mapstructure:"server"`
+	AI            AIConfig            `mapstructure:"ai"`
+	Log           LogConfig           `mapstructure:"log"`
+	Mongo         MongoConfig         `mapstructure:"mongo"`
+	Redis         RedisConfig         `mapstructure:"redis"`
+	Auth          AuthConfig          `mapstructure:"auth"`
+	Storage       StorageConfig       `mapstructure:"storage"`
+	Maintenance   MaintenanceConfig   `mapstructure:"maintenance"`
+	Embed         EmbedConfig         `mapstructure:"embed"`
+	FFmpeg        FFmpegConfig        `mapstructure:"ffmpeg"`
+	AssetCache    AssetCacheConfig    `mapstructure:"asset_cache"`
+	ProviderCache ProviderCacheConfig `mapstructure:"provider_cache"`
+	ProcReaper    ProcReaperConfig    `mapstructure:"proc_reaper"`
+	ResourceGC    ResourceGCConfig    `mapstructure:"resource_gc"`
+	RateLimit     RateLimitConfig     `mapstructure:"rate_limit"`
+	Notarization  NotarizationConfig  `mapstructure:"notarization"`
 }
 
 // ServerConfig HTTP 服务器配置
@@ -23,6 +40,10 @@ type ServerConfig struct {
 	Mode         string        `mapstructure:"mode"`
 	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
 	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+
+	// TrustedProxies 信任的反向代理（IP 或网段），只有来自这些地址的 X-Forwarded-For/X-Real-IP 才会被用作客户端 IP
+	// 为空时不信任任何代理，客户端 IP 即连接的远端地址
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 // AIConfig AI 服务配置
@@ -70,6 +91,7 @@ type AuthConfig struct {
 	JWTSecret          string        `mapstructure:"jwt_secret"`           // JWT密钥
 	AccessTokenExpiry  time.Duration `mapstructure:"access_token_expiry"`  // Access Token过期时间
 	RefreshTokenExpiry time.Duration `mapstructure:"refresh_token_expiry"` // Refresh Token过期时间
+	EnforceNovelAccess bool          `mapstructure:"enforce_novel_access"` // 小说接口是否要求登录并检查小说权限（所有者/协作授权/角色）
 }
 
 // StorageConfig 存储配置
@@ -77,6 +99,45 @@ type StorageConfig struct {
 	Type  string       `mapstructure:"type"` // local, oss, s3, minio
 	Local *LocalConfig `mapstructure:"local,omitempty"`
 	OSS   *OSSConfig   `mapstructure:"oss,omitempty"`
+	S3    *S3Config    `mapstructure:"s3,omitempty"` // s3、minio 共用
+
+	// KeyTemplates 按产物类型配置的存储路径模板（default 用于未单独配置的类型），为空时使用 resources/{user_id}/{resource_id}.{ext}
+	// 例如 novels/{novel_id}/ch{chapter_seq}/{artifact_type}/v{version}/{seq}.{ext}
+	KeyTemplates map[string]string `mapstructure:"key_templates"`
+
+	// URLLifetimes 按用途配置预签名URL的有效期（upload、download、playback、preview），未配置的字段使用默认策略
+	// 预签名URL不保存在业务记录中，每次返回前按策略重新生成
+	URLLifetimes map[string]URLLifetimeConfig `mapstructure:"url_lifetimes"`
+
+	// Secondary 备用存储（可选）：写入主存储后异步复制到备用存储，主存储不可用时读写切换到备用存储
+	Secondary   *SecondaryStorageConfig `mapstructure:"secondary,omitempty"`
+	Replication ReplicationConfig       `mapstructure:"replication"`
+
+	// MaxUploadSize 通过上传接口上传的文件大小上限（字节），超出时中止上传并删除已写入的数据；0 表示不限制
+	MaxUploadSize int64 `mapstructure:"max_upload_size"`
+}
+
+// URLLifetimeConfig 预签名URL有效期配置
+type URLLifetimeConfig struct {
+	Default       time.Duration `mapstructure:"default"`        // 未指定有效期时使用
+	Max           time.Duration `mapstructure:"max"`            // 最长有效期（请求的有效期超出时截断）
+	RefreshBefore time.Duration `mapstructure:"refresh_before"` // 响应中的链接剩余有效期不足该值时重新生成
+}
+
+// SecondaryStorageConfig 备用存储配置
+type SecondaryStorageConfig struct {
+	Type  string       `mapstructure:"type"` // local, oss, s3, minio
+	Local *LocalConfig `mapstructure:"local,omitempty"`
+	OSS   *OSSConfig   `mapstructure:"oss,omitempty"`
+	S3    *S3Config    `mapstructure:"s3,omitempty"`
+}
+
+// ReplicationConfig 主备存储复制配置（仅配置了备用存储时生效）
+type ReplicationConfig struct {
+	QueueSize         int           `mapstructure:"queue_size"`         // 异步复制队列长度（默认 1024），队列满时由对账任务补齐
+	Workers           int           `mapstructure:"workers"`            // 复制并发数（默认 2）
+	FailoverCooldown  time.Duration `mapstructure:"failover_cooldown"`  // 主存储出错后多久内直接使用备用存储（默认 30s）
+	ReconcileInterval time.Duration `mapstructure:"reconcile_interval"` // 对账间隔（默认 10m），对账修复复制失败和两侧不一致的文件
 }
 
 // LocalConfig 本地文件系统配置
@@ -95,6 +156,122 @@ type OSSConfig struct {
 	PresignExpiry   int    `mapstructure:"presign_expiry"`    // 预签名URL过期时间（秒）
 }
 
+// S3Config S3 兼容存储配置（AWS S3、MinIO、阿里云 OSS 的 S3 兼容端点）
+type S3Config struct {
+	Endpoint        string `mapstructure:"endpoint"`          // 服务端点，如 https://s3.us-east-1.amazonaws.com、http://minio:9000（为空时按 region 使用 AWS 端点）
+	Region          string `mapstructure:"region"`            // 区域（参与签名），为空时使用 us-east-1
+	Bucket          string `mapstructure:"bucket"`            // Bucket名称
+	AccessKeyID     string `mapstructure:"access_key_id"`     // AccessKey ID
+	SecretAccessKey string `mapstructure:"secret_access_key"` // Secret Access Key
+	SessionToken    string `mapstructure:"session_token"`     // 临时凭证的 Session Token（可选）
+	UsePathStyle    *bool  `mapstructure:"use_path_style"`    // 路径风格访问（endpoint/bucket/key），未配置时 minio 为 true、s3 为 false
+	PresignExpiry   int    `mapstructure:"presign_expiry"`    // 预签名URL过期时间（秒）
+}
+
+// MaintenanceConfig 维护模式（熔断开关）配置
+// 供应商故障时用于立即停止新的生成任务，运行期也可以通过接口开关
+type MaintenanceConfig struct {
+	Enabled           bool     `mapstructure:"enabled"`            // 是否开启全局维护模式
+	Reason            string   `mapstructure:"reason"`             // 维护原因（返回给调用方）
+	DisabledProviders []string `mapstructure:"disabled_providers"` // 暂停的 provider（llm, tts, image, video）
+	InFlightPolicy    string   `mapstructure:"in_flight_policy"`   // 进行中任务的处理策略：finish（继续执行）, abort（立即取消）
+}
+
+// EmbedConfig 公开嵌入播放接口配置
+// 合作方通过嵌入令牌调用只读接口，跨域来源和限流独立于管理接口
+type EmbedConfig struct {
+	AllowedOrigins     []string      `mapstructure:"allowed_origins"`       // 允许嵌入的来源（如 https://partner.example.com，* 表示任意来源）；令牌未单独配置时使用
+	RateLimitPerMinute int           `mapstructure:"rate_limit_per_minute"` // 每个令牌每分钟请求上限（令牌未单独配置时使用，0 表示不限流）
+	URLExpiry          time.Duration `mapstructure:"url_expiry"`            // 视频/字幕/缩略图播放地址的有效期
+}
+
+// FFmpegConfig FFmpeg 配置
+// 启动时会解析 ffmpeg/ffprobe 路径并检查版本，再检测字幕烧录（libass）是否可用，避免在不支持的机器上生成没有字幕的视频
+type FFmpegConfig struct {
+	RequireSubtitles bool          `mapstructure:"require_subtitles"` // 字幕烧录不可用时拒绝启动（false 时只记录警告，并在健康检查中标记）
+	ProbeTimeout     time.Duration `mapstructure:"probe_timeout"`     // 启动检测的超时时间
+
+	Path            string                 `mapstructure:"path"`             // ffmpeg 路径（为空时依次尝试 FFMPEG_PATH、数据目录中已下载的静态构建、系统 PATH）
+	FFprobePath     string                 `mapstructure:"ffprobe_path"`     // ffprobe 路径（为空时取 ffmpeg 同目录下的 ffprobe）
+	MinVersion      string                 `mapstructure:"min_version"`      // 支持的最低版本（含），为空表示不限制
+	MaxVersion      string                 `mapstructure:"max_version"`      // 支持的最高版本（不含），为空表示不限制
+	AutoDownload    bool                   `mapstructure:"auto_download"`    // 找不到 ffmpeg 或版本不在范围内时自动下载固定版本的静态构建
+	DataDir         string                 `mapstructure:"data_dir"`         // 静态构建的存放目录
+	Version         string                 `mapstructure:"version"`          // 固定的静态构建版本
+	Downloads       []FFmpegDownloadConfig `mapstructure:"downloads"`        // 静态构建下载地址（.zip 或 .tar.gz）
+	DownloadTimeout time.Duration          `mapstructure:"download_timeout"` // 自动下载的超时时间
+}
+
+// FFmpegDownloadConfig FFmpeg 静态构建下载地址
+type FFmpegDownloadConfig struct {
+	URL    string `mapstructure:"url"`    // 下载地址
+	SHA256 string `mapstructure:"sha256"` // 压缩包 SHA256（为空时不校验）
+}
+
+// AssetCacheConfig 本地资源缓存配置
+// 定时渲染前把图片/音频/字幕预先下载到本地，渲染时优先读取本地缓存
+type AssetCacheConfig struct {
+	Dir      string        `mapstructure:"dir"`       // 缓存目录，为空时不启用缓存和素材预热
+	LeadTime time.Duration `mapstructure:"lead_time"` // 渲染窗口开始前多久开始预热
+	MaxAge   time.Duration `mapstructure:"max_age"`   // 超过该时间未访问的缓存文件在预热前清理（0 表示不清理）
+}
+
+// ProviderCacheConfig provider 响应缓存配置（进程内存）
+// 部分失败后重试时相同提示词的 LLM 请求直接复用上次的输出，避免重复付费
+type ProviderCacheConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`     // 是否启用（默认关闭）
+	TTL        time.Duration `mapstructure:"ttl"`         // 缓存有效期
+	MaxEntries int           `mapstructure:"max_entries"` // 最多缓存的响应数
+	MaxBytes   int64         `mapstructure:"max_bytes"`   // 缓存内容的总字节数上限
+}
+
+// ProcReaperConfig 孤儿进程与临时文件回收配置
+// 记录生成任务启动的 ffmpeg 进程和临时目录，定期结束任务已结束的残留进程、清理崩溃留下的临时文件
+type ProcReaperConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`        // 是否启用（默认开启）
+	StateDir     string        `mapstructure:"state_dir"`      // 进程和临时目录记录的存放目录（为空时使用系统临时目录下的 lemon-reaper）
+	Interval     time.Duration `mapstructure:"interval"`       // 回收间隔
+	StaleTempAge time.Duration `mapstructure:"stale_temp_age"` // 系统临时目录中的残留临时文件超过该时间未修改时清理
+}
+
+// ResourceGCConfig 资源回收配置
+// 定期回收没有被任何业务记录引用的资源：超过保留期的资源先标记删除（软删除），宽限期内没有重新被引用时从存储中删除
+type ResourceGCConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`      // 是否启用定时回收（默认关闭，关闭时仍可通过管理接口手动执行）
+	Interval    time.Duration `mapstructure:"interval"`     // 回收间隔
+	Retention   time.Duration `mapstructure:"retention"`    // 创建超过该时间且没有被引用的资源才会被标记删除
+	GracePeriod time.Duration `mapstructure:"grace_period"` // 标记删除后超过该时间仍没有被引用时从存储中删除
+	DryRun      bool          `mapstructure:"dry_run"`      // 定时回收只统计不删除
+}
+
+// RateLimitConfig 接口限流配置
+// 按用户（未登录时按客户端 IP）和接口类别分别使用令牌桶限流，配置了 Redis 时多个实例共享计数
+type RateLimitConfig struct {
+	Enabled             bool     `mapstructure:"enabled"`               // 是否启用
+	ReadPerMinute       int      `mapstructure:"read_per_minute"`       // 查询类接口（GET/HEAD）每分钟请求上限（0 表示不限）
+	WritePerMinute      int      `mapstructure:"write_per_minute"`      // 其他修改类接口每分钟请求上限（0 表示不限，生成类接口同时计入）
+	GenerationPerMinute int      `mapstructure:"generation_per_minute"` // 生成类接口每分钟请求上限（0 表示不限）
+	ExemptUserIDs       []string `mapstructure:"exempt_user_ids"`       // 不限流的用户（内部服务账号等）
+	ExemptRoles         []string `mapstructure:"exempt_roles"`          // 不限流的角色
+	ExemptCIDRs         []string `mapstructure:"exempt_cidrs"`          // 不限流的来源网段（内部调用方、本机）
+
+	Endpoints []RateLimitEndpointConfig `mapstructure:"endpoints"` // 单独限流的接口（与类别限流同时生效，用于限制开销特别大的接口）
+}
+
+// RateLimitEndpointConfig 单个接口的限流配置
+type RateLimitEndpointConfig struct {
+	Method    string `mapstructure:"method"`     // 请求方法，如 POST
+	Path      string `mapstructure:"path"`       // 路由模板，如 /api/v1/novels/chapters/:chapter_id/narration
+	PerMinute int    `mapstructure:"per_minute"` // 每分钟请求上限
+}
+
+// NotarizationConfig 最终视频公证配置
+// 章节最终视频发布后登记文件的 SHA256；配置了时间戳服务时公证记录另外由外部时间戳服务（RFC 3161）签名
+type NotarizationConfig struct {
+	TSAURL     string        `mapstructure:"tsa_url"`     // 时间戳服务地址（为空时不申请外部时间戳）
+	TSATimeout time.Duration `mapstructure:"tsa_timeout"` // 时间戳请求超时
+}
+
 // Validate 验证配置有效性
 func (c *Config) Validate() error {
 	if c.Server.Port <= 0 || c.Server.Port > 65535 {
@@ -106,5 +283,99 @@ func (c *Config) Validate() error {
 		return errors.New("invalid server mode, must be debug/release/test")
 	}
 
+	if _, err := environment.Parse(c.Environment); err != nil {
+		return err
+	}
+
+	validPolicies := map[string]bool{"": true, "finish": true, "abort": true}
+	if !validPolicies[c.Maintenance.InFlightPolicy] {
+		return errors.New("invalid maintenance in_flight_policy, must be finish/abort")
+	}
+
+	if c.Embed.RateLimitPerMinute < 0 {
+		return errors.New("invalid embed rate_limit_per_minute, must not be negative")
+	}
+
+	if c.FFmpeg.ProbeTimeout < 0 || c.FFmpeg.DownloadTimeout < 0 {
+		return errors.New("invalid ffmpeg probe_timeout/download_timeout, must not be negative")
+	}
+
+	if c.FFmpeg.AutoDownload && (c.FFmpeg.DataDir == "" || len(c.FFmpeg.Downloads) == 0) {
+		return errors.New("ffmpeg auto_download requires data_dir and downloads")
+	}
+
+	if c.AssetCache.LeadTime < 0 || c.AssetCache.MaxAge < 0 {
+		return errors.New("invalid asset_cache lead_time/max_age, must not be negative")
+	}
+
+	if c.ProviderCache.TTL < 0 || c.ProviderCache.MaxEntries < 0 || c.ProviderCache.MaxBytes < 0 {
+		return errors.New("invalid provider_cache ttl/max_entries/max_bytes, must not be negative")
+	}
+
+	if c.ProcReaper.Enabled && c.ProcReaper.Interval <= 0 {
+		return errors.New("invalid proc_reaper interval, must be positive")
+	}
+
+	if c.ProcReaper.StaleTempAge < 0 {
+		return errors.New("invalid proc_reaper stale_temp_age, must not be negative")
+	}
+
+	if c.ResourceGC.Enabled && c.ResourceGC.Interval <= 0 {
+		return errors.New("invalid resource_gc interval, must be positive")
+	}
+
+	if c.ResourceGC.Retention <= 0 || c.ResourceGC.GracePeriod < 0 {
+		return errors.New("invalid resource_gc retention/grace_period, retention must be positive and grace_period must not be negative")
+	}
+
+	if c.RateLimit.ReadPerMinute < 0 || c.RateLimit.WritePerMinute < 0 || c.RateLimit.GenerationPerMinute < 0 {
+		return errors.New("invalid rate_limit read/write/generation_per_minute, must not be negative")
+	}
+
+	if c.Storage.MaxUploadSize < 0 {
+		return errors.New("invalid storage max_upload_size, must not be negative")
+	}
+
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return fmt.Errorf("invalid server trusted_proxies %q, must be an IP or CIDR", proxy)
+		}
+	}
+
+	for _, cidr := range c.RateLimit.ExemptCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("invalid rate_limit exempt_cidrs %q: %w", cidr, err)
+		}
+	}
+
+	for _, ep := range c.RateLimit.Endpoints {
+		if ep.Method == "" || !strings.HasPrefix(ep.Path, "/") || ep.PerMinute <= 0 {
+			return fmt.Errorf("invalid rate_limit endpoint %q %q, requires method, path starting with / and positive per_minute", ep.Method, ep.Path)
+		}
+	}
+
+	if c.Notarization.TSAURL != "" {
+		if u, err := url.Parse(c.Notarization.TSAURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("invalid notarization tsa_url, must be an http(s) address")
+		}
+	}
+
+	if c.Notarization.TSATimeout < 0 {
+		return errors.New("invalid notarization tsa_timeout, must not be negative")
+	}
+
+	if err := storage.KeyTemplates(c.Storage.KeyTemplates).Validate(); err != nil {
+		return fmt.Errorf("invalid storage key_templates: %w", err)
+	}
+
+	if c.Storage.Secondary != nil && c.Storage.Secondary.Type == "" {
+		return errors.New("storage secondary requires type")
+	}
+
+	if c.Storage.Replication.QueueSize < 0 || c.Storage.Replication.Workers < 0 ||
+		c.Storage.Replication.FailoverCooldown < 0 || c.Storage.Replication.ReconcileInterval < 0 {
+		return errors.New("invalid storage replication settings, must not be negative")
+	}
+
 	return nil
 }
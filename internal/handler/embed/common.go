@@ -0,0 +1,92 @@
+package embed
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/model/embed"
+	"lemon/internal/pkg/ctxutil"
+	httputil "lemon/internal/pkg/http"
+	"lemon/internal/service"
+)
+
+// ErrorResponse 错误响应类型别名（使用共用的 http.ErrorResponse）
+type ErrorResponse = httputil.ErrorResponse
+
+// EmbedTokenInfo 嵌入令牌信息 DTO（不包含令牌明文和摘要）
+type EmbedTokenInfo struct {
+	ID                 string   `json:"id"`                     // 令牌ID
+	Name               string   `json:"name"`                   // 令牌名称
+	TokenPrefix        string   `json:"token_prefix"`           // 令牌前缀（用于识别）
+	NovelIDs           []string `json:"novel_ids"`              // 可访问的小说ID
+	AllowedOrigins     []string `json:"allowed_origins"`        // 允许嵌入的来源（为空时使用全局配置）
+	RateLimitPerMinute int      `json:"rate_limit_per_minute"`  // 每分钟请求上限（0 表示使用全局配置）
+	CreatedBy          string   `json:"created_by,omitempty"`   // 创建人
+	Active             bool     `json:"active"`                 // 是否可用（未吊销且未过期）
+	LastUsedAt         string   `json:"last_used_at,omitempty"` // 最近使用时间
+	ExpiresAt          string   `json:"expires_at,omitempty"`   // 过期时间
+	RevokedAt          string   `json:"revoked_at,omitempty"`   // 吊销时间
+	CreatedAt          string   `json:"created_at"`             // 创建时间
+}
+
+// toEmbedTokenInfo 将EmbedToken实体转换为EmbedTokenInfo
+func toEmbedTokenInfo(t *embed.EmbedToken) EmbedTokenInfo {
+	info := EmbedTokenInfo{
+		ID:                 t.ID,
+		Name:               t.Name,
+		TokenPrefix:        t.TokenPrefix,
+		NovelIDs:           t.NovelIDs,
+		AllowedOrigins:     t.AllowedOrigins,
+		RateLimitPerMinute: t.RateLimitPerMinute,
+		CreatedBy:          t.CreatedBy,
+		Active:             t.IsActive(time.Now()),
+		CreatedAt:          t.CreatedAt.Format(time.RFC3339),
+	}
+	if t.LastUsedAt != nil {
+		info.LastUsedAt = t.LastUsedAt.Format(time.RFC3339)
+	}
+	if t.ExpiresAt != nil {
+		info.ExpiresAt = t.ExpiresAt.Format(time.RFC3339)
+	}
+	if t.RevokedAt != nil {
+		info.RevokedAt = t.RevokedAt.Format(time.RFC3339)
+	}
+	return info
+}
+
+// embedToken 获取认证中间件注入的嵌入令牌，缺失时直接返回 401
+func embedToken(c *gin.Context) (*embed.EmbedToken, bool) {
+	token, ok := ctxutil.GetEmbedToken(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Code:    40103,
+			Message: service.ErrEmbedTokenInvalid.Error(),
+		})
+		return nil, false
+	}
+	return token, true
+}
+
+// writeEmbedError 公开只读接口的错误响应
+func writeEmbedError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+
+	// 根据错误类型设置错误码
+	switch {
+	case errors.Is(err, service.ErrEmbedChapterNotFound):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, service.ErrEmbedMediaNotReady):
+		code = http.StatusNotFound
+		errorCode = 40402
+	}
+
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
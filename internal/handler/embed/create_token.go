@@ -0,0 +1,95 @@
+package embed
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/pkg/ctxutil"
+	"lemon/internal/service"
+	novelservice "lemon/internal/service/novel"
+)
+
+// CreateTokenRequest 创建嵌入令牌请求
+type CreateTokenRequest struct {
+	Name               string   `json:"name" binding:"required"`      // 令牌名称（合作方/用途说明）
+	NovelIDs           []string `json:"novel_ids" binding:"required"` // 可访问的小说ID（至少一个）
+	AllowedOrigins     []string `json:"allowed_origins"`              // 允许嵌入的来源，如 https://partner.example.com（为空时使用全局配置）
+	RateLimitPerMinute int      `json:"rate_limit_per_minute"`        // 每分钟请求上限（0 表示使用全局配置）
+	ExpiresIn          int      `json:"expires_in"`                   // 有效期（秒，0 表示不过期）
+}
+
+// CreateToken 创建嵌入令牌
+// @Summary      创建嵌入令牌
+// @Description  为合作方创建嵌入播放令牌，令牌只能以只读方式访问指定小说下的章节（元数据、视频播放地址、字幕、缩略图）。需要登录，且对每本小说都有编辑权限。令牌明文只在本次响应中返回，请妥善保存
+// @Tags         嵌入播放
+// @Accept       json
+// @Produce      json
+// @Param        request  body      CreateTokenRequest  true  "创建嵌入令牌请求"
+// @Success      200      {object}  map[string]interface{}  "成功响应"
+// @Failure      400      {object}  ErrorResponse  "请求参数错误"
+// @Failure      401      {object}  ErrorResponse  "未登录"
+// @Failure      403      {object}  ErrorResponse  "无权访问小说"
+// @Failure      404      {object}  ErrorResponse  "小说不存在"
+// @Failure      500      {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/embed-tokens [post]
+func (h *Handler) CreateToken(c *gin.Context) {
+	var req CreateTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID, _ := ctxutil.GetUserID(ctx)
+	role, _ := ctxutil.GetUserRole(ctx)
+
+	result, err := h.embedService.CreateToken(ctx, &service.CreateEmbedTokenRequest{
+		Name:               req.Name,
+		NovelIDs:           req.NovelIDs,
+		AllowedOrigins:     req.AllowedOrigins,
+		RateLimitPerMinute: req.RateLimitPerMinute,
+		ExpiresIn:          time.Duration(req.ExpiresIn) * time.Second,
+		CreatedBy:          userID,
+		CreatorRole:        role,
+	})
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+
+		// 根据错误类型设置错误码
+		switch {
+		case errors.Is(err, service.ErrInvalidEmbedTokenRequest):
+			code = http.StatusBadRequest
+			errorCode = 40002
+		case errors.Is(err, novelservice.ErrNovelAccessDenied):
+			code = http.StatusForbidden
+			errorCode = 40303
+		case errors.Is(err, mongo.ErrNoDocuments):
+			code = http.StatusNotFound
+			errorCode = 40401
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "嵌入令牌已创建",
+		"data": gin.H{
+			"token":       result.Token,
+			"embed_token": toEmbedTokenInfo(result.EmbedToken),
+		},
+	})
+}
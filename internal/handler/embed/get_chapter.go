@@ -0,0 +1,41 @@
+package embed
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetChapter 获取章节元数据
+// @Summary      获取章节元数据
+// @Description  获取章节的公开元数据（标题、序号、字数、视频时长等），章节必须属于令牌授权的小说
+// @Tags         嵌入播放
+// @Accept       json
+// @Produce      json
+// @Param        X-Embed-Token  header    string  true  "嵌入令牌（也可以通过 token 查询参数传递）"
+// @Param        chapter_id     path      string  true  "章节ID"
+// @Success      200            {object}  map[string]interface{}  "成功响应"
+// @Failure      401            {object}  ErrorResponse  "令牌无效或已失效"
+// @Failure      403            {object}  ErrorResponse  "来源未被授权嵌入"
+// @Failure      404            {object}  ErrorResponse  "章节不存在"
+// @Failure      429            {object}  ErrorResponse  "请求过于频繁"
+// @Failure      500            {object}  ErrorResponse  "服务器内部错误"
+// @Router       /embed/v1/chapters/{chapter_id} [get]
+func (h *Handler) GetChapter(c *gin.Context) {
+	token, ok := embedToken(c)
+	if !ok {
+		return
+	}
+
+	chapter, err := h.embedService.GetChapter(c.Request.Context(), token, c.Param("chapter_id"))
+	if err != nil {
+		writeEmbedError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    chapter,
+	})
+}
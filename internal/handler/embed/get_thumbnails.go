@@ -0,0 +1,46 @@
+package embed
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetThumbnails 获取章节缩略图
+// @Summary      获取章节缩略图
+// @Description  获取章节最新版本镜头图片的临时地址（按镜头顺序），可用作播放器封面和进度条预览，地址过期后需重新获取
+// @Tags         嵌入播放
+// @Accept       json
+// @Produce      json
+// @Param        X-Embed-Token  header    string  true  "嵌入令牌（也可以通过 token 查询参数传递）"
+// @Param        chapter_id     path      string  true  "章节ID"
+// @Success      200            {object}  map[string]interface{}  "成功响应"
+// @Failure      401            {object}  ErrorResponse  "令牌无效或已失效"
+// @Failure      403            {object}  ErrorResponse  "来源未被授权嵌入"
+// @Failure      404            {object}  ErrorResponse  "章节不存在或图片尚未生成"
+// @Failure      429            {object}  ErrorResponse  "请求过于频繁"
+// @Failure      500            {object}  ErrorResponse  "服务器内部错误"
+// @Router       /embed/v1/chapters/{chapter_id}/thumbnails [get]
+func (h *Handler) GetThumbnails(c *gin.Context) {
+	token, ok := embedToken(c)
+	if !ok {
+		return
+	}
+
+	chapterID := c.Param("chapter_id")
+	thumbnails, err := h.embedService.GetThumbnails(c.Request.Context(), token, chapterID)
+	if err != nil {
+		writeEmbedError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"chapter_id": chapterID,
+			"thumbnails": thumbnails,
+			"count":      len(thumbnails),
+		},
+	})
+}
@@ -0,0 +1,41 @@
+package embed
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetVideo 获取章节视频播放地址
+// @Summary      获取章节视频播放地址
+// @Description  获取章节最新版本最终视频的临时播放地址（同一版本优先授权版，其次预览版），地址过期后需重新获取
+// @Tags         嵌入播放
+// @Accept       json
+// @Produce      json
+// @Param        X-Embed-Token  header    string  true  "嵌入令牌（也可以通过 token 查询参数传递）"
+// @Param        chapter_id     path      string  true  "章节ID"
+// @Success      200            {object}  map[string]interface{}  "成功响应"
+// @Failure      401            {object}  ErrorResponse  "令牌无效或已失效"
+// @Failure      403            {object}  ErrorResponse  "来源未被授权嵌入"
+// @Failure      404            {object}  ErrorResponse  "章节不存在或视频尚未生成"
+// @Failure      429            {object}  ErrorResponse  "请求过于频繁"
+// @Failure      500            {object}  ErrorResponse  "服务器内部错误"
+// @Router       /embed/v1/chapters/{chapter_id}/video [get]
+func (h *Handler) GetVideo(c *gin.Context) {
+	token, ok := embedToken(c)
+	if !ok {
+		return
+	}
+
+	video, err := h.embedService.GetVideo(c.Request.Context(), token, c.Param("chapter_id"))
+	if err != nil {
+		writeEmbedError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    video,
+	})
+}
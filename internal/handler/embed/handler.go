@@ -0,0 +1,18 @@
+package embed
+
+import (
+	"lemon/internal/service"
+)
+
+// Handler 嵌入播放处理器
+// 包含嵌入令牌的管理接口，以及合作方通过令牌访问的公开只读接口
+type Handler struct {
+	embedService service.EmbedService
+}
+
+// NewHandler 创建嵌入播放处理器
+func NewHandler(embedService service.EmbedService) *Handler {
+	return &Handler{
+		embedService: embedService,
+	}
+}
@@ -0,0 +1,60 @@
+package embed
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/pkg/ctxutil"
+)
+
+// ListTokens 查询嵌入令牌
+// @Summary      查询嵌入令牌
+// @Description  查询嵌入播放令牌（按创建时间倒序），不返回令牌明文。管理员查询所有令牌，其他用户只查询自己创建的令牌
+// @Tags         嵌入播放
+// @Accept       json
+// @Produce      json
+// @Param        limit  query     int  false  "返回数量（默认50，最大200）"
+// @Success      200    {object}  map[string]interface{}  "成功响应"
+// @Failure      401    {object}  ErrorResponse  "未登录"
+// @Failure      500    {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/embed-tokens [get]
+func (h *Handler) ListTokens(c *gin.Context) {
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	ctx := c.Request.Context()
+	userID, _ := ctxutil.GetUserID(ctx)
+	role, _ := ctxutil.GetUserRole(ctx)
+
+	tokens, err := h.embedService.ListTokens(ctx, userID, role, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50001,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	infos := make([]EmbedTokenInfo, 0, len(tokens))
+	for _, t := range tokens {
+		infos = append(infos, toEmbedTokenInfo(t))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"embed_tokens": infos,
+			"count":        len(infos),
+		},
+	})
+}
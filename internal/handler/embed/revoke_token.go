@@ -0,0 +1,64 @@
+package embed
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/pkg/ctxutil"
+	"lemon/internal/service"
+)
+
+// RevokeToken 吊销嵌入令牌
+// @Summary      吊销嵌入令牌
+// @Description  吊销嵌入播放令牌，吊销后使用该令牌的公开接口请求立即返回 401。只有令牌创建人和管理员可以吊销
+// @Tags         嵌入播放
+// @Accept       json
+// @Produce      json
+// @Param        token_id  path      string  true  "令牌ID"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      401       {object}  ErrorResponse  "未登录"
+// @Failure      403       {object}  ErrorResponse  "无权吊销该令牌"
+// @Failure      404       {object}  ErrorResponse  "令牌不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/embed-tokens/{token_id} [delete]
+func (h *Handler) RevokeToken(c *gin.Context) {
+	tokenID := c.Param("token_id")
+	if tokenID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "token_id is required",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID, _ := ctxutil.GetUserID(ctx)
+	role, _ := ctxutil.GetUserRole(ctx)
+
+	if err := h.embedService.RevokeToken(ctx, tokenID, userID, role); err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		switch {
+		case errors.Is(err, service.ErrEmbedTokenNotFound):
+			code = http.StatusNotFound
+			errorCode = 40401
+		case errors.Is(err, service.ErrEmbedTokenAccessDenied):
+			code = http.StatusForbidden
+			errorCode = 40303
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "嵌入令牌已吊销",
+	})
+}
@@ -4,28 +4,55 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"lemon/internal/pkg/ffmpeg"
+	"lemon/internal/pkg/procreaper"
+	"lemon/internal/pkg/storage"
 )
 
 // HealthHandler 健康检查处理器
-type HealthHandler struct{}
+type HealthHandler struct {
+	ffmpegCaps  *ffmpeg.Capabilities // 启动时的 FFmpeg 能力检测结果（可选）
+	replication storage.Replicator   // 主备存储复制状态（未配置备用存储时为 nil）
+	procReaper  *procreaper.Reaper   // 孤儿进程与临时文件回收器（未启用时为 nil）
+}
 
 // NewHealthHandler 创建健康检查处理器
-func NewHealthHandler() *HealthHandler {
-	return &HealthHandler{}
+func NewHealthHandler(ffmpegCaps *ffmpeg.Capabilities, replication storage.Replicator, procReaper *procreaper.Reaper) *HealthHandler {
+	return &HealthHandler{ffmpegCaps: ffmpegCaps, replication: replication, procReaper: procReaper}
 }
 
 // Health 健康检查
 // @Summary      健康检查
-// @Description  检查服务健康状态
+// @Description  检查服务健康状态，并返回启动时检测到的 FFmpeg 版本、路径和能力（字幕烧录不可用时 status 为 degraded）；配置了备用存储时返回复制状态和复制延迟（主存储不可用时 status 为 degraded）；启用进程回收时返回回收统计（结束的孤儿进程数、清理的临时目录/文件数和释放的字节数）
 // @Tags         健康检查
 // @Accept       json
 // @Produce      json
-// @Success      200  {object}  map[string]string
+// @Success      200  {object}  map[string]interface{}
 // @Router       /health [get]
 func (h *HealthHandler) Health(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status": "ok",
-	})
+	status := "ok"
+	resp := gin.H{}
+	if h.ffmpegCaps != nil {
+		if !h.ffmpegCaps.SubtitleRendering {
+			status = "degraded"
+		}
+		resp["capabilities"] = gin.H{
+			"ffmpeg": h.ffmpegCaps,
+		}
+	}
+	if h.replication != nil {
+		replication := h.replication.ReplicationStatus()
+		if !replication.PrimaryAvailable {
+			status = "degraded"
+		}
+		resp["storage_replication"] = replication
+	}
+	if h.procReaper != nil {
+		resp["proc_reaper"] = h.procReaper.Stats()
+	}
+	resp["status"] = status
+	c.JSON(http.StatusOK, resp)
 }
 
 // Ready 就绪检查
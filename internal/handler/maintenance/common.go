@@ -0,0 +1,42 @@
+package maintenance
+
+import (
+	"time"
+
+	"lemon/internal/model/maintenance"
+	httputil "lemon/internal/pkg/http"
+)
+
+// ErrorResponse 错误响应类型别名（使用共用的 http.ErrorResponse）
+type ErrorResponse = httputil.ErrorResponse
+
+// DowntimeWindowInfo 停机窗口信息 DTO
+type DowntimeWindowInfo struct {
+	ID              string `json:"id"`                   // 停机窗口ID
+	Scope           string `json:"scope"`                // 开关范围：global 或 provider
+	Reason          string `json:"reason,omitempty"`     // 维护原因
+	InFlightPolicy  string `json:"in_flight_policy"`     // 进行中任务的处理策略
+	StartedBy       string `json:"started_by,omitempty"` // 打开开关的操作人
+	EndedBy         string `json:"ended_by,omitempty"`   // 关闭开关的操作人
+	StartedAt       string `json:"started_at"`           // 开始时间
+	EndedAt         string `json:"ended_at,omitempty"`   // 结束时间（为空表示仍在维护中）
+	DurationSeconds int64  `json:"duration_seconds"`     // 停机时长（秒，未结束时计算到当前时间）
+}
+
+// toDowntimeWindowInfo 将DowntimeWindow实体转换为DowntimeWindowInfo
+func toDowntimeWindowInfo(w *maintenance.DowntimeWindow) DowntimeWindowInfo {
+	info := DowntimeWindowInfo{
+		ID:              w.ID,
+		Scope:           w.Scope,
+		Reason:          w.Reason,
+		InFlightPolicy:  w.InFlightPolicy,
+		StartedBy:       w.StartedBy,
+		EndedBy:         w.EndedBy,
+		StartedAt:       w.StartedAt.Format(time.RFC3339),
+		DurationSeconds: int64(w.Duration().Seconds()),
+	}
+	if w.EndedAt != nil {
+		info.EndedAt = w.EndedAt.Format(time.RFC3339)
+	}
+	return info
+}
@@ -0,0 +1,63 @@
+package maintenance
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/pkg/ctxutil"
+	"lemon/internal/service"
+)
+
+// DisableRequest 关闭维护模式请求
+type DisableRequest struct {
+	Scope string `json:"scope" binding:"required"` // 开关范围：global, llm, tts, image, video
+}
+
+// Disable 关闭维护模式
+// @Summary      关闭维护模式
+// @Description  关闭全局或指定 provider 的熔断开关，恢复接收新的生成任务，并结束对应的停机窗口。仅管理员
+// @Tags         维护模式
+// @Accept       json
+// @Produce      json
+// @Param        request  body      DisableRequest  true  "关闭维护模式请求"
+// @Success      200      {object}  map[string]interface{}  "成功响应"
+// @Failure      400      {object}  ErrorResponse  "请求参数错误"
+// @Failure      401      {object}  ErrorResponse  "未登录"
+// @Failure      403      {object}  ErrorResponse  "不是管理员"
+// @Failure      500      {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/maintenance/disable [post]
+func (h *Handler) Disable(c *gin.Context) {
+	var req DisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	operator, _ := ctxutil.GetUserID(c.Request.Context())
+	if err := h.maintenanceService.Disable(c.Request.Context(), req.Scope, operator); err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, service.ErrInvalidMaintenanceScope) {
+			code = http.StatusBadRequest
+			errorCode = 40002
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "维护模式已关闭",
+		"data":    h.maintenanceService.Status(),
+	})
+}
@@ -0,0 +1,78 @@
+package maintenance
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/pkg/ctxutil"
+	"lemon/internal/pkg/killswitch"
+	"lemon/internal/service"
+)
+
+// EnableRequest 打开维护模式请求
+type EnableRequest struct {
+	Scope          string `json:"scope" binding:"required"` // 开关范围：global, llm, tts, image, video
+	Reason         string `json:"reason"`                   // 维护原因（返回给调用方）
+	InFlightPolicy string `json:"in_flight_policy"`         // 进行中任务的处理策略：finish（继续执行）, abort（立即取消），为空时使用配置的默认策略
+}
+
+// Enable 打开维护模式
+// @Summary      打开维护模式
+// @Description  打开全局或指定 provider 的熔断开关，新的生成任务将返回 503 维护中错误；进行中的任务按策略继续执行或立即取消，并记录停机窗口。重复打开不会覆盖原状态。仅管理员
+// @Tags         维护模式
+// @Accept       json
+// @Produce      json
+// @Param        request  body      EnableRequest  true  "打开维护模式请求"
+// @Success      200      {object}  map[string]interface{}  "成功响应"
+// @Failure      400      {object}  ErrorResponse  "请求参数错误"
+// @Failure      401      {object}  ErrorResponse  "未登录"
+// @Failure      403      {object}  ErrorResponse  "不是管理员"
+// @Failure      500      {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/maintenance/enable [post]
+func (h *Handler) Enable(c *gin.Context) {
+	var req EnableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	operator, _ := ctxutil.GetUserID(c.Request.Context())
+	state, err := h.maintenanceService.Enable(c.Request.Context(), &service.EnableMaintenanceRequest{
+		Scope:    req.Scope,
+		Reason:   req.Reason,
+		Policy:   killswitch.InFlightPolicy(req.InFlightPolicy),
+		Operator: operator,
+	})
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+
+		// 根据错误类型设置错误码
+		switch {
+		case errors.Is(err, service.ErrInvalidMaintenanceScope):
+			code = http.StatusBadRequest
+			errorCode = 40002
+		case errors.Is(err, service.ErrInvalidMaintenancePolicy):
+			code = http.StatusBadRequest
+			errorCode = 40003
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "维护模式已打开",
+		"data":    state,
+	})
+}
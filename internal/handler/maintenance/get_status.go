@@ -0,0 +1,23 @@
+package maintenance
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetStatus 获取维护模式状态
+// @Summary      获取维护模式状态
+// @Description  获取当前打开的熔断开关（全局/按 provider）及进行中的生成任务数
+// @Tags         维护模式
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "成功响应"
+// @Router       /api/v1/maintenance [get]
+func (h *Handler) GetStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    h.maintenanceService.Status(),
+	})
+}
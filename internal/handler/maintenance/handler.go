@@ -0,0 +1,18 @@
+package maintenance
+
+import (
+	"lemon/internal/service"
+)
+
+// Handler 维护模式处理器
+// 所有维护模式相关的Handler方法都通过这个结构体访问Service
+type Handler struct {
+	maintenanceService service.MaintenanceService
+}
+
+// NewHandler 创建维护模式处理器
+func NewHandler(maintenanceService service.MaintenanceService) *Handler {
+	return &Handler{
+		maintenanceService: maintenanceService,
+	}
+}
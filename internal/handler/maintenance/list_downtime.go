@@ -0,0 +1,65 @@
+package maintenance
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/service"
+)
+
+// ListDowntimeWindows 查询停机窗口
+// @Summary      查询停机窗口
+// @Description  查询维护模式的停机窗口记录（按开始时间倒序），用于统计供应商故障造成的停机时长
+// @Tags         维护模式
+// @Accept       json
+// @Produce      json
+// @Param        scope  query     string  false  "开关范围：global, llm, tts, image, video（为空查询全部）"
+// @Param        limit  query     int     false  "返回数量（默认50，最大200）"
+// @Success      200    {object}  map[string]interface{}  "成功响应"
+// @Failure      400    {object}  ErrorResponse  "请求参数错误"
+// @Failure      500    {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/maintenance/downtime-windows [get]
+func (h *Handler) ListDowntimeWindows(c *gin.Context) {
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	windows, err := h.maintenanceService.ListDowntimeWindows(c.Request.Context(), c.Query("scope"), limit)
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, service.ErrInvalidMaintenanceScope) {
+			code = http.StatusBadRequest
+			errorCode = 40002
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	infos := make([]DowntimeWindowInfo, 0, len(windows))
+	for _, w := range windows {
+		infos = append(infos, toDowntimeWindowInfo(w))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"windows": infos,
+			"count":   len(infos),
+		},
+	})
+}
@@ -0,0 +1,158 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	novelservice "lemon/internal/service/novel"
+)
+
+// ExportAnalyticsRequest 分析数据导出请求
+type ExportAnalyticsRequest struct {
+	Datasets   []string `json:"datasets"`                                       // 导出的数据集：tasks, usage, costs, qa_scores（为空时导出全部）
+	BatchSize  int      `json:"batch_size" binding:"omitempty,min=1,max=50000"` // 每个批次最多的源记录数（默认 5000）
+	MaxBatches int      `json:"max_batches" binding:"omitempty,min=1,max=100"`  // 每个数据集本次最多导出的批次数（默认 20）
+}
+
+// ExportAnalytics 增量导出分析数据
+// @Summary      导出分析数据
+// @Description  从各数据集最新批次的游标开始增量导出：tasks（队列任务，按更新时间导出，状态变化后会再次导出）、usage（章节渲染阶段执行记录：耗时、provider 调用次数、传输字节数）、costs（费用记录）、qa_scores（章节生成最终视频后的 QA 评分）。
+// @Description  每个批次上传一个 JSON Lines 文件（BigQuery 和 ClickHouse JSONEachRow 可直接加载），每行带 schema_version。同一数据集同时有其他导出提交了相同序号的批次时返回 409
+// @Tags         分析数据
+// @Accept       json
+// @Produce      json
+// @Param        request  body      ExportAnalyticsRequest  false  "导出参数"
+// @Success      200      {object}  map[string]interface{}  "成功响应"
+// @Failure      400      {object}  ErrorResponse  "请求参数错误"
+// @Failure      409      {object}  ErrorResponse  "其他导出同时提交了批次"
+// @Failure      500      {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/analytics/exports [post]
+func (h *Handler) ExportAnalytics(c *gin.Context) {
+	var req ExportAnalyticsRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40002,
+				Message: "Invalid request body",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	datasets := make([]novel.AnalyticsDataset, 0, len(req.Datasets))
+	for _, d := range req.Datasets {
+		datasets = append(datasets, novel.AnalyticsDataset(d))
+	}
+	ctx := c.Request.Context()
+	userID, _ := ctxutil.GetUserID(ctx)
+
+	result, err := h.novelService.ExportAnalytics(ctx, &novelservice.ExportAnalyticsRequest{
+		Datasets:   datasets,
+		UserID:     userID,
+		BatchSize:  req.BatchSize,
+		MaxBatches: req.MaxBatches,
+	})
+	if err != nil {
+		respondAnalyticsExportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    result,
+	})
+}
+
+// ListAnalyticsExportBatches 查询分析数据导出批次
+// @Summary      查询导出批次
+// @Description  查询数据集序号大于 after_seq 的导出批次（按序号升序）。数仓记录已加载的最大序号，按序号顺序下载 resource_id 对应的文件并加载；rows 为 0 的批次没有文件，只推进游标
+// @Tags         分析数据
+// @Accept       json
+// @Produce      json
+// @Param        dataset    query     string  true   "数据集：tasks, usage, costs, qa_scores"
+// @Param        after_seq  query     int     false  "已加载的最大批次序号（默认 0）"
+// @Param        limit      query     int     false  "返回数量（默认50，最大200）"
+// @Success      200        {object}  map[string]interface{}  "成功响应"
+// @Failure      400        {object}  ErrorResponse  "请求参数错误"
+// @Failure      500        {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/analytics/exports [get]
+func (h *Handler) ListAnalyticsExportBatches(c *gin.Context) {
+	dataset := c.Query("dataset")
+	if dataset == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "dataset is required",
+		})
+		return
+	}
+	var afterSeq int64
+	if raw := c.Query("after_seq"); raw != "" {
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || v < 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40002,
+				Message: "Invalid after_seq",
+			})
+			return
+		}
+		afterSeq = v
+	}
+
+	batches, err := h.novelService.ListAnalyticsExportBatches(c.Request.Context(), novel.AnalyticsDataset(dataset), afterSeq, budgetListLimit(c))
+	if err != nil {
+		respondAnalyticsExportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"dataset": dataset,
+			"batches": batches,
+			"count":   len(batches),
+		},
+	})
+}
+
+// GetAnalyticsSchemas 查询分析数据的表结构
+// @Summary      查询分析数据表结构
+// @Description  返回各数据集当前的行结构版本和表结构（字段格式与 BigQuery JSON 表结构一致，ClickHouse 可按类型映射建表）。行结构变化时 schema_version 递增
+// @Tags         分析数据
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "成功响应"
+// @Router       /api/v1/analytics/schemas [get]
+func (h *Handler) GetAnalyticsSchemas(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    h.novelService.GetAnalyticsSchemas(),
+	})
+}
+
+func respondAnalyticsExportError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+
+	switch {
+	case errors.Is(err, novelservice.ErrInvalidAnalyticsExport):
+		code = http.StatusBadRequest
+		errorCode = 40005
+	case errors.Is(err, novelservice.ErrAnalyticsExportConflict):
+		code = http.StatusConflict
+		errorCode = 40901
+	}
+
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
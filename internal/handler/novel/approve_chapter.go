@@ -0,0 +1,79 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/auth"
+	"lemon/internal/pkg/ctxutil"
+)
+
+// ApproveChapter 审核通过章节
+// @Summary      审核通过章节
+// @Description  将章节标记为审核通过，审核人为当前登录用户（需要审核人员或管理员角色）。只有审核通过的章节才允许导出无水印的授权版最终视频。
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true  "章节ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"  "{\"code\": 0, \"message\": \"审核成功\", \"data\": {\"chapter_id\": \"...\"}}"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      401         {object}  ErrorResponse  "未登录"
+// @Failure      403         {object}  ErrorResponse  "不是审核人员或管理员"
+// @Failure      404         {object}  ErrorResponse  "章节不存在"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/approve [post]
+func (h *Handler) ApproveChapter(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	// 审核人取自登录信息，只有审核人员和管理员可以审核
+	ctx := c.Request.Context()
+	userID, ok := ctxutil.GetUserID(ctx)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Code:    40101,
+			Message: "未授权",
+		})
+		return
+	}
+	if role, _ := ctxutil.GetUserRole(ctx); role != auth.RoleReviewer && role != auth.RoleAdmin {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Code:    40303,
+			Message: "只有审核人员或管理员可以审核章节",
+		})
+		return
+	}
+
+	// 调用Service层
+	if err := h.novelService.ApproveChapter(ctx, chapterID, userID); err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			code = http.StatusNotFound
+			errorCode = 40401
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "审核成功",
+		"data": gin.H{
+			"chapter_id": chapterID,
+		},
+	})
+}
@@ -0,0 +1,91 @@
+package novel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/auth"
+	"lemon/internal/pkg/ctxutil"
+	"lemon/internal/service/novel"
+)
+
+// approveStub 只实现 ApproveChapter 的小说服务，记录审核人
+type approveStub struct {
+	novel.NovelService
+	chapterID  string
+	approvedBy string
+	err        error
+}
+
+func (s *approveStub) ApproveChapter(ctx context.Context, chapterID, approvedBy string) error {
+	s.chapterID = chapterID
+	s.approvedBy = approvedBy
+	return s.err
+}
+
+// serveApprove 以指定的登录用户请求审核接口（userID 为空时模拟未登录）
+func serveApprove(svc novel.NovelService, userID string, role auth.UserRole, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if userID != "" {
+			ctx := ctxutil.WithUserID(c.Request.Context(), userID)
+			c.Request = c.Request.WithContext(ctxutil.WithUserRole(ctx, role))
+		}
+		c.Next()
+	})
+	r.POST("/novels/chapters/:chapter_id/approve", NewHandler(svc).ApproveChapter)
+
+	req := httptest.NewRequest(http.MethodPost, "/novels/chapters/ch-1/approve", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestApproveChapter(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     string
+		role       auth.UserRole
+		body       string
+		wantStatus int
+		wantBy     string
+	}{
+		{"未登录", "", "", `{}`, http.StatusUnauthorized, ""},
+		{"编辑人员不能审核", "editor-1", auth.RoleEditor, `{}`, http.StatusForbidden, ""},
+		{"审核人员", "reviewer-1", auth.RoleReviewer, `{}`, http.StatusOK, "reviewer-1"},
+		{"管理员", "admin-1", auth.RoleAdmin, ``, http.StatusOK, "admin-1"},
+		{"忽略请求体中的 user_id", "reviewer-1", auth.RoleReviewer, `{"user_id":"someone-else"}`, http.StatusOK, "reviewer-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &approveStub{}
+			w := serveApprove(svc, tt.userID, tt.role, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if svc.approvedBy != tt.wantBy {
+				t.Errorf("approvedBy = %q, want %q", svc.approvedBy, tt.wantBy)
+			}
+			if tt.wantBy != "" && svc.chapterID != "ch-1" {
+				t.Errorf("chapterID = %q, want ch-1", svc.chapterID)
+			}
+		})
+	}
+}
+
+func TestApproveChapterNotFound(t *testing.T) {
+	svc := &approveStub{err: fmt.Errorf("approve chapter: %w", mongo.ErrNoDocuments)}
+	w := serveApprove(svc, "reviewer-1", auth.RoleReviewer, `{}`)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,85 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/pkg/noveltools"
+)
+
+// ListChapterArtifactsRequest 章节产物列表查询参数
+type ListChapterArtifactsRequest struct {
+	Type    string `form:"type"`    // 产物类型，逗号分隔：audio, subtitle, image, video（为空时不限）
+	Status  string `form:"status"`  // 产物状态，逗号分隔：pending, processing, completed, failed（为空时不限）
+	Version string `form:"version"` // 版本号或 latest（为空时返回所有版本）
+}
+
+// ListChapterArtifacts 获取章节生成的所有产物
+// @Summary      获取章节生成的所有产物
+// @Description  一次列出章节生成的音频、字幕、图片和视频（所有版本），可按类型、状态、版本筛选；version=latest 只返回每种产物的最新版本（各类型的版本号相互独立）。
+// @Description  每个产物使用统一的结构（type、version、status、resource_id、是否为最新版本和发布版本），links 中为下载地址、依赖关系（重新生成影响分析；有流水线清单的视频另有 manifest）、质量评分卡、内容风险审核报告和发布记录的接口地址
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true   "章节ID"
+// @Param        type        query     string  false  "产物类型，逗号分隔（audio, subtitle, image, video）"
+// @Param        status      query     string  false  "产物状态，逗号分隔（pending, processing, completed, failed）"
+// @Param        version     query     string  false  "版本号或 latest"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  ErrorResponse  "章节不存在"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/artifacts [get]
+func (h *Handler) ListChapterArtifacts(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	var req ListChapterArtifactsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid query parameters",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	filter, err := noveltools.ParseArtifactFilter(req.Type, req.Status, req.Version)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40003,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	artifacts, err := h.novelService.ListChapterArtifacts(c.Request.Context(), chapterID, filter)
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			code = http.StatusNotFound
+			errorCode = 40401
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    artifacts,
+	})
+}
@@ -0,0 +1,148 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/noveltools"
+)
+
+// SetAttributionRequest 设置小说署名请求
+type SetAttributionRequest struct {
+	Required  bool                       `json:"required"`   // 是否必须署名（授权协议要求）：开启后署名无法渲染时最终视频生成失败
+	Text      string                     `json:"text"`       // 署名文字（单行，最多 120 个字符），为空时按原作信息拼接
+	WorkTitle string                     `json:"work_title"` // 原作名称
+	Author    string                     `json:"author"`     // 原作作者
+	Licensor  string                     `json:"licensor"`   // 授权方
+	License   string                     `json:"license"`    // 授权说明（如授权编号、协议名称）
+	Placement novel.AttributionPlacement `json:"placement"`  // 展示方式：overlay（开头叠加）、end_frame（结尾署名画面，默认）
+	Duration  float64                    `json:"duration"`   // 展示时长（1~10 秒，默认 3 秒）
+}
+
+// SetAttribution 设置小说署名
+// @Summary      设置小说署名
+// @Description  整体替换小说的原作署名设置。之后生成的每个最终视频和回顾合集都会渲染署名（开头叠加或结尾署名画面），实际渲染的署名文字记录在视频记录和流水线清单的 attribution 中，用于合规审计。required 为 true 时署名无法渲染则生成失败
+// @Tags         小说管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string                 true  "小说ID"
+// @Param        request   body      SetAttributionRequest  true  "署名设置"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误或署名设置不合法"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/attribution [put]
+func (h *Handler) SetAttribution(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	var req SetAttributionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	attribution, err := h.novelService.SetAttribution(c.Request.Context(), novelID, &novel.Attribution{
+		Required:  req.Required,
+		Text:      req.Text,
+		WorkTitle: req.WorkTitle,
+		Author:    req.Author,
+		Licensor:  req.Licensor,
+		License:   req.License,
+		Placement: req.Placement,
+		Duration:  req.Duration,
+	})
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+
+		// 根据错误类型设置错误码
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			code = http.StatusNotFound
+			errorCode = 40401
+		case errors.Is(err, noveltools.ErrInvalidAttribution):
+			code = http.StatusBadRequest
+			errorCode = 40003
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "署名设置已更新",
+		"data": gin.H{
+			"novel_id":      novelID,
+			"attribution":   attribution,
+			"rendered_text": noveltools.AttributionText(attribution),
+		},
+	})
+}
+
+// GetAttribution 查询小说署名
+// @Summary      查询小说署名
+// @Description  查询小说的原作署名设置，以及最终视频中将要渲染的署名文字（未设置时 attribution 为 null）
+// @Tags         小说管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string  true  "小说ID"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/attribution [get]
+func (h *Handler) GetAttribution(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	attribution, err := h.novelService.GetAttribution(c.Request.Context(), novelID)
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			code = http.StatusNotFound
+			errorCode = 40401
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"novel_id":      novelID,
+			"attribution":   attribution,
+			"rendered_text": noveltools.AttributionText(attribution),
+		},
+	})
+}
@@ -0,0 +1,342 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	novelservice "lemon/internal/service/novel"
+)
+
+// BGMTrackInfo 背景音乐曲目 DTO
+type BGMTrackInfo struct {
+	ID         string   `json:"id"`                 // 曲目ID
+	Name       string   `json:"name"`               // 曲目名称
+	Mood       string   `json:"mood"`               // 适用的场景情绪
+	Tags       []string `json:"tags,omitempty"`     // 自定义标签
+	ResourceID string   `json:"resource_id"`        // 音频文件的 resource_id
+	Duration   float64  `json:"duration,omitempty"` // 时长（秒）
+	Volume     float64  `json:"volume"`             // 混音音量
+	UserID     string   `json:"user_id"`            // 上传用户ID
+	CreatedAt  string   `json:"created_at"`         // 创建时间
+}
+
+func toBGMTrackInfo(t *novel.BGMTrack) BGMTrackInfo {
+	return BGMTrackInfo{
+		ID:         t.ID,
+		Name:       t.Name,
+		Mood:       string(t.Mood),
+		Tags:       t.Tags,
+		ResourceID: t.ResourceID,
+		Duration:   t.Duration,
+		Volume:     t.Volume,
+		UserID:     t.UserID,
+		CreatedAt:  t.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// UploadBGMTrack 上传背景音乐曲目
+// @Summary      上传背景音乐曲目
+// @Description  上传背景音乐并标注适用的场景情绪（calm、tense、romantic、battle、sad、joyful、mystery）。合成最终视频时按场景情绪选择曲目，相邻场景切换曲目时交叉淡化；某情绪没有曲目时使用 calm 的曲目
+// @Tags         视频生成
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file     formData  file    true   "曲目（音频文件）"
+// @Param        user_id  formData  string  true   "用户ID"
+// @Param        mood     formData  string  true   "场景情绪"
+// @Param        name     formData  string  false  "曲目名称（为空时使用文件名）"
+// @Param        tags     formData  string  false  "自定义标签（逗号分隔，如 古风,笛子）"
+// @Param        volume   formData  number  false  "混音音量（0-1，默认 0.25）"
+// @Success      201      {object}  map[string]interface{}  "成功响应"
+// @Failure      400      {object}  ErrorResponse  "请求参数错误"
+// @Failure      500      {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/bgm-tracks [post]
+func (h *Handler) UploadBGMTrack(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid file",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	userID := c.PostForm("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40003,
+			Message: "user_id is required",
+		})
+		return
+	}
+
+	var volume float64
+	if v := c.PostForm("volume"); v != "" {
+		volume, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40005,
+				Message: "Invalid volume",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	fileReader, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40004,
+			Message: "Failed to open file",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	defer fileReader.Close()
+
+	// 调用Service层
+	track, err := h.novelService.CreateBGMTrack(c.Request.Context(), &novelservice.CreateBGMTrackRequest{
+		Name:        c.PostForm("name"),
+		Mood:        novel.SceneMood(c.PostForm("mood")),
+		Tags:        strings.Split(c.PostForm("tags"), ","),
+		Volume:      volume,
+		UserID:      userID,
+		FileName:    file.Filename,
+		ContentType: file.Header.Get("Content-Type"),
+		Data:        fileReader,
+	})
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, novelservice.ErrInvalidBGMTrack) {
+			code = http.StatusBadRequest
+			errorCode = 40005
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    0,
+		"message": "背景音乐曲目上传成功",
+		"data":    toBGMTrackInfo(track),
+	})
+}
+
+// ListBGMTracks 获取背景音乐曲库
+// @Summary      获取背景音乐曲库
+// @Description  获取曲库中的曲目（按情绪、上传时间排序），传 mood 时只返回该情绪的曲目，传 tag 时只返回带该标签的曲目
+// @Tags         视频生成
+// @Accept       json
+// @Produce      json
+// @Param        mood  query     string  false  "场景情绪"
+// @Param        tag   query     string  false  "自定义标签"
+// @Success      200   {object}  map[string]interface{}  "成功响应"
+// @Failure      400   {object}  ErrorResponse  "请求参数错误"
+// @Failure      500   {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/bgm-tracks [get]
+func (h *Handler) ListBGMTracks(c *gin.Context) {
+	tracks, err := h.novelService.ListBGMTracks(c.Request.Context(), novel.SceneMood(c.Query("mood")), c.Query("tag"))
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, novelservice.ErrInvalidBGMTrack) {
+			code = http.StatusBadRequest
+			errorCode = 40003
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	infos := make([]BGMTrackInfo, 0, len(tracks))
+	for _, t := range tracks {
+		infos = append(infos, toBGMTrackInfo(t))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"bgm_tracks": infos,
+			"count":      len(infos),
+		},
+	})
+}
+
+// DeleteBGMTrack 删除背景音乐曲目
+// @Summary      删除背景音乐曲目
+// @Description  从曲库中删除曲目，之后合成的视频不再使用该曲目（已合成的视频不受影响）
+// @Tags         视频生成
+// @Accept       json
+// @Produce      json
+// @Param        track_id  path      string  true  "曲目ID"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      404       {object}  ErrorResponse  "曲目不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/bgm-tracks/{track_id} [delete]
+func (h *Handler) DeleteBGMTrack(c *gin.Context) {
+	trackID := c.Param("track_id")
+	if trackID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "track_id is required",
+		})
+		return
+	}
+
+	if err := h.novelService.DeleteBGMTrack(c.Request.Context(), trackID); err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			code = http.StatusNotFound
+			errorCode = 40401
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "背景音乐曲目已删除",
+		"data": gin.H{
+			"track_id": trackID,
+		},
+	})
+}
+
+// SetChapterBGMRequest 设置章节背景音乐请求
+type SetChapterBGMRequest struct {
+	TrackID  string  `json:"track_id"`                               // 整章使用的曲目ID（disabled 为 false 时必需）
+	Volume   float64 `json:"volume" binding:"omitempty,min=0,max=1"` // 混音音量（0-1，为 0 时使用曲目的音量）
+	Disabled bool    `json:"disabled"`                               // 不加背景音乐
+	UserID   string  `json:"user_id"`                                // 操作用户ID
+}
+
+// SetChapterBGM 设置章节背景音乐
+// @Summary      设置章节背景音乐
+// @Description  为章节指定整章使用的背景音乐曲目（曲目比章节短时循环播放，解说出声时自动压低），或设置 disabled=true 不加背景音乐。未设置时合成最终视频按场景情绪从曲库自动选择；设置只影响之后合成的最终视频
+// @Tags         视频生成
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string                true  "章节ID"
+// @Param        request     body      SetChapterBGMRequest  true  "背景音乐选择"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  ErrorResponse  "章节或曲目不存在"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/bgm [put]
+func (h *Handler) SetChapterBGM(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	var req SetChapterBGMRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	bgm, err := h.novelService.SetChapterBGM(c.Request.Context(), chapterID, &novelservice.SetChapterBGMRequest{
+		TrackID:  req.TrackID,
+		Volume:   req.Volume,
+		Disabled: req.Disabled,
+		UserID:   req.UserID,
+	})
+	if err != nil {
+		respondChapterBGMError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "章节背景音乐已设置",
+		"data": gin.H{
+			"chapter_id": chapterID,
+			"bgm":        bgm,
+		},
+	})
+}
+
+// ClearChapterBGM 清空章节背景音乐选择
+// @Summary      清空章节背景音乐选择
+// @Description  清空章节的背景音乐选择，之后合成最终视频恢复按场景情绪从曲库自动选择
+// @Tags         视频生成
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true  "章节ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  ErrorResponse  "章节不存在"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/bgm [delete]
+func (h *Handler) ClearChapterBGM(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	if err := h.novelService.ClearChapterBGM(c.Request.Context(), chapterID); err != nil {
+		respondChapterBGMError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "章节背景音乐选择已清空",
+		"data": gin.H{
+			"chapter_id": chapterID,
+		},
+	})
+}
+
+func respondChapterBGMError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, novelservice.ErrInvalidBGMTrack):
+		code = http.StatusBadRequest
+		errorCode = 40003
+	}
+
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,231 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	novelservice "lemon/internal/service/novel"
+)
+
+// SetNovelBudgetRequest 设置小说预算请求
+type SetNovelBudgetRequest struct {
+	CapFen     int64   `json:"cap_fen"`     // 费用上限（分），如 ¥500 传 50000；0 表示不限额
+	WarnRatio  float64 `json:"warn_ratio"`  // 预警比例（0~1，默认 0.8）
+	HardStop   bool    `json:"hard_stop"`   // 达到上限后是否停止新的生成调用
+	WebhookURL string  `json:"webhook_url"` // 预算事件通知地址（可选，POST 签名的 JSON，必须是公网地址）
+}
+
+// SetNovelBudget 设置小说预算
+// @Summary      设置小说预算
+// @Description  设置小说的费用上限。花费达到预警比例时触发 budget.warning 事件，达到上限时触发 budget.exceeded 事件（每个事件只通知一次，调整预算后重新计算）；开启 hard_stop 后达到上限的小说不能再发起新的生成调用（返回 402）
+// @Description  设置 webhook_url 后预算事件按任务通知的方式投递：请求头带 X-Lemon-Signature 签名，失败后按退避时间重试。通知地址变更时生成新的签名密钥，只在本次响应的 webhook_secret 中返回一次；地址解析到内网、回环或链路本地地址时返回 400
+// @Tags         预算
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string                 true  "小说ID"
+// @Param        request   body      SetNovelBudgetRequest  true  "预算设置"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/budget [put]
+func (h *Handler) SetNovelBudget(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	var req SetNovelBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	status, err := h.novelService.SetNovelBudget(c.Request.Context(), novelID, &novelservice.SetNovelBudgetRequest{
+		CapFen:     req.CapFen,
+		WarnRatio:  req.WarnRatio,
+		HardStop:   req.HardStop,
+		WebhookURL: req.WebhookURL,
+	})
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+
+		// 根据错误类型设置错误码
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			code = http.StatusNotFound
+			errorCode = 40401
+		case errors.Is(err, novelservice.ErrInvalidBudget):
+			code = http.StatusBadRequest
+			errorCode = 40003
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "小说预算已更新",
+		"data":    status,
+	})
+}
+
+// GetNovelBudget 查询小说预算
+// @Summary      查询小说预算
+// @Description  查询小说的预算设置、实时花费、预算状态（ok/warning/exceeded）和按 provider 汇总的花费，金额单位为分
+// @Tags         预算
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string  true  "小说ID"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/budget [get]
+func (h *Handler) GetNovelBudget(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	status, err := h.novelService.GetNovelBudget(c.Request.Context(), novelID)
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			code = http.StatusNotFound
+			errorCode = 40401
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    status,
+	})
+}
+
+// ListCostRecords 查询小说费用记录
+// @Summary      查询小说费用记录
+// @Description  查询小说每次调用付费 provider（llm/tts/image/video）的费用记录（按时间倒序），金额单位为分
+// @Tags         预算
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string  true   "小说ID"
+// @Param        limit     query     int     false  "返回数量（默认50，最大200）"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/budget/costs [get]
+func (h *Handler) ListCostRecords(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	records, err := h.novelService.ListCostRecords(c.Request.Context(), novelID, budgetListLimit(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50001,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"novel_id":     novelID,
+			"cost_records": records,
+			"count":        len(records),
+		},
+	})
+}
+
+// ListBudgetEvents 查询小说预算事件
+// @Summary      查询小说预算事件
+// @Description  查询小说的预算事件（budget.warning / budget.exceeded，按时间倒序）及 webhook 通知状态
+// @Tags         预算
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string  true   "小说ID"
+// @Param        limit     query     int     false  "返回数量（默认50，最大200）"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/budget/events [get]
+func (h *Handler) ListBudgetEvents(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	events, err := h.novelService.ListBudgetEvents(c.Request.Context(), novelID, budgetListLimit(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50001,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"novel_id":      novelID,
+			"budget_events": events,
+			"count":         len(events),
+		},
+	})
+}
+
+// budgetListLimit 解析 limit 查询参数（默认50，最大200）
+func budgetListLimit(c *gin.Context) int {
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	return limit
+}
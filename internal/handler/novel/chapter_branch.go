@@ -0,0 +1,272 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	novelservice "lemon/internal/service/novel"
+)
+
+// ForkChapterRequest 创建章节分支请求
+type ForkChapterRequest struct {
+	Name          string `json:"name" binding:"required"`    // 分支名称（同一章节内唯一，最多 64 个字符）
+	Text          string `json:"text"`                       // 分支的章节正文，为空时使用主章节的正文
+	WithArtifacts bool   `json:"with_artifacts"`             // 是否引用主章节最新的解说及其场景、镜头、图片、音频、字幕
+	UserID        string `json:"user_id" binding:"required"` // 操作人用户ID（必填）
+}
+
+// MergeChapterBranchRequest 合并章节分支请求
+type MergeChapterBranchRequest struct {
+	Promote  bool   `json:"promote"`                    // 合并后把分支最新的各产物版本发布为主章节的发布版本
+	IgnoreQA bool   `json:"ignore_qa"`                  // 发布时忽略 QA 评分卡的阻断
+	UserID   string `json:"user_id" binding:"required"` // 操作人用户ID（必填）
+}
+
+// DiscardChapterBranchRequest 放弃章节分支请求
+type DiscardChapterBranchRequest struct {
+	UserID string `json:"user_id" binding:"required"` // 操作人用户ID（必填）
+}
+
+// ForkChapter 创建章节分支
+// @Summary      创建章节分支
+// @Description  从主章节创建一个命名分支，用于尝试不同的叙事角度而不影响主章节。分支是序号相同的独立章节（返回的 id 即分支的章节ID），可以使用新的正文（text），并用该章节ID独立运行剧本、图片、音频、字幕、视频等流水线。with_artifacts=true 时引用主章节最新的解说及其场景、镜头、图片、音频、字幕（复制记录，资源文件共享），最终视频需要在分支中重新生成。分支不能再创建分支，同一章节下分支名称不能重复
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string              true  "主章节ID"
+// @Param        request     body      ForkChapterRequest  true  "分支请求"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  ErrorResponse  "章节不存在"
+// @Failure      409         {object}  ErrorResponse  "分支名称已存在"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/branches [post]
+func (h *Handler) ForkChapter(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	var req ForkChapterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	branch, err := h.novelService.ForkChapter(c.Request.Context(), chapterID, &novelservice.ForkChapterRequest{
+		Name:          req.Name,
+		Text:          req.Text,
+		WithArtifacts: req.WithArtifacts,
+		UserID:        req.UserID,
+	})
+	if err != nil {
+		respondChapterBranchError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "章节分支已创建",
+		"data":    branch,
+	})
+}
+
+// ListChapterBranches 查询章节分支
+// @Summary      查询章节分支
+// @Description  查询主章节的所有分支（按创建时间倒序），包括已合并和已放弃的分支（branch.status）
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true  "主章节ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  ErrorResponse  "章节不存在"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/branches [get]
+func (h *Handler) ListChapterBranches(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	branches, err := h.novelService.ListChapterBranches(c.Request.Context(), chapterID)
+	if err != nil {
+		respondChapterBranchError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    branches,
+	})
+}
+
+// CompareChapterBranch 比较章节分支与主章节
+// @Summary      比较章节分支与主章节
+// @Description  比较分支与主章节的正文（字数、是否修改）、各产物的最新版本、最新解说的场景数和镜头数，以及最新版本的 QA 评分卡（score_delta 为分支分数减去主章节分数）
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true  "分支的章节ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "章节不是分支"
+// @Failure      404         {object}  ErrorResponse  "分支不存在"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/branch/compare [get]
+func (h *Handler) CompareChapterBranch(c *gin.Context) {
+	branchID := c.Param("chapter_id")
+	if branchID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	comparison, err := h.novelService.CompareChapterBranch(c.Request.Context(), branchID)
+	if err != nil {
+		respondChapterBranchError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    comparison,
+	})
+}
+
+// MergeChapterBranch 合并章节分支
+// @Summary      合并章节分支
+// @Description  把选定的分支合并回主章节：分支正文与主章节不同时覆盖主章节正文，分支各产物的所有版本按顺序分配主章节的新版本号后移到主章节（versions 返回版本映射），分支标记为已合并。promote=true 时把合并后的最新版本按 explicit 策略发布为主章节的发布版本，发布失败（如 QA 阻断）不影响合并，原因见 promote_error
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string                     true  "分支的章节ID"
+// @Param        request     body      MergeChapterBranchRequest  true  "合并请求"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误或章节不是分支"
+// @Failure      404         {object}  ErrorResponse  "分支不存在"
+// @Failure      409         {object}  ErrorResponse  "分支已合并或已放弃"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/branch/merge [post]
+func (h *Handler) MergeChapterBranch(c *gin.Context) {
+	branchID := c.Param("chapter_id")
+	if branchID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	var req MergeChapterBranchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	result, err := h.novelService.MergeChapterBranch(c.Request.Context(), branchID, &novelservice.MergeChapterBranchRequest{
+		Promote:  req.Promote,
+		IgnoreQA: req.IgnoreQA,
+		UserID:   req.UserID,
+	})
+	if err != nil {
+		respondChapterBranchError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "章节分支已合并",
+		"data":    result,
+	})
+}
+
+// DiscardChapterBranch 放弃章节分支
+// @Summary      放弃章节分支
+// @Description  把分支标记为已放弃，分支及其产物保留但不能再合并
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string                       true  "分支的章节ID"
+// @Param        request     body      DiscardChapterBranchRequest  true  "放弃请求"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误或章节不是分支"
+// @Failure      404         {object}  ErrorResponse  "分支不存在"
+// @Failure      409         {object}  ErrorResponse  "分支已合并或已放弃"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/branch/discard [post]
+func (h *Handler) DiscardChapterBranch(c *gin.Context) {
+	branchID := c.Param("chapter_id")
+	if branchID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	var req DiscardChapterBranchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	if err := h.novelService.DiscardChapterBranch(c.Request.Context(), branchID, req.UserID); err != nil {
+		respondChapterBranchError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "章节分支已放弃",
+	})
+}
+
+func respondChapterBranchError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, novelservice.ErrInvalidChapterBranch):
+		code = http.StatusBadRequest
+		errorCode = 40003
+	case errors.Is(err, novelservice.ErrChapterBranchExists),
+		errors.Is(err, novelservice.ErrChapterBranchClosed):
+		code = http.StatusConflict
+		errorCode = 40901
+	}
+
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,139 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetChapterPipeline 查询章节渲染流程的当前状态
+// @Summary      查询章节流程状态
+// @Description  重放章节流程事件得到各阶段（解说、音频、字幕、图片、解说视频、最终视频）的当前状态、执行次数和整体进度
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true  "章节ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  ErrorResponse  "章节不存在"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/pipeline [get]
+func (h *Handler) GetChapterPipeline(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	state, err := h.novelService.GetChapterPipeline(c.Request.Context(), chapterID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:    40401,
+				Message: "chapter not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50001,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    state,
+	})
+}
+
+// GetChapterPipelineGraph 查询章节渲染流程的依赖图
+// @Summary      查询章节流程依赖图
+// @Description  返回章节渲染流程的依赖图用于绘制流程图：nodes 为各阶段（产出的产物、直接上游、状态、耗时、是否可执行），edges 为依赖关系（上游 -> 下游）。critical_path 为到最终视频耗时最长的依赖链（已完成阶段取实际耗时，未完成阶段取最近一次成功执行的耗时），blocking 为关键路径上可以执行但尚未完成、阻塞章节完成的阶段
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true  "章节ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  ErrorResponse  "章节不存在"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/pipeline/graph [get]
+func (h *Handler) GetChapterPipelineGraph(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	graph, err := h.novelService.GetChapterPipelineGraph(c.Request.Context(), chapterID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Code:    40401,
+				Message: "chapter not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50001,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    graph,
+	})
+}
+
+// ListChapterEvents 查询章节的流程事件历史
+// @Summary      查询章节流程事件
+// @Description  按发生时间返回章节的所有流程事件（阶段开始/完成/失败及结束时的耗时、费用快照），用于复盘
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true  "章节ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/pipeline/events [get]
+func (h *Handler) ListChapterEvents(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	events, err := h.novelService.ListChapterEvents(c.Request.Context(), chapterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50001,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"chapter_id": chapterID,
+			"events":     events,
+		},
+	})
+}
@@ -0,0 +1,74 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/pkg/killswitch"
+	novelservice "lemon/internal/service/novel"
+)
+
+// GetChapterSummary 查询章节内容摘要
+// @Summary      查询章节内容摘要
+// @Description  返回章节的 200~300 字内容摘要、视角人物和预计场景数，供编辑在处理章节前快速了解内容。首次查询时调用大模型生成并缓存，之后直接返回缓存（cached=true）；章节全文变化后缓存失效，下次查询重新生成
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true  "章节ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "章节没有正文"
+// @Failure      402         {object}  ErrorResponse  "超出小说预算"
+// @Failure      404         {object}  ErrorResponse  "章节不存在"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Failure      503         {object}  ErrorResponse  "大模型维护中"
+// @Router       /api/v1/novels/chapters/{chapter_id}/summary [get]
+func (h *Handler) GetChapterSummary(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	summary, err := h.novelService.GetChapterSummary(c.Request.Context(), chapterID)
+	if err != nil {
+		respondChapterSummaryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    summary,
+	})
+}
+
+func respondChapterSummaryError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, novelservice.ErrChapterTextEmpty):
+		code = http.StatusBadRequest
+		errorCode = 40002
+	case errors.Is(err, novelservice.ErrBudgetExceeded):
+		code = http.StatusPaymentRequired
+		errorCode = 40201
+	case errors.Is(err, killswitch.ErrMaintenance):
+		code = http.StatusServiceUnavailable
+		errorCode = 50301
+	}
+
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
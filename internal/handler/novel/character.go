@@ -18,6 +18,9 @@ type CharacterInfo struct {
 	RoleNumber string                      `json:"role_number"` // 角色编号
 	Appearance *novel.CharacterAppearance  `json:"appearance,omitempty"` // 外貌特征
 	Clothing   *novel.CharacterClothing    `json:"clothing,omitempty"`   // 服装风格
+	VoiceType       string                          `json:"voice_type,omitempty"`       // 角色配音音色
+	VoiceSource     string                          `json:"voice_source,omitempty"`     // 音色来源：suggestion, manual
+	VoiceSuggestion *novel.CharacterVoiceSuggestion `json:"voice_suggestion,omitempty"` // 最近一次的选角建议
 	CreatedAt  string                      `json:"created_at"`  // 创建时间
 	UpdatedAt  string                      `json:"updated_at"`  // 更新时间
 }
@@ -33,6 +36,9 @@ func toCharacterInfo(characterEntity *novel.Character) CharacterInfo {
 		RoleNumber: characterEntity.RoleNumber,
 		Appearance: characterEntity.Appearance,
 		Clothing:   characterEntity.Clothing,
+		VoiceType:       characterEntity.VoiceType,
+		VoiceSource:     string(characterEntity.VoiceSource),
+		VoiceSuggestion: characterEntity.VoiceSuggestion,
 		CreatedAt:  characterEntity.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:  characterEntity.UpdatedAt.Format(time.RFC3339),
 	}
@@ -0,0 +1,94 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	novelservice "lemon/internal/service/novel"
+)
+
+// CleanupFailedArtifactsRequest 清理失败产物请求
+type CleanupFailedArtifactsRequest struct {
+	NovelID        string   `json:"novel_id"`         // 只清理该小说的产物（路径中带 novel_id 时以路径为准；为空时清理所有小说）
+	Kinds          []string `json:"kinds"`            // 产物类型：image、video（为空时两者都清理）
+	OlderThanHours int      `json:"older_than_hours"` // 只清理失败超过该小时数的产物（为 0 时默认 24 小时）
+	DryRun         bool     `json:"dry_run"`          // 只预览将被删除的产物，不删除
+}
+
+// CleanupFailedArtifacts 批量清理失败产物
+// @Summary      批量清理失败产物
+// @Description  删除状态为 failed 且最后更新时间早于 older_than_hours 的图片/视频记录，并删除其在存储中的文件。带 novel_id 路径时只清理该小说的产物。dry_run=true 时只返回将被删除的产物；执行后返回清理报告（单个产物删除失败不影响其他产物，失败原因记录在报告中）
+// @Tags         小说管理
+// @Accept       json
+// @Produce      json
+// @Param        request   body      CleanupFailedArtifactsRequest  true  "清理请求"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/artifacts/failed/cleanup [post]
+// @Router       /api/v1/novels/{novel_id}/artifacts/failed/cleanup [post]
+func (h *Handler) CleanupFailedArtifacts(c *gin.Context) {
+	var req CleanupFailedArtifactsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if req.OlderThanHours < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40003,
+			Message: "older_than_hours must not be negative",
+		})
+		return
+	}
+
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		novelID = req.NovelID
+	}
+
+	report, err := h.novelService.CleanupFailedArtifacts(c.Request.Context(), &novelservice.CleanupRequest{
+		NovelID:   novelID,
+		Kinds:     req.Kinds,
+		OlderThan: time.Duration(req.OlderThanHours) * time.Hour,
+		DryRun:    req.DryRun,
+	})
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+
+		// 根据错误类型设置错误码
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			code = http.StatusNotFound
+			errorCode = 40401
+		case errors.Is(err, novelservice.ErrInvalidCleanup):
+			code = http.StatusBadRequest
+			errorCode = 40003
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	message := "失败产物清理完成"
+	if report.DryRun {
+		message = "失败产物清理预览"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": message,
+		"data":    report,
+	})
+}
@@ -12,19 +12,32 @@ type ErrorResponse = httputil.ErrorResponse
 
 // VideoInfo 视频信息（用于响应）
 type VideoInfo struct {
-	ID              string  `json:"id"`                // 视频ID
-	ChapterID       string  `json:"chapter_id"`        // 章节ID
-	NarrationID     string  `json:"narration_id"`      // 解说ID
-	UserID          string  `json:"user_id"`           // 用户ID
-	Sequence        int     `json:"sequence"`          // 序号
-	VideoResourceID string  `json:"video_resource_id"` // 视频资源ID
-	Duration        float64 `json:"duration"`          // 视频时长（秒）
-	VideoType       string  `json:"video_type"`        // 视频类型：narration_video, final_video
-	Prompt          string  `json:"prompt,omitempty"`  // 视频生成提示词
-	Version         int     `json:"version"`           // 版本号
-	Status          string  `json:"status"`            // 状态：pending, processing, completed, failed
-	CreatedAt       string  `json:"created_at"`        // 创建时间
-	UpdatedAt       string  `json:"updated_at"`        // 更新时间
+	ID              string  `json:"id"`                     // 视频ID
+	ChapterID       string  `json:"chapter_id"`             // 章节ID
+	NarrationID     string  `json:"narration_id"`           // 解说ID
+	UserID          string  `json:"user_id"`                // 用户ID
+	Sequence        int     `json:"sequence"`               // 序号
+	SequenceEnd     int     `json:"sequence_end,omitempty"` // 合并片段覆盖的最后一个镜头序号
+	MergeRule       string  `json:"merge_rule,omitempty"`   // 生成时使用的镜头合并规则：none, short, scene
+	VideoResourceID string  `json:"video_resource_id"`      // 视频资源ID
+	Duration        float64 `json:"duration"`               // 视频时长（秒）
+	VideoType       string  `json:"video_type"`             // 视频类型：narration_video, final_video
+	Prompt          string  `json:"prompt,omitempty"`       // 视频生成提示词
+	Version         int     `json:"version"`                // 版本号
+	Status          string  `json:"status"`                 // 状态：pending, processing, completed, failed
+	CreatedAt       string  `json:"created_at"`             // 创建时间
+	UpdatedAt       string  `json:"updated_at"`             // 更新时间
+
+	Compliance         *novel.ComplianceReport `json:"compliance,omitempty"`           // 平台规范校验报告（仅 final_video）
+	ManifestResourceID string                  `json:"manifest_resource_id,omitempty"` // 流水线清单的 resource_id（仅 final_video）
+	ManifestSHA256     string                  `json:"manifest_sha256,omitempty"`      // 流水线清单内容的 SHA256
+
+	ThumbnailSpriteResourceID string `json:"thumbnail_sprite_resource_id,omitempty"` // 缩略图雪碧图的 resource_id
+	ThumbnailVTTResourceID    string `json:"thumbnail_vtt_resource_id,omitempty"`    // 雪碧图 WebVTT 索引的 resource_id
+
+	TranscriptResourceID       string `json:"transcript_resource_id,omitempty"`        // 纯文本文字稿的 resource_id（仅 final_video）
+	AudioDescriptionResourceID string `json:"audio_description_resource_id,omitempty"` // 口述影像音轨（AAC）的 resource_id
+	DescriptionVTTResourceID   string `json:"description_vtt_resource_id,omitempty"`   // 口述影像 WebVTT 描述轨的 resource_id
 }
 
 // toVideoInfo 将Video实体转换为VideoInfo
@@ -35,6 +48,8 @@ func toVideoInfo(video *novel.Video) VideoInfo {
 		NarrationID:     video.NarrationID,
 		UserID:          video.UserID,
 		Sequence:        video.Sequence,
+		SequenceEnd:     video.SequenceEnd,
+		MergeRule:       string(video.MergeRule),
 		VideoResourceID: video.VideoResourceID,
 		Duration:        video.Duration,
 		VideoType:       string(video.VideoType),
@@ -43,6 +58,17 @@ func toVideoInfo(video *novel.Video) VideoInfo {
 		Status:          string(video.Status),
 		CreatedAt:       video.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:       video.UpdatedAt.Format(time.RFC3339),
+		Compliance:      video.Compliance,
+
+		ManifestResourceID: video.ManifestResourceID,
+		ManifestSHA256:     video.ManifestSHA256,
+
+		ThumbnailSpriteResourceID: video.ThumbnailSpriteResourceID,
+		ThumbnailVTTResourceID:    video.ThumbnailVTTResourceID,
+
+		TranscriptResourceID:       video.TranscriptResourceID,
+		AudioDescriptionResourceID: video.AudioDescriptionResourceID,
+		DescriptionVTTResourceID:   video.DescriptionVTTResourceID,
 	}
 }
 
@@ -65,6 +91,11 @@ type NovelInfo struct {
 	Description string `json:"description,omitempty"` // 简介
 	CreatedAt   string `json:"created_at"`            // 创建时间
 	UpdatedAt   string `json:"updated_at"`            // 更新时间
+
+	SourceEncoding string `json:"source_encoding,omitempty"` // 源文件编码（切分章节后可用）
+
+	CoverID         string `json:"cover_id,omitempty"`          // 当前封面ID（没有封面时为空）
+	CoverResourceID string `json:"cover_resource_id,omitempty"` // 当前封面图片的 resource_id
 }
 
 // toNovelInfo 将 Novel 实体转换为 NovelInfo DTO
@@ -78,6 +109,11 @@ func toNovelInfo(novelEntity *novel.Novel) NovelInfo {
 		Description: novelEntity.Description,
 		CreatedAt:   novelEntity.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:   novelEntity.UpdatedAt.Format(time.RFC3339),
+
+		SourceEncoding: novelEntity.SourceEncoding,
+
+		CoverID:         novelEntity.CoverID,
+		CoverResourceID: novelEntity.CoverResourceID,
 	}
 }
 
@@ -87,29 +123,40 @@ type ChapterInfo struct {
 	NovelID     string `json:"novel_id"`     // 小说ID
 	UserID      string `json:"user_id"`      // 用户ID
 	Sequence    int    `json:"sequence"`     // 章节序号
-	Title       string `json:"title"`        // 章节标题
+	Title       string `json:"title"`        // 章节标题（展示用）
 	ChapterText string `json:"chapter_text"` // 章节全文
 	TotalChars  int    `json:"total_chars"`  // 章节总字符数
 	WordCount   int    `json:"word_count"`   // 章节总字数
 	LineCount   int    `json:"line_count"`   // 章节行数
-	CreatedAt   string `json:"created_at"`   // 创建时间
-	UpdatedAt   string `json:"updated_at"`   // 更新时间
+
+	OriginalTitle   string `json:"original_title,omitempty"`   // 原始标题
+	NormalizedTitle string `json:"normalized_title,omitempty"` // 规范化标题（章节序号为阿拉伯数字）
+	TitleNumber     int    `json:"title_number,omitempty"`     // 标题中的章节序号（用于排序）
+
+	PromotedVersions *novel.PromotedVersions `json:"promoted_versions,omitempty"` // 发布版本（未发布时为空）
+
+	CreatedAt string `json:"created_at"` // 创建时间
+	UpdatedAt string `json:"updated_at"` // 更新时间
 }
 
 // toChapterInfo 将 Chapter 实体转换为 ChapterInfo DTO
 func toChapterInfo(chapterEntity *novel.Chapter) ChapterInfo {
 	return ChapterInfo{
-		ID:          chapterEntity.ID,
-		NovelID:     chapterEntity.NovelID,
-		UserID:      chapterEntity.UserID,
-		Sequence:    chapterEntity.Sequence,
-		Title:       chapterEntity.Title,
-		ChapterText: chapterEntity.ChapterText,
-		TotalChars:  chapterEntity.TotalChars,
-		WordCount:   chapterEntity.WordCount,
-		LineCount:   chapterEntity.LineCount,
-		CreatedAt:   chapterEntity.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:   chapterEntity.UpdatedAt.Format(time.RFC3339),
+		ID:               chapterEntity.ID,
+		NovelID:          chapterEntity.NovelID,
+		UserID:           chapterEntity.UserID,
+		Sequence:         chapterEntity.Sequence,
+		Title:            chapterEntity.Title,
+		OriginalTitle:    chapterEntity.OriginalTitle,
+		NormalizedTitle:  chapterEntity.NormalizedTitle,
+		TitleNumber:      chapterEntity.TitleNumber,
+		ChapterText:      chapterEntity.ChapterText,
+		TotalChars:       chapterEntity.TotalChars,
+		WordCount:        chapterEntity.WordCount,
+		LineCount:        chapterEntity.LineCount,
+		PromotedVersions: chapterEntity.PromotedVersions,
+		CreatedAt:        chapterEntity.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:        chapterEntity.UpdatedAt.Format(time.RFC3339),
 	}
 }
 
@@ -0,0 +1,183 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	novelservice "lemon/internal/service/novel"
+)
+
+// CreateCompilationRequest 创建回顾合集请求
+type CreateCompilationRequest struct {
+	UserID         string   `json:"user_id"`                                              // 操作用户ID（为空时使用小说所有者）
+	Title          string   `json:"title"`                                                // 合集标题（为空时按章节范围生成）
+	Selection      string   `json:"selection" binding:"required,oneof=manual highlights"` // 镜头选择方式：manual（手动指定）、highlights（按评分挑选高光）
+	ShotIDs        []string `json:"shot_ids"`                                             // 手动指定的镜头ID（按播放顺序，manual 时必填）
+	ChapterFrom    int      `json:"chapter_from" binding:"omitempty,min=1"`               // 起始章节序号（默认第一章）
+	ChapterTo      int      `json:"chapter_to" binding:"omitempty,min=1"`                 // 结束章节序号（默认最后一章）
+	TargetDuration float64  `json:"target_duration" binding:"omitempty,gt=0,max=600"`     // 目标时长（秒，默认60，最长600）
+}
+
+// CreateCompilation 创建跨章节回顾合集
+// @Summary      创建回顾合集
+// @Description  从多个章节中选取镜头（manual 按 shot_ids 顺序；highlights 按镜头评分挑选高光镜头，按故事顺序排列），由大模型重新撰写回顾解说，按新解说的配音时长重新剪辑每个镜头并拼接成横版合集视频。合集是独立的视频实体，不影响章节的视频版本
+// @Tags         视频管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string                    true  "小说ID"
+// @Param        request   body      CreateCompilationRequest  true  "合集参数"
+// @Success      201       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      402       {object}  ErrorResponse  "小说预算已超限"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/compilations [post]
+func (h *Handler) CreateCompilation(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	var req CreateCompilationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := req.UserID
+	if currentUserID, ok := ctxutil.GetUserID(ctx); ok {
+		userID = currentUserID
+	}
+
+	compilation, err := h.novelService.CreateCompilation(ctx, &novelservice.CreateCompilationRequest{
+		NovelID:        novelID,
+		UserID:         userID,
+		Title:          req.Title,
+		Selection:      novel.CompilationSelection(req.Selection),
+		ShotIDs:        req.ShotIDs,
+		ChapterFrom:    req.ChapterFrom,
+		ChapterTo:      req.ChapterTo,
+		TargetDuration: req.TargetDuration,
+	})
+	if err != nil {
+		respondCompilationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    compilation,
+	})
+}
+
+// ListCompilations 查询小说的回顾合集
+// @Summary      查询回顾合集
+// @Description  查询小说的跨章节回顾合集（按创建时间倒序），包含每个镜头的来源、回顾解说和在合集中的时间
+// @Tags         视频管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string  true   "小说ID"
+// @Param        limit     query     int     false  "返回数量（默认50，最大200）"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/compilations [get]
+func (h *Handler) ListCompilations(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	compilations, err := h.novelService.ListCompilations(c.Request.Context(), novelID, budgetListLimit(c))
+	if err != nil {
+		respondCompilationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"novel_id":     novelID,
+			"compilations": compilations,
+			"count":        len(compilations),
+		},
+	})
+}
+
+// GetCompilation 获取回顾合集
+// @Summary      获取回顾合集
+// @Description  获取回顾合集的详情和渲染状态（processing, completed, failed），完成后 video_resource_id 为合集视频
+// @Tags         视频管理
+// @Accept       json
+// @Produce      json
+// @Param        compilation_id  path      string  true  "合集ID"
+// @Success      200             {object}  map[string]interface{}  "成功响应"
+// @Failure      400             {object}  ErrorResponse  "请求参数错误"
+// @Failure      404             {object}  ErrorResponse  "合集不存在"
+// @Failure      500             {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/compilations/{compilation_id} [get]
+func (h *Handler) GetCompilation(c *gin.Context) {
+	compilationID := c.Param("compilation_id")
+	if compilationID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "compilation_id is required",
+		})
+		return
+	}
+
+	compilation, err := h.novelService.GetCompilation(c.Request.Context(), compilationID)
+	if err != nil {
+		respondCompilationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    compilation,
+	})
+}
+
+func respondCompilationError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, novelservice.ErrInvalidCompilation):
+		code = http.StatusBadRequest
+		errorCode = 40005
+	case errors.Is(err, novelservice.ErrBudgetExceeded):
+		code = http.StatusPaymentRequired
+		errorCode = 40201
+	}
+
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
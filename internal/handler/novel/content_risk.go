@@ -0,0 +1,175 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	novelservice "lemon/internal/service/novel"
+)
+
+// ScanContentRiskRequest 内容风险扫描参数
+type ScanContentRiskRequest struct {
+	NarrationVersion int `form:"narration_version" binding:"min=0"` // 解说版本（为空时取最新版本）
+}
+
+// AcknowledgeContentRiskRequest 确认内容风险报告请求
+type AcknowledgeContentRiskRequest struct {
+	UserID string `json:"user_id" binding:"required"` // 确认人用户ID（必填）
+	Note   string `json:"note"`                       // 确认说明（如已获得歌词授权、已咨询平台）
+}
+
+// ScanChapterContentRisk 扫描章节的内容风险
+// @Summary      扫描章节的内容风险
+// @Description  发布前扫描章节解说版本的场景解说、镜头解说（字幕正文，按小说的数字写法转换）和字幕片头/片尾文案，检查版权内容（歌词、影视作品引用）、医疗功效和投资收益宣称、目标平台禁止的话题（站外引流、极限用语等按平台生效）和粗口，返回带命中位置（highlights，按字符计）的风险报告。内容与已有报告相同时返回已有报告；存在风险的报告需要编辑确认后，该解说版本才能批量发布
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id         path      string  true   "章节ID"
+// @Param        narration_version  query     int     false  "解说版本（默认最新版本）"
+// @Success      200                {object}  map[string]interface{}  "成功响应"
+// @Failure      400                {object}  ErrorResponse  "请求参数错误"
+// @Failure      404                {object}  ErrorResponse  "章节或解说不存在"
+// @Failure      500                {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/content-risk/scan [post]
+func (h *Handler) ScanChapterContentRisk(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	var req ScanContentRiskRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid query parameters",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	report, err := h.novelService.ScanChapterContentRisk(c.Request.Context(), chapterID, req.NarrationVersion)
+	if err != nil {
+		h.respondContentRiskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    report,
+	})
+}
+
+// ListChapterContentRiskReports 获取章节的内容风险报告
+// @Summary      获取章节的内容风险报告
+// @Description  获取章节的所有内容风险报告（按时间倒序），包含风险列表和确认状态
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true  "章节ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  ErrorResponse  "章节不存在"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/content-risk-reports [get]
+func (h *Handler) ListChapterContentRiskReports(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	reports, err := h.novelService.ListChapterContentRiskReports(c.Request.Context(), chapterID)
+	if err != nil {
+		h.respondContentRiskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"chapter_id": chapterID,
+			"reports":    reports,
+			"count":      len(reports),
+		},
+	})
+}
+
+// AcknowledgeContentRisk 确认内容风险报告
+// @Summary      确认内容风险报告
+// @Description  编辑确认已审阅风险报告中的所有风险，确认后对应解说版本可以批量发布。报告扫描后解说或字幕文案被修改（或风险规则已更新）时返回 409，需要重新扫描后确认；重复确认返回已确认的报告
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        report_id  path      string                         true  "报告ID"
+// @Param        request    body      AcknowledgeContentRiskRequest  true  "确认请求"
+// @Success      200        {object}  map[string]interface{}  "成功响应"
+// @Failure      400        {object}  ErrorResponse  "请求参数错误"
+// @Failure      404        {object}  ErrorResponse  "报告不存在"
+// @Failure      409        {object}  ErrorResponse  "报告已过期"
+// @Failure      500        {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/content-risk-reports/{report_id}/acknowledge [post]
+func (h *Handler) AcknowledgeContentRisk(c *gin.Context) {
+	reportID := c.Param("report_id")
+	if reportID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "report_id is required",
+		})
+		return
+	}
+
+	var req AcknowledgeContentRiskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	report, err := h.novelService.AcknowledgeContentRisk(c.Request.Context(), reportID, &novelservice.AcknowledgeContentRiskRequest{
+		AcknowledgedBy: req.UserID,
+		Note:           req.Note,
+	})
+	if err != nil {
+		h.respondContentRiskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "风险报告已确认",
+		"data":    report,
+	})
+}
+
+// respondContentRiskError 把内容风险服务的错误映射为 HTTP 响应
+func (h *Handler) respondContentRiskError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, novelservice.ErrContentRiskReportStale):
+		code = http.StatusConflict
+		errorCode = 40901
+	}
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,81 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	novelservice "lemon/internal/service/novel"
+)
+
+// SetChapterContinuityRequest 设置章节衔接方式请求
+type SetChapterContinuityRequest struct {
+	Continuity novel.ChapterContinuity `json:"continuity" binding:"required"` // 章节衔接方式：none（不衔接）、reference_frame（作为第一个镜头的参考图）、recap_overlay（在最终视频开头叠加并淡出）
+}
+
+// SetChapterContinuity 设置小说的章节衔接方式
+// @Summary      设置章节衔接方式
+// @Description  设置相邻章节的画面衔接方式：使用上一章最终视频（优先已发布版本）的最后一帧。reference_frame：作为本章第一个镜头生成图片时的参考图（需要图片生成提供者支持参考图）；recap_overlay：生成最终视频时叠加在开头并淡出。第一章或上一章还没有最终视频时不衔接。只影响之后生成的图片和最终视频
+// @Tags         小说管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string                       true  "小说ID"
+// @Param        request   body      SetChapterContinuityRequest  true  "章节衔接方式"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/continuity [put]
+func (h *Handler) SetChapterContinuity(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	var req SetChapterContinuityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	if err := h.novelService.SetChapterContinuity(c.Request.Context(), novelID, req.Continuity); err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+
+		// 根据错误类型设置错误码
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			code = http.StatusNotFound
+			errorCode = 40401
+		case errors.Is(err, novelservice.ErrInvalidContinuity):
+			code = http.StatusBadRequest
+			errorCode = 40003
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "章节衔接方式已更新",
+		"data": gin.H{
+			"novel_id":   novelID,
+			"continuity": req.Continuity,
+		},
+	})
+}
@@ -0,0 +1,374 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	novelservice "lemon/internal/service/novel"
+)
+
+// NovelCoverInfo 小说封面 DTO
+type NovelCoverInfo struct {
+	ID                string   `json:"id"`                            // 封面ID
+	NovelID           string   `json:"novel_id"`                      // 小说ID
+	UserID            string   `json:"user_id"`                       // 创建用户ID
+	ResourceID        string   `json:"resource_id"`                   // 封面图片的 resource_id
+	Source            string   `json:"source"`                        // 来源：generated（生成）、uploaded（上传）
+	Selected          bool     `json:"selected"`                      // 是否为当前封面
+	Prompt            string   `json:"prompt,omitempty"`              // 生成使用的提示词
+	LocalizedPrompt   string   `json:"localized_prompt,omitempty"`    // 实际发给图片提供者的提示词
+	StyleReferenceIDs []string `json:"style_reference_ids,omitempty"` // 生成时使用的风格参考图ID
+	CreatedAt         string   `json:"created_at"`                    // 创建时间
+}
+
+func toNovelCoverInfo(cover *novel.NovelCover, selectedID string) NovelCoverInfo {
+	return NovelCoverInfo{
+		ID:                cover.ID,
+		NovelID:           cover.NovelID,
+		UserID:            cover.UserID,
+		ResourceID:        cover.ResourceID,
+		Source:            cover.Source.String(),
+		Selected:          cover.ID == selectedID,
+		Prompt:            cover.Prompt,
+		LocalizedPrompt:   cover.LocalizedPrompt,
+		StyleReferenceIDs: cover.StyleReferenceIDs,
+		CreatedAt:         cover.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// GenerateNovelCoversRequest 生成封面请求
+type GenerateNovelCoversRequest struct {
+	UserID string `json:"user_id"`                               // 操作用户ID（为空时使用小说所有者）
+	Count  int    `json:"count" binding:"omitempty,min=1,max=4"` // 生成数量（默认2，最多4）
+}
+
+// GenerateNovelCovers 生成小说封面
+// @Summary      生成小说封面
+// @Description  根据作品设定（书名、简介、题材、基调、主角等）和第一章开头，先由大模型设计封面画面，再调用图片提供者生成封面候选（有小说级风格参考图时带上参考图）。单张失败不影响其他候选；小说还没有封面时自动选用第一张
+// @Tags         小说管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string                      true  "小说ID"
+// @Param        request   body      GenerateNovelCoversRequest  false "生成参数"
+// @Success      201       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      402       {object}  ErrorResponse  "小说预算已超限"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/covers/generate [post]
+func (h *Handler) GenerateNovelCovers(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	var req GenerateNovelCoversRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40002,
+				Message: "Invalid request body",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	userID := req.UserID
+	if currentUserID, ok := ctxutil.GetUserID(ctx); ok {
+		userID = currentUserID
+	}
+
+	covers, err := h.novelService.GenerateNovelCovers(ctx, &novelservice.GenerateNovelCoversRequest{
+		NovelID: novelID,
+		UserID:  userID,
+		Count:   req.Count,
+	})
+	if err != nil {
+		respondCoverError(c, err)
+		return
+	}
+
+	gallery, err := h.novelService.ListNovelCovers(ctx, novelID)
+	if err != nil {
+		respondCoverError(c, err)
+		return
+	}
+
+	infos := make([]NovelCoverInfo, 0, len(covers))
+	for _, cover := range covers {
+		infos = append(infos, toNovelCoverInfo(cover, gallery.SelectedCoverID))
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    0,
+		"message": "封面生成成功",
+		"data": gin.H{
+			"novel_id":          novelID,
+			"covers":            infos,
+			"count":             len(infos),
+			"selected_cover_id": gallery.SelectedCoverID,
+		},
+	})
+}
+
+// UploadNovelCover 上传小说封面
+// @Summary      上传小说封面
+// @Description  手动上传封面图片作为封面候选。小说还没有封面或 select=true 时选用该封面
+// @Tags         小说管理
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        novel_id  path      string  true   "小说ID"
+// @Param        file      formData  file    true   "封面（图片文件）"
+// @Param        user_id   formData  string  true   "用户ID"
+// @Param        select    formData  bool    false  "是否选用为当前封面"
+// @Success      201       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/covers [post]
+func (h *Handler) UploadNovelCover(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid file",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	userID := c.PostForm("user_id")
+	if currentUserID, ok := ctxutil.GetUserID(c.Request.Context()); ok {
+		userID = currentUserID
+	}
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40003,
+			Message: "user_id is required",
+		})
+		return
+	}
+
+	var selectCover bool
+	if v := c.PostForm("select"); v != "" {
+		if selectCover, err = strconv.ParseBool(v); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40002,
+				Message: "Invalid select",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	fileReader, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40004,
+			Message: "Failed to open file",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	defer fileReader.Close()
+
+	ctx := c.Request.Context()
+
+	// 调用Service层
+	cover, err := h.novelService.UploadNovelCover(ctx, &novelservice.UploadNovelCoverRequest{
+		NovelID:     novelID,
+		UserID:      userID,
+		FileName:    file.Filename,
+		ContentType: file.Header.Get("Content-Type"),
+		Data:        fileReader,
+		Select:      selectCover,
+	})
+	if err != nil {
+		respondCoverError(c, err)
+		return
+	}
+
+	gallery, err := h.novelService.ListNovelCovers(ctx, novelID)
+	if err != nil {
+		respondCoverError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    0,
+		"message": "封面上传成功",
+		"data":    toNovelCoverInfo(cover, gallery.SelectedCoverID),
+	})
+}
+
+// ListNovelCovers 获取小说封面候选
+// @Summary      获取小说封面候选
+// @Description  获取小说的所有封面候选（按创建时间倒序），selected 标记当前封面
+// @Tags         小说管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string  true  "小说ID"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/covers [get]
+func (h *Handler) ListNovelCovers(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	gallery, err := h.novelService.ListNovelCovers(c.Request.Context(), novelID)
+	if err != nil {
+		respondCoverError(c, err)
+		return
+	}
+
+	infos := make([]NovelCoverInfo, 0, len(gallery.Covers))
+	for _, cover := range gallery.Covers {
+		infos = append(infos, toNovelCoverInfo(cover, gallery.SelectedCoverID))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"novel_id":          novelID,
+			"selected_cover_id": gallery.SelectedCoverID,
+			"covers":            infos,
+			"count":             len(infos),
+		},
+	})
+}
+
+// SelectNovelCover 选用小说封面
+// @Summary      选用小说封面
+// @Description  把封面候选设为小说的当前封面，小说详情和列表中展示该封面
+// @Tags         小说管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string  true  "小说ID"
+// @Param        cover_id  path      string  true  "封面ID"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误或封面不属于该小说"
+// @Failure      404       {object}  ErrorResponse  "小说或封面不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/covers/{cover_id}/select [post]
+func (h *Handler) SelectNovelCover(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	coverID := c.Param("cover_id")
+	if novelID == "" || coverID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id and cover_id are required",
+		})
+		return
+	}
+
+	n, err := h.novelService.SelectNovelCover(c.Request.Context(), novelID, coverID)
+	if err != nil {
+		respondCoverError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "封面已选用",
+		"data": gin.H{
+			"novel": toNovelInfo(n),
+		},
+	})
+}
+
+// DeleteNovelCover 删除小说封面
+// @Summary      删除小说封面
+// @Description  删除封面候选。删除的是当前封面时改用最新的其他候选，没有其他候选时清空封面
+// @Tags         小说管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string  true  "小说ID"
+// @Param        cover_id  path      string  true  "封面ID"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误或封面不属于该小说"
+// @Failure      404       {object}  ErrorResponse  "小说或封面不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/covers/{cover_id} [delete]
+func (h *Handler) DeleteNovelCover(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	coverID := c.Param("cover_id")
+	if novelID == "" || coverID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id and cover_id are required",
+		})
+		return
+	}
+
+	if err := h.novelService.DeleteNovelCover(c.Request.Context(), novelID, coverID); err != nil {
+		respondCoverError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "封面已删除",
+		"data": gin.H{
+			"novel_id": novelID,
+			"cover_id": coverID,
+		},
+	})
+}
+
+// respondCoverError 按错误类型返回封面接口的错误响应
+func respondCoverError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+
+	// 根据错误类型设置错误码
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, novelservice.ErrInvalidCover), errors.Is(err, novelservice.ErrInvalidCoverCount):
+		code = http.StatusBadRequest
+		errorCode = 40005
+	case errors.Is(err, novelservice.ErrCoverNotInNovel):
+		code = http.StatusBadRequest
+		errorCode = 40006
+	case errors.Is(err, novelservice.ErrBudgetExceeded):
+		code = http.StatusPaymentRequired
+		errorCode = 40201
+	}
+
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
@@ -1,19 +1,22 @@
 package novel
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 
 	novelmodel "lemon/internal/model/novel"
+	novelservice "lemon/internal/service/novel"
 )
 
 // CreateNovelRequest 创建小说请求
 type CreateNovelRequest struct {
-	ResourceID    string `json:"resource_id" binding:"required"`    // 资源ID（必填）
-	UserID        string `json:"user_id" binding:"required"`        // 用户ID（必填）
-	NarrationType string `json:"narration_type" binding:"required"` // 旁白类型：narration（旁白/解说）或 dialogue（真人对话）
-	Style         string `json:"style" binding:"required"`          // 风格：anime（漫剧）、live（真人剧）、mixed（混合）
+	ResourceID       string `json:"resource_id" binding:"required"` // 资源ID（必填）
+	UserID           string `json:"user_id" binding:"required"`     // 用户ID（必填）
+	NarrationType    string `json:"narration_type"`                 // 旁白类型：narration（旁白/解说）或 dialogue（真人对话），不传时使用用户的创作默认配置
+	Style            string `json:"style"`                          // 风格：anime（漫剧）、live（真人剧）、mixed（混合），不传时使用用户的创作默认配置
+	WorkflowTemplate string `json:"workflow_template"`              // 工作流模板名称（见 GET /api/v1/workflow-templates），模板配置覆盖用户的创作默认配置
 }
 
 // CreateNovelResponseData 创建小说响应数据
@@ -23,13 +26,14 @@ type CreateNovelResponseData struct {
 
 // CreateNovel 根据资源ID创建小说
 // @Summary      创建小说
-// @Description  根据资源ID创建小说，返回小说ID。这是小说处理流程的第一步。
+// @Description  根据资源ID创建小说，返回小说ID。这是小说处理流程的第一步。用户开通过（POST /api/v1/onboarding）时，小说会复制用户的创作默认配置（数字写法、章节衔接、创作设定、默认平台、配音、字幕样式、片头片尾文案），未传的旁白类型和风格也使用默认配置。
+// @Description  指定 workflow_template 时复制模板的解说结构、配音、图片风格、视频规格、发布平台和创作设定（覆盖创作默认配置），未传的旁白类型和风格优先使用模板中的值
 // @Tags         小说管理
 // @Accept       json
 // @Produce      json
 // @Param        request  body      CreateNovelRequest  true  "创建小说请求"
 // @Success      201      {object}  map[string]interface{}  "成功响应"  "{\"code\": 0, \"message\": \"小说创建成功\", \"data\": {\"novel_id\": \"...\"}}"
-// @Failure      400      {object}  ErrorResponse  "请求参数错误"
+// @Failure      400      {object}  ErrorResponse  "请求参数错误或工作流模板不存在"
 // @Failure      500      {object}  ErrorResponse  "服务器内部错误"
 // @Router       /api/v1/novels [post]
 func (h *Handler) CreateNovel(c *gin.Context) {
@@ -46,8 +50,10 @@ func (h *Handler) CreateNovel(c *gin.Context) {
 	ctx := c.Request.Context()
 
 	// 将请求中的字符串类型转换为枚举类型
+	// 为空时由 Service 层使用用户的创作默认配置
 	var narrationType novelmodel.NarrationType
 	switch req.NarrationType {
+	case "":
 	case string(novelmodel.NarrationTypeNarration):
 		narrationType = novelmodel.NarrationTypeNarration
 	case string(novelmodel.NarrationTypeDialogue):
@@ -62,6 +68,7 @@ func (h *Handler) CreateNovel(c *gin.Context) {
 
 	var style novelmodel.NovelStyle
 	switch req.Style {
+	case "":
 	case string(novelmodel.NovelStyleAnime):
 		style = novelmodel.NovelStyleAnime
 	case string(novelmodel.NovelStyleLive):
@@ -77,15 +84,19 @@ func (h *Handler) CreateNovel(c *gin.Context) {
 	}
 
 	// 调用Service层
-	novelID, err := h.novelService.CreateNovelFromResource(ctx, req.ResourceID, req.UserID, narrationType, style)
+	novelID, err := h.novelService.CreateNovelFromResourceWithTemplate(ctx, req.ResourceID, req.UserID, narrationType, style, req.WorkflowTemplate)
 	if err != nil {
 		code := http.StatusInternalServerError
 		errorCode := 50001
 
 		// 根据错误类型设置错误码
-		if err.Error() == "failed to find resource" {
+		switch {
+		case err.Error() == "failed to find resource":
 			code = http.StatusBadRequest
 			errorCode = 40002
+		case errors.Is(err, novelservice.ErrUnknownWorkflowTemplate):
+			code = http.StatusBadRequest
+			errorCode = 40003
 		}
 
 		c.JSON(code, ErrorResponse{
@@ -0,0 +1,136 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/noveltools"
+)
+
+// SetCreativeMetadataRequest 设置小说创作设定请求
+type SetCreativeMetadataRequest struct {
+	Genre        string            `json:"genre"`         // 题材（如 玄幻、都市、悬疑）
+	Tone         string            `json:"tone"`          // 基调（如 热血、轻松、压抑）
+	Audience     string            `json:"audience"`      // 目标受众（如 男频、女频、青少年）
+	Protagonist  string            `json:"protagonist"`   // 主角姓名
+	BannedTopics []string          `json:"banned_topics"` // 禁止涉及的话题
+	Variables    map[string]string `json:"variables"`     // 自定义变量（变量名为小写字母开头的 snake_case，提示词中用 {{变量名}} 引用）
+}
+
+// SetCreativeMetadata 设置小说创作设定
+// @Summary      设置小说创作设定
+// @Description  整体替换小说的创作设定（题材、基调、目标受众、主角、禁用话题和自定义变量）。所有生成提示词（解说、图片、视频）都可以用 {{title}}、{{genre}}、{{tone}}、{{audience}}、{{protagonist}}、{{banned_topics}} 和自定义变量引用这些设定，未定义的变量保持原样；解说等文本生成提示词还会在开头附带作品设定和禁用话题。只影响之后的生成
+// @Tags         小说管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string                      true  "小说ID"
+// @Param        request   body      SetCreativeMetadataRequest  true  "创作设定"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/metadata [put]
+func (h *Handler) SetCreativeMetadata(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	var req SetCreativeMetadataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	view, err := h.novelService.SetCreativeMetadata(c.Request.Context(), novelID, &novel.CreativeMetadata{
+		Genre:        req.Genre,
+		Tone:         req.Tone,
+		Audience:     req.Audience,
+		Protagonist:  req.Protagonist,
+		BannedTopics: req.BannedTopics,
+		Variables:    req.Variables,
+	})
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+
+		// 根据错误类型设置错误码
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			code = http.StatusNotFound
+			errorCode = 40401
+		case errors.Is(err, noveltools.ErrInvalidCreativeMetadata):
+			code = http.StatusBadRequest
+			errorCode = 40003
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "创作设定已更新",
+		"data":    view,
+	})
+}
+
+// GetCreativeMetadata 查询小说创作设定
+// @Summary      查询小说创作设定
+// @Description  查询小说的创作设定，以及提示词中可用 {{变量名}} 引用的全部变量和当前取值
+// @Tags         小说管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string  true  "小说ID"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/metadata [get]
+func (h *Handler) GetCreativeMetadata(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	view, err := h.novelService.GetCreativeMetadata(c.Request.Context(), novelID)
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			code = http.StatusNotFound
+			errorCode = 40401
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    view,
+	})
+}
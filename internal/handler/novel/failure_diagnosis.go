@@ -0,0 +1,55 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	novelservice "lemon/internal/service/novel"
+)
+
+// ExplainChapterFailure 诊断章节生成失败
+// @Summary      诊断章节生成失败
+// @Description  收集章节最近一次失败的上下文（失败阶段、失败类别、provider 错误信息摘录、连续失败次数、上次成功以来的变更），调用大模型给出面向运营人员的说明、可能原因和建议操作，同时返回原始执行记录、失败任务和一键生成流程供运维排查。大模型不可用时按失败类别给出固定诊断（source=rules）
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true  "章节ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "参数错误"
+// @Failure      404         {object}  ErrorResponse  "章节不存在或没有失败记录"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/pipeline/failure-explanation [post]
+func (h *Handler) ExplainChapterFailure(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	explanation, err := h.novelService.ExplainChapterFailure(c.Request.Context(), chapterID)
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, mongo.ErrNoDocuments) || errors.Is(err, novelservice.ErrNoChapterFailure) {
+			code = http.StatusNotFound
+			errorCode = 40401
+		}
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    explanation,
+	})
+}
@@ -0,0 +1,50 @@
+package novel
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetFailureStats 查询生成失败的分类统计
+// @Summary      查询生成失败分类统计
+// @Description  按失败类别（provider_timeout 超时、provider_content_policy 内容审核、ffmpeg_codec 编解码、validation_schema 格式校验、storage_io 存储读写、unknown 未分类）统计最近 days 天（按 UTC 日期，含今天）各渲染阶段的失败次数，
+// @Description  分别按天和按 provider 汇总。失败类别在包装错误时标注，同时记录在阶段执行记录和失败的产物记录上
+// @Tags         视频生成
+// @Accept       json
+// @Produce      json
+// @Param        days  query     int  false  "统计天数（1-90，默认 7）"
+// @Success      200   {object}  map[string]interface{}  "成功响应"
+// @Failure      400   {object}  ErrorResponse  "请求参数错误"
+// @Failure      500   {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/failure-stats [get]
+func (h *Handler) GetFailureStats(c *gin.Context) {
+	days := 0
+	if daysStr := c.Query("days"); daysStr != "" {
+		v, err := strconv.Atoi(daysStr)
+		if err != nil || v <= 0 || v > 90 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40002,
+				Message: "Invalid days, must be between 1 and 90",
+			})
+			return
+		}
+		days = v
+	}
+
+	stats, err := h.novelService.GetFailureStats(c.Request.Context(), days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50001,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    stats,
+	})
+}
@@ -1,9 +1,12 @@
 package novel
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	novelservice "lemon/internal/service/novel"
 )
 
 // GenerateAudiosRequest 生成音频请求
@@ -27,6 +30,7 @@ type GenerateAudiosResponseData struct {
 // @Param        narration_id  path      string  true  "解说ID"
 // @Success      200           {object}  map[string]interface{}  "成功响应"  "{\"code\": 0, \"message\": \"音频生成任务已提交\", \"data\": {\"audio_ids\": [\"...\"], \"count\": 1, \"narration_id\": \"...\"}}"
 // @Failure      400           {object}  ErrorResponse  "请求参数错误"
+// @Failure      402           {object}  ErrorResponse  "小说花费已达到预算上限"
 // @Failure      500           {object}  ErrorResponse  "服务器内部错误"
 // @Router       /api/v1/narrations/{narration_id}/audios [post]
 func (h *Handler) GenerateAudios(c *gin.Context) {
@@ -50,6 +54,9 @@ func (h *Handler) GenerateAudios(c *gin.Context) {
 
 		// 根据错误类型设置错误码
 		switch {
+		case errors.Is(err, novelservice.ErrBudgetExceeded):
+			code = http.StatusPaymentRequired
+			errorCode = 40201
 		case err.Error() == "failed to find narration":
 			code = http.StatusNotFound
 			errorCode = 40401
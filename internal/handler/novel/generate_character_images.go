@@ -1,9 +1,12 @@
 package novel
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	novelservice "lemon/internal/service/novel"
 )
 
 // GenerateCharacterImages 为小说的所有角色生成图片
@@ -15,6 +18,7 @@ import (
 // @Param        novel_id  path      string  true  "小说ID"
 // @Success      200       {object}  map[string]interface{}  "成功响应"
 // @Failure      400       {object}  ErrorResponse          "请求参数错误"
+// @Failure      402       {object}  ErrorResponse          "小说花费已达到预算上限"
 // @Failure      500       {object}  ErrorResponse          "服务器内部错误"
 // @Router       /api/v1/novels/{novel_id}/characters/images [post]
 func (h *Handler) GenerateCharacterImages(c *gin.Context) {
@@ -30,8 +34,15 @@ func (h *Handler) GenerateCharacterImages(c *gin.Context) {
 	ctx := c.Request.Context()
 	imageIDs, err := h.novelService.GenerateCharacterImages(ctx, novelID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Code:    50001,
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, novelservice.ErrBudgetExceeded) {
+			code = http.StatusPaymentRequired
+			errorCode = 40201
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
 			Message: err.Error(),
 		})
 		return
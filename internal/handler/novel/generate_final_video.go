@@ -1,10 +1,16 @@
 package novel
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	"lemon/internal/pkg/noveltools"
+	novelservice "lemon/internal/service/novel"
 )
 
 // GenerateFinalVideoRequest 生成最终视频请求
@@ -14,19 +20,32 @@ type GenerateFinalVideoRequest struct {
 
 // GenerateFinalVideoResponseData 生成最终视频响应数据
 type GenerateFinalVideoResponseData struct {
-	VideoID   string `json:"video_id"`   // 生成的最终视频ID
-	ChapterID string `json:"chapter_id"` // 章节ID
+	VideoID    string `json:"video_id"`    // 生成的最终视频ID
+	ChapterID  string `json:"chapter_id"`  // 章节ID
+	ExportTier string `json:"export_tier"` // 导出档位：preview, licensed
+
+	RenderBreakdown *novel.RenderBreakdown `json:"render_breakdown,omitempty"` // 章节渲染的耗时与费用明细（各阶段耗时、provider 调用次数、传输字节数、费用）
 }
 
 // GenerateFinalVideo 生成章节的最终完整视频
 // @Summary      生成章节的最终完整视频
-// @Description  拼接所有 narration 视频，添加 finish.mp4，生成章节的最终完整视频。需要确保所有 narration 视频已完成（status=completed）。
+// @Description  拼接所有 narration 视频，添加 finish.mp4，生成章节的最终完整视频。需要确保所有 narration 视频已完成（status=completed），且片段按镜头序号连续覆盖（合并片段通过 sequence_end 引用成员镜头），存在重叠或缺失时拒绝拼接。
+// @Description  tier=preview（默认）导出带水印的预览版（渲染分辨率缩小到 720p）；tier=licensed 导出无水印的授权版（使用生成参数中的渲染分辨率），要求章节已审核通过，并为 user_id（默认章节所属用户）记录授权。
+// @Description  响应中的 render_breakdown 为章节渲染的耗时与费用明细（每个阶段取最近一次成功的执行），同时保存到最终视频记录上。
+// @Description  最终视频按 narration 视频的目标平台预设处理响度（两遍 loudnorm）、真峰值、码率上限、像素格式和 faststart，校验报告保存在视频记录的 compliance 字段（视频列表接口返回）。
+// @Description  同时生成无障碍输出：按解说配音生成带时间的纯文本文字稿（transcript_resource_id）；audio_description=true（默认按 AUDIO_DESCRIPTION 配置）时为只有画面的时段按镜头画面描述生成口述影像音轨（audio_description_resource_id，与最终视频等长的独立 AAC 音轨）和 WebVTT 描述轨（description_vtt_resource_id）。无障碍输出记录在视频记录和流水线清单的 accessibility 中，生成失败不影响最终视频。
 // @Tags         视频生成
 // @Accept       json
 // @Produce      json
-// @Param        chapter_id  path      string  true  "章节ID"
-// @Success      200         {object}  map[string]interface{}  "成功响应"  "{\"code\": 0, \"message\": \"最终视频生成成功\", \"data\": {\"video_id\": \"...\", \"chapter_id\": \"...\"}}"
+// @Param        chapter_id  path      string  true   "章节ID"
+// @Param        version     query     int     false  "narration 视频版本号（默认最新版本）"
+// @Param        tier        query     string  false  "导出档位：preview（默认）, licensed"
+// @Param        user_id     query     string  false  "被授权用户ID（仅 licensed 档位，默认章节所属用户）"
+// @Param        audio_description  query  bool  false  "是否生成口述影像音轨（默认按系统配置）"
+// @Success      200         {object}  map[string]interface{}  "成功响应"  "{\"code\": 0, \"message\": \"最终视频生成成功\", \"data\": {\"video_id\": \"...\", \"chapter_id\": \"...\", \"export_tier\": \"preview\"}}"
 // @Failure      400         {object}  ErrorResponse  "请求参数错误（如没有找到 narration 视频）"
+// @Failure      403         {object}  ErrorResponse  "章节未审核通过，不允许导出授权版"
+// @Failure      409         {object}  ErrorResponse  "narration 视频片段的镜头序号重叠或缺失"
 // @Failure      500         {object}  ErrorResponse  "服务器内部错误"
 // @Router       /api/v1/novels/chapters/{chapter_id}/videos/final [post]
 func (h *Handler) GenerateFinalVideo(c *gin.Context) {
@@ -58,14 +77,45 @@ func (h *Handler) GenerateFinalVideo(c *gin.Context) {
 		version = v
 	}
 
+	// 导出档位：默认预览版
+	tier := novel.ExportTier(c.DefaultQuery("tier", string(novel.ExportTierPreview)))
+	if !tier.IsValid() {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40004,
+			Message: "Invalid tier",
+			Detail:  "tier must be one of: preview, licensed",
+		})
+		return
+	}
+
+	// 可选：覆盖是否生成口述影像音轨
+	if raw := c.Query("audio_description"); raw != "" {
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40005,
+				Message: "Invalid audio_description",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		ctx = ctxutil.WithAudioDescription(ctx, enabled)
+	}
+
 	// 调用Service层
-	videoID, err := h.novelService.GenerateFinalVideoForChapterWithVersion(ctx, req.ChapterID, version)
+	videoID, err := h.novelService.GenerateFinalVideoForChapterWithTier(ctx, req.ChapterID, version, tier, c.Query("user_id"))
 	if err != nil {
 		code := http.StatusInternalServerError
 		errorCode := 50001
 
 		// 根据错误类型设置错误码
 		switch {
+		case errors.Is(err, novelservice.ErrChapterNotApproved):
+			code = http.StatusForbidden
+			errorCode = 40301
+		case errors.Is(err, noveltools.ErrInvalidVideoComposition):
+			code = http.StatusConflict
+			errorCode = 40901
 		case err.Error() == "no narration videos found for chapter":
 			code = http.StatusBadRequest
 			errorCode = 40002
@@ -81,12 +131,20 @@ func (h *Handler) GenerateFinalVideo(c *gin.Context) {
 		return
 	}
 
+	// 渲染明细查询失败不影响已经生成的视频
+	var breakdown *novel.RenderBreakdown
+	if b, err := h.novelService.GetRenderBreakdown(ctx, req.ChapterID); err == nil {
+		breakdown = b
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"code":    0,
 		"message": "最终视频生成成功",
 		"data": GenerateFinalVideoResponseData{
-			VideoID:   videoID,
-			ChapterID: req.ChapterID,
+			VideoID:         videoID,
+			ChapterID:       req.ChapterID,
+			ExportTier:      string(tier),
+			RenderBreakdown: breakdown,
 		},
 	})
 }
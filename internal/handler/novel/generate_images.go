@@ -1,32 +1,43 @@
 package novel
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	novelservice "lemon/internal/service/novel"
 )
 
 // GenerateImagesRequest 生成图片请求
 type GenerateImagesRequest struct {
 	NarrationID string `json:"narration_id" uri:"narration_id" binding:"required"` // 解说ID（必填）
+	Resume      bool   `form:"resume"`                                             // 续接上次生成（跳过最新版本中已完成的序号）
 }
 
 // GenerateImagesResponseData 生成图片响应数据
 type GenerateImagesResponseData struct {
-	ImageIDs    []string `json:"image_ids"`    // 生成的图片ID列表
-	Count       int      `json:"count"`         // 生成的图片数量
-	NarrationID string   `json:"narration_id"` // 解说ID
+	ImageIDs    []string                           `json:"image_ids"`    // 生成的图片ID列表
+	Count       int                                `json:"count"`        // 生成的图片数量
+	NarrationID string                             `json:"narration_id"` // 解说ID
+	Version     int                                `json:"version"`      // 图片版本号
+	Resumed     bool                               `json:"resumed"`      // 是否续接了已有版本
+	Failed      int                                `json:"failed"`       // 生成失败的图片数量
+	Skipped     int                                `json:"skipped"`      // 续接时跳过的图片数量
+	Items       []novelservice.ImageGenerationItem `json:"items"`        // 每个镜头的生成结果
 }
 
 // GenerateImages 为章节解说生成所有章节图片
 // @Summary      生成章节图片
-// @Description  为章节解说生成所有章节图片，使用图片生成服务（Ark API）生成图片。图片生成是异步的，提交任务后需要通过状态查询接口轮询进度。
+// @Description  为章节解说生成所有章节图片。图片提供者支持批量提交（如 ComfyUI）时分批提交到队列后统一等待，否则按 IMAGE_GENERATION_CONCURRENCY 并发生成。单张图片失败不影响其他图片，items 中返回每个镜头的结果；resume=true 时沿用最新图片版本，只重新生成失败或缺失的序号
 // @Tags         图片生成
 // @Accept       json
 // @Produce      json
 // @Param        narration_id  path      string  true  "解说ID"
+// @Param        resume        query     bool    false  "续接上次生成"
 // @Success      200           {object}  map[string]interface{}  "成功响应"  "{\"code\": 0, \"message\": \"图片生成任务已提交\", \"data\": {\"image_ids\": [\"...\"], \"count\": 1, \"narration_id\": \"...\"}}"
 // @Failure      400           {object}  ErrorResponse  "请求参数错误"
+// @Failure      402           {object}  ErrorResponse  "小说花费已达到预算上限"
 // @Failure      500           {object}  ErrorResponse  "服务器内部错误"
 // @Router       /api/v1/narrations/{narration_id}/images [post]
 func (h *Handler) GenerateImages(c *gin.Context) {
@@ -39,17 +50,30 @@ func (h *Handler) GenerateImages(c *gin.Context) {
 		})
 		return
 	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "Invalid resume",
+			Detail:  err.Error(),
+		})
+		return
+	}
 
 	ctx := c.Request.Context()
 
 	// 调用Service层
-	imageIDs, err := h.novelService.GenerateImagesForNarration(ctx, req.NarrationID)
+	result, err := h.novelService.GenerateImagesForNarrationWithOptions(ctx, req.NarrationID, novelservice.ImageGenerationOptions{
+		Resume: req.Resume,
+	})
 	if err != nil {
 		code := http.StatusInternalServerError
 		errorCode := 50001
 
 		// 根据错误类型设置错误码
 		switch {
+		case errors.Is(err, novelservice.ErrBudgetExceeded):
+			code = http.StatusPaymentRequired
+			errorCode = 40201
 		case err.Error() == "find narration":
 			code = http.StatusNotFound
 			errorCode = 40401
@@ -69,9 +93,14 @@ func (h *Handler) GenerateImages(c *gin.Context) {
 		"code":    0,
 		"message": "图片生成任务已提交",
 		"data": GenerateImagesResponseData{
-			ImageIDs:    imageIDs,
-			Count:       len(imageIDs),
+			ImageIDs:    result.ImageIDs,
+			Count:       len(result.ImageIDs),
 			NarrationID: req.NarrationID,
+			Version:     result.Version,
+			Resumed:     result.Resumed,
+			Failed:      result.Failed,
+			Skipped:     result.Skipped,
+			Items:       result.Items,
 		},
 	})
 }
@@ -1,9 +1,13 @@
 package novel
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"lemon/internal/model/novel"
+	novelservice "lemon/internal/service/novel"
 )
 
 // GenerateNarrationRequest 生成解说请求
@@ -28,6 +32,7 @@ type GenerateNarrationResponseData struct {
 // @Param        chapter_id  path      string  true  "章节ID"
 // @Success      200         {object}  map[string]interface{}  "成功响应"  "{\"code\": 0, \"message\": \"解说生成成功\", \"data\": {\"narration_text\": \"...\", \"chapter_id\": \"...\"}}"
 // @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      402         {object}  ErrorResponse  "小说花费已达到预算上限"
 // @Failure      500         {object}  ErrorResponse  "服务器内部错误"
 // @Router       /api/v1/novels/chapters/{chapter_id}/narration [post]
 func (h *Handler) GenerateNarration(c *gin.Context) {
@@ -51,6 +56,9 @@ func (h *Handler) GenerateNarration(c *gin.Context) {
 
 		// 根据错误类型设置错误码
 		switch {
+		case errors.Is(err, novelservice.ErrBudgetExceeded):
+			code = http.StatusPaymentRequired
+			errorCode = 40201
 		case err.Error() == "generated narrationText is empty":
 			code = http.StatusBadRequest
 			errorCode = 40002
@@ -85,19 +93,21 @@ type GenerateNarrationsForAllChaptersRequest struct {
 
 // GenerateNarrationsForAllChaptersResponseData 为所有章节生成解说响应数据
 type GenerateNarrationsForAllChaptersResponseData struct {
-	NovelID string `json:"novel_id"` // 小说ID
-	Message string `json:"message"`  // 处理结果消息
+	NovelID string       `json:"novel_id"` // 小说ID
+	Message string       `json:"message"`  // 处理结果消息
+	Jobs    []*novel.Job `json:"jobs"`     // 提交的解说生成任务（每章一个，可通过 GET /jobs/{job_id} 查询进度）
 }
 
-// GenerateNarrationsForAllChapters 并发地为所有章节生成解说文本
+// GenerateNarrationsForAllChapters 为所有章节提交解说生成任务
 // @Summary      为所有章节生成解说
-// @Description  并发地为小说的所有章节生成解说文本。这是一个异步操作，会为每个章节生成解说并保存到数据库。
+// @Description  为小说的每个章节提交一个解说生成任务并立即返回。任务持久化到任务队列，由工作协程并发执行（服务重启后继续执行），可通过 GET /jobs/{job_id} 查询进度、取消或重试。
 // @Tags         解说管理
 // @Accept       json
 // @Produce      json
 // @Param        novel_id  path      string  true  "小说ID"
 // @Success      200       {object}  map[string]interface{}  "成功响应"  "{\"code\": 0, \"message\": \"所有章节解说生成任务已提交\", \"data\": {\"novel_id\": \"...\", \"message\": \"...\"}}"
 // @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      402       {object}  ErrorResponse  "小说花费已达到预算上限"
 // @Failure      500       {object}  ErrorResponse  "服务器内部错误"
 // @Router       /api/v1/novels/{novel_id}/chapters/narration [post]
 func (h *Handler) GenerateNarrationsForAllChapters(c *gin.Context) {
@@ -114,13 +124,16 @@ func (h *Handler) GenerateNarrationsForAllChapters(c *gin.Context) {
 	ctx := c.Request.Context()
 
 	// 调用Service层
-	err := h.novelService.GenerateNarrationsForAllChapters(ctx, req.NovelID)
+	jobs, err := h.novelService.GenerateNarrationsForAllChapters(ctx, req.NovelID)
 	if err != nil {
 		code := http.StatusInternalServerError
 		errorCode := 50001
 
 		// 根据错误类型设置错误码
 		switch {
+		case errors.Is(err, novelservice.ErrBudgetExceeded):
+			code = http.StatusPaymentRequired
+			errorCode = 40201
 		case err.Error() == "no chapters found":
 			code = http.StatusBadRequest
 			errorCode = 40002
@@ -139,6 +152,7 @@ func (h *Handler) GenerateNarrationsForAllChapters(c *gin.Context) {
 		"data": GenerateNarrationsForAllChaptersResponseData{
 			NovelID: req.NovelID,
 			Message: "所有章节解说生成任务已提交",
+			Jobs:    jobs,
 		},
 	})
 }
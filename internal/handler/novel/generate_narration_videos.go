@@ -1,9 +1,15 @@
 package novel
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	"lemon/internal/pkg/noveltools"
+	novelservice "lemon/internal/service/novel"
 )
 
 // GenerateNarrationVideosRequest 生成 narration 视频请求
@@ -11,22 +17,46 @@ type GenerateNarrationVideosRequest struct {
 	ChapterID string `json:"chapter_id" uri:"chapter_id" binding:"required"` // 章节ID（必填）
 }
 
+// GenerateNarrationVideosBody 生成 narration 视频的可选请求体
+type GenerateNarrationVideosBody struct {
+	SubtitleStyle *novel.SubtitleStyle `json:"subtitle_style"` // 烧录字幕的样式（字体、字号、文字/描边颜色、边距、对齐方式），只覆盖设置了的字段
+}
+
+const (
+	// idempotencyKeyHeader 幂等键请求头
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotentReplayedHeader 重复请求返回已有生成版本时设置的响应头
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
 // GenerateNarrationVideosResponseData 生成 narration 视频响应数据
 type GenerateNarrationVideosResponseData struct {
-	VideoIDs  []string `json:"video_ids"`  // 生成的视频ID列表
-	Count     int      `json:"count"`      // 生成的视频数量
-	ChapterID string   `json:"chapter_id"` // 章节ID
+	VideoIDs  []string `json:"video_ids"`          // 生成的视频ID列表（其他相同请求正在生成时为空）
+	Count     int      `json:"count"`              // 生成的视频数量
+	ChapterID string   `json:"chapter_id"`         // 章节ID
+	Platform  string   `json:"platform,omitempty"` // 目标发布平台（未指定时使用小说的默认平台）
+	Version   int      `json:"version"`            // 视频版本号
+	Status    string   `json:"status"`             // completed（已生成）或 processing（相同请求正在生成）
+	RequestID string   `json:"request_id"`         // 生成请求ID
+	Replayed  bool     `json:"replayed"`           // 是否为重复请求（返回的是已有的生成版本，没有重新生成）
 }
 
 // GenerateNarrationVideos 为章节生成所有 narration 视频
 // @Summary      生成章节的 narration 视频
 // @Description  为章节生成所有 narration 视频，所有分镜都单独生成视频，使用图生视频方式（Ark API 或 FFmpeg）。视频生成是异步的，提交任务后需要通过状态查询接口轮询进度。
+// @Description  请求是幂等的：同一用户相同 Idempotency-Key 的重复请求（24 小时内）返回已有的生成版本（status 为 processing 或 completed，响应头 Idempotent-Replayed: true），不重复生成；同一用户的幂等键用于参数不同的请求时返回 409，不同用户的幂等键互不影响。
+// @Description  未提供 Idempotency-Key 时，只合并章节、解说版本、图片/音频/字幕版本、镜头内容和参数都相同且正在生成的请求；已生成完成后再次请求会重新生成。之前的请求失败时重新生成
 // @Tags         视频生成
 // @Accept       json
 // @Produce      json
-// @Param        chapter_id  path      string  true  "章节ID"
+// @Param        chapter_id  path      string  true   "章节ID"
+// @Param        Idempotency-Key  header    string  false  "幂等键（最长 255 个字符），重复请求返回已有的生成版本"
+// @Param        platform    query     string  false  "目标发布平台：default, douyin, tiktok, kuaishou, youtube, bilibili，不传时使用小说渲染设置中的默认平台（未设置时为 default）。字幕按平台安全区排版，避开平台 UI；最终视频按平台规范处理响度和格式"
+// @Param        request     body      GenerateNarrationVideosBody  false  "烧录字幕样式：烧录前改写字幕文件的 [V4+ Styles]（颜色为 #RRGGBB，alignment 为 ASS 小键盘布局 1-9），样式记录在生成的视频版本上"
 // @Success      200         {object}  map[string]interface{}  "成功响应"  "{\"code\": 0, \"message\": \"视频生成任务已提交\", \"data\": {\"video_ids\": [\"...\"], \"count\": 1, \"chapter_id\": \"...\"}}"
 // @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      402         {object}  ErrorResponse  "小说花费已达到预算上限"
+// @Failure      409         {object}  ErrorResponse  "幂等键已用于参数不同的请求"
 // @Failure      500         {object}  ErrorResponse  "服务器内部错误"
 // @Router       /api/v1/novels/chapters/{chapter_id}/videos/narration [post]
 func (h *Handler) GenerateNarrationVideos(c *gin.Context) {
@@ -40,16 +70,64 @@ func (h *Handler) GenerateNarrationVideos(c *gin.Context) {
 		return
 	}
 
+	// 目标发布平台：不传时由 Service 层使用小说的默认平台
+	platform := novel.TargetPlatform(c.Query("platform"))
+	if platform != "" && !platform.IsValid() {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40004,
+			Message: "Invalid platform",
+			Detail:  "platform must be one of: default, douyin, tiktok, kuaishou, youtube, bilibili",
+		})
+		return
+	}
+
+	// 可选：烧录字幕样式
+	var body GenerateNarrationVideosBody
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40002,
+				Message: "Invalid request body",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+	if err := noveltools.ValidateSubtitleStyle(body.SubtitleStyle); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40005,
+			Message: "Invalid subtitle_style",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
 	ctx := c.Request.Context()
+	if body.SubtitleStyle != nil {
+		ctx = ctxutil.WithSubtitleStyle(ctx, body.SubtitleStyle)
+	}
 
-	// 调用Service层
-	videoIDs, err := h.novelService.GenerateNarrationVideosForChapter(ctx, req.ChapterID)
+	// 调用Service层（幂等：重复请求返回已有的生成版本）
+	result, err := h.novelService.GenerateNarrationVideosIdempotent(ctx, &novelservice.GenerateNarrationVideosIdempotentRequest{
+		ChapterID:      req.ChapterID,
+		Platform:       platform,
+		IdempotencyKey: c.GetHeader(idempotencyKeyHeader),
+	})
 	if err != nil {
 		code := http.StatusInternalServerError
 		errorCode := 50001
 
 		// 根据错误类型设置错误码
 		switch {
+		case errors.Is(err, novelservice.ErrBudgetExceeded):
+			code = http.StatusPaymentRequired
+			errorCode = 40201
+		case errors.Is(err, novelservice.ErrInvalidIdempotencyKey):
+			code = http.StatusBadRequest
+			errorCode = 40002
+		case errors.Is(err, novelservice.ErrIdempotencyKeyReused):
+			code = http.StatusConflict
+			errorCode = 40901
 		case err.Error() == "narration content is empty":
 			code = http.StatusBadRequest
 			errorCode = 40002
@@ -65,13 +143,26 @@ func (h *Handler) GenerateNarrationVideos(c *gin.Context) {
 		return
 	}
 
+	message := "视频生成任务已提交"
+	if result.Replayed {
+		c.Header(idempotentReplayedHeader, "true")
+		message = "重复请求，返回已有的生成版本"
+		if result.Status == novel.GenerationRequestStatusProcessing {
+			message = "相同的视频生成请求正在处理中"
+		}
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"code":    0,
-		"message": "视频生成任务已提交",
+		"message": message,
 		"data": GenerateNarrationVideosResponseData{
-			VideoIDs:  videoIDs,
-			Count:     len(videoIDs),
+			VideoIDs:  result.VideoIDs,
+			Count:     len(result.VideoIDs),
 			ChapterID: req.ChapterID,
+			Platform:  string(platform),
+			Version:   result.Version,
+			Status:    string(result.Status),
+			RequestID: result.RequestID,
+			Replayed:  result.Replayed,
 		},
 	})
 }
@@ -0,0 +1,239 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	"lemon/internal/pkg/noveltools"
+)
+
+// SetGenerationSettingsRequest 设置生成参数请求（整体替换，不传或为 0 的字段沿用上一层）
+type SetGenerationSettingsRequest struct {
+	UserID         string              `json:"user_id"`          // 用户ID（仅用户设置使用，已登录时使用当前用户）
+	VideoWidth     int                 `json:"video_width"`      // 视频宽度（240~3840 的偶数）
+	VideoHeight    int                 `json:"video_height"`     // 视频高度（240~3840 的偶数）
+	VideoFPS       int                 `json:"video_fps"`        // 视频帧率（1~60）
+	VoiceType      string              `json:"voice_type"`       // TTS 音色
+	SpeedRatio     float64             `json:"speed_ratio"`      // TTS 语速（0.5~2.0）
+	ImageSteps     int                 `json:"image_steps"`      // 图片采样步数（1~150，支持的图片提供者生效）
+	SegmentGap     float64             `json:"segment_gap"`      // 解说片段之间的停顿（0~5 秒）
+	SceneGap       float64             `json:"scene_gap"`        // 场景切换处的停顿（0~5 秒，代替片段停顿）
+	ClipMerge      novel.ClipMergeMode `json:"clip_merge"`       // 镜头视频合并规则（none 不合并、short 合并短镜头、scene 按场景合并）
+	ClipMergeUnder float64             `json:"clip_merge_under"` // short 规则的时长阈值（0~20 秒，默认 3 秒）
+}
+
+// settings 转换为生成参数
+func (r *SetGenerationSettingsRequest) settings() *novel.GenerationSettings {
+	return &novel.GenerationSettings{
+		VideoWidth:     r.VideoWidth,
+		VideoHeight:    r.VideoHeight,
+		VideoFPS:       r.VideoFPS,
+		VoiceType:      r.VoiceType,
+		SpeedRatio:     r.SpeedRatio,
+		ImageSteps:     r.ImageSteps,
+		SegmentGap:     r.SegmentGap,
+		SceneGap:       r.SceneGap,
+		ClipMerge:      r.ClipMerge,
+		ClipMergeUnder: r.ClipMergeUnder,
+	}
+}
+
+// GetUserGenerationSettings 查询用户级生成参数
+// @Summary      查询用户生成参数
+// @Description  查询用户级生成参数（作用于该用户所有小说，小说设置和单次请求可以覆盖），未设置时返回空设置
+// @Tags         生成参数
+// @Accept       json
+// @Produce      json
+// @Param        user_id  query     string  false  "用户ID（已登录时使用当前用户）"
+// @Success      200      {object}  map[string]interface{}  "成功响应"
+// @Failure      400      {object}  ErrorResponse  "请求参数错误"
+// @Failure      500      {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/users/generation-settings [get]
+func (h *Handler) GetUserGenerationSettings(c *gin.Context) {
+	userID := c.Query("user_id")
+	if currentUserID, ok := ctxutil.GetUserID(c.Request.Context()); ok {
+		userID = currentUserID
+	}
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "user_id is required",
+		})
+		return
+	}
+
+	settings, err := h.novelService.GetUserGenerationSettings(c.Request.Context(), userID)
+	if err != nil {
+		respondGenerationSettingsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"user_id":    userID,
+			"generation": settings,
+		},
+	})
+}
+
+// SetUserGenerationSettings 设置用户级生成参数
+// @Summary      设置用户生成参数
+// @Description  整体替换用户级生成参数（视频分辨率和帧率、配音音色和语速、图片采样步数），不传或为 0 的字段沿用系统默认值。只影响之后的生成任务
+// @Tags         生成参数
+// @Accept       json
+// @Produce      json
+// @Param        request  body      SetGenerationSettingsRequest  true  "生成参数"
+// @Success      200      {object}  map[string]interface{}  "成功响应"
+// @Failure      400      {object}  ErrorResponse  "请求参数错误或参数超出范围"
+// @Failure      500      {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/users/generation-settings [put]
+func (h *Handler) SetUserGenerationSettings(c *gin.Context) {
+	var req SetGenerationSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	userID := req.UserID
+	if currentUserID, ok := ctxutil.GetUserID(c.Request.Context()); ok {
+		userID = currentUserID
+	}
+
+	settings, err := h.novelService.SetUserGenerationSettings(c.Request.Context(), userID, req.settings())
+	if err != nil {
+		respondGenerationSettingsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "生成参数已更新",
+		"data":    settings,
+	})
+}
+
+// GetNovelGenerationSettings 查询小说的生成参数
+// @Summary      查询小说生成参数
+// @Description  查询小说各层生成参数（system 系统默认、user 所有者设置、novel 小说设置、request 本次请求覆盖）和合并后的最终取值，effective.sources 标明每个参数取自哪一层。可以带上与生成接口相同的覆盖参数预览效果
+// @Tags         生成参数
+// @Accept       json
+// @Produce      json
+// @Param        novel_id          path      string   true   "小说ID"
+// @Param        video_width       query     int      false  "覆盖视频宽度"
+// @Param        video_height      query     int      false  "覆盖视频高度"
+// @Param        video_fps         query     int      false  "覆盖视频帧率"
+// @Param        voice_type        query     string   false  "覆盖 TTS 音色"
+// @Param        speed_ratio       query     number   false  "覆盖 TTS 语速"
+// @Param        image_steps       query     int      false  "覆盖图片采样步数"
+// @Param        segment_gap       query     number   false  "覆盖解说片段之间的停顿（秒）"
+// @Param        scene_gap         query     number   false  "覆盖场景切换处的停顿（秒）"
+// @Param        clip_merge        query     string   false  "覆盖镜头视频合并规则（none/short/scene）"
+// @Param        clip_merge_under  query     number   false  "覆盖短镜头合并的时长阈值（秒）"
+// @Success      200               {object}  map[string]interface{}  "成功响应"
+// @Failure      400               {object}  ErrorResponse  "请求参数错误"
+// @Failure      404               {object}  ErrorResponse  "小说不存在"
+// @Failure      500               {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/generation-settings [get]
+func (h *Handler) GetNovelGenerationSettings(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	view, err := h.novelService.GetGenerationSettings(c.Request.Context(), novelID)
+	if err != nil {
+		respondGenerationSettingsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    view,
+	})
+}
+
+// SetNovelGenerationSettings 设置小说的生成参数
+// @Summary      设置小说生成参数
+// @Description  整体替换小说级生成参数，不传或为 0 的字段沿用所有者的用户设置和系统默认值。只影响之后的生成任务；单次生成请求可以用查询参数 video_width、video_height、video_fps、voice_type、speed_ratio、image_steps、segment_gap、scene_gap、clip_merge、clip_merge_under 临时覆盖
+// @Tags         生成参数
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string                        true  "小说ID"
+// @Param        request   body      SetGenerationSettingsRequest  true  "生成参数"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误或参数超出范围"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/generation-settings [put]
+func (h *Handler) SetNovelGenerationSettings(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	var req SetGenerationSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	settings := req.settings()
+	if err := h.novelService.SetNovelGenerationSettings(c.Request.Context(), novelID, settings); err != nil {
+		respondGenerationSettingsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "生成参数已更新",
+		"data": gin.H{
+			"novel_id":   novelID,
+			"generation": settings,
+		},
+	})
+}
+
+// respondGenerationSettingsError 按错误类型返回生成参数接口的错误响应
+func respondGenerationSettingsError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+
+	// 根据错误类型设置错误码
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, noveltools.ErrInvalidGenerationSettings):
+		code = http.StatusBadRequest
+		errorCode = 40003
+	}
+
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
@@ -15,19 +15,24 @@ type GetChaptersRequest struct {
 type GetChaptersResponseData struct {
 	NovelID  string       `json:"novel_id"`  // 小说ID
 	Chapters []ChapterInfo `json:"chapters"`  // 章节列表
-	Count    int          `json:"count"`     // 章节数量
+	Count    int          `json:"count"`     // 本页章节数量
+	ListPageInfo
 }
 
 // GetChapters 获取小说的所有章节
 // @Summary      获取章节列表
-// @Description  根据小说ID获取该小说的所有章节列表，按序号排序
+// @Description  根据小说ID获取该小说的章节列表，默认按序号排序、返回全部章节；指定 page/page_size 时分页返回，total 为章节总数
 // @Tags         章节管理
 // @Accept       json
 // @Produce      json
-// @Param        novel_id  path      string  true  "小说ID"
-// @Success      200       {object}  map[string]interface{}  "成功响应"  "{\"code\": 0, \"message\": \"success\", \"data\": {\"novel_id\": \"...\", \"chapters\": [...], \"count\": 10}}"
-// @Failure      400       {object}  ErrorResponse  "请求参数错误"
-// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Param        novel_id   path      string  true   "小说ID"
+// @Param        page       query     int     false  "页码，从 1 开始"
+// @Param        page_size  query     int     false  "每页数量（最大 100，只指定 page 时为 20）"
+// @Param        sort       query     string  false  "排序字段：sequence, created_at"
+// @Param        order      query     string  false  "排序顺序：asc, desc"
+// @Success      200        {object}  map[string]interface{}  "成功响应"  "{\"code\": 0, \"message\": \"success\", \"data\": {\"novel_id\": \"...\", \"chapters\": [...], \"count\": 10}}"
+// @Failure      400        {object}  ErrorResponse  "请求参数错误"
+// @Failure      500        {object}  ErrorResponse  "服务器内部错误"
 // @Router       /api/v1/novels/{novel_id}/chapters [get]
 func (h *Handler) GetChapters(c *gin.Context) {
 	var req GetChaptersRequest
@@ -40,15 +45,17 @@ func (h *Handler) GetChapters(c *gin.Context) {
 		return
 	}
 
+	q, ok := bindListQuery(c)
+	if !ok {
+		return
+	}
+
 	ctx := c.Request.Context()
 
 	// 调用Service层
-	chapters, err := h.novelService.GetChapters(ctx, req.NovelID)
+	page, err := h.novelService.QueryChapters(ctx, req.NovelID, q)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Code:    50001,
-			Message: err.Error(),
-		})
+		respondListError(c, err)
 		return
 	}
 
@@ -56,9 +63,10 @@ func (h *Handler) GetChapters(c *gin.Context) {
 		"code":    0,
 		"message": "success",
 		"data": GetChaptersResponseData{
-			NovelID:  req.NovelID,
-			Chapters: toChapterInfoList(chapters),
-			Count:    len(chapters),
+			NovelID:      req.NovelID,
+			Chapters:     toChapterInfoList(page.Items),
+			Count:        len(page.Items),
+			ListPageInfo: newListPageInfo(q, page.Total),
 		},
 	})
 }
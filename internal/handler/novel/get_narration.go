@@ -11,33 +11,59 @@ import (
 
 // NarrationInfo 解说信息 DTO
 type NarrationInfo struct {
-	ID        string `json:"id"`         // 解说ID
-	ChapterID string `json:"chapter_id"`  // 章节ID
-	UserID    string `json:"user_id"`    // 用户ID
-	Prompt    string `json:"prompt"`     // 生成提示词
-	Version   int    `json:"version"`    // 版本号
-	Status    string `json:"status"`     // 状态：pending, completed, failed
-	CreatedAt string `json:"created_at"` // 创建时间
-	UpdatedAt string `json:"updated_at"` // 更新时间
+	ID              string `json:"id"`               // 解说ID
+	ChapterID       string `json:"chapter_id"`       // 章节ID
+	UserID          string `json:"user_id"`          // 用户ID
+	Prompt          string `json:"prompt"`           // 生成提示词
+	Version         int    `json:"version"`          // 版本号
+	Status          string `json:"status"`           // 状态：pending, completed, failed
+	CompletedScenes int    `json:"completed_scenes"` // 已生成的场景数（生成中时可据此查看部分结果）
+	CreatedAt       string `json:"created_at"`       // 创建时间
+	UpdatedAt       string `json:"updated_at"`       // 更新时间
 }
 
 // toNarrationInfo 将 Narration 实体转换为 NarrationInfo DTO
 func toNarrationInfo(narrationEntity *novel.Narration) NarrationInfo {
 	return NarrationInfo{
-		ID:        narrationEntity.ID,
-		ChapterID: narrationEntity.ChapterID,
-		UserID:    narrationEntity.UserID,
-		Prompt:    narrationEntity.Prompt,
-		Version:   narrationEntity.Version,
-		Status:    string(narrationEntity.Status),
-		CreatedAt: narrationEntity.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: narrationEntity.UpdatedAt.Format(time.RFC3339),
+		ID:              narrationEntity.ID,
+		ChapterID:       narrationEntity.ChapterID,
+		UserID:          narrationEntity.UserID,
+		Prompt:          narrationEntity.Prompt,
+		Version:         narrationEntity.Version,
+		Status:          string(narrationEntity.Status),
+		CompletedScenes: narrationEntity.CompletedScenes,
+		CreatedAt:       narrationEntity.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       narrationEntity.UpdatedAt.Format(time.RFC3339),
 	}
 }
 
+// NarrationDetail 解说详情 DTO（包含已生成的场景）
+// 解说仍在生成中（status=pending）时，Scenes 只包含已完成的场景
+type NarrationDetail struct {
+	NarrationInfo
+	Scenes []SceneInfo `json:"scenes"` // 已生成的场景列表（按 sequence 排序）
+}
+
+// toNarrationDetail 查询解说已生成的场景并组装 NarrationDetail
+func (h *Handler) toNarrationDetail(c *gin.Context, narrationEntity *novel.Narration) (NarrationDetail, error) {
+	scenes, err := h.novelService.GetScenesByNarrationID(c.Request.Context(), narrationEntity.ID)
+	if err != nil {
+		return NarrationDetail{}, err
+	}
+
+	infos := make([]SceneInfo, 0, len(scenes))
+	for _, s := range scenes {
+		infos = append(infos, toSceneInfo(s))
+	}
+	return NarrationDetail{
+		NarrationInfo: toNarrationInfo(narrationEntity),
+		Scenes:        infos,
+	}, nil
+}
+
 // GetNarration 根据章节ID获取章节解说（返回最新版本）
 // @Summary      获取章节解说
-// @Description  根据章节ID获取章节解说，返回最新版本的解说信息。
+// @Description  根据章节ID获取章节解说，返回最新版本的解说信息及已生成的场景。解说生成中时返回部分场景，completed_scenes 为已完成的场景数。
 // @Tags         解说管理
 // @Accept       json
 // @Produce      json
@@ -78,16 +104,26 @@ func (h *Handler) GetNarration(c *gin.Context) {
 		return
 	}
 
+	detail, err := h.toNarrationDetail(c, narration)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50001,
+			Message: "获取场景列表失败",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"code":    0,
 		"message": "获取成功",
-		"data":    toNarrationInfo(narration),
+		"data":    detail,
 	})
 }
 
 // GetNarrationByVersion 根据章节ID和版本号获取章节解说
 // @Summary      获取指定版本的章节解说
-// @Description  根据章节ID和版本号获取指定版本的章节解说信息。
+// @Description  根据章节ID和版本号获取指定版本的章节解说信息及已生成的场景。
 // @Tags         解说管理
 // @Accept       json
 // @Produce      json
@@ -140,10 +176,20 @@ func (h *Handler) GetNarrationByVersion(c *gin.Context) {
 		return
 	}
 
+	detail, err := h.toNarrationDetail(c, narration)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50001,
+			Message: "获取场景列表失败",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"code":    0,
 		"message": "获取成功",
-		"data":    toNarrationInfo(narration),
+		"data":    detail,
 	})
 }
 
@@ -0,0 +1,174 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/pkg/ctxutil"
+	novelservice "lemon/internal/service/novel"
+)
+
+// ImportSubtitles 导入外部字幕文件
+// @Summary      导入外部字幕
+// @Description  上传专业制作的 SRT/ASS 字幕文件（UTF-8 编码，最大 2MB），转换为章节的新字幕版本。字幕时间轴按章节最新音频依次拼接后的时间理解，超过音频总时长时按比例压缩；每条字幕按中点归入所在的音频片段并重新计时，每个音频片段生成一个 ASS 文件（使用小说的字幕样式）。select=true 时导入后选中该版本，视频阶段使用导入的字幕；未选中时视频阶段仍使用最新生成的字幕
+// @Tags         字幕生成
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        chapter_id  path      string  true   "章节ID"
+// @Param        file        formData  file    true   "字幕文件（.srt 或 .ass）"
+// @Param        user_id     formData  string  false  "用户ID（为空时使用章节所有者）"
+// @Param        select      formData  bool    false  "导入后是否选中（默认 false）"
+// @Success      201         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误或字幕文件不合法"
+// @Failure      404         {object}  ErrorResponse  "章节或解说不存在"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/subtitles/import [post]
+func (h *Handler) ImportSubtitles(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid file",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	selected := false
+	if v := c.PostForm("select"); v != "" {
+		if selected, err = strconv.ParseBool(v); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40003,
+				Message: "select must be a boolean",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	fileReader, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40004,
+			Message: "Failed to open file",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	defer fileReader.Close()
+
+	ctx := c.Request.Context()
+	userID := c.PostForm("user_id")
+	if currentUserID, ok := ctxutil.GetUserID(ctx); ok {
+		userID = currentUserID
+	}
+
+	result, err := h.novelService.ImportSubtitles(ctx, &novelservice.ImportSubtitlesRequest{
+		ChapterID: chapterID,
+		UserID:    userID,
+		FileName:  file.Filename,
+		Data:      fileReader,
+		Select:    selected,
+	})
+	if err != nil {
+		respondSubtitleImportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    0,
+		"message": "字幕导入成功",
+		"data":    result,
+	})
+}
+
+// SelectSubtitleVersionRequest 选择字幕版本请求
+type SelectSubtitleVersionRequest struct {
+	Version *int `json:"version" binding:"required,min=0"` // 字幕版本号（0 表示恢复使用最新生成的字幕）
+}
+
+// SelectSubtitleVersion 选择视频阶段使用的字幕版本
+// @Summary      选择字幕版本
+// @Description  选择章节在视频阶段使用的字幕版本（通常是导入的外部字幕）。选中后生成 narration 视频和最终视频清单都使用该版本；version 为 0 时恢复使用最新生成的字幕。解说重新生成后选中的版本不再匹配时自动回退到最新生成的字幕
+// @Tags         字幕生成
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string                        true  "章节ID"
+// @Param        request     body      SelectSubtitleVersionRequest  true  "字幕版本"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  ErrorResponse  "章节或字幕版本不存在"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/subtitles/selection [put]
+func (h *Handler) SelectSubtitleVersion(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	var req SelectSubtitleVersionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	if err := h.novelService.SelectSubtitleVersion(c.Request.Context(), chapterID, *req.Version); err != nil {
+		respondSubtitleImportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"chapter_id":       chapterID,
+			"subtitle_version": *req.Version,
+		},
+	})
+}
+
+func respondSubtitleImportError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, novelservice.ErrSubtitleVersionNotFound):
+		code = http.StatusNotFound
+		errorCode = 40402
+	case errors.Is(err, novelservice.ErrInvalidSubtitleFile):
+		code = http.StatusBadRequest
+		errorCode = 40005
+	case errors.Is(err, novelservice.ErrSubtitleImportNoAudio):
+		code = http.StatusBadRequest
+		errorCode = 40006
+	}
+
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
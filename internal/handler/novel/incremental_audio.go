@@ -0,0 +1,69 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	novelservice "lemon/internal/service/novel"
+)
+
+// RegenerateEditedAudios 增量重新合成修改过的章节音频
+// @Summary      增量重新合成修改过的章节音频
+// @Description  修改镜头解说文本后，对比镜头当前的文本和最新音频版本，只重新合成文本修改过的片段（按镜头对应，镜头重排后仍能对应），与未修改的片段（共用音频文件）一起组成新的音频版本。
+// @Description  最新字幕是生成的字幕时同时生成新的字幕版本，只重新生成音频变化或片头/片尾位置变化的片段；最新视频版本中使用了旧音频片段的镜头视频（以及同一版本的最终视频）标记为过期（stale）。没有修改时不生成新版本（changed=false）。配音或发音词典变化需要完整重新生成音频
+// @Tags         音频生成
+// @Accept       json
+// @Produce      json
+// @Param        narration_id  path      string  true  "解说ID"
+// @Success      200           {object}  map[string]interface{}  "成功响应"
+// @Failure      400           {object}  ErrorResponse  "请求参数错误或还没有音频版本"
+// @Failure      402           {object}  ErrorResponse  "小说花费已达到预算上限"
+// @Failure      404           {object}  ErrorResponse  "解说不存在"
+// @Failure      500           {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/narrations/{narration_id}/audios/incremental [post]
+func (h *Handler) RegenerateEditedAudios(c *gin.Context) {
+	narrationID := c.Param("narration_id")
+	if narrationID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "narration_id is required",
+		})
+		return
+	}
+
+	result, err := h.novelService.RegenerateEditedAudios(c.Request.Context(), narrationID)
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		switch {
+		case errors.Is(err, novelservice.ErrBudgetExceeded):
+			code = http.StatusPaymentRequired
+			errorCode = 40201
+		case errors.Is(err, mongo.ErrNoDocuments):
+			code = http.StatusNotFound
+			errorCode = 40401
+		case errors.Is(err, novelservice.ErrNoBaseAudio):
+			code = http.StatusBadRequest
+			errorCode = 40003
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	message := "音频已增量重新合成"
+	if !result.Changed {
+		message = "解说文本没有修改，无需重新合成"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": message,
+		"data":    result,
+	})
+}
@@ -0,0 +1,258 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	novelservice "lemon/internal/service/novel"
+)
+
+// EnqueueJobRequest 提交生成任务请求
+type EnqueueJobRequest struct {
+	Type        novel.PipelineStage `json:"type" binding:"required"` // 任务类型：narration、audio、subtitle、image、narration_video、final_video
+	NarrationID string              `json:"narration_id"`            // 解说ID（audio、subtitle、image 任务可选，需属于该章节，为空时执行时使用章节当前的解说）
+	UserID      string              `json:"user_id"`                 // 提交人ID（已登录时使用当前用户）
+}
+
+// EnqueueJob 提交生成任务
+// @Summary      提交生成任务
+// @Description  把章节的生成任务（解说、音频、字幕、图片、解说视频、最终视频）提交到任务队列后立即返回。
+// @Description  任务持久化保存，由工作协程池领取执行（JOB_WORKERS，默认 4 个）；执行中的服务重启后任务会被重新执行。
+// @Description  失败时按 30 秒起翻倍的间隔自动重试（最多执行 JOB_MAX_ATTEMPTS 次，默认 3 次），维护模式下推迟执行
+// @Tags         任务队列
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string                  true  "章节ID"
+// @Param        request     body      EnqueueJobRequest       true  "任务请求"
+// @Success      201         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      403         {object}  ErrorResponse  "没有小说的编辑权限"
+// @Failure      404         {object}  ErrorResponse  "章节或解说不存在"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/jobs [post]
+func (h *Handler) EnqueueJob(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	var req EnqueueJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := req.UserID
+	if currentUserID, ok := ctxutil.GetUserID(ctx); ok {
+		userID = currentUserID
+	}
+
+	job, err := h.novelService.EnqueueJob(ctx, &novelservice.EnqueueJobRequest{
+		Type:        req.Type,
+		ChapterID:   chapterID,
+		NarrationID: req.NarrationID,
+		UserID:      userID,
+	})
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    0,
+		"message": "任务已提交",
+		"data":    job,
+	})
+}
+
+// ListJobs 查询生成任务
+// @Summary      查询生成任务列表
+// @Description  按小说或章节查询任务队列中的任务（按创建时间倒序），可按类型和状态过滤；小说的任务包含长视频拼接任务（novel_video）
+// @Tags         任务队列
+// @Accept       json
+// @Produce      json
+// @Param        novel_id    path      string  true   "小说ID（/novels/{novel_id}/jobs）"
+// @Param        chapter_id  path      string  true   "章节ID（/novels/chapters/{chapter_id}/jobs）"
+// @Param        type        query     string  false  "任务类型"
+// @Param        status      query     string  false  "状态：queued, running, succeeded, failed, canceled"
+// @Param        limit       query     int     false  "返回数量（默认50，最大200）"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      403         {object}  ErrorResponse  "没有小说的只读权限"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/jobs [get]
+// @Router       /api/v1/novels/chapters/{chapter_id}/jobs [get]
+func (h *Handler) ListJobs(c *gin.Context) {
+	req := &novelservice.ListJobsRequest{
+		NovelID:   c.Param("novel_id"),
+		ChapterID: c.Param("chapter_id"),
+		Type:      novel.PipelineStage(c.Query("type")),
+		Status:    novel.JobStatus(c.Query("status")),
+		Limit:     budgetListLimit(c),
+	}
+	if req.NovelID == "" && req.ChapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id or chapter_id is required",
+		})
+		return
+	}
+
+	jobs, err := h.novelService.ListJobs(c.Request.Context(), req)
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"jobs":  jobs,
+			"count": len(jobs),
+		},
+	})
+}
+
+// GetJob 查询生成任务
+// @Summary      查询生成任务
+// @Description  查询任务的状态、执行次数、产物ID和最近一次失败原因
+// @Tags         任务队列
+// @Accept       json
+// @Produce      json
+// @Param        job_id  path      string  true  "任务ID"
+// @Success      200     {object}  map[string]interface{}  "成功响应"
+// @Failure      400     {object}  ErrorResponse  "请求参数错误"
+// @Failure      404     {object}  ErrorResponse  "任务不存在"
+// @Failure      500     {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/jobs/{job_id} [get]
+func (h *Handler) GetJob(c *gin.Context) {
+	jobID := c.Param("job_id")
+	if jobID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "job_id is required",
+		})
+		return
+	}
+
+	job, err := h.novelService.GetJob(c.Request.Context(), jobID)
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    job,
+	})
+}
+
+// CancelJob 取消生成任务
+// @Summary      取消生成任务
+// @Description  排队中的任务直接取消；执行中的任务被中断（在其他实例上执行时最迟 30 秒内中断），已生成的产物保留
+// @Tags         任务队列
+// @Accept       json
+// @Produce      json
+// @Param        job_id  path      string  true  "任务ID"
+// @Success      200     {object}  map[string]interface{}  "成功响应"
+// @Failure      400     {object}  ErrorResponse  "请求参数错误"
+// @Failure      404     {object}  ErrorResponse  "任务不存在"
+// @Failure      409     {object}  ErrorResponse  "任务已结束"
+// @Failure      500     {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/jobs/{job_id}/cancel [post]
+func (h *Handler) CancelJob(c *gin.Context) {
+	jobID := c.Param("job_id")
+	if jobID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "job_id is required",
+		})
+		return
+	}
+
+	job, err := h.novelService.CancelJob(c.Request.Context(), jobID)
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "任务已取消",
+		"data":    job,
+	})
+}
+
+// RetryJob 重试生成任务
+// @Summary      重试生成任务
+// @Description  把失败或已取消的任务重新放回队列（执行次数清零）
+// @Tags         任务队列
+// @Accept       json
+// @Produce      json
+// @Param        job_id  path      string  true  "任务ID"
+// @Success      200     {object}  map[string]interface{}  "成功响应"
+// @Failure      400     {object}  ErrorResponse  "请求参数错误"
+// @Failure      404     {object}  ErrorResponse  "任务不存在"
+// @Failure      409     {object}  ErrorResponse  "任务不是失败或已取消状态"
+// @Failure      500     {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/jobs/{job_id}/retry [post]
+func (h *Handler) RetryJob(c *gin.Context) {
+	jobID := c.Param("job_id")
+	if jobID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "job_id is required",
+		})
+		return
+	}
+
+	job, err := h.novelService.RetryJob(c.Request.Context(), jobID)
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "任务已重新提交",
+		"data":    job,
+	})
+}
+
+// respondJobError 任务请求不合法返回 400，任务/章节不存在返回 404，状态不允许返回 409，其余返回 500
+func respondJobError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+	switch {
+	case errors.Is(err, novelservice.ErrInvalidJob):
+		code = http.StatusBadRequest
+		errorCode = 40003
+	case errors.Is(err, mongo.ErrNoDocuments):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, novelservice.ErrJobFinished), errors.Is(err, novelservice.ErrJobNotRetryable):
+		code = http.StatusConflict
+		errorCode = 40901
+	}
+
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
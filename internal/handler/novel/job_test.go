@@ -0,0 +1,126 @@
+package novel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/model/auth"
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	"lemon/internal/server/middleware"
+	novelservice "lemon/internal/service/novel"
+)
+
+// jobStub 只实现任务提交、查询和权限检查的小说服务：章节 ch-1 属于小说 novel-1，
+// grants 为用户对 novel-1 的权限
+type jobStub struct {
+	novelservice.NovelService
+	grants   map[string]novel.NovelPermission
+	enqueued *novelservice.EnqueueJobRequest
+	listed   *novelservice.ListJobsRequest
+}
+
+func (s *jobStub) ResolveNovelID(_ context.Context, scope novelservice.NovelScope, resourceID string) (string, error) {
+	if scope == novelservice.NovelScopeChapter && resourceID == "ch-1" {
+		return "novel-1", nil
+	}
+	return resourceID, nil
+}
+
+func (s *jobStub) CheckNovelAccess(_ context.Context, novelID, userID string, _ auth.UserRole, required novel.NovelPermission) error {
+	granted, ok := s.grants[userID]
+	if novelID != "novel-1" || !ok || (required == novel.NovelPermissionEdit && granted != novel.NovelPermissionEdit) {
+		return fmt.Errorf("%w: %s", novelservice.ErrNovelAccessDenied, userID)
+	}
+	return nil
+}
+
+func (s *jobStub) EnqueueJob(_ context.Context, req *novelservice.EnqueueJobRequest) (*novel.Job, error) {
+	s.enqueued = req
+	return &novel.Job{ID: "job-1", Type: req.Type, ChapterID: req.ChapterID}, nil
+}
+
+func (s *jobStub) ListJobs(_ context.Context, req *novelservice.ListJobsRequest) ([]*novel.Job, error) {
+	s.listed = req
+	return nil, nil
+}
+
+// serveJobs 以指定的登录用户请求任务接口，路由和权限中间件与开启小说权限时一致
+func serveJobs(svc *jobStub, userID, method, path, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		ctx := ctxutil.WithUserID(c.Request.Context(), userID)
+		c.Request = c.Request.WithContext(ctxutil.WithUserRole(ctx, auth.RoleEditor))
+		c.Next()
+	}, middleware.NovelAccess(svc))
+	h := NewHandler(svc)
+	r.POST("/novels/chapters/:chapter_id/jobs", h.EnqueueJob)
+	r.GET("/novels/chapters/:chapter_id/jobs", h.ListJobs)
+	r.GET("/novels/:novel_id/jobs", h.ListJobs)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestJobRoutesCheckNovelAccess(t *testing.T) {
+	grants := map[string]novel.NovelPermission{
+		"editor-1": novel.NovelPermissionEdit,
+		"reader-1": novel.NovelPermissionRead,
+	}
+	tests := []struct {
+		name       string
+		userID     string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{"编辑权限提交任务", "editor-1", http.MethodPost, "/novels/chapters/ch-1/jobs", http.StatusCreated},
+		{"只读权限不能提交任务", "reader-1", http.MethodPost, "/novels/chapters/ch-1/jobs", http.StatusForbidden},
+		{"无权限不能提交任务", "stranger-1", http.MethodPost, "/novels/chapters/ch-1/jobs", http.StatusForbidden},
+		{"只读权限查询章节任务", "reader-1", http.MethodGet, "/novels/chapters/ch-1/jobs", http.StatusOK},
+		{"只读权限查询小说任务", "reader-1", http.MethodGet, "/novels/novel-1/jobs", http.StatusOK},
+		{"无权限不能查询章节任务", "stranger-1", http.MethodGet, "/novels/chapters/ch-1/jobs", http.StatusForbidden},
+		{"无权限不能查询小说任务", "stranger-1", http.MethodGet, "/novels/novel-1/jobs", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &jobStub{grants: grants}
+			w := serveJobs(svc, tt.userID, tt.method, tt.path, `{"type":"audio","user_id":"someone-else"}`)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusForbidden && (svc.enqueued != nil || svc.listed != nil) {
+				t.Error("service called without access")
+			}
+		})
+	}
+}
+
+func TestEnqueueJobUsesPathChapterAndCurrentUser(t *testing.T) {
+	svc := &jobStub{grants: map[string]novel.NovelPermission{"editor-1": novel.NovelPermissionEdit}}
+	w := serveJobs(svc, "editor-1", http.MethodPost, "/novels/chapters/ch-1/jobs", `{"type":"audio","chapter_id":"ch-other","user_id":"someone-else"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if svc.enqueued.ChapterID != "ch-1" || svc.enqueued.UserID != "editor-1" {
+		t.Errorf("enqueued = %+v, want chapter ch-1 submitted by editor-1", svc.enqueued)
+	}
+
+	w = serveJobs(svc, "editor-1", http.MethodGet, "/novels/novel-1/jobs?status=failed", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if svc.listed.NovelID != "novel-1" || svc.listed.ChapterID != "" || svc.listed.Status != novel.JobStatusFailed {
+		t.Errorf("listed = %+v, want failed jobs of novel-1", svc.listed)
+	}
+}
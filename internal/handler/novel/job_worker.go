@@ -0,0 +1,129 @@
+package novel
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CordonJobWorkerRequest 隔离工作节点请求
+type CordonJobWorkerRequest struct {
+	Reason         string `json:"reason"`          // 隔离原因（如维护说明）
+	RequeueRunning bool   `json:"requeue_running"` // 是否把节点上执行中的任务放回队列，由其他节点重新执行
+}
+
+// ListJobWorkers 查询任务队列工作节点
+// @Summary      查询任务队列工作节点
+// @Description  返回所有上报过心跳的工作节点，包括隔离状态、心跳是否有效（2 分钟内）和执行中的任务数（仅管理员）
+// @Tags         任务队列
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "成功响应"
+// @Failure      403  {object}  ErrorResponse  "无权限"
+// @Failure      500  {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/job-workers [get]
+func (h *Handler) ListJobWorkers(c *gin.Context) {
+	workers, err := h.novelService.ListJobWorkers(c.Request.Context())
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"workers": workers,
+			"count":   len(workers),
+		},
+	})
+}
+
+// CordonJobWorker 隔离任务队列工作节点
+// @Summary      隔离任务队列工作节点
+// @Description  隔离后节点不再领取新任务，排队中的任务由其他节点执行（仅管理员）。
+// @Description  requeue_running 为 true 时节点上执行中的任务被中断并放回队列（节点在线时最迟 30 秒内中断，节点已失联时立即放回），不计入执行次数
+// @Tags         任务队列
+// @Accept       json
+// @Produce      json
+// @Param        worker_id  path      string                  true   "节点ID"
+// @Param        request    body      CordonJobWorkerRequest  false  "隔离请求"
+// @Success      200        {object}  map[string]interface{}  "成功响应"
+// @Failure      400        {object}  ErrorResponse  "请求参数错误"
+// @Failure      403        {object}  ErrorResponse  "无权限"
+// @Failure      404        {object}  ErrorResponse  "节点不存在"
+// @Failure      500        {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/job-workers/{worker_id}/cordon [post]
+func (h *Handler) CordonJobWorker(c *gin.Context) {
+	workerID := c.Param("worker_id")
+	if workerID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "worker_id is required",
+		})
+		return
+	}
+
+	var req CordonJobWorkerRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40002,
+				Message: "Invalid request body",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	worker, requeued, err := h.novelService.CordonJobWorker(c.Request.Context(), workerID, req.Reason, req.RequeueRunning)
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "节点已隔离",
+		"data": gin.H{
+			"worker":   worker,
+			"requeued": requeued,
+		},
+	})
+}
+
+// UncordonJobWorker 解除隔离任务队列工作节点
+// @Summary      解除隔离任务队列工作节点
+// @Description  节点恢复领取任务（仅管理员）
+// @Tags         任务队列
+// @Accept       json
+// @Produce      json
+// @Param        worker_id  path      string  true  "节点ID"
+// @Success      200        {object}  map[string]interface{}  "成功响应"
+// @Failure      400        {object}  ErrorResponse  "请求参数错误"
+// @Failure      403        {object}  ErrorResponse  "无权限"
+// @Failure      404        {object}  ErrorResponse  "节点不存在"
+// @Failure      500        {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/job-workers/{worker_id}/uncordon [post]
+func (h *Handler) UncordonJobWorker(c *gin.Context) {
+	workerID := c.Param("worker_id")
+	if workerID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "worker_id is required",
+		})
+		return
+	}
+
+	worker, err := h.novelService.UncordonJobWorker(c.Request.Context(), workerID)
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "节点已解除隔离",
+		"data":    worker,
+	})
+}
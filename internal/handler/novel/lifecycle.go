@@ -0,0 +1,198 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/pkg/noveltools"
+	novelservice "lemon/internal/service/novel"
+)
+
+// DeleteRequest 删除请求的查询参数
+type DeleteRequest struct {
+	Purge bool `form:"purge"` // 是否同时删除存储中的文件（仍被其他记录使用的文件保留）
+}
+
+// DeleteChapterArtifactsRequest 删除章节产物的查询参数
+type DeleteChapterArtifactsRequest struct {
+	ListChapterArtifactsRequest
+	Purge bool `form:"purge"` // 是否同时删除存储中的文件（仍被其他记录使用的文件保留）
+}
+
+// DeleteNovel 删除小说
+// @Summary      删除小说
+// @Description  软删除小说，并级联删除章节（包括分支）、解说、场景、镜头、音频、字幕、图片、视频、角色、道具、封面、风格参考图和发音词典；授权记录保留。
+// @Description  purge=true 时同时删除这些记录引用的存储文件（合集等仍在使用的文件、发布版本和已公证的视频文件保留，删除失败的文件计入 purge_failed）。开启小说权限时只有所有者可以删除
+// @Tags         小说管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string  true   "小说ID"
+// @Param        purge     query     bool    false  "是否同时删除存储中的文件"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id} [delete]
+func (h *Handler) DeleteNovel(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	var req DeleteRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid query parameters",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	result, err := h.novelService.DeleteNovel(c.Request.Context(), novelID, req.Purge)
+	if err != nil {
+		respondDeleteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "小说已删除",
+		"data":    result,
+	})
+}
+
+// DeleteChapter 删除章节
+// @Summary      删除章节
+// @Description  软删除章节及其所有分支，并级联删除它们的解说、场景、镜头、音频、字幕、图片、视频和章节级风格参考图。
+// @Description  purge=true 时同时删除这些记录引用的存储文件（小说中其他记录仍在使用的文件保留，例如分支复制自其他章节的产物；发布版本和已公证的视频文件保留）
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true   "章节ID"
+// @Param        purge       query     bool    false  "是否同时删除存储中的文件"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  ErrorResponse  "章节不存在"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id} [delete]
+func (h *Handler) DeleteChapter(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	var req DeleteRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid query parameters",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	result, err := h.novelService.DeleteChapter(c.Request.Context(), chapterID, req.Purge)
+	if err != nil {
+		respondDeleteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "章节已删除",
+		"data":    result,
+	})
+}
+
+// DeleteChapterArtifacts 删除章节生成的产物
+// @Summary      删除章节生成的产物
+// @Description  按类型、状态、版本筛选并软删除章节生成的音频、字幕、图片和视频（筛选条件与产物列表接口相同，至少指定一个）。
+// @Description  筛选结果包含发布版本时拒绝删除（视频包括同一版本的镜头视频）。purge=true 时同时删除这些产物引用的存储文件（其他记录仍在使用的文件和已公证的视频文件保留）
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true   "章节ID"
+// @Param        type        query     string  false  "产物类型，逗号分隔（audio, subtitle, image, video）"
+// @Param        status      query     string  false  "产物状态，逗号分隔（pending, processing, completed, failed）"
+// @Param        version     query     string  false  "版本号或 latest"
+// @Param        purge       query     bool    false  "是否同时删除存储中的文件"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  ErrorResponse  "章节不存在"
+// @Failure      409         {object}  ErrorResponse  "筛选结果包含发布版本"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/artifacts [delete]
+func (h *Handler) DeleteChapterArtifacts(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	var req DeleteChapterArtifactsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid query parameters",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	filter, err := noveltools.ParseArtifactFilter(req.Type, req.Status, req.Version)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40003,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	result, err := h.novelService.DeleteChapterArtifacts(c.Request.Context(), chapterID, filter, req.Purge)
+	if err != nil {
+		respondDeleteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "章节产物已删除",
+		"data":    result,
+	})
+}
+
+// respondDeleteError 删除接口的错误响应
+func respondDeleteError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, novelservice.ErrEmptyArtifactSelection):
+		code = http.StatusBadRequest
+		errorCode = 40003
+	case errors.Is(err, novelservice.ErrArtifactPromoted):
+		code = http.StatusConflict
+		errorCode = 40901
+	}
+
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
@@ -12,36 +12,38 @@ import (
 
 // AudioInfo 音频信息 DTO
 type AudioInfo struct {
-	ID              string  `json:"id"`
-	NarrationID     string  `json:"narration_id"`
-	ChapterID       string  `json:"chapter_id"`
-	UserID          string  `json:"user_id"`
-	Sequence        int     `json:"sequence"`
-	AudioResourceID string  `json:"audio_resource_id"`
-	Duration        float64 `json:"duration"`
-	Text            string  `json:"text"`
-	Prompt          string  `json:"prompt,omitempty"`
-	Version         int     `json:"version"`
-	Status          string  `json:"status"`
-	CreatedAt       string  `json:"created_at"`
-	UpdatedAt       string  `json:"updated_at"`
+	ID                 string  `json:"id"`
+	NarrationID        string  `json:"narration_id"`
+	ChapterID          string  `json:"chapter_id"`
+	UserID             string  `json:"user_id"`
+	Sequence           int     `json:"sequence"`
+	AudioResourceID    string  `json:"audio_resource_id"`
+	WaveformResourceID string  `json:"waveform_resource_id,omitempty"` // 波形峰值文件的 resource_id
+	Duration           float64 `json:"duration"`
+	Text               string  `json:"text"`
+	Prompt             string  `json:"prompt,omitempty"`
+	Version            int     `json:"version"`
+	Status             string  `json:"status"`
+	CreatedAt          string  `json:"created_at"`
+	UpdatedAt          string  `json:"updated_at"`
 }
 
 func toAudioInfo(a *novel.Audio) AudioInfo {
 	return AudioInfo{
-		ID:              a.ID,
-		NarrationID:     a.NarrationID,
-		ChapterID:       a.ChapterID,
-		UserID:          a.UserID,
-		Sequence:        a.Sequence,
-		AudioResourceID: a.AudioResourceID,
-		Duration:        a.Duration,
-		Text:            a.Text,
-		Prompt:          a.Prompt,
-		Version:         a.Version,
-		Status:          string(a.Status),
-		CreatedAt:       a.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:       a.UpdatedAt.Format(time.RFC3339),
+		ID:                 a.ID,
+		NarrationID:        a.NarrationID,
+		ChapterID:          a.ChapterID,
+		UserID:             a.UserID,
+		Sequence:           a.Sequence,
+		AudioResourceID:    a.AudioResourceID,
+		WaveformResourceID: a.WaveformResourceID,
+		Duration:           a.Duration,
+		Text:               a.Text,
+		Prompt:             a.Prompt,
+		Version:            a.Version,
+		Status:             string(a.Status),
+		CreatedAt:          a.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:          a.UpdatedAt.Format(time.RFC3339),
 	}
 }
 
@@ -88,6 +90,7 @@ type ImageInfo struct {
 	ImageResourceID  string `json:"image_resource_id"`
 	CharacterName    string `json:"character_name"`
 	Prompt           string `json:"prompt,omitempty"`
+	StyleReferenceIDs []string `json:"style_reference_ids,omitempty"` // 生成图片时使用的风格参考图ID
 	Version          int    `json:"version"`
 	Status           string `json:"status"`
 	Sequence         int    `json:"sequence"`
@@ -105,6 +108,7 @@ func toImageInfo(i *novel.Image) ImageInfo {
 		ImageResourceID: i.ImageResourceID,
 		CharacterName:   i.CharacterName,
 		Prompt:          i.Prompt,
+		StyleReferenceIDs: i.StyleReferenceIDs,
 		Version:         i.Version,
 		Status:          string(i.Status),
 		Sequence:        i.Sequence,
@@ -113,7 +117,7 @@ func toImageInfo(i *novel.Image) ImageInfo {
 	}
 }
 
-// ListAudiosByNarration 列出解说的音频列表（可选 version）
+// ListAudiosByNarration 列出解说的音频列表（可选 version；支持分页、排序和 status 筛选，见 ListQueryRequest）
 // @Router /api/v1/narrations/{narration_id}/audios [get]
 func (h *Handler) ListAudiosByNarration(c *gin.Context) {
 	narrationID := c.Param("narration_id")
@@ -122,15 +126,19 @@ func (h *Handler) ListAudiosByNarration(c *gin.Context) {
 		return
 	}
 	version := parseOptionalIntQuery(c, "version")
+	q, ok := bindListQuery(c)
+	if !ok {
+		return
+	}
 
 	ctx := c.Request.Context()
-	audios, resolved, err := h.novelService.ListAudiosByNarration(ctx, narrationID, version)
+	page, err := h.novelService.QueryAudios(ctx, narrationID, version, q)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Code: 50001, Message: err.Error()})
+		respondListError(c, err)
 		return
 	}
-	out := make([]AudioInfo, 0, len(audios))
-	for _, a := range audios {
+	out := make([]AudioInfo, 0, len(page.Items))
+	for _, a := range page.Items {
 		out = append(out, toAudioInfo(a))
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -138,14 +146,17 @@ func (h *Handler) ListAudiosByNarration(c *gin.Context) {
 		"message": "success",
 		"data": gin.H{
 			"narration_id": narrationID,
-			"version":      resolved,
+			"version":      page.Version,
 			"audios":       out,
 			"count":        len(out),
+			"total":        page.Total,
+			"page":         q.Page,
+			"page_size":    q.PageSize,
 		},
 	})
 }
 
-// ListSubtitlesByNarration 列出解说的字幕列表（可选 version）
+// ListSubtitlesByNarration 列出解说的字幕列表（可选 version；支持分页、排序和 status 筛选，见 ListQueryRequest）
 // @Router /api/v1/narrations/{narration_id}/subtitles [get]
 func (h *Handler) ListSubtitlesByNarration(c *gin.Context) {
 	narrationID := c.Param("narration_id")
@@ -154,15 +165,19 @@ func (h *Handler) ListSubtitlesByNarration(c *gin.Context) {
 		return
 	}
 	version := parseOptionalIntQuery(c, "version")
+	q, ok := bindListQuery(c)
+	if !ok {
+		return
+	}
 
 	ctx := c.Request.Context()
-	subs, resolved, err := h.novelService.ListSubtitlesByNarration(ctx, narrationID, version)
+	page, err := h.novelService.QuerySubtitles(ctx, narrationID, version, q)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Code: 50001, Message: err.Error()})
+		respondListError(c, err)
 		return
 	}
-	out := make([]SubtitleInfo, 0, len(subs))
-	for _, s := range subs {
+	out := make([]SubtitleInfo, 0, len(page.Items))
+	for _, s := range page.Items {
 		out = append(out, toSubtitleInfo(s))
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -170,14 +185,17 @@ func (h *Handler) ListSubtitlesByNarration(c *gin.Context) {
 		"message": "success",
 		"data": gin.H{
 			"narration_id": narrationID,
-			"version":      resolved,
+			"version":      page.Version,
 			"subtitles":    out,
 			"count":        len(out),
+			"total":        page.Total,
+			"page":         q.Page,
+			"page_size":    q.PageSize,
 		},
 	})
 }
 
-// ListImagesByNarration 列出解说的图片列表（可选 version）
+// ListImagesByNarration 列出解说的图片列表（可选 version；支持分页、排序和 status 筛选，见 ListQueryRequest）
 // @Router /api/v1/narrations/{narration_id}/images [get]
 func (h *Handler) ListImagesByNarration(c *gin.Context) {
 	narrationID := c.Param("narration_id")
@@ -186,15 +204,19 @@ func (h *Handler) ListImagesByNarration(c *gin.Context) {
 		return
 	}
 	version := parseOptionalIntQuery(c, "version")
+	q, ok := bindListQuery(c)
+	if !ok {
+		return
+	}
 
 	ctx := c.Request.Context()
-	images, resolved, err := h.novelService.ListImagesByNarration(ctx, narrationID, version)
+	page, err := h.novelService.QueryImages(ctx, narrationID, version, q)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Code: 50001, Message: err.Error()})
+		respondListError(c, err)
 		return
 	}
-	out := make([]ImageInfo, 0, len(images))
-	for _, i := range images {
+	out := make([]ImageInfo, 0, len(page.Items))
+	for _, i := range page.Items {
 		out = append(out, toImageInfo(i))
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -202,14 +224,17 @@ func (h *Handler) ListImagesByNarration(c *gin.Context) {
 		"message": "success",
 		"data": gin.H{
 			"narration_id": narrationID,
-			"version":      resolved,
+			"version":      page.Version,
 			"images":       out,
 			"count":        len(out),
+			"total":        page.Total,
+			"page":         q.Page,
+			"page_size":    q.PageSize,
 		},
 	})
 }
 
-// ListVideosByChapter 列出章节视频列表（可选 version）
+// ListVideosByChapter 列出章节视频列表（可选 version；支持分页、排序和 status、video_type 筛选，见 ListQueryRequest）
 // @Router /api/v1/novels/chapters/{chapter_id}/videos [get]
 func (h *Handler) ListVideosByChapter(c *gin.Context) {
 	chapterID := c.Param("chapter_id")
@@ -218,11 +243,15 @@ func (h *Handler) ListVideosByChapter(c *gin.Context) {
 		return
 	}
 	version := parseOptionalIntQuery(c, "version")
+	q, ok := bindListQuery(c)
+	if !ok {
+		return
+	}
 
 	ctx := c.Request.Context()
-	videos, resolved, err := h.novelService.ListVideosByChapter(ctx, chapterID, version)
+	page, err := h.novelService.QueryVideos(ctx, chapterID, version, q)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Code: 50001, Message: err.Error()})
+		respondListError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -230,9 +259,12 @@ func (h *Handler) ListVideosByChapter(c *gin.Context) {
 		"message": "success",
 		"data": gin.H{
 			"chapter_id": chapterID,
-			"version":    resolved,
-			"videos":     toVideoInfoList(videos),
-			"count":      len(videos),
+			"version":    page.Version,
+			"videos":     toVideoInfoList(page.Items),
+			"count":      len(page.Items),
+			"total":      page.Total,
+			"page":       q.Page,
+			"page_size":  q.PageSize,
 		},
 	})
 }
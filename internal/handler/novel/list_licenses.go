@@ -0,0 +1,89 @@
+package novel
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/model/novel"
+)
+
+// LicenseGrantInfo 授权记录 DTO
+type LicenseGrantInfo struct {
+	ID        string `json:"id"`         // 授权ID
+	VideoID   string `json:"video_id"`   // 最终视频ID
+	ChapterID string `json:"chapter_id"` // 章节ID
+	NovelID   string `json:"novel_id"`   // 小说ID
+	UserID    string `json:"user_id"`    // 被授权的用户ID
+	Tier      string `json:"tier"`       // 导出档位
+	Version   int    `json:"version"`    // 视频版本号
+	CreatedAt string `json:"created_at"` // 授权时间
+}
+
+func toLicenseGrantInfo(g *novel.LicenseGrant) LicenseGrantInfo {
+	return LicenseGrantInfo{
+		ID:        g.ID,
+		VideoID:   g.VideoID,
+		ChapterID: g.ChapterID,
+		NovelID:   g.NovelID,
+		UserID:    g.UserID,
+		Tier:      string(g.Tier),
+		Version:   g.Version,
+		CreatedAt: g.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// ListLicenseGrantsResponseData 章节授权记录列表响应
+type ListLicenseGrantsResponseData struct {
+	ChapterID string             `json:"chapter_id"`
+	Licenses  []LicenseGrantInfo `json:"licenses"`
+	Count     int                `json:"count"`
+}
+
+// ListLicenseGrantsByChapter 列出章节的授权记录
+// @Summary      列出章节授权记录
+// @Description  列出指定章节每次导出授权版（无水印）最终视频时记录的授权（按时间倒序）
+// @Tags         视频生成
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true  "章节ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/licenses [get]
+func (h *Handler) ListLicenseGrantsByChapter(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	grants, err := h.novelService.ListLicenseGrantsByChapter(ctx, chapterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50001,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	infos := make([]LicenseGrantInfo, 0, len(grants))
+	for _, g := range grants {
+		infos = append(infos, toLicenseGrantInfo(g))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": ListLicenseGrantsResponseData{
+			ChapterID: chapterID,
+			Licenses:  infos,
+			Count:     len(infos),
+		},
+	})
+}
@@ -11,15 +11,22 @@ type ListNarrationsResponseData struct {
 	ChapterID  string         `json:"chapter_id"`
 	Narrations []NarrationInfo `json:"narrations"`
 	Count      int            `json:"count"`
+	ListPageInfo
 }
 
 // ListNarrationsByChapterID 列出章节的所有解说版本
 // @Summary      列出章节解说版本
-// @Description  列出指定章节的所有解说版本（包含 narration_id、version、prompt、status）
+// @Description  列出指定章节的解说版本（包含 narration_id、version、prompt、status），默认按版本倒序返回全部；指定 page/page_size 时分页返回，total 为满足条件的总数
 // @Tags         解说管理
 // @Accept       json
 // @Produce      json
-// @Param        chapter_id  path      string  true  "章节ID"
+// @Param        chapter_id  path      string  true   "章节ID"
+// @Param        version     query     int     false  "解说版本号"
+// @Param        status      query     string  false  "状态，逗号分隔（pending, completed, failed）"
+// @Param        page        query     int     false  "页码，从 1 开始"
+// @Param        page_size   query     int     false  "每页数量（最大 100，只指定 page 时为 20）"
+// @Param        sort        query     string  false  "排序字段：created_at"
+// @Param        order       query     string  false  "排序顺序：asc, desc"
 // @Success      200         {object}  map[string]interface{}  "成功响应"
 // @Failure      400         {object}  ErrorResponse  "请求参数错误"
 // @Failure      500         {object}  ErrorResponse  "服务器内部错误"
@@ -34,18 +41,21 @@ func (h *Handler) ListNarrationsByChapterID(c *gin.Context) {
 		return
 	}
 
+	q, ok := bindListQuery(c)
+	if !ok {
+		return
+	}
+	version := parseOptionalIntQuery(c, "version")
+
 	ctx := c.Request.Context()
-	narrations, err := h.novelService.ListNarrationsByChapterID(ctx, chapterID)
+	page, err := h.novelService.QueryNarrations(ctx, chapterID, version, q)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Code:    50001,
-			Message: err.Error(),
-		})
+		respondListError(c, err)
 		return
 	}
 
-	infos := make([]NarrationInfo, 0, len(narrations))
-	for _, n := range narrations {
+	infos := make([]NarrationInfo, 0, len(page.Items))
+	for _, n := range page.Items {
 		infos = append(infos, toNarrationInfo(n))
 	}
 
@@ -53,9 +63,10 @@ func (h *Handler) ListNarrationsByChapterID(c *gin.Context) {
 		"code":    0,
 		"message": "success",
 		"data": ListNarrationsResponseData{
-			ChapterID:  chapterID,
-			Narrations: infos,
-			Count:      len(infos),
+			ChapterID:    chapterID,
+			Narrations:   infos,
+			Count:        len(infos),
+			ListPageInfo: newListPageInfo(q, page.Total),
 		},
 	})
 }
@@ -0,0 +1,101 @@
+package novel
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/model/auth"
+	"lemon/internal/pkg/ctxutil"
+)
+
+// ListNovelsRequest 小说列表查询参数
+type ListNovelsRequest struct {
+	UserID   string `form:"user_id"`                           // 用户ID（登录后普通用户固定为当前用户）
+	Page     int64  `form:"page" binding:"min=0"`              // 页码（从1开始，默认1）
+	PageSize int64  `form:"page_size" binding:"min=0,max=100"` // 每页数量（默认20，最大100）
+}
+
+// ListNovelsResponseData 小说列表响应数据
+type ListNovelsResponseData struct {
+	Novels   []NovelInfo `json:"novels"`    // 小说列表（按创建时间倒序，包含当前封面）
+	Total    int64       `json:"total"`     // 总数
+	Page     int64       `json:"page"`      // 页码
+	PageSize int64       `json:"page_size"` // 每页数量
+}
+
+// ListNovels 获取小说列表
+// @Summary      获取小说列表
+// @Description  分页获取用户的小说（按创建时间倒序），每本小说带当前封面（cover_id、cover_resource_id），用于前端作品墙展示。开启小说权限后，管理员和审核人员可以查询任意用户，其他用户只能查询自己的小说
+// @Tags         小说管理
+// @Accept       json
+// @Produce      json
+// @Param        user_id    query     string  true   "用户ID"
+// @Param        page       query     int     false  "页码（从1开始，默认1）"
+// @Param        page_size  query     int     false  "每页数量（默认20，最大100）"
+// @Success      200        {object}  map[string]interface{}  "成功响应"
+// @Failure      400        {object}  ErrorResponse  "请求参数错误"
+// @Failure      500        {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels [get]
+func (h *Handler) ListNovels(c *gin.Context) {
+	var req ListNovelsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid query parameters",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	userID := req.UserID
+	if currentUserID, ok := ctxutil.GetUserID(ctx); ok {
+		role, _ := ctxutil.GetUserRole(ctx)
+		if userID == "" || (role != auth.RoleAdmin && role != auth.RoleReviewer) {
+			userID = currentUserID
+		}
+	}
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "user_id is required",
+		})
+		return
+	}
+
+	page := req.Page
+	if page == 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize == 0 {
+		pageSize = 20
+	}
+
+	novels, total, err := h.novelService.ListNovels(ctx, userID, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50001,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	infos := make([]NovelInfo, 0, len(novels))
+	for _, n := range novels {
+		infos = append(infos, toNovelInfo(n))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": ListNovelsResponseData{
+			Novels:   infos,
+			Total:    total,
+			Page:     page,
+			PageSize: pageSize,
+		},
+	})
+}
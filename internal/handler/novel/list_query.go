@@ -0,0 +1,68 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/pkg/noveltools"
+)
+
+// ListQueryRequest 列表接口的分页、排序和筛选参数（都不指定时返回全部数据）
+type ListQueryRequest struct {
+	Page      int64  `form:"page" binding:"min=0"`              // 页码，从 1 开始
+	PageSize  int64  `form:"page_size" binding:"min=0,max=100"` // 每页数量，只指定 page 时为 20
+	Sort      string `form:"sort"`                              // 排序字段：sequence, created_at（为空时使用默认排序）
+	Order     string `form:"order"`                             // 排序顺序：asc（默认）, desc
+	Status    string `form:"status"`                            // 状态，逗号分隔：pending, processing, completed, failed
+	VideoType string `form:"video_type"`                        // 视频类型，逗号分隔（仅视频列表）：narration_video, final_video, novel_video
+}
+
+// ListPageInfo 列表分页信息（与列表同级返回）
+type ListPageInfo struct {
+	Total    int64 `json:"total"`     // 满足筛选条件的总数
+	Page     int64 `json:"page"`      // 页码（未分页时为 0）
+	PageSize int64 `json:"page_size"` // 每页数量（未分页时为 0）
+}
+
+func newListPageInfo(q *noveltools.ListQuery, total int64) ListPageInfo {
+	return ListPageInfo{Total: total, Page: q.Page, PageSize: q.PageSize}
+}
+
+// bindListQuery 读取列表参数，格式错误时返回 400
+func bindListQuery(c *gin.Context) (*noveltools.ListQuery, bool) {
+	var req ListQueryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid query parameters",
+			Detail:  err.Error(),
+		})
+		return nil, false
+	}
+	q, err := noveltools.ParseListQuery(req.Page, req.PageSize, req.Sort, req.Order, req.Status, req.VideoType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40003,
+			Message: err.Error(),
+		})
+		return nil, false
+	}
+	return q, true
+}
+
+// respondListError 列表接口的错误响应（列表不支持的排序或筛选条件返回 400）
+func respondListError(c *gin.Context, err error) {
+	if errors.Is(err, noveltools.ErrInvalidListQuery) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40003,
+			Message: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Code:    50001,
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,80 @@
+package novel
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/model/novel"
+	novelservice "lemon/internal/service/novel"
+)
+
+// narrationEventsHeartbeat SSE 心跳间隔（避免代理层因空闲断开连接）
+const narrationEventsHeartbeat = 15 * time.Second
+
+// StreamNarrationEvents 订阅解说生成事件（SSE）
+// @Summary      订阅解说生成事件
+// @Description  以 Server-Sent Events 推送解说生成进度：每个场景落库时推送 narration.scene_completed，生成结束时推送 narration.completed 或 narration.failed 后关闭连接。
+// @Description  订阅前已完成的场景不会重放，请先调用获取章节解说接口拿到已生成的部分结果。
+// @Tags         解说管理
+// @Produce      text/event-stream
+// @Param        narration_id  path      string  true  "解说ID"
+// @Success      200           {string}  string         "事件流"
+// @Failure      400           {object}  ErrorResponse  "请求参数错误"
+// @Failure      404           {object}  ErrorResponse  "解说不存在"
+// @Router       /api/v1/narrations/{narration_id}/events [get]
+func (h *Handler) StreamNarrationEvents(c *gin.Context) {
+	narrationID := c.Param("narration_id")
+	if narrationID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "narration_id is required",
+		})
+		return
+	}
+
+	// 先订阅再查询状态，避免查询与订阅之间错过结束事件
+	events, cancel := h.novelService.SubscribeNarrationEvents(narrationID)
+	defer cancel()
+
+	narration, err := h.novelService.GetNarrationByID(c.Request.Context(), narrationID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:    40401,
+			Message: "narration not found",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	// 已经结束的解说直接推送最终状态
+	if narration.Status != novel.TaskStatusPending {
+		eventType := novelservice.NarrationEventCompleted
+		if narration.Status == novel.TaskStatusFailed {
+			eventType = novelservice.NarrationEventFailed
+		}
+		c.SSEvent(eventType, narration)
+		return
+	}
+
+	heartbeat := time.NewTicker(narrationEventsHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			c.SSEvent("heartbeat", time.Now().Format(time.RFC3339))
+			return true
+		case evt, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(evt.Type, evt.Data)
+			return evt.Type == novelservice.NarrationEventSceneCompleted
+		}
+	})
+}
@@ -0,0 +1,148 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/pkg/ctxutil"
+	"lemon/internal/pkg/noveltools"
+	novelservice "lemon/internal/service/novel"
+)
+
+// PatchNarrationRequest 解说补丁请求
+type PatchNarrationRequest struct {
+	Operations       []noveltools.NarrationPatchOperation `json:"operations" binding:"required"` // 补丁操作（RFC 6902：replace、test）
+	BaseMinorVersion *int                                 `json:"base_minor_version"`            // 基于哪个小版本修改（可选，与当前小版本不一致时返回 409）
+	UserID           string                               `json:"user_id"`                       // 修改人ID（已登录时使用当前用户）
+}
+
+// PatchNarration 按 JSON Patch 修改解说
+// @Summary      内联修改解说
+// @Description  按 JSON Patch（RFC 6902 的 replace、test 操作）直接修改解说版本中的字段，不重新生成解说。
+// @Description  允许的路径：/scenes/{i}/image_prompt、/scenes/{i}/shots/{j}/narration、/scenes/{i}/shots/{j}/image_prompt、/scenes/{i}/shots/{j}/video_prompt、/scenes/{i}/shots/{j}/duration（下标从 0 开始，与 GET /narrations/{narration_id}/scenes 的顺序一致）。
+// @Description  所有操作原子生效，成功后解说小版本号加一并记录补丁（含修改前后的值）；test 不通过或 base_minor_version 不是当前小版本时返回 409
+// @Tags         解说管理
+// @Accept       json
+// @Produce      json
+// @Param        narration_id  path      string                  true  "解说ID"
+// @Param        request       body      PatchNarrationRequest   true  "补丁请求"
+// @Success      200           {object}  map[string]interface{}  "成功响应"
+// @Failure      400           {object}  ErrorResponse  "请求参数错误或补丁不合法"
+// @Failure      404           {object}  ErrorResponse  "解说不存在"
+// @Failure      409           {object}  ErrorResponse  "补丁冲突或解说不可修改"
+// @Failure      500           {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/narrations/{narration_id} [patch]
+func (h *Handler) PatchNarration(c *gin.Context) {
+	narrationID := c.Param("narration_id")
+	if narrationID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "narration_id is required",
+		})
+		return
+	}
+
+	var req PatchNarrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := req.UserID
+	if currentUserID, ok := ctxutil.GetUserID(ctx); ok {
+		userID = currentUserID
+	}
+
+	patch, err := h.novelService.PatchNarration(ctx, &novelservice.PatchNarrationRequest{
+		NarrationID:      narrationID,
+		UserID:           userID,
+		BaseMinorVersion: req.BaseMinorVersion,
+		Operations:       req.Operations,
+	})
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			code = http.StatusNotFound
+			errorCode = 40401
+		case errors.Is(err, noveltools.ErrInvalidNarrationPatch):
+			code = http.StatusBadRequest
+			errorCode = 40003
+		case errors.Is(err, noveltools.ErrNarrationPatchTestFailed),
+			errors.Is(err, novelservice.ErrNarrationPatchConflict),
+			errors.Is(err, novelservice.ErrNarrationNotPatchable):
+			code = http.StatusConflict
+			errorCode = 40901
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "解说已修改",
+		"data":    patch,
+	})
+}
+
+// ListNarrationPatches 查询解说的补丁记录
+// @Summary      查询解说补丁记录
+// @Description  查询解说的内联修改记录（按小版本号升序），包含提交的操作和每个字段修改前后的值
+// @Tags         解说管理
+// @Accept       json
+// @Produce      json
+// @Param        narration_id  path      string  true  "解说ID"
+// @Success      200           {object}  map[string]interface{}  "成功响应"
+// @Failure      400           {object}  ErrorResponse  "请求参数错误"
+// @Failure      404           {object}  ErrorResponse  "解说不存在"
+// @Failure      500           {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/narrations/{narration_id}/patches [get]
+func (h *Handler) ListNarrationPatches(c *gin.Context) {
+	narrationID := c.Param("narration_id")
+	if narrationID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "narration_id is required",
+		})
+		return
+	}
+
+	patches, err := h.novelService.ListNarrationPatches(c.Request.Context(), narrationID)
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			code = http.StatusNotFound
+			errorCode = 40401
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"narration_id": narrationID,
+			"patches":      patches,
+			"count":        len(patches),
+		},
+	})
+}
@@ -0,0 +1,129 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/noveltools"
+)
+
+// SetNarrationStructureRequest 设置解说结构请求
+type SetNarrationStructureRequest struct {
+	Template         string `json:"template"`            // 内置模板：classic（7 个场景，每个场景 1~3 个镜头）、short（3~5 个场景，约 90 秒）、long（10~14 个场景，约 10 分钟），为空时以 classic 为基础
+	MinScenes        int    `json:"min_scenes"`          // 最少场景数（1~30，为 0 时沿用模板）
+	MaxScenes        int    `json:"max_scenes"`          // 最多场景数（1~30，为 0 时沿用模板）
+	MinShotsPerScene int    `json:"min_shots_per_scene"` // 每个场景最少镜头数（1~6，为 0 时沿用模板）
+	MaxShotsPerScene int    `json:"max_shots_per_scene"` // 每个场景最多镜头数（1~6，为 0 时沿用模板）
+	TargetDuration   int    `json:"target_duration"`     // 目标总时长（15~1800 秒，为 0 时沿用模板）
+}
+
+// SetNarrationStructure 设置小说的解说结构
+// @Summary      设置解说结构
+// @Description  整体替换小说的解说结构：选择内置模板，或在模板基础上自定义场景数范围、每个场景的镜头数范围和目标总时长。生成剧本的提示词、剧本校验（解说校验和 QA 评分卡）和视频阶段都按该结构处理；设置了目标时长时解说字数按目标时长计算，否则按章节字数计算。只影响之后生成的剧本
+// @Tags         小说管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string                        true  "小说ID"
+// @Param        request   body      SetNarrationStructureRequest  true  "解说结构"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误或解说结构不合法"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/narration-structure [put]
+func (h *Handler) SetNarrationStructure(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	var req SetNarrationStructureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	view, err := h.novelService.SetNarrationStructure(c.Request.Context(), novelID, &novel.NarrationStructure{
+		Template:         req.Template,
+		MinScenes:        req.MinScenes,
+		MaxScenes:        req.MaxScenes,
+		MinShotsPerScene: req.MinShotsPerScene,
+		MaxShotsPerScene: req.MaxShotsPerScene,
+		TargetDuration:   req.TargetDuration,
+	})
+	if err != nil {
+		respondNarrationStructureError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "解说结构已更新",
+		"data":    view,
+	})
+}
+
+// GetNarrationStructure 查询小说的解说结构
+// @Summary      查询解说结构
+// @Description  查询小说生效的解说结构（未设置时为默认的 classic 模板）及全部内置模板
+// @Tags         小说管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string  true  "小说ID"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/narration-structure [get]
+func (h *Handler) GetNarrationStructure(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	view, err := h.novelService.GetNarrationStructure(c.Request.Context(), novelID)
+	if err != nil {
+		respondNarrationStructureError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    view,
+	})
+}
+
+func respondNarrationStructureError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, noveltools.ErrInvalidNarrationStructure):
+		code = http.StatusBadRequest
+		errorCode = 40003
+	}
+
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
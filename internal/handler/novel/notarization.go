@@ -0,0 +1,178 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	novelservice "lemon/internal/service/novel"
+)
+
+// NotarizeChapterVideos 公证章节发布版本的最终视频
+// @Summary      公证章节最终视频
+// @Description  在公证登记表中登记章节发布版本的最终视频（授权版和预览版分别登记）的 SHA256。发布最终视频时会自动公证，此接口用于补登记功能上线前发布的视频；已公证的视频直接返回已有记录
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true  "章节ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  ErrorResponse  "章节不存在"
+// @Failure      409         {object}  ErrorResponse  "章节没有已发布的最终视频"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/notarizations [post]
+func (h *Handler) NotarizeChapterVideos(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	records, err := h.novelService.NotarizePromotedVideos(c.Request.Context(), chapterID)
+	if err != nil {
+		respondNotarizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "最终视频已公证",
+		"data": gin.H{
+			"chapter_id":    chapterID,
+			"notarizations": records,
+			"count":         len(records),
+		},
+	})
+}
+
+// ListChapterNotarizations 获取章节的公证记录
+// @Summary      获取章节公证记录
+// @Description  获取章节最终视频的公证记录（按序号倒序），包含文件 SHA256、哈希链信息和外部时间戳令牌（base64 编码的 RFC 3161 TimeStampToken）
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true  "章节ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  ErrorResponse  "章节不存在"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/notarizations [get]
+func (h *Handler) ListChapterNotarizations(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	records, err := h.novelService.ListChapterNotarizations(c.Request.Context(), chapterID)
+	if err != nil {
+		respondNotarizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"chapter_id":    chapterID,
+			"notarizations": records,
+			"count":         len(records),
+		},
+	})
+}
+
+// VerifyNotarization 验证视频是否已公证
+// @Summary      验证视频公证
+// @Description  上传交付的视频文件或提供文件的 SHA256，查询公证登记表。notarized=true 表示存在哈希匹配、记录哈希正确且与前后记录链接完整的公证记录；
+// @Description  匹配记录带有外部时间戳令牌时可以用 openssl ts -verify 独立验证（令牌签名的摘要为记录的 record_hash）
+// @Tags         章节管理
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file    formData  file    false  "交付的视频文件（与 sha256 二选一）"
+// @Param        sha256  formData  string  false  "视频文件的 SHA256（十六进制）"
+// @Success      200     {object}  map[string]interface{}  "成功响应"
+// @Failure      400     {object}  ErrorResponse  "请求参数错误"
+// @Failure      500     {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/notarizations/verify [post]
+func (h *Handler) VerifyNotarization(c *gin.Context) {
+	req := &novelservice.VerifyNotarizationRequest{SHA256: c.PostForm("sha256")}
+	if file, err := c.FormFile("file"); err == nil {
+		fileReader, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40002,
+				Message: "Failed to open file",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		defer fileReader.Close()
+		req.File = fileReader
+	}
+
+	result, err := h.novelService.VerifyNotarization(c.Request.Context(), req)
+	if err != nil {
+		respondNotarizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    result,
+	})
+}
+
+// VerifyNotarizationChain 校验整条公证链
+// @Summary      校验公证链
+// @Description  从第一条记录开始校验公证登记表的哈希链（序号连续、prev_hash 指向上一条记录、record_hash 与内容一致），返回链头哈希和发现的问题（最多 100 条）。开启小说权限时只有管理员可以调用
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "成功响应"
+// @Failure      500  {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/notarizations/chain [get]
+func (h *Handler) VerifyNotarizationChain(c *gin.Context) {
+	report, err := h.novelService.VerifyNotarizationChain(c.Request.Context())
+	if err != nil {
+		respondNotarizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    report,
+	})
+}
+
+// respondNotarizationError 公证接口的错误响应
+func respondNotarizationError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, novelservice.ErrInvalidNotarizationQuery):
+		code = http.StatusBadRequest
+		errorCode = 40003
+	case errors.Is(err, novelservice.ErrNothingToNotarize),
+		errors.Is(err, novelservice.ErrNotarizationConflict):
+		code = http.StatusConflict
+		errorCode = 40901
+	}
+
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,222 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	novelservice "lemon/internal/service/novel"
+)
+
+// NovelGrantInfo 小说协作授权信息
+type NovelGrantInfo struct {
+	ID         string `json:"id"`         // 授权ID
+	NovelID    string `json:"novel_id"`   // 小说ID
+	UserID     string `json:"user_id"`    // 被授权用户ID
+	Permission string `json:"permission"` // 权限：read、edit
+	GrantedBy  string `json:"granted_by"` // 授权人用户ID
+	CreatedAt  string `json:"created_at"` // 创建时间
+	UpdatedAt  string `json:"updated_at"` // 更新时间
+}
+
+func toNovelGrantInfo(g *novel.NovelGrant) NovelGrantInfo {
+	return NovelGrantInfo{
+		ID:         g.ID,
+		NovelID:    g.NovelID,
+		UserID:     g.UserID,
+		Permission: g.Permission.String(),
+		GrantedBy:  g.GrantedBy,
+		CreatedAt:  g.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  g.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// GrantNovelAccessRequest 授予小说权限请求
+type GrantNovelAccessRequest struct {
+	UserID     string                `json:"user_id" binding:"required"`    // 被授权用户ID（必填）
+	Permission novel.NovelPermission `json:"permission" binding:"required"` // 权限：read（只读）、edit（编辑）
+	GrantedBy  string                `json:"granted_by"`                    // 授权人用户ID（已登录时使用当前用户）
+}
+
+// GrantNovelAccess 授予用户小说权限
+// @Summary      授予小说权限
+// @Description  小说所有者把单本小说的只读（read）或编辑（edit）权限授予指定用户，权限作用于小说下的所有章节、解说、素材等。同一用户重复授权时更新权限。开启 auth.enforce_novel_access 后只有小说所有者和管理员可以调用
+// @Tags         小说管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string                   true  "小说ID"
+// @Param        request   body      GrantNovelAccessRequest  true  "授权信息"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      403       {object}  ErrorResponse  "不是小说所有者"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/grants [post]
+func (h *Handler) GrantNovelAccess(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	var req GrantNovelAccessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	grantedBy := req.GrantedBy
+	if userID, ok := ctxutil.GetUserID(c.Request.Context()); ok {
+		grantedBy = userID
+	}
+
+	grant, err := h.novelService.GrantNovelAccess(c.Request.Context(), &novelservice.GrantNovelAccessRequest{
+		NovelID:    novelID,
+		UserID:     req.UserID,
+		Permission: req.Permission,
+		GrantedBy:  grantedBy,
+	})
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+
+		// 根据错误类型设置错误码
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			code = http.StatusNotFound
+			errorCode = 40401
+		case errors.Is(err, novelservice.ErrInvalidNovelGrant):
+			code = http.StatusBadRequest
+			errorCode = 40003
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "授权成功",
+		"data":    toNovelGrantInfo(grant),
+	})
+}
+
+// ListNovelGrants 获取小说授权列表
+// @Summary      获取小说授权列表
+// @Description  获取小说的所有协作授权（按授权时间正序）。开启 auth.enforce_novel_access 后只有小说所有者和管理员可以调用
+// @Tags         小说管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string  true  "小说ID"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      403       {object}  ErrorResponse  "不是小说所有者"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/grants [get]
+func (h *Handler) ListNovelGrants(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	grants, err := h.novelService.ListNovelGrants(c.Request.Context(), novelID)
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			code = http.StatusNotFound
+			errorCode = 40401
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	infos := make([]NovelGrantInfo, 0, len(grants))
+	for _, g := range grants {
+		infos = append(infos, toNovelGrantInfo(g))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"novel_id": novelID,
+			"grants":   infos,
+			"count":    len(infos),
+		},
+	})
+}
+
+// RevokeNovelAccess 撤销用户小说权限
+// @Summary      撤销小说权限
+// @Description  撤销指定用户对小说的协作权限，撤销后立即生效。开启 auth.enforce_novel_access 后只有小说所有者和管理员可以调用
+// @Tags         小说管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string  true  "小说ID"
+// @Param        user_id   path      string  true  "被授权用户ID"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      403       {object}  ErrorResponse  "不是小说所有者"
+// @Failure      404       {object}  ErrorResponse  "授权不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/grants/{user_id} [delete]
+func (h *Handler) RevokeNovelAccess(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	userID := c.Param("user_id")
+	if novelID == "" || userID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id and user_id are required",
+		})
+		return
+	}
+
+	if err := h.novelService.RevokeNovelAccess(c.Request.Context(), novelID, userID); err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			code = http.StatusNotFound
+			errorCode = 40401
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "授权已撤销",
+		"data": gin.H{
+			"novel_id": novelID,
+			"user_id":  userID,
+		},
+	})
+}
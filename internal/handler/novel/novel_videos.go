@@ -0,0 +1,143 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/pkg/ctxutil"
+	novelservice "lemon/internal/service/novel"
+)
+
+// AssembleNovelVideoRequest 拼接长视频请求
+type AssembleNovelVideoRequest struct {
+	UserID         string `json:"user_id"`                                // 操作用户ID（为空时使用小说所有者）
+	ChapterFrom    int    `json:"chapter_from" binding:"omitempty,min=1"` // 起始章节序号（默认第一章）
+	ChapterTo      int    `json:"chapter_to" binding:"omitempty,min=1"`   // 结束章节序号（默认最后一章）
+	SkipTitleCards bool   `json:"skip_title_cards"`                       // 不插入章节标题画面
+}
+
+// AssembleNovelVideo 拼接长视频
+// @Summary      拼接长视频
+// @Description  按章节顺序拼接章节范围内（默认整本小说，也可以只拼接一个篇章，如第1-10章）各章节发布版本的最终视频，章节之间插入标题画面。各章节的视频统一为小说当前生成参数的分辨率和帧率（全部为授权版时使用全分辨率）后拼接，并写入章节标记。拼接作为长视频拼接任务（novel_video）提交到任务队列执行，返回处理中的视频记录（video_type=novel_video），job_id 为任务ID，可通过任务接口查询、取消和重试，完成后包含视频资源和 chapter_markers
+// @Tags         视频管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string                     true  "小说ID"
+// @Param        request   body      AssembleNovelVideoRequest  false  "拼接参数"
+// @Success      202       {object}  map[string]interface{}  "已提交拼接任务"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      409       {object}  ErrorResponse  "有章节还没有发布的最终视频"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/novel-videos [post]
+func (h *Handler) AssembleNovelVideo(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	var req AssembleNovelVideoRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40002,
+				Message: "Invalid request body",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	userID := req.UserID
+	if currentUserID, ok := ctxutil.GetUserID(ctx); ok {
+		userID = currentUserID
+	}
+
+	video, err := h.novelService.AssembleNovelVideo(ctx, &novelservice.AssembleNovelVideoRequest{
+		NovelID:        novelID,
+		UserID:         userID,
+		ChapterFrom:    req.ChapterFrom,
+		ChapterTo:      req.ChapterTo,
+		SkipTitleCards: req.SkipTitleCards,
+	})
+	if err != nil {
+		respondNovelVideoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    video,
+	})
+}
+
+// ListNovelVideos 查询小说的长视频
+// @Summary      查询长视频
+// @Description  查询小说拼接的长视频（按创建时间倒序），包含拼接状态（processing, completed, failed）和每个章节在长视频中的时间
+// @Tags         视频管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string  true   "小说ID"
+// @Param        limit     query     int     false  "返回数量（默认50，最大200）"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/novel-videos [get]
+func (h *Handler) ListNovelVideos(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	videos, err := h.novelService.ListNovelVideos(c.Request.Context(), novelID, budgetListLimit(c))
+	if err != nil {
+		respondNovelVideoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"novel_id": novelID,
+			"videos":   videos,
+			"count":    len(videos),
+		},
+	})
+}
+
+func respondNovelVideoError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, novelservice.ErrInvalidNovelVideo):
+		code = http.StatusBadRequest
+		errorCode = 40005
+	case errors.Is(err, novelservice.ErrNovelVideoNotReady):
+		code = http.StatusConflict
+		errorCode = 40901
+	}
+
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
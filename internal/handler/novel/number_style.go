@@ -0,0 +1,81 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	novelservice "lemon/internal/service/novel"
+)
+
+// SetNumberStyleRequest 设置数字写法请求
+type SetNumberStyleRequest struct {
+	NumberStyle novel.NumberStyle `json:"number_style" binding:"required"` // 数字写法：none（不转换）、chinese（转为中文数字）、arabic（转为阿拉伯数字）
+}
+
+// SetNumberStyle 设置小说的数字写法
+// @Summary      设置数字写法
+// @Description  设置小说解说文本的数字写法，生成音频和字幕前按该设置统一转换（chinese：3万 → 三万、2024年 → 二零二四年、15% → 百分之十五，便于 TTS 朗读；arabic：反向转换，成语和固定搭配保持不变）。只影响之后生成的音频和字幕
+// @Tags         小说管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string                 true  "小说ID"
+// @Param        request   body      SetNumberStyleRequest  true  "数字写法"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/number-style [put]
+func (h *Handler) SetNumberStyle(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	var req SetNumberStyleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	if err := h.novelService.SetNumberStyle(c.Request.Context(), novelID, req.NumberStyle); err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+
+		// 根据错误类型设置错误码
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			code = http.StatusNotFound
+			errorCode = 40401
+		case errors.Is(err, novelservice.ErrInvalidNumberStyle):
+			code = http.StatusBadRequest
+			errorCode = 40003
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "数字写法已更新",
+		"data": gin.H{
+			"novel_id":     novelID,
+			"number_style": req.NumberStyle,
+		},
+	})
+}
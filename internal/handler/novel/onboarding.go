@@ -0,0 +1,152 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/pkg/ctxutil"
+	"lemon/internal/pkg/noveltools"
+	novelservice "lemon/internal/service/novel"
+)
+
+// OnboardCreatorRequest 新用户开通请求
+type OnboardCreatorRequest struct {
+	UserID string `json:"user_id"` // 用户ID（已登录时使用当前用户）
+	Preset string `json:"preset"`  // 预设名称（见 GET /api/v1/onboarding/presets），不传时使用 standard
+	Reset  bool   `json:"reset"`   // 已开通时是否按预设重置创作默认配置（已创建的小说不受影响）
+}
+
+// ListOnboardingPresets 列出新用户开通可用的预设
+// @Summary      列出开通预设
+// @Description  列出新用户开通时可以选择的创作默认配置预设，每个预设包含默认的旁白类型、风格、数字写法、章节衔接、创作设定、目标平台、配音、字幕样式和片头片尾文案
+// @Tags         新用户开通
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "成功响应"
+// @Router       /api/v1/onboarding/presets [get]
+func (h *Handler) ListOnboardingPresets(c *gin.Context) {
+	presets := h.novelService.ListOnboardingPresets()
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"presets":        presets,
+			"default_preset": noveltools.DefaultOnboardingPreset,
+		},
+	})
+}
+
+// OnboardCreator 新用户开通
+// @Summary      新用户开通
+// @Description  按预设一次性为用户生成创作默认配置（视频平台、配音音色和语速、字幕样式、提示词变量、片头片尾占位文案）。之后创建的小说会复制这些默认值，第一章不需要手动配置就能完成渲染。已开通的用户返回 409，传 reset=true 按预设重置
+// @Tags         新用户开通
+// @Accept       json
+// @Produce      json
+// @Param        request  body      OnboardCreatorRequest  true  "开通请求"
+// @Success      201      {object}  map[string]interface{}  "成功响应"
+// @Failure      400      {object}  ErrorResponse  "请求参数错误或预设不存在"
+// @Failure      409      {object}  ErrorResponse  "用户已开通"
+// @Failure      500      {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/onboarding [post]
+func (h *Handler) OnboardCreator(c *gin.Context) {
+	var req OnboardCreatorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	userID := req.UserID
+	if currentUserID, ok := ctxutil.GetUserID(c.Request.Context()); ok {
+		userID = currentUserID
+	}
+
+	profile, err := h.novelService.OnboardCreator(c.Request.Context(), &novelservice.OnboardCreatorRequest{
+		UserID: userID,
+		Preset: req.Preset,
+		Reset:  req.Reset,
+	})
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+
+		// 根据错误类型设置错误码
+		switch {
+		case errors.Is(err, novelservice.ErrInvalidOnboarding):
+			code = http.StatusBadRequest
+			errorCode = 40002
+		case errors.Is(err, noveltools.ErrUnknownOnboardingPreset):
+			code = http.StatusBadRequest
+			errorCode = 40003
+		case errors.Is(err, novelservice.ErrAlreadyOnboarded):
+			code = http.StatusConflict
+			errorCode = 40901
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    0,
+		"message": "开通成功",
+		"data":    profile,
+	})
+}
+
+// GetCreatorProfile 查询用户的创作默认配置
+// @Summary      查询创作默认配置
+// @Description  查询用户开通时生成的创作默认配置
+// @Tags         新用户开通
+// @Accept       json
+// @Produce      json
+// @Param        user_id  query     string  false  "用户ID（已登录时使用当前用户）"
+// @Success      200      {object}  map[string]interface{}  "成功响应"
+// @Failure      400      {object}  ErrorResponse  "请求参数错误"
+// @Failure      404      {object}  ErrorResponse  "用户未开通"
+// @Failure      500      {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/onboarding/profile [get]
+func (h *Handler) GetCreatorProfile(c *gin.Context) {
+	userID := c.Query("user_id")
+	if currentUserID, ok := ctxutil.GetUserID(c.Request.Context()); ok {
+		userID = currentUserID
+	}
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "user_id is required",
+		})
+		return
+	}
+
+	profile, err := h.novelService.GetCreatorProfile(c.Request.Context(), userID)
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			code = http.StatusNotFound
+			errorCode = 40401
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    profile,
+	})
+}
@@ -0,0 +1,140 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	novelservice "lemon/internal/service/novel"
+)
+
+// RunChapterPipelineRequest 提交章节一键生成流程请求（请求体可省略）
+type RunChapterPipelineRequest struct {
+	FromStage novel.PipelineStage `json:"from_stage"` // 起始阶段：narration、audio、subtitle、image、narration_video、final_video（之前的步骤沿用已有产物）
+}
+
+// RunChapterPipeline 提交章节一键生成流程
+// @Summary      一键生成章节视频
+// @Description  按 解说 -> 音频 -> 字幕 -> 图片 -> 解说视频 -> 最终视频 的顺序依次生成，每一步作为一个任务提交到任务队列（失败时按任务队列的规则自动重试），上一步成功后提交下一步，提交后立即返回。
+// @Description  某一步最终失败或任务被取消时流程停止；不指定 from_stage 再次提交时从该步骤继续，已完成的步骤不再执行。指定 from_stage 时开始新的流程，之前的步骤沿用已有产物。
+// @Description  同一章节同时只能有一个执行中的流程
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string                      true   "章节ID"
+// @Param        request     body      RunChapterPipelineRequest  false  "起始阶段"
+// @Success      202         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误或起始阶段不支持"
+// @Failure      404         {object}  ErrorResponse  "章节不存在"
+// @Failure      409         {object}  ErrorResponse  "章节已有执行中的流程"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/pipeline [post]
+func (h *Handler) RunChapterPipeline(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	var req RunChapterPipelineRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40002,
+				Message: "Invalid request body",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	userID, _ := ctxutil.GetUserID(ctx)
+	run, resumed, err := h.novelService.RunChapterPipeline(ctx, &novelservice.RunChapterPipelineRequest{
+		ChapterID: chapterID,
+		FromStage: req.FromStage,
+		UserID:    userID,
+	})
+	if err != nil {
+		respondPipelineRunError(c, err)
+		return
+	}
+
+	message := "一键生成流程已提交"
+	if resumed {
+		message = "一键生成流程已从失败的步骤继续执行"
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"code":    0,
+		"message": message,
+		"data": gin.H{
+			"run":     run,
+			"resumed": resumed,
+		},
+	})
+}
+
+// GetChapterPipelineRun 查询章节一键生成流程
+// @Summary      查询一键生成流程进度
+// @Description  返回章节最近一次一键生成流程的状态、各步骤的状态（任务ID、执行次数、产物ID、失败原因）和进度百分比。
+// @Description  执行中步骤的进度按该阶段最近一次成功执行的耗时估算（最多 95%），整体进度为各步骤进度的平均值
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true  "章节ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  ErrorResponse  "章节不存在或没有提交过一键生成流程"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/pipeline/run [get]
+func (h *Handler) GetChapterPipelineRun(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	run, err := h.novelService.GetChapterPipelineRun(c.Request.Context(), chapterID)
+	if err != nil {
+		respondPipelineRunError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    run,
+	})
+}
+
+// respondPipelineRunError 请求不合法返回 400，章节或流程不存在返回 404，已有执行中的流程返回 409，其余返回 500
+func respondPipelineRunError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+	switch {
+	case errors.Is(err, novelservice.ErrInvalidPipelineRun), errors.Is(err, novelservice.ErrInvalidJob):
+		code = http.StatusBadRequest
+		errorCode = 40003
+	case errors.Is(err, mongo.ErrNoDocuments):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, novelservice.ErrPipelineRunning):
+		code = http.StatusConflict
+		errorCode = 40901
+	}
+
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
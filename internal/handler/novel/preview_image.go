@@ -0,0 +1,108 @@
+package novel
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	novelservice "lemon/internal/service/novel"
+)
+
+// PreviewImageRequest 生成预览图请求
+type PreviewImageRequest struct {
+	Prompt             string `json:"prompt" binding:"required"` // 原始 prompt
+	StylePreset        string `json:"style_preset"`              // 风格预设：guofeng_comic, anime, realistic, ink_wash, none，为空时使用小说的风格预设（未设置时为 guofeng_comic）
+	ChapterID          string `json:"chapter_id"`                // 章节ID（可选，设置后优先使用章节级风格参考图）
+	UseStyleReferences bool   `json:"use_style_references"`      // 是否带上小说/章节的风格参考图
+}
+
+// PreviewImage 生成预览图（提示词调试）
+// @Summary      生成预览图
+// @Description  按任意 prompt 和风格预设生成一张图片并直接返回（base64），用于编辑调试提示词。预览图不上传、不写入章节图片版本；生成费用计入小说花费，开启 hard_stop 且达到预算上限时返回 402
+// @Tags         图片生成
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string               true  "小说ID"
+// @Param        request   body      PreviewImageRequest  true  "预览图请求"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      402       {object}  ErrorResponse  "小说花费已达到预算上限"
+// @Failure      404       {object}  ErrorResponse  "小说或章节不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/images/preview [post]
+func (h *Handler) PreviewImage(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	var req PreviewImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	preset := novel.ImageStylePreset(req.StylePreset)
+	if preset != "" && !preset.IsValid() {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40003,
+			Message: "Invalid style_preset",
+			Detail:  "style_preset must be one of: guofeng_comic, anime, realistic, ink_wash, none",
+		})
+		return
+	}
+
+	preview, err := h.novelService.PreviewImage(c.Request.Context(), &novelservice.PreviewImageRequest{
+		NovelID:            novelID,
+		ChapterID:          req.ChapterID,
+		Prompt:             req.Prompt,
+		StylePreset:        preset,
+		UseStyleReferences: req.UseStyleReferences,
+	})
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+
+		// 根据错误类型设置错误码
+		switch {
+		case errors.Is(err, novelservice.ErrBudgetExceeded):
+			code = http.StatusPaymentRequired
+			errorCode = 40201
+		case errors.Is(err, mongo.ErrNoDocuments):
+			code = http.StatusNotFound
+			errorCode = 40401
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "预览图生成完成",
+		"data": gin.H{
+			"novel_id":            novelID,
+			"prompt":              preview.Prompt,
+			"localized_prompt":    preview.LocalizedPrompt,
+			"style_preset":        preview.StylePreset,
+			"style_reference_ids": preview.StyleReferenceIDs,
+			"content_type":        preview.ContentType,
+			"image_base64":        base64.StdEncoding.EncodeToString(preview.ImageData),
+		},
+	})
+}
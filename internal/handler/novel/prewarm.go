@@ -0,0 +1,177 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	novelservice "lemon/internal/service/novel"
+)
+
+// SchedulePrewarmRequest 创建素材预热任务请求
+type SchedulePrewarmRequest struct {
+	ChapterIDs []string  `json:"chapter_ids"`                  // 需要预热的章节ID（为空表示整部小说）
+	RenderAt   time.Time `json:"render_at" binding:"required"` // 渲染窗口开始时间（RFC3339，如 2026-01-02T02:00:00+08:00）
+	UserID     string    `json:"user_id" binding:"required"`   // 创建人用户ID（必填）
+}
+
+// SchedulePrewarm 创建素材预热任务
+// @Summary      创建素材预热任务
+// @Description  为定时渲染创建素材预热任务：在渲染窗口开始前（默认提前 1 小时，见 asset_cache.lead_time）把章节需要的图片/音频/字幕/解说视频片段下载到本地缓存，渲染时直接读取本地文件。开始时间已过时立即执行。需要配置 asset_cache.dir
+// @Tags         素材预热
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string                  true  "小说ID"
+// @Param        request   body      SchedulePrewarmRequest  true  "预热请求"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Failure      503       {object}  ErrorResponse  "未启用本地资源缓存"
+// @Router       /api/v1/novels/{novel_id}/prewarm-jobs [post]
+func (h *Handler) SchedulePrewarm(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	var req SchedulePrewarmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	job, err := h.novelService.SchedulePrewarm(c.Request.Context(), &novelservice.SchedulePrewarmRequest{
+		NovelID:    novelID,
+		ChapterIDs: req.ChapterIDs,
+		RenderAt:   req.RenderAt,
+		UserID:     req.UserID,
+	})
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+
+		// 根据错误类型设置错误码
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			code = http.StatusNotFound
+			errorCode = 40401
+		case errors.Is(err, novelservice.ErrInvalidPrewarm):
+			code = http.StatusBadRequest
+			errorCode = 40003
+		case errors.Is(err, novelservice.ErrPrewarmDisabled):
+			code = http.StatusServiceUnavailable
+			errorCode = 50301
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "预热任务已创建",
+		"data":    job,
+	})
+}
+
+// ListPrewarmJobs 查询小说的素材预热任务
+// @Summary      查询素材预热任务列表
+// @Description  查询小说的素材预热任务（按创建时间倒序），包含每个任务的缓存命中统计
+// @Tags         素材预热
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string  true   "小说ID"
+// @Param        limit     query     int     false  "返回数量（默认50，最大200）"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/prewarm-jobs [get]
+func (h *Handler) ListPrewarmJobs(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	jobs, err := h.novelService.ListPrewarmJobs(c.Request.Context(), novelID, budgetListLimit(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50001,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"novel_id":     novelID,
+			"prewarm_jobs": jobs,
+			"count":        len(jobs),
+		},
+	})
+}
+
+// GetPrewarmJob 查询素材预热任务
+// @Summary      查询素材预热任务
+// @Description  查询素材预热任务的状态和缓存命中统计（total/hits/misses/failed/bytes_staged）
+// @Tags         素材预热
+// @Accept       json
+// @Produce      json
+// @Param        prewarm_job_id  path      string  true  "预热任务ID"
+// @Success      200             {object}  map[string]interface{}  "成功响应"
+// @Failure      400             {object}  ErrorResponse  "请求参数错误"
+// @Failure      404             {object}  ErrorResponse  "预热任务不存在"
+// @Failure      500             {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/prewarm-jobs/{prewarm_job_id} [get]
+func (h *Handler) GetPrewarmJob(c *gin.Context) {
+	jobID := c.Param("prewarm_job_id")
+	if jobID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "prewarm_job_id is required",
+		})
+		return
+	}
+
+	job, err := h.novelService.GetPrewarmJob(c.Request.Context(), jobID)
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			code = http.StatusNotFound
+			errorCode = 40401
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    job,
+	})
+}
@@ -0,0 +1,76 @@
+package novel
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/model/novel"
+	novelservice "lemon/internal/service/novel"
+)
+
+// PromoteVersionsRequest 批量发布版本请求
+type PromoteVersionsRequest struct {
+	Strategy novel.PromotionStrategy                  `json:"strategy" binding:"required"` // 选择策略：latest_approved（每个已审核章节取审核时已完成的最新版本）、explicit（按 versions 指定）
+	Versions map[string]novelservice.VersionSelection `json:"versions"`                    // explicit 策略下按章节ID指定的版本，如 {"<chapter_id>": {"narration": 2, "video": 3}}，未填的产物保持原发布版本
+	DryRun   bool                                     `json:"dry_run"`                     // 只预览发布计划，不写入
+	IgnoreQA bool                                     `json:"ignore_qa"`                   // 忽略 QA 评分卡的阻断（仍返回评分卡）
+	UserID   string                                   `json:"user_id" binding:"required"`  // 操作人用户ID（必填）
+}
+
+// PromoteNovelVersions 批量发布小说版本
+// @Summary      批量发布小说版本
+// @Description  为整部小说批量发布各章节的解说/图片/音频/字幕/最终视频版本。先为所有章节生成发布计划并校验（指定的版本必须存在且全部生成完成），任一章节校验失败则整体不写入；写入中途失败时已写入的章节会回滚；章节的发布版本在发布期间被其他操作修改时返回 409。每个版本有变化的章节追加一条发布记录，返回的 batch_id 可用于整批回滚，发布完成后推送 promotion.promoted 事件。版本有变化的章节会计算发布后版本的 QA 评分卡（chapters[].qa），评分卡阻断的章节跳过发布，ignore_qa=true 时不阻断。同时扫描发布后解说版本的内容风险（chapters[].content_risk），存在风险且编辑尚未确认的章节同样跳过发布（不受 ignore_qa 影响）。dry_run=true 时只返回发布计划
+// @Tags         小说管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string                  true  "小说ID"
+// @Param        request   body      PromoteVersionsRequest  true  "发布请求"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误或指定的版本不可发布"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      409       {object}  ErrorResponse  "发布版本已被其他操作修改"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/promote-versions [post]
+func (h *Handler) PromoteNovelVersions(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	var req PromoteVersionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	plan, err := h.novelService.PromoteNovelVersions(c.Request.Context(), novelID, &novelservice.PromoteVersionsRequest{
+		Strategy:   req.Strategy,
+		Versions:   req.Versions,
+		DryRun:     req.DryRun,
+		IgnoreQA:   req.IgnoreQA,
+		PromotedBy: req.UserID,
+	})
+	if err != nil {
+		h.respondPromotionError(c, err)
+		return
+	}
+
+	message := "版本发布成功"
+	if plan.DryRun {
+		message = "发布计划预览"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": message,
+		"data":    plan,
+	})
+}
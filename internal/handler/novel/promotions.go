@@ -0,0 +1,227 @@
+package novel
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	novelservice "lemon/internal/service/novel"
+)
+
+// RollbackPromotionRequest 回滚发布请求
+type RollbackPromotionRequest struct {
+	UserID string `json:"user_id" binding:"required"` // 操作人用户ID（必填）
+	Reason string `json:"reason"`                     // 回滚原因
+}
+
+// ListChapterPromotions 获取章节发布记录
+// @Summary      获取章节发布记录
+// @Description  获取章节的发布与回滚记录（按时间倒序），每条记录包含变更前后的解说/图片/音频/字幕/最终视频版本；rolled_back 表示该记录已被回滚
+// @Tags         小说管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true  "章节ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  ErrorResponse  "章节不存在"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/promotions [get]
+func (h *Handler) ListChapterPromotions(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	entries, err := h.novelService.ListChapterPromotions(c.Request.Context(), chapterID)
+	if err != nil {
+		h.respondPromotionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"chapter_id": chapterID,
+			"promotions": entries,
+			"count":      len(entries),
+		},
+	})
+}
+
+// RollbackChapterPromotion 回滚章节发布
+// @Summary      回滚章节发布
+// @Description  把章节恢复到最近一次仍然生效的发布之前的版本（解说/图片/音频/字幕/最终视频一起恢复，首次发布被回滚后章节回到未发布状态）。重复调用逐次向前回滚；回滚会追加一条回滚记录并推送 promotion.rolled_back 事件
+// @Tags         小说管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string                    true  "章节ID"
+// @Param        request     body      RollbackPromotionRequest  true  "回滚请求"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  ErrorResponse  "章节不存在"
+// @Failure      409         {object}  ErrorResponse  "没有可回滚的发布，或发布版本已被其他操作修改"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/promotions/rollback [post]
+func (h *Handler) RollbackChapterPromotion(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	var req RollbackPromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	result, err := h.novelService.RollbackChapterPromotion(c.Request.Context(), chapterID, &novelservice.RollbackPromotionRequest{
+		OperatedBy: req.UserID,
+		Reason:     req.Reason,
+	})
+	if err != nil {
+		h.respondPromotionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "章节发布已回滚",
+		"data":    result,
+	})
+}
+
+// RollbackPromotionBatch 回滚批量发布
+// @Summary      回滚批量发布
+// @Description  回滚一次批量发布（batch_id 为批量发布接口返回的批次ID）涉及的所有章节。先校验全部章节，任一章节之后又发布过新版本时整体不回滚；写入中途失败时已回滚的章节会恢复。已被单独回滚的章节跳过
+// @Tags         小说管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string                    true  "小说ID"
+// @Param        batch_id  path      string                    true  "发布批次ID"
+// @Param        request   body      RollbackPromotionRequest  true  "回滚请求"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      404       {object}  ErrorResponse  "章节不存在"
+// @Failure      409       {object}  ErrorResponse  "没有可回滚的发布，或批次中的章节之后又发布过新版本"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/promotions/{batch_id}/rollback [post]
+func (h *Handler) RollbackPromotionBatch(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	batchID := c.Param("batch_id")
+	if novelID == "" || batchID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id and batch_id are required",
+		})
+		return
+	}
+
+	var req RollbackPromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	result, err := h.novelService.RollbackPromotionBatch(c.Request.Context(), novelID, batchID, &novelservice.RollbackPromotionRequest{
+		OperatedBy: req.UserID,
+		Reason:     req.Reason,
+	})
+	if err != nil {
+		h.respondPromotionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "批量发布已回滚",
+		"data":    result,
+	})
+}
+
+// StreamPromotionEvents 订阅小说发布事件（SSE）
+// @Summary      订阅小说发布事件
+// @Description  以 Server-Sent Events 推送小说的发布与回滚事件：批量发布完成时推送 promotion.promoted，回滚完成时推送 promotion.rolled_back，事件数据包含每个变更章节的发布记录（next 为变更后的发布版本）。连接保持到客户端断开
+// @Description  订阅前发生的变更不会重放，请通过章节发布记录接口补齐
+// @Tags         小说管理
+// @Produce      text/event-stream
+// @Param        novel_id  path      string  true  "小说ID"
+// @Success      200       {string}  string         "事件流"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Router       /api/v1/novels/{novel_id}/promotions/events [get]
+func (h *Handler) StreamPromotionEvents(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	events, cancel := h.novelService.SubscribePromotionEvents(novelID)
+	defer cancel()
+
+	heartbeat := time.NewTicker(narrationEventsHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			c.SSEvent("heartbeat", time.Now().Format(time.RFC3339))
+			return true
+		case evt, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(evt.Type, evt.Data)
+			return true
+		}
+	})
+}
+
+func (h *Handler) respondPromotionError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, novelservice.ErrInvalidPromotion):
+		code = http.StatusBadRequest
+		errorCode = 40003
+	case errors.Is(err, novelservice.ErrPromotionVersionNotFound):
+		code = http.StatusBadRequest
+		errorCode = 40004
+	case errors.Is(err, novelservice.ErrPromotionConflict),
+		errors.Is(err, novelservice.ErrNoPromotionToRollback):
+		code = http.StatusConflict
+		errorCode = 40901
+	}
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,242 @@
+package novel
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/pkg/noveltools"
+	novelservice "lemon/internal/service/novel"
+)
+
+// UpsertPronunciationRequest 添加或更新发音词典条目请求
+type UpsertPronunciationRequest struct {
+	Term        string `json:"term" binding:"required"` // 词（人名、功法名等）
+	Pinyin      string `json:"pinyin"`                  // 带声调数字的拼音，音节之间用空格分隔（如 "mo4 qi2"）
+	Replacement string `json:"replacement"`             // 同音替换文本（TTS 不支持发音标注时使用）
+	Note        string `json:"note"`                    // 备注
+}
+
+// TestPronunciationRequest 试听发音请求
+type TestPronunciationRequest struct {
+	Text string `json:"text"` // 试听文本，为空时朗读词典中的所有词
+}
+
+// UpsertPronunciation 添加或更新发音词典条目
+// @Summary      添加或更新发音词典条目
+// @Description  为小说的发音词典添加一个容易读错的词（人名、功法名等），同一个词已存在时更新。拼音使用带声调数字的写法（如 "mo4 qi2"，音节数与字数一致），TTS 支持发音标注时按拼音朗读；replacement 为同音替换文本，TTS 不支持发音标注或没有拼音时合成前替换原词（字幕仍显示原词）。拼音和替换文本至少填一项，之后生成的音频生效
+// @Tags         音频生成
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string                      true  "小说ID"
+// @Param        request   body      UpsertPronunciationRequest  true  "词典条目"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/pronunciations [post]
+func (h *Handler) UpsertPronunciation(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	var req UpsertPronunciationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	entry, err := h.novelService.UpsertPronunciation(c.Request.Context(), &novelservice.UpsertPronunciationRequest{
+		NovelID:     novelID,
+		Term:        req.Term,
+		Pinyin:      req.Pinyin,
+		Replacement: req.Replacement,
+		Note:        req.Note,
+	})
+	if err != nil {
+		h.respondPronunciationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "发音词典已更新",
+		"data":    entry,
+	})
+}
+
+// ListPronunciations 获取小说发音词典
+// @Summary      获取发音词典
+// @Description  获取小说发音词典中的所有词（按词排序）
+// @Tags         音频生成
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string  true  "小说ID"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/pronunciations [get]
+func (h *Handler) ListPronunciations(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	entries, err := h.novelService.ListPronunciations(c.Request.Context(), novelID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50001,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"novel_id":       novelID,
+			"pronunciations": entries,
+			"count":          len(entries),
+		},
+	})
+}
+
+// DeletePronunciation 删除发音词典条目
+// @Summary      删除发音词典条目
+// @Description  从小说发音词典中删除一个词，之后生成的音频不再纠正该词的读音
+// @Tags         音频生成
+// @Accept       json
+// @Produce      json
+// @Param        entry_id  path      string  true  "词典条目ID"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      404       {object}  ErrorResponse  "词典条目不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/pronunciations/{entry_id} [delete]
+func (h *Handler) DeletePronunciation(c *gin.Context) {
+	entryID := c.Param("entry_id")
+	if entryID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "entry_id is required",
+		})
+		return
+	}
+
+	if err := h.novelService.DeletePronunciation(c.Request.Context(), entryID); err != nil {
+		h.respondPronunciationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "发音词典条目已删除",
+		"data": gin.H{
+			"entry_id": entryID,
+		},
+	})
+}
+
+// TestPronunciation 试听发音
+// @Summary      试听发音
+// @Description  按小说的配音设置和发音词典合成一段试听音频（不保存），返回实际提交给 TTS 的文本、拼音标注和每个命中词的纠正方式（phoneme 按拼音朗读，substitution 同音替换）。text 为空时朗读词典中的所有词；生成前检查小说预算，费用计入小说花费
+// @Tags         音频生成
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string                    true  "小说ID"
+// @Param        request   body      TestPronunciationRequest  false  "试听文本"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      402       {object}  ErrorResponse  "小说花费已达到预算上限"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/pronunciations/test [post]
+func (h *Handler) TestPronunciation(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	var req TestPronunciationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40002,
+				Message: "Invalid request body",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	test, err := h.novelService.TestPronunciation(c.Request.Context(), &novelservice.TestPronunciationRequest{
+		NovelID: novelID,
+		Text:    req.Text,
+	})
+	if err != nil {
+		h.respondPronunciationError(c, err)
+		return
+	}
+
+	matched := make([]string, 0, len(test.Matched))
+	for _, hint := range test.Matched {
+		matched = append(matched, hint.Term)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "试听音频生成完成",
+		"data": gin.H{
+			"novel_id":     novelID,
+			"text":         test.Text,
+			"spoken_text":  test.SpokenText,
+			"ssml":         test.SSML,
+			"matched":      matched,
+			"methods":      test.Methods,
+			"duration":     test.Duration,
+			"content_type": test.ContentType,
+			"audio_base64": base64.StdEncoding.EncodeToString(test.AudioData),
+		},
+	})
+}
+
+func (h *Handler) respondPronunciationError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, noveltools.ErrInvalidPronunciation),
+		errors.Is(err, novelservice.ErrInvalidPronunciationTest):
+		code = http.StatusBadRequest
+		errorCode = 40003
+	case errors.Is(err, novelservice.ErrBudgetExceeded):
+		code = http.StatusPaymentRequired
+		errorCode = 40201
+	}
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,83 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	novelservice "lemon/internal/service/novel"
+)
+
+// GetChapterQAScorecardRequest 章节 QA 评分卡查询参数（版本为空时取最新版本）
+type GetChapterQAScorecardRequest struct {
+	NarrationVersion int `form:"narration_version" binding:"min=0"` // 解说版本
+	ImageVersion     int `form:"image_version" binding:"min=0"`     // 图片版本
+	AudioVersion     int `form:"audio_version" binding:"min=0"`     // 音频版本
+	VideoVersion     int `form:"video_version" binding:"min=0"`     // 最终视频版本
+}
+
+// GetChapterQAScorecard 获取章节的 QA 评分卡
+// @Summary      获取章节的 QA 评分卡
+// @Description  汇总解说文案校验（字数、分镜数量、开头特写、近似重复镜头）、字幕与音频同步、视频片段和最终视频质检（状态、时长、平台规范）、镜头图片质检，计算 0-100 的总分和 A-F 等级。每个警告扣 10 分，阻断问题使该信号记 0 分；存在阻断问题或总分低于 QA_MIN_PROMOTION_SCORE（默认 60）时 blocked=true，批量发布版本时跳过该章节。语音识别校验尚未接入，不计入评分
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id         path      string  true   "章节ID"
+// @Param        narration_version  query     int     false  "解说版本（默认最新版本）"
+// @Param        image_version      query     int     false  "图片版本（默认最新版本）"
+// @Param        audio_version      query     int     false  "音频版本（默认最新版本）"
+// @Param        video_version      query     int     false  "最终视频版本（默认最新版本）"
+// @Success      200                {object}  map[string]interface{}  "成功响应"
+// @Failure      400                {object}  ErrorResponse  "请求参数错误"
+// @Failure      404                {object}  ErrorResponse  "章节不存在"
+// @Failure      500                {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/qa-scorecard [get]
+func (h *Handler) GetChapterQAScorecard(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	var req GetChapterQAScorecardRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid query parameters",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	card, err := h.novelService.GetChapterQAScorecard(c.Request.Context(), chapterID, novelservice.VersionSelection{
+		Narration: req.NarrationVersion,
+		Image:     req.ImageVersion,
+		Audio:     req.AudioVersion,
+		Video:     req.VideoVersion,
+	})
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			code = http.StatusNotFound
+			errorCode = 40401
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    card,
+	})
+}
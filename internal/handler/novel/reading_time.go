@@ -0,0 +1,98 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	novelservice "lemon/internal/service/novel"
+)
+
+// EstimateChapterReadingTime 估算章节解说的朗读时长
+// @Summary      估算章节朗读时长
+// @Description  在生成配音前按小说的配音语速估算当前解说每个镜头的朗读时长（字数 ÷ 朗读速度，加上标点停顿），不调用 TTS。朗读时长低于 1.5 秒或超过 12 秒（图生视频上限）的镜头在 warnings 中提示
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true  "章节ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  ErrorResponse  "章节没有解说"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/reading-time [get]
+func (h *Handler) EstimateChapterReadingTime(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	estimate, err := h.novelService.EstimateChapterReadingTime(c.Request.Context(), chapterID)
+	if err != nil {
+		h.respondReadingTimeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    estimate,
+	})
+}
+
+// RenderStoryboardPreview 生成无声分镜预览视频
+// @Summary      生成无声分镜预览
+// @Description  不生成配音，用镜头图片和按估算朗读时长排列的字幕生成一段无声预览视频，用于在付费生成配音前检查节奏。使用小说的字幕样式和目标平台安全区，返回预览视频的临时下载链接；预览不影响章节的任何版本。需要先生成镜头图片
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true  "章节ID"
+// @Success      201         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "章节没有可朗读的镜头或镜头缺少图片"
+// @Failure      404         {object}  ErrorResponse  "章节没有解说"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/storyboard-preview [post]
+func (h *Handler) RenderStoryboardPreview(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	preview, err := h.novelService.RenderStoryboardPreview(c.Request.Context(), chapterID)
+	if err != nil {
+		h.respondReadingTimeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    0,
+		"message": "分镜预览生成完成",
+		"data":    preview,
+	})
+}
+
+func (h *Handler) respondReadingTimeError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, novelservice.ErrInvalidStoryboardPreview):
+		code = http.StatusBadRequest
+		errorCode = 40003
+	}
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
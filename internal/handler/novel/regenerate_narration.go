@@ -0,0 +1,112 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	novelservice "lemon/internal/service/novel"
+)
+
+// RegenerateNarrationWithFeedbackRequest 按审核意见重新生成解说请求
+type RegenerateNarrationWithFeedbackRequest struct {
+	Feedback     string   `json:"feedback" binding:"required"` // 审核意见（必填），如「少一些煽情，第3场对白保持原文」
+	SceneNumbers []string `json:"scene_numbers,omitempty"`     // 需要按意见重写的场景编号（可选，为空表示全部场景）
+	ReviewerID   string   `json:"reviewer_id,omitempty"`       // 审核人ID（可选，登录用户优先）
+}
+
+// RegenerateNarrationWithFeedbackResponseData 按审核意见重新生成解说响应数据
+type RegenerateNarrationWithFeedbackResponseData struct {
+	ChapterID   string                   `json:"chapter_id"`   // 章节ID
+	NarrationID string                   `json:"narration_id"` // 新解说ID
+	Version     int                      `json:"version"`      // 新解说版本号
+	Feedback    *novel.NarrationFeedback `json:"feedback"`     // 促成该版本的审核意见
+}
+
+// RegenerateNarrationWithFeedback 按审核意见重新生成解说
+// @Summary      按审核意见重新生成解说
+// @Description  审核人提交修改意见，目标场景连同审核意见交给 LLM 重写，其余场景原样保留，生成新的解说版本并记录审核意见
+// @Tags         解说管理
+// @Accept       json
+// @Produce      json
+// @Param        narration_id  path      string                                  true  "基础解说ID"
+// @Param        request       body      RegenerateNarrationWithFeedbackRequest  true  "请求体"
+// @Success      200           {object}  map[string]interface{}                  "成功响应"
+// @Failure      400           {object}  ErrorResponse                           "请求参数错误"
+// @Failure      402           {object}  ErrorResponse                           "小说花费已达到预算上限"
+// @Failure      404           {object}  ErrorResponse                           "解说或场景不存在"
+// @Failure      500           {object}  ErrorResponse                           "服务器内部错误"
+// @Router       /api/v1/narrations/{narration_id}/regenerate [post]
+func (h *Handler) RegenerateNarrationWithFeedback(c *gin.Context) {
+	narrationID := c.Param("narration_id")
+	if narrationID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "narration_id is required",
+		})
+		return
+	}
+
+	var req RegenerateNarrationWithFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	reviewerID := req.ReviewerID
+	if userID, ok := ctxutil.GetUserID(c.Request.Context()); ok {
+		reviewerID = userID
+	}
+
+	n, err := h.novelService.RegenerateNarrationWithFeedback(c.Request.Context(), &novelservice.RegenerateNarrationWithFeedbackRequest{
+		NarrationID:  narrationID,
+		ReviewerID:   reviewerID,
+		Feedback:     req.Feedback,
+		SceneNumbers: req.SceneNumbers,
+	})
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+
+		// 根据错误类型设置错误码
+		switch {
+		case errors.Is(err, novelservice.ErrEmptyNarrationFeedback):
+			code = http.StatusBadRequest
+			errorCode = 40003
+		case errors.Is(err, novelservice.ErrBudgetExceeded):
+			code = http.StatusPaymentRequired
+			errorCode = 40201
+		case errors.Is(err, mongo.ErrNoDocuments):
+			code = http.StatusNotFound
+			errorCode = 40401
+		case errors.Is(err, novelservice.ErrFeedbackSceneNotFound):
+			code = http.StatusNotFound
+			errorCode = 40402
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": RegenerateNarrationWithFeedbackResponseData{
+			ChapterID:   n.ChapterID,
+			NarrationID: n.ID,
+			Version:     n.Version,
+			Feedback:    n.Feedback,
+		},
+	})
+}
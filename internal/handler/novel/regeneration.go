@@ -0,0 +1,111 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	novelservice "lemon/internal/service/novel"
+)
+
+// ListNarrationRegenerations 查询解说下场景和镜头的重新生成次数
+// @Summary      查询重新生成次数
+// @Description  查询解说下重新生成过的场景（生成图片变体）和镜头（重新生成脚本、重新渲染片段预览）的次数和限制状态。level 表示再重新生成一次时的状态：warning 为接近上限，exceeded 为需要管理员/审核员通过 override_reason 越权
+// @Tags         分镜头管理
+// @Accept       json
+// @Produce      json
+// @Param        narration_id  path      string  true  "解说ID"
+// @Success      200           {object}  map[string]interface{}  "成功响应"
+// @Failure      400           {object}  ErrorResponse  "请求参数错误"
+// @Failure      500           {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/narrations/{narration_id}/regenerations [get]
+func (h *Handler) ListNarrationRegenerations(c *gin.Context) {
+	narrationID := c.Param("narration_id")
+	if narrationID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "narration_id is required",
+		})
+		return
+	}
+
+	statuses, err := h.novelService.ListNarrationRegenerations(c.Request.Context(), narrationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50001,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"narration_id":  narrationID,
+			"regenerations": statuses,
+			"count":         len(statuses),
+		},
+	})
+}
+
+// ListRegenerationOverrides 查询重新生成越权记录
+// @Summary      查询重新生成越权记录
+// @Description  查询小说中超过重新生成次数上限后越权重新生成的审计记录（操作人、角色、原因，按时间倒序）
+// @Tags         预算
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string  true   "小说ID"
+// @Param        limit     query     int     false  "返回数量（默认50，最大200）"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/regeneration-overrides [get]
+func (h *Handler) ListRegenerationOverrides(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	overrides, err := h.novelService.ListRegenerationOverrides(c.Request.Context(), novelID, budgetListLimit(c))
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			code = http.StatusNotFound
+			errorCode = 40401
+		}
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"novel_id":  novelID,
+			"overrides": overrides,
+			"count":     len(overrides),
+		},
+	})
+}
+
+// regenerationStatus 重新生成成功后查询产物的最新次数，查询失败时不影响响应
+func (h *Handler) regenerationStatus(c *gin.Context, artifact novel.RegenerationArtifact, artifactID string) *novelservice.RegenerationStatus {
+	status, err := h.novelService.GetRegenerationStatus(c.Request.Context(), artifact, artifactID)
+	if err != nil {
+		return nil
+	}
+	return status
+}
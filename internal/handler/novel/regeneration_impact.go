@@ -0,0 +1,81 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	novelservice "lemon/internal/service/novel"
+)
+
+// GetRegenerationImpactRequest 重新生成影响分析查询参数
+type GetRegenerationImpactRequest struct {
+	Stage novel.PipelineStage `form:"stage"` // 准备重新生成的阶段，默认 narration
+}
+
+// GetRegenerationImpact 分析重新生成的影响范围
+// @Summary      分析重新生成的影响范围
+// @Description  在重新生成章节的某个阶段（narration、audio、subtitle、image、narration_video、final_video）之前，沿产物依赖关系列出需要重建的下游阶段：每个阶段当前最新版本将失效的产物数量、是否为发布版本、预计费用（分）和耗时。
+// @Description  费用按当前单价和当前解说的字数、镜头数、音频时长估算，耗时取该阶段最近一次成功执行的耗时（incomplete_history=true 表示有阶段没有执行记录）；最终视频需要重建时还会列出使用本章最终视频做衔接帧的下一章图片。只查询，不生成任何产物
+// @Tags         章节管理
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true   "章节ID"
+// @Param        stage       query     string  false  "准备重新生成的阶段（默认 narration）"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  ErrorResponse  "章节不存在"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/regeneration-impact [get]
+func (h *Handler) GetRegenerationImpact(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	var req GetRegenerationImpactRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid query parameters",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if req.Stage == "" {
+		req.Stage = novel.PipelineStageNarration
+	}
+
+	impact, err := h.novelService.AnalyzeRegenerationImpact(c.Request.Context(), chapterID, req.Stage)
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			code = http.StatusNotFound
+			errorCode = 40401
+		case errors.Is(err, novelservice.ErrInvalidImpactStage):
+			code = http.StatusBadRequest
+			errorCode = 40003
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    impact,
+	})
+}
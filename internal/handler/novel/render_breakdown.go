@@ -0,0 +1,44 @@
+package novel
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRenderBreakdown 查询章节渲染的耗时与费用明细
+// @Summary      查询章节渲染明细
+// @Description  汇总章节渲染各阶段（解说、音频、字幕、图片、解说视频、最终视频）最近一次成功执行的耗时、provider 调用次数、上传/下载字节数和费用（分）
+// @Tags         视频生成
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true  "章节ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/render-breakdown [get]
+func (h *Handler) GetRenderBreakdown(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	breakdown, err := h.novelService.GetRenderBreakdown(c.Request.Context(), chapterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50001,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    breakdown,
+	})
+}
@@ -0,0 +1,137 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	novelservice "lemon/internal/service/novel"
+)
+
+// ReorderScenesRequest 重排场景请求
+type ReorderScenesRequest struct {
+	SceneIDs []string `json:"scene_ids" binding:"required"` // 按新顺序排列的场景ID（必须包含解说的所有场景各一次）
+}
+
+// ReorderShotsRequest 重排镜头请求
+type ReorderShotsRequest struct {
+	ShotIDs []string `json:"shot_ids" binding:"required"` // 按新顺序排列的镜头ID（必须包含场景的所有镜头各一次）
+}
+
+// ReorderScenes 重排解说中的场景
+// @Summary      重排场景
+// @Description  按给定的场景ID顺序重排解说中的场景，场景编号/序号和镜头的全局索引按新顺序重新编号；已生成的图片、音频、字幕、解说视频按镜头ID重新关联（合并片段需重新生成）
+// @Tags         分镜头管理
+// @Accept       json
+// @Produce      json
+// @Param        narration_id  path      string                true  "解说ID"
+// @Param        request       body      ReorderScenesRequest  true  "请求体"
+// @Success      200           {object}  map[string]interface{}  "成功响应"
+// @Failure      400           {object}  ErrorResponse  "请求参数错误或场景ID列表与现有场景不一致"
+// @Failure      404           {object}  ErrorResponse  "解说不存在"
+// @Failure      500           {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/narrations/{narration_id}/scenes/order [put]
+func (h *Handler) ReorderScenes(c *gin.Context) {
+	narrationID := c.Param("narration_id")
+	if narrationID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "narration_id is required",
+		})
+		return
+	}
+
+	var req ReorderScenesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	scenes, err := h.novelService.ReorderScenes(c.Request.Context(), narrationID, req.SceneIDs)
+	if err != nil {
+		h.respondReorderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"narration_id": narrationID,
+			"scenes":       scenes,
+		},
+	})
+}
+
+// ReorderShots 重排场景内的镜头
+// @Summary      重排镜头
+// @Description  按给定的镜头ID顺序重排场景内的镜头，镜头编号/序号和整个解说的全局索引按新顺序重新编号；已生成的图片、音频、字幕、解说视频按镜头ID重新关联（合并片段需重新生成）
+// @Tags         分镜头管理
+// @Accept       json
+// @Produce      json
+// @Param        scene_id  path      string               true  "场景ID"
+// @Param        request   body      ReorderShotsRequest  true  "请求体"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误或镜头ID列表与现有镜头不一致"
+// @Failure      404       {object}  ErrorResponse  "场景不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/scenes/{scene_id}/shots/order [put]
+func (h *Handler) ReorderShots(c *gin.Context) {
+	sceneID := c.Param("scene_id")
+	if sceneID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "scene_id is required",
+		})
+		return
+	}
+
+	var req ReorderShotsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	shots, err := h.novelService.ReorderShots(c.Request.Context(), sceneID, req.ShotIDs)
+	if err != nil {
+		h.respondReorderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"scene_id": sceneID,
+			"shots":    shots,
+		},
+	})
+}
+
+// respondReorderError 按错误类型返回重排失败的响应
+func (h *Handler) respondReorderError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, novelservice.ErrInvalidReorder):
+		code = http.StatusBadRequest
+		errorCode = 40003
+	}
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
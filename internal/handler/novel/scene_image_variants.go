@@ -0,0 +1,191 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	novelservice "lemon/internal/service/novel"
+)
+
+// SceneImageVariantInfo 场景图片变体 DTO
+type SceneImageVariantInfo struct {
+	ID               string `json:"id"`                          // 变体ID
+	SceneID          string `json:"scene_id"`                    // 场景ID
+	NarrationID      string `json:"narration_id"`                // 解说ID
+	ParentResourceID string `json:"parent_resource_id"`          // 父图片（原场景图片）的 resource_id
+	ImageResourceID  string `json:"image_resource_id,omitempty"` // 变体图片的 resource_id
+	Variant          string `json:"variant"`                     // 变体类型：night, dusk, rain
+	Status           string `json:"status"`                      // 状态：pending, completed, failed
+	ErrorMessage     string `json:"error_message,omitempty"`     // 错误信息（失败时）
+	CreatedAt        string `json:"created_at"`                  // 创建时间
+}
+
+func toSceneImageVariantInfo(v *novel.SceneImageVariant) SceneImageVariantInfo {
+	return SceneImageVariantInfo{
+		ID:               v.ID,
+		SceneID:          v.SceneID,
+		NarrationID:      v.NarrationID,
+		ParentResourceID: v.ParentResourceID,
+		ImageResourceID:  v.ImageResourceID,
+		Variant:          string(v.Variant),
+		Status:           string(v.Status),
+		ErrorMessage:     v.ErrorMessage,
+		CreatedAt:        v.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// GenerateSceneImageVariantsRequest 生成场景图片变体请求
+type GenerateSceneImageVariantsRequest struct {
+	Variants []string `json:"variants"` // 变体类型列表：night, dusk, rain（为空则生成全部）
+}
+
+// GenerateSceneImageVariants 生成场景图片的重新打光变体
+// @Summary      生成场景图片重新打光变体
+// @Description  基于场景已生成的图片，通过图生图生成夜晚/黄昏/雨天等重新打光的变体，变体通过 parent_resource_id 关联原图片，保证画面连续性。单个变体失败不影响其他变体。每次生成计入场景的重新生成次数（响应中的 regeneration），超过上限后需要管理员/审核员填写 override_reason 越权
+// @Tags         图片生成
+// @Accept       json
+// @Produce      json
+// @Param        scene_id         path      string                             true   "场景ID"
+// @Param        request          body      GenerateSceneImageVariantsRequest  false  "变体类型"
+// @Param        override_reason  query     string                             false  "越过重新生成次数上限的原因（需要管理员/审核员角色）"
+// @Success      200              {object}  map[string]interface{}  "成功响应"
+// @Failure      400              {object}  ErrorResponse  "请求参数错误（如场景图片尚未生成）"
+// @Failure      402              {object}  ErrorResponse  "小说花费已达到预算上限"
+// @Failure      403              {object}  ErrorResponse  "重新生成次数超过上限"
+// @Failure      404              {object}  ErrorResponse  "场景不存在"
+// @Failure      500              {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/scenes/{scene_id}/images/variants [post]
+func (h *Handler) GenerateSceneImageVariants(c *gin.Context) {
+	sceneID := c.Param("scene_id")
+	if sceneID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "scene_id is required",
+		})
+		return
+	}
+
+	var req GenerateSceneImageVariantsRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40002,
+				Message: "Invalid request body",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	variants := make([]novel.RelightVariant, 0, len(req.Variants))
+	for _, v := range req.Variants {
+		variant := novel.RelightVariant(v)
+		if !variant.IsValid() {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40003,
+				Message: "Invalid variant",
+				Detail:  "variant must be one of: night, dusk, rain",
+			})
+			return
+		}
+		variants = append(variants, variant)
+	}
+
+	ctx := c.Request.Context()
+
+	// 调用Service层
+	results, err := h.novelService.GenerateSceneImageVariants(ctx, sceneID, variants)
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+
+		// 根据错误类型设置错误码
+		switch {
+		case errors.Is(err, novelservice.ErrBudgetExceeded):
+			code = http.StatusPaymentRequired
+			errorCode = 40201
+		case errors.Is(err, mongo.ErrNoDocuments):
+			code = http.StatusNotFound
+			errorCode = 40401
+		case errors.Is(err, novelservice.ErrSceneImageNotReady):
+			code = http.StatusBadRequest
+			errorCode = 40004
+		case errors.Is(err, novelservice.ErrRegenerationLimitExceeded):
+			code = http.StatusForbidden
+			errorCode = 40304
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	infos := make([]SceneImageVariantInfo, 0, len(results))
+	for _, v := range results {
+		infos = append(infos, toSceneImageVariantInfo(v))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "场景图片变体生成完成",
+		"data": gin.H{
+			"scene_id":     sceneID,
+			"variants":     infos,
+			"regeneration": h.regenerationStatus(c, novel.RegenerationArtifactScene, sceneID),
+		},
+	})
+}
+
+// ListSceneImageVariants 获取场景图片变体列表
+// @Summary      获取场景图片变体列表
+// @Description  获取场景的所有重新打光图片变体（按创建时间倒序）
+// @Tags         图片生成
+// @Accept       json
+// @Produce      json
+// @Param        scene_id  path      string  true  "场景ID"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/scenes/{scene_id}/images/variants [get]
+func (h *Handler) ListSceneImageVariants(c *gin.Context) {
+	sceneID := c.Param("scene_id")
+	if sceneID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "scene_id is required",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	variants, err := h.novelService.ListSceneImageVariants(ctx, sceneID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50001,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	infos := make([]SceneImageVariantInfo, 0, len(variants))
+	for _, v := range variants {
+		infos = append(infos, toSceneImageVariantInfo(v))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"scene_id": sceneID,
+			"variants": infos,
+			"count":    len(infos),
+		},
+	})
+}
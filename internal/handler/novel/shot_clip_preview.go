@@ -0,0 +1,233 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	novelservice "lemon/internal/service/novel"
+)
+
+// PreviewShotClipRequest 单镜头片段预览请求
+type PreviewShotClipRequest struct {
+	VideoPrompt string `json:"video_prompt" binding:"required"` // 编辑后的视频提示词
+}
+
+// PreviewShotClip 按编辑后的 video_prompt 重新渲染单个镜头的片段
+// @Summary      预览单镜头片段
+// @Description  按编辑后的 video_prompt 只重新渲染该镜头的片段，沿用镜头已有的音频和字幕，返回预览片段的临时下载链接。预览不修改镜头，也不改变章节的视频版本；确认后才生成新版本。镜头包含在合并片段中时不能单独预览。预览 24 小时内有效。预览使用快速编码预设并复用本地缓存的素材和图生视频结果（同一图片、提示词和时长不重复调用图生视频），响应中的 latency_ms 为本次预览的耗时。每次预览计入镜头的重新生成次数（响应中的 regeneration），超过上限后需要管理员/审核员填写 override_reason 越权
+// @Tags         分镜头管理
+// @Accept       json
+// @Produce      json
+// @Param        shot_id          path      string                  true   "镜头ID"
+// @Param        request          body      PreviewShotClipRequest  true   "请求体"
+// @Param        override_reason  query     string                  false  "越过重新生成次数上限的原因（需要管理员/审核员角色）"
+// @Success      201              {object}  map[string]interface{}  "成功响应"
+// @Failure      400              {object}  ErrorResponse  "请求参数错误或镜头没有可替换的单镜头片段"
+// @Failure      402              {object}  ErrorResponse  "小说花费已达到预算上限"
+// @Failure      403              {object}  ErrorResponse  "重新生成次数超过上限"
+// @Failure      404              {object}  ErrorResponse  "镜头不存在"
+// @Failure      500              {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/shots/{shot_id}/clip-previews [post]
+func (h *Handler) PreviewShotClip(c *gin.Context) {
+	shotID := c.Param("shot_id")
+	if shotID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "shot_id is required",
+		})
+		return
+	}
+
+	var req PreviewShotClipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	preview, err := h.novelService.PreviewShotClip(c.Request.Context(), shotID, req.VideoPrompt)
+	if err != nil {
+		h.respondShotClipPreviewError(c, err)
+		return
+	}
+	_, download, err := h.novelService.GetShotClipPreview(c.Request.Context(), shotID, preview.ID)
+	if err != nil {
+		h.respondShotClipPreviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    0,
+		"message": "镜头片段预览渲染完成",
+		"data": gin.H{
+			"preview":      preview,
+			"download":     download,
+			"regeneration": h.regenerationStatus(c, novel.RegenerationArtifactShot, shotID),
+		},
+	})
+}
+
+// GetShotClipPreview 获取单镜头片段预览
+// @Summary      获取单镜头片段预览
+// @Description  获取待确认的预览及预览片段的临时下载链接（已确认、已放弃或已过期的预览返回 400）
+// @Tags         分镜头管理
+// @Accept       json
+// @Produce      json
+// @Param        shot_id     path      string  true  "镜头ID"
+// @Param        preview_id  path      string  true  "预览ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "预览已处理或已过期"
+// @Failure      404         {object}  ErrorResponse  "预览不存在"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/shots/{shot_id}/clip-previews/{preview_id} [get]
+func (h *Handler) GetShotClipPreview(c *gin.Context) {
+	preview, download, err := h.novelService.GetShotClipPreview(c.Request.Context(), c.Param("shot_id"), c.Param("preview_id"))
+	if err != nil {
+		h.respondShotClipPreviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"preview":  preview,
+			"download": download,
+		},
+	})
+}
+
+// ConfirmShotClipPreview 确认单镜头片段预览
+// @Summary      确认单镜头片段预览
+// @Description  以章节最新的视频版本为基础生成新版本：复制所有 narration 片段并把该镜头的片段替换为预览片段，同时把 video_prompt 写回镜头。渲染预览后章节又生成了新版本时返回 409，需要重新预览
+// @Tags         分镜头管理
+// @Accept       json
+// @Produce      json
+// @Param        shot_id     path      string  true  "镜头ID"
+// @Param        preview_id  path      string  true  "预览ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "预览已处理或已过期"
+// @Failure      404         {object}  ErrorResponse  "预览不存在"
+// @Failure      409         {object}  ErrorResponse  "预览的基础版本已不是最新版本"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/shots/{shot_id}/clip-previews/{preview_id}/confirm [post]
+func (h *Handler) ConfirmShotClipPreview(c *gin.Context) {
+	preview, err := h.novelService.ConfirmShotClipPreview(c.Request.Context(), c.Param("shot_id"), c.Param("preview_id"))
+	if err != nil {
+		h.respondShotClipPreviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "镜头片段已确认，已生成新的视频版本",
+		"data": gin.H{
+			"preview": preview,
+			"version": preview.ConfirmedVersion,
+		},
+	})
+}
+
+// DiscardShotClipPreview 放弃单镜头片段预览
+// @Summary      放弃单镜头片段预览
+// @Description  放弃待确认的预览并删除预览片段，镜头和视频版本不变
+// @Tags         分镜头管理
+// @Accept       json
+// @Produce      json
+// @Param        shot_id     path      string  true  "镜头ID"
+// @Param        preview_id  path      string  true  "预览ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "预览已处理"
+// @Failure      404         {object}  ErrorResponse  "预览不存在"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/shots/{shot_id}/clip-previews/{preview_id} [delete]
+func (h *Handler) DiscardShotClipPreview(c *gin.Context) {
+	previewID := c.Param("preview_id")
+	if err := h.novelService.DiscardShotClipPreview(c.Request.Context(), c.Param("shot_id"), previewID); err != nil {
+		h.respondShotClipPreviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "镜头片段预览已放弃",
+		"data": gin.H{
+			"preview_id": previewID,
+		},
+	})
+}
+
+// GetShotClipPreviewLatencyStats 查询单镜头预览耗时统计
+// @Summary      查询单镜头预览耗时统计
+// @Description  统计最近 days 天（按 UTC 日期，含今天）单镜头「重新生成并预览」从请求到预览可用的耗时：p50、p95、最大值、目标耗时（60 秒）内完成的次数和比例，以及复用缓存图生视频结果的次数
+// @Tags         分镜头管理
+// @Accept       json
+// @Produce      json
+// @Param        days  query     int  false  "统计天数（1-90，默认 7）"
+// @Success      200   {object}  map[string]interface{}  "成功响应"
+// @Failure      400   {object}  ErrorResponse  "请求参数错误"
+// @Failure      500   {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/shot-clip-previews/latency-stats [get]
+func (h *Handler) GetShotClipPreviewLatencyStats(c *gin.Context) {
+	days := 0
+	if daysStr := c.Query("days"); daysStr != "" {
+		v, err := strconv.Atoi(daysStr)
+		if err != nil || v <= 0 || v > 90 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40002,
+				Message: "Invalid days, must be between 1 and 90",
+			})
+			return
+		}
+		days = v
+	}
+
+	stats, err := h.novelService.GetShotClipPreviewLatencyStats(c.Request.Context(), days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50001,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    stats,
+	})
+}
+
+func (h *Handler) respondShotClipPreviewError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, novelservice.ErrInvalidShotClipPreview):
+		code = http.StatusBadRequest
+		errorCode = 40003
+	case errors.Is(err, novelservice.ErrShotClipPreviewStale):
+		code = http.StatusConflict
+		errorCode = 40901
+	case errors.Is(err, novelservice.ErrBudgetExceeded):
+		code = http.StatusPaymentRequired
+		errorCode = 40201
+	case errors.Is(err, novelservice.ErrRegenerationLimitExceeded):
+		code = http.StatusForbidden
+		errorCode = 40304
+	}
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
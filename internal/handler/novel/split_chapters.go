@@ -1,9 +1,12 @@
 package novel
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	novelservice "lemon/internal/service/novel"
 )
 
 // SplitChaptersRequest 切分章节请求
@@ -27,7 +30,7 @@ type SplitChaptersResponseData struct {
 // @Produce      json
 // @Param        request  body      SplitChaptersRequest  true  "切分章节请求"
 // @Success      200      {object}  map[string]interface{}  "成功响应"  "{\"code\": 0, \"message\": \"章节切分成功\", \"data\": {\"novel_id\": \"...\", \"target_chapters\": 10, \"message\": \"已切分为 10 个章节\"}}"
-// @Failure      400      {object}  ErrorResponse  "请求参数错误"
+// @Failure      400      {object}  ErrorResponse  "请求参数错误 / 小说文件编码无法识别"
 // @Failure      500      {object}  ErrorResponse  "服务器内部错误"
 // @Router       /api/v1/novels/{novel_id}/chapters/split [post]
 func (h *Handler) SplitChapters(c *gin.Context) {
@@ -57,6 +60,10 @@ func (h *Handler) SplitChapters(c *gin.Context) {
 		case err.Error() == "no chapters split from novel content":
 			code = http.StatusBadRequest
 			errorCode = 40003
+		case errors.Is(err, novelservice.ErrUndecodableNovelText):
+			code = http.StatusBadRequest
+			errorCode = 40004
+			err = errors.New("无法识别小说文件编码，请上传 UTF-8、GBK/GB18030 或 Big5 编码的文本文件")
 		}
 
 		c.JSON(code, ErrorResponse{
@@ -0,0 +1,305 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	novelservice "lemon/internal/service/novel"
+)
+
+// SetStandbySettingsRequest 设置下一章预生成配置请求
+type SetStandbySettingsRequest struct {
+	Enabled bool                 `json:"enabled"` // 是否在章节成片完成后自动预生成下一章
+	Stages  []novel.StandbyStage `json:"stages"`  // 预生成阶段：summary（章节摘要）、narration（解说草稿，含分镜图片提示词），为空表示全部
+}
+
+// StartStandbyRequest 手动启动下一章预生成请求
+type StartStandbyRequest struct {
+	Stages []novel.StandbyStage `json:"stages"`  // 预生成阶段，为空时使用小说配置（未配置时为全部阶段）
+	UserID string               `json:"user_id"` // 触发人用户ID（可选）
+}
+
+// SetStandbySettings 设置小说的下一章预生成配置
+// @Summary      设置下一章预生成配置
+// @Description  开启后，章节成片完成（编辑开始审核）时自动为下一章预生成低成本素材：章节摘要和解说草稿（含分镜图片提示词），不调用付费的图片/视频生成。
+// @Description  stages 控制执行哪些阶段，已有最新结果的阶段会跳过
+// @Tags         下一章预生成
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string                     true  "小说ID"
+// @Param        request   body      SetStandbySettingsRequest  true  "预生成配置"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/standby-settings [put]
+func (h *Handler) SetStandbySettings(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	var req SetStandbySettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	settings := &novel.StandbySettings{Enabled: req.Enabled, Stages: req.Stages}
+	if err := h.novelService.SetStandbySettings(c.Request.Context(), novelID, settings); err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+
+		// 根据错误类型设置错误码
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			code = http.StatusNotFound
+			errorCode = 40401
+		case errors.Is(err, novelservice.ErrInvalidStandby):
+			code = http.StatusBadRequest
+			errorCode = 40003
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "预生成配置已更新",
+		"data": gin.H{
+			"novel_id": novelID,
+			"standby":  settings,
+		},
+	})
+}
+
+// StartStandby 手动为下一章启动预生成
+// @Summary      启动下一章预生成
+// @Description  为指定章节的下一章（按章节序号）启动预生成任务，后台依次执行各阶段。下一章已有执行中的任务时直接返回该任务
+// @Tags         下一章预生成
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string               true   "当前章节ID"
+// @Param        request     body      StartStandbyRequest  false  "预生成请求"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  ErrorResponse  "章节不存在"
+// @Failure      409         {object}  ErrorResponse  "已经是最后一章"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Failure      503         {object}  ErrorResponse  "维护模式"
+// @Router       /api/v1/novels/chapters/{chapter_id}/standby [post]
+func (h *Handler) StartStandby(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+
+	var req StartStandbyRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40002,
+				Message: "Invalid request body",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	userID := req.UserID
+	if currentUserID, ok := ctxutil.GetUserID(ctx); ok {
+		userID = currentUserID
+	}
+
+	job, err := h.novelService.StartStandby(ctx, chapterID, req.Stages, userID)
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+
+		// 根据错误类型设置错误码
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			code = http.StatusNotFound
+			errorCode = 40401
+		case errors.Is(err, novelservice.ErrInvalidStandby):
+			code = http.StatusBadRequest
+			errorCode = 40003
+		case errors.Is(err, novelservice.ErrNoNextChapter):
+			code = http.StatusConflict
+			errorCode = 40901
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "预生成任务已启动",
+		"data":    job,
+	})
+}
+
+// ListStandbyJobs 查询小说的预生成任务
+// @Summary      查询预生成任务列表
+// @Description  查询小说的下一章预生成任务（按创建时间倒序），包含每个阶段的执行结果
+// @Tags         下一章预生成
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string  true   "小说ID"
+// @Param        limit     query     int     false  "返回数量（默认50，最大200）"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/standby-jobs [get]
+func (h *Handler) ListStandbyJobs(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	jobs, err := h.novelService.ListStandbyJobs(c.Request.Context(), novelID, budgetListLimit(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50001,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"novel_id":     novelID,
+			"standby_jobs": jobs,
+			"count":        len(jobs),
+		},
+	})
+}
+
+// GetStandbyJob 查询预生成任务
+// @Summary      查询预生成任务
+// @Description  查询下一章预生成任务的状态和各阶段执行结果（completed/skipped/failed/canceled）
+// @Tags         下一章预生成
+// @Accept       json
+// @Produce      json
+// @Param        standby_job_id  path      string  true  "预生成任务ID"
+// @Success      200             {object}  map[string]interface{}  "成功响应"
+// @Failure      400             {object}  ErrorResponse  "请求参数错误"
+// @Failure      404             {object}  ErrorResponse  "预生成任务不存在"
+// @Failure      500             {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/standby-jobs/{standby_job_id} [get]
+func (h *Handler) GetStandbyJob(c *gin.Context) {
+	jobID := c.Param("standby_job_id")
+	if jobID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "standby_job_id is required",
+		})
+		return
+	}
+
+	job, err := h.novelService.GetStandbyJob(c.Request.Context(), jobID)
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			code = http.StatusNotFound
+			errorCode = 40401
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    job,
+	})
+}
+
+// CancelStandbyJob 取消预生成任务
+// @Summary      取消预生成任务
+// @Description  取消执行中的预生成任务：正在执行的阶段会被中断，后续阶段不再执行，已完成阶段的结果保留
+// @Tags         下一章预生成
+// @Accept       json
+// @Produce      json
+// @Param        standby_job_id  path      string  true  "预生成任务ID"
+// @Success      200             {object}  map[string]interface{}  "成功响应"
+// @Failure      400             {object}  ErrorResponse  "请求参数错误"
+// @Failure      404             {object}  ErrorResponse  "预生成任务不存在"
+// @Failure      409             {object}  ErrorResponse  "任务已结束"
+// @Failure      500             {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/standby-jobs/{standby_job_id}/cancel [post]
+func (h *Handler) CancelStandbyJob(c *gin.Context) {
+	jobID := c.Param("standby_job_id")
+	if jobID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "standby_job_id is required",
+		})
+		return
+	}
+
+	job, err := h.novelService.CancelStandbyJob(c.Request.Context(), jobID)
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+
+		// 根据错误类型设置错误码
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			code = http.StatusNotFound
+			errorCode = 40401
+		case errors.Is(err, novelservice.ErrStandbyNotRunning):
+			code = http.StatusConflict
+			errorCode = 40901
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "预生成任务已取消",
+		"data":    job,
+	})
+}
@@ -0,0 +1,228 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	novelservice "lemon/internal/service/novel"
+)
+
+// StyleReferenceInfo 风格参考图 DTO
+type StyleReferenceInfo struct {
+	ID          string `json:"id"`                    // 参考图ID
+	NovelID     string `json:"novel_id"`              // 小说ID
+	ChapterID   string `json:"chapter_id,omitempty"`  // 章节ID（为空表示小说级）
+	UserID      string `json:"user_id"`               // 上传用户ID
+	ResourceID  string `json:"resource_id"`           // 参考图文件的 resource_id
+	Description string `json:"description,omitempty"` // 说明
+	CreatedAt   string `json:"created_at"`            // 创建时间
+}
+
+func toStyleReferenceInfo(r *novel.StyleReference) StyleReferenceInfo {
+	return StyleReferenceInfo{
+		ID:          r.ID,
+		NovelID:     r.NovelID,
+		ChapterID:   r.ChapterID,
+		UserID:      r.UserID,
+		ResourceID:  r.ResourceID,
+		Description: r.Description,
+		CreatedAt:   r.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// UploadStyleReference 上传风格参考图
+// @Summary      上传风格参考图
+// @Description  上传美术设定图作为风格参考，用于引导生成图片的构图和配色。不传 chapter_id 时为小说级参考图；章节设置了参考图时优先使用章节级参考图。图片提供者不支持风格参考时会忽略参考图。
+// @Tags         图片生成
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        novel_id     path      string  true   "小说ID"
+// @Param        file         formData  file    true   "参考图（图片文件）"
+// @Param        user_id      formData  string  true   "用户ID"
+// @Param        chapter_id   formData  string  false  "章节ID（为空表示小说级）"
+// @Param        description  formData  string  false  "说明"
+// @Success      201          {object}  map[string]interface{}  "成功响应"
+// @Failure      400          {object}  ErrorResponse  "请求参数错误"
+// @Failure      404          {object}  ErrorResponse  "小说或章节不存在"
+// @Failure      500          {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/style-references [post]
+func (h *Handler) UploadStyleReference(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid file",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	userID := c.PostForm("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40003,
+			Message: "user_id is required",
+		})
+		return
+	}
+
+	fileReader, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40004,
+			Message: "Failed to open file",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	defer fileReader.Close()
+
+	ctx := c.Request.Context()
+
+	// 调用Service层
+	ref, err := h.novelService.CreateStyleReference(ctx, &novelservice.CreateStyleReferenceRequest{
+		NovelID:     novelID,
+		ChapterID:   c.PostForm("chapter_id"),
+		UserID:      userID,
+		Description: c.PostForm("description"),
+		FileName:    file.Filename,
+		ContentType: file.Header.Get("Content-Type"),
+		Data:        fileReader,
+	})
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+
+		// 根据错误类型设置错误码
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			code = http.StatusNotFound
+			errorCode = 40401
+		case errors.Is(err, novelservice.ErrInvalidStyleReference):
+			code = http.StatusBadRequest
+			errorCode = 40005
+		case errors.Is(err, novelservice.ErrChapterNotInNovel):
+			code = http.StatusBadRequest
+			errorCode = 40006
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    0,
+		"message": "风格参考图上传成功",
+		"data":    toStyleReferenceInfo(ref),
+	})
+}
+
+// ListStyleReferences 获取风格参考图列表
+// @Summary      获取风格参考图列表
+// @Description  获取小说的风格参考图（按上传时间正序）。传 chapter_id 时只返回该章节的参考图，否则返回小说的所有参考图（包含章节级）
+// @Tags         图片生成
+// @Accept       json
+// @Produce      json
+// @Param        novel_id    path      string  true   "小说ID"
+// @Param        chapter_id  query     string  false  "章节ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/style-references [get]
+func (h *Handler) ListStyleReferences(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	refs, err := h.novelService.ListStyleReferences(c.Request.Context(), novelID, c.Query("chapter_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50001,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	infos := make([]StyleReferenceInfo, 0, len(refs))
+	for _, r := range refs {
+		infos = append(infos, toStyleReferenceInfo(r))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"novel_id":         novelID,
+			"style_references": infos,
+			"count":            len(infos),
+		},
+	})
+}
+
+// DeleteStyleReference 删除风格参考图
+// @Summary      删除风格参考图
+// @Description  删除风格参考图，之后生成的图片不再使用该参考图（已生成图片上记录的参考图ID保留）
+// @Tags         图片生成
+// @Accept       json
+// @Produce      json
+// @Param        reference_id  path      string  true  "参考图ID"
+// @Success      200           {object}  map[string]interface{}  "成功响应"
+// @Failure      400           {object}  ErrorResponse  "请求参数错误"
+// @Failure      404           {object}  ErrorResponse  "参考图不存在"
+// @Failure      500           {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/style-references/{reference_id} [delete]
+func (h *Handler) DeleteStyleReference(c *gin.Context) {
+	referenceID := c.Param("reference_id")
+	if referenceID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "reference_id is required",
+		})
+		return
+	}
+
+	if err := h.novelService.DeleteStyleReference(c.Request.Context(), referenceID); err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			code = http.StatusNotFound
+			errorCode = 40401
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "风格参考图已删除",
+		"data": gin.H{
+			"reference_id": referenceID,
+		},
+	})
+}
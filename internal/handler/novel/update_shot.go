@@ -1,9 +1,13 @@
 package novel
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"lemon/internal/model/novel"
+	novelservice "lemon/internal/service/novel"
 )
 
 // UpdateShotRequest 更新分镜头请求
@@ -13,11 +17,12 @@ type UpdateShotRequest struct {
 	VideoPrompt    *string  `json:"video_prompt,omitempty"`    // 视频提示词
 	CameraMovement *string  `json:"camera_movement,omitempty"` // 运镜方式
 	Duration       *float64 `json:"duration,omitempty"`        // 时长（秒）
+	Rating         *int     `json:"rating,omitempty"`          // 编辑评分（1-5，0 表示清除评分；用于自动挑选回顾合集的高光镜头）
 }
 
 // UpdateShot 更新分镜头信息
 // @Summary      更新分镜头信息
-// @Description  更新分镜头的脚本信息（解说、图片提示词、视频提示词、运镜方式、时长等）
+// @Description  更新分镜头的脚本信息（解说、图片提示词、视频提示词、运镜方式、时长等）和编辑评分（1-5，用于自动挑选回顾合集的高光镜头）
 // @Tags         分镜头管理
 // @Accept       json
 // @Produce      json
@@ -63,6 +68,16 @@ func (h *Handler) UpdateShot(c *gin.Context) {
 	if req.Duration != nil {
 		updates["duration"] = *req.Duration
 	}
+	if req.Rating != nil {
+		if *req.Rating < 0 || *req.Rating > 5 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40002,
+				Message: "rating must be between 0 and 5",
+			})
+			return
+		}
+		updates["rating"] = *req.Rating
+	}
 
 	if len(updates) == 0 {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
@@ -92,14 +107,16 @@ func (h *Handler) UpdateShot(c *gin.Context) {
 
 // RegenerateShotScript 重新生成分镜头脚本
 // @Summary      重新生成分镜头脚本
-// @Description  调用 LLM 重新生成分镜头的脚本信息（解说、图片提示词、视频提示词、运镜方式、时长等）
+// @Description  调用 LLM 重新生成分镜头的脚本信息（解说、图片提示词、视频提示词、运镜方式、时长等）。每次重新生成计入镜头的重新生成次数（响应中的 regeneration），超过上限后需要管理员/审核员填写 override_reason 越权
 // @Tags         分镜头管理
 // @Accept       json
 // @Produce      json
-// @Param        shot_id  path      string  true  "分镜头ID"
-// @Success      200      {object}  map[string]interface{}  "成功响应"
-// @Failure      400      {object}  ErrorResponse          "请求参数错误"
-// @Failure      500      {object}  ErrorResponse          "服务器内部错误"
+// @Param        shot_id          path      string  true   "分镜头ID"
+// @Param        override_reason  query     string  false  "越过重新生成次数上限的原因（需要管理员/审核员角色）"
+// @Success      200              {object}  map[string]interface{}  "成功响应"
+// @Failure      400              {object}  ErrorResponse          "请求参数错误"
+// @Failure      403              {object}  ErrorResponse          "重新生成次数超过上限"
+// @Failure      500              {object}  ErrorResponse          "服务器内部错误"
 // @Router       /api/v1/shots/{shot_id}/regenerate [post]
 func (h *Handler) RegenerateShotScript(c *gin.Context) {
 	shotID := c.Param("shot_id")
@@ -113,8 +130,14 @@ func (h *Handler) RegenerateShotScript(c *gin.Context) {
 
 	ctx := c.Request.Context()
 	if err := h.novelService.RegenerateShotScript(ctx, shotID); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Code:    50001,
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, novelservice.ErrRegenerationLimitExceeded) {
+			code = http.StatusForbidden
+			errorCode = 40304
+		}
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
 			Message: err.Error(),
 		})
 		return
@@ -124,7 +147,8 @@ func (h *Handler) RegenerateShotScript(c *gin.Context) {
 		"code":    0,
 		"message": "success",
 		"data": gin.H{
-			"shot_id": shotID,
+			"shot_id":      shotID,
+			"regeneration": h.regenerationStatus(c, novel.RegenerationArtifactShot, shotID),
 		},
 	})
 }
@@ -0,0 +1,70 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ValidateNarrationRequest 解说校验查询参数
+type ValidateNarrationRequest struct {
+	Suggest bool `form:"suggest"` // 存在节奏问题时是否调用 LLM 给出需要压缩的场景
+}
+
+// ValidateNarration 校验解说文案并分析节奏
+// @Summary      校验解说文案并分析节奏
+// @Description  校验解说文案（字数、分镜数量、开头特写、近似重复镜头），并根据场景情绪和旁白文本为每个场景打节奏分（0-100），检查连续慢节奏场景、节奏平淡和前重后轻。suggest=true 且存在节奏问题时调用 LLM 给出需要压缩的场景（计入预算，失败时只在 suggest_error 中说明）。节奏警告在 pacing.warnings 中，不计入 QA 评分
+// @Tags         解说管理
+// @Accept       json
+// @Produce      json
+// @Param        narration_id  path      string  true   "解说ID"
+// @Param        suggest       query     bool    false  "是否调用 LLM 给出压缩建议"
+// @Success      200           {object}  map[string]interface{}  "成功响应"
+// @Failure      400           {object}  ErrorResponse  "请求参数错误"
+// @Failure      404           {object}  ErrorResponse  "解说不存在"
+// @Failure      500           {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/narrations/{narration_id}/validation [get]
+func (h *Handler) ValidateNarration(c *gin.Context) {
+	narrationID := c.Param("narration_id")
+	if narrationID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "narration_id is required",
+		})
+		return
+	}
+
+	var req ValidateNarrationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid query parameters",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	validation, err := h.novelService.ValidateNarration(c.Request.Context(), narrationID, req.Suggest)
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			code = http.StatusNotFound
+			errorCode = 40401
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    validation,
+	})
+}
@@ -0,0 +1,56 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetVideoManifest 获取最终视频的流水线清单
+// @Summary      获取最终视频的流水线清单
+// @Description  返回生成最终视频时记录的清单：解说、镜头、图片、音频、字幕、片段和背景音乐的ID，各生成能力的 provider/模型，提示词的 SHA256，以及 ffmpeg/Go/服务版本。清单随最终视频一起存储（manifest_resource_id），配置 EMBED_PIPELINE_MANIFEST=true 时同时写入 MP4 元数据的 lemon_manifest 标签
+// @Tags         视频生成
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true  "章节ID"
+// @Param        video_id    path      string  true  "最终视频ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  ErrorResponse  "视频不存在或没有清单"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/videos/{video_id}/manifest [get]
+func (h *Handler) GetVideoManifest(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	videoID := c.Param("video_id")
+	if chapterID == "" || videoID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id and video_id are required",
+		})
+		return
+	}
+
+	manifest, err := h.novelService.GetVideoManifest(c.Request.Context(), chapterID, videoID)
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			code = http.StatusNotFound
+			errorCode = 40401
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    manifest,
+	})
+}
@@ -0,0 +1,146 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/model/auth"
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	novelservice "lemon/internal/service/novel"
+)
+
+// ListVideoTasksRequest 视频任务状态查询参数
+type ListVideoTasksRequest struct {
+	UserID string `form:"user_id"`                       // 用户ID（默认当前登录用户；查询其他用户需要管理员/审核员角色）
+	Status string `form:"status"`                        // 状态，多个用逗号分隔：pending, processing, completed, failed；为空时不限状态
+	Cursor string `form:"cursor"`                        // 上一页返回的 next_cursor
+	Limit  int    `form:"limit" binding:"min=0,max=200"` // 每页数量（默认50，最大200）
+	Wait   int    `form:"wait" binding:"min=0,max=30"`   // 长轮询等待秒数（最长30秒）：游标之后没有变化时等待新变化再返回
+}
+
+// ListVideoTasksResponseData 视频任务状态查询响应数据
+type ListVideoTasksResponseData struct {
+	Videos     []VideoInfo `json:"videos"`      // 视频列表（按最后更新时间升序）
+	Count      int         `json:"count"`       // 本页数量
+	NextCursor string      `json:"next_cursor"` // 下一页游标（没有新数据时与请求的游标相同）
+	HasMore    bool        `json:"has_more"`    // 是否还有更多数据（可以立即请求下一页）
+	Status     string      `json:"status"`      // 查询的状态（兼容原按状态查询接口的响应字段）
+}
+
+// ListVideoTasks 按用户查询视频任务状态（用于轮询）
+// @Summary      按用户查询视频任务状态
+// @Description  按用户查询视频任务状态，用于轮询视频生成进度。视频按最后更新时间升序排列，使用游标分页：保存返回的 next_cursor，下次请求只会拿到之后有变化的视频。
+// @Description  wait>0 时为长轮询：游标之后没有变化时最多等待 wait 秒，期间出现变化立即返回，超时返回空列表和原游标。
+// @Description  user_id 默认为当前登录用户；管理员和审核员可以查询其他用户，其他角色只能查询自己的视频。
+// @Description  兼容说明：原接口 GET /api/v1/videos?status=xxx 返回所有用户该状态的全部视频，现在只返回当前用户（或 user_id 指定用户）的一页视频，响应中保留 videos、count、status 字段；未登录时必须指定 user_id
+// @Tags         视频查询
+// @Accept       json
+// @Produce      json
+// @Param        user_id  query     string  false  "用户ID（默认当前登录用户）"
+// @Param        status   query     string  false  "状态，多个用逗号分隔：pending, processing, completed, failed"
+// @Param        cursor   query     string  false  "上一页返回的 next_cursor"
+// @Param        limit    query     int     false  "每页数量（默认50，最大200）"
+// @Param        wait     query     int     false  "长轮询等待秒数（0-30）"
+// @Success      200      {object}  map[string]interface{}  "成功响应"
+// @Failure      400      {object}  ErrorResponse  "请求参数错误（如未登录且缺少 user_id、status 或 cursor 无效）"
+// @Failure      500      {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/videos [get]
+func (h *Handler) ListVideoTasks(c *gin.Context) {
+	h.listVideoTasks(c, "")
+}
+
+// ListChapterVideoTasks 按章节查询视频任务状态（用于轮询）
+// @Summary      按章节查询视频任务状态
+// @Description  按章节查询视频任务状态，分页和长轮询规则与按用户查询相同
+// @Tags         视频查询
+// @Accept       json
+// @Produce      json
+// @Param        chapter_id  path      string  true   "章节ID"
+// @Param        status      query     string  false  "状态，多个用逗号分隔：pending, processing, completed, failed"
+// @Param        cursor      query     string  false  "上一页返回的 next_cursor"
+// @Param        limit       query     int     false  "每页数量（默认50，最大200）"
+// @Param        wait        query     int     false  "长轮询等待秒数（0-30）"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/chapters/{chapter_id}/video-tasks [get]
+func (h *Handler) ListChapterVideoTasks(c *gin.Context) {
+	chapterID := c.Param("chapter_id")
+	if chapterID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "chapter_id is required",
+		})
+		return
+	}
+	h.listVideoTasks(c, chapterID)
+}
+
+func (h *Handler) listVideoTasks(c *gin.Context, chapterID string) {
+	var req ListVideoTasksRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid query parameters",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	var statuses []novel.VideoStatus
+	for _, status := range strings.Split(req.Status, ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			statuses = append(statuses, novel.VideoStatus(status))
+		}
+	}
+
+	ctx := c.Request.Context()
+	// 按用户查询时默认查询当前登录用户，管理员和审核员以外只能查询自己；按章节查询由小说权限控制，user_id 只用于过滤
+	userID := req.UserID
+	if currentUserID, ok := ctxutil.GetUserID(ctx); ok && chapterID == "" {
+		role, _ := ctxutil.GetUserRole(ctx)
+		if userID == "" || (role != auth.RoleAdmin && role != auth.RoleReviewer) {
+			userID = currentUserID
+		}
+	}
+
+	page, err := h.novelService.ListVideoTasks(ctx, &novelservice.VideoTaskQuery{
+		UserID:    userID,
+		ChapterID: chapterID,
+		Statuses:  statuses,
+		Cursor:    req.Cursor,
+		Limit:     req.Limit,
+		Wait:      time.Duration(req.Wait) * time.Second,
+	})
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, novelservice.ErrInvalidVideoTaskQuery) {
+			code = http.StatusBadRequest
+			errorCode = 40003
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": ListVideoTasksResponseData{
+			Videos:     toVideoInfoList(page.Videos),
+			Count:      len(page.Videos),
+			NextCursor: page.NextCursor,
+			HasMore:    page.HasMore,
+			Status:     req.Status,
+		},
+	})
+}
@@ -0,0 +1,95 @@
+package novel
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/model/auth"
+	"lemon/internal/pkg/ctxutil"
+	novelservice "lemon/internal/service/novel"
+)
+
+// videoTaskStub 记录视频任务查询条件的小说服务
+type videoTaskStub struct {
+	novelservice.NovelService
+	query *novelservice.VideoTaskQuery
+}
+
+func (s *videoTaskStub) ListVideoTasks(_ context.Context, q *novelservice.VideoTaskQuery) (*novelservice.VideoTaskPage, error) {
+	s.query = q
+	return &novelservice.VideoTaskPage{NextCursor: q.Cursor}, nil
+}
+
+// serveVideoTasks 以指定的登录用户（userID 为空表示未登录）请求 GET /videos
+func serveVideoTasks(svc *videoTaskStub, userID string, role auth.UserRole, query string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if userID != "" {
+			ctx := ctxutil.WithUserID(c.Request.Context(), userID)
+			c.Request = c.Request.WithContext(ctxutil.WithUserRole(ctx, role))
+		}
+		c.Next()
+	})
+	r.GET("/videos", NewHandler(svc).ListVideoTasks)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/videos"+query, nil))
+	return w
+}
+
+func TestListVideoTasksScopesToCurrentUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		userID   string
+		role     auth.UserRole
+		query    string
+		wantUser string
+	}{
+		{"默认查询当前用户", "editor-1", auth.RoleEditor, "?status=processing", "editor-1"},
+		{"查询自己", "editor-1", auth.RoleEditor, "?user_id=editor-1", "editor-1"},
+		{"非管理员不能查询其他用户", "editor-1", auth.RoleEditor, "?user_id=editor-2", "editor-1"},
+		{"审核员查询其他用户", "reviewer-1", auth.RoleReviewer, "?user_id=editor-2", "editor-2"},
+		{"管理员默认查询自己", "admin-1", auth.RoleAdmin, "", "admin-1"},
+		{"未登录时使用请求的用户", "", "", "?user_id=editor-2", "editor-2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &videoTaskStub{}
+			w := serveVideoTasks(svc, tt.userID, tt.role, tt.query)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+			}
+			if svc.query.UserID != tt.wantUser {
+				t.Errorf("queried user = %q, want %q", svc.query.UserID, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestListVideoTasksKeepsLegacyResponseFields(t *testing.T) {
+	svc := &videoTaskStub{}
+	w := serveVideoTasks(svc, "editor-1", auth.RoleEditor, "?status=pending")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"videos", "count", "status"} {
+		if _, ok := resp.Data[field]; !ok {
+			t.Errorf("response data has no %q field: %s", field, w.Body.String())
+		}
+	}
+	if string(resp.Data["status"]) != `"pending"` {
+		t.Errorf("status = %s, want \"pending\"", resp.Data["status"])
+	}
+}
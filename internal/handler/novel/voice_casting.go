@@ -0,0 +1,167 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/pkg/killswitch"
+	novelservice "lemon/internal/service/novel"
+)
+
+// SuggestCharacterVoicesRequest 角色选角建议请求
+type SuggestCharacterVoicesRequest struct {
+	Names []string `json:"names"` // 要选角的角色名称，为空时为小说的所有角色推荐
+}
+
+// SetCharacterVoiceRequest 确定角色配音音色请求
+type SetCharacterVoiceRequest struct {
+	VoiceType string `json:"voice_type"` // 手动指定的音色（必须在音色目录中），为空时接受选角建议
+}
+
+// ListVoiceCatalog 查询音色目录
+// @Summary      查询音色目录
+// @Description  返回角色选角可选的 TTS 音色（音色、名称、性别、年龄段、音色特点）。默认为内置目录，可通过 TTS_VOICE_CATALOG 配置 JSON 文件替换
+// @Tags         角色管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string  true  "小说ID"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Router       /api/v1/novels/{novel_id}/voice-catalog [get]
+func (h *Handler) ListVoiceCatalog(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    h.novelService.ListVoiceCatalog(c.Request.Context()),
+	})
+}
+
+// SuggestCharacterVoices 生成角色选角建议
+// @Summary      生成角色选角建议
+// @Description  根据角色的性别、年龄段和描述，调用大模型从音色目录中为每个角色推荐配音音色。建议保存在角色的 voice_suggestion 上（重新生成时覆盖），不改变已确定的音色（voice_type）；
+// @Description  大模型没有给出有效建议（音色不在目录中或性别不一致）的角色按性别和年龄段匹配（fallback=true）。确定音色使用 PUT /novels/{novel_id}/characters/{name}/voice
+// @Tags         角色管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string                         true   "小说ID"
+// @Param        request   body      SuggestCharacterVoicesRequest  false  "选角请求"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      402       {object}  ErrorResponse  "超出小说预算"
+// @Failure      404       {object}  ErrorResponse  "角色不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Failure      503       {object}  ErrorResponse  "大模型维护中"
+// @Router       /api/v1/novels/{novel_id}/characters/voice-suggestions [post]
+func (h *Handler) SuggestCharacterVoices(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	if novelID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id is required",
+		})
+		return
+	}
+
+	var req SuggestCharacterVoicesRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40002,
+				Message: "Invalid request body",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	characters, err := h.novelService.SuggestCharacterVoices(c.Request.Context(), novelID, req.Names)
+	if err != nil {
+		respondVoiceCastingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "选角建议已生成",
+		"data":    toCharacterInfoList(characters),
+	})
+}
+
+// SetCharacterVoice 确定角色配音音色
+// @Summary      确定角色配音音色
+// @Description  voice_type 为空时接受角色当前的选角建议（voice_source=suggestion），否则手动指定音色（voice_source=manual，必须在音色目录中）
+// @Tags         角色管理
+// @Accept       json
+// @Produce      json
+// @Param        novel_id  path      string                    true  "小说ID"
+// @Param        name      path      string                    true  "角色名称"
+// @Param        request   body      SetCharacterVoiceRequest  true  "音色请求"
+// @Success      200       {object}  map[string]interface{}  "成功响应"
+// @Failure      400       {object}  ErrorResponse  "请求参数错误或音色不在目录中"
+// @Failure      404       {object}  ErrorResponse  "角色不存在"
+// @Failure      409       {object}  ErrorResponse  "角色还没有选角建议"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/novels/{novel_id}/characters/{name}/voice [put]
+func (h *Handler) SetCharacterVoice(c *gin.Context) {
+	novelID := c.Param("novel_id")
+	name := c.Param("name")
+	if novelID == "" || name == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "novel_id and name are required",
+		})
+		return
+	}
+
+	var req SetCharacterVoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	character, err := h.novelService.SetCharacterVoice(c.Request.Context(), novelID, name, req.VoiceType)
+	if err != nil {
+		respondVoiceCastingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "角色配音音色已确定",
+		"data":    toCharacterInfo(character),
+	})
+}
+
+func respondVoiceCastingError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, novelservice.ErrUnknownVoice):
+		code = http.StatusBadRequest
+		errorCode = 40003
+	case errors.Is(err, novelservice.ErrNoVoiceSuggestion):
+		code = http.StatusConflict
+		errorCode = 40901
+	case errors.Is(err, novelservice.ErrBudgetExceeded):
+		code = http.StatusPaymentRequired
+		errorCode = 40201
+	case errors.Is(err, killswitch.ErrMaintenance):
+		code = http.StatusServiceUnavailable
+		errorCode = 50301
+	}
+
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,220 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	novelservice "lemon/internal/service/novel"
+)
+
+// CreateWebhookRequest 注册任务通知请求
+type CreateWebhookRequest struct {
+	URL       string                `json:"url" binding:"required"` // 通知地址（http/https）
+	TaskTypes []novel.PipelineStage `json:"task_types"`             // 订阅的任务类型：narration、audio、subtitle、image、narration_video、final_video（为空表示全部）
+	UserID    string                `json:"user_id"`                // 用户ID（已登录时使用当前用户）
+}
+
+// CreateWebhook 注册任务通知地址
+// @Summary      注册任务通知
+// @Description  注册任务通知地址：用户名下章节的生成任务（解说、音频、字幕、图片、解说视频、最终视频）完成或失败时，服务端向该地址 POST JSON（event、task_type、chapter_id、resource_id、status、error 等）。
+// @Description  请求头 X-Lemon-Signature 为 HMAC-SHA256 签名（sha256=<hex>，签名内容为 "<X-Lemon-Timestamp>.<请求体>"），密钥只在注册时返回一次。
+// @Description  响应不是 2xx 时按 30 秒起 4 倍递增的间隔重试（最多尝试 WEBHOOK_MAX_ATTEMPTS 次，默认 6 次），每次尝试记录在投递记录中
+// @Description  通知地址必须解析到公网地址，内网、回环和链路本地地址在注册时返回 400，发送时同样拒绝连接（不重试）
+// @Tags         任务通知
+// @Accept       json
+// @Produce      json
+// @Param        request  body      CreateWebhookRequest    true  "注册请求"
+// @Success      201      {object}  map[string]interface{}  "成功响应"
+// @Failure      400      {object}  ErrorResponse  "请求参数错误"
+// @Failure      500      {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/webhooks [post]
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := req.UserID
+	if currentUserID, ok := ctxutil.GetUserID(ctx); ok {
+		userID = currentUserID
+	}
+
+	webhook, err := h.novelService.CreateWebhook(ctx, &novelservice.CreateWebhookRequest{
+		UserID:    userID,
+		URL:       req.URL,
+		TaskTypes: req.TaskTypes,
+	})
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		if errors.Is(err, novelservice.ErrInvalidWebhook) {
+			code = http.StatusBadRequest
+			errorCode = 40003
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    0,
+		"message": "任务通知已注册",
+		"data": gin.H{
+			"webhook": webhook,
+			"secret":  webhook.Secret,
+		},
+	})
+}
+
+// ListWebhooks 查询用户的任务通知订阅
+// @Summary      查询任务通知列表
+// @Description  查询用户注册的任务通知地址（不返回签名密钥）
+// @Tags         任务通知
+// @Accept       json
+// @Produce      json
+// @Param        user_id  query     string  false  "用户ID（已登录时使用当前用户）"
+// @Success      200      {object}  map[string]interface{}  "成功响应"
+// @Failure      400      {object}  ErrorResponse  "请求参数错误"
+// @Failure      500      {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/webhooks [get]
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	userID := c.Query("user_id")
+	if currentUserID, ok := ctxutil.GetUserID(c.Request.Context()); ok {
+		userID = currentUserID
+	}
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "user_id is required",
+		})
+		return
+	}
+
+	webhooks, err := h.novelService.ListWebhooks(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Code:    50001,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"user_id":  userID,
+			"webhooks": webhooks,
+			"count":    len(webhooks),
+		},
+	})
+}
+
+// DeleteWebhook 删除任务通知订阅
+// @Summary      删除任务通知
+// @Description  删除任务通知地址，等待重试的投递不再发送
+// @Tags         任务通知
+// @Accept       json
+// @Produce      json
+// @Param        webhook_id  path      string  true  "订阅ID"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  ErrorResponse  "订阅不存在"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/webhooks/{webhook_id} [delete]
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	webhookID := c.Param("webhook_id")
+	if webhookID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "webhook_id is required",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID, _ := ctxutil.GetUserID(ctx)
+	if err := h.novelService.DeleteWebhook(ctx, userID, webhookID); err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "任务通知已删除",
+		"data": gin.H{
+			"webhook_id": webhookID,
+		},
+	})
+}
+
+// ListWebhookDeliveries 查询任务通知的投递记录
+// @Summary      查询任务通知投递记录
+// @Description  查询任务通知的投递记录（按创建时间倒序），包含通知内容、投递状态（pending 等待重试、sent 已送达、failed 重试耗尽）和每次尝试的响应状态码与错误
+// @Tags         任务通知
+// @Accept       json
+// @Produce      json
+// @Param        webhook_id  path      string  true   "订阅ID"
+// @Param        limit       query     int     false  "返回数量（默认50，最大200）"
+// @Success      200         {object}  map[string]interface{}  "成功响应"
+// @Failure      400         {object}  ErrorResponse  "请求参数错误"
+// @Failure      404         {object}  ErrorResponse  "订阅不存在"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/webhooks/{webhook_id}/deliveries [get]
+func (h *Handler) ListWebhookDeliveries(c *gin.Context) {
+	webhookID := c.Param("webhook_id")
+	if webhookID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "webhook_id is required",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID, _ := ctxutil.GetUserID(ctx)
+	deliveries, err := h.novelService.ListWebhookDeliveries(ctx, userID, webhookID, budgetListLimit(c))
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"webhook_id": webhookID,
+			"deliveries": deliveries,
+			"count":      len(deliveries),
+		},
+	})
+}
+
+// respondWebhookError 订阅不存在返回 404，其余返回 500
+func respondWebhookError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		code = http.StatusNotFound
+		errorCode = 40401
+	}
+
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,220 @@
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	"lemon/internal/pkg/noveltools"
+	novelservice "lemon/internal/service/novel"
+)
+
+// WorkflowTemplateRequest 创建/更新工作流模板请求（除名称外字段为空表示沿用用户的创作默认配置）
+type WorkflowTemplateRequest struct {
+	Name          string                    `json:"name"`           // 模板名称（创建时必填，小写字母开头的 snake_case，2~40 个字符；更新时以路径为准）
+	DisplayName   string                    `json:"display_name"`   // 展示名称（最多 50 个字符，为空时使用模板名称）
+	Description   string                    `json:"description"`    // 说明（最多 500 个字符）
+	NarrationType novel.NarrationType       `json:"narration_type"` // 旁白类型：narration、dialogue
+	Style         novel.NovelStyle          `json:"style"`          // 剧本风格：anime、live、mixed
+	Structure     *novel.NarrationStructure `json:"structure"`      // 解说结构（同 PUT /novels/{novel_id}/narration-structure）
+	Voice         *novel.VoiceSettings      `json:"voice"`          // 配音：voice_type、speed_ratio（0.5~2.0）
+	StylePreset   novel.ImageStylePreset    `json:"style_preset"`   // 图片风格预设：guofeng_comic、anime、realistic、ink_wash、none
+	Video         *novel.VideoProfile       `json:"video"`          // 视频规格：width、height（240~3840 的偶数）、fps（1~60）
+	Platforms     []novel.TargetPlatform    `json:"platforms"`      // 目标发布平台（第一个为小说的默认平台）
+	Metadata      *novel.CreativeMetadata   `json:"metadata"`       // 创作设定（题材、基调、受众、禁用话题、自定义变量）
+}
+
+func (r *WorkflowTemplateRequest) toModel() *novel.WorkflowTemplate {
+	return &novel.WorkflowTemplate{
+		Name:          r.Name,
+		DisplayName:   r.DisplayName,
+		Description:   r.Description,
+		NarrationType: r.NarrationType,
+		Style:         r.Style,
+		Structure:     r.Structure,
+		Voice:         r.Voice,
+		StylePreset:   r.StylePreset,
+		Video:         r.Video,
+		Platforms:     r.Platforms,
+		Metadata:      r.Metadata,
+	}
+}
+
+// ListWorkflowTemplates 列出工作流模板
+// @Summary      列出工作流模板
+// @Description  列出内置模板（快节奏解说、深度剧情向、英文配音版）和自定义模板。创建小说时通过 workflow_template 选用
+// @Tags         工作流模板
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "成功响应"
+// @Failure      500  {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/workflow-templates [get]
+func (h *Handler) ListWorkflowTemplates(c *gin.Context) {
+	templates, err := h.novelService.ListWorkflowTemplates(c.Request.Context())
+	if err != nil {
+		respondWorkflowTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"templates": templates,
+			"count":     len(templates),
+		},
+	})
+}
+
+// GetWorkflowTemplate 查询工作流模板
+// @Summary      查询工作流模板
+// @Description  按名称查询内置或自定义模板
+// @Tags         工作流模板
+// @Accept       json
+// @Produce      json
+// @Param        name  path      string  true  "模板名称"
+// @Success      200   {object}  map[string]interface{}  "成功响应"
+// @Failure      404   {object}  ErrorResponse  "模板不存在"
+// @Failure      500   {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/workflow-templates/{name} [get]
+func (h *Handler) GetWorkflowTemplate(c *gin.Context) {
+	template, err := h.novelService.GetWorkflowTemplate(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		respondWorkflowTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    template,
+	})
+}
+
+// CreateWorkflowTemplate 创建工作流模板
+// @Summary      创建工作流模板
+// @Description  创建自定义模板，打包解说结构、配音、图片风格、视频规格、发布平台和创作设定（仅管理员）
+// @Tags         工作流模板
+// @Accept       json
+// @Produce      json
+// @Param        request  body      WorkflowTemplateRequest  true  "模板配置"
+// @Success      201      {object}  map[string]interface{}  "成功响应"
+// @Failure      400      {object}  ErrorResponse  "请求参数错误或模板配置不合法"
+// @Failure      403      {object}  ErrorResponse  "无权限"
+// @Failure      409      {object}  ErrorResponse  "模板名称已存在"
+// @Failure      500      {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/workflow-templates [post]
+func (h *Handler) CreateWorkflowTemplate(c *gin.Context) {
+	var req WorkflowTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID, _ := ctxutil.GetUserID(ctx)
+	template, err := h.novelService.CreateWorkflowTemplate(ctx, req.toModel(), userID)
+	if err != nil {
+		respondWorkflowTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    0,
+		"message": "工作流模板已创建",
+		"data":    template,
+	})
+}
+
+// UpdateWorkflowTemplate 更新工作流模板
+// @Summary      更新工作流模板
+// @Description  整体替换自定义模板的配置，只影响之后创建的小说；内置模板不能修改（仅管理员）
+// @Tags         工作流模板
+// @Accept       json
+// @Produce      json
+// @Param        name     path      string                   true  "模板名称"
+// @Param        request  body      WorkflowTemplateRequest  true  "模板配置"
+// @Success      200      {object}  map[string]interface{}  "成功响应"
+// @Failure      400      {object}  ErrorResponse  "请求参数错误或模板配置不合法"
+// @Failure      403      {object}  ErrorResponse  "无权限"
+// @Failure      404      {object}  ErrorResponse  "模板不存在"
+// @Failure      409      {object}  ErrorResponse  "内置模板不能修改"
+// @Failure      500      {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/workflow-templates/{name} [put]
+func (h *Handler) UpdateWorkflowTemplate(c *gin.Context) {
+	var req WorkflowTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	template, err := h.novelService.UpdateWorkflowTemplate(c.Request.Context(), c.Param("name"), req.toModel())
+	if err != nil {
+		respondWorkflowTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "工作流模板已更新",
+		"data":    template,
+	})
+}
+
+// DeleteWorkflowTemplate 删除工作流模板
+// @Summary      删除工作流模板
+// @Description  删除自定义模板，已创建的小说不受影响；内置模板不能删除（仅管理员）
+// @Tags         工作流模板
+// @Accept       json
+// @Produce      json
+// @Param        name  path      string  true  "模板名称"
+// @Success      200   {object}  map[string]interface{}  "成功响应"
+// @Failure      403   {object}  ErrorResponse  "无权限"
+// @Failure      404   {object}  ErrorResponse  "模板不存在"
+// @Failure      409   {object}  ErrorResponse  "内置模板不能删除"
+// @Failure      500   {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/workflow-templates/{name} [delete]
+func (h *Handler) DeleteWorkflowTemplate(c *gin.Context) {
+	if err := h.novelService.DeleteWorkflowTemplate(c.Request.Context(), c.Param("name")); err != nil {
+		respondWorkflowTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "工作流模板已删除",
+	})
+}
+
+// respondWorkflowTemplateError 模板配置不合法返回 400，模板不存在返回 404，同名或内置模板返回 409，其余返回 500
+func respondWorkflowTemplateError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+	switch {
+	case errors.Is(err, noveltools.ErrInvalidWorkflowTemplate):
+		code = http.StatusBadRequest
+		errorCode = 40003
+	case errors.Is(err, novelservice.ErrUnknownWorkflowTemplate):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, novelservice.ErrWorkflowTemplateExists), errors.Is(err, novelservice.ErrBuiltinWorkflowTemplate):
+		code = http.StatusConflict
+		errorCode = 40901
+	}
+
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,89 @@
+package resource
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/service"
+)
+
+// GCHandler 资源回收处理器
+type GCHandler struct {
+	gcService service.ResourceGCService
+}
+
+// NewGCHandler 创建资源回收处理器
+func NewGCHandler(gcService service.ResourceGCService) *GCHandler {
+	return &GCHandler{
+		gcService: gcService,
+	}
+}
+
+// CollectGarbageRequest 资源回收请求
+type CollectGarbageRequest struct {
+	DryRun           bool `json:"dry_run"`                                   // 只统计不删除
+	RetentionHours   int  `json:"retention_hours" binding:"omitempty,min=1"` // 保留期（小时，为空时使用配置）
+	GracePeriodHours int  `json:"grace_period_hours" binding:"min=0"`        // 宽限期（小时，为空时使用配置）
+}
+
+// CollectGarbage 执行资源回收
+// @Summary      执行资源回收
+// @Description  回收没有被任何业务记录（音频、字幕、图片、视频、小说等）引用的资源：先处理标记删除超过宽限期的资源（期间重新被引用的恢复，其余从存储中删除文件和记录；存储路径还被其他资源使用时只删除记录），
+// @Description  再把创建超过保留期且没有被引用的资源标记删除（软删除）。dry_run=true 时只返回将要处理的资源，不做任何修改。同一时间只允许一次回收（包括定时回收）
+// @Tags         资源管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      CollectGarbageRequest  true  "资源回收请求"
+// @Success      200      {object}  map[string]interface{}  "成功响应"
+// @Failure      400      {object}  ErrorResponse  "请求参数错误"
+// @Failure      409      {object}  ErrorResponse  "资源回收正在进行中"
+// @Failure      500      {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/resources/gc [post]
+func (h *GCHandler) CollectGarbage(c *gin.Context) {
+	var req CollectGarbageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	report, err := h.gcService.Collect(c.Request.Context(), &service.ResourceGCRequest{
+		DryRun:      req.DryRun,
+		Retention:   time.Duration(req.RetentionHours) * time.Hour,
+		GracePeriod: time.Duration(req.GracePeriodHours) * time.Hour,
+	})
+	if err != nil {
+		code := http.StatusInternalServerError
+		errorCode := 50001
+		switch {
+		case errors.Is(err, service.ErrInvalidResourceGCPolicy):
+			code = http.StatusBadRequest
+			errorCode = 40003
+		case errors.Is(err, service.ErrResourceGCRunning):
+			code = http.StatusConflict
+			errorCode = 40901
+		}
+
+		c.JSON(code, ErrorResponse{
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	message := "资源回收完成"
+	if report.DryRun {
+		message = "资源回收预览完成，未做任何修改"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": message,
+		"data":    report,
+	})
+}
@@ -1,24 +1,34 @@
 package resource
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/pkg/storage"
 	"lemon/internal/service"
+	novelservice "lemon/internal/service/novel"
 )
 
 // DownloadFile 下载文件
 // @Summary      下载文件
-// @Description  根据资源ID下载文件，返回文件流
+// @Description  根据资源ID下载文件，返回文件流。支持断点续传：请求头 Range（单个区间，如 bytes=1048576-）返回 206 和 Content-Range；
+// @Description  响应头 ETag 为文件 SHA256，续传时通过 If-Range 携带，文件已变化时忽略 Range 返回完整文件（200）。区间超出文件大小时返回 416
 // @Tags         资源管理
 // @Accept       json
 // @Produce      application/octet-stream
-// @Param        resource_id  path      string  true  "资源ID"
+// @Param        resource_id  path      string  true   "资源ID"
+// @Param        Range        header    string  false  "下载区间，如 bytes=0-1048575"
+// @Param        If-Range     header    string  false  "上次下载时的 ETag，不一致时返回完整文件"
 // @Success      200         {file}    binary  "文件流"
+// @Success      206         {file}    binary  "文件区间"
 // @Failure      400         {object}  ErrorResponse  "请求参数错误"
 // @Failure      404         {object}  ErrorResponse  "资源不存在"
+// @Failure      416         {object}  ErrorResponse  "请求的区间超出文件大小"
 // @Failure      500         {object}  ErrorResponse  "服务器内部错误"
 // @Router       /api/v1/resources/{resource_id}/download [get]
 func (h *Handler) DownloadFile(c *gin.Context) {
@@ -37,28 +47,49 @@ func (h *Handler) DownloadFile(c *gin.Context) {
 	// 目前先使用空字符串，视为系统内部请求
 	userID := ""
 
-	// 调用Service层
-	result, err := h.resourceService.DownloadFile(ctx, &service.DownloadFileRequest{
+	// 先读取元数据，根据文件大小和 ETag 解析 Range
+	meta, err := h.resourceService.GetResource(ctx, &service.GetResourceRequest{
 		UserID:     userID,
 		ResourceID: resourceID,
 	})
 	if err != nil {
-		code := http.StatusInternalServerError
-		errorCode := 50001
+		respondDownloadError(c, err)
+		return
+	}
+	fileSize := meta.Resource.FileSize
+	etag := ""
+	if meta.Resource.SHA256 != "" {
+		etag = `"` + meta.Resource.SHA256 + `"`
+	}
 
-		// 根据错误类型设置错误码
-		if err.Error() == "资源不存在" {
-			code = http.StatusNotFound
-			errorCode = 40401
-		} else if err.Error() == "无权访问该资源" {
-			code = http.StatusForbidden
-			errorCode = 40301
+	var byteRange *storage.ByteRange
+	if ifRange := c.GetHeader("If-Range"); ifRange == "" || (etag != "" && ifRange == etag) {
+		byteRange, err = storage.ParseRange(c.GetHeader("Range"), fileSize)
+		if errors.Is(err, storage.ErrRangeNotSatisfiable) {
+			c.Header("Content-Range", fmt.Sprintf("bytes */%d", fileSize))
+			c.JSON(http.StatusRequestedRangeNotSatisfiable, ErrorResponse{
+				Code:    41601,
+				Message: "Range not satisfiable",
+				Detail:  err.Error(),
+			})
+			return
 		}
+		// 格式不支持的 Range（如多个区间）按规范忽略，返回完整文件
+	}
 
-		c.JSON(code, ErrorResponse{
-			Code:    errorCode,
-			Message: err.Error(),
-		})
+	downloadReq := &service.DownloadFileRequest{
+		UserID:     userID,
+		ResourceID: resourceID,
+	}
+	if byteRange != nil {
+		downloadReq.Offset = byteRange.Start
+		downloadReq.Length = byteRange.Length()
+	}
+
+	// 调用Service层
+	result, err := h.resourceService.DownloadFile(ctx, downloadReq)
+	if err != nil {
+		respondDownloadError(c, err)
 		return
 	}
 	defer result.Data.Close()
@@ -66,16 +97,44 @@ func (h *Handler) DownloadFile(c *gin.Context) {
 	// 设置响应头
 	c.Header("Content-Type", result.ContentType)
 	c.Header("Content-Disposition", `attachment; filename="`+result.FileName+`"`)
-	c.Header("Content-Length", fmt.Sprintf("%d", result.FileSize))
+	c.Header("Content-Length", fmt.Sprintf("%d", result.Length))
+	c.Header("Accept-Ranges", "bytes")
+	if etag != "" {
+		c.Header("ETag", etag)
+	}
+	status := http.StatusOK
+	if byteRange != nil {
+		c.Header("Content-Range", byteRange.ContentRange(fileSize))
+		status = http.StatusPartialContent
+	}
+	c.Status(status)
 
-	// 流式传输文件
-	_, err = io.Copy(c.Writer, result.Data)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Code:    50002,
-			Message: "Failed to stream file",
-			Detail:  err.Error(),
-		})
-		return
+	// 流式传输文件（响应头已写出，失败时只能中断连接，客户端可从已接收的位置续传）
+	if _, err = io.Copy(c.Writer, result.Data); err != nil {
+		log.Warn().Err(err).Str("resource_id", resourceID).Msg("文件传输中断")
 	}
 }
+
+// respondDownloadError 下载相关接口的错误响应
+func respondDownloadError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+
+	// 根据错误类型设置错误码
+	switch {
+	case errors.Is(err, service.ErrResourceNotFound):
+		code = http.StatusNotFound
+		errorCode = 40401
+	case errors.Is(err, service.ErrResourceAccessDenied), errors.Is(err, novelservice.ErrNovelAccessDenied):
+		code = http.StatusForbidden
+		errorCode = 40301
+	case errors.Is(err, storage.ErrRangeNotSatisfiable):
+		code = http.StatusRequestedRangeNotSatisfiable
+		errorCode = 41601
+	}
+
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
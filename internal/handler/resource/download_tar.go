@@ -0,0 +1,91 @@
+package resource
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	"lemon/internal/service"
+)
+
+// DownloadTarRequest 打包下载请求
+type DownloadTarRequest struct {
+	ResourceIDs  []string `json:"resource_ids" binding:"required"` // 要下载的资源ID（按顺序作为 tar 成员，最多 1000 个）
+	ResumeMember int      `json:"resume_member"`                   // 续传：已完整接收的成员数
+	MemberOffset int64    `json:"member_offset"`                   // 续传：第 resume_member 个成员已接收的字节数
+}
+
+// DownloadTar 流式打包下载
+// @Summary      流式打包下载
+// @Description  把多个资源按顺序打包为 tar 流直接从存储传输给客户端，不在服务端生成压缩包（不占用额外存储）。成员文件名为资源的原始文件名，重名时追加序号；最后一个成员 SHA256SUMS 为各成员的 SHA256 校验清单（sha256sum 格式，边传输边计算）。
+// @Description  传输速度跟随客户端的读取速度，每个成员传输完立即刷新。中断后续传：resume_member 为已完整接收的成员数，member_offset 为下一个成员已接收的字节数，续传的第一个成员只包含剩余内容，并在 PAX 扩展头 LEMON.offset 中标明起始偏移；已接收的成员不再传输，但仍写入校验清单（使用资源记录中的 SHA256）。
+// @Description  响应头 X-Tar-Members 为成员数（不含校验清单）。资源校验在传输前完成，传输开始后出错只能中断连接
+// @Tags         资源管理
+// @Accept       json
+// @Produce      application/x-tar
+// @Param        request  body      DownloadTarRequest  true  "打包下载请求"
+// @Success      200      {file}    binary  "tar 流"
+// @Failure      400      {object}  ErrorResponse  "请求参数错误或续传位置无效"
+// @Failure      403      {object}  ErrorResponse  "无权访问资源"
+// @Failure      404      {object}  ErrorResponse  "资源不存在"
+// @Failure      500      {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/resources/download-tar [post]
+func (h *Handler) DownloadTar(c *gin.Context) {
+	var req DownloadTarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "Invalid request body",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// 开启小说权限时逐个检查资源，任一资源无权访问时整个请求失败
+	if h.accessChecker != nil {
+		userID, _ := ctxutil.GetUserID(ctx)
+		role, _ := ctxutil.GetUserRole(ctx)
+		for _, resourceID := range req.ResourceIDs {
+			if err := h.accessChecker.CheckResourceAccess(ctx, resourceID, userID, role, novel.NovelPermissionRead); err != nil {
+				respondDownloadError(c, err)
+				return
+			}
+		}
+	}
+
+	plan, err := h.resourceService.PlanTarStream(ctx, &service.PlanTarStreamRequest{
+		ResourceIDs:  req.ResourceIDs,
+		ResumeMember: req.ResumeMember,
+		MemberOffset: req.MemberOffset,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTarStream) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    40002,
+				Message: err.Error(),
+			})
+			return
+		}
+		respondDownloadError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "application/x-tar")
+	c.Header("Content-Disposition", `attachment; filename="resources.tar"`)
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Accel-Buffering", "no") // 关闭反向代理缓冲，让客户端的读取速度传递到存储读取
+	c.Header("X-Tar-Members", strconv.Itoa(len(plan.Members)))
+	c.Status(http.StatusOK)
+
+	// 响应头已写出，失败时只能中断连接，客户端按已完整接收的成员续传
+	if err := h.resourceService.StreamTar(ctx, plan, c.Writer); err != nil {
+		log.Warn().Err(err).Int("members", len(plan.Members)).Int("resume_member", plan.ResumeMember).Msg("打包下载传输中断")
+	}
+}
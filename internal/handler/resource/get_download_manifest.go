@@ -0,0 +1,48 @@
+package resource
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/service"
+)
+
+// GetDownloadManifest 获取下载校验清单
+// @Summary      获取下载校验清单
+// @Description  返回文件大小、整个文件的 SHA256 以及按 chunk_size 分块的 SHA256，用于断点续传：续传前按分块校验本地已下载的部分，只保留校验通过的分块，下载完成后校验整个文件。首次请求时读取文件计算，之后直接返回保存的结果
+// @Tags         资源管理
+// @Accept       json
+// @Produce      json
+// @Param        resource_id  path      string  true  "资源ID"
+// @Success      200          {object}  map[string]interface{}  "成功响应"
+// @Failure      400          {object}  ErrorResponse  "请求参数错误"
+// @Failure      404          {object}  ErrorResponse  "资源不存在"
+// @Failure      500          {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v1/resources/{resource_id}/download-manifest [get]
+func (h *Handler) GetDownloadManifest(c *gin.Context) {
+	resourceID := c.Param("resource_id")
+	if resourceID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "Invalid resource_id",
+		})
+		return
+	}
+
+	// TODO: 从认证中间件中获取用户ID
+	// 目前先使用空字符串，视为系统内部请求
+	manifest, err := h.resourceService.GetDownloadManifest(c.Request.Context(), &service.GetDownloadManifestRequest{
+		ResourceID: resourceID,
+	})
+	if err != nil {
+		respondDownloadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    manifest,
+	})
+}
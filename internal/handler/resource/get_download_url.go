@@ -12,7 +12,7 @@ import (
 // GetDownloadURLRequest 获取下载URL请求
 type GetDownloadURLRequest struct {
 	ResourceID string `uri:"resource_id" binding:"required"` // 资源ID（必填）
-	ExpiresIn  int    `form:"expires_in"`                    // 过期时间（秒，可选，默认3600）
+	ExpiresIn  int    `form:"expires_in"`                    // 过期时间（秒，可选，默认和上限按下载链接的有效期策略）
 }
 
 // GetDownloadURLResponseData 获取下载URL响应数据
@@ -27,12 +27,12 @@ type GetDownloadURLResponseData struct {
 
 // GetDownloadURL 获取下载URL（预签名URL）
 // @Summary      获取下载URL
-// @Description  根据资源ID获取预签名的下载URL，适用于客户端直接下载
+// @Description  根据资源ID获取预签名的下载URL，适用于客户端直接下载。有效期默认和上限按存储配置的下载链接策略（storage.url_lifetimes.download，默认 1 小时、最长 24 小时），超过上限时截断；链接不要保存，过期后重新获取
 // @Tags         资源管理
 // @Accept       json
 // @Produce      json
 // @Param        resource_id  path      string  true   "资源ID"
-// @Param        expires_in   query     int     false  "过期时间（秒，默认3600）"
+// @Param        expires_in   query     int     false  "过期时间（秒，默认按有效期策略）"
 // @Success      200          {object}  map[string]interface{}  "成功响应"  "{\"code\": 0, \"message\": \"success\", \"data\": {\"resource_id\": \"...\", \"download_url\": \"...\", \"expires_at\": \"...\", \"file_name\": \"...\", \"file_size\": 1024, \"content_type\": \"...\"}}"
 // @Failure      400          {object}  ErrorResponse  "请求参数错误"
 // @Failure      404          {object}  ErrorResponse  "资源不存在"
@@ -49,8 +49,8 @@ func (h *Handler) GetDownloadURL(c *gin.Context) {
 		return
 	}
 
-	// 解析 expires_in 参数
-	expiresIn := time.Hour // 默认1小时
+	// 解析 expires_in 参数（为 0 时由 service 按有效期策略决定）
+	var expiresIn time.Duration
 	if expiresInStr := c.Query("expires_in"); expiresInStr != "" {
 		if seconds, err := strconv.Atoi(expiresInStr); err == nil && seconds > 0 {
 			expiresIn = time.Duration(seconds) * time.Second
@@ -71,11 +71,14 @@ func (h *Handler) GetResource(c *gin.Context) {
 		return
 	}
 
+	data := GetResourceResponseData{
+		Resource: toResourceInfo(result.Resource),
+	}
+	h.refreshLinks(c, &data)
+
 	c.JSON(http.StatusOK, gin.H{
 		"code":    0,
 		"message": "success",
-		"data": GetResourceResponseData{
-			Resource: toResourceInfo(result.Resource),
-		},
+		"data":    data,
 	})
 }
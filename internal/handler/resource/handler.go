@@ -1,18 +1,35 @@
 package resource
 
 import (
+	"context"
+
+	"lemon/internal/model/auth"
+	"lemon/internal/model/novel"
 	"lemon/internal/service"
 )
 
+// AccessChecker 资源文件权限检查（开启小说权限时由小说服务实现）
+type AccessChecker interface {
+	// CheckResourceAccess 检查用户对资源文件是否有 required 权限
+	CheckResourceAccess(ctx context.Context, resourceID, userID string, role auth.UserRole, required novel.NovelPermission) error
+}
+
 // Handler 资源模块处理器
 // 所有资源相关的Handler方法都通过这个结构体访问Service
 type Handler struct {
 	resourceService service.ResourceService
+	accessChecker   AccessChecker // 为空时不检查权限（未开启小说权限）
+	maxUploadSize   int64         // 上传接口的文件大小上限（字节），<=0 表示不限制
 }
 
 // NewHandler 创建资源模块处理器
-func NewHandler(resourceService service.ResourceService) *Handler {
+// accessChecker 为空时不检查资源权限；路径中带 resource_id 的接口由 ResourceAccess 中间件检查，
+// 请求体中带多个资源ID的接口（打包下载）在处理器中逐个检查
+// maxUploadSize 为上传接口的文件大小上限（字节），<=0 表示不限制
+func NewHandler(resourceService service.ResourceService, accessChecker AccessChecker, maxUploadSize int64) *Handler {
 	return &Handler{
 		resourceService: resourceService,
+		accessChecker:   accessChecker,
+		maxUploadSize:   maxUploadSize,
 	}
 }
@@ -5,6 +5,8 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"lemon/internal/model/auth"
+	"lemon/internal/pkg/ctxutil"
 	"lemon/internal/service"
 )
 
@@ -31,7 +33,7 @@ type ListResourcesResponseData struct {
 // @Tags         资源管理
 // @Accept       json
 // @Produce      json
-// @Param        user_id   query     string  false  "用户ID"
+// @Param        user_id   query     string  false  "用户ID（已登录时只有管理员可以指定）"
 // @Param        ext       query     string  false  "文件扩展名筛选"
 // @Param        status    query     string  false  "状态筛选"
 // @Param        page      query     int     false  "页码（默认1）"
@@ -80,10 +82,13 @@ func (h *Handler) ListResources(c *gin.Context) {
 
 	ctx := c.Request.Context()
 
-	// TODO: 从认证中间件中获取用户ID
-	// 如果请求中没有指定 user_id，且用户已登录，则使用当前登录用户的ID
-	// 目前先使用请求中的 user_id，如果为空则视为系统内部请求
+	// 已登录的非管理员只能查询自己的资源；管理员和未登录（未开启小说权限）时使用请求中的 user_id，为空则查询所有用户
 	userID := req.UserID
+	if currentUserID, ok := ctxutil.GetUserID(ctx); ok {
+		if role, _ := ctxutil.GetUserRole(ctx); role != auth.RoleAdmin {
+			userID = currentUserID
+		}
+	}
 
 	// 调用Service层
 	result, err := h.resourceService.ListResources(ctx, &service.ListResourcesRequest{
@@ -101,14 +106,17 @@ func (h *Handler) ListResources(c *gin.Context) {
 		return
 	}
 
+	data := ListResourcesResponseData{
+		Resources: toResourceInfoList(result.Resources),
+		Total:     result.Total,
+		Page:      result.Page,
+		PageSize:  result.PageSize,
+	}
+	h.refreshLinks(c, &data)
+
 	c.JSON(http.StatusOK, gin.H{
 		"code":    0,
 		"message": "success",
-		"data": ListResourcesResponseData{
-			Resources: toResourceInfoList(result.Resources),
-			Total:     result.Total,
-			Page:      result.Page,
-			PageSize:  result.PageSize,
-		},
+		"data":    data,
 	})
 }
@@ -1,11 +1,14 @@
 package resource
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"path/filepath"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"lemon/internal/pkg/ctxutil"
 	"lemon/internal/service"
 )
 
@@ -24,9 +27,10 @@ type UploadFileResponseData struct {
 // @Accept       multipart/form-data
 // @Produce      json
 // @Param        file      formData  file    true   "上传的文件"
-// @Param        user_id   formData  string  false  "用户ID（可选，如果为空则从认证信息中获取）"
+// @Param        user_id   formData  string  false  "用户ID（未登录时必填；已登录时使用当前登录用户）"
 // @Success      201       {object}  map[string]interface{}  "成功响应"  "{\"code\": 0, \"message\": \"文件上传成功\", \"data\": {\"resource_id\": \"...\", \"resource_url\": \"...\", \"file_size\": 1024, \"file_name\": \"...\"}}"
 // @Failure      400       {object}  ErrorResponse  "请求参数错误"
+// @Failure      413       {object}  ErrorResponse  "文件超过大小限制（storage.max_upload_size）"
 // @Failure      500       {object}  ErrorResponse  "服务器内部错误"
 // @Router       /api/v1/resources/upload [post]
 func (h *Handler) UploadFile(c *gin.Context) {
@@ -41,11 +45,12 @@ func (h *Handler) UploadFile(c *gin.Context) {
 		return
 	}
 
-	// 获取用户ID（从表单或认证信息中获取）
-	userID := c.PostForm("user_id")
+	// 获取用户ID：已登录时使用当前登录用户（忽略表单中的 user_id），否则从表单中获取
+	userID, ok := ctxutil.GetUserID(c.Request.Context())
+	if !ok {
+		userID = c.PostForm("user_id")
+	}
 	if userID == "" {
-		// TODO: 从认证中间件中获取用户ID
-		// 目前先使用默认值或返回错误
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Code:    40002,
 			Message: "user_id is required",
@@ -53,6 +58,16 @@ func (h *Handler) UploadFile(c *gin.Context) {
 		return
 	}
 
+	// 表单解析后已知文件大小，超出上限时不再写入存储
+	if h.maxUploadSize > 0 && file.Size > h.maxUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+			Code:    41301,
+			Message: service.ErrFileTooLarge.Error(),
+			Detail:  fmt.Sprintf("limit %d bytes", h.maxUploadSize),
+		})
+		return
+	}
+
 	// 打开文件
 	fileHeader, err := file.Open()
 	if err != nil {
@@ -86,13 +101,18 @@ func (h *Handler) UploadFile(c *gin.Context) {
 		ContentType: contentType,
 		Ext:         ext,
 		Data:        fileHeader,
+		MaxSize:     h.maxUploadSize,
 	})
 	if err != nil {
 		code := http.StatusInternalServerError
 		errorCode := 50001
 
 		// 根据错误类型设置错误码
-		if strings.Contains(err.Error(), "文件数据不能为空") {
+		switch {
+		case errors.Is(err, service.ErrFileTooLarge):
+			code = http.StatusRequestEntityTooLarge
+			errorCode = 41301
+		case strings.Contains(err.Error(), "文件数据不能为空"):
 			code = http.StatusBadRequest
 			errorCode = 40004
 		}
@@ -0,0 +1,99 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/service"
+)
+
+// uploadStub 只实现 UploadFile 的资源服务，记录收到的请求
+type uploadStub struct {
+	service.ResourceService
+	req *service.UploadFileRequest
+	err error
+}
+
+func (s *uploadStub) UploadFile(ctx context.Context, req *service.UploadFileRequest) (*service.UploadFileResult, error) {
+	s.req = req
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &service.UploadFileResult{ResourceID: "res-1"}, nil
+}
+
+// serveUpload 以 multipart/form-data 上传 content，maxUploadSize 为上传大小限制
+func serveUpload(t *testing.T, svc service.ResourceService, maxUploadSize int64, content []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(content)
+	mw.WriteField("user_id", "user-1")
+	mw.Close()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/resources/upload", NewHandler(svc, nil, maxUploadSize).UploadFile)
+
+	req := httptest.NewRequest(http.MethodPost, "/resources/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestUploadFileMaxSize(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxUploadSize int64
+		size          int
+		wantStatus    int
+		wantCalled    bool
+	}{
+		{"不限制大小", 0, 16, http.StatusCreated, true},
+		{"等于上限", 16, 16, http.StatusCreated, true},
+		{"超出上限时不调用服务", 16, 17, http.StatusRequestEntityTooLarge, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &uploadStub{}
+			w := serveUpload(t, svc, tt.maxUploadSize, bytes.Repeat([]byte("a"), tt.size))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if called := svc.req != nil; called != tt.wantCalled {
+				t.Fatalf("service called = %v, want %v", called, tt.wantCalled)
+			}
+			if tt.wantCalled && svc.req.MaxSize != tt.maxUploadSize {
+				t.Errorf("MaxSize = %d, want %d", svc.req.MaxSize, tt.maxUploadSize)
+			}
+		})
+	}
+}
+
+func TestUploadFileTooLargeFromService(t *testing.T) {
+	svc := &uploadStub{err: fmt.Errorf("%w: limit %d bytes", service.ErrFileTooLarge, 16)}
+	w := serveUpload(t, svc, 16, []byte("small"))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != 41301 {
+		t.Errorf("code = %d, want 41301", resp.Code)
+	}
+}
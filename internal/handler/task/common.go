@@ -0,0 +1,8 @@
+package task
+
+import (
+	httputil "lemon/internal/pkg/http"
+)
+
+// ErrorResponse 错误响应类型别名（使用共用的 http.ErrorResponse）
+type ErrorResponse = httputil.ErrorResponse
@@ -0,0 +1,163 @@
+package task
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/service"
+)
+
+const (
+	// taskLogsHeartbeat SSE 心跳间隔（避免代理层因空闲断开连接）
+	taskLogsHeartbeat = 15 * time.Second
+	// maxTaskLogsPerCatchUp 每次从缓冲区补齐的最大条数
+	maxTaskLogsPerCatchUp = 1000
+)
+
+// SSE 事件类型
+const (
+	taskLogEventLog = "log" // 一条任务日志
+	taskLogEventEnd = "end" // 任务已结束（之后关闭连接）
+)
+
+// GetTaskLogs 查询任务日志
+// @Summary      查询任务日志
+// @Description  查询生成任务的结构化日志（阶段进度、provider 请求ID、ffmpeg 错误输出片段等）。任务ID为生成请求的 X-Request-ID（请求时可以自行指定，也可以从响应头获取）。
+// @Description  默认返回 JSON，用 after=next_seq 续读；follow=true 时以 Server-Sent Events 先推送 after 之后已有的日志，再实时推送新日志（事件 log），任务结束后推送事件 end 并关闭连接。
+// @Description  日志只保存在内存中（每个任务保留最近 1000 条），服务重启或任务被淘汰后返回 404。
+// @Tags         任务
+// @Produce      json
+// @Produce      text/event-stream
+// @Param        task_id  path      string  true   "任务ID（生成请求的 X-Request-ID）"
+// @Param        after    query     int     false  "只返回序号大于该值的日志（默认0）"
+// @Param        limit    query     int     false  "每页数量（默认200，最大1000，follow 模式下忽略）"
+// @Param        follow   query     bool    false  "是否以 SSE 实时跟踪新日志"
+// @Success      200      {object}  map[string]interface{}  "成功响应"
+// @Failure      400      {object}  ErrorResponse  "请求参数错误"
+// @Failure      404      {object}  ErrorResponse  "任务日志不存在"
+// @Router       /api/v1/tasks/{task_id}/logs [get]
+func (h *Handler) GetTaskLogs(c *gin.Context) {
+	taskID := c.Param("task_id")
+	if taskID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: "task_id is required",
+		})
+		return
+	}
+	after, err := strconv.ParseInt(c.DefaultQuery("after", "0"), 10, 64)
+	if err != nil || after < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "after must be a non-negative integer",
+		})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "limit must be a non-negative integer",
+		})
+		return
+	}
+
+	if c.Query("follow") == "true" {
+		h.followTaskLogs(c, taskID, after)
+		return
+	}
+
+	snap, err := h.taskLogService.GetTaskLogs(taskID, after, limit)
+	if err != nil {
+		respondTaskLogError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    snap,
+	})
+}
+
+// followTaskLogs 以 SSE 推送任务日志，直到任务结束或客户端断开
+// 先订阅再读取已有日志，按序号去重；订阅通道丢弃日志（消费过慢）时按序号发现缺口，从缓冲区补齐
+func (h *Handler) followTaskLogs(c *gin.Context, taskID string, after int64) {
+	entries, cancel, err := h.taskLogService.SubscribeTaskLogs(taskID)
+	if err != nil {
+		respondTaskLogError(c, err)
+		return
+	}
+	defer cancel()
+
+	last := after
+	// catchUp 推送 last 之后缓冲区中已有的日志，返回任务是否仍在运行
+	catchUp := func() bool {
+		snap, err := h.taskLogService.GetTaskLogs(taskID, last, maxTaskLogsPerCatchUp)
+		if err != nil {
+			return false
+		}
+		for _, entry := range snap.Entries {
+			c.SSEvent(taskLogEventLog, entry)
+			last = entry.Seq
+		}
+		if snap.HasMore {
+			return true
+		}
+		if !snap.Running {
+			c.SSEvent(taskLogEventEnd, gin.H{"task_id": taskID, "finished_at": snap.FinishedAt, "last_seq": last})
+		}
+		return snap.Running
+	}
+
+	if !catchUp() {
+		return
+	}
+
+	heartbeat := time.NewTicker(taskLogsHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			c.SSEvent("heartbeat", time.Now().Format(time.RFC3339))
+			return true
+		case entry, ok := <-entries:
+			if !ok {
+				// 任务结束：补齐剩余日志后推送 end
+				for catchUp() {
+				}
+				return false
+			}
+			switch {
+			case entry.Seq <= last:
+				return true
+			case entry.Seq > last+1:
+				return catchUp()
+			}
+			c.SSEvent(taskLogEventLog, entry)
+			last = entry.Seq
+			return true
+		}
+	})
+}
+
+// respondTaskLogError 按错误类型返回任务日志接口的错误响应
+func respondTaskLogError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+	if errors.Is(err, service.ErrTaskLogNotFound) {
+		code = http.StatusNotFound
+		errorCode = 40401
+	}
+	c.JSON(code, ErrorResponse{
+		Code:    errorCode,
+		Message: err.Error(),
+	})
+}
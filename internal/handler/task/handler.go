@@ -0,0 +1,18 @@
+package task
+
+import (
+	"lemon/internal/service"
+)
+
+// Handler 任务处理器
+// 所有任务相关的Handler方法都通过这个结构体访问Service
+type Handler struct {
+	taskLogService service.TaskLogService
+}
+
+// NewHandler 创建任务处理器
+func NewHandler(taskLogService service.TaskLogService) *Handler {
+	return &Handler{
+		taskLogService: taskLogService,
+	}
+}
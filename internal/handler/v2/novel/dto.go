@@ -0,0 +1,458 @@
+package novel
+
+import (
+	"time"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/noveltools"
+	novelservice "lemon/internal/service/novel"
+)
+
+// ListData 列表响应数据
+type ListData[T any] struct {
+	Items    []T   `json:"items"`               // 列表项（没有数据时为空数组）
+	Total    int64 `json:"total"`               // 满足查询条件的总数（未分页时即列表项数量）
+	Page     int64 `json:"page,omitempty"`      // 页码（分页时返回）
+	PageSize int64 `json:"page_size,omitempty"` // 每页数量（分页时返回）
+}
+
+// VersionedListData 带版本号的列表响应数据
+type VersionedListData[T any] struct {
+	Version  int   `json:"version"`             // 实际返回的版本号（未指定时为最新版本）
+	Items    []T   `json:"items"`               // 列表项（没有数据时为空数组）
+	Total    int64 `json:"total"`               // 满足查询条件的总数（未分页时即列表项数量）
+	Page     int64 `json:"page,omitempty"`      // 页码（分页时返回）
+	PageSize int64 `json:"page_size,omitempty"` // 每页数量（分页时返回）
+}
+
+// newList 把分页查询结果转换为列表响应数据
+func newList[M any, T any](page *novelservice.ListPage[M], q *noveltools.ListQuery, convert func(M) T) ListData[T] {
+	items := make([]T, 0, len(page.Items))
+	for _, e := range page.Items {
+		items = append(items, convert(e))
+	}
+	return ListData[T]{Items: items, Total: page.Total, Page: q.Page, PageSize: q.PageSize}
+}
+
+// newVersionedList 把分页查询结果转换为带版本号的列表响应数据
+func newVersionedList[M any, T any](page *novelservice.ListPage[M], q *noveltools.ListQuery, convert func(M) T) VersionedListData[T] {
+	list := newList(page, q, convert)
+	return VersionedListData[T]{Version: page.Version, Items: list.Items, Total: list.Total, Page: list.Page, PageSize: list.PageSize}
+}
+
+// formatTime 格式化时间为 RFC3339（UTC），零值返回空字符串
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// formatTimePtr 格式化可选时间
+func formatTimePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return formatTime(*t)
+}
+
+// NovelDTO 小说
+type NovelDTO struct {
+	ID              string `json:"id"`
+	OwnerID         string `json:"owner_id"`
+	ResourceID      string `json:"resource_id"`
+	Title           string `json:"title"`
+	Author          string `json:"author"`
+	Description     string `json:"description"`
+	SourceEncoding  string `json:"source_encoding,omitempty"`
+	NarrationType   string `json:"narration_type"`
+	Style           string `json:"style"`
+	NumberStyle     string `json:"number_style,omitempty"`
+	Continuity      string `json:"continuity,omitempty"`
+	CoverID         string `json:"cover_id,omitempty"`
+	CoverResourceID string `json:"cover_resource_id,omitempty"`
+	CreatedAt       string `json:"created_at"`
+	UpdatedAt       string `json:"updated_at"`
+}
+
+func toNovelDTO(n *novel.Novel) NovelDTO {
+	return NovelDTO{
+		ID:              n.ID,
+		OwnerID:         n.UserID,
+		ResourceID:      n.ResourceID,
+		Title:           n.Title,
+		Author:          n.Author,
+		Description:     n.Description,
+		SourceEncoding:  n.SourceEncoding,
+		NarrationType:   string(n.NarrationType),
+		Style:           string(n.Style),
+		NumberStyle:     string(n.NumberStyle),
+		Continuity:      string(n.Continuity),
+		CoverID:         n.CoverID,
+		CoverResourceID: n.CoverResourceID,
+		CreatedAt:       formatTime(n.CreatedAt),
+		UpdatedAt:       formatTime(n.UpdatedAt),
+	}
+}
+
+// ChapterStatsDTO 章节文本统计
+type ChapterStatsDTO struct {
+	TotalChars int `json:"total_chars"`
+	WordCount  int `json:"word_count"`
+	LineCount  int `json:"line_count"`
+}
+
+// PromotedVersionsDTO 章节发布版本
+type PromotedVersionsDTO struct {
+	NarrationVersion int    `json:"narration_version,omitempty"`
+	ImageVersion     int    `json:"image_version,omitempty"`
+	AudioVersion     int    `json:"audio_version,omitempty"`
+	SubtitleVersion  int    `json:"subtitle_version,omitempty"`
+	VideoVersion     int    `json:"video_version,omitempty"`
+	PromotedBy       string `json:"promoted_by,omitempty"`
+	PromotedAt       string `json:"promoted_at,omitempty"`
+}
+
+// ChapterDTO 章节（不含章节全文，全文通过 v1 章节接口获取）
+type ChapterDTO struct {
+	ID               string               `json:"id"`
+	NovelID          string               `json:"novel_id"`
+	Sequence         int                  `json:"sequence"`
+	Title            string               `json:"title"`
+	OriginalTitle    string               `json:"original_title,omitempty"`
+	Stats            ChapterStatsDTO      `json:"stats"`
+	Approved         bool                 `json:"approved"`
+	ApprovedAt       string               `json:"approved_at,omitempty"`
+	PromotedVersions *PromotedVersionsDTO `json:"promoted_versions,omitempty"`
+	CreatedAt        string               `json:"created_at"`
+	UpdatedAt        string               `json:"updated_at"`
+}
+
+func toChapterDTO(c *novel.Chapter) ChapterDTO {
+	dto := ChapterDTO{
+		ID:            c.ID,
+		NovelID:       c.NovelID,
+		Sequence:      c.Sequence,
+		Title:         c.Title,
+		OriginalTitle: c.OriginalTitle,
+		Stats: ChapterStatsDTO{
+			TotalChars: c.TotalChars,
+			WordCount:  c.WordCount,
+			LineCount:  c.LineCount,
+		},
+		Approved:   c.IsApproved(),
+		ApprovedAt: formatTimePtr(c.ApprovedAt),
+		CreatedAt:  formatTime(c.CreatedAt),
+		UpdatedAt:  formatTime(c.UpdatedAt),
+	}
+	if p := c.PromotedVersions; p != nil {
+		dto.PromotedVersions = &PromotedVersionsDTO{
+			NarrationVersion: p.Narration,
+			ImageVersion:     p.Image,
+			AudioVersion:     p.Audio,
+			SubtitleVersion:  p.Subtitle,
+			VideoVersion:     p.Video,
+			PromotedBy:       p.PromotedBy,
+			PromotedAt:       formatTime(p.PromotedAt),
+		}
+	}
+	return dto
+}
+
+// NarrationFeedbackDTO 促成解说版本的审核意见
+type NarrationFeedbackDTO struct {
+	Comments        string   `json:"comments"`
+	SceneNumbers    []string `json:"scene_numbers"`
+	ReviewerID      string   `json:"reviewer_id,omitempty"`
+	BaseNarrationID string   `json:"base_narration_id,omitempty"`
+	BaseVersion     int      `json:"base_version,omitempty"`
+	CreatedAt       string   `json:"created_at,omitempty"`
+}
+
+// NarrationDTO 解说版本
+type NarrationDTO struct {
+	ID              string                `json:"id"`
+	NovelID         string                `json:"novel_id"`
+	ChapterID       string                `json:"chapter_id"`
+	Version         int                   `json:"version"`
+	Status          string                `json:"status"`
+	ErrorMessage    string                `json:"error_message,omitempty"`
+	CompletedScenes int                   `json:"completed_scenes"`
+	Feedback        *NarrationFeedbackDTO `json:"feedback,omitempty"`
+	CreatedAt       string                `json:"created_at"`
+	UpdatedAt       string                `json:"updated_at"`
+}
+
+func toNarrationDTO(n *novel.Narration) NarrationDTO {
+	dto := NarrationDTO{
+		ID:              n.ID,
+		NovelID:         n.NovelID,
+		ChapterID:       n.ChapterID,
+		Version:         n.Version,
+		Status:          string(n.Status),
+		ErrorMessage:    n.ErrorMessage,
+		CompletedScenes: n.CompletedScenes,
+		CreatedAt:       formatTime(n.CreatedAt),
+		UpdatedAt:       formatTime(n.UpdatedAt),
+	}
+	if f := n.Feedback; f != nil {
+		sceneNumbers := f.SceneNumbers
+		if sceneNumbers == nil {
+			sceneNumbers = []string{}
+		}
+		dto.Feedback = &NarrationFeedbackDTO{
+			Comments:        f.Comments,
+			SceneNumbers:    sceneNumbers,
+			ReviewerID:      f.ReviewerID,
+			BaseNarrationID: f.BaseNarrationID,
+			BaseVersion:     f.BaseVersion,
+			CreatedAt:       formatTime(f.CreatedAt),
+		}
+	}
+	return dto
+}
+
+// ShotDTO 镜头
+type ShotDTO struct {
+	ID             string  `json:"id"`
+	SceneID        string  `json:"scene_id"`
+	Number         string  `json:"number"`   // 镜头编号（场景内）
+	Position       int     `json:"position"` // 场景内顺序（从 1 开始）
+	Index          int     `json:"index"`    // 解说内全局顺序（从 1 开始）
+	Character      string  `json:"character,omitempty"`
+	Image          string  `json:"image"`
+	Narration      string  `json:"narration"`
+	SoundEffect    string  `json:"sound_effect,omitempty"`
+	DurationSec    float64 `json:"duration_sec,omitempty"`
+	ImagePrompt    string  `json:"image_prompt"`
+	VideoPrompt    string  `json:"video_prompt"`
+	CameraMovement string  `json:"camera_movement,omitempty"`
+	Status         string  `json:"status"`
+	ErrorMessage   string  `json:"error_message,omitempty"`
+}
+
+func toShotDTO(s *novel.Shot) ShotDTO {
+	return ShotDTO{
+		ID:             s.ID,
+		SceneID:        s.SceneID,
+		Number:         s.ShotNumber,
+		Position:       s.Sequence,
+		Index:          s.Index,
+		Character:      s.Character,
+		Image:          s.Image,
+		Narration:      s.Narration,
+		SoundEffect:    s.SoundEffect,
+		DurationSec:    s.Duration,
+		ImagePrompt:    s.ImagePrompt,
+		VideoPrompt:    s.VideoPrompt,
+		CameraMovement: s.CameraMovement,
+		Status:         string(s.Status),
+		ErrorMessage:   s.ErrorMessage,
+	}
+}
+
+// SceneDTO 场景（包含场景内的镜头）
+type SceneDTO struct {
+	ID          string    `json:"id"`
+	NarrationID string    `json:"narration_id"`
+	Number      string    `json:"number"`   // 场景编号
+	Position    int       `json:"position"` // 解说内顺序（从 1 开始）
+	Description string    `json:"description"`
+	ImagePrompt string    `json:"image_prompt"`
+	Narration   string    `json:"narration,omitempty"`
+	Status      string    `json:"status"`
+	Shots       []ShotDTO `json:"shots"`
+}
+
+// toSceneDTO 转换场景，shots 可以包含其他场景的镜头，只取属于该场景的镜头（保持传入顺序）
+func toSceneDTO(s *novel.Scene, shots []*novel.Shot) SceneDTO {
+	dto := SceneDTO{
+		ID:          s.ID,
+		NarrationID: s.NarrationID,
+		Number:      s.SceneNumber,
+		Position:    s.Sequence,
+		Description: s.Description,
+		ImagePrompt: s.ImagePrompt,
+		Narration:   s.Narration,
+		Status:      string(s.Status),
+		Shots:       []ShotDTO{},
+	}
+	for _, shot := range shots {
+		if shot.SceneID == s.ID {
+			dto.Shots = append(dto.Shots, toShotDTO(shot))
+		}
+	}
+	return dto
+}
+
+// NarrationDetailDTO 解说版本详情（包含场景和镜头）
+type NarrationDetailDTO struct {
+	NarrationDTO
+	Scenes []SceneDTO `json:"scenes"`
+}
+
+// AudioDTO 音频片段
+type AudioDTO struct {
+	ID                 string  `json:"id"`
+	NarrationID        string  `json:"narration_id"`
+	ChapterID          string  `json:"chapter_id"`
+	ShotID             string  `json:"shot_id,omitempty"`
+	Sequence           int     `json:"sequence"`
+	ResourceID         string  `json:"resource_id"`
+	WaveformResourceID string  `json:"waveform_resource_id,omitempty"` // 波形峰值文件（audiowaveform JSON）
+	DurationSec        float64 `json:"duration_sec"`
+	Text               string  `json:"text"`
+	Version            int     `json:"version"`
+	Status             string  `json:"status"`
+	CreatedAt          string  `json:"created_at"`
+}
+
+func toAudioDTO(a *novel.Audio) AudioDTO {
+	return AudioDTO{
+		ID:                 a.ID,
+		NarrationID:        a.NarrationID,
+		ChapterID:          a.ChapterID,
+		ShotID:             a.ShotID,
+		Sequence:           a.Sequence,
+		ResourceID:         a.AudioResourceID,
+		WaveformResourceID: a.WaveformResourceID,
+		DurationSec:        a.Duration,
+		Text:               a.Text,
+		Version:            a.Version,
+		Status:             string(a.Status),
+		CreatedAt:          formatTime(a.CreatedAt),
+	}
+}
+
+// SubtitleDTO 字幕片段
+type SubtitleDTO struct {
+	ID          string `json:"id"`
+	NarrationID string `json:"narration_id"`
+	ChapterID   string `json:"chapter_id"`
+	ShotID      string `json:"shot_id,omitempty"`
+	Sequence    int    `json:"sequence"`
+	ResourceID  string `json:"resource_id"`
+	Format      string `json:"format"`
+	Version     int    `json:"version"`
+	Status      string `json:"status"`
+	CreatedAt   string `json:"created_at"`
+}
+
+func toSubtitleDTO(s *novel.Subtitle) SubtitleDTO {
+	return SubtitleDTO{
+		ID:          s.ID,
+		NarrationID: s.NarrationID,
+		ChapterID:   s.ChapterID,
+		ShotID:      s.ShotID,
+		Sequence:    s.Sequence,
+		ResourceID:  s.SubtitleResourceID,
+		Format:      string(s.Format),
+		Version:     s.Version,
+		Status:      string(s.Status),
+		CreatedAt:   formatTime(s.CreatedAt),
+	}
+}
+
+// ImageDTO 镜头图片
+type ImageDTO struct {
+	ID                string   `json:"id"`
+	NarrationID       string   `json:"narration_id"`
+	ChapterID         string   `json:"chapter_id"`
+	ShotID            string   `json:"shot_id,omitempty"`
+	SceneNumber       string   `json:"scene_number"`
+	ShotNumber        string   `json:"shot_number"`
+	Sequence          int      `json:"sequence"`
+	ResourceID        string   `json:"resource_id"`
+	CharacterName     string   `json:"character_name,omitempty"`
+	Prompt            string   `json:"prompt,omitempty"`
+	LocalizedPrompt   string   `json:"localized_prompt,omitempty"`
+	StyleReferenceIDs []string `json:"style_reference_ids"`
+	Version           int      `json:"version"`
+	Status            string   `json:"status"`
+	CreatedAt         string   `json:"created_at"`
+}
+
+func toImageDTO(i *novel.Image) ImageDTO {
+	refs := i.StyleReferenceIDs
+	if refs == nil {
+		refs = []string{}
+	}
+	return ImageDTO{
+		ID:                i.ID,
+		NarrationID:       i.NarrationID,
+		ChapterID:         i.ChapterID,
+		ShotID:            i.ShotID,
+		SceneNumber:       i.SceneNumber,
+		ShotNumber:        i.ShotNumber,
+		Sequence:          i.Sequence,
+		ResourceID:        i.ImageResourceID,
+		CharacterName:     i.CharacterName,
+		Prompt:            i.Prompt,
+		LocalizedPrompt:   i.LocalizedPrompt,
+		StyleReferenceIDs: refs,
+		Version:           i.Version,
+		Status:            string(i.Status),
+		CreatedAt:         formatTime(i.CreatedAt),
+	}
+}
+
+// SequenceRangeDTO 视频片段覆盖的镜头序号范围
+type SequenceRangeDTO struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// VideoDTO 视频（解说视频片段和最终视频使用同一结构，通过 kind 区分）
+type VideoDTO struct {
+	ID                         string            `json:"id"`
+	NovelID                    string            `json:"novel_id"`
+	ChapterID                  string            `json:"chapter_id"`
+	NarrationID                string            `json:"narration_id,omitempty"`
+	ShotID                     string            `json:"shot_id,omitempty"`
+	Kind                       string            `json:"kind"`                     // narration_video, final_video
+	SequenceRange              *SequenceRangeDTO `json:"sequence_range,omitempty"` // 仅解说视频片段
+	ResourceID                 string            `json:"resource_id"`
+	ThumbnailSpriteResourceID  string            `json:"thumbnail_sprite_resource_id,omitempty"`  // 缩略图雪碧图（JPEG）
+	ThumbnailVTTResourceID     string            `json:"thumbnail_vtt_resource_id,omitempty"`     // 雪碧图 WebVTT 索引
+	TranscriptResourceID       string            `json:"transcript_resource_id,omitempty"`        // 纯文本文字稿（仅最终视频）
+	AudioDescriptionResourceID string            `json:"audio_description_resource_id,omitempty"` // 口述影像音轨（AAC）
+	DescriptionVTTResourceID   string            `json:"description_vtt_resource_id,omitempty"`   // 口述影像 WebVTT 描述轨
+	DurationSec                float64           `json:"duration_sec"`
+	Platform                   string            `json:"platform,omitempty"`
+	ExportTier                 string            `json:"export_tier,omitempty"`
+	Version                    int               `json:"version"`
+	Status                     string            `json:"status"`
+	ErrorMessage               string            `json:"error_message,omitempty"`
+	CreatedAt                  string            `json:"created_at"`
+	UpdatedAt                  string            `json:"updated_at"`
+}
+
+func toVideoDTO(v *novel.Video) VideoDTO {
+	dto := VideoDTO{
+		ID:                         v.ID,
+		NovelID:                    v.NovelID,
+		ChapterID:                  v.ChapterID,
+		NarrationID:                v.NarrationID,
+		ShotID:                     v.ShotID,
+		Kind:                       string(v.VideoType),
+		ResourceID:                 v.VideoResourceID,
+		ThumbnailSpriteResourceID:  v.ThumbnailSpriteResourceID,
+		ThumbnailVTTResourceID:     v.ThumbnailVTTResourceID,
+		TranscriptResourceID:       v.TranscriptResourceID,
+		AudioDescriptionResourceID: v.AudioDescriptionResourceID,
+		DescriptionVTTResourceID:   v.DescriptionVTTResourceID,
+		DurationSec:                v.Duration,
+		Platform:                   string(v.Platform),
+		ExportTier:                 string(v.ExportTier),
+		Version:                    v.Version,
+		Status:                     string(v.Status),
+		ErrorMessage:               v.ErrorMessage,
+		CreatedAt:                  formatTime(v.CreatedAt),
+		UpdatedAt:                  formatTime(v.UpdatedAt),
+	}
+	if v.VideoType == novel.VideoTypeNarration {
+		start, end := v.SequenceRange()
+		dto.SequenceRange = &SequenceRangeDTO{Start: start, End: end}
+	}
+	return dto
+}
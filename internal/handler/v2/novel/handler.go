@@ -0,0 +1,77 @@
+// Package novel 提供 /api/v2 下的小说接口
+//
+// v2 接口的响应只通过本包的 DTO 输出，不直接序列化 model 结构体，model 字段重命名或拆分（如 Video 与 ChapterVideo）不会影响客户端。
+// 响应约定：
+//   - 字段统一使用 snake_case，外键统一命名为 <资源>_id
+//   - 时间统一为 RFC3339（UTC）字符串，为空时省略
+//   - 枚举统一输出字符串
+//   - 列表统一为 {"items": [...], "total": n}，查询条件（如 version、page、page_size）与列表同级返回；
+//     列表接口都支持 page/page_size 分页（不指定时返回全部）、sort/order 排序和 status 筛选，total 为满足条件的总数
+package novel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	httputil "lemon/internal/pkg/http"
+	"lemon/internal/pkg/noveltools"
+	"lemon/internal/service/novel"
+)
+
+// ErrorResponse 错误响应类型别名（使用共用的 http.ErrorResponse）
+type ErrorResponse = httputil.ErrorResponse
+
+// Handler v2 小说处理器
+type Handler struct {
+	novelService novel.NovelService
+}
+
+// NewHandler 创建 v2 小说处理器
+func NewHandler(novelService novel.NovelService) *Handler {
+	return &Handler{
+		novelService: novelService,
+	}
+}
+
+// respondOK 返回成功响应
+func respondOK(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusOK, httputil.NewSuccessResponse("success", data))
+}
+
+// respondError 按错误类型返回失败响应
+func respondError(c *gin.Context, err error) {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:    40401,
+			Message: "resource not found",
+		})
+		return
+	}
+	if errors.Is(err, noveltools.ErrInvalidListQuery) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40003,
+			Message: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Code:    50001,
+		Message: err.Error(),
+	})
+}
+
+// requireParam 读取必填路径参数，缺失时返回 400
+func requireParam(c *gin.Context, name string) (string, bool) {
+	value := c.Param(name)
+	if value == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40001,
+			Message: name + " is required",
+		})
+		return "", false
+	}
+	return value, true
+}
@@ -0,0 +1,347 @@
+package novel
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/pkg/noveltools"
+)
+
+// GetNovel 获取小说
+// @Summary      获取小说（v2）
+// @Description  根据小说ID获取小说信息
+// @Tags         v2
+// @Produce      json
+// @Param        novel_id  path      string  true  "小说ID"
+// @Success      200       {object}  map[string]interface{}  "data 为 NovelDTO"
+// @Failure      404       {object}  ErrorResponse  "小说不存在"
+// @Failure      500       {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v2/novels/{novel_id} [get]
+func (h *Handler) GetNovel(c *gin.Context) {
+	novelID, ok := requireParam(c, "novel_id")
+	if !ok {
+		return
+	}
+	n, err := h.novelService.GetNovel(c.Request.Context(), novelID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, toNovelDTO(n))
+}
+
+// ListChapters 获取小说的章节列表
+// @Summary      获取章节列表（v2）
+// @Description  按章节序号返回小说的章节（不含章节全文），指定 page/page_size 时分页返回
+// @Tags         v2
+// @Produce      json
+// @Param        novel_id   path      string  true   "小说ID"
+// @Param        page       query     int     false  "页码，从 1 开始"
+// @Param        page_size  query     int     false  "每页数量（最大 100，只指定 page 时为 20）"
+// @Param        sort       query     string  false  "排序字段：sequence, created_at"
+// @Param        order      query     string  false  "排序顺序：asc, desc"
+// @Success      200        {object}  map[string]interface{}  "data 为 ListData[ChapterDTO]"
+// @Failure      400        {object}  ErrorResponse  "分页、排序参数不合法"
+// @Failure      500        {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v2/novels/{novel_id}/chapters [get]
+func (h *Handler) ListChapters(c *gin.Context) {
+	novelID, ok := requireParam(c, "novel_id")
+	if !ok {
+		return
+	}
+	q, ok := listQuery(c)
+	if !ok {
+		return
+	}
+	page, err := h.novelService.QueryChapters(c.Request.Context(), novelID, q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, newList(page, q, toChapterDTO))
+}
+
+// ListNarrations 获取章节的解说版本列表
+// @Summary      获取解说版本列表（v2）
+// @Description  返回章节的解说版本（不含场景，按版本倒序），可按版本和状态筛选、分页
+// @Tags         v2
+// @Produce      json
+// @Param        chapter_id  path      string  true   "章节ID"
+// @Param        version     query     int     false  "解说版本号（不指定时返回所有版本）"
+// @Param        page        query     int     false  "页码，从 1 开始"
+// @Param        page_size   query     int     false  "每页数量（最大 100，只指定 page 时为 20）"
+// @Param        sort        query     string  false  "排序字段：created_at"
+// @Param        order       query     string  false  "排序顺序：asc, desc"
+// @Param        status      query     string  false  "状态，逗号分隔"
+// @Success      200         {object}  map[string]interface{}  "data 为 ListData[NarrationDTO]"
+// @Failure      400         {object}  ErrorResponse  "查询参数不合法"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v2/chapters/{chapter_id}/narrations [get]
+func (h *Handler) ListNarrations(c *gin.Context) {
+	chapterID, ok := requireParam(c, "chapter_id")
+	if !ok {
+		return
+	}
+	version, ok := versionQuery(c)
+	if !ok {
+		return
+	}
+	q, ok := listQuery(c)
+	if !ok {
+		return
+	}
+	page, err := h.novelService.QueryNarrations(c.Request.Context(), chapterID, version, q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, newList(page, q, toNarrationDTO))
+}
+
+// GetNarration 获取解说版本详情
+// @Summary      获取解说详情（v2）
+// @Description  返回解说版本及其场景，场景内包含镜头
+// @Tags         v2
+// @Produce      json
+// @Param        narration_id  path      string  true  "解说ID"
+// @Success      200           {object}  map[string]interface{}  "data 为 NarrationDetailDTO"
+// @Failure      404           {object}  ErrorResponse  "解说不存在"
+// @Failure      500           {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v2/narrations/{narration_id} [get]
+func (h *Handler) GetNarration(c *gin.Context) {
+	narrationID, ok := requireParam(c, "narration_id")
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	n, err := h.novelService.GetNarrationByID(ctx, narrationID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	scenes, err := h.novelService.GetScenesByNarrationID(ctx, narrationID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	shots, err := h.novelService.GetShotsByNarrationID(ctx, narrationID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	detail := NarrationDetailDTO{
+		NarrationDTO: toNarrationDTO(n),
+		Scenes:       make([]SceneDTO, 0, len(scenes)),
+	}
+	for _, scene := range scenes {
+		detail.Scenes = append(detail.Scenes, toSceneDTO(scene, shots))
+	}
+	respondOK(c, detail)
+}
+
+// ListAudios 获取解说的音频片段
+// @Summary      获取音频列表（v2）
+// @Description  返回解说指定版本（默认最新版本）的音频片段
+// @Tags         v2
+// @Produce      json
+// @Param        narration_id  path      string  true   "解说ID"
+// @Param        version       query     int     false  "音频版本号"
+// @Param        page          query     int     false  "页码，从 1 开始"
+// @Param        page_size     query     int     false  "每页数量（最大 100，只指定 page 时为 20）"
+// @Param        sort          query     string  false  "排序字段：sequence, created_at"
+// @Param        order         query     string  false  "排序顺序：asc, desc"
+// @Param        status        query     string  false  "状态，逗号分隔"
+// @Success      200           {object}  map[string]interface{}  "data 为 VersionedListData[AudioDTO]"
+// @Failure      400           {object}  ErrorResponse  "查询参数不合法"
+// @Failure      500           {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v2/narrations/{narration_id}/audios [get]
+func (h *Handler) ListAudios(c *gin.Context) {
+	narrationID, ok := requireParam(c, "narration_id")
+	if !ok {
+		return
+	}
+	version, ok := versionQuery(c)
+	if !ok {
+		return
+	}
+	q, ok := listQuery(c)
+	if !ok {
+		return
+	}
+	page, err := h.novelService.QueryAudios(c.Request.Context(), narrationID, version, q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, newVersionedList(page, q, toAudioDTO))
+}
+
+// ListSubtitles 获取解说的字幕片段
+// @Summary      获取字幕列表（v2）
+// @Description  返回解说指定版本（默认最新版本）的字幕片段
+// @Tags         v2
+// @Produce      json
+// @Param        narration_id  path      string  true   "解说ID"
+// @Param        version       query     int     false  "字幕版本号"
+// @Param        page          query     int     false  "页码，从 1 开始"
+// @Param        page_size     query     int     false  "每页数量（最大 100，只指定 page 时为 20）"
+// @Param        sort          query     string  false  "排序字段：sequence, created_at"
+// @Param        order         query     string  false  "排序顺序：asc, desc"
+// @Param        status        query     string  false  "状态，逗号分隔"
+// @Success      200           {object}  map[string]interface{}  "data 为 VersionedListData[SubtitleDTO]"
+// @Failure      400           {object}  ErrorResponse  "查询参数不合法"
+// @Failure      500           {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v2/narrations/{narration_id}/subtitles [get]
+func (h *Handler) ListSubtitles(c *gin.Context) {
+	narrationID, ok := requireParam(c, "narration_id")
+	if !ok {
+		return
+	}
+	version, ok := versionQuery(c)
+	if !ok {
+		return
+	}
+	q, ok := listQuery(c)
+	if !ok {
+		return
+	}
+	page, err := h.novelService.QuerySubtitles(c.Request.Context(), narrationID, version, q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, newVersionedList(page, q, toSubtitleDTO))
+}
+
+// ListImages 获取解说的镜头图片
+// @Summary      获取图片列表（v2）
+// @Description  返回解说指定版本（默认最新版本）的镜头图片
+// @Tags         v2
+// @Produce      json
+// @Param        narration_id  path      string  true   "解说ID"
+// @Param        version       query     int     false  "图片版本号"
+// @Param        page          query     int     false  "页码，从 1 开始"
+// @Param        page_size     query     int     false  "每页数量（最大 100，只指定 page 时为 20）"
+// @Param        sort          query     string  false  "排序字段：sequence, created_at"
+// @Param        order         query     string  false  "排序顺序：asc, desc"
+// @Param        status        query     string  false  "状态，逗号分隔"
+// @Success      200           {object}  map[string]interface{}  "data 为 VersionedListData[ImageDTO]"
+// @Failure      400           {object}  ErrorResponse  "查询参数不合法"
+// @Failure      500           {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v2/narrations/{narration_id}/images [get]
+func (h *Handler) ListImages(c *gin.Context) {
+	narrationID, ok := requireParam(c, "narration_id")
+	if !ok {
+		return
+	}
+	version, ok := versionQuery(c)
+	if !ok {
+		return
+	}
+	q, ok := listQuery(c)
+	if !ok {
+		return
+	}
+	page, err := h.novelService.QueryImages(c.Request.Context(), narrationID, version, q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, newVersionedList(page, q, toImageDTO))
+}
+
+// ListVideos 获取章节的视频
+// @Summary      获取视频列表（v2）
+// @Description  返回章节指定版本（默认最新版本）的视频，解说视频片段和最终视频通过 kind 区分
+// @Tags         v2
+// @Produce      json
+// @Param        chapter_id  path      string  true   "章节ID"
+// @Param        version     query     int     false  "视频版本号"
+// @Param        page        query     int     false  "页码，从 1 开始"
+// @Param        page_size   query     int     false  "每页数量（最大 100，只指定 page 时为 20）"
+// @Param        sort        query     string  false  "排序字段：sequence, created_at"
+// @Param        order       query     string  false  "排序顺序：asc, desc"
+// @Param        status      query     string  false  "状态，逗号分隔"
+// @Param        video_type  query     string  false  "视频类型，逗号分隔（narration_video, final_video, novel_video）"
+// @Success      200         {object}  map[string]interface{}  "data 为 VersionedListData[VideoDTO]"
+// @Failure      400         {object}  ErrorResponse  "查询参数不合法"
+// @Failure      500         {object}  ErrorResponse  "服务器内部错误"
+// @Router       /api/v2/chapters/{chapter_id}/videos [get]
+func (h *Handler) ListVideos(c *gin.Context) {
+	chapterID, ok := requireParam(c, "chapter_id")
+	if !ok {
+		return
+	}
+	version, ok := versionQuery(c)
+	if !ok {
+		return
+	}
+	q, ok := listQuery(c)
+	if !ok {
+		return
+	}
+	page, err := h.novelService.QueryVideos(c.Request.Context(), chapterID, version, q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, newVersionedList(page, q, toVideoDTO))
+}
+
+// versionQuery 读取可选的 version 查询参数（未指定时为 0，表示最新版本），格式错误时返回 400
+func versionQuery(c *gin.Context) (int, bool) {
+	value := c.Query("version")
+	if value == "" {
+		return 0, true
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil || version <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: "version must be a positive integer",
+		})
+		return 0, false
+	}
+	return version, true
+}
+
+// listQuery 读取列表的分页、排序和筛选参数（page、page_size、sort、order、status、video_type），参数不合法时返回 400
+func listQuery(c *gin.Context) (*noveltools.ListQuery, bool) {
+	page, ok := nonNegativeQuery(c, "page")
+	if !ok {
+		return nil, false
+	}
+	pageSize, ok := nonNegativeQuery(c, "page_size")
+	if !ok {
+		return nil, false
+	}
+	q, err := noveltools.ParseListQuery(page, pageSize, c.Query("sort"), c.Query("order"), c.Query("status"), c.Query("video_type"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40003,
+			Message: err.Error(),
+		})
+		return nil, false
+	}
+	return q, true
+}
+
+// nonNegativeQuery 读取可选的非负整数查询参数（未指定时为 0），格式错误时返回 400
+func nonNegativeQuery(c *gin.Context, name string) (int64, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, true
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Code:    40002,
+			Message: name + " must be a non-negative integer",
+		})
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,77 @@
+package embed
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EmbedToken 嵌入播放令牌实体
+// 发放给合作方用于调用公开只读接口（章节元数据、视频播放地址、字幕、缩略图），与管理接口的 JWT 相互独立
+// 数据库中只保存令牌的 SHA-256 摘要，明文只在创建时返回一次
+type EmbedToken struct {
+	ID                 string     `bson:"id" json:"id"`                                         // 令牌ID（UUID）
+	Name               string     `bson:"name" json:"name"`                                     // 令牌名称（合作方/用途说明）
+	TokenHash          string     `bson:"token_hash" json:"-"`                                  // 令牌摘要（SHA-256，十六进制）
+	TokenPrefix        string     `bson:"token_prefix" json:"token_prefix"`                     // 令牌前缀（用于识别，不可用于认证）
+	NovelIDs           []string   `bson:"novel_ids" json:"novel_ids"`                           // 可访问的小说ID（令牌作用域）
+	AllowedOrigins     []string   `bson:"allowed_origins,omitempty" json:"allowed_origins"`     // 允许嵌入的来源（为空时使用全局配置）
+	RateLimitPerMinute int        `bson:"rate_limit_per_minute" json:"rate_limit_per_minute"`   // 每分钟请求上限（0 表示使用全局默认值）
+	CreatedBy          string     `bson:"created_by,omitempty" json:"created_by,omitempty"`     // 创建人用户ID
+	LastUsedAt         *time.Time `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"` // 最近使用时间
+	ExpiresAt          *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"`     // 过期时间（为空表示不过期）
+	RevokedAt          *time.Time `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`     // 吊销时间
+	CreatedAt          time.Time  `bson:"created_at" json:"created_at"`                         // 创建时间
+	UpdatedAt          time.Time  `bson:"updated_at" json:"updated_at"`                         // 更新时间
+}
+
+// IsActive 判断令牌当前是否可用（未吊销且未过期）
+func (t *EmbedToken) IsActive(now time.Time) bool {
+	if t.RevokedAt != nil {
+		return false
+	}
+	return t.ExpiresAt == nil || now.Before(*t.ExpiresAt)
+}
+
+// AllowsNovel 判断令牌是否可以访问指定小说
+func (t *EmbedToken) AllowsNovel(novelID string) bool {
+	for _, id := range t.NovelIDs {
+		if id == novelID {
+			return true
+		}
+	}
+	return false
+}
+
+// Collection 返回集合名称
+func (t *EmbedToken) Collection() string {
+	return "embed_tokens"
+}
+
+// EnsureIndexes 创建和维护索引
+func (t *EmbedToken) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(t.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_token_hash_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "created_by", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_created_by_created_at"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -0,0 +1,65 @@
+package maintenance
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DowntimeWindow 停机窗口实体
+// 记录每次打开熔断开关（维护模式）的时间段，用于事后统计供应商故障造成的停机时长
+// EndedAt 为空表示开关仍处于打开状态
+type DowntimeWindow struct {
+	ID             string     `bson:"id" json:"id"`                                     // 停机窗口ID（UUID）
+	Scope          string     `bson:"scope" json:"scope"`                               // 开关范围：global 或 provider（llm, tts, image, video）
+	Reason         string     `bson:"reason,omitempty" json:"reason,omitempty"`         // 维护原因
+	InFlightPolicy string     `bson:"in_flight_policy" json:"in_flight_policy"`         // 进行中任务的处理策略：finish, abort
+	StartedBy      string     `bson:"started_by,omitempty" json:"started_by,omitempty"` // 打开开关的操作人（配置启动时为 config）
+	EndedBy        string     `bson:"ended_by,omitempty" json:"ended_by,omitempty"`     // 关闭开关的操作人
+	StartedAt      time.Time  `bson:"started_at" json:"started_at"`                     // 开始时间
+	EndedAt        *time.Time `bson:"ended_at,omitempty" json:"ended_at,omitempty"`     // 结束时间
+	CreatedAt      time.Time  `bson:"created_at" json:"created_at"`                     // 创建时间
+	UpdatedAt      time.Time  `bson:"updated_at" json:"updated_at"`                     // 更新时间
+}
+
+// IsOpen 判断停机窗口是否仍未结束
+func (w *DowntimeWindow) IsOpen() bool {
+	return w.EndedAt == nil
+}
+
+// Duration 返回停机时长（未结束时计算到当前时间）
+func (w *DowntimeWindow) Duration() time.Duration {
+	if w.EndedAt == nil {
+		return time.Since(w.StartedAt)
+	}
+	return w.EndedAt.Sub(w.StartedAt)
+}
+
+// Collection 返回集合名称
+func (w *DowntimeWindow) Collection() string {
+	return "downtime_windows"
+}
+
+// EnsureIndexes 创建和维护索引
+func (w *DowntimeWindow) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(w.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "scope", Value: 1}, {Key: "ended_at", Value: 1}},
+			Options: options.Index().SetName("idx_scope_ended"),
+		},
+		{
+			Keys:    bson.D{{Key: "started_at", Value: -1}},
+			Options: options.Index().SetName("idx_started_at"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -0,0 +1,82 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AnalyticsDataset 分析数据导出的数据集
+type AnalyticsDataset string
+
+const (
+	AnalyticsDatasetTasks    AnalyticsDataset = "tasks"     // 队列任务（按 updated_at 增量导出，状态变化后会再次导出，按 job_id 取最新一行）
+	AnalyticsDatasetUsage    AnalyticsDataset = "usage"     // 章节渲染阶段执行记录（耗时、provider 调用次数、传输字节数）
+	AnalyticsDatasetCosts    AnalyticsDataset = "costs"     // 付费 provider 的费用记录
+	AnalyticsDatasetQAScores AnalyticsDataset = "qa_scores" // 章节 QA 评分（最终视频生成后计算）
+)
+
+// AnalyticsDatasets 所有数据集（按导出顺序）
+var AnalyticsDatasets = []AnalyticsDataset{
+	AnalyticsDatasetTasks,
+	AnalyticsDatasetUsage,
+	AnalyticsDatasetCosts,
+	AnalyticsDatasetQAScores,
+}
+
+// IsValid 检查数据集是否有效
+func (d AnalyticsDataset) IsValid() bool {
+	switch d {
+	case AnalyticsDatasetTasks, AnalyticsDatasetUsage, AnalyticsDatasetCosts, AnalyticsDatasetQAScores:
+		return true
+	}
+	return false
+}
+
+// AnalyticsExportBatch 分析数据导出批次
+// 说明：每个批次是一个 JSON Lines 文件（每行一条记录），数据集内按 Seq 连续递增；
+// 最新批次的游标即下次增量导出的检查点，数仓按 Seq 顺序拉取批次并加载
+type AnalyticsExportBatch struct {
+	ID            string           `bson:"id" json:"id"`                                       // 批次ID（UUID）
+	Dataset       AnalyticsDataset `bson:"dataset" json:"dataset"`                             // 数据集
+	Seq           int64            `bson:"seq" json:"seq"`                                     // 批次序号（数据集内从 1 开始连续递增）
+	SchemaVersion int              `bson:"schema_version" json:"schema_version"`               // 行结构版本（字段变化时递增）
+	ResourceID    string           `bson:"resource_id,omitempty" json:"resource_id,omitempty"` // JSON Lines 文件的 resource_id（没有行时为空，只推进游标）
+	Rows          int              `bson:"rows" json:"rows"`                                   // 行数
+	Bytes         int64            `bson:"bytes" json:"bytes"`                                 // 文件大小（字节）
+
+	// 游标：源记录按（时间, ID）升序导出，本批次覆盖 (From, To] 区间
+	FromTime time.Time `bson:"from_time" json:"from_time"`
+	FromID   string    `bson:"from_id,omitempty" json:"from_id,omitempty"`
+	ToTime   time.Time `bson:"to_time" json:"to_time"`
+	ToID     string    `bson:"to_id" json:"to_id"`
+
+	UserID    string    `bson:"user_id,omitempty" json:"user_id,omitempty"` // 触发导出的用户ID（定时导出为 system）
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// Collection 返回集合名称
+func (b *AnalyticsExportBatch) Collection() string {
+	return "analytics_export_batches"
+}
+
+// EnsureIndexes 创建和维护索引
+func (b *AnalyticsExportBatch) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(b.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			// 同一数据集的批次序号唯一：多个实例同时导出时只有一个能提交同一序号的批次
+			Keys:    bson.D{{Key: "dataset", Value: 1}, {Key: "seq", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_dataset_seq_unique"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
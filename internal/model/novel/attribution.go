@@ -0,0 +1,38 @@
+package novel
+
+import "time"
+
+// AttributionPlacement 署名展示方式
+type AttributionPlacement string
+
+const (
+	AttributionPlacementOverlay  AttributionPlacement = "overlay"   // 在视频开头的画面底部叠加署名文字
+	AttributionPlacementEndFrame AttributionPlacement = "end_frame" // 在视频结尾追加黑底署名画面
+)
+
+// IsValid 检查署名展示方式是否合法
+func (p AttributionPlacement) IsValid() bool {
+	return p == AttributionPlacementOverlay || p == AttributionPlacementEndFrame
+}
+
+// Attribution 小说署名设置
+// 说明：授权改编的小说按协议要求在每个最终视频中展示原作署名；Required 为 true 时署名无法渲染则最终视频生成失败
+type Attribution struct {
+	Required  bool                 `bson:"required" json:"required"`                         // 是否必须署名（授权协议要求）
+	Text      string               `bson:"text,omitempty" json:"text,omitempty"`             // 署名文字，为空时按原作名称、作者、授权方和授权说明拼接
+	WorkTitle string               `bson:"work_title,omitempty" json:"work_title,omitempty"` // 原作名称
+	Author    string               `bson:"author,omitempty" json:"author,omitempty"`         // 原作作者
+	Licensor  string               `bson:"licensor,omitempty" json:"licensor,omitempty"`     // 授权方
+	License   string               `bson:"license,omitempty" json:"license,omitempty"`       // 授权说明（如授权编号、协议名称）
+	Placement AttributionPlacement `bson:"placement" json:"placement"`                       // 展示方式：overlay（开头叠加）、end_frame（结尾署名画面）
+	Duration  float64              `bson:"duration" json:"duration"`                         // 展示时长（秒）
+	UpdatedAt time.Time            `bson:"updated_at" json:"updated_at"`
+}
+
+// AttributionRecord 最终视频中实际渲染的署名（用于合规审计）
+type AttributionRecord struct {
+	Text      string               `bson:"text" json:"text"`           // 渲染的署名文字
+	Placement AttributionPlacement `bson:"placement" json:"placement"` // 展示方式
+	Duration  float64              `bson:"duration" json:"duration"`   // 展示时长（秒）
+	Required  bool                 `bson:"required" json:"required"`   // 渲染时小说是否要求署名
+}
@@ -17,14 +17,17 @@ type Audio struct {
 	ChapterID   string `bson:"chapter_id" json:"chapter_id"`     // 关联的章节ID
 	NovelID     string `bson:"novel_id" json:"novel_id"`         // 关联的小说ID
 	UserID      string `bson:"user_id" json:"user_id"`           // 用户ID
+	ShotID      string `bson:"shot_id,omitempty" json:"shot_id,omitempty"` // 关联的镜头ID（稳定标识，镜头重排后仍通过它关联）
 	Sequence        int        `bson:"sequence" json:"sequence"`                   // 音频片段序号（从1开始）
 	AudioResourceID string     `bson:"audio_resource_id" json:"audio_resource_id"` // 音频文件的 resource_id
 	Duration        float64    `bson:"duration" json:"duration"`                   // 音频时长（秒）
 	Text            string     `bson:"text" json:"text"`                           // 对应的解说文本
 	Timestamps      []CharTime `bson:"timestamps" json:"timestamps"`               // 字符级别的时间戳
 	Prompt          string     `bson:"prompt,omitempty" json:"prompt,omitempty"`   // 生成音频时使用的提示词/参数（TTS参数配置）
+	WaveformResourceID string  `bson:"waveform_resource_id,omitempty" json:"waveform_resource_id,omitempty"` // 波形峰值文件（audiowaveform JSON）的 resource_id，供编辑器画波形
 	Version         int        `bson:"version" json:"version"`                     // 版本号（用于支持多版本，默认 1）
 	Status          TaskStatus `bson:"status" json:"status"`                       // 状态：pending, completed, failed
+	ReusedFromID    string     `bson:"reused_from_id,omitempty" json:"reused_from_id,omitempty"` // 复用的上一版本音频ID（增量重新合成时文本未修改的片段，与其共用音频文件）
 	CreatedAt       time.Time  `bson:"created_at" json:"created_at"`
 	UpdatedAt       time.Time  `bson:"updated_at" json:"updated_at"`
 	DeletedAt       *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
@@ -50,6 +53,10 @@ func (a *Audio) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
 			Keys:    bson.D{{Key: "narration_id", Value: 1}, {Key: "sequence", Value: 1}},
 			Options: options.Index().SetName("idx_narration_sequence"),
 		},
+		{
+			Keys:    bson.D{{Key: "shot_id", Value: 1}},
+			Options: options.Index().SetName("idx_shot_id"),
+		},
 		{
 			Keys:    bson.D{{Key: "chapter_id", Value: 1}},
 			Options: options.Index().SetName("idx_chapter_id"),
@@ -0,0 +1,51 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DefaultBGMVolume 背景音乐默认音量（相对原音量的倍数，避免盖过解说）
+const DefaultBGMVolume = 0.25
+
+// BGMTrack 背景音乐曲库中的曲目
+// 说明：曲目按情绪标注，合成最终视频时按场景情绪选择曲目；同一情绪可以有多首曲目
+type BGMTrack struct {
+	ID         string     `bson:"id" json:"id"`                                 // 曲目ID（UUID）
+	Name       string     `bson:"name" json:"name"`                             // 曲目名称
+	Mood       SceneMood  `bson:"mood" json:"mood"`                             // 适用的场景情绪
+	Tags       []string   `bson:"tags,omitempty" json:"tags,omitempty"`         // 自定义标签（如风格、乐器，便于编辑挑选）
+	ResourceID string     `bson:"resource_id" json:"resource_id"`               // 音频文件的 resource_id
+	Duration   float64    `bson:"duration,omitempty" json:"duration,omitempty"` // 时长（秒，场景比曲目长时循环播放）
+	Volume     float64    `bson:"volume" json:"volume"`                         // 混音音量（0-1，相对原音量的倍数）
+	UserID     string     `bson:"user_id" json:"user_id"`                       // 上传用户ID
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time  `bson:"updated_at" json:"updated_at"`
+	DeletedAt  *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
+
+// Collection 返回集合名称
+func (t *BGMTrack) Collection() string {
+	return "bgm_tracks"
+}
+
+// EnsureIndexes 创建和维护索引
+func (t *BGMTrack) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(t.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "mood", Value: 1}, {Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("idx_mood_created"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
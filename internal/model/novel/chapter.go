@@ -2,6 +2,7 @@ package novel
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -18,7 +19,12 @@ type Chapter struct {
 	UserID  string `bson:"user_id" json:"user_id"`
 
 	Sequence int    `bson:"sequence" json:"sequence"` // 章节序号，从1开始
-	Title    string `bson:"title" json:"title"`
+	Title    string `bson:"title" json:"title"`       // 展示标题（去掉空白和括号批注）
+
+	// 标题规范化信息
+	OriginalTitle   string `bson:"original_title,omitempty" json:"original_title,omitempty"`     // 切分器识别出的原始标题
+	NormalizedTitle string `bson:"normalized_title,omitempty" json:"normalized_title,omitempty"` // 规范化标题（章节序号为阿拉伯数字，如「第12章 重生」）
+	TitleNumber     int    `bson:"title_number,omitempty" json:"title_number,omitempty"`         // 标题中的章节序号（用于排序），无法识别时为 0
 
 	ChapterText string `bson:"chapter_text" json:"chapter_text"` // 章节全文
 
@@ -27,24 +33,103 @@ type Chapter struct {
 	WordCount  int `bson:"word_count" json:"word_count"`   // 章节总字数（仅中文字符，不包括标点）
 	LineCount  int `bson:"line_count" json:"line_count"`   // 章节行数
 
+	// 审核信息（审核通过后才允许导出无水印的授权版视频）
+	ApprovedBy string     `bson:"approved_by,omitempty" json:"approved_by,omitempty"` // 审核人用户ID
+	ApprovedAt *time.Time `bson:"approved_at,omitempty" json:"approved_at,omitempty"` // 审核通过时间
+
+	// 发布版本（运营审核后锁定的各类产物版本，为空表示尚未发布）
+	PromotedVersions *PromotedVersions `bson:"promoted_versions,omitempty" json:"promoted_versions,omitempty"`
+
+	// 视频阶段使用的字幕版本（选中导入的外部字幕时设置，为 0 表示使用最新生成的字幕）
+	SubtitleVersion int `bson:"subtitle_version,omitempty" json:"subtitle_version,omitempty"`
+
+	// 背景音乐选择（为空时合成最终视频按场景情绪从曲库自动选择曲目）
+	BGM *ChapterBGM `bson:"bgm,omitempty" json:"bgm,omitempty"`
+
+	// 内容摘要缓存（首次查询时生成，章节全文变化后失效）
+	Summary *ChapterSummary `bson:"summary,omitempty" json:"summary,omitempty"`
+
+	// 分支信息（为空表示主章节）：分支与主章节序号相同，独立运行流水线，选定后合并回主章节
+	Branch *ChapterBranch `bson:"branch,omitempty" json:"branch,omitempty"`
+
 	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time  `bson:"updated_at" json:"updated_at"`
 	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
 }
 
+// PromotedVersions 章节的发布版本
+// 各字段为对应产物的版本号，0 表示该类产物尚未发布
+type PromotedVersions struct {
+	Narration  int       `bson:"narration,omitempty" json:"narration,omitempty"` // 解说版本
+	Image      int       `bson:"image,omitempty" json:"image,omitempty"`         // 图片版本
+	Audio      int       `bson:"audio,omitempty" json:"audio,omitempty"`         // 音频版本
+	Subtitle   int       `bson:"subtitle,omitempty" json:"subtitle,omitempty"`   // 字幕版本
+	Video      int       `bson:"video,omitempty" json:"video,omitempty"`         // 最终视频版本
+	PromotedBy string    `bson:"promoted_by,omitempty" json:"promoted_by,omitempty"`
+	PromotedAt time.Time `bson:"promoted_at" json:"promoted_at"`
+}
+
+// ChapterBGM 章节的背景音乐选择
+type ChapterBGM struct {
+	TrackID    string    `bson:"track_id,omitempty" json:"track_id,omitempty"`       // 整章使用的曲目（曲目比章节短时循环播放）
+	Volume     float64   `bson:"volume,omitempty" json:"volume,omitempty"`           // 混音音量（0-1，为 0 时使用曲目的音量）
+	Disabled   bool      `bson:"disabled,omitempty" json:"disabled,omitempty"`       // 不加背景音乐
+	SelectedBy string    `bson:"selected_by,omitempty" json:"selected_by,omitempty"` // 选择人用户ID
+	SelectedAt time.Time `bson:"selected_at" json:"selected_at"`
+}
+
+// ChapterSummary 章节内容摘要（供编辑在处理前快速了解章节）
+type ChapterSummary struct {
+	Summary         string    `bson:"summary" json:"summary"`                   // 内容摘要（200~300 字）
+	POVCharacters   []string  `bson:"pov_characters" json:"pov_characters"`     // 视角人物
+	EstimatedScenes int       `bson:"estimated_scenes" json:"estimated_scenes"` // 预计场景数
+	TextHash        string    `bson:"text_hash" json:"text_hash"`               // 生成摘要时章节全文的哈希，与当前全文不一致时摘要失效
+	GeneratedAt     time.Time `bson:"generated_at" json:"generated_at"`
+}
+
+// ChapterBranch 章节分支信息
+type ChapterBranch struct {
+	ParentID      string              `bson:"parent_id" json:"parent_id"`                     // 主章节ID
+	Name          string              `bson:"name" json:"name"`                               // 分支名称（同一章节内唯一）
+	WithArtifacts bool                `bson:"with_artifacts" json:"with_artifacts"`           // 创建时是否引用了主章节最新的解说及其产物
+	Status        ChapterBranchStatus `bson:"status" json:"status"`                           // 分支状态：open, merged, discarded
+	ForkedBy      string              `bson:"forked_by,omitempty" json:"forked_by,omitempty"` // 创建人用户ID
+	ClosedBy      string              `bson:"closed_by,omitempty" json:"closed_by,omitempty"` // 合并或放弃的操作人用户ID
+	ClosedAt      *time.Time          `bson:"closed_at,omitempty" json:"closed_at,omitempty"` // 合并或放弃时间
+}
+
+// IsBranch 章节是否为分支
+func (c *Chapter) IsBranch() bool {
+	return c.Branch != nil
+}
+
+// IsApproved 章节是否已审核通过
+func (c *Chapter) IsApproved() bool {
+	return c.ApprovedAt != nil
+}
+
 // Collection 返回集合名称
 func (c *Chapter) Collection() string { return "chapters" }
 
 // EnsureIndexes 创建和维护索引
 func (c *Chapter) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
 	coll := db.Collection(c.Collection())
+	// 章节分支与主章节序号相同，旧的 (novel_id, sequence) 唯一索引改为包含分支名称
+	if _, err := coll.Indexes().DropOne(ctx, "uniq_novel_sequence"); err != nil && !isIndexNotFound(err) {
+		return err
+	}
 	indexes := []mongo.IndexModel{
 		{
 			Keys: bson.D{
 				{Key: "novel_id", Value: 1},
 				{Key: "sequence", Value: 1},
+				{Key: "branch.name", Value: 1},
 			},
-			Options: options.Index().SetName("uniq_novel_sequence").SetUnique(true),
+			Options: options.Index().SetName("uniq_novel_sequence_branch").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "branch.parent_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_branch_parent"),
 		},
 		{
 			Keys:    bson.D{{Key: "novel_id", Value: 1}},
@@ -58,3 +143,10 @@ func (c *Chapter) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
 	_, err := coll.Indexes().CreateMany(ctx, indexes)
 	return err
 }
+
+// isIndexNotFound 要删除的索引或集合不存在（新部署的库没有旧索引）
+// 27: IndexNotFound，26: NamespaceNotFound
+func isIndexNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && (cmdErr.Code == 27 || cmdErr.Code == 26)
+}
@@ -0,0 +1,106 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ChapterEventType 章节流程事件类型
+type ChapterEventType string
+
+const (
+	ChapterEventStageStarted   ChapterEventType = "stage_started"   // 阶段开始
+	ChapterEventStageCompleted ChapterEventType = "stage_completed" // 阶段完成
+	ChapterEventStageFailed    ChapterEventType = "stage_failed"    // 阶段失败
+)
+
+// String 返回事件类型的字符串表示
+func (t ChapterEventType) String() string {
+	return string(t)
+}
+
+// ChapterEvent 章节流程事件
+// 说明：章节渲染流程状态的事实来源，只追加不修改；章节当前的流程状态由事件投影得到（见 ChapterPipelineState）
+type ChapterEvent struct {
+	ID        string           `bson:"id" json:"id"`                 // 事件ID（UUID）
+	NovelID   string           `bson:"novel_id" json:"novel_id"`     // 关联的小说ID
+	ChapterID string           `bson:"chapter_id" json:"chapter_id"` // 关联的章节ID
+	RunID     string           `bson:"run_id" json:"run_id"`         // 阶段执行ID（同一次执行的开始/结束事件相同）
+	Type      ChapterEventType `bson:"type" json:"type"`             // 事件类型
+	Stage     PipelineStage    `bson:"stage" json:"stage"`           // 阶段
+
+	Payload    *ChapterEventPayload `bson:"payload,omitempty" json:"payload,omitempty"` // 事件发生时的快照（结束事件才有）
+	OccurredAt time.Time            `bson:"occurred_at" json:"occurred_at"`             // 事件发生时间
+	CreatedAt  time.Time            `bson:"created_at" json:"created_at"`
+}
+
+// ChapterEventPayload 阶段结束时的快照
+type ChapterEventPayload struct {
+	DurationMs      int64          `bson:"duration_ms" json:"duration_ms"`                           // 耗时（毫秒）
+	ProviderCalls   map[string]int `bson:"provider_calls,omitempty" json:"provider_calls,omitempty"` // 按 provider 统计的调用次数
+	CostFen         int64          `bson:"cost_fen" json:"cost_fen"`                                 // 费用（分）
+	BytesUploaded   int64          `bson:"bytes_uploaded" json:"bytes_uploaded"`                     // 上传字节数
+	BytesDownloaded int64          `bson:"bytes_downloaded" json:"bytes_downloaded"`                 // 下载字节数
+	ErrorMessage    string         `bson:"error_message,omitempty" json:"error_message,omitempty"`   // 失败原因
+	ErrorClass      string         `bson:"error_class,omitempty" json:"error_class,omitempty"`       // 失败类别
+}
+
+// Collection 返回集合名称
+func (e *ChapterEvent) Collection() string {
+	return "chapter_events"
+}
+
+// EnsureIndexes 创建和维护索引
+func (e *ChapterEvent) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(e.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "chapter_id", Value: 1}, {Key: "occurred_at", Value: 1}},
+			Options: options.Index().SetName("idx_chapter_occurred"),
+		},
+		{
+			Keys:    bson.D{{Key: "run_id", Value: 1}},
+			Options: options.Index().SetName("idx_run_id"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// StageState 阶段状态（由事件投影得到）
+type StageState string
+
+const (
+	StageStatePending   StageState = "pending"   // 未开始
+	StageStateRunning   StageState = "running"   // 执行中
+	StageStateCompleted StageState = "completed" // 已完成
+	StageStateFailed    StageState = "failed"    // 失败
+)
+
+// ChapterPipelineState 章节渲染流程的当前状态（由章节事件投影得到，不落库）
+type ChapterPipelineState struct {
+	ChapterID       string                 `json:"chapter_id"`
+	Stages          []ChapterStageProgress `json:"stages"`                  // 各阶段状态（按流程顺序）
+	CurrentStage    PipelineStage          `json:"current_stage,omitempty"` // 当前阶段：执行中的阶段，否则为第一个未完成的阶段；全部完成时为空
+	CompletedStages int                    `json:"completed_stages"`        // 已完成的阶段数
+	TotalStages     int                    `json:"total_stages"`            // 阶段总数
+	Progress        float64                `json:"progress"`                // 进度（0~1）
+	EventCount      int                    `json:"event_count"`             // 投影使用的事件数
+	UpdatedAt       *time.Time             `json:"updated_at,omitempty"`    // 最近一条事件的时间
+}
+
+// ChapterStageProgress 单个阶段的状态
+type ChapterStageProgress struct {
+	Stage          PipelineStage `json:"stage"`
+	State          StageState    `json:"state"`                      // 最近一次执行的状态
+	Attempts       int           `json:"attempts"`                   // 执行次数
+	LastStartedAt  *time.Time    `json:"last_started_at,omitempty"`  // 最近一次开始时间
+	LastFinishedAt *time.Time    `json:"last_finished_at,omitempty"` // 最近一次结束时间
+	DurationMs     int64         `json:"duration_ms,omitempty"`      // 最近一次执行耗时（毫秒）
+	ErrorMessage   string        `json:"error_message,omitempty"`    // 最近一次失败原因
+	EverCompleted  bool          `json:"ever_completed"`             // 是否曾经成功完成过（重跑失败时产物仍可用）
+}
@@ -24,6 +24,7 @@ type Character struct {
 	Description     string `bson:"description" json:"description"`           // 角色详细描述
 	ImagePrompt     string `bson:"image_prompt" json:"image_prompt"`          // 角色图片提示词
 	ImageResourceID string `bson:"image_resource_id,omitempty" json:"image_resource_id,omitempty"` // 角色图片的 resource_id
+	ImageStyleReferenceIDs []string `bson:"image_style_reference_ids,omitempty" json:"image_style_reference_ids,omitempty"` // 生成角色图片时使用的风格参考图ID
 
 	// Appearance 外貌特征
 	Appearance *CharacterAppearance `bson:"appearance,omitempty" json:"appearance,omitempty"`
@@ -31,6 +32,10 @@ type Character struct {
 	// Clothing 服装风格
 	Clothing *CharacterClothing `bson:"clothing,omitempty" json:"clothing,omitempty"`
 
+	VoiceType       string                    `bson:"voice_type,omitempty" json:"voice_type,omitempty"`             // 角色配音音色（接受选角建议或手动指定）
+	VoiceSource     CharacterVoiceSource      `bson:"voice_source,omitempty" json:"voice_source,omitempty"`         // 音色来源：suggestion, manual
+	VoiceSuggestion *CharacterVoiceSuggestion `bson:"voice_suggestion,omitempty" json:"voice_suggestion,omitempty"` // 最近一次的选角建议
+
 	Status      TaskStatus `bson:"status" json:"status"`                           // 状态：pending, completed, failed
 	ErrorMessage string    `bson:"error_message,omitempty" json:"error_message,omitempty"` // 错误信息（失败时）
 	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
@@ -46,6 +51,14 @@ type CharacterAppearance struct {
 	Body      string `bson:"body,omitempty" json:"body,omitempty"`             // 身材特征
 }
 
+// CharacterVoiceSuggestion 角色配音音色的选角建议
+type CharacterVoiceSuggestion struct {
+	VoiceType   string    `bson:"voice_type" json:"voice_type"`                 // 建议的音色
+	Reason      string    `bson:"reason,omitempty" json:"reason,omitempty"`     // 建议理由
+	Fallback    bool      `bson:"fallback,omitempty" json:"fallback,omitempty"` // 是否为按性别和年龄段匹配的兜底建议（大模型没有给出有效建议）
+	SuggestedAt time.Time `bson:"suggested_at" json:"suggested_at"`             // 建议时间
+}
+
 // CharacterClothing 角色服装风格
 type CharacterClothing struct {
 	Top       string `bson:"top,omitempty" json:"top,omitempty"`             // 上衣
@@ -0,0 +1,79 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CompilationSelection 合集镜头的选择方式
+type CompilationSelection string
+
+const (
+	CompilationSelectionManual     CompilationSelection = "manual"     // 手动指定镜头列表
+	CompilationSelectionHighlights CompilationSelection = "highlights" // 按镜头评分自动选择高光镜头
+)
+
+// Compilation 跨章节回顾合集（横版）实体
+// 说明：从多个章节中选取镜头，由大模型重新撰写回顾解说，按新解说的配音时长重新剪辑每个镜头后拼接成一个独立视频，
+// 与章节的 narration_video / final_video 互不影响
+type Compilation struct {
+	ID              string               `bson:"id" json:"id"`                                                   // 合集ID（UUID）
+	NovelID         string               `bson:"novel_id" json:"novel_id"`                                       // 关联的小说ID
+	UserID          string               `bson:"user_id" json:"user_id"`                                         // 创建用户ID
+	Title           string               `bson:"title" json:"title"`                                             // 合集标题
+	ChapterFrom     int                  `bson:"chapter_from" json:"chapter_from"`                               // 起始章节序号
+	ChapterTo       int                  `bson:"chapter_to" json:"chapter_to"`                                   // 结束章节序号
+	Selection       CompilationSelection `bson:"selection" json:"selection"`                                     // 镜头选择方式：manual, highlights
+	TargetDuration  float64              `bson:"target_duration" json:"target_duration"`                         // 目标时长（秒）
+	Clips           []CompilationClip    `bson:"clips" json:"clips"`                                             // 合集镜头（按播放顺序）
+	Width           int                  `bson:"width" json:"width"`                                             // 视频宽度（横版）
+	Height          int                  `bson:"height" json:"height"`                                           // 视频高度
+	FPS             int                  `bson:"fps" json:"fps"`                                                 // 帧率
+	VideoResourceID string               `bson:"video_resource_id,omitempty" json:"video_resource_id,omitempty"` // 合集视频的 resource_id
+	Duration        float64              `bson:"duration,omitempty" json:"duration,omitempty"`                   // 合集视频时长（秒）
+	Attribution     *AttributionRecord   `bson:"attribution,omitempty" json:"attribution,omitempty"`             // 渲染的原作署名（用于合规审计）
+	Status          VideoStatus          `bson:"status" json:"status"`                                           // 状态：processing, completed, failed
+	ErrorMessage    string               `bson:"error_message,omitempty" json:"error_message,omitempty"`         // 错误信息（失败时）
+	ErrorClass      string               `bson:"error_class,omitempty" json:"error_class,omitempty"`             // 失败类别（失败时）
+	CreatedAt       time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time            `bson:"updated_at" json:"updated_at"`
+}
+
+// CompilationClip 合集中的一个镜头
+type CompilationClip struct {
+	ChapterID       string  `bson:"chapter_id" json:"chapter_id"`                               // 镜头所属章节ID
+	ChapterSequence int     `bson:"chapter_sequence" json:"chapter_sequence"`                   // 镜头所属章节序号
+	ShotID          string  `bson:"shot_id" json:"shot_id"`                                     // 镜头ID
+	SourceVideoID   string  `bson:"source_video_id,omitempty" json:"source_video_id,omitempty"` // 画面来源的单镜头片段（镜头在合并片段中或还没有视频时为空，使用镜头图片）
+	SourceImageID   string  `bson:"source_image_id,omitempty" json:"source_image_id,omitempty"` // 画面来源的镜头图片（没有单镜头片段时）
+	Rating          int     `bson:"rating,omitempty" json:"rating,omitempty"`                   // 选择时的镜头评分
+	Narration       string  `bson:"narration" json:"narration"`                                 // 回顾解说（大模型重新撰写）
+	Start           float64 `bson:"start" json:"start"`                                         // 在合集中的开始时间（秒）
+	Duration        float64 `bson:"duration" json:"duration"`                                   // 时长（秒，即回顾解说配音时长）
+}
+
+// Collection 返回集合名称
+func (c *Compilation) Collection() string {
+	return "compilations"
+}
+
+// EnsureIndexes 创建和维护索引
+func (c *Compilation) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(c.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "novel_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_novel_created"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
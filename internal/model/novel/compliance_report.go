@@ -0,0 +1,33 @@
+package novel
+
+import "time"
+
+// ComplianceReport 最终视频的平台规范校验报告（仅 final_video）
+// 说明：最终渲染时按目标平台的预设归一化响度、限制码率并统一封装格式，处理后探测成片并记录校验结果
+type ComplianceReport struct {
+	Preset    string    `bson:"preset" json:"preset"`                     // 使用的平台预设
+	Passed    bool      `bson:"passed" json:"passed"`                     // 是否全部检查通过
+	Issues    []string  `bson:"issues,omitempty" json:"issues,omitempty"` // 未通过的检查项说明
+	CheckedAt time.Time `bson:"checked_at" json:"checked_at"`             // 校验时间
+
+	// 目标值
+	TargetLoudness   float64 `bson:"target_loudness" json:"target_loudness"`       // 目标综合响度（LUFS）
+	TargetTruePeak   float64 `bson:"target_true_peak" json:"target_true_peak"`     // 真峰值上限（dBTP）
+	TargetPixFmt     string  `bson:"target_pix_fmt" json:"target_pix_fmt"`         // 要求的像素格式
+	MaxVideoBitrate  int     `bson:"max_video_bitrate" json:"max_video_bitrate"`   // 视频码率上限（kbps）
+	TargetSampleRate int     `bson:"target_sample_rate" json:"target_sample_rate"` // 要求的音频采样率（Hz）
+	Faststart        bool    `bson:"faststart" json:"faststart"`                   // 是否要求 faststart
+
+	// 实测值
+	InputLoudness   float64 `bson:"input_loudness" json:"input_loudness"`                           // 处理前综合响度（LUFS）
+	InputTruePeak   float64 `bson:"input_true_peak" json:"input_true_peak"`                         // 处理前真峰值（dBTP）
+	OutputLoudness  float64 `bson:"output_loudness" json:"output_loudness"`                         // 处理后综合响度（LUFS）
+	OutputTruePeak  float64 `bson:"output_true_peak" json:"output_true_peak"`                       // 处理后真峰值（dBTP）
+	OutputLRA       float64 `bson:"output_lra" json:"output_lra"`                                   // 处理后响度范围（LU）
+	VideoCodec      string  `bson:"video_codec,omitempty" json:"video_codec,omitempty"`             // 视频编码
+	PixFmt          string  `bson:"pix_fmt,omitempty" json:"pix_fmt,omitempty"`                     // 像素格式
+	VideoBitrate    int     `bson:"video_bitrate,omitempty" json:"video_bitrate,omitempty"`         // 视频码率（kbps）
+	AudioCodec      string  `bson:"audio_codec,omitempty" json:"audio_codec,omitempty"`             // 音频编码
+	AudioSampleRate int     `bson:"audio_sample_rate,omitempty" json:"audio_sample_rate,omitempty"` // 音频采样率（Hz）
+	MoovAtFront     bool    `bson:"moov_at_front" json:"moov_at_front"`                             // moov 是否前置
+}
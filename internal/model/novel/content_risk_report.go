@@ -0,0 +1,124 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ContentRiskCategory 内容风险类别
+type ContentRiskCategory string
+
+const (
+	ContentRiskCopyright      ContentRiskCategory = "copyright"       // 版权：歌词、影视台词等受版权保护的内容
+	ContentRiskMedicalClaim   ContentRiskCategory = "medical_claim"   // 医疗功效宣称
+	ContentRiskFinancialClaim ContentRiskCategory = "financial_claim" // 投资收益宣称
+	ContentRiskBannedTopic    ContentRiskCategory = "banned_topic"    // 平台禁止的话题
+	ContentRiskProfanity      ContentRiskCategory = "profanity"       // 粗口脏话
+)
+
+// String 返回风险类别的字符串表示
+func (c ContentRiskCategory) String() string {
+	return string(c)
+}
+
+// ContentRiskLevel 内容风险等级
+type ContentRiskLevel string
+
+const (
+	ContentRiskLevelMedium ContentRiskLevel = "medium" // 中风险：可能被限流或要求修改
+	ContentRiskLevelHigh   ContentRiskLevel = "high"   // 高风险：大概率被平台拒绝或下架
+)
+
+// ContentRiskSource 被扫描文本的来源
+type ContentRiskSource string
+
+const (
+	ContentRiskSourceSceneNarration ContentRiskSource = "scene_narration" // 场景解说
+	ContentRiskSourceShotNarration  ContentRiskSource = "shot_narration"  // 镜头解说（字幕正文）
+	ContentRiskSourceSubtitleIntro  ContentRiskSource = "subtitle_intro"  // 字幕片头文案
+	ContentRiskSourceSubtitleOutro  ContentRiskSource = "subtitle_outro"  // 字幕片尾文案
+)
+
+// ContentRiskSegment 一段被扫描的文本
+type ContentRiskSegment struct {
+	Source ContentRiskSource `bson:"source" json:"source"`               // 文本来源
+	Ref    string            `bson:"ref,omitempty" json:"ref,omitempty"` // 相关对象（场景ID、镜头ID）
+	Text   string            `bson:"text" json:"text"`                   // 文本内容
+}
+
+// ContentRiskHighlight 命中的文本位置（按字符计，左闭右开）
+type ContentRiskHighlight struct {
+	Start int    `bson:"start" json:"start"`
+	End   int    `bson:"end" json:"end"`
+	Text  string `bson:"text" json:"text"`
+}
+
+// ContentRiskFinding 单条风险：同一规则在同一段文本中的所有命中
+type ContentRiskFinding struct {
+	Category   ContentRiskCategory    `bson:"category" json:"category"`
+	Level      ContentRiskLevel       `bson:"level" json:"level"`
+	Rule       string                 `bson:"rule" json:"rule"`       // 命中的规则名称
+	Message    string                 `bson:"message" json:"message"` // 风险说明
+	Segment    ContentRiskSegment     `bson:"segment" json:"segment"` // 命中的文本段
+	Highlights []ContentRiskHighlight `bson:"highlights" json:"highlights"`
+}
+
+// ContentRiskReport 发布前的内容风险报告
+// 说明：扫描章节某个解说版本的解说文本和字幕文案生成；存在风险时需要编辑确认后才能发布该版本。
+// Fingerprint 由扫描的文本、目标平台和规则版本计算，文本修改后需要重新扫描和确认
+type ContentRiskReport struct {
+	ID               string               `bson:"id" json:"id"`                               // 报告ID（UUID）
+	NovelID          string               `bson:"novel_id" json:"novel_id"`                   // 关联的小说ID
+	ChapterID        string               `bson:"chapter_id" json:"chapter_id"`               // 关联的章节ID
+	NarrationID      string               `bson:"narration_id" json:"narration_id"`           // 扫描的解说ID
+	NarrationVersion int                  `bson:"narration_version" json:"narration_version"` // 扫描的解说版本
+	Platform         TargetPlatform       `bson:"platform" json:"platform"`                   // 目标发布平台（决定平台禁止话题）
+	Fingerprint      string               `bson:"fingerprint" json:"fingerprint"`             // 扫描内容指纹
+	Findings         []ContentRiskFinding `bson:"findings" json:"findings"`                   // 风险列表（按等级从高到低）
+	HighRisk         int                  `bson:"high_risk" json:"high_risk"`                 // 高风险条数
+	RequiresAck      bool                 `bson:"requires_ack" json:"requires_ack"`           // 是否需要编辑确认后才能发布
+	AcknowledgedBy   string               `bson:"acknowledged_by,omitempty" json:"acknowledged_by,omitempty"`
+	AcknowledgedAt   *time.Time           `bson:"acknowledged_at,omitempty" json:"acknowledged_at,omitempty"`
+	AckNote          string               `bson:"ack_note,omitempty" json:"ack_note,omitempty"` // 确认说明（如已向版权方获得授权）
+	CreatedAt        time.Time            `bson:"created_at" json:"created_at"`
+}
+
+// Collection 返回集合名称
+func (r *ContentRiskReport) Collection() string {
+	return "content_risk_reports"
+}
+
+// EnsureIndexes 创建和维护索引
+func (r *ContentRiskReport) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(r.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "chapter_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_chapter_created"),
+		},
+		{
+			Keys:    bson.D{{Key: "chapter_id", Value: 1}, {Key: "fingerprint", Value: 1}},
+			Options: options.Index().SetName("idx_chapter_fingerprint"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// IsAcknowledged 报告是否已被编辑确认
+func (r *ContentRiskReport) IsAcknowledged() bool {
+	return r.AcknowledgedAt != nil
+}
+
+// Blocking 报告是否阻断发布（存在风险且尚未确认）
+func (r *ContentRiskReport) Blocking() bool {
+	return r.RequiresAck && !r.IsAcknowledged()
+}
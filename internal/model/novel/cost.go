@@ -0,0 +1,101 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CostRecord 费用记录实体
+// 说明：每次调用付费 provider（LLM/TTS/图片/视频）记录一条，用于按小说统计实时花费
+type CostRecord struct {
+	ID        string    `bson:"id" json:"id"`                                     // 记录ID（UUID）
+	NovelID   string    `bson:"novel_id" json:"novel_id"`                         // 关联的小说ID
+	ChapterID string    `bson:"chapter_id,omitempty" json:"chapter_id,omitempty"` // 关联的章节ID（小说级任务为空）
+	Provider  string    `bson:"provider" json:"provider"`                         // provider：llm, tts, image, video
+	Units     float64   `bson:"units" json:"units"`                               // 计量数量（字数/张数/秒数）
+	AmountFen int64     `bson:"amount_fen" json:"amount_fen"`                     // 费用（分）
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// Collection 返回集合名称
+func (r *CostRecord) Collection() string {
+	return "cost_records"
+}
+
+// EnsureIndexes 创建和维护索引
+func (r *CostRecord) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(r.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "novel_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_novel_created"),
+		},
+		{
+			Keys:    bson.D{{Key: "novel_id", Value: 1}, {Key: "provider", Value: 1}},
+			Options: options.Index().SetName("idx_novel_provider"),
+		},
+		{
+			// 分析数据按（created_at, id）增量导出
+			Keys:    bson.D{{Key: "created_at", Value: 1}, {Key: "id", Value: 1}},
+			Options: options.Index().SetName("idx_created_id"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// BudgetEventType 预算事件类型
+type BudgetEventType string
+
+const (
+	BudgetEventWarning  BudgetEventType = "budget.warning"  // 花费接近上限（软预警）
+	BudgetEventExceeded BudgetEventType = "budget.exceeded" // 花费达到或超出上限
+)
+
+// WebhookStatus 通知发送状态
+type WebhookStatus string
+
+const (
+	WebhookStatusSkipped WebhookStatus = "skipped" // 未配置通知地址
+	WebhookStatusPending WebhookStatus = "pending" // 发送中（含等待重试）
+	WebhookStatusSent    WebhookStatus = "sent"    // 已发送
+	WebhookStatusFailed  WebhookStatus = "failed"  // 发送失败
+)
+
+// BudgetEvent 预算事件实体
+// 说明：花费首次达到预警线/上限时记录，并通过 webhook 通知
+type BudgetEvent struct {
+	ID            string          `bson:"id" json:"id"`                                           // 事件ID（UUID）
+	NovelID       string          `bson:"novel_id" json:"novel_id"`                               // 关联的小说ID
+	Type          BudgetEventType `bson:"type" json:"type"`                                       // 事件类型
+	CapFen        int64           `bson:"cap_fen" json:"cap_fen"`                                 // 触发时的费用上限（分）
+	SpentFen      int64           `bson:"spent_fen" json:"spent_fen"`                             // 触发时的已花费（分）
+	HardStop      bool            `bson:"hard_stop" json:"hard_stop"`                             // 触发时是否开启了超限停止
+	WebhookStatus WebhookStatus   `bson:"webhook_status" json:"webhook_status"`                   // 通知发送状态
+	WebhookError  string          `bson:"webhook_error,omitempty" json:"webhook_error,omitempty"` // 通知失败原因
+	DeliveryID    string          `bson:"delivery_id,omitempty" json:"delivery_id,omitempty"`     // 通知投递ID（见 WebhookDelivery）
+	CreatedAt     time.Time       `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time       `bson:"updated_at" json:"updated_at"`
+}
+
+// Collection 返回集合名称
+func (e *BudgetEvent) Collection() string {
+	return "budget_events"
+}
+
+// EnsureIndexes 创建和维护索引
+func (e *BudgetEvent) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(e.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "novel_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_novel_created"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
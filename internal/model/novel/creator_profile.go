@@ -0,0 +1,77 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CreatorProfile 用户的创作默认配置
+// 说明：新用户开通（onboarding）时按预设生成，用户之后创建的小说会复制这些默认值，
+// 第一章不需要手动配置就能完成渲染；每个用户只有一份
+type CreatorProfile struct {
+	ID            string            `bson:"id" json:"id"`                                         // 配置ID（UUID）
+	UserID        string            `bson:"user_id" json:"user_id"`                               // 所属用户ID（唯一）
+	Preset        string            `bson:"preset" json:"preset"`                                 // 生成配置时使用的预设名称
+	NarrationType NarrationType     `bson:"narration_type" json:"narration_type"`                 // 默认旁白类型
+	Style         NovelStyle        `bson:"style" json:"style"`                                   // 默认剧本风格
+	NumberStyle   NumberStyle       `bson:"number_style,omitempty" json:"number_style,omitempty"` // 默认数字写法
+	Continuity    ChapterContinuity `bson:"continuity,omitempty" json:"continuity,omitempty"`     // 默认章节衔接方式
+	Metadata      *CreativeMetadata `bson:"metadata,omitempty" json:"metadata,omitempty"`         // 默认创作设定（提示词变量）
+	Render        *RenderSettings   `bson:"render,omitempty" json:"render,omitempty"`             // 默认渲染设置（视频、配音、字幕、片头片尾）
+	CreatedAt     time.Time         `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time         `bson:"updated_at" json:"updated_at"`
+}
+
+// RenderSettings 小说的渲染设置
+// 字段为空时使用系统默认值（通用平台、TTS 默认音色和 1.2 倍速、默认字幕样式、无片头片尾）
+type RenderSettings struct {
+	Platform  TargetPlatform `bson:"platform,omitempty" json:"platform,omitempty"`     // 默认目标发布平台（生成视频时未指定平台则使用）
+	Voice     *VoiceSettings `bson:"voice,omitempty" json:"voice,omitempty"`           // 配音设置
+	Subtitle  *SubtitleStyle `bson:"subtitle,omitempty" json:"subtitle,omitempty"`     // 字幕样式
+	IntroText string         `bson:"intro_text,omitempty" json:"intro_text,omitempty"` // 片头文案（显示在第一段字幕开头，支持 {{title}} 等提示词变量）
+	OutroText string         `bson:"outro_text,omitempty" json:"outro_text,omitempty"` // 片尾文案（显示在最后一段字幕结尾，支持提示词变量）
+}
+
+// VoiceSettings 配音设置
+type VoiceSettings struct {
+	VoiceType  string  `bson:"voice_type,omitempty" json:"voice_type,omitempty"`   // TTS 音色（如 BV115_streaming），为空时使用 TTS 默认音色
+	SpeedRatio float64 `bson:"speed_ratio,omitempty" json:"speed_ratio,omitempty"` // 语速（0.5~2.0），为 0 时使用 1.2 倍速
+}
+
+// SubtitleStyle 字幕样式（对应 ASS 字幕的 Default/Highlight 样式）
+type SubtitleStyle struct {
+	FontName       string `bson:"font_name,omitempty" json:"font_name,omitempty"`             // 字体
+	FontSize       int    `bson:"font_size,omitempty" json:"font_size,omitempty"`             // 字号
+	PrimaryColor   string `bson:"primary_color,omitempty" json:"primary_color,omitempty"`     // 文字颜色（#RRGGBB）
+	HighlightColor string `bson:"highlight_color,omitempty" json:"highlight_color,omitempty"` // 关键词高亮颜色（#RRGGBB）
+	OutlineColor   string `bson:"outline_color,omitempty" json:"outline_color,omitempty"`     // 描边颜色（#RRGGBB）
+	Outline        int    `bson:"outline,omitempty" json:"outline,omitempty"`                 // 描边宽度
+	MarginV        int    `bson:"margin_v,omitempty" json:"margin_v,omitempty"`               // 距底部的垂直边距
+	Alignment      int    `bson:"alignment,omitempty" json:"alignment,omitempty"`             // 对齐方式（ASS 小键盘布局 1-9：1-3 底部，4-6 居中，7-9 顶部；默认 2 底部居中）
+}
+
+// Collection 返回集合名称
+func (p *CreatorProfile) Collection() string {
+	return "creator_profiles"
+}
+
+// EnsureIndexes 创建和维护索引
+func (p *CreatorProfile) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(p.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_user_id_unique"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -35,6 +35,7 @@ type VideoType string
 const (
 	VideoTypeNarration VideoType = "narration_video" // 解说视频
 	VideoTypeFinal     VideoType = "final_video"     // 最终完整视频
+	VideoTypeNovel     VideoType = "novel_video"     // 多个章节最终视频拼接的长视频（整部小说或一段章节）
 )
 
 // String 返回类型的字符串表示
@@ -42,6 +43,225 @@ func (t VideoType) String() string {
 	return string(t)
 }
 
+// ExportTier 最终视频导出档位
+type ExportTier string
+
+const (
+	ExportTierPreview  ExportTier = "preview"  // 预览版：带水印，分辨率按生成参数（默认 720p）
+	ExportTierLicensed ExportTier = "licensed" // 授权版：无水印，全分辨率（需章节已审核通过）
+)
+
+// String 返回档位的字符串表示
+func (t ExportTier) String() string {
+	return string(t)
+}
+
+// IsValid 判断导出档位是否合法
+func (t ExportTier) IsValid() bool {
+	return t == ExportTierPreview || t == ExportTierLicensed
+}
+
+// RelightVariant 场景图片重新打光的变体类型
+type RelightVariant string
+
+const (
+	RelightVariantNight RelightVariant = "night" // 夜晚
+	RelightVariantDusk  RelightVariant = "dusk"  // 黄昏
+	RelightVariantRain  RelightVariant = "rain"  // 雨天
+)
+
+// AllRelightVariants 所有支持的重新打光变体
+var AllRelightVariants = []RelightVariant{RelightVariantNight, RelightVariantDusk, RelightVariantRain}
+
+// String 返回变体的字符串表示
+func (v RelightVariant) String() string {
+	return string(v)
+}
+
+// IsValid 判断重新打光变体是否合法
+func (v RelightVariant) IsValid() bool {
+	for _, variant := range AllRelightVariants {
+		if v == variant {
+			return true
+		}
+	}
+	return false
+}
+
+// TargetPlatform 视频的目标发布平台（决定字幕和叠加层需要避开的平台 UI 区域，以及最终视频的响度和格式规范）
+type TargetPlatform string
+
+const (
+	TargetPlatformDefault  TargetPlatform = "default"  // 通用：不考虑平台 UI 遮挡
+	TargetPlatformDouyin   TargetPlatform = "douyin"   // 抖音
+	TargetPlatformTikTok   TargetPlatform = "tiktok"   // TikTok
+	TargetPlatformKuaishou TargetPlatform = "kuaishou" // 快手
+	TargetPlatformYouTube  TargetPlatform = "youtube"  // YouTube Shorts
+	TargetPlatformBilibili TargetPlatform = "bilibili" // 哔哩哔哩（竖屏）
+)
+
+// AllTargetPlatforms 所有支持的目标平台
+var AllTargetPlatforms = []TargetPlatform{TargetPlatformDefault, TargetPlatformDouyin, TargetPlatformTikTok, TargetPlatformKuaishou, TargetPlatformYouTube, TargetPlatformBilibili}
+
+// String 返回平台的字符串表示
+func (p TargetPlatform) String() string {
+	return string(p)
+}
+
+// IsValid 判断目标平台是否合法
+func (p TargetPlatform) IsValid() bool {
+	for _, platform := range AllTargetPlatforms {
+		if p == platform {
+			return true
+		}
+	}
+	return false
+}
+
+// ImageStylePreset 图片风格预设（决定图片 prompt 前缀的画面风格描述）
+type ImageStylePreset string
+
+const (
+	ImageStylePresetGuofengComic ImageStylePreset = "guofeng_comic" // 国风漫画（默认，与章节图片生成一致）
+	ImageStylePresetAnime        ImageStylePreset = "anime"         // 日系动漫
+	ImageStylePresetRealistic    ImageStylePreset = "realistic"     // 写实电影感
+	ImageStylePresetInkWash      ImageStylePreset = "ink_wash"      // 水墨
+	ImageStylePresetNone         ImageStylePreset = "none"          // 不附加风格描述，直接使用原始 prompt
+)
+
+// AllImageStylePresets 所有支持的图片风格预设
+var AllImageStylePresets = []ImageStylePreset{
+	ImageStylePresetGuofengComic,
+	ImageStylePresetAnime,
+	ImageStylePresetRealistic,
+	ImageStylePresetInkWash,
+	ImageStylePresetNone,
+}
+
+// String 返回风格预设的字符串表示
+func (p ImageStylePreset) String() string {
+	return string(p)
+}
+
+// IsValid 判断风格预设是否合法
+func (p ImageStylePreset) IsValid() bool {
+	for _, preset := range AllImageStylePresets {
+		if p == preset {
+			return true
+		}
+	}
+	return false
+}
+
+// NumberStyle 解说文本中数字的写法（TTS 和字幕生成前统一转换）
+type NumberStyle string
+
+const (
+	NumberStyleNone    NumberStyle = "none"    // 不转换（默认）
+	NumberStyleChinese NumberStyle = "chinese" // 转为中文数字（3万 → 三万），适用于中文 TTS
+	NumberStyleArabic  NumberStyle = "arabic"  // 转为阿拉伯数字（三万 → 3万），适用于非中文朗读或字幕
+)
+
+// AllNumberStyles 所有支持的数字写法
+var AllNumberStyles = []NumberStyle{NumberStyleNone, NumberStyleChinese, NumberStyleArabic}
+
+// String 返回数字写法的字符串表示
+func (s NumberStyle) String() string {
+	return string(s)
+}
+
+// IsValid 判断数字写法是否合法
+func (s NumberStyle) IsValid() bool {
+	for _, style := range AllNumberStyles {
+		if s == style {
+			return true
+		}
+	}
+	return false
+}
+
+// PromotionStrategy 批量发布版本的选择策略
+type PromotionStrategy string
+
+const (
+	PromotionStrategyLatestApproved PromotionStrategy = "latest_approved" // 每个已审核章节取审核时已完成的最新版本
+	PromotionStrategyExplicit       PromotionStrategy = "explicit"        // 按请求中指定的章节版本发布
+)
+
+// AllPromotionStrategies 所有支持的发布策略
+var AllPromotionStrategies = []PromotionStrategy{PromotionStrategyLatestApproved, PromotionStrategyExplicit}
+
+// String 返回发布策略的字符串表示
+func (s PromotionStrategy) String() string {
+	return string(s)
+}
+
+// IsValid 判断发布策略是否合法
+func (s PromotionStrategy) IsValid() bool {
+	for _, strategy := range AllPromotionStrategies {
+		if s == strategy {
+			return true
+		}
+	}
+	return false
+}
+
+// ChapterContinuity 章节衔接方式（第 N 章开头衔接第 N-1 章最终视频的最后一帧）
+type ChapterContinuity string
+
+const (
+	ChapterContinuityNone           ChapterContinuity = "none"            // 不衔接（默认）
+	ChapterContinuityReferenceFrame ChapterContinuity = "reference_frame" // 作为第一个镜头生成图片时的参考图
+	ChapterContinuityRecapOverlay   ChapterContinuity = "recap_overlay"   // 合成最终视频时在开头叠加并淡出（前情回顾过渡）
+)
+
+// AllChapterContinuities 所有支持的章节衔接方式
+var AllChapterContinuities = []ChapterContinuity{ChapterContinuityNone, ChapterContinuityReferenceFrame, ChapterContinuityRecapOverlay}
+
+// String 返回章节衔接方式的字符串表示
+func (c ChapterContinuity) String() string {
+	return string(c)
+}
+
+// IsValid 判断章节衔接方式是否合法
+func (c ChapterContinuity) IsValid() bool {
+	for _, continuity := range AllChapterContinuities {
+		if c == continuity {
+			return true
+		}
+	}
+	return false
+}
+
+// PrewarmStatus 素材预热任务状态
+type PrewarmStatus string
+
+const (
+	PrewarmStatusScheduled PrewarmStatus = "scheduled" // 等待开始
+	PrewarmStatusRunning   PrewarmStatus = "running"   // 预热中
+	PrewarmStatusCompleted PrewarmStatus = "completed" // 已完成（个别资源失败时见 stats.failed）
+	PrewarmStatusFailed    PrewarmStatus = "failed"    // 失败
+)
+
+// String 返回状态的字符串表示
+func (s PrewarmStatus) String() string {
+	return string(s)
+}
+
+// ShotClipPreviewStatus 单镜头片段预览状态
+type ShotClipPreviewStatus string
+
+const (
+	ShotClipPreviewStatusPending   ShotClipPreviewStatus = "pending"   // 等待编辑确认
+	ShotClipPreviewStatusConfirmed ShotClipPreviewStatus = "confirmed" // 已确认（片段已写入新的视频版本）
+	ShotClipPreviewStatusDiscarded ShotClipPreviewStatus = "discarded" // 已放弃（预览文件已删除）
+)
+
+// String 返回状态的字符串表示
+func (s ShotClipPreviewStatus) String() string {
+	return string(s)
+}
+
 // SubtitleFormat 字幕格式
 type SubtitleFormat string
 
@@ -55,3 +275,215 @@ const (
 func (f SubtitleFormat) String() string {
 	return string(f)
 }
+
+// CharacterVoiceSource 角色配音音色的来源
+type CharacterVoiceSource string
+
+const (
+	CharacterVoiceSourceSuggestion CharacterVoiceSource = "suggestion" // 接受选角建议
+	CharacterVoiceSourceManual     CharacterVoiceSource = "manual"     // 手动指定
+)
+
+// SubtitleSource 字幕来源
+type SubtitleSource string
+
+const (
+	SubtitleSourceGenerated SubtitleSource = ""         // 根据音频时间戳生成
+	SubtitleSourceImported  SubtitleSource = "imported" // 导入的外部字幕文件（SRT/ASS）
+)
+
+// ClipMergeMode 镜头视频合并规则（合成章节视频前把相邻的镜头片段合并为一个视频）
+type ClipMergeMode string
+
+const (
+	ClipMergeNone  ClipMergeMode = "none"  // 不合并，每个镜头一个视频（默认）
+	ClipMergeShort ClipMergeMode = "short" // 合并时长不足阈值的相邻镜头
+	ClipMergeScene ClipMergeMode = "scene" // 同一场景的相邻镜头合并为一个视频
+)
+
+// AllClipMergeModes 所有支持的镜头视频合并规则
+var AllClipMergeModes = []ClipMergeMode{ClipMergeNone, ClipMergeShort, ClipMergeScene}
+
+// String 返回合并规则的字符串表示
+func (m ClipMergeMode) String() string {
+	return string(m)
+}
+
+// IsValid 判断合并规则是否合法
+func (m ClipMergeMode) IsValid() bool {
+	for _, mode := range AllClipMergeModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// NovelPermission 单本小说的协作权限（小说所有者授予其他用户）
+type NovelPermission string
+
+const (
+	NovelPermissionRead NovelPermission = "read" // 只读：查看小说及其章节、解说、素材
+	NovelPermissionEdit NovelPermission = "edit" // 编辑：在只读基础上可以生成、修改内容
+)
+
+// AllNovelPermissions 所有支持的协作权限
+var AllNovelPermissions = []NovelPermission{NovelPermissionRead, NovelPermissionEdit}
+
+// String 返回协作权限的字符串表示
+func (p NovelPermission) String() string {
+	return string(p)
+}
+
+// IsValid 判断协作权限是否合法
+func (p NovelPermission) IsValid() bool {
+	for _, permission := range AllNovelPermissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Includes 判断该权限是否包含 required（编辑权限包含只读权限）
+func (p NovelPermission) Includes(required NovelPermission) bool {
+	return p == required || (p == NovelPermissionEdit && required == NovelPermissionRead)
+}
+
+// PipelineStage 章节渲染流程的阶段
+type PipelineStage string
+
+const (
+	PipelineStageNarration      PipelineStage = "narration"       // 解说生成
+	PipelineStageAudio          PipelineStage = "audio"           // 音频合成
+	PipelineStageSubtitle       PipelineStage = "subtitle"        // 字幕生成
+	PipelineStageImage          PipelineStage = "image"           // 图片生成
+	PipelineStageNarrationVideo PipelineStage = "narration_video" // 解说视频生成
+	PipelineStageFinalVideo     PipelineStage = "final_video"     // 最终视频拼接
+)
+
+// AllPipelineStages 章节渲染流程的所有阶段（按执行顺序）
+var AllPipelineStages = []PipelineStage{
+	PipelineStageNarration,
+	PipelineStageAudio,
+	PipelineStageSubtitle,
+	PipelineStageImage,
+	PipelineStageNarrationVideo,
+	PipelineStageFinalVideo,
+}
+
+// JobTypeNovelVideo 长视频拼接任务的类型（小说级任务，不属于章节渲染流程，不在 AllPipelineStages 中）
+const JobTypeNovelVideo PipelineStage = "novel_video"
+
+// String 返回阶段的字符串表示
+func (s PipelineStage) String() string {
+	return string(s)
+}
+
+// SceneMood 场景情绪（生成剧本时由 LLM 为每个场景标注，合成最终视频时据此选择背景音乐）
+type SceneMood string
+
+const (
+	SceneMoodCalm     SceneMood = "calm"     // 平静（默认）
+	SceneMoodTense    SceneMood = "tense"    // 紧张
+	SceneMoodRomantic SceneMood = "romantic" // 浪漫
+	SceneMoodBattle   SceneMood = "battle"   // 战斗
+	SceneMoodSad      SceneMood = "sad"      // 悲伤
+	SceneMoodJoyful   SceneMood = "joyful"   // 欢快
+	SceneMoodMystery  SceneMood = "mystery"  // 悬疑
+)
+
+// AllSceneMoods 所有支持的场景情绪
+var AllSceneMoods = []SceneMood{SceneMoodCalm, SceneMoodTense, SceneMoodRomantic, SceneMoodBattle, SceneMoodSad, SceneMoodJoyful, SceneMoodMystery}
+
+// String 返回场景情绪的字符串表示
+func (m SceneMood) String() string {
+	return string(m)
+}
+
+// IsValid 判断场景情绪是否合法
+func (m SceneMood) IsValid() bool {
+	for _, mood := range AllSceneMoods {
+		if m == mood {
+			return true
+		}
+	}
+	return false
+}
+
+// ChapterBranchStatus 章节分支状态
+type ChapterBranchStatus string
+
+const (
+	ChapterBranchStatusOpen      ChapterBranchStatus = "open"      // 进行中（可以独立运行流水线）
+	ChapterBranchStatusMerged    ChapterBranchStatus = "merged"    // 已合并回主章节
+	ChapterBranchStatusDiscarded ChapterBranchStatus = "discarded" // 已放弃
+)
+
+// String 返回分支状态的字符串表示
+func (s ChapterBranchStatus) String() string {
+	return string(s)
+}
+
+// StandbyStage 预生成阶段（只包含不调用付费图片/视频生成的低成本阶段）
+type StandbyStage string
+
+const (
+	StandbyStageSummary   StandbyStage = "summary"   // 章节内容摘要
+	StandbyStageNarration StandbyStage = "narration" // 解说草稿（包含每个镜头的图片提示词）
+)
+
+// AllStandbyStages 所有支持的预生成阶段（按执行顺序）
+var AllStandbyStages = []StandbyStage{StandbyStageSummary, StandbyStageNarration}
+
+// String 返回预生成阶段的字符串表示
+func (s StandbyStage) String() string {
+	return string(s)
+}
+
+// IsValid 判断预生成阶段是否合法
+func (s StandbyStage) IsValid() bool {
+	for _, stage := range AllStandbyStages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// StandbyStatus 预生成任务（及其阶段）的状态
+type StandbyStatus string
+
+const (
+	StandbyStatusRunning   StandbyStatus = "running"   // 执行中
+	StandbyStatusCompleted StandbyStatus = "completed" // 已完成
+	StandbyStatusSkipped   StandbyStatus = "skipped"   // 已跳过（仅阶段：产物已存在）
+	StandbyStatusFailed    StandbyStatus = "failed"    // 失败
+	StandbyStatusCanceled  StandbyStatus = "canceled"  // 已取消
+)
+
+// String 返回状态的字符串表示
+func (s StandbyStatus) String() string {
+	return string(s)
+}
+
+// JobStatus 异步任务队列中任务的状态
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued"    // 排队中（包括等待重试）
+	JobStatusRunning   JobStatus = "running"   // 执行中
+	JobStatusSucceeded JobStatus = "succeeded" // 已成功
+	JobStatusFailed    JobStatus = "failed"    // 失败（重试次数已用完）
+	JobStatusCanceled  JobStatus = "canceled"  // 已取消
+)
+
+// String 返回状态的字符串表示
+func (s JobStatus) String() string {
+	return string(s)
+}
+
+// IsFinished 任务是否已结束（成功、失败或取消）
+func (s JobStatus) IsFinished() bool {
+	return s == JobStatusSucceeded || s == JobStatusFailed || s == JobStatusCanceled
+}
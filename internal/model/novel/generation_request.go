@@ -0,0 +1,88 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GenerationRequestStatus 生成请求的状态
+type GenerationRequestStatus string
+
+const (
+	GenerationRequestStatusProcessing GenerationRequestStatus = "processing" // 生成中
+	GenerationRequestStatusCompleted  GenerationRequestStatus = "completed"  // 已完成
+	GenerationRequestStatusFailed     GenerationRequestStatus = "failed"     // 失败（相同请求可以重新生成）
+)
+
+// GenerationRequest 生成请求记录（用于幂等）
+// 说明：同一用户的同一幂等键（Idempotency-Key）或相同指纹（章节 + 解说版本 + 参数哈希）的重复请求返回已有的生成版本，不重复生成；
+// 记录在 ExpiresAt 之后自动删除，之后相同的幂等键视为新请求
+type GenerationRequest struct {
+	ID               string                  `bson:"id" json:"id"`                                               // 请求ID（UUID）
+	IdempotencyKey   string                  `bson:"idempotency_key,omitempty" json:"idempotency_key,omitempty"` // 调用方提供的幂等键（按用户区分；未提供时为空，按指纹去重）
+	Stage            PipelineStage           `bson:"stage" json:"stage"`                                         // 生成阶段（目前为 narration_video）
+	NovelID          string                  `bson:"novel_id" json:"novel_id"`                                   // 关联的小说ID
+	ChapterID        string                  `bson:"chapter_id" json:"chapter_id"`                               // 关联的章节ID
+	NarrationID      string                  `bson:"narration_id" json:"narration_id"`                           // 使用的解说ID
+	NarrationVersion string                  `bson:"narration_version" json:"narration_version"`                 // 使用的解说版本（如 2.1）
+	Fingerprint      string                  `bson:"fingerprint" json:"fingerprint"`                             // 请求指纹（章节 + 解说版本 + 参数的哈希）
+	Status           GenerationRequestStatus `bson:"status" json:"status"`                                       // 状态：processing, completed, failed
+	Version          int                     `bson:"version" json:"version"`                                     // 生成的产物版本号（开始生成前分配）
+	ResourceIDs      []string                `bson:"resource_ids,omitempty" json:"resource_ids,omitempty"`       // 生成的产物ID
+	ErrorMessage     string                  `bson:"error_message,omitempty" json:"error_message,omitempty"`     // 失败原因
+	UserID           string                  `bson:"user_id,omitempty" json:"user_id,omitempty"`                 // 提交人用户ID
+
+	CompletedAt *time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	ExpiresAt   time.Time  `bson:"expires_at" json:"expires_at"` // 记录过期时间（TTL 索引自动删除）
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+// Collection 返回集合名称
+func (r *GenerationRequest) Collection() string {
+	return "generation_requests"
+}
+
+// EnsureIndexes 创建和维护索引
+func (r *GenerationRequest) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(r.Collection())
+	// 幂等键按用户区分，旧的全局幂等键唯一索引改为 (user_id, idempotency_key)；
+	// 没有幂等键的请求只合并生成中的请求（由 idx_fingerprint_processing_unique 覆盖），不再按指纹查询已完成的请求
+	for _, name := range []string{"idx_idempotency_key_unique", "idx_fingerprint_created"} {
+		if _, err := coll.Indexes().DropOne(ctx, name); err != nil && !isIndexNotFound(err) {
+			return err
+		}
+	}
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			// 同一用户的同一幂等键只能对应一个请求
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "idempotency_key", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"idempotency_key": bson.M{"$exists": true}}).
+				SetName("idx_user_idempotency_key_unique"),
+		},
+		{
+			// 相同指纹同时只能有一个生成中的请求
+			Keys: bson.D{{Key: "fingerprint", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": GenerationRequestStatusProcessing}).
+				SetName("idx_fingerprint_processing_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetName("idx_expires_at").SetExpireAfterSeconds(0),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -0,0 +1,62 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GenerationSettings 生成参数
+// 按 系统默认 → 用户设置 → 小说设置 → 请求覆盖 逐层合并，字段为零值表示沿用上一层
+type GenerationSettings struct {
+	VideoWidth     int           `bson:"video_width,omitempty" json:"video_width,omitempty"`           // 视频宽度（像素）
+	VideoHeight    int           `bson:"video_height,omitempty" json:"video_height,omitempty"`         // 视频高度（像素）
+	VideoFPS       int           `bson:"video_fps,omitempty" json:"video_fps,omitempty"`               // 视频帧率
+	VoiceType      string        `bson:"voice_type,omitempty" json:"voice_type,omitempty"`             // TTS 音色
+	SpeedRatio     float64       `bson:"speed_ratio,omitempty" json:"speed_ratio,omitempty"`           // TTS 语速（0.5~2.0）
+	ImageSteps     int           `bson:"image_steps,omitempty" json:"image_steps,omitempty"`           // 图片采样步数（支持的图片提供者生效）
+	SegmentGap     float64       `bson:"segment_gap,omitempty" json:"segment_gap,omitempty"`           // 解说片段之间的停顿（秒）
+	SceneGap       float64       `bson:"scene_gap,omitempty" json:"scene_gap,omitempty"`               // 场景切换处的停顿（秒，代替片段停顿）
+	ClipMerge      ClipMergeMode `bson:"clip_merge,omitempty" json:"clip_merge,omitempty"`             // 镜头视频合并规则（none/short/scene）
+	ClipMergeUnder float64       `bson:"clip_merge_under,omitempty" json:"clip_merge_under,omitempty"` // short 规则的时长阈值（秒）：不足该时长的相邻镜头合并
+}
+
+// IsZero 是否没有设置任何参数
+func (s *GenerationSettings) IsZero() bool {
+	return s == nil || *s == GenerationSettings{}
+}
+
+// UserSettings 用户设置实体
+// 说明：每个用户一份，Generation 为用户级生成参数（作用于该用户所有小说，小说设置可以覆盖）
+type UserSettings struct {
+	ID         string              `bson:"id" json:"id"`                                     // 设置ID（UUID）
+	UserID     string              `bson:"user_id" json:"user_id"`                           // 所属用户ID（唯一）
+	Generation *GenerationSettings `bson:"generation,omitempty" json:"generation,omitempty"` // 用户级生成参数
+	CreatedAt  time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+// Collection 返回集合名称
+func (s *UserSettings) Collection() string {
+	return "user_settings"
+}
+
+// EnsureIndexes 创建和维护索引
+func (s *UserSettings) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(s.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_user_id_unique"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
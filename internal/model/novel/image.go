@@ -20,11 +20,15 @@ type Image struct {
 
 	SceneNumber string `bson:"scene_number" json:"scene_number"` // 场景编号（字符串，如 "1"）
 	ShotNumber  string `bson:"shot_number" json:"shot_number"`   // 镜头编号（字符串，如 "1"）
+	ShotID      string `bson:"shot_id,omitempty" json:"shot_id,omitempty"` // 关联的镜头ID（稳定标识，镜头重排后仍通过它关联）
 
 	ImageResourceID string `bson:"image_resource_id" json:"image_resource_id"` // 图片文件的 resource_id
 	CharacterName   string `bson:"character_name" json:"character_name"`       // 角色名称（镜头中的主要角色）
 
-	Prompt string `bson:"prompt,omitempty" json:"prompt,omitempty"` // 生成图片时使用的完整 prompt
+	Prompt            string   `bson:"prompt,omitempty" json:"prompt,omitempty"`                           // 生成图片时使用的完整 prompt
+	LocalizedPrompt   string   `bson:"localized_prompt,omitempty" json:"localized_prompt,omitempty"`       // 实际提交给图片生成提供者的本地化 prompt（翻译并追加风格关键词；提供者偏好中文时为空）
+	StyleReferenceIDs []string `bson:"style_reference_ids,omitempty" json:"style_reference_ids,omitempty"` // 生成图片时使用的风格参考图ID
+	ContinuityVideoID string   `bson:"continuity_video_id,omitempty" json:"continuity_video_id,omitempty"` // 作为参考图的上一章最终视频ID（章节衔接，仅第一个镜头）
 
 	Version  int    `bson:"version" json:"version"`   // 版本号（用于支持多版本，默认 1）
 	Status   TaskStatus `bson:"status" json:"status"`     // 状态：pending, completed, failed
@@ -54,6 +58,10 @@ func (i *Image) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
 			Keys:    bson.D{{Key: "narration_id", Value: 1}},
 			Options: options.Index().SetName("idx_narration_id"),
 		},
+		{
+			Keys:    bson.D{{Key: "shot_id", Value: 1}},
+			Options: options.Index().SetName("idx_shot_id"),
+		},
 		{
 			Keys:    bson.D{{Key: "chapter_id", Value: 1}, {Key: "scene_number", Value: 1}, {Key: "shot_number", Value: 1}},
 			Options: options.Index().SetUnique(true).SetName("idx_chapter_scene_shot_unique"),
@@ -0,0 +1,93 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Job 异步任务队列中的生成任务
+// 说明：生成任务持久化到任务队列，由工作协程池领取执行；执行中的任务定期续租，进程重启后租约过期的任务被其他工作协程重新领取，不会丢失
+type Job struct {
+	ID          string        `bson:"id" json:"id"`                                         // 任务ID（UUID）
+	Type        PipelineStage `bson:"type" json:"type"`                                     // 任务类型：narration, audio, subtitle, image, narration_video, final_video, novel_video
+	NovelID     string        `bson:"novel_id" json:"novel_id"`                             // 关联的小说ID
+	ChapterID   string        `bson:"chapter_id" json:"chapter_id"`                         // 关联的章节ID（长视频拼接任务为空）
+	NarrationID string        `bson:"narration_id,omitempty" json:"narration_id,omitempty"` // 关联的解说ID（音频、字幕、图片任务；为空时使用章节当前的解说）
+	TriggeredBy string        `bson:"triggered_by,omitempty" json:"triggered_by,omitempty"` // 提交人用户ID
+
+	PipelineRunID string `bson:"pipeline_run_id,omitempty" json:"pipeline_run_id,omitempty"` // 所属的章节一键生成流程ID（成功后提交流程的下一步）
+
+	NovelVideo *NovelVideoJobParams `bson:"novel_video,omitempty" json:"novel_video,omitempty"` // 长视频拼接参数（仅 novel_video 任务）
+
+	Status          JobStatus `bson:"status" json:"status"`                                   // 状态：queued, running, succeeded, failed, canceled
+	Attempts        int       `bson:"attempts" json:"attempts"`                               // 已执行次数
+	MaxAttempts     int       `bson:"max_attempts" json:"max_attempts"`                       // 最多执行次数（含第一次）
+	CancelRequested bool      `bson:"cancel_requested" json:"cancel_requested"`               // 是否已请求取消（执行中的任务由执行它的工作协程中断）
+	ResourceIDs     []string  `bson:"resource_ids,omitempty" json:"resource_ids,omitempty"`   // 生成的产物ID（解说、音频、字幕、图片、视频）
+	ErrorMessage    string    `bson:"error_message,omitempty" json:"error_message,omitempty"` // 最近一次失败的原因
+	ErrorClass      string    `bson:"error_class,omitempty" json:"error_class,omitempty"`     // 最近一次失败的分类（见 errclass）
+
+	RequeueRequested bool `bson:"requeue_requested" json:"requeue_requested"` // 是否已请求迁移（节点隔离排空时，执行中的任务中断后放回队列，不计入执行次数）
+
+	NodeID     string     `bson:"node_id,omitempty" json:"node_id,omitempty"`         // 执行任务的工作节点
+	WorkerID   string     `bson:"worker_id,omitempty" json:"worker_id,omitempty"`     // 执行任务的工作协程
+	LeaseUntil *time.Time `bson:"lease_until,omitempty" json:"lease_until,omitempty"` // 执行租约到期时间（到期未续租视为执行中断）
+	RunAfter   time.Time  `bson:"run_after" json:"run_after"`                         // 最早执行时间（失败重试时按退避时间推迟）
+
+	StartedAt   *time.Time `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	CompletedAt *time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+// NovelVideoJobParams 长视频拼接任务的参数
+type NovelVideoJobParams struct {
+	VideoID        string `bson:"video_id" json:"video_id"`                                     // 拼接输出的长视频记录ID
+	ChapterFrom    int    `bson:"chapter_from,omitempty" json:"chapter_from,omitempty"`         // 起始章节序号（0 表示第一章）
+	ChapterTo      int    `bson:"chapter_to,omitempty" json:"chapter_to,omitempty"`             // 结束章节序号（0 表示最后一章）
+	SkipTitleCards bool   `bson:"skip_title_cards,omitempty" json:"skip_title_cards,omitempty"` // 不插入章节标题画面
+}
+
+// Collection 返回集合名称
+func (j *Job) Collection() string {
+	return "jobs"
+}
+
+// EnsureIndexes 创建和维护索引
+func (j *Job) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(j.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			// 工作协程按状态和最早执行时间领取任务
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "run_after", Value: 1}},
+			Options: options.Index().SetName("idx_status_run_after"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "lease_until", Value: 1}},
+			Options: options.Index().SetName("idx_status_lease"),
+		},
+		{
+			Keys:    bson.D{{Key: "chapter_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_chapter_created"),
+		},
+		{
+			Keys:    bson.D{{Key: "novel_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_novel_created"),
+		},
+		{
+			// 分析数据按（updated_at, id）增量导出
+			Keys:    bson.D{{Key: "updated_at", Value: 1}, {Key: "id", Value: 1}},
+			Options: options.Index().SetName("idx_updated_id"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
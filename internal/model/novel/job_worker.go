@@ -0,0 +1,48 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// JobWorker 任务队列的工作节点（一个服务进程）
+// 说明：节点启动工作协程池后定期上报心跳；节点被隔离（cordon）后不再领取新任务，用于维护前排空节点
+type JobWorker struct {
+	ID           string     `bson:"id" json:"id"`                                           // 节点ID（主机名-进程号）
+	Host         string     `bson:"host" json:"host"`                                       // 主机名
+	Concurrency  int        `bson:"concurrency" json:"concurrency"`                         // 工作协程数
+	Cordoned     bool       `bson:"cordoned" json:"cordoned"`                               // 是否已隔离（不再领取新任务）
+	CordonReason string     `bson:"cordon_reason,omitempty" json:"cordon_reason,omitempty"` // 隔离原因
+	CordonedAt   *time.Time `bson:"cordoned_at,omitempty" json:"cordoned_at,omitempty"`     // 隔离时间
+	StartedAt    time.Time  `bson:"started_at" json:"started_at"`                           // 节点启动时间
+	HeartbeatAt  time.Time  `bson:"heartbeat_at" json:"heartbeat_at"`                       // 最近一次心跳时间
+
+	RunningJobs int  `bson:"-" json:"running_jobs"` // 节点上执行中的任务数（查询时统计）
+	Alive       bool `bson:"-" json:"alive"`        // 心跳是否在有效期内（查询时计算）
+}
+
+// Collection 返回集合名称
+func (w *JobWorker) Collection() string {
+	return "job_workers"
+}
+
+// EnsureIndexes 创建和维护索引
+func (w *JobWorker) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(w.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "heartbeat_at", Value: -1}},
+			Options: options.Index().SetName("idx_heartbeat"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
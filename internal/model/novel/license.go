@@ -0,0 +1,51 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LicenseGrant 授权记录实体
+// 说明：每次导出授权版（无水印）最终视频都会记录一条授权，用于追踪成片的授权去向
+type LicenseGrant struct {
+	ID        string     `bson:"id" json:"id"`                 // 授权ID（UUID）
+	VideoID   string     `bson:"video_id" json:"video_id"`     // 关联的最终视频ID
+	ChapterID string     `bson:"chapter_id" json:"chapter_id"` // 关联的章节ID
+	NovelID   string     `bson:"novel_id" json:"novel_id"`     // 关联的小说ID
+	UserID    string     `bson:"user_id" json:"user_id"`       // 被授权的用户ID
+	Tier      ExportTier `bson:"tier" json:"tier"`             // 导出档位
+	Version   int        `bson:"version" json:"version"`       // 导出的视频版本号
+	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `bson:"updated_at" json:"updated_at"`
+	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
+
+// Collection 返回集合名称
+func (l *LicenseGrant) Collection() string {
+	return "license_grants"
+}
+
+// EnsureIndexes 创建和维护索引
+func (l *LicenseGrant) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(l.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "video_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_video_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "chapter_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_chapter_created"),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_user_created"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -14,17 +14,30 @@ import (
 // 注意：Characters 存储在 Character 表中，Scenes 存储在 Scene 表中，Shots 存储在 Shot 表中
 // 此表只存储解说的基本信息和元数据
 type Narration struct {
-	ID        string `bson:"id" json:"id"`                   // 解说ID（UUID）
-	ChapterID string `bson:"chapter_id" json:"chapter_id"`   // 关联的章节ID
-	NovelID   string `bson:"novel_id" json:"novel_id"`        // 关联的小说ID
-	UserID    string `bson:"user_id" json:"user_id"`         // 用户ID
-	Prompt       string     `bson:"prompt,omitempty" json:"prompt,omitempty"` // 生成解说时使用的提示词
-	Version      int        `bson:"version" json:"version"`                   // 版本号（用于支持多版本，默认 1）
-	Status       TaskStatus `bson:"status" json:"status"`                     // 状态：pending, completed, failed
-	ErrorMessage string     `bson:"error_message,omitempty" json:"error_message,omitempty"` // 错误信息（失败时）
-	CreatedAt    time.Time  `bson:"created_at" json:"created_at"`
-	UpdatedAt    time.Time  `bson:"updated_at" json:"updated_at"`
-	DeletedAt    *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	ID              string             `bson:"id" json:"id"`                                           // 解说ID（UUID）
+	ChapterID       string             `bson:"chapter_id" json:"chapter_id"`                           // 关联的章节ID
+	NovelID         string             `bson:"novel_id" json:"novel_id"`                               // 关联的小说ID
+	UserID          string             `bson:"user_id" json:"user_id"`                                 // 用户ID
+	Prompt          string             `bson:"prompt,omitempty" json:"prompt,omitempty"`               // 生成解说时使用的提示词
+	Version         int                `bson:"version" json:"version"`                                 // 版本号（用于支持多版本，默认 1）
+	MinorVersion    int                `bson:"minor_version" json:"minor_version"`                     // 小版本号（内联补丁修改的次数，0 表示未修改）
+	Status          TaskStatus         `bson:"status" json:"status"`                                   // 状态：pending, completed, failed
+	ErrorMessage    string             `bson:"error_message,omitempty" json:"error_message,omitempty"` // 错误信息（失败时）
+	CompletedScenes int                `bson:"completed_scenes" json:"completed_scenes"`               // 已生成并落库的场景数（流式生成时逐个递增）
+	Feedback        *NarrationFeedback `bson:"feedback,omitempty" json:"feedback,omitempty"`           // 按审核意见重新生成时记录的审核意见（其他方式生成的版本为空）
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time          `bson:"updated_at" json:"updated_at"`
+	DeletedAt       *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
+
+// NarrationFeedback 审核意见：记录促成该解说版本的审核意见及其作用范围
+type NarrationFeedback struct {
+	Comments        string    `bson:"comments" json:"comments"`                               // 审核意见（如「少一些煽情，第3场对白保持原文」）
+	SceneNumbers    []string  `bson:"scene_numbers,omitempty" json:"scene_numbers,omitempty"` // 按意见重写的场景编号（为空表示全部场景）
+	ReviewerID      string    `bson:"reviewer_id,omitempty" json:"reviewer_id,omitempty"`     // 审核人ID
+	BaseNarrationID string    `bson:"base_narration_id" json:"base_narration_id"`             // 基于哪个解说版本修改
+	BaseVersion     int       `bson:"base_version" json:"base_version"`                       // 基础版本号
+	CreatedAt       time.Time `bson:"created_at" json:"created_at"`
 }
 
 // Collection 返回集合名称
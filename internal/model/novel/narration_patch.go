@@ -0,0 +1,64 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NarrationPatch 解说内联补丁记录
+// 说明：按 JSON Patch 直接修改解说版本中的镜头旁白、提示词和时长（不生成新的大版本），每次修改小版本号加一并记录一条补丁
+type NarrationPatch struct {
+	ID           string                    `bson:"id" json:"id"`                               // 补丁ID（UUID）
+	NarrationID  string                    `bson:"narration_id" json:"narration_id"`           // 关联的解说ID
+	ChapterID    string                    `bson:"chapter_id" json:"chapter_id"`               // 关联的章节ID
+	NovelID      string                    `bson:"novel_id" json:"novel_id"`                   // 关联的小说ID
+	Version      int                       `bson:"version" json:"version"`                     // 解说版本号
+	MinorVersion int                       `bson:"minor_version" json:"minor_version"`         // 应用补丁后的小版本号
+	UserID       string                    `bson:"user_id,omitempty" json:"user_id,omitempty"` // 修改人ID
+	Operations   []NarrationPatchOperation `bson:"operations" json:"operations"`               // 提交的补丁操作
+	Changes      []NarrationPatchChange    `bson:"changes" json:"changes"`                     // 实际修改的字段（含修改前后的值）
+	CreatedAt    time.Time                 `bson:"created_at" json:"created_at"`
+}
+
+// NarrationPatchOperation 补丁操作（RFC 6902 子集：replace、test）
+type NarrationPatchOperation struct {
+	Op    string      `bson:"op" json:"op"`                           // 操作
+	Path  string      `bson:"path" json:"path"`                       // 字段路径（JSON Pointer）
+	Value interface{} `bson:"value,omitempty" json:"value,omitempty"` // 操作的值
+}
+
+// NarrationPatchChange 补丁修改的字段
+type NarrationPatchChange struct {
+	Path     string      `bson:"path" json:"path"`                           // 字段路径
+	SceneID  string      `bson:"scene_id" json:"scene_id"`                   // 场景ID
+	ShotID   string      `bson:"shot_id,omitempty" json:"shot_id,omitempty"` // 镜头ID（修改场景字段时为空）
+	Field    string      `bson:"field" json:"field"`                         // 字段名
+	OldValue interface{} `bson:"old_value" json:"old_value"`                 // 修改前的值
+	NewValue interface{} `bson:"new_value" json:"new_value"`                 // 修改后的值
+}
+
+// Collection 返回集合名称
+func (p *NarrationPatch) Collection() string {
+	return "narration_patches"
+}
+
+// EnsureIndexes 创建和维护索引
+func (p *NarrationPatch) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(p.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "narration_id", Value: 1}, {Key: "minor_version", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_narration_minor_unique"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
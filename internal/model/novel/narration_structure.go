@@ -0,0 +1,12 @@
+package novel
+
+// NarrationStructure 解说结构模板：场景数范围、每个场景的镜头数范围和目标时长
+// 生成剧本的提示词、剧本校验和视频阶段按该结构处理，字段为 0 时沿用模板（或默认结构）的取值
+type NarrationStructure struct {
+	Template         string `bson:"template,omitempty" json:"template,omitempty"`                       // 模板名称：classic（7 个场景）、short、long，空表示在默认结构上自定义
+	MinScenes        int    `bson:"min_scenes,omitempty" json:"min_scenes,omitempty"`                   // 最少场景数
+	MaxScenes        int    `bson:"max_scenes,omitempty" json:"max_scenes,omitempty"`                   // 最多场景数
+	MinShotsPerScene int    `bson:"min_shots_per_scene,omitempty" json:"min_shots_per_scene,omitempty"` // 每个场景最少镜头数
+	MaxShotsPerScene int    `bson:"max_shots_per_scene,omitempty" json:"max_shots_per_scene,omitempty"` // 每个场景最多镜头数
+	TargetDuration   int    `bson:"target_duration,omitempty" json:"target_duration,omitempty"`         // 目标总时长（秒），为 0 时按章节字数决定解说字数
+}
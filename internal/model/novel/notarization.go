@@ -0,0 +1,71 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NotarizationRecord 最终视频公证记录
+// 说明：章节最终视频发布时登记文件的 SHA256，只追加不修改（只登记哈希，不引用视频文件，删除视频不影响验证）。
+// 所有记录按 Sequence 组成一条哈希链：RecordHash 由记录内容和上一条记录的 RecordHash（PrevHash）计算，修改或删除任意一条记录都会使之后的链校验失败；
+// 配置了时间戳服务（RFC 3161）时，RecordHash 另外由时间戳服务签名，TimestampToken 可以脱离本系统独立验证
+type NotarizationRecord struct {
+	ID         string `bson:"id" json:"id"`                                 // 记录ID（UUID）
+	Sequence   int64  `bson:"sequence" json:"sequence"`                     // 链上序号（从 1 开始连续递增）
+	NovelID    string `bson:"novel_id" json:"novel_id"`                     // 关联的小说ID
+	ChapterID  string `bson:"chapter_id" json:"chapter_id"`                 // 关联的章节ID
+	VideoID    string `bson:"video_id" json:"video_id"`                     // 最终视频ID（每个视频只公证一次）
+	Version    int    `bson:"version" json:"version"`                       // 视频版本号
+	SHA256     string `bson:"sha256" json:"sha256"`                         // 视频文件的 SHA256（十六进制小写）
+	FileSize   int64  `bson:"file_size" json:"file_size"`                   // 视频文件大小（字节）
+	BatchID    string `bson:"batch_id,omitempty" json:"batch_id,omitempty"` // 触发公证的发布批次ID
+	PrevHash   string `bson:"prev_hash" json:"prev_hash"`                   // 上一条记录的 RecordHash（第一条记录为空）
+	RecordHash string `bson:"record_hash" json:"record_hash"`               // 本记录的哈希（十六进制小写）
+
+	// 外部时间戳（可选）：时间戳服务对 RecordHash 签发的 RFC 3161 TimeStampToken（DER）
+	TimestampAuthority string     `bson:"timestamp_authority,omitempty" json:"timestamp_authority,omitempty"` // 时间戳服务地址
+	TimestampToken     []byte     `bson:"timestamp_token,omitempty" json:"timestamp_token,omitempty"`         // TimeStampToken（DER，JSON 中为 base64）
+	TimestampedAt      *time.Time `bson:"timestamped_at,omitempty" json:"timestamped_at,omitempty"`           // 时间戳服务签发的时间（genTime）
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"` // 公证时间（毫秒精度，参与 RecordHash 计算）
+}
+
+// Collection 返回集合名称
+func (r *NotarizationRecord) Collection() string {
+	return "notarization_records"
+}
+
+// EnsureIndexes 创建和维护索引
+func (r *NotarizationRecord) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(r.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			// 链上序号唯一：多个实例同时公证时只有一个能追加同一序号的记录
+			Keys:    bson.D{{Key: "sequence", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_sequence_unique"),
+		},
+		{
+			// 每个视频只公证一次（回滚后重新发布同一视频不重复登记）
+			Keys:    bson.D{{Key: "video_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_video_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "sha256", Value: 1}},
+			Options: options.Index().SetName("idx_sha256"),
+		},
+		{
+			Keys:    bson.D{{Key: "chapter_id", Value: 1}, {Key: "sequence", Value: -1}},
+			Options: options.Index().SetName("idx_chapter_sequence"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -41,15 +41,79 @@ type Novel struct {
 	Author      string `bson:"author,omitempty" json:"author,omitempty"`           // 作者
 	Description string `bson:"description,omitempty" json:"description,omitempty"` // 简介
 
+	// 当前选用的封面（候选封面存储在 novel_covers，这里冗余 resource_id 便于列表展示）
+	CoverID         string `bson:"cover_id,omitempty" json:"cover_id,omitempty"`                   // 封面ID
+	CoverResourceID string `bson:"cover_resource_id,omitempty" json:"cover_resource_id,omitempty"` // 封面图片的 resource_id
+
+	// 源文件编码（切分章节时识别并统一转换为 UTF-8，如 utf-8、gb18030、big5）
+	SourceEncoding string `bson:"source_encoding,omitempty" json:"source_encoding,omitempty"`
+
 	// 创作配置
-	NarrationType NarrationType `bson:"narration_type" json:"narration_type"` // 旁白类型：narration（旁白/解说）或 dialogue（真人对话）
-	Style         NovelStyle    `bson:"style" json:"style"`                   // 风格：anime（漫剧）、live（真人剧）、mixed（混合）
+	NarrationType NarrationType     `bson:"narration_type" json:"narration_type"`                 // 旁白类型：narration（旁白/解说）或 dialogue（真人对话）
+	Style         NovelStyle        `bson:"style" json:"style"`                                   // 风格：anime（漫剧）、live（真人剧）、mixed（混合）
+	NumberStyle   NumberStyle       `bson:"number_style,omitempty" json:"number_style,omitempty"` // 数字写法：TTS 和字幕生成前对解说文本统一转换，空表示不转换
+	Continuity    ChapterContinuity `bson:"continuity,omitempty" json:"continuity,omitempty"`     // 章节衔接方式：用上一章最终视频的最后一帧衔接本章开头，空表示不衔接
+
+	// 预算（费用上限与实时花费）
+	Budget *NovelBudget `bson:"budget,omitempty" json:"budget,omitempty"`
+
+	// 创作设定（题材、基调、受众、禁用话题等，生成提示词时注入）
+	Metadata *CreativeMetadata `bson:"metadata,omitempty" json:"metadata,omitempty"`
+
+	// 渲染设置（默认平台、配音、字幕样式、片头片尾，创建时从用户的创作默认配置复制）
+	Render *RenderSettings `bson:"render,omitempty" json:"render,omitempty"`
+
+	// 生成参数（视频分辨率/帧率、配音、图片步数，覆盖用户设置，为空时沿用用户设置和系统默认值）
+	Generation *GenerationSettings `bson:"generation,omitempty" json:"generation,omitempty"`
+
+	// 解说结构模板（场景数、每个场景的镜头数、目标时长），为空时使用默认的 7 个场景结构
+	NarrationStructure *NarrationStructure `bson:"narration_structure,omitempty" json:"narration_structure,omitempty"`
+
+	// 预生成设置（审核第 N 章时为第 N+1 章预生成解说草稿等低成本产物），为空表示不自动预生成
+	Standby *StandbySettings `bson:"standby,omitempty" json:"standby,omitempty"`
+
+	// 创建时选用的工作流模板（模板配置已复制到小说上，之后修改模板不影响已创建的小说）
+	WorkflowTemplate string `bson:"workflow_template,omitempty" json:"workflow_template,omitempty"`
+
+	// 图片风格预设（章节图片和预览图的画面风格），为空时使用国风漫画风格
+	StylePreset ImageStylePreset `bson:"style_preset,omitempty" json:"style_preset,omitempty"`
+
+	// 目标发布平台（第一个与渲染设置中的默认平台一致），为空时只发布到默认平台
+	Platforms []TargetPlatform `bson:"platforms,omitempty" json:"platforms,omitempty"`
+
+	// 署名设置（授权作品要求在最终视频中展示的原作署名），为空表示不添加署名
+	Attribution *Attribution `bson:"attribution,omitempty" json:"attribution,omitempty"`
 
 	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time  `bson:"updated_at" json:"updated_at"`
 	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
 }
 
+// NovelBudget 小说预算
+// 金额单位为「分」；CapFen 为 0 表示不限额，此时仍然记录花费
+type NovelBudget struct {
+	CapFen        int64      `bson:"cap_fen" json:"cap_fen"`                             // 费用上限（分）
+	WarnRatio     float64    `bson:"warn_ratio" json:"warn_ratio"`                       // 预警比例（花费达到上限的该比例时预警，默认 0.8）
+	HardStop      bool       `bson:"hard_stop" json:"hard_stop"`                         // 超出上限后是否停止新的生成调用（否则只通知）
+	WebhookURL    string     `bson:"webhook_url,omitempty" json:"webhook_url,omitempty"` // 预算事件通知地址（可选）
+	WebhookSecret string     `bson:"webhook_secret,omitempty" json:"-"`                  // 预算事件通知签名密钥（设置通知地址时生成）
+	SpentFen      int64      `bson:"spent_fen" json:"spent_fen"`                         // 已花费（分）
+	WarnedAt      *time.Time `bson:"warned_at,omitempty" json:"warned_at,omitempty"`     // 发出预警的时间（调整上限后重置）
+	ExceededAt    *time.Time `bson:"exceeded_at,omitempty" json:"exceeded_at,omitempty"` // 超出上限的时间（调整上限后重置）
+}
+
+// CreativeMetadata 小说创作设定
+// 所有生成提示词都可以用 {{genre}}、{{protagonist}} 等变量引用，文本生成提示词还会自动附带设定说明
+type CreativeMetadata struct {
+	Genre        string            `bson:"genre,omitempty" json:"genre,omitempty"`                 // 题材（如 玄幻、都市、悬疑）
+	Tone         string            `bson:"tone,omitempty" json:"tone,omitempty"`                   // 基调（如 热血、轻松、压抑）
+	Audience     string            `bson:"audience,omitempty" json:"audience,omitempty"`           // 目标受众（如 男频、女频、青少年）
+	Protagonist  string            `bson:"protagonist,omitempty" json:"protagonist,omitempty"`     // 主角姓名
+	BannedTopics []string          `bson:"banned_topics,omitempty" json:"banned_topics,omitempty"` // 禁止涉及的话题
+	Variables    map[string]string `bson:"variables,omitempty" json:"variables,omitempty"`         // 自定义变量（变量名 -> 取值）
+	UpdatedAt    time.Time         `bson:"updated_at" json:"updated_at"`
+}
+
 // Collection 返回集合名称
 func (n *Novel) Collection() string { return "novels" }
 
@@ -0,0 +1,64 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CoverSource 封面来源
+type CoverSource string
+
+const (
+	CoverSourceGenerated CoverSource = "generated" // 根据作品设定和第一章梗概生成
+	CoverSourceUploaded  CoverSource = "uploaded"  // 手动上传
+)
+
+// String 返回封面来源字符串
+func (s CoverSource) String() string {
+	return string(s)
+}
+
+// NovelCover 小说封面候选实体
+// 说明：一本小说可以有多张候选封面，当前选用的封面记录在 Novel.CoverID
+type NovelCover struct {
+	ID         string      `bson:"id" json:"id"`                   // 封面ID（UUID）
+	NovelID    string      `bson:"novel_id" json:"novel_id"`       // 关联的小说ID
+	UserID     string      `bson:"user_id" json:"user_id"`         // 创建用户ID
+	ResourceID string      `bson:"resource_id" json:"resource_id"` // 封面图片的 resource_id
+	Source     CoverSource `bson:"source" json:"source"`           // 来源：generated（生成）或 uploaded（上传）
+
+	// 生成信息（仅 generated）
+	Prompt            string   `bson:"prompt,omitempty" json:"prompt,omitempty"`                           // 生成使用的提示词
+	LocalizedPrompt   string   `bson:"localized_prompt,omitempty" json:"localized_prompt,omitempty"`       // 提供者偏好非中文时实际使用的提示词
+	StyleReferenceIDs []string `bson:"style_reference_ids,omitempty" json:"style_reference_ids,omitempty"` // 生成时使用的风格参考图ID
+
+	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `bson:"updated_at" json:"updated_at"`
+	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
+
+// Collection 返回集合名称
+func (c *NovelCover) Collection() string {
+	return "novel_covers"
+}
+
+// EnsureIndexes 创建和维护索引
+func (c *NovelCover) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(c.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "novel_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_novel_created"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -0,0 +1,49 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NovelGrant 小说协作授权实体
+// 说明：小说所有者把单本小说的只读/编辑权限授予其他用户，权限作用于小说下的所有资源（章节、解说、素材等）
+// 同一用户在同一小说下只有一条授权，重复授权时更新权限
+type NovelGrant struct {
+	ID         string          `bson:"id" json:"id"`                 // 授权ID（UUID）
+	NovelID    string          `bson:"novel_id" json:"novel_id"`     // 小说ID
+	UserID     string          `bson:"user_id" json:"user_id"`       // 被授权用户ID
+	Permission NovelPermission `bson:"permission" json:"permission"` // 权限：read、edit
+	GrantedBy  string          `bson:"granted_by" json:"granted_by"` // 授权人用户ID
+	CreatedAt  time.Time       `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time       `bson:"updated_at" json:"updated_at"`
+}
+
+// Collection 返回集合名称
+func (g *NovelGrant) Collection() string {
+	return "novel_grants"
+}
+
+// EnsureIndexes 创建和维护索引
+func (g *NovelGrant) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(g.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "novel_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_novel_user_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetName("idx_user_id"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -0,0 +1,97 @@
+package novel
+
+import "time"
+
+// PipelineManifestSchemaVersion 流水线清单的结构版本，字段有不兼容的变化时递增
+const PipelineManifestSchemaVersion = 1
+
+// PipelineManifest 最终视频的流水线清单（JSON，随最终视频一起存储为资源文件）
+// 说明：记录生成最终视频用到的全部输入（解说、镜头、图片、音频、字幕、片段、背景音乐）的ID、
+// 生成时使用的 provider/模型、提示词哈希以及工具版本，用于复现和向客户证明素材来源
+type PipelineManifest struct {
+	SchemaVersion int                         `json:"schema_version"`           // 清单结构版本
+	VideoID       string                      `json:"video_id"`                 // 最终视频ID
+	NovelID       string                      `json:"novel_id"`                 // 小说ID
+	ChapterID     string                      `json:"chapter_id"`               // 章节ID
+	Version       int                         `json:"version"`                  // 视频版本号
+	ExportTier    ExportTier                  `json:"export_tier"`              // 导出档位
+	Platform      TargetPlatform              `json:"platform,omitempty"`       // 目标发布平台
+	CreatedAt     time.Time                   `json:"created_at"`               // 生成时间
+	Narration     ManifestNarration           `json:"narration"`                // 使用的解说
+	Clips         []ManifestClip              `json:"clips"`                    // 按拼接顺序排列的片段
+	BGMTracks     []ManifestBGMTrack          `json:"bgm_tracks,omitempty"`     // 混入的背景音乐
+	Providers     map[string]ManifestProvider `json:"providers"`                // 各生成能力的 provider：llm, tts, image, video
+	Tools         ManifestTools               `json:"tools"`                    // 工具版本
+	Compliance    string                      `json:"compliance,omitempty"`     // 使用的平台规范预设
+	Watermarked   bool                        `json:"watermarked"`              // 是否添加了预览水印
+	RecapVideoID  string                      `json:"recap_video_id,omitempty"` // 片头衔接使用的上一章最终视频ID
+	Accessibility *ManifestAccessibility      `json:"accessibility,omitempty"`  // 随最终视频导出的无障碍输出
+	Attribution   *AttributionRecord          `json:"attribution,omitempty"`    // 渲染的原作署名
+}
+
+// ManifestAccessibility 清单中的无障碍输出
+type ManifestAccessibility struct {
+	TranscriptResourceID       string `json:"transcript_resource_id"`                  // 纯文本文字稿的 resource_id
+	AudioDescriptionResourceID string `json:"audio_description_resource_id,omitempty"` // 口述影像音轨的 resource_id（未开启或失败时为空）
+	DescriptionVTTResourceID   string `json:"description_vtt_resource_id,omitempty"`   // 口述影像 WebVTT 描述轨的 resource_id
+	DescriptionCues            int    `json:"description_cues,omitempty"`              // 口述影像描述条数
+}
+
+// ManifestProvider 清单中生成能力的 provider 名称和模型
+type ManifestProvider struct {
+	Name  string `json:"name"`            // provider 名称（如 ark、bytedance_tts）
+	Model string `json:"model,omitempty"` // 模型/音色/工作流
+}
+
+// ManifestNarration 清单中的解说
+type ManifestNarration struct {
+	ID         string `json:"id"`                    // 解说ID
+	Version    int    `json:"version"`               // 解说版本号
+	PromptHash string `json:"prompt_hash,omitempty"` // 生成解说的提示词哈希
+}
+
+// ManifestClip 清单中的 narration 视频片段
+type ManifestClip struct {
+	VideoID     string         `json:"video_id"`              // 片段视频ID
+	ResourceID  string         `json:"resource_id"`           // 片段文件的 resource_id
+	Sequence    int            `json:"sequence"`              // 覆盖的第一个镜头序号
+	SequenceEnd int            `json:"sequence_end"`          // 覆盖的最后一个镜头序号
+	PromptHash  string         `json:"prompt_hash,omitempty"` // 生成片段的视频提示词哈希
+	Shots       []ManifestShot `json:"shots"`                 // 片段包含的镜头
+}
+
+// ManifestShot 清单中的镜头及其素材
+type ManifestShot struct {
+	ShotID             string   `json:"shot_id"`                        // 镜头ID
+	SceneNumber        string   `json:"scene_number"`                   // 场景编号
+	ShotNumber         string   `json:"shot_number"`                    // 镜头编号
+	Index              int      `json:"index"`                          // 全局镜头序号
+	ImagePromptHash    string   `json:"image_prompt_hash,omitempty"`    // 镜头图片提示词哈希
+	VideoPromptHash    string   `json:"video_prompt_hash,omitempty"`    // 镜头视频提示词哈希
+	ImageID            string   `json:"image_id,omitempty"`             // 图片ID
+	ImageResourceID    string   `json:"image_resource_id,omitempty"`    // 图片文件的 resource_id
+	ImageRequestHash   string   `json:"image_request_hash,omitempty"`   // 实际提交给图片 provider 的提示词哈希
+	StyleReferenceIDs  []string `json:"style_reference_ids,omitempty"`  // 风格参考图ID
+	AudioID            string   `json:"audio_id,omitempty"`             // 音频ID
+	AudioResourceID    string   `json:"audio_resource_id,omitempty"`    // 音频文件的 resource_id
+	TextHash           string   `json:"text_hash,omitempty"`            // 配音文本哈希
+	SubtitleID         string   `json:"subtitle_id,omitempty"`          // 字幕ID
+	SubtitleResourceID string   `json:"subtitle_resource_id,omitempty"` // 字幕文件的 resource_id
+}
+
+// ManifestBGMTrack 清单中的背景音乐曲目
+type ManifestBGMTrack struct {
+	TrackID      string    `json:"track_id"`      // 曲目ID
+	ResourceID   string    `json:"resource_id"`   // 曲目文件的 resource_id
+	Mood         SceneMood `json:"mood"`          // 场景情绪
+	Start        float64   `json:"start"`         // 在视频中的开始时间（秒）
+	End          float64   `json:"end"`           // 在视频中的结束时间（秒）
+	SceneNumbers []string  `json:"scene_numbers"` // 覆盖的场景编号
+}
+
+// ManifestTools 清单中的工具版本
+type ManifestTools struct {
+	FFmpegVersion string `json:"ffmpeg_version,omitempty"` // ffmpeg 版本
+	GoVersion     string `json:"go_version"`               // 构建服务的 Go 版本
+	AppRevision   string `json:"app_revision,omitempty"`   // 服务的 VCS 提交（构建信息中没有时为空）
+}
@@ -0,0 +1,122 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PipelineRunStatus 章节一键生成流程的状态
+type PipelineRunStatus string
+
+const (
+	PipelineRunStatusRunning   PipelineRunStatus = "running"   // 执行中
+	PipelineRunStatusSucceeded PipelineRunStatus = "succeeded" // 全部步骤已完成
+	PipelineRunStatusFailed    PipelineRunStatus = "failed"    // 某个步骤失败（可从失败的步骤继续）
+	PipelineRunStatusCanceled  PipelineRunStatus = "canceled"  // 某个步骤的任务被取消（可从取消的步骤继续）
+)
+
+// String 返回状态的字符串表示
+func (s PipelineRunStatus) String() string {
+	return string(s)
+}
+
+// IsFinished 流程是否已结束
+func (s PipelineRunStatus) IsFinished() bool {
+	return s != PipelineRunStatusRunning
+}
+
+// PipelineStepStatus 一键生成流程中单个步骤的状态
+type PipelineStepStatus string
+
+const (
+	PipelineStepStatusPending   PipelineStepStatus = "pending"   // 未开始
+	PipelineStepStatusQueued    PipelineStepStatus = "queued"    // 已提交到任务队列（包括等待重试）
+	PipelineStepStatusRunning   PipelineStepStatus = "running"   // 执行中
+	PipelineStepStatusSucceeded PipelineStepStatus = "succeeded" // 已完成
+	PipelineStepStatusFailed    PipelineStepStatus = "failed"    // 失败
+	PipelineStepStatusCanceled  PipelineStepStatus = "canceled"  // 已取消
+	PipelineStepStatusSkipped   PipelineStepStatus = "skipped"   // 跳过（从后面的步骤开始时沿用已有产物）
+)
+
+// IsDone 步骤是否已完成（成功或跳过）
+func (s PipelineStepStatus) IsDone() bool {
+	return s == PipelineStepStatusSucceeded || s == PipelineStepStatusSkipped
+}
+
+// PipelineRun 章节一键生成流程（解说 -> 音频 -> 字幕 -> 图片 -> 解说视频 -> 最终视频）
+// 说明：每个步骤作为一个任务提交到任务队列，上一步成功后提交下一步；某一步失败或被取消时流程停止，再次提交时从该步骤继续
+type PipelineRun struct {
+	ID           string            `bson:"id" json:"id"`                                           // 流程ID（UUID）
+	NovelID      string            `bson:"novel_id" json:"novel_id"`                               // 关联的小说ID
+	ChapterID    string            `bson:"chapter_id" json:"chapter_id"`                           // 关联的章节ID
+	TriggeredBy  string            `bson:"triggered_by,omitempty" json:"triggered_by,omitempty"`   // 提交人用户ID
+	Status       PipelineRunStatus `bson:"status" json:"status"`                                   // 状态：running, succeeded, failed, canceled
+	Steps        []PipelineRunStep `bson:"steps" json:"steps"`                                     // 各步骤（按执行顺序）
+	Resumes      int               `bson:"resumes" json:"resumes"`                                 // 从失败步骤继续执行的次数
+	ErrorMessage string            `bson:"error_message,omitempty" json:"error_message,omitempty"` // 最近一次失败的原因
+
+	ProgressPercent int `bson:"-" json:"progress_percent"` // 整体进度（0~100，查询时计算）
+
+	StartedAt   time.Time  `bson:"started_at" json:"started_at"`
+	CompletedAt *time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+// PipelineRunStep 一键生成流程中的单个步骤
+type PipelineRunStep struct {
+	Stage        PipelineStage      `bson:"stage" json:"stage"`                                     // 阶段
+	Status       PipelineStepStatus `bson:"status" json:"status"`                                   // 状态
+	JobID        string             `bson:"job_id,omitempty" json:"job_id,omitempty"`               // 最近一次提交的任务ID
+	Attempts     int                `bson:"attempts" json:"attempts"`                               // 任务执行次数（继续执行时累加）
+	ResourceIDs  []string           `bson:"resource_ids,omitempty" json:"resource_ids,omitempty"`   // 生成的产物ID
+	ErrorMessage string             `bson:"error_message,omitempty" json:"error_message,omitempty"` // 最近一次失败的原因
+	StartedAt    *time.Time         `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	CompletedAt  *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+
+	ProgressPercent int `bson:"-" json:"progress_percent"` // 步骤进度（0~100，执行中按最近一次成功执行的耗时估算，查询时计算）
+}
+
+// Step 返回阶段对应的步骤，不存在时返回 nil
+func (r *PipelineRun) Step(stage PipelineStage) *PipelineRunStep {
+	for i := range r.Steps {
+		if r.Steps[i].Stage == stage {
+			return &r.Steps[i]
+		}
+	}
+	return nil
+}
+
+// Collection 返回集合名称
+func (r *PipelineRun) Collection() string {
+	return "pipeline_runs"
+}
+
+// EnsureIndexes 创建和维护索引
+func (r *PipelineRun) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(r.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "chapter_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_chapter_created"),
+		},
+		{
+			// 同一章节同时只能有一个执行中的流程
+			Keys: bson.D{{Key: "chapter_id", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": PipelineRunStatusRunning}).
+				SetName("idx_chapter_running_unique"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -0,0 +1,66 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PrewarmJob 素材预热任务实体
+// 说明：定时渲染前把章节需要的图片/音频/字幕提前下载到本地缓存，渲染窗口内直接读取本地文件
+type PrewarmJob struct {
+	ID         string   `bson:"id" json:"id"`                   // 任务ID（UUID）
+	NovelID    string   `bson:"novel_id" json:"novel_id"`       // 关联的小说ID
+	ChapterIDs []string `bson:"chapter_ids" json:"chapter_ids"` // 需要预热的章节ID（为空表示整部小说）
+	UserID     string   `bson:"user_id" json:"user_id"`         // 创建人用户ID
+
+	RenderAt time.Time `bson:"render_at" json:"render_at"` // 渲染窗口开始时间
+	StartAt  time.Time `bson:"start_at" json:"start_at"`   // 预热开始时间（渲染窗口前的提前量）
+
+	Status       PrewarmStatus `bson:"status" json:"status"`                                   // 状态：scheduled, running, completed, failed
+	Stats        PrewarmStats  `bson:"stats" json:"stats"`                                     // 命中统计
+	ErrorMessage string        `bson:"error_message,omitempty" json:"error_message,omitempty"` // 失败原因
+
+	StartedAt   *time.Time `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	CompletedAt *time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+// PrewarmStats 预热命中统计
+type PrewarmStats struct {
+	Total       int   `bson:"total" json:"total"`               // 需要预热的资源数
+	Hits        int   `bson:"hits" json:"hits"`                 // 已在本地缓存中的资源数
+	Misses      int   `bson:"misses" json:"misses"`             // 本次下载到缓存的资源数
+	Failed      int   `bson:"failed" json:"failed"`             // 下载失败的资源数
+	BytesStaged int64 `bson:"bytes_staged" json:"bytes_staged"` // 本次下载的字节数
+}
+
+// Collection 返回集合名称
+func (j *PrewarmJob) Collection() string {
+	return "prewarm_jobs"
+}
+
+// EnsureIndexes 创建和维护索引
+func (j *PrewarmJob) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(j.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "novel_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_novel_created"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "start_at", Value: 1}},
+			Options: options.Index().SetName("idx_status_start"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
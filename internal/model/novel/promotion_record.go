@@ -0,0 +1,67 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PromotionAction 发布记录的操作类型
+type PromotionAction string
+
+const (
+	PromotionActionPromote  PromotionAction = "promote"  // 发布新版本
+	PromotionActionRollback PromotionAction = "rollback" // 回滚到上一次发布的版本
+)
+
+// String 返回操作类型的字符串表示
+func (a PromotionAction) String() string {
+	return string(a)
+}
+
+// PromotionRecord 章节发布记录
+// 说明：章节发布版本变更的历史，只追加不修改。每次批量发布或回滚为每个版本有变化的章节追加一条记录，
+// 同一次操作的记录 BatchID 相同；发布记录是否已被回滚由回滚记录的 RollbackOf 推导
+type PromotionRecord struct {
+	ID         string            `bson:"id" json:"id"`                                       // 记录ID（UUID）
+	NovelID    string            `bson:"novel_id" json:"novel_id"`                           // 关联的小说ID
+	ChapterID  string            `bson:"chapter_id" json:"chapter_id"`                       // 关联的章节ID
+	BatchID    string            `bson:"batch_id" json:"batch_id"`                           // 操作批次ID（同一次批量发布/回滚相同）
+	Action     PromotionAction   `bson:"action" json:"action"`                               // 操作类型：promote、rollback
+	Strategy   PromotionStrategy `bson:"strategy,omitempty" json:"strategy,omitempty"`       // 发布策略（只有发布记录有）
+	Previous   *PromotedVersions `bson:"previous,omitempty" json:"previous,omitempty"`       // 变更前的发布版本（首次发布时为空）
+	Next       *PromotedVersions `bson:"next,omitempty" json:"next,omitempty"`               // 变更后的发布版本（回滚到未发布状态时为空）
+	RollbackOf string            `bson:"rollback_of,omitempty" json:"rollback_of,omitempty"` // 回滚记录撤销的发布记录ID
+	Reason     string            `bson:"reason,omitempty" json:"reason,omitempty"`           // 回滚原因
+	OperatedBy string            `bson:"operated_by,omitempty" json:"operated_by,omitempty"` // 操作人用户ID
+	CreatedAt  time.Time         `bson:"created_at" json:"created_at"`
+}
+
+// Collection 返回集合名称
+func (r *PromotionRecord) Collection() string {
+	return "promotion_records"
+}
+
+// EnsureIndexes 创建和维护索引
+func (r *PromotionRecord) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(r.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "chapter_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_chapter_created"),
+		},
+		{
+			Keys:    bson.D{{Key: "batch_id", Value: 1}},
+			Options: options.Index().SetName("idx_batch_id"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -0,0 +1,46 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PronunciationEntry 小说发音词典条目
+// 说明：纠正人名、功法名等容易被 TTS 读错的词。TTS 支持发音标注时按拼音朗读，否则合成前用同音替换文本替换原词（字幕仍显示原词）
+type PronunciationEntry struct {
+	ID          string     `bson:"id" json:"id"`                                       // 条目ID（UUID）
+	NovelID     string     `bson:"novel_id" json:"novel_id"`                           // 关联的小说ID
+	Term        string     `bson:"term" json:"term"`                                   // 词（同一小说内唯一）
+	Pinyin      string     `bson:"pinyin,omitempty" json:"pinyin,omitempty"`           // 带声调数字的拼音，音节之间用空格分隔（如 "mo4 qi2"）
+	Replacement string     `bson:"replacement,omitempty" json:"replacement,omitempty"` // 同音替换文本（TTS 不支持发音标注时使用）
+	Note        string     `bson:"note,omitempty" json:"note,omitempty"`               // 备注（如 "反派人名"）
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `bson:"updated_at" json:"updated_at"`
+	DeletedAt   *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
+
+// Collection 返回集合名称
+func (e *PronunciationEntry) Collection() string {
+	return "pronunciation_entries"
+}
+
+// EnsureIndexes 创建和维护索引
+func (e *PronunciationEntry) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(e.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "novel_id", Value: 1}, {Key: "term", Value: 1}},
+			Options: options.Index().SetName("idx_novel_term"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
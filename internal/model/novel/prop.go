@@ -20,6 +20,7 @@ type Prop struct {
 	Description     string `bson:"description" json:"description"`           // 道具详细描述
 	ImagePrompt     string `bson:"image_prompt" json:"image_prompt"`          // 道具图片提示词
 	ImageResourceID string `bson:"image_resource_id,omitempty" json:"image_resource_id,omitempty"` // 道具图片的 resource_id
+	ImageStyleReferenceIDs []string `bson:"image_style_reference_ids,omitempty" json:"image_style_reference_ids,omitempty"` // 生成道具图片时使用的风格参考图ID
 
 	Category string `bson:"category,omitempty" json:"category,omitempty"` // 道具类别（如：武器、法器、丹药等）
 
@@ -0,0 +1,65 @@
+package novel
+
+import "time"
+
+// QASignal QA 评分卡的信号来源
+type QASignal string
+
+const (
+	QASignalNarration    QASignal = "narration"     // 解说文案校验（字数、分镜数量、开头特写、近似重复镜头）
+	QASignalSubtitleSync QASignal = "subtitle_sync" // 字幕与音频的同步（字幕由音频的字符时间戳生成）
+	QASignalVideo        QASignal = "video"         // 解说视频片段和最终视频的质检（状态、时长、平台规范）
+	QASignalImage        QASignal = "image"         // 镜头图片质检
+	QASignalASR          QASignal = "asr"           // 语音识别回听校验
+)
+
+// String 返回信号的字符串表示
+func (s QASignal) String() string {
+	return string(s)
+}
+
+// QASeverity QA 问题的严重程度
+type QASeverity string
+
+const (
+	QASeverityWarning  QASeverity = "warning"  // 警告：扣分，不阻断发布
+	QASeverityBlocking QASeverity = "blocking" // 阻断：该信号记 0 分，章节不允许发布
+)
+
+// QAFinding 单个 QA 问题
+type QAFinding struct {
+	Severity QASeverity `json:"severity"`
+	Message  string     `json:"message"`
+	Ref      string     `json:"ref,omitempty"` // 相关对象（如镜头ID、片段序号）
+}
+
+// QASignalResult 单个信号的评分
+type QASignalResult struct {
+	Signal    QASignal    `json:"signal"`
+	Available bool        `json:"available"`      // 是否有可用数据（不可用的信号不计入总分）
+	Score     float64     `json:"score"`          // 0-100
+	Weight    float64     `json:"weight"`         // 计入总分的权重
+	Note      string      `json:"note,omitempty"` // 说明（如信号不可用的原因）
+	Findings  []QAFinding `json:"findings,omitempty"`
+}
+
+// QAScorecard 章节 QA 评分卡
+// 说明：按需根据各类产物的当前数据计算，不单独存储；发布版本时随发布计划一起返回
+type QAScorecard struct {
+	ChapterID string `json:"chapter_id"`
+	NovelID   string `json:"novel_id"`
+
+	// 评分对应的产物版本
+	NarrationVersion int `json:"narration_version"`
+	ImageVersion     int `json:"image_version"`
+	AudioVersion     int `json:"audio_version"`
+	VideoVersion     int `json:"video_version"`
+
+	Score           float64          `json:"score"`                      // 可用信号的加权平均分（0-100）
+	Grade           string           `json:"grade"`                      // 等级：A/B/C/D/F
+	MinScore        float64          `json:"min_score"`                  // 允许发布的最低分
+	Blocked         bool             `json:"blocked"`                    // 是否阻断发布
+	BlockingReasons []string         `json:"blocking_reasons,omitempty"` // 阻断原因
+	Signals         []QASignalResult `json:"signals"`
+	GeneratedAt     time.Time        `json:"generated_at"`
+}
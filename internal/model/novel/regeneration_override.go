@@ -0,0 +1,66 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RegenerationArtifact 计入重新生成次数的产物类型
+type RegenerationArtifact string
+
+const (
+	RegenerationArtifactScene RegenerationArtifact = "scene" // 场景
+	RegenerationArtifactShot  RegenerationArtifact = "shot"  // 镜头
+)
+
+// RegenerationKind 重新生成操作
+type RegenerationKind string
+
+const (
+	RegenerationKindShotScript         RegenerationKind = "shot_script"          // 重新生成镜头脚本
+	RegenerationKindShotClipPreview    RegenerationKind = "shot_clip_preview"    // 重新渲染镜头片段预览
+	RegenerationKindSceneImageVariants RegenerationKind = "scene_image_variants" // 生成场景图片重新打光变体
+)
+
+// RegenerationOverride 重新生成越权审计记录
+// 说明：产物的重新生成次数超过上限后，管理员/审核员填写原因后才能继续重新生成，每次越权记录一条
+type RegenerationOverride struct {
+	ID           string               `bson:"id" json:"id"`                       // 记录ID（UUID）
+	NovelID      string               `bson:"novel_id" json:"novel_id"`           // 关联的小说ID
+	ChapterID    string               `bson:"chapter_id" json:"chapter_id"`       // 关联的章节ID
+	ArtifactType RegenerationArtifact `bson:"artifact_type" json:"artifact_type"` // 产物类型：scene, shot
+	ArtifactID   string               `bson:"artifact_id" json:"artifact_id"`     // 场景ID或镜头ID
+	Kind         RegenerationKind     `bson:"kind" json:"kind"`                   // 重新生成操作
+	Count        int                  `bson:"count" json:"count"`                 // 本次是第几次重新生成
+	Limit        int                  `bson:"limit" json:"limit"`                 // 越权时的上限次数
+	UserID       string               `bson:"user_id" json:"user_id"`             // 越权的用户ID
+	Role         string               `bson:"role" json:"role"`                   // 越权时的用户角色
+	Reason       string               `bson:"reason" json:"reason"`               // 越权原因
+	CreatedAt    time.Time            `bson:"created_at" json:"created_at"`
+}
+
+// Collection 返回集合名称
+func (o *RegenerationOverride) Collection() string {
+	return "regeneration_overrides"
+}
+
+// EnsureIndexes 创建和维护索引
+func (o *RegenerationOverride) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(o.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "novel_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_novel_created"),
+		},
+		{
+			Keys:    bson.D{{Key: "artifact_id", Value: 1}},
+			Options: options.Index().SetName("idx_artifact_id"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -13,23 +13,26 @@ import (
 // 说明：场景独立存储，通过 chapter_id + version 标识批次
 // 不再需要 narration_id，直接通过 chapter_id + version 关联
 type Scene struct {
-	ID              string     `bson:"id" json:"id"`                                                   // 场景ID（UUID）
-	NarrationID     string     `bson:"narration_id" json:"narration_id"`                               // 关联的解说ID（批次标识）
-	ChapterID       string     `bson:"chapter_id" json:"chapter_id"`                                   // 关联的章节ID
-	NovelID         string     `bson:"novel_id" json:"novel_id"`                                       // 关联的小说ID
-	UserID          string     `bson:"user_id" json:"user_id"`                                         // 用户ID（冗余字段，方便查询）
-	SceneNumber     string     `bson:"scene_number" json:"scene_number"`                               // 场景编号（字符串，如 "1"）
-	Description     string     `bson:"description" json:"description"`                                 // 场景详细描述
-	ImagePrompt     string     `bson:"image_prompt" json:"image_prompt"`                               // 场景图片提示词
-	ImageResourceID string     `bson:"image_resource_id,omitempty" json:"image_resource_id,omitempty"` // 场景图片的 resource_id
-	Narration       string     `bson:"narration,omitempty" json:"narration,omitempty"`                 // 场景级别的解说内容（可选）
-	Sequence        int        `bson:"sequence" json:"sequence"`                                       // 序号（在解说中的顺序，从1开始）
-	Version         int        `bson:"version" json:"version"`                                         // 版本号（用于支持多版本，默认 1）
-	Status          TaskStatus `bson:"status" json:"status"`                                           // 状态：pending, completed, failed
-	ErrorMessage    string     `bson:"error_message,omitempty" json:"error_message,omitempty"`         // 错误信息（失败时）
-	CreatedAt       time.Time  `bson:"created_at" json:"created_at"`
-	UpdatedAt       time.Time  `bson:"updated_at" json:"updated_at"`
-	DeletedAt       *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	ID                     string     `bson:"id" json:"id"`                                                                   // 场景ID（UUID）
+	NarrationID            string     `bson:"narration_id" json:"narration_id"`                                               // 关联的解说ID（批次标识）
+	ChapterID              string     `bson:"chapter_id" json:"chapter_id"`                                                   // 关联的章节ID
+	NovelID                string     `bson:"novel_id" json:"novel_id"`                                                       // 关联的小说ID
+	UserID                 string     `bson:"user_id" json:"user_id"`                                                         // 用户ID（冗余字段，方便查询）
+	SceneNumber            string     `bson:"scene_number" json:"scene_number"`                                               // 场景编号（字符串，如 "1"）
+	Description            string     `bson:"description" json:"description"`                                                 // 场景详细描述
+	ImagePrompt            string     `bson:"image_prompt" json:"image_prompt"`                                               // 场景图片提示词
+	ImageResourceID        string     `bson:"image_resource_id,omitempty" json:"image_resource_id,omitempty"`                 // 场景图片的 resource_id
+	ImageStyleReferenceIDs []string   `bson:"image_style_reference_ids,omitempty" json:"image_style_reference_ids,omitempty"` // 生成场景图片时使用的风格参考图ID
+	Narration              string     `bson:"narration,omitempty" json:"narration,omitempty"`                                 // 场景级别的解说内容（可选）
+	Mood                   SceneMood  `bson:"mood,omitempty" json:"mood,omitempty"`                                           // 场景情绪（用于选择背景音乐，旧数据为空时按 calm 处理）
+	Sequence               int        `bson:"sequence" json:"sequence"`                                                       // 序号（在解说中的顺序，从1开始）
+	Version                int        `bson:"version" json:"version"`                                                         // 版本号（用于支持多版本，默认 1）
+	Status                 TaskStatus `bson:"status" json:"status"`                                                           // 状态：pending, completed, failed
+	ErrorMessage           string     `bson:"error_message,omitempty" json:"error_message,omitempty"`                         // 错误信息（失败时）
+	RegenerationCount      int        `bson:"regeneration_count,omitempty" json:"regeneration_count"`                         // 重新生成次数（重新生成图片变体等，超过上限需要越权）
+	CreatedAt              time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt              time.Time  `bson:"updated_at" json:"updated_at"`
+	DeletedAt              *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
 }
 
 // Collection 返回集合名称
@@ -0,0 +1,59 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SceneImageVariant 场景图片变体实体
+// 说明：基于已生成的场景图片（父图片）通过图生图重新打光得到的变体（夜晚、黄昏、雨天等），
+// 通过 parent_resource_id 关联父图片，保证时间/天气切换时画面的连续性
+type SceneImageVariant struct {
+	ID string `bson:"id" json:"id"` // 变体ID（UUID）
+
+	SceneID     string `bson:"scene_id" json:"scene_id"`         // 关联的场景ID
+	NarrationID string `bson:"narration_id" json:"narration_id"` // 关联的解说ID
+	ChapterID   string `bson:"chapter_id" json:"chapter_id"`     // 关联的章节ID
+	NovelID     string `bson:"novel_id" json:"novel_id"`         // 关联的小说ID
+	UserID      string `bson:"user_id" json:"user_id"`           // 用户ID
+
+	ParentResourceID string         `bson:"parent_resource_id" json:"parent_resource_id"`                   // 父图片（原场景图片）的 resource_id
+	ImageResourceID  string         `bson:"image_resource_id,omitempty" json:"image_resource_id,omitempty"` // 变体图片的 resource_id
+	Variant          RelightVariant `bson:"variant" json:"variant"`                                         // 变体类型：night, dusk, rain
+	Prompt           string         `bson:"prompt,omitempty" json:"prompt,omitempty"`                       // 图生图使用的 prompt
+
+	Status       TaskStatus `bson:"status" json:"status"`                                   // 状态：pending, completed, failed
+	ErrorMessage string     `bson:"error_message,omitempty" json:"error_message,omitempty"` // 错误信息（失败时）
+	ErrorClass   string     `bson:"error_class,omitempty" json:"error_class,omitempty"`     // 失败类别（失败时）
+	CreatedAt    time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time  `bson:"updated_at" json:"updated_at"`
+	DeletedAt    *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
+
+// Collection 返回集合名称
+func (v *SceneImageVariant) Collection() string { return "scene_image_variants" }
+
+// EnsureIndexes 创建和维护索引
+func (v *SceneImageVariant) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(v.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "scene_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_scene_created"),
+		},
+		{
+			Keys:    bson.D{{Key: "parent_resource_id", Value: 1}},
+			Options: options.Index().SetName("idx_parent_resource_id"),
+		},
+		{
+			Keys:    bson.D{{Key: "narration_id", Value: 1}},
+			Options: options.Index().SetName("idx_narration_id"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -34,6 +34,8 @@ type Shot struct {
 	Version     int        `bson:"version" json:"version"`          // 版本号（用于支持多版本，默认 1）
 	Status      TaskStatus `bson:"status" json:"status"`            // 状态：pending, completed, failed
 	ErrorMessage string    `bson:"error_message,omitempty" json:"error_message,omitempty"` // 错误信息（失败时）
+	RegenerationCount int  `bson:"regeneration_count,omitempty" json:"regeneration_count"` // 重新生成次数（重新生成脚本、重新渲染片段预览，超过上限需要越权）
+	Rating      int        `bson:"rating,omitempty" json:"rating,omitempty"` // 编辑评分（1-5，用于自动挑选回顾合集的高光镜头，0 表示未评分）
 	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
 	UpdatedAt   time.Time  `bson:"updated_at" json:"updated_at"`
 	DeletedAt   *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
@@ -0,0 +1,96 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ShotClipPreviewTTL 单镜头片段预览的有效期，过期后不能再确认
+const ShotClipPreviewTTL = 24 * time.Hour
+
+// ShotClipPreviewLatencyTarget 单镜头「重新生成并预览」的目标耗时（p95）
+const ShotClipPreviewLatencyTarget = 60 * time.Second
+
+// ShotClipPreview 单镜头片段预览实体
+// 说明：编辑修改镜头的 video_prompt 后只重新渲染该镜头的片段（沿用已有的音频和字幕）用于预览；
+// 确认前不影响章节的视频版本，确认后以最新版本为基础生成新版本并替换该镜头的片段
+type ShotClipPreview struct {
+	ID               string                 `bson:"id" json:"id"`                                                   // 预览ID（UUID）
+	ShotID           string                 `bson:"shot_id" json:"shot_id"`                                         // 关联的镜头ID
+	NarrationID      string                 `bson:"narration_id" json:"narration_id"`                               // 关联的解说ID
+	ChapterID        string                 `bson:"chapter_id" json:"chapter_id"`                                   // 关联的章节ID
+	NovelID          string                 `bson:"novel_id" json:"novel_id"`                                       // 关联的小说ID
+	UserID           string                 `bson:"user_id" json:"user_id"`                                         // 用户ID
+	VideoPrompt      string                 `bson:"video_prompt" json:"video_prompt"`                               // 编辑后的视频提示词
+	VideoResourceID  string                 `bson:"video_resource_id" json:"video_resource_id"`                     // 预览片段文件的 resource_id
+	Duration         float64                `bson:"duration" json:"duration"`                                       // 片段时长（秒）
+	Platform         TargetPlatform         `bson:"platform,omitempty" json:"platform,omitempty"`                   // 目标发布平台（沿用原片段）
+	Sequence         int                    `bson:"sequence" json:"sequence"`                                       // 片段的镜头序号
+	BaseVersion      int                    `bson:"base_version" json:"base_version"`                               // 渲染预览时章节的最新视频版本
+	BaseVideoID      string                 `bson:"base_video_id" json:"base_video_id"`                             // 被替换的原片段视频ID
+	Status           ShotClipPreviewStatus  `bson:"status" json:"status"`                                           // 状态：pending, confirmed, discarded
+	ConfirmedVersion int                    `bson:"confirmed_version,omitempty" json:"confirmed_version,omitempty"` // 确认后生成的视频版本号
+	LatencyMs        int64                  `bson:"latency_ms,omitempty" json:"latency_ms,omitempty"`               // 从请求开始到预览可用的耗时（毫秒）
+	Timings          *ShotClipRenderTimings `bson:"timings,omitempty" json:"timings,omitempty"`                     // 各步骤耗时
+	SourceReused     bool                   `bson:"source_reused,omitempty" json:"source_reused,omitempty"`         // 是否复用了本地缓存的图生视频结果（没有调用图生视频接口）
+	ExpiresAt        time.Time              `bson:"expires_at" json:"expires_at"`                                   // 过期时间
+	CreatedAt        time.Time              `bson:"created_at" json:"created_at"`
+	UpdatedAt        time.Time              `bson:"updated_at" json:"updated_at"`
+}
+
+// ShotClipRenderTimings 单镜头片段渲染的各步骤耗时（毫秒）
+type ShotClipRenderTimings struct {
+	InputsMs int64 `bson:"inputs_ms" json:"inputs_ms"` // 查询和下载图片、音频、字幕
+	SourceMs int64 `bson:"source_ms" json:"source_ms"` // 图生视频（复用缓存时为读取缓存的耗时）
+	EncodeMs int64 `bson:"encode_ms" json:"encode_ms"` // FFmpeg 合成编码
+	UploadMs int64 `bson:"upload_ms" json:"upload_ms"` // 上传片段
+}
+
+// ShotClipPreviewLatencyStats 单镜头「重新生成并预览」的耗时统计
+type ShotClipPreviewLatencyStats struct {
+	Since             time.Time `json:"since"`               // 统计起始时间
+	Count             int       `json:"count"`               // 预览次数
+	P50Ms             int64     `json:"p50_ms"`              // 耗时中位数（毫秒）
+	P95Ms             int64     `json:"p95_ms"`              // 耗时 p95（毫秒）
+	MaxMs             int64     `json:"max_ms"`              // 最长耗时（毫秒）
+	TargetMs          int64     `json:"target_ms"`           // 目标耗时（毫秒）
+	WithinTarget      int       `json:"within_target"`       // 在目标耗时内完成的次数
+	WithinTargetRatio float64   `json:"within_target_ratio"` // 在目标耗时内完成的比例（0~1）
+	SourceReused      int       `json:"source_reused"`       // 复用图生视频缓存的次数
+}
+
+// IsExpired 预览是否已过期
+func (p *ShotClipPreview) IsExpired(now time.Time) bool {
+	return now.After(p.ExpiresAt)
+}
+
+// Collection 返回集合名称
+func (p *ShotClipPreview) Collection() string {
+	return "shot_clip_previews"
+}
+
+// EnsureIndexes 创建和维护索引
+func (p *ShotClipPreview) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(p.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "shot_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_shot_created"),
+		},
+		{
+			// 按时间范围统计预览耗时
+			Keys:    bson.D{{Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_created"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -0,0 +1,105 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// StageRun 章节渲染阶段执行记录
+// 说明：每次执行章节级生成任务（解说/音频/字幕/图片/视频）记录一条，用于汇总章节渲染的耗时与费用明细
+type StageRun struct {
+	ID        string        `bson:"id" json:"id"`                 // 记录ID（UUID）
+	NovelID   string        `bson:"novel_id" json:"novel_id"`     // 关联的小说ID
+	ChapterID string        `bson:"chapter_id" json:"chapter_id"` // 关联的章节ID
+	Stage     PipelineStage `bson:"stage" json:"stage"`           // 阶段
+
+	StartedAt  time.Time `bson:"started_at" json:"started_at"`   // 开始时间
+	FinishedAt time.Time `bson:"finished_at" json:"finished_at"` // 结束时间
+	DurationMs int64     `bson:"duration_ms" json:"duration_ms"` // 耗时（毫秒）
+
+	ProviderCalls   map[string]int `bson:"provider_calls,omitempty" json:"provider_calls,omitempty"` // 按 provider 统计的调用次数
+	CostFen         int64          `bson:"cost_fen" json:"cost_fen"`                                 // 费用（分）
+	BytesUploaded   int64          `bson:"bytes_uploaded" json:"bytes_uploaded"`                     // 上传字节数
+	BytesDownloaded int64          `bson:"bytes_downloaded" json:"bytes_downloaded"`                 // 下载字节数
+
+	ErrorMessage  string    `bson:"error_message,omitempty" json:"error_message,omitempty"`   // 失败原因（为空表示成功）
+	ErrorClass    string    `bson:"error_class,omitempty" json:"error_class,omitempty"`       // 失败类别（provider_timeout/provider_content_policy/ffmpeg_codec/validation_schema/storage_io/unknown）
+	ErrorProvider string    `bson:"error_provider,omitempty" json:"error_provider,omitempty"` // 出错的 provider（无法判断时为空）
+	CreatedAt     time.Time `bson:"created_at" json:"created_at"`
+}
+
+// Succeeded 阶段是否执行成功
+func (r *StageRun) Succeeded() bool {
+	return r.ErrorMessage == ""
+}
+
+// Collection 返回集合名称
+func (r *StageRun) Collection() string {
+	return "stage_runs"
+}
+
+// EnsureIndexes 创建和维护索引
+func (r *StageRun) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(r.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "chapter_id", Value: 1}, {Key: "started_at", Value: -1}},
+			Options: options.Index().SetName("idx_chapter_started"),
+		},
+		{
+			Keys:    bson.D{{Key: "error_class", Value: 1}, {Key: "started_at", Value: -1}},
+			Options: options.Index().SetName("idx_error_class_started").SetSparse(true),
+		},
+		{
+			// 分析数据按（created_at, id）增量导出
+			Keys:    bson.D{{Key: "created_at", Value: 1}, {Key: "id", Value: 1}},
+			Options: options.Index().SetName("idx_created_id"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// RenderBreakdown 章节渲染的耗时与费用明细
+// 每个阶段取最近一次成功的执行记录，合计值为各阶段之和
+type RenderBreakdown struct {
+	Stages []StageBreakdown `bson:"stages" json:"stages"` // 各阶段明细（按流程顺序）
+
+	TotalDurationMs    int64          `bson:"total_duration_ms" json:"total_duration_ms"`                           // 合计耗时（毫秒）
+	TotalCostFen       int64          `bson:"total_cost_fen" json:"total_cost_fen"`                                 // 合计费用（分）
+	TotalProviderCalls map[string]int `bson:"total_provider_calls,omitempty" json:"total_provider_calls,omitempty"` // 合计 provider 调用次数
+	BytesUploaded      int64          `bson:"bytes_uploaded" json:"bytes_uploaded"`                                 // 合计上传字节数
+	BytesDownloaded    int64          `bson:"bytes_downloaded" json:"bytes_downloaded"`                             // 合计下载字节数
+}
+
+// StageBreakdown 单个阶段的耗时与费用
+type StageBreakdown struct {
+	Stage           PipelineStage  `bson:"stage" json:"stage"`
+	StartedAt       time.Time      `bson:"started_at" json:"started_at"`
+	DurationMs      int64          `bson:"duration_ms" json:"duration_ms"`
+	ProviderCalls   map[string]int `bson:"provider_calls,omitempty" json:"provider_calls,omitempty"`
+	CostFen         int64          `bson:"cost_fen" json:"cost_fen"`
+	BytesUploaded   int64          `bson:"bytes_uploaded" json:"bytes_uploaded"`
+	BytesDownloaded int64          `bson:"bytes_downloaded" json:"bytes_downloaded"`
+}
+
+// FailureClassCount 按类别汇总的失败次数
+type FailureClassCount struct {
+	Day      string `bson:"day,omitempty" json:"day,omitempty"`           // 日期（YYYY-MM-DD，UTC），按 provider 汇总时为空
+	Provider string `bson:"provider,omitempty" json:"provider,omitempty"` // provider（按天汇总时为空；无法判断 provider 时为空）
+	Class    string `bson:"class" json:"class"`                           // 失败类别
+	Count    int    `bson:"count" json:"count"`                           // 失败次数
+}
+
+// FailureStats 生成失败的分类统计
+type FailureStats struct {
+	Since      time.Time           `json:"since"`       // 统计起始时间
+	Total      int                 `json:"total"`       // 失败总次数
+	ByClass    map[string]int      `json:"by_class"`    // 按类别汇总
+	ByDay      []FailureClassCount `json:"by_day"`      // 按天和类别汇总（按日期升序）
+	ByProvider []FailureClassCount `json:"by_provider"` // 按 provider 和类别汇总（按次数降序）
+}
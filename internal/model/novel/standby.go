@@ -0,0 +1,71 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// StandbySettings 小说的预生成设置
+// 开启后，第 N 章的最终视频生成完成（进入审核）时，自动为第 N+1 章预生成低成本产物
+type StandbySettings struct {
+	Enabled bool           `bson:"enabled" json:"enabled"`                   // 是否在最终视频生成完成后自动预生成下一章
+	Stages  []StandbyStage `bson:"stages,omitempty" json:"stages,omitempty"` // 预生成的阶段，为空表示所有阶段
+}
+
+// StandbyJob 预生成任务实体
+// 说明：编辑审核第 N 章时，提前为第 N+1 章生成解说草稿和图片提示词等低成本产物（不调用付费的图片/视频生成），缩短审核到渲染的等待时间
+type StandbyJob struct {
+	ID              string         `bson:"id" json:"id"`                                         // 任务ID（UUID）
+	NovelID         string         `bson:"novel_id" json:"novel_id"`                             // 关联的小说ID
+	SourceChapterID string         `bson:"source_chapter_id" json:"source_chapter_id"`           // 审核中的章节ID（第 N 章）
+	ChapterID       string         `bson:"chapter_id" json:"chapter_id"`                         // 预生成的章节ID（第 N+1 章）
+	Stages          []StandbyStage `bson:"stages" json:"stages"`                                 // 预生成的阶段（按执行顺序）
+	TriggeredBy     string         `bson:"triggered_by,omitempty" json:"triggered_by,omitempty"` // 触发人用户ID，自动触发时为空
+
+	Status       StandbyStatus        `bson:"status" json:"status"`                                   // 状态：running, completed, failed, canceled
+	StageResults []StandbyStageResult `bson:"stage_results" json:"stage_results"`                     // 各阶段的执行结果
+	NarrationID  string               `bson:"narration_id,omitempty" json:"narration_id,omitempty"`   // 预生成的解说ID
+	ErrorMessage string               `bson:"error_message,omitempty" json:"error_message,omitempty"` // 失败原因
+
+	CompletedAt *time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+// StandbyStageResult 预生成阶段的执行结果
+type StandbyStageResult struct {
+	Stage      StandbyStage  `bson:"stage" json:"stage"`
+	Status     StandbyStatus `bson:"status" json:"status"`                     // completed, skipped, failed, canceled
+	Reason     string        `bson:"reason,omitempty" json:"reason,omitempty"` // 跳过原因或失败原因
+	DurationMs int64         `bson:"duration_ms" json:"duration_ms"`           // 耗时（毫秒）
+}
+
+// Collection 返回集合名称
+func (j *StandbyJob) Collection() string {
+	return "standby_jobs"
+}
+
+// EnsureIndexes 创建和维护索引
+func (j *StandbyJob) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(j.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "novel_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_novel_created"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}},
+			Options: options.Index().SetName("idx_status"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
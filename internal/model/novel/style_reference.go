@@ -0,0 +1,47 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// StyleReference 风格参考图实体
+// 说明：美术设定图，用于引导生成图片的构图和配色
+// ChapterID 为空表示小说级参考图（作用于整本小说）；章节设置了参考图时优先使用章节级参考图
+type StyleReference struct {
+	ID          string     `bson:"id" json:"id"`                                       // 参考图ID（UUID）
+	NovelID     string     `bson:"novel_id" json:"novel_id"`                           // 关联的小说ID
+	ChapterID   string     `bson:"chapter_id,omitempty" json:"chapter_id,omitempty"`   // 关联的章节ID（为空表示小说级）
+	UserID      string     `bson:"user_id" json:"user_id"`                             // 上传用户ID
+	ResourceID  string     `bson:"resource_id" json:"resource_id"`                     // 参考图文件的 resource_id
+	Description string     `bson:"description,omitempty" json:"description,omitempty"` // 说明（如 "整体色调偏冷，远景构图"）
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `bson:"updated_at" json:"updated_at"`
+	DeletedAt   *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
+
+// Collection 返回集合名称
+func (r *StyleReference) Collection() string {
+	return "style_references"
+}
+
+// EnsureIndexes 创建和维护索引
+func (r *StyleReference) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(r.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "novel_id", Value: 1}, {Key: "chapter_id", Value: 1}, {Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("idx_novel_chapter_created"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
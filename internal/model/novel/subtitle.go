@@ -17,12 +17,16 @@ type Subtitle struct {
 	NarrationID string `bson:"narration_id" json:"narration_id"` // 关联的解说ID
 	NovelID     string `bson:"novel_id" json:"novel_id"`         // 关联的小说ID
 	UserID      string `bson:"user_id" json:"user_id"`           // 用户ID
+	ShotID      string `bson:"shot_id,omitempty" json:"shot_id,omitempty"` // 关联的镜头ID（与对应音频片段一致）
 	Sequence           int        `bson:"sequence" json:"sequence"`                         // 序号（对应 shot 的顺序，从1开始）
 	SubtitleResourceID string     `bson:"subtitle_resource_id" json:"subtitle_resource_id"` // 字幕文件的 resource_id
 	Format             SubtitleFormat `bson:"format" json:"format"`                             // 字幕格式：ass, srt, vtt
 	Prompt             string         `bson:"prompt,omitempty" json:"prompt,omitempty"`         // 生成字幕时使用的提示词/参数（字幕生成参数配置）
 	Version            int            `bson:"version" json:"version"`                           // 版本号（用于支持多版本，默认 1）
 	Status             TaskStatus     `bson:"status" json:"status"`                             // 状态：pending, completed, failed
+	ReusedFromID       string         `bson:"reused_from_id,omitempty" json:"reused_from_id,omitempty"` // 复用的上一版本字幕ID（增量重新生成时音频未变化的片段，与其共用字幕文件）
+	Source             SubtitleSource `bson:"source,omitempty" json:"source,omitempty"`         // 来源：为空表示生成的字幕，imported 表示导入的外部字幕
+	SourceResourceID   string         `bson:"source_resource_id,omitempty" json:"source_resource_id,omitempty"` // 导入时上传的原始字幕文件的 resource_id
 	CreatedAt          time.Time  `bson:"created_at" json:"created_at"`
 	UpdatedAt          time.Time  `bson:"updated_at" json:"updated_at"`
 	DeletedAt          *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
@@ -53,6 +57,10 @@ func (s *Subtitle) EnsureIndexes(ctx context.Context, db *mongo.Database) error
 			Keys:    bson.D{{Key: "narration_id", Value: 1}, {Key: "sequence", Value: 1}},
 			Options: options.Index().SetName("idx_narration_sequence"),
 		},
+		{
+			Keys:    bson.D{{Key: "shot_id", Value: 1}},
+			Options: options.Index().SetName("idx_shot_id"),
+		},
 		{
 			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
 			Options: options.Index().SetName("idx_user_created"),
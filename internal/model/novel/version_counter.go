@@ -0,0 +1,48 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// VersionArtifact 按章节分配版本号的产物类型
+type VersionArtifact string
+
+const (
+	VersionArtifactNarration VersionArtifact = "narration" // 解说（含场景、镜头）
+	VersionArtifactAudio     VersionArtifact = "audio"     // 音频
+	VersionArtifactSubtitle  VersionArtifact = "subtitle"  // 字幕
+	VersionArtifactImage     VersionArtifact = "image"     // 图片
+	VersionArtifactVideo     VersionArtifact = "video"     // 视频（解说视频和最终视频共用）
+)
+
+// VersionCounter 章节产物版本号计数器
+// 说明：每个章节的每类产物一条记录，通过原子自增分配版本号，避免并发生成时读取最大版本号再写入导致版本号重复
+type VersionCounter struct {
+	ChapterID string          `bson:"chapter_id" json:"chapter_id"` // 章节ID
+	Artifact  VersionArtifact `bson:"artifact" json:"artifact"`     // 产物类型
+	Value     int             `bson:"value" json:"value"`           // 最近一次分配的版本号
+	UpdatedAt time.Time       `bson:"updated_at" json:"updated_at"`
+}
+
+// Collection 返回集合名称
+func (c *VersionCounter) Collection() string {
+	return "version_counters"
+}
+
+// EnsureIndexes 创建和维护索引
+func (c *VersionCounter) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(c.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "chapter_id", Value: 1}, {Key: "artifact", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_chapter_artifact_unique"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -17,19 +17,59 @@ type Video struct {
 	NarrationID string `bson:"narration_id,omitempty" json:"narration_id,omitempty"` // 关联的解说ID（可选，final_video 没有 narration_id）
 	NovelID     string `bson:"novel_id" json:"novel_id"`                             // 关联的小说ID
 	UserID      string `bson:"user_id" json:"user_id"`                               // 用户ID
+	ShotID      string `bson:"shot_id,omitempty" json:"shot_id,omitempty"`           // 关联的镜头ID（仅单镜头的 narration_video；合并片段为空）
 	Sequence        int        `bson:"sequence" json:"sequence"`                               // 视频片段序号（从1开始）
+	SequenceEnd     int        `bson:"sequence_end,omitempty" json:"sequence_end,omitempty"`   // 片段覆盖的最后一个镜头序号（合并片段如 narration_01-03 为 3；为空表示只覆盖 Sequence 对应的镜头）
+	MergeRule       ClipMergeMode `bson:"merge_rule,omitempty" json:"merge_rule,omitempty"`     // 生成时使用的镜头合并规则（仅 narration_video；合并片段的成员镜头为 Sequence~SequenceEnd）
 	VideoResourceID string     `bson:"video_resource_id" json:"video_resource_id"`             // 视频文件的 resource_id
 	Duration        float64    `bson:"duration" json:"duration"`                               // 视频时长（秒）
 	VideoType       VideoType   `bson:"video_type" json:"video_type"`                           // 视频类型：narration_video, final_video
 	Prompt          string      `bson:"prompt,omitempty" json:"prompt,omitempty"`               // 生成视频时使用的提示词/参数
 	Version         int         `bson:"version" json:"version"`                                 // 版本号（用于支持多版本，默认 1）
 	Status          VideoStatus `bson:"status" json:"status"`                                   // 状态：pending, processing, completed, failed
+	ExportTier      ExportTier  `bson:"export_tier,omitempty" json:"export_tier,omitempty"`     // 导出档位（仅 final_video）：preview, licensed
+	Platform        TargetPlatform `bson:"platform,omitempty" json:"platform,omitempty"`      // 目标发布平台（字幕和水印按平台安全区排版）
+	SubtitleStyle   *SubtitleStyle `bson:"subtitle_style,omitempty" json:"subtitle_style,omitempty"` // 烧录字幕时覆盖的字幕样式（生成请求指定时记录，为空表示使用字幕文件自带的样式）
+	LastFrameResourceID string `bson:"last_frame_resource_id,omitempty" json:"last_frame_resource_id,omitempty"` // 最后一帧截图的 resource_id（仅 final_video，下一章衔接时按需提取）
+	RenderBreakdown *RenderBreakdown `bson:"render_breakdown,omitempty" json:"render_breakdown,omitempty"` // 章节渲染的耗时与费用明细（仅 final_video）
+	Compliance      *ComplianceReport `bson:"compliance,omitempty" json:"compliance,omitempty"` // 平台规范校验报告（仅 final_video）
+	ManifestResourceID string `bson:"manifest_resource_id,omitempty" json:"manifest_resource_id,omitempty"` // 流水线清单（JSON）的 resource_id（仅 final_video）
+	ManifestSHA256     string `bson:"manifest_sha256,omitempty" json:"manifest_sha256,omitempty"`           // 流水线清单内容的 SHA256
+	ThumbnailSpriteResourceID string `bson:"thumbnail_sprite_resource_id,omitempty" json:"thumbnail_sprite_resource_id,omitempty"` // 缩略图雪碧图（JPEG）的 resource_id，供编辑器拖动预览
+	ThumbnailVTTResourceID    string `bson:"thumbnail_vtt_resource_id,omitempty" json:"thumbnail_vtt_resource_id,omitempty"`       // 雪碧图 WebVTT 索引的 resource_id
+	TranscriptResourceID       string `bson:"transcript_resource_id,omitempty" json:"transcript_resource_id,omitempty"`               // 纯文本文字稿的 resource_id（仅 final_video）
+	AudioDescriptionResourceID string `bson:"audio_description_resource_id,omitempty" json:"audio_description_resource_id,omitempty"` // 口述影像音轨（AAC，与最终视频等长）的 resource_id
+	DescriptionVTTResourceID   string `bson:"description_vtt_resource_id,omitempty" json:"description_vtt_resource_id,omitempty"`     // 口述影像 WebVTT 描述轨的 resource_id
+	Attribution                *AttributionRecord `bson:"attribution,omitempty" json:"attribution,omitempty"`                             // 渲染的原作署名（仅 final_video，用于合规审计）
+	ChapterMarkers             []VideoChapterMarker `bson:"chapter_markers,omitempty" json:"chapter_markers,omitempty"`                 // 各章节在视频中的位置（仅 novel_video，同时写入 MP4 章节标记）
+	JobID           string     `bson:"job_id,omitempty" json:"job_id,omitempty"`               // 执行拼接的任务ID（仅 novel_video，可通过任务接口查询、取消和重试）
 	ErrorMessage    string     `bson:"error_message,omitempty" json:"error_message,omitempty"` // 错误信息
+	Stale           bool       `bson:"stale,omitempty" json:"stale,omitempty"`               // 是否已过期（依赖的音频片段已重新合成，需要重新生成）
+	StaleReason     string     `bson:"stale_reason,omitempty" json:"stale_reason,omitempty"` // 过期原因
 	CreatedAt       time.Time  `bson:"created_at" json:"created_at"`
 	UpdatedAt       time.Time  `bson:"updated_at" json:"updated_at"`
 	DeletedAt       *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
 }
 
+// VideoChapterMarker 长视频中一个章节的位置
+type VideoChapterMarker struct {
+	ChapterID       string  `bson:"chapter_id" json:"chapter_id"`
+	ChapterSequence int     `bson:"chapter_sequence" json:"chapter_sequence"`
+	Title           string  `bson:"title" json:"title"`
+	SourceVideoID   string  `bson:"source_video_id" json:"source_video_id"` // 使用的章节最终视频ID（发布版本）
+	Start           float64 `bson:"start" json:"start"`                     // 开始时间（秒，含标题画面）
+	End             float64 `bson:"end" json:"end"`                         // 结束时间（秒）
+}
+
+// SequenceRange 返回视频片段覆盖的镜头序号范围 [start, end]
+// 单镜头片段的 start 与 end 相同；合并片段通过 SequenceEnd 引用成员镜头，不再重复记录成员镜头的内容
+func (v *Video) SequenceRange() (start, end int) {
+	if v.SequenceEnd > v.Sequence {
+		return v.Sequence, v.SequenceEnd
+	}
+	return v.Sequence, v.Sequence
+}
+
 // Collection 返回集合名称
 func (v *Video) Collection() string {
 	return "videos"
@@ -51,13 +91,25 @@ func (v *Video) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
 			Keys:    bson.D{{Key: "narration_id", Value: 1}},
 			Options: options.Index().SetName("idx_narration_id"),
 		},
+		{
+			Keys:    bson.D{{Key: "shot_id", Value: 1}},
+			Options: options.Index().SetName("idx_shot_id"),
+		},
 		{
 			Keys:    bson.D{{Key: "chapter_id", Value: 1}, {Key: "video_type", Value: 1}},
 			Options: options.Index().SetName("idx_chapter_video_type"),
 		},
 		{
-			Keys:    bson.D{{Key: "status", Value: 1}},
-			Options: options.Index().SetName("idx_status"),
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "updated_at", Value: 1}},
+			Options: options.Index().SetName("idx_status_updated"),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "updated_at", Value: 1}, {Key: "id", Value: 1}},
+			Options: options.Index().SetName("idx_user_updated"),
+		},
+		{
+			Keys:    bson.D{{Key: "chapter_id", Value: 1}, {Key: "updated_at", Value: 1}, {Key: "id", Value: 1}},
+			Options: options.Index().SetName("idx_chapter_updated"),
 		},
 		{
 			Keys:    bson.D{{Key: "chapter_id", Value: 1}, {Key: "version", Value: 1}},
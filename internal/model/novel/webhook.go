@@ -0,0 +1,140 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WebhookEvent 任务通知事件类型
+type WebhookEvent string
+
+const (
+	WebhookEventTaskCompleted WebhookEvent = "task.completed" // 生成任务完成
+	WebhookEventTaskFailed    WebhookEvent = "task.failed"    // 生成任务失败
+)
+
+// String 返回字符串表示
+func (e WebhookEvent) String() string {
+	return string(e)
+}
+
+// Webhook 任务通知订阅实体
+// 说明：用户注册的通知地址，名下章节的生成任务（解说、音频、字幕、图片、解说视频、最终视频）完成或失败时推送签名的 JSON
+type Webhook struct {
+	ID        string          `bson:"id" json:"id"`                                     // 订阅ID（UUID）
+	UserID    string          `bson:"user_id" json:"user_id"`                           // 所属用户ID
+	URL       string          `bson:"url" json:"url"`                                   // 通知地址
+	Secret    string          `bson:"secret" json:"-"`                                  // 签名密钥（只在创建时返回）
+	TaskTypes []PipelineStage `bson:"task_types,omitempty" json:"task_types,omitempty"` // 订阅的任务类型（为空表示全部）
+	Enabled   bool            `bson:"enabled" json:"enabled"`                           // 是否启用
+	CreatedAt time.Time       `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time       `bson:"updated_at" json:"updated_at"`
+}
+
+// Accepts 是否订阅了该任务类型
+func (w *Webhook) Accepts(taskType PipelineStage) bool {
+	if len(w.TaskTypes) == 0 {
+		return true
+	}
+	for _, t := range w.TaskTypes {
+		if t == taskType {
+			return true
+		}
+	}
+	return false
+}
+
+// Collection 返回集合名称
+func (w *Webhook) Collection() string {
+	return "webhooks"
+}
+
+// EnsureIndexes 创建和维护索引
+func (w *Webhook) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(w.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_user_created"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// WebhookPayload 任务通知内容
+// 说明：预算通知（事件类型为 budget.warning / budget.exceeded）同样签名和重试，Budget 非空，发送到小说预算设置的通知地址
+type WebhookPayload struct {
+	Event       WebhookEvent  `bson:"event" json:"event"`                                   // 事件类型
+	TaskType    PipelineStage `bson:"task_type" json:"task_type"`                           // 任务类型
+	NovelID     string        `bson:"novel_id" json:"novel_id"`                             // 小说ID
+	ChapterID   string        `bson:"chapter_id" json:"chapter_id"`                         // 章节ID
+	ResourceID  string        `bson:"resource_id,omitempty" json:"resource_id,omitempty"`   // 生成结果ID（多个结果时为第一个）
+	ResourceIDs []string      `bson:"resource_ids,omitempty" json:"resource_ids,omitempty"` // 生成结果ID列表
+	RunID       string        `bson:"run_id" json:"run_id"`                                 // 阶段执行记录ID
+	Status      string        `bson:"status" json:"status"`                                 // 任务状态：completed, failed
+	Error       string        `bson:"error,omitempty" json:"error,omitempty"`               // 失败原因
+	ErrorClass  string        `bson:"error_class,omitempty" json:"error_class,omitempty"`   // 失败类别
+	DurationMs  int64         `bson:"duration_ms" json:"duration_ms"`                       // 任务耗时（毫秒）
+	OccurredAt  time.Time     `bson:"occurred_at" json:"occurred_at"`                       // 任务结束时间
+	Budget      *BudgetEvent  `bson:"budget,omitempty" json:"budget,omitempty"`             // 预算事件（预算通知时有效）
+}
+
+// WebhookAttempt 一次投递尝试
+type WebhookAttempt struct {
+	At         time.Time `bson:"at" json:"at"`                                       // 尝试时间
+	StatusCode int       `bson:"status_code,omitempty" json:"status_code,omitempty"` // 响应状态码（请求未发出时为空）
+	Response   string    `bson:"response,omitempty" json:"response,omitempty"`       // 响应体（截断）
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`             // 失败原因
+	DurationMs int64     `bson:"duration_ms" json:"duration_ms"`                     // 请求耗时（毫秒）
+}
+
+// WebhookDelivery 任务通知投递记录
+// 说明：每次通知一条记录，失败后按退避时间重试，每次尝试追加到 Attempts
+type WebhookDelivery struct {
+	ID            string           `bson:"id" json:"id"`                                               // 投递ID（UUID，请求头 X-Lemon-Delivery）
+	WebhookID     string           `bson:"webhook_id" json:"webhook_id"`                               // 订阅ID（预算通知为空）
+	UserID        string           `bson:"user_id" json:"user_id"`                                     // 所属用户ID
+	Payload       *WebhookPayload  `bson:"payload" json:"payload"`                                     // 通知内容
+	Status        WebhookStatus    `bson:"status" json:"status"`                                       // 投递状态：pending, sent, failed
+	AttemptCount  int              `bson:"attempt_count" json:"attempt_count"`                         // 已尝试次数
+	Attempts      []WebhookAttempt `bson:"attempts,omitempty" json:"attempts,omitempty"`               // 尝试记录
+	NextAttemptAt *time.Time       `bson:"next_attempt_at,omitempty" json:"next_attempt_at,omitempty"` // 下一次重试时间（pending 时有效）
+	DeliveredAt   *time.Time       `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`       // 投递成功时间
+	CreatedAt     time.Time        `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time        `bson:"updated_at" json:"updated_at"`
+}
+
+// Collection 返回集合名称
+func (d *WebhookDelivery) Collection() string {
+	return "webhook_deliveries"
+}
+
+// EnsureIndexes 创建和维护索引
+func (d *WebhookDelivery) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(d.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "webhook_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_webhook_created"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+			Options: options.Index().SetName("idx_status_next_attempt"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -0,0 +1,63 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WorkflowTemplate 工作流模板
+// 说明：按内容风格（快节奏解说、深度剧情向、英文配音版等）打包的一组流程配置，创建小说时选用后复制到小说上；
+// 内置模板由代码提供（Builtin 为 true），自定义模板保存在 workflow_templates 集合；字段为空表示沿用用户的创作默认配置
+type WorkflowTemplate struct {
+	ID          string `bson:"id" json:"id,omitempty"`                             // 模板ID（UUID，内置模板为空）
+	Name        string `bson:"name" json:"name"`                                   // 模板名称（唯一，小写字母开头的 snake_case，创建小说时用于选择模板）
+	DisplayName string `bson:"display_name" json:"display_name"`                   // 展示名称
+	Description string `bson:"description,omitempty" json:"description,omitempty"` // 说明
+	Builtin     bool   `bson:"-" json:"builtin"`                                   // 是否为内置模板（不可修改或删除）
+	CreatedBy   string `bson:"created_by,omitempty" json:"created_by,omitempty"`   // 创建人用户ID
+
+	NarrationType NarrationType       `bson:"narration_type,omitempty" json:"narration_type,omitempty"` // 旁白类型（创建请求未指定时使用）
+	Style         NovelStyle          `bson:"style,omitempty" json:"style,omitempty"`                   // 剧本风格（创建请求未指定时使用）
+	Structure     *NarrationStructure `bson:"structure,omitempty" json:"structure,omitempty"`           // 解说结构模板（场景数、镜头数、目标时长）
+	Voice         *VoiceSettings      `bson:"voice,omitempty" json:"voice,omitempty"`                   // 配音（音色、语速）
+	StylePreset   ImageStylePreset    `bson:"style_preset,omitempty" json:"style_preset,omitempty"`     // 图片风格预设
+	Video         *VideoProfile       `bson:"video,omitempty" json:"video,omitempty"`                   // 视频规格（分辨率、帧率）
+	Platforms     []TargetPlatform    `bson:"platforms,omitempty" json:"platforms,omitempty"`           // 目标发布平台（第一个为小说的默认平台）
+	Metadata      *CreativeMetadata   `bson:"metadata,omitempty" json:"metadata,omitempty"`             // 创作设定（基调、受众等，生成提示词时注入）
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at,omitempty"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at,omitempty"`
+}
+
+// VideoProfile 视频规格
+type VideoProfile struct {
+	Width  int `bson:"width,omitempty" json:"width,omitempty"`   // 宽度（像素）
+	Height int `bson:"height,omitempty" json:"height,omitempty"` // 高度（像素）
+	FPS    int `bson:"fps,omitempty" json:"fps,omitempty"`       // 帧率
+}
+
+// Collection 返回集合名称
+func (t *WorkflowTemplate) Collection() string {
+	return "workflow_templates"
+}
+
+// EnsureIndexes 创建和维护索引
+func (t *WorkflowTemplate) EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection(t.Collection())
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_name_unique"),
+		},
+	}
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -22,9 +22,13 @@ type Resource struct {
 	Description string `bson:"description,omitempty" json:"description,omitempty"`   // 描述
 
 	// 存储信息
-	StorageKey  string `bson:"storage_key" json:"storage_key"`                     // 存储路径（key）
-	StorageURL  string `bson:"storage_url,omitempty" json:"storage_url,omitempty"` // 存储URL（临时访问）
-	StorageType string `bson:"storage_type" json:"storage_type"`                   // 存储类型（local/oss/s3/minio）
+	// 注意：预签名URL会过期，不保存在记录中，需要访问时通过 ResourceService 按 StorageKey 生成
+	StorageKey  string `bson:"storage_key" json:"storage_key"`   // 存储路径（key）
+	StorageType string `bson:"storage_type" json:"storage_type"` // 存储类型（local/oss/s3/minio）
+
+	// 存储路径来源（用于追溯存储路径是按哪个模板生成的）
+	ArtifactType string `bson:"artifact_type,omitempty" json:"artifact_type,omitempty"` // 产物类型（如 audio、subtitle、image、clip、final_video）
+	KeyTemplate  string `bson:"key_template,omitempty" json:"key_template,omitempty"`   // 生成存储路径使用的模板
 
 	// 文件信息
 	FileSize    int64  `bson:"file_size" json:"file_size"`               // 文件大小（字节）
@@ -32,6 +36,10 @@ type Resource struct {
 	MD5         string `bson:"md5,omitempty" json:"md5,omitempty"`       // 文件MD5值（用于去重）
 	SHA256      string `bson:"sha256,omitempty" json:"sha256,omitempty"` // 文件SHA256值
 
+	// 下载校验清单（首次请求时计算并保存，用于断点续传时校验已下载的分块）
+	ChunkSize   int64    `bson:"chunk_size,omitempty" json:"chunk_size,omitempty"`     // 分块大小（字节）
+	ChunkSHA256 []string `bson:"chunk_sha256,omitempty" json:"chunk_sha256,omitempty"` // 各分块的SHA256值
+
 	// 元数据
 	Metadata map[string]interface{} `bson:"metadata,omitempty" json:"metadata,omitempty"` // 扩展元数据
 	Tags     []string               `bson:"tags,omitempty" json:"tags,omitempty"`         // 标签
@@ -48,6 +56,10 @@ type Resource struct {
 	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`                     // 创建时间
 	UpdatedAt  time.Time  `bson:"updated_at" json:"updated_at"`                     // 更新时间
 	DeletedAt  *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"` // 软删除时间
+
+	// 资源回收（没有被业务记录引用的资源先标记删除，宽限期过后从存储中删除）
+	GCMarkedAt       *time.Time     `bson:"gc_marked_at,omitempty" json:"gc_marked_at,omitempty"`             // 回收标记时间
+	GCPreviousStatus ResourceStatus `bson:"gc_previous_status,omitempty" json:"gc_previous_status,omitempty"` // 标记前的状态（重新被引用时恢复）
 }
 
 // ResourceStatus 资源状态
@@ -73,7 +85,7 @@ type UploadSession struct {
 	Ext         string `bson:"ext" json:"ext"` // 文件扩展名（不含点号，如：txt、pdf、jpg、mp4等）
 
 	// 客户端上传相关
-	UploadURL string    `bson:"upload_url" json:"upload_url"` // 客户端上传URL（预签名URL）
+	// 预签名上传URL只在准备上传时返回给客户端，不保存在会话中
 	UploadKey string    `bson:"upload_key" json:"upload_key"` // 上传路径（key）
 	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"` // 上传URL过期时间
 
@@ -129,6 +141,10 @@ func (r *Resource) EnsureIndexes(ctx context.Context, db *mongo.Database) error
 			Keys:    bson.D{bson.E{Key: "ext", Value: 1}},
 			Options: options.Index().SetName("idx_ext"),
 		},
+		{
+			Keys:    bson.D{bson.E{Key: "gc_marked_at", Value: 1}},
+			Options: options.Index().SetName("idx_gc_marked_at").SetSparse(true),
+		},
 	}
 
 	if len(indexes) == 0 {
@@ -10,36 +10,54 @@ import (
 
 	"github.com/volcengine/volcengine-go-sdk/service/arkruntime"
 	"github.com/volcengine/volcengine-go-sdk/service/arkruntime/model"
+
+	"lemon/internal/pkg/environment"
+	"lemon/internal/pkg/tasklog"
 )
 
 // ArkImageConfig Ark 图片生成配置
 type ArkImageConfig struct {
-	APIKey  string // API Key（必需）
-	BaseURL string // API 基础 URL（可选，默认: https://ark.cn-beijing.volces.com/api/v3）
-	Model   string // 模型名称（可选，默认: doubao-seedream-3-0-t2i-250415）
+	APIKey         string // API Key（必需）
+	BaseURL        string // API 基础 URL（可选，默认: https://ark.cn-beijing.volces.com/api/v3）
+	Model          string // 模型名称（可选，默认: doubao-seedream-3-0-t2i-250415）
+	EditModel      string // 图生图模型名称（可选，默认: doubao-seededit-3-0-i2i-250628）
+	ReferenceModel string // 风格参考图生成模型名称（可选，默认: doubao-seedream-4-0-250828，支持多张参考图）
 }
 
 // ArkImageConfigFromEnv 从环境变量创建 Ark 图片生成配置
+// ARK_API_KEY、ARK_BASE_URL 按部署环境读取（优先 ARK_API_KEY_<ENV>，见 environment.Getenv）
 // 支持的环境变量：
 //   - ARK_API_KEY: API Key（必需，用于图片生成）
 //   - ARK_IMAGE_MODEL: 图片生成模型名称（可选，默认: doubao-seedream-3-0-t2i-250415）
+//   - ARK_IMAGE_EDIT_MODEL: 图生图模型名称（可选，默认: doubao-seededit-3-0-i2i-250628）
+//   - ARK_IMAGE_REFERENCE_MODEL: 风格参考图生成模型名称（可选，默认: doubao-seedream-4-0-250828）
 //   - ARK_BASE_URL: API 基础 URL（可选，默认: https://ark.cn-beijing.volces.com/api/v3）
 func ArkImageConfigFromEnv() *ArkImageConfig {
-	apiKey := os.Getenv("ARK_API_KEY")
+	apiKey := environment.Getenv("ARK_API_KEY")
 	model := os.Getenv("ARK_IMAGE_MODEL")
-	baseURL := os.Getenv("ARK_BASE_URL")
+	editModel := os.Getenv("ARK_IMAGE_EDIT_MODEL")
+	referenceModel := os.Getenv("ARK_IMAGE_REFERENCE_MODEL")
+	baseURL := environment.Getenv("ARK_BASE_URL")
 
 	if model == "" {
 		model = "doubao-seedream-3-0-t2i-250415" // 默认图片生成模型
 	}
+	if editModel == "" {
+		editModel = "doubao-seededit-3-0-i2i-250628" // 默认图生图模型
+	}
+	if referenceModel == "" {
+		referenceModel = "doubao-seedream-4-0-250828" // 默认风格参考图生成模型
+	}
 	if baseURL == "" {
 		baseURL = "https://ark.cn-beijing.volces.com/api/v3"
 	}
 
 	return &ArkImageConfig{
-		APIKey:  apiKey,
-		BaseURL: baseURL,
-		Model:   model,
+		APIKey:         apiKey,
+		BaseURL:        baseURL,
+		Model:          model,
+		EditModel:      editModel,
+		ReferenceModel: referenceModel,
 	}
 }
 
@@ -47,8 +65,10 @@ func ArkImageConfigFromEnv() *ArkImageConfig {
 // 用于调用火山引擎的 Ark API 生成图片
 // 参考 Python SDK: volcenginesdkarkruntime.Ark().images.generate()
 type ArkImageClient struct {
-	client *arkruntime.Client
-	model  string
+	client         *arkruntime.Client
+	model          string
+	editModel      string
+	referenceModel string
 }
 
 // NewArkImageClient 创建 Ark 图片生成客户端
@@ -67,11 +87,18 @@ func NewArkImageClient(config *ArkImageConfig) (*ArkImageClient, error) {
 	arkClient := arkruntime.NewClientWithApiKey(config.APIKey, opts...)
 
 	return &ArkImageClient{
-		client: arkClient,
-		model:  config.Model,
+		client:         arkClient,
+		model:          config.Model,
+		editModel:      config.EditModel,
+		referenceModel: config.ReferenceModel,
 	}, nil
 }
 
+// Model 返回文生图模型名称
+func (c *ArkImageClient) Model() string {
+	return c.model
+}
+
 // GenerateImage 生成图片（同步接口）
 // 对应 Python SDK: client.images.generate()
 func (c *ArkImageClient) GenerateImage(ctx context.Context, prompt string, size string, watermark bool) ([]byte, error) {
@@ -91,10 +118,66 @@ func (c *ArkImageClient) GenerateImage(ctx context.Context, prompt string, size
 		Watermark:      &watermark,
 	}
 
+	return c.generateImages(ctx, input)
+}
+
+// GenerateImageFromImage 图生图（同步接口）
+// 以参考图片为基础，按 prompt 对画面进行编辑（如改变光照、天气），保持构图和主体一致
+// imageDataURL 为参考图片的 data URL（base64 编码）或可公网访问的 URL
+func (c *ArkImageClient) GenerateImageFromImage(ctx context.Context, imageDataURL, prompt string, size string, watermark bool) ([]byte, error) {
+	// 设置默认值：图生图默认与参考图片保持相同的尺寸
+	if size == "" {
+		size = "adaptive"
+	}
+
+	responseFormat := "b64_json"
+
+	input := model.GenerateImagesRequest{
+		Model:          c.editModel,
+		Prompt:         prompt,
+		Image:          imageDataURL,
+		Size:           &size,
+		ResponseFormat: &responseFormat,
+		Watermark:      &watermark,
+	}
+
+	return c.generateImages(ctx, input)
+}
+
+// GenerateImageWithReferences 带风格参考图的文生图（同步接口）
+// 参考图用于引导构图和配色，画面内容仍由 prompt 决定
+// referenceURLs 为参考图片的 data URL（base64 编码）或可公网访问的 URL，可传多张
+func (c *ArkImageClient) GenerateImageWithReferences(ctx context.Context, prompt string, referenceURLs []string, size string, watermark bool) ([]byte, error) {
+	if len(referenceURLs) == 0 {
+		return c.GenerateImage(ctx, prompt, size, watermark)
+	}
+
+	// 设置默认值
+	if size == "" {
+		size = "720x1280"
+	}
+
+	responseFormat := "b64_json"
+
+	input := model.GenerateImagesRequest{
+		Model:          c.referenceModel,
+		Prompt:         prompt,
+		Image:          referenceURLs,
+		Size:           &size,
+		ResponseFormat: &responseFormat,
+		Watermark:      &watermark,
+	}
+
+	return c.generateImages(ctx, input)
+}
+
+// generateImages 调用 GenerateImages API 并解码第一张图片
+func (c *ArkImageClient) generateImages(ctx context.Context, input model.GenerateImagesRequest) ([]byte, error) {
 	// 调用 API（使用 Go SDK 的实际方法名）
 	output, err := c.client.GenerateImages(ctx, input)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to call Ark GenerateImages API")
+		tasklog.FromContext(ctx).Error("Ark 图片生成请求失败", tasklog.Fields{"model": input.Model, "error": err.Error()})
 		return nil, fmt.Errorf("Ark GenerateImages API call failed: %w", err)
 	}
 
@@ -103,6 +186,8 @@ func (c *ArkImageClient) GenerateImage(ctx context.Context, prompt string, size
 		return nil, fmt.Errorf("no image data in response")
 	}
 
+	tasklog.FromContext(ctx).Info("Ark 图片生成请求完成", tasklog.Fields{"model": input.Model, "images": len(output.Data)})
+
 	// 获取第一张图片的 base64 数据
 	firstImage := output.Data[0]
 	if firstImage.B64Json == nil {
@@ -15,6 +15,9 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/volcengine/volcengine-go-sdk/service/arkruntime"
+
+	"lemon/internal/pkg/environment"
+	"lemon/internal/pkg/tasklog"
 )
 
 // ArkVideoConfig Ark 视频生成配置
@@ -25,14 +28,15 @@ type ArkVideoConfig struct {
 }
 
 // ArkVideoConfigFromEnv 从环境变量创建 Ark 视频生成配置
+// ARK_API_KEY、ARK_BASE_URL 按部署环境读取（优先 ARK_API_KEY_<ENV>，见 environment.Getenv）
 // 支持的环境变量：
 //   - ARK_API_KEY: API Key（必需，用于视频生成）
 //   - ARK_VIDEO_MODEL: 视频生成模型名称（可选，默认: doubao-seedance-1-0-lite-i2v-250428）
 //   - ARK_BASE_URL: API 基础 URL（可选，默认: https://ark.cn-beijing.volces.com/api/v3）
 func ArkVideoConfigFromEnv() *ArkVideoConfig {
-	apiKey := os.Getenv("ARK_API_KEY")
+	apiKey := environment.Getenv("ARK_API_KEY")
 	model := os.Getenv("ARK_VIDEO_MODEL")
-	baseURL := os.Getenv("ARK_BASE_URL")
+	baseURL := environment.Getenv("ARK_BASE_URL")
 
 	if model == "" {
 		model = "doubao-seedance-1-0-lite-i2v-250428" // 默认视频生成模型
@@ -81,6 +85,11 @@ func NewArkVideoClient(config *ArkVideoConfig) (*ArkVideoClient, error) {
 	}, nil
 }
 
+// Model 返回视频生成模型名称
+func (c *ArkVideoClient) Model() string {
+	return c.model
+}
+
 // GenerateVideoFromImage 从单张图片生成视频（同步等待）
 // 对应 Python: client.content_generation.tasks.create() + 轮询等待
 //
@@ -121,6 +130,8 @@ func (c *ArkVideoClient) GenerateVideoFromImage(ctx context.Context, imageDataUR
 	}
 
 	log.Info().Str("task_id", taskID).Msg("视频生成任务提交成功")
+	taskLog := tasklog.FromContext(ctx)
+	taskLog.Info("Ark 视频生成任务已提交", tasklog.Fields{"provider_task_id": taskID, "model": c.model, "duration": limitedDuration})
 
 	// 2. 同步轮询等待任务完成（在函数内部，阻塞等待）
 	maxWaitTime := 30 * time.Minute // 最大等待 10 分钟（视频生成可能需要较长时间）
@@ -149,8 +160,10 @@ func (c *ArkVideoClient) GenerateVideoFromImage(ctx context.Context, imageDataUR
 				return nil, fmt.Errorf("failed to download video: %w", err)
 			}
 			log.Info().Str("task_id", taskID).Int("size", len(videoData)).Msg("视频生成成功并下载完成")
+			taskLog.Info("Ark 视频生成任务完成", tasklog.Fields{"provider_task_id": taskID, "size": len(videoData), "elapsed_ms": time.Since(startTime).Milliseconds()})
 			return videoData, nil
 		} else if status == "failed" {
+			taskLog.Error("Ark 视频生成任务失败", tasklog.Fields{"provider_task_id": taskID})
 			return nil, fmt.Errorf("video generation task failed: task_id=%s", taskID)
 		}
 
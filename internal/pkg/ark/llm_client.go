@@ -2,8 +2,11 @@ package ark
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/rs/zerolog/log"
@@ -12,6 +15,8 @@ import (
 	"github.com/volcengine/volcengine-go-sdk/service/arkruntime/model"
 
 	"lemon/internal/config"
+	"lemon/internal/pkg/environment"
+	"lemon/internal/pkg/tasklog"
 )
 
 // LLMClient Ark LLM 客户端封装
@@ -25,14 +30,15 @@ type LLMClient struct {
 }
 
 // ArkConfigFromEnv 从环境变量创建 Ark 配置
+// ARK_API_KEY、ARK_BASE_URL 按部署环境读取（优先 ARK_API_KEY_<ENV>，见 environment.Getenv）
 // 支持的环境变量：
 //   - ARK_API_KEY: API Key（必需）
 //   - ARK_MODEL: 模型名称（可选，默认: doubao-seed-1-6-flash-250615）
 //   - ARK_BASE_URL: API 基础 URL（可选，默认: https://ark.cn-beijing.volces.com/api/v3）
 func ArkConfigFromEnv() *config.AIConfig {
-	apiKey := os.Getenv("ARK_API_KEY")
+	apiKey := environment.Getenv("ARK_API_KEY")
 	model := os.Getenv("ARK_MODEL")
-	baseURL := os.Getenv("ARK_BASE_URL")
+	baseURL := environment.Getenv("ARK_BASE_URL")
 
 	if model == "" {
 		model = "doubao-seed-1-6-flash-250615"
@@ -117,6 +123,11 @@ type Usage struct {
 	TotalTokens      int `json:"total_tokens"`
 }
 
+// Model 返回使用的模型名称
+func (c *LLMClient) Model() string {
+	return c.model
+}
+
 // CreateChatCompletion 创建聊天完成（对应 Python 的 client.chat.completions.create）
 // 这是主要的 API 调用方法，用于生成文本
 func (c *LLMClient) CreateChatCompletion(ctx context.Context, req *ChatCompletionRequest) (*ChatCompletionResponse, error) {
@@ -150,8 +161,15 @@ func (c *LLMClient) CreateChatCompletion(ctx context.Context, req *ChatCompletio
 	output, err := c.client.CreateChatCompletion(ctx, input)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to call Ark ChatCompletion API")
+		tasklog.FromContext(ctx).Error("LLM 请求失败", tasklog.Fields{"model": req.Model, "error": err.Error()})
 		return nil, fmt.Errorf("Ark API call failed: %w", err)
 	}
+	tasklog.FromContext(ctx).Info("LLM 请求完成", tasklog.Fields{
+		"model":             req.Model,
+		"response_id":       output.ID,
+		"prompt_tokens":     output.Usage.PromptTokens,
+		"completion_tokens": output.Usage.CompletionTokens,
+	})
 
 	// 转换响应
 	return convertChatCompletionResponse(&output), nil
@@ -187,6 +205,95 @@ func (c *LLMClient) CreateChatCompletionSimple(ctx context.Context, prompt strin
 	return resp.Choices[0].Message.Content, nil
 }
 
+// CreateChatCompletionStream 流式聊天完成
+// 每收到一段增量内容就回调 onDelta（可为 nil），结束后返回拼接好的完整内容
+func (c *LLMClient) CreateChatCompletionStream(ctx context.Context, req *ChatCompletionRequest, onDelta func(delta string)) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 如果没有指定模型，使用客户端默认模型
+	if req.Model == "" {
+		req.Model = c.model
+	}
+
+	// 构建请求参数
+	input := &model.ChatCompletionRequest{
+		Model:    req.Model,
+		Messages: convertMessages(req.Messages),
+	}
+
+	if req.MaxTokens != nil {
+		input.MaxTokens = *req.MaxTokens
+	}
+
+	if req.Temperature != nil {
+		input.Temperature = float32(*req.Temperature)
+	}
+
+	if req.TopP != nil {
+		input.TopP = float32(*req.TopP)
+	}
+
+	taskLog := tasklog.FromContext(ctx)
+	stream, err := c.client.CreateChatCompletionStream(ctx, input)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to call Ark ChatCompletion stream API")
+		taskLog.Error("LLM 流式请求失败", tasklog.Fields{"model": req.Model, "error": err.Error()})
+		return "", fmt.Errorf("Ark API call failed: %w", err)
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	var responseID string
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("failed to receive Ark ChatCompletion stream")
+			taskLog.Error("LLM 流式响应中断", tasklog.Fields{"model": req.Model, "response_id": responseID, "received_chars": content.Len(), "error": err.Error()})
+			return content.String(), fmt.Errorf("Ark stream receive failed: %w", err)
+		}
+		if responseID == "" {
+			responseID = chunk.ID
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice == nil || choice.Delta.Content == "" {
+				continue
+			}
+			content.WriteString(choice.Delta.Content)
+			if onDelta != nil {
+				onDelta(choice.Delta.Content)
+			}
+		}
+	}
+
+	taskLog.Info("LLM 流式请求完成", tasklog.Fields{"model": req.Model, "response_id": responseID, "received_chars": content.Len()})
+	return content.String(), nil
+}
+
+// CreateChatCompletionStreamSimple 简化版本的流式聊天完成（只需要 prompt）
+func (c *LLMClient) CreateChatCompletionStreamSimple(ctx context.Context, prompt string, onDelta func(delta string)) (string, error) {
+	maxTokens := 32 * 1024
+	temperature := 0.7
+
+	req := &ChatCompletionRequest{
+		Model: c.model,
+		Messages: []Message{
+			{
+				Role:    "user",
+				Content: prompt,
+			},
+		},
+		MaxTokens:   &maxTokens,
+		Temperature: &temperature,
+	}
+
+	return c.CreateChatCompletionStream(ctx, req, onDelta)
+}
+
 // convertMessages 转换消息格式
 func convertMessages(messages []Message) []*model.ChatCompletionMessage {
 	result := make([]*model.ChatCompletionMessage, len(messages))
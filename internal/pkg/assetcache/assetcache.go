@@ -0,0 +1,149 @@
+// Package assetcache 本地资源缓存
+// 定时渲染前把需要的图片/音频/字幕预先下载到本地磁盘，渲染时直接读取，避免渲染窗口内集中下载
+package assetcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ErrSizeMismatch 写入的文件大小与预期不一致（下载不完整）
+var ErrSizeMismatch = errors.New("cached file size mismatch")
+
+const (
+	// tmpPrefix 写入中的临时文件前缀，清理时一并删除残留的临时文件
+	tmpPrefix = ".tmp-"
+	// staleTmpAge 临时文件超过该时间仍未完成写入视为残留（进程中途退出）
+	staleTmpAge = time.Hour
+)
+
+// Cache 本地资源缓存
+// 资源内容不可变，按资源ID缓存，不需要失效处理；文件修改时间记录最后访问时间，用于清理长期未使用的文件
+type Cache struct {
+	dir    string
+	hits   atomic.Int64
+	misses atomic.Int64
+	now    func() time.Time
+}
+
+// Stats 缓存命中统计（进程启动以来）
+type Stats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// New 创建本地资源缓存，目录不存在时自动创建
+func New(dir string) (*Cache, error) {
+	if dir == "" {
+		return nil, errors.New("asset cache dir is required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create asset cache dir: %w", err)
+	}
+	return &Cache{dir: dir, now: time.Now}, nil
+}
+
+// Dir 返回缓存目录
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// path 返回缓存文件路径（对 key 做哈希，避免 key 中的特殊字符影响路径）
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
+
+// Has 判断是否已缓存（不计入命中统计）
+func (c *Cache) Has(key string) bool {
+	_, err := os.Stat(c.path(key))
+	return err == nil
+}
+
+// Open 打开缓存文件，命中时刷新访问时间
+func (c *Cache) Open(key string) (io.ReadCloser, bool) {
+	p := c.path(key)
+	f, err := os.Open(p)
+	if err != nil {
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.hits.Add(1)
+	now := c.now()
+	_ = os.Chtimes(p, now, now)
+	return f, true
+}
+
+// Put 写入缓存，返回写入的字节数
+// 先写临时文件再重命名，并发写同一个 key 或写入中途失败都不会留下不完整的缓存文件
+// expectedSize > 0 时校验写入大小
+func (c *Cache) Put(key string, r io.Reader, expectedSize int64) (int64, error) {
+	tmp, err := os.CreateTemp(c.dir, tmpPrefix+"*")
+	if err != nil {
+		return 0, fmt.Errorf("create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	n, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return n, fmt.Errorf("write cache file: %w", err)
+	}
+	if expectedSize > 0 && n != expectedSize {
+		return n, fmt.Errorf("%w: got %d bytes, want %d", ErrSizeMismatch, n, expectedSize)
+	}
+
+	if err := os.Rename(tmpPath, c.path(key)); err != nil {
+		return n, fmt.Errorf("rename cache file: %w", err)
+	}
+	return n, nil
+}
+
+// Prune 删除超过 maxAge 未访问的缓存文件和残留的临时文件，返回删除的文件数
+func (c *Cache) Prune(maxAge time.Duration) (int, error) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return 0, fmt.Errorf("read asset cache dir: %w", err)
+	}
+
+	now := c.now()
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		age := now.Sub(info.ModTime())
+		var stale bool
+		if strings.HasPrefix(entry.Name(), tmpPrefix) {
+			stale = age > staleTmpAge
+		} else {
+			stale = maxAge > 0 && age > maxAge
+		}
+		if stale {
+			if err := os.Remove(filepath.Join(c.dir, entry.Name())); err == nil {
+				removed++
+			}
+		}
+	}
+	return removed, nil
+}
+
+// Stats 返回命中统计
+func (c *Cache) Stats() Stats {
+	return Stats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
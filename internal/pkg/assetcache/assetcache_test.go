@@ -0,0 +1,98 @@
+package assetcache
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCache_PutOpen(t *testing.T) {
+	c, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, ok := c.Open("res-1"); ok {
+		t.Fatal("Open() on empty cache should miss")
+	}
+
+	n, err := c.Put("res-1", strings.NewReader("hello"), 5)
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if n != 5 {
+		t.Errorf("Put() wrote %d bytes, want 5", n)
+	}
+	if !c.Has("res-1") {
+		t.Error("Has() = false after Put()")
+	}
+
+	rc, ok := c.Open("res-1")
+	if !ok {
+		t.Fatal("Open() after Put() should hit")
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "hello" {
+		t.Errorf("Open() content = %q, want %q", data, "hello")
+	}
+
+	if got := c.Stats(); got != (Stats{Hits: 1, Misses: 1}) {
+		t.Errorf("Stats() = %+v, want 1 hit and 1 miss", got)
+	}
+}
+
+func TestCache_PutSizeMismatch(t *testing.T) {
+	c, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	_, err = c.Put("res-1", strings.NewReader("hel"), 5)
+	if !errors.Is(err, ErrSizeMismatch) {
+		t.Fatalf("Put() error = %v, want ErrSizeMismatch", err)
+	}
+	if c.Has("res-1") {
+		t.Error("incomplete file should not be cached")
+	}
+
+	entries, _ := os.ReadDir(c.Dir())
+	if len(entries) != 0 {
+		t.Errorf("temp files left behind: %d", len(entries))
+	}
+}
+
+func TestCache_Prune(t *testing.T) {
+	c, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, err := c.Put("old", strings.NewReader("a"), 0); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if _, err := c.Put("new", strings.NewReader("b"), 0); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(c.path("old"), old, old); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+
+	removed, err := c.Prune(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Prune() removed %d files, want 1", removed)
+	}
+	if c.Has("old") {
+		t.Error("stale file should be pruned")
+	}
+	if !c.Has("new") {
+		t.Error("recent file should be kept")
+	}
+}
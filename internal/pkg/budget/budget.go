@@ -0,0 +1,110 @@
+package budget
+
+import (
+	"math"
+	"os"
+	"strconv"
+)
+
+// 金额统一使用「分」（int64）计量，避免浮点误差
+
+// 默认单价（分），可通过环境变量覆盖
+const (
+	defaultLLMPer1KChars  = 1   // 文本生成：每千字（输入+输出）
+	defaultTTSPer1KChars  = 50  // 语音合成：每千字
+	defaultImagePerImage  = 20  // 图片生成：每张
+	defaultVideoPerSecond = 100 // 视频生成：每秒
+)
+
+// DefaultWarnRatio 默认预警比例（已花费达到上限的 80% 时预警）
+const DefaultWarnRatio = 0.8
+
+// Pricing 各 provider 的单价表（分）
+type Pricing struct {
+	LLMPer1KChars  int64 // 文本生成：每千字（输入+输出）
+	TTSPer1KChars  int64 // 语音合成：每千字
+	ImagePerImage  int64 // 图片生成：每张
+	VideoPerSecond int64 // 视频生成：每秒
+}
+
+// PricingFromEnv 从环境变量读取单价表
+// 支持的环境变量（单位：分，未设置或非法时使用默认值）：
+//   - COST_LLM_PER_1K_CHARS: 文本生成每千字单价（默认: 1）
+//   - COST_TTS_PER_1K_CHARS: 语音合成每千字单价（默认: 50）
+//   - COST_IMAGE_PER_IMAGE: 图片生成每张单价（默认: 20）
+//   - COST_VIDEO_PER_SECOND: 视频生成每秒单价（默认: 100）
+func PricingFromEnv() *Pricing {
+	return &Pricing{
+		LLMPer1KChars:  envInt64("COST_LLM_PER_1K_CHARS", defaultLLMPer1KChars),
+		TTSPer1KChars:  envInt64("COST_TTS_PER_1K_CHARS", defaultTTSPer1KChars),
+		ImagePerImage:  envInt64("COST_IMAGE_PER_IMAGE", defaultImagePerImage),
+		VideoPerSecond: envInt64("COST_VIDEO_PER_SECOND", defaultVideoPerSecond),
+	}
+}
+
+// LLM 计算文本生成费用（chars 为输入+输出字数）
+func (p *Pricing) LLM(chars int) int64 {
+	return ceilFen(float64(chars) / 1000 * float64(p.LLMPer1KChars))
+}
+
+// TTS 计算语音合成费用
+func (p *Pricing) TTS(chars int) int64 {
+	return ceilFen(float64(chars) / 1000 * float64(p.TTSPer1KChars))
+}
+
+// Image 计算图片生成费用
+func (p *Pricing) Image(count int) int64 {
+	return int64(count) * p.ImagePerImage
+}
+
+// Video 计算视频生成费用
+func (p *Pricing) Video(seconds float64) int64 {
+	return ceilFen(seconds * float64(p.VideoPerSecond))
+}
+
+// Level 预算状态
+type Level string
+
+const (
+	LevelOK       Level = "ok"       // 正常
+	LevelWarning  Level = "warning"  // 接近上限（软预警）
+	LevelExceeded Level = "exceeded" // 已达到或超出上限
+)
+
+// Evaluate 根据上限和已花费计算预算状态
+// capFen <= 0 表示不限额；warnRatio 不在 (0, 1) 区间时使用默认预警比例
+func Evaluate(capFen, spentFen int64, warnRatio float64) Level {
+	if capFen <= 0 {
+		return LevelOK
+	}
+	if spentFen >= capFen {
+		return LevelExceeded
+	}
+	if warnRatio <= 0 || warnRatio >= 1 {
+		warnRatio = DefaultWarnRatio
+	}
+	if float64(spentFen) >= float64(capFen)*warnRatio {
+		return LevelWarning
+	}
+	return LevelOK
+}
+
+// ceilFen 向上取整到分（不足一分按一分计）
+func ceilFen(v float64) int64 {
+	if v <= 0 {
+		return 0
+	}
+	return int64(math.Ceil(v))
+}
+
+func envInt64(key string, def int64) int64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return def
+	}
+	return n
+}
@@ -0,0 +1,62 @@
+package budget
+
+import (
+	"testing"
+)
+
+func TestPricing(t *testing.T) {
+	p := &Pricing{LLMPer1KChars: 1, TTSPer1KChars: 50, ImagePerImage: 20, VideoPerSecond: 100}
+
+	tests := []struct {
+		name string
+		got  int64
+		want int64
+	}{
+		{"llm rounds up to one fen", p.LLM(10), 1},
+		{"llm zero chars", p.LLM(0), 0},
+		{"tts 1500 chars", p.TTS(1500), 75},
+		{"image count", p.Image(3), 60},
+		{"video fractional seconds", p.Video(5.01), 501},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestPricingFromEnv(t *testing.T) {
+	t.Setenv("COST_IMAGE_PER_IMAGE", "35")
+	t.Setenv("COST_VIDEO_PER_SECOND", "invalid")
+
+	p := PricingFromEnv()
+	if p.ImagePerImage != 35 {
+		t.Errorf("expected image price from env, got %d", p.ImagePerImage)
+	}
+	if p.VideoPerSecond != defaultVideoPerSecond {
+		t.Errorf("expected default video price for invalid env, got %d", p.VideoPerSecond)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name      string
+		capFen    int64
+		spentFen  int64
+		warnRatio float64
+		want      Level
+	}{
+		{"no cap", 0, 1000000, 0.8, LevelOK},
+		{"below warning", 50000, 10000, 0.8, LevelOK},
+		{"at warning", 50000, 40000, 0.8, LevelWarning},
+		{"default ratio when invalid", 50000, 40000, 0, LevelWarning},
+		{"custom ratio", 50000, 40000, 0.9, LevelOK},
+		{"at cap", 50000, 50000, 0.8, LevelExceeded},
+		{"over cap", 50000, 60000, 0.8, LevelExceeded},
+	}
+	for _, tt := range tests {
+		if got := Evaluate(tt.capFen, tt.spentFen, tt.warnRatio); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
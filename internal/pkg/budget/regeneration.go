@@ -0,0 +1,35 @@
+package budget
+
+// 单个产物（场景/镜头）重新生成次数的默认限制，可通过环境变量覆盖
+const (
+	defaultRegenerationWarnAt = 5  // 达到该次数时预警
+	defaultRegenerationLimit  = 20 // 超过该次数后需要管理员/审核员越权
+)
+
+// RegenerationLimits 单个产物的重新生成次数限制
+type RegenerationLimits struct {
+	WarnAt int // 重新生成次数达到该值时预警，0 表示不预警
+	Limit  int // 重新生成次数超过该值后需要越权，0 表示不限制
+}
+
+// RegenerationLimitsFromEnv 从环境变量读取重新生成次数限制
+// 支持的环境变量（未设置或非法时使用默认值）：
+//   - REGENERATION_WARN_AT: 预警次数（默认: 5，0 表示不预警）
+//   - REGENERATION_LIMIT: 上限次数（默认: 20，0 表示不限制）
+func RegenerationLimitsFromEnv() RegenerationLimits {
+	return RegenerationLimits{
+		WarnAt: int(envInt64("REGENERATION_WARN_AT", defaultRegenerationWarnAt)),
+		Limit:  int(envInt64("REGENERATION_LIMIT", defaultRegenerationLimit)),
+	}
+}
+
+// Evaluate 根据重新生成次数（包含本次）计算状态：超过上限为 exceeded，达到预警次数为 warning
+func (l RegenerationLimits) Evaluate(count int) Level {
+	if l.Limit > 0 && count > l.Limit {
+		return LevelExceeded
+	}
+	if l.WarnAt > 0 && count >= l.WarnAt {
+		return LevelWarning
+	}
+	return LevelOK
+}
@@ -0,0 +1,41 @@
+package budget
+
+import (
+	"testing"
+)
+
+func TestRegenerationLimitsEvaluate(t *testing.T) {
+	limits := RegenerationLimits{WarnAt: 5, Limit: 20}
+
+	tests := []struct {
+		name   string
+		limits RegenerationLimits
+		count  int
+		want   Level
+	}{
+		{"below warning", limits, 4, LevelOK},
+		{"at warning", limits, 5, LevelWarning},
+		{"at limit", limits, 20, LevelWarning},
+		{"beyond limit", limits, 21, LevelExceeded},
+		{"no limit", RegenerationLimits{WarnAt: 5}, 100, LevelWarning},
+		{"disabled", RegenerationLimits{}, 100, LevelOK},
+	}
+	for _, tt := range tests {
+		if got := tt.limits.Evaluate(tt.count); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRegenerationLimitsFromEnv(t *testing.T) {
+	t.Setenv("REGENERATION_WARN_AT", "3")
+	t.Setenv("REGENERATION_LIMIT", "-1")
+
+	limits := RegenerationLimitsFromEnv()
+	if limits.WarnAt != 3 {
+		t.Errorf("expected warn_at from env, got %d", limits.WarnAt)
+	}
+	if limits.Limit != defaultRegenerationLimit {
+		t.Errorf("expected default limit for invalid env, got %d", limits.Limit)
+	}
+}
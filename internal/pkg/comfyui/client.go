@@ -384,3 +384,53 @@ func SetPositivePrompt(workflow map[string]interface{}, promptText string) map[s
 
 	return wf
 }
+
+// samplerStepsClassTypes 带有采样步数（inputs.steps）的节点类型
+var samplerStepsClassTypes = map[string]bool{
+	"KSampler":         true,
+	"KSamplerAdvanced": true,
+	"BasicScheduler":   true,
+}
+
+// SetSamplerSteps 将 workflow 中所有采样节点的步数替换为 steps（steps<=0 时不修改）
+func SetSamplerSteps(workflow map[string]interface{}, steps int) map[string]interface{} {
+	if steps <= 0 {
+		return workflow
+	}
+
+	// 深拷贝
+	workflowBytes, err := json.Marshal(workflow)
+	if err != nil {
+		log.Warn().Err(err).Msg("深拷贝工作流失败")
+		return workflow
+	}
+
+	var wf map[string]interface{}
+	if err := json.Unmarshal(workflowBytes, &wf); err != nil {
+		log.Warn().Err(err).Msg("反序列化工作流失败")
+		return workflow
+	}
+
+	updated := 0
+	for _, nodeVal := range wf {
+		node, ok := nodeVal.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		classType, _ := node["class_type"].(string)
+		if !samplerStepsClassTypes[classType] {
+			continue
+		}
+		inputs, ok := node["inputs"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		inputs["steps"] = steps
+		updated++
+	}
+
+	if updated == 0 {
+		log.Warn().Int("steps", steps).Msg("未找到采样节点，跳过步数替换")
+	}
+	return wf
+}
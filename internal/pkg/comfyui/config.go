@@ -16,12 +16,19 @@ type Config struct {
 	RetryDelay       time.Duration // 重试延迟
 	PollInterval     time.Duration // 轮询间隔
 	MaxWait          time.Duration // 最大等待时间
+	PromptLanguage   string        // 提示词语言（ComfyUI 常用模型对英文提示词效果更好，默认 en；设为 zh 时不翻译）
+	StyleKeywords    string        // 翻译后追加的风格关键词（逗号分隔）
 }
 
+// DefaultStyleKeywords 默认追加的英文风格关键词（与章节图片默认的国风漫画风格一致）
+const DefaultStyleKeywords = "Chinese guofeng comic style, bold lines, high contrast, saturated colors, masterpiece, best quality, highly detailed"
+
 // ConfigFromEnv 从环境变量创建 ComfyUI 配置
 // 支持的环境变量：
 //   - COMFYUI_API_URL: API URL（可选，默认: http://127.0.0.1:8188/api/prompt）
 //   - COMFYUI_WORKFLOW_JSON: 工作流 JSON 模板路径（可选，默认: test/comfyui/image_compact.json）
+//   - COMFYUI_PROMPT_LANGUAGE: 提示词语言（可选，默认: en；设为 zh 时直接使用中文提示词）
+//   - COMFYUI_STYLE_KEYWORDS: 翻译后追加的风格关键词，逗号分隔（可选，默认: DefaultStyleKeywords）
 func ConfigFromEnv() *Config {
 	apiURL := os.Getenv("COMFYUI_API_URL")
 	if apiURL == "" {
@@ -33,6 +40,16 @@ func ConfigFromEnv() *Config {
 		workflowJSONPath = "test/comfyui/image_compact.json"
 	}
 
+	promptLanguage := os.Getenv("COMFYUI_PROMPT_LANGUAGE")
+	if promptLanguage == "" {
+		promptLanguage = "en"
+	}
+
+	styleKeywords := os.Getenv("COMFYUI_STYLE_KEYWORDS")
+	if styleKeywords == "" {
+		styleKeywords = DefaultStyleKeywords
+	}
+
 	return &Config{
 		APIURL:           normalizePromptURL(apiURL),
 		WorkflowJSONPath: workflowJSONPath,
@@ -41,6 +58,8 @@ func ConfigFromEnv() *Config {
 		RetryDelay:       1 * time.Second,
 		PollInterval:     1 * time.Second,
 		MaxWait:          300 * time.Second,
+		PromptLanguage:   promptLanguage,
+		StyleKeywords:    styleKeywords,
 	}
 }
 
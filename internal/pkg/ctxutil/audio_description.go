@@ -0,0 +1,25 @@
+package ctxutil
+
+import "context"
+
+// audioDescriptionKeyType 使用私有类型避免与其他 context key 冲突
+type audioDescriptionKeyType struct{}
+
+var audioDescriptionKey = audioDescriptionKeyType{}
+
+// WithAudioDescription 将单次请求是否生成口述影像音轨注入到 context 中（覆盖系统默认配置）
+func WithAudioDescription(ctx context.Context, enabled bool) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, audioDescriptionKey, enabled)
+}
+
+// GetAudioDescription 从 context 中解析单次请求是否生成口述影像音轨，未设置时第二个返回值为 false
+func GetAudioDescription(ctx context.Context) (bool, bool) {
+	if ctx == nil {
+		return false, false
+	}
+	enabled, ok := ctx.Value(audioDescriptionKey).(bool)
+	return enabled, ok
+}
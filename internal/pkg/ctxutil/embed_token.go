@@ -0,0 +1,32 @@
+package ctxutil
+
+import (
+	"context"
+
+	"lemon/internal/model/embed"
+)
+
+// embedTokenKeyType 使用私有类型避免与其他 context key 冲突
+type embedTokenKeyType struct{}
+
+var embedTokenKey = embedTokenKeyType{}
+
+// WithEmbedToken 将已认证的嵌入播放令牌注入到 context 中（由嵌入接口的认证中间件调用）
+func WithEmbedToken(ctx context.Context, token *embed.EmbedToken) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, embedTokenKey, token)
+}
+
+// GetEmbedToken 从 context 中解析嵌入播放令牌
+func GetEmbedToken(ctx context.Context) (*embed.EmbedToken, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	token, ok := ctx.Value(embedTokenKey).(*embed.EmbedToken)
+	if !ok || token == nil {
+		return nil, false
+	}
+	return token, true
+}
@@ -0,0 +1,32 @@
+package ctxutil
+
+import (
+	"context"
+
+	"lemon/internal/model/novel"
+)
+
+// generationOverridesKeyType 使用私有类型避免与其他 context key 冲突
+type generationOverridesKeyType struct{}
+
+var generationOverridesKey = generationOverridesKeyType{}
+
+// WithGenerationOverrides 将单次请求的生成参数覆盖注入到 context 中（由解析请求参数的中间件调用）
+func WithGenerationOverrides(ctx context.Context, overrides *novel.GenerationSettings) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, generationOverridesKey, overrides)
+}
+
+// GetGenerationOverrides 从 context 中解析单次请求的生成参数覆盖
+func GetGenerationOverrides(ctx context.Context) (*novel.GenerationSettings, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	overrides, ok := ctx.Value(generationOverridesKey).(*novel.GenerationSettings)
+	if !ok || overrides.IsZero() {
+		return nil, false
+	}
+	return overrides, true
+}
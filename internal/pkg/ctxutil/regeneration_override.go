@@ -0,0 +1,28 @@
+package ctxutil
+
+import "context"
+
+// regenerationOverrideKeyType 使用私有类型避免与其他 context key 冲突
+type regenerationOverrideKeyType struct{}
+
+var regenerationOverrideKey = regenerationOverrideKeyType{}
+
+// WithRegenerationOverride 将越过重新生成次数上限的原因注入到 context 中（由解析请求参数的中间件调用）
+func WithRegenerationOverride(ctx context.Context, reason string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, regenerationOverrideKey, reason)
+}
+
+// GetRegenerationOverride 从 context 中解析越过重新生成次数上限的原因
+func GetRegenerationOverride(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	reason, ok := ctx.Value(regenerationOverrideKey).(string)
+	if !ok || reason == "" {
+		return "", false
+	}
+	return reason, true
+}
@@ -0,0 +1,32 @@
+package ctxutil
+
+import (
+	"context"
+
+	"lemon/internal/model/novel"
+)
+
+// subtitleStyleKeyType 使用私有类型避免与其他 context key 冲突
+type subtitleStyleKeyType struct{}
+
+var subtitleStyleKey = subtitleStyleKeyType{}
+
+// WithSubtitleStyle 将单次请求的烧录字幕样式注入到 context 中（覆盖字幕文件自带的样式）
+func WithSubtitleStyle(ctx context.Context, style *novel.SubtitleStyle) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, subtitleStyleKey, style)
+}
+
+// GetSubtitleStyle 从 context 中解析单次请求的烧录字幕样式，未设置时第二个返回值为 false
+func GetSubtitleStyle(ctx context.Context) (*novel.SubtitleStyle, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	style, ok := ctx.Value(subtitleStyleKey).(*novel.SubtitleStyle)
+	if !ok || style == nil || *style == (novel.SubtitleStyle{}) {
+		return nil, false
+	}
+	return style, true
+}
@@ -0,0 +1,32 @@
+package ctxutil
+
+import (
+	"context"
+
+	"lemon/internal/model/auth"
+)
+
+// userRoleKeyType 使用私有类型避免与其他 context key 冲突
+type userRoleKeyType struct{}
+
+var userRoleKey = userRoleKeyType{}
+
+// WithUserRole 将用户角色注入到 context 中（由认证中间件在解析 JWT 成功后调用）
+func WithUserRole(ctx context.Context, role auth.UserRole) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, userRoleKey, role)
+}
+
+// GetUserRole 从 context 中解析用户角色
+func GetUserRole(ctx context.Context) (auth.UserRole, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	role, ok := ctx.Value(userRoleKey).(auth.UserRole)
+	if !ok || role == "" {
+		return "", false
+	}
+	return role, true
+}
@@ -0,0 +1,343 @@
+package download
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrChecksumMismatch 下载完成后文件的 SHA256 与清单不一致
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	// ErrUnexpectedResponse 服务端返回了无法处理的响应
+	ErrUnexpectedResponse = errors.New("unexpected response")
+)
+
+const (
+	partSuffix  = ".part"      // 未完成的下载内容
+	stateSuffix = ".part.json" // 未完成下载的状态（用于判断服务端文件是否变化）
+
+	defaultMaxRetries   = 5
+	defaultRetryBackoff = time.Second
+)
+
+// Chunk 下载校验清单中的一个分块
+type Chunk struct {
+	Index  int    `json:"index"`
+	Offset int64  `json:"offset"`
+	Length int64  `json:"length"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest 下载校验清单（对应 GET /api/v1/resources/{resource_id}/download-manifest 的 data）
+type Manifest struct {
+	ResourceID  string  `json:"resource_id"`
+	FileName    string  `json:"file_name"`
+	ContentType string  `json:"content_type"`
+	FileSize    int64   `json:"file_size"`
+	SHA256      string  `json:"sha256"`
+	ChunkSize   int64   `json:"chunk_size"`
+	Chunks      []Chunk `json:"chunks"`
+}
+
+// Downloader 支持断点续传的下载客户端
+// 未完成的内容写入 <dest>.part，状态写入 <dest>.part.json；续传前按清单逐块校验已下载的部分，
+// 只保留校验通过的分块，并通过 If-Range 保证服务端文件变化时从头下载；完成后校验整个文件再改名为 dest
+type Downloader struct {
+	Client       *http.Client  // 为空时使用 http.DefaultClient
+	Header       http.Header   // 每个请求附加的请求头（如 Authorization）
+	MaxRetries   int           // 传输中断后的最大重试次数（默认 5）
+	RetryBackoff time.Duration // 重试初始退避时间（每次重试翻倍，默认 1 秒）
+}
+
+// Result 下载结果
+type Result struct {
+	Path    string // 下载完成的文件路径
+	Size    int64  // 文件大小
+	SHA256  string // 文件 SHA256
+	Resumed int64  // 复用的已下载字节数
+}
+
+// partState 未完成下载的状态
+type partState struct {
+	URL       string `json:"url"`
+	SHA256    string `json:"sha256"`
+	FileSize  int64  `json:"file_size"`
+	ChunkSize int64  `json:"chunk_size"`
+}
+
+// FetchManifest 获取下载校验清单
+func (d *Downloader) FetchManifest(ctx context.Context, manifestURL string) (*Manifest, error) {
+	req, err := d.newRequest(ctx, manifestURL)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := d.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch manifest: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Code    int       `json:"code"`
+		Message string    `json:"message"`
+		Data    *Manifest `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode manifest (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || body.Code != 0 || body.Data == nil {
+		return nil, fmt.Errorf("%w: manifest status %d: %s", ErrUnexpectedResponse, resp.StatusCode, body.Message)
+	}
+	return body.Data, nil
+}
+
+// Download 按清单把 url 下载到 dest，已有 <dest>.part 时从校验通过的位置续传
+func (d *Downloader) Download(ctx context.Context, url string, manifest *Manifest, dest string) (*Result, error) {
+	partPath := dest + partSuffix
+	statePath := dest + stateSuffix
+	state := partState{URL: url, SHA256: manifest.SHA256, FileSize: manifest.FileSize, ChunkSize: manifest.ChunkSize}
+
+	file, err := os.OpenFile(partPath, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open part file: %w", err)
+	}
+	defer file.Close()
+
+	// 只有上次下载的是同一个文件时才复用已下载的部分
+	var offset int64
+	if previous, err := readState(statePath); err == nil && *previous == state {
+		if offset, err = verifiedPrefix(file, manifest); err != nil {
+			return nil, err
+		}
+	}
+	if err := file.Truncate(offset); err != nil {
+		return nil, fmt.Errorf("truncate part file: %w", err)
+	}
+	if err := writeState(statePath, &state); err != nil {
+		return nil, err
+	}
+	resumed := offset
+
+	// 下载剩余部分；校验失败时回退到最后一个校验通过的分块再下载一次
+	for attempt := 0; ; attempt++ {
+		if err := d.fetchFrom(ctx, url, manifest, file, offset); err != nil {
+			return nil, err
+		}
+		verified, err := verifiedPrefix(file, manifest)
+		if err != nil {
+			return nil, err
+		}
+		if verified == manifest.FileSize {
+			break
+		}
+		if attempt > 0 {
+			return nil, fmt.Errorf("%w: only %d of %d bytes verified", ErrChecksumMismatch, verified, manifest.FileSize)
+		}
+		offset = verified
+		if err := file.Truncate(offset); err != nil {
+			return nil, fmt.Errorf("truncate part file: %w", err)
+		}
+	}
+
+	sum, err := fileSHA256(file)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.SHA256 != "" && sum != manifest.SHA256 {
+		return nil, fmt.Errorf("%w: got %s, want %s", ErrChecksumMismatch, sum, manifest.SHA256)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("close part file: %w", err)
+	}
+	if err := os.Rename(partPath, dest); err != nil {
+		return nil, fmt.Errorf("rename part file: %w", err)
+	}
+	os.Remove(statePath)
+
+	return &Result{Path: dest, Size: manifest.FileSize, SHA256: sum, Resumed: resumed}, nil
+}
+
+// fetchFrom 从 offset 开始下载并追加到 file，传输中断时从已写入的位置重试
+func (d *Downloader) fetchFrom(ctx context.Context, url string, manifest *Manifest, file *os.File, offset int64) error {
+	maxRetries := d.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultMaxRetries
+	}
+	backoff := d.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if offset >= manifest.FileSize {
+			return nil
+		}
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		written, err := d.fetchOnce(ctx, url, manifest, file, offset)
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, ErrUnexpectedResponse) || ctx.Err() != nil {
+			return err
+		}
+		lastErr = err
+		offset = written
+	}
+	return fmt.Errorf("download failed after %d attempts: %w", maxRetries+1, lastErr)
+}
+
+// fetchOnce 发起一次区间请求，返回写入后 file 的长度
+func (d *Downloader) fetchOnce(ctx context.Context, url string, manifest *Manifest, file *os.File, offset int64) (int64, error) {
+	req, err := d.newRequest(ctx, url)
+	if err != nil {
+		return offset, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if manifest.SHA256 != "" {
+			req.Header.Set("If-Range", `"`+manifest.SHA256+`"`)
+		}
+	}
+
+	resp, err := d.client().Do(req)
+	if err != nil {
+		return offset, fmt.Errorf("request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		start, err := contentRangeStart(resp.Header.Get("Content-Range"))
+		if err != nil || start != offset {
+			return offset, fmt.Errorf("%w: content range %q for offset %d", ErrUnexpectedResponse, resp.Header.Get("Content-Range"), offset)
+		}
+	case http.StatusOK:
+		// 服务端不支持续传或文件已变化（If-Range 不匹配），从头写入
+		offset = 0
+	default:
+		return offset, fmt.Errorf("%w: status %d", ErrUnexpectedResponse, resp.StatusCode)
+	}
+
+	if err := file.Truncate(offset); err != nil {
+		return offset, fmt.Errorf("truncate part file: %w", err)
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return offset, fmt.Errorf("seek part file: %w", err)
+	}
+	n, err := io.Copy(file, resp.Body)
+	if err != nil {
+		return offset + n, fmt.Errorf("transfer: %w", err)
+	}
+	return offset + n, nil
+}
+
+func (d *Downloader) client() *http.Client {
+	if d.Client != nil {
+		return d.Client
+	}
+	return http.DefaultClient
+}
+
+func (d *Downloader) newRequest(ctx context.Context, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	for key, values := range d.Header {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+	return req, nil
+}
+
+// verifiedPrefix 按清单逐块校验 file，返回从头开始连续校验通过的字节数
+func verifiedPrefix(file *os.File, manifest *Manifest) (int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("stat part file: %w", err)
+	}
+
+	var verified int64
+	for _, chunk := range manifest.Chunks {
+		if chunk.Offset != verified || chunk.Offset+chunk.Length > info.Size() {
+			break
+		}
+		h := sha256.New()
+		if _, err := io.Copy(h, io.NewSectionReader(file, chunk.Offset, chunk.Length)); err != nil {
+			return 0, fmt.Errorf("read chunk %d: %w", chunk.Index, err)
+		}
+		if hex.EncodeToString(h.Sum(nil)) != chunk.SHA256 {
+			break
+		}
+		verified += chunk.Length
+	}
+	return verified, nil
+}
+
+func fileSHA256(file *os.File) (string, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("stat part file: %w", err)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(file, 0, info.Size())); err != nil {
+		return "", fmt.Errorf("hash part file: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// contentRangeStart 解析 Content-Range（bytes start-end/size）的起始位置
+func contentRangeStart(header string) (int64, error) {
+	spec, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, fmt.Errorf("invalid content range %q", header)
+	}
+	start, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, fmt.Errorf("invalid content range %q", header)
+	}
+	return strconv.ParseInt(start, 10, 64)
+}
+
+func readState(path string) (*partState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var state partState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func writeState(path string, state *partState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("encode download state: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("write download state: %w", err)
+	}
+	return nil
+}
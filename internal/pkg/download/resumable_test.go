@@ -0,0 +1,168 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testFile 测试用的文件内容及其清单
+func testFile(size int, chunkSize int64) ([]byte, *Manifest) {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	m := &Manifest{FileSize: int64(size), SHA256: sum(data), ChunkSize: chunkSize}
+	for offset := int64(0); offset < int64(size); offset += chunkSize {
+		end := min(offset+chunkSize, int64(size))
+		m.Chunks = append(m.Chunks, Chunk{Index: len(m.Chunks), Offset: offset, Length: end - offset, SHA256: sum(data[offset:end])})
+	}
+	return data, m
+}
+
+func sum(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+// cutWriter 写入 limit 个字节后失败，用于模拟传输中断
+type cutWriter struct {
+	http.ResponseWriter
+	remaining int
+}
+
+func (w *cutWriter) Write(p []byte) (int, error) {
+	if len(p) > w.remaining {
+		n, _ := w.ResponseWriter.Write(p[:w.remaining])
+		w.remaining = 0
+		return n, errors.New("cut")
+	}
+	w.remaining -= len(p)
+	return w.ResponseWriter.Write(p)
+}
+
+// rangeServer 支持 Range/If-Range 的文件服务，cutAfter > 0 时第一次请求只传输 cutAfter 个字节后断开
+func rangeServer(data []byte, etag string, cutAfter int, ranges *[]string) *httptest.Server {
+	var requests atomic.Int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ranges != nil {
+			*ranges = append(*ranges, r.Header.Get("Range"))
+		}
+		w.Header().Set("ETag", etag)
+		if requests.Add(1) == 1 && cutAfter > 0 {
+			http.ServeContent(&cutWriter{ResponseWriter: w, remaining: cutAfter}, r, "file.mp4", time.Time{}, bytes.NewReader(data))
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "file.mp4", time.Time{}, bytes.NewReader(data))
+	}))
+}
+
+func TestDownloader_Download(t *testing.T) {
+	data, manifest := testFile(10000, 1024)
+	etag := `"` + manifest.SHA256 + `"`
+	d := &Downloader{RetryBackoff: time.Millisecond}
+
+	t.Run("fresh download", func(t *testing.T) {
+		srv := rangeServer(data, etag, 0, nil)
+		defer srv.Close()
+		dest := filepath.Join(t.TempDir(), "out.mp4")
+
+		result, err := d.Download(context.Background(), srv.URL, manifest, dest)
+		if err != nil {
+			t.Fatalf("Download() error = %v", err)
+		}
+		got, _ := os.ReadFile(dest)
+		if !bytes.Equal(got, data) || result.SHA256 != manifest.SHA256 || result.Resumed != 0 {
+			t.Errorf("Download() result = %+v", result)
+		}
+		if _, err := os.Stat(dest + partSuffix); !os.IsNotExist(err) {
+			t.Errorf("part file should be removed, stat err = %v", err)
+		}
+	})
+
+	t.Run("resumes after interrupted transfer", func(t *testing.T) {
+		var ranges []string
+		srv := rangeServer(data, etag, 4500, &ranges)
+		defer srv.Close()
+		dest := filepath.Join(t.TempDir(), "out.mp4")
+
+		if _, err := d.Download(context.Background(), srv.URL, manifest, dest); err != nil {
+			t.Fatalf("Download() error = %v", err)
+		}
+		got, _ := os.ReadFile(dest)
+		if !bytes.Equal(got, data) {
+			t.Fatal("downloaded content mismatch")
+		}
+		if len(ranges) != 2 || ranges[0] != "" || ranges[1] != "bytes=4500-" {
+			t.Errorf("ranges = %q, want [\"\" \"bytes=4500-\"]", ranges)
+		}
+	})
+
+	t.Run("keeps only verified chunks of an existing part file", func(t *testing.T) {
+		var ranges []string
+		srv := rangeServer(data, etag, 0, &ranges)
+		defer srv.Close()
+		dest := filepath.Join(t.TempDir(), "out.mp4")
+
+		// 前 3 个分块正确，第 4 个分块损坏
+		part := append([]byte{}, data[:4000]...)
+		part[3500] ^= 0xff
+		os.WriteFile(dest+partSuffix, part, 0o644)
+		writeState(dest+stateSuffix, &partState{URL: srv.URL, SHA256: manifest.SHA256, FileSize: manifest.FileSize, ChunkSize: manifest.ChunkSize})
+
+		result, err := d.Download(context.Background(), srv.URL, manifest, dest)
+		if err != nil {
+			t.Fatalf("Download() error = %v", err)
+		}
+		got, _ := os.ReadFile(dest)
+		if !bytes.Equal(got, data) {
+			t.Fatal("downloaded content mismatch")
+		}
+		if result.Resumed != 3072 || len(ranges) != 1 || ranges[0] != "bytes=3072-" {
+			t.Errorf("resumed = %d, ranges = %q", result.Resumed, ranges)
+		}
+	})
+
+	t.Run("restarts when the part file belongs to another file", func(t *testing.T) {
+		var ranges []string
+		srv := rangeServer(data, etag, 0, &ranges)
+		defer srv.Close()
+		dest := filepath.Join(t.TempDir(), "out.mp4")
+
+		os.WriteFile(dest+partSuffix, data[:4000], 0o644)
+		writeState(dest+stateSuffix, &partState{URL: srv.URL, SHA256: "old", FileSize: manifest.FileSize, ChunkSize: manifest.ChunkSize})
+
+		result, err := d.Download(context.Background(), srv.URL, manifest, dest)
+		if err != nil {
+			t.Fatalf("Download() error = %v", err)
+		}
+		if result.Resumed != 0 || len(ranges) != 1 || ranges[0] != "" {
+			t.Errorf("resumed = %d, ranges = %q", result.Resumed, ranges)
+		}
+	})
+
+	t.Run("fails when the server content does not match the manifest", func(t *testing.T) {
+		changed := append([]byte{}, data...)
+		changed[100] ^= 0xff
+		srv := rangeServer(changed, `"changed"`, 0, nil)
+		defer srv.Close()
+		dest := filepath.Join(t.TempDir(), "out.mp4")
+
+		_, err := d.Download(context.Background(), srv.URL, manifest, dest)
+		if !errors.Is(err, ErrChecksumMismatch) {
+			t.Fatalf("Download() error = %v, want ErrChecksumMismatch", err)
+		}
+		if _, err := os.Stat(dest); !os.IsNotExist(err) {
+			t.Errorf("dest should not exist, stat err = %v", err)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package environment
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Environment 部署环境
+type Environment string
+
+const (
+	Dev     Environment = "dev"     // 开发环境（默认）
+	Staging Environment = "staging" // 预发环境
+	Prod    Environment = "prod"    // 生产环境
+)
+
+// Header 标记响应所属环境的响应头
+const Header = "X-Lemon-Environment"
+
+// CredentialKeys 供应商凭据的环境变量（启动时检查非生产环境是否使用了生产凭据）
+var CredentialKeys = []string{
+	"ARK_API_KEY",
+	"TTS_ACCESS_TOKEN",
+	"VOLCENGINE_ACCESS_KEY",
+	"VOLCENGINE_SECRET_KEY",
+	"AZURE_SPEECH_KEY",
+	"OPENAI_API_KEY",
+}
+
+var current atomic.Value
+
+// Parse 解析环境名称，为空时返回 Dev
+func Parse(name string) (Environment, error) {
+	env := Environment(strings.ToLower(strings.TrimSpace(name)))
+	switch env {
+	case "":
+		return Dev, nil
+	case Dev, Staging, Prod:
+		return env, nil
+	}
+	return "", fmt.Errorf("invalid environment %q, must be dev/staging/prod", name)
+}
+
+// String 返回环境的字符串表示
+func (e Environment) String() string {
+	return string(e)
+}
+
+// Set 设置当前进程所在的环境（启动时按配置设置一次）
+func Set(env Environment) {
+	current.Store(env)
+}
+
+// Current 返回当前进程所在的环境，未设置时为 Dev
+func Current() Environment {
+	if env, ok := current.Load().(Environment); ok {
+		return env
+	}
+	return Dev
+}
+
+// Getenv 读取当前环境的供应商配置：优先 KEY_<ENV>（如 ARK_API_KEY_STAGING），未配置时回退到 KEY
+// 不带后缀的 KEY 视为生产环境的凭据（兼容只配置了一套凭据的部署）
+func Getenv(key string) string {
+	if v := os.Getenv(envKey(key, Current())); v != "" {
+		return v
+	}
+	return os.Getenv(key)
+}
+
+// ProdCredentialsInUse 返回非生产环境下实际使用了生产凭据的环境变量
+// 生产凭据指回退到不带后缀的 KEY，或取值与 KEY_PROD 相同；生产环境或凭据为空时不检查
+func ProdCredentialsInUse() []string {
+	env := Current()
+	if env == Prod {
+		return nil
+	}
+	var keys []string
+	for _, key := range CredentialKeys {
+		value := Getenv(key)
+		if value == "" {
+			continue
+		}
+		if os.Getenv(envKey(key, env)) == "" || value == os.Getenv(envKey(key, Prod)) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// envKey 返回环境专属的变量名（如 ARK_API_KEY_STAGING）
+func envKey(key string, env Environment) string {
+	return key + "_" + strings.ToUpper(string(env))
+}
@@ -0,0 +1,59 @@
+package environment
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := map[string]Environment{"": Dev, "dev": Dev, " Staging ": Staging, "PROD": Prod}
+	for name, want := range cases {
+		got, err := Parse(name)
+		if err != nil || got != want {
+			t.Errorf("Parse(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := Parse("production"); err == nil {
+		t.Error("expected error for unknown environment")
+	}
+}
+
+func TestGetenv(t *testing.T) {
+	t.Cleanup(func() { Set(Dev) })
+	t.Setenv("ARK_API_KEY", "prod-key")
+	t.Setenv("ARK_API_KEY_STAGING", "staging-key")
+
+	Set(Staging)
+	if got := Getenv("ARK_API_KEY"); got != "staging-key" {
+		t.Errorf("staging Getenv = %q, want staging-key", got)
+	}
+	Set(Dev)
+	if got := Getenv("ARK_API_KEY"); got != "prod-key" {
+		t.Errorf("dev Getenv should fall back to unsuffixed key, got %q", got)
+	}
+}
+
+func TestProdCredentialsInUse(t *testing.T) {
+	t.Cleanup(func() { Set(Dev) })
+	for _, key := range CredentialKeys {
+		t.Setenv(key, "")
+	}
+	t.Setenv("ARK_API_KEY", "prod-key")
+	t.Setenv("ARK_API_KEY_STAGING", "staging-key")
+	t.Setenv("TTS_ACCESS_TOKEN", "prod-token")
+	t.Setenv("TTS_ACCESS_TOKEN_PROD", "prod-token")
+	t.Setenv("TTS_ACCESS_TOKEN_STAGING", "prod-token")
+
+	Set(Staging)
+	if got := ProdCredentialsInUse(); !reflect.DeepEqual(got, []string{"TTS_ACCESS_TOKEN"}) {
+		t.Errorf("staging ProdCredentialsInUse = %v", got)
+	}
+	Set(Dev)
+	if got := ProdCredentialsInUse(); !reflect.DeepEqual(got, []string{"ARK_API_KEY", "TTS_ACCESS_TOKEN"}) {
+		t.Errorf("dev ProdCredentialsInUse = %v", got)
+	}
+	Set(Prod)
+	if got := ProdCredentialsInUse(); got != nil {
+		t.Errorf("prod ProdCredentialsInUse = %v, want nil", got)
+	}
+}
@@ -0,0 +1,116 @@
+// Package errclass 生成失败的错误分类
+// 在包装错误的位置标注失败类别（provider 超时、内容审核、ffmpeg 编解码、格式校验、存储读写）和出错的 provider，
+// 任务结束后从错误链中取出分类，写入任务/产物记录并按类别汇总失败统计
+package errclass
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+)
+
+// Class 失败类别
+type Class string
+
+const (
+	ProviderTimeout       Class = "provider_timeout"        // provider 调用超时
+	ProviderContentPolicy Class = "provider_content_policy" // provider 内容审核拒绝
+	FFmpegCodec           Class = "ffmpeg_codec"            // ffmpeg 编解码/滤镜失败
+	ValidationSchema      Class = "validation_schema"       // 大模型输出格式或结构校验失败
+	StorageIO             Class = "storage_io"              // 存储上传/下载失败
+	Unknown               Class = "unknown"                 // 未分类
+)
+
+// Classes 所有已知类别（不含 unknown），用于统计展示
+var Classes = []Class{ProviderTimeout, ProviderContentPolicy, FFmpegCodec, ValidationSchema, StorageIO}
+
+// contentPolicyMarkers provider 内容审核拒绝时错误信息中的关键字（小写）
+var contentPolicyMarkers = []string{
+	"sensitivecontent",
+	"sensitive content",
+	"content policy",
+	"risk not pass",
+	"riskcontrol",
+	"内容审核",
+	"敏感",
+	"违规",
+}
+
+// Error 带分类的错误
+type Error struct {
+	Class    Class  // 失败类别
+	Provider string // 出错的 provider（如 ark、bytedance_tts、ffmpeg、storage），可为空
+	Err      error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap 为错误标注类别和 provider；err 为 nil 时返回 nil
+// 错误链中已有分类时保留内层分类（最靠近出错位置的分类最准确），只补充缺少的 provider
+func Wrap(class Class, provider string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var inner *Error
+	if errors.As(err, &inner) {
+		if inner.Provider != "" || provider == "" {
+			return err
+		}
+		class = inner.Class
+	}
+	return &Error{Class: class, Provider: provider, Err: err}
+}
+
+// Provider 包装 provider 调用返回的错误：按错误内容判断超时或内容审核，其余标记为 unknown
+func Provider(provider string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return Wrap(detect(err), provider, err)
+}
+
+// Classify 返回错误的类别和 provider；err 为 nil 时返回空类别
+// 错误链中没有分类时按错误内容判断（超时、内容审核），仍无法判断的返回 unknown
+func Classify(err error) (Class, string) {
+	if err == nil {
+		return "", ""
+	}
+	var classified *Error
+	if errors.As(err, &classified) {
+		return classified.Class, classified.Provider
+	}
+	return detect(err), ""
+}
+
+// detect 按错误内容判断类别
+func detect(err error) Class {
+	if isTimeout(err) {
+		return ProviderTimeout
+	}
+	msg := strings.ToLower(err.Error())
+	for _, marker := range contentPolicyMarkers {
+		if strings.Contains(msg, marker) {
+			return ProviderContentPolicy
+		}
+	}
+	return Unknown
+}
+
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "timeout") || strings.Contains(msg, "timed out") || strings.Contains(msg, "超时")
+}
@@ -0,0 +1,63 @@
+package errclass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestWrapKeepsInnerClass(t *testing.T) {
+	err := Wrap(FFmpegCodec, "ffmpeg", errors.New("exit status 1"))
+	err = fmt.Errorf("concat videos: %w", err)
+	err = Wrap(StorageIO, "storage", err)
+
+	class, provider := Classify(err)
+	if class != FFmpegCodec || provider != "ffmpeg" {
+		t.Errorf("Classify = %s/%s, want ffmpeg_codec/ffmpeg", class, provider)
+	}
+}
+
+func TestWrapFillsMissingProvider(t *testing.T) {
+	err := Wrap(ValidationSchema, "", errors.New("invalid json"))
+	err = Wrap(Unknown, "ark", fmt.Errorf("parse summary: %w", err))
+
+	class, provider := Classify(err)
+	if class != ValidationSchema || provider != "ark" {
+		t.Errorf("Classify = %s/%s, want validation_schema/ark", class, provider)
+	}
+	if Wrap(StorageIO, "storage", nil) != nil {
+		t.Error("Wrap(nil) should return nil")
+	}
+}
+
+func TestProviderDetectsClass(t *testing.T) {
+	tests := []struct {
+		err  error
+		want Class
+	}{
+		{fmt.Errorf("request: %w", context.DeadlineExceeded), ProviderTimeout},
+		{errors.New("Client.Timeout exceeded while awaiting headers"), ProviderTimeout},
+		{errors.New("code=OutputImageSensitiveContentDetected"), ProviderContentPolicy},
+		{errors.New("50411 Pre Img Risk Not Pass"), ProviderContentPolicy},
+		{errors.New("status 500"), Unknown},
+	}
+	for _, tt := range tests {
+		class, provider := Classify(Provider("ark_image", tt.err))
+		if class != tt.want || provider != "ark_image" {
+			t.Errorf("Classify(Provider(%q)) = %s/%s, want %s/ark_image", tt.err, class, provider, tt.want)
+		}
+	}
+}
+
+func TestClassifyUnwrapped(t *testing.T) {
+	if class, _ := Classify(nil); class != "" {
+		t.Errorf("Classify(nil) = %s, want empty", class)
+	}
+	if class, provider := Classify(errors.New("boom")); class != Unknown || provider != "" {
+		t.Errorf("Classify = %s/%s, want unknown", class, provider)
+	}
+	if class, _ := Classify(fmt.Errorf("wait: %w", context.DeadlineExceeded)); class != ProviderTimeout {
+		t.Errorf("Classify = %s, want provider_timeout", class)
+	}
+}
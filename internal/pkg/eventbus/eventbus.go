@@ -0,0 +1,78 @@
+package eventbus
+
+import (
+	"sync"
+	"time"
+)
+
+// Event 事件
+type Event struct {
+	Topic     string    `json:"topic"`     // 事件主题（如某个解说的ID）
+	Type      string    `json:"type"`      // 事件类型（如 narration.scene_completed）
+	Data      any       `json:"data"`      // 事件数据
+	Timestamp time.Time `json:"timestamp"` // 事件时间
+}
+
+// defaultBufferSize 每个订阅者的默认缓冲区大小
+const defaultBufferSize = 64
+
+// Bus 进程内事件总线
+// 按主题发布/订阅，发布方不会因为订阅者消费慢而阻塞（缓冲区满时丢弃事件）
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[string]map[chan Event]struct{}
+}
+
+// New 创建事件总线
+func New() *Bus {
+	return &Bus{
+		subs: make(map[string]map[chan Event]struct{}),
+	}
+}
+
+// Publish 发布事件到指定主题
+func (b *Bus) Publish(topic, eventType string, data any) {
+	evt := Event{
+		Topic:     topic,
+		Type:      eventType,
+		Data:      data,
+		Timestamp: time.Now(),
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for ch := range b.subs[topic] {
+		select {
+		case ch <- evt:
+		default:
+			// 订阅者消费过慢，丢弃事件
+		}
+	}
+}
+
+// Subscribe 订阅指定主题，返回事件通道和取消订阅函数
+// 取消订阅后通道会被关闭；取消函数可重复调用
+func (b *Bus) Subscribe(topic string) (<-chan Event, func()) {
+	ch := make(chan Event, defaultBufferSize)
+
+	b.mu.Lock()
+	if b.subs[topic] == nil {
+		b.subs[topic] = make(map[chan Event]struct{})
+	}
+	b.subs[topic][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs[topic], ch)
+			if len(b.subs[topic]) == 0 {
+				delete(b.subs, topic)
+			}
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
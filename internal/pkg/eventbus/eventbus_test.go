@@ -0,0 +1,57 @@
+package eventbus
+
+import (
+	"testing"
+)
+
+func TestBus_PublishSubscribe(t *testing.T) {
+	bus := New()
+
+	ch, cancel := bus.Subscribe("narration-1")
+	defer cancel()
+	other, cancelOther := bus.Subscribe("narration-2")
+	defer cancelOther()
+
+	bus.Publish("narration-1", "scene_completed", 1)
+
+	select {
+	case evt := <-ch:
+		if evt.Type != "scene_completed" || evt.Data != 1 {
+			t.Errorf("unexpected event: %+v", evt)
+		}
+	default:
+		t.Fatal("expected event on subscribed topic")
+	}
+
+	select {
+	case evt := <-other:
+		t.Errorf("unexpected event on other topic: %+v", evt)
+	default:
+	}
+}
+
+func TestBus_Cancel(t *testing.T) {
+	bus := New()
+
+	ch, cancel := bus.Subscribe("narration-1")
+	cancel()
+	cancel() // 重复取消不应 panic
+
+	if _, ok := <-ch; ok {
+		t.Error("expected channel to be closed after cancel")
+	}
+
+	// 取消后发布不应 panic
+	bus.Publish("narration-1", "scene_completed", nil)
+}
+
+func TestBus_PublishDoesNotBlock(t *testing.T) {
+	bus := New()
+
+	_, cancel := bus.Subscribe("narration-1")
+	defer cancel()
+
+	for i := 0; i < defaultBufferSize*2; i++ {
+		bus.Publish("narration-1", "scene_completed", i)
+	}
+}
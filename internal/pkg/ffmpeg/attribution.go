@@ -0,0 +1,83 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Attribution 署名渲染参数
+type Attribution struct {
+	Text          string  // 署名文字（单行）
+	FontPath      string  // 字体文件（中文署名需要指定支持中文的字体，为空时使用 FFmpeg 默认字体）
+	EndFrame      bool    // 在视频结尾追加黑底署名画面；否则在开头的画面上叠加署名
+	Duration      float64 // 展示时长（秒）
+	VideoDuration float64 // 输入视频时长（秒），追加署名画面时用于确定署名的开始时间
+	Top           float64 // 安全区上边距比例（相对画面高，0~1），开头叠加时署名放在安全区顶部，避开底部字幕
+	Left          float64 // 安全区左边距比例（相对画面宽，0~1）
+	Right         float64 // 安全区右边距比例（相对画面宽，0~1）
+}
+
+// AddAttribution 在视频中渲染署名
+// 开头叠加：署名带半透明底框，显示在安全区顶部，持续 Duration 秒；
+// 结尾署名画面：视频末尾追加 Duration 秒黑底画面（音频补静音），署名在画面中居中
+func (c *Client) AddAttribution(ctx context.Context, inputPath, outputPath string, a Attribution) error {
+	cmd := exec.CommandContext(ctx, c.ffmpegPath, buildAttributionArgs(inputPath, outputPath, a)...)
+	if err := run(ctx, cmd); err != nil {
+		return fmt.Errorf("ffmpeg add attribution failed: %w", err)
+	}
+
+	log.Info().
+		Str("input", inputPath).
+		Str("output", outputPath).
+		Str("text", a.Text).
+		Bool("end_frame", a.EndFrame).
+		Float64("duration", a.Duration).
+		Msg("署名添加成功")
+
+	return nil
+}
+
+// buildAttributionArgs 构建署名渲染的 FFmpeg 参数
+func buildAttributionArgs(inputPath, outputPath string, a Attribution) []string {
+	args := []string{
+		"-y",
+		"-i", inputPath,
+		"-vf", buildAttributionFilter(a),
+	}
+	if a.EndFrame {
+		// 视频延长后音频补齐相同时长的静音
+		args = append(args, "-af", fmt.Sprintf("apad=pad_dur=%.3f", a.Duration))
+	}
+	args = append(args,
+		"-c:v", "libx264",
+		"-crf", "20",
+		"-preset", "medium",
+		"-pix_fmt", "yuv420p",
+		"-c:a", "aac",
+		"-b:a", "160k",
+		"-movflags", "+faststart",
+		outputPath,
+	)
+	return args
+}
+
+// buildAttributionFilter 构建署名的视频滤镜
+func buildAttributionFilter(a Attribution) string {
+	font := ""
+	if a.FontPath != "" {
+		font = fmt.Sprintf(":fontfile='%s'", escapeDrawtext(a.FontPath))
+	}
+
+	if a.EndFrame {
+		// tpad=stop_mode=add:stop_duration=3.000:color=black,drawtext=text='...':fontcolor=white:fontsize=h/28:x=(w-text_w)/2:y=(h-text_h)/2:enable='gte(t,60.000)'
+		return fmt.Sprintf("tpad=stop_mode=add:stop_duration=%.3f:color=black,drawtext=text='%s'%s:fontcolor=white:fontsize=h/28:x=(w-text_w)/2:y=(h-text_h)/2:enable='gte(t,%.3f)'",
+			a.Duration, escapeDrawtext(a.Text), font, a.VideoDuration)
+	}
+
+	// drawtext=text='...':fontcolor=white:fontsize=h/36:box=1:boxcolor=black@0.5:boxborderw=12:x=w*left+(w*(1-left-right)-text_w)/2:y=h*top+h*0.03:enable='between(t,0,3.000)'
+	return fmt.Sprintf("drawtext=text='%s'%s:fontcolor=white:fontsize=h/36:box=1:boxcolor=black@0.5:boxborderw=12:x=w*%.4f+(w*%.4f-text_w)/2:y=h*%.4f+h*0.03:enable='between(t,0,%.3f)'",
+		escapeDrawtext(a.Text), font, a.Left, 1-a.Left-a.Right, a.Top, a.Duration)
+}
@@ -0,0 +1,44 @@
+package ffmpeg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildAttributionFilterOverlay(t *testing.T) {
+	filter := buildAttributionFilter(Attribution{
+		Text:     "原作《斗破苍穹》 Author: 天蚕土豆",
+		Duration: 3,
+		Top:      0.1,
+		Left:     0.05,
+		Right:    0.15,
+	})
+	want := "drawtext=text='原作《斗破苍穹》 Author\\: 天蚕土豆':fontcolor=white:fontsize=h/36:box=1:boxcolor=black@0.5:boxborderw=12:" +
+		"x=w*0.0500+(w*0.8000-text_w)/2:y=h*0.1000+h*0.03:enable='between(t,0,3.000)'"
+	if filter != want {
+		t.Errorf("unexpected overlay filter:\n got %s\nwant %s", filter, want)
+	}
+}
+
+func TestBuildAttributionArgsEndFrame(t *testing.T) {
+	args := buildAttributionArgs("in.mp4", "out.mp4", Attribution{
+		Text:          "Licensed by Example Press",
+		FontPath:      "/fonts/NotoSansCJK.ttc",
+		EndFrame:      true,
+		Duration:      4,
+		VideoDuration: 62.5,
+	})
+
+	filter := args[indexOf(args, "-vf")+1]
+	want := "tpad=stop_mode=add:stop_duration=4.000:color=black," +
+		"drawtext=text='Licensed by Example Press':fontfile='/fonts/NotoSansCJK.ttc':fontcolor=white:fontsize=h/28:" +
+		"x=(w-text_w)/2:y=(h-text_h)/2:enable='gte(t,62.500)'"
+	if filter != want {
+		t.Errorf("unexpected end frame filter:\n got %s\nwant %s", filter, want)
+	}
+
+	joined := strings.Join(args, " ")
+	if !strings.Contains(joined, "-af apad=pad_dur=4.000") || !strings.HasSuffix(joined, "out.mp4") {
+		t.Errorf("unexpected args: %s", joined)
+	}
+}
@@ -0,0 +1,74 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// AudioCue 音轨时间轴上的一段音频
+type AudioCue struct {
+	Path     string  // 音频本地路径
+	Start    float64 // 在音轨中的开始时间（秒）
+	Duration float64 // 最长播放时长（秒），超出部分截断，<=0 时完整播放
+}
+
+// BuildCueTrack 把多段音频按开始时间放到一条独立音轨上（其余时间为静音），输出 AAC 音频（.m4a）
+// 用于口述影像等与视频分开交付的辅助音轨，音轨总时长为 duration 秒
+func (c *Client) BuildCueTrack(ctx context.Context, cues []AudioCue, duration float64, outputPath string) error {
+	if len(cues) == 0 {
+		return fmt.Errorf("no audio cues")
+	}
+	if duration <= 0 {
+		return fmt.Errorf("invalid track duration: %.3f", duration)
+	}
+
+	args := []string{"-y", "-f", "lavfi", "-t", fmt.Sprintf("%.3f", duration), "-i", "anullsrc=r=44100:cl=stereo"}
+	for _, cue := range cues {
+		args = append(args, "-i", cue.Path)
+	}
+	args = append(args,
+		"-filter_complex", buildCueTrackFilter(cues),
+		"-map", "[aout]",
+		"-c:a", "aac", "-b:a", "128k",
+		outputPath,
+	)
+
+	cmd := exec.CommandContext(ctx, c.ffmpegPath, args...)
+	if err := run(ctx, cmd); err != nil {
+		return fmt.Errorf("ffmpeg build cue track failed: %w", err)
+	}
+
+	log.Info().
+		Int("cues", len(cues)).
+		Float64("duration", duration).
+		Str("output", outputPath).
+		Msg("辅助音轨生成成功")
+
+	return nil
+}
+
+// buildCueTrackFilter 构建辅助音轨的 filter_complex
+// 输入 0 为静音底轨，输入 i+1 为第 i 段音频；输出标签为 [aout]
+func buildCueTrackFilter(cues []AudioCue) string {
+	parts := make([]string, 0, len(cues)+1)
+	labels := []string{"[0:a]"}
+	for i, cue := range cues {
+		filters := []string{"aresample=44100", "aformat=channel_layouts=stereo"}
+		if cue.Duration > 0 {
+			filters = append(filters, fmt.Sprintf("atrim=0:%.3f", cue.Duration), "asetpts=PTS-STARTPTS")
+		}
+		delay := int64(max(cue.Start, 0) * 1000)
+		filters = append(filters, fmt.Sprintf("adelay=%d|%d", delay, delay))
+
+		label := fmt.Sprintf("[cue%d]", i)
+		parts = append(parts, fmt.Sprintf("[%d:a]%s%s", i+1, strings.Join(filters, ","), label))
+		labels = append(labels, label)
+	}
+	// duration=first：以静音底轨的时长为准；normalize=0：各段音量不被 amix 按输入数平均压低
+	parts = append(parts, fmt.Sprintf("%samix=inputs=%d:duration=first:dropout_transition=0:normalize=0[aout]", strings.Join(labels, ""), len(labels)))
+	return strings.Join(parts, ";")
+}
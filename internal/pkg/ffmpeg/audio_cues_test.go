@@ -0,0 +1,28 @@
+package ffmpeg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildCueTrackFilter(t *testing.T) {
+	filter := buildCueTrackFilter([]AudioCue{
+		{Path: "cue1.mp3", Start: 3.2, Duration: 1.5},
+		{Path: "cue2.mp3", Start: 12},
+	})
+
+	parts := strings.Split(filter, ";")
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 filter chains, got %d: %s", len(parts), filter)
+	}
+	// 限制时长的片段先截断再延迟到开始时间
+	if want := "[1:a]aresample=44100,aformat=channel_layouts=stereo,atrim=0:1.500,asetpts=PTS-STARTPTS,adelay=3200|3200[cue0]"; parts[0] != want {
+		t.Errorf("unexpected first cue filter:\n got %s\nwant %s", parts[0], want)
+	}
+	if want := "[2:a]aresample=44100,aformat=channel_layouts=stereo,adelay=12000|12000[cue1]"; parts[1] != want {
+		t.Errorf("unexpected second cue filter:\n got %s\nwant %s", parts[1], want)
+	}
+	if want := "[0:a][cue0][cue1]amix=inputs=3:duration=first:dropout_transition=0:normalize=0[aout]"; parts[2] != want {
+		t.Errorf("unexpected amix filter:\n got %s\nwant %s", parts[2], want)
+	}
+}
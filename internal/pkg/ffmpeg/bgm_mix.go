@@ -0,0 +1,140 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// BGMSegment 背景音乐时间轴上的一段曲目
+type BGMSegment struct {
+	Path   string  // 曲目本地路径
+	Start  float64 // 在视频中的开始时间（秒）
+	End    float64 // 在视频中的结束时间（秒）
+	Volume float64 // 音量（相对原音量的倍数，<=0 时为 1）
+}
+
+// BGMDucking 解说时压低背景音乐（以原视频音轨为侧链做 sidechain 压缩）
+type BGMDucking struct {
+	Threshold float64 // 触发阈值（0-1，原音轨音量超过时开始压低背景音乐）
+	Ratio     float64 // 压缩比（<=1 时不压低）
+	Attack    float64 // 压低的起始时间（毫秒）
+	Release   float64 // 解说停顿后恢复音量的时间（毫秒）
+}
+
+// DefaultBGMDucking 默认的背景音乐闪避参数：解说开始后约 20ms 压低，停顿 0.4 秒后恢复
+var DefaultBGMDucking = BGMDucking{Threshold: 0.03, Ratio: 6, Attack: 20, Release: 400}
+
+// Enabled 是否压低背景音乐
+func (d BGMDucking) Enabled() bool {
+	return d.Ratio > 1
+}
+
+// MixSceneBGM 按场景时间轴把多段背景音乐混入视频
+// 每段曲目循环播放直到覆盖对应区间；相邻两段之间使用 crossfade 秒的交叉淡化（前一段延长淡出，后一段淡入）
+// ducking 启用时背景音乐在解说出声时自动压低（闪避）；视频流直接复制，音频以原视频音轨长度为准
+func (c *Client) MixSceneBGM(ctx context.Context, videoPath string, segments []BGMSegment, crossfade float64, ducking BGMDucking, outputPath string) error {
+	if len(segments) == 0 {
+		return fmt.Errorf("no bgm segments")
+	}
+
+	args := []string{"-y", "-i", videoPath}
+	for _, seg := range segments {
+		// 曲目比场景短时循环播放
+		args = append(args, "-stream_loop", "-1", "-i", seg.Path)
+	}
+	args = append(args,
+		"-filter_complex", buildSceneBGMFilter(segments, crossfade, ducking),
+		"-map", "0:v",
+		"-map", "[aout]",
+		"-c:v", "copy",
+		"-c:a", "aac", "-b:a", "128k",
+		outputPath,
+	)
+
+	cmd := exec.CommandContext(ctx, c.ffmpegPath, args...)
+	if err := run(ctx, cmd); err != nil {
+		return fmt.Errorf("ffmpeg mix scene bgm failed: %w", err)
+	}
+
+	log.Info().
+		Str("video", videoPath).
+		Int("segments", len(segments)).
+		Float64("crossfade", crossfade).
+		Bool("ducking", ducking.Enabled()).
+		Str("output", outputPath).
+		Msg("场景背景音乐混合成功")
+
+	return nil
+}
+
+// buildSceneBGMFilter 构建场景背景音乐的 filter_complex
+// 输入 0 为视频，输入 i+1 为第 i 段曲目；输出标签为 [aout]
+// 启用闪避时先把各段曲目混为一路，再以原音轨为侧链压缩后与原音轨混合
+func buildSceneBGMFilter(segments []BGMSegment, crossfade float64, ducking BGMDucking) string {
+	if crossfade < 0 {
+		crossfade = 0
+	}
+
+	var parts []string
+	labels := []string{"[0:a]"}
+	for i, seg := range segments {
+		length := seg.End - seg.Start
+		if length <= 0 {
+			continue
+		}
+		// 与下一段交叉淡化：当前段延长 crossfade 秒并在末尾淡出，下一段在开头淡入
+		fadeIn := 0.0
+		if i > 0 {
+			fadeIn = min(crossfade, length/2)
+		}
+		fadeOut := 0.0
+		if i < len(segments)-1 {
+			fadeOut = crossfade
+			length += crossfade
+		}
+		volume := seg.Volume
+		if volume <= 0 {
+			volume = 1
+		}
+
+		filters := []string{
+			fmt.Sprintf("atrim=0:%.3f", length),
+			"asetpts=PTS-STARTPTS",
+			fmt.Sprintf("volume=%.3f", volume),
+		}
+		if fadeIn > 0 {
+			filters = append(filters, fmt.Sprintf("afade=t=in:st=0:d=%.3f", fadeIn))
+		}
+		if fadeOut > 0 {
+			filters = append(filters, fmt.Sprintf("afade=t=out:st=%.3f:d=%.3f", length-fadeOut, fadeOut))
+		}
+		delay := int64(seg.Start * 1000)
+		filters = append(filters, fmt.Sprintf("adelay=%d|%d", delay, delay))
+
+		label := fmt.Sprintf("[bgm%d]", i)
+		parts = append(parts, fmt.Sprintf("[%d:a]%s%s", i+1, strings.Join(filters, ","), label))
+		labels = append(labels, label)
+	}
+
+	// normalize=0：保持解说音量不被 amix 按输入数平均压低
+	if !ducking.Enabled() || len(labels) == 1 {
+		parts = append(parts, fmt.Sprintf("%samix=inputs=%d:duration=first:dropout_transition=0:normalize=0[aout]", strings.Join(labels, ""), len(labels)))
+		return strings.Join(parts, ";")
+	}
+
+	bgm := labels[1]
+	if len(labels) > 2 {
+		bgm = "[bgm]"
+		parts = append(parts, fmt.Sprintf("%samix=inputs=%d:duration=longest:dropout_transition=0:normalize=0%s", strings.Join(labels[1:], ""), len(labels)-1, bgm))
+	}
+	parts = append(parts,
+		"[0:a]asplit=2[narr][sidechain]",
+		fmt.Sprintf("%s[sidechain]sidechaincompress=threshold=%.3f:ratio=%.1f:attack=%.1f:release=%.1f[ducked]", bgm, ducking.Threshold, ducking.Ratio, ducking.Attack, ducking.Release),
+		"[narr][ducked]amix=inputs=2:duration=first:dropout_transition=0:normalize=0[aout]",
+	)
+	return strings.Join(parts, ";")
+}
@@ -0,0 +1,57 @@
+package ffmpeg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildSceneBGMFilter(t *testing.T) {
+	filter := buildSceneBGMFilter([]BGMSegment{
+		{Path: "calm.mp3", Start: 0, End: 10, Volume: 0.25},
+		{Path: "battle.mp3", Start: 10, End: 25},
+	}, 1.5, BGMDucking{})
+
+	parts := strings.Split(filter, ";")
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 filter chains, got %d: %s", len(parts), filter)
+	}
+	// 第一段延长 crossfade 并在末尾淡出
+	if want := "[1:a]atrim=0:11.500,asetpts=PTS-STARTPTS,volume=0.250,afade=t=out:st=10.000:d=1.500,adelay=0|0[bgm0]"; parts[0] != want {
+		t.Errorf("unexpected first segment filter:\n got %s\nwant %s", parts[0], want)
+	}
+	// 最后一段在开头淡入，从场景开始时间延迟播放
+	if want := "[2:a]atrim=0:15.000,asetpts=PTS-STARTPTS,volume=1.000,afade=t=in:st=0:d=1.500,adelay=10000|10000[bgm1]"; parts[1] != want {
+		t.Errorf("unexpected last segment filter:\n got %s\nwant %s", parts[1], want)
+	}
+	if !strings.HasPrefix(parts[2], "[0:a][bgm0][bgm1]amix=inputs=3:duration=first") || !strings.HasSuffix(parts[2], "[aout]") {
+		t.Errorf("unexpected amix filter: %s", parts[2])
+	}
+}
+
+func TestBuildSceneBGMFilter_Ducking(t *testing.T) {
+	segments := []BGMSegment{
+		{Path: "calm.mp3", Start: 0, End: 10, Volume: 0.25},
+		{Path: "battle.mp3", Start: 10, End: 25},
+	}
+	filter := buildSceneBGMFilter(segments, 1.5, DefaultBGMDucking)
+
+	parts := strings.Split(filter, ";")
+	if len(parts) != 6 {
+		t.Fatalf("expected 6 filter chains, got %d: %s", len(parts), filter)
+	}
+	if want := "[bgm0][bgm1]amix=inputs=2:duration=longest:dropout_transition=0:normalize=0[bgm]"; parts[2] != want {
+		t.Errorf("unexpected bgm mix filter:\n got %s\nwant %s", parts[2], want)
+	}
+	if want := "[bgm][sidechain]sidechaincompress=threshold=0.030:ratio=6.0:attack=20.0:release=400.0[ducked]"; parts[4] != want {
+		t.Errorf("unexpected ducking filter:\n got %s\nwant %s", parts[4], want)
+	}
+	if !strings.HasPrefix(parts[5], "[narr][ducked]amix=inputs=2:duration=first") {
+		t.Errorf("unexpected output mix filter: %s", parts[5])
+	}
+
+	// 只有一段曲目时直接以该段作为压缩输入
+	single := buildSceneBGMFilter(segments[:1], 1.5, DefaultBGMDucking)
+	if !strings.Contains(single, "[bgm0][sidechain]sidechaincompress") {
+		t.Errorf("unexpected single segment filter: %s", single)
+	}
+}
@@ -0,0 +1,370 @@
+package ffmpeg
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrUnsupportedVersion FFmpeg 版本不在支持的范围内
+var ErrUnsupportedVersion = errors.New("unsupported ffmpeg version")
+
+// 二进制来源
+const (
+	SourceConfig  = "config"  // 配置文件指定的路径
+	SourceEnv     = "env"     // 环境变量 FFMPEG_PATH / FFPROBE_PATH
+	SourceManaged = "managed" // 自动下载到数据目录的静态构建
+	SourcePath    = "path"    // 系统 PATH 中的 ffmpeg
+)
+
+// BinaryConfig FFmpeg 二进制解析配置
+type BinaryConfig struct {
+	FFmpegPath   string     // 指定的 ffmpeg 路径（为空时依次尝试环境变量、数据目录、系统 PATH）
+	FFprobePath  string     // 指定的 ffprobe 路径（为空时取 ffmpeg 同目录下的 ffprobe）
+	MinVersion   string     // 支持的最低版本（含），为空表示不限制
+	MaxVersion   string     // 支持的最高版本（不含），为空表示不限制
+	AutoDownload bool       // 找不到可用的 ffmpeg 或版本不在范围内时，自动下载固定版本的静态构建
+	DataDir      string     // 静态构建的存放目录（自动下载时必填）
+	Version      string     // 固定的静态构建版本（用于区分数据目录下的子目录）
+	Downloads    []Download // 静态构建的下载地址（.zip 或 .tar.gz，压缩包内包含 ffmpeg/ffprobe）
+}
+
+// Download 静态构建下载地址
+type Download struct {
+	URL    string // 下载地址
+	SHA256 string // 压缩包的 SHA256（为空时不校验）
+}
+
+// Binaries 解析得到的 FFmpeg 二进制
+type Binaries struct {
+	FFmpegPath  string `json:"ffmpeg_path"`
+	FFprobePath string `json:"ffprobe_path"`
+	Version     string `json:"version"` // ffmpeg -version 报告的版本
+	Source      string `json:"source"`  // 来源：config, env, managed, path
+}
+
+var (
+	resolvedMu sync.RWMutex
+	resolved   *Binaries
+)
+
+// Use 设置进程内使用的 FFmpeg 二进制，之后 NewClient 创建的客户端都使用该路径
+func Use(b *Binaries) {
+	resolvedMu.Lock()
+	defer resolvedMu.Unlock()
+	resolved = b
+}
+
+// resolvedBinaries 返回 Use 设置的二进制（未设置时为 nil）
+func resolvedBinaries() *Binaries {
+	resolvedMu.RLock()
+	defer resolvedMu.RUnlock()
+	return resolved
+}
+
+// Resolve 解析要使用的 FFmpeg 二进制并检查版本
+//
+// 解析顺序：配置路径 → 环境变量 → 数据目录中已下载的静态构建 → 系统 PATH；
+// 找到的 ffmpeg 不可用或版本不在 [MinVersion, MaxVersion) 范围内时，开启 AutoDownload 则下载固定版本到数据目录
+func Resolve(ctx context.Context, cfg *BinaryConfig) (*Binaries, error) {
+	candidate, err := locate(cfg)
+	if err == nil {
+		err = checkCandidate(ctx, cfg, candidate)
+	}
+	if err == nil {
+		return candidate, nil
+	}
+	if !cfg.AutoDownload {
+		return nil, err
+	}
+
+	// 之前已经下载过固定版本时直接使用
+	if dir := managedDir(cfg); dir != "" && candidate != nil && candidate.Source != SourceManaged {
+		managed := &Binaries{FFmpegPath: filepath.Join(dir, "ffmpeg"), FFprobePath: filepath.Join(dir, "ffprobe"), Source: SourceManaged}
+		if _, statErr := os.Stat(managed.FFmpegPath); statErr == nil && checkCandidate(ctx, cfg, managed) == nil {
+			log.Warn().Err(err).Str("ffmpeg", managed.FFmpegPath).Msg("configured ffmpeg unavailable or unsupported, using downloaded static build")
+			return managed, nil
+		}
+	}
+
+	log.Warn().Err(err).Str("version", cfg.Version).Msg("ffmpeg unavailable or unsupported, downloading pinned static build")
+	managed, dlErr := download(ctx, cfg)
+	if dlErr != nil {
+		return nil, fmt.Errorf("%v; download static build: %w", err, dlErr)
+	}
+	if err := checkCandidate(ctx, cfg, managed); err != nil {
+		return nil, fmt.Errorf("downloaded static build: %w", err)
+	}
+	return managed, nil
+}
+
+// checkCandidate 读取版本并检查是否在支持范围内
+func checkCandidate(ctx context.Context, cfg *BinaryConfig, b *Binaries) error {
+	version, err := ProbeVersion(ctx, b.FFmpegPath)
+	if err != nil {
+		return err
+	}
+	b.Version = version
+	if !VersionInRange(version, cfg.MinVersion, cfg.MaxVersion) {
+		return fmt.Errorf("%w: %s (%s) not in [%s, %s)", ErrUnsupportedVersion, version, b.FFmpegPath, cfg.MinVersion, cfg.MaxVersion)
+	}
+	if _, err := os.Stat(b.FFprobePath); err != nil {
+		if _, lookErr := exec.LookPath(b.FFprobePath); lookErr != nil {
+			return fmt.Errorf("ffprobe not found: %s", b.FFprobePath)
+		}
+	}
+	return nil
+}
+
+// locate 按解析顺序查找 ffmpeg
+func locate(cfg *BinaryConfig) (*Binaries, error) {
+	if cfg.FFmpegPath != "" {
+		return &Binaries{FFmpegPath: cfg.FFmpegPath, FFprobePath: probePathFor(cfg.FFmpegPath, cfg.FFprobePath), Source: SourceConfig}, nil
+	}
+	if p := os.Getenv("FFMPEG_PATH"); p != "" {
+		return &Binaries{FFmpegPath: p, FFprobePath: probePathFor(p, os.Getenv("FFPROBE_PATH")), Source: SourceEnv}, nil
+	}
+	if dir := managedDir(cfg); dir != "" {
+		p := filepath.Join(dir, "ffmpeg")
+		if _, err := os.Stat(p); err == nil {
+			return &Binaries{FFmpegPath: p, FFprobePath: filepath.Join(dir, "ffprobe"), Source: SourceManaged}, nil
+		}
+	}
+	p, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg not found in PATH: %w", err)
+	}
+	return &Binaries{FFmpegPath: p, FFprobePath: probePathFor(p, cfg.FFprobePath), Source: SourcePath}, nil
+}
+
+// probePathFor 未指定 ffprobe 时取 ffmpeg 同目录下的 ffprobe（ffmpeg 为裸命令名时取 PATH 中的 ffprobe）
+func probePathFor(ffmpegPath, ffprobePath string) string {
+	if ffprobePath != "" {
+		return ffprobePath
+	}
+	if !strings.ContainsRune(ffmpegPath, filepath.Separator) {
+		return "ffprobe"
+	}
+	return filepath.Join(filepath.Dir(ffmpegPath), "ffprobe")
+}
+
+// managedDir 数据目录下固定版本静态构建的存放目录
+func managedDir(cfg *BinaryConfig) string {
+	if cfg.DataDir == "" {
+		return ""
+	}
+	version := cfg.Version
+	if version == "" {
+		version = "pinned"
+	}
+	return filepath.Join(cfg.DataDir, "ffmpeg-"+version)
+}
+
+// versionPattern 匹配 `ffmpeg -version` 第一行中的版本号，如 "ffmpeg version 6.1.1-static"、"ffmpeg version n7.0"
+var versionPattern = regexp.MustCompile(`version\s+n?(\d+(?:\.\d+){0,2})`)
+
+// ProbeVersion 运行 `ffmpeg -version` 并解析版本号
+func ProbeVersion(ctx context.Context, ffmpegPath string) (string, error) {
+	out, err := exec.CommandContext(ctx, ffmpegPath, "-hide_banner", "-version").Output()
+	if err != nil {
+		return "", fmt.Errorf("run %s -version: %w", ffmpegPath, err)
+	}
+	return ParseVersion(string(out))
+}
+
+// ParseVersion 从 `ffmpeg -version` 的输出中解析版本号
+func ParseVersion(output string) (string, error) {
+	firstLine, _, _ := strings.Cut(output, "\n")
+	m := versionPattern.FindStringSubmatch(firstLine)
+	if m == nil {
+		return "", fmt.Errorf("%w: cannot parse version from %q", ErrUnsupportedVersion, strings.TrimSpace(firstLine))
+	}
+	return m[1], nil
+}
+
+// VersionInRange 判断版本是否在 [min, max) 范围内，min/max 为空表示不限制
+func VersionInRange(version, min, max string) bool {
+	if min != "" && compareVersions(version, min) < 0 {
+		return false
+	}
+	if max != "" && compareVersions(version, max) >= 0 {
+		return false
+	}
+	return true
+}
+
+// compareVersions 按点分数字比较版本号，缺失的部分视为 0
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// download 下载固定版本的静态构建并解压 ffmpeg/ffprobe 到数据目录
+func download(ctx context.Context, cfg *BinaryConfig) (*Binaries, error) {
+	dir := managedDir(cfg)
+	if dir == "" {
+		return nil, errors.New("data_dir is required for auto download")
+	}
+	if len(cfg.Downloads) == 0 {
+		return nil, errors.New("no download url configured")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+
+	for _, d := range cfg.Downloads {
+		data, err := fetch(ctx, d)
+		if err != nil {
+			return nil, err
+		}
+		if err := extractBinaries(d.URL, data, dir); err != nil {
+			return nil, err
+		}
+		log.Info().Str("url", d.URL).Str("dir", dir).Msg("ffmpeg static build downloaded")
+	}
+
+	b := &Binaries{FFmpegPath: filepath.Join(dir, "ffmpeg"), FFprobePath: filepath.Join(dir, "ffprobe"), Source: SourceManaged}
+	if _, err := os.Stat(b.FFmpegPath); err != nil {
+		return nil, fmt.Errorf("ffmpeg not found in downloaded archives")
+	}
+	return b, nil
+}
+
+// fetch 下载压缩包并校验 SHA256
+func fetch(ctx context.Context, d Download) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", d.URL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download %s: status %d", d.URL, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", d.URL, err)
+	}
+	if d.SHA256 != "" {
+		sum := sha256.Sum256(data)
+		if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, d.SHA256) {
+			return nil, fmt.Errorf("download %s: sha256 mismatch, got %s", d.URL, got)
+		}
+	}
+	return data, nil
+}
+
+// binaryNames 压缩包中需要解压的文件
+var binaryNames = map[string]bool{"ffmpeg": true, "ffprobe": true}
+
+// extractBinaries 从 .zip 或 .tar.gz 压缩包中解压 ffmpeg/ffprobe（忽略压缩包内的目录层级）
+func extractBinaries(name string, data []byte, dir string) error {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return fmt.Errorf("open zip: %w", err)
+		}
+		for _, f := range zr.File {
+			base := path.Base(f.Name)
+			if f.FileInfo().IsDir() || !binaryNames[base] {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return fmt.Errorf("open %s: %w", f.Name, err)
+			}
+			err = writeExecutable(filepath.Join(dir, base), rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("open gzip: %w", err)
+		}
+		defer gz.Close()
+		tr := tar.NewReader(gz)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("read tar: %w", err)
+			}
+			base := path.Base(hdr.Name)
+			if hdr.Typeflag != tar.TypeReg || !binaryNames[base] {
+				continue
+			}
+			if err := writeExecutable(filepath.Join(dir, base), tr); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported archive format: %s (only .zip and .tar.gz)", name)
+	}
+}
+
+// writeExecutable 写入可执行文件（先写临时文件再重命名，避免留下不完整的二进制）
+func writeExecutable(dst string, r io.Reader) error {
+	tmp := dst + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o755)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", tmp, err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("write %s: %w", tmp, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("close %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("rename %s: %w", dst, err)
+	}
+	return nil
+}
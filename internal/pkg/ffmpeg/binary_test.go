@@ -0,0 +1,119 @@
+package ffmpeg
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	cases := []struct {
+		output string
+		want   string
+	}{
+		{"ffmpeg version 6.1.1-static https://johnvansickle.com/ffmpeg/  Copyright (c) 2000-2023\nbuilt with gcc", "6.1.1"},
+		{"ffmpeg version n7.0 Copyright (c) 2000-2024 the FFmpeg developers", "7.0"},
+		{"ffmpeg version 4.4.2-0ubuntu0.22.04.1 Copyright (c) 2000-2021", "4.4.2"},
+	}
+	for _, c := range cases {
+		got, err := ParseVersion(c.output)
+		if err != nil || got != c.want {
+			t.Errorf("ParseVersion(%q) = %q, %v; want %q", c.output, got, err, c.want)
+		}
+	}
+
+	if _, err := ParseVersion("ffmpeg version N-113000-g1234abcd"); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("git build version: got %v, want ErrUnsupportedVersion", err)
+	}
+}
+
+func TestVersionInRange(t *testing.T) {
+	cases := []struct {
+		version, min, max string
+		want              bool
+	}{
+		{"6.1.1", "4.4", "8.0", true},
+		{"4.4", "4.4", "8.0", true},
+		{"4.3.9", "4.4", "8.0", false},
+		{"8.0", "4.4", "8.0", false},
+		{"7.10", "4.4", "7.9", false},
+		{"3.0", "", "", true},
+	}
+	for _, c := range cases {
+		if got := VersionInRange(c.version, c.min, c.max); got != c.want {
+			t.Errorf("VersionInRange(%q, %q, %q) = %v, want %v", c.version, c.min, c.max, got, c.want)
+		}
+	}
+}
+
+func TestExtractBinaries(t *testing.T) {
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	for name, content := range map[string]string{"ffmpeg-4.4.1/ffmpeg": "ffmpeg-bin", "ffmpeg-4.4.1/README": "readme"} {
+		w, _ := zw.Create(name)
+		_, _ = w.Write([]byte(content))
+	}
+	_ = zw.Close()
+
+	var tgzBuf bytes.Buffer
+	gz := gzip.NewWriter(&tgzBuf)
+	tw := tar.NewWriter(gz)
+	content := []byte("ffprobe-bin")
+	_ = tw.WriteHeader(&tar.Header{Name: "build/bin/ffprobe", Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg})
+	_, _ = tw.Write(content)
+	_ = tw.Close()
+	_ = gz.Close()
+
+	dir := t.TempDir()
+	if err := extractBinaries("ffmpeg.zip", zipBuf.Bytes(), dir); err != nil {
+		t.Fatalf("extract zip: %v", err)
+	}
+	if err := extractBinaries("ffprobe.tar.gz", tgzBuf.Bytes(), dir); err != nil {
+		t.Fatalf("extract tar.gz: %v", err)
+	}
+
+	for name, want := range map[string]string{"ffmpeg": "ffmpeg-bin", "ffprobe": "ffprobe-bin"} {
+		p := filepath.Join(dir, name)
+		got, err := os.ReadFile(p)
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v; want %q", name, got, err, want)
+		}
+		if info, err := os.Stat(p); err != nil || info.Mode()&0o100 == 0 {
+			t.Errorf("%s should be executable", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "README")); !os.IsNotExist(err) {
+		t.Error("README should not be extracted")
+	}
+
+	if err := extractBinaries("ffmpeg.tar.xz", nil, dir); err == nil {
+		t.Error("expected error for unsupported archive format")
+	}
+}
+
+func TestFetchVerifiesChecksum(t *testing.T) {
+	body := []byte("archive")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	sum := sha256.Sum256(body)
+	data, err := fetch(context.Background(), Download{URL: srv.URL, SHA256: hex.EncodeToString(sum[:])})
+	if err != nil || !bytes.Equal(data, body) {
+		t.Fatalf("fetch = %q, %v", data, err)
+	}
+	if _, err := fetch(context.Background(), Download{URL: srv.URL, SHA256: "deadbeef"}); err == nil {
+		t.Error("expected checksum mismatch error")
+	}
+}
@@ -0,0 +1,133 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrSubtitleRenderingUnavailable 当前 FFmpeg 无法烧录 ASS 字幕（缺少 libass 或字体）
+var ErrSubtitleRenderingUnavailable = errors.New("ffmpeg subtitle rendering unavailable")
+
+// 检测用测试帧尺寸（黑底，渲染字幕后应出现亮像素）
+const (
+	probeFrameWidth  = 320
+	probeFrameHeight = 180
+	// probePixelThreshold 灰度值超过该阈值视为被字幕改变的像素
+	probePixelThreshold = 128
+)
+
+// probeASS 检测用字幕：白色大字，覆盖整个测试帧的时间范围
+const probeASS = `[Script Info]
+ScriptType: v4.00+
+PlayResX: 320
+PlayResY: 180
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+Style: Default,Arial,72,&H00FFFFFF,&H00FFFFFF,&H00FFFFFF,&H00000000,1,0,0,0,100,100,0,0,1,2,0,5,0,0,0,1
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Dialogue: 0,0:00:00.00,0:00:05.00,Default,,0,0,0,,字幕 Test
+`
+
+// Capabilities FFmpeg 能力检测结果
+type Capabilities struct {
+	Binaries          *Binaries `json:"binaries,omitempty"`       // 使用的 ffmpeg/ffprobe 路径、版本和来源
+	SubtitleRendering bool      `json:"subtitle_rendering"`       // 是否能烧录 ASS 字幕
+	SubtitleError     string    `json:"subtitle_error,omitempty"` // 不可用时的原因
+	CheckedAt         time.Time `json:"checked_at"`
+}
+
+// ProbeCapabilities 检测 FFmpeg 能力
+func (c *Client) ProbeCapabilities(ctx context.Context) *Capabilities {
+	caps := &Capabilities{Binaries: resolvedBinaries(), CheckedAt: time.Now()}
+	if err := c.ProbeSubtitleRendering(ctx); err != nil {
+		caps.SubtitleError = err.Error()
+	} else {
+		caps.SubtitleRendering = true
+	}
+	return caps
+}
+
+// ProbeSubtitleRendering 检测 ASS 字幕烧录是否可用
+// 在黑色测试帧上渲染一行字幕并检查像素是否被改变：
+// FFmpeg 缺少 libass 时 ass 滤镜不存在；缺少字体时滤镜不报错但画面不变，两种情况都会导致生成的视频没有字幕
+func (c *Client) ProbeSubtitleRendering(ctx context.Context) error {
+	filters, err := exec.CommandContext(ctx, c.ffmpegPath, "-hide_banner", "-filters").Output()
+	if err != nil {
+		return fmt.Errorf("%w: run %s -filters: %v", ErrSubtitleRenderingUnavailable, c.ffmpegPath, err)
+	}
+	if !hasFilter(filters, "ass") {
+		return fmt.Errorf("%w: ffmpeg is built without libass (ass filter not found)", ErrSubtitleRenderingUnavailable)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "lemon-ffmpeg-probe-*")
+	if err != nil {
+		return fmt.Errorf("create probe dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	assPath := filepath.Join(tmpDir, "probe.ass")
+	if err := os.WriteFile(assPath, []byte(probeASS), 0o644); err != nil {
+		return fmt.Errorf("write probe subtitle: %w", err)
+	}
+
+	// 输出单帧灰度原始像素到 stdout
+	cmd := exec.CommandContext(ctx, c.ffmpegPath,
+		"-hide_banner",
+		"-v", "error",
+		"-f", "lavfi",
+		"-i", fmt.Sprintf("color=c=black:s=%dx%d:d=1", probeFrameWidth, probeFrameHeight),
+		"-vf", fmt.Sprintf("ass=%s", assPath),
+		"-frames:v", "1",
+		"-f", "rawvideo",
+		"-pix_fmt", "gray",
+		"-",
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	frame, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("%w: render probe frame: %v: %s", ErrSubtitleRenderingUnavailable, err, strings.TrimSpace(stderr.String()))
+	}
+	if len(frame) != probeFrameWidth*probeFrameHeight {
+		return fmt.Errorf("%w: unexpected probe frame size %d", ErrSubtitleRenderingUnavailable, len(frame))
+	}
+	if countChangedPixels(frame, probePixelThreshold) == 0 {
+		return fmt.Errorf("%w: subtitle was not drawn on probe frame (missing fonts?)", ErrSubtitleRenderingUnavailable)
+	}
+	return nil
+}
+
+// hasFilter 判断 `ffmpeg -filters` 的输出中是否包含指定滤镜
+// 输出格式为 " T.. ass               V->V       Render ASS subtitles ..."
+func hasFilter(output []byte, name string) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[1] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// countChangedPixels 统计灰度帧中超过阈值的像素数量
+func countChangedPixels(frame []byte, threshold byte) int {
+	n := 0
+	for _, p := range frame {
+		if p > threshold {
+			n++
+		}
+	}
+	return n
+}
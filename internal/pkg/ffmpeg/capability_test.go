@@ -0,0 +1,40 @@
+package ffmpeg
+
+import "testing"
+
+func TestHasFilter(t *testing.T) {
+	output := []byte(`Filters:
+  T.. = Timeline support
+  ---
+ ... aresample          A->A       Resample audio data.
+ ... ass               V->V       Render ASS subtitles onto input video using the libass library.
+ T.C drawtext          V->V       Draw text on top of video frames using libfreetype library.
+`)
+
+	if !hasFilter(output, "ass") {
+		t.Error("expected ass filter to be found")
+	}
+	if !hasFilter(output, "drawtext") {
+		t.Error("expected drawtext filter to be found")
+	}
+	if hasFilter(output, "subtitles") {
+		t.Error("subtitles filter should not be found")
+	}
+	if hasFilter(nil, "ass") {
+		t.Error("empty output should not contain any filter")
+	}
+}
+
+func TestCountChangedPixels(t *testing.T) {
+	frame := make([]byte, probeFrameWidth*probeFrameHeight)
+	if n := countChangedPixels(frame, probePixelThreshold); n != 0 {
+		t.Errorf("black frame: got %d changed pixels, want 0", n)
+	}
+
+	frame[10] = 255
+	frame[20] = 200
+	frame[30] = probePixelThreshold
+	if n := countChangedPixels(frame, probePixelThreshold); n != 2 {
+		t.Errorf("got %d changed pixels, want 2", n)
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"time"
 
 	"github.com/rs/zerolog/log"
+
+	"lemon/internal/pkg/errclass"
 )
 
 // Client FFmpeg 客户端
@@ -20,7 +22,12 @@ type Client struct {
 }
 
 // NewClient 创建 FFmpeg 客户端
+// 启动时通过 Use 设置了解析后的二进制时使用该路径，否则读取环境变量 FFMPEG_PATH / FFPROBE_PATH
 func NewClient() *Client {
+	if b := resolvedBinaries(); b != nil {
+		return &Client{ffmpegPath: b.FFmpegPath, ffprobePath: b.FFprobePath}
+	}
+
 	ffmpegPath := os.Getenv("FFMPEG_PATH")
 	if ffmpegPath == "" {
 		ffmpegPath = "ffmpeg"
@@ -65,7 +72,7 @@ func (c *Client) GetVideoInfo(ctx context.Context, videoPath string) (*VideoInfo
 
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("ffprobe failed: %w", err)
+		return nil, errclass.Wrap(errclass.FFmpegCodec, "ffprobe", fmt.Errorf("ffprobe failed: %w", err))
 	}
 
 	// 解析 JSON 输出
@@ -124,7 +131,7 @@ func (c *Client) GetAudioInfo(ctx context.Context, audioPath string) (*AudioInfo
 
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("ffprobe failed: %w", err)
+		return nil, errclass.Wrap(errclass.FFmpegCodec, "ffprobe", fmt.Errorf("ffprobe failed: %w", err))
 	}
 
 	// 解析 JSON 输出
@@ -169,9 +176,7 @@ func (c *Client) CreateImageVideo(ctx context.Context, imagePath, outputPath str
 	}
 
 	cmd := exec.CommandContext(ctx, c.ffmpegPath, args...)
-	cmd.Stderr = os.Stderr // 输出错误信息到 stderr
-
-	if err := cmd.Run(); err != nil {
+	if err := run(ctx, cmd); err != nil {
 		return fmt.Errorf("ffmpeg failed: %w", err)
 	}
 
@@ -223,9 +228,7 @@ func (c *Client) ConcatVideos(ctx context.Context, videoPaths []string, outputPa
 	}
 
 	cmd := exec.CommandContext(ctx, c.ffmpegPath, args...)
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
+	if err := run(ctx, cmd); err != nil {
 		return fmt.Errorf("ffmpeg concat failed: %w", err)
 	}
 
@@ -237,6 +240,53 @@ func (c *Client) ConcatVideos(ctx context.Context, videoPaths []string, outputPa
 	return nil
 }
 
+// ConcatAudios 按顺序拼接多个音频文件，段与段之间插入 gap 秒静音，输出 mp3
+// 各段需要相同的采样率和声道（同一 TTS provider 的输出满足该条件）
+func (c *Client) ConcatAudios(ctx context.Context, audioPaths []string, outputPath string, gap float64) error {
+	if len(audioPaths) == 0 {
+		return fmt.Errorf("no audios to concat")
+	}
+
+	// ffmpeg -i a.mp3 -i b.mp3 -filter_complex "[0:a]apad=pad_dur=0.25[a0];[a0][1:a]concat=n=2:v=0:a=1[out]" -map "[out]" output.mp3
+	args := []string{"-y"}
+	for _, audioPath := range audioPaths {
+		args = append(args, "-i", audioPath)
+	}
+
+	var filters []string
+	var labels strings.Builder
+	for i := range audioPaths {
+		if i < len(audioPaths)-1 && gap > 0 {
+			filters = append(filters, fmt.Sprintf("[%d:a]apad=pad_dur=%.3f[a%d]", i, gap, i))
+			fmt.Fprintf(&labels, "[a%d]", i)
+		} else {
+			fmt.Fprintf(&labels, "[%d:a]", i)
+		}
+	}
+	filters = append(filters, fmt.Sprintf("%sconcat=n=%d:v=0:a=1[out]", labels.String(), len(audioPaths)))
+
+	args = append(args,
+		"-filter_complex", strings.Join(filters, ";"),
+		"-map", "[out]",
+		"-c:a", "libmp3lame",
+		"-b:a", "128k",
+		outputPath,
+	)
+
+	cmd := exec.CommandContext(ctx, c.ffmpegPath, args...)
+	if err := run(ctx, cmd); err != nil {
+		return fmt.Errorf("ffmpeg concat audios failed: %w", err)
+	}
+
+	log.Info().
+		Int("count", len(audioPaths)).
+		Float64("gap", gap).
+		Str("output", outputPath).
+		Msg("音频拼接成功")
+
+	return nil
+}
+
 // StandardizeVideo 标准化视频（分辨率、帧率）
 func (c *Client) StandardizeVideo(ctx context.Context, inputPath, outputPath string, width, height int, fps int) error {
 	// 构建视频滤镜
@@ -262,9 +312,7 @@ func (c *Client) StandardizeVideo(ctx context.Context, inputPath, outputPath str
 	}
 
 	cmd := exec.CommandContext(ctx, c.ffmpegPath, args...)
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
+	if err := run(ctx, cmd); err != nil {
 		return fmt.Errorf("ffmpeg standardize failed: %w", err)
 	}
 
@@ -279,6 +327,60 @@ func (c *Client) StandardizeVideo(ctx context.Context, inputPath, outputPath str
 	return nil
 }
 
+// AddWatermark 添加文字水印到视频（居中、半透明）
+// fontPath 为空时使用 FFmpeg 默认字体（中文水印需要指定支持中文的字体文件）
+func (c *Client) AddWatermark(ctx context.Context, inputPath, outputPath, text, fontPath string) error {
+	return c.AddWatermarkInSafeArea(ctx, inputPath, outputPath, text, fontPath, 0, 0, 0, 0)
+}
+
+// AddWatermarkInSafeArea 添加文字水印到视频，水印在安全区内居中（避开平台 UI 遮挡的区域）
+// top/bottom/left/right 为四边需要避开的比例（相对画面宽/高，0~1），全为 0 时等同于画面居中
+func (c *Client) AddWatermarkInSafeArea(ctx context.Context, inputPath, outputPath, text, fontPath string, top, bottom, left, right float64) error {
+	// drawtext=text='PREVIEW':fontcolor=white@0.35:fontsize=h/12:x=w*left+(w*(1-left-right)-text_w)/2:y=h*top+(h*(1-top-bottom)-text_h)/2
+	vf := fmt.Sprintf("drawtext=text='%s':fontcolor=white@0.35:fontsize=h/12:x=w*%.4f+(w*%.4f-text_w)/2:y=h*%.4f+(h*%.4f-text_h)/2",
+		escapeDrawtext(text), left, 1-left-right, top, 1-top-bottom)
+	if fontPath != "" {
+		vf += fmt.Sprintf(":fontfile='%s'", escapeDrawtext(fontPath))
+	}
+
+	args := []string{
+		"-y",
+		"-i", inputPath,
+		"-vf", vf,
+		"-c:v", "libx264",
+		"-crf", "20",
+		"-preset", "medium",
+		"-pix_fmt", "yuv420p",
+		"-c:a", "copy",
+		"-movflags", "+faststart",
+		outputPath,
+	}
+
+	cmd := exec.CommandContext(ctx, c.ffmpegPath, args...)
+	if err := run(ctx, cmd); err != nil {
+		return fmt.Errorf("ffmpeg add watermark failed: %w", err)
+	}
+
+	log.Info().
+		Str("input", inputPath).
+		Str("output", outputPath).
+		Str("text", text).
+		Msg("水印添加成功")
+
+	return nil
+}
+
+// escapeDrawtext 转义 drawtext 滤镜参数中的特殊字符
+func escapeDrawtext(s string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		`'`, `'\''`,
+		`:`, `\:`,
+		`%`, `\%`,
+	)
+	return replacer.Replace(s)
+}
+
 // AddSubtitles 添加字幕到视频（ASS 格式）
 func (c *Client) AddSubtitles(ctx context.Context, videoPath, assPath, outputPath string) error {
 	// 构建 FFmpeg 命令
@@ -293,9 +395,7 @@ func (c *Client) AddSubtitles(ctx context.Context, videoPath, assPath, outputPat
 	}
 
 	cmd := exec.CommandContext(ctx, c.ffmpegPath, args...)
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
+	if err := run(ctx, cmd); err != nil {
 		return fmt.Errorf("ffmpeg add subtitles failed: %w", err)
 	}
 
@@ -368,9 +468,7 @@ func (c *Client) MixAudio(ctx context.Context, videoPath string, bgmPath string,
 	args = append(args, outputPath)
 
 	cmd := exec.CommandContext(ctx, c.ffmpegPath, args...)
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
+	if err := run(ctx, cmd); err != nil {
 		return fmt.Errorf("ffmpeg mix audio failed: %w", err)
 	}
 
@@ -404,11 +502,71 @@ func (c *Client) CropVideo(ctx context.Context, inputPath, outputPath string, du
 	}
 
 	cmd := exec.CommandContext(ctx, c.ffmpegPath, args...)
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
+	if err := run(ctx, cmd); err != nil {
 		return fmt.Errorf("ffmpeg crop failed: %w", err)
 	}
 
 	return nil
 }
+
+// ExtractLastFrame 提取视频的最后一帧（JPEG）
+// 从结尾前 0.5 秒开始解码并只保留最后一帧，避免 -sseof 正好落在结尾时取不到帧
+func (c *Client) ExtractLastFrame(ctx context.Context, videoPath, outputPath string) error {
+	args := []string{
+		"-y",
+		"-sseof", "-0.5",
+		"-i", videoPath,
+		"-update", "1",
+		"-q:v", "2",
+		outputPath,
+	}
+
+	cmd := exec.CommandContext(ctx, c.ffmpegPath, args...)
+	if err := run(ctx, cmd); err != nil {
+		return fmt.Errorf("ffmpeg extract last frame failed: %w", err)
+	}
+
+	log.Info().
+		Str("video", videoPath).
+		Str("output", outputPath).
+		Msg("最后一帧提取成功")
+
+	return nil
+}
+
+// OverlayIntroImage 在视频开头叠加一张全屏图片并在 duration 秒内淡出（前情回顾过渡）
+// 图片按视频分辨率缩放，音频流直接复制
+func (c *Client) OverlayIntroImage(ctx context.Context, videoPath, imagePath, outputPath string, duration float64) error {
+	// ffmpeg -i video.mp4 -loop 1 -t 1.5 -i frame.jpg -filter_complex "[1:v][0:v]scale2ref[img][vid];[img]format=yuva420p,fade=t=out:st=0:d=1.5:alpha=1[ov];[vid][ov]overlay=0:0:eof_action=pass[out]" -map "[out]" -map 0:a? output.mp4
+	filter := fmt.Sprintf(
+		"[1:v][0:v]scale2ref[img][vid];[img]format=yuva420p,fade=t=out:st=0:d=%.2f:alpha=1[ov];[vid][ov]overlay=0:0:eof_action=pass,format=yuv420p[out]",
+		duration,
+	)
+	args := []string{
+		"-y",
+		"-i", videoPath,
+		"-loop", "1",
+		"-t", fmt.Sprintf("%.2f", duration),
+		"-i", imagePath,
+		"-filter_complex", filter,
+		"-map", "[out]",
+		"-map", "0:a?",
+		"-c:v", "libx264",
+		"-c:a", "copy",
+		outputPath,
+	}
+
+	cmd := exec.CommandContext(ctx, c.ffmpegPath, args...)
+	if err := run(ctx, cmd); err != nil {
+		return fmt.Errorf("ffmpeg overlay intro image failed: %w", err)
+	}
+
+	log.Info().
+		Str("video", videoPath).
+		Str("image", imagePath).
+		Str("output", outputPath).
+		Float64("duration", duration).
+		Msg("开头过渡画面叠加成功")
+
+	return nil
+}
@@ -0,0 +1,307 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// CompliancePreset 发布平台的响度和格式规范
+type CompliancePreset struct {
+	Name            string  // 预设名称（通常为平台名）
+	LoudnessTarget  float64 // 目标综合响度（LUFS）
+	TruePeak        float64 // 真峰值上限（dBTP）
+	LoudnessRange   float64 // 目标响度范围（LU）
+	PixelFormat     string  // 像素格式（如 yuv420p）
+	MaxVideoBitrate int     // 视频码率上限（kbps）
+	AudioBitrate    int     // 音频码率（kbps）
+	AudioSampleRate int     // 音频采样率（Hz）
+	Faststart       bool    // 是否把 moov 前置（边下边播）
+}
+
+// LoudnessStats loudnorm 滤镜输出的响度统计
+type LoudnessStats struct {
+	Integrated    float64 // 综合响度（LUFS）
+	TruePeak      float64 // 真峰值（dBTP）
+	LoudnessRange float64 // 响度范围（LU）
+	Threshold     float64 // 门限（LUFS）
+	TargetOffset  float64 // 与目标的偏移（LU）
+}
+
+// MediaFormat 成片的封装和编码信息
+type MediaFormat struct {
+	VideoCodec      string // 视频编码
+	PixelFormat     string // 像素格式
+	VideoBitrate    int    // 视频码率（kbps，ffprobe 无法获取流码率时为整体码率）
+	AudioCodec      string // 音频编码
+	AudioSampleRate int    // 音频采样率（Hz）
+	Faststart       bool   // moov 是否位于 mdat 之前
+}
+
+// ApplyCompliance 按平台规范重新编码最终视频
+// 音频使用两遍 loudnorm 归一化到目标响度并限制真峰值；视频限制码率上限并统一像素格式；按需开启 faststart
+// 返回处理前（第一遍测量）和处理后（第二遍 loudnorm 统计）的响度
+func (c *Client) ApplyCompliance(ctx context.Context, inputPath, outputPath string, preset CompliancePreset) (*LoudnessStats, *LoudnessStats, error) {
+	input, err := c.MeasureLoudness(ctx, inputPath, preset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	loudnorm := fmt.Sprintf("%s:measured_I=%.2f:measured_TP=%.2f:measured_LRA=%.2f:measured_thresh=%.2f:offset=%.2f:linear=true:print_format=json",
+		loudnormTarget(preset), input.Integrated, input.TruePeak, input.LoudnessRange, input.Threshold, input.TargetOffset)
+
+	args := []string{
+		"-y", "-hide_banner",
+		"-i", inputPath,
+		"-map", "0:v:0",
+		"-map", "0:a:0",
+		"-c:v", "libx264",
+		"-crf", "20",
+		"-preset", "medium",
+		"-pix_fmt", preset.PixelFormat,
+	}
+	if preset.MaxVideoBitrate > 0 {
+		args = append(args,
+			"-maxrate", fmt.Sprintf("%dk", preset.MaxVideoBitrate),
+			"-bufsize", fmt.Sprintf("%dk", preset.MaxVideoBitrate*2),
+		)
+	}
+	args = append(args,
+		"-af", loudnorm,
+		"-c:a", "aac",
+		"-b:a", fmt.Sprintf("%dk", preset.AudioBitrate),
+		"-ar", strconv.Itoa(preset.AudioSampleRate), // loudnorm 内部会上采样到 192kHz，需要重新指定采样率
+	)
+	if preset.Faststart {
+		args = append(args, "-movflags", "+faststart")
+	}
+	args = append(args, outputPath)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.ffmpegPath, args...)
+	cmd.Stderr = &stderr
+	if err := run(ctx, cmd); err != nil {
+		return nil, nil, fmt.Errorf("ffmpeg apply compliance failed: %w, stderr: %s", err, tailString(stderr.String(), 2000))
+	}
+
+	output, err := parseLoudnormStats(stderr.Bytes(), "output")
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse loudnorm output stats: %w", err)
+	}
+
+	log.Info().
+		Str("preset", preset.Name).
+		Float64("input_i", input.Integrated).
+		Float64("output_i", output.Integrated).
+		Float64("output_tp", output.TruePeak).
+		Str("output", outputPath).
+		Msg("平台规范处理成功")
+
+	return input, output, nil
+}
+
+// MeasureLoudness 测量音频响度（loudnorm 第一遍）
+func (c *Client) MeasureLoudness(ctx context.Context, path string, preset CompliancePreset) (*LoudnessStats, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.ffmpegPath,
+		"-hide_banner",
+		"-i", path,
+		"-map", "0:a:0",
+		"-af", loudnormTarget(preset)+":print_format=json",
+		"-f", "null", "-",
+	)
+	cmd.Stderr = &stderr
+	if err := run(ctx, cmd); err != nil {
+		return nil, fmt.Errorf("ffmpeg measure loudness failed: %w, stderr: %s", err, tailString(stderr.String(), 2000))
+	}
+
+	stats, err := parseLoudnormStats(stderr.Bytes(), "input")
+	if err != nil {
+		return nil, fmt.Errorf("parse loudnorm input stats: %w", err)
+	}
+	return stats, nil
+}
+
+// ProbeMediaFormat 获取成片的编码、像素格式、码率和 faststart 信息
+func (c *Client) ProbeMediaFormat(ctx context.Context, path string) (*MediaFormat, error) {
+	cmd := exec.CommandContext(ctx, c.ffprobePath,
+		"-v", "error",
+		"-show_entries", "stream=codec_type,codec_name,pix_fmt,sample_rate,bit_rate",
+		"-show_entries", "format=bit_rate",
+		"-of", "json",
+		path,
+	)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %w", err)
+	}
+
+	format, err := parseProbeFormat(output)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open media file: %w", err)
+	}
+	defer file.Close()
+	format.Faststart, err = isFaststart(file)
+	if err != nil {
+		return nil, fmt.Errorf("inspect mp4 atoms: %w", err)
+	}
+	return format, nil
+}
+
+// loudnormTarget 构建 loudnorm 目标参数
+func loudnormTarget(preset CompliancePreset) string {
+	return fmt.Sprintf("loudnorm=I=%.1f:TP=%.1f:LRA=%.1f", preset.LoudnessTarget, preset.TruePeak, preset.LoudnessRange)
+}
+
+// parseLoudnormStats 从 ffmpeg stderr 中解析 loudnorm 输出的 JSON 统计
+// prefix 为 input 时读取 input_* 字段（测量值），为 output 时读取 output_* 字段（处理后的值）
+func parseLoudnormStats(stderr []byte, prefix string) (*LoudnessStats, error) {
+	start := bytes.LastIndexByte(stderr, '{')
+	end := bytes.LastIndexByte(stderr, '}')
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("loudnorm stats not found")
+	}
+
+	var raw map[string]string
+	if err := json.Unmarshal(stderr[start:end+1], &raw); err != nil {
+		return nil, fmt.Errorf("decode loudnorm stats: %w", err)
+	}
+
+	var stats LoudnessStats
+	fields := []struct {
+		key string
+		dst *float64
+	}{
+		{prefix + "_i", &stats.Integrated},
+		{prefix + "_tp", &stats.TruePeak},
+		{prefix + "_lra", &stats.LoudnessRange},
+		{prefix + "_thresh", &stats.Threshold},
+		{"target_offset", &stats.TargetOffset},
+	}
+	for _, f := range fields {
+		value, ok := raw[f.key]
+		if !ok {
+			return nil, fmt.Errorf("loudnorm stats missing %s", f.key)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			// 静音片段的响度为 -inf，按极小值处理
+			if strings.Contains(value, "inf") {
+				v = -99
+			} else {
+				return nil, fmt.Errorf("invalid loudnorm %s %q", f.key, value)
+			}
+		}
+		*f.dst = v
+	}
+	return &stats, nil
+}
+
+// parseProbeFormat 解析 ffprobe 输出的流信息
+func parseProbeFormat(output []byte) (*MediaFormat, error) {
+	var probe struct {
+		Streams []struct {
+			CodecType  string `json:"codec_type"`
+			CodecName  string `json:"codec_name"`
+			PixFmt     string `json:"pix_fmt"`
+			SampleRate string `json:"sample_rate"`
+			BitRate    string `json:"bit_rate"`
+		} `json:"streams"`
+		Format struct {
+			BitRate string `json:"bit_rate"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("decode ffprobe output: %w", err)
+	}
+
+	var format MediaFormat
+	for _, s := range probe.Streams {
+		switch s.CodecType {
+		case "video":
+			if format.VideoCodec != "" {
+				continue
+			}
+			format.VideoCodec = s.CodecName
+			format.PixelFormat = s.PixFmt
+			if bps, err := strconv.Atoi(s.BitRate); err == nil {
+				format.VideoBitrate = bps / 1000
+			}
+		case "audio":
+			if format.AudioCodec != "" {
+				continue
+			}
+			format.AudioCodec = s.CodecName
+			format.AudioSampleRate, _ = strconv.Atoi(s.SampleRate)
+		}
+	}
+	if format.VideoBitrate == 0 {
+		if bps, err := strconv.Atoi(probe.Format.BitRate); err == nil {
+			format.VideoBitrate = bps / 1000
+		}
+	}
+	return &format, nil
+}
+
+// isFaststart 判断 MP4 的 moov 是否位于 mdat 之前（只遍历顶层 box）
+func isFaststart(r io.ReadSeeker) (bool, error) {
+	header := make([]byte, 16)
+	for {
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return false, nil
+			}
+			return false, err
+		}
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		boxType := string(header[4:8])
+		headerLen := int64(8)
+		if size == 1 {
+			// 64 位扩展长度
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return false, err
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerLen = 16
+		}
+
+		switch boxType {
+		case "moov":
+			return true, nil
+		case "mdat":
+			return false, nil
+		}
+		if size == 0 {
+			// box 一直延伸到文件末尾
+			return false, nil
+		}
+		if size < headerLen {
+			return false, fmt.Errorf("invalid box %q size %d", boxType, size)
+		}
+		if _, err := r.Seek(size-headerLen, io.SeekCurrent); err != nil {
+			return false, err
+		}
+	}
+}
+
+// tailString 截取字符串末尾（错误信息中只保留 ffmpeg 输出的最后部分）
+func tailString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[len(s)-n:]
+}
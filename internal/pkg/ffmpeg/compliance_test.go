@@ -0,0 +1,84 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestParseLoudnormStats(t *testing.T) {
+	stderr := []byte(`[Parsed_loudnorm_0 @ 0x7f8b] 
+{
+	"input_i" : "-23.54",
+	"input_tp" : "-5.12",
+	"input_lra" : "3.10",
+	"input_thresh" : "-33.80",
+	"output_i" : "-14.02",
+	"output_tp" : "-1.00",
+	"output_lra" : "2.90",
+	"output_thresh" : "-24.30",
+	"normalization_type" : "linear",
+	"target_offset" : "0.02"
+}
+`)
+
+	input, err := parseLoudnormStats(stderr, "input")
+	if err != nil {
+		t.Fatalf("parse input stats: %v", err)
+	}
+	if input.Integrated != -23.54 || input.TruePeak != -5.12 || input.Threshold != -33.80 || input.TargetOffset != 0.02 {
+		t.Errorf("unexpected input stats: %+v", input)
+	}
+
+	output, err := parseLoudnormStats(stderr, "output")
+	if err != nil {
+		t.Fatalf("parse output stats: %v", err)
+	}
+	if output.Integrated != -14.02 || output.TruePeak != -1.00 || output.LoudnessRange != 2.90 {
+		t.Errorf("unexpected output stats: %+v", output)
+	}
+
+	if _, err := parseLoudnormStats([]byte("no stats"), "input"); err == nil {
+		t.Error("expected error when stats are missing")
+	}
+}
+
+func TestParseProbeFormat(t *testing.T) {
+	output := []byte(`{
+	"streams": [
+		{"codec_name": "h264", "codec_type": "video", "pix_fmt": "yuv420p", "bit_rate": "5800000"},
+		{"codec_name": "aac", "codec_type": "audio", "sample_rate": "48000", "bit_rate": "192000"}
+	],
+	"format": {"bit_rate": "6000000"}
+}`)
+
+	format, err := parseProbeFormat(output)
+	if err != nil {
+		t.Fatalf("parse probe format: %v", err)
+	}
+	if format.VideoCodec != "h264" || format.PixelFormat != "yuv420p" || format.VideoBitrate != 5800 {
+		t.Errorf("unexpected video format: %+v", format)
+	}
+	if format.AudioCodec != "aac" || format.AudioSampleRate != 48000 {
+		t.Errorf("unexpected audio format: %+v", format)
+	}
+}
+
+func TestIsFaststart(t *testing.T) {
+	box := func(boxType string, payload int) []byte {
+		b := make([]byte, 8+payload)
+		binary.BigEndian.PutUint32(b, uint32(8+payload))
+		copy(b[4:], boxType)
+		return b
+	}
+	mp4 := func(boxes ...[]byte) *bytes.Reader {
+		return bytes.NewReader(bytes.Join(boxes, nil))
+	}
+
+	if ok, err := isFaststart(mp4(box("ftyp", 16), box("moov", 32), box("mdat", 64))); err != nil || !ok {
+		t.Errorf("moov before mdat should be faststart, got %v, %v", ok, err)
+	}
+	if ok, err := isFaststart(mp4(box("ftyp", 16), box("mdat", 64), box("moov", 32))); err != nil || ok {
+		t.Errorf("mdat before moov should not be faststart, got %v, %v", ok, err)
+	}
+}
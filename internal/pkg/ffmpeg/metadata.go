@@ -0,0 +1,48 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Version 返回客户端使用的 ffmpeg 版本
+// 启动时已通过 Use 解析过二进制时直接使用记录的版本，否则运行 `ffmpeg -version`
+func (c *Client) Version(ctx context.Context) (string, error) {
+	if b := resolvedBinaries(); b != nil && b.FFmpegPath == c.ffmpegPath && b.Version != "" {
+		return b.Version, nil
+	}
+	return ProbeVersion(ctx, c.ffmpegPath)
+}
+
+// EmbedMetadata 把键值对写入 MP4 的全局元数据（流复制，不重新编码），保留 faststart
+func (c *Client) EmbedMetadata(ctx context.Context, inputPath, outputPath string, metadata map[string]string) error {
+	args := []string{
+		"-y", "-hide_banner",
+		"-i", inputPath,
+		"-map", "0",
+		"-c", "copy",
+	}
+	for key, value := range metadata {
+		args = append(args, "-metadata", fmt.Sprintf("%s=%s", key, value))
+	}
+	// MP4 默认只写入标准标签，自定义键需要 use_metadata_tags
+	args = append(args, "-movflags", "+faststart+use_metadata_tags", outputPath)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.ffmpegPath, args...)
+	cmd.Stderr = &stderr
+	if err := run(ctx, cmd); err != nil {
+		return fmt.Errorf("ffmpeg embed metadata failed: %w, stderr: %s", err, tailString(stderr.String(), 2000))
+	}
+
+	log.Info().
+		Int("keys", len(metadata)).
+		Str("output", outputPath).
+		Msg("视频元数据写入成功")
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// 拼接长视频时统一的音频参数（各章节的最终视频可能来自不同的平台规范，采样率和声道数不一定相同）
+const (
+	concatAudioSampleRate = 44100
+	concatAudioLayout     = "stereo"
+)
+
+// TitleCard 章节之间的标题画面
+type TitleCard struct {
+	Title    string  // 主标题（如「第12章 重生」）
+	Subtitle string  // 副标题（如小说名，可为空）
+	FontPath string  // 字体文件（中文标题需要指定支持中文的字体，为空时使用 FFmpeg 默认字体）
+	Duration float64 // 时长（秒）
+	Width    int
+	Height   int
+	FPS      int
+}
+
+// ChapterMark MP4 章节标记
+type ChapterMark struct {
+	Title string
+	Start float64 // 开始时间（秒）
+	End   float64 // 结束时间（秒）
+}
+
+// NormalizeVideo 把视频统一为指定的分辨率、帧率和音频参数，使多个视频可以流复制拼接
+// 画幅不同时等比缩放后补黑边（不裁剪，避免裁掉烧录的字幕）
+func (c *Client) NormalizeVideo(ctx context.Context, inputPath, outputPath string, width, height, fps int) error {
+	cmd := exec.CommandContext(ctx, c.ffmpegPath, buildNormalizeArgs(inputPath, outputPath, width, height, fps)...)
+	if err := run(ctx, cmd); err != nil {
+		return fmt.Errorf("ffmpeg normalize failed: %w", err)
+	}
+
+	log.Info().
+		Str("input", inputPath).
+		Str("output", outputPath).
+		Int("width", width).
+		Int("height", height).
+		Int("fps", fps).
+		Msg("视频规格统一成功")
+
+	return nil
+}
+
+// buildNormalizeArgs 构建统一视频规格的 FFmpeg 参数
+func buildNormalizeArgs(inputPath, outputPath string, width, height, fps int) []string {
+	// scale=1920:1080:force_original_aspect_ratio=decrease,pad=1920:1080:(ow-iw)/2:(oh-ih)/2:color=black,setsar=1
+	vf := fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2:color=black,setsar=1",
+		width, height, width, height)
+	return []string{
+		"-y",
+		"-i", inputPath,
+		"-map", "0:v:0",
+		"-map", "0:a:0",
+		"-vf", vf,
+		"-r", fmt.Sprintf("%d", fps),
+		"-af", fmt.Sprintf("aresample=%d,aformat=channel_layouts=%s", concatAudioSampleRate, concatAudioLayout),
+		"-c:v", "libx264",
+		"-crf", "20",
+		"-preset", "medium",
+		"-pix_fmt", "yuv420p",
+		"-c:a", "aac",
+		"-b:a", "160k",
+		"-movflags", "+faststart",
+		outputPath,
+	}
+}
+
+// CreateTitleCard 生成黑底标题画面（带静音音轨，规格与 NormalizeVideo 的输出一致，可直接流复制拼接）
+func (c *Client) CreateTitleCard(ctx context.Context, card TitleCard, outputPath string) error {
+	cmd := exec.CommandContext(ctx, c.ffmpegPath, buildTitleCardArgs(card, outputPath)...)
+	if err := run(ctx, cmd); err != nil {
+		return fmt.Errorf("ffmpeg create title card failed: %w", err)
+	}
+
+	log.Info().
+		Str("title", card.Title).
+		Float64("duration", card.Duration).
+		Str("output", outputPath).
+		Msg("标题画面生成成功")
+
+	return nil
+}
+
+// buildTitleCardArgs 构建标题画面的 FFmpeg 参数
+func buildTitleCardArgs(card TitleCard, outputPath string) []string {
+	return []string{
+		"-y",
+		"-f", "lavfi",
+		"-i", fmt.Sprintf("color=c=black:s=%dx%d:r=%d:d=%.3f", card.Width, card.Height, card.FPS, card.Duration),
+		"-f", "lavfi",
+		"-i", fmt.Sprintf("anullsrc=r=%d:cl=%s", concatAudioSampleRate, concatAudioLayout),
+		"-vf", buildTitleCardFilter(card),
+		"-t", fmt.Sprintf("%.3f", card.Duration),
+		"-c:v", "libx264",
+		"-crf", "20",
+		"-preset", "medium",
+		"-pix_fmt", "yuv420p",
+		"-c:a", "aac",
+		"-b:a", "160k",
+		"-movflags", "+faststart",
+		outputPath,
+	}
+}
+
+// buildTitleCardFilter 构建标题画面的视频滤镜：主标题居中，副标题在主标题下方；开头和结尾各淡入淡出 0.5 秒
+func buildTitleCardFilter(card TitleCard) string {
+	font := ""
+	if card.FontPath != "" {
+		font = fmt.Sprintf(":fontfile='%s'", escapeDrawtext(card.FontPath))
+	}
+	fade := min(0.5, card.Duration/4)
+
+	filters := []string{
+		fmt.Sprintf("drawtext=text='%s'%s:fontcolor=white:fontsize=h/14:x=(w-text_w)/2:y=(h-text_h)/2", escapeDrawtext(card.Title), font),
+	}
+	if card.Subtitle != "" {
+		filters = append(filters, fmt.Sprintf("drawtext=text='%s'%s:fontcolor=white@0.7:fontsize=h/28:x=(w-text_w)/2:y=h/2+h/14", escapeDrawtext(card.Subtitle), font))
+	}
+	filters = append(filters,
+		fmt.Sprintf("fade=t=in:st=0:d=%.3f", fade),
+		fmt.Sprintf("fade=t=out:st=%.3f:d=%.3f", card.Duration-fade, fade),
+	)
+	return strings.Join(filters, ",")
+}
+
+// EmbedChapters 把章节标记写入 MP4（流复制，不重新编码），播放器可按章节跳转
+func (c *Client) EmbedChapters(ctx context.Context, inputPath, outputPath string, chapters []ChapterMark) error {
+	if len(chapters) == 0 {
+		return fmt.Errorf("no chapters to embed")
+	}
+
+	metadataPath := filepath.Join(filepath.Dir(outputPath), fmt.Sprintf("%s.ffmetadata", filepath.Base(outputPath)))
+	if err := os.WriteFile(metadataPath, []byte(buildChapterMetadata(chapters)), 0644); err != nil {
+		return fmt.Errorf("write chapter metadata: %w", err)
+	}
+	defer os.Remove(metadataPath)
+
+	args := []string{
+		"-y",
+		"-i", inputPath,
+		"-f", "ffmetadata",
+		"-i", metadataPath,
+		"-map", "0",
+		"-map_metadata", "0",
+		"-map_chapters", "1",
+		"-c", "copy",
+		"-movflags", "+faststart",
+		outputPath,
+	}
+	cmd := exec.CommandContext(ctx, c.ffmpegPath, args...)
+	if err := run(ctx, cmd); err != nil {
+		return fmt.Errorf("ffmpeg embed chapters failed: %w", err)
+	}
+
+	log.Info().
+		Int("chapters", len(chapters)).
+		Str("output", outputPath).
+		Msg("章节标记写入成功")
+
+	return nil
+}
+
+// buildChapterMetadata 构建 FFMETADATA 格式的章节标记（时间基为毫秒）
+func buildChapterMetadata(chapters []ChapterMark) string {
+	var b strings.Builder
+	b.WriteString(";FFMETADATA1\n")
+	for _, ch := range chapters {
+		b.WriteString("\n[CHAPTER]\nTIMEBASE=1/1000\n")
+		fmt.Fprintf(&b, "START=%d\n", int64(ch.Start*1000))
+		fmt.Fprintf(&b, "END=%d\n", int64(ch.End*1000))
+		fmt.Fprintf(&b, "title=%s\n", escapeFFMetadata(ch.Title))
+	}
+	return b.String()
+}
+
+// escapeFFMetadata 转义 FFMETADATA 值中的特殊字符（= ; # \ 和换行）
+func escapeFFMetadata(s string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		`=`, `\=`,
+		`;`, `\;`,
+		`#`, `\#`,
+		"\n", "\\\n",
+	)
+	return replacer.Replace(s)
+}
@@ -0,0 +1,51 @@
+package ffmpeg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildChapterMetadata(t *testing.T) {
+	got := buildChapterMetadata([]ChapterMark{
+		{Title: "第1章 下山", Start: 0, End: 63.25},
+		{Title: "第2章 a=b;c#d", Start: 63.25, End: 120},
+	})
+
+	want := ";FFMETADATA1\n" +
+		"\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=0\nEND=63250\ntitle=第1章 下山\n" +
+		"\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=63250\nEND=120000\ntitle=第2章 a\\=b\\;c\\#d\n"
+	if got != want {
+		t.Errorf("unexpected metadata:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestBuildTitleCardFilter(t *testing.T) {
+	filter := buildTitleCardFilter(TitleCard{Title: "第1章: 下山", Subtitle: "剑来", Duration: 3})
+
+	parts := strings.Split(filter, ",")
+	if len(parts) != 4 {
+		t.Fatalf("expected 4 filters, got %d: %s", len(parts), filter)
+	}
+	if !strings.HasPrefix(parts[0], `drawtext=text='第1章\: 下山':fontcolor=white`) {
+		t.Errorf("unexpected title filter: %s", parts[0])
+	}
+	if !strings.HasPrefix(parts[1], "drawtext=text='剑来'") {
+		t.Errorf("unexpected subtitle filter: %s", parts[1])
+	}
+	if parts[3] != "fade=t=out:st=2.500:d=0.500" {
+		t.Errorf("unexpected fade out filter: %s", parts[3])
+	}
+}
+
+func TestBuildNormalizeArgs(t *testing.T) {
+	args := strings.Join(buildNormalizeArgs("in.mp4", "out.mp4", 1920, 1080, 30), " ")
+	for _, want := range []string{
+		"scale=1920:1080:force_original_aspect_ratio=decrease,pad=1920:1080:(ow-iw)/2:(oh-ih)/2",
+		"-r 30",
+		"aresample=44100,aformat=channel_layouts=stereo",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("args %q do not contain %q", args, want)
+		}
+	}
+}
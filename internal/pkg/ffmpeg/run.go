@@ -0,0 +1,60 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"lemon/internal/pkg/errclass"
+	"lemon/internal/pkg/procreaper"
+	"lemon/internal/pkg/tasklog"
+)
+
+const (
+	// stderrExcerptChars 命令失败时写入任务日志的 stderr 末尾片段长度
+	stderrExcerptChars = 2000
+	// argsExcerptChars 写入任务日志的命令参数最大长度（滤镜图可能很长）
+	argsExcerptChars = 1000
+)
+
+// run 执行 ffmpeg 命令并把结果写入 context 关联的任务日志
+// stderr 仍然输出到命令原本的目标（未设置时为进程 stderr），同时保留一份，命令失败时把末尾片段写入任务日志
+// 命令失败的错误标记为 ffmpeg_codec；context 关联了进程回收器时记录进程 PID，任务结束后仍在运行的进程会被回收
+func run(ctx context.Context, cmd *exec.Cmd) error {
+	var stderr bytes.Buffer
+	target := cmd.Stderr
+	if target == nil {
+		target = os.Stderr
+	}
+	cmd.Stderr = io.MultiWriter(target, &stderr)
+
+	start := time.Now()
+	err := errclass.Wrap(errclass.FFmpegCodec, "ffmpeg", procreaper.Run(ctx, cmd))
+
+	logger := tasklog.FromContext(ctx)
+	if logger == nil {
+		return err
+	}
+	args := strings.Join(cmd.Args[1:], " ")
+	if len(args) > argsExcerptChars {
+		args = args[:argsExcerptChars] + "..."
+	}
+	fields := tasklog.Fields{
+		"command":     filepath.Base(cmd.Path),
+		"args":        args,
+		"duration_ms": time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+		fields["stderr"] = tailString(stderr.String(), stderrExcerptChars)
+		logger.Error("ffmpeg 命令执行失败", fields)
+		return err
+	}
+	logger.Info("ffmpeg 命令执行完成", fields)
+	return nil
+}
@@ -0,0 +1,51 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"lemon/internal/pkg/tasklog"
+)
+
+func TestRunLogsStderrExcerptToTask(t *testing.T) {
+	store := tasklog.NewStore(10, 10)
+	ctx := tasklog.NewContext(context.Background(), store, "task-1")
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", "echo 'Invalid data found' >&2; exit 1")
+	cmd.Stderr = &stderr
+	if err := run(ctx, cmd); err == nil {
+		t.Fatal("expected command to fail")
+	}
+	if !strings.Contains(stderr.String(), "Invalid data found") {
+		t.Errorf("original stderr target lost output: %q", stderr.String())
+	}
+
+	if err := run(ctx, exec.CommandContext(ctx, "sh", "-c", "true")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	snap, ok := store.Snapshot("task-1", 0, 0)
+	if !ok || len(snap.Entries) != 2 {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+	failed := snap.Entries[0]
+	if failed.Level != tasklog.LevelError || !strings.Contains(failed.Fields["stderr"].(string), "Invalid data found") {
+		t.Errorf("failed entry = %+v", failed)
+	}
+	if failed.Fields["command"] != "sh" {
+		t.Errorf("command = %v, want sh", failed.Fields["command"])
+	}
+	if snap.Entries[1].Level != tasklog.LevelInfo {
+		t.Errorf("succeeded entry = %+v", snap.Entries[1])
+	}
+}
+
+func TestRunWithoutTask(t *testing.T) {
+	if err := run(context.Background(), exec.Command("sh", "-c", "true")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInvalidShotAssembly 镜头合成参数不合法
+var ErrInvalidShotAssembly = errors.New("invalid shot assembly")
+
+// ShotAssembly 单镜头合成参数
+// 画面来源为 VideoPath（如图生视频接口返回的视频）或 ImagePath（使用 Ken Burns 效果生成画面），二选一
+type ShotAssembly struct {
+	VideoPath    string  // 画面来源视频
+	ImagePath    string  // 画面来源图片
+	AudioPath    string  // 镜头音频（替换画面来源的原有音轨），为空时输出无声视频
+	SubtitlePath string  // ASS 字幕，为空时不烧录字幕
+	Duration     float64 // 输出时长（秒，通常为音频时长）；视频比音频短时用最后一帧补齐
+	TailPause    float64 // 音频结束后的停顿（秒）：画面继续、音频补静音，实际输出时长为 Duration + TailPause
+	Width        int     // 输出宽度
+	Height       int     // 输出高度
+	FPS          int     // 输出帧率
+	Preview      bool    // 预览渲染：使用更快的编码预设（画质略低、文件略大），用于交互式预览，不用于正式版本
+}
+
+// 正式渲染和预览渲染的编码参数
+const (
+	shotPreset        = "medium"
+	shotCRF           = "20"
+	shotPreviewPreset = "ultrafast"
+	shotPreviewCRF    = "26"
+)
+
+// AssembleShot 单次调用 FFmpeg 合成镜头片段
+// 在同一个 filter graph 中完成缩放裁剪（图片输入时叠加 Ken Burns 效果）、帧率统一、字幕烧录，并混入镜头音频，
+// 替代「图片生成视频 → 烧录字幕 → 替换音频 → 标准化」的多次调用，只编码一次
+func (c *Client) AssembleShot(ctx context.Context, shot ShotAssembly, outputPath string) error {
+	args, err := buildShotAssemblyArgs(shot, outputPath)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	cmd := exec.CommandContext(ctx, c.ffmpegPath, args...)
+	if err := run(ctx, cmd); err != nil {
+		return fmt.Errorf("ffmpeg assemble shot failed: %w", err)
+	}
+
+	log.Info().
+		Str("video", shot.VideoPath).
+		Str("image", shot.ImagePath).
+		Str("audio", shot.AudioPath).
+		Str("subtitle", shot.SubtitlePath).
+		Float64("duration", shot.Duration).
+		Bool("preview", shot.Preview).
+		Dur("elapsed", time.Since(start)).
+		Str("output", outputPath).
+		Msg("镜头片段合成成功")
+
+	return nil
+}
+
+// buildShotAssemblyArgs 构建单次合成镜头片段的 FFmpeg 参数
+// 输入 0 为画面（视频或循环的图片），输入 1 为音频（可选）；视频输出标签为 [vout]
+func buildShotAssemblyArgs(shot ShotAssembly, outputPath string) ([]string, error) {
+	if (shot.VideoPath == "") == (shot.ImagePath == "") {
+		return nil, fmt.Errorf("%w: exactly one of video or image is required", ErrInvalidShotAssembly)
+	}
+	if shot.Duration <= 0 || shot.Width <= 0 || shot.Height <= 0 || shot.FPS <= 0 {
+		return nil, fmt.Errorf("%w: duration, size and fps must be positive", ErrInvalidShotAssembly)
+	}
+
+	duration := fmt.Sprintf("%.3f", shot.totalDuration())
+	args := []string{"-y"}
+	if shot.ImagePath != "" {
+		args = append(args, "-loop", "1", "-framerate", fmt.Sprintf("%d", shot.FPS), "-t", duration, "-i", shot.ImagePath)
+	} else {
+		args = append(args, "-i", shot.VideoPath)
+	}
+	filter := buildShotAssemblyFilter(shot)
+	preset, crf := shotPreset, shotCRF
+	if shot.Preview {
+		preset, crf = shotPreviewPreset, shotPreviewCRF
+	}
+	audio := []string{"-an"}
+	if shot.AudioPath != "" {
+		args = append(args, "-i", shot.AudioPath)
+		audio = []string{"-map", "1:a:0", "-c:a", "aac", "-b:a", "160k"}
+		if shot.TailPause > 0 {
+			// 停顿期间补静音，保证音轨和画面等长，拼接后后续片段的音画不会错位
+			filter += fmt.Sprintf(";[1:a]apad=pad_dur=%.3f[aout]", shot.TailPause)
+			audio[1] = "[aout]"
+		}
+	}
+
+	args = append(args,
+		"-filter_complex", filter,
+		"-map", "[vout]",
+	)
+	args = append(args, audio...)
+	return append(args,
+		"-t", duration,
+		"-r", fmt.Sprintf("%d", shot.FPS),
+		"-c:v", "libx264",
+		"-crf", crf,
+		"-preset", preset,
+		"-pix_fmt", "yuv420p",
+		"-movflags", "+faststart",
+		outputPath,
+	), nil
+}
+
+// buildShotAssemblyFilter 构建镜头合成的视频 filter graph
+// 先缩放裁剪到目标分辨率再烧录字幕，保证字幕按最终画面尺寸渲染
+func buildShotAssemblyFilter(shot ShotAssembly) string {
+	filters := []string{
+		fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=increase", shot.Width, shot.Height),
+		fmt.Sprintf("crop=%d:%d", shot.Width, shot.Height),
+	}
+	if shot.ImagePath != "" {
+		// 与 CreateImageVideo 相同的缓慢推进效果
+		totalFrames := int(shot.totalDuration() * float64(shot.FPS))
+		filters = append(filters, fmt.Sprintf("zoompan=z='min(1.0+on*0.0008,1.3)':x='iw/2-(iw/zoom/2)':y='ih/2-(ih/zoom/2)':d=%d:s=%dx%d:fps=%d",
+			totalFrames, shot.Width, shot.Height, shot.FPS))
+	} else {
+		// 画面来源视频比音频短时定格最后一帧，避免结尾黑屏或音画提前结束
+		filters = append(filters,
+			fmt.Sprintf("fps=%d", shot.FPS),
+			fmt.Sprintf("tpad=stop_mode=clone:stop_duration=%.3f", shot.totalDuration()),
+		)
+	}
+	filters = append(filters, "setsar=1")
+	if shot.SubtitlePath != "" {
+		filters = append(filters, "ass="+escapeFilterValue(shot.SubtitlePath))
+	}
+	filters = append(filters, "format=yuv420p")
+	return "[0:v]" + strings.Join(filters, ",") + "[vout]"
+}
+
+// totalDuration 输出时长（音频时长加上结尾停顿）
+func (shot ShotAssembly) totalDuration() float64 {
+	return shot.Duration + max(shot.TailPause, 0)
+}
+
+// escapeFilterValue 转义 filter graph 中的滤镜参数值
+// 需要两层转义：先转义滤镜参数中的特殊字符，再转义 filter graph 中的特殊字符
+func escapeFilterValue(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `'`, `\'`, `:`, `\:`).Replace(s)
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`'`, `\'`,
+		`[`, `\[`,
+		`]`, `\]`,
+		`,`, `\,`,
+		`;`, `\;`,
+	).Replace(s)
+}
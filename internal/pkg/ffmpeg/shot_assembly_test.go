@@ -0,0 +1,247 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildShotAssemblyArgs(t *testing.T) {
+	args, err := buildShotAssemblyArgs(ShotAssembly{
+		ImagePath:    "shot.jpg",
+		AudioPath:    "shot.mp3",
+		SubtitlePath: "/tmp/shot.ass",
+		Duration:     15,
+		Width:        720,
+		Height:       1280,
+		FPS:          30,
+	}, "out.mp4")
+	if err != nil {
+		t.Fatalf("build args: %v", err)
+	}
+
+	joined := strings.Join(args, " ")
+	if !strings.HasPrefix(joined, "-y -loop 1 -framerate 30 -t 15.000 -i shot.jpg -i shot.mp3 -filter_complex ") {
+		t.Errorf("unexpected inputs: %s", joined)
+	}
+	if !strings.Contains(joined, "-map [vout] -map 1:a:0 -c:a aac -b:a 160k -t 15.000") || !strings.HasSuffix(joined, "out.mp4") {
+		t.Errorf("unexpected outputs: %s", joined)
+	}
+
+	// 图片输入：缩放裁剪后叠加 Ken Burns，再烧录字幕，只有一条视频滤镜链
+	filter := args[indexOf(args, "-filter_complex")+1]
+	want := "[0:v]scale=720:1280:force_original_aspect_ratio=increase,crop=720:1280," +
+		"zoompan=z='min(1.0+on*0.0008,1.3)':x='iw/2-(iw/zoom/2)':y='ih/2-(ih/zoom/2)':d=450:s=720x1280:fps=30," +
+		"setsar=1,ass=/tmp/shot.ass,format=yuv420p[vout]"
+	if filter != want {
+		t.Errorf("unexpected image filter:\n got %s\nwant %s", filter, want)
+	}
+}
+
+func TestBuildShotAssemblyFilterVideoInput(t *testing.T) {
+	filter := buildShotAssemblyFilter(ShotAssembly{
+		VideoPath: "ark.mp4",
+		AudioPath: "shot.mp3",
+		Duration:  8.5,
+		Width:     720,
+		Height:    1280,
+		FPS:       30,
+	})
+	want := "[0:v]scale=720:1280:force_original_aspect_ratio=increase,crop=720:1280," +
+		"fps=30,tpad=stop_mode=clone:stop_duration=8.500,setsar=1,format=yuv420p[vout]"
+	if filter != want {
+		t.Errorf("unexpected video filter:\n got %s\nwant %s", filter, want)
+	}
+}
+
+func TestBuildShotAssemblyArgsPreview(t *testing.T) {
+	shot := ShotAssembly{
+		VideoPath: "ark.mp4",
+		AudioPath: "shot.mp3",
+		Duration:  5,
+		Width:     720,
+		Height:    1280,
+		FPS:       30,
+	}
+	args, err := buildShotAssemblyArgs(shot, "out.mp4")
+	if err != nil {
+		t.Fatalf("build args: %v", err)
+	}
+	if joined := strings.Join(args, " "); !strings.Contains(joined, "-crf 20 -preset medium") {
+		t.Errorf("unexpected final encode args: %s", joined)
+	}
+
+	// 预览渲染只改变编码预设，滤镜和输出时长不变
+	shot.Preview = true
+	previewArgs, err := buildShotAssemblyArgs(shot, "out.mp4")
+	if err != nil {
+		t.Fatalf("build preview args: %v", err)
+	}
+	if joined := strings.Join(previewArgs, " "); !strings.Contains(joined, "-crf 26 -preset ultrafast") {
+		t.Errorf("unexpected preview encode args: %s", joined)
+	}
+	if got, want := previewArgs[indexOf(previewArgs, "-filter_complex")+1], args[indexOf(args, "-filter_complex")+1]; got != want {
+		t.Errorf("preview filter changed:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestBuildShotAssemblyArgsSilent(t *testing.T) {
+	args, err := buildShotAssemblyArgs(ShotAssembly{
+		ImagePath: "shot.jpg",
+		Duration:  2,
+		Width:     720,
+		Height:    1280,
+		FPS:       30,
+	}, "out.mp4")
+	if err != nil {
+		t.Fatalf("build args: %v", err)
+	}
+
+	// 没有音频时只有一个输入，输出不带音轨
+	joined := strings.Join(args, " ")
+	if strings.Count(joined, "-i ") != 1 || !strings.Contains(joined, "-map [vout] -an -t 2.000") || strings.Contains(joined, "-c:a") {
+		t.Errorf("unexpected silent args: %s", joined)
+	}
+}
+
+func TestBuildShotAssemblyArgsTailPause(t *testing.T) {
+	args, err := buildShotAssemblyArgs(ShotAssembly{
+		VideoPath: "ark.mp4",
+		AudioPath: "shot.mp3",
+		Duration:  4,
+		TailPause: 0.5,
+		Width:     720,
+		Height:    1280,
+		FPS:       30,
+	}, "out.mp4")
+	if err != nil {
+		t.Fatalf("build args: %v", err)
+	}
+
+	// 结尾停顿：画面定格到 4.5 秒，音频补静音，输出时长包含停顿
+	joined := strings.Join(args, " ")
+	if !strings.Contains(joined, "-map [vout] -map [aout] -c:a aac -b:a 160k -t 4.500") {
+		t.Errorf("unexpected outputs: %s", joined)
+	}
+	filter := args[indexOf(args, "-filter_complex")+1]
+	if !strings.Contains(filter, "tpad=stop_mode=clone:stop_duration=4.500") || !strings.HasSuffix(filter, ";[1:a]apad=pad_dur=0.500[aout]") {
+		t.Errorf("unexpected filter: %s", filter)
+	}
+}
+
+func TestBuildShotAssemblyArgsInvalid(t *testing.T) {
+	cases := map[string]ShotAssembly{
+		"no source":   {AudioPath: "a.mp3", Duration: 1, Width: 720, Height: 1280, FPS: 30},
+		"two sources": {VideoPath: "v.mp4", ImagePath: "i.jpg", AudioPath: "a.mp3", Duration: 1, Width: 720, Height: 1280, FPS: 30},
+		"no duration": {VideoPath: "v.mp4", AudioPath: "a.mp3", Width: 720, Height: 1280, FPS: 30},
+	}
+	for name, shot := range cases {
+		if _, err := buildShotAssemblyArgs(shot, "out.mp4"); !errors.Is(err, ErrInvalidShotAssembly) {
+			t.Errorf("%s: expected ErrInvalidShotAssembly, got %v", name, err)
+		}
+	}
+}
+
+func TestEscapeFilterValue(t *testing.T) {
+	cases := map[string]string{
+		"/tmp/subtitle_1.ass": "/tmp/subtitle_1.ass",
+		"/tmp/a:b.ass":        `/tmp/a\\:b.ass`,
+		"/tmp/a,b[1].ass":     `/tmp/a\,b\[1\].ass`,
+	}
+	for in, want := range cases {
+		if got := escapeFilterValue(in); got != want {
+			t.Errorf("escapeFilterValue(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// BenchmarkShotAssembly 对比多次调用 FFmpeg 的串行合成和单次 filter graph 合成
+// 需要本机安装带 libass 的 ffmpeg：go test -run '^$' -bench ShotAssembly ./internal/pkg/ffmpeg/
+func BenchmarkShotAssembly(b *testing.B) {
+	ctx := context.Background()
+	c := NewClient()
+	if _, err := exec.LookPath(c.ffmpegPath); err != nil {
+		b.Skipf("ffmpeg not found: %v", err)
+	}
+	if err := c.ProbeSubtitleRendering(ctx); err != nil {
+		b.Skipf("subtitle rendering unavailable: %v", err)
+	}
+
+	dir := b.TempDir()
+	imagePath := filepath.Join(dir, "shot.png")
+	audioPath := filepath.Join(dir, "shot.mp3")
+	assPath := filepath.Join(dir, "shot.ass")
+	fixtures := [][]string{
+		{"-y", "-v", "error", "-f", "lavfi", "-i", "testsrc2=s=1024x1024", "-frames:v", "1", imagePath},
+		{"-y", "-v", "error", "-f", "lavfi", "-i", "sine=frequency=440:duration=5", audioPath},
+	}
+	for _, args := range fixtures {
+		if out, err := exec.CommandContext(ctx, c.ffmpegPath, args...).CombinedOutput(); err != nil {
+			b.Fatalf("create fixture: %v: %s", err, out)
+		}
+	}
+	if err := os.WriteFile(assPath, []byte(probeASS), 0o644); err != nil {
+		b.Fatalf("write subtitle: %v", err)
+	}
+
+	const (
+		duration = 5.0
+		width    = 720
+		height   = 1280
+		fps      = 30
+	)
+
+	b.Run("chained", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			video := filepath.Join(dir, "chained_video.mp4")
+			withSubtitle := filepath.Join(dir, "chained_subtitle.mp4")
+			withAudio := filepath.Join(dir, "chained_audio.mp4")
+			output := filepath.Join(dir, "chained_std.mp4")
+			if err := c.CreateImageVideo(ctx, imagePath, video, duration, width, height, fps); err != nil {
+				b.Fatal(err)
+			}
+			if err := c.AddSubtitles(ctx, video, assPath, withSubtitle); err != nil {
+				b.Fatal(err)
+			}
+			replace := exec.CommandContext(ctx, c.ffmpegPath, "-y", "-v", "error",
+				"-i", withSubtitle, "-i", audioPath,
+				"-c:v", "copy", "-c:a", "aac", "-map", "0:v:0", "-map", "1:a:0", withAudio)
+			if out, err := replace.CombinedOutput(); err != nil {
+				b.Fatalf("replace audio: %v: %s", err, out)
+			}
+			if err := c.StandardizeVideo(ctx, withAudio, output, width, height, fps); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("single_pass", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			err := c.AssembleShot(ctx, ShotAssembly{
+				ImagePath:    imagePath,
+				AudioPath:    audioPath,
+				SubtitlePath: assPath,
+				Duration:     duration,
+				Width:        width,
+				Height:       height,
+				FPS:          fps,
+			}, filepath.Join(dir, "single.mp4"))
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func indexOf(args []string, target string) int {
+	for i, arg := range args {
+		if arg == target {
+			return i
+		}
+	}
+	return -1
+}
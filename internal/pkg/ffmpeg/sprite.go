@@ -0,0 +1,121 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os/exec"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DefaultSpriteInterval  = 2.0 // 相邻缩略图的默认时间间隔（秒）
+	DefaultSpriteColumns   = 10  // 雪碧图默认列数
+	DefaultSpriteTileWidth = 160 // 缩略图默认宽度（像素）
+
+	// maxSpriteTiles 单张雪碧图最多包含的缩略图数量，视频较长时加大间隔
+	maxSpriteTiles = 300
+)
+
+// SpriteLayout 缩略图雪碧图布局
+// 缩略图按时间顺序从左到右、从上到下排列，第 i 张对应 [i*Interval, (i+1)*Interval) 的画面
+type SpriteLayout struct {
+	Interval   float64 // 相邻缩略图的时间间隔（秒）
+	Count      int     // 缩略图数量
+	Columns    int     // 列数
+	Rows       int     // 行数
+	TileWidth  int     // 缩略图宽度（像素）
+	TileHeight int     // 缩略图高度（像素，按视频宽高比计算）
+}
+
+// NewSpriteLayout 根据视频时长和分辨率计算雪碧图布局
+// interval、columns、tileWidth <= 0 时使用默认值；缩略图数量超过上限时自动加大间隔
+func NewSpriteLayout(duration float64, videoWidth, videoHeight int, interval float64, columns, tileWidth int) (SpriteLayout, error) {
+	if duration <= 0 || videoWidth <= 0 || videoHeight <= 0 {
+		return SpriteLayout{}, fmt.Errorf("invalid video: duration=%.2f, size=%dx%d", duration, videoWidth, videoHeight)
+	}
+	if interval <= 0 {
+		interval = DefaultSpriteInterval
+	}
+	if columns <= 0 {
+		columns = DefaultSpriteColumns
+	}
+	if tileWidth <= 0 {
+		tileWidth = DefaultSpriteTileWidth
+	}
+
+	count := int(math.Ceil(duration / interval))
+	if count > maxSpriteTiles {
+		interval = duration / maxSpriteTiles
+		count = maxSpriteTiles
+	}
+	columns = min(columns, count)
+
+	// 高度取偶数，满足 yuvj420p 编码要求
+	tileHeight := int(math.Round(float64(tileWidth)*float64(videoHeight)/float64(videoWidth)/2)) * 2
+	return SpriteLayout{
+		Interval:   interval,
+		Count:      count,
+		Columns:    columns,
+		Rows:       (count + columns - 1) / columns,
+		TileWidth:  tileWidth,
+		TileHeight: max(tileHeight, 2),
+	}, nil
+}
+
+// GenerateThumbnailSprite 按布局把视频截取的缩略图拼成一张 JPEG 雪碧图
+func (c *Client) GenerateThumbnailSprite(ctx context.Context, videoPath, outputPath string, layout SpriteLayout) error {
+	// ffmpeg -i video.mp4 -vf "fps=0.5,scale=160:284,tile=10x3" -frames:v 1 -q:v 5 sprite.jpg
+	filter := fmt.Sprintf("fps=%.6f,scale=%d:%d,tile=%dx%d",
+		1/layout.Interval, layout.TileWidth, layout.TileHeight, layout.Columns, layout.Rows)
+	args := []string{
+		"-y",
+		"-i", videoPath,
+		"-vf", filter,
+		"-an",
+		"-frames:v", "1",
+		"-q:v", "5",
+		outputPath,
+	}
+
+	cmd := exec.CommandContext(ctx, c.ffmpegPath, args...)
+	if err := run(ctx, cmd); err != nil {
+		return fmt.Errorf("ffmpeg generate thumbnail sprite failed: %w", err)
+	}
+
+	log.Info().
+		Str("video", videoPath).
+		Int("tiles", layout.Count).
+		Float64("interval", layout.Interval).
+		Str("output", outputPath).
+		Msg("缩略图雪碧图生成成功")
+
+	return nil
+}
+
+// BuildSpriteVTT 生成雪碧图的 WebVTT 索引
+// 每个时间段对应雪碧图中的一块区域（spriteURL#xywh=x,y,w,h），播放器/编辑器拖动进度条时据此显示预览图
+func BuildSpriteVTT(spriteURL string, layout SpriteLayout, duration float64) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+	for i := 0; i < layout.Count; i++ {
+		start := float64(i) * layout.Interval
+		end := math.Min(start+layout.Interval, duration)
+		if start >= end {
+			break
+		}
+		x := (i % layout.Columns) * layout.TileWidth
+		y := (i / layout.Columns) * layout.TileHeight
+		fmt.Fprintf(&b, "\n%s --> %s\n%s#xywh=%d,%d,%d,%d\n",
+			formatVTTTime(start), formatVTTTime(end), spriteURL, x, y, layout.TileWidth, layout.TileHeight)
+	}
+	return b.String()
+}
+
+// formatVTTTime 格式化为 WebVTT 时间戳（HH:MM:SS.mmm）
+func formatVTTTime(seconds float64) string {
+	ms := int64(math.Round(seconds * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
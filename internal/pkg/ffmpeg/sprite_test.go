@@ -0,0 +1,51 @@
+package ffmpeg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewSpriteLayout(t *testing.T) {
+	layout, err := NewSpriteLayout(25, 720, 1280, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("NewSpriteLayout() error = %v", err)
+	}
+	want := SpriteLayout{Interval: 2, Count: 13, Columns: 10, Rows: 2, TileWidth: 160, TileHeight: 284}
+	if layout != want {
+		t.Errorf("NewSpriteLayout() = %+v, want %+v", layout, want)
+	}
+
+	// 短视频列数不超过缩略图数量
+	if layout, _ := NewSpriteLayout(3, 1280, 720, 2, 10, 160); layout.Columns != 2 || layout.Rows != 1 || layout.TileHeight != 90 {
+		t.Errorf("short video layout = %+v", layout)
+	}
+
+	// 超长视频加大间隔
+	if layout, _ := NewSpriteLayout(3000, 1280, 720, 2, 10, 160); layout.Count != maxSpriteTiles || layout.Interval != 10 {
+		t.Errorf("long video layout = %+v", layout)
+	}
+
+	if _, err := NewSpriteLayout(0, 1280, 720, 2, 10, 160); err == nil {
+		t.Error("expected error for zero duration")
+	}
+}
+
+func TestBuildSpriteVTT(t *testing.T) {
+	layout := SpriteLayout{Interval: 2, Count: 3, Columns: 2, Rows: 2, TileWidth: 160, TileHeight: 90}
+	vtt := BuildSpriteVTT("sprite.jpg", layout, 5.5)
+
+	want := "WEBVTT\n" +
+		"\n00:00:00.000 --> 00:00:02.000\nsprite.jpg#xywh=0,0,160,90\n" +
+		"\n00:00:02.000 --> 00:00:04.000\nsprite.jpg#xywh=160,0,160,90\n" +
+		"\n00:00:04.000 --> 00:00:05.500\nsprite.jpg#xywh=0,90,160,90\n"
+	if vtt != want {
+		t.Errorf("BuildSpriteVTT() =\n%s\nwant\n%s", vtt, want)
+	}
+
+	if got := formatVTTTime(3725.25); got != "01:02:05.250" {
+		t.Errorf("formatVTTTime() = %q", got)
+	}
+	if !strings.HasPrefix(BuildSpriteVTT("s.jpg", layout, 0), "WEBVTT\n") {
+		t.Error("empty duration should still produce a header")
+	}
+}
@@ -0,0 +1,115 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"strings"
+)
+
+const (
+	// DefaultWaveformPixelsPerSecond 波形每秒的峰值点数
+	DefaultWaveformPixelsPerSecond = 50
+
+	// waveformSampleRate 提取波形时重采样的采样率（只用于画波形，不需要高采样率）
+	waveformSampleRate = 8000
+)
+
+// Waveform 音频波形峰值
+// 兼容 BBC audiowaveform 的 JSON 格式（version 2），编辑器的波形组件（如 peaks.js）可直接读取
+type Waveform struct {
+	Version         int     `json:"version"`           // 格式版本，固定为 2
+	Channels        int     `json:"channels"`          // 声道数（混为单声道，固定为 1）
+	SampleRate      int     `json:"sample_rate"`       // 采样率
+	SamplesPerPixel int     `json:"samples_per_pixel"` // 每个峰值点对应的采样数
+	Bits            int     `json:"bits"`              // 峰值位深，固定为 16
+	Length          int     `json:"length"`            // 峰值点数
+	Data            []int16 `json:"data"`              // 每个点的最小值、最大值交替排列
+}
+
+// ExtractWaveform 解码音频（或视频的音轨）并按 pixelsPerSecond 计算波形峰值
+// 音频混为单声道、重采样为 8kHz 的 16 位 PCM 后通过管道读取，不落盘
+func (c *Client) ExtractWaveform(ctx context.Context, audioPath string, pixelsPerSecond int) (*Waveform, error) {
+	if pixelsPerSecond <= 0 {
+		pixelsPerSecond = DefaultWaveformPixelsPerSecond
+	}
+	samplesPerPixel := max(waveformSampleRate/pixelsPerSecond, 1)
+
+	args := []string{
+		"-v", "error",
+		"-i", audioPath,
+		"-vn",
+		"-ac", "1",
+		"-ar", fmt.Sprintf("%d", waveformSampleRate),
+		"-f", "s16le",
+		"-acodec", "pcm_s16le",
+		"pipe:1",
+	}
+
+	cmd := exec.CommandContext(ctx, c.ffmpegPath, args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start ffmpeg: %w", err)
+	}
+
+	waveform, readErr := computeWaveform(stdout, waveformSampleRate, samplesPerPixel)
+	if readErr != nil {
+		// 读取失败时排空输出，避免 ffmpeg 阻塞在写管道上
+		_, _ = io.Copy(io.Discard, stdout)
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("ffmpeg extract waveform failed: %w: %s", err, tailString(stderr.String(), 500))
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	return waveform, nil
+}
+
+// computeWaveform 从 16 位小端单声道 PCM 计算每 samplesPerPixel 个采样的最小值和最大值
+func computeWaveform(r io.Reader, sampleRate, samplesPerPixel int) (*Waveform, error) {
+	w := &Waveform{
+		Version:         2,
+		Channels:        1,
+		SampleRate:      sampleRate,
+		SamplesPerPixel: samplesPerPixel,
+		Bits:            16,
+		Data:            []int16{},
+	}
+
+	br := bufio.NewReader(r)
+	var sample [2]byte
+	lo, hi := int16(math.MaxInt16), int16(math.MinInt16)
+	n := 0
+	for {
+		if _, err := io.ReadFull(br, sample[:]); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return nil, fmt.Errorf("read pcm: %w", err)
+		}
+		v := int16(binary.LittleEndian.Uint16(sample[:]))
+		lo, hi = min(lo, v), max(hi, v)
+		n++
+		if n == samplesPerPixel {
+			w.Data = append(w.Data, lo, hi)
+			lo, hi = math.MaxInt16, math.MinInt16
+			n = 0
+		}
+	}
+	if n > 0 {
+		w.Data = append(w.Data, lo, hi)
+	}
+	w.Length = len(w.Data) / 2
+	return w, nil
+}
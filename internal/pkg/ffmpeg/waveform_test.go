@@ -0,0 +1,38 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestComputeWaveform(t *testing.T) {
+	var pcm bytes.Buffer
+	for _, v := range []int16{-100, 200, 50, -300, 1000, 0, 7} {
+		binary.Write(&pcm, binary.LittleEndian, v)
+	}
+	pcm.WriteByte(0x01) // 不完整的采样被忽略
+
+	w, err := computeWaveform(&pcm, 8000, 3)
+	if err != nil {
+		t.Fatalf("computeWaveform() error = %v", err)
+	}
+	want := []int16{-100, 200, -300, 1000, 7, 7}
+	if w.Length != 3 || len(w.Data) != len(want) {
+		t.Fatalf("length = %d, data = %v, want %v", w.Length, w.Data, want)
+	}
+	for i := range want {
+		if w.Data[i] != want[i] {
+			t.Errorf("data = %v, want %v", w.Data, want)
+			break
+		}
+	}
+	if w.Version != 2 || w.Channels != 1 || w.Bits != 16 || w.SampleRate != 8000 || w.SamplesPerPixel != 3 {
+		t.Errorf("unexpected header: %+v", w)
+	}
+
+	empty, err := computeWaveform(bytes.NewReader(nil), 8000, 3)
+	if err != nil || empty.Length != 0 || empty.Data == nil {
+		t.Errorf("empty input: %+v, %v", empty, err)
+	}
+}
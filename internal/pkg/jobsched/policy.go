@@ -0,0 +1,99 @@
+// Package jobsched 任务队列调度策略及其模拟
+// 调度策略决定空闲工作协程从就绪任务中领取哪一个；模拟模式用合成的工作负载（交互/批量混合、多租户）回放调度过程，
+// 不执行真实任务，输出等待时间、公平性和饥饿指标，用于在修改调度策略前评估效果
+package jobsched
+
+import "time"
+
+// Class 任务类别
+type Class string
+
+const (
+	ClassInteractive Class = "interactive" // 交互任务：用户在页面上直接提交、等待结果的单个任务
+	ClassBatch       Class = "batch"       // 批量任务：一键生成流程等批量提交的任务
+)
+
+// Job 调度视角下的任务
+type Job struct {
+	ID       string        `json:"id"`
+	Tenant   string        `json:"tenant"`    // 租户（提交任务的用户或小说）
+	Class    Class         `json:"class"`     // 类别
+	SubmitAt time.Duration `json:"submit_at"` // 提交时间（相对模拟开始，纳秒）
+	Duration time.Duration `json:"duration"`  // 执行耗时（纳秒）
+}
+
+// Policy 调度策略
+type Policy interface {
+	// Name 策略名称
+	Name() string
+	// Next 从就绪任务中选出下一个执行的任务，返回其下标；running 为各租户执行中的任务数
+	// ready 非空，按提交顺序排列
+	Next(ready []*Job, running map[string]int) int
+}
+
+const (
+	PolicyFIFO             = "fifo"
+	PolicyInteractiveFirst = "interactive-first"
+	PolicyFairShare        = "fair-share"
+)
+
+// PolicyNames 所有内置策略的名称（第一个为当前任务队列使用的策略）
+var PolicyNames = []string{PolicyFIFO, PolicyInteractiveFirst, PolicyFairShare}
+
+// NewPolicy 按名称创建内置策略，名称不存在时返回 false
+func NewPolicy(name string) (Policy, bool) {
+	switch name {
+	case PolicyFIFO:
+		return fifo{}, true
+	case PolicyInteractiveFirst:
+		return interactiveFirst{}, true
+	case PolicyFairShare:
+		return fairShare{}, true
+	}
+	return nil, false
+}
+
+// fifo 先提交先执行，与任务队列领取任务的顺序一致（JobRepo.Claim 按 run_after、created_at 排序）
+type fifo struct{}
+
+func (fifo) Name() string { return PolicyFIFO }
+
+func (fifo) Next(ready []*Job, _ map[string]int) int {
+	return 0
+}
+
+// interactiveFirst 交互任务优先，同类别先提交先执行（批量任务在交互任务持续到达时可能饥饿）
+type interactiveFirst struct{}
+
+func (interactiveFirst) Name() string { return PolicyInteractiveFirst }
+
+func (interactiveFirst) Next(ready []*Job, _ map[string]int) int {
+	for i, job := range ready {
+		if job.Class == ClassInteractive {
+			return i
+		}
+	}
+	return 0
+}
+
+// fairShare 执行中任务最少的租户优先，同一租户交互任务优先，其余先提交先执行
+type fairShare struct{}
+
+func (fairShare) Name() string { return PolicyFairShare }
+
+func (fairShare) Next(ready []*Job, running map[string]int) int {
+	best := 0
+	for i := 1; i < len(ready); i++ {
+		a, b := ready[i], ready[best]
+		if ra, rb := running[a.Tenant], running[b.Tenant]; ra != rb {
+			if ra < rb {
+				best = i
+			}
+			continue
+		}
+		if a.Class == ClassInteractive && b.Class != ClassInteractive {
+			best = i
+		}
+	}
+	return best
+}
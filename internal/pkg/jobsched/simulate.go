@@ -0,0 +1,213 @@
+package jobsched
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"time"
+)
+
+// DefaultStarvationThreshold 默认的饥饿阈值：等待超过该时间的任务计为饥饿
+const DefaultStarvationThreshold = 10 * time.Minute
+
+// WaitStats 一组任务的等待时间统计
+type WaitStats struct {
+	Count        int           `json:"count"`
+	Mean         time.Duration `json:"mean"`
+	P50          time.Duration `json:"p50"`
+	P95          time.Duration `json:"p95"`
+	Max          time.Duration `json:"max"`
+	MeanSlowdown float64       `json:"mean_slowdown"` // 平均减速比：(等待 + 执行) / 执行，1 表示无需等待
+	Starved      int           `json:"starved"`       // 等待超过饥饿阈值的任务数
+	// Interference 受其他租户干扰的程度（仅按租户统计）：平均减速比 / 该租户单独回放时的平均减速比，1 表示不受干扰
+	Interference float64 `json:"interference,omitempty"`
+}
+
+// Report 一次模拟的结果
+type Report struct {
+	Policy              string               `json:"policy"`
+	Workers             int                  `json:"workers"`
+	Jobs                int                  `json:"jobs"`
+	Makespan            time.Duration        `json:"makespan"`             // 第一个任务提交到最后一个任务完成的时间
+	Utilization         float64              `json:"utilization"`          // 工作协程利用率（0~1）
+	StarvationThreshold time.Duration        `json:"starvation_threshold"` // 饥饿阈值
+	Overall             WaitStats            `json:"overall"`
+	ByClass             map[Class]WaitStats  `json:"by_class"`
+	ByTenant            map[string]WaitStats `json:"by_tenant"`
+	// FairnessIndex 租户间的 Jain 公平性指数（按各租户得到的相对服务计算，1 表示各租户受到的干扰相同，越接近 1/租户数越不公平）
+	FairnessIndex float64 `json:"fairness_index"`
+}
+
+// Simulate 用 workers 个工作协程按 policy 回放 jobs（不执行真实任务），starvation 不大于 0 时使用默认饥饿阈值
+// 每个租户的任务还会单独回放一次，得到租户独占工作协程时的减速比，用于计算其他租户造成的干扰和公平性指数
+func Simulate(jobs []Job, workers int, policy Policy, starvation time.Duration) *Report {
+	if workers <= 0 {
+		workers = 1
+	}
+	if starvation <= 0 {
+		starvation = DefaultStarvationThreshold
+	}
+
+	report := &Report{
+		Policy:              policy.Name(),
+		Workers:             workers,
+		Jobs:                len(jobs),
+		StarvationThreshold: starvation,
+		ByClass:             make(map[Class]WaitStats),
+		ByTenant:            make(map[string]WaitStats),
+	}
+	if len(jobs) == 0 {
+		return report
+	}
+
+	var all []*Job
+	byClass := make(map[Class][]*Job)
+	byTenant := make(map[string][]*Job)
+	for i := range jobs {
+		job := &jobs[i]
+		all = append(all, job)
+		byClass[job.Class] = append(byClass[job.Class], job)
+		byTenant[job.Tenant] = append(byTenant[job.Tenant], job)
+	}
+
+	result := replay(all, workers, policy)
+	report.Makespan = result.end - result.start
+	if report.Makespan > 0 {
+		report.Utilization = float64(result.busy) / float64(report.Makespan) / float64(workers)
+	}
+	report.Overall = waitStats(all, result.waits, starvation)
+	for class, group := range byClass {
+		report.ByClass[class] = waitStats(group, result.waits, starvation)
+	}
+	// 公平性按各租户得到的相对服务（单独回放时的减速比 / 实际减速比，0~1）计算，避免所有租户都严重受干扰时也被视为公平
+	service := make([]float64, 0, len(byTenant))
+	for tenant, group := range byTenant {
+		stats := waitStats(group, result.waits, starvation)
+		isolated := waitStats(group, replay(group, workers, policy).waits, starvation)
+		stats.Interference = stats.MeanSlowdown / isolated.MeanSlowdown
+		report.ByTenant[tenant] = stats
+		service = append(service, 1/stats.Interference)
+	}
+	report.FairnessIndex = jainIndex(service)
+	return report
+}
+
+// replayResult 一次回放的结果
+type replayResult struct {
+	waits      map[*Job]time.Duration // 各任务的等待时间
+	busy       time.Duration          // 工作协程累计忙碌时间
+	start, end time.Duration          // 第一个任务提交、最后一个任务完成的时间
+}
+
+// replay 离散事件模拟：任务按 SubmitAt 进入就绪队列，有空闲工作协程时由策略选出下一个任务，执行 Duration 后释放工作协程
+func replay(jobs []*Job, workers int, policy Policy) replayResult {
+	pending := slices.Clone(jobs)
+	sort.SliceStable(pending, func(i, j int) bool { return pending[i].SubmitAt < pending[j].SubmitAt })
+
+	type execution struct {
+		job   *Job
+		endAt time.Duration
+	}
+	var (
+		ready     []*Job
+		executing []execution
+		running   = make(map[string]int)
+		result    = replayResult{waits: make(map[*Job]time.Duration, len(jobs))}
+		now       time.Duration
+	)
+	if len(pending) > 0 {
+		now = pending[0].SubmitAt
+		result.start, result.end = now, now
+	}
+	for len(pending) > 0 || len(ready) > 0 || len(executing) > 0 {
+		// 到达当前时间的任务进入就绪队列，执行完成的任务释放工作协程
+		for len(pending) > 0 && pending[0].SubmitAt <= now {
+			ready = append(ready, pending[0])
+			pending = pending[1:]
+		}
+		executing = slices.DeleteFunc(executing, func(e execution) bool {
+			if e.endAt > now {
+				return false
+			}
+			running[e.job.Tenant]--
+			return true
+		})
+
+		for len(executing) < workers && len(ready) > 0 {
+			i := policy.Next(ready, running)
+			job := ready[i]
+			ready = slices.Delete(ready, i, i+1)
+			result.waits[job] = now - job.SubmitAt
+			result.busy += job.Duration
+			result.end = max(result.end, now+job.Duration)
+			running[job.Tenant]++
+			executing = append(executing, execution{job: job, endAt: now + job.Duration})
+		}
+
+		// 跳到下一个事件：新任务到达或执行中的任务完成
+		next := time.Duration(-1)
+		if len(pending) > 0 {
+			next = pending[0].SubmitAt
+		}
+		for _, e := range executing {
+			if next < 0 || e.endAt < next {
+				next = e.endAt
+			}
+		}
+		if next < 0 {
+			break
+		}
+		now = next
+	}
+	return result
+}
+
+// waitStats 统计一组任务的等待时间
+func waitStats(jobs []*Job, waits map[*Job]time.Duration, starvation time.Duration) WaitStats {
+	stats := WaitStats{Count: len(jobs)}
+	if len(jobs) == 0 {
+		return stats
+	}
+	sorted := make([]time.Duration, 0, len(jobs))
+	var total time.Duration
+	var slowdown float64
+	for _, job := range jobs {
+		wait := waits[job]
+		sorted = append(sorted, wait)
+		total += wait
+		if wait > starvation {
+			stats.Starved++
+		}
+		if job.Duration > 0 {
+			slowdown += float64(wait+job.Duration) / float64(job.Duration)
+		} else {
+			slowdown++
+		}
+	}
+	slices.Sort(sorted)
+	stats.Mean = total / time.Duration(len(jobs))
+	stats.P50 = percentile(sorted, 50)
+	stats.P95 = percentile(sorted, 95)
+	stats.Max = sorted[len(sorted)-1]
+	stats.MeanSlowdown = slowdown / float64(len(jobs))
+	return stats
+}
+
+// percentile 已排序数据的 p 分位数（最近秩法）
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
+
+// jainIndex Jain 公平性指数：(Σx)² / (n·Σx²)，取值 1/n~1，没有数据时为 1
+func jainIndex(values []float64) float64 {
+	var sum, sumSquares float64
+	for _, v := range values {
+		sum += v
+		sumSquares += v * v
+	}
+	if sumSquares == 0 {
+		return 1
+	}
+	return sum * sum / (float64(len(values)) * sumSquares)
+}
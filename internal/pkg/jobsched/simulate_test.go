@@ -0,0 +1,154 @@
+package jobsched
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// burstWorkload 一个租户先集中提交大量批量任务，随后其他租户陆续提交交互任务
+func burstWorkload() []Job {
+	var jobs []Job
+	for i := range 20 {
+		jobs = append(jobs, Job{ID: "batch", Tenant: "bulk", Class: ClassBatch, SubmitAt: time.Duration(i) * time.Second, Duration: 5 * time.Minute})
+	}
+	for i := range 6 {
+		jobs = append(jobs, Job{ID: "interactive", Tenant: tenantName(i + 1), Class: ClassInteractive, SubmitAt: time.Minute + time.Duration(i)*time.Minute, Duration: 30 * time.Second})
+	}
+	return jobs
+}
+
+func mustPolicy(t *testing.T, name string) Policy {
+	t.Helper()
+	p, ok := NewPolicy(name)
+	if !ok {
+		t.Fatalf("policy %q not found", name)
+	}
+	return p
+}
+
+func TestSimulate_FIFOStarvesInteractiveBehindBurst(t *testing.T) {
+	report := Simulate(burstWorkload(), 2, mustPolicy(t, PolicyFIFO), 10*time.Minute)
+
+	if report.Jobs != 26 || report.Overall.Count != 26 {
+		t.Fatalf("jobs = %d/%d, want 26", report.Jobs, report.Overall.Count)
+	}
+	interactive := report.ByClass[ClassInteractive]
+	if interactive.Starved == 0 {
+		t.Errorf("expected interactive jobs to starve behind the batch burst under fifo, got %+v", interactive)
+	}
+	if report.Utilization <= 0 || report.Utilization > 1 {
+		t.Errorf("utilization = %v, want (0, 1]", report.Utilization)
+	}
+}
+
+func TestSimulate_PoliciesImproveInteractiveWait(t *testing.T) {
+	fifo := Simulate(burstWorkload(), 2, mustPolicy(t, PolicyFIFO), 0)
+	for _, name := range []string{PolicyInteractiveFirst, PolicyFairShare} {
+		report := Simulate(burstWorkload(), 2, mustPolicy(t, name), 0)
+		if report.ByClass[ClassInteractive].P95 >= fifo.ByClass[ClassInteractive].P95 {
+			t.Errorf("%s: interactive p95 = %v, want below fifo %v", name, report.ByClass[ClassInteractive].P95, fifo.ByClass[ClassInteractive].P95)
+		}
+		if report.ByClass[ClassInteractive].Starved != 0 {
+			t.Errorf("%s: interactive starved = %d, want 0", name, report.ByClass[ClassInteractive].Starved)
+		}
+		if report.FairnessIndex <= fifo.FairnessIndex {
+			t.Errorf("%s: fairness = %v, want above fifo %v", name, report.FairnessIndex, fifo.FairnessIndex)
+		}
+		// 调度顺序不影响总工作量
+		if report.Makespan != fifo.Makespan {
+			t.Errorf("%s: makespan = %v, want %v", name, report.Makespan, fifo.Makespan)
+		}
+	}
+}
+
+func TestSimulate_FairShareAcrossBatchTenants(t *testing.T) {
+	// 只有批量任务时交互优先等同于先提交先执行，按租户公平调度才能避免小租户被大批量提交的租户挡住
+	var jobs []Job
+	for i := range 20 {
+		jobs = append(jobs, Job{Tenant: "bulk", Class: ClassBatch, SubmitAt: time.Duration(i) * time.Second, Duration: 5 * time.Minute})
+	}
+	for i := range 3 {
+		jobs = append(jobs, Job{Tenant: tenantName(i), Class: ClassBatch, SubmitAt: time.Minute, Duration: 5 * time.Minute})
+	}
+
+	first := Simulate(jobs, 2, mustPolicy(t, PolicyInteractiveFirst), 0)
+	fair := Simulate(jobs, 2, mustPolicy(t, PolicyFairShare), 0)
+	if fair.FairnessIndex <= first.FairnessIndex {
+		t.Errorf("fairness = %v, want above interactive-first %v", fair.FairnessIndex, first.FairnessIndex)
+	}
+	if got := fair.ByTenant["bulk"].Interference; got < 1 {
+		t.Errorf("bulk interference = %v, want >= 1", got)
+	}
+	if got := first.ByTenant[tenantName(0)].Starved; got != 1 {
+		t.Errorf("small tenant starved = %d under interactive-first, want 1", got)
+	}
+}
+
+func TestSimulate_NoContention(t *testing.T) {
+	jobs := []Job{
+		{Tenant: "a", Class: ClassInteractive, SubmitAt: 0, Duration: time.Second},
+		{Tenant: "b", Class: ClassBatch, SubmitAt: 2 * time.Second, Duration: time.Second},
+	}
+	report := Simulate(jobs, 1, mustPolicy(t, PolicyFIFO), 0)
+	if report.Overall.Max != 0 || report.Overall.MeanSlowdown != 1 {
+		t.Errorf("overall = %+v, want no waiting", report.Overall)
+	}
+	if report.FairnessIndex != 1 {
+		t.Errorf("fairness = %v, want 1", report.FairnessIndex)
+	}
+	if report.Makespan != 3*time.Second {
+		t.Errorf("makespan = %v, want 3s", report.Makespan)
+	}
+	if report.StarvationThreshold != DefaultStarvationThreshold {
+		t.Errorf("starvation threshold = %v, want default", report.StarvationThreshold)
+	}
+
+	empty := Simulate(nil, 2, mustPolicy(t, PolicyFairShare), 0)
+	if empty.Jobs != 0 || empty.Makespan != 0 {
+		t.Errorf("empty report = %+v", empty)
+	}
+}
+
+func TestFairShare_PrefersIdleTenant(t *testing.T) {
+	ready := []*Job{
+		{Tenant: "busy", Class: ClassInteractive},
+		{Tenant: "idle", Class: ClassBatch},
+		{Tenant: "idle", Class: ClassInteractive},
+	}
+	got := mustPolicy(t, PolicyFairShare).Next(ready, map[string]int{"busy": 2})
+	if got != 2 {
+		t.Errorf("next = %d, want 2 (idle tenant, interactive first)", got)
+	}
+	if _, ok := NewPolicy("unknown"); ok {
+		t.Error("expected unknown policy to be rejected")
+	}
+}
+
+func TestGenerateWorkload(t *testing.T) {
+	cfg := DefaultWorkloadConfig()
+	jobs := GenerateWorkload(cfg)
+	if len(jobs) != cfg.Jobs {
+		t.Fatalf("jobs = %d, want %d", len(jobs), cfg.Jobs)
+	}
+	if !slices.IsSortedFunc(jobs, func(a, b Job) int { return int(a.SubmitAt - b.SubmitAt) }) {
+		t.Error("expected jobs sorted by submit time")
+	}
+	if !slices.Equal(jobs, GenerateWorkload(cfg)) {
+		t.Error("expected the same seed to generate the same workload")
+	}
+
+	classes := make(map[Class]int)
+	for _, job := range jobs {
+		classes[job.Class]++
+		if job.SubmitAt < 0 || job.SubmitAt >= cfg.Span || job.Duration <= 0 {
+			t.Fatalf("job %s out of range: %+v", job.ID, job)
+		}
+	}
+	if classes[ClassInteractive] == 0 || classes[ClassBatch] == 0 {
+		t.Errorf("classes = %v, want both interactive and batch jobs", classes)
+	}
+	if GenerateWorkload(WorkloadConfig{}) != nil {
+		t.Error("expected empty workload for zero jobs")
+	}
+}
@@ -0,0 +1,231 @@
+package killswitch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 作用范围：全局或单个 provider
+const (
+	ScopeGlobal = "global" // 全局开关，暂停所有生成任务
+
+	ProviderLLM   = "llm"   // 文本生成（解说）
+	ProviderTTS   = "tts"   // 语音合成
+	ProviderImage = "image" // 图片生成
+	ProviderVideo = "video" // 视频生成
+)
+
+// AllScopes 所有可用的开关范围
+var AllScopes = []string{ScopeGlobal, ProviderLLM, ProviderTTS, ProviderImage, ProviderVideo}
+
+// IsValidScope 判断开关范围是否有效
+func IsValidScope(scope string) bool {
+	for _, s := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// InFlightPolicy 开关打开时对进行中任务的处理策略
+type InFlightPolicy string
+
+const (
+	PolicyFinish InFlightPolicy = "finish" // 进行中的任务继续执行直到结束
+	PolicyAbort  InFlightPolicy = "abort"  // 立即取消进行中的任务
+)
+
+// IsValid 判断策略是否有效
+func (p InFlightPolicy) IsValid() bool {
+	return p == PolicyFinish || p == PolicyAbort
+}
+
+// ErrMaintenance 维护中错误（可用 errors.Is 判断）
+var ErrMaintenance = errors.New("服务维护中")
+
+// MaintenanceError 维护中错误详情
+type MaintenanceError struct {
+	Scope  string    // 触发的开关范围（global 或 provider）
+	Reason string    // 维护原因
+	Since  time.Time // 开关打开时间
+}
+
+func (e *MaintenanceError) Error() string {
+	msg := fmt.Sprintf("%s: %s 已暂停", ErrMaintenance.Error(), e.Scope)
+	if e.Reason != "" {
+		msg += "（" + e.Reason + "）"
+	}
+	return msg
+}
+
+// Unwrap 使 errors.Is(err, ErrMaintenance) 成立
+func (e *MaintenanceError) Unwrap() error {
+	return ErrMaintenance
+}
+
+// State 单个开关的状态
+type State struct {
+	Scope  string         `json:"scope"`
+	Reason string         `json:"reason,omitempty"`
+	Policy InFlightPolicy `json:"in_flight_policy"`
+	Since  time.Time      `json:"since"`
+}
+
+// guard 受开关保护的进行中任务
+type guard struct {
+	provider string
+	cancel   context.CancelCauseFunc
+}
+
+// Switch 进程内的全局/按 provider 熔断开关
+// 开关打开后新的生成任务会被拒绝；按策略决定进行中的任务是继续执行还是被取消
+type Switch struct {
+	mu            sync.RWMutex
+	defaultPolicy InFlightPolicy
+	states        map[string]*State // key: scope
+	guards        map[*guard]struct{}
+}
+
+// New 创建开关，defaultPolicy 为打开开关时未指定策略所使用的默认策略
+func New(defaultPolicy InFlightPolicy) *Switch {
+	if !defaultPolicy.IsValid() {
+		defaultPolicy = PolicyFinish
+	}
+	return &Switch{
+		defaultPolicy: defaultPolicy,
+		states:        make(map[string]*State),
+		guards:        make(map[*guard]struct{}),
+	}
+}
+
+// DefaultPolicy 返回默认的进行中任务处理策略
+func (s *Switch) DefaultPolicy() InFlightPolicy {
+	return s.defaultPolicy
+}
+
+// Enable 打开开关
+// policy 为空时使用默认策略；返回 false 表示开关此前已经打开（不会覆盖原状态）
+// 策略为 abort 时，会立即取消受该开关影响的进行中任务
+func (s *Switch) Enable(scope, reason string, policy InFlightPolicy) bool {
+	if policy == "" {
+		policy = s.defaultPolicy
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.states[scope]; ok {
+		return false
+	}
+	state := &State{
+		Scope:  scope,
+		Reason: reason,
+		Policy: policy,
+		Since:  time.Now(),
+	}
+	s.states[scope] = state
+
+	if policy == PolicyAbort {
+		cause := &MaintenanceError{Scope: scope, Reason: reason, Since: state.Since}
+		for g := range s.guards {
+			if scope == ScopeGlobal || g.provider == scope {
+				g.cancel(cause)
+			}
+		}
+	}
+	return true
+}
+
+// Disable 关闭开关，返回 false 表示开关此前未打开
+func (s *Switch) Disable(scope string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.states[scope]; !ok {
+		return false
+	}
+	delete(s.states, scope)
+	return true
+}
+
+// Check 检查 provider 当前是否可以发起新任务，维护中时返回 *MaintenanceError
+// 全局开关优先于 provider 开关
+func (s *Switch) Check(provider string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.check(provider)
+}
+
+// check 检查开关状态，调用方需持有锁
+func (s *Switch) check(provider string) error {
+	if state, ok := s.states[ScopeGlobal]; ok {
+		return &MaintenanceError{Scope: ScopeGlobal, Reason: state.Reason, Since: state.Since}
+	}
+	if state, ok := s.states[provider]; ok {
+		return &MaintenanceError{Scope: provider, Reason: state.Reason, Since: state.Since}
+	}
+	return nil
+}
+
+// Guard 检查开关并为进行中的任务登记取消函数
+// 开关以 abort 策略打开时，返回的 context 会被取消，context.Cause 为 *MaintenanceError
+// 调用方必须在任务结束后调用返回的 release 函数
+func (s *Switch) Guard(ctx context.Context, provider string) (context.Context, func(), error) {
+	// 检查与登记在同一把锁内完成，避免两者之间开关被打开而漏取消
+	s.mu.Lock()
+	if err := s.check(provider); err != nil {
+		s.mu.Unlock()
+		return ctx, func() {}, err
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	g := &guard{provider: provider, cancel: cancel}
+	s.guards[g] = struct{}{}
+	s.mu.Unlock()
+
+	release := func() {
+		s.mu.Lock()
+		delete(s.guards, g)
+		s.mu.Unlock()
+		cancel(nil)
+	}
+	return ctx, release, nil
+}
+
+// State 返回指定范围的开关状态，未打开时 ok 为 false
+func (s *Switch) State(scope string) (State, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	state, ok := s.states[scope]
+	if !ok {
+		return State{}, false
+	}
+	return *state, true
+}
+
+// States 返回当前打开的所有开关（按范围排序）
+func (s *Switch) States() []State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	states := make([]State, 0, len(s.states))
+	for _, state := range s.states {
+		states = append(states, *state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Scope < states[j].Scope
+	})
+	return states
+}
+
+// InFlight 返回当前登记的进行中任务数
+func (s *Switch) InFlight() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.guards)
+}
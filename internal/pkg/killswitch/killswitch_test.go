@@ -0,0 +1,95 @@
+package killswitch
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSwitch_Check(t *testing.T) {
+	s := New(PolicyFinish)
+
+	if err := s.Check(ProviderImage); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !s.Enable(ProviderImage, "vendor incident", "") {
+		t.Fatal("expected Enable to report a change")
+	}
+	if s.Enable(ProviderImage, "again", "") {
+		t.Error("expected second Enable to be a no-op")
+	}
+
+	err := s.Check(ProviderImage)
+	if !errors.Is(err, ErrMaintenance) {
+		t.Fatalf("expected ErrMaintenance, got %v", err)
+	}
+	var mErr *MaintenanceError
+	if !errors.As(err, &mErr) || mErr.Scope != ProviderImage || mErr.Reason != "vendor incident" {
+		t.Errorf("unexpected maintenance error: %+v", mErr)
+	}
+	if err := s.Check(ProviderTTS); err != nil {
+		t.Errorf("expected other provider to be available, got %v", err)
+	}
+
+	s.Enable(ScopeGlobal, "", "")
+	if err := s.Check(ProviderTTS); !errors.Is(err, ErrMaintenance) {
+		t.Errorf("expected global switch to block all providers, got %v", err)
+	}
+
+	s.Disable(ScopeGlobal)
+	s.Disable(ProviderImage)
+	if err := s.Check(ProviderImage); err != nil {
+		t.Errorf("expected no error after disable, got %v", err)
+	}
+}
+
+func TestSwitch_GuardAbort(t *testing.T) {
+	s := New(PolicyFinish)
+
+	ttsCtx, releaseTTS, err := s.Guard(context.Background(), ProviderTTS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer releaseTTS()
+	imageCtx, releaseImage, err := s.Guard(context.Background(), ProviderImage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer releaseImage()
+
+	s.Enable(ProviderImage, "", PolicyAbort)
+
+	if !errors.Is(context.Cause(imageCtx), ErrMaintenance) {
+		t.Errorf("expected image task to be aborted, got %v", context.Cause(imageCtx))
+	}
+	if ttsCtx.Err() != nil {
+		t.Errorf("expected tts task to keep running, got %v", ttsCtx.Err())
+	}
+
+	if _, _, err := s.Guard(context.Background(), ProviderImage); !errors.Is(err, ErrMaintenance) {
+		t.Errorf("expected new image task to be rejected, got %v", err)
+	}
+}
+
+func TestSwitch_GuardFinish(t *testing.T) {
+	s := New(PolicyFinish)
+
+	ctx, release, err := s.Guard(context.Background(), ProviderVideo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Enable(ScopeGlobal, "", "")
+	if ctx.Err() != nil {
+		t.Errorf("expected in-flight task to finish under finish policy, got %v", ctx.Err())
+	}
+	if s.InFlight() != 1 {
+		t.Errorf("expected 1 in-flight task, got %d", s.InFlight())
+	}
+
+	release()
+	if s.InFlight() != 0 {
+		t.Errorf("expected 0 in-flight tasks after release, got %d", s.InFlight())
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"lemon/internal/model/embed"
+	"lemon/internal/model/maintenance"
 	"lemon/internal/model/novel"
 	"lemon/internal/model/resource"
 )
@@ -33,6 +35,39 @@ func EnsureIndexes(db *mongo.Database) error {
 		&novel.Prop{},
 		&novel.Image{},
 		&novel.Video{},
+		&novel.LicenseGrant{},
+		&novel.SceneImageVariant{},
+		&novel.StyleReference{},
+		&novel.BGMTrack{},
+		&novel.ShotClipPreview{},
+		&novel.CostRecord{},
+		&novel.BudgetEvent{},
+		&novel.PrewarmJob{},
+		&novel.StandbyJob{},
+		&novel.Webhook{},
+		&novel.WebhookDelivery{},
+		&novel.NarrationPatch{},
+		&novel.Job{},
+		&novel.JobWorker{},
+		&novel.WorkflowTemplate{},
+		&novel.PipelineRun{},
+		&novel.NovelGrant{},
+		&novel.StageRun{},
+		&novel.ChapterEvent{},
+		&novel.CreatorProfile{},
+		&novel.PronunciationEntry{},
+		&novel.PromotionRecord{},
+		&novel.NovelCover{},
+		&novel.UserSettings{},
+		&novel.ContentRiskReport{},
+		&novel.RegenerationOverride{},
+		&novel.Compilation{},
+		&novel.VersionCounter{},
+		&novel.AnalyticsExportBatch{},
+		&novel.GenerationRequest{},
+		&novel.NotarizationRecord{},
+		&maintenance.DowntimeWindow{},
+		&embed.EmbedToken{},
 	}
 
 	// 为实现了 Model 接口的模型创建索引
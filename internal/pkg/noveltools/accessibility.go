@@ -0,0 +1,183 @@
+package noveltools
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultAudioDescriptionMinGap 口述影像的最短空档（秒）：没有解说的画面短于该时长时不插入描述
+const DefaultAudioDescriptionMinGap = 1.5
+
+// minAudioDescriptionChars 口述影像描述的最少字数，空档容纳不下时不插入描述
+const minAudioDescriptionChars = 4
+
+// AccessibilityShot 无障碍输出中的一个镜头
+type AccessibilityShot struct {
+	SceneNumber    string  // 场景编号
+	Text           string  // 镜头的解说文本（配音文本）
+	Description    string  // 画面描述
+	SpeechDuration float64 // 配音时长（秒），没有配音时为 0
+}
+
+// AccessibilityClip 最终视频中按拼接顺序排列的一个片段
+type AccessibilityClip struct {
+	Duration float64             // 片段时长（秒）
+	Shots    []AccessibilityShot // 片段包含的镜头（按镜头序号排列）
+}
+
+// TranscriptSegment 文字稿中的一段解说
+type TranscriptSegment struct {
+	Start       float64 // 在最终视频中的开始时间（秒）
+	SceneNumber string  // 场景编号
+	Text        string  // 解说文本
+}
+
+// DescriptionMoment 最终视频中没有解说、只有画面的时段
+type DescriptionMoment struct {
+	Start       float64 // 开始时间（秒）
+	Duration    float64 // 时长（秒）
+	Description string  // 该时段画面的描述
+}
+
+// AudioDescriptionCue 口述影像的一条描述
+type AudioDescriptionCue struct {
+	Start float64 // 开始时间（秒）
+	End   float64 // 结束时间（秒）
+	Text  string  // 描述文本
+}
+
+// BuildAccessibilityTimeline 按片段时长推算每个镜头在最终视频中的时间，返回文字稿的解说段落和只有画面的时段
+// 镜头先播放配音，配音之后到下一个镜头之前是停顿；片段内的停顿按片段时长减去配音总时长平均分给各镜头（单镜头片段即为末尾停顿）。
+// 没有配音的镜头整段都只有画面
+func BuildAccessibilityTimeline(clips []AccessibilityClip) ([]TranscriptSegment, []DescriptionMoment) {
+	var segments []TranscriptSegment
+	var moments []DescriptionMoment
+	offset := 0.0
+	for _, clip := range clips {
+		if len(clip.Shots) == 0 {
+			offset += clip.Duration
+			continue
+		}
+		speech := 0.0
+		for _, shot := range clip.Shots {
+			speech += max(shot.SpeechDuration, 0)
+		}
+		pause := max(clip.Duration-speech, 0) / float64(len(clip.Shots))
+
+		start := offset
+		for _, shot := range clip.Shots {
+			spoken := max(shot.SpeechDuration, 0)
+			if text := strings.TrimSpace(shot.Text); text != "" && spoken > 0 {
+				segments = append(segments, TranscriptSegment{Start: start, SceneNumber: shot.SceneNumber, Text: text})
+			}
+			if pause > 0 {
+				moments = append(moments, DescriptionMoment{
+					Start:       start + spoken,
+					Duration:    pause,
+					Description: strings.TrimSpace(shot.Description),
+				})
+			}
+			start += spoken + pause
+		}
+		offset += clip.Duration
+	}
+	return segments, mergeDescriptionMoments(moments)
+}
+
+// mergeDescriptionMoments 合并首尾相接且画面描述相同的时段（如没有配音的镜头与其末尾停顿）
+func mergeDescriptionMoments(moments []DescriptionMoment) []DescriptionMoment {
+	var merged []DescriptionMoment
+	for _, m := range moments {
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			if last.Description == m.Description && math.Abs(last.Start+last.Duration-m.Start) < 0.001 {
+				last.Duration += m.Duration
+				continue
+			}
+		}
+		merged = append(merged, m)
+	}
+	return merged
+}
+
+// BuildTranscript 生成纯文本文字稿：标题之后每段解说一行，行首为开始时间（[mm:ss]），场景之间空一行
+func BuildTranscript(title string, segments []TranscriptSegment) string {
+	var b strings.Builder
+	if title = strings.TrimSpace(title); title != "" {
+		b.WriteString(title)
+		b.WriteString("\n")
+	}
+	for i, seg := range segments {
+		if (i == 0 && b.Len() > 0) || (i > 0 && seg.SceneNumber != segments[i-1].SceneNumber) {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "[%s] %s\n", formatTranscriptTime(seg.Start), seg.Text)
+	}
+	return b.String()
+}
+
+// formatTranscriptTime 格式化文字稿时间（mm:ss，超过一小时为 h:mm:ss）
+func formatTranscriptTime(seconds float64) string {
+	total := int(math.Max(seconds, 0))
+	if total >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", total/3600, total/60%60, total%60)
+	}
+	return fmt.Sprintf("%02d:%02d", total/60, total%60)
+}
+
+// BuildAudioDescriptionCues 为只有画面的时段生成口述影像描述
+// 短于 minGap 秒或没有画面描述的时段跳过；描述按 1.0 倍语速截断到时段内能读完的字数（优先在句子或短语的结尾截断），
+// 容纳不下 minAudioDescriptionChars 个字时跳过
+func BuildAudioDescriptionCues(moments []DescriptionMoment, minGap float64) []AudioDescriptionCue {
+	if minGap <= 0 {
+		minGap = DefaultAudioDescriptionMinGap
+	}
+	var cues []AudioDescriptionCue
+	for _, m := range moments {
+		if m.Duration < minGap || m.Description == "" {
+			continue
+		}
+		text := fitDescription(m.Description, int(m.Duration*ReadingCharsPerSecond))
+		if utf8.RuneCountInString(text) < minAudioDescriptionChars {
+			continue
+		}
+		cues = append(cues, AudioDescriptionCue{Start: m.Start, End: m.Start + m.Duration, Text: text})
+	}
+	return cues
+}
+
+// fitDescription 把描述截断到 limit 个字以内，优先保留到最后一个句子或短语结尾的标点
+func fitDescription(description string, limit int) string {
+	runes := []rune(strings.TrimSpace(description))
+	if len(runes) <= limit {
+		return string(runes)
+	}
+	if limit <= 0 {
+		return ""
+	}
+	cut := runes[:limit]
+	for i := len(cut) - 1; i >= minAudioDescriptionChars-1; i-- {
+		if strings.ContainsRune("。！？；，、.!?;,", cut[i]) {
+			return strings.TrimRight(string(cut[:i+1]), "，、,")
+		}
+	}
+	return string(cut)
+}
+
+// BuildDescriptionVTT 生成口述影像的 WebVTT 描述轨（播放器以 kind="descriptions" 加载，供屏幕阅读器朗读）
+func BuildDescriptionVTT(cues []AudioDescriptionCue) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+	for i, cue := range cues {
+		fmt.Fprintf(&b, "\n%d\n%s --> %s\n%s\n", i+1, formatVTTTimestamp(cue.Start), formatVTTTimestamp(cue.End), cue.Text)
+	}
+	return b.String()
+}
+
+// formatVTTTimestamp 格式化为 WebVTT 时间戳（HH:MM:SS.mmm）
+func formatVTTTimestamp(seconds float64) string {
+	ms := int64(math.Round(math.Max(seconds, 0) * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
@@ -0,0 +1,85 @@
+package noveltools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBuildAccessibilityTimeline(t *testing.T) {
+	Convey("BuildAccessibilityTimeline 推算解说段落和只有画面的时段", t, func() {
+		Convey("单镜头片段：配音之后是末尾停顿", func() {
+			segments, moments := BuildAccessibilityTimeline([]AccessibilityClip{
+				{Duration: 5, Shots: []AccessibilityShot{{SceneNumber: "1", Text: "少年下山。", Description: "山门前", SpeechDuration: 3}}},
+				{Duration: 4, Shots: []AccessibilityShot{{SceneNumber: "2", Text: "进城。", Description: "城门", SpeechDuration: 4}}},
+			})
+			So(segments, ShouldResemble, []TranscriptSegment{
+				{Start: 0, SceneNumber: "1", Text: "少年下山。"},
+				{Start: 5, SceneNumber: "2", Text: "进城。"},
+			})
+			So(moments, ShouldResemble, []DescriptionMoment{{Start: 3, Duration: 2, Description: "山门前"}})
+		})
+
+		Convey("合并片段：停顿平均分给各镜头", func() {
+			segments, moments := BuildAccessibilityTimeline([]AccessibilityClip{
+				{Duration: 6, Shots: []AccessibilityShot{
+					{SceneNumber: "1", Text: "甲", Description: "画面甲", SpeechDuration: 2},
+					{SceneNumber: "1", Text: "乙", Description: "画面乙", SpeechDuration: 2},
+				}},
+			})
+			So(len(segments), ShouldEqual, 2)
+			So(segments[1].Start, ShouldEqual, 3)
+			So(moments, ShouldResemble, []DescriptionMoment{
+				{Start: 2, Duration: 1, Description: "画面甲"},
+				{Start: 5, Duration: 1, Description: "画面乙"},
+			})
+		})
+
+		Convey("没有配音的镜头不进入文字稿", func() {
+			segments, moments := BuildAccessibilityTimeline([]AccessibilityClip{
+				{Duration: 3, Shots: []AccessibilityShot{{SceneNumber: "1", Text: "旁白", Description: "远景"}}},
+			})
+			So(segments, ShouldBeEmpty)
+			So(moments, ShouldResemble, []DescriptionMoment{{Start: 0, Duration: 3, Description: "远景"}})
+		})
+	})
+}
+
+func TestBuildTranscript(t *testing.T) {
+	Convey("BuildTranscript 生成纯文本文字稿", t, func() {
+		transcript := BuildTranscript("第1章 下山", []TranscriptSegment{
+			{Start: 0, SceneNumber: "1", Text: "少年下山。"},
+			{Start: 65.4, SceneNumber: "1", Text: "回头望去。"},
+			{Start: 3725, SceneNumber: "2", Text: "进城。"},
+		})
+		So(transcript, ShouldEqual, "第1章 下山\n\n[00:00] 少年下山。\n[01:05] 回头望去。\n\n[1:02:05] 进城。\n")
+	})
+}
+
+func TestBuildAudioDescriptionCues(t *testing.T) {
+	Convey("BuildAudioDescriptionCues 生成口述影像描述", t, func() {
+		Convey("跳过过短或没有描述的时段", func() {
+			cues := BuildAudioDescriptionCues([]DescriptionMoment{
+				{Start: 0, Duration: 1, Description: "山门前的石阶"},
+				{Start: 5, Duration: 3, Description: ""},
+				{Start: 10, Duration: 3, Description: "山门前的石阶"},
+			}, 0)
+			So(cues, ShouldResemble, []AudioDescriptionCue{{Start: 10, End: 13, Text: "山门前的石阶"}})
+		})
+
+		Convey("描述过长时在标点处截断", func() {
+			cues := BuildAudioDescriptionCues([]DescriptionMoment{
+				{Start: 0, Duration: 2, Description: "少年背剑，站在山门前回望，云雾缭绕"},
+			}, 1.5)
+			So(len(cues), ShouldEqual, 1)
+			So(cues[0].Text, ShouldEqual, "少年背剑")
+		})
+	})
+}
+
+func TestBuildDescriptionVTT(t *testing.T) {
+	Convey("BuildDescriptionVTT 生成 WebVTT 描述轨", t, func() {
+		vtt := BuildDescriptionVTT([]AudioDescriptionCue{{Start: 3, End: 5.5, Text: "山门前"}})
+		So(vtt, ShouldEqual, "WEBVTT\n\n1\n00:00:03.000 --> 00:00:05.500\n山门前\n")
+	})
+}
@@ -0,0 +1,294 @@
+package noveltools
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"lemon/internal/model/novel"
+)
+
+// analyticsSchemaVersions 各数据集的行结构版本
+// 行结构的字段变化（新增、改名、改类型）时递增对应数据集的版本，批次记录和每一行都带有版本号，数仓按版本迁移表结构
+var analyticsSchemaVersions = map[novel.AnalyticsDataset]int{
+	novel.AnalyticsDatasetTasks:    1,
+	novel.AnalyticsDatasetUsage:    1,
+	novel.AnalyticsDatasetCosts:    1,
+	novel.AnalyticsDatasetQAScores: 1,
+}
+
+// AnalyticsSchemaVersion 返回数据集当前的行结构版本
+func AnalyticsSchemaVersion(dataset novel.AnalyticsDataset) int {
+	return analyticsSchemaVersions[dataset]
+}
+
+// AnalyticsTaskRow tasks 数据集的一行（队列任务的当前状态）
+type AnalyticsTaskRow struct {
+	SchemaVersion int        `json:"schema_version"`
+	JobID         string     `json:"job_id"`
+	Type          string     `json:"type"`
+	NovelID       string     `json:"novel_id"`
+	ChapterID     string     `json:"chapter_id"`
+	PipelineRunID string     `json:"pipeline_run_id"`
+	TriggeredBy   string     `json:"triggered_by"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	MaxAttempts   int        `json:"max_attempts"`
+	ErrorClass    string     `json:"error_class"`
+	NodeID        string     `json:"node_id"`
+	QueueWaitMs   int64      `json:"queue_wait_ms"`   // 创建到开始执行的等待时间（毫秒，未开始时为 0）
+	RunDurationMs int64      `json:"run_duration_ms"` // 开始执行到结束的时间（毫秒，未结束时为 0）
+	StartedAt     *time.Time `json:"started_at"`
+	CompletedAt   *time.Time `json:"completed_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// AnalyticsProviderCalls 单个 provider 的调用次数
+type AnalyticsProviderCalls struct {
+	Provider string `json:"provider"`
+	Calls    int    `json:"calls"`
+}
+
+// AnalyticsUsageRow usage 数据集的一行（章节渲染阶段的一次执行）
+type AnalyticsUsageRow struct {
+	SchemaVersion   int                      `json:"schema_version"`
+	RunID           string                   `json:"run_id"`
+	NovelID         string                   `json:"novel_id"`
+	ChapterID       string                   `json:"chapter_id"`
+	Stage           string                   `json:"stage"`
+	Succeeded       bool                     `json:"succeeded"`
+	ErrorClass      string                   `json:"error_class"`
+	ErrorProvider   string                   `json:"error_provider"`
+	DurationMs      int64                    `json:"duration_ms"`
+	CostFen         int64                    `json:"cost_fen"`
+	BytesUploaded   int64                    `json:"bytes_uploaded"`
+	BytesDownloaded int64                    `json:"bytes_downloaded"`
+	ProviderCalls   []AnalyticsProviderCalls `json:"provider_calls"`
+	StartedAt       time.Time                `json:"started_at"`
+	FinishedAt      time.Time                `json:"finished_at"`
+	CreatedAt       time.Time                `json:"created_at"`
+}
+
+// AnalyticsCostRow costs 数据集的一行（一次付费 provider 调用的费用）
+type AnalyticsCostRow struct {
+	SchemaVersion int       `json:"schema_version"`
+	RecordID      string    `json:"record_id"`
+	NovelID       string    `json:"novel_id"`
+	ChapterID     string    `json:"chapter_id"`
+	Provider      string    `json:"provider"`
+	Units         float64   `json:"units"`
+	AmountFen     int64     `json:"amount_fen"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// AnalyticsQASignal 单个 QA 信号的评分
+type AnalyticsQASignal struct {
+	Signal    string  `json:"signal"`
+	Available bool    `json:"available"`
+	Score     float64 `json:"score"`
+	Weight    float64 `json:"weight"`
+	Findings  int     `json:"findings"`
+}
+
+// AnalyticsQAScoreRow qa_scores 数据集的一行（章节生成最终视频后的 QA 评分）
+type AnalyticsQAScoreRow struct {
+	SchemaVersion    int                 `json:"schema_version"`
+	ChapterID        string              `json:"chapter_id"`
+	NovelID          string              `json:"novel_id"`
+	StageRunID       string              `json:"stage_run_id"` // 触发评分的最终视频阶段执行ID
+	NarrationVersion int                 `json:"narration_version"`
+	ImageVersion     int                 `json:"image_version"`
+	AudioVersion     int                 `json:"audio_version"`
+	VideoVersion     int                 `json:"video_version"`
+	Score            float64             `json:"score"`
+	Grade            string              `json:"grade"`
+	Blocked          bool                `json:"blocked"`
+	Signals          []AnalyticsQASignal `json:"signals"`
+	ScoredAt         time.Time           `json:"scored_at"`
+}
+
+// NewAnalyticsTaskRow 把队列任务转换为 tasks 数据集的一行
+func NewAnalyticsTaskRow(job *novel.Job) AnalyticsTaskRow {
+	row := AnalyticsTaskRow{
+		SchemaVersion: AnalyticsSchemaVersion(novel.AnalyticsDatasetTasks),
+		JobID:         job.ID,
+		Type:          string(job.Type),
+		NovelID:       job.NovelID,
+		ChapterID:     job.ChapterID,
+		PipelineRunID: job.PipelineRunID,
+		TriggeredBy:   job.TriggeredBy,
+		Status:        string(job.Status),
+		Attempts:      job.Attempts,
+		MaxAttempts:   job.MaxAttempts,
+		ErrorClass:    job.ErrorClass,
+		NodeID:        job.NodeID,
+		StartedAt:     job.StartedAt,
+		CompletedAt:   job.CompletedAt,
+		CreatedAt:     job.CreatedAt,
+		UpdatedAt:     job.UpdatedAt,
+	}
+	if job.StartedAt != nil {
+		row.QueueWaitMs = max(0, job.StartedAt.Sub(job.CreatedAt).Milliseconds())
+		if job.CompletedAt != nil {
+			row.RunDurationMs = max(0, job.CompletedAt.Sub(*job.StartedAt).Milliseconds())
+		}
+	}
+	return row
+}
+
+// NewAnalyticsUsageRow 把阶段执行记录转换为 usage 数据集的一行
+func NewAnalyticsUsageRow(run *novel.StageRun) AnalyticsUsageRow {
+	return AnalyticsUsageRow{
+		SchemaVersion:   AnalyticsSchemaVersion(novel.AnalyticsDatasetUsage),
+		RunID:           run.ID,
+		NovelID:         run.NovelID,
+		ChapterID:       run.ChapterID,
+		Stage:           string(run.Stage),
+		Succeeded:       run.ErrorMessage == "",
+		ErrorClass:      run.ErrorClass,
+		ErrorProvider:   run.ErrorProvider,
+		DurationMs:      run.DurationMs,
+		CostFen:         run.CostFen,
+		BytesUploaded:   run.BytesUploaded,
+		BytesDownloaded: run.BytesDownloaded,
+		ProviderCalls:   analyticsProviderCalls(run.ProviderCalls),
+		StartedAt:       run.StartedAt,
+		FinishedAt:      run.FinishedAt,
+		CreatedAt:       run.CreatedAt,
+	}
+}
+
+// analyticsProviderCalls 把按 provider 统计的调用次数转换为按 provider 名称排序的数组（数仓不便处理动态键）
+func analyticsProviderCalls(calls map[string]int) []AnalyticsProviderCalls {
+	result := make([]AnalyticsProviderCalls, 0, len(calls))
+	for provider, n := range calls {
+		result = append(result, AnalyticsProviderCalls{Provider: provider, Calls: n})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Provider < result[j].Provider })
+	return result
+}
+
+// NewAnalyticsCostRow 把费用记录转换为 costs 数据集的一行
+func NewAnalyticsCostRow(record *novel.CostRecord) AnalyticsCostRow {
+	return AnalyticsCostRow{
+		SchemaVersion: AnalyticsSchemaVersion(novel.AnalyticsDatasetCosts),
+		RecordID:      record.ID,
+		NovelID:       record.NovelID,
+		ChapterID:     record.ChapterID,
+		Provider:      record.Provider,
+		Units:         record.Units,
+		AmountFen:     record.AmountFen,
+		CreatedAt:     record.CreatedAt,
+	}
+}
+
+// NewAnalyticsQAScoreRow 把章节 QA 评分卡转换为 qa_scores 数据集的一行（只保留各信号的分数和问题数量）
+func NewAnalyticsQAScoreRow(card *novel.QAScorecard, stageRunID string) AnalyticsQAScoreRow {
+	signals := make([]AnalyticsQASignal, 0, len(card.Signals))
+	for _, sig := range card.Signals {
+		signals = append(signals, AnalyticsQASignal{
+			Signal:    sig.Signal.String(),
+			Available: sig.Available,
+			Score:     sig.Score,
+			Weight:    sig.Weight,
+			Findings:  len(sig.Findings),
+		})
+	}
+	return AnalyticsQAScoreRow{
+		SchemaVersion:    AnalyticsSchemaVersion(novel.AnalyticsDatasetQAScores),
+		ChapterID:        card.ChapterID,
+		NovelID:          card.NovelID,
+		StageRunID:       stageRunID,
+		NarrationVersion: card.NarrationVersion,
+		ImageVersion:     card.ImageVersion,
+		AudioVersion:     card.AudioVersion,
+		VideoVersion:     card.VideoVersion,
+		Score:            card.Score,
+		Grade:            card.Grade,
+		Blocked:          card.Blocked,
+		Signals:          signals,
+		ScoredAt:         card.GeneratedAt,
+	}
+}
+
+// EncodeJSONLines 把行编码为 JSON Lines（每行一个 JSON 对象，BigQuery 和 ClickHouse 的 JSONEachRow 都可以直接加载）
+func EncodeJSONLines[T any](rows []T) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// AnalyticsField 数据集的字段定义（与 BigQuery 的 JSON 表结构格式一致）
+type AnalyticsField struct {
+	Name   string           `json:"name"`
+	Type   string           `json:"type"`             // STRING, INTEGER, FLOAT, BOOLEAN, TIMESTAMP, RECORD
+	Mode   string           `json:"mode"`             // NULLABLE, REPEATED
+	Fields []AnalyticsField `json:"fields,omitempty"` // RECORD 的子字段
+}
+
+// analyticsRowTypes 各数据集的行类型（用于生成表结构）
+var analyticsRowTypes = map[novel.AnalyticsDataset]reflect.Type{
+	novel.AnalyticsDatasetTasks:    reflect.TypeOf(AnalyticsTaskRow{}),
+	novel.AnalyticsDatasetUsage:    reflect.TypeOf(AnalyticsUsageRow{}),
+	novel.AnalyticsDatasetCosts:    reflect.TypeOf(AnalyticsCostRow{}),
+	novel.AnalyticsDatasetQAScores: reflect.TypeOf(AnalyticsQAScoreRow{}),
+}
+
+// AnalyticsSchema 返回数据集的表结构（按行类型的 JSON 字段生成），未知数据集返回 nil
+func AnalyticsSchema(dataset novel.AnalyticsDataset) []AnalyticsField {
+	t, ok := analyticsRowTypes[dataset]
+	if !ok {
+		return nil
+	}
+	return analyticsFields(t)
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// analyticsFields 按结构体的 json 标签生成字段定义
+func analyticsFields(t reflect.Type) []AnalyticsField {
+	fields := make([]AnalyticsField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		field := AnalyticsField{Name: name, Mode: "NULLABLE"}
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Slice {
+			field.Mode = "REPEATED"
+			ft = ft.Elem()
+		}
+		switch {
+		case ft == timeType:
+			field.Type = "TIMESTAMP"
+		case ft.Kind() == reflect.Struct:
+			field.Type = "RECORD"
+			field.Fields = analyticsFields(ft)
+		case ft.Kind() == reflect.String:
+			field.Type = "STRING"
+		case ft.Kind() == reflect.Bool:
+			field.Type = "BOOLEAN"
+		case ft.Kind() == reflect.Float32 || ft.Kind() == reflect.Float64:
+			field.Type = "FLOAT"
+		default:
+			field.Type = "INTEGER"
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
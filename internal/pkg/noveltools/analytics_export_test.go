@@ -0,0 +1,93 @@
+package noveltools
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestAnalyticsRows(t *testing.T) {
+	created := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	Convey("NewAnalyticsTaskRow 计算排队等待和执行耗时", t, func() {
+		started := created.Add(1500 * time.Millisecond)
+		completed := started.Add(time.Minute)
+		row := NewAnalyticsTaskRow(&novel.Job{
+			ID:          "job-1",
+			Type:        novel.PipelineStageFinalVideo,
+			Status:      novel.JobStatusSucceeded,
+			StartedAt:   &started,
+			CompletedAt: &completed,
+			CreatedAt:   created,
+		})
+		So(row.SchemaVersion, ShouldEqual, 1)
+		So(row.Type, ShouldEqual, "final_video")
+		So(row.QueueWaitMs, ShouldEqual, 1500)
+		So(row.RunDurationMs, ShouldEqual, 60000)
+
+		Convey("未开始的任务耗时为 0", func() {
+			row := NewAnalyticsTaskRow(&novel.Job{ID: "job-2", CreatedAt: created})
+			So(row.QueueWaitMs, ShouldEqual, 0)
+			So(row.RunDurationMs, ShouldEqual, 0)
+		})
+	})
+
+	Convey("NewAnalyticsUsageRow 把 provider 调用次数转换为排序后的数组", t, func() {
+		row := NewAnalyticsUsageRow(&novel.StageRun{
+			ID:            "run-1",
+			Stage:         novel.PipelineStageFinalVideo,
+			ProviderCalls: map[string]int{"tts": 3, "llm": 1},
+			ErrorMessage:  "timeout",
+		})
+		So(row.Succeeded, ShouldBeFalse)
+		So(row.ProviderCalls, ShouldResemble, []AnalyticsProviderCalls{{Provider: "llm", Calls: 1}, {Provider: "tts", Calls: 3}})
+	})
+
+	Convey("EncodeJSONLines 每行一个 JSON 对象", t, func() {
+		data, err := EncodeJSONLines([]AnalyticsCostRow{
+			NewAnalyticsCostRow(&novel.CostRecord{ID: "c1", Provider: "llm", AmountFen: 12, CreatedAt: created}),
+			NewAnalyticsCostRow(&novel.CostRecord{ID: "c2", Provider: "tts", AmountFen: 3, CreatedAt: created}),
+		})
+		So(err, ShouldBeNil)
+		lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		So(lines, ShouldHaveLength, 2)
+		So(lines[0], ShouldEqual, `{"schema_version":1,"record_id":"c1","novel_id":"","chapter_id":"","provider":"llm","units":0,"amount_fen":12,"created_at":"2026-03-01T08:00:00Z"}`)
+	})
+}
+
+func TestAnalyticsSchema(t *testing.T) {
+	Convey("AnalyticsSchema 按行类型生成表结构", t, func() {
+		fields := map[string]AnalyticsField{}
+		for _, f := range AnalyticsSchema(novel.AnalyticsDatasetUsage) {
+			fields[f.Name] = f
+		}
+		So(fields["run_id"].Type, ShouldEqual, "STRING")
+		So(fields["succeeded"].Type, ShouldEqual, "BOOLEAN")
+		So(fields["duration_ms"].Type, ShouldEqual, "INTEGER")
+		So(fields["started_at"].Type, ShouldEqual, "TIMESTAMP")
+		So(fields["provider_calls"].Type, ShouldEqual, "RECORD")
+		So(fields["provider_calls"].Mode, ShouldEqual, "REPEATED")
+		So(fields["provider_calls"].Fields, ShouldHaveLength, 2)
+
+		Convey("可为空的时间字段", func() {
+			for _, f := range AnalyticsSchema(novel.AnalyticsDatasetTasks) {
+				if f.Name == "completed_at" {
+					So(f.Type, ShouldEqual, "TIMESTAMP")
+					So(f.Mode, ShouldEqual, "NULLABLE")
+				}
+			}
+		})
+
+		Convey("每个数据集都有表结构和版本", func() {
+			for _, dataset := range novel.AnalyticsDatasets {
+				So(AnalyticsSchema(dataset), ShouldNotBeEmpty)
+				So(AnalyticsSchemaVersion(dataset), ShouldBeGreaterThan, 0)
+			}
+			So(AnalyticsSchema("unknown"), ShouldBeNil)
+		})
+	})
+}
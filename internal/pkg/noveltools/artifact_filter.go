@@ -0,0 +1,138 @@
+package noveltools
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"lemon/internal/model/novel"
+)
+
+// ErrInvalidArtifactFilter 产物列表的筛选条件不合法
+var ErrInvalidArtifactFilter = errors.New("invalid artifact filter")
+
+// ArtifactTypes 章节产物列表支持的产物类型（按流程顺序）
+var ArtifactTypes = []novel.VersionArtifact{
+	novel.VersionArtifactAudio,
+	novel.VersionArtifactSubtitle,
+	novel.VersionArtifactImage,
+	novel.VersionArtifactVideo,
+}
+
+// artifactStatuses 产物状态（音频、字幕、图片的 TaskStatus 与视频的 VideoStatus 的并集）
+var artifactStatuses = map[string]bool{
+	string(novel.VideoStatusPending):    true,
+	string(novel.VideoStatusProcessing): true,
+	string(novel.VideoStatusCompleted):  true,
+	string(novel.VideoStatusFailed):     true,
+}
+
+// ArtifactVersionLatest 只返回每种产物最新版本的版本筛选值
+const ArtifactVersionLatest = "latest"
+
+// ArtifactFilter 章节产物列表的筛选条件（各条件之间为“且”，同一条件的多个值为“或”）
+type ArtifactFilter struct {
+	Types    map[novel.VersionArtifact]bool // 产物类型，为空时不限
+	Statuses map[string]bool                // 产物状态，为空时不限
+	Version  int                            // 指定版本号，0 表示不限
+	Latest   bool                           // 只返回每种产物的最新版本
+}
+
+// ParseArtifactFilter 解析筛选条件
+// types、statuses 为逗号分隔的列表；version 为版本号或 latest，为空时不限版本
+func ParseArtifactFilter(types, statuses, version string) (*ArtifactFilter, error) {
+	filter := &ArtifactFilter{}
+	for _, t := range splitArtifactFilterList(types) {
+		artifact := novel.VersionArtifact(t)
+		if !isArtifactType(artifact) {
+			return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidArtifactFilter, t)
+		}
+		if filter.Types == nil {
+			filter.Types = map[novel.VersionArtifact]bool{}
+		}
+		filter.Types[artifact] = true
+	}
+	for _, s := range splitArtifactFilterList(statuses) {
+		if !artifactStatuses[s] {
+			return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidArtifactFilter, s)
+		}
+		if filter.Statuses == nil {
+			filter.Statuses = map[string]bool{}
+		}
+		filter.Statuses[s] = true
+	}
+
+	version = strings.TrimSpace(version)
+	switch {
+	case version == "":
+	case strings.EqualFold(version, ArtifactVersionLatest):
+		filter.Latest = true
+	default:
+		v, err := strconv.Atoi(version)
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("%w: version must be a positive integer or %q", ErrInvalidArtifactFilter, ArtifactVersionLatest)
+		}
+		filter.Version = v
+	}
+	return filter, nil
+}
+
+// IncludesType 是否需要查询该类型的产物
+func (f *ArtifactFilter) IncludesType(artifact novel.VersionArtifact) bool {
+	return len(f.Types) == 0 || f.Types[artifact]
+}
+
+// Match 产物是否满足状态和版本条件；latestVersion 为该类型产物的最新版本号（只在筛选最新版本时使用）
+func (f *ArtifactFilter) Match(status string, version, latestVersion int) bool {
+	if len(f.Statuses) > 0 && !f.Statuses[status] {
+		return false
+	}
+	if f.Version > 0 && version != f.Version {
+		return false
+	}
+	if f.Latest && version != latestVersion {
+		return false
+	}
+	return true
+}
+
+// ArtifactStage 产物对应的流程阶段（用于关联重新生成影响分析）；视频按视频类型区分解说视频和最终视频，不属于章节流程的产物返回空
+func ArtifactStage(artifact novel.VersionArtifact, videoType novel.VideoType) novel.PipelineStage {
+	switch artifact {
+	case novel.VersionArtifactAudio:
+		return novel.PipelineStageAudio
+	case novel.VersionArtifactSubtitle:
+		return novel.PipelineStageSubtitle
+	case novel.VersionArtifactImage:
+		return novel.PipelineStageImage
+	case novel.VersionArtifactVideo:
+		switch videoType {
+		case novel.VideoTypeNarration:
+			return novel.PipelineStageNarrationVideo
+		case novel.VideoTypeFinal:
+			return novel.PipelineStageFinalVideo
+		}
+	}
+	return ""
+}
+
+func isArtifactType(artifact novel.VersionArtifact) bool {
+	for _, t := range ArtifactTypes {
+		if t == artifact {
+			return true
+		}
+	}
+	return false
+}
+
+// splitArtifactFilterList 拆分逗号分隔的列表（去掉空白和空项）
+func splitArtifactFilterList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
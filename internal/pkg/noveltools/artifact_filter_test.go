@@ -0,0 +1,71 @@
+package noveltools
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestParseArtifactFilter(t *testing.T) {
+	Convey("ParseArtifactFilter 解析产物列表的筛选条件", t, func() {
+		Convey("没有条件时不限类型、状态和版本", func() {
+			f, err := ParseArtifactFilter("", "", "")
+			So(err, ShouldBeNil)
+			for _, artifact := range ArtifactTypes {
+				So(f.IncludesType(artifact), ShouldBeTrue)
+			}
+			So(f.Match("failed", 3, 5), ShouldBeTrue)
+		})
+
+		Convey("类型和状态为逗号分隔的列表", func() {
+			f, err := ParseArtifactFilter("audio, video", "completed,failed", "")
+			So(err, ShouldBeNil)
+			So(f.IncludesType(novel.VersionArtifactAudio), ShouldBeTrue)
+			So(f.IncludesType(novel.VersionArtifactVideo), ShouldBeTrue)
+			So(f.IncludesType(novel.VersionArtifactImage), ShouldBeFalse)
+			So(f.Match("completed", 1, 1), ShouldBeTrue)
+			So(f.Match("failed", 1, 1), ShouldBeTrue)
+			So(f.Match("pending", 1, 1), ShouldBeFalse)
+		})
+
+		Convey("指定版本号或最新版本", func() {
+			f, err := ParseArtifactFilter("", "", "2")
+			So(err, ShouldBeNil)
+			So(f.Match("completed", 2, 3), ShouldBeTrue)
+			So(f.Match("completed", 3, 3), ShouldBeFalse)
+
+			f, err = ParseArtifactFilter("", "", "latest")
+			So(err, ShouldBeNil)
+			So(f.Latest, ShouldBeTrue)
+			So(f.Match("completed", 3, 3), ShouldBeTrue)
+			So(f.Match("completed", 2, 3), ShouldBeFalse)
+		})
+
+		Convey("未知的类型、状态或不合法的版本返回 ErrInvalidArtifactFilter", func() {
+			for _, args := range [][3]string{
+				{"narration", "", ""},
+				{"", "done", ""},
+				{"", "", "0"},
+				{"", "", "v2"},
+			} {
+				_, err := ParseArtifactFilter(args[0], args[1], args[2])
+				So(errors.Is(err, ErrInvalidArtifactFilter), ShouldBeTrue)
+			}
+		})
+	})
+}
+
+func TestArtifactStage(t *testing.T) {
+	Convey("ArtifactStage 返回产物对应的流程阶段", t, func() {
+		So(ArtifactStage(novel.VersionArtifactAudio, ""), ShouldEqual, novel.PipelineStageAudio)
+		So(ArtifactStage(novel.VersionArtifactSubtitle, ""), ShouldEqual, novel.PipelineStageSubtitle)
+		So(ArtifactStage(novel.VersionArtifactImage, ""), ShouldEqual, novel.PipelineStageImage)
+		So(ArtifactStage(novel.VersionArtifactVideo, novel.VideoTypeNarration), ShouldEqual, novel.PipelineStageNarrationVideo)
+		So(ArtifactStage(novel.VersionArtifactVideo, novel.VideoTypeFinal), ShouldEqual, novel.PipelineStageFinalVideo)
+		So(ArtifactStage(novel.VersionArtifactVideo, novel.VideoTypeNovel), ShouldEqual, novel.PipelineStage(""))
+		So(ArtifactStage(novel.VersionArtifactNarration, ""), ShouldEqual, novel.PipelineStage(""))
+	})
+}
@@ -2,15 +2,130 @@ package noveltools
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+
+	"lemon/internal/model/novel"
+)
+
+// 默认字幕样式（与 gen_ass.py 一致）
+const (
+	defaultSubtitleFontName       = "Microsoft YaHei"
+	defaultSubtitleFontSize       = 36
+	defaultSubtitlePrimaryColor   = "#FFFFFF"
+	defaultSubtitleHighlightColor = "#FFFF00"
+	defaultSubtitleOutlineColor   = "#000000"
+	defaultSubtitleOutline        = 2
+	defaultSubtitleMarginV        = 427
+	defaultSubtitleAlignment      = 2
 )
 
+// SubtitleCardDuration 片头/片尾文案卡片的显示时长（秒）
+const SubtitleCardDuration = 3.0
+
 // ASSGenerator ASS字幕生成器
-type ASSGenerator struct{}
+type ASSGenerator struct {
+	style novel.SubtitleStyle // 字幕样式（已补全默认值）
+
+	intro    string  // 片头文案（为空表示没有片头卡片）
+	outro    string  // 片尾文案（为空表示没有片尾卡片）
+	outroEnd float64 // 片尾卡片的结束时间（秒）
+}
 
-// NewASSGenerator 创建ASS字幕生成器实例
+// NewASSGenerator 创建ASS字幕生成器实例（使用默认字幕样式）
 func NewASSGenerator() *ASSGenerator {
-	return &ASSGenerator{}
+	return NewASSGeneratorWithStyle(nil)
+}
+
+// NewASSGeneratorWithStyle 按字幕样式创建ASS字幕生成器，样式中为空或不合法的字段使用默认值
+func NewASSGeneratorWithStyle(style *novel.SubtitleStyle) *ASSGenerator {
+	return &ASSGenerator{style: ResolveSubtitleStyle(style)}
+}
+
+// ResolveSubtitleStyle 补全字幕样式中为空或不合法的字段
+func ResolveSubtitleStyle(style *novel.SubtitleStyle) novel.SubtitleStyle {
+	resolved := novel.SubtitleStyle{
+		FontName:       defaultSubtitleFontName,
+		FontSize:       defaultSubtitleFontSize,
+		PrimaryColor:   defaultSubtitlePrimaryColor,
+		HighlightColor: defaultSubtitleHighlightColor,
+		OutlineColor:   defaultSubtitleOutlineColor,
+		Outline:        defaultSubtitleOutline,
+		MarginV:        defaultSubtitleMarginV,
+		Alignment:      defaultSubtitleAlignment,
+	}
+	if style == nil {
+		return resolved
+	}
+	// 字体名中的逗号会破坏 ASS 样式行的字段划分
+	if name := strings.TrimSpace(style.FontName); name != "" && !strings.Contains(name, ",") {
+		resolved.FontName = name
+	}
+	if style.FontSize > 0 {
+		resolved.FontSize = style.FontSize
+	}
+	if _, ok := assColor(style.PrimaryColor); ok {
+		resolved.PrimaryColor = style.PrimaryColor
+	}
+	if _, ok := assColor(style.HighlightColor); ok {
+		resolved.HighlightColor = style.HighlightColor
+	}
+	if _, ok := assColor(style.OutlineColor); ok {
+		resolved.OutlineColor = style.OutlineColor
+	}
+	if style.Outline > 0 {
+		resolved.Outline = style.Outline
+	}
+	if style.MarginV > 0 {
+		resolved.MarginV = style.MarginV
+	}
+	if style.Alignment >= 1 && style.Alignment <= 9 {
+		resolved.Alignment = style.Alignment
+	}
+	return resolved
+}
+
+// ValidateSubtitleStyle 校验字幕样式：颜色必须是 #RRGGBB，字号、描边和边距不能为负数，对齐方式为 1-9
+func ValidateSubtitleStyle(style *novel.SubtitleStyle) error {
+	if style == nil {
+		return nil
+	}
+	for _, c := range []string{style.PrimaryColor, style.HighlightColor, style.OutlineColor} {
+		if _, ok := assColor(c); c != "" && !ok {
+			return fmt.Errorf("invalid subtitle color %q, expected #RRGGBB", c)
+		}
+	}
+	if strings.Contains(style.FontName, ",") {
+		return fmt.Errorf("invalid subtitle font name %q", style.FontName)
+	}
+	if style.FontSize < 0 || style.Outline < 0 || style.MarginV < 0 {
+		return fmt.Errorf("subtitle font size, outline and margin must not be negative")
+	}
+	if style.Alignment < 0 || style.Alignment > 9 {
+		return fmt.Errorf("invalid subtitle alignment %d, expected 1-9", style.Alignment)
+	}
+	return nil
+}
+
+// assColor 把 #RRGGBB 转为 ASS 颜色（&H00BBGGRR）
+func assColor(hex string) (string, bool) {
+	hex = strings.TrimPrefix(strings.TrimSpace(hex), "#")
+	if len(hex) != 6 {
+		return "", false
+	}
+	if _, err := strconv.ParseUint(hex, 16, 32); err != nil {
+		return "", false
+	}
+	hex = strings.ToUpper(hex)
+	return "&H00" + hex[4:6] + hex[2:4] + hex[0:2], true
+}
+
+// SetCards 设置片头/片尾文案卡片
+// 片头卡片显示在字幕开头 SubtitleCardDuration 秒，片尾卡片显示在 end 之前 SubtitleCardDuration 秒；文案为空表示不显示
+func (ag *ASSGenerator) SetCards(intro, outro string, end float64) {
+	ag.intro = strings.TrimSpace(intro)
+	ag.outro = strings.TrimSpace(outro)
+	ag.outroEnd = end
 }
 
 // GenerateASSContent 生成ASS格式内容
@@ -20,6 +135,23 @@ func (ag *ASSGenerator) GenerateASSContent(segmentTimestamps []SegmentTimestamp,
 		title = "Generated Subtitle"
 	}
 
+	primary, _ := assColor(ag.style.PrimaryColor)
+	highlight, _ := assColor(ag.style.HighlightColor)
+	outline, _ := assColor(ag.style.OutlineColor)
+	styleLine := func(name, color string, bold, fontSize, alignment, marginV int) string {
+		return fmt.Sprintf("Style: %s,%s,%d,%s,&H000000FF,%s,&H80000000,%d,0,0,0,100,100,0,0,1,%d,2,%d,10,10,%d,1",
+			name, ag.style.FontName, fontSize, color, outline, bold, ag.style.Outline, alignment, marginV)
+	}
+	styles := []string{
+		styleLine("Default", primary, 0, ag.style.FontSize, ag.style.Alignment, ag.style.MarginV),
+		styleLine("Highlight", highlight, 1, ag.style.FontSize, ag.style.Alignment, ag.style.MarginV),
+	}
+	hasCards := ag.intro != "" || ag.outro != ""
+	if hasCards {
+		// 片头/片尾卡片：顶部居中、放大加粗
+		styles = append(styles, styleLine("Card", primary, 1, ag.style.FontSize*3/2, 8, 120))
+	}
+
 	// ASS文件头部
 	assHeader := fmt.Sprintf(`[Script Info]
 Title: %s
@@ -32,12 +164,11 @@ PlayResY: 1080
 
 [V4+ Styles]
 Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
-Style: Default,Microsoft YaHei,36,&H00FFFFFF,&H000000FF,&H00000000,&H80000000,0,0,0,0,100,100,0,0,1,2,2,2,10,10,427,1
-Style: Highlight,Microsoft YaHei,36,&H0000FFFF,&H000000FF,&H00000000,&H80000000,1,0,0,0,100,100,0,0,1,2,2,2,10,10,427,1
+%s
 
 [Events]
 Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
-`, title)
+`, title, strings.Join(styles, "\n"))
 
 	// 生成字幕事件
 	events := []string{}
@@ -50,25 +181,41 @@ Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
 		keyWord := identifyKeyWord(text)
 		highlightedText := text
 		if keyWord != "" && strings.Contains(text, keyWord) {
-			// 使用ASS标签为关键词添加黄色加粗效果
-			replacement := fmt.Sprintf("{\\c&H0000FFFF&\\b1}%s{\\c&H00FFFFFF&\\b0}", keyWord)
+			// 使用ASS标签为关键词添加高亮色加粗效果
+			replacement := fmt.Sprintf("{\\c%s&\\b1}%s{\\c%s&\\b0}", highlight, keyWord, primary)
 			highlightedText = strings.Replace(text, keyWord, replacement, 1)
 		}
 
-		// 转义ASS字幕中的特殊字符，特别是汉字双引号
-		escapedText := strings.ReplaceAll(highlightedText, "\"", "\\\"")
-		escapedText = strings.ReplaceAll(escapedText, "\u201c", "\\\"") // 左双引号
-		escapedText = strings.ReplaceAll(escapedText, "\u201d", "\\\"") // 右双引号
-
 		// 生成事件行
 		eventLine := fmt.Sprintf("Dialogue: 0,%s,%s,Default,,0,0,0,,%s",
-			startTime, endTime, escapedText)
+			startTime, endTime, escapeASSText(highlightedText))
 		events = append(events, eventLine)
 	}
 
+	if ag.intro != "" {
+		events = append(events, fmt.Sprintf("Dialogue: 1,%s,%s,Card,,0,0,0,,%s",
+			formatTimeForASS(0), formatTimeForASS(SubtitleCardDuration), escapeASSText(ag.intro)))
+	}
+	if ag.outro != "" && ag.outroEnd > 0 {
+		start := ag.outroEnd - SubtitleCardDuration
+		if start < 0 {
+			start = 0
+		}
+		events = append(events, fmt.Sprintf("Dialogue: 1,%s,%s,Card,,0,0,0,,%s",
+			formatTimeForASS(start), formatTimeForASS(ag.outroEnd), escapeASSText(ag.outro)))
+	}
+
 	return assHeader + strings.Join(events, "\n")
 }
 
+// escapeASSText 转义ASS字幕中的特殊字符，特别是汉字双引号
+func escapeASSText(text string) string {
+	escapedText := strings.ReplaceAll(text, "\"", "\\\"")
+	escapedText = strings.ReplaceAll(escapedText, "\u201c", "\\\"") // 左双引号
+	escapedText = strings.ReplaceAll(escapedText, "\u201d", "\\\"") // 右双引号
+	return escapedText
+}
+
 // formatTimeForASS 将秒数转换为ASS时间格式 (H:MM:SS.CC)
 func formatTimeForASS(seconds float64) string {
 	hours := int(seconds / 3600)
@@ -0,0 +1,54 @@
+package noveltools
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestASSGeneratorStyle(t *testing.T) {
+	segments := []SegmentTimestamp{{Text: "林凡拔剑", StartTime: 0.5, EndTime: 2}}
+
+	Convey("ASSGenerator 按字幕样式生成 ASS", t, func() {
+		Convey("默认样式与 gen_ass.py 一致", func() {
+			content := NewASSGenerator().GenerateASSContent(segments, "test")
+			So(content, ShouldContainSubstring, "Style: Default,Microsoft YaHei,36,&H00FFFFFF,&H000000FF,&H00000000,&H80000000,0,0,0,0,100,100,0,0,1,2,2,2,10,10,427,1\n")
+			So(content, ShouldContainSubstring, "Style: Highlight,Microsoft YaHei,36,&H0000FFFF,&H000000FF,&H00000000,&H80000000,1,0,0,0,100,100,0,0,1,2,2,2,10,10,427,1\n")
+			So(content, ShouldNotContainSubstring, "Card")
+		})
+
+		Convey("自定义样式覆盖字体、颜色和边距，不合法的字段使用默认值", func() {
+			content := NewASSGeneratorWithStyle(&novel.SubtitleStyle{
+				FontName:       "Source Han Sans",
+				FontSize:       48,
+				HighlightColor: "#66ccff",
+				PrimaryColor:   "white",
+				MarginV:        300,
+			}).GenerateASSContent(segments, "test")
+			So(content, ShouldContainSubstring, "Style: Default,Source Han Sans,48,&H00FFFFFF,")
+			So(content, ShouldContainSubstring, "Style: Highlight,Source Han Sans,48,&H00FFCC66,")
+			So(content, ShouldContainSubstring, ",10,10,300,1\n")
+		})
+
+		Convey("片头片尾卡片使用 Card 样式", func() {
+			gen := NewASSGenerator()
+			gen.SetCards("斗破苍穹", "未完待续", 10)
+			content := gen.GenerateASSContent(segments, "test")
+			So(content, ShouldContainSubstring, "Style: Card,Microsoft YaHei,54,")
+			So(content, ShouldContainSubstring, "Dialogue: 1,0:00:00.00,0:00:03.00,Card,,0,0,0,,斗破苍穹")
+			So(strings.HasSuffix(content, "Dialogue: 1,0:00:07.00,0:00:10.00,Card,,0,0,0,,未完待续"), ShouldBeTrue)
+		})
+	})
+
+	Convey("ValidateSubtitleStyle 校验字幕样式", t, func() {
+		So(ValidateSubtitleStyle(nil), ShouldBeNil)
+		So(ValidateSubtitleStyle(&novel.SubtitleStyle{PrimaryColor: "#FFFFFF", FontSize: 40}), ShouldBeNil)
+		So(ValidateSubtitleStyle(&novel.SubtitleStyle{OutlineColor: "black"}), ShouldNotBeNil)
+		So(ValidateSubtitleStyle(&novel.SubtitleStyle{FontName: "A,B"}), ShouldNotBeNil)
+		So(ValidateSubtitleStyle(&novel.SubtitleStyle{MarginV: -1}), ShouldNotBeNil)
+		So(ValidateSubtitleStyle(&novel.SubtitleStyle{Alignment: 10}), ShouldNotBeNil)
+	})
+}
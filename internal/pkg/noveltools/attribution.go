@@ -0,0 +1,99 @@
+package noveltools
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"lemon/internal/model/novel"
+)
+
+const (
+	DefaultAttributionDuration = 3.0 // 默认署名展示时长（秒）
+	MinAttributionDuration     = 1.0 // 最短署名展示时长（秒）
+	MaxAttributionDuration     = 10.0
+	MaxAttributionTextLength   = 120 // 署名文字（含拼接结果）的最大字数，单行显示
+	maxAttributionFieldLength  = 60  // 原作名称、作者、授权方、授权说明的最大字数
+)
+
+// ErrInvalidAttribution 署名设置不合法（展示方式或时长超出范围、文字过长、要求署名但没有署名内容）
+var ErrInvalidAttribution = errors.New("invalid attribution")
+
+// NormalizeAttribution 整理并校验署名设置：去掉首尾空白，展示方式默认为结尾署名画面，时长默认 3 秒
+// 要求署名时必须填写署名文字或至少一项原作信息
+func NormalizeAttribution(a *novel.Attribution) (*novel.Attribution, error) {
+	if a == nil {
+		return nil, fmt.Errorf("%w: attribution is required", ErrInvalidAttribution)
+	}
+	normalized := &novel.Attribution{
+		Required:  a.Required,
+		Text:      strings.TrimSpace(a.Text),
+		WorkTitle: strings.TrimSpace(a.WorkTitle),
+		Author:    strings.TrimSpace(a.Author),
+		Licensor:  strings.TrimSpace(a.Licensor),
+		License:   strings.TrimSpace(a.License),
+		Placement: a.Placement,
+		Duration:  a.Duration,
+	}
+	if normalized.Placement == "" {
+		normalized.Placement = novel.AttributionPlacementEndFrame
+	}
+	if !normalized.Placement.IsValid() {
+		return nil, fmt.Errorf("%w: placement must be overlay or end_frame", ErrInvalidAttribution)
+	}
+	if normalized.Duration == 0 {
+		normalized.Duration = DefaultAttributionDuration
+	}
+	if normalized.Duration < MinAttributionDuration || normalized.Duration > MaxAttributionDuration {
+		return nil, fmt.Errorf("%w: duration must be between %.0f and %.0f seconds", ErrInvalidAttribution, MinAttributionDuration, MaxAttributionDuration)
+	}
+
+	for name, value := range map[string]string{
+		"work_title": normalized.WorkTitle,
+		"author":     normalized.Author,
+		"licensor":   normalized.Licensor,
+		"license":    normalized.License,
+	} {
+		if utf8.RuneCountInString(value) > maxAttributionFieldLength {
+			return nil, fmt.Errorf("%w: %s exceeds %d characters", ErrInvalidAttribution, name, maxAttributionFieldLength)
+		}
+	}
+	if strings.ContainsAny(normalized.Text, "\r\n") {
+		return nil, fmt.Errorf("%w: text must be a single line", ErrInvalidAttribution)
+	}
+
+	text := AttributionText(normalized)
+	if utf8.RuneCountInString(text) > MaxAttributionTextLength {
+		return nil, fmt.Errorf("%w: text exceeds %d characters", ErrInvalidAttribution, MaxAttributionTextLength)
+	}
+	if normalized.Required && text == "" {
+		return nil, fmt.Errorf("%w: required attribution needs text or work_title/author/licensor/license", ErrInvalidAttribution)
+	}
+	return normalized, nil
+}
+
+// AttributionText 返回最终视频中展示的署名文字
+// 填写了署名文字时直接使用，否则按「原作《名称》 作者：xx 授权方：xx 授权说明」拼接；没有任何署名内容时返回空字符串
+func AttributionText(a *novel.Attribution) string {
+	if a == nil {
+		return ""
+	}
+	if a.Text != "" {
+		return a.Text
+	}
+	var parts []string
+	if a.WorkTitle != "" {
+		parts = append(parts, fmt.Sprintf("原作《%s》", a.WorkTitle))
+	}
+	if a.Author != "" {
+		parts = append(parts, "作者："+a.Author)
+	}
+	if a.Licensor != "" {
+		parts = append(parts, "授权方："+a.Licensor)
+	}
+	if a.License != "" {
+		parts = append(parts, a.License)
+	}
+	return strings.Join(parts, "  ")
+}
@@ -0,0 +1,65 @@
+package noveltools
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestNormalizeAttribution(t *testing.T) {
+	Convey("NormalizeAttribution 整理并校验署名设置", t, func() {
+		Convey("去掉首尾空白，展示方式和时长使用默认值", func() {
+			a, err := NormalizeAttribution(&novel.Attribution{Required: true, WorkTitle: " 斗破苍穹 ", Author: "天蚕土豆 "})
+			So(err, ShouldBeNil)
+			So(a.WorkTitle, ShouldEqual, "斗破苍穹")
+			So(a.Placement, ShouldEqual, novel.AttributionPlacementEndFrame)
+			So(a.Duration, ShouldEqual, DefaultAttributionDuration)
+		})
+
+		Convey("不要求署名时可以没有署名内容", func() {
+			a, err := NormalizeAttribution(&novel.Attribution{Placement: novel.AttributionPlacementOverlay, Duration: 5})
+			So(err, ShouldBeNil)
+			So(AttributionText(a), ShouldBeEmpty)
+		})
+
+		Convey("不合法的设置返回 ErrInvalidAttribution", func() {
+			invalid := []*novel.Attribution{
+				nil,
+				{Required: true},
+				{Required: true, Text: "   "},
+				{Text: "署名", Placement: "banner"},
+				{Text: "署名", Duration: 0.5},
+				{Text: "署名", Duration: 11},
+				{Text: "第一行\n第二行"},
+				{Text: strings.Repeat("字", MaxAttributionTextLength+1)},
+				{Author: strings.Repeat("字", 61)},
+			}
+			for _, a := range invalid {
+				_, err := NormalizeAttribution(a)
+				So(errors.Is(err, ErrInvalidAttribution), ShouldBeTrue)
+			}
+		})
+	})
+}
+
+func TestAttributionText(t *testing.T) {
+	Convey("AttributionText 返回展示的署名文字", t, func() {
+		Convey("优先使用填写的署名文字", func() {
+			So(AttributionText(&novel.Attribution{Text: "本视频改编自起点中文网授权作品", Author: "作者"}), ShouldEqual, "本视频改编自起点中文网授权作品")
+		})
+
+		Convey("未填写时按原作信息拼接", func() {
+			So(AttributionText(&novel.Attribution{WorkTitle: "斗破苍穹", Author: "天蚕土豆", Licensor: "阅文集团", License: "授权编号 2024-001"}),
+				ShouldEqual, "原作《斗破苍穹》  作者：天蚕土豆  授权方：阅文集团  授权编号 2024-001")
+			So(AttributionText(&novel.Attribution{Author: "天蚕土豆"}), ShouldEqual, "作者：天蚕土豆")
+		})
+
+		Convey("没有署名设置时返回空字符串", func() {
+			So(AttributionText(nil), ShouldBeEmpty)
+		})
+	})
+}
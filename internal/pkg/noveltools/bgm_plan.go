@@ -0,0 +1,112 @@
+package noveltools
+
+import (
+	"hash/fnv"
+	"strings"
+
+	"lemon/internal/model/novel"
+)
+
+// DefaultBGMCrossfade 相邻场景切换曲目时的默认交叉淡化时长（秒）
+const DefaultBGMCrossfade = 1.5
+
+// sceneMoodAliases LLM 输出情绪标签时常见的同义词（中文或近义英文）
+var sceneMoodAliases = map[string]novel.SceneMood{
+	"平静": novel.SceneMoodCalm, "舒缓": novel.SceneMoodCalm, "日常": novel.SceneMoodCalm, "peaceful": novel.SceneMoodCalm,
+	"紧张": novel.SceneMoodTense, "危机": novel.SceneMoodTense, "suspense": novel.SceneMoodTense,
+	"浪漫": novel.SceneMoodRomantic, "甜蜜": novel.SceneMoodRomantic, "暧昧": novel.SceneMoodRomantic, "romance": novel.SceneMoodRomantic,
+	"战斗": novel.SceneMoodBattle, "打斗": novel.SceneMoodBattle, "激战": novel.SceneMoodBattle, "fight": novel.SceneMoodBattle, "action": novel.SceneMoodBattle,
+	"悲伤": novel.SceneMoodSad, "伤感": novel.SceneMoodSad, "悲壮": novel.SceneMoodSad, "sorrow": novel.SceneMoodSad,
+	"欢快": novel.SceneMoodJoyful, "轻松": novel.SceneMoodJoyful, "喜悦": novel.SceneMoodJoyful, "happy": novel.SceneMoodJoyful, "comedy": novel.SceneMoodJoyful,
+	"悬疑": novel.SceneMoodMystery, "神秘": novel.SceneMoodMystery, "诡异": novel.SceneMoodMystery, "mysterious": novel.SceneMoodMystery,
+}
+
+// NormalizeSceneMood 把 LLM 输出的情绪标签归一化为支持的场景情绪
+// 支持英文取值（忽略大小写）和常见中文同义词，无法识别时返回空（混音时按 calm 处理）
+func NormalizeSceneMood(raw string) novel.SceneMood {
+	value := strings.ToLower(strings.TrimSpace(raw))
+	if value == "" {
+		return ""
+	}
+	if mood := novel.SceneMood(value); mood.IsValid() {
+		return mood
+	}
+	return sceneMoodAliases[value]
+}
+
+// SceneSpan 场景在最终视频时间轴上的区间
+type SceneSpan struct {
+	SceneNumber string
+	Mood        novel.SceneMood
+	Start       float64 // 开始时间（秒）
+	End         float64 // 结束时间（秒）
+}
+
+// BGMCue 背景音乐时间轴上的一段曲目
+// 相邻场景使用同一曲目时合并为一段连续播放，切换曲目时与下一段交叉淡化
+type BGMCue struct {
+	Track        *novel.BGMTrack
+	Mood         novel.SceneMood // 实际选择曲目时使用的情绪（场景情绪没有曲目时为回退的 calm）
+	Start        float64
+	End          float64
+	SceneNumbers []string // 覆盖的场景编号
+}
+
+// PlanSceneBGM 按场景情绪为每个场景选择曲目，生成背景音乐时间轴
+//
+// 选择规则：
+//  1. 场景没有情绪标签时按 calm 处理；该情绪没有曲目时回退到 calm 的曲目，仍没有则该场景不加背景音乐
+//  2. 同一情绪有多首曲目时按 seed（如解说ID）和情绪稳定地选择，同一章节的同一情绪始终使用同一首，重新渲染结果不变
+//  3. 相邻场景选中同一曲目时合并为一段，避免音乐在场景边界重新开始
+func PlanSceneBGM(spans []SceneSpan, tracks []*novel.BGMTrack, seed string) []BGMCue {
+	byMood := make(map[novel.SceneMood][]*novel.BGMTrack)
+	for _, track := range tracks {
+		if track != nil && track.ResourceID != "" {
+			byMood[track.Mood] = append(byMood[track.Mood], track)
+		}
+	}
+	if len(byMood) == 0 {
+		return nil
+	}
+
+	var cues []BGMCue
+	for _, span := range spans {
+		if span.End <= span.Start {
+			continue
+		}
+		mood := span.Mood
+		if !mood.IsValid() {
+			mood = novel.SceneMoodCalm
+		}
+		candidates := byMood[mood]
+		if len(candidates) == 0 {
+			mood = novel.SceneMoodCalm
+			candidates = byMood[mood]
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+		track := candidates[stableIndex(seed+"/"+string(mood), len(candidates))]
+
+		if n := len(cues); n > 0 && cues[n-1].Track.ID == track.ID && cues[n-1].End >= span.Start {
+			cues[n-1].End = span.End
+			cues[n-1].SceneNumbers = append(cues[n-1].SceneNumbers, span.SceneNumber)
+			continue
+		}
+		cues = append(cues, BGMCue{
+			Track:        track,
+			Mood:         mood,
+			Start:        span.Start,
+			End:          span.End,
+			SceneNumbers: []string{span.SceneNumber},
+		})
+	}
+	return cues
+}
+
+// stableIndex 根据 key 稳定地选择 [0, n) 中的一个下标
+func stableIndex(key string, n int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(n))
+}
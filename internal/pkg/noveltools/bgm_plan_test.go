@@ -0,0 +1,71 @@
+package noveltools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestNormalizeSceneMood(t *testing.T) {
+	Convey("NormalizeSceneMood 归一化 LLM 输出的情绪标签", t, func() {
+		So(NormalizeSceneMood("Battle "), ShouldEqual, novel.SceneMoodBattle)
+		So(NormalizeSceneMood("悬疑"), ShouldEqual, novel.SceneMoodMystery)
+		So(NormalizeSceneMood("甜蜜"), ShouldEqual, novel.SceneMoodRomantic)
+		So(NormalizeSceneMood(""), ShouldEqual, novel.SceneMood(""))
+		So(NormalizeSceneMood("epic"), ShouldEqual, novel.SceneMood(""))
+	})
+}
+
+func TestPlanSceneBGM(t *testing.T) {
+	Convey("PlanSceneBGM 按场景情绪选择曲目", t, func() {
+		calm := &novel.BGMTrack{ID: "calm", Mood: novel.SceneMoodCalm, ResourceID: "r-calm"}
+		battleA := &novel.BGMTrack{ID: "battle-a", Mood: novel.SceneMoodBattle, ResourceID: "r-battle-a"}
+		battleB := &novel.BGMTrack{ID: "battle-b", Mood: novel.SceneMoodBattle, ResourceID: "r-battle-b"}
+		tracks := []*novel.BGMTrack{calm, battleA, battleB}
+
+		spans := []SceneSpan{
+			{SceneNumber: "1", Mood: novel.SceneMoodCalm, Start: 0, End: 10},
+			{SceneNumber: "2", Mood: "", Start: 10, End: 20},
+			{SceneNumber: "3", Mood: novel.SceneMoodBattle, Start: 20, End: 30},
+			{SceneNumber: "4", Mood: novel.SceneMoodBattle, Start: 30, End: 40},
+			{SceneNumber: "5", Mood: novel.SceneMoodSad, Start: 40, End: 50},
+		}
+
+		cues := PlanSceneBGM(spans, tracks, "narration-1")
+
+		Convey("相邻场景同一曲目合并，缺少曲目的情绪回退到 calm", func() {
+			So(cues, ShouldHaveLength, 3)
+			So(cues[0].Track, ShouldEqual, calm)
+			So(cues[0].Start, ShouldEqual, 0)
+			So(cues[0].End, ShouldEqual, 20)
+			So(cues[0].SceneNumbers, ShouldResemble, []string{"1", "2"})
+
+			So(cues[1].Mood, ShouldEqual, novel.SceneMoodBattle)
+			So(cues[1].Start, ShouldEqual, 20)
+			So(cues[1].End, ShouldEqual, 40)
+			So(cues[1].SceneNumbers, ShouldResemble, []string{"3", "4"})
+
+			So(cues[2].Track, ShouldEqual, calm)
+			So(cues[2].Mood, ShouldEqual, novel.SceneMoodCalm)
+			So(cues[2].SceneNumbers, ShouldResemble, []string{"5"})
+		})
+
+		Convey("同一 seed 的选择结果稳定", func() {
+			again := PlanSceneBGM(spans, tracks, "narration-1")
+			So(again[1].Track.ID, ShouldEqual, cues[1].Track.ID)
+		})
+
+		Convey("没有 calm 曲目时无法匹配的场景不加背景音乐", func() {
+			cues := PlanSceneBGM(spans, []*novel.BGMTrack{battleA}, "narration-1")
+			So(cues, ShouldHaveLength, 1)
+			So(cues[0].Track, ShouldEqual, battleA)
+			So(cues[0].SceneNumbers, ShouldResemble, []string{"3", "4"})
+		})
+
+		Convey("没有曲目时返回空", func() {
+			So(PlanSceneBGM(spans, nil, "narration-1"), ShouldBeEmpty)
+		})
+	})
+}
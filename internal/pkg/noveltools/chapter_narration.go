@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"strings"
+
+	"lemon/internal/model/novel"
 )
 
 // NarrationGenerator 解说文案生成器，用于为章节生成解说文案
@@ -12,7 +14,8 @@ import (
 //   - 不负责落库 / 不依赖 HTTP / 不操作资源，只负责组装 prompt 并调用上层注入的 LLM 客户端
 //   - 具体的「如何调用大模型」由调用方通过 llmProvider 注入，方便单测和替换实现
 type NarrationGenerator struct {
-	llmProvider LLMProvider // 调用大模型的提供者（由上层注入，便于在不同环境下切换实现）
+	llmProvider LLMProvider              // 调用大模型的提供者（由上层注入，便于在不同环境下切换实现）
+	structure   novel.NarrationStructure // 解说结构（场景数、每个场景的镜头数、目标时长）
 }
 
 // NewNarrationGenerator 创建解说文案生成器实例
@@ -25,9 +28,16 @@ type NarrationGenerator struct {
 func NewNarrationGenerator(llmProvider LLMProvider) *NarrationGenerator {
 	return &NarrationGenerator{
 		llmProvider: llmProvider,
+		structure:   DefaultNarrationStructure(),
 	}
 }
 
+// WithStructure 设置生成剧本使用的解说结构（由 ResolveNarrationStructure 解析），返回生成器本身
+func (ng *NarrationGenerator) WithStructure(structure novel.NarrationStructure) *NarrationGenerator {
+	ng.structure = structure
+	return ng
+}
+
 // Generate 生成单章节解说
 //
 // Args:
@@ -85,18 +95,115 @@ func (ng *NarrationGenerator) GenerateWithPrompt(
 		wordCount = chapterWordCount[0]
 	}
 
-	prompt := buildChapterNarrationPrompt(chapterContent, chapterNum, totalChapters, wordCount)
+	prompt := buildChapterNarrationPrompt(chapterContent, chapterNum, totalChapters, wordCount, ng.structure)
 	narration, err := ng.llmProvider.Generate(ctx, prompt)
 	return prompt, narration, err
 }
 
+// BuildPrompt 校验章节参数并构造章节解说的提示词
+// 用于调用方需要在生成前拿到提示词的场景（如流式生成时先创建解说记录）
+//
+// Args:
+//   - chapterContent: 章节原始内容
+//   - chapterNum: 当前章节编号（从 1 开始）
+//   - totalChapters: 总章节数
+//   - chapterWordCount: 章节字数（可选，用于调整 prompt 要求）
+//
+// Returns:
+//   - prompt: 提示词
+//   - err: 错误信息
+func (ng *NarrationGenerator) BuildPrompt(
+	chapterContent string,
+	chapterNum int,
+	totalChapters int,
+	chapterWordCount ...int,
+) (string, error) {
+	chapterContent = strings.TrimSpace(chapterContent)
+	if chapterContent == "" {
+		return "", fmt.Errorf("chapterContent is empty")
+	}
+	if chapterNum <= 0 || totalChapters <= 0 {
+		return "", fmt.Errorf("invalid chapter number or totalChapters")
+	}
+
+	var wordCount int
+	if len(chapterWordCount) > 0 {
+		wordCount = chapterWordCount[0]
+	}
+
+	return buildChapterNarrationPrompt(chapterContent, chapterNum, totalChapters, wordCount, ng.structure), nil
+}
+
+// GenerateScenesStream 根据提示词流式生成解说，每解析出一个完整场景就回调 onScene
+// 如果 llmProvider 不支持流式输出，则退化为一次性生成，生成结束后再依次回调所有场景
+//
+// Args:
+//   - ctx: 上下文
+//   - prompt: 提示词（通过 BuildPrompt 构造）
+//   - onScene: 场景回调（index 从 0 开始，按场景解析出的顺序）；返回错误时终止后续回调
+//
+// Returns:
+//   - narration: 大模型生成的完整解说文案
+//   - err: 错误信息（包括 onScene 返回的错误）
+func (ng *NarrationGenerator) GenerateScenesStream(
+	ctx context.Context,
+	prompt string,
+	onScene func(index int, scene *NarrationJSONScene) error,
+) (string, error) {
+	if ng.llmProvider == nil {
+		return "", fmt.Errorf("llmProvider is required")
+	}
+
+	parser := NewSceneStreamParser()
+	var sceneErr error
+	emit := func(chunk string) {
+		scenes := parser.Write(chunk)
+		base := parser.SceneCount() - len(scenes)
+		for i, scene := range scenes {
+			if sceneErr != nil {
+				return
+			}
+			sceneErr = onScene(base+i, scene)
+		}
+	}
+
+	streaming, ok := ng.llmProvider.(StreamingLLMProvider)
+	if !ok {
+		narration, err := ng.llmProvider.Generate(ctx, prompt)
+		if err != nil {
+			return narration, err
+		}
+		emit(narration)
+		return narration, sceneErr
+	}
+
+	narration, err := streaming.GenerateStream(ctx, prompt, emit)
+	if err != nil {
+		return narration, err
+	}
+	return narration, sceneErr
+}
+
 // buildChapterNarrationPrompt 构造章节解说的提示词
 // 要求生成 JSON 格式的结构化数据
 // chapterWordCount: 章节字数（可选），用于根据章节长度调整 prompt 要求
-func buildChapterNarrationPrompt(chapterContent string, chapterNum, totalChapters int, chapterWordCount int) string {
+// structure: 解说结构，决定场景数、每个场景的镜头数和解说字数要求
+func buildChapterNarrationPrompt(chapterContent string, chapterNum, totalChapters int, chapterWordCount int, structure novel.NarrationStructure) string {
+	intro := "请基于下面给出的章节内容，生成适合短视频解说的结构化解说文案。\n\n"
+	return buildNarrationPrompt(intro, func(b *strings.Builder) {
+		b.WriteString("下面是本章节的原始内容：\n")
+		b.WriteString("---- BEGIN CHAPTER ----\n")
+		b.WriteString(chapterContent)
+		b.WriteString("\n---- END CHAPTER ----\n\n")
+	}, chapterNum, totalChapters, chapterWordCount, structure)
+}
+
+// buildNarrationPrompt 构造生成完整剧本的提示词
+// intro 说明素材来源，writeSource 写入素材（章节原文或分段剧本），其余格式和内容要求对整章生成和分块合并相同
+func buildNarrationPrompt(intro string, writeSource func(b *strings.Builder), chapterNum, totalChapters int, chapterWordCount int, structure novel.NarrationStructure) string {
 	var b strings.Builder
 	b.WriteString("你是一名专业的中文小说解说文案撰写助手。\n")
-	b.WriteString("请基于下面给出的章节内容，生成适合短视频解说的结构化解说文案。\n\n")
+	b.WriteString(intro)
 
 	b.WriteString("【⚠️ 关键输出格式要求 - 必须严格遵守】\n")
 	b.WriteString("你的输出必须是一个有效的 JSON 对象，可以直接被 JSON.parse() 或 json.Unmarshal() 解析。\n\n")
@@ -125,32 +232,13 @@ func buildChapterNarrationPrompt(chapterContent string, chapterNum, totalChapter
 	b.WriteString("注意：最后一行 scenes 数组的最后一个元素后面不要有逗号！\n\n")
 
 	b.WriteString("【内容要求】\n")
-	b.WriteString("1. 必须生成7个场景（scene），每个场景包含1-3个分镜头（shot）\n")
+	fmt.Fprintf(&b, "1. %s\n", narrationSceneRequirement(structure))
 	b.WriteString("2. 每个分镜头必须包含：解说内容（narration）、图片描述（scene_prompt）、视频描述（video_prompt）\n")
 	b.WriteString("3. 必须提取并列出本章节中出现的所有角色（characters），包括角色的基本信息（姓名、性别、年龄段、角色编号）和详细描述（外貌、性格、背景等），以及角色图片提示词\n")
 	b.WriteString("4. 必须提取并列出本章节中出现的所有重要道具（props），包括道具的名称、描述、类别（如：武器、法器、丹药、服饰等）和图片提示词\n")
+	b.WriteString("5. 每个场景必须包含情绪标签（mood），只能从以下取值中选择一个：calm（平静）、tense（紧张）、romantic（浪漫）、battle（战斗）、sad（悲伤）、joyful（欢快）、mystery（悬疑），用于为场景选择背景音乐\n")
 
-	// 根据章节长度调整字数要求
-	if chapterWordCount > 0 {
-		// 根据章节字数动态调整解说字数要求（约为章节字数的 10-15%）
-		minNarrationWords := chapterWordCount / 10
-		maxNarrationWords := chapterWordCount * 15 / 100
-		if minNarrationWords < 800 {
-			minNarrationWords = 800
-		}
-		if maxNarrationWords < 1000 {
-			maxNarrationWords = 1000
-		}
-		if minNarrationWords > 1500 {
-			minNarrationWords = 1500
-		}
-		if maxNarrationWords > 2000 {
-			maxNarrationWords = 2000
-		}
-		fmt.Fprintf(&b, "3. 解说内容总字数必须达到%d-%d字（中文字符，根据章节长度%d字调整）\n", minNarrationWords, maxNarrationWords, chapterWordCount)
-	} else {
-		b.WriteString("3. 解说内容总字数必须达到1100-1300字（中文字符）\n")
-	}
+	fmt.Fprintf(&b, "3. 解说内容总字数（中文字符）必须达到%s\n", narrationWordRequirement(structure, chapterWordCount))
 
 	b.WriteString("4. 使用第三人称口播风格，语言自然、口语化\n")
 	b.WriteString("5. 不要剧透后续章节，只围绕当前章节的内容\n\n")
@@ -193,10 +281,7 @@ func buildChapterNarrationPrompt(chapterContent string, chapterNum, totalChapter
 	b.WriteString("4. 如果没有明确的动态效果需求，可以使用默认描述：\"特写镜头，固定机位，时长10秒，画面有明显的动态效果，动作大一些\"\n\n")
 
 	fmt.Fprintf(&b, "当前进度：第 %d 章 / 共 %d 章。\n\n", chapterNum, totalChapters)
-	b.WriteString("下面是本章节的原始内容：\n")
-	b.WriteString("---- BEGIN CHAPTER ----\n")
-	b.WriteString(chapterContent)
-	b.WriteString("\n---- END CHAPTER ----\n\n")
+	writeSource(&b)
 
 	b.WriteString("【输出格式示例】\n")
 	b.WriteString("请严格按照以下 JSON 格式输出，直接输出 JSON 内容，不要任何其他文字：\n")
@@ -229,6 +314,7 @@ func buildChapterNarrationPrompt(chapterContent string, chapterNum, totalChapter
   "scenes": [
     {
       "scene_number": "1",
+      "mood": "tense",
       "narration": "场景级别的解说内容（可选）",
       "shots": [
         {
@@ -249,6 +335,7 @@ func buildChapterNarrationPrompt(chapterContent string, chapterNum, totalChapter
     },
     {
       "scene_number": "2",
+      "mood": "calm",
       "shots": [
         {
           "closeup_number": "1",
@@ -272,29 +359,11 @@ func buildChapterNarrationPrompt(chapterContent string, chapterNum, totalChapter
 	b.WriteString("7. 确认可以直接被 JSON 解析器解析（建议在输出前用 JSON 验证工具测试）\n\n")
 
 	b.WriteString("【内容要求】\n")
-	b.WriteString("1. 必须生成7个场景（scene），每个场景包含1-3个分镜头（shot）\n")
+	fmt.Fprintf(&b, "1. %s\n", narrationSceneRequirement(structure))
 	b.WriteString("2. 每个分镜头必须包含：narration（解说内容）、scene_prompt（图片描述）、video_prompt（视频描述）\n")
+	b.WriteString("3. 每个场景必须包含 mood（情绪标签：calm/tense/romantic/battle/sad/joyful/mystery）\n")
 
-	// 根据章节长度调整字数要求提示
-	if chapterWordCount > 0 {
-		minNarrationWords := chapterWordCount / 10
-		maxNarrationWords := chapterWordCount * 15 / 100
-		if minNarrationWords < 800 {
-			minNarrationWords = 800
-		}
-		if maxNarrationWords < 1000 {
-			maxNarrationWords = 1000
-		}
-		if minNarrationWords > 1500 {
-			minNarrationWords = 1500
-		}
-		if maxNarrationWords > 2000 {
-			maxNarrationWords = 2000
-		}
-		fmt.Fprintf(&b, "6. 确保解说内容总字数在%d-%d字之间（根据章节长度%d字调整）\n", minNarrationWords, maxNarrationWords, chapterWordCount)
-	} else {
-		b.WriteString("6. 确保解说内容总字数在1100-1300字之间\n")
-	}
+	fmt.Fprintf(&b, "6. 确保解说内容总字数为%s\n", narrationWordRequirement(structure, chapterWordCount))
 
 	b.WriteString("8. 解说内容（narration）必须只包含故事内容，禁止包含任何技术性描述（如\"室内场景\"、\"光影\"、\"近景拍摄\"等）\n")
 	b.WriteString("9. 所有技术性描述必须放在 scene_prompt 和 video_prompt 字段中，不要放在 narration 中\n\n")
@@ -0,0 +1,86 @@
+package noveltools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/errclass"
+)
+
+const (
+	// maxSummaryInputRunes 生成摘要时送入大模型的章节正文上限，超出部分截断
+	maxSummaryInputRunes = 20000
+	// maxPOVCharacters 摘要最多返回的视角人物数
+	maxPOVCharacters = 5
+)
+
+// chapterSummaryOutput 大模型返回的章节摘要
+type chapterSummaryOutput struct {
+	Summary         string   `json:"summary"`
+	POVCharacters   []string `json:"pov_characters"`
+	EstimatedScenes int      `json:"estimated_scenes"`
+}
+
+// BuildChapterSummaryPrompt 构建章节摘要提示词：200~300 字摘要、视角人物、预计场景数
+// 正文超过 maxSummaryInputRunes 时只取开头部分
+func BuildChapterSummaryPrompt(title, text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", fmt.Errorf("chapter text is empty")
+	}
+	truncated := ""
+	if utf8.RuneCountInString(text) > maxSummaryInputRunes {
+		text = string([]rune(text)[:maxSummaryInputRunes])
+		truncated = "（正文过长，只提供了开头部分）\n"
+	}
+
+	return fmt.Sprintf(`你是一名小说编辑。请阅读下面的章节「%s」，帮助编辑在改编成解说视频前快速了解这一章。
+%s
+【章节正文】
+%s
+
+要求：
+1. summary：用 200~300 字概括本章的主要情节，交代清楚人物、冲突和结果，不要评价
+2. pov_characters：本章的视角人物（叙述主要跟随的人物），按重要程度排列，最多 %d 个；第三人称全知视角时列出戏份最重的人物
+3. estimated_scenes：改编成解说视频时预计的场景数（地点或时间发生变化即为新场景），为正整数
+4. 只返回 JSON 对象，格式为 {"summary":"摘要","pov_characters":["人物"],"estimated_scenes":场景数}，不要其他文字`,
+		title, truncated, text, maxPOVCharacters,
+	), nil
+}
+
+// ParseChapterSummary 解析大模型返回的章节摘要
+// 视角人物去掉空白和重复项，最多保留 maxPOVCharacters 个；预计场景数至少为 1；摘要为空时返回错误
+func ParseChapterSummary(output string) (*novel.ChapterSummary, error) {
+	var parsed chapterSummaryOutput
+	if err := json.Unmarshal([]byte(CleanJSONContent(output)), &parsed); err != nil {
+		return nil, errclass.Wrap(errclass.ValidationSchema, "", fmt.Errorf("parse chapter summary: %w", err))
+	}
+
+	summary := strings.TrimSpace(parsed.Summary)
+	if summary == "" {
+		return nil, fmt.Errorf("parse chapter summary: summary is empty")
+	}
+
+	characters := make([]string, 0, len(parsed.POVCharacters))
+	seen := make(map[string]bool, len(parsed.POVCharacters))
+	for _, name := range parsed.POVCharacters {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		characters = append(characters, name)
+		if len(characters) == maxPOVCharacters {
+			break
+		}
+	}
+
+	return &novel.ChapterSummary{
+		Summary:         summary,
+		POVCharacters:   characters,
+		EstimatedScenes: max(parsed.EstimatedScenes, 1),
+	}, nil
+}
@@ -0,0 +1,59 @@
+package noveltools
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBuildChapterSummaryPrompt(t *testing.T) {
+	Convey("BuildChapterSummaryPrompt 构建章节摘要提示词", t, func() {
+		Convey("包含标题和正文", func() {
+			prompt, err := BuildChapterSummaryPrompt("第1章 下山", "少年背着剑走下山门。")
+			So(err, ShouldBeNil)
+			So(prompt, ShouldContainSubstring, "第1章 下山")
+			So(prompt, ShouldContainSubstring, "少年背着剑走下山门。")
+			So(prompt, ShouldNotContainSubstring, "只提供了开头部分")
+		})
+
+		Convey("正文过长时截断并提示", func() {
+			prompt, err := BuildChapterSummaryPrompt("长章", strings.Repeat("字", maxSummaryInputRunes+100))
+			So(err, ShouldBeNil)
+			So(prompt, ShouldContainSubstring, "只提供了开头部分")
+			So(strings.Count(prompt, "字"), ShouldBeLessThan, maxSummaryInputRunes+100)
+		})
+
+		Convey("正文为空时返回错误", func() {
+			_, err := BuildChapterSummaryPrompt("空章", "  \n")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestParseChapterSummary(t *testing.T) {
+	Convey("ParseChapterSummary 解析章节摘要", t, func() {
+		Convey("去掉代码块标记，视角人物去重，场景数至少为 1", func() {
+			output := "```json\n{\"summary\":\" 少年下山。 \",\"pov_characters\":[\"林凡\",\" \",\"林凡\",\"苏青\"],\"estimated_scenes\":0}\n```"
+			summary, err := ParseChapterSummary(output)
+			So(err, ShouldBeNil)
+			So(summary.Summary, ShouldEqual, "少年下山。")
+			So(summary.POVCharacters, ShouldResemble, []string{"林凡", "苏青"})
+			So(summary.EstimatedScenes, ShouldEqual, 1)
+		})
+
+		Convey("视角人物最多保留 5 个", func() {
+			summary, err := ParseChapterSummary(`{"summary":"摘要","pov_characters":["甲","乙","丙","丁","戊","己"],"estimated_scenes":4}`)
+			So(err, ShouldBeNil)
+			So(len(summary.POVCharacters), ShouldEqual, maxPOVCharacters)
+			So(summary.EstimatedScenes, ShouldEqual, 4)
+		})
+
+		Convey("摘要为空或不是 JSON 时返回错误", func() {
+			_, err := ParseChapterSummary(`{"summary":"","pov_characters":[]}`)
+			So(err, ShouldNotBeNil)
+			_, err = ParseChapterSummary("这一章讲了少年下山")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
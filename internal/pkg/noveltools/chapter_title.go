@@ -0,0 +1,108 @@
+package noveltools
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ChapterTitle 规范化后的章节标题
+type ChapterTitle struct {
+	Original   string // 切分器识别出的原始标题
+	Display    string // 展示标题：去掉首尾空白、多余空格和括号批注（如「（修）」「【求月票】」）
+	Normalized string // 规范化标题：章节序号转为阿拉伯数字（第十二章 重生 → 第12章 重生），便于排序和检索
+	Number     int    // 标题中的章节序号，无法识别时为 0
+	Name       string // 章节名（去掉「第X章」前缀后的部分）
+}
+
+// chapterTitleHeadPatterns 章节标题前缀，第一个分组为章节序号
+var chapterTitleHeadPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^第\s*([零〇一二两三四五六七八九十百千万0-9]+)\s*章`),
+	regexp.MustCompile(`(?i)^chapter\s*([0-9]+)`),
+	regexp.MustCompile(`^章节\s*([0-9]+)`),
+}
+
+// titleAnnotationPattern 标题中的括号批注（（修）、(求月票)、【加更】、[修正]）
+var titleAnnotationPattern = regexp.MustCompile(`（[^（）]*）|\([^()]*\)|【[^【】]*】|\[[^\[\]]*\]`)
+
+// NormalizeChapterTitle 规范化章节标题
+//
+// 例如 "　　第十二章  重生（修）" →
+//
+//	Display:    "第十二章 重生"
+//	Normalized: "第12章 重生"
+//	Number:     12
+//	Name:       "重生"
+func NormalizeChapterTitle(raw string) ChapterTitle {
+	title := ChapterTitle{Original: raw}
+
+	display := toHalfWidthDigits(raw)
+	display = titleAnnotationPattern.ReplaceAllString(display, " ")
+	display = strings.Join(strings.FieldsFunc(display, unicode.IsSpace), " ")
+	title.Display = display
+	title.Normalized = display
+	title.Name = display
+
+	for i, re := range chapterTitleHeadPatterns {
+		m := re.FindStringSubmatchIndex(display)
+		if m == nil {
+			continue
+		}
+		n, ok := parseChapterNumber(display[m[2]:m[3]])
+		if !ok {
+			break
+		}
+		title.Number = n
+		title.Name = strings.TrimSpace(strings.TrimLeft(display[m[1]:], " :：.、-—"))
+
+		var head string
+		switch i {
+		case 0:
+			head = "第" + strconv.Itoa(n) + "章"
+		case 1:
+			head = "Chapter " + strconv.Itoa(n)
+		default:
+			head = "章节 " + strconv.Itoa(n)
+		}
+		title.Normalized = head
+		if title.Name != "" {
+			title.Normalized += " " + title.Name
+		}
+		break
+	}
+	return title
+}
+
+// parseChapterNumber 解析章节序号，支持阿拉伯数字和中文数字（十二、一百零五、一二三）
+func parseChapterNumber(s string) (int, bool) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, n > 0
+	}
+
+	run := []rune(s)
+	if v, ok := parseWan(run); ok && v > 0 {
+		return int(v), true
+	}
+
+	// 逐位书写的中文数字（一二三 → 123）
+	n := 0
+	for _, r := range run {
+		d := chineseDigitValue(r)
+		if d < 0 {
+			return 0, false
+		}
+		n = n*10 + int(d)
+	}
+	return n, n > 0
+}
+
+// toHalfWidthDigits 将全角数字转为半角数字
+func toHalfWidthDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '０' && r <= '９' {
+			return r - '０' + '0'
+		}
+		return r
+	}, s)
+}
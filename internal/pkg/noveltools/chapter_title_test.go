@@ -0,0 +1,44 @@
+package noveltools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNormalizeChapterTitle(t *testing.T) {
+	Convey("NormalizeChapterTitle 规范化章节标题", t, func() {
+		Convey("去掉空白和括号批注，中文序号转为阿拉伯数字", func() {
+			title := NormalizeChapterTitle("　　第十二章  重生（修）")
+			So(title.Original, ShouldEqual, "　　第十二章  重生（修）")
+			So(title.Display, ShouldEqual, "第十二章 重生")
+			So(title.Normalized, ShouldEqual, "第12章 重生")
+			So(title.Number, ShouldEqual, 12)
+			So(title.Name, ShouldEqual, "重生")
+		})
+
+		Convey("支持百位、零和「两」", func() {
+			So(NormalizeChapterTitle("第一百零五章：归来【求月票】").Normalized, ShouldEqual, "第105章 归来")
+			So(NormalizeChapterTitle("第两百章").Number, ShouldEqual, 200)
+		})
+
+		Convey("支持逐位书写的中文数字和全角数字", func() {
+			So(NormalizeChapterTitle("第一二三章 夜行").Number, ShouldEqual, 123)
+			So(NormalizeChapterTitle("第１２章 风起").Normalized, ShouldEqual, "第12章 风起")
+		})
+
+		Convey("英文标题", func() {
+			title := NormalizeChapterTitle("Chapter 3  The End (rev)")
+			So(title.Display, ShouldEqual, "Chapter 3 The End")
+			So(title.Number, ShouldEqual, 3)
+			So(title.Name, ShouldEqual, "The End")
+		})
+
+		Convey("无法识别序号时保留展示标题，序号为 0", func() {
+			title := NormalizeChapterTitle("序章 开始")
+			So(title.Display, ShouldEqual, "序章 开始")
+			So(title.Normalized, ShouldEqual, "序章 开始")
+			So(title.Number, ShouldEqual, 0)
+		})
+	})
+}
@@ -0,0 +1,118 @@
+package noveltools
+
+// numeralPart 按单位切分后的片段
+type numeralPart struct {
+	runes []rune
+	unit  rune // 片段后面的单位（最后一个片段为 0）
+}
+
+// splitKeep 按单位切分中文数字，保留每段对应的单位
+func splitKeep(run []rune, unit rune) []numeralPart {
+	var parts []numeralPart
+	start := 0
+	for i, r := range run {
+		if r == unit {
+			parts = append(parts, numeralPart{runes: run[start:i], unit: unit})
+			start = i + 1
+		}
+	}
+	return append(parts, numeralPart{runes: run[start:]})
+}
+
+// parseWan 解析亿以内（可带「万」）的中文数字
+func parseWan(run []rune) (int64, bool) {
+	var total int64
+	parts := splitKeep(run, '万')
+	if len(parts) > 2 {
+		return 0, false
+	}
+	for _, part := range parts {
+		if part.unit == '万' && len(part.runes) == 0 {
+			return 0, false
+		}
+		v, ok := parseSection(part.runes)
+		if !ok {
+			return 0, false
+		}
+		if part.unit == '万' {
+			v *= 10000
+		}
+		total += v
+	}
+	return total, true
+}
+
+// parseSection 解析万以内的中文数字（一千零一、十五、一百二 = 120）
+func parseSection(run []rune) (int64, bool) {
+	var total int64
+	digit := int64(-1)
+	lastUnit := int64(10000)
+	afterZero := false
+	for i, r := range run {
+		if v := chineseDigitValue(r); v >= 0 {
+			if r == '零' || r == '〇' {
+				if digit >= 0 {
+					return 0, false
+				}
+				afterZero = true
+				continue
+			}
+			if digit >= 0 {
+				return 0, false // 连续两个数字（一五）
+			}
+			digit = int64(v)
+			continue
+		}
+
+		unit := map[rune]int64{'十': 10, '百': 100, '千': 1000}[r]
+		if unit == 0 || unit >= lastUnit {
+			return 0, false
+		}
+		if digit < 0 {
+			if r != '十' || i != 0 {
+				return 0, false
+			}
+			digit = 1 // 十五 = 一十五
+		}
+		total += digit * unit
+		digit = -1
+		lastUnit = unit
+		afterZero = false
+	}
+	if digit >= 0 {
+		// 口语省略末位单位：一百二 = 120，一千五 = 1500（前面有「零」时按个位处理：一百零二 = 102）
+		if lastUnit >= 100 && lastUnit != 10000 && !afterZero {
+			total += digit * lastUnit / 10
+		} else {
+			total += digit
+		}
+	}
+	return total, true
+}
+
+// chineseDigitValue 返回中文数字对应的数值（0-9），非数字返回 -1
+func chineseDigitValue(r rune) rune {
+	switch r {
+	case '零', '〇':
+		return 0
+	case '一':
+		return 1
+	case '二', '两':
+		return 2
+	case '三':
+		return 3
+	case '四':
+		return 4
+	case '五':
+		return 5
+	case '六':
+		return 6
+	case '七':
+		return 7
+	case '八':
+		return 8
+	case '九':
+		return 9
+	}
+	return -1
+}
@@ -0,0 +1,71 @@
+package noveltools
+
+import (
+	"errors"
+	"fmt"
+
+	"lemon/internal/model/novel"
+)
+
+const (
+	// DefaultClipMergeUnder short 规则未设置阈值时使用的时长阈值（秒）
+	DefaultClipMergeUnder = 3.0
+	// maxMergedClipDuration short 规则合并后单个视频的最长时长（秒），超过时另起一组
+	maxMergedClipDuration = 30.0
+)
+
+// ErrInvalidClipDuration 镜头的音频时长缺失或不合法，无法按时长合并
+var ErrInvalidClipDuration = errors.New("invalid clip duration")
+
+// ClipMergeCandidate 参与合并分组的镜头（按播放顺序）
+type ClipMergeCandidate struct {
+	Scene    string  // 所属场景编号
+	Duration float64 // 镜头视频时长（秒，音频时长加片段之后的停顿）
+}
+
+// GroupClipsForMerge 按合并规则把镜头分组，返回每组镜头在 clips 中的下标（组内和组间都保持播放顺序）
+//   - none（或空）：不合并，每个镜头一组
+//   - short：时长不足 under 秒（为 0 时使用 DefaultClipMergeUnder）的镜头并入前一组，
+//     累计时长仍不足 under 的组继续吸收下一个镜头，合并后的时长不超过 30 秒
+//   - scene：同一场景的相邻镜头为一组
+//
+// 合并时校验每个镜头的时长，时长不大于 0 时返回 ErrInvalidClipDuration
+func GroupClipsForMerge(clips []ClipMergeCandidate, mode novel.ClipMergeMode, under float64) ([][]int, error) {
+	if mode == "" {
+		mode = novel.ClipMergeNone
+	}
+	if !mode.IsValid() {
+		return nil, fmt.Errorf("%w: unknown clip_merge %q", ErrInvalidGenerationSettings, mode)
+	}
+	if mode != novel.ClipMergeNone {
+		for i, clip := range clips {
+			if clip.Duration <= 0 {
+				return nil, fmt.Errorf("%w: clip %d has duration %.2f", ErrInvalidClipDuration, i, clip.Duration)
+			}
+		}
+	}
+	if under <= 0 {
+		under = DefaultClipMergeUnder
+	}
+
+	var groups [][]int
+	var total float64 // 当前组（最后一组）的累计时长
+	for i, clip := range clips {
+		last := len(groups) - 1
+		join := false
+		switch mode {
+		case novel.ClipMergeShort:
+			join = last >= 0 && (clip.Duration < under || total < under) && total+clip.Duration <= maxMergedClipDuration
+		case novel.ClipMergeScene:
+			join = last >= 0 && clips[groups[last][0]].Scene == clip.Scene
+		}
+		if join {
+			groups[last] = append(groups[last], i)
+			total += clip.Duration
+			continue
+		}
+		groups = append(groups, []int{i})
+		total = clip.Duration
+	}
+	return groups, nil
+}
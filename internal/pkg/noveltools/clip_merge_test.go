@@ -0,0 +1,70 @@
+package noveltools
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestGroupClipsForMerge(t *testing.T) {
+	Convey("GroupClipsForMerge 按规则分组镜头", t, func() {
+		clips := []ClipMergeCandidate{
+			{Scene: "1", Duration: 5},
+			{Scene: "1", Duration: 1.5},
+			{Scene: "1", Duration: 6},
+			{Scene: "2", Duration: 1},
+			{Scene: "2", Duration: 1.2},
+			{Scene: "3", Duration: 8},
+		}
+
+		Convey("none 和空规则不合并", func() {
+			groups, err := GroupClipsForMerge(clips, novel.ClipMergeNone, 0)
+			So(err, ShouldBeNil)
+			So(groups, ShouldResemble, [][]int{{0}, {1}, {2}, {3}, {4}, {5}})
+
+			groups, err = GroupClipsForMerge(clips, "", 0)
+			So(err, ShouldBeNil)
+			So(len(groups), ShouldEqual, len(clips))
+		})
+
+		Convey("short 把不足阈值的镜头并入前一组，不足阈值的组继续吸收下一个镜头", func() {
+			groups, err := GroupClipsForMerge(clips, novel.ClipMergeShort, 2)
+			So(err, ShouldBeNil)
+			So(groups, ShouldResemble, [][]int{{0, 1}, {2, 3, 4}, {5}})
+
+			groups, err = GroupClipsForMerge([]ClipMergeCandidate{{Duration: 1}, {Duration: 1}, {Duration: 4}, {Duration: 4}}, novel.ClipMergeShort, 0)
+			So(err, ShouldBeNil)
+			So(groups, ShouldResemble, [][]int{{0, 1, 2}, {3}})
+		})
+
+		Convey("short 合并后的时长不超过上限", func() {
+			groups, err := GroupClipsForMerge([]ClipMergeCandidate{{Duration: 29}, {Duration: 2}}, novel.ClipMergeShort, 3)
+			So(err, ShouldBeNil)
+			So(groups, ShouldResemble, [][]int{{0}, {1}})
+		})
+
+		Convey("scene 按相邻的同一场景分组", func() {
+			groups, err := GroupClipsForMerge(clips, novel.ClipMergeScene, 0)
+			So(err, ShouldBeNil)
+			So(groups, ShouldResemble, [][]int{{0, 1, 2}, {3, 4}, {5}})
+		})
+
+		Convey("合并时镜头时长不合法返回错误，不合并时不校验时长", func() {
+			invalid := []ClipMergeCandidate{{Scene: "1", Duration: 3}, {Scene: "1"}}
+			_, err := GroupClipsForMerge(invalid, novel.ClipMergeScene, 0)
+			So(errors.Is(err, ErrInvalidClipDuration), ShouldBeTrue)
+
+			groups, err := GroupClipsForMerge(invalid, novel.ClipMergeNone, 0)
+			So(err, ShouldBeNil)
+			So(groups, ShouldResemble, [][]int{{0}, {1}})
+		})
+
+		Convey("未知规则返回错误", func() {
+			_, err := GroupClipsForMerge(clips, "first3", 0)
+			So(errors.Is(err, ErrInvalidGenerationSettings), ShouldBeTrue)
+		})
+	})
+}
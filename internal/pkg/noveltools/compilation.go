@@ -0,0 +1,137 @@
+package noveltools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"lemon/internal/pkg/errclass"
+)
+
+// CompilationClipSeconds 回顾合集中每个镜头的平均时长（秒），用于按目标时长估算镜头数量
+const CompilationClipSeconds = 5.0
+
+// MaxCompilationClips 单个回顾合集最多包含的镜头数
+const MaxCompilationClips = 60
+
+// CompilationCandidate 回顾合集的候选镜头
+type CompilationCandidate struct {
+	ShotID          string // 镜头ID
+	ChapterSequence int    // 所属章节序号
+	Index           int    // 镜头在章节中的全局索引
+	Rating          int    // 编辑评分（1-5，0 表示未评分）
+	Narration       string // 镜头的原解说
+}
+
+// CompilationClipCount 按目标时长估算回顾合集的镜头数量（至少 1 个，不超过 MaxCompilationClips）
+func CompilationClipCount(targetDuration float64) int {
+	count := int(math.Ceil(targetDuration / CompilationClipSeconds))
+	if count < 1 {
+		return 1
+	}
+	if count > MaxCompilationClips {
+		return MaxCompilationClips
+	}
+	return count
+}
+
+// SelectCompilationHighlights 按评分挑选高光镜头
+// 只考虑已评分的镜头，评分高的优先，同分时故事中靠前的优先；选出的镜头按故事顺序（章节序号、镜头索引）返回
+func SelectCompilationHighlights(candidates []CompilationCandidate, targetDuration float64) []CompilationCandidate {
+	rated := make([]CompilationCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		if c.Rating > 0 {
+			rated = append(rated, c)
+		}
+	}
+	sort.SliceStable(rated, func(i, j int) bool {
+		if rated[i].Rating != rated[j].Rating {
+			return rated[i].Rating > rated[j].Rating
+		}
+		return compilationStoryLess(rated[i], rated[j])
+	})
+
+	if count := CompilationClipCount(targetDuration); len(rated) > count {
+		rated = rated[:count]
+	}
+	SortCompilationCandidates(rated)
+	return rated
+}
+
+// SortCompilationCandidates 按故事顺序（章节序号、镜头索引）排序
+func SortCompilationCandidates(candidates []CompilationCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return compilationStoryLess(candidates[i], candidates[j])
+	})
+}
+
+func compilationStoryLess(a, b CompilationCandidate) bool {
+	if a.ChapterSequence != b.ChapterSequence {
+		return a.ChapterSequence < b.ChapterSequence
+	}
+	return a.Index < b.Index
+}
+
+// recapLine LLM 返回的单条回顾解说
+type recapLine struct {
+	Index     int    `json:"index"`
+	Narration string `json:"narration"`
+}
+
+// BuildRecapScriptPrompt 构造让大模型为回顾合集撰写新解说的提示词
+// 每个镜头一句解说，总字数按目标时长和正常语速估算，解说需要把跨章节的镜头串成连贯的前情回顾
+func BuildRecapScriptPrompt(title string, clips []CompilationCandidate, targetDuration float64) (string, error) {
+	if len(clips) == 0 {
+		return "", fmt.Errorf("compilation has no clips")
+	}
+
+	totalChars := int(targetDuration * ReadingCharsPerSecond)
+	perClip := totalChars / len(clips)
+	if perClip < 10 {
+		perClip = 10
+	}
+
+	var sb strings.Builder
+	for i, clip := range clips {
+		fmt.Fprintf(&sb, "%d. （第%d章）%s\n", i+1, clip.ChapterSequence, strings.TrimSpace(clip.Narration))
+	}
+
+	return fmt.Sprintf(`你是一名短视频解说编辑。下面是小说《%s》多个章节中挑选出的镜头，按播放顺序列出了每个镜头原来的解说。
+请为这些镜头重新撰写一段前情回顾解说，用于剪辑成一个约%.0f秒的回顾合集视频。
+
+【镜头原解说】
+%s
+要求：
+1. 每个镜头写一句解说，与镜头画面内容对应，共%d句，顺序不变
+2. 所有解说连起来是一段连贯的回顾，跨章节时自然过渡，交代清楚前因后果
+3. 每句约%d字，总字数不超过%d字
+4. 只返回 JSON 数组，格式为 [{"index":镜头序号,"narration":"解说"}]，不要其他文字`,
+		title, targetDuration, sb.String(), len(clips), perClip, totalChars,
+	), nil
+}
+
+// ParseRecapScript 解析大模型返回的回顾解说，返回与镜头一一对应的解说（缺失的镜头为空字符串）
+// 序号越界或解说为空的条目会被丢弃；没有任何有效解说时返回错误
+func ParseRecapScript(output string, clipCount int) ([]string, error) {
+	var lines []recapLine
+	if err := json.Unmarshal([]byte(CleanJSONContent(output)), &lines); err != nil {
+		return nil, errclass.Wrap(errclass.ValidationSchema, "", fmt.Errorf("parse recap script: %w", err))
+	}
+
+	script := make([]string, clipCount)
+	valid := 0
+	for _, line := range lines {
+		text := strings.TrimSpace(line.Narration)
+		if line.Index < 1 || line.Index > clipCount || text == "" || script[line.Index-1] != "" {
+			continue
+		}
+		script[line.Index-1] = text
+		valid++
+	}
+	if valid == 0 {
+		return nil, fmt.Errorf("parse recap script: no valid lines")
+	}
+	return script, nil
+}
@@ -0,0 +1,86 @@
+package noveltools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSelectCompilationHighlights(t *testing.T) {
+	Convey("SelectCompilationHighlights 按评分挑选高光镜头", t, func() {
+		candidates := []CompilationCandidate{
+			{ShotID: "c2-1", ChapterSequence: 2, Index: 1, Rating: 5},
+			{ShotID: "c1-3", ChapterSequence: 1, Index: 3, Rating: 4},
+			{ShotID: "c1-1", ChapterSequence: 1, Index: 1, Rating: 5},
+			{ShotID: "c1-2", ChapterSequence: 1, Index: 2},
+			{ShotID: "c3-1", ChapterSequence: 3, Index: 1, Rating: 4},
+		}
+
+		Convey("评分高的优先，同分时靠前的优先，结果按故事顺序返回", func() {
+			selected := SelectCompilationHighlights(candidates, 15)
+			ids := make([]string, 0, len(selected))
+			for _, c := range selected {
+				ids = append(ids, c.ShotID)
+			}
+			So(ids, ShouldResemble, []string{"c1-1", "c1-3", "c2-1"})
+		})
+
+		Convey("未评分的镜头不入选", func() {
+			selected := SelectCompilationHighlights(candidates, 100)
+			So(len(selected), ShouldEqual, 4)
+			for _, c := range selected {
+				So(c.Rating, ShouldBeGreaterThan, 0)
+			}
+		})
+
+		Convey("没有评分时返回空", func() {
+			So(SelectCompilationHighlights([]CompilationCandidate{{ShotID: "a"}}, 30), ShouldBeEmpty)
+		})
+	})
+}
+
+func TestCompilationClipCount(t *testing.T) {
+	Convey("CompilationClipCount 按目标时长估算镜头数量", t, func() {
+		So(CompilationClipCount(0), ShouldEqual, 1)
+		So(CompilationClipCount(12), ShouldEqual, 3)
+		So(CompilationClipCount(3600), ShouldEqual, MaxCompilationClips)
+	})
+}
+
+func TestRecapScript(t *testing.T) {
+	Convey("回顾解说的提示词和解析", t, func() {
+		clips := []CompilationCandidate{
+			{ChapterSequence: 1, Narration: " 少年离开小镇。 "},
+			{ChapterSequence: 3, Narration: "他在山门前跪了三天。"},
+		}
+
+		Convey("提示词包含每个镜头的章节和原解说", func() {
+			prompt, err := BuildRecapScriptPrompt("剑来", clips, 20)
+			So(err, ShouldBeNil)
+			So(prompt, ShouldContainSubstring, "《剑来》")
+			So(prompt, ShouldContainSubstring, "1. （第1章）少年离开小镇。\n")
+			So(prompt, ShouldContainSubstring, "2. （第3章）他在山门前跪了三天。")
+			So(prompt, ShouldContainSubstring, "共2句")
+			So(prompt, ShouldContainSubstring, "总字数不超过84字")
+		})
+
+		Convey("没有镜头时返回错误", func() {
+			_, err := BuildRecapScriptPrompt("剑来", nil, 20)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("按序号解析，丢弃越界、重复和空解说", func() {
+			output := "```json\n[{\"index\":2,\"narration\":\" 三天后山门终于打开。 \"},{\"index\":2,\"narration\":\"重复\"},{\"index\":5,\"narration\":\"越界\"},{\"index\":1,\"narration\":\"\"}]\n```"
+			script, err := ParseRecapScript(output, 2)
+			So(err, ShouldBeNil)
+			So(script, ShouldResemble, []string{"", "三天后山门终于打开。"})
+		})
+
+		Convey("没有有效解说或不是 JSON 时返回错误", func() {
+			_, err := ParseRecapScript(`[{"index":3,"narration":"越界"}]`, 2)
+			So(err, ShouldNotBeNil)
+			_, err = ParseRecapScript("不是JSON", 2)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
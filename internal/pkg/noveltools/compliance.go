@@ -0,0 +1,105 @@
+package noveltools
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ffmpeg"
+)
+
+// 校验容差
+const (
+	complianceLoudnessTolerance = 1.0 // 综合响度允许偏离目标的范围（LU）
+	complianceTruePeakTolerance = 0.5 // 真峰值允许超出上限的范围（dB，linear 模式下 loudnorm 的统计为估算值）
+	complianceBitrateTolerance  = 1.1 // 平均码率允许超出上限的比例
+)
+
+// compliancePresets 各平台的响度和格式规范
+// 抖音/快手/TikTok 推荐 -14 LUFS；YouTube 按 -14 LUFS 归一化播放；B 站按 -16 LUFS 处理，码率超过约 6Mbps 会被二次压缩
+var compliancePresets = map[novel.TargetPlatform]ffmpeg.CompliancePreset{
+	novel.TargetPlatformDefault:  {LoudnessTarget: -16, TruePeak: -1.5, LoudnessRange: 11, PixelFormat: "yuv420p", MaxVideoBitrate: 8000, AudioBitrate: 160, AudioSampleRate: 48000, Faststart: true},
+	novel.TargetPlatformDouyin:   {LoudnessTarget: -14, TruePeak: -1, LoudnessRange: 11, PixelFormat: "yuv420p", MaxVideoBitrate: 6000, AudioBitrate: 192, AudioSampleRate: 44100, Faststart: true},
+	novel.TargetPlatformTikTok:   {LoudnessTarget: -14, TruePeak: -1, LoudnessRange: 11, PixelFormat: "yuv420p", MaxVideoBitrate: 6000, AudioBitrate: 192, AudioSampleRate: 44100, Faststart: true},
+	novel.TargetPlatformKuaishou: {LoudnessTarget: -14, TruePeak: -1, LoudnessRange: 11, PixelFormat: "yuv420p", MaxVideoBitrate: 6000, AudioBitrate: 192, AudioSampleRate: 44100, Faststart: true},
+	novel.TargetPlatformYouTube:  {LoudnessTarget: -14, TruePeak: -1, LoudnessRange: 11, PixelFormat: "yuv420p", MaxVideoBitrate: 12000, AudioBitrate: 192, AudioSampleRate: 48000, Faststart: true},
+	novel.TargetPlatformBilibili: {LoudnessTarget: -16, TruePeak: -1, LoudnessRange: 11, PixelFormat: "yuv420p", MaxVideoBitrate: 6000, AudioBitrate: 192, AudioSampleRate: 48000, Faststart: true},
+}
+
+// CompliancePresetForPlatform 返回平台的响度和格式规范，未知平台使用通用预设
+func CompliancePresetForPlatform(platform novel.TargetPlatform) ffmpeg.CompliancePreset {
+	preset, ok := compliancePresets[platform]
+	if !ok {
+		platform = novel.TargetPlatformDefault
+		preset = compliancePresets[platform]
+	}
+	preset.Name = string(platform)
+	return preset
+}
+
+// BuildComplianceReport 根据处理前后的响度和成片的格式生成平台规范校验报告
+// format 为 nil 时（探测失败）格式相关的检查记为未通过
+func BuildComplianceReport(preset ffmpeg.CompliancePreset, input, output *ffmpeg.LoudnessStats, format *ffmpeg.MediaFormat) *novel.ComplianceReport {
+	report := &novel.ComplianceReport{
+		Preset:           preset.Name,
+		CheckedAt:        time.Now(),
+		TargetLoudness:   preset.LoudnessTarget,
+		TargetTruePeak:   preset.TruePeak,
+		TargetPixFmt:     preset.PixelFormat,
+		MaxVideoBitrate:  preset.MaxVideoBitrate,
+		TargetSampleRate: preset.AudioSampleRate,
+		Faststart:        preset.Faststart,
+	}
+	if input != nil {
+		report.InputLoudness = input.Integrated
+		report.InputTruePeak = input.TruePeak
+	}
+
+	if output == nil {
+		report.Issues = append(report.Issues, "loudness not measured")
+	} else {
+		report.OutputLoudness = output.Integrated
+		report.OutputTruePeak = output.TruePeak
+		report.OutputLRA = output.LoudnessRange
+		if math.Abs(output.Integrated-preset.LoudnessTarget) > complianceLoudnessTolerance {
+			report.Issues = append(report.Issues, fmt.Sprintf("integrated loudness %.1f LUFS is outside target %.1f±%.1f LUFS", output.Integrated, preset.LoudnessTarget, complianceLoudnessTolerance))
+		}
+		if output.TruePeak > preset.TruePeak+complianceTruePeakTolerance {
+			report.Issues = append(report.Issues, fmt.Sprintf("true peak %.1f dBTP exceeds limit %.1f dBTP", output.TruePeak, preset.TruePeak))
+		}
+	}
+
+	if format == nil {
+		report.Issues = append(report.Issues, "media format not probed")
+	} else {
+		report.VideoCodec = format.VideoCodec
+		report.PixFmt = format.PixelFormat
+		report.VideoBitrate = format.VideoBitrate
+		report.AudioCodec = format.AudioCodec
+		report.AudioSampleRate = format.AudioSampleRate
+		report.MoovAtFront = format.Faststart
+
+		if format.VideoCodec != "h264" {
+			report.Issues = append(report.Issues, fmt.Sprintf("video codec %q is not h264", format.VideoCodec))
+		}
+		if format.AudioCodec != "aac" {
+			report.Issues = append(report.Issues, fmt.Sprintf("audio codec %q is not aac", format.AudioCodec))
+		}
+		if preset.PixelFormat != "" && format.PixelFormat != preset.PixelFormat {
+			report.Issues = append(report.Issues, fmt.Sprintf("pixel format %q is not %q", format.PixelFormat, preset.PixelFormat))
+		}
+		if preset.MaxVideoBitrate > 0 && float64(format.VideoBitrate) > float64(preset.MaxVideoBitrate)*complianceBitrateTolerance {
+			report.Issues = append(report.Issues, fmt.Sprintf("video bitrate %dkbps exceeds ceiling %dkbps", format.VideoBitrate, preset.MaxVideoBitrate))
+		}
+		if preset.AudioSampleRate > 0 && format.AudioSampleRate != preset.AudioSampleRate {
+			report.Issues = append(report.Issues, fmt.Sprintf("audio sample rate %dHz is not %dHz", format.AudioSampleRate, preset.AudioSampleRate))
+		}
+		if preset.Faststart && !format.Faststart {
+			report.Issues = append(report.Issues, "moov atom is not at the front (faststart)")
+		}
+	}
+
+	report.Passed = len(report.Issues) == 0
+	return report
+}
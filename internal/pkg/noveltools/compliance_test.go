@@ -0,0 +1,65 @@
+package noveltools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ffmpeg"
+)
+
+func TestCompliancePresetForPlatform(t *testing.T) {
+	Convey("CompliancePresetForPlatform 返回平台预设", t, func() {
+		for _, platform := range novel.AllTargetPlatforms {
+			preset := CompliancePresetForPlatform(platform)
+			So(preset.Name, ShouldEqual, string(platform))
+			So(preset.LoudnessTarget, ShouldBeLessThan, 0)
+			So(preset.PixelFormat, ShouldEqual, "yuv420p")
+			So(preset.Faststart, ShouldBeTrue)
+		}
+
+		Convey("未知平台使用通用预设", func() {
+			So(CompliancePresetForPlatform("weibo").Name, ShouldEqual, string(novel.TargetPlatformDefault))
+		})
+	})
+}
+
+func TestBuildComplianceReport(t *testing.T) {
+	Convey("BuildComplianceReport 校验处理后的成片", t, func() {
+		preset := CompliancePresetForPlatform(novel.TargetPlatformDouyin)
+		input := &ffmpeg.LoudnessStats{Integrated: -23.5, TruePeak: -3}
+		output := &ffmpeg.LoudnessStats{Integrated: -14.2, TruePeak: -1.1, LoudnessRange: 4}
+		format := &ffmpeg.MediaFormat{
+			VideoCodec:      "h264",
+			PixelFormat:     "yuv420p",
+			VideoBitrate:    5200,
+			AudioCodec:      "aac",
+			AudioSampleRate: 44100,
+			Faststart:       true,
+		}
+
+		Convey("全部符合时通过", func() {
+			report := BuildComplianceReport(preset, input, output, format)
+			So(report.Passed, ShouldBeTrue)
+			So(report.Issues, ShouldBeEmpty)
+			So(report.Preset, ShouldEqual, "douyin")
+			So(report.InputLoudness, ShouldEqual, -23.5)
+			So(report.OutputLoudness, ShouldEqual, -14.2)
+		})
+
+		Convey("响度、码率和 faststart 不符合时记录问题", func() {
+			format.VideoBitrate = 9000
+			format.Faststart = false
+			report := BuildComplianceReport(preset, input, &ffmpeg.LoudnessStats{Integrated: -18, TruePeak: 0}, format)
+			So(report.Passed, ShouldBeFalse)
+			So(report.Issues, ShouldHaveLength, 4)
+		})
+
+		Convey("格式探测失败时不通过", func() {
+			report := BuildComplianceReport(preset, input, output, nil)
+			So(report.Passed, ShouldBeFalse)
+			So(report.Issues, ShouldContain, "media format not probed")
+		})
+	})
+}
@@ -0,0 +1,186 @@
+package noveltools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"lemon/internal/model/novel"
+)
+
+// contentRiskRulesVersion 风险规则版本，规则调整后递增，使旧报告的确认失效
+const contentRiskRulesVersion = "1"
+
+// contentRiskRule 内容风险规则
+type contentRiskRule struct {
+	name      string
+	category  novel.ContentRiskCategory
+	level     novel.ContentRiskLevel
+	message   string
+	pattern   *regexp.Regexp
+	platforms []novel.TargetPlatform // 只对这些平台生效，为空时对所有平台生效
+}
+
+// 国内短视频平台（站外引流、极限用语等按平台规则单独限制）
+var domesticPlatforms = []novel.TargetPlatform{novel.TargetPlatformDouyin, novel.TargetPlatformKuaishou, novel.TargetPlatformBilibili}
+
+// contentRiskRules 内容风险规则（关键词只覆盖平台审核中常见的拒绝原因，命中后由编辑判断）
+var contentRiskRules = []contentRiskRule{
+	{
+		name:     "song_lyrics",
+		category: novel.ContentRiskCopyright,
+		level:    novel.ContentRiskLevelHigh,
+		message:  "疑似引用歌词，配音或字幕中出现受版权保护的歌词可能被平台拒绝",
+		pattern:  regexp.MustCompile(`歌词|[♪♫♬]|(?:唱道|唱着|哼着|歌里唱)[：:，,“"「]`),
+	},
+	{
+		name:     "quoted_work",
+		category: novel.ContentRiskCopyright,
+		level:    novel.ContentRiskLevelMedium,
+		message:  "引用了歌曲或影视作品，请确认没有大段引用原作内容",
+		pattern:  regexp.MustCompile(`(?:歌曲|电影|电视剧|主题曲|插曲|片尾曲)《[^》]{1,30}》`),
+	},
+	{
+		name:     "medical_efficacy",
+		category: novel.ContentRiskMedicalClaim,
+		level:    novel.ContentRiskLevelHigh,
+		message:  "包含医疗功效宣称，平台禁止未经证实的治疗效果描述",
+		pattern:  regexp.MustCompile(`包治百病|药到病除|根治|治愈率|特效药|祖传秘方|神药|抗癌|无副作用|降血压|降血糖|一针见效|延年益寿`),
+	},
+	{
+		name:     "financial_return",
+		category: novel.ContentRiskFinancialClaim,
+		level:    novel.ContentRiskLevelHigh,
+		message:  "包含投资收益宣称，平台禁止承诺收益或推荐投资",
+		pattern:  regexp.MustCompile(`稳赚不赔|稳赚|保本|零风险|保证收益|收益翻倍|躺赚|日赚[0-9一二三四五六七八九十百千万]+|月入[0-9一二三四五六七八九十百千万]+|内幕消息|荐股|一夜暴富`),
+	},
+	{
+		name:     "gambling_drugs",
+		category: novel.ContentRiskBannedTopic,
+		level:    novel.ContentRiskLevelHigh,
+		message:  "涉及赌博、毒品等平台禁止的话题",
+		pattern:  regexp.MustCompile(`赌博|博彩|赌场|网赌|毒品|吸毒|贩毒|冰毒|海洛因|代孕`),
+	},
+	{
+		name:     "self_harm_method",
+		category: novel.ContentRiskBannedTopic,
+		level:    novel.ContentRiskLevelHigh,
+		message:  "涉及自残或自杀方式的描述",
+		pattern:  regexp.MustCompile(`自杀方法|怎么自杀|割腕|服毒自尽`),
+	},
+	{
+		name:      "off_platform_traffic",
+		category:  novel.ContentRiskBannedTopic,
+		level:     novel.ContentRiskLevelMedium,
+		message:   "包含站外引流内容，国内平台会限流或拒绝",
+		pattern:   regexp.MustCompile(`加微信|加[vV]信|加我[vV]|私信我|扫码|二维码|淘宝搜索|公众号`),
+		platforms: domesticPlatforms,
+	},
+	{
+		name:      "absolute_terms",
+		category:  novel.ContentRiskBannedTopic,
+		level:     novel.ContentRiskLevelMedium,
+		message:   "包含极限用语，国内平台按广告法限制",
+		pattern:   regexp.MustCompile(`全网最低|史上最低|国家级|全球首发|第一品牌`),
+		platforms: domesticPlatforms,
+	},
+	{
+		name:      "violent_extremism",
+		category:  novel.ContentRiskBannedTopic,
+		level:     novel.ContentRiskLevelMedium,
+		message:   "涉及枪支或暴力极端内容，YouTube/TikTok 可能限制推荐",
+		pattern:   regexp.MustCompile(`枪支|买枪|炸弹制作|恐怖袭击`),
+		platforms: []novel.TargetPlatform{novel.TargetPlatformYouTube, novel.TargetPlatformTikTok},
+	},
+	{
+		name:     "profanity",
+		category: novel.ContentRiskProfanity,
+		level:    novel.ContentRiskLevelMedium,
+		message:  "包含粗口脏话，可能被平台限流",
+		pattern:  regexp.MustCompile(`他妈的|特么|操你|草泥马|傻逼|煞笔|狗日的|王八蛋|滚犊子`),
+	},
+}
+
+// appliesTo 规则是否对目标平台生效
+func (r *contentRiskRule) appliesTo(platform novel.TargetPlatform) bool {
+	return len(r.platforms) == 0 || slices.Contains(r.platforms, platform)
+}
+
+// ScanContentRisk 按目标平台的规则扫描文本段，返回风险列表（高风险在前，同等级按文本顺序）
+// 同一规则在同一段文本中的多处命中合并为一条风险，Highlights 按字符位置标出每处命中
+func ScanContentRisk(segments []novel.ContentRiskSegment, platform novel.TargetPlatform) []novel.ContentRiskFinding {
+	findings := []novel.ContentRiskFinding{}
+	for _, seg := range segments {
+		if strings.TrimSpace(seg.Text) == "" {
+			continue
+		}
+		for i := range contentRiskRules {
+			rule := &contentRiskRules[i]
+			if !rule.appliesTo(platform) {
+				continue
+			}
+			matches := rule.pattern.FindAllStringIndex(seg.Text, -1)
+			if len(matches) == 0 {
+				continue
+			}
+			highlights := make([]novel.ContentRiskHighlight, 0, len(matches))
+			for _, m := range matches {
+				start := utf8.RuneCountInString(seg.Text[:m[0]])
+				highlights = append(highlights, novel.ContentRiskHighlight{
+					Start: start,
+					End:   start + utf8.RuneCountInString(seg.Text[m[0]:m[1]]),
+					Text:  seg.Text[m[0]:m[1]],
+				})
+			}
+			findings = append(findings, novel.ContentRiskFinding{
+				Category:   rule.category,
+				Level:      rule.level,
+				Rule:       rule.name,
+				Message:    rule.message,
+				Segment:    seg,
+				Highlights: highlights,
+			})
+		}
+	}
+	slices.SortStableFunc(findings, func(a, b novel.ContentRiskFinding) int {
+		return contentRiskLevelRank(b.Level) - contentRiskLevelRank(a.Level)
+	})
+	return findings
+}
+
+// contentRiskLevelRank 风险等级排序值，越大越严重
+func contentRiskLevelRank(level novel.ContentRiskLevel) int {
+	if level == novel.ContentRiskLevelHigh {
+		return 1
+	}
+	return 0
+}
+
+// ContentRiskFingerprint 计算扫描内容的指纹：文本、目标平台或规则版本变化时指纹随之变化
+func ContentRiskFingerprint(segments []novel.ContentRiskSegment, platform novel.TargetPlatform) string {
+	h := sha256.New()
+	h.Write([]byte(contentRiskRulesVersion + "\x00" + platform.String()))
+	for _, seg := range segments {
+		h.Write([]byte("\x00" + string(seg.Source) + "\x00" + seg.Ref + "\x00" + seg.Text))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// BuildContentRiskReport 扫描文本段并生成风险报告（不含报告ID和关联对象）
+func BuildContentRiskReport(segments []novel.ContentRiskSegment, platform novel.TargetPlatform) *novel.ContentRiskReport {
+	report := &novel.ContentRiskReport{
+		Platform:    platform,
+		Fingerprint: ContentRiskFingerprint(segments, platform),
+		Findings:    ScanContentRisk(segments, platform),
+	}
+	for _, f := range report.Findings {
+		if f.Level == novel.ContentRiskLevelHigh {
+			report.HighRisk++
+		}
+	}
+	report.RequiresAck = len(report.Findings) > 0
+	return report
+}
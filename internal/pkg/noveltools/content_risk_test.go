@@ -0,0 +1,92 @@
+package noveltools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestScanContentRisk(t *testing.T) {
+	Convey("ScanContentRisk 扫描解说和字幕文本的风险", t, func() {
+		segments := []novel.ContentRiskSegment{
+			{Source: novel.ContentRiskSourceShotNarration, Ref: "shot-1", Text: "他轻声唱着：“月亮代表我的心”，然后说这药能根治百病。"},
+			{Source: novel.ContentRiskSourceShotNarration, Ref: "shot-2", Text: "他妈的，这笔买卖稳赚，稳赚！"},
+			{Source: novel.ContentRiskSourceSubtitleOutro, Text: "关注我，加微信看后续"},
+			{Source: novel.ContentRiskSourceSceneNarration, Ref: "scene-1", Text: "夜色很安静。"},
+		}
+
+		Convey("命中规则并按字符位置标出", func() {
+			findings := ScanContentRisk(segments, novel.TargetPlatformDouyin)
+			rules := make(map[string]novel.ContentRiskFinding)
+			for _, f := range findings {
+				rules[f.Rule] = f
+			}
+			So(rules, ShouldContainKey, "song_lyrics")
+			So(rules, ShouldContainKey, "medical_efficacy")
+			So(rules, ShouldContainKey, "financial_return")
+			So(rules, ShouldContainKey, "profanity")
+			So(rules, ShouldContainKey, "off_platform_traffic")
+
+			lyrics := rules["song_lyrics"]
+			So(lyrics.Segment.Ref, ShouldEqual, "shot-1")
+			So(lyrics.Highlights, ShouldResemble, []novel.ContentRiskHighlight{{Start: 3, End: 6, Text: "唱着："}})
+
+			money := rules["financial_return"]
+			So(money.Category, ShouldEqual, novel.ContentRiskFinancialClaim)
+			So(len(money.Highlights), ShouldEqual, 2)
+			So(money.Highlights[1], ShouldResemble, novel.ContentRiskHighlight{Start: 11, End: 13, Text: "稳赚"})
+		})
+
+		Convey("高风险排在前面", func() {
+			findings := ScanContentRisk(segments, novel.TargetPlatformDouyin)
+			seenMedium := false
+			for _, f := range findings {
+				if f.Level == novel.ContentRiskLevelMedium {
+					seenMedium = true
+				} else {
+					So(seenMedium, ShouldBeFalse)
+				}
+			}
+		})
+
+		Convey("平台专属规则只对对应平台生效", func() {
+			for _, f := range ScanContentRisk(segments, novel.TargetPlatformYouTube) {
+				So(f.Rule, ShouldNotEqual, "off_platform_traffic")
+			}
+		})
+
+		Convey("没有风险时返回空列表", func() {
+			findings := ScanContentRisk(segments[3:], novel.TargetPlatformDouyin)
+			So(findings, ShouldNotBeNil)
+			So(findings, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestBuildContentRiskReport(t *testing.T) {
+	Convey("BuildContentRiskReport 汇总风险并计算指纹", t, func() {
+		segments := []novel.ContentRiskSegment{
+			{Source: novel.ContentRiskSourceShotNarration, Ref: "shot-1", Text: "这是祖传秘方，药到病除。"},
+		}
+		report := BuildContentRiskReport(segments, novel.TargetPlatformDouyin)
+		So(report.HighRisk, ShouldEqual, 1)
+		So(report.RequiresAck, ShouldBeTrue)
+		So(report.Blocking(), ShouldBeTrue)
+
+		Convey("文本或平台变化时指纹变化", func() {
+			So(ContentRiskFingerprint(segments, novel.TargetPlatformDouyin), ShouldEqual, report.Fingerprint)
+			So(ContentRiskFingerprint(segments, novel.TargetPlatformYouTube), ShouldNotEqual, report.Fingerprint)
+
+			edited := []novel.ContentRiskSegment{{Source: novel.ContentRiskSourceShotNarration, Ref: "shot-1", Text: "这是一张旧药方。"}}
+			So(ContentRiskFingerprint(edited, novel.TargetPlatformDouyin), ShouldNotEqual, report.Fingerprint)
+		})
+
+		Convey("没有风险时不需要确认", func() {
+			clean := BuildContentRiskReport([]novel.ContentRiskSegment{{Source: novel.ContentRiskSourceShotNarration, Text: "雨停了。"}}, novel.TargetPlatformDouyin)
+			So(clean.RequiresAck, ShouldBeFalse)
+			So(clean.Blocking(), ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,124 @@
+package noveltools
+
+import (
+	"fmt"
+	"strings"
+
+	"lemon/internal/model/novel"
+)
+
+// coverExcerptRunes 封面设计时参考的第一章开头字数（只需要主角和故事基调，不需要全文）
+const coverExcerptRunes = 1500
+
+// coverStylePrompts 小说风格对应的封面画风
+var coverStylePrompts = map[novel.NovelStyle]string{
+	novel.NovelStyleAnime: "日系动漫插画风格，线条干净，色彩明亮通透",
+	novel.NovelStyleLive:  "写实电影海报风格，真实的材质与光影，电影级调色",
+	novel.NovelStyleMixed: "半写实插画风格，兼具绘画质感与真实光影",
+}
+
+// coverComposition 封面构图要求（竖版、主体居中、预留标题区域、画面不出现文字）
+const coverComposition = "竖版小说封面，主体人物居中突出，上方预留标题留白区域，画面中不出现任何文字、字母或水印，高清细节"
+
+// CoverBrief 封面创作素材：作品设定（故事圣经）与第一章梗概
+type CoverBrief struct {
+	Title        string                  // 小说名称
+	Description  string                  // 简介
+	Metadata     *novel.CreativeMetadata // 创作设定（可为空）
+	Style        novel.NovelStyle        // 小说风格（决定封面画风）
+	FirstChapter string                  // 第一章正文（只取开头部分）
+}
+
+// BuildCoverDesignPrompt 构造让大模型根据作品设定和第一章梗概设计封面画面的提示词
+func BuildCoverDesignPrompt(brief CoverBrief) string {
+	var sb strings.Builder
+	sb.WriteString("你是小说封面美术设计师。请根据下面的作品信息，为这部小说设计一张封面插画的画面。\n")
+	sb.WriteString("要求：\n")
+	sb.WriteString("1. 画面突出主角形象（外貌、服饰、神态、动作）和作品最有代表性的场景氛围；\n")
+	sb.WriteString("2. 画面风格与作品题材和基调一致，能吸引目标受众；\n")
+	sb.WriteString("3. 只描述画面内容，不要出现书名、文字或排版说明；\n")
+	sb.WriteString("4. 用一段中文描述，不超过150字，只输出画面描述，不要输出解释或其他内容。\n\n")
+
+	sb.WriteString("作品信息：\n")
+	for _, line := range coverBriefLines(brief) {
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	if excerpt := coverExcerpt(brief.FirstChapter); excerpt != "" {
+		sb.WriteString("\n第一章开头：\n")
+		sb.WriteString(excerpt)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// BuildCoverImagePrompt 构建封面图片 prompt
+// design 为大模型设计的画面描述，为空时（大模型不可用）根据作品设定拼出基础描述
+// 格式：画风。画面描述。构图要求
+func BuildCoverImagePrompt(brief CoverBrief, design string) string {
+	design = strings.TrimSpace(design)
+	if design == "" {
+		design = fallbackCoverDesign(brief)
+	}
+
+	var parts []string
+	if style := coverStylePrompts[brief.Style]; style != "" {
+		parts = append(parts, style)
+	}
+	parts = append(parts, strings.TrimRight(design, "。"), coverComposition)
+	return strings.Join(parts, "。")
+}
+
+// fallbackCoverDesign 根据作品设定拼出封面画面描述
+func fallbackCoverDesign(brief CoverBrief) string {
+	var parts []string
+	if meta := brief.Metadata; meta != nil {
+		if meta.Genre != "" {
+			parts = append(parts, meta.Genre+"题材")
+		}
+		if meta.Tone != "" {
+			parts = append(parts, meta.Tone+"基调")
+		}
+		if meta.Protagonist != "" {
+			parts = append(parts, "主角"+meta.Protagonist+"的半身像")
+		}
+	}
+	if brief.Title != "" {
+		parts = append(parts, fmt.Sprintf("表现小说《%s》的故事氛围", brief.Title))
+	}
+	if len(parts) == 0 {
+		return "小说主角的半身像，富有故事感的背景"
+	}
+	return strings.Join(parts, "，")
+}
+
+// coverBriefLines 作品信息（只输出非空字段）
+func coverBriefLines(brief CoverBrief) []string {
+	var lines []string
+	add := func(label, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			lines = append(lines, fmt.Sprintf("%s：%s", label, value))
+		}
+	}
+	add("书名", brief.Title)
+	add("简介", brief.Description)
+	if meta := brief.Metadata; meta != nil {
+		add("题材", meta.Genre)
+		add("基调", meta.Tone)
+		add("受众", meta.Audience)
+		add("主角", meta.Protagonist)
+		if len(meta.BannedTopics) > 0 {
+			add("禁止出现", strings.Join(meta.BannedTopics, "、"))
+		}
+	}
+	return lines
+}
+
+// coverExcerpt 截取第一章开头（按字符截断）
+func coverExcerpt(text string) string {
+	text = strings.TrimSpace(text)
+	runes := []rune(text)
+	if len(runes) <= coverExcerptRunes {
+		return text
+	}
+	return string(runes[:coverExcerptRunes]) + "……"
+}
@@ -0,0 +1,67 @@
+package noveltools
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestBuildCoverDesignPrompt(t *testing.T) {
+	Convey("BuildCoverDesignPrompt 构造封面设计提示词", t, func() {
+		Convey("包含作品设定和第一章开头，忽略空字段", func() {
+			prompt := BuildCoverDesignPrompt(CoverBrief{
+				Title:        "剑来",
+				Metadata:     &novel.CreativeMetadata{Genre: "仙侠", Protagonist: "陈平安", BannedTopics: []string{"血腥", "赌博"}},
+				FirstChapter: "  小镇的雪下了一整夜。  ",
+			})
+			So(prompt, ShouldContainSubstring, "书名：剑来")
+			So(prompt, ShouldContainSubstring, "题材：仙侠")
+			So(prompt, ShouldContainSubstring, "主角：陈平安")
+			So(prompt, ShouldContainSubstring, "禁止出现：血腥、赌博")
+			So(prompt, ShouldNotContainSubstring, "简介：")
+			So(prompt, ShouldNotContainSubstring, "基调：")
+			So(prompt, ShouldEndWith, "小镇的雪下了一整夜。")
+		})
+
+		Convey("第一章过长时截断", func() {
+			prompt := BuildCoverDesignPrompt(CoverBrief{Title: "剑来", FirstChapter: strings.Repeat("雪", coverExcerptRunes+10)})
+			So(prompt, ShouldEndWith, strings.Repeat("雪", coverExcerptRunes)+"……")
+		})
+
+		Convey("没有第一章时不输出第一章段落", func() {
+			prompt := BuildCoverDesignPrompt(CoverBrief{Title: "剑来"})
+			So(prompt, ShouldNotContainSubstring, "第一章开头")
+		})
+	})
+}
+
+func TestBuildCoverImagePrompt(t *testing.T) {
+	Convey("BuildCoverImagePrompt 构建封面图片提示词", t, func() {
+		Convey("画风、画面描述和构图要求依次拼接", func() {
+			prompt := BuildCoverImagePrompt(CoverBrief{Style: novel.NovelStyleAnime}, " 少年持剑立于雪夜山门前。 ")
+			So(prompt, ShouldStartWith, coverStylePrompts[novel.NovelStyleAnime]+"。少年持剑立于雪夜山门前。")
+			So(prompt, ShouldEndWith, coverComposition)
+		})
+
+		Convey("未知风格不加画风", func() {
+			prompt := BuildCoverImagePrompt(CoverBrief{}, "少年持剑")
+			So(prompt, ShouldStartWith, "少年持剑。")
+		})
+
+		Convey("没有画面描述时根据作品设定拼出描述", func() {
+			prompt := BuildCoverImagePrompt(CoverBrief{
+				Title:    "剑来",
+				Metadata: &novel.CreativeMetadata{Genre: "仙侠", Tone: "热血", Protagonist: "陈平安"},
+			}, "")
+			So(prompt, ShouldStartWith, "仙侠题材，热血基调，主角陈平安的半身像，表现小说《剑来》的故事氛围。")
+		})
+
+		Convey("没有任何设定时使用通用描述", func() {
+			prompt := BuildCoverImagePrompt(CoverBrief{}, "")
+			So(prompt, ShouldStartWith, "小说主角的半身像")
+		})
+	})
+}
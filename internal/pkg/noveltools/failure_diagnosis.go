@@ -0,0 +1,195 @@
+package noveltools
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/errclass"
+)
+
+const (
+	// failureExcerptHeadRunes/failureExcerptTailRunes 送入大模型的错误信息保留开头和结尾（provider 的响应内容通常在结尾）
+	failureExcerptHeadRunes = 1000
+	failureExcerptTailRunes = 500
+	// maxFailureNextActions 诊断最多返回的建议操作数
+	maxFailureNextActions = 5
+)
+
+// secretPattern 错误信息中可能出现的凭据（Authorization 头、token、api key），送入大模型前脱敏
+var secretPattern = regexp.MustCompile(`(?i)(bearer|token|api[_-]?key|authorization|secret)(["']?\s*[:=;]?\s*["']?)([A-Za-z0-9._\-+/=]{8,})`)
+
+// FailureContext 章节生成失败的上下文（送入大模型诊断，同时返回给运维人员）
+type FailureContext struct {
+	ChapterTitle        string              `json:"chapter_title"`
+	Stage               novel.PipelineStage `json:"stage"`                       // 失败的阶段
+	ErrorClass          string              `json:"error_class"`                 // 失败类别（见 errclass）
+	ErrorProvider       string              `json:"error_provider,omitempty"`    // 出错的 provider
+	ErrorExcerpt        string              `json:"error_excerpt"`               // 错误信息摘录（截断、脱敏）
+	FailedAt            time.Time           `json:"failed_at"`                   // 失败时间
+	Attempts            int                 `json:"attempts,omitempty"`          // 任务已执行次数（通过任务队列执行时）
+	MaxAttempts         int                 `json:"max_attempts,omitempty"`      // 任务最多执行次数
+	ConsecutiveFailures int                 `json:"consecutive_failures"`        // 该阶段自上次成功以来连续失败的次数
+	LastSucceededAt     *time.Time          `json:"last_succeeded_at,omitempty"` // 该阶段上次成功的时间
+	Resolved            bool                `json:"resolved"`                    // 失败之后该阶段是否已重新执行成功
+	Changes             []FailureChange     `json:"changes,omitempty"`           // 上次成功以来的变更（按时间升序）
+}
+
+// FailureChange 失败前的一项变更
+type FailureChange struct {
+	At          time.Time `json:"at"`
+	Description string    `json:"description"`
+}
+
+// FailureDiagnosis 失败诊断
+type FailureDiagnosis struct {
+	Summary     string   `json:"summary"`      // 用一两句话说明发生了什么
+	LikelyCause string   `json:"likely_cause"` // 最可能的原因
+	NextActions []string `json:"next_actions"` // 建议的后续操作（按优先级）
+	Retryable   bool     `json:"retryable"`    // 不做修改直接重试是否可能成功
+}
+
+// ExcerptErrorMessage 截取错误信息用于诊断：超长时保留开头和结尾，并把凭据替换为 ***
+func ExcerptErrorMessage(message string) string {
+	message = secretPattern.ReplaceAllString(strings.TrimSpace(message), "$1$2***")
+	if utf8.RuneCountInString(message) <= failureExcerptHeadRunes+failureExcerptTailRunes {
+		return message
+	}
+	runes := []rune(message)
+	return string(runes[:failureExcerptHeadRunes]) + " …（中间省略）… " + string(runes[len(runes)-failureExcerptTailRunes:])
+}
+
+// BuildFailureDiagnosisPrompt 构建失败诊断提示词：根据失败阶段、类别、错误信息和近期变更给出诊断和建议操作
+func BuildFailureDiagnosisPrompt(fc *FailureContext) (string, error) {
+	if fc == nil || strings.TrimSpace(fc.ErrorExcerpt) == "" {
+		return "", fmt.Errorf("failure context has no error message")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "- 章节：%s\n", fc.ChapterTitle)
+	fmt.Fprintf(&b, "- 失败阶段：%s（解说 narration -> 音频 audio -> 字幕 subtitle -> 图片 image -> 解说视频 narration_video -> 最终视频 final_video）\n", fc.Stage)
+	fmt.Fprintf(&b, "- 失败类别：%s\n", fc.ErrorClass)
+	if fc.ErrorProvider != "" {
+		fmt.Fprintf(&b, "- 出错的服务：%s\n", fc.ErrorProvider)
+	}
+	fmt.Fprintf(&b, "- 失败时间：%s\n", fc.FailedAt.Format(time.RFC3339))
+	if fc.MaxAttempts > 0 {
+		fmt.Fprintf(&b, "- 任务已自动重试：执行 %d 次（最多 %d 次）\n", fc.Attempts, fc.MaxAttempts)
+	}
+	if fc.LastSucceededAt != nil {
+		fmt.Fprintf(&b, "- 该阶段上次成功：%s，此后连续失败 %d 次\n", fc.LastSucceededAt.Format(time.RFC3339), fc.ConsecutiveFailures)
+	} else {
+		fmt.Fprintf(&b, "- 该阶段从未成功过，已失败 %d 次\n", fc.ConsecutiveFailures)
+	}
+	changes := "（没有记录到变更）"
+	if len(fc.Changes) > 0 {
+		lines := make([]string, 0, len(fc.Changes))
+		for _, c := range fc.Changes {
+			lines = append(lines, fmt.Sprintf("- %s %s", c.At.Format(time.RFC3339), c.Description))
+		}
+		changes = strings.Join(lines, "\n")
+	}
+
+	return fmt.Sprintf(`你是小说解说视频生成平台的运维工程师。平台依次调用大模型、TTS、图片生成、视频生成和 ffmpeg 把小说章节做成解说视频。下面是一个章节生成失败的信息，请帮值班人员判断原因并给出处理建议。
+
+【失败信息】
+%s
+【错误信息】
+%s
+
+【失败前的近期变更】
+%s
+
+失败类别说明：provider_timeout 调用外部服务超时；provider_content_policy 外部服务内容审核拒绝；ffmpeg_codec 音视频编解码或滤镜失败；validation_schema 大模型输出格式不符合要求；storage_io 文件存储读写失败；unknown 未分类。
+
+要求：
+1. summary：用一两句话说明发生了什么，面向非技术的运营人员
+2. likely_cause：最可能的原因，结合错误信息和近期变更判断；无法确定时说明需要排查的方向
+3. next_actions：建议的后续操作，按优先级排列，最多 %d 条，每条是可以直接执行的具体操作（如修改哪段文本、调整什么设置、稍后重试、联系哪个服务方）
+4. retryable：不做任何修改直接重试是否可能成功（超时、限流等临时问题为 true；内容审核、格式错误等为 false）
+5. 只返回 JSON 对象，格式为 {"summary":"说明","likely_cause":"原因","next_actions":["操作"],"retryable":true}，不要其他文字`,
+		b.String(), fc.ErrorExcerpt, changes, maxFailureNextActions,
+	), nil
+}
+
+// ParseFailureDiagnosis 解析大模型返回的失败诊断
+// 建议操作去掉空白项，最多保留 maxFailureNextActions 条；说明为空时返回错误
+func ParseFailureDiagnosis(output string) (*FailureDiagnosis, error) {
+	var parsed FailureDiagnosis
+	if err := json.Unmarshal([]byte(CleanJSONContent(output)), &parsed); err != nil {
+		return nil, errclass.Wrap(errclass.ValidationSchema, "", fmt.Errorf("parse failure diagnosis: %w", err))
+	}
+
+	diagnosis := &FailureDiagnosis{
+		Summary:     strings.TrimSpace(parsed.Summary),
+		LikelyCause: strings.TrimSpace(parsed.LikelyCause),
+		NextActions: make([]string, 0, len(parsed.NextActions)),
+		Retryable:   parsed.Retryable,
+	}
+	if diagnosis.Summary == "" {
+		return nil, errclass.Wrap(errclass.ValidationSchema, "", fmt.Errorf("parse failure diagnosis: summary is empty"))
+	}
+	for _, action := range parsed.NextActions {
+		if action = strings.TrimSpace(action); action != "" {
+			diagnosis.NextActions = append(diagnosis.NextActions, action)
+		}
+		if len(diagnosis.NextActions) == maxFailureNextActions {
+			break
+		}
+	}
+	return diagnosis, nil
+}
+
+// FallbackFailureDiagnosis 按失败类别给出固定的诊断（大模型不可用或诊断失败时使用）
+func FallbackFailureDiagnosis(fc *FailureContext) *FailureDiagnosis {
+	stage := fc.Stage.String()
+	provider := fc.ErrorProvider
+	if provider == "" {
+		provider = "外部服务"
+	}
+
+	switch errclass.Class(fc.ErrorClass) {
+	case errclass.ProviderTimeout:
+		return &FailureDiagnosis{
+			Summary:     fmt.Sprintf("%s 阶段调用 %s 超时。", stage, provider),
+			LikelyCause: "外部服务响应慢、限流或网络波动，通常是临时问题。",
+			NextActions: []string{"稍后重试该阶段", "连续超时时检查该服务的状态和限流配额"},
+			Retryable:   true,
+		}
+	case errclass.ProviderContentPolicy:
+		return &FailureDiagnosis{
+			Summary:     fmt.Sprintf("%s 阶段的内容被 %s 的内容审核拒绝。", stage, provider),
+			LikelyCause: "解说文本或图片/视频提示词中含有敏感内容。",
+			NextActions: []string{"根据错误信息定位被拒绝的文本或提示词并修改", "修改后从该阶段重新生成"},
+		}
+	case errclass.FFmpegCodec:
+		return &FailureDiagnosis{
+			Summary:     fmt.Sprintf("%s 阶段合成音视频时 ffmpeg 执行失败。", stage),
+			LikelyCause: "输入的音频、图片或视频文件损坏、格式不支持，或参数组合不合法。",
+			NextActions: []string{"检查上一阶段产物是否完整（能否正常播放/打开）", "重新生成上一阶段的产物后再重试", "仍失败时把错误信息提供给开发人员"},
+		}
+	case errclass.ValidationSchema:
+		return &FailureDiagnosis{
+			Summary:     fmt.Sprintf("%s 阶段大模型的输出格式不符合要求。", stage),
+			LikelyCause: "大模型输出不稳定，或章节内容/提示词导致输出结构异常。",
+			NextActions: []string{"重试该阶段", "多次失败时检查近期修改的章节内容或提示词模板"},
+			Retryable:   true,
+		}
+	case errclass.StorageIO:
+		return &FailureDiagnosis{
+			Summary:     fmt.Sprintf("%s 阶段读写文件存储失败。", stage),
+			LikelyCause: "存储服务不可用、凭据失效或依赖的产物文件已被删除。",
+			NextActions: []string{"检查存储服务状态和凭据", "确认上一阶段的产物文件仍然存在", "稍后重试该阶段"},
+			Retryable:   true,
+		}
+	}
+	return &FailureDiagnosis{
+		Summary:     fmt.Sprintf("%s 阶段执行失败，无法自动判断原因。", stage),
+		LikelyCause: "请根据错误信息排查。",
+		NextActions: []string{"查看错误信息和任务日志", "重试一次确认是否为临时问题", "仍失败时把错误信息提供给开发人员"},
+	}
+}
@@ -0,0 +1,112 @@
+package noveltools
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/errclass"
+)
+
+func TestExcerptErrorMessage(t *testing.T) {
+	Convey("ExcerptErrorMessage 截取错误信息", t, func() {
+		Convey("凭据替换为 ***", func() {
+			msg := `request failed: Authorization: Bearer sk-abcdef123456 {"api_key":"AKLTabcdefgh1234"} token=xyz`
+			excerpt := ExcerptErrorMessage(msg)
+			So(excerpt, ShouldNotContainSubstring, "sk-abcdef123456")
+			So(excerpt, ShouldNotContainSubstring, "AKLTabcdefgh1234")
+			So(excerpt, ShouldContainSubstring, "Bearer ***")
+			So(excerpt, ShouldContainSubstring, "token=xyz") // 过短的值不是凭据
+		})
+
+		Convey("超长时保留开头和结尾", func() {
+			msg := "开头" + strings.Repeat("中", 2*(failureExcerptHeadRunes+failureExcerptTailRunes)) + "结尾body"
+			excerpt := ExcerptErrorMessage(msg)
+			So(excerpt, ShouldStartWith, "开头")
+			So(excerpt, ShouldEndWith, "结尾body")
+			So(excerpt, ShouldContainSubstring, "中间省略")
+			So(len([]rune(excerpt)), ShouldBeLessThan, len([]rune(msg)))
+		})
+	})
+}
+
+func TestBuildFailureDiagnosisPrompt(t *testing.T) {
+	Convey("BuildFailureDiagnosisPrompt 构建失败诊断提示词", t, func() {
+		failedAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+		fc := &FailureContext{
+			ChapterTitle:        "第1章 下山",
+			Stage:               novel.PipelineStageImage,
+			ErrorClass:          string(errclass.ProviderContentPolicy),
+			ErrorProvider:       "ark_image",
+			ErrorExcerpt:        "risk not pass",
+			FailedAt:            failedAt,
+			Attempts:            1,
+			MaxAttempts:         3,
+			ConsecutiveFailures: 2,
+			Changes:             []FailureChange{{At: failedAt.Add(-time.Hour), Description: "生成了新的解说版本 v3"}},
+		}
+
+		Convey("包含失败信息和近期变更", func() {
+			prompt, err := BuildFailureDiagnosisPrompt(fc)
+			So(err, ShouldBeNil)
+			So(prompt, ShouldContainSubstring, "第1章 下山")
+			So(prompt, ShouldContainSubstring, "provider_content_policy")
+			So(prompt, ShouldContainSubstring, "ark_image")
+			So(prompt, ShouldContainSubstring, "risk not pass")
+			So(prompt, ShouldContainSubstring, "执行 1 次（最多 3 次）")
+			So(prompt, ShouldContainSubstring, "从未成功过")
+			So(prompt, ShouldContainSubstring, "生成了新的解说版本 v3")
+		})
+
+		Convey("没有错误信息时返回错误", func() {
+			_, err := BuildFailureDiagnosisPrompt(&FailureContext{Stage: novel.PipelineStageAudio})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestParseFailureDiagnosis(t *testing.T) {
+	Convey("ParseFailureDiagnosis 解析失败诊断", t, func() {
+		Convey("去掉代码块标记和空白的建议操作", func() {
+			output := "```json\n{\"summary\":\" 图片被审核拒绝 \",\"likely_cause\":\"提示词含敏感词\",\"next_actions\":[\"修改提示词\",\" \",\"重新生成\"],\"retryable\":false}\n```"
+			diagnosis, err := ParseFailureDiagnosis(output)
+			So(err, ShouldBeNil)
+			So(diagnosis.Summary, ShouldEqual, "图片被审核拒绝")
+			So(diagnosis.NextActions, ShouldResemble, []string{"修改提示词", "重新生成"})
+			So(diagnosis.Retryable, ShouldBeFalse)
+		})
+
+		Convey("建议操作最多保留 maxFailureNextActions 条", func() {
+			diagnosis, err := ParseFailureDiagnosis(`{"summary":"s","next_actions":["1","2","3","4","5","6","7"]}`)
+			So(err, ShouldBeNil)
+			So(diagnosis.NextActions, ShouldHaveLength, maxFailureNextActions)
+		})
+
+		Convey("说明为空或不是 JSON 时返回格式校验错误", func() {
+			_, err := ParseFailureDiagnosis(`{"summary":" "}`)
+			class, _ := errclass.Classify(err)
+			So(class, ShouldEqual, errclass.ValidationSchema)
+			_, err = ParseFailureDiagnosis("无法诊断")
+			class, _ = errclass.Classify(err)
+			So(class, ShouldEqual, errclass.ValidationSchema)
+		})
+	})
+}
+
+func TestFallbackFailureDiagnosis(t *testing.T) {
+	Convey("FallbackFailureDiagnosis 按失败类别给出固定诊断", t, func() {
+		timeout := FallbackFailureDiagnosis(&FailureContext{Stage: novel.PipelineStageAudio, ErrorClass: string(errclass.ProviderTimeout), ErrorProvider: "bytedance_tts"})
+		So(timeout.Retryable, ShouldBeTrue)
+		So(timeout.Summary, ShouldContainSubstring, "bytedance_tts")
+
+		policy := FallbackFailureDiagnosis(&FailureContext{Stage: novel.PipelineStageImage, ErrorClass: string(errclass.ProviderContentPolicy)})
+		So(policy.Retryable, ShouldBeFalse)
+		So(policy.NextActions, ShouldNotBeEmpty)
+
+		unknown := FallbackFailureDiagnosis(&FailureContext{Stage: novel.PipelineStageFinalVideo, ErrorClass: "something"})
+		So(unknown.Summary, ShouldContainSubstring, "无法自动判断")
+	})
+}
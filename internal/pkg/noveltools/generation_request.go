@@ -0,0 +1,51 @@
+package noveltools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"lemon/internal/model/novel"
+)
+
+// NarrationVideoParams 影响 narration 视频生成结果的请求参数和生成输入（参与请求指纹计算）
+type NarrationVideoParams struct {
+	Platform      novel.TargetPlatform      `json:"platform,omitempty"`       // 目标发布平台（未指定时为空）
+	SubtitleStyle *novel.SubtitleStyle      `json:"subtitle_style,omitempty"` // 烧录字幕样式覆盖
+	Overrides     *novel.GenerationSettings `json:"overrides,omitempty"`      // 单次请求的生成参数覆盖
+
+	ImageVersion    int    `json:"image_version,omitempty"`    // 章节最新的图片版本
+	AudioVersion    int    `json:"audio_version,omitempty"`    // 章节最新的音频版本
+	SubtitleVersion int    `json:"subtitle_version,omitempty"` // 章节最新的字幕版本
+	ShotsHash       string `json:"shots_hash,omitempty"`       // 镜头内容的哈希（编辑、重新生成或重排镜头后变化）
+}
+
+// ShotContentHash 计算镜头内容的哈希：镜头的任何字段或顺序变化时哈希随之变化（不含创建和更新时间）
+func ShotContentHash(shots []*novel.Shot) (string, error) {
+	h := sha256.New()
+	for _, shot := range shots {
+		content := *shot
+		content.CreatedAt, content.UpdatedAt = time.Time{}, time.Time{}
+		data, err := json.Marshal(content)
+		if err != nil {
+			return "", err
+		}
+		h.Write(data)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// GenerationRequestFingerprint 计算生成请求的指纹：阶段、章节、解说版本或参数变化时指纹随之变化
+// 参数按 JSON 编码后参与哈希（结构体字段顺序固定，map 按键排序），相同参数得到相同的指纹
+func GenerationRequestFingerprint(stage novel.PipelineStage, chapterID, narrationVersion string, params any) (string, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(string(stage) + "\x00" + chapterID + "\x00" + narrationVersion + "\x00"))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
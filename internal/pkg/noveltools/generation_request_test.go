@@ -0,0 +1,79 @@
+package noveltools
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestGenerationRequestFingerprint(t *testing.T) {
+	Convey("GenerationRequestFingerprint 相同请求得到相同指纹", t, func() {
+		params := NarrationVideoParams{
+			Platform:      novel.TargetPlatformDouyin,
+			SubtitleStyle: &novel.SubtitleStyle{FontSize: 48},
+		}
+		a, err := GenerationRequestFingerprint(novel.PipelineStageNarrationVideo, "ch-1", "2.0", params)
+		So(err, ShouldBeNil)
+		b, err := GenerationRequestFingerprint(novel.PipelineStageNarrationVideo, "ch-1", "2.0", NarrationVideoParams{
+			Platform:      novel.TargetPlatformDouyin,
+			SubtitleStyle: &novel.SubtitleStyle{FontSize: 48},
+		})
+		So(err, ShouldBeNil)
+		So(a, ShouldEqual, b)
+		So(a, ShouldHaveLength, 64)
+
+		Convey("章节、解说版本或参数变化时指纹不同", func() {
+			others := []struct {
+				chapterID string
+				version   string
+				params    NarrationVideoParams
+			}{
+				{"ch-2", "2.0", params},
+				{"ch-1", "2.1", params},
+				{"ch-1", "2.0", NarrationVideoParams{Platform: novel.TargetPlatformDouyin}},
+				{"ch-1", "2.0", NarrationVideoParams{Platform: novel.TargetPlatformBilibili, SubtitleStyle: params.SubtitleStyle}},
+				{"ch-1", "2.0", NarrationVideoParams{Platform: novel.TargetPlatformDouyin, SubtitleStyle: params.SubtitleStyle, ImageVersion: 2}},
+				{"ch-1", "2.0", NarrationVideoParams{Platform: novel.TargetPlatformDouyin, SubtitleStyle: params.SubtitleStyle, ShotsHash: "edited"}},
+			}
+			for _, o := range others {
+				fp, err := GenerationRequestFingerprint(novel.PipelineStageNarrationVideo, o.chapterID, o.version, o.params)
+				So(err, ShouldBeNil)
+				So(fp, ShouldNotEqual, a)
+			}
+		})
+	})
+}
+
+func TestShotContentHash(t *testing.T) {
+	Convey("ShotContentHash 镜头内容或顺序变化时哈希不同，只有时间变化时相同", t, func() {
+		shots := func() []*novel.Shot {
+			return []*novel.Shot{
+				{ID: "shot-1", Narration: "第一句", ImagePrompt: "雨夜", Index: 1},
+				{ID: "shot-2", Narration: "第二句", ImagePrompt: "清晨", Index: 2},
+			}
+		}
+		base, err := ShotContentHash(shots())
+		So(err, ShouldBeNil)
+
+		touched := shots()
+		touched[0].UpdatedAt = time.Now()
+		hash, err := ShotContentHash(touched)
+		So(err, ShouldBeNil)
+		So(hash, ShouldEqual, base)
+
+		edited := shots()
+		edited[1].Narration = "改写的第二句"
+		hash, err = ShotContentHash(edited)
+		So(err, ShouldBeNil)
+		So(hash, ShouldNotEqual, base)
+
+		reordered := shots()
+		reordered[0], reordered[1] = reordered[1], reordered[0]
+		hash, err = ShotContentHash(reordered)
+		So(err, ShouldBeNil)
+		So(hash, ShouldNotEqual, base)
+	})
+}
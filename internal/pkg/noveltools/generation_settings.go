@@ -0,0 +1,199 @@
+package noveltools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"lemon/internal/model/novel"
+)
+
+// ErrInvalidGenerationSettings 生成参数超出允许范围
+var ErrInvalidGenerationSettings = errors.New("invalid generation settings")
+
+// 生成参数的取值范围
+const (
+	minVideoDimension = 240
+	maxVideoDimension = 3840
+	maxVideoFPS       = 60
+	minSpeedRatio     = 0.5
+	maxSpeedRatio     = 2.0
+	maxImageSteps     = 150
+	maxNarrationGap   = 5.0
+	maxClipMergeUnder = 20.0
+)
+
+// SettingsLayer 生成参数的来源层级
+type SettingsLayer string
+
+const (
+	SettingsLayerSystem  SettingsLayer = "system"  // 系统默认值（环境变量）
+	SettingsLayerUser    SettingsLayer = "user"    // 用户设置
+	SettingsLayerNovel   SettingsLayer = "novel"   // 小说设置
+	SettingsLayerRequest SettingsLayer = "request" // 单次请求覆盖
+)
+
+// GenerationSettingsLayer 一层生成参数
+type GenerationSettingsLayer struct {
+	Layer    SettingsLayer
+	Settings *novel.GenerationSettings // 为 nil 时跳过该层
+}
+
+// ResolvedGenerationSettings 合并后的生成参数
+type ResolvedGenerationSettings struct {
+	novel.GenerationSettings
+	Sources map[string]SettingsLayer `json:"sources"` // 每个参数（JSON 字段名）最终取值的来源层级
+}
+
+// ResolveGenerationSettings 按顺序逐层合并生成参数，后面的层覆盖前面的层，零值字段不覆盖
+func ResolveGenerationSettings(layers ...GenerationSettingsLayer) *ResolvedGenerationSettings {
+	resolved := &ResolvedGenerationSettings{Sources: make(map[string]SettingsLayer)}
+	out := &resolved.GenerationSettings
+	for _, layer := range layers {
+		s := layer.Settings
+		if s == nil {
+			continue
+		}
+		if s.VideoWidth > 0 {
+			out.VideoWidth = s.VideoWidth
+			resolved.Sources["video_width"] = layer.Layer
+		}
+		if s.VideoHeight > 0 {
+			out.VideoHeight = s.VideoHeight
+			resolved.Sources["video_height"] = layer.Layer
+		}
+		if s.VideoFPS > 0 {
+			out.VideoFPS = s.VideoFPS
+			resolved.Sources["video_fps"] = layer.Layer
+		}
+		if s.VoiceType != "" {
+			out.VoiceType = s.VoiceType
+			resolved.Sources["voice_type"] = layer.Layer
+		}
+		if s.SpeedRatio > 0 {
+			out.SpeedRatio = s.SpeedRatio
+			resolved.Sources["speed_ratio"] = layer.Layer
+		}
+		if s.ImageSteps > 0 {
+			out.ImageSteps = s.ImageSteps
+			resolved.Sources["image_steps"] = layer.Layer
+		}
+		if s.SegmentGap > 0 {
+			out.SegmentGap = s.SegmentGap
+			resolved.Sources["segment_gap"] = layer.Layer
+		}
+		if s.SceneGap > 0 {
+			out.SceneGap = s.SceneGap
+			resolved.Sources["scene_gap"] = layer.Layer
+		}
+		if s.ClipMerge != "" {
+			out.ClipMerge = s.ClipMerge
+			resolved.Sources["clip_merge"] = layer.Layer
+		}
+		if s.ClipMergeUnder > 0 {
+			out.ClipMergeUnder = s.ClipMergeUnder
+			resolved.Sources["clip_merge_under"] = layer.Layer
+		}
+	}
+	return resolved
+}
+
+// ValidateGenerationSettings 校验生成参数的取值范围（零值表示沿用上一层，不校验）
+// 视频宽高为 240~3840 的偶数（H.264 编码要求），帧率 1~60，语速 0.5~2.0，图片步数 1~150，停顿 0~5 秒，
+// 镜头合并规则为 none/short/scene，合并阈值 0~20 秒
+func ValidateGenerationSettings(s *novel.GenerationSettings) error {
+	if s == nil {
+		return nil
+	}
+	dimensions := []struct {
+		name  string
+		value int
+	}{
+		{"video_width", s.VideoWidth},
+		{"video_height", s.VideoHeight},
+	}
+	for _, d := range dimensions {
+		if d.value == 0 {
+			continue
+		}
+		if d.value < minVideoDimension || d.value > maxVideoDimension || d.value%2 != 0 {
+			return fmt.Errorf("%w: %s must be an even number between %d and %d", ErrInvalidGenerationSettings, d.name, minVideoDimension, maxVideoDimension)
+		}
+	}
+	if s.VideoFPS < 0 || s.VideoFPS > maxVideoFPS {
+		return fmt.Errorf("%w: video_fps must be between 1 and %d", ErrInvalidGenerationSettings, maxVideoFPS)
+	}
+	if s.SpeedRatio != 0 && (s.SpeedRatio < minSpeedRatio || s.SpeedRatio > maxSpeedRatio) {
+		return fmt.Errorf("%w: speed_ratio must be between %.1f and %.1f", ErrInvalidGenerationSettings, minSpeedRatio, maxSpeedRatio)
+	}
+	if s.ImageSteps < 0 || s.ImageSteps > maxImageSteps {
+		return fmt.Errorf("%w: image_steps must be between 1 and %d", ErrInvalidGenerationSettings, maxImageSteps)
+	}
+	gaps := []struct {
+		name  string
+		value float64
+	}{
+		{"segment_gap", s.SegmentGap},
+		{"scene_gap", s.SceneGap},
+	}
+	for _, g := range gaps {
+		if g.value < 0 || g.value > maxNarrationGap {
+			return fmt.Errorf("%w: %s must be between 0 and %.0f seconds", ErrInvalidGenerationSettings, g.name, maxNarrationGap)
+		}
+	}
+	if s.ClipMerge != "" && !s.ClipMerge.IsValid() {
+		return fmt.Errorf("%w: clip_merge must be one of none, short, scene", ErrInvalidGenerationSettings)
+	}
+	if s.ClipMergeUnder < 0 || s.ClipMergeUnder > maxClipMergeUnder {
+		return fmt.Errorf("%w: clip_merge_under must be between 0 and %.0f seconds", ErrInvalidGenerationSettings, maxClipMergeUnder)
+	}
+	return nil
+}
+
+// NarrationGaps 计算每个解说片段结束后的停顿（秒）
+// scenes 为各片段所属的场景编号（按播放顺序）：下一个片段属于另一个场景时使用 sceneGap（为 0 时使用 segmentGap），
+// 否则使用 segmentGap；最后一个片段之后没有停顿
+func NarrationGaps(scenes []string, segmentGap, sceneGap float64) []float64 {
+	gaps := make([]float64, len(scenes))
+	for i := 0; i+1 < len(scenes); i++ {
+		gaps[i] = segmentGap
+		if scenes[i+1] != scenes[i] && sceneGap > 0 {
+			gaps[i] = sceneGap
+		}
+	}
+	return gaps
+}
+
+// imageStepsKey 图片采样步数的 context key
+type imageStepsKey struct{}
+
+// WithImageSteps 把图片采样步数放入 context，供支持的图片提供者读取（steps<=0 时不设置）
+func WithImageSteps(ctx context.Context, steps int) context.Context {
+	if steps <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, imageStepsKey{}, steps)
+}
+
+// ImageStepsFromContext 读取 context 中的图片采样步数，未设置时返回 0（使用提供者默认值）
+func ImageStepsFromContext(ctx context.Context) int {
+	steps, _ := ctx.Value(imageStepsKey{}).(int)
+	return steps
+}
+
+// previewShortSide 预览版视频的最大短边像素
+const previewShortSide = 720
+
+// PreviewResolution 预览版视频分辨率：按相同画幅把渲染分辨率缩小到短边 720（已经不高于 720 时保持不变），宽高取偶数
+// 授权版直接使用渲染分辨率（生成参数中的 video_width、video_height）
+func PreviewResolution(width, height int) (int, int) {
+	short := min(width, height)
+	if short <= previewShortSide {
+		return width, height
+	}
+	scale := func(v int) int {
+		scaled := v * previewShortSide / short
+		return scaled - scaled%2
+	}
+	return scale(width), scale(height)
+}
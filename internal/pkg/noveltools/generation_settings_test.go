@@ -0,0 +1,122 @@
+package noveltools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestResolveGenerationSettings(t *testing.T) {
+	Convey("ResolveGenerationSettings 逐层合并生成参数", t, func() {
+		system := &novel.GenerationSettings{VideoWidth: 720, VideoHeight: 1280, VideoFPS: 30, SpeedRatio: 1.2}
+		user := &novel.GenerationSettings{VoiceType: "BV115_streaming", SpeedRatio: 1.0}
+		novelLayer := &novel.GenerationSettings{VideoFPS: 24}
+		request := &novel.GenerationSettings{SpeedRatio: 1.5, ImageSteps: 30, SceneGap: 0.8, ClipMerge: novel.ClipMergeScene}
+
+		resolved := ResolveGenerationSettings(
+			GenerationSettingsLayer{Layer: SettingsLayerSystem, Settings: system},
+			GenerationSettingsLayer{Layer: SettingsLayerUser, Settings: user},
+			GenerationSettingsLayer{Layer: SettingsLayerNovel, Settings: novelLayer},
+			GenerationSettingsLayer{Layer: SettingsLayerRequest, Settings: request},
+		)
+
+		Convey("后面的层覆盖前面的层，零值字段沿用上一层", func() {
+			So(resolved.GenerationSettings, ShouldResemble, novel.GenerationSettings{
+				VideoWidth:  720,
+				VideoHeight: 1280,
+				VideoFPS:    24,
+				VoiceType:   "BV115_streaming",
+				SpeedRatio:  1.5,
+				ImageSteps:  30,
+				SceneGap:    0.8,
+				ClipMerge:   novel.ClipMergeScene,
+			})
+		})
+
+		Convey("记录每个参数的来源层级", func() {
+			So(resolved.Sources["video_width"], ShouldEqual, SettingsLayerSystem)
+			So(resolved.Sources["video_fps"], ShouldEqual, SettingsLayerNovel)
+			So(resolved.Sources["voice_type"], ShouldEqual, SettingsLayerUser)
+			So(resolved.Sources["speed_ratio"], ShouldEqual, SettingsLayerRequest)
+			So(resolved.Sources, ShouldNotContainKey, "missing")
+		})
+
+		Convey("跳过为 nil 的层", func() {
+			resolved := ResolveGenerationSettings(
+				GenerationSettingsLayer{Layer: SettingsLayerSystem, Settings: system},
+				GenerationSettingsLayer{Layer: SettingsLayerUser},
+			)
+			So(resolved.GenerationSettings, ShouldResemble, *system)
+			So(resolved.Sources, ShouldNotContainKey, "image_steps")
+		})
+	})
+}
+
+func TestValidateGenerationSettings(t *testing.T) {
+	Convey("ValidateGenerationSettings 校验取值范围", t, func() {
+		So(ValidateGenerationSettings(nil), ShouldBeNil)
+		So(ValidateGenerationSettings(&novel.GenerationSettings{}), ShouldBeNil)
+		So(ValidateGenerationSettings(&novel.GenerationSettings{VideoWidth: 1080, VideoHeight: 1920, VideoFPS: 60, SpeedRatio: 0.5, ImageSteps: 150}), ShouldBeNil)
+		So(ValidateGenerationSettings(&novel.GenerationSettings{ClipMerge: novel.ClipMergeShort, ClipMergeUnder: 2.5}), ShouldBeNil)
+
+		invalid := []*novel.GenerationSettings{
+			{VideoWidth: 721},
+			{VideoHeight: 100},
+			{VideoWidth: 4096},
+			{VideoFPS: 61},
+			{VideoFPS: -1},
+			{SpeedRatio: 2.5},
+			{SpeedRatio: -1},
+			{ImageSteps: 151},
+			{SegmentGap: -0.5},
+			{SceneGap: 6},
+			{ClipMerge: "first3"},
+			{ClipMergeUnder: 21},
+		}
+		for _, s := range invalid {
+			So(errors.Is(ValidateGenerationSettings(s), ErrInvalidGenerationSettings), ShouldBeTrue)
+		}
+	})
+}
+
+func TestNarrationGaps(t *testing.T) {
+	Convey("NarrationGaps 计算解说片段之间的停顿", t, func() {
+		scenes := []string{"1", "1", "2", "2", "3"}
+		So(NarrationGaps(scenes, 0.3, 0.8), ShouldResemble, []float64{0.3, 0.8, 0.3, 0.8, 0})
+		So(NarrationGaps(scenes, 0.3, 0), ShouldResemble, []float64{0.3, 0.3, 0.3, 0.3, 0})
+		So(NarrationGaps(scenes, 0, 0), ShouldResemble, []float64{0, 0, 0, 0, 0})
+		So(NarrationGaps(nil, 0.3, 0.8), ShouldBeEmpty)
+	})
+}
+
+func TestImageStepsContext(t *testing.T) {
+	Convey("图片采样步数通过 context 传递", t, func() {
+		ctx := context.Background()
+		So(ImageStepsFromContext(ctx), ShouldEqual, 0)
+		So(ImageStepsFromContext(WithImageSteps(ctx, 0)), ShouldEqual, 0)
+		So(ImageStepsFromContext(WithImageSteps(ctx, 28)), ShouldEqual, 28)
+	})
+}
+
+func TestPreviewResolution(t *testing.T) {
+	Convey("PreviewResolution 按画幅缩小到短边 720", t, func() {
+		w, h := PreviewResolution(1080, 1920)
+		So([]int{w, h}, ShouldResemble, []int{720, 1280})
+
+		w, h = PreviewResolution(1920, 1080)
+		So([]int{w, h}, ShouldResemble, []int{1280, 720})
+
+		w, h = PreviewResolution(1080, 1440)
+		So([]int{w, h}, ShouldResemble, []int{720, 960})
+
+		w, h = PreviewResolution(1446, 2570)
+		So([]int{w, h}, ShouldResemble, []int{720, 1278})
+
+		w, h = PreviewResolution(540, 960)
+		So([]int{w, h}, ShouldResemble, []int{540, 960})
+	})
+}
@@ -12,11 +12,29 @@ type ImagePromptBuilder struct {
 	stylePrompt string
 }
 
-// NewImagePromptBuilder 创建图片 prompt 构建器
+// stylePresetPrompts 各风格预设对应的画面风格描述
+var stylePresetPrompts = map[novel.ImageStylePreset]string{
+	novel.ImageStylePresetGuofengComic: "画面风格是强调强烈线条、鲜明对比和现代感造型，色彩饱和，带有动态夸张与都市叙事视觉冲击力的国风漫画风格",
+	novel.ImageStylePresetAnime:        "画面风格是线条干净、色彩明亮通透、光影柔和、人物五官精致的日系动漫风格",
+	novel.ImageStylePresetRealistic:    "画面风格是写实电影感，真实的材质与光影，浅景深，电影级调色与构图",
+	novel.ImageStylePresetInkWash:      "画面风格是中国传统水墨画，墨色浓淡层次分明，大量留白，笔触写意，色彩淡雅",
+	novel.ImageStylePresetNone:         "",
+}
+
+// NewImagePromptBuilder 创建图片 prompt 构建器（使用默认的国风漫画风格）
 func NewImagePromptBuilder() *ImagePromptBuilder {
 	return &ImagePromptBuilder{
-		stylePrompt: "画面风格是强调强烈线条、鲜明对比和现代感造型，色彩饱和，带有动态夸张与都市叙事视觉冲击力的国风漫画风格",
+		stylePrompt: stylePresetPrompts[novel.ImageStylePresetGuofengComic],
+	}
+}
+
+// NewImagePromptBuilderWithPreset 创建使用指定风格预设的图片 prompt 构建器（预设为空或不支持时使用默认的国风漫画风格）
+func NewImagePromptBuilderWithPreset(preset novel.ImageStylePreset) *ImagePromptBuilder {
+	style, ok := stylePresetPrompts[preset]
+	if !ok {
+		return NewImagePromptBuilder()
 	}
+	return &ImagePromptBuilder{stylePrompt: style}
 }
 
 // BuildCharacterDescription 构建角色描述
@@ -71,3 +89,41 @@ func (b *ImagePromptBuilder) BuildCompletePrompt(character *novel.Character, sce
 
 	return fmt.Sprintf("%s。%s。%s", stylePart, characterPart, scenePart)
 }
+
+// BuildStylePresetPrompt 按风格预设构建图片 prompt
+// 格式：风格描述。原始 prompt；预设为 none 时直接返回原始 prompt
+func BuildStylePresetPrompt(preset novel.ImageStylePreset, prompt string) (string, error) {
+	style, ok := stylePresetPrompts[preset]
+	if !ok {
+		return "", fmt.Errorf("unsupported image style preset: %s", preset)
+	}
+	prompt = strings.TrimSpace(prompt)
+	if style == "" {
+		return prompt, nil
+	}
+	return fmt.Sprintf("%s。%s", style, prompt), nil
+}
+
+// relightPrompts 各重新打光变体对应的画面描述
+var relightPrompts = map[novel.RelightVariant]string{
+	novel.RelightVariantNight: "将画面改为夜晚：深蓝色夜空，月光从侧上方洒下，建筑与人物边缘有冷色轮廓光，室内与灯笼透出暖黄色灯光，整体偏暗但主体清晰",
+	novel.RelightVariantDusk:  "将画面改为黄昏：夕阳低垂于地平线，天空呈橙红到紫色渐变，暖色逆光勾勒人物轮廓，阴影拉长",
+	novel.RelightVariantRain:  "将画面改为雨天：阴云密布，细密雨丝斜落，地面湿润有积水倒影，整体色调偏冷偏灰，光线柔和漫射",
+}
+
+// BuildRelightPrompt 构建场景图片重新打光（图生图）的 prompt
+// 只改变时间/天气与光照，保持原图的构图、人物、服饰和画风不变，保证前后镜头的连续性
+func BuildRelightPrompt(variant novel.RelightVariant, scenePrompt string) (string, error) {
+	relight, ok := relightPrompts[variant]
+	if !ok {
+		return "", fmt.Errorf("unsupported relight variant: %s", variant)
+	}
+
+	var b strings.Builder
+	b.WriteString(relight)
+	b.WriteString("。保持原图的构图、镜头角度、人物姿态、服饰和画面风格完全不变，只调整时间、天气和光照效果")
+	if scenePrompt = strings.TrimSpace(scenePrompt); scenePrompt != "" {
+		fmt.Fprintf(&b, "。原场景描述：%s", scenePrompt)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,62 @@
+package noveltools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestBuildRelightPrompt(t *testing.T) {
+	Convey("BuildRelightPrompt 能为每种变体构建重新打光 prompt", t, func() {
+		Convey("所有支持的变体都能构建", func() {
+			for _, variant := range novel.AllRelightVariants {
+				prompt, err := BuildRelightPrompt(variant, "山门前的广场")
+				So(err, ShouldBeNil)
+				So(prompt, ShouldContainSubstring, "保持原图的构图")
+				So(prompt, ShouldContainSubstring, "山门前的广场")
+			}
+		})
+
+		Convey("场景描述为空时不附加原场景描述", func() {
+			prompt, err := BuildRelightPrompt(novel.RelightVariantNight, "  ")
+			So(err, ShouldBeNil)
+			So(prompt, ShouldNotContainSubstring, "原场景描述")
+		})
+
+		Convey("不支持的变体返回错误", func() {
+			_, err := BuildRelightPrompt(novel.RelightVariant("snow"), "")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestBuildStylePresetPrompt(t *testing.T) {
+	Convey("BuildStylePresetPrompt 按风格预设构建 prompt", t, func() {
+		Convey("所有支持的预设都能构建，且包含原始 prompt", func() {
+			for _, preset := range novel.AllImageStylePresets {
+				prompt, err := BuildStylePresetPrompt(preset, " 雪夜山门，少年持剑 ")
+				So(err, ShouldBeNil)
+				So(prompt, ShouldEndWith, "雪夜山门，少年持剑")
+			}
+		})
+
+		Convey("默认预设与章节图片使用相同的风格描述", func() {
+			prompt, err := BuildStylePresetPrompt(novel.ImageStylePresetGuofengComic, "雪夜山门")
+			So(err, ShouldBeNil)
+			So(prompt, ShouldStartWith, NewImagePromptBuilder().stylePrompt)
+		})
+
+		Convey("none 预设直接返回原始 prompt", func() {
+			prompt, err := BuildStylePresetPrompt(novel.ImageStylePresetNone, "雪夜山门")
+			So(err, ShouldBeNil)
+			So(prompt, ShouldEqual, "雪夜山门")
+		})
+
+		Convey("不支持的预设返回错误", func() {
+			_, err := BuildStylePresetPrompt(novel.ImageStylePreset("pixel"), "雪夜山门")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,94 @@
+package noveltools
+
+// AudioSegmentText 镜头当前用于合成音频的解说文本（按数字写法转换并清理后的文本）
+type AudioSegmentText struct {
+	ShotID string
+	Text   string
+}
+
+// PreviousAudioSegment 上一个音频版本中的片段
+type PreviousAudioSegment struct {
+	ShotID   string
+	Sequence int
+	Text     string // 合成时使用的文本（音频记录中保存的清理后文本）
+}
+
+// AudioSegmentPlan 增量重新合成时一个片段的处理方式
+type AudioSegmentPlan struct {
+	ShotID   string
+	Sequence int    // 新版本中的序号（从1开始）
+	Text     string // 合成用的文本
+	Reuse    int    // 复用的上一版本片段下标，-1 表示需要重新合成
+}
+
+// IncrementalAudioPlan 解说文本修改后的音频增量重新合成计划
+type IncrementalAudioPlan struct {
+	Segments []AudioSegmentPlan // 新版本的所有片段（按镜头顺序）
+	Stale    []int              // 上一版本中不再使用的片段下标（文本已修改或镜头已删除）
+}
+
+// Changed 是否有片段需要重新合成或删除
+func (p *IncrementalAudioPlan) Changed() bool {
+	if len(p.Stale) > 0 {
+		return true
+	}
+	for _, seg := range p.Segments {
+		if seg.Reuse < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Resynthesized 需要重新合成的片段数
+func (p *IncrementalAudioPlan) Resynthesized() int {
+	n := 0
+	for _, seg := range p.Segments {
+		if seg.Reuse < 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// PlanIncrementalAudio 对比镜头当前的解说文本和上一个音频版本，找出需要重新合成的片段
+// 片段按镜头ID对应（镜头重排后仍能对应）；上一版本的片段没有镜头ID时（旧数据）按序号对应。
+// 文本相同的片段复用上一版本的音频，其余片段重新合成；上一版本中没有被复用的片段视为已过期
+func PlanIncrementalAudio(current []AudioSegmentText, previous []PreviousAudioSegment) *IncrementalAudioPlan {
+	byShot := make(map[string]int, len(previous))
+	bySequence := make(map[int]int, len(previous))
+	for i, prev := range previous {
+		if prev.ShotID != "" {
+			byShot[prev.ShotID] = i
+		} else if prev.Sequence > 0 {
+			bySequence[prev.Sequence] = i
+		}
+	}
+
+	plan := &IncrementalAudioPlan{}
+	used := make(map[int]bool, len(previous))
+	for i, cur := range current {
+		sequence := i + 1
+		idx, ok := byShot[cur.ShotID]
+		if !ok || cur.ShotID == "" {
+			idx, ok = bySequence[sequence]
+		}
+		reuse := -1
+		if ok && !used[idx] && previous[idx].Text == cur.Text {
+			reuse = idx
+			used[idx] = true
+		}
+		plan.Segments = append(plan.Segments, AudioSegmentPlan{
+			ShotID:   cur.ShotID,
+			Sequence: sequence,
+			Text:     cur.Text,
+			Reuse:    reuse,
+		})
+	}
+	for i := range previous {
+		if !used[i] {
+			plan.Stale = append(plan.Stale, i)
+		}
+	}
+	return plan
+}
@@ -0,0 +1,71 @@
+package noveltools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPlanIncrementalAudio(t *testing.T) {
+	Convey("PlanIncrementalAudio 找出需要重新合成的音频片段", t, func() {
+		previous := []PreviousAudioSegment{
+			{ShotID: "s1", Sequence: 1, Text: "第一句"},
+			{ShotID: "s2", Sequence: 2, Text: "第二句"},
+			{ShotID: "s3", Sequence: 3, Text: "第三句"},
+		}
+
+		Convey("文本没有修改时全部复用", func() {
+			plan := PlanIncrementalAudio([]AudioSegmentText{
+				{ShotID: "s1", Text: "第一句"},
+				{ShotID: "s2", Text: "第二句"},
+				{ShotID: "s3", Text: "第三句"},
+			}, previous)
+			So(plan.Changed(), ShouldBeFalse)
+			So(plan.Resynthesized(), ShouldEqual, 0)
+			So(plan.Stale, ShouldBeEmpty)
+		})
+
+		Convey("只重新合成修改过的镜头", func() {
+			plan := PlanIncrementalAudio([]AudioSegmentText{
+				{ShotID: "s1", Text: "第一句"},
+				{ShotID: "s2", Text: "修改后的第二句"},
+				{ShotID: "s3", Text: "第三句"},
+			}, previous)
+			So(plan.Changed(), ShouldBeTrue)
+			So(plan.Resynthesized(), ShouldEqual, 1)
+			So(plan.Segments[0].Reuse, ShouldEqual, 0)
+			So(plan.Segments[1].Reuse, ShouldEqual, -1)
+			So(plan.Segments[1].Text, ShouldEqual, "修改后的第二句")
+			So(plan.Segments[2].Reuse, ShouldEqual, 2)
+			So(plan.Stale, ShouldResemble, []int{1})
+		})
+
+		Convey("镜头重排后按镜头ID复用，删除的镜头视为过期", func() {
+			plan := PlanIncrementalAudio([]AudioSegmentText{
+				{ShotID: "s3", Text: "第三句"},
+				{ShotID: "s1", Text: "第一句"},
+				{ShotID: "s4", Text: "新增的一句"},
+			}, previous)
+			So(plan.Segments[0].Reuse, ShouldEqual, 2)
+			So(plan.Segments[0].Sequence, ShouldEqual, 1)
+			So(plan.Segments[1].Reuse, ShouldEqual, 0)
+			So(plan.Segments[2].Reuse, ShouldEqual, -1)
+			So(plan.Resynthesized(), ShouldEqual, 1)
+			So(plan.Stale, ShouldResemble, []int{1})
+		})
+
+		Convey("上一版本没有镜头ID时按序号对应", func() {
+			legacy := []PreviousAudioSegment{
+				{Sequence: 1, Text: "第一句"},
+				{Sequence: 2, Text: "第二句"},
+			}
+			plan := PlanIncrementalAudio([]AudioSegmentText{
+				{ShotID: "s1", Text: "第一句"},
+				{ShotID: "s2", Text: "第二句改"},
+			}, legacy)
+			So(plan.Segments[0].Reuse, ShouldEqual, 0)
+			So(plan.Segments[1].Reuse, ShouldEqual, -1)
+			So(plan.Stale, ShouldResemble, []int{1})
+		})
+	})
+}
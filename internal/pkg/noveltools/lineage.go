@@ -0,0 +1,65 @@
+package noveltools
+
+import (
+	"lemon/internal/model/novel"
+)
+
+// artifactDependents 章节产物的直接下游：上游重新生成后，下游产物需要重建才能用上新内容
+// 音频按镜头解说合成，图片按镜头提示词生成；字幕来自音频时间戳；解说视频由图片和音频时长生成；最终视频拼接解说视频并烧录字幕
+var artifactDependents = map[novel.PipelineStage][]novel.PipelineStage{
+	novel.PipelineStageNarration:      {novel.PipelineStageAudio, novel.PipelineStageImage},
+	novel.PipelineStageAudio:          {novel.PipelineStageSubtitle, novel.PipelineStageNarrationVideo},
+	novel.PipelineStageSubtitle:       {novel.PipelineStageFinalVideo},
+	novel.PipelineStageImage:          {novel.PipelineStageNarrationVideo},
+	novel.PipelineStageNarrationVideo: {novel.PipelineStageFinalVideo},
+}
+
+// IsPipelineStage 是否为章节渲染流程的阶段
+func IsPipelineStage(stage novel.PipelineStage) bool {
+	for _, s := range novel.AllPipelineStages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// RegenerationBlastRadius 返回重新生成某个阶段后需要重建的所有阶段（含该阶段本身，按流程顺序）
+// 沿产物依赖关系向下游遍历；不是流程阶段时返回 nil
+func RegenerationBlastRadius(stage novel.PipelineStage) []novel.PipelineStage {
+	if !IsPipelineStage(stage) {
+		return nil
+	}
+	affected := map[novel.PipelineStage]bool{stage: true}
+	queue := []novel.PipelineStage{stage}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range artifactDependents[current] {
+			if !affected[next] {
+				affected[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	var stages []novel.PipelineStage
+	for _, s := range novel.AllPipelineStages {
+		if affected[s] {
+			stages = append(stages, s)
+		}
+	}
+	return stages
+}
+
+// ImageToVideoSeconds 估算按音频时长生成解说视频时调用图生视频接口的总秒数
+// 与生成解说视频时一致：音频不超过 ReadingShotMaxDuration 秒的镜头按整数秒调用图生视频，更长的镜头用图片缩放效果（不计费）
+func ImageToVideoSeconds(durations []float64) int {
+	total := 0
+	for _, d := range durations {
+		if d > 0 && d <= ReadingShotMaxDuration {
+			total += int(d)
+		}
+	}
+	return total
+}
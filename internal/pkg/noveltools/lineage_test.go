@@ -0,0 +1,46 @@
+package noveltools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestRegenerationBlastRadius(t *testing.T) {
+	Convey("RegenerationBlastRadius 沿产物依赖关系返回需要重建的阶段", t, func() {
+		Convey("重新生成解说时所有下游阶段都需要重建", func() {
+			So(RegenerationBlastRadius(novel.PipelineStageNarration), ShouldResemble, novel.AllPipelineStages)
+		})
+
+		Convey("重新生成音频时图片不受影响", func() {
+			So(RegenerationBlastRadius(novel.PipelineStageAudio), ShouldResemble, []novel.PipelineStage{
+				novel.PipelineStageAudio,
+				novel.PipelineStageSubtitle,
+				novel.PipelineStageNarrationVideo,
+				novel.PipelineStageFinalVideo,
+			})
+		})
+
+		Convey("重新生成图片时只影响解说视频和最终视频", func() {
+			So(RegenerationBlastRadius(novel.PipelineStageImage), ShouldResemble, []novel.PipelineStage{
+				novel.PipelineStageImage,
+				novel.PipelineStageNarrationVideo,
+				novel.PipelineStageFinalVideo,
+			})
+		})
+
+		Convey("最终视频没有下游，未知阶段返回 nil", func() {
+			So(RegenerationBlastRadius(novel.PipelineStageFinalVideo), ShouldResemble, []novel.PipelineStage{novel.PipelineStageFinalVideo})
+			So(RegenerationBlastRadius(novel.PipelineStage("thumbnail")), ShouldBeNil)
+		})
+	})
+}
+
+func TestImageToVideoSeconds(t *testing.T) {
+	Convey("ImageToVideoSeconds 只统计不超过 12 秒的镜头并按整数秒计算", t, func() {
+		So(ImageToVideoSeconds([]float64{3.6, 12, 12.5, 0, 5.2}), ShouldEqual, 20)
+		So(ImageToVideoSeconds(nil), ShouldEqual, 0)
+	})
+}
@@ -0,0 +1,110 @@
+package noveltools
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"lemon/internal/model/novel"
+)
+
+// ErrInvalidListQuery 列表接口的分页、排序或筛选参数不合法
+var ErrInvalidListQuery = errors.New("invalid list query")
+
+const (
+	// DefaultListPageSize 只指定 page 时的每页数量
+	DefaultListPageSize int64 = 20
+	// MaxListPageSize 每页最大数量
+	MaxListPageSize int64 = 100
+)
+
+// ListSortField 列表排序字段
+type ListSortField string
+
+const (
+	ListSortSequence  ListSortField = "sequence"   // 按序号排序
+	ListSortCreatedAt ListSortField = "created_at" // 按创建时间排序
+)
+
+// listVideoTypes 视频类型筛选支持的值
+var listVideoTypes = map[novel.VideoType]bool{
+	novel.VideoTypeNarration: true,
+	novel.VideoTypeFinal:     true,
+	novel.VideoTypeNovel:     true,
+}
+
+// ListQuery 列表接口的分页、排序和筛选条件（各条件之间为“且”，同一条件的多个值为“或”）
+type ListQuery struct {
+	Page       int64             // 页码，从 1 开始（不分页时为 0）
+	PageSize   int64             // 每页数量，0 表示不分页（返回全部）
+	Sort       ListSortField     // 排序字段，为空时使用列表的默认排序
+	Desc       bool              // 是否倒序（只在指定排序字段时生效）
+	Statuses   []string          // 状态，为空时不限
+	VideoTypes []novel.VideoType // 视频类型（仅视频列表），为空时不限
+}
+
+// ParseListQuery 解析列表参数
+// page、pageSize 都为 0 时不分页；只指定 page 时每页 DefaultListPageSize 条，只指定 pageSize 时为第 1 页；
+// sort 为 sequence 或 created_at，order 为 asc（默认）或 desc，且必须与 sort 一起指定；
+// statuses、videoTypes 为逗号分隔的列表
+func ParseListQuery(page, pageSize int64, sort, order, statuses, videoTypes string) (*ListQuery, error) {
+	if page < 0 {
+		return nil, fmt.Errorf("%w: page must not be negative", ErrInvalidListQuery)
+	}
+	if pageSize < 0 || pageSize > MaxListPageSize {
+		return nil, fmt.Errorf("%w: page_size must be between 0 and %d", ErrInvalidListQuery, MaxListPageSize)
+	}
+	q := &ListQuery{Page: page, PageSize: pageSize}
+	switch {
+	case page > 0 && pageSize == 0:
+		q.PageSize = DefaultListPageSize
+	case page == 0 && pageSize > 0:
+		q.Page = 1
+	}
+
+	sort = strings.TrimSpace(sort)
+	switch ListSortField(sort) {
+	case "", ListSortSequence, ListSortCreatedAt:
+		q.Sort = ListSortField(sort)
+	default:
+		return nil, fmt.Errorf("%w: unknown sort %q", ErrInvalidListQuery, sort)
+	}
+	switch order = strings.ToLower(strings.TrimSpace(order)); order {
+	case "", "asc":
+	case "desc":
+		q.Desc = true
+	default:
+		return nil, fmt.Errorf("%w: order must be asc or desc", ErrInvalidListQuery)
+	}
+	if order != "" && q.Sort == "" {
+		return nil, fmt.Errorf("%w: order requires sort", ErrInvalidListQuery)
+	}
+
+	for _, s := range splitArtifactFilterList(statuses) {
+		if !artifactStatuses[s] {
+			return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidListQuery, s)
+		}
+		q.Statuses = append(q.Statuses, s)
+	}
+	for _, t := range splitArtifactFilterList(videoTypes) {
+		videoType := novel.VideoType(t)
+		if !listVideoTypes[videoType] {
+			return nil, fmt.Errorf("%w: unknown video_type %q", ErrInvalidListQuery, t)
+		}
+		q.VideoTypes = append(q.VideoTypes, videoType)
+	}
+	return q, nil
+}
+
+// Paged 是否分页
+func (q *ListQuery) Paged() bool {
+	return q.PageSize > 0
+}
+
+// Skip 跳过的条数（不分页时为 0）
+func (q *ListQuery) Skip() int64 {
+	if !q.Paged() {
+		return 0
+	}
+	return (q.Page - 1) * q.PageSize
+}
@@ -0,0 +1,74 @@
+package noveltools
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestParseListQuery(t *testing.T) {
+	Convey("ParseListQuery 解析列表的分页、排序和筛选参数", t, func() {
+		Convey("没有参数时不分页、使用默认排序、不筛选", func() {
+			q, err := ParseListQuery(0, 0, "", "", "", "")
+			So(err, ShouldBeNil)
+			So(q.Paged(), ShouldBeFalse)
+			So(q.Skip(), ShouldEqual, 0)
+			So(q.Sort, ShouldEqual, ListSortField(""))
+			So(q.Statuses, ShouldBeEmpty)
+			So(q.VideoTypes, ShouldBeEmpty)
+		})
+
+		Convey("只指定 page 或 page_size 时补全另一个", func() {
+			q, err := ParseListQuery(3, 0, "", "", "", "")
+			So(err, ShouldBeNil)
+			So(q.PageSize, ShouldEqual, DefaultListPageSize)
+			So(q.Skip(), ShouldEqual, 2*DefaultListPageSize)
+
+			q, err = ParseListQuery(0, 50, "", "", "", "")
+			So(err, ShouldBeNil)
+			So(q.Page, ShouldEqual, 1)
+			So(q.Skip(), ShouldEqual, 0)
+		})
+
+		Convey("page_size 不能超过上限", func() {
+			_, err := ParseListQuery(1, MaxListPageSize+1, "", "", "", "")
+			So(errors.Is(err, ErrInvalidListQuery), ShouldBeTrue)
+			_, err = ParseListQuery(-1, 10, "", "", "", "")
+			So(errors.Is(err, ErrInvalidListQuery), ShouldBeTrue)
+		})
+
+		Convey("排序字段和顺序", func() {
+			q, err := ParseListQuery(0, 0, "created_at", "DESC", "", "")
+			So(err, ShouldBeNil)
+			So(q.Sort, ShouldEqual, ListSortCreatedAt)
+			So(q.Desc, ShouldBeTrue)
+
+			q, err = ParseListQuery(0, 0, "sequence", "", "", "")
+			So(err, ShouldBeNil)
+			So(q.Sort, ShouldEqual, ListSortSequence)
+			So(q.Desc, ShouldBeFalse)
+
+			_, err = ParseListQuery(0, 0, "updated_at", "", "", "")
+			So(errors.Is(err, ErrInvalidListQuery), ShouldBeTrue)
+			_, err = ParseListQuery(0, 0, "sequence", "random", "", "")
+			So(errors.Is(err, ErrInvalidListQuery), ShouldBeTrue)
+			_, err = ParseListQuery(0, 0, "", "desc", "", "")
+			So(errors.Is(err, ErrInvalidListQuery), ShouldBeTrue)
+		})
+
+		Convey("状态和视频类型为逗号分隔的列表", func() {
+			q, err := ParseListQuery(0, 0, "", "", "completed, failed", "final_video,narration_video")
+			So(err, ShouldBeNil)
+			So(q.Statuses, ShouldResemble, []string{"completed", "failed"})
+			So(q.VideoTypes, ShouldResemble, []novel.VideoType{novel.VideoTypeFinal, novel.VideoTypeNarration})
+
+			_, err = ParseListQuery(0, 0, "", "", "done", "")
+			So(errors.Is(err, ErrInvalidListQuery), ShouldBeTrue)
+			_, err = ParseListQuery(0, 0, "", "", "", "trailer")
+			So(errors.Is(err, ErrInvalidListQuery), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,296 @@
+package noveltools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"lemon/internal/model/novel"
+)
+
+const (
+	// DefaultNarrationChunkThreshold 章节字数超过该值时使用分块（map-reduce）方式生成剧本
+	// 过长的章节一次性放进提示词容易超出模型上下文，且单次生成耗时过长导致超时
+	DefaultNarrationChunkThreshold = 12000
+
+	// DefaultNarrationChunkSize 每个分块的最大字符数
+	DefaultNarrationChunkSize = 5000
+
+	// DefaultNarrationChunkOverlap 相邻分块之间重叠的字符数（上一块结尾作为下一块的上下文，避免情节在分块边界断开）
+	DefaultNarrationChunkOverlap = 300
+
+	// DefaultNarrationChunkConcurrency 并行生成分段剧本的最大并发数
+	DefaultNarrationChunkConcurrency = 4
+)
+
+// NarrationChunkOptions 分块生成剧本的配置
+type NarrationChunkOptions struct {
+	Threshold   int // 章节字数超过该值时分块生成（<=0 时使用默认值）
+	ChunkSize   int // 每个分块的最大字符数
+	Overlap     int // 相邻分块重叠的字符数
+	Concurrency int // 并行生成分段剧本的最大并发数
+}
+
+// DefaultNarrationChunkOptions 返回默认的分块配置
+func DefaultNarrationChunkOptions() NarrationChunkOptions {
+	return NarrationChunkOptions{
+		Threshold:   DefaultNarrationChunkThreshold,
+		ChunkSize:   DefaultNarrationChunkSize,
+		Overlap:     DefaultNarrationChunkOverlap,
+		Concurrency: DefaultNarrationChunkConcurrency,
+	}
+}
+
+// Normalize 把未设置或不合法的配置项替换为默认值
+// 重叠长度不超过分块大小的一半，保证每个分块都有足够的新内容
+func (o NarrationChunkOptions) Normalize() NarrationChunkOptions {
+	d := DefaultNarrationChunkOptions()
+	if o.Threshold <= 0 {
+		o.Threshold = d.Threshold
+	}
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = d.ChunkSize
+	}
+	if o.Overlap < 0 { // 0 表示不重叠
+		o.Overlap = d.Overlap
+	}
+	if o.Overlap > o.ChunkSize/2 {
+		o.Overlap = o.ChunkSize / 2
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = d.Concurrency
+	}
+	return o
+}
+
+// ShouldChunk 判断指定字数的章节是否需要分块生成
+func (o NarrationChunkOptions) ShouldChunk(wordCount int) bool {
+	return wordCount > o.Normalize().Threshold
+}
+
+// ChapterChunk 章节分块
+type ChapterChunk struct {
+	Index   int    // 分块序号（从 1 开始）
+	Context string // 与上一分块重叠的内容（只作为上下文，不单独生成场景；第一个分块为空）
+	Text    string // 本分块的正文
+}
+
+// SplitChapterChunks 把章节正文按句子边界切分为不超过 chunkSize 个字符的分块
+// 每个分块（第一个除外）附带上一分块结尾约 overlap 个字符作为上下文，重叠部分从句子开头截取
+// 正文不超过 chunkSize 时返回一个分块
+func SplitChapterChunks(text string, chunkSize, overlap int) []ChapterChunk {
+	if chunkSize <= 0 {
+		chunkSize = DefaultNarrationChunkSize
+	}
+	segments := SplitTTSSegments(text, chunkSize)
+	chunks := make([]ChapterChunk, 0, len(segments))
+	for i, seg := range segments {
+		chunk := ChapterChunk{Index: i + 1, Text: seg}
+		if i > 0 && overlap > 0 {
+			chunk.Context = overlapTail(segments[i-1], overlap)
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// overlapTail 取文本结尾不超过 maxChars 个字符，并尽量从句子开头开始
+func overlapTail(text string, maxChars int) string {
+	runes := []rune(text)
+	if len(runes) <= maxChars {
+		return text
+	}
+	tail := string(runes[len(runes)-maxChars:])
+	sentences := splitAfter(tail, ttsSentenceEndings)
+	if len(sentences) > 1 {
+		// 第一句多半是被截断的半句，丢弃
+		tail = strings.Join(sentences[1:], "")
+	}
+	return strings.TrimSpace(tail)
+}
+
+// PartialNarration 单个分块生成的分段剧本
+type PartialNarration struct {
+	Chunk   ChapterChunk
+	Prompt  string                // 分段剧本的提示词
+	Output  string                // 大模型原始输出
+	Content *NarrationJSONContent // 解析后的分段剧本
+}
+
+// GeneratePartialNarrations 并行为每个分块生成分段剧本（map 阶段），结果按分块顺序返回
+// 任一分块生成或解析失败时返回错误，已成功的分段一并返回（便于调用方统计费用）
+//
+// Args:
+//   - ctx: 上下文
+//   - chunks: 章节分块（通过 SplitChapterChunks 切分）
+//   - chapterNum: 当前章节编号（从 1 开始）
+//   - totalChapters: 总章节数
+//   - concurrency: 最大并发数（<=0 时使用默认值）
+func (ng *NarrationGenerator) GeneratePartialNarrations(
+	ctx context.Context,
+	chunks []ChapterChunk,
+	chapterNum int,
+	totalChapters int,
+	concurrency int,
+) ([]*PartialNarration, error) {
+	if ng.llmProvider == nil {
+		return nil, fmt.Errorf("llmProvider is required")
+	}
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("chunks is empty")
+	}
+	if concurrency <= 0 {
+		concurrency = DefaultNarrationChunkConcurrency
+	}
+
+	partials := make([]*PartialNarration, len(chunks))
+	errs := make([]error, len(chunks))
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk ChapterChunk) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			prompt := buildChunkNarrationPrompt(chunk, len(chunks), chapterNum, totalChapters, ng.structure)
+			output, err := ng.llmProvider.Generate(ctx, prompt)
+			if err != nil {
+				errs[i] = fmt.Errorf("chunk %d: %w", chunk.Index, err)
+				return
+			}
+			partial := &PartialNarration{Chunk: chunk, Prompt: prompt, Output: output}
+			partials[i] = partial
+			content, err := ParseNarrationJSON(output)
+			if err != nil {
+				errs[i] = fmt.Errorf("chunk %d: %w", chunk.Index, err)
+				return
+			}
+			partial.Content = content
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	result := make([]*PartialNarration, 0, len(partials))
+	for _, p := range partials {
+		if p != nil {
+			result = append(result, p)
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// BuildMergePrompt 根据各分块的分段剧本构造合并提示词（reduce 阶段）
+// 合并结果与整章生成的剧本格式相同（按生成器的解说结构组织场景），可以直接交给 GenerateScenesStream 流式生成
+//
+// Args:
+//   - partials: 按分块顺序排列的分段剧本
+//   - chapterNum: 当前章节编号（从 1 开始）
+//   - totalChapters: 总章节数
+//   - chapterWordCount: 章节字数（用于调整解说字数要求）
+func (ng *NarrationGenerator) BuildMergePrompt(
+	partials []*PartialNarration,
+	chapterNum int,
+	totalChapters int,
+	chapterWordCount int,
+) (string, error) {
+	if len(partials) == 0 {
+		return "", fmt.Errorf("partials is empty")
+	}
+	if chapterNum <= 0 || totalChapters <= 0 {
+		return "", fmt.Errorf("invalid chapter number or totalChapters")
+	}
+
+	encoded := make([]string, 0, len(partials))
+	for _, p := range partials {
+		if p == nil || p.Content == nil {
+			return "", fmt.Errorf("partial narration is incomplete")
+		}
+		data, err := json.Marshal(p.Content)
+		if err != nil {
+			return "", fmt.Errorf("marshal chunk %d: %w", p.Chunk.Index, err)
+		}
+		encoded = append(encoded, string(data))
+	}
+
+	intro := "下面给出的是本章节按原文顺序分段提炼的分段剧本，请把它们合并为一份完整的、适合短视频解说的结构化解说文案。\n\n"
+	return buildNarrationPrompt(intro, func(b *strings.Builder) {
+		b.WriteString("【分段剧本合并要求】\n")
+		b.WriteString("1. 分段剧本按章节原文顺序排列，相邻分段的开头可能与上一分段的结尾情节重叠，合并时重叠的情节只保留一次\n")
+		fmt.Fprintf(b, "2. 按时间顺序把所有分段的情节重新组织为%s个场景，覆盖整章的主要情节，不要遗漏关键转折\n", formatCountRange(ng.structure.MinScenes, ng.structure.MaxScenes))
+		b.WriteString("3. 连贯性检查：同一角色、道具在全章中的称呼和设定必须一致，场景之间的过渡要自然，不要出现前后矛盾的情节\n")
+		b.WriteString("4. 同一角色或道具在多个分段中出现时只输出一条，合并各分段中的描述\n")
+		b.WriteString("5. 解说内容可以重新撰写，不必照搬分段剧本中的原句\n\n")
+		b.WriteString("下面是本章节的分段剧本：\n")
+		b.WriteString("---- BEGIN PARTIAL NARRATIONS ----\n")
+		for i, data := range encoded {
+			fmt.Fprintf(b, "【第 %d 段 / 共 %d 段】\n", i+1, len(encoded))
+			b.WriteString(data)
+			b.WriteString("\n")
+		}
+		b.WriteString("---- END PARTIAL NARRATIONS ----\n\n")
+	}, chapterNum, totalChapters, chapterWordCount, ng.structure), nil
+}
+
+// buildChunkNarrationPrompt 构造单个分块的分段剧本提示词
+// 分段剧本只提炼情节、角色和道具，图片和视频提示词在合并阶段统一生成；每个场景的镜头数按解说结构要求
+func buildChunkNarrationPrompt(chunk ChapterChunk, totalChunks, chapterNum, totalChapters int, structure novel.NarrationStructure) string {
+	var b strings.Builder
+	b.WriteString("你是一名专业的中文小说解说文案撰写助手。\n")
+	fmt.Fprintf(&b, "本章节篇幅较长，已按原文顺序切分为 %d 段，下面给出的是第 %d 段。", totalChunks, chunk.Index)
+	b.WriteString("请只针对本段内容提炼分段剧本，后续会把所有分段合并为完整剧本。\n\n")
+
+	b.WriteString("【输出格式】\n")
+	b.WriteString("只输出一个有效的 JSON 对象，不要使用 markdown 代码块，不要添加任何解释文字，格式如下：\n")
+	b.WriteString(`{
+  "characters": [
+    {"name": "角色姓名", "gender": "男/女", "age_group": "青年/中年/老年/青少年/儿童", "description": "角色在本段中体现的外貌、性格、身份"}
+  ],
+  "props": [
+    {"name": "道具名称", "description": "道具描述", "category": "道具类别"}
+  ],
+  "scenes": [
+    {
+      "scene_number": "1",
+      "description": "场景发生的地点和情境",
+      "shots": [
+        {"closeup_number": "1", "character": "分镜头人物姓名", "narration": "本镜头对应的情节（只包含故事内容）"}
+      ]
+    }
+  ]
+}`)
+	b.WriteString("\n\n【内容要求】\n")
+	fmt.Fprintf(&b, "1. 按本段情节的先后顺序提炼2-4个场景，每个场景包含%s个分镜头\n", formatCountRange(structure.MinShotsPerScene, structure.MaxShotsPerScene))
+	b.WriteString("2. 解说内容使用第三人称口播风格，只包含情节、对话、人物心理活动，不要包含镜头、画面等技术性描述\n")
+	b.WriteString("3. 列出本段出现的所有角色和重要道具\n")
+	b.WriteString("4. 不要编造本段没有的情节，也不要剧透后续内容\n")
+	if chunk.Context != "" {
+		b.WriteString("5. 【上文衔接】部分是上一段的结尾，只用于理解上下文，不要为它单独生成场景\n")
+	}
+	b.WriteString("\n")
+
+	fmt.Fprintf(&b, "当前进度：第 %d 章 / 共 %d 章，第 %d 段 / 共 %d 段。\n\n", chapterNum, totalChapters, chunk.Index, totalChunks)
+	if chunk.Context != "" {
+		b.WriteString("【上文衔接】\n")
+		b.WriteString(chunk.Context)
+		b.WriteString("\n\n")
+	}
+	b.WriteString("---- BEGIN CHAPTER PART ----\n")
+	b.WriteString(chunk.Text)
+	b.WriteString("\n---- END CHAPTER PART ----\n")
+	return b.String()
+}
+
+// ChapterRuneCount 返回章节正文的字符数（章节没有记录字数时用于判断是否分块）
+func ChapterRuneCount(text string) int {
+	return utf8.RuneCountInString(strings.TrimSpace(text))
+}
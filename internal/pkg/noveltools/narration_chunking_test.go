@@ -0,0 +1,135 @@
+package noveltools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+// chunkLLM 按分块序号返回固定分段剧本的 LLM
+type chunkLLM struct {
+	failChunk int
+}
+
+func (l *chunkLLM) Generate(ctx context.Context, prompt string) (string, error) {
+	index, _ := strconv.Atoi(chunkIndexPattern.FindStringSubmatch(prompt)[1])
+	if index == l.failChunk {
+		return "", fmt.Errorf("timeout")
+	}
+	return fmt.Sprintf(`{"characters":[{"name":"林渊"}],"scenes":[{"scene_number":"1","shots":[{"closeup_number":"1","narration":"第%d段情节"}]}]}`, index), nil
+}
+
+var chunkIndexPattern = regexp.MustCompile(`第 (\d+) 段 / 共`)
+
+func TestNarrationChunkOptions(t *testing.T) {
+	Convey("NarrationChunkOptions 按字数决定是否分块", t, func() {
+		opts := NarrationChunkOptions{Overlap: -1}.Normalize()
+		So(opts, ShouldResemble, DefaultNarrationChunkOptions())
+		So(opts.ShouldChunk(DefaultNarrationChunkThreshold), ShouldBeFalse)
+		So(opts.ShouldChunk(DefaultNarrationChunkThreshold+1), ShouldBeTrue)
+
+		Convey("重叠长度不超过分块大小的一半", func() {
+			opts := NarrationChunkOptions{ChunkSize: 100, Overlap: 80}.Normalize()
+			So(opts.Overlap, ShouldEqual, 50)
+		})
+	})
+}
+
+func TestSplitChapterChunks(t *testing.T) {
+	Convey("SplitChapterChunks 按句子切分章节并附带重叠上下文", t, func() {
+		text := strings.Repeat("少年拔剑出鞘，寒光映雪。", 20) // 每句 12 个字符
+
+		Convey("短章节只有一个分块", func() {
+			chunks := SplitChapterChunks("少年拔剑出鞘。", 100, 10)
+			So(chunks, ShouldHaveLength, 1)
+			So(chunks[0].Index, ShouldEqual, 1)
+			So(chunks[0].Context, ShouldBeEmpty)
+		})
+
+		Convey("长章节切分为多个分块", func() {
+			chunks := SplitChapterChunks(text, 50, 20)
+			So(len(chunks), ShouldBeGreaterThan, 1)
+
+			var joined strings.Builder
+			for i, chunk := range chunks {
+				So(chunk.Index, ShouldEqual, i+1)
+				So(utf8.RuneCountInString(chunk.Text), ShouldBeLessThanOrEqualTo, 50)
+				So(strings.HasSuffix(chunk.Text, "。"), ShouldBeTrue)
+				joined.WriteString(chunk.Text)
+				if i == 0 {
+					So(chunk.Context, ShouldBeEmpty)
+					continue
+				}
+				// 重叠部分从句子开头开始，且来自上一分块的结尾
+				So(chunk.Context, ShouldNotBeEmpty)
+				So(utf8.RuneCountInString(chunk.Context), ShouldBeLessThanOrEqualTo, 20)
+				So(strings.HasPrefix(chunk.Context, "少年"), ShouldBeTrue)
+				So(strings.HasSuffix(chunks[i-1].Text, chunk.Context), ShouldBeTrue)
+			}
+			So(joined.String(), ShouldEqual, text)
+		})
+
+		Convey("overlap 为 0 时没有上下文", func() {
+			for _, chunk := range SplitChapterChunks(text, 50, 0) {
+				So(chunk.Context, ShouldBeEmpty)
+			}
+		})
+	})
+}
+
+func TestGeneratePartialNarrations(t *testing.T) {
+	Convey("GeneratePartialNarrations 并行生成分段剧本", t, func() {
+		chunks := SplitChapterChunks(strings.Repeat("少年拔剑出鞘，寒光映雪。", 20), 50, 20)
+		total := len(chunks)
+
+		Convey("结果按分块顺序返回", func() {
+			ng := NewNarrationGenerator(&chunkLLM{})
+			partials, err := ng.GeneratePartialNarrations(context.Background(), chunks, 3, 10, 2)
+			So(err, ShouldBeNil)
+			So(partials, ShouldHaveLength, total)
+			for i, p := range partials {
+				So(p.Chunk.Index, ShouldEqual, i+1)
+				So(p.Prompt, ShouldContainSubstring, fmt.Sprintf("第 %d 段 / 共 %d 段", i+1, total))
+				So(p.Content.Scenes[0].Shots[0].Narration, ShouldEqual, fmt.Sprintf("第%d段情节", i+1))
+			}
+			So(partials[0].Prompt, ShouldNotContainSubstring, "【上文衔接】\n")
+			So(partials[1].Prompt, ShouldContainSubstring, "【上文衔接】\n")
+
+			Convey("合并提示词包含所有分段并要求生成7个场景", func() {
+				prompt, err := ng.BuildMergePrompt(partials, 3, 10, 20000)
+				So(err, ShouldBeNil)
+				So(prompt, ShouldContainSubstring, "必须生成7个场景")
+				So(prompt, ShouldContainSubstring, "当前进度：第 3 章 / 共 10 章")
+				So(prompt, ShouldContainSubstring, fmt.Sprintf("【第 %d 段 / 共 %d 段】", total, total))
+				So(prompt, ShouldContainSubstring, "第1段情节")
+				So(prompt, ShouldNotContainSubstring, "---- BEGIN CHAPTER ----")
+			})
+
+			Convey("合并提示词按生成器的解说结构要求场景数", func() {
+				short := ResolveNarrationStructure(&novel.NarrationStructure{Template: NarrationStructureShort})
+				prompt, err := ng.WithStructure(short).BuildMergePrompt(partials, 3, 10, 20000)
+				So(err, ShouldBeNil)
+				So(prompt, ShouldContainSubstring, "必须生成3-5个场景（scene），每个场景包含1-2个分镜头（shot）")
+				So(prompt, ShouldContainSubstring, "重新组织为3-5个场景")
+				So(prompt, ShouldContainSubstring, "对应约90秒的解说视频")
+				So(prompt, ShouldNotContainSubstring, "必须生成7个场景")
+			})
+		})
+
+		Convey("任一分块失败时返回错误和已成功的分段", func() {
+			ng := NewNarrationGenerator(&chunkLLM{failChunk: 2})
+			partials, err := ng.GeneratePartialNarrations(context.Background(), chunks, 3, 10, 2)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "chunk 2")
+			So(partials, ShouldHaveLength, total-1)
+		})
+	})
+}
@@ -0,0 +1,229 @@
+package noveltools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"lemon/internal/model/novel"
+)
+
+// maxReportedConsistencyIssues 校验报告文本中最多列出的问题数（完整列表见 Issues）
+const maxReportedConsistencyIssues = 20
+
+// NarrationConsistencyIssue 剧本 JSON 与落库的场景/镜头之间的一处不一致
+type NarrationConsistencyIssue struct {
+	SceneNumber string `json:"scene_number,omitempty"` // 场景编号（整体问题为空）
+	ShotNumber  string `json:"shot_number,omitempty"`  // 镜头编号（场景级问题为空）
+	Message     string `json:"message"`                // 问题描述
+}
+
+// String 返回问题的可读描述
+func (i NarrationConsistencyIssue) String() string {
+	switch {
+	case i.SceneNumber != "" && i.ShotNumber != "":
+		return fmt.Sprintf("场景%s镜头%s：%s", i.SceneNumber, i.ShotNumber, i.Message)
+	case i.SceneNumber != "":
+		return fmt.Sprintf("场景%s：%s", i.SceneNumber, i.Message)
+	default:
+		return i.Message
+	}
+}
+
+// NarrationConsistencyReport 剧本落库校验报告
+type NarrationConsistencyReport struct {
+	ExpectedScenes  int                         `json:"expected_scenes"`  // JSON 中的场景数（包含空场景）
+	PersistedScenes int                         `json:"persisted_scenes"` // 落库的场景数
+	ExpectedShots   int                         `json:"expected_shots"`   // JSON 中的镜头数（包含空镜头）
+	PersistedShots  int                         `json:"persisted_shots"`  // 落库的镜头数
+	Issues          []NarrationConsistencyIssue `json:"issues,omitempty"` // 不一致的问题列表
+}
+
+// OK 是否一致（没有任何问题）
+func (r *NarrationConsistencyReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// String 返回报告的可读描述（用作解说失败原因）
+func (r *NarrationConsistencyReport) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("剧本落库校验失败：JSON 有 %d 个场景、%d 个镜头，落库 %d 个场景、%d 个镜头，共 %d 个问题",
+		r.ExpectedScenes, r.ExpectedShots, r.PersistedScenes, r.PersistedShots, len(r.Issues)))
+	for i, issue := range r.Issues {
+		if i == maxReportedConsistencyIssues {
+			sb.WriteString(fmt.Sprintf("；……（其余 %d 个问题省略）", len(r.Issues)-i))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("；%d) %s", i+1, issue))
+	}
+	return sb.String()
+}
+
+// CheckNarrationConsistency 校验落库的场景/镜头与解析出的剧本 JSON 是否一致
+// 检查内容：
+//  1. 场景和镜头数量一致，JSON 中的空场景/空镜头视为未落库；
+//  2. JSON 中的每个场景、镜头都已落库，落库数据中没有 JSON 之外或重复的场景、镜头；
+//  3. 编号连续：场景顺序为 1..N，场景内镜头顺序为 1..k，镜头全局索引为 1..M；
+//  4. 镜头图片提示词非空（图片阶段依赖）
+func CheckNarrationConsistency(content *NarrationJSONContent, scenes []*novel.Scene, shots []*novel.Shot) *NarrationConsistencyReport {
+	report := &NarrationConsistencyReport{
+		PersistedScenes: len(scenes),
+		PersistedShots:  len(shots),
+	}
+	addIssue := func(sceneNumber, shotNumber, format string, args ...interface{}) {
+		report.Issues = append(report.Issues, NarrationConsistencyIssue{
+			SceneNumber: sceneNumber,
+			ShotNumber:  shotNumber,
+			Message:     fmt.Sprintf(format, args...),
+		})
+	}
+
+	var jsonScenes []*NarrationJSONScene
+	if content != nil {
+		jsonScenes = content.Scenes
+	}
+	report.ExpectedScenes = len(jsonScenes)
+	for _, jsonScene := range jsonScenes {
+		if jsonScene != nil {
+			report.ExpectedShots += len(jsonScene.Shots)
+		}
+	}
+
+	if report.ExpectedScenes != report.PersistedScenes {
+		addIssue("", "", "场景数量不一致：JSON 有 %d 个，落库 %d 个", report.ExpectedScenes, report.PersistedScenes)
+	}
+	if report.ExpectedShots != report.PersistedShots {
+		addIssue("", "", "镜头数量不一致：JSON 有 %d 个，落库 %d 个", report.ExpectedShots, report.PersistedShots)
+	}
+
+	// 落库的场景按编号索引
+	scenesByNumber := make(map[string]*novel.Scene, len(scenes))
+	for _, scene := range scenes {
+		if _, exists := scenesByNumber[scene.SceneNumber]; exists {
+			addIssue(scene.SceneNumber, "", "场景重复落库")
+			continue
+		}
+		scenesByNumber[scene.SceneNumber] = scene
+	}
+
+	// 落库的镜头按场景分组
+	shotsByScene := make(map[string][]*novel.Shot)
+	sceneIDs := make(map[string]bool, len(scenes))
+	for _, scene := range scenes {
+		sceneIDs[scene.ID] = true
+	}
+	for _, shot := range shots {
+		if !sceneIDs[shot.SceneID] {
+			addIssue(shot.SceneNumber, shot.ShotNumber, "镜头所属的场景未落库")
+			continue
+		}
+		shotsByScene[shot.SceneID] = append(shotsByScene[shot.SceneID], shot)
+	}
+
+	// 逐个场景对比
+	expectedSceneNumbers := make(map[string]bool, len(jsonScenes))
+	for i, jsonScene := range jsonScenes {
+		if jsonScene == nil {
+			addIssue("", "", "第%d个场景为空，未能落库", i+1)
+			continue
+		}
+		expectedSceneNumbers[jsonScene.SceneNumber] = true
+
+		scene, ok := scenesByNumber[jsonScene.SceneNumber]
+		if !ok {
+			addIssue(jsonScene.SceneNumber, "", "场景未落库")
+			continue
+		}
+		checkSceneShots(jsonScene, shotsByScene[scene.ID], addIssue)
+	}
+	for _, scene := range scenes {
+		if !expectedSceneNumbers[scene.SceneNumber] {
+			addIssue(scene.SceneNumber, "", "落库的场景不在剧本 JSON 中")
+		}
+	}
+
+	// 场景顺序连续
+	sequences := make([]int, 0, len(scenes))
+	for _, scene := range scenes {
+		sequences = append(sequences, scene.Sequence)
+	}
+	if gap := sequenceGap(sequences); gap != "" {
+		addIssue("", "", "场景顺序不连续：%s", gap)
+	}
+
+	// 镜头全局索引连续
+	indexes := make([]int, 0, len(shots))
+	for _, shot := range shots {
+		indexes = append(indexes, shot.Index)
+	}
+	if gap := sequenceGap(indexes); gap != "" {
+		addIssue("", "", "镜头全局索引不连续：%s", gap)
+	}
+
+	return report
+}
+
+// checkSceneShots 对比单个场景的镜头
+func checkSceneShots(jsonScene *NarrationJSONScene, shots []*novel.Shot, addIssue func(sceneNumber, shotNumber, format string, args ...interface{})) {
+	sceneNumber := jsonScene.SceneNumber
+	if len(jsonScene.Shots) != len(shots) {
+		addIssue(sceneNumber, "", "镜头数量不一致：JSON 有 %d 个，落库 %d 个", len(jsonScene.Shots), len(shots))
+	}
+
+	shotsByNumber := make(map[string]*novel.Shot, len(shots))
+	for _, shot := range shots {
+		if _, exists := shotsByNumber[shot.ShotNumber]; exists {
+			addIssue(sceneNumber, shot.ShotNumber, "镜头重复落库")
+			continue
+		}
+		shotsByNumber[shot.ShotNumber] = shot
+	}
+
+	expectedShotNumbers := make(map[string]bool, len(jsonScene.Shots))
+	for i, jsonShot := range jsonScene.Shots {
+		if jsonShot == nil {
+			addIssue(sceneNumber, "", "第%d个镜头为空，未能落库", i+1)
+			continue
+		}
+		expectedShotNumbers[jsonShot.CloseupNumber] = true
+		shot, ok := shotsByNumber[jsonShot.CloseupNumber]
+		if !ok {
+			addIssue(sceneNumber, jsonShot.CloseupNumber, "镜头未落库")
+			continue
+		}
+		if strings.TrimSpace(shot.ImagePrompt) == "" {
+			addIssue(sceneNumber, shot.ShotNumber, "图片提示词为空")
+		}
+	}
+	for _, shot := range shots {
+		if !expectedShotNumbers[shot.ShotNumber] {
+			addIssue(sceneNumber, shot.ShotNumber, "落库的镜头不在剧本 JSON 中")
+		}
+	}
+
+	sequences := make([]int, 0, len(shots))
+	for _, shot := range shots {
+		sequences = append(sequences, shot.Sequence)
+	}
+	if gap := sequenceGap(sequences); gap != "" {
+		addIssue(sceneNumber, "", "镜头顺序不连续：%s", gap)
+	}
+}
+
+// sequenceGap 检查序号是否恰好为 1..n，不是时返回第一处问题的描述
+func sequenceGap(values []int) string {
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	for i, v := range sorted {
+		want := i + 1
+		switch {
+		case v == want:
+			continue
+		case i > 0 && v == sorted[i-1]:
+			return fmt.Sprintf("序号 %d 重复", v)
+		default:
+			return fmt.Sprintf("期望序号 %d，实际为 %d", want, v)
+		}
+	}
+	return ""
+}
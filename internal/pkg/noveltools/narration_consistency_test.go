@@ -0,0 +1,103 @@
+package noveltools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func consistencyFixture() *NarrationJSONContent {
+	return &NarrationJSONContent{
+		Scenes: []*NarrationJSONScene{
+			{
+				SceneNumber: "1",
+				Shots: []*NarrationJSONShot{
+					{CloseupNumber: "1", ImagePrompt: "雪夜山门"},
+					{CloseupNumber: "2", ImagePrompt: "少年持剑"},
+				},
+			},
+			{
+				SceneNumber: "2",
+				Shots: []*NarrationJSONShot{
+					{CloseupNumber: "1", ImagePrompt: "长街灯火"},
+				},
+			},
+		},
+	}
+}
+
+func TestCheckNarrationConsistency(t *testing.T) {
+	Convey("CheckNarrationConsistency 校验落库数据与剧本 JSON 一致", t, func() {
+		Convey("按转换结果落库时一致", func() {
+			content := consistencyFixture()
+			scenes, shots, _, _, err := ConvertToScenesAndShots("n1", "c1", "novel1", "u1", 1, content)
+			So(err, ShouldBeNil)
+
+			report := CheckNarrationConsistency(content, scenes, shots)
+			So(report.OK(), ShouldBeTrue)
+			So(report.ExpectedScenes, ShouldEqual, 2)
+			So(report.ExpectedShots, ShouldEqual, 3)
+			So(report.PersistedShots, ShouldEqual, 3)
+		})
+
+		Convey("空镜头被丢弃时报告数量和顺序问题", func() {
+			content := consistencyFixture()
+			content.Scenes[0].Shots = append([]*NarrationJSONShot{nil}, content.Scenes[0].Shots...)
+			scenes, shots, _, _, err := ConvertToScenesAndShots("n1", "c1", "novel1", "u1", 1, content)
+			So(err, ShouldBeNil)
+
+			report := CheckNarrationConsistency(content, scenes, shots)
+			So(report.OK(), ShouldBeFalse)
+			messages := report.String()
+			So(messages, ShouldContainSubstring, "镜头数量不一致：JSON 有 4 个，落库 3 个")
+			So(messages, ShouldContainSubstring, "场景1：第1个镜头为空，未能落库")
+			So(messages, ShouldContainSubstring, "场景1：镜头顺序不连续：期望序号 1，实际为 2")
+		})
+
+		Convey("镜头未落库时报告缺失和全局索引断档", func() {
+			content := consistencyFixture()
+			scenes, shots, _, _, err := ConvertToScenesAndShots("n1", "c1", "novel1", "u1", 1, content)
+			So(err, ShouldBeNil)
+			shots = append(shots[:1], shots[2:]...)
+
+			report := CheckNarrationConsistency(content, scenes, shots)
+			So(report.OK(), ShouldBeFalse)
+			messages := report.String()
+			So(messages, ShouldContainSubstring, "场景1镜头2：镜头未落库")
+			So(messages, ShouldContainSubstring, "镜头全局索引不连续：期望序号 2，实际为 3")
+		})
+
+		Convey("场景未落库且顺序重复", func() {
+			content := consistencyFixture()
+			scenes, shots, _, _, err := ConvertToScenesAndShots("n1", "c1", "novel1", "u1", 1, content)
+			So(err, ShouldBeNil)
+			scenes[1].Sequence = 1
+			scenes[1].SceneNumber = "3"
+
+			report := CheckNarrationConsistency(content, scenes, shots)
+			messages := report.String()
+			So(messages, ShouldContainSubstring, "场景2：场景未落库")
+			So(messages, ShouldContainSubstring, "场景3：落库的场景不在剧本 JSON 中")
+			So(messages, ShouldContainSubstring, "场景顺序不连续：序号 1 重复")
+		})
+
+		Convey("图片提示词为空", func() {
+			content := consistencyFixture()
+			content.Scenes[1].Shots[0].ImagePrompt = " "
+			scenes, shots, _, _, err := ConvertToScenesAndShots("n1", "c1", "novel1", "u1", 1, content)
+			So(err, ShouldBeNil)
+
+			report := CheckNarrationConsistency(content, scenes, shots)
+			So(report.Issues, ShouldHaveLength, 1)
+			So(report.Issues[0].String(), ShouldEqual, "场景2镜头1：图片提示词为空")
+		})
+
+		Convey("问题过多时报告文本只列出前面的问题", func() {
+			report := &NarrationConsistencyReport{}
+			for i := 0; i < maxReportedConsistencyIssues+3; i++ {
+				report.Issues = append(report.Issues, NarrationConsistencyIssue{Message: "问题"})
+			}
+			So(report.String(), ShouldEndWith, "……（其余 3 个问题省略）")
+		})
+	})
+}
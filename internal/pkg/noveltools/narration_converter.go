@@ -77,57 +77,80 @@ func ConvertToScenesAndShots(
 			continue
 		}
 
-		// 创建 Scene 实体
-		sceneID := fmt.Sprintf("%s-scene-%s-v%d", narrationID, jsonScene.SceneNumber, version)
-		scene := &novel.Scene{
-			ID:          sceneID,
-			NarrationID: narrationID,
-			ChapterID:   chapterID,
-			NovelID:     novelID,
-			UserID:      userID,
-			SceneNumber: jsonScene.SceneNumber,
-			Description: jsonScene.Description,
-			ImagePrompt: jsonScene.ImagePrompt,
-			Narration:   jsonScene.Narration,
-			Sequence:    sceneSeq + 1, // 从1开始
-			Version:     version,
-			Status:      novel.TaskStatusCompleted,
-		}
+		scene, sceneShots := ConvertScene(narrationID, chapterID, novelID, userID, version, sceneSeq+1, globalShotIndex, jsonScene)
 		scenes = append(scenes, scene)
+		shots = append(shots, sceneShots...)
+		globalShotIndex += len(sceneShots)
+	}
+
+	return scenes, shots, characters, props, nil
+}
+
+// ConvertScene 将单个 JSON 场景转换为 Scene 及其下属 Shot 实体
+// 用于流式生成时逐个场景落库；sequence 为场景在解说中的顺序（从1开始），
+// startShotIndex 为该场景第一个镜头的全局索引（从1开始）
+func ConvertScene(
+	narrationID string,
+	chapterID string,
+	novelID string,
+	userID string,
+	version int,
+	sequence int,
+	startShotIndex int,
+	jsonScene *NarrationJSONScene,
+) (*novel.Scene, []*novel.Shot) {
+	// 创建 Scene 实体
+	sceneID := fmt.Sprintf("%s-scene-%s-v%d", narrationID, jsonScene.SceneNumber, version)
+	scene := &novel.Scene{
+		ID:          sceneID,
+		NarrationID: narrationID,
+		ChapterID:   chapterID,
+		NovelID:     novelID,
+		UserID:      userID,
+		SceneNumber: jsonScene.SceneNumber,
+		Description: jsonScene.Description,
+		ImagePrompt: jsonScene.ImagePrompt,
+		Narration:   jsonScene.Narration,
+		Mood:        NormalizeSceneMood(jsonScene.Mood),
+		Sequence:    sequence,
+		Version:     version,
+		Status:      novel.TaskStatusCompleted,
+	}
 
-		// 创建该场景下的所有 Shot 实体
-		for shotSeq, jsonShot := range jsonScene.Shots {
-			if jsonShot == nil {
-				continue
-			}
+	// 创建该场景下的所有 Shot 实体
+	var shots []*novel.Shot
+	globalShotIndex := startShotIndex
+	for shotSeq, jsonShot := range jsonScene.Shots {
+		if jsonShot == nil {
+			continue
+		}
 
-			shotID := fmt.Sprintf("%s-shot-%s-%s-v%d", narrationID, jsonScene.SceneNumber, jsonShot.CloseupNumber, version)
-			shot := &novel.Shot{
-				ID:          shotID,
-				SceneID:     sceneID,
-				SceneNumber: jsonScene.SceneNumber,
-				NarrationID: narrationID,
-				ChapterID:   chapterID,
-				NovelID:     novelID,
-				UserID:      userID,
-				ShotNumber:     jsonShot.CloseupNumber,
-				Character:      jsonShot.Character,
-				Image:          jsonShot.Image,
-				Narration:      jsonShot.Narration,
-				SoundEffect:    jsonShot.SoundEffect,
-				Duration:       jsonShot.Duration,
-				ImagePrompt:    jsonShot.ImagePrompt,
-				VideoPrompt:    jsonShot.VideoPrompt,
-				CameraMovement: jsonShot.CameraMovement,
-				Sequence:       shotSeq + 1,     // 在场景中的顺序，从1开始
-				Index:          globalShotIndex, // 全局索引
-				Version:        version,
-				Status:         novel.TaskStatusCompleted,
-			}
-			shots = append(shots, shot)
-			globalShotIndex++
+		shotID := fmt.Sprintf("%s-shot-%s-%s-v%d", narrationID, jsonScene.SceneNumber, jsonShot.CloseupNumber, version)
+		shot := &novel.Shot{
+			ID:             shotID,
+			SceneID:        sceneID,
+			SceneNumber:    jsonScene.SceneNumber,
+			NarrationID:    narrationID,
+			ChapterID:      chapterID,
+			NovelID:        novelID,
+			UserID:         userID,
+			ShotNumber:     jsonShot.CloseupNumber,
+			Character:      jsonShot.Character,
+			Image:          jsonShot.Image,
+			Narration:      jsonShot.Narration,
+			SoundEffect:    jsonShot.SoundEffect,
+			Duration:       jsonShot.Duration,
+			ImagePrompt:    jsonShot.ImagePrompt,
+			VideoPrompt:    jsonShot.VideoPrompt,
+			CameraMovement: jsonShot.CameraMovement,
+			Sequence:       shotSeq + 1,     // 在场景中的顺序，从1开始
+			Index:          globalShotIndex, // 全局索引
+			Version:        version,
+			Status:         novel.TaskStatusCompleted,
 		}
+		shots = append(shots, shot)
+		globalShotIndex++
 	}
 
-	return scenes, shots, characters, props, nil
+	return scene, shots
 }
@@ -0,0 +1,113 @@
+package noveltools
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/errclass"
+)
+
+// SceneToJSON 将已落库的场景及其镜头还原为 JSON 场景结构（用于基于已有版本重新生成）
+// shots 可以包含其他场景的镜头，只取 SceneID 匹配的镜头并按场景内顺序排列
+func SceneToJSON(scene *novel.Scene, shots []*novel.Shot) *NarrationJSONScene {
+	jsonScene := &NarrationJSONScene{
+		SceneNumber: scene.SceneNumber,
+		Description: scene.Description,
+		ImagePrompt: scene.ImagePrompt,
+		Narration:   scene.Narration,
+		Mood:        string(scene.Mood),
+	}
+
+	var sceneShots []*novel.Shot
+	for _, shot := range shots {
+		if shot != nil && shot.SceneID == scene.ID {
+			sceneShots = append(sceneShots, shot)
+		}
+	}
+	sort.SliceStable(sceneShots, func(i, j int) bool {
+		return sceneShots[i].Sequence < sceneShots[j].Sequence
+	})
+
+	for _, shot := range sceneShots {
+		jsonScene.Shots = append(jsonScene.Shots, &NarrationJSONShot{
+			CloseupNumber:  shot.ShotNumber,
+			Character:      shot.Character,
+			Image:          shot.Image,
+			Narration:      shot.Narration,
+			SoundEffect:    shot.SoundEffect,
+			Duration:       shot.Duration,
+			ImagePrompt:    shot.ImagePrompt,
+			VideoPrompt:    shot.VideoPrompt,
+			CameraMovement: shot.CameraMovement,
+		})
+	}
+	return jsonScene
+}
+
+// BuildSceneFeedbackPrompt 构造按审核意见重写单个场景的提示词
+// chapterContent 为章节原文，scene 为当前版本的场景，feedback 为审核人的修改意见
+func BuildSceneFeedbackPrompt(chapterContent string, scene *NarrationJSONScene, feedback string) (string, error) {
+	if scene == nil {
+		return "", fmt.Errorf("scene is required")
+	}
+	feedback = strings.TrimSpace(feedback)
+	if feedback == "" {
+		return "", fmt.Errorf("feedback is empty")
+	}
+
+	sceneJSON, err := json.MarshalIndent(scene, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal scene: %w", err)
+	}
+
+	return fmt.Sprintf(`你是一名短视频解说剧本编辑。请根据审核意见修改下面的场景剧本。
+
+【章节原文】
+%s
+
+【当前场景剧本】
+%s
+
+【审核意见】
+%s
+
+要求：
+1. 严格按照审核意见修改，审核意见未涉及的内容尽量保持不变
+2. 保持场景编号 scene_number 为 "%s" 不变，镜头编号从 1 开始连续编号
+3. 返回的 JSON 结构与当前场景剧本一致（scene_number、description、image_prompt、narration、shots）
+4. 只返回一个场景的 JSON 对象，不要其他文字，确保 JSON 格式正确，可以直接解析`,
+		strings.TrimSpace(chapterContent),
+		string(sceneJSON),
+		feedback,
+		scene.SceneNumber,
+	), nil
+}
+
+// ParseFeedbackScene 解析 LLM 按审核意见重写后返回的单个场景 JSON
+// sceneNumber 为目标场景编号，LLM 返回的编号会被强制改回该编号
+func ParseFeedbackScene(content string, sceneNumber string) (*NarrationJSONScene, error) {
+	var scene NarrationJSONScene
+	if err := json.Unmarshal([]byte(CleanJSONContent(content)), &scene); err != nil {
+		return nil, errclass.Wrap(errclass.ValidationSchema, "", fmt.Errorf("parse scene json: %w", err))
+	}
+
+	var shots []*NarrationJSONShot
+	for _, shot := range scene.Shots {
+		if shot == nil || strings.TrimSpace(shot.Narration) == "" {
+			continue
+		}
+		shots = append(shots, shot)
+	}
+	if len(shots) == 0 {
+		return nil, fmt.Errorf("scene %s has no shots", sceneNumber)
+	}
+	for i, shot := range shots {
+		shot.CloseupNumber = fmt.Sprintf("%d", i+1)
+	}
+	scene.Shots = shots
+	scene.SceneNumber = sceneNumber
+	return &scene, nil
+}
@@ -0,0 +1,73 @@
+package noveltools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestSceneToJSON(t *testing.T) {
+	Convey("SceneToJSON 将落库的场景和镜头还原为 JSON 场景", t, func() {
+		scene := &novel.Scene{ID: "s1", SceneNumber: "1", Description: "山门", ImagePrompt: "雪中山门"}
+		shots := []*novel.Shot{
+			{SceneID: "s1", ShotNumber: "2", Narration: "长老走来", Sequence: 2, Duration: 3},
+			{SceneID: "s2", ShotNumber: "1", Narration: "其他场景", Sequence: 1},
+			{SceneID: "s1", ShotNumber: "1", Narration: "林凡握剑", Sequence: 1, Duration: 2},
+		}
+
+		got := SceneToJSON(scene, shots)
+		So(got.SceneNumber, ShouldEqual, "1")
+		So(got.Description, ShouldEqual, "山门")
+		So(got.Shots, ShouldHaveLength, 2)
+		So(got.Shots[0].Narration, ShouldEqual, "林凡握剑")
+		So(got.Shots[1].Narration, ShouldEqual, "长老走来")
+		So(got.Shots[1].Duration, ShouldEqual, 3)
+	})
+}
+
+func TestBuildSceneFeedbackPrompt(t *testing.T) {
+	Convey("BuildSceneFeedbackPrompt 构造按审核意见重写场景的提示词", t, func() {
+		scene := &NarrationJSONScene{SceneNumber: "3", Shots: []*NarrationJSONShot{{CloseupNumber: "1", Narration: "林凡怒吼"}}}
+
+		Convey("提示词包含原文、当前场景和审核意见", func() {
+			prompt, err := BuildSceneFeedbackPrompt("章节原文内容", scene, "  少一些煽情，对白保持原文  ")
+			So(err, ShouldBeNil)
+			So(prompt, ShouldContainSubstring, "章节原文内容")
+			So(prompt, ShouldContainSubstring, "林凡怒吼")
+			So(prompt, ShouldContainSubstring, "少一些煽情，对白保持原文")
+			So(prompt, ShouldContainSubstring, `scene_number 为 "3"`)
+		})
+
+		Convey("审核意见为空时返回错误", func() {
+			_, err := BuildSceneFeedbackPrompt("原文", scene, " ")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestParseFeedbackScene(t *testing.T) {
+	Convey("ParseFeedbackScene 解析重写后的场景", t, func() {
+		Convey("去掉空镜头，重排镜头编号并固定场景编号", func() {
+			content := "```json\n" + `{"scene_number":"9","description":"山门","shots":[{"closeup_number":"5","narration":"林凡握剑"},{"closeup_number":"6","narration":""},{"closeup_number":"7","narration":"长老走来"}]}` + "\n```"
+			scene, err := ParseFeedbackScene(content, "3")
+			So(err, ShouldBeNil)
+			So(scene.SceneNumber, ShouldEqual, "3")
+			So(scene.Shots, ShouldHaveLength, 2)
+			So(scene.Shots[0].CloseupNumber, ShouldEqual, "1")
+			So(scene.Shots[1].CloseupNumber, ShouldEqual, "2")
+			So(scene.Shots[1].Narration, ShouldEqual, "长老走来")
+		})
+
+		Convey("没有镜头时返回错误", func() {
+			_, err := ParseFeedbackScene(`{"scene_number":"3","shots":[]}`, "3")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("非法 JSON 返回错误", func() {
+			_, err := ParseFeedbackScene("不是 JSON", "3")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/errclass"
 )
 
 // CleanJSONContent 清理 LLM 返回的 JSON 内容（公开函数）
@@ -73,6 +76,7 @@ type NarrationJSONScene struct {
 	Description string               `json:"description"`         // 场景详细描述
 	ImagePrompt string               `json:"image_prompt"`        // 场景图片提示词
 	Narration   string               `json:"narration,omitempty"` // 场景级别的解说内容（可选）
+	Mood        string               `json:"mood,omitempty"`      // 场景情绪（用于选择背景音乐，通过 NormalizeSceneMood 归一化）
 	Shots       []*NarrationJSONShot `json:"shots"`
 }
 
@@ -116,16 +120,34 @@ func ValidateNarrationJSON(jsonContent string, minLength, maxLength int) (*Narra
 		return nil, result
 	}
 
+	result = ValidateNarrationContent(&content, DefaultNarrationStructure(), minLength, maxLength)
+	if !result.IsValid {
+		return nil, result
+	}
+	return &content, result
+}
+
+// ValidateNarrationContent 验证已解析的解说文案（也用于校验从场景和镜头还原的解说）
+// structure 为小说的解说结构，场景数和每个场景的镜头数不符合时给出提示；会合并相邻的近似重复镜头，content 可能被修改
+func ValidateNarrationContent(content *NarrationJSONContent, structure novel.NarrationStructure, minLength, maxLength int) *ValidationResult {
+	result := &ValidationResult{
+		IsValid:  true,
+		Warnings: make([]string, 0),
+	}
+
 	// 验证基本结构
 	if len(content.Scenes) == 0 {
 		result.IsValid = false
 		result.Message = "缺少 scenes 字段或 scenes 为空"
-		return nil, result
+		return result
 	}
 
-	if len(content.Scenes) < 7 {
-		result.Warnings = append(result.Warnings,
-			fmt.Sprintf("分镜数量不足，期望至少7个，实际%d个，但继续生成", len(content.Scenes)))
+	result.Warnings = append(result.Warnings, NarrationStructureWarnings(content, structure)...)
+
+	// 合并相邻的近似重复镜头（LLM 偶尔会输出旁白和提示词几乎相同的连续镜头，浪费生成费用）
+	result.MergedShots = DedupNarrationShots(content, DefaultShotDedupThreshold)
+	for _, merge := range result.MergedShots {
+		result.Warnings = append(result.Warnings, merge.String())
 	}
 
 	// 提取所有解说内容并统计字数
@@ -205,7 +227,8 @@ func ValidateNarrationJSON(jsonContent string, minLength, maxLength int) (*Narra
 
 	result.Message = "验证通过"
 	result.TotalLength = explanationLength
-	return &content, result
+	result.Pacing = AnalyzeNarrationPacing(content)
+	return result
 }
 
 // countChineseCharacters 计算中文字符数量
@@ -227,7 +250,7 @@ func ParseNarrationJSON(jsonContent string) (*NarrationJSONContent, error) {
 	// 使用验证函数来解析和验证 JSON
 	content, validationResult := ValidateNarrationJSON(jsonContent, 1100, 1300)
 	if !validationResult.IsValid {
-		return nil, fmt.Errorf("narration validation failed: %s", validationResult.Message)
+		return nil, errclass.Wrap(errclass.ValidationSchema, "", fmt.Errorf("narration validation failed: %s", validationResult.Message))
 	}
 	return content, nil
 }
@@ -0,0 +1,283 @@
+package noveltools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/errclass"
+)
+
+const (
+	// PacingSlowThreshold 节奏分低于该值的场景视为慢节奏
+	PacingSlowThreshold = 40.0
+	// PacingMaxSlowRun 连续慢节奏场景达到该数量时警告
+	PacingMaxSlowRun = 3
+
+	// pacingMinScenes 判断节奏平淡、前重后轻所需的最少场景数
+	pacingMinScenes = 4
+	// pacingFlatDeviation 各场景节奏分的标准差低于该值时视为节奏平淡
+	pacingFlatDeviation = 8.0
+	// pacingFrontLoadGap 前三分之一场景的平均节奏分比后三分之一高出该值时视为前重后轻
+	pacingFrontLoadGap = 15.0
+)
+
+// pacingMoodScores 各场景情绪的基础节奏分（未标注情绪按 calm 处理）
+var pacingMoodScores = map[novel.SceneMood]float64{
+	novel.SceneMoodBattle:   85,
+	novel.SceneMoodTense:    75,
+	novel.SceneMoodMystery:  60,
+	novel.SceneMoodJoyful:   50,
+	novel.SceneMoodSad:      45,
+	novel.SceneMoodRomantic: 35,
+	novel.SceneMoodCalm:     25,
+}
+
+// pacingCueWords 表示情节推进、冲突或转折的词，旁白中出现越多节奏越快
+var pacingCueWords = []string{
+	"突然", "猛地", "瞬间", "竟然", "居然", "刹那", "轰", "怒", "杀", "冲", "爆", "吼",
+	"震惊", "危机", "鲜血", "反转", "逃", "追", "拼命", "生死", "终于", "没想到",
+}
+
+// ScenePacing 单个场景的节奏评分
+type ScenePacing struct {
+	SceneNumber string          `json:"scene_number"` // 场景编号
+	Mood        novel.SceneMood `json:"mood"`         // 归一化后的场景情绪
+	Score       float64         `json:"score"`        // 节奏分（0-100，越高节奏越快、情绪越强）
+	Slow        bool            `json:"slow"`         // 是否慢节奏场景
+}
+
+// PacingRun 连续的慢节奏场景
+type PacingRun struct {
+	StartScene string `json:"start_scene"` // 起始场景编号
+	EndScene   string `json:"end_scene"`   // 结束场景编号
+	Count      int    `json:"count"`       // 场景数量
+}
+
+// PacingAnalysis 解说文案的节奏/情绪曲线分析
+type PacingAnalysis struct {
+	Scenes      []ScenePacing `json:"scenes"`       // 按场景顺序排列的节奏评分
+	Average     float64       `json:"average"`      // 平均节奏分
+	Deviation   float64       `json:"deviation"`    // 节奏分标准差（越小起伏越小）
+	Flat        bool          `json:"flat"`         // 节奏平淡（起伏过小）
+	FrontLoaded bool          `json:"front_loaded"` // 前重后轻（高潮集中在开头，后段乏力）
+	SlowRuns    []PacingRun   `json:"slow_runs"`    // 连续慢节奏场景
+	Warnings    []string      `json:"warnings"`     // 节奏警告
+}
+
+// HasIssues 是否存在节奏问题
+func (p *PacingAnalysis) HasIssues() bool {
+	return p != nil && len(p.Warnings) > 0
+}
+
+// AnalyzeNarrationPacing 根据场景情绪和旁白文本为每个场景打节奏分，检查节奏平淡、前重后轻和连续慢节奏场景
+// 节奏分 = 情绪基础分与旁白文本强度各占一半；文本强度由冲突/转折词、感叹和疑问句比例、句子长短计算
+func AnalyzeNarrationPacing(content *NarrationJSONContent) *PacingAnalysis {
+	analysis := &PacingAnalysis{
+		Scenes:   []ScenePacing{},
+		SlowRuns: []PacingRun{},
+		Warnings: []string{},
+	}
+	if content == nil {
+		return analysis
+	}
+
+	for _, scene := range content.Scenes {
+		if scene == nil {
+			continue
+		}
+		mood := NormalizeSceneMood(scene.Mood)
+		if mood == "" {
+			mood = novel.SceneMoodCalm
+		}
+		score := math.Round((pacingMoodScores[mood]+pacingTextIntensity(sceneNarrationText(scene)))/2*10) / 10
+		analysis.Scenes = append(analysis.Scenes, ScenePacing{
+			SceneNumber: scene.SceneNumber,
+			Mood:        mood,
+			Score:       score,
+			Slow:        score < PacingSlowThreshold,
+		})
+	}
+	if len(analysis.Scenes) == 0 {
+		return analysis
+	}
+
+	scores := make([]float64, len(analysis.Scenes))
+	for i, sp := range analysis.Scenes {
+		scores[i] = sp.Score
+	}
+	analysis.Average = math.Round(meanFloat(scores)*10) / 10
+	analysis.Deviation = math.Round(stddevFloat(scores)*10) / 10
+
+	// 连续慢节奏场景
+	runStart := -1
+	for i := 0; i <= len(analysis.Scenes); i++ {
+		if i < len(analysis.Scenes) && analysis.Scenes[i].Slow {
+			if runStart < 0 {
+				runStart = i
+			}
+			continue
+		}
+		if runStart >= 0 && i-runStart >= PacingMaxSlowRun {
+			run := PacingRun{
+				StartScene: analysis.Scenes[runStart].SceneNumber,
+				EndScene:   analysis.Scenes[i-1].SceneNumber,
+				Count:      i - runStart,
+			}
+			analysis.SlowRuns = append(analysis.SlowRuns, run)
+			analysis.Warnings = append(analysis.Warnings,
+				fmt.Sprintf("场景%s-%s连续%d个慢节奏场景，建议压缩或加入冲突", run.StartScene, run.EndScene, run.Count))
+		}
+		runStart = -1
+	}
+
+	if len(scores) >= pacingMinScenes {
+		if analysis.Deviation < pacingFlatDeviation {
+			analysis.Flat = true
+			analysis.Warnings = append(analysis.Warnings,
+				fmt.Sprintf("节奏平淡，各场景节奏分标准差%.1f，缺少起伏", analysis.Deviation))
+		}
+
+		third := len(scores) / 3
+		head, tail := meanFloat(scores[:third]), meanFloat(scores[len(scores)-third:])
+		if head-tail >= pacingFrontLoadGap {
+			analysis.FrontLoaded = true
+			analysis.Warnings = append(analysis.Warnings,
+				fmt.Sprintf("节奏前重后轻，前段平均节奏分%.1f，后段%.1f，结尾缺少高潮或悬念", head, tail))
+		}
+	}
+	return analysis
+}
+
+// sceneNarrationText 场景级解说和各镜头旁白拼接后的文本
+func sceneNarrationText(scene *NarrationJSONScene) string {
+	var b strings.Builder
+	b.WriteString(scene.Narration)
+	for _, shot := range scene.Shots {
+		if shot != nil {
+			b.WriteString(shot.Narration)
+		}
+	}
+	return b.String()
+}
+
+// pacingTextIntensity 旁白文本强度（0-100）
+// 冲突/转折词占 40%，感叹和疑问句比例占 30%，句子越短分越高占 30%
+func pacingTextIntensity(text string) float64 {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0
+	}
+
+	cues := 0
+	for _, word := range pacingCueWords {
+		cues += strings.Count(text, word)
+	}
+	cueScore := math.Min(float64(cues)*20, 100)
+
+	sentences := strings.FieldsFunc(text, func(r rune) bool {
+		return strings.ContainsRune("。！？!?；;…\n", r)
+	})
+	if len(sentences) == 0 {
+		return cueScore * 0.4
+	}
+	marks := strings.Count(text, "！") + strings.Count(text, "!") + strings.Count(text, "？") + strings.Count(text, "?")
+	markScore := math.Min(float64(marks)/float64(len(sentences))*100, 100)
+
+	avgLen := float64(len([]rune(strings.Join(sentences, "")))) / float64(len(sentences))
+	lengthScore := math.Max(0, math.Min((30-avgLen)/20*100, 100))
+
+	return cueScore*0.4 + markScore*0.3 + lengthScore*0.3
+}
+
+func meanFloat(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func stddevFloat(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	mean := meanFloat(values)
+	sum := 0.0
+	for _, v := range values {
+		sum += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sum / float64(len(values)))
+}
+
+// PacingSuggestion LLM 给出的场景压缩建议
+type PacingSuggestion struct {
+	SceneNumber string `json:"scene_number"` // 场景编号
+	Reason      string `json:"reason"`       // 节奏问题
+	Suggestion  string `json:"suggestion"`   // 修改建议（压缩、合并、加入冲突等）
+}
+
+// BuildPacingSuggestionPrompt 构造让 LLM 指出需要压缩的场景的提示词
+// 只把各场景的旁白和节奏分交给 LLM，不包含图片/视频提示词
+func BuildPacingSuggestionPrompt(content *NarrationJSONContent, analysis *PacingAnalysis) (string, error) {
+	if content == nil || analysis == nil || len(analysis.Scenes) == 0 {
+		return "", fmt.Errorf("pacing analysis is empty")
+	}
+
+	scores := make(map[string]ScenePacing, len(analysis.Scenes))
+	for _, sp := range analysis.Scenes {
+		scores[sp.SceneNumber] = sp
+	}
+	var scenes strings.Builder
+	for _, scene := range content.Scenes {
+		if scene == nil {
+			continue
+		}
+		sp := scores[scene.SceneNumber]
+		fmt.Fprintf(&scenes, "场景%s（情绪：%s，节奏分：%.1f）：%s\n",
+			scene.SceneNumber, sp.Mood, sp.Score, sceneNarrationText(scene))
+	}
+
+	return fmt.Sprintf(`你是一名短视频解说剧本编辑，负责把控解说的节奏。下面是一章解说剧本各场景的旁白和自动评估的节奏分（0-100，越高节奏越快）。
+
+【各场景旁白】
+%s
+【节奏问题】
+%s
+
+要求：
+1. 针对上述节奏问题，指出最需要压缩、合并或加强冲突的场景，最多5个
+2. 每个场景说明问题和具体修改建议，建议要简短可执行
+3. 只返回 JSON 数组，格式为 [{"scene_number":"场景编号","reason":"问题","suggestion":"修改建议"}]，不要其他文字`,
+		scenes.String(),
+		strings.Join(analysis.Warnings, "\n"),
+	), nil
+}
+
+// ParsePacingSuggestions 解析 LLM 返回的场景压缩建议，丢弃不存在的场景编号和空建议
+func ParsePacingSuggestions(output string, analysis *PacingAnalysis) ([]PacingSuggestion, error) {
+	var suggestions []PacingSuggestion
+	if err := json.Unmarshal([]byte(CleanJSONContent(output)), &suggestions); err != nil {
+		return nil, errclass.Wrap(errclass.ValidationSchema, "", fmt.Errorf("parse pacing suggestions: %w", err))
+	}
+
+	known := make(map[string]bool, len(analysis.Scenes))
+	for _, sp := range analysis.Scenes {
+		known[sp.SceneNumber] = true
+	}
+	result := make([]PacingSuggestion, 0, len(suggestions))
+	for _, s := range suggestions {
+		s.SceneNumber = strings.TrimSpace(s.SceneNumber)
+		s.Suggestion = strings.TrimSpace(s.Suggestion)
+		if !known[s.SceneNumber] || s.Suggestion == "" {
+			continue
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
@@ -0,0 +1,106 @@
+package noveltools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func pacingScene(number, mood, narration string) *NarrationJSONScene {
+	return &NarrationJSONScene{
+		SceneNumber: number,
+		Mood:        mood,
+		Shots:       []*NarrationJSONShot{{CloseupNumber: "1", Narration: narration}},
+	}
+}
+
+const (
+	slowNarration = "清晨的山门静静伫立在云雾之中，弟子们沿着石阶缓缓走向讲经堂，听长老讲述宗门的历史与规矩。"
+	fastNarration = "突然，黑影冲来！林凡猛地拔剑！鲜血飞溅！生死一线！"
+)
+
+func TestAnalyzeNarrationPacing(t *testing.T) {
+	Convey("AnalyzeNarrationPacing 分析解说节奏", t, func() {
+		Convey("战斗场景的节奏分高于平静场景", func() {
+			analysis := AnalyzeNarrationPacing(&NarrationJSONContent{Scenes: []*NarrationJSONScene{
+				pacingScene("1", "calm", slowNarration),
+				pacingScene("2", "战斗", fastNarration),
+			}})
+			So(analysis.Scenes, ShouldHaveLength, 2)
+			So(analysis.Scenes[0].Slow, ShouldBeTrue)
+			So(analysis.Scenes[1].Mood, ShouldEqual, novel.SceneMoodBattle)
+			So(analysis.Scenes[1].Score, ShouldBeGreaterThan, analysis.Scenes[0].Score)
+			So(analysis.Scenes[1].Slow, ShouldBeFalse)
+		})
+
+		Convey("连续慢节奏场景和节奏平淡给出警告", func() {
+			var scenes []*NarrationJSONScene
+			for _, n := range []string{"1", "2", "3", "4", "5"} {
+				scenes = append(scenes, pacingScene(n, "", slowNarration))
+			}
+			analysis := AnalyzeNarrationPacing(&NarrationJSONContent{Scenes: scenes})
+			So(analysis.Flat, ShouldBeTrue)
+			So(analysis.SlowRuns, ShouldResemble, []PacingRun{{StartScene: "1", EndScene: "5", Count: 5}})
+			So(analysis.HasIssues(), ShouldBeTrue)
+		})
+
+		Convey("高潮集中在开头时判定为前重后轻", func() {
+			analysis := AnalyzeNarrationPacing(&NarrationJSONContent{Scenes: []*NarrationJSONScene{
+				pacingScene("1", "battle", fastNarration),
+				pacingScene("2", "battle", fastNarration),
+				pacingScene("3", "tense", fastNarration),
+				pacingScene("4", "calm", slowNarration),
+				pacingScene("5", "calm", slowNarration),
+				pacingScene("6", "calm", slowNarration),
+			}})
+			So(analysis.FrontLoaded, ShouldBeTrue)
+			So(analysis.Flat, ShouldBeFalse)
+			So(analysis.SlowRuns, ShouldHaveLength, 1)
+		})
+
+		Convey("有起伏的节奏没有警告", func() {
+			analysis := AnalyzeNarrationPacing(&NarrationJSONContent{Scenes: []*NarrationJSONScene{
+				pacingScene("1", "tense", fastNarration),
+				pacingScene("2", "calm", slowNarration),
+				pacingScene("3", "mystery", slowNarration),
+				pacingScene("4", "battle", fastNarration),
+			}})
+			So(analysis.HasIssues(), ShouldBeFalse)
+			So(analysis.Warnings, ShouldBeEmpty)
+		})
+
+		Convey("内容为空时返回空分析", func() {
+			So(AnalyzeNarrationPacing(nil).Scenes, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestParsePacingSuggestions(t *testing.T) {
+	Convey("ParsePacingSuggestions 解析 LLM 的压缩建议", t, func() {
+		analysis := &PacingAnalysis{Scenes: []ScenePacing{{SceneNumber: "1"}, {SceneNumber: "2"}}}
+
+		Convey("丢弃未知场景和空建议", func() {
+			output := "```json\n" + `[{"scene_number":"2","reason":"拖沓","suggestion":"合并为一个镜头"},{"scene_number":"9","suggestion":"删除"},{"scene_number":"1","suggestion":" "}]` + "\n```"
+			suggestions, err := ParsePacingSuggestions(output, analysis)
+			So(err, ShouldBeNil)
+			So(suggestions, ShouldResemble, []PacingSuggestion{{SceneNumber: "2", Reason: "拖沓", Suggestion: "合并为一个镜头"}})
+		})
+
+		Convey("不是 JSON 数组时返回错误", func() {
+			_, err := ParsePacingSuggestions("无法给出建议", analysis)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("BuildPacingSuggestionPrompt 包含各场景旁白和节奏问题", t, func() {
+		content := &NarrationJSONContent{Scenes: []*NarrationJSONScene{pacingScene("1", "calm", slowNarration)}}
+		analysis := AnalyzeNarrationPacing(content)
+		analysis.Warnings = append(analysis.Warnings, "节奏平淡")
+		prompt, err := BuildPacingSuggestionPrompt(content, analysis)
+		So(err, ShouldBeNil)
+		So(prompt, ShouldContainSubstring, slowNarration)
+		So(prompt, ShouldContainSubstring, "节奏平淡")
+	})
+}
@@ -0,0 +1,260 @@
+package noveltools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidNarrationPatch 解说 JSON 补丁不合法（操作不支持、路径不允许修改、值类型不对）
+var ErrInvalidNarrationPatch = errors.New("invalid narration patch")
+
+// ErrNarrationPatchTestFailed 补丁中的 test 操作与当前值不一致（解说已被其他人修改）
+var ErrNarrationPatchTestFailed = errors.New("narration patch test failed")
+
+// MaxNarrationPatchOperations 单个补丁最多的操作数
+const MaxNarrationPatchOperations = 200
+
+// maxPatchShotDuration 补丁允许设置的最长镜头时长（秒）
+const maxPatchShotDuration = 60
+
+// 补丁操作（RFC 6902 的子集：解说结构固定，只能修改已有字段，不能增删场景和镜头）
+const (
+	NarrationPatchOpReplace = "replace" // 替换字段值
+	NarrationPatchOpTest    = "test"    // 校验字段当前值，不一致时整个补丁失败
+)
+
+// 允许修改的镜头字段和场景字段
+var (
+	patchableShotFields  = []string{"narration", "image_prompt", "video_prompt", "duration"}
+	patchableSceneFields = []string{"image_prompt"}
+)
+
+// NarrationPatchOperation 补丁操作
+// Path 为 JSON Pointer（RFC 6901），指向解说 JSON 中的字段，如 /scenes/0/shots/2/narration、/scenes/1/image_prompt（下标从 0 开始）
+type NarrationPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// NarrationPatchChange 补丁对一个字段的修改
+type NarrationPatchChange struct {
+	Path       string `json:"path"`        // 字段路径
+	SceneIndex int    `json:"scene_index"` // 场景下标
+	ShotIndex  int    `json:"shot_index"`  // 镜头下标（修改场景字段时为 -1）
+	Field      string `json:"field"`       // 字段名
+	OldValue   any    `json:"old_value"`   // 修改前的值
+	NewValue   any    `json:"new_value"`   // 修改后的值
+}
+
+// narrationPatchTarget 补丁路径解析结果
+type narrationPatchTarget struct {
+	sceneIndex int
+	shotIndex  int // 场景字段为 -1
+	field      string
+}
+
+// ApplyNarrationPatch 按顺序把补丁应用到解说内容上，返回每个 replace 操作的修改
+// 所有操作都通过校验后才修改 content；任一操作不合法或 test 不通过时 content 保持不变
+func ApplyNarrationPatch(content *NarrationJSONContent, ops []NarrationPatchOperation) ([]NarrationPatchChange, error) {
+	if content == nil {
+		return nil, fmt.Errorf("%w: narration content is empty", ErrInvalidNarrationPatch)
+	}
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("%w: no operations", ErrInvalidNarrationPatch)
+	}
+	if len(ops) > MaxNarrationPatchOperations {
+		return nil, fmt.Errorf("%w: too many operations (%d > %d)", ErrInvalidNarrationPatch, len(ops), MaxNarrationPatchOperations)
+	}
+
+	// 先在副本上执行，全部成功后再写回，保证补丁原子生效
+	working := cloneNarrationScenes(content.Scenes)
+	var changes []NarrationPatchChange
+	for i, op := range ops {
+		if op.Op != NarrationPatchOpReplace && op.Op != NarrationPatchOpTest {
+			return nil, fmt.Errorf("%w: operation %d: unsupported op %q (only replace and test)", ErrInvalidNarrationPatch, i, op.Op)
+		}
+		target, err := parseNarrationPatchPath(op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+		current, err := patchFieldValue(working, target)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+		value, err := decodePatchValue(target.field, op.Value)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+
+		if op.Op == NarrationPatchOpTest {
+			if value != current {
+				return nil, fmt.Errorf("%w: %s is %v", ErrNarrationPatchTestFailed, op.Path, current)
+			}
+			continue
+		}
+		if err := validatePatchValue(target.field, value); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+		setPatchFieldValue(working, target, value)
+		changes = append(changes, NarrationPatchChange{
+			Path:       op.Path,
+			SceneIndex: target.sceneIndex,
+			ShotIndex:  target.shotIndex,
+			Field:      target.field,
+			OldValue:   current,
+			NewValue:   value,
+		})
+	}
+
+	content.Scenes = working
+	return changes, nil
+}
+
+// parseNarrationPatchPath 解析补丁路径，只允许 /scenes/{i}/{场景字段} 和 /scenes/{i}/shots/{j}/{镜头字段}
+func parseNarrationPatchPath(path string) (*narrationPatchTarget, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("%w: path %q must start with /", ErrInvalidNarrationPatch, path)
+	}
+	tokens := strings.Split(path[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	switch {
+	case len(tokens) == 3 && tokens[0] == "scenes":
+		sceneIndex, err := parsePatchIndex(path, tokens[1])
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(patchableSceneFields, tokens[2]) {
+			return nil, fmt.Errorf("%w: path %q is not patchable", ErrInvalidNarrationPatch, path)
+		}
+		return &narrationPatchTarget{sceneIndex: sceneIndex, shotIndex: -1, field: tokens[2]}, nil
+	case len(tokens) == 5 && tokens[0] == "scenes" && tokens[2] == "shots":
+		sceneIndex, err := parsePatchIndex(path, tokens[1])
+		if err != nil {
+			return nil, err
+		}
+		shotIndex, err := parsePatchIndex(path, tokens[3])
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(patchableShotFields, tokens[4]) {
+			return nil, fmt.Errorf("%w: path %q is not patchable", ErrInvalidNarrationPatch, path)
+		}
+		return &narrationPatchTarget{sceneIndex: sceneIndex, shotIndex: shotIndex, field: tokens[4]}, nil
+	}
+	return nil, fmt.Errorf("%w: path %q is not patchable", ErrInvalidNarrationPatch, path)
+}
+
+// parsePatchIndex 解析数组下标（RFC 6901：非负整数，不允许前导 0）
+func parsePatchIndex(path, token string) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid index %q in path %q", ErrInvalidNarrationPatch, token, path)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("%w: invalid index %q in path %q", ErrInvalidNarrationPatch, token, path)
+	}
+	return index, nil
+}
+
+// patchFieldValue 读取路径指向的字段当前值
+func patchFieldValue(scenes []*NarrationJSONScene, target *narrationPatchTarget) (any, error) {
+	if target.sceneIndex >= len(scenes) {
+		return nil, fmt.Errorf("%w: scene index %d out of range", ErrInvalidNarrationPatch, target.sceneIndex)
+	}
+	scene := scenes[target.sceneIndex]
+	if target.shotIndex < 0 {
+		return scene.ImagePrompt, nil
+	}
+	if target.shotIndex >= len(scene.Shots) {
+		return nil, fmt.Errorf("%w: shot index %d out of range in scene %d", ErrInvalidNarrationPatch, target.shotIndex, target.sceneIndex)
+	}
+	shot := scene.Shots[target.shotIndex]
+	switch target.field {
+	case "narration":
+		return shot.Narration, nil
+	case "image_prompt":
+		return shot.ImagePrompt, nil
+	case "video_prompt":
+		return shot.VideoPrompt, nil
+	default:
+		return shot.Duration, nil
+	}
+}
+
+// setPatchFieldValue 写入路径指向的字段（调用前已校验路径和值）
+func setPatchFieldValue(scenes []*NarrationJSONScene, target *narrationPatchTarget, value any) {
+	scene := scenes[target.sceneIndex]
+	if target.shotIndex < 0 {
+		scene.ImagePrompt = value.(string)
+		return
+	}
+	shot := scene.Shots[target.shotIndex]
+	switch target.field {
+	case "narration":
+		shot.Narration = value.(string)
+	case "image_prompt":
+		shot.ImagePrompt = value.(string)
+	case "video_prompt":
+		shot.VideoPrompt = value.(string)
+	default:
+		shot.Duration = value.(float64)
+	}
+}
+
+// decodePatchValue 按字段类型解析操作的值：duration 为数字，其余为字符串
+func decodePatchValue(field string, raw json.RawMessage) (any, error) {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return nil, fmt.Errorf("%w: value is required", ErrInvalidNarrationPatch)
+	}
+	if field == "duration" {
+		var v float64
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("%w: duration must be a number", ErrInvalidNarrationPatch)
+		}
+		return v, nil
+	}
+	var v string
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, fmt.Errorf("%w: %s must be a string", ErrInvalidNarrationPatch, field)
+	}
+	return v, nil
+}
+
+// validatePatchValue 校验替换后的值：文本不能为空，时长在 (0, 60] 秒内
+func validatePatchValue(field string, value any) error {
+	if field == "duration" {
+		d := value.(float64)
+		if d <= 0 || d > maxPatchShotDuration {
+			return fmt.Errorf("%w: duration must be in (0, %d] seconds", ErrInvalidNarrationPatch, maxPatchShotDuration)
+		}
+		return nil
+	}
+	if strings.TrimSpace(value.(string)) == "" {
+		return fmt.Errorf("%w: %s must not be empty", ErrInvalidNarrationPatch, field)
+	}
+	return nil
+}
+
+// cloneNarrationScenes 复制场景和镜头（补丁只修改标量字段，浅拷贝结构体即可）
+func cloneNarrationScenes(scenes []*NarrationJSONScene) []*NarrationJSONScene {
+	cloned := make([]*NarrationJSONScene, len(scenes))
+	for i, scene := range scenes {
+		sceneCopy := *scene
+		sceneCopy.Shots = make([]*NarrationJSONShot, len(scene.Shots))
+		for j, shot := range scene.Shots {
+			shotCopy := *shot
+			sceneCopy.Shots[j] = &shotCopy
+		}
+		cloned[i] = &sceneCopy
+	}
+	return cloned
+}
@@ -0,0 +1,113 @@
+package noveltools
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func patchTestContent() *NarrationJSONContent {
+	return &NarrationJSONContent{
+		Scenes: []*NarrationJSONScene{
+			{
+				SceneNumber: "1",
+				ImagePrompt: "雪中山门",
+				Shots: []*NarrationJSONShot{
+					{CloseupNumber: "1", Narration: "林凡握剑", ImagePrompt: "少年握剑", Duration: 3},
+					{CloseupNumber: "2", Narration: "长老走来", VideoPrompt: "镜头缓慢推进", Duration: 4},
+				},
+			},
+		},
+	}
+}
+
+func patchOp(op, path string, value any) NarrationPatchOperation {
+	raw, _ := json.Marshal(value)
+	return NarrationPatchOperation{Op: op, Path: path, Value: raw}
+}
+
+func TestApplyNarrationPatch(t *testing.T) {
+	Convey("ApplyNarrationPatch 按 JSON Patch 修改解说字段", t, func() {
+		content := patchTestContent()
+
+		Convey("replace 修改镜头旁白、提示词、时长和场景提示词", func() {
+			changes, err := ApplyNarrationPatch(content, []NarrationPatchOperation{
+				patchOp("replace", "/scenes/0/shots/1/narration", "长老缓缓走来"),
+				patchOp("replace", "/scenes/0/shots/0/image_prompt", "少年紧握长剑"),
+				patchOp("replace", "/scenes/0/shots/1/duration", 5.5),
+				patchOp("replace", "/scenes/0/image_prompt", "大雪中的山门"),
+			})
+			So(err, ShouldBeNil)
+			So(changes, ShouldHaveLength, 4)
+			So(changes[0].OldValue, ShouldEqual, "长老走来")
+			So(changes[0].NewValue, ShouldEqual, "长老缓缓走来")
+			So(changes[0].ShotIndex, ShouldEqual, 1)
+			So(changes[3].ShotIndex, ShouldEqual, -1)
+			So(content.Scenes[0].Shots[1].Narration, ShouldEqual, "长老缓缓走来")
+			So(content.Scenes[0].Shots[0].ImagePrompt, ShouldEqual, "少年紧握长剑")
+			So(content.Scenes[0].Shots[1].Duration, ShouldEqual, 5.5)
+			So(content.Scenes[0].ImagePrompt, ShouldEqual, "大雪中的山门")
+		})
+
+		Convey("后面的操作看到前面操作的结果", func() {
+			changes, err := ApplyNarrationPatch(content, []NarrationPatchOperation{
+				patchOp("replace", "/scenes/0/shots/0/narration", "第一次"),
+				patchOp("test", "/scenes/0/shots/0/narration", "第一次"),
+				patchOp("replace", "/scenes/0/shots/0/narration", "第二次"),
+			})
+			So(err, ShouldBeNil)
+			So(changes, ShouldHaveLength, 2)
+			So(changes[1].OldValue, ShouldEqual, "第一次")
+			So(content.Scenes[0].Shots[0].Narration, ShouldEqual, "第二次")
+		})
+
+		Convey("test 不一致时整个补丁失败且不修改内容", func() {
+			_, err := ApplyNarrationPatch(content, []NarrationPatchOperation{
+				patchOp("replace", "/scenes/0/shots/0/narration", "新旁白"),
+				patchOp("test", "/scenes/0/shots/1/narration", "别人改过的旁白"),
+			})
+			So(errors.Is(err, ErrNarrationPatchTestFailed), ShouldBeTrue)
+			So(content.Scenes[0].Shots[0].Narration, ShouldEqual, "林凡握剑")
+		})
+
+		Convey("拒绝不允许修改的路径和不支持的操作", func() {
+			invalid := []NarrationPatchOperation{
+				patchOp("replace", "/scenes/0/shots/0/character", "张三"),
+				patchOp("replace", "/scenes/0/description", "新描述"),
+				patchOp("replace", "/scenes/01/image_prompt", "前导零"),
+				patchOp("replace", "/scenes/-/image_prompt", "追加"),
+				patchOp("replace", "/scenes/3/image_prompt", "越界"),
+				patchOp("replace", "/scenes/0/shots/2/narration", "越界"),
+				patchOp("replace", "scenes/0/image_prompt", "缺少斜杠"),
+				patchOp("remove", "/scenes/0/shots/0/narration", nil),
+				patchOp("add", "/scenes/0/shots/0/narration", "新增"),
+			}
+			for _, op := range invalid {
+				_, err := ApplyNarrationPatch(content, []NarrationPatchOperation{op})
+				So(errors.Is(err, ErrInvalidNarrationPatch), ShouldBeTrue)
+			}
+		})
+
+		Convey("拒绝类型不对或超出范围的值", func() {
+			invalid := []NarrationPatchOperation{
+				patchOp("replace", "/scenes/0/shots/0/duration", "3"),
+				patchOp("replace", "/scenes/0/shots/0/duration", 0),
+				patchOp("replace", "/scenes/0/shots/0/duration", 61),
+				patchOp("replace", "/scenes/0/shots/0/narration", 1),
+				patchOp("replace", "/scenes/0/shots/0/narration", "   "),
+				{Op: "replace", Path: "/scenes/0/shots/0/narration"},
+			}
+			for _, op := range invalid {
+				_, err := ApplyNarrationPatch(content, []NarrationPatchOperation{op})
+				So(errors.Is(err, ErrInvalidNarrationPatch), ShouldBeTrue)
+			}
+		})
+
+		Convey("没有操作时返回错误", func() {
+			_, err := ApplyNarrationPatch(content, nil)
+			So(errors.Is(err, ErrInvalidNarrationPatch), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,105 @@
+package noveltools
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// SceneStreamParser 剧本 JSON 的增量解析器
+// 用于流式生成剧本时，在 LLM 输出尚未结束前逐个解析出已经完整的场景（scenes 数组中的元素）
+//
+// 设计原则：
+//   - 纯函数式状态机，不依赖 LLM / 数据库，方便单测
+//   - 只识别顶层对象中 "scenes" 数组的直接子对象，其他字段（characters/props 等）忽略
+//   - 单个场景解析失败时跳过，由调用方在完整输出后再做一次整体解析兜底
+type SceneStreamParser struct {
+	buf strings.Builder
+
+	pos      int  // 已扫描到的位置
+	depth    int  // 当前嵌套深度（{ 和 [ 都计入）
+	inString bool // 是否处于字符串中
+	escaped  bool // 上一个字符是否为转义符
+
+	strStart int    // 当前字符串的起始位置
+	lastStr  string // 最近一个结束的字符串（用于识别键名）
+	lastKey  string // 顶层对象中最近一个键名
+
+	scenesDepth int // scenes 数组所在深度（0 表示尚未进入 scenes 数组）
+	sceneStart  int // 当前场景对象的起始位置（-1 表示不在场景对象中）
+	sceneCount  int // 已解析出的场景数
+}
+
+// NewSceneStreamParser 创建剧本 JSON 增量解析器
+func NewSceneStreamParser() *SceneStreamParser {
+	return &SceneStreamParser{sceneStart: -1}
+}
+
+// Write 追加一段 LLM 输出，返回本次新解析出的完整场景（按出现顺序）
+func (p *SceneStreamParser) Write(chunk string) []*NarrationJSONScene {
+	p.buf.WriteString(chunk)
+	text := p.buf.String()
+
+	var scenes []*NarrationJSONScene
+	for ; p.pos < len(text); p.pos++ {
+		c := text[p.pos]
+
+		if p.inString {
+			switch {
+			case p.escaped:
+				p.escaped = false
+			case c == '\\':
+				p.escaped = true
+			case c == '"':
+				p.inString = false
+				p.lastStr = text[p.strStart:p.pos]
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			p.inString = true
+			p.strStart = p.pos + 1
+		case ':':
+			// 只记录顶层对象的键名
+			if p.depth == 1 {
+				p.lastKey = p.lastStr
+			}
+		case '{', '[':
+			p.depth++
+			if c == '[' && p.depth == 2 && p.lastKey == "scenes" {
+				p.scenesDepth = p.depth
+			}
+			if c == '{' && p.scenesDepth > 0 && p.depth == p.scenesDepth+1 {
+				p.sceneStart = p.pos
+			}
+		case '}', ']':
+			if c == '}' && p.sceneStart >= 0 && p.depth == p.scenesDepth+1 {
+				var scene NarrationJSONScene
+				if err := json.Unmarshal([]byte(text[p.sceneStart:p.pos+1]), &scene); err == nil {
+					scenes = append(scenes, &scene)
+					p.sceneCount++
+				}
+				p.sceneStart = -1
+			}
+			if c == ']' && p.depth == p.scenesDepth {
+				p.scenesDepth = 0
+			}
+			if p.depth > 0 {
+				p.depth--
+			}
+		}
+	}
+
+	return scenes
+}
+
+// SceneCount 返回已解析出的场景数
+func (p *SceneStreamParser) SceneCount() int {
+	return p.sceneCount
+}
+
+// String 返回目前为止累计的完整输出
+func (p *SceneStreamParser) String() string {
+	return p.buf.String()
+}
@@ -0,0 +1,55 @@
+package noveltools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const streamNarrationJSON = `{
+  "chapter_info": {"title": "第一章 {开端}"},
+  "characters": [{"name": "林动", "description": "少年 {主角}"}],
+  "scenes": [
+    {"scene_number": "1", "description": "山门 \"外\"", "image_prompt": "p1", "shots": [{"closeup_number": "1", "narration": "旁白[1]"}]},
+    {"scene_number": "2", "description": "大殿", "image_prompt": "p2", "shots": [{"closeup_number": "1"}, {"closeup_number": "2"}]}
+  ],
+  "props": [{"name": "石符"}]
+}`
+
+func TestSceneStreamParser_Write(t *testing.T) {
+	Convey("SceneStreamParser 能增量解析出完整的场景", t, func() {
+		Convey("一次性写入完整内容", func() {
+			parser := NewSceneStreamParser()
+			scenes := parser.Write(streamNarrationJSON)
+			So(len(scenes), ShouldEqual, 2)
+			So(scenes[0].SceneNumber, ShouldEqual, "1")
+			So(scenes[0].Description, ShouldEqual, `山门 "外"`)
+			So(scenes[0].Shots[0].Narration, ShouldEqual, "旁白[1]")
+			So(len(scenes[1].Shots), ShouldEqual, 2)
+			So(parser.SceneCount(), ShouldEqual, 2)
+		})
+
+		Convey("逐字节写入时场景在闭合后立即产出", func() {
+			parser := NewSceneStreamParser()
+			var got []*NarrationJSONScene
+			firstAt := -1
+			for i := 0; i < len(streamNarrationJSON); i++ {
+				scenes := parser.Write(streamNarrationJSON[i : i+1])
+				if len(scenes) > 0 && firstAt < 0 {
+					firstAt = i
+				}
+				got = append(got, scenes...)
+			}
+			So(len(got), ShouldEqual, 2)
+			So(got[1].SceneNumber, ShouldEqual, "2")
+			So(firstAt, ShouldBeLessThan, len(streamNarrationJSON)-1)
+			So(parser.String(), ShouldEqual, streamNarrationJSON)
+		})
+
+		Convey("characters 等其他数组中的对象不会被当成场景", func() {
+			parser := NewSceneStreamParser()
+			scenes := parser.Write(`{"characters": [{"name": "a"}], "props": [{"name": "b"}]}`)
+			So(len(scenes), ShouldEqual, 0)
+		})
+	})
+}
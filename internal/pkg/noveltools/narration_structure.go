@@ -0,0 +1,198 @@
+package noveltools
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"lemon/internal/model/novel"
+)
+
+// 内置的解说结构模板
+const (
+	NarrationStructureClassic = "classic" // 默认结构：7 个场景，每个场景 1~3 个镜头，解说字数按章节字数决定
+	NarrationStructureShort   = "short"   // 短篇：3~5 个场景，每个场景 1~2 个镜头，约 90 秒
+	NarrationStructureLong    = "long"    // 长篇：10~14 个场景，每个场景 1~3 个镜头，约 10 分钟
+)
+
+const (
+	// MaxNarrationScenes 解说结构允许的最多场景数
+	MaxNarrationScenes = 30
+	// MaxNarrationShotsPerScene 解说结构允许的每个场景最多镜头数
+	MaxNarrationShotsPerScene = 6
+	// minNarrationTargetDuration、maxNarrationTargetDuration 目标总时长的取值范围（秒）
+	minNarrationTargetDuration = 15
+	maxNarrationTargetDuration = 1800
+)
+
+// ErrInvalidNarrationStructure 解说结构不合法（模板不存在、场景数或镜头数范围不合法、目标时长超出范围）
+var ErrInvalidNarrationStructure = errors.New("invalid narration structure")
+
+var narrationStructurePresets = []novel.NarrationStructure{
+	{Template: NarrationStructureClassic, MinScenes: 7, MaxScenes: 7, MinShotsPerScene: 1, MaxShotsPerScene: 3},
+	{Template: NarrationStructureShort, MinScenes: 3, MaxScenes: 5, MinShotsPerScene: 1, MaxShotsPerScene: 2, TargetDuration: 90},
+	{Template: NarrationStructureLong, MinScenes: 10, MaxScenes: 14, MinShotsPerScene: 1, MaxShotsPerScene: 3, TargetDuration: 600},
+}
+
+// NarrationStructurePresets 返回内置的解说结构模板
+func NarrationStructurePresets() []novel.NarrationStructure {
+	return append([]novel.NarrationStructure(nil), narrationStructurePresets...)
+}
+
+// DefaultNarrationStructure 返回默认的解说结构（classic：7 个场景，每个场景 1~3 个镜头）
+func DefaultNarrationStructure() novel.NarrationStructure {
+	return narrationStructurePresets[0]
+}
+
+// findNarrationStructurePreset 按名称查找内置模板
+func findNarrationStructurePreset(template string) (novel.NarrationStructure, bool) {
+	for _, preset := range narrationStructurePresets {
+		if preset.Template == template {
+			return preset, true
+		}
+	}
+	return novel.NarrationStructure{}, false
+}
+
+// ResolveNarrationStructure 解析小说的解说结构：以模板（未指定或模板不存在时为默认结构）为基础，非 0 字段覆盖模板的取值
+// structure 为 nil 时返回默认结构
+func ResolveNarrationStructure(structure *novel.NarrationStructure) novel.NarrationStructure {
+	if structure == nil {
+		return DefaultNarrationStructure()
+	}
+	resolved, ok := findNarrationStructurePreset(structure.Template)
+	if !ok {
+		resolved = DefaultNarrationStructure()
+		resolved.Template = ""
+	}
+	if structure.MinScenes > 0 {
+		resolved.MinScenes = structure.MinScenes
+	}
+	if structure.MaxScenes > 0 {
+		resolved.MaxScenes = structure.MaxScenes
+	}
+	if structure.MinShotsPerScene > 0 {
+		resolved.MinShotsPerScene = structure.MinShotsPerScene
+	}
+	if structure.MaxShotsPerScene > 0 {
+		resolved.MaxShotsPerScene = structure.MaxShotsPerScene
+	}
+	if structure.TargetDuration > 0 {
+		resolved.TargetDuration = structure.TargetDuration
+	}
+	return resolved
+}
+
+// NormalizeNarrationStructure 校验并解析用户设置的解说结构，返回完整的结构（便于之后模板调整时不影响已设置的小说）
+// 覆盖了模板字段的结构不再记录模板名称
+func NormalizeNarrationStructure(structure *novel.NarrationStructure) (*novel.NarrationStructure, error) {
+	if structure == nil {
+		structure = &novel.NarrationStructure{}
+	}
+	template := strings.TrimSpace(structure.Template)
+	if template != "" {
+		if _, ok := findNarrationStructurePreset(template); !ok {
+			return nil, fmt.Errorf("%w: unknown template %q", ErrInvalidNarrationStructure, template)
+		}
+	}
+	if structure.MinScenes < 0 || structure.MaxScenes < 0 || structure.MinShotsPerScene < 0 ||
+		structure.MaxShotsPerScene < 0 || structure.TargetDuration < 0 {
+		return nil, fmt.Errorf("%w: values must not be negative", ErrInvalidNarrationStructure)
+	}
+
+	input := *structure
+	input.Template = template
+	resolved := ResolveNarrationStructure(&input)
+	if preset, ok := findNarrationStructurePreset(template); !ok || resolved != preset {
+		resolved.Template = ""
+	}
+
+	switch {
+	case resolved.MinScenes < 1 || resolved.MaxScenes > MaxNarrationScenes || resolved.MinScenes > resolved.MaxScenes:
+		return nil, fmt.Errorf("%w: scenes must be within 1-%d and min_scenes must not exceed max_scenes",
+			ErrInvalidNarrationStructure, MaxNarrationScenes)
+	case resolved.MinShotsPerScene < 1 || resolved.MaxShotsPerScene > MaxNarrationShotsPerScene ||
+		resolved.MinShotsPerScene > resolved.MaxShotsPerScene:
+		return nil, fmt.Errorf("%w: shots per scene must be within 1-%d and min_shots_per_scene must not exceed max_shots_per_scene",
+			ErrInvalidNarrationStructure, MaxNarrationShotsPerScene)
+	case resolved.TargetDuration != 0 &&
+		(resolved.TargetDuration < minNarrationTargetDuration || resolved.TargetDuration > maxNarrationTargetDuration):
+		return nil, fmt.Errorf("%w: target_duration must be within %d-%d seconds",
+			ErrInvalidNarrationStructure, minNarrationTargetDuration, maxNarrationTargetDuration)
+	}
+	return &resolved, nil
+}
+
+// NarrationMaxShots 返回解说结构允许的最多镜头数
+func NarrationMaxShots(structure novel.NarrationStructure) int {
+	return structure.MaxScenes * structure.MaxShotsPerScene
+}
+
+// NarrationWordRange 返回解说总字数的要求范围
+// 设置了目标时长时按 1.0 倍语速的朗读字数上下浮动 10%；否则按章节字数的 10%~15%（限制在 800~2000 字），章节字数未知时为 1100~1300 字
+func NarrationWordRange(structure novel.NarrationStructure, chapterWordCount int) (int, int) {
+	if structure.TargetDuration > 0 {
+		chars := float64(structure.TargetDuration) * ReadingCharsPerSecond
+		return int(chars * 0.9), int(chars * 1.1)
+	}
+	if chapterWordCount <= 0 {
+		return 1100, 1300
+	}
+	minWords := min(max(chapterWordCount/10, 800), 1500)
+	maxWords := min(max(chapterWordCount*15/100, 1000), 2000)
+	return minWords, maxWords
+}
+
+// narrationWordRequirement 返回提示词中的解说字数要求（如「800-1000字（根据章节长度8000字调整）」）
+func narrationWordRequirement(structure novel.NarrationStructure, chapterWordCount int) string {
+	minWords, maxWords := NarrationWordRange(structure, chapterWordCount)
+	switch {
+	case structure.TargetDuration > 0:
+		return fmt.Sprintf("%d-%d字（对应约%d秒的解说视频）", minWords, maxWords, structure.TargetDuration)
+	case chapterWordCount > 0:
+		return fmt.Sprintf("%d-%d字（根据章节长度%d字调整）", minWords, maxWords, chapterWordCount)
+	default:
+		return fmt.Sprintf("%d-%d字", minWords, maxWords)
+	}
+}
+
+// narrationSceneRequirement 返回提示词中的场景数量要求（如「必须生成7个场景（scene），每个场景包含1-3个分镜头（shot）」）
+func narrationSceneRequirement(structure novel.NarrationStructure) string {
+	return fmt.Sprintf("必须生成%s个场景（scene），每个场景包含%s个分镜头（shot）",
+		formatCountRange(structure.MinScenes, structure.MaxScenes),
+		formatCountRange(structure.MinShotsPerScene, structure.MaxShotsPerScene))
+}
+
+// formatCountRange 格式化数量范围（上下限相同时只写一个数）
+func formatCountRange(lo, hi int) string {
+	if lo == hi {
+		return fmt.Sprintf("%d", lo)
+	}
+	return fmt.Sprintf("%d-%d", lo, hi)
+}
+
+// NarrationStructureWarnings 检查剧本是否符合解说结构，返回场景数和每个场景镜头数的偏差提示
+func NarrationStructureWarnings(content *NarrationJSONContent, structure novel.NarrationStructure) []string {
+	var warnings []string
+	if n := len(content.Scenes); n < structure.MinScenes {
+		warnings = append(warnings, fmt.Sprintf("分镜数量不足，期望至少%d个，实际%d个，但继续生成", structure.MinScenes, n))
+	} else if n > structure.MaxScenes {
+		warnings = append(warnings, fmt.Sprintf("分镜数量过多，期望至多%d个，实际%d个，但继续生成", structure.MaxScenes, n))
+	}
+	for i, scene := range content.Scenes {
+		if scene == nil {
+			continue
+		}
+		n := len(scene.Shots)
+		if n >= structure.MinShotsPerScene && n <= structure.MaxShotsPerScene {
+			continue
+		}
+		number := scene.SceneNumber
+		if number == "" {
+			number = fmt.Sprintf("%d", i+1)
+		}
+		warnings = append(warnings, fmt.Sprintf("场景%s有%d个分镜头，期望%s个",
+			number, n, formatCountRange(structure.MinShotsPerScene, structure.MaxShotsPerScene)))
+	}
+	return warnings
+}
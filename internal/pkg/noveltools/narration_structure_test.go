@@ -0,0 +1,102 @@
+package noveltools
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestResolveNarrationStructure(t *testing.T) {
+	Convey("ResolveNarrationStructure 以模板为基础合并自定义字段", t, func() {
+		So(ResolveNarrationStructure(nil), ShouldResemble, DefaultNarrationStructure())
+
+		resolved := ResolveNarrationStructure(&novel.NarrationStructure{Template: NarrationStructureShort, MaxScenes: 6})
+		So(resolved.MinScenes, ShouldEqual, 3)
+		So(resolved.MaxScenes, ShouldEqual, 6)
+		So(resolved.TargetDuration, ShouldEqual, 90)
+
+		Convey("模板不存在时以默认结构为基础", func() {
+			resolved := ResolveNarrationStructure(&novel.NarrationStructure{Template: "unknown", MaxShotsPerScene: 4})
+			So(resolved.Template, ShouldEqual, "")
+			So(resolved.MinScenes, ShouldEqual, 7)
+			So(resolved.MaxShotsPerScene, ShouldEqual, 4)
+		})
+	})
+}
+
+func TestNormalizeNarrationStructure(t *testing.T) {
+	Convey("NormalizeNarrationStructure 校验解说结构", t, func() {
+		Convey("只选择模板时保留模板名称", func() {
+			normalized, err := NormalizeNarrationStructure(&novel.NarrationStructure{Template: " long "})
+			So(err, ShouldBeNil)
+			So(normalized.Template, ShouldEqual, NarrationStructureLong)
+			So(normalized.MinScenes, ShouldEqual, 10)
+		})
+
+		Convey("覆盖模板字段后不再记录模板名称", func() {
+			normalized, err := NormalizeNarrationStructure(&novel.NarrationStructure{Template: NarrationStructureClassic, MinScenes: 5})
+			So(err, ShouldBeNil)
+			So(normalized.Template, ShouldEqual, "")
+			So(normalized.MinScenes, ShouldEqual, 5)
+			So(normalized.MaxScenes, ShouldEqual, 7)
+		})
+
+		Convey("不合法的结构返回 ErrInvalidNarrationStructure", func() {
+			for _, structure := range []*novel.NarrationStructure{
+				{Template: "epic"},
+				{MinScenes: 8},
+				{MaxScenes: MaxNarrationScenes + 1},
+				{MaxShotsPerScene: MaxNarrationShotsPerScene + 1},
+				{TargetDuration: 5},
+				{MinScenes: -1},
+			} {
+				_, err := NormalizeNarrationStructure(structure)
+				So(errors.Is(err, ErrInvalidNarrationStructure), ShouldBeTrue)
+			}
+		})
+	})
+}
+
+func TestNarrationWordRange(t *testing.T) {
+	Convey("NarrationWordRange 计算解说字数要求", t, func() {
+		classic := DefaultNarrationStructure()
+		lo, hi := NarrationWordRange(classic, 0)
+		So([]int{lo, hi}, ShouldResemble, []int{1100, 1300})
+		lo, hi = NarrationWordRange(classic, 10000)
+		So([]int{lo, hi}, ShouldResemble, []int{1000, 1500})
+		lo, hi = NarrationWordRange(classic, 3000)
+		So([]int{lo, hi}, ShouldResemble, []int{800, 1000})
+
+		lo, hi = NarrationWordRange(novel.NarrationStructure{TargetDuration: 100}, 10000)
+		So([]int{lo, hi}, ShouldResemble, []int{378, 462})
+	})
+}
+
+func TestNarrationStructureWarnings(t *testing.T) {
+	Convey("NarrationStructureWarnings 检查场景数和每个场景的镜头数", t, func() {
+		shots := func(n int) []*NarrationJSONShot {
+			result := make([]*NarrationJSONShot, n)
+			for i := range result {
+				result[i] = &NarrationJSONShot{Narration: "旁白"}
+			}
+			return result
+		}
+		structure := novel.NarrationStructure{MinScenes: 2, MaxScenes: 3, MinShotsPerScene: 1, MaxShotsPerScene: 2}
+
+		content := &NarrationJSONContent{Scenes: []*NarrationJSONScene{
+			{SceneNumber: "1", Shots: shots(2)},
+			{SceneNumber: "2", Shots: shots(3)},
+		}}
+		So(NarrationStructureWarnings(content, structure), ShouldResemble, []string{"场景2有3个分镜头，期望1-2个"})
+
+		content.Scenes = append(content.Scenes, &NarrationJSONScene{Shots: shots(1)}, &NarrationJSONScene{Shots: shots(1)})
+		warnings := NarrationStructureWarnings(content, structure)
+		So(warnings[0], ShouldEqual, "分镜数量过多，期望至多3个，实际4个，但继续生成")
+
+		content.Scenes = content.Scenes[:1]
+		So(NarrationStructureWarnings(content, structure), ShouldResemble, []string{"分镜数量不足，期望至少2个，实际1个，但继续生成"})
+	})
+}
@@ -28,6 +28,8 @@ type ValidationResult struct {
 	FirstCloseup  *CloseupValidation // 第一个特写验证结果
 	SecondCloseup *CloseupValidation // 第二个特写验证结果
 	TotalLength   int                // 总字数
+	MergedShots   []ShotMerge        // 合并的近似重复镜头
+	Pacing        *PacingAnalysis    // 节奏/情绪曲线分析（节奏警告不计入 Warnings）
 }
 
 // CloseupValidation 特写验证结果
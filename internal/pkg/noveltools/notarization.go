@@ -0,0 +1,97 @@
+package noveltools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"lemon/internal/model/novel"
+)
+
+// notarizationHashVersion 公证记录哈希的内容格式版本（参与哈希计算，格式变化时递增）
+const notarizationHashVersion = 1
+
+// notarizationHashContent 参与公证记录哈希计算的内容
+// 字段顺序固定（JSON 按结构体字段顺序编码）；时间戳相关字段由时间戳服务对哈希签名，不参与计算
+type notarizationHashContent struct {
+	Version      int    `json:"v"`
+	Sequence     int64  `json:"sequence"`
+	NovelID      string `json:"novel_id"`
+	ChapterID    string `json:"chapter_id"`
+	VideoID      string `json:"video_id"`
+	VideoVersion int    `json:"video_version"`
+	SHA256       string `json:"sha256"`
+	FileSize     int64  `json:"file_size"`
+	BatchID      string `json:"batch_id"`
+	PrevHash     string `json:"prev_hash"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// NotarizationRecordHash 计算公证记录的哈希（十六进制小写）
+// CreatedAt 按 UTC 毫秒精度参与计算，与 MongoDB 存储的精度一致
+func NotarizationRecordHash(r *novel.NotarizationRecord) string {
+	content, _ := json.Marshal(notarizationHashContent{
+		Version:      notarizationHashVersion,
+		Sequence:     r.Sequence,
+		NovelID:      r.NovelID,
+		ChapterID:    r.ChapterID,
+		VideoID:      r.VideoID,
+		VideoVersion: r.Version,
+		SHA256:       r.SHA256,
+		FileSize:     r.FileSize,
+		BatchID:      r.BatchID,
+		PrevHash:     r.PrevHash,
+		CreatedAt:    r.CreatedAt.UTC().Truncate(time.Millisecond).Format("2006-01-02T15:04:05.000Z"),
+	})
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// NormalizeSHA256 校验并规范化 SHA256 十六进制字符串（去掉首尾空白，转为小写）
+func NormalizeSHA256(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) != sha256.Size*2 {
+		return "", fmt.Errorf("sha256 must be %d hex characters", sha256.Size*2)
+	}
+	if _, err := hex.DecodeString(s); err != nil {
+		return "", fmt.Errorf("sha256 must be hex encoded")
+	}
+	return s, nil
+}
+
+// NotarizationChainIssue 公证链校验发现的问题
+type NotarizationChainIssue struct {
+	Sequence int64  `json:"sequence"` // 出问题的记录序号
+	RecordID string `json:"record_id"`
+	Reason   string `json:"reason"`
+}
+
+// CheckNotarizationChain 校验一段连续的公证记录（按 Sequence 升序）
+// prev 为第一条记录的上一条记录（从序号 1 开始校验时为 nil）；检查序号连续、PrevHash 指向上一条记录、RecordHash 与内容一致
+func CheckNotarizationChain(prev *novel.NotarizationRecord, records []*novel.NotarizationRecord) []NotarizationChainIssue {
+	var issues []NotarizationChainIssue
+	for _, r := range records {
+		issue := func(format string, args ...any) {
+			issues = append(issues, NotarizationChainIssue{Sequence: r.Sequence, RecordID: r.ID, Reason: fmt.Sprintf(format, args...)})
+		}
+
+		wantSeq, wantPrev := int64(1), ""
+		if prev != nil {
+			wantSeq, wantPrev = prev.Sequence+1, prev.RecordHash
+		}
+		if r.Sequence != wantSeq {
+			issue("sequence %d follows %d, expected %d", r.Sequence, wantSeq-1, wantSeq)
+		}
+		if r.PrevHash != wantPrev {
+			issue("prev_hash does not match record hash of sequence %d", wantSeq-1)
+		}
+		if got := NotarizationRecordHash(r); got != r.RecordHash {
+			issue("record_hash mismatch: stored %s, computed %s", r.RecordHash, got)
+		}
+		prev = r
+	}
+	return issues
+}
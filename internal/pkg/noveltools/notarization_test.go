@@ -0,0 +1,129 @@
+package noveltools
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestNotarizationRecordHash(t *testing.T) {
+	created := time.Date(2026, 5, 1, 8, 0, 0, 123456789, time.UTC)
+	record := func() *novel.NotarizationRecord {
+		return &novel.NotarizationRecord{
+			ID:        "n-1",
+			Sequence:  1,
+			NovelID:   "novel-1",
+			ChapterID: "chapter-1",
+			VideoID:   "video-1",
+			Version:   2,
+			SHA256:    strings.Repeat("ab", 32),
+			FileSize:  1024,
+			CreatedAt: created,
+		}
+	}
+
+	Convey("NotarizationRecordHash 计算记录哈希", t, func() {
+		hash := NotarizationRecordHash(record())
+		So(hash, ShouldHaveLength, 64)
+		So(NotarizationRecordHash(record()), ShouldEqual, hash)
+
+		Convey("创建时间按毫秒精度和 UTC 计算", func() {
+			r := record()
+			r.CreatedAt = created.Truncate(time.Millisecond).In(time.FixedZone("CST", 8*3600))
+			So(NotarizationRecordHash(r), ShouldEqual, hash)
+		})
+
+		Convey("任一内容字段变化时哈希变化", func() {
+			r := record()
+			r.SHA256 = strings.Repeat("cd", 32)
+			So(NotarizationRecordHash(r), ShouldNotEqual, hash)
+
+			r = record()
+			r.PrevHash = hash
+			So(NotarizationRecordHash(r), ShouldNotEqual, hash)
+
+			r = record()
+			r.Sequence = 2
+			So(NotarizationRecordHash(r), ShouldNotEqual, hash)
+		})
+
+		Convey("时间戳字段不参与计算", func() {
+			r := record()
+			r.TimestampAuthority = "https://tsa.example.com"
+			r.TimestampToken = []byte{0x30, 0x00}
+			So(NotarizationRecordHash(r), ShouldEqual, hash)
+		})
+	})
+
+	Convey("NormalizeSHA256 校验并规范化哈希", t, func() {
+		got, err := NormalizeSHA256("  " + strings.Repeat("AB", 32) + "\n")
+		So(err, ShouldBeNil)
+		So(got, ShouldEqual, strings.Repeat("ab", 32))
+
+		_, err = NormalizeSHA256("abc")
+		So(err, ShouldNotBeNil)
+		_, err = NormalizeSHA256(strings.Repeat("zz", 32))
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestCheckNotarizationChain(t *testing.T) {
+	created := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	chain := func(n int) []*novel.NotarizationRecord {
+		var records []*novel.NotarizationRecord
+		prevHash := ""
+		for i := 1; i <= n; i++ {
+			r := &novel.NotarizationRecord{
+				ID:        "n-" + strings.Repeat("x", i),
+				Sequence:  int64(i),
+				VideoID:   "video-" + strings.Repeat("x", i),
+				SHA256:    strings.Repeat("ab", 32),
+				PrevHash:  prevHash,
+				CreatedAt: created.Add(time.Duration(i) * time.Minute),
+			}
+			r.RecordHash = NotarizationRecordHash(r)
+			prevHash = r.RecordHash
+			records = append(records, r)
+		}
+		return records
+	}
+
+	Convey("CheckNotarizationChain 校验哈希链", t, func() {
+		records := chain(4)
+		So(CheckNotarizationChain(nil, records), ShouldBeEmpty)
+
+		Convey("从中间开始校验时以上一条记录为起点", func() {
+			So(CheckNotarizationChain(records[1], records[2:]), ShouldBeEmpty)
+		})
+
+		Convey("修改记录内容后该记录哈希不一致", func() {
+			records[1].FileSize = 2048
+			issues := CheckNotarizationChain(nil, records)
+			So(issues, ShouldHaveLength, 1)
+			So(issues[0].Sequence, ShouldEqual, 2)
+			So(issues[0].Reason, ShouldContainSubstring, "record_hash mismatch")
+		})
+
+		Convey("删除记录后序号和链接都断开", func() {
+			records = append(records[:1], records[2:]...)
+			issues := CheckNotarizationChain(nil, records)
+			So(issues, ShouldHaveLength, 2)
+			So(issues[0].Sequence, ShouldEqual, 3)
+			So(issues[0].Reason, ShouldContainSubstring, "expected 2")
+			So(issues[1].Reason, ShouldContainSubstring, "prev_hash")
+		})
+
+		Convey("重算被修改记录的哈希后下一条记录的链接断开", func() {
+			records[1].FileSize = 2048
+			records[1].RecordHash = NotarizationRecordHash(records[1])
+			issues := CheckNotarizationChain(nil, records)
+			So(issues, ShouldHaveLength, 1)
+			So(issues[0].Sequence, ShouldEqual, 3)
+			So(issues[0].Reason, ShouldContainSubstring, "prev_hash")
+		})
+	})
+}
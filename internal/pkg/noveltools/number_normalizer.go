@@ -0,0 +1,360 @@
+package noveltools
+
+import (
+	"strconv"
+	"strings"
+
+	"lemon/internal/model/novel"
+)
+
+// NormalizeNumbers 按数字读法统一解说文本中的数字写法
+//   - chinese：阿拉伯数字转为中文数字（3万 → 三万，2024年 → 二零二四年，15% → 百分之十五，10:30 → 十点三十分），便于 TTS 稳定朗读
+//   - arabic：中文数字转为阿拉伯数字（三万 → 3万，二零二四年 → 2024年，百分之十五 → 15%），成语和无法确定读法的写法保持不变
+//   - none 或空：不做转换
+func NormalizeNumbers(text string, style novel.NumberStyle) string {
+	switch style {
+	case novel.NumberStyleChinese:
+		return digitsToChinese(text)
+	case novel.NumberStyleArabic:
+		return chineseToDigits(text)
+	default:
+		return text
+	}
+}
+
+var chineseDigits = []rune("零一二三四五六七八九")
+
+// liangMeasureWords 数字 2 后面跟这些量词时读作「两」（两个、两天）
+const liangMeasureWords = "个只条位把次天本件人名头匹张辆块斤倍种层岁场声句杯碗口家间座支根双对步"
+
+// digitsToChinese 将文本中的阿拉伯数字转为中文数字
+func digitsToChinese(text string) string {
+	runes := []rune(text)
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		if _, ok := asciiDigit(runes[i]); !ok {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+
+		// 整数部分（允许 1,000 形式的千分位）
+		var intPart []rune
+		j := i
+		for j < len(runes) {
+			if d, ok := asciiDigit(runes[j]); ok {
+				intPart = append(intPart, d)
+				j++
+				continue
+			}
+			if (runes[j] == ',' || runes[j] == '，') && len(intPart) > 0 && isThousandsGroup(runes, j+1) {
+				j++
+				continue
+			}
+			break
+		}
+
+		// 小数部分
+		var fracPart []rune
+		if j+1 < len(runes) && runes[j] == '.' {
+			if _, ok := asciiDigit(runes[j+1]); ok {
+				j++
+				for j < len(runes) {
+					d, ok := asciiDigit(runes[j])
+					if !ok {
+						break
+					}
+					fracPart = append(fracPart, d)
+					j++
+				}
+			}
+		}
+
+		var next rune
+		if j < len(runes) {
+			next = runes[j]
+		}
+
+		switch {
+		case next == '%' || next == '％':
+			b.WriteString("百分之")
+			b.WriteString(readNumber(intPart, fracPart))
+			j++
+		case next == '年' && len(intPart) == 4 && fracPart == nil:
+			// 年份逐位朗读
+			b.WriteString(readDigits(intPart))
+		case next == ':' && fracPart == nil && isClockTime(runes, intPart, j):
+			hour, _ := strconv.Atoi(string(intPart))
+			minute, _ := strconv.Atoi(string(runes[j+1 : j+3]))
+			b.WriteString(readInteger(int64(hour)))
+			b.WriteString("点")
+			if minute > 0 {
+				if minute < 10 {
+					b.WriteString("零")
+				}
+				b.WriteString(readInteger(int64(minute)))
+				b.WriteString("分")
+			}
+			j += 3
+		case string(intPart) == "2" && fracPart == nil && next != 0 && strings.ContainsRune(liangMeasureWords, next):
+			b.WriteString("两")
+		default:
+			b.WriteString(readNumber(intPart, fracPart))
+		}
+		i = j
+	}
+	return b.String()
+}
+
+// asciiDigit 识别半角和全角数字，返回对应的半角数字
+func asciiDigit(r rune) (rune, bool) {
+	switch {
+	case r >= '0' && r <= '9':
+		return r, true
+	case r >= '０' && r <= '９':
+		return '0' + (r - '０'), true
+	}
+	return 0, false
+}
+
+// isThousandsGroup 判断 start 处是否恰好是 3 位数字的千分位分组
+func isThousandsGroup(runes []rune, start int) bool {
+	if start+3 > len(runes) {
+		return false
+	}
+	for k := start; k < start+3; k++ {
+		if _, ok := asciiDigit(runes[k]); !ok {
+			return false
+		}
+	}
+	if start+3 < len(runes) {
+		if _, ok := asciiDigit(runes[start+3]); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// isClockTime 判断是否为 HH:MM 形式的时间
+func isClockTime(runes, hourDigits []rune, colon int) bool {
+	if len(hourDigits) > 2 || colon+3 > len(runes) {
+		return false
+	}
+	for k := colon + 1; k < colon+3; k++ {
+		if runes[k] < '0' || runes[k] > '9' {
+			return false
+		}
+	}
+	if colon+3 < len(runes) && runes[colon+3] >= '0' && runes[colon+3] <= '9' {
+		return false
+	}
+	hour, _ := strconv.Atoi(string(hourDigits))
+	minute, _ := strconv.Atoi(string(runes[colon+1 : colon+3]))
+	return hour <= 24 && minute < 60
+}
+
+// readNumber 朗读数字：带前导零或 11 位以上的数字（编号、手机号等）逐位朗读，其余按数值朗读
+func readNumber(intPart, fracPart []rune) string {
+	var s string
+	if (len(intPart) > 1 && intPart[0] == '0') || len(intPart) > 10 {
+		s = readDigits(intPart)
+	} else {
+		n, _ := strconv.ParseInt(string(intPart), 10, 64)
+		s = readInteger(n)
+	}
+	if len(fracPart) > 0 {
+		s += "点" + readDigits(fracPart)
+	}
+	return s
+}
+
+// readDigits 逐位朗读数字
+func readDigits(digits []rune) string {
+	var b strings.Builder
+	for _, d := range digits {
+		b.WriteRune(chineseDigits[d-'0'])
+	}
+	return b.String()
+}
+
+// readInteger 按数值朗读整数（支持到万亿级）
+func readInteger(n int64) string {
+	if n == 0 {
+		return "零"
+	}
+
+	sectionUnits := []string{"", "万", "亿", "万亿"}
+	var sections []int64
+	for n > 0 {
+		sections = append(sections, n%10000)
+		n /= 10000
+	}
+
+	var b strings.Builder
+	needZero := false
+	for i := len(sections) - 1; i >= 0; i-- {
+		sec := sections[i]
+		if sec == 0 {
+			needZero = b.Len() > 0
+			continue
+		}
+		if b.Len() > 0 && (needZero || sec < 1000) {
+			b.WriteString("零")
+		}
+		b.WriteString(readSection(int(sec)))
+		b.WriteString(sectionUnits[i])
+		needZero = false
+	}
+
+	s := b.String()
+	// 10~19 习惯读作「十X」而不是「一十X」
+	if strings.HasPrefix(s, "一十") {
+		s = strings.TrimPrefix(s, "一")
+	}
+	return s
+}
+
+// readSection 朗读 4 位以内的数字段
+func readSection(sec int) string {
+	units := []string{"千", "百", "十", ""}
+	divisors := []int{1000, 100, 10, 1}
+	var b strings.Builder
+	zero := false
+	for i, d := range divisors {
+		digit := sec / d % 10
+		if digit == 0 {
+			zero = b.Len() > 0
+			continue
+		}
+		if zero {
+			b.WriteString("零")
+			zero = false
+		}
+		b.WriteRune(chineseDigits[digit])
+		b.WriteString(units[i])
+	}
+	return b.String()
+}
+
+// chineseNumeralRunes 中文数字（数位和单位）
+const chineseNumeralRunes = "零〇一二两三四五六七八九十百千万亿"
+
+// chineseToDigits 将文本中的中文数字转为阿拉伯数字
+// 只转换读法明确的写法：带单位的数值（三十六、一千零一）、三位以上的逐位数字（二零二四）、百分数；
+// 单个汉字（一个、十分）和不符合数值规则的组合（三三两两、一五一十）保持不变
+func chineseToDigits(text string) string {
+	runes := []rune(text)
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		// 百分之X
+		if hasPrefixAt(runes, i, "百分之") {
+			end := scanChineseNumeral(runes, i+3)
+			if v, ok := parseChineseNumeral(runes[i+3 : end]); ok && end > i+3 {
+				b.WriteString(v)
+				b.WriteRune('%')
+				i = end
+				continue
+			}
+		}
+
+		if !strings.ContainsRune(chineseNumeralRunes, runes[i]) {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+
+		end := scanChineseNumeral(runes, i)
+		run := runes[i:end]
+		if v, ok := convertChineseRun(run, runes, end); ok {
+			b.WriteString(v)
+		} else {
+			b.WriteString(string(run))
+		}
+		i = end
+	}
+	return b.String()
+}
+
+// hasPrefixAt 判断 runes 在 i 处是否以 prefix 开头
+func hasPrefixAt(runes []rune, i int, prefix string) bool {
+	p := []rune(prefix)
+	if i+len(p) > len(runes) {
+		return false
+	}
+	return string(runes[i:i+len(p)]) == prefix
+}
+
+// scanChineseNumeral 返回从 start 开始的连续中文数字的结束位置
+func scanChineseNumeral(runes []rune, start int) int {
+	end := start
+	for end < len(runes) && strings.ContainsRune(chineseNumeralRunes, runes[end]) {
+		end++
+	}
+	return end
+}
+
+// convertChineseRun 转换一段连续的中文数字，无法确定读法时返回 false
+func convertChineseRun(run, runes []rune, end int) (string, bool) {
+	if len(run) < 2 {
+		return "", false
+	}
+
+	// 逐位数字（二零二四年）：三位以上，或后面紧跟「年」
+	if !strings.ContainsAny(string(run), "十百千万亿两") {
+		if len(run) < 3 && (end >= len(runes) || runes[end] != '年') {
+			return "", false
+		}
+		var b strings.Builder
+		for _, r := range run {
+			b.WriteRune('0' + chineseDigitValue(r))
+		}
+		return b.String(), true
+	}
+
+	return parseChineseNumeral(run)
+}
+
+// parseChineseNumeral 解析带单位的中文数字
+// 只有末尾一个「万」「亿」单位时保留单位（三万 → 3万），其余转为完整数值（三万五千 → 35000，一亿三千万 → 130000000）
+func parseChineseNumeral(run []rune) (string, bool) {
+	if len(run) == 0 {
+		return "", false
+	}
+	// 必须以数字开头，或以「十」开头且后面还有数字（十五）
+	first := run[0]
+	if chineseDigitValue(first) < 0 && !(first == '十' && (len(run) == 1 || chineseDigitValue(run[1]) >= 0)) {
+		return "", false
+	}
+
+	suffix := ""
+	if last := run[len(run)-1]; (last == '万' || last == '亿') && !strings.ContainsAny(string(run[:len(run)-1]), "万亿") {
+		suffix = string(last)
+		run = run[:len(run)-1]
+	}
+
+	parts := splitKeep(run, '亿')
+	if len(parts) > 2 {
+		return "", false
+	}
+	var total, section int64
+	for _, part := range parts {
+		if part.unit == '亿' {
+			v, ok := parseWan(part.runes)
+			if !ok {
+				return "", false
+			}
+			total += v * 100000000
+			continue
+		}
+		v, ok := parseWan(part.runes)
+		if !ok {
+			return "", false
+		}
+		section = v
+	}
+	total += section
+	if total == 0 && suffix == "" {
+		return "", false
+	}
+	return strconv.FormatInt(total, 10) + suffix, true
+}
@@ -0,0 +1,64 @@
+package noveltools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestNormalizeNumbers(t *testing.T) {
+	Convey("NormalizeNumbers 统一解说文本中的数字写法", t, func() {
+		Convey("chinese：阿拉伯数字转为中文数字", func() {
+			cases := map[string]string{
+				"他拿出3万块":         "他拿出三万块",
+				"2024年3月5日":      "二零二四年三月五日",
+				"增长了15%":         "增长了百分之十五",
+				"约3.14米":         "约三点一四米",
+				"10:30出发，10:05到": "十点三十分出发，十点零五分到",
+				"第105章":          "第一百零五章",
+				"共1001人":         "共一千零一人",
+				"1,000,000元":     "一百万元",
+				"2个人":            "两个人",
+				"编号007":          "编号零零七",
+				"100000001":      "一亿零一",
+				"手机13800138000":  "手机一三八零零一三八零零零",
+				"１２":             "十二",
+				"没有数字的句子":        "没有数字的句子",
+			}
+			for in, want := range cases {
+				So(NormalizeNumbers(in, novel.NumberStyleChinese), ShouldEqual, want)
+			}
+		})
+
+		Convey("arabic：中文数字转为阿拉伯数字", func() {
+			cases := map[string]string{
+				"他拿出三万块":   "他拿出3万块",
+				"二零二四年":    "2024年",
+				"增长了百分之十五": "增长了15%",
+				"一千零一夜":    "1001夜",
+				"三十六计":     "36计",
+				"一百二":      "120",
+				"一百零二":     "102",
+				"两千五百":     "2500",
+				"三万五千":     "35000",
+				"一亿三千万":    "130000000",
+			}
+			for in, want := range cases {
+				So(NormalizeNumbers(in, novel.NumberStyleArabic), ShouldEqual, want)
+			}
+		})
+
+		Convey("arabic：成语和单个数字保持不变", func() {
+			for _, in := range []string{"三三两两", "一五一十", "十分", "一个人", "万一", "千万小心", "九九八十一", "一心一意"} {
+				So(NormalizeNumbers(in, novel.NumberStyleArabic), ShouldEqual, in)
+			}
+		})
+
+		Convey("none 或空不做转换", func() {
+			So(NormalizeNumbers("3万和三万", novel.NumberStyleNone), ShouldEqual, "3万和三万")
+			So(NormalizeNumbers("3万和三万", ""), ShouldEqual, "3万和三万")
+		})
+	})
+}
@@ -0,0 +1,103 @@
+package noveltools
+
+import (
+	"errors"
+	"fmt"
+
+	"lemon/internal/model/novel"
+)
+
+// DefaultOnboardingPreset 未指定预设时使用的预设名称
+const DefaultOnboardingPreset = "standard"
+
+// ErrUnknownOnboardingPreset 预设不存在
+var ErrUnknownOnboardingPreset = errors.New("unknown onboarding preset")
+
+// OnboardingPreset 新用户开通时使用的创作默认配置预设
+type OnboardingPreset struct {
+	Name        string                `json:"name"`        // 预设名称
+	Description string                `json:"description"` // 说明
+	Profile     *novel.CreatorProfile `json:"profile"`     // 预设内容（不含 ID 和用户信息）
+}
+
+// onboardingPresets 内置预设（按展示顺序）
+// 每次调用返回新的实例，调用方可以直接修改
+func onboardingPresets() []*OnboardingPreset {
+	return []*OnboardingPreset{
+		{
+			Name:        "standard",
+			Description: "通用横屏解说：漫剧风格旁白、中文数字朗读、默认字幕样式",
+			Profile: &novel.CreatorProfile{
+				NarrationType: novel.NarrationTypeNarration,
+				Style:         novel.NovelStyleAnime,
+				NumberStyle:   novel.NumberStyleChinese,
+				Continuity:    novel.ChapterContinuityNone,
+				Metadata:      &novel.CreativeMetadata{},
+				Render: &novel.RenderSettings{
+					Platform:  novel.TargetPlatformDefault,
+					Voice:     &novel.VoiceSettings{SpeedRatio: 1.2},
+					Subtitle:  &novel.SubtitleStyle{},
+					IntroText: "{{title}}",
+					OutroText: "未完待续",
+				},
+			},
+		},
+		{
+			Name:        "douyin_comic",
+			Description: "抖音漫剧：节奏更快的配音、更大的字幕、章节开头叠加前情回顾",
+			Profile: &novel.CreatorProfile{
+				NarrationType: novel.NarrationTypeNarration,
+				Style:         novel.NovelStyleAnime,
+				NumberStyle:   novel.NumberStyleChinese,
+				Continuity:    novel.ChapterContinuityRecapOverlay,
+				Metadata: &novel.CreativeMetadata{
+					Tone:     "节奏紧凑、悬念迭起",
+					Audience: "短视频用户",
+				},
+				Render: &novel.RenderSettings{
+					Platform:  novel.TargetPlatformDouyin,
+					Voice:     &novel.VoiceSettings{SpeedRatio: 1.3},
+					Subtitle:  &novel.SubtitleStyle{FontSize: 44, Outline: 3},
+					IntroText: "{{title}}",
+					OutroText: "关注我，下集更精彩",
+				},
+			},
+		},
+		{
+			Name:        "bilibili_live",
+			Description: "哔哩哔哩真人剧：真人风格、语速适中、用上一章最后一帧衔接开头",
+			Profile: &novel.CreatorProfile{
+				NarrationType: novel.NarrationTypeNarration,
+				Style:         novel.NovelStyleLive,
+				NumberStyle:   novel.NumberStyleChinese,
+				Continuity:    novel.ChapterContinuityReferenceFrame,
+				Metadata:      &novel.CreativeMetadata{},
+				Render: &novel.RenderSettings{
+					Platform:  novel.TargetPlatformBilibili,
+					Voice:     &novel.VoiceSettings{SpeedRatio: 1.1},
+					Subtitle:  &novel.SubtitleStyle{FontSize: 40, HighlightColor: "#66CCFF"},
+					IntroText: "{{title}}",
+					OutroText: "未完待续",
+				},
+			},
+		},
+	}
+}
+
+// OnboardingPresets 返回所有内置预设
+func OnboardingPresets() []*OnboardingPreset {
+	return onboardingPresets()
+}
+
+// FindOnboardingPreset 按名称查找内置预设，名称为空时返回默认预设
+func FindOnboardingPreset(name string) (*OnboardingPreset, error) {
+	if name == "" {
+		name = DefaultOnboardingPreset
+	}
+	for _, preset := range onboardingPresets() {
+		if preset.Name == name {
+			return preset, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownOnboardingPreset, name)
+}
@@ -0,0 +1,41 @@
+package noveltools
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOnboardingPresets(t *testing.T) {
+	Convey("内置预设都是合法的配置", t, func() {
+		for _, preset := range OnboardingPresets() {
+			p := preset.Profile
+			So(p.NarrationType, ShouldNotBeEmpty)
+			So(p.Style, ShouldNotBeEmpty)
+			So(p.NumberStyle.IsValid(), ShouldBeTrue)
+			So(p.Continuity.IsValid(), ShouldBeTrue)
+			So(p.Render.Platform.IsValid(), ShouldBeTrue)
+			So(ValidateSubtitleStyle(p.Render.Subtitle), ShouldBeNil)
+			_, err := NormalizeCreativeMetadata(p.Metadata)
+			So(err, ShouldBeNil)
+		}
+	})
+
+	Convey("FindOnboardingPreset 查找预设", t, func() {
+		preset, err := FindOnboardingPreset("")
+		So(err, ShouldBeNil)
+		So(preset.Name, ShouldEqual, DefaultOnboardingPreset)
+
+		Convey("每次返回新的实例", func() {
+			preset.Profile.Render.OutroText = "changed"
+			again, _ := FindOnboardingPreset(DefaultOnboardingPreset)
+			So(again.Profile.Render.OutroText, ShouldNotEqual, "changed")
+		})
+
+		Convey("未知预设返回错误", func() {
+			_, err := FindOnboardingPreset("unknown")
+			So(errors.Is(err, ErrUnknownOnboardingPreset), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,20 @@
+package noveltools
+
+import (
+	"math"
+	"slices"
+)
+
+// Percentile 按最近秩法（nearest-rank）计算百分位数，p 取值 0~100（超出范围时按边界处理）
+// values 为空时返回 0；不修改 values
+func Percentile(values []int64, p float64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	rank = min(max(rank, 1), len(sorted))
+	return sorted[rank-1]
+}
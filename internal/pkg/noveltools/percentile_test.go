@@ -0,0 +1,29 @@
+package noveltools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPercentile(t *testing.T) {
+	Convey("Percentile 按最近秩法计算百分位数", t, func() {
+		Convey("空数据返回 0", func() {
+			So(Percentile(nil, 95), ShouldEqual, 0)
+		})
+
+		Convey("取排序后第 ceil(p/100*n) 个值，不修改输入", func() {
+			values := []int64{9000, 1000, 5000, 3000, 7000, 2000, 8000, 4000, 10000, 6000}
+			So(Percentile(values, 50), ShouldEqual, 5000)
+			So(Percentile(values, 95), ShouldEqual, 10000)
+			So(Percentile(values, 90), ShouldEqual, 9000)
+			So(values[0], ShouldEqual, 9000)
+		})
+
+		Convey("超出范围时按边界处理", func() {
+			values := []int64{30, 10, 20}
+			So(Percentile(values, 0), ShouldEqual, 10)
+			So(Percentile(values, 150), ShouldEqual, 30)
+		})
+	})
+}
@@ -0,0 +1,167 @@
+package noveltools
+
+import (
+	"time"
+
+	"lemon/internal/model/novel"
+)
+
+// stageArtifacts 各阶段产出的章节产物
+var stageArtifacts = map[novel.PipelineStage]string{
+	novel.PipelineStageNarration:      "narration",
+	novel.PipelineStageAudio:          "audio",
+	novel.PipelineStageSubtitle:       "subtitle",
+	novel.PipelineStageImage:          "image",
+	novel.PipelineStageNarrationVideo: "narration_video",
+	novel.PipelineStageFinalVideo:     "final_video",
+}
+
+// PipelineGraph 章节渲染流程的依赖图（用于前端绘制流程图）
+type PipelineGraph struct {
+	ChapterID    string                `json:"chapter_id"`
+	Nodes        []PipelineNode        `json:"nodes"`         // 阶段节点（按流程顺序）
+	Edges        []PipelineEdge        `json:"edges"`         // 依赖边（上游 -> 下游）
+	CriticalPath []novel.PipelineStage `json:"critical_path"` // 关键路径：耗时最长的依赖链（到最终视频）
+	Blocking     []novel.PipelineStage `json:"blocking"`      // 阻塞完成的阶段：关键路径上上游已完成、自身未完成的阶段
+	RemainingMs  int64                 `json:"remaining_ms"`  // 关键路径上未完成阶段的预计剩余耗时（毫秒）
+	Progress     float64               `json:"progress"`      // 进度（0~1）
+	UpdatedAt    *time.Time            `json:"updated_at,omitempty"`
+}
+
+// PipelineNode 阶段节点
+type PipelineNode struct {
+	novel.ChapterStageProgress
+	Artifact    string                `json:"artifact"`               // 阶段产出的产物类型
+	DependsOn   []novel.PipelineStage `json:"depends_on"`             // 直接上游阶段
+	Ready       bool                  `json:"ready"`                  // 上游已全部完成、自身未完成（可以执行）
+	EstimatedMs int64                 `json:"estimated_ms,omitempty"` // 预计耗时（毫秒），取最近一次成功执行的耗时，没有记录时为 0
+	WeightMs    int64                 `json:"weight_ms"`              // 计算关键路径使用的耗时：已完成取实际耗时，未完成取预计耗时（执行中不少于已执行时长）
+	Critical    bool                  `json:"critical"`               // 是否在关键路径上
+}
+
+// PipelineEdge 依赖边
+type PipelineEdge struct {
+	From novel.PipelineStage `json:"from"` // 上游阶段
+	To   novel.PipelineStage `json:"to"`   // 下游阶段
+}
+
+// PipelineDependencies 返回阶段的直接上游阶段（按流程顺序）
+func PipelineDependencies(stage novel.PipelineStage) []novel.PipelineStage {
+	var deps []novel.PipelineStage
+	for _, upstream := range novel.AllPipelineStages {
+		for _, next := range artifactDependents[upstream] {
+			if next == stage {
+				deps = append(deps, upstream)
+			}
+		}
+	}
+	return deps
+}
+
+// BuildPipelineGraph 根据章节流程状态和各阶段的预计耗时构建依赖图，并计算关键路径
+// 关键路径为到最终视频耗时之和最大的依赖链（耗时相同时取流程中靠前的上游）；
+// now 用于计算执行中阶段已执行的时长
+func BuildPipelineGraph(state *novel.ChapterPipelineState, estimates map[novel.PipelineStage]int64, now time.Time) *PipelineGraph {
+	graph := &PipelineGraph{
+		ChapterID:    state.ChapterID,
+		Nodes:        make([]PipelineNode, 0, len(state.Stages)),
+		Edges:        []PipelineEdge{},
+		CriticalPath: []novel.PipelineStage{},
+		Blocking:     []novel.PipelineStage{},
+		Progress:     state.Progress,
+		UpdatedAt:    state.UpdatedAt,
+	}
+
+	index := make(map[novel.PipelineStage]int, len(state.Stages))
+	for _, p := range state.Stages {
+		node := PipelineNode{
+			ChapterStageProgress: p,
+			Artifact:             stageArtifacts[p.Stage],
+			DependsOn:            PipelineDependencies(p.Stage),
+			EstimatedMs:          estimates[p.Stage],
+		}
+		if node.DependsOn == nil {
+			node.DependsOn = []novel.PipelineStage{}
+		}
+		node.WeightMs = stageWeight(p, node.EstimatedMs, now)
+		index[p.Stage] = len(graph.Nodes)
+		graph.Nodes = append(graph.Nodes, node)
+		for _, dep := range node.DependsOn {
+			graph.Edges = append(graph.Edges, PipelineEdge{From: dep, To: p.Stage})
+		}
+	}
+
+	// 可执行：上游全部完成且自身未完成
+	for i := range graph.Nodes {
+		node := &graph.Nodes[i]
+		if node.State == novel.StageStateCompleted {
+			continue
+		}
+		node.Ready = true
+		for _, dep := range node.DependsOn {
+			if j, ok := index[dep]; !ok || graph.Nodes[j].State != novel.StageStateCompleted {
+				node.Ready = false
+				break
+			}
+		}
+	}
+
+	// 最长路径：节点按流程顺序即为拓扑序
+	longest := make(map[novel.PipelineStage]int64, len(graph.Nodes))
+	prev := make(map[novel.PipelineStage]novel.PipelineStage, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		var best int64
+		for _, dep := range node.DependsOn {
+			if _, ok := index[dep]; !ok {
+				continue
+			}
+			if _, chosen := prev[node.Stage]; !chosen || longest[dep] > best {
+				best = longest[dep]
+				prev[node.Stage] = dep
+			}
+		}
+		longest[node.Stage] = best + node.WeightMs
+	}
+
+	end, ok := index[novel.PipelineStageFinalVideo]
+	if !ok {
+		return graph
+	}
+	var path []novel.PipelineStage
+	for stage := graph.Nodes[end].Stage; ; {
+		path = append([]novel.PipelineStage{stage}, path...)
+		dep, ok := prev[stage]
+		if !ok {
+			break
+		}
+		stage = dep
+	}
+	for _, stage := range path {
+		node := &graph.Nodes[index[stage]]
+		node.Critical = true
+		graph.CriticalPath = append(graph.CriticalPath, stage)
+		if node.State == novel.StageStateCompleted {
+			continue
+		}
+		graph.RemainingMs += node.WeightMs
+		if node.Ready {
+			graph.Blocking = append(graph.Blocking, stage)
+		}
+	}
+	return graph
+}
+
+// stageWeight 计算关键路径使用的阶段耗时
+func stageWeight(p novel.ChapterStageProgress, estimate int64, now time.Time) int64 {
+	switch p.State {
+	case novel.StageStateCompleted:
+		return p.DurationMs
+	case novel.StageStateRunning:
+		if p.LastStartedAt != nil {
+			if elapsed := now.Sub(*p.LastStartedAt).Milliseconds(); elapsed > estimate {
+				return elapsed
+			}
+		}
+	}
+	return estimate
+}
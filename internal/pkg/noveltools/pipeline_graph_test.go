@@ -0,0 +1,111 @@
+package noveltools
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func pipelineState(states map[novel.PipelineStage]novel.ChapterStageProgress) *novel.ChapterPipelineState {
+	state := &novel.ChapterPipelineState{ChapterID: "ch-1", TotalStages: len(novel.AllPipelineStages)}
+	for _, stage := range novel.AllPipelineStages {
+		p, ok := states[stage]
+		if !ok {
+			p = novel.ChapterStageProgress{State: novel.StageStatePending}
+		}
+		p.Stage = stage
+		state.Stages = append(state.Stages, p)
+	}
+	return state
+}
+
+func graphNode(graph *PipelineGraph, stage novel.PipelineStage) PipelineNode {
+	for _, node := range graph.Nodes {
+		if node.Stage == stage {
+			return node
+		}
+	}
+	return PipelineNode{}
+}
+
+func TestPipelineDependencies(t *testing.T) {
+	Convey("PipelineDependencies 返回直接上游阶段", t, func() {
+		So(PipelineDependencies(novel.PipelineStageNarration), ShouldBeEmpty)
+		So(PipelineDependencies(novel.PipelineStageNarrationVideo), ShouldResemble, []novel.PipelineStage{
+			novel.PipelineStageAudio,
+			novel.PipelineStageImage,
+		})
+		So(PipelineDependencies(novel.PipelineStageFinalVideo), ShouldResemble, []novel.PipelineStage{
+			novel.PipelineStageSubtitle,
+			novel.PipelineStageNarrationVideo,
+		})
+	})
+}
+
+func TestBuildPipelineGraph(t *testing.T) {
+	Convey("BuildPipelineGraph 构建依赖图并计算关键路径", t, func() {
+		now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+		completed := func(ms int64) novel.ChapterStageProgress {
+			return novel.ChapterStageProgress{State: novel.StageStateCompleted, DurationMs: ms}
+		}
+
+		Convey("边与产物依赖一致", func() {
+			graph := BuildPipelineGraph(pipelineState(nil), nil, now)
+			So(len(graph.Nodes), ShouldEqual, len(novel.AllPipelineStages))
+			So(len(graph.Edges), ShouldEqual, 7)
+			So(graph.Edges, ShouldContain, PipelineEdge{From: novel.PipelineStageImage, To: novel.PipelineStageNarrationVideo})
+			So(graphNode(graph, novel.PipelineStageAudio).Artifact, ShouldEqual, "audio")
+		})
+
+		Convey("关键路径取耗时最长的依赖链，阻塞阶段为上游已完成的未完成阶段", func() {
+			graph := BuildPipelineGraph(pipelineState(map[novel.PipelineStage]novel.ChapterStageProgress{
+				novel.PipelineStageNarration: completed(1000),
+				novel.PipelineStageAudio:     completed(2000),
+				novel.PipelineStageSubtitle:  completed(100),
+			}), map[novel.PipelineStage]int64{
+				novel.PipelineStageImage:          30000,
+				novel.PipelineStageNarrationVideo: 60000,
+				novel.PipelineStageFinalVideo:     5000,
+			}, now)
+
+			So(graph.CriticalPath, ShouldResemble, []novel.PipelineStage{
+				novel.PipelineStageNarration,
+				novel.PipelineStageImage,
+				novel.PipelineStageNarrationVideo,
+				novel.PipelineStageFinalVideo,
+			})
+			So(graph.Blocking, ShouldResemble, []novel.PipelineStage{novel.PipelineStageImage})
+			So(graph.RemainingMs, ShouldEqual, 95000)
+			So(graphNode(graph, novel.PipelineStageImage).Ready, ShouldBeTrue)
+			So(graphNode(graph, novel.PipelineStageNarrationVideo).Ready, ShouldBeFalse)
+			So(graphNode(graph, novel.PipelineStageAudio).Critical, ShouldBeFalse)
+		})
+
+		Convey("执行中的阶段耗时不少于已执行时长", func() {
+			started := now.Add(-90 * time.Second)
+			graph := BuildPipelineGraph(pipelineState(map[novel.PipelineStage]novel.ChapterStageProgress{
+				novel.PipelineStageNarration: completed(1000),
+				novel.PipelineStageAudio:     {State: novel.StageStateRunning, LastStartedAt: &started},
+				novel.PipelineStageImage:     completed(30000),
+			}), map[novel.PipelineStage]int64{novel.PipelineStageAudio: 10000}, now)
+
+			So(graphNode(graph, novel.PipelineStageAudio).WeightMs, ShouldEqual, 90000)
+			So(graph.CriticalPath[1], ShouldEqual, novel.PipelineStageAudio)
+			So(graph.Blocking, ShouldResemble, []novel.PipelineStage{novel.PipelineStageAudio})
+		})
+
+		Convey("全部完成时没有阻塞阶段", func() {
+			states := map[novel.PipelineStage]novel.ChapterStageProgress{}
+			for _, stage := range novel.AllPipelineStages {
+				states[stage] = completed(1000)
+			}
+			graph := BuildPipelineGraph(pipelineState(states), nil, now)
+			So(graph.Blocking, ShouldBeEmpty)
+			So(graph.RemainingMs, ShouldEqual, 0)
+			So(graph.CriticalPath[len(graph.CriticalPath)-1], ShouldEqual, novel.PipelineStageFinalVideo)
+		})
+	})
+}
@@ -0,0 +1,52 @@
+package noveltools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"runtime"
+	"runtime/debug"
+
+	"lemon/internal/model/novel"
+)
+
+// HashPrompt 计算提示词/文本的 SHA256（十六进制），空字符串返回空（清单中省略）
+func HashPrompt(prompt string) string {
+	if prompt == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// ManifestToolVersions 返回清单中的工具版本：ffmpeg 版本由调用方探测，Go 版本和服务提交从构建信息读取
+func ManifestToolVersions(ffmpegVersion string) novel.ManifestTools {
+	tools := novel.ManifestTools{
+		FFmpegVersion: ffmpegVersion,
+		GoVersion:     runtime.Version(),
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				tools.AppRevision = setting.Value
+			}
+		}
+	}
+	return tools
+}
+
+// ManifestProviderInfo 把提供者描述转换为清单中的 provider 记录
+func ManifestProviderInfo(provider any) novel.ManifestProvider {
+	info := DescribeProvider(provider)
+	return novel.ManifestProvider{Name: info.Name, Model: info.Model}
+}
+
+// EncodePipelineManifest 把清单编码为缩进的 JSON，并返回内容的 SHA256（十六进制）
+func EncodePipelineManifest(manifest *novel.PipelineManifest) ([]byte, string, error) {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, "", err
+	}
+	sum := sha256.Sum256(data)
+	return data, hex.EncodeToString(sum[:]), nil
+}
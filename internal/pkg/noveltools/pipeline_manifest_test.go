@@ -0,0 +1,74 @@
+package noveltools
+
+import (
+	"encoding/json"
+	"runtime"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+type describedStub struct{}
+
+func (describedStub) Describe() ProviderInfo {
+	return ProviderInfo{Name: "stub", Model: "stub-v1"}
+}
+
+type undescribedStub struct{}
+
+func TestHashPrompt(t *testing.T) {
+	Convey("HashPrompt 计算提示词哈希", t, func() {
+		So(HashPrompt("镜头缓慢推进"), ShouldHaveLength, 64)
+		So(HashPrompt("镜头缓慢推进"), ShouldEqual, HashPrompt("镜头缓慢推进"))
+		So(HashPrompt("镜头缓慢推进"), ShouldNotEqual, HashPrompt("镜头缓慢拉远"))
+
+		Convey("空提示词返回空", func() {
+			So(HashPrompt(""), ShouldBeEmpty)
+		})
+	})
+}
+
+func TestManifestProviderInfo(t *testing.T) {
+	Convey("ManifestProviderInfo 记录提供者", t, func() {
+		So(ManifestProviderInfo(describedStub{}), ShouldResemble, novel.ManifestProvider{Name: "stub", Model: "stub-v1"})
+
+		Convey("未实现 Describe 时使用实现类型", func() {
+			So(ManifestProviderInfo(undescribedStub{}).Name, ShouldEqual, "noveltools.undescribedStub")
+		})
+
+		Convey("nil 提供者为空记录", func() {
+			So(ManifestProviderInfo(nil), ShouldResemble, novel.ManifestProvider{})
+		})
+	})
+}
+
+func TestEncodePipelineManifest(t *testing.T) {
+	Convey("EncodePipelineManifest 编码清单", t, func() {
+		manifest := &novel.PipelineManifest{
+			SchemaVersion: novel.PipelineManifestSchemaVersion,
+			VideoID:       "video-1",
+			ChapterID:     "chapter-1",
+			Clips: []novel.ManifestClip{
+				{VideoID: "clip-1", Sequence: 1, SequenceEnd: 3, Shots: []novel.ManifestShot{{ShotID: "shot-1", Index: 1, TextHash: HashPrompt("第一句")}}},
+			},
+			Tools: ManifestToolVersions("6.1.1"),
+		}
+
+		data, sum, err := EncodePipelineManifest(manifest)
+		So(err, ShouldBeNil)
+		So(sum, ShouldHaveLength, 64)
+
+		again, sumAgain, err := EncodePipelineManifest(manifest)
+		So(err, ShouldBeNil)
+		So(string(again), ShouldEqual, string(data))
+		So(sumAgain, ShouldEqual, sum)
+
+		var decoded novel.PipelineManifest
+		So(json.Unmarshal(data, &decoded), ShouldBeNil)
+		So(decoded.Clips[0].Shots[0].ShotID, ShouldEqual, "shot-1")
+		So(decoded.Tools.FFmpegVersion, ShouldEqual, "6.1.1")
+		So(decoded.Tools.GoVersion, ShouldEqual, runtime.Version())
+	})
+}
@@ -0,0 +1,80 @@
+package noveltools
+
+import (
+	"time"
+
+	"lemon/internal/model/novel"
+)
+
+// maxRunningStepPercent 执行中步骤估算进度的上限（执行时长超过预计耗时时停在该值，直到步骤完成）
+const maxRunningStepPercent = 95
+
+// FillPipelineRunProgress 计算一键生成流程各步骤和整体的进度百分比（原地写入 ProgressPercent）
+// 已完成或跳过的步骤为 100；执行中的步骤按已执行时长占预计耗时（estimates，取最近一次成功执行的耗时）的比例估算，没有预计耗时时为 0；
+// 整体进度为各步骤进度的平均值
+func FillPipelineRunProgress(run *novel.PipelineRun, estimates map[novel.PipelineStage]int64, now time.Time) {
+	if run == nil {
+		return
+	}
+	if len(run.Steps) == 0 {
+		run.ProgressPercent = 0
+		return
+	}
+
+	total := 0
+	for i := range run.Steps {
+		step := &run.Steps[i]
+		step.ProgressPercent = pipelineStepPercent(step, estimates[step.Stage], now)
+		total += step.ProgressPercent
+	}
+	run.ProgressPercent = total / len(run.Steps)
+}
+
+// pipelineStepPercent 单个步骤的进度百分比
+func pipelineStepPercent(step *novel.PipelineRunStep, estimateMs int64, now time.Time) int {
+	switch {
+	case step.Status.IsDone():
+		return 100
+	case step.Status != novel.PipelineStepStatusRunning || step.StartedAt == nil || estimateMs <= 0:
+		return 0
+	}
+	elapsedMs := now.Sub(*step.StartedAt).Milliseconds()
+	if elapsedMs <= 0 {
+		return 0
+	}
+	return int(min(elapsedMs*100/estimateMs, maxRunningStepPercent))
+}
+
+// NewPipelineRunSteps 创建一键生成流程的步骤（按流程顺序）：from 之前的步骤标记为跳过（沿用已有产物），from 为空时从解说开始
+// from 不是流程中的阶段时返回 false
+func NewPipelineRunSteps(from novel.PipelineStage) ([]novel.PipelineRunStep, bool) {
+	if from == "" {
+		from = novel.AllPipelineStages[0]
+	}
+	steps := make([]novel.PipelineRunStep, 0, len(novel.AllPipelineStages))
+	found := false
+	for _, stage := range novel.AllPipelineStages {
+		status := novel.PipelineStepStatusSkipped
+		if stage == from {
+			found = true
+		}
+		if found {
+			status = novel.PipelineStepStatusPending
+		}
+		steps = append(steps, novel.PipelineRunStep{Stage: stage, Status: status})
+	}
+	if !found {
+		return nil, false
+	}
+	return steps, true
+}
+
+// NextPipelineRunStep 返回流程中第一个未完成的步骤（失败或取消的步骤即为继续执行的起点），全部完成时返回 nil
+func NextPipelineRunStep(run *novel.PipelineRun) *novel.PipelineRunStep {
+	for i := range run.Steps {
+		if !run.Steps[i].Status.IsDone() {
+			return &run.Steps[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package noveltools
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestNewPipelineRunSteps(t *testing.T) {
+	Convey("NewPipelineRunSteps 按流程顺序创建步骤", t, func() {
+		Convey("未指定起点时从解说开始，所有步骤未开始", func() {
+			steps, ok := NewPipelineRunSteps("")
+			So(ok, ShouldBeTrue)
+			So(len(steps), ShouldEqual, len(novel.AllPipelineStages))
+			So(steps[0].Stage, ShouldEqual, novel.PipelineStageNarration)
+			for _, step := range steps {
+				So(step.Status, ShouldEqual, novel.PipelineStepStatusPending)
+			}
+		})
+
+		Convey("从后面的步骤开始时，之前的步骤标记为跳过", func() {
+			steps, ok := NewPipelineRunSteps(novel.PipelineStageImage)
+			So(ok, ShouldBeTrue)
+			So(steps[2].Status, ShouldEqual, novel.PipelineStepStatusSkipped)
+			So(steps[3].Stage, ShouldEqual, novel.PipelineStageImage)
+			So(steps[3].Status, ShouldEqual, novel.PipelineStepStatusPending)
+			So(steps[5].Status, ShouldEqual, novel.PipelineStepStatusPending)
+		})
+
+		Convey("未知阶段返回 false", func() {
+			_, ok := NewPipelineRunSteps("cover")
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
+
+func TestNextPipelineRunStep(t *testing.T) {
+	Convey("NextPipelineRunStep 返回第一个未完成的步骤", t, func() {
+		steps, _ := NewPipelineRunSteps(novel.PipelineStageAudio)
+		run := &novel.PipelineRun{Steps: steps}
+		So(NextPipelineRunStep(run).Stage, ShouldEqual, novel.PipelineStageAudio)
+
+		run.Steps[1].Status = novel.PipelineStepStatusSucceeded
+		run.Steps[2].Status = novel.PipelineStepStatusFailed
+		So(NextPipelineRunStep(run).Stage, ShouldEqual, novel.PipelineStageSubtitle)
+
+		for i := range run.Steps {
+			run.Steps[i].Status = novel.PipelineStepStatusSucceeded
+		}
+		So(NextPipelineRunStep(run), ShouldBeNil)
+	})
+}
+
+func TestFillPipelineRunProgress(t *testing.T) {
+	Convey("FillPipelineRunProgress 计算步骤和整体进度", t, func() {
+		now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+		steps, _ := NewPipelineRunSteps(novel.PipelineStageAudio)
+		run := &novel.PipelineRun{Steps: steps}
+
+		Convey("跳过和完成的步骤为 100，执行中的步骤按预计耗时估算", func() {
+			started := now.Add(-30 * time.Second)
+			run.Steps[1].Status = novel.PipelineStepStatusSucceeded
+			run.Steps[2].Status = novel.PipelineStepStatusRunning
+			run.Steps[2].StartedAt = &started
+
+			FillPipelineRunProgress(run, map[novel.PipelineStage]int64{novel.PipelineStageSubtitle: 60000}, now)
+			So(run.Steps[0].ProgressPercent, ShouldEqual, 100)
+			So(run.Steps[1].ProgressPercent, ShouldEqual, 100)
+			So(run.Steps[2].ProgressPercent, ShouldEqual, 50)
+			So(run.Steps[3].ProgressPercent, ShouldEqual, 0)
+			So(run.ProgressPercent, ShouldEqual, 41)
+		})
+
+		Convey("执行时长超过预计耗时时停在上限，没有预计耗时时为 0", func() {
+			started := now.Add(-10 * time.Minute)
+			run.Steps[1].Status = novel.PipelineStepStatusRunning
+			run.Steps[1].StartedAt = &started
+
+			FillPipelineRunProgress(run, map[novel.PipelineStage]int64{novel.PipelineStageAudio: 60000}, now)
+			So(run.Steps[1].ProgressPercent, ShouldEqual, maxRunningStepPercent)
+
+			FillPipelineRunProgress(run, nil, now)
+			So(run.Steps[1].ProgressPercent, ShouldEqual, 0)
+		})
+
+		Convey("全部完成时为 100", func() {
+			for i := range run.Steps {
+				run.Steps[i].Status = novel.PipelineStepStatusSucceeded
+			}
+			FillPipelineRunProgress(run, nil, now)
+			So(run.ProgressPercent, ShouldEqual, 100)
+		})
+	})
+}
@@ -0,0 +1,105 @@
+package noveltools
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// promptLanguageNames 常见目标语言代码对应的语言名称（用于翻译提示词）
+var promptLanguageNames = map[string]string{
+	"en": "English",
+	"ja": "Japanese",
+	"ko": "Korean",
+}
+
+// NeedsTranslation 判断是否需要把中文提示词翻译为目标语言
+func (l PromptLocale) NeedsTranslation() bool {
+	lang := strings.ToLower(strings.TrimSpace(l.Language))
+	return lang != "" && lang != "zh" && !strings.HasPrefix(lang, "zh-")
+}
+
+// ContainsHan 判断文本是否包含汉字（不含汉字的提示词无需翻译）
+func ContainsHan(text string) bool {
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildPromptTranslationPrompt 构造把中文图片提示词翻译为目标语言的提示词
+func BuildPromptTranslationPrompt(prompt, language string) string {
+	name := promptLanguageNames[strings.ToLower(strings.TrimSpace(language))]
+	if name == "" {
+		name = language
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("请把下面的中文图片生成提示词翻译为%s，用于文生图模型。\n", name))
+	sb.WriteString("要求：\n")
+	sb.WriteString("1. 保留所有画面要素（人物、外貌、服饰、动作、场景、光影、构图、镜头），不要增删内容；\n")
+	sb.WriteString("2. 使用文生图模型常用的简洁描述短语，短语之间用英文逗号分隔；\n")
+	sb.WriteString("3. 人名、地名等专有名词使用拼音或意译，不要保留汉字；\n")
+	sb.WriteString("4. 只输出翻译后的提示词，不要输出解释、引号或其他内容。\n\n")
+	sb.WriteString("中文提示词：\n")
+	sb.WriteString(strings.TrimSpace(prompt))
+	return sb.String()
+}
+
+// CleanTranslatedPrompt 清理大模型返回的翻译结果（去掉代码块、引号和多余换行）
+func CleanTranslatedPrompt(output string) string {
+	text := strings.TrimSpace(output)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```")
+		if idx := strings.Index(text, "\n"); idx >= 0 {
+			text = text[idx+1:]
+		}
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	}
+	text = strings.Trim(strings.TrimSpace(text), "\"'“”")
+
+	lines := strings.FieldsFunc(text, func(r rune) bool { return r == '\n' || r == '\r' })
+	var parts []string
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			parts = append(parts, line)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// AppendStyleKeywords 在提示词末尾追加风格关键词（已包含的关键词不重复追加，忽略大小写）
+func AppendStyleKeywords(prompt string, keywords []string) string {
+	prompt = strings.TrimRight(strings.TrimSpace(prompt), ",，")
+	lower := strings.ToLower(prompt)
+
+	var extra []string
+	for _, keyword := range keywords {
+		keyword = strings.TrimSpace(keyword)
+		if keyword == "" || strings.Contains(lower, strings.ToLower(keyword)) {
+			continue
+		}
+		extra = append(extra, keyword)
+		lower += ", " + strings.ToLower(keyword)
+	}
+	if len(extra) == 0 {
+		return prompt
+	}
+	if prompt == "" {
+		return strings.Join(extra, ", ")
+	}
+	return prompt + ", " + strings.Join(extra, ", ")
+}
+
+// ParseStyleKeywords 解析逗号分隔的风格关键词列表（用于环境变量配置）
+func ParseStyleKeywords(value string) []string {
+	var keywords []string
+	for _, keyword := range strings.Split(value, ",") {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+	return keywords
+}
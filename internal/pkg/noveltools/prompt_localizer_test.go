@@ -0,0 +1,83 @@
+package noveltools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPromptLocaleNeedsTranslation(t *testing.T) {
+	Convey("PromptLocale 只在目标语言不是中文时需要翻译", t, func() {
+		So(PromptLocale{Language: "en"}.NeedsTranslation(), ShouldBeTrue)
+		So(PromptLocale{Language: " EN "}.NeedsTranslation(), ShouldBeTrue)
+		So(PromptLocale{Language: ""}.NeedsTranslation(), ShouldBeFalse)
+		So(PromptLocale{Language: "zh"}.NeedsTranslation(), ShouldBeFalse)
+		So(PromptLocale{Language: "zh-CN"}.NeedsTranslation(), ShouldBeFalse)
+	})
+}
+
+func TestContainsHan(t *testing.T) {
+	Convey("ContainsHan 判断文本是否包含汉字", t, func() {
+		So(ContainsHan("雪夜山门，少年持剑"), ShouldBeTrue)
+		So(ContainsHan("a boy holding a sword, 雪夜"), ShouldBeTrue)
+		So(ContainsHan("a boy holding a sword at night"), ShouldBeFalse)
+		So(ContainsHan(""), ShouldBeFalse)
+	})
+}
+
+func TestBuildPromptTranslationPrompt(t *testing.T) {
+	Convey("BuildPromptTranslationPrompt 构造翻译提示词", t, func() {
+		Convey("常见语言代码使用语言名称", func() {
+			prompt := BuildPromptTranslationPrompt(" 雪夜山门，少年持剑 ", "en")
+			So(prompt, ShouldContainSubstring, "翻译为English")
+			So(prompt, ShouldEndWith, "雪夜山门，少年持剑")
+		})
+
+		Convey("未知语言代码原样使用", func() {
+			prompt := BuildPromptTranslationPrompt("雪夜", "French")
+			So(prompt, ShouldContainSubstring, "翻译为French")
+		})
+	})
+}
+
+func TestCleanTranslatedPrompt(t *testing.T) {
+	Convey("CleanTranslatedPrompt 清理翻译结果", t, func() {
+		Convey("去掉代码块和多余换行", func() {
+			out := CleanTranslatedPrompt("```text\na young swordsman,\nsnowy night\n```")
+			So(out, ShouldEqual, "a young swordsman, snowy night")
+		})
+
+		Convey("去掉首尾引号", func() {
+			So(CleanTranslatedPrompt(" \"a young swordsman\" "), ShouldEqual, "a young swordsman")
+		})
+	})
+}
+
+func TestAppendStyleKeywords(t *testing.T) {
+	Convey("AppendStyleKeywords 追加风格关键词", t, func() {
+		Convey("追加未包含的关键词", func() {
+			out := AppendStyleKeywords("a young swordsman, snowy night,", []string{"masterpiece", " best quality "})
+			So(out, ShouldEqual, "a young swordsman, snowy night, masterpiece, best quality")
+		})
+
+		Convey("已包含的关键词和重复关键词不重复追加（忽略大小写）", func() {
+			out := AppendStyleKeywords("Masterpiece, a young swordsman", []string{"masterpiece", "cinematic", "Cinematic", ""})
+			So(out, ShouldEqual, "Masterpiece, a young swordsman, cinematic")
+		})
+
+		Convey("没有关键词时原样返回", func() {
+			So(AppendStyleKeywords("a young swordsman", nil), ShouldEqual, "a young swordsman")
+		})
+
+		Convey("提示词为空时只返回关键词", func() {
+			So(AppendStyleKeywords("", []string{"masterpiece", "best quality"}), ShouldEqual, "masterpiece, best quality")
+		})
+	})
+}
+
+func TestParseStyleKeywords(t *testing.T) {
+	Convey("ParseStyleKeywords 解析逗号分隔的关键词", t, func() {
+		So(ParseStyleKeywords(" masterpiece, best quality,, cinematic lighting "), ShouldResemble, []string{"masterpiece", "best quality", "cinematic lighting"})
+		So(ParseStyleKeywords(""), ShouldBeNil)
+	})
+}
@@ -0,0 +1,193 @@
+package noveltools
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"lemon/internal/model/novel"
+)
+
+// 内置提示词变量（提示词中用 {{title}}、{{genre}} 等引用）
+const (
+	PromptVarTitle        = "title"         // 小说名称
+	PromptVarAuthor       = "author"        // 作者
+	PromptVarGenre        = "genre"         // 题材
+	PromptVarTone         = "tone"          // 基调
+	PromptVarAudience     = "audience"      // 目标受众
+	PromptVarProtagonist  = "protagonist"   // 主角姓名
+	PromptVarBannedTopics = "banned_topics" // 禁止涉及的话题（顿号分隔）
+)
+
+// 创作设定限制
+const (
+	MaxPromptVariables      = 20  // 自定义变量个数上限
+	MaxBannedTopics         = 50  // 禁用话题个数上限
+	MaxPromptVariableLength = 200 // 单个取值的最大字数
+)
+
+// ErrInvalidCreativeMetadata 创作设定不合法（变量名不合法、与内置变量重名或取值过长）
+var ErrInvalidCreativeMetadata = errors.New("invalid creative metadata")
+
+var (
+	// promptVariablePattern 匹配提示词中的 {{变量名}}，允许花括号内有空格
+	promptVariablePattern = regexp.MustCompile(`\{\{\s*([a-z][a-z0-9_]*)\s*\}\}`)
+	// promptVariableNamePattern 自定义变量名：小写字母开头，只包含小写字母、数字和下划线
+	promptVariableNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+)
+
+// builtinPromptVariables 内置变量名（自定义变量不能与之重名）
+var builtinPromptVariables = map[string]bool{
+	PromptVarTitle:        true,
+	PromptVarAuthor:       true,
+	PromptVarGenre:        true,
+	PromptVarTone:         true,
+	PromptVarAudience:     true,
+	PromptVarProtagonist:  true,
+	PromptVarBannedTopics: true,
+}
+
+// PromptVariables 提示词变量（变量名 -> 取值）
+type PromptVariables map[string]string
+
+// NovelPromptVariables 根据小说信息和创作设定生成提示词变量，取值为空的变量不返回
+func NovelPromptVariables(n *novel.Novel) PromptVariables {
+	vars := PromptVariables{}
+	if n == nil {
+		return vars
+	}
+	set := func(name, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			vars[name] = value
+		}
+	}
+	set(PromptVarTitle, n.Title)
+	set(PromptVarAuthor, n.Author)
+
+	meta := n.Metadata
+	if meta == nil {
+		return vars
+	}
+	for name, value := range meta.Variables {
+		set(name, value)
+	}
+	set(PromptVarGenre, meta.Genre)
+	set(PromptVarTone, meta.Tone)
+	set(PromptVarAudience, meta.Audience)
+	set(PromptVarProtagonist, meta.Protagonist)
+	set(PromptVarBannedTopics, strings.Join(meta.BannedTopics, "、"))
+	return vars
+}
+
+// NormalizeCreativeMetadata 整理并校验创作设定：去掉首尾空白，禁用话题去重去空，
+// 自定义变量名必须是小写字母开头的 snake_case 且不能与内置变量重名
+func NormalizeCreativeMetadata(meta *novel.CreativeMetadata) (*novel.CreativeMetadata, error) {
+	if meta == nil {
+		return &novel.CreativeMetadata{}, nil
+	}
+
+	normalized := &novel.CreativeMetadata{
+		Genre:       strings.TrimSpace(meta.Genre),
+		Tone:        strings.TrimSpace(meta.Tone),
+		Audience:    strings.TrimSpace(meta.Audience),
+		Protagonist: strings.TrimSpace(meta.Protagonist),
+	}
+	for name, value := range map[string]string{
+		PromptVarGenre:       normalized.Genre,
+		PromptVarTone:        normalized.Tone,
+		PromptVarAudience:    normalized.Audience,
+		PromptVarProtagonist: normalized.Protagonist,
+	} {
+		if utf8.RuneCountInString(value) > MaxPromptVariableLength {
+			return nil, fmt.Errorf("%w: %s exceeds %d characters", ErrInvalidCreativeMetadata, name, MaxPromptVariableLength)
+		}
+	}
+
+	seen := make(map[string]bool, len(meta.BannedTopics))
+	for _, topic := range meta.BannedTopics {
+		topic = strings.TrimSpace(topic)
+		if topic == "" || seen[topic] {
+			continue
+		}
+		if utf8.RuneCountInString(topic) > MaxPromptVariableLength {
+			return nil, fmt.Errorf("%w: banned topic exceeds %d characters", ErrInvalidCreativeMetadata, MaxPromptVariableLength)
+		}
+		seen[topic] = true
+		normalized.BannedTopics = append(normalized.BannedTopics, topic)
+	}
+	if len(normalized.BannedTopics) > MaxBannedTopics {
+		return nil, fmt.Errorf("%w: at most %d banned topics", ErrInvalidCreativeMetadata, MaxBannedTopics)
+	}
+
+	if len(meta.Variables) > MaxPromptVariables {
+		return nil, fmt.Errorf("%w: at most %d custom variables", ErrInvalidCreativeMetadata, MaxPromptVariables)
+	}
+	for name, value := range meta.Variables {
+		if !promptVariableNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("%w: invalid variable name %q", ErrInvalidCreativeMetadata, name)
+		}
+		if builtinPromptVariables[name] {
+			return nil, fmt.Errorf("%w: variable %q is built in", ErrInvalidCreativeMetadata, name)
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if utf8.RuneCountInString(value) > MaxPromptVariableLength {
+			return nil, fmt.Errorf("%w: variable %q exceeds %d characters", ErrInvalidCreativeMetadata, name, MaxPromptVariableLength)
+		}
+		if normalized.Variables == nil {
+			normalized.Variables = make(map[string]string)
+		}
+		normalized.Variables[name] = value
+	}
+	return normalized, nil
+}
+
+// RenderPromptVariables 把提示词中的 {{变量名}} 替换为变量取值
+// 未定义的变量保持原样，避免误伤提示词中的示例文本
+func RenderPromptVariables(prompt string, vars PromptVariables) string {
+	if len(vars) == 0 || !strings.Contains(prompt, "{{") {
+		return prompt
+	}
+	return promptVariablePattern.ReplaceAllStringFunc(prompt, func(match string) string {
+		name := promptVariablePattern.FindStringSubmatch(match)[1]
+		if value, ok := vars[name]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+// BuildNovelContextBlock 生成注入文本生成提示词的作品设定说明，没有任何设定时返回空字符串
+func BuildNovelContextBlock(vars PromptVariables) string {
+	items := []struct{ label, name string }{
+		{"作品", PromptVarTitle},
+		{"题材", PromptVarGenre},
+		{"基调", PromptVarTone},
+		{"目标受众", PromptVarAudience},
+		{"主角", PromptVarProtagonist},
+	}
+
+	var lines []string
+	for _, item := range items {
+		if value := vars[item.name]; value != "" {
+			lines = append(lines, fmt.Sprintf("- %s：%s", item.label, value))
+		}
+	}
+	if topics := vars[PromptVarBannedTopics]; topics != "" {
+		lines = append(lines, fmt.Sprintf("- 禁止涉及的话题：%s（生成内容中不得出现或暗示这些话题）", topics))
+	}
+	// 只有书名时不注入，避免改变没有配置创作设定的小说的提示词
+	if len(lines) == 0 || (len(lines) == 1 && vars[PromptVarTitle] != "") {
+		return ""
+	}
+	return "【作品设定】\n" + strings.Join(lines, "\n") + "\n\n"
+}
+
+// InjectNovelContext 替换文本生成提示词中的变量，并在开头附加作品设定说明
+func InjectNovelContext(prompt string, vars PromptVariables) string {
+	return BuildNovelContextBlock(vars) + RenderPromptVariables(prompt, vars)
+}
@@ -0,0 +1,75 @@
+package noveltools
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestRenderPromptVariables(t *testing.T) {
+	Convey("RenderPromptVariables 替换提示词变量", t, func() {
+		vars := NovelPromptVariables(&novel.Novel{
+			Title: "斗破苍穹",
+			Metadata: &novel.CreativeMetadata{
+				Genre:        "玄幻",
+				Protagonist:  "萧炎",
+				BannedTopics: []string{"血腥", "赌博"},
+				Variables:    map[string]string{"era": "古代"},
+			},
+		})
+
+		Convey("替换内置变量和自定义变量，允许花括号内有空格", func() {
+			prompt := RenderPromptVariables("{{title}}：{{ protagonist }}在{{era}}的{{genre}}世界，避免{{banned_topics}}", vars)
+			So(prompt, ShouldEqual, "斗破苍穹：萧炎在古代的玄幻世界，避免血腥、赌博")
+		})
+
+		Convey("未定义的变量保持原样", func() {
+			So(RenderPromptVariables("{{tone}} {{Unknown}} {x}", vars), ShouldEqual, "{{tone}} {{Unknown}} {x}")
+		})
+
+		Convey("文本提示词开头附加作品设定", func() {
+			prompt := InjectNovelContext("请为{{protagonist}}写解说", vars)
+			So(prompt, ShouldStartWith, "【作品设定】\n- 作品：斗破苍穹\n- 题材：玄幻\n- 主角：萧炎\n")
+			So(prompt, ShouldContainSubstring, "禁止涉及的话题：血腥、赌博")
+			So(prompt, ShouldEndWith, "请为萧炎写解说")
+		})
+
+		Convey("没有创作设定时不改变提示词", func() {
+			plain := NovelPromptVariables(&novel.Novel{Title: "斗破苍穹"})
+			So(InjectNovelContext("请写解说", plain), ShouldEqual, "请写解说")
+			So(InjectNovelContext("请写解说", nil), ShouldEqual, "请写解说")
+		})
+	})
+}
+
+func TestNormalizeCreativeMetadata(t *testing.T) {
+	Convey("NormalizeCreativeMetadata 整理并校验创作设定", t, func() {
+		Convey("去掉空白并对禁用话题去重", func() {
+			meta, err := NormalizeCreativeMetadata(&novel.CreativeMetadata{
+				Genre:        " 都市 ",
+				BannedTopics: []string{"赌博", " ", "赌博 ", "毒品"},
+				Variables:    map[string]string{"city": " 江城 ", "empty": " "},
+			})
+			So(err, ShouldBeNil)
+			So(meta.Genre, ShouldEqual, "都市")
+			So(meta.BannedTopics, ShouldResemble, []string{"赌博", "毒品"})
+			So(meta.Variables, ShouldResemble, map[string]string{"city": "江城"})
+		})
+
+		Convey("变量名不合法或与内置变量重名时返回错误", func() {
+			for _, name := range []string{"City", "1st", "with-dash", PromptVarGenre} {
+				_, err := NormalizeCreativeMetadata(&novel.CreativeMetadata{Variables: map[string]string{name: "x"}})
+				So(errors.Is(err, ErrInvalidCreativeMetadata), ShouldBeTrue)
+			}
+		})
+
+		Convey("nil 返回空设定", func() {
+			meta, err := NormalizeCreativeMetadata(nil)
+			So(err, ShouldBeNil)
+			So(meta, ShouldResemble, &novel.CreativeMetadata{})
+		})
+	})
+}
@@ -0,0 +1,242 @@
+package noveltools
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidPronunciation 发音词典条目不合法
+var ErrInvalidPronunciation = errors.New("invalid pronunciation")
+
+// pinyinSyllablePattern 带声调数字的拼音音节（如 lin2、lv4，5 表示轻声）
+var pinyinSyllablePattern = regexp.MustCompile(`^[a-z]+[1-5]$`)
+
+// PronunciationHint 发音提示（小说发音词典中的一个词）
+type PronunciationHint struct {
+	Term        string // 需要纠正读音的词（人名、功法名等）
+	Pinyin      string // 带声调数字的拼音，音节之间用空格分隔（如 "mo4 qi2"），TTS 支持发音标注时使用
+	Replacement string // 同音替换文本，TTS 不支持发音标注（或没有拼音）时用它替换原词后合成
+}
+
+// PronunciationSubstitution 一次同音替换（用于把时间戳还原为原文）
+type PronunciationSubstitution struct {
+	Term        string // 原词
+	Replacement string // 替换文本
+	Offset      int    // 替换文本在合成文本中的起始位置（按字符计）
+}
+
+// NormalizePinyin 统一拼音写法：转为小写，ü 写作 v，音节之间用单个空格分隔
+func NormalizePinyin(pinyin string) string {
+	pinyin = strings.NewReplacer("ü", "v", "u:", "v").Replace(strings.ToLower(pinyin))
+	return strings.Join(strings.Fields(pinyin), " ")
+}
+
+// ValidatePronunciationHint 校验发音提示
+// 拼音和同音替换文本至少填一项；填写拼音时音节数必须与词的字数一致，每个音节带声调数字
+func ValidatePronunciationHint(hint PronunciationHint) error {
+	if strings.TrimSpace(hint.Term) == "" {
+		return fmt.Errorf("%w: term is required", ErrInvalidPronunciation)
+	}
+	if hint.Pinyin == "" && hint.Replacement == "" {
+		return fmt.Errorf("%w: pinyin or replacement is required", ErrInvalidPronunciation)
+	}
+	if hint.Pinyin == "" {
+		return nil
+	}
+
+	syllables := strings.Fields(NormalizePinyin(hint.Pinyin))
+	if len(syllables) != utf8.RuneCountInString(hint.Term) {
+		return fmt.Errorf("%w: %s has %d characters but %d pinyin syllables",
+			ErrInvalidPronunciation, hint.Term, utf8.RuneCountInString(hint.Term), len(syllables))
+	}
+	for _, syllable := range syllables {
+		if !pinyinSyllablePattern.MatchString(syllable) {
+			return fmt.Errorf("%w: pinyin syllable %q must end with a tone number 1-5", ErrInvalidPronunciation, syllable)
+		}
+	}
+	return nil
+}
+
+// pronunciationMatch 文本中命中的词典词（按字符位置）
+type pronunciationMatch struct {
+	start int
+	end   int
+	hint  PronunciationHint
+}
+
+// matchPronunciations 从左到右查找文本中的词典词，同一位置优先匹配最长的词，命中的词不重叠
+func matchPronunciations(runes []rune, hints []PronunciationHint) []pronunciationMatch {
+	sorted := make([]PronunciationHint, 0, len(hints))
+	for _, hint := range hints {
+		if hint.Term != "" {
+			sorted = append(sorted, hint)
+		}
+	}
+	if len(sorted) == 0 {
+		return nil
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return utf8.RuneCountInString(sorted[i].Term) > utf8.RuneCountInString(sorted[j].Term)
+	})
+
+	var matches []pronunciationMatch
+	for i := 0; i < len(runes); {
+		matched := false
+		for _, hint := range sorted {
+			term := []rune(hint.Term)
+			if i+len(term) <= len(runes) && string(runes[i:i+len(term)]) == hint.Term {
+				matches = append(matches, pronunciationMatch{start: i, end: i + len(term), hint: hint})
+				i += len(term)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			i++
+		}
+	}
+	return matches
+}
+
+// MatchedPronunciations 返回文本中命中的词典词（按出现顺序去重）
+func MatchedPronunciations(text string, hints []PronunciationHint) []PronunciationHint {
+	var result []PronunciationHint
+	seen := make(map[string]bool)
+	for _, m := range matchPronunciations([]rune(text), hints) {
+		if !seen[m.hint.Term] {
+			seen[m.hint.Term] = true
+			result = append(result, m.hint)
+		}
+	}
+	return result
+}
+
+// BuildPronunciationSSML 把文本转换为带拼音标注的 SSML，只使用填写了拼音的词
+// 文本没有命中任何词时返回 false，调用方应按普通文本合成
+func BuildPronunciationSSML(text string, hints []PronunciationHint) (string, bool) {
+	var withPinyin []PronunciationHint
+	for _, hint := range hints {
+		if hint.Pinyin != "" {
+			withPinyin = append(withPinyin, hint)
+		}
+	}
+	runes := []rune(text)
+	matches := matchPronunciations(runes, withPinyin)
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	var b strings.Builder
+	b.WriteString("<speak>")
+	last := 0
+	for _, m := range matches {
+		b.WriteString(html.EscapeString(string(runes[last:m.start])))
+		fmt.Fprintf(&b, `<phoneme alphabet="py" ph="%s">%s</phoneme>`,
+			html.EscapeString(NormalizePinyin(m.hint.Pinyin)), html.EscapeString(m.hint.Term))
+		last = m.end
+	}
+	b.WriteString(html.EscapeString(string(runes[last:])))
+	b.WriteString("</speak>")
+	return b.String(), true
+}
+
+// SubstitutePronunciations 用同音替换文本替换文本中的词典词（只使用填写了替换文本的词）
+// 返回合成用的文本和替换记录，合成后用 RestorePronunciationTimestamps 把时间戳还原为原文
+func SubstitutePronunciations(text string, hints []PronunciationHint) (string, []PronunciationSubstitution) {
+	var withReplacement []PronunciationHint
+	for _, hint := range hints {
+		if hint.Replacement != "" && hint.Replacement != hint.Term {
+			withReplacement = append(withReplacement, hint)
+		}
+	}
+	runes := []rune(text)
+	matches := matchPronunciations(runes, withReplacement)
+	if len(matches) == 0 {
+		return text, nil
+	}
+
+	var b strings.Builder
+	var subs []PronunciationSubstitution
+	last, offset := 0, 0
+	for _, m := range matches {
+		b.WriteString(string(runes[last:m.start]))
+		offset += m.start - last
+		subs = append(subs, PronunciationSubstitution{
+			Term:        m.hint.Term,
+			Replacement: m.hint.Replacement,
+			Offset:      offset,
+		})
+		b.WriteString(m.hint.Replacement)
+		offset += utf8.RuneCountInString(m.hint.Replacement)
+		last = m.end
+	}
+	b.WriteString(string(runes[last:]))
+	return b.String(), subs
+}
+
+// RestorePronunciationTimestamps 把按替换文本合成得到的时间戳还原为原文
+// spoken 为实际合成的文本，original 为替换前的原文；替换文本各字的时间范围合并后平均分配给原词的每个字，其他字符的时间戳不变
+func RestorePronunciationTimestamps(data *TimestampData, spoken, original string, subs []PronunciationSubstitution) {
+	if data == nil || len(subs) == 0 {
+		return
+	}
+	data.Text = original
+
+	// 合成文本中每个字符属于哪次替换（-1 表示不属于）
+	spokenRunes := []rune(spoken)
+	owner := make([]int, len(spokenRunes))
+	for i := range owner {
+		owner[i] = -1
+	}
+	for k, sub := range subs {
+		end := min(sub.Offset+utf8.RuneCountInString(sub.Replacement), len(owner))
+		for i := sub.Offset; i < end; i++ {
+			owner[i] = k
+		}
+	}
+
+	timestamps := data.CharacterTimestamps
+	restored := make([]CharTimestamp, 0, len(timestamps))
+	pos := 0
+	for i := 0; i < len(timestamps); i++ {
+		ts := timestamps[i]
+		// 时间戳只包含朗读的字符，跳过合成文本中没有时间戳的字符（标点、空白）
+		next := pos
+		for next < len(spokenRunes) && string(spokenRunes[next]) != ts.Character {
+			next++
+		}
+		if next >= len(spokenRunes) {
+			restored = append(restored, ts)
+			continue
+		}
+		pos = next + 1
+		k := owner[next]
+		if k < 0 {
+			restored = append(restored, ts)
+			continue
+		}
+
+		// 合并属于同一次替换的连续时间戳
+		start, end := ts.StartTime, ts.EndTime
+		for i+1 < len(timestamps) && pos < len(spokenRunes) && owner[pos] == k && string(spokenRunes[pos]) == timestamps[i+1].Character {
+			i++
+			end = timestamps[i].EndTime
+			pos++
+		}
+		term := []rune(subs[k].Term)
+		step := (end - start) / float64(len(term))
+		for j, r := range term {
+			restored = append(restored, CharTimestamp{
+				Character: string(r),
+				StartTime: start + float64(j)*step,
+				EndTime:   start + float64(j+1)*step,
+			})
+		}
+	}
+	data.CharacterTimestamps = restored
+}
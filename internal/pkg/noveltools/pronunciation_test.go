@@ -0,0 +1,103 @@
+package noveltools
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestValidatePronunciationHint(t *testing.T) {
+	Convey("ValidatePronunciationHint 校验发音词典条目", t, func() {
+		Convey("拼音音节数与字数一致且带声调时合法", func() {
+			So(ValidatePronunciationHint(PronunciationHint{Term: "万俟", Pinyin: "Mo4  Qi2"}), ShouldBeNil)
+			So(ValidatePronunciationHint(PronunciationHint{Term: "吕布", Pinyin: "lü3 bu4"}), ShouldBeNil)
+		})
+
+		Convey("只填同音替换文本时合法", func() {
+			So(ValidatePronunciationHint(PronunciationHint{Term: "万俟卨", Replacement: "莫其谢"}), ShouldBeNil)
+		})
+
+		Convey("缺少词、缺少读音、音节数不一致或缺少声调时不合法", func() {
+			invalid := []PronunciationHint{
+				{Pinyin: "mo4"},
+				{Term: "万俟"},
+				{Term: "万俟", Pinyin: "mo4"},
+				{Term: "万俟", Pinyin: "mo qi"},
+			}
+			for _, hint := range invalid {
+				So(errors.Is(ValidatePronunciationHint(hint), ErrInvalidPronunciation), ShouldBeTrue)
+			}
+		})
+	})
+}
+
+func TestBuildPronunciationSSML(t *testing.T) {
+	Convey("BuildPronunciationSSML 为命中的词加拼音标注", t, func() {
+		hints := []PronunciationHint{
+			{Term: "万俟", Pinyin: "mo4 qi2"},
+			{Term: "万俟卨", Pinyin: "mo4 qi2 xie4"},
+			{Term: "青冥", Replacement: "青明"},
+		}
+
+		Convey("优先匹配最长的词，没有拼音的词不标注，特殊字符被转义", func() {
+			ssml, ok := BuildPronunciationSSML("万俟卨修炼青冥诀<上>", hints)
+			So(ok, ShouldBeTrue)
+			So(ssml, ShouldEqual, `<speak><phoneme alphabet="py" ph="mo4 qi2 xie4">万俟卨</phoneme>修炼青冥诀&lt;上&gt;</speak>`)
+		})
+
+		Convey("没有命中时返回 false", func() {
+			_, ok := BuildPronunciationSSML("林凡握紧了剑", hints)
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
+
+func TestSubstitutePronunciations(t *testing.T) {
+	Convey("SubstitutePronunciations 用同音字替换后还原时间戳", t, func() {
+		hints := []PronunciationHint{
+			{Term: "万俟", Pinyin: "mo4 qi2", Replacement: "莫其"},
+			{Term: "青冥诀", Replacement: "青明决法"},
+		}
+		original := "万俟说，青冥诀。"
+		spoken, subs := SubstitutePronunciations(original, hints)
+		So(spoken, ShouldEqual, "莫其说，青明决法。")
+		So(subs, ShouldResemble, []PronunciationSubstitution{
+			{Term: "万俟", Replacement: "莫其", Offset: 0},
+			{Term: "青冥诀", Replacement: "青明决法", Offset: 4},
+		})
+
+		Convey("替换文本的时长平均分配给原词的每个字，其他字符不变", func() {
+			data := &TimestampData{
+				Text: spoken,
+				CharacterTimestamps: []CharTimestamp{
+					{Character: "莫", StartTime: 0, EndTime: 0.2},
+					{Character: "其", StartTime: 0.2, EndTime: 0.4},
+					{Character: "说", StartTime: 0.4, EndTime: 0.6},
+					{Character: "青", StartTime: 0.8, EndTime: 1.0},
+					{Character: "明", StartTime: 1.0, EndTime: 1.2},
+					{Character: "决", StartTime: 1.2, EndTime: 1.4},
+					{Character: "法", StartTime: 1.4, EndTime: 1.7},
+				},
+			}
+			RestorePronunciationTimestamps(data, spoken, original, subs)
+			So(data.Text, ShouldEqual, original)
+
+			var chars string
+			for _, ts := range data.CharacterTimestamps {
+				chars += ts.Character
+			}
+			So(chars, ShouldEqual, "万俟说青冥诀")
+			So(data.CharacterTimestamps[1].EndTime, ShouldAlmostEqual, 0.4)
+			So(data.CharacterTimestamps[3].StartTime, ShouldAlmostEqual, 0.8)
+			So(data.CharacterTimestamps[4].StartTime, ShouldAlmostEqual, 1.1)
+			So(data.CharacterTimestamps[5].EndTime, ShouldAlmostEqual, 1.7)
+		})
+
+		Convey("没有填写替换文本的词不替换", func() {
+			spoken, subs := SubstitutePronunciations("万俟说", []PronunciationHint{{Term: "万俟", Pinyin: "mo4 qi2"}})
+			So(spoken, ShouldEqual, "万俟说")
+			So(subs, ShouldBeEmpty)
+		})
+	})
+}
@@ -2,6 +2,7 @@ package noveltools
 
 import (
 	"context"
+	"fmt"
 	"time"
 )
 
@@ -20,6 +21,24 @@ type LLMProvider interface {
 	Generate(ctx context.Context, prompt string) (string, error)
 }
 
+// StreamingLLMProvider 支持流式输出的大模型接口（可选能力）
+// 实现了此接口的 LLMProvider 可以边生成边回调，上层据此实现增量解析（如逐个场景落库）
+type StreamingLLMProvider interface {
+	LLMProvider
+
+	// GenerateStream 根据提示词流式生成文本
+	//
+	// Args:
+	//   - ctx: 上下文
+	//   - prompt: 提示词
+	//   - onChunk: 每收到一段增量文本时的回调
+	//
+	// Returns:
+	//   - text: 生成的完整文本
+	//   - err: 错误信息
+	GenerateStream(ctx context.Context, prompt string, onChunk func(chunk string)) (string, error)
+}
+
 // TTSProvider TTS提供者接口（用于单测/替换实现）
 // 参考 Python 脚本 gen_audio.py 的 VoiceGenerator.generate_voice_with_timestamps
 type TTSProvider interface {
@@ -41,6 +60,32 @@ type TTSProvider interface {
 	) (*TTSResult, error)
 }
 
+// VoiceSelectableTTSProvider 支持按调用指定音色的 TTS 提供者（可选能力）
+// 未实现时小说配置的音色不生效，统一使用提供者默认音色
+type VoiceSelectableTTSProvider interface {
+	// GenerateVoiceWithTimestampsForVoice 使用指定音色生成语音并获取时间戳，voiceType 为空时使用默认音色
+	GenerateVoiceWithTimestampsForVoice(
+		ctx context.Context,
+		text string,
+		speedRatio float64,
+		voiceType string,
+	) (*TTSResult, error)
+}
+
+// PronunciationTTSProvider 支持发音标注的 TTS 提供者（可选能力）
+// 实现了此接口时小说发音词典中填写了拼音的词按拼音朗读；未实现时只能用同音替换文本纠正读音
+type PronunciationTTSProvider interface {
+	// GenerateVoiceWithPronunciations 按发音提示生成语音并获取时间戳，voiceType 为空时使用默认音色
+	// 时间戳按原文字符返回
+	GenerateVoiceWithPronunciations(
+		ctx context.Context,
+		text string,
+		speedRatio float64,
+		voiceType string,
+		hints []PronunciationHint,
+	) (*TTSResult, error)
+}
+
 // ImageProvider 图片生成提供者接口
 // 统一抽象 T2P 和 ComfyUI 两种图片生成方式
 type ImageProvider interface {
@@ -55,6 +100,100 @@ type ImageProvider interface {
 	GenerateImage(ctx context.Context, prompt, filename string) ([]byte, error)
 }
 
+// ImageToImageProvider 图生图提供者接口（可选能力）
+// 实现了此接口的 ImageProvider 可以基于参考图片编辑画面（如重新打光），保持构图和主体一致
+type ImageToImageProvider interface {
+	// GenerateImageFromImage 基于参考图片生成图片
+	// Args:
+	//   - ctx: 上下文
+	//   - imageData: 参考图片二进制数据
+	//   - prompt: 编辑描述文本
+	//   - filename: 输出文件名（用于标识）
+	// Returns:
+	//   - imageData: 生成的图片二进制数据
+	//   - error: 错误信息
+	GenerateImageFromImage(ctx context.Context, imageData []byte, prompt, filename string) ([]byte, error)
+}
+
+// StyleReferenceProvider 风格参考图提供者接口（可选能力）
+// 实现了此接口的 ImageProvider 可以根据风格参考图（美术设定图）引导生成图片的构图和配色
+type StyleReferenceProvider interface {
+	// GenerateImageWithStyleReferences 带风格参考图生成图片
+	// Args:
+	//   - ctx: 上下文
+	//   - prompt: 图片描述文本
+	//   - references: 风格参考图二进制数据（可多张）
+	//   - filename: 输出文件名（用于标识）
+	// Returns:
+	//   - imageData: 生成的图片二进制数据
+	//   - error: 错误信息
+	GenerateImageWithStyleReferences(ctx context.Context, prompt string, references [][]byte, filename string) ([]byte, error)
+}
+
+// ImageBatchRequest 批量生成中的单张图片请求
+type ImageBatchRequest struct {
+	Prompt   string // 图片描述文本
+	Filename string // 输出文件名（用于标识）
+}
+
+// ImageBatchResult 批量生成中单张图片的结果
+type ImageBatchResult struct {
+	ImageData []byte // 生成的图片二进制数据
+	Err       error  // 该张图片的错误信息（不影响同批其他图片）
+}
+
+// BatchImageProvider 批量图片生成接口（可选能力）
+// 实现了此接口的 ImageProvider 可以一次提交多张图片（如全部提交到任务队列后依次等待结果），减少逐张同步调用的等待时间
+type BatchImageProvider interface {
+	// MaxBatchSize 单批最多提交的图片数量（<=0 表示不限制）
+	MaxBatchSize() int
+
+	// GenerateImages 批量生成图片
+	// Args:
+	//   - ctx: 上下文
+	//   - requests: 图片请求列表
+	// Returns:
+	//   - []ImageBatchResult: 与 requests 一一对应的结果，单张失败时只设置对应结果的 Err
+	GenerateImages(ctx context.Context, requests []ImageBatchRequest) []ImageBatchResult
+}
+
+// PromptLocale 图片提示词本地化设置
+type PromptLocale struct {
+	Language      string   // 提示词目标语言（如 "en"）；为空或 "zh" 时不翻译
+	StyleKeywords []string // 翻译后追加的风格关键词（使用目标语言）
+}
+
+// PromptLocalizedProvider 提示词本地化接口（可选能力）
+// 实现了此接口的 ImageProvider 对非中文提示词效果更好，生成前会把中文提示词翻译为目标语言并追加风格关键词
+type PromptLocalizedProvider interface {
+	// PromptLocale 返回提供者偏好的提示词语言和风格关键词
+	PromptLocale() PromptLocale
+}
+
+// ProviderInfo 提供者的名称和使用的模型（记录到最终视频的溯源清单）
+type ProviderInfo struct {
+	Name  string // 提供者名称（如 ark、bytedance_tts、comfyui）
+	Model string // 模型名称（TTS 为音色，ComfyUI 为工作流文件）
+}
+
+// DescribedProvider 提供者描述接口（可选能力）
+// 未实现时溯源清单只记录提供者的实现类型
+type DescribedProvider interface {
+	// Describe 返回提供者的名称和使用的模型
+	Describe() ProviderInfo
+}
+
+// DescribeProvider 返回提供者的名称和模型，未实现 DescribedProvider 时使用实现类型作为名称
+func DescribeProvider(provider any) ProviderInfo {
+	if provider == nil {
+		return ProviderInfo{}
+	}
+	if d, ok := provider.(DescribedProvider); ok {
+		return d.Describe()
+	}
+	return ProviderInfo{Name: fmt.Sprintf("%T", provider)}
+}
+
 // VideoProvider 视频生成提供者接口
 // 统一抽象视频生成方式（如 Ark API）
 type VideoProvider interface {
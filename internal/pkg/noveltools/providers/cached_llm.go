@@ -0,0 +1,83 @@
+package providers
+
+import (
+	"context"
+
+	"lemon/internal/pkg/noveltools"
+	"lemon/internal/pkg/respcache"
+)
+
+// CachedLLMProvider 带响应缓存的 LLM 提供者
+// 按 provider、模型和提示词缓存生成结果，相同提示词的重试直接返回上次的输出；context 标记了 respcache.Bypass 时重新调用并刷新缓存
+// 实现了 noveltools.LLMProvider 接口
+type CachedLLMProvider struct {
+	inner noveltools.LLMProvider
+	cache *respcache.Cache
+	info  noveltools.ProviderInfo
+}
+
+// cachedStreamingLLMProvider 被包装的提供者支持流式输出时使用，保留 noveltools.StreamingLLMProvider 能力
+type cachedStreamingLLMProvider struct {
+	*CachedLLMProvider
+	stream noveltools.StreamingLLMProvider
+}
+
+// NewCachedLLMProvider 为 LLM 提供者加上响应缓存
+// 被包装的提供者支持流式输出时，返回值同样实现 noveltools.StreamingLLMProvider
+func NewCachedLLMProvider(inner noveltools.LLMProvider, cache *respcache.Cache) noveltools.LLMProvider {
+	p := &CachedLLMProvider{
+		inner: inner,
+		cache: cache,
+		info:  noveltools.DescribeProvider(inner),
+	}
+	if stream, ok := inner.(noveltools.StreamingLLMProvider); ok {
+		return &cachedStreamingLLMProvider{CachedLLMProvider: p, stream: stream}
+	}
+	return p
+}
+
+// LLMCacheKey 返回 LLM 请求的缓存键
+func LLMCacheKey(info noveltools.ProviderInfo, prompt string) string {
+	return respcache.Key("llm", info.Name, info.Model, prompt)
+}
+
+// Describe 返回被包装提供者的名称和模型
+// 实现了 noveltools.DescribedProvider 接口
+func (p *CachedLLMProvider) Describe() noveltools.ProviderInfo {
+	return p.info
+}
+
+// Generate 根据提示词生成文本，命中缓存时不调用被包装的提供者
+// 实现了 noveltools.LLMProvider 接口
+func (p *CachedLLMProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	key := LLMCacheKey(p.info, prompt)
+	if !respcache.Bypassed(ctx) {
+		if v, ok := p.cache.Get(key); ok {
+			return string(v), nil
+		}
+	}
+	content, err := p.inner.Generate(ctx, prompt)
+	if err == nil && content != "" {
+		p.cache.Put(key, []byte(content))
+	}
+	return content, err
+}
+
+// GenerateStream 流式生成文本，命中缓存时把缓存的完整文本作为一段回调
+// 实现了 noveltools.StreamingLLMProvider 接口
+func (p *cachedStreamingLLMProvider) GenerateStream(ctx context.Context, prompt string, onChunk func(chunk string)) (string, error) {
+	key := LLMCacheKey(p.info, prompt)
+	if !respcache.Bypassed(ctx) {
+		if v, ok := p.cache.Get(key); ok {
+			if onChunk != nil {
+				onChunk(string(v))
+			}
+			return string(v), nil
+		}
+	}
+	content, err := p.stream.GenerateStream(ctx, prompt, onChunk)
+	if err == nil && content != "" {
+		p.cache.Put(key, []byte(content))
+	}
+	return content, err
+}
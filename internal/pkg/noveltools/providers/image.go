@@ -3,13 +3,17 @@ package providers
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"path/filepath"
 
 	"github.com/rs/zerolog/log"
 
 	"lemon/internal/pkg/ark"
 	"lemon/internal/pkg/comfyui"
+	"lemon/internal/pkg/errclass"
 	"lemon/internal/pkg/noveltools"
 	"lemon/internal/pkg/t2p"
+	"lemon/internal/pkg/tasklog"
 )
 
 // ArkImageProvider Ark 图片生成提供者
@@ -32,12 +36,18 @@ func NewArkImageProvider() (noveltools.ImageProvider, error) {
 	}, nil
 }
 
+// Describe 返回提供者名称和模型
+// 实现了 noveltools.DescribedProvider 接口
+func (p *ArkImageProvider) Describe() noveltools.ProviderInfo {
+	return noveltools.ProviderInfo{Name: "ark_image", Model: p.client.Model()}
+}
+
 // GenerateImage 生成图片
 // 调用 ark.ArkImageClient.GenerateImageSimple
 func (p *ArkImageProvider) GenerateImage(ctx context.Context, prompt, filename string) ([]byte, error) {
 	imageData, err := p.client.GenerateImageSimple(ctx, prompt)
 	if err != nil {
-		return nil, fmt.Errorf("Ark generate image: %w", err)
+		return nil, errclass.Provider("ark_image", fmt.Errorf("Ark generate image: %w", err))
 	}
 
 	log.Info().
@@ -48,10 +58,50 @@ func (p *ArkImageProvider) GenerateImage(ctx context.Context, prompt, filename s
 	return imageData, nil
 }
 
+// GenerateImageFromImage 基于参考图片生成图片（图生图）
+// 调用 ark.ArkImageClient.GenerateImageFromImage，实现了 noveltools.ImageToImageProvider 接口
+func (p *ArkImageProvider) GenerateImageFromImage(ctx context.Context, imageData []byte, prompt, filename string) ([]byte, error) {
+	imageDataURL := ark.ConvertImageToDataURL(imageData, http.DetectContentType(imageData))
+	result, err := p.client.GenerateImageFromImage(ctx, imageDataURL, prompt, "", false)
+	if err != nil {
+		return nil, errclass.Provider("ark_image", fmt.Errorf("Ark generate image from image: %w", err))
+	}
+
+	log.Info().
+		Str("filename", filename).
+		Int("size", len(result)).
+		Msg("Ark 图生图成功")
+
+	return result, nil
+}
+
+// GenerateImageWithStyleReferences 带风格参考图生成图片
+// 调用 ark.ArkImageClient.GenerateImageWithReferences，实现了 noveltools.StyleReferenceProvider 接口
+func (p *ArkImageProvider) GenerateImageWithStyleReferences(ctx context.Context, prompt string, references [][]byte, filename string) ([]byte, error) {
+	referenceURLs := make([]string, 0, len(references))
+	for _, ref := range references {
+		referenceURLs = append(referenceURLs, ark.ConvertImageToDataURL(ref, http.DetectContentType(ref)))
+	}
+
+	result, err := p.client.GenerateImageWithReferences(ctx, prompt, referenceURLs, "", false)
+	if err != nil {
+		return nil, errclass.Provider("ark_image", fmt.Errorf("Ark generate image with style references: %w", err))
+	}
+
+	log.Info().
+		Str("filename", filename).
+		Int("references", len(references)).
+		Int("size", len(result)).
+		Msg("Ark 风格参考图生成成功")
+
+	return result, nil
+}
+
 // T2PProvider T2P（火山引擎 Text-to-Picture）图片生成提供者
 // 适配层，调用 t2p.Client
 type T2PProvider struct {
 	client *t2p.Client
+	reqKey string
 }
 
 // NewT2PProvider 创建 T2P 提供者
@@ -65,15 +115,22 @@ func NewT2PProvider() (noveltools.ImageProvider, error) {
 
 	return &T2PProvider{
 		client: client,
+		reqKey: config.ReqKey,
 	}, nil
 }
 
+// Describe 返回提供者名称和模型（req_key）
+// 实现了 noveltools.DescribedProvider 接口
+func (p *T2PProvider) Describe() noveltools.ProviderInfo {
+	return noveltools.ProviderInfo{Name: "t2p", Model: p.reqKey}
+}
+
 // GenerateImage 生成图片
 // 调用 t2p.Client.GenerateImageSimple
 func (p *T2PProvider) GenerateImage(ctx context.Context, prompt, filename string) ([]byte, error) {
 	imageData, err := p.client.GenerateImageSimple(ctx, prompt)
 	if err != nil {
-		return nil, fmt.Errorf("T2P generate image: %w", err)
+		return nil, errclass.Provider("t2p", fmt.Errorf("T2P generate image: %w", err))
 	}
 
 	log.Info().
@@ -84,11 +141,16 @@ func (p *T2PProvider) GenerateImage(ctx context.Context, prompt, filename string
 	return imageData, nil
 }
 
+// comfyUIMaxBatchSize 单批最多提交到 ComfyUI 队列的任务数（避免队列过长导致后提交的任务等待超时）
+const comfyUIMaxBatchSize = 8
+
 // ComfyUIProvider ComfyUI 图片生成提供者
 // 包装现有的 ComfyUI 客户端
 type ComfyUIProvider struct {
 	client           *comfyui.Client
 	workflowTemplate map[string]interface{}
+	workflowName     string
+	locale           noveltools.PromptLocale
 }
 
 // NewComfyUIProvider 创建 ComfyUI 提供者
@@ -105,37 +167,94 @@ func NewComfyUIProvider() (noveltools.ImageProvider, error) {
 	return &ComfyUIProvider{
 		client:           client,
 		workflowTemplate: workflowTemplate,
+		workflowName:     filepath.Base(config.WorkflowJSONPath),
+		locale: noveltools.PromptLocale{
+			Language:      config.PromptLanguage,
+			StyleKeywords: noveltools.ParseStyleKeywords(config.StyleKeywords),
+		},
 	}, nil
 }
 
+// PromptLocale 返回 ComfyUI 偏好的提示词语言和风格关键词
+// 实现了 noveltools.PromptLocalizedProvider 接口
+func (p *ComfyUIProvider) PromptLocale() noveltools.PromptLocale {
+	return p.locale
+}
+
+// Describe 返回提供者名称和工作流文件名
+// 实现了 noveltools.DescribedProvider 接口
+func (p *ComfyUIProvider) Describe() noveltools.ProviderInfo {
+	return noveltools.ProviderInfo{Name: "comfyui", Model: p.workflowName}
+}
+
 // GenerateImage 生成图片
 func (p *ComfyUIProvider) GenerateImage(ctx context.Context, prompt, filename string) ([]byte, error) {
-	// 1. 替换工作流中的正向提示词
+	promptID, err := p.submit(ctx, prompt, filename)
+	if err != nil {
+		return nil, err
+	}
+	return p.collect(ctx, promptID, filename)
+}
+
+// MaxBatchSize 单批最多提交到 ComfyUI 队列的任务数
+// 实现了 noveltools.BatchImageProvider 接口
+func (p *ComfyUIProvider) MaxBatchSize() int {
+	return comfyUIMaxBatchSize
+}
+
+// GenerateImages 批量生成图片
+// 先把所有工作流提交到 ComfyUI 队列，再按提交顺序等待并下载结果（ComfyUI 按队列顺序执行），单张失败不影响其他图片
+// 实现了 noveltools.BatchImageProvider 接口
+func (p *ComfyUIProvider) GenerateImages(ctx context.Context, requests []noveltools.ImageBatchRequest) []noveltools.ImageBatchResult {
+	results := make([]noveltools.ImageBatchResult, len(requests))
+	promptIDs := make([]string, len(requests))
+	for i, req := range requests {
+		promptIDs[i], results[i].Err = p.submit(ctx, req.Prompt, req.Filename)
+	}
+
+	for i, req := range requests {
+		if results[i].Err != nil {
+			continue
+		}
+		results[i].ImageData, results[i].Err = p.collect(ctx, promptIDs[i], req.Filename)
+	}
+	return results
+}
+
+// submit 提交工作流到 ComfyUI 队列，返回 prompt_id
+func (p *ComfyUIProvider) submit(ctx context.Context, prompt, filename string) (string, error) {
+	// 1. 替换工作流中的正向提示词（context 中指定了采样步数时同时替换步数）
 	workflow := comfyui.SetPositivePrompt(p.workflowTemplate, prompt)
+	workflow = comfyui.SetSamplerSteps(workflow, noveltools.ImageStepsFromContext(ctx))
 
 	// 2. 提交工作流
 	result, err := p.client.SubmitWorkflow(ctx, workflow, filename)
 	if err != nil {
-		return nil, fmt.Errorf("submit workflow: %w", err)
+		return "", errclass.Provider("comfyui", fmt.Errorf("submit workflow: %w", err))
 	}
 
 	if !result.Success {
-		return nil, fmt.Errorf("submit workflow failed: %s", result.Error)
+		return "", errclass.Provider("comfyui", fmt.Errorf("submit workflow failed: %s", result.Error))
 	}
 
 	// 3. 获取 prompt_id
 	promptID, ok := result.Data["prompt_id"].(string)
+	tasklog.FromContext(ctx).Info("ComfyUI 工作流已提交", tasklog.Fields{"prompt_id": promptID, "filename": filename})
 	if !ok {
-		return nil, fmt.Errorf("prompt_id not found in response")
+		return "", fmt.Errorf("prompt_id not found in response")
 	}
+	return promptID, nil
+}
 
-	// 4. 轮询任务状态，等待输出文件名
+// collect 等待 ComfyUI 任务完成并下载生成的图片
+func (p *ComfyUIProvider) collect(ctx context.Context, promptID, filename string) ([]byte, error) {
+	// 1. 轮询任务状态，等待输出文件名
 	outputResult, err := p.client.WaitForOutputFilename(ctx, promptID, filename)
 	if err != nil {
-		return nil, fmt.Errorf("wait for output filename: %w", err)
+		return nil, errclass.Provider("comfyui", fmt.Errorf("wait for output filename: %w", err))
 	}
 
-	// 5. 下载生成的图片
+	// 2. 下载生成的图片
 	imageData, err := p.client.DownloadViewFile(
 		ctx,
 		outputResult.Filename,
@@ -144,7 +263,7 @@ func (p *ComfyUIProvider) GenerateImage(ctx context.Context, prompt, filename st
 		filename,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("download image: %w", err)
+		return nil, errclass.Provider("comfyui", fmt.Errorf("download image: %w", err))
 	}
 
 	log.Info().
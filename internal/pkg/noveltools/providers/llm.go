@@ -8,6 +8,8 @@ import (
 	"github.com/cloudwego/eino/schema"
 
 	"lemon/internal/pkg/ark"
+	"lemon/internal/pkg/errclass"
+	"lemon/internal/pkg/noveltools"
 )
 
 // EinoProvider Eino 封装的 LLM 提供者（默认使用）
@@ -30,6 +32,12 @@ func NewEinoProvider(chatModel model.ChatModel) *EinoProvider {
 	}
 }
 
+// Describe 返回提供者名称（ChatModel 不暴露模型名称）
+// 实现了 noveltools.DescribedProvider 接口
+func (p *EinoProvider) Describe() noveltools.ProviderInfo {
+	return noveltools.ProviderInfo{Name: "eino"}
+}
+
 // Generate 根据提示词生成文本（使用 eino ChatModel）
 // 实现了 noveltools.LLMProvider 接口
 func (p *EinoProvider) Generate(ctx context.Context, prompt string) (string, error) {
@@ -45,7 +53,7 @@ func (p *EinoProvider) Generate(ctx context.Context, prompt string) (string, err
 	// 调用 ChatModel 的 Generate 方法
 	response, err := p.chatModel.Generate(ctx, messages)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate text: %w", err)
+		return "", errclass.Provider("eino", fmt.Errorf("failed to generate text: %w", err))
 	}
 
 	// 提取内容
@@ -76,11 +84,28 @@ func NewArkProvider(client *ark.LLMClient) *ArkProvider {
 	}
 }
 
+// Describe 返回提供者名称和模型
+// 实现了 noveltools.DescribedProvider 接口
+func (p *ArkProvider) Describe() noveltools.ProviderInfo {
+	return noveltools.ProviderInfo{Name: "ark", Model: p.client.Model()}
+}
+
 // Generate 根据提示词生成文本（使用 Ark LLM 客户端）
 // 实现了 noveltools.LLMProvider 接口
 func (p *ArkProvider) Generate(ctx context.Context, prompt string) (string, error) {
 	if p.client == nil {
 		return "", fmt.Errorf("ark client is required")
 	}
-	return p.client.CreateChatCompletionSimple(ctx, prompt)
+	content, err := p.client.CreateChatCompletionSimple(ctx, prompt)
+	return content, errclass.Provider("ark", err)
+}
+
+// GenerateStream 流式生成文本（使用 Ark LLM 客户端）
+// 实现了 noveltools.StreamingLLMProvider 接口
+func (p *ArkProvider) GenerateStream(ctx context.Context, prompt string, onChunk func(chunk string)) (string, error) {
+	if p.client == nil {
+		return "", fmt.Errorf("ark client is required")
+	}
+	content, err := p.client.CreateChatCompletionStreamSimple(ctx, prompt, onChunk)
+	return content, errclass.Provider("ark", err)
 }
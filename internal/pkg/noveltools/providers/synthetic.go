@@ -0,0 +1,231 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"lemon/internal/pkg/ffmpeg"
+	"lemon/internal/pkg/noveltools"
+)
+
+// ErrNoSyntheticResponse 合成 LLM 没有与提示词匹配的预置回复
+var ErrNoSyntheticResponse = errors.New("no synthetic llm response matches prompt")
+
+// 合成 provider 的输出参数（回归测试依赖这些参数保持不变，修改后需要重新生成基准产物）
+const (
+	syntheticSampleRate     = 16000 // 合成音频采样率（单声道 16bit PCM）
+	syntheticSecondsPerRune = 0.2   // 合成音频每个字符的朗读时长（1.0 倍速）
+	syntheticToneHz         = 440   // 合成音频的提示音频率
+	syntheticImageWidth     = 360
+	syntheticImageHeight    = 640
+	syntheticVideoWidth     = 720
+	syntheticVideoHeight    = 1280
+	syntheticVideoFPS       = 30
+)
+
+// SyntheticLLMResponse 合成 LLM 的预置回复：提示词包含 Match 时返回 Response
+type SyntheticLLMResponse struct {
+	Match    string
+	Response string
+}
+
+// SyntheticLLMProvider 合成 LLM 提供者（回归测试用）
+// 按预置回复的顺序匹配提示词，不调用真实大模型，输出完全确定
+type SyntheticLLMProvider struct {
+	responses []SyntheticLLMResponse
+}
+
+// NewSyntheticLLMProvider 创建合成 LLM 提供者
+func NewSyntheticLLMProvider(responses []SyntheticLLMResponse) *SyntheticLLMProvider {
+	return &SyntheticLLMProvider{responses: responses}
+}
+
+// Describe 返回提供者名称
+// 实现了 noveltools.DescribedProvider 接口
+func (p *SyntheticLLMProvider) Describe() noveltools.ProviderInfo {
+	return noveltools.ProviderInfo{Name: "synthetic"}
+}
+
+// Generate 返回第一个与提示词匹配的预置回复
+func (p *SyntheticLLMProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	for _, r := range p.responses {
+		if strings.Contains(prompt, r.Match) {
+			return r.Response, nil
+		}
+	}
+	return "", ErrNoSyntheticResponse
+}
+
+// SyntheticTTSProvider 合成 TTS 提供者（回归测试用）
+// 按字符数生成固定时长的 WAV 提示音，字符时间戳均匀分布，输出完全确定
+type SyntheticTTSProvider struct{}
+
+// NewSyntheticTTSProvider 创建合成 TTS 提供者
+func NewSyntheticTTSProvider() *SyntheticTTSProvider {
+	return &SyntheticTTSProvider{}
+}
+
+// Describe 返回提供者名称
+// 实现了 noveltools.DescribedProvider 接口
+func (p *SyntheticTTSProvider) Describe() noveltools.ProviderInfo {
+	return noveltools.ProviderInfo{Name: "synthetic"}
+}
+
+// GenerateVoiceWithTimestamps 生成合成语音和字符时间戳
+func (p *SyntheticTTSProvider) GenerateVoiceWithTimestamps(ctx context.Context, text string, speedRatio float64) (*noveltools.TTSResult, error) {
+	if speedRatio <= 0 {
+		speedRatio = 1.0
+	}
+	runes := []rune(text)
+	perRune := syntheticSecondsPerRune / speedRatio
+	duration := math.Round(float64(len(runes))*perRune*1000) / 1000
+
+	timestamps := make([]noveltools.CharTimestamp, 0, len(runes))
+	for i, r := range runes {
+		timestamps = append(timestamps, noveltools.CharTimestamp{
+			Character: string(r),
+			StartTime: math.Round(float64(i)*perRune*1000) / 1000,
+			EndTime:   math.Round(float64(i+1)*perRune*1000) / 1000,
+		})
+	}
+
+	return &noveltools.TTSResult{
+		Success:   true,
+		AudioData: syntheticWAV(duration),
+		Duration:  duration,
+		TimestampData: &noveltools.TimestampData{
+			Text:                text,
+			Duration:            duration,
+			CharacterTimestamps: timestamps,
+			GeneratedAt:         time.Time{},
+		},
+	}, nil
+}
+
+// syntheticWAV 生成指定时长的单声道 16bit PCM WAV 提示音
+func syntheticWAV(duration float64) []byte {
+	samples := int(duration * syntheticSampleRate)
+	dataSize := samples * 2
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(36+dataSize))
+	buf.WriteString("WAVEfmt ")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(16))                    // fmt chunk 大小
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(1))                     // PCM
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(1))                     // 单声道
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(syntheticSampleRate))   // 采样率
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(syntheticSampleRate*2)) // 字节率
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(2))                     // 块对齐
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(16))                    // 位深
+	buf.WriteString("data")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(dataSize))
+	for i := 0; i < samples; i++ {
+		v := int16(3000 * math.Sin(2*math.Pi*syntheticToneHz*float64(i)/syntheticSampleRate))
+		_ = binary.Write(&buf, binary.LittleEndian, v)
+	}
+	return buf.Bytes()
+}
+
+// SyntheticImageProvider 合成图片提供者（回归测试用）
+// 生成纯色 JPEG，颜色由提示词的哈希决定，相同提示词得到相同图片
+type SyntheticImageProvider struct{}
+
+// NewSyntheticImageProvider 创建合成图片提供者
+func NewSyntheticImageProvider() *SyntheticImageProvider {
+	return &SyntheticImageProvider{}
+}
+
+// Describe 返回提供者名称
+// 实现了 noveltools.DescribedProvider 接口
+func (p *SyntheticImageProvider) Describe() noveltools.ProviderInfo {
+	return noveltools.ProviderInfo{Name: "synthetic"}
+}
+
+// GenerateImage 生成合成图片
+func (p *SyntheticImageProvider) GenerateImage(ctx context.Context, prompt, filename string) ([]byte, error) {
+	sum := sha256.Sum256([]byte(prompt))
+	fill := color.RGBA{R: sum[0], G: sum[1], B: sum[2], A: 255}
+
+	img := image.NewRGBA(image.Rect(0, 0, syntheticImageWidth, syntheticImageHeight))
+	for y := 0; y < syntheticImageHeight; y++ {
+		for x := 0; x < syntheticImageWidth; x++ {
+			img.SetRGBA(x, y, fill)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, fmt.Errorf("encode synthetic image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// SyntheticVideoProvider 合成视频提供者（回归测试用）
+// 使用 FFmpeg 把输入图片生成指定时长的静态视频（编码结果依赖 FFmpeg 版本，只适合比较时长）
+type SyntheticVideoProvider struct {
+	ffmpeg *ffmpeg.Client
+}
+
+// NewSyntheticVideoProvider 创建合成视频提供者
+func NewSyntheticVideoProvider() *SyntheticVideoProvider {
+	return &SyntheticVideoProvider{ffmpeg: ffmpeg.NewClient()}
+}
+
+// Describe 返回提供者名称
+// 实现了 noveltools.DescribedProvider 接口
+func (p *SyntheticVideoProvider) Describe() noveltools.ProviderInfo {
+	return noveltools.ProviderInfo{Name: "synthetic"}
+}
+
+// GenerateVideoFromImage 从图片生成合成视频
+func (p *SyntheticVideoProvider) GenerateVideoFromImage(ctx context.Context, imageDataURL string, duration int, prompt string) ([]byte, error) {
+	_, encoded, ok := strings.Cut(imageDataURL, ";base64,")
+	if !ok {
+		return nil, fmt.Errorf("invalid image data url")
+	}
+	imageData, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decode image data url: %w", err)
+	}
+	if duration < 1 {
+		duration = 1
+	}
+
+	tmpDir, err := os.MkdirTemp("", "lemon-synthetic-video-*")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	imagePath := filepath.Join(tmpDir, "input.jpg")
+	if err := os.WriteFile(imagePath, imageData, 0o644); err != nil {
+		return nil, fmt.Errorf("write input image: %w", err)
+	}
+	videoPath := filepath.Join(tmpDir, "output.mp4")
+	if err := p.ffmpeg.CreateImageVideo(ctx, imagePath, videoPath, float64(duration), syntheticVideoWidth, syntheticVideoHeight, syntheticVideoFPS); err != nil {
+		return nil, fmt.Errorf("create synthetic video: %w", err)
+	}
+	return os.ReadFile(videoPath)
+}
+
+// 编译期检查合成 provider 实现了对应接口
+var (
+	_ noveltools.LLMProvider   = (*SyntheticLLMProvider)(nil)
+	_ noveltools.TTSProvider   = (*SyntheticTTSProvider)(nil)
+	_ noveltools.ImageProvider = (*SyntheticImageProvider)(nil)
+	_ noveltools.VideoProvider = (*SyntheticVideoProvider)(nil)
+)
@@ -2,11 +2,49 @@ package providers
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"strings"
 
+	"lemon/internal/pkg/errclass"
 	"lemon/internal/pkg/noveltools"
 	"lemon/internal/pkg/tts"
 )
 
+// TTS 提供者名称（环境变量 TTS_PROVIDER 的取值）
+const (
+	TTSProviderByteDance = "bytedance" // 字节跳动（火山引擎），默认
+	TTSProviderAzure     = "azure"     // Azure 语音服务
+	TTSProviderOpenAI    = "openai"    // OpenAI TTS
+)
+
+// NewTTSProviderFromEnv 按环境变量 TTS_PROVIDER 创建 TTS 提供者（未配置时使用字节跳动 TTS），各提供者的配置见对应的 ConfigFromEnv
+// 切换提供者不影响音频生成流程；音色目录中的音色按 TTS_VOICE_MAP 映射为新提供者的音色
+func NewTTSProviderFromEnv() (noveltools.TTSProvider, error) {
+	switch name := strings.ToLower(strings.TrimSpace(os.Getenv("TTS_PROVIDER"))); name {
+	case "", TTSProviderByteDance:
+		client, err := tts.NewClient(tts.ConfigFromEnv())
+		if err != nil {
+			return nil, err
+		}
+		return NewByteDanceTTSProvider(client), nil
+	case TTSProviderAzure:
+		client, err := tts.NewAzureClient(tts.AzureConfigFromEnv())
+		if err != nil {
+			return nil, err
+		}
+		return NewAzureTTSProvider(client), nil
+	case TTSProviderOpenAI:
+		client, err := tts.NewOpenAIClient(tts.OpenAIConfigFromEnv())
+		if err != nil {
+			return nil, err
+		}
+		return NewOpenAITTSProvider(client), nil
+	default:
+		return nil, fmt.Errorf("unknown TTS provider %q, must be %s/%s/%s", name, TTSProviderByteDance, TTSProviderAzure, TTSProviderOpenAI)
+	}
+}
+
 // ByteDanceTTSProvider 字节跳动 TTS 提供者（使用 pkg/tts 的 Client）
 // 实现了 noveltools.TTSProvider 接口
 type ByteDanceTTSProvider struct {
@@ -26,12 +64,29 @@ func NewByteDanceTTSProvider(client *tts.Client) *ByteDanceTTSProvider {
 	}
 }
 
+// Describe 返回提供者名称和音色
+// 实现了 noveltools.DescribedProvider 接口
+func (p *ByteDanceTTSProvider) Describe() noveltools.ProviderInfo {
+	return noveltools.ProviderInfo{Name: "bytedance_tts", Model: p.client.VoiceType()}
+}
+
 // GenerateVoiceWithTimestamps 生成语音并获取时间戳（使用 TTS 客户端）
 // 实现了 noveltools.TTSProvider 接口
 func (p *ByteDanceTTSProvider) GenerateVoiceWithTimestamps(
 	ctx context.Context,
 	text string,
 	speedRatio float64,
+) (*noveltools.TTSResult, error) {
+	return p.GenerateVoiceWithTimestampsForVoice(ctx, text, speedRatio, "")
+}
+
+// GenerateVoiceWithTimestampsForVoice 使用指定音色生成语音并获取时间戳，voiceType 为空时使用客户端配置的音色
+// 实现了 noveltools.VoiceSelectableTTSProvider 接口
+func (p *ByteDanceTTSProvider) GenerateVoiceWithTimestampsForVoice(
+	ctx context.Context,
+	text string,
+	speedRatio float64,
+	voiceType string,
 ) (*noveltools.TTSResult, error) {
 	if p.client == nil {
 		return &noveltools.TTSResult{
@@ -41,15 +96,125 @@ func (p *ByteDanceTTSProvider) GenerateVoiceWithTimestamps(
 	}
 
 	// 调用 tts.Client，返回 tts.Result
-	ttsResult, err := p.client.GenerateVoiceWithTimestamps(ctx, text, speedRatio)
+	ttsResult, err := p.client.GenerateVoiceWithTimestampsForVoice(ctx, text, speedRatio, voiceType)
+	return convertTTSResult("bytedance_tts", ttsResult, err)
+}
+
+// GenerateVoiceWithPronunciations 按发音提示生成语音：命中词典的词用 SSML <phoneme> 标注拼音，没有命中时按普通文本合成
+// 实现了 noveltools.PronunciationTTSProvider 接口
+func (p *ByteDanceTTSProvider) GenerateVoiceWithPronunciations(
+	ctx context.Context,
+	text string,
+	speedRatio float64,
+	voiceType string,
+	hints []noveltools.PronunciationHint,
+) (*noveltools.TTSResult, error) {
+	ssml, ok := noveltools.BuildPronunciationSSML(text, hints)
+	if !ok {
+		return p.GenerateVoiceWithTimestampsForVoice(ctx, text, speedRatio, voiceType)
+	}
+	if p.client == nil {
+		return &noveltools.TTSResult{
+			Success:      false,
+			ErrorMessage: "TTS client is required",
+		}, nil
+	}
+
+	ttsResult, err := p.client.GenerateVoiceWithSSML(ctx, ssml, text, speedRatio, voiceType)
+	return convertTTSResult("bytedance_tts", ttsResult, err)
+}
+
+// AzureTTSProvider Azure 语音服务 TTS 提供者（使用 pkg/tts 的 AzureClient）
+// 实现了 noveltools.TTSProvider 接口；不支持发音标注，发音词典只能用同音替换文本纠正读音
+type AzureTTSProvider struct {
+	client *tts.AzureClient
+}
+
+// NewAzureTTSProvider 创建 Azure 语音服务 TTS 提供者
+func NewAzureTTSProvider(client *tts.AzureClient) *AzureTTSProvider {
+	return &AzureTTSProvider{
+		client: client,
+	}
+}
+
+// Describe 返回提供者名称和默认音色
+// 实现了 noveltools.DescribedProvider 接口
+func (p *AzureTTSProvider) Describe() noveltools.ProviderInfo {
+	return noveltools.ProviderInfo{Name: "azure_tts", Model: p.client.VoiceType()}
+}
+
+// GenerateVoiceWithTimestamps 使用默认音色生成语音并获取时间戳
+// 实现了 noveltools.TTSProvider 接口
+func (p *AzureTTSProvider) GenerateVoiceWithTimestamps(
+	ctx context.Context,
+	text string,
+	speedRatio float64,
+) (*noveltools.TTSResult, error) {
+	return p.GenerateVoiceWithTimestampsForVoice(ctx, text, speedRatio, "")
+}
+
+// GenerateVoiceWithTimestampsForVoice 使用指定音色生成语音并获取时间戳，voiceType 按音色映射转换
+// 实现了 noveltools.VoiceSelectableTTSProvider 接口
+func (p *AzureTTSProvider) GenerateVoiceWithTimestampsForVoice(
+	ctx context.Context,
+	text string,
+	speedRatio float64,
+	voiceType string,
+) (*noveltools.TTSResult, error) {
+	ttsResult, err := p.client.GenerateVoiceWithTimestampsForVoice(ctx, text, speedRatio, voiceType)
+	return convertTTSResult("azure_tts", ttsResult, err)
+}
+
+// OpenAITTSProvider OpenAI TTS 提供者（使用 pkg/tts 的 OpenAIClient）
+// 实现了 noveltools.TTSProvider 接口；不支持发音标注，发音词典只能用同音替换文本纠正读音
+type OpenAITTSProvider struct {
+	client *tts.OpenAIClient
+}
+
+// NewOpenAITTSProvider 创建 OpenAI TTS 提供者
+func NewOpenAITTSProvider(client *tts.OpenAIClient) *OpenAITTSProvider {
+	return &OpenAITTSProvider{
+		client: client,
+	}
+}
+
+// Describe 返回提供者名称和模型
+// 实现了 noveltools.DescribedProvider 接口
+func (p *OpenAITTSProvider) Describe() noveltools.ProviderInfo {
+	return noveltools.ProviderInfo{Name: "openai_tts", Model: p.client.Model()}
+}
+
+// GenerateVoiceWithTimestamps 使用默认音色生成语音并获取时间戳
+// 实现了 noveltools.TTSProvider 接口
+func (p *OpenAITTSProvider) GenerateVoiceWithTimestamps(
+	ctx context.Context,
+	text string,
+	speedRatio float64,
+) (*noveltools.TTSResult, error) {
+	return p.GenerateVoiceWithTimestampsForVoice(ctx, text, speedRatio, "")
+}
+
+// GenerateVoiceWithTimestampsForVoice 使用指定音色生成语音并获取时间戳，voiceType 按音色映射转换
+// 实现了 noveltools.VoiceSelectableTTSProvider 接口
+func (p *OpenAITTSProvider) GenerateVoiceWithTimestampsForVoice(
+	ctx context.Context,
+	text string,
+	speedRatio float64,
+	voiceType string,
+) (*noveltools.TTSResult, error) {
+	ttsResult, err := p.client.GenerateVoiceWithTimestampsForVoice(ctx, text, speedRatio, voiceType)
+	return convertTTSResult("openai_tts", ttsResult, err)
+}
+
+// convertTTSResult 转换 tts.Result 到 noveltools.TTSResult，错误按 provider 分类
+func convertTTSResult(provider string, ttsResult *tts.Result, err error) (*noveltools.TTSResult, error) {
 	if err != nil {
 		return &noveltools.TTSResult{
 			Success:      false,
 			ErrorMessage: err.Error(),
-		}, err
+		}, errclass.Provider(provider, err)
 	}
 
-	// 转换 tts.Result 到 noveltools.TTSResult
 	result := &noveltools.TTSResult{
 		Success:      ttsResult.Success,
 		AudioData:    ttsResult.AudioData,
@@ -7,6 +7,7 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"lemon/internal/pkg/ark"
+	"lemon/internal/pkg/errclass"
 	"lemon/internal/pkg/noveltools"
 )
 
@@ -30,12 +31,18 @@ func NewArkVideoProvider() (noveltools.VideoProvider, error) {
 	}, nil
 }
 
+// Describe 返回提供者名称和模型
+// 实现了 noveltools.DescribedProvider 接口
+func (p *ArkVideoProvider) Describe() noveltools.ProviderInfo {
+	return noveltools.ProviderInfo{Name: "ark_video", Model: p.client.Model()}
+}
+
 // GenerateVideoFromImage 从图片生成视频
 // 调用 ark.ArkVideoClient.GenerateVideoFromImage
 func (p *ArkVideoProvider) GenerateVideoFromImage(ctx context.Context, imageDataURL string, duration int, prompt string) ([]byte, error) {
 	videoData, err := p.client.GenerateVideoFromImage(ctx, imageDataURL, duration, prompt)
 	if err != nil {
-		return nil, fmt.Errorf("Ark generate video: %w", err)
+		return nil, errclass.Provider("ark_video", fmt.Errorf("Ark generate video: %w", err))
 	}
 
 	log.Info().
@@ -0,0 +1,263 @@
+package noveltools
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"lemon/internal/model/novel"
+)
+
+// QA 评分规则
+const (
+	DefaultQAMinScore = 60.0 // 默认允许发布的最低分
+	qaWarningPenalty  = 10.0 // 每个警告扣除的分数
+	qaSyncTolerance   = 0.5  // 字幕/视频与音频时长允许的偏差（秒），与渲染时的同步诊断一致
+)
+
+// qaSignalWeights 各信号计入总分的权重，不可用的信号按剩余权重重新归一化
+var qaSignalWeights = map[novel.QASignal]float64{
+	novel.QASignalNarration:    0.2,
+	novel.QASignalSubtitleSync: 0.2,
+	novel.QASignalVideo:        0.3,
+	novel.QASignalImage:        0.2,
+	novel.QASignalASR:          0.1,
+}
+
+// ScoreQASignal 根据问题列表计算信号得分：满分 100，每个警告扣 10 分，存在阻断问题时记 0 分
+func ScoreQASignal(signal novel.QASignal, findings []novel.QAFinding) novel.QASignalResult {
+	score := 100.0
+	for _, f := range findings {
+		if f.Severity == novel.QASeverityBlocking {
+			score = 0
+			break
+		}
+		score -= qaWarningPenalty
+	}
+	return novel.QASignalResult{
+		Signal:    signal,
+		Available: true,
+		Score:     math.Max(score, 0),
+		Weight:    qaSignalWeights[signal],
+		Findings:  findings,
+	}
+}
+
+// UnavailableQASignal 没有可用数据的信号，不计入总分
+func UnavailableQASignal(signal novel.QASignal, note string) novel.QASignalResult {
+	return novel.QASignalResult{
+		Signal: signal,
+		Weight: qaSignalWeights[signal],
+		Note:   note,
+	}
+}
+
+// BuildQAScorecard 汇总各信号的得分生成评分卡
+// 阻断规则：任一信号存在阻断问题，或总分低于 minScore 时不允许发布；没有任何可用信号时同样阻断
+func BuildQAScorecard(signals []novel.QASignalResult, minScore float64) *novel.QAScorecard {
+	card := &novel.QAScorecard{
+		MinScore:    minScore,
+		Signals:     signals,
+		GeneratedAt: time.Now(),
+	}
+
+	var weighted, totalWeight float64
+	for _, s := range signals {
+		if !s.Available {
+			continue
+		}
+		weighted += s.Score * s.Weight
+		totalWeight += s.Weight
+		for _, f := range s.Findings {
+			if f.Severity == novel.QASeverityBlocking {
+				card.BlockingReasons = append(card.BlockingReasons, fmt.Sprintf("%s: %s", s.Signal, f.Message))
+			}
+		}
+	}
+	if totalWeight > 0 {
+		card.Score = math.Round(weighted/totalWeight*10) / 10
+	} else {
+		card.BlockingReasons = append(card.BlockingReasons, "no qa signal available")
+	}
+	if totalWeight > 0 && card.Score < minScore {
+		card.BlockingReasons = append(card.BlockingReasons, fmt.Sprintf("score %.1f is below %.1f", card.Score, minScore))
+	}
+	card.Grade = QAGrade(card.Score)
+	card.Blocked = len(card.BlockingReasons) > 0
+	return card
+}
+
+// QAGrade 分数对应的等级：90 分以上 A，80 分以上 B，70 分以上 C，60 分以上 D，其余 F
+func QAGrade(score float64) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	case score >= 60:
+		return "D"
+	default:
+		return "F"
+	}
+}
+
+// NarrationQAFindings 把解说文案的校验结果转换为 QA 问题：校验不通过为阻断，校验警告和开头特写字数不符为警告
+func NarrationQAFindings(result *ValidationResult) []novel.QAFinding {
+	if !result.IsValid {
+		return []novel.QAFinding{{Severity: novel.QASeverityBlocking, Message: result.Message}}
+	}
+	var findings []novel.QAFinding
+	for _, w := range result.Warnings {
+		findings = append(findings, novel.QAFinding{Severity: novel.QASeverityWarning, Message: w})
+	}
+	closeups := []struct {
+		name string
+		v    *CloseupValidation
+	}{
+		{"第一个特写", result.FirstCloseup},
+		{"第二个特写", result.SecondCloseup},
+	}
+	for _, c := range closeups {
+		if c.v != nil && c.v.Exists && !c.v.Valid {
+			findings = append(findings, novel.QAFinding{
+				Severity: novel.QASeverityWarning,
+				Message:  fmt.Sprintf("分镜1%s%d字，要求30-32字", c.name, c.v.CharCount),
+			})
+		}
+	}
+	return findings
+}
+
+// SubtitleSyncQAFindings 检查字幕与音频的同步
+// 字幕由音频的字符时间戳生成：时间戳缺失、开头晚于 0.5 秒、结束早于或晚于音频结束 0.5 秒以上记为警告；
+// 没有音频、音频生成失败为阻断，音频片段缺少字幕记为警告
+func SubtitleSyncQAFindings(audios []*novel.Audio, subtitles []*novel.Subtitle) []novel.QAFinding {
+	if len(audios) == 0 {
+		return []novel.QAFinding{{Severity: novel.QASeverityBlocking, Message: "没有音频"}}
+	}
+
+	hasSubtitle := make(map[int]bool, len(subtitles))
+	for _, s := range subtitles {
+		if s.Status != novel.TaskStatusFailed && s.SubtitleResourceID != "" {
+			hasSubtitle[s.Sequence] = true
+		}
+	}
+
+	var findings []novel.QAFinding
+	for _, a := range audios {
+		ref := fmt.Sprintf("audio#%d", a.Sequence)
+		if a.Status != novel.TaskStatusCompleted {
+			findings = append(findings, novel.QAFinding{Severity: novel.QASeverityBlocking, Message: fmt.Sprintf("音频状态为 %s", a.Status), Ref: ref})
+			continue
+		}
+		if !hasSubtitle[a.Sequence] {
+			findings = append(findings, novel.QAFinding{Severity: novel.QASeverityWarning, Message: "缺少字幕", Ref: ref})
+		}
+		if len(a.Timestamps) == 0 {
+			findings = append(findings, novel.QAFinding{Severity: novel.QASeverityWarning, Message: "缺少字符时间戳，字幕无法与音频对齐", Ref: ref})
+			continue
+		}
+		first := a.Timestamps[0].StartTime
+		last := a.Timestamps[len(a.Timestamps)-1].EndTime
+		if first > qaSyncTolerance {
+			findings = append(findings, novel.QAFinding{Severity: novel.QASeverityWarning, Message: fmt.Sprintf("字幕从 %.2fs 开始，晚于音频开头", first), Ref: ref})
+		}
+		if last < a.Duration-qaSyncTolerance {
+			findings = append(findings, novel.QAFinding{Severity: novel.QASeverityWarning, Message: fmt.Sprintf("字幕在 %.2fs 结束，早于音频结束 %.2fs", last, a.Duration), Ref: ref})
+		}
+		if last > a.Duration+qaSyncTolerance {
+			findings = append(findings, novel.QAFinding{Severity: novel.QASeverityWarning, Message: fmt.Sprintf("字幕在 %.2fs 结束，晚于音频结束 %.2fs", last, a.Duration), Ref: ref})
+		}
+	}
+	return findings
+}
+
+// VideoQAFindings 检查解说视频片段和最终视频
+// 没有最终视频、最终视频或片段未完成、镜头没有对应片段为阻断；
+// 片段时长与对应音频总时长偏差超过 0.5 秒、最终视频未做平台规范校验或校验不通过为警告
+func VideoQAFindings(final *novel.Video, clips []*novel.Video, audios []*novel.Audio, shots []*novel.Shot) []novel.QAFinding {
+	var findings []novel.QAFinding
+	switch {
+	case final == nil:
+		findings = append(findings, novel.QAFinding{Severity: novel.QASeverityBlocking, Message: "没有最终视频"})
+	case final.Status != novel.VideoStatusCompleted:
+		findings = append(findings, novel.QAFinding{Severity: novel.QASeverityBlocking, Message: fmt.Sprintf("最终视频状态为 %s", final.Status), Ref: final.ID})
+	case final.Compliance == nil:
+		findings = append(findings, novel.QAFinding{Severity: novel.QASeverityWarning, Message: "最终视频没有平台规范校验报告", Ref: final.ID})
+	case !final.Compliance.Passed:
+		for _, issue := range final.Compliance.Issues {
+			findings = append(findings, novel.QAFinding{Severity: novel.QASeverityWarning, Message: fmt.Sprintf("平台规范（%s）：%s", final.Compliance.Preset, issue), Ref: final.ID})
+		}
+	}
+
+	audioDuration := make(map[int]float64, len(audios))
+	for _, a := range audios {
+		audioDuration[a.Sequence] += a.Duration
+	}
+
+	covered := make(map[int]bool)
+	for _, clip := range clips {
+		start, end := clip.SequenceRange()
+		ref := fmt.Sprintf("clip#%d", start)
+		if end > start {
+			ref = fmt.Sprintf("clip#%d-%d", start, end)
+		}
+		if clip.Status != novel.VideoStatusCompleted {
+			findings = append(findings, novel.QAFinding{Severity: novel.QASeverityBlocking, Message: fmt.Sprintf("视频片段状态为 %s", clip.Status), Ref: ref})
+			continue
+		}
+		var expected float64
+		for index := start; index <= end; index++ {
+			covered[index] = true
+			expected += audioDuration[index]
+		}
+		if expected > 0 && math.Abs(clip.Duration-expected) > qaSyncTolerance {
+			findings = append(findings, novel.QAFinding{
+				Severity: novel.QASeverityWarning,
+				Message:  fmt.Sprintf("视频片段时长 %.2fs 与音频时长 %.2fs 相差超过 %.1fs", clip.Duration, expected, qaSyncTolerance),
+				Ref:      ref,
+			})
+		}
+	}
+
+	if len(clips) > 0 {
+		for _, shot := range shots {
+			if !covered[shot.Index] {
+				findings = append(findings, novel.QAFinding{Severity: novel.QASeverityBlocking, Message: "镜头没有对应的视频片段", Ref: shot.ID})
+			}
+		}
+	}
+	return findings
+}
+
+// ImageQAFindings 检查镜头图片：优先按镜头ID匹配，旧数据按场景和镜头编号匹配
+// 镜头没有图片、图片未生成完成或没有图片文件为阻断
+func ImageQAFindings(shots []*novel.Shot, images []*novel.Image) []novel.QAFinding {
+	byShotID := make(map[string]*novel.Image, len(images))
+	byNumber := make(map[string]*novel.Image, len(images))
+	for _, img := range images {
+		if img.ShotID != "" {
+			byShotID[img.ShotID] = img
+		}
+		byNumber[img.SceneNumber+"/"+img.ShotNumber] = img
+	}
+
+	var findings []novel.QAFinding
+	for _, shot := range shots {
+		img, ok := byShotID[shot.ID]
+		if !ok {
+			img, ok = byNumber[shot.SceneNumber+"/"+shot.ShotNumber]
+		}
+		switch {
+		case !ok:
+			findings = append(findings, novel.QAFinding{Severity: novel.QASeverityBlocking, Message: "镜头没有图片", Ref: shot.ID})
+		case img.Status != novel.TaskStatusCompleted:
+			findings = append(findings, novel.QAFinding{Severity: novel.QASeverityBlocking, Message: fmt.Sprintf("图片状态为 %s", img.Status), Ref: shot.ID})
+		case img.ImageResourceID == "":
+			findings = append(findings, novel.QAFinding{Severity: novel.QASeverityBlocking, Message: "图片没有文件", Ref: shot.ID})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,186 @@
+package noveltools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestScoreQASignal(t *testing.T) {
+	Convey("ScoreQASignal 计算信号得分", t, func() {
+		Convey("没有问题时满分", func() {
+			s := ScoreQASignal(novel.QASignalVideo, nil)
+			So(s.Available, ShouldBeTrue)
+			So(s.Score, ShouldEqual, 100)
+			So(s.Weight, ShouldEqual, 0.3)
+		})
+
+		Convey("每个警告扣 10 分，最低 0 分", func() {
+			warning := novel.QAFinding{Severity: novel.QASeverityWarning, Message: "w"}
+			So(ScoreQASignal(novel.QASignalNarration, []novel.QAFinding{warning, warning}).Score, ShouldEqual, 80)
+
+			many := make([]novel.QAFinding, 12)
+			for i := range many {
+				many[i] = warning
+			}
+			So(ScoreQASignal(novel.QASignalNarration, many).Score, ShouldEqual, 0)
+		})
+
+		Convey("阻断问题记 0 分", func() {
+			s := ScoreQASignal(novel.QASignalImage, []novel.QAFinding{{Severity: novel.QASeverityBlocking, Message: "b"}})
+			So(s.Score, ShouldEqual, 0)
+		})
+	})
+}
+
+func TestBuildQAScorecard(t *testing.T) {
+	Convey("BuildQAScorecard 汇总评分", t, func() {
+		warning := novel.QAFinding{Severity: novel.QASeverityWarning, Message: "w"}
+
+		Convey("按可用信号的权重加权，不可用信号不计入", func() {
+			card := BuildQAScorecard([]novel.QASignalResult{
+				ScoreQASignal(novel.QASignalNarration, []novel.QAFinding{warning, warning}), // 80 * 0.2
+				ScoreQASignal(novel.QASignalVideo, nil),                                     // 100 * 0.3
+				UnavailableQASignal(novel.QASignalASR, "n/a"),
+			}, DefaultQAMinScore)
+			So(card.Score, ShouldEqual, 92)
+			So(card.Grade, ShouldEqual, "A")
+			So(card.Blocked, ShouldBeFalse)
+			So(card.BlockingReasons, ShouldBeEmpty)
+		})
+
+		Convey("存在阻断问题时阻断", func() {
+			card := BuildQAScorecard([]novel.QASignalResult{
+				ScoreQASignal(novel.QASignalNarration, nil),
+				ScoreQASignal(novel.QASignalImage, []novel.QAFinding{{Severity: novel.QASeverityBlocking, Message: "镜头没有图片"}}),
+			}, DefaultQAMinScore)
+			So(card.Score, ShouldEqual, 50)
+			So(card.Grade, ShouldEqual, "F")
+			So(card.Blocked, ShouldBeTrue)
+			So(card.BlockingReasons, ShouldContain, "image: 镜头没有图片")
+		})
+
+		Convey("总分低于最低分时阻断", func() {
+			card := BuildQAScorecard([]novel.QASignalResult{
+				ScoreQASignal(novel.QASignalNarration, []novel.QAFinding{warning, warning, warning}),
+			}, 75)
+			So(card.Score, ShouldEqual, 70)
+			So(card.Grade, ShouldEqual, "C")
+			So(card.Blocked, ShouldBeTrue)
+		})
+
+		Convey("没有可用信号时阻断", func() {
+			card := BuildQAScorecard([]novel.QASignalResult{UnavailableQASignal(novel.QASignalASR, "n/a")}, DefaultQAMinScore)
+			So(card.Blocked, ShouldBeTrue)
+		})
+	})
+}
+
+func TestSubtitleSyncQAFindings(t *testing.T) {
+	Convey("SubtitleSyncQAFindings 检查字幕与音频同步", t, func() {
+		audio := func(seq int, duration, first, last float64) *novel.Audio {
+			return &novel.Audio{
+				Sequence: seq,
+				Duration: duration,
+				Status:   novel.TaskStatusCompleted,
+				Timestamps: []novel.CharTime{
+					{Character: "一", StartTime: first, EndTime: first + 0.2},
+					{Character: "二", StartTime: last - 0.2, EndTime: last},
+				},
+			}
+		}
+		subtitles := []*novel.Subtitle{
+			{Sequence: 1, SubtitleResourceID: "s1", Status: novel.TaskStatusCompleted},
+			{Sequence: 2, SubtitleResourceID: "s2", Status: novel.TaskStatusCompleted},
+		}
+
+		Convey("时间戳覆盖音频时长时没有问题", func() {
+			So(SubtitleSyncQAFindings([]*novel.Audio{audio(1, 5, 0.1, 4.8), audio(2, 3, 0, 3.2)}, subtitles), ShouldBeEmpty)
+		})
+
+		Convey("开头过晚、结束过早和缺少字幕记为警告", func() {
+			findings := SubtitleSyncQAFindings([]*novel.Audio{audio(1, 5, 0.8, 4.8), audio(2, 3, 0, 2), audio(3, 2, 0, 2)}, subtitles)
+			So(findings, ShouldHaveLength, 3)
+			for _, f := range findings {
+				So(f.Severity, ShouldEqual, novel.QASeverityWarning)
+			}
+			So(findings[2].Ref, ShouldEqual, "audio#3")
+		})
+
+		Convey("没有音频或音频失败时阻断", func() {
+			So(SubtitleSyncQAFindings(nil, subtitles)[0].Severity, ShouldEqual, novel.QASeverityBlocking)
+			failed := audio(1, 5, 0, 5)
+			failed.Status = novel.TaskStatusFailed
+			So(SubtitleSyncQAFindings([]*novel.Audio{failed}, subtitles)[0].Severity, ShouldEqual, novel.QASeverityBlocking)
+		})
+	})
+}
+
+func TestVideoQAFindings(t *testing.T) {
+	Convey("VideoQAFindings 检查片段和最终视频", t, func() {
+		final := &novel.Video{ID: "final", Status: novel.VideoStatusCompleted, Compliance: &novel.ComplianceReport{Preset: "douyin", Passed: true}}
+		shots := []*novel.Shot{{ID: "s1", Index: 1}, {ID: "s2", Index: 2}, {ID: "s3", Index: 3}}
+		audios := []*novel.Audio{{Sequence: 1, Duration: 4}, {Sequence: 2, Duration: 3}, {Sequence: 3, Duration: 2}}
+		clips := []*novel.Video{
+			{Sequence: 1, Duration: 4.1, Status: novel.VideoStatusCompleted},
+			{Sequence: 2, SequenceEnd: 3, Duration: 5, Status: novel.VideoStatusCompleted},
+		}
+
+		Convey("片段覆盖所有镜头且时长匹配时没有问题", func() {
+			So(VideoQAFindings(final, clips, audios, shots), ShouldBeEmpty)
+		})
+
+		Convey("没有最终视频、镜头缺少片段时阻断", func() {
+			findings := VideoQAFindings(nil, clips[:1], audios, shots)
+			So(findings, ShouldHaveLength, 3)
+			for _, f := range findings {
+				So(f.Severity, ShouldEqual, novel.QASeverityBlocking)
+			}
+		})
+
+		Convey("时长偏差和平台规范问题记为警告", func() {
+			clips[0].Duration = 6
+			final.Compliance = &novel.ComplianceReport{Preset: "douyin", Issues: []string{"true peak too high"}}
+			findings := VideoQAFindings(final, clips, audios, shots)
+			So(findings, ShouldHaveLength, 2)
+			So(findings[1].Ref, ShouldEqual, "clip#1")
+			So(findings[1].Severity, ShouldEqual, novel.QASeverityWarning)
+		})
+	})
+}
+
+func TestImageQAFindings(t *testing.T) {
+	Convey("ImageQAFindings 检查镜头图片", t, func() {
+		shots := []*novel.Shot{
+			{ID: "s1", SceneNumber: "1", ShotNumber: "1"},
+			{ID: "s2", SceneNumber: "1", ShotNumber: "2"},
+			{ID: "s3", SceneNumber: "2", ShotNumber: "1"},
+		}
+		images := []*novel.Image{
+			{ShotID: "s1", SceneNumber: "1", ShotNumber: "1", ImageResourceID: "r1", Status: novel.TaskStatusCompleted},
+			{SceneNumber: "1", ShotNumber: "2", ImageResourceID: "r2", Status: novel.TaskStatusFailed}, // 旧数据按编号匹配
+		}
+
+		findings := ImageQAFindings(shots, images)
+		So(findings, ShouldHaveLength, 2)
+		So(findings[0].Ref, ShouldEqual, "s2")
+		So(findings[1].Ref, ShouldEqual, "s3")
+		So(findings[1].Message, ShouldEqual, "镜头没有图片")
+	})
+}
+
+func TestNarrationQAFindings(t *testing.T) {
+	Convey("NarrationQAFindings 转换解说校验结果", t, func() {
+		So(NarrationQAFindings(&ValidationResult{IsValid: false, Message: "缺少 scenes 字段或 scenes 为空"})[0].Severity, ShouldEqual, novel.QASeverityBlocking)
+
+		findings := NarrationQAFindings(&ValidationResult{
+			IsValid:      true,
+			Warnings:     []string{"解说文本长度不足"},
+			FirstCloseup: &CloseupValidation{CharCount: 20, Exists: true},
+		})
+		So(findings, ShouldHaveLength, 2)
+		So(findings[1].Message, ShouldContainSubstring, "20字")
+	})
+}
@@ -0,0 +1,130 @@
+package noveltools
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// ReadingCharsPerSecond TTS 1.0 倍速下每秒朗读的字数（中文播音约每分钟 250 字）
+	ReadingCharsPerSecond = 4.2
+
+	// ReadingShotMinDuration 镜头朗读时长低于该值时画面一闪而过
+	ReadingShotMinDuration = 1.5
+	// ReadingShotMaxDuration 镜头朗读时长超过该值时图生视频接口无法生成，会回退为图片缩放效果
+	ReadingShotMaxDuration = 12.0
+
+	// readingSentencePause 句末标点的停顿（秒，1.0 倍速）
+	readingSentencePause = 0.4
+	// readingClausePause 句中标点的停顿（秒，1.0 倍速）
+	readingClausePause = 0.2
+	// readingWordChars 一个英文单词或数字按几个汉字的朗读时长计算
+	readingWordChars = 2
+	// readingSubtitleMaxChars 估算字幕每行的最大字数
+	readingSubtitleMaxChars = 16
+)
+
+// ReadingEstimate 文本的朗读时长估算
+type ReadingEstimate struct {
+	Characters int     `json:"characters"` // 朗读字数（不含标点，英文单词和数字按 2 字计算）
+	Pauses     float64 `json:"pauses"`     // 标点停顿时长（秒）
+	Duration   float64 `json:"duration"`   // 估算朗读时长（秒）
+}
+
+// EstimateReadingTime 估算文本按指定语速朗读的时长
+// 时长 = 字数 ÷ 朗读速度 + 标点停顿，整体按语速缩放；speedRatio<=0 时按 1.0 倍速计算
+func EstimateReadingTime(text string, speedRatio float64) ReadingEstimate {
+	return estimateReading(text, speedRatio, false)
+}
+
+// estimateReading 估算朗读时长，trailingPause 为 false 时结尾的句末标点不计停顿（下一段文本的停顿由镜头切换承担）
+func estimateReading(text string, speedRatio float64, trailingPause bool) ReadingEstimate {
+	if speedRatio <= 0 {
+		speedRatio = 1
+	}
+
+	var chars int
+	var pauses float64
+	inWord := false
+	for _, r := range text {
+		switch {
+		case r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if !inWord {
+				chars += readingWordChars
+			}
+			inWord = true
+			continue
+		case strings.ContainsRune(ttsSentenceEndings, r):
+			pauses += readingSentencePause
+		case strings.ContainsRune(ttsClauseEndings, r):
+			pauses += readingClausePause
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			chars++
+		}
+		inWord = false
+	}
+	if trimmed := strings.TrimRight(strings.TrimSpace(text), ttsClosingMarks); !trailingPause && trimmed != "" {
+		last, _ := utf8.DecodeLastRuneInString(trimmed)
+		if strings.ContainsRune(ttsSentenceEndings, last) {
+			pauses -= readingSentencePause
+		}
+	}
+	pauses = math.Max(pauses, 0) / speedRatio
+
+	return ReadingEstimate{
+		Characters: chars,
+		Pauses:     roundSeconds(pauses),
+		Duration:   roundSeconds(float64(chars)/(ReadingCharsPerSecond*speedRatio) + pauses),
+	}
+}
+
+// EstimateSubtitleTimeline 按估算的朗读时长为文本生成字幕时间轴（不需要 TTS 音频）
+// 文本在标点处切分为不超过 16 字的字幕，每条字幕的时长为其估算朗读时长（含句间停顿），从 offset 秒开始依次排列，
+// 总时长与 EstimateReadingTime 一致
+func EstimateSubtitleTimeline(text string, speedRatio, offset float64) []SegmentTimestamp {
+	var lines []string
+	var cur strings.Builder
+	flush := func() {
+		if line := strings.TrimSpace(cur.String()); line != "" {
+			lines = append(lines, line)
+		}
+		cur.Reset()
+	}
+	for _, piece := range splitAfter(strings.TrimSpace(text), ttsSentenceEndings+ttsClauseEndings) {
+		for _, part := range hardSplit(strings.TrimSpace(piece), readingSubtitleMaxChars) {
+			if cur.Len() > 0 && utf8.RuneCountInString(cur.String()+part) > readingSubtitleMaxChars {
+				flush()
+			}
+			cur.WriteString(part)
+		}
+		// 句末标点处总是换行
+		if last, _ := utf8.DecodeLastRuneInString(strings.TrimRight(piece, ttsClosingMarks)); strings.ContainsRune(ttsSentenceEndings, last) {
+			flush()
+		}
+	}
+	flush()
+
+	timeline := make([]SegmentTimestamp, 0, len(lines))
+	start := offset
+	for i, line := range lines {
+		duration := estimateReading(line, speedRatio, i < len(lines)-1).Duration
+		display := strings.TrimRight(line, ttsSentenceEndings+ttsClauseEndings)
+		if display == "" {
+			continue
+		}
+		timeline = append(timeline, SegmentTimestamp{
+			Text:      display,
+			StartTime: roundSeconds(start),
+			EndTime:   roundSeconds(start + duration),
+		})
+		start += duration
+	}
+	return timeline
+}
+
+// roundSeconds 时长保留两位小数
+func roundSeconds(seconds float64) float64 {
+	return math.Round(seconds*100) / 100
+}
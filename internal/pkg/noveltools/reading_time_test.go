@@ -0,0 +1,72 @@
+package noveltools
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEstimateReadingTime(t *testing.T) {
+	Convey("EstimateReadingTime 按字数和标点停顿估算朗读时长", t, func() {
+		Convey("结尾的句末标点不计停顿", func() {
+			estimate := EstimateReadingTime("林凡握紧了剑。", 1)
+			So(estimate.Characters, ShouldEqual, 6)
+			So(estimate.Pauses, ShouldEqual, 0)
+			So(estimate.Duration, ShouldEqual, 1.43)
+		})
+
+		Convey("句中标点计入停顿", func() {
+			estimate := EstimateReadingTime("林凡握紧了剑，长老缓缓走来。", 1)
+			So(estimate.Characters, ShouldEqual, 12)
+			So(estimate.Pauses, ShouldEqual, 0.2)
+			So(estimate.Duration, ShouldEqual, 3.06)
+		})
+
+		Convey("语速越快时长越短，未设置语速按 1.0 倍速", func() {
+			So(EstimateReadingTime("林凡握紧了剑。", 1.2).Duration, ShouldEqual, 1.19)
+			So(EstimateReadingTime("林凡握紧了剑。", 0).Duration, ShouldEqual, 1.43)
+		})
+
+		Convey("英文单词和数字按 2 字计算", func() {
+			So(EstimateReadingTime("他用AI写了300字", 1).Characters, ShouldEqual, 9)
+		})
+
+		Convey("空文本时长为 0", func() {
+			So(EstimateReadingTime("  ", 1.2).Duration, ShouldEqual, 0)
+		})
+	})
+}
+
+func TestEstimateSubtitleTimeline(t *testing.T) {
+	Convey("EstimateSubtitleTimeline 按估算时长生成字幕时间轴", t, func() {
+		Convey("按句切分并从 offset 开始依次排列，总时长与朗读估算一致", func() {
+			text := "林凡握紧了剑。长老缓缓走来！"
+			timeline := EstimateSubtitleTimeline(text, 1, 2)
+			So(timeline, ShouldResemble, []SegmentTimestamp{
+				{Text: "林凡握紧了剑", StartTime: 2, EndTime: 3.83},
+				{Text: "长老缓缓走来", StartTime: 3.83, EndTime: 5.26},
+			})
+			So(timeline[1].EndTime, ShouldEqual, 2+EstimateReadingTime(text, 1).Duration)
+		})
+
+		Convey("长句在逗号处换行，每行不超过 16 字", func() {
+			text := "林凡握紧了手中的长剑，目光冰冷如霜，缓缓走向山门，" + strings.Repeat("雪", 30) + "。"
+			timeline := EstimateSubtitleTimeline(text, 1.2, 0)
+			So(len(timeline), ShouldBeGreaterThan, 3)
+			So(timeline[0].Text, ShouldEqual, "林凡握紧了手中的长剑")
+			So(timeline[1].Text, ShouldEqual, "目光冰冷如霜，缓缓走向山门")
+			for i, seg := range timeline {
+				So(utf8.RuneCountInString(seg.Text), ShouldBeLessThanOrEqualTo, 16)
+				if i > 0 {
+					So(seg.StartTime, ShouldEqual, timeline[i-1].EndTime)
+				}
+			}
+		})
+
+		Convey("空文本没有字幕", func() {
+			So(EstimateSubtitleTimeline("", 1, 0), ShouldBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,140 @@
+package noveltools
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"lemon/internal/model/novel"
+)
+
+// SafeArea 平台安全区
+// 各字段为画面四边被平台 UI（点赞/评论按钮、标题文案、进度条等）遮挡的比例，相对画面宽/高（0~1）
+type SafeArea struct {
+	Top    float64 // 顶部遮挡比例（搜索栏、直播入口等）
+	Bottom float64 // 底部遮挡比例（作者名、文案、音乐条、进度条等）
+	Left   float64 // 左侧遮挡比例
+	Right  float64 // 右侧遮挡比例（头像、点赞、评论、分享按钮列）
+}
+
+// safeAreaPresets 各平台的安全区预设（竖屏 9:16）
+var safeAreaPresets = map[novel.TargetPlatform]SafeArea{
+	novel.TargetPlatformDefault:  {},
+	novel.TargetPlatformDouyin:   {Top: 0.08, Bottom: 0.15, Left: 0.04, Right: 0.15},
+	novel.TargetPlatformTikTok:   {Top: 0.10, Bottom: 0.15, Left: 0.04, Right: 0.15},
+	novel.TargetPlatformKuaishou: {Top: 0.08, Bottom: 0.15, Left: 0.04, Right: 0.14},
+	novel.TargetPlatformYouTube:  {Top: 0.08, Bottom: 0.20, Left: 0.04, Right: 0.15},
+	novel.TargetPlatformBilibili: {Top: 0.08, Bottom: 0.14, Left: 0.04, Right: 0.13},
+}
+
+// SafeAreaForPlatform 返回平台的安全区预设，未知平台返回空安全区（不做调整）
+func SafeAreaForPlatform(platform novel.TargetPlatform) SafeArea {
+	return safeAreaPresets[platform]
+}
+
+// IsZero 判断安全区是否为空（无需调整排版）
+func (a SafeArea) IsZero() bool {
+	return a == SafeArea{}
+}
+
+// ASS 默认画布尺寸（与 ASSGenerator 生成的 PlayResX/PlayResY 保持一致）
+const (
+	defaultASSPlayResX = 1920
+	defaultASSPlayResY = 1080
+)
+
+// ApplySafeAreaToASS 按安全区调整 ASS 字幕所有样式的边距，使字幕避开平台 UI
+// 边距按 PlayResX/PlayResY 换算为画布坐标，只会增大原有边距，不会缩小；
+// 底部对齐的样式调整 MarginV 避开底部区域，顶部对齐的样式避开顶部区域
+func ApplySafeAreaToASS(content string, area SafeArea) string {
+	if area.IsZero() {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	playResX, playResY := defaultASSPlayResX, defaultASSPlayResY
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if v, ok := parseASSHeaderInt(trimmed, "PlayResX:"); ok {
+			playResX = v
+		}
+		if v, ok := parseASSHeaderInt(trimmed, "PlayResY:"); ok {
+			playResY = v
+		}
+	}
+
+	minLeft := int(math.Ceil(area.Left * float64(playResX)))
+	minRight := int(math.Ceil(area.Right * float64(playResX)))
+	minTop := int(math.Ceil(area.Top * float64(playResY)))
+	minBottom := int(math.Ceil(area.Bottom * float64(playResY)))
+
+	// 字段下标从 [V4+ Styles] 的 Format 行解析，缺失时使用 ASS 标准顺序
+	idx := map[string]int{"Alignment": 18, "MarginL": 19, "MarginR": 20, "MarginV": 21}
+	inStyles := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			inStyles = strings.EqualFold(trimmed, "[V4+ Styles]") || strings.EqualFold(trimmed, "[V4 Styles]")
+			continue
+		}
+		if !inStyles {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "Format:") {
+			fields := strings.Split(strings.TrimPrefix(trimmed, "Format:"), ",")
+			for j, f := range fields {
+				if _, ok := idx[strings.TrimSpace(f)]; ok {
+					idx[strings.TrimSpace(f)] = j
+				}
+			}
+			continue
+		}
+		if !strings.HasPrefix(trimmed, "Style:") {
+			continue
+		}
+
+		fields := strings.Split(strings.TrimPrefix(trimmed, "Style:"), ",")
+		if len(fields) <= idx["MarginV"] || len(fields) <= idx["Alignment"] {
+			continue
+		}
+		raiseASSField(fields, idx["MarginL"], minLeft)
+		raiseASSField(fields, idx["MarginR"], minRight)
+
+		// Alignment 使用小键盘布局：1-3 底部，4-6 居中，7-9 顶部
+		alignment, _ := strconv.Atoi(strings.TrimSpace(fields[idx["Alignment"]]))
+		switch {
+		case alignment >= 1 && alignment <= 3:
+			raiseASSField(fields, idx["MarginV"], minBottom)
+		case alignment >= 7 && alignment <= 9:
+			raiseASSField(fields, idx["MarginV"], minTop)
+		}
+
+		lines[i] = "Style:" + strings.Join(fields, ",")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// parseASSHeaderInt 解析 ASS [Script Info] 中的整数字段（如 PlayResX: 1920）
+func parseASSHeaderInt(line, key string) (int, bool) {
+	if !strings.HasPrefix(line, key) {
+		return 0, false
+	}
+	v, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, key)))
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+	return v, true
+}
+
+// raiseASSField 将数值字段提升到至少 min（无法解析时直接使用 min）
+func raiseASSField(fields []string, index, min int) {
+	if index >= len(fields) {
+		return
+	}
+	v, err := strconv.Atoi(strings.TrimSpace(fields[index]))
+	if err == nil && v >= min {
+		return
+	}
+	fields[index] = strconv.Itoa(min)
+}
@@ -0,0 +1,52 @@
+package noveltools
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestApplySafeAreaToASS(t *testing.T) {
+	Convey("ApplySafeAreaToASS 按平台安全区调整字幕边距", t, func() {
+		content := NewASSGenerator().GenerateASSContent([]SegmentTimestamp{
+			{Text: "天下英雄", StartTime: 0, EndTime: 1.5},
+		}, "test")
+
+		styleLine := func(content, name string) []string {
+			for _, line := range strings.Split(content, "\n") {
+				if strings.HasPrefix(line, "Style: "+name+",") {
+					return strings.Split(line, ",")
+				}
+			}
+			return nil
+		}
+
+		Convey("默认平台不做调整", func() {
+			So(ApplySafeAreaToASS(content, SafeAreaForPlatform(novel.TargetPlatformDefault)), ShouldEqual, content)
+		})
+
+		Convey("抖音避开右侧按钮列", func() {
+			result := ApplySafeAreaToASS(content, SafeAreaForPlatform(novel.TargetPlatformDouyin))
+			fields := styleLine(result, "Default")
+			So(fields, ShouldNotBeNil)
+			So(fields[19], ShouldEqual, "77")  // MarginL: 0.04 * 1920
+			So(fields[20], ShouldEqual, "288") // MarginR: 0.15 * 1920
+			So(fields[21], ShouldEqual, "427") // MarginV 原值已高于底部安全区，保持不变
+			So(styleLine(result, "Highlight")[20], ShouldEqual, "288")
+		})
+
+		Convey("底部边距不足时抬高到安全区之上", func() {
+			low := strings.ReplaceAll(content, ",10,10,427,1", ",10,10,20,1")
+			fields := styleLine(ApplySafeAreaToASS(low, SafeArea{Bottom: 0.15}), "Default")
+			So(fields[21], ShouldEqual, "162") // 0.15 * 1080
+		})
+
+		Convey("字幕事件不受影响", func() {
+			result := ApplySafeAreaToASS(content, SafeAreaForPlatform(novel.TargetPlatformTikTok))
+			So(result, ShouldContainSubstring, "Dialogue: 0,0:00:00.00,0:00:01.50,Default,,0,0,0,,")
+		})
+	})
+}
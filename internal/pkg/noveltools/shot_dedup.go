@@ -0,0 +1,150 @@
+package noveltools
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// DefaultShotDedupThreshold 默认的近似重复镜头相似度阈值
+// 相邻镜头的旁白和图片提示词相似度都达到阈值时视为重复
+const DefaultShotDedupThreshold = 0.85
+
+// ShotMerge 一次近似重复镜头的合并记录
+type ShotMerge struct {
+	SceneNumber string  // 场景编号
+	KeptShot    string  // 保留的镜头编号
+	MergedShot  string  // 被合并（移除）的镜头编号
+	Similarity  float64 // 旁白相似度（0~1）
+}
+
+// DedupSceneShots 合并场景内相邻的近似重复镜头
+// 被合并镜头的旁白拼接到保留镜头之后，时长累加；其余字段以保留镜头为准
+// 返回合并记录（没有重复时为 nil）
+func DedupSceneShots(scene *NarrationJSONScene, threshold float64) []ShotMerge {
+	if scene == nil || len(scene.Shots) < 2 {
+		return nil
+	}
+
+	var merges []ShotMerge
+	kept := make([]*NarrationJSONShot, 0, len(scene.Shots))
+	var prev *NarrationJSONShot // 上一个原始镜头（用合并前的内容比较，避免拼接后的旁白拉低相似度）
+	for _, shot := range scene.Shots {
+		if shot == nil {
+			continue
+		}
+		if prev != nil && len(kept) > 0 {
+			narrationSim := TextSimilarity(prev.Narration, shot.Narration)
+			promptSim := TextSimilarity(prev.ImagePrompt, shot.ImagePrompt)
+			if narrationSim >= threshold && promptSim >= threshold {
+				target := kept[len(kept)-1]
+				target.Narration = joinNarration(target.Narration, shot.Narration)
+				target.Duration += shot.Duration
+				merges = append(merges, ShotMerge{
+					SceneNumber: scene.SceneNumber,
+					KeptShot:    target.CloseupNumber,
+					MergedShot:  shot.CloseupNumber,
+					Similarity:  narrationSim,
+				})
+				prev = shot
+				continue
+			}
+		}
+		kept = append(kept, shot)
+		prev = shot
+	}
+
+	if len(merges) > 0 {
+		scene.Shots = kept
+	}
+	return merges
+}
+
+// DedupNarrationShots 对所有场景执行近似重复镜头合并
+func DedupNarrationShots(content *NarrationJSONContent, threshold float64) []ShotMerge {
+	if content == nil {
+		return nil
+	}
+	var merges []ShotMerge
+	for _, scene := range content.Scenes {
+		merges = append(merges, DedupSceneShots(scene, threshold)...)
+	}
+	return merges
+}
+
+// String 返回合并记录的可读描述
+func (m ShotMerge) String() string {
+	return fmt.Sprintf("场景%s的镜头%s与镜头%s近似重复（相似度%.2f），已合并", m.SceneNumber, m.MergedShot, m.KeptShot, m.Similarity)
+}
+
+// TextSimilarity 计算两段文本的相似度（0~1）
+// 忽略空白和标点，按字符二元组（bigram）计算 Dice 系数；两段文本都为空时视为完全相同
+func TextSimilarity(a, b string) float64 {
+	ra, rb := normalizeForSimilarity(a), normalizeForSimilarity(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+	if string(ra) == string(rb) {
+		return 1
+	}
+
+	ga, gb := bigrams(ra), bigrams(rb)
+	total := 0
+	for _, n := range ga {
+		total += n
+	}
+	for _, n := range gb {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+
+	common := 0
+	for g, na := range ga {
+		if nb, ok := gb[g]; ok {
+			common += min(na, nb)
+		}
+	}
+	return float64(2*common) / float64(total)
+}
+
+// normalizeForSimilarity 去掉空白和标点，英文统一小写
+func normalizeForSimilarity(s string) []rune {
+	runes := make([]rune, 0, len(s))
+	for _, r := range s {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			continue
+		}
+		runes = append(runes, unicode.ToLower(r))
+	}
+	return runes
+}
+
+// bigrams 统计字符二元组出现次数（单字符文本按单字计）
+func bigrams(runes []rune) map[string]int {
+	grams := make(map[string]int)
+	if len(runes) == 1 {
+		grams[string(runes)]++
+		return grams
+	}
+	for i := 0; i+1 < len(runes); i++ {
+		grams[string(runes[i:i+2])]++
+	}
+	return grams
+}
+
+// joinNarration 拼接两段旁白，一段完全包含另一段时只保留较长的一段
+func joinNarration(a, b string) string {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	switch {
+	case b == "" || strings.Contains(a, b):
+		return a
+	case a == "" || strings.Contains(b, a):
+		return b
+	}
+	return a + b
+}
@@ -0,0 +1,84 @@
+package noveltools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTextSimilarity(t *testing.T) {
+	Convey("TextSimilarity 计算文本相似度", t, func() {
+		Convey("相同文本（忽略标点和空白）相似度为1", func() {
+			So(TextSimilarity("林凡握紧了手中的剑。", "林凡握紧了手中的剑"), ShouldEqual, 1)
+			So(TextSimilarity("", " "), ShouldEqual, 1)
+		})
+
+		Convey("一段为空时相似度为0", func() {
+			So(TextSimilarity("林凡", ""), ShouldEqual, 0)
+		})
+
+		Convey("近似文本相似度高，无关文本相似度低", func() {
+			So(TextSimilarity("林凡握紧了手中的长剑，目光冰冷", "林凡握紧了手中的长剑，目光冰冷如霜"), ShouldBeGreaterThan, 0.85)
+			So(TextSimilarity("林凡握紧了手中的长剑", "山门外大雪纷飞"), ShouldBeLessThan, 0.2)
+		})
+	})
+}
+
+func TestDedupSceneShots(t *testing.T) {
+	Convey("DedupSceneShots 合并相邻的近似重复镜头", t, func() {
+		newScene := func() *NarrationJSONScene {
+			return &NarrationJSONScene{
+				SceneNumber: "1",
+				Shots: []*NarrationJSONShot{
+					{CloseupNumber: "1", Narration: "林凡握紧了手中的长剑，目光冰冷", ImagePrompt: "少年持剑，冷峻眼神，特写", Duration: 3},
+					{CloseupNumber: "2", Narration: "林凡握紧了手中的长剑，目光冰冷如霜", ImagePrompt: "少年持剑，冷峻眼神，特写", Duration: 2},
+					{CloseupNumber: "3", Narration: "山门外大雪纷飞，长老缓缓走来", ImagePrompt: "雪中山门，白发老者，远景", Duration: 4},
+				},
+			}
+		}
+
+		Convey("近似重复的镜头被合并，旁白拼接、时长累加", func() {
+			scene := newScene()
+			merges := DedupSceneShots(scene, DefaultShotDedupThreshold)
+
+			So(merges, ShouldHaveLength, 1)
+			So(merges[0].KeptShot, ShouldEqual, "1")
+			So(merges[0].MergedShot, ShouldEqual, "2")
+			So(scene.Shots, ShouldHaveLength, 2)
+			So(scene.Shots[0].Narration, ShouldEqual, "林凡握紧了手中的长剑，目光冰冷如霜")
+			So(scene.Shots[0].Duration, ShouldEqual, 5)
+			So(scene.Shots[1].CloseupNumber, ShouldEqual, "3")
+		})
+
+		Convey("旁白相似但画面不同的镜头不合并", func() {
+			scene := newScene()
+			scene.Shots[1].ImagePrompt = "雪中山门，白发老者，远景"
+			So(DedupSceneShots(scene, DefaultShotDedupThreshold), ShouldBeEmpty)
+			So(scene.Shots, ShouldHaveLength, 3)
+		})
+
+		Convey("连续多个重复镜头合并到第一个镜头", func() {
+			scene := newScene()
+			scene.Shots[2] = &NarrationJSONShot{CloseupNumber: "3", Narration: "林凡握紧了手中的长剑，目光冰冷如霜。", ImagePrompt: "少年持剑，冷峻眼神，特写", Duration: 1}
+			merges := DedupSceneShots(scene, DefaultShotDedupThreshold)
+			So(merges, ShouldHaveLength, 2)
+			So(scene.Shots, ShouldHaveLength, 1)
+			So(scene.Shots[0].Duration, ShouldEqual, 6)
+		})
+	})
+}
+
+func TestValidateNarrationJSONReportsMergedShots(t *testing.T) {
+	Convey("ValidateNarrationJSON 在验证结果中报告合并的镜头", t, func() {
+		content := `{"scenes":[{"scene_number":"1","shots":[
+			{"closeup_number":"1","narration":"林凡握紧了手中的长剑，目光冰冷","image_prompt":"少年持剑，冷峻眼神，特写"},
+			{"closeup_number":"2","narration":"林凡握紧了手中的长剑，目光冰冷如霜","image_prompt":"少年持剑，冷峻眼神，特写"}
+		]}]}`
+
+		parsed, result := ValidateNarrationJSON(content, 0, 10000)
+		So(result.IsValid, ShouldBeTrue)
+		So(result.MergedShots, ShouldHaveLength, 1)
+		So(parsed.Scenes[0].Shots, ShouldHaveLength, 1)
+		So(result.Warnings, ShouldContain, result.MergedShots[0].String())
+	})
+}
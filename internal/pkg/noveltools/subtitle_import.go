@@ -0,0 +1,227 @@
+package noveltools
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"lemon/internal/model/novel"
+)
+
+// MaxImportedSubtitleCues 外部字幕文件最多包含的字幕条数
+const MaxImportedSubtitleCues = 5000
+
+var (
+	srtTimingPattern   = regexp.MustCompile(`^(\d+):(\d{1,2}):(\d{1,2})[,.](\d{1,3})\s*-->\s*(\d+):(\d{1,2}):(\d{1,2})[,.](\d{1,3})`)
+	assOverridePattern = regexp.MustCompile(`\{[^}]*\}`)
+	srtTagPattern      = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+)
+
+// DetectSubtitleFormat 根据文件名和内容识别外部字幕格式（只支持 srt 和 ass）
+func DetectSubtitleFormat(fileName, content string) (novel.SubtitleFormat, error) {
+	lower := strings.ToLower(fileName)
+	switch {
+	case strings.HasSuffix(lower, ".srt"):
+		return novel.SubtitleFormatSRT, nil
+	case strings.HasSuffix(lower, ".ass"), strings.HasSuffix(lower, ".ssa"):
+		return novel.SubtitleFormatASS, nil
+	}
+	if strings.Contains(content, "[Events]") {
+		return novel.SubtitleFormatASS, nil
+	}
+	if strings.Contains(content, "-->") {
+		return novel.SubtitleFormatSRT, nil
+	}
+	return "", fmt.Errorf("unsupported subtitle file %q: only srt and ass are supported", fileName)
+}
+
+// ParseSubtitleFile 解析外部字幕文件，返回按开始时间排序的字幕条目
+// 会去掉 ASS 的样式标签和 SRT 的 HTML 标签，多行文本合并为一行；空文本的条目会被丢弃
+func ParseSubtitleFile(content string, format novel.SubtitleFormat) ([]SegmentTimestamp, error) {
+	content = strings.TrimPrefix(content, "\ufeff")
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.ReplaceAll(content, "\r", "\n")
+
+	var cues []SegmentTimestamp
+	var err error
+	switch format {
+	case novel.SubtitleFormatSRT:
+		cues, err = parseSRT(content)
+	case novel.SubtitleFormatASS:
+		cues, err = parseASSEvents(content)
+	default:
+		return nil, fmt.Errorf("unsupported subtitle format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(cues) == 0 {
+		return nil, fmt.Errorf("subtitle file has no cues")
+	}
+	if len(cues) > MaxImportedSubtitleCues {
+		return nil, fmt.Errorf("subtitle file has %d cues, at most %d are allowed", len(cues), MaxImportedSubtitleCues)
+	}
+	sort.SliceStable(cues, func(i, j int) bool { return cues[i].StartTime < cues[j].StartTime })
+	return cues, nil
+}
+
+// parseSRT 解析 SRT 字幕：序号行可省略，时间行之后到空行为止为字幕文本
+func parseSRT(content string) ([]SegmentTimestamp, error) {
+	var cues []SegmentTimestamp
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if !strings.Contains(line, "-->") {
+			continue
+		}
+		m := srtTimingPattern.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("line %d: invalid srt timing %q", i+1, line)
+		}
+		start := srtSeconds(m[1:5])
+		end := srtSeconds(m[5:9])
+		if end <= start {
+			return nil, fmt.Errorf("line %d: cue ends before it starts", i+1)
+		}
+
+		var text []string
+		for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+			i++
+			text = append(text, strings.TrimSpace(lines[i]))
+		}
+		if cue := cleanImportedText(srtTagPattern.ReplaceAllString(strings.Join(text, " "), "")); cue != "" {
+			cues = append(cues, SegmentTimestamp{Text: cue, StartTime: start, EndTime: end})
+		}
+	}
+	return cues, nil
+}
+
+// srtSeconds 将 SRT 时间（时、分、秒、毫秒）转换为秒数
+func srtSeconds(parts []string) float64 {
+	h, _ := strconv.Atoi(parts[0])
+	m, _ := strconv.Atoi(parts[1])
+	s, _ := strconv.Atoi(parts[2])
+	ms, _ := strconv.Atoi((parts[3] + "00")[:3])
+	return float64(h*3600+m*60+s) + float64(ms)/1000
+}
+
+// parseASSEvents 解析 ASS 字幕 [Events] 段的 Dialogue 行，按 Format 行确定 Start、End、Text 字段的位置
+func parseASSEvents(content string) ([]SegmentTimestamp, error) {
+	var cues []SegmentTimestamp
+	inEvents := false
+	fields := []string{"layer", "start", "end", "style", "name", "marginl", "marginr", "marginv", "effect", "text"}
+	for i, raw := range strings.Split(content, "\n") {
+		line := strings.TrimSpace(raw)
+		if strings.HasPrefix(line, "[") {
+			inEvents = strings.EqualFold(line, "[Events]")
+			continue
+		}
+		if !inEvents {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "format":
+			fields = fields[:0]
+			for _, f := range strings.Split(value, ",") {
+				fields = append(fields, strings.ToLower(strings.TrimSpace(f)))
+			}
+		case "dialogue":
+			values := strings.SplitN(value, ",", len(fields))
+			if len(values) != len(fields) {
+				return nil, fmt.Errorf("line %d: dialogue has %d fields, expected %d", i+1, len(values), len(fields))
+			}
+			var cue SegmentTimestamp
+			var err error
+			for j, f := range fields {
+				switch f {
+				case "start":
+					cue.StartTime, err = assSeconds(values[j])
+				case "end":
+					cue.EndTime, err = assSeconds(values[j])
+				case "text":
+					text := strings.NewReplacer(`\N`, " ", `\n`, " ", `\h`, " ").Replace(values[j])
+					cue.Text = cleanImportedText(assOverridePattern.ReplaceAllString(text, ""))
+				}
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", i+1, err)
+				}
+			}
+			if cue.EndTime <= cue.StartTime {
+				return nil, fmt.Errorf("line %d: cue ends before it starts", i+1)
+			}
+			if cue.Text != "" {
+				cues = append(cues, cue)
+			}
+		}
+	}
+	return cues, nil
+}
+
+// assSeconds 将 ASS 时间（H:MM:SS.CC）转换为秒数
+func assSeconds(value string) (float64, error) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid ass time %q", value)
+	}
+	h, err1 := strconv.Atoi(parts[0])
+	m, err2 := strconv.Atoi(parts[1])
+	s, err3 := strconv.ParseFloat(parts[2], 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return 0, fmt.Errorf("invalid ass time %q", value)
+	}
+	return float64(h*3600+m*60) + s, nil
+}
+
+// cleanImportedText 合并连续空白并去掉首尾空白
+func cleanImportedText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// RetimeSubtitleCues 将整章的外部字幕按章节音频片段重新计时
+// durations 为各音频片段的时长（按播放顺序），外部字幕的时间轴对应依次拼接的音频；
+// 字幕结束时间超过音频总时长时按比例压缩（与生成字幕按音频时长调整的规则一致）。
+// 每条字幕按中点归入所在的音频片段，时间转换为相对片段开始的时间并截断在片段范围内，
+// 返回与 durations 一一对应的字幕列表（没有字幕的片段为空列表）
+func RetimeSubtitleCues(cues []SegmentTimestamp, durations []float64) [][]SegmentTimestamp {
+	result := make([][]SegmentTimestamp, len(durations))
+	if len(durations) == 0 {
+		return result
+	}
+
+	offsets := make([]float64, len(durations)+1)
+	for i, d := range durations {
+		offsets[i+1] = offsets[i] + max(d, 0)
+	}
+	total := offsets[len(durations)]
+
+	var last float64
+	for _, cue := range cues {
+		last = max(last, cue.EndTime)
+	}
+	scale := 1.0
+	if last > total && total > 0 {
+		scale = total / last
+	}
+
+	for _, cue := range cues {
+		start, end := cue.StartTime*scale, cue.EndTime*scale
+		mid := (start + end) / 2
+		i := sort.Search(len(durations), func(i int) bool { return offsets[i+1] > mid })
+		if i == len(durations) {
+			i = len(durations) - 1
+		}
+		start = min(max(start-offsets[i], 0), durations[i])
+		end = min(max(end-offsets[i], 0), durations[i])
+		if end <= start {
+			continue
+		}
+		result[i] = append(result[i], SegmentTimestamp{Text: cue.Text, StartTime: start, EndTime: end})
+	}
+	return result
+}
@@ -0,0 +1,92 @@
+package noveltools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestDetectSubtitleFormat(t *testing.T) {
+	Convey("DetectSubtitleFormat 按文件名和内容识别字幕格式", t, func() {
+		format, err := DetectSubtitleFormat("ch1.SRT", "")
+		So(err, ShouldBeNil)
+		So(format, ShouldEqual, novel.SubtitleFormatSRT)
+
+		format, err = DetectSubtitleFormat("upload", "[Script Info]\n[Events]\n")
+		So(err, ShouldBeNil)
+		So(format, ShouldEqual, novel.SubtitleFormatASS)
+
+		_, err = DetectSubtitleFormat("ch1.vtt", "WEBVTT")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestParseSubtitleFile(t *testing.T) {
+	Convey("ParseSubtitleFile 解析外部字幕", t, func() {
+		Convey("SRT：支持 BOM、CRLF、多行文本和 HTML 标签", func() {
+			content := "\ufeff1\r\n00:00:01,500 --> 00:00:03,000\r\n<i>少年</i>离开\r\n小镇。\r\n\r\n2\r\n00:00:03,200 --> 00:00:05,000\r\n他回头望了一眼。\r\n"
+			cues, err := ParseSubtitleFile(content, novel.SubtitleFormatSRT)
+			So(err, ShouldBeNil)
+			So(cues, ShouldResemble, []SegmentTimestamp{
+				{Text: "少年离开 小镇。", StartTime: 1.5, EndTime: 3},
+				{Text: "他回头望了一眼。", StartTime: 3.2, EndTime: 5},
+			})
+		})
+
+		Convey("ASS：按 Format 行定位字段，去掉样式标签，文本中的逗号保留", func() {
+			content := "[Script Info]\nTitle: x\n\n[Events]\nFormat: Layer, Start, End, Style, Text\n" +
+				"Dialogue: 0,0:00:02.00,0:00:04.50,Default,{\\b1}山门{\\b0}开了，\\N众人进来\n" +
+				"Comment: 0,0:00:00.00,0:00:01.00,Default,注释\n" +
+				"Dialogue: 0,0:00:00.50,0:00:01.50,Default,天亮了\n"
+			cues, err := ParseSubtitleFile(content, novel.SubtitleFormatASS)
+			So(err, ShouldBeNil)
+			So(cues, ShouldResemble, []SegmentTimestamp{
+				{Text: "天亮了", StartTime: 0.5, EndTime: 1.5},
+				{Text: "山门开了， 众人进来", StartTime: 2, EndTime: 4.5},
+			})
+		})
+
+		Convey("时间无效或没有字幕时返回错误", func() {
+			_, err := ParseSubtitleFile("1\n00:00:03,000 --> 00:00:01,000\n反了\n", novel.SubtitleFormatSRT)
+			So(err, ShouldNotBeNil)
+			_, err = ParseSubtitleFile("1\n00:00 --> 00:01\n错误\n", novel.SubtitleFormatSRT)
+			So(err, ShouldNotBeNil)
+			_, err = ParseSubtitleFile("[Events]\nFormat: Start, End, Text\n", novel.SubtitleFormatASS)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestRetimeSubtitleCues(t *testing.T) {
+	Convey("RetimeSubtitleCues 按音频片段重新计时", t, func() {
+		Convey("按中点归入片段，时间转换为片段内时间并截断", func() {
+			cues := []SegmentTimestamp{
+				{Text: "一", StartTime: 0.5, EndTime: 2},
+				{Text: "二", StartTime: 2.5, EndTime: 4.5},
+				{Text: "三", StartTime: 4.5, EndTime: 7},
+			}
+			result := RetimeSubtitleCues(cues, []float64{4, 4})
+			So(result, ShouldResemble, [][]SegmentTimestamp{
+				{{Text: "一", StartTime: 0.5, EndTime: 2}, {Text: "二", StartTime: 2.5, EndTime: 4}},
+				{{Text: "三", StartTime: 0.5, EndTime: 3}},
+			})
+		})
+
+		Convey("字幕超过音频总时长时按比例压缩", func() {
+			cues := []SegmentTimestamp{{Text: "一", StartTime: 0, EndTime: 4}, {Text: "二", StartTime: 4, EndTime: 8}}
+			result := RetimeSubtitleCues(cues, []float64{2, 2})
+			So(result, ShouldResemble, [][]SegmentTimestamp{
+				{{Text: "一", StartTime: 0, EndTime: 2}},
+				{{Text: "二", StartTime: 0, EndTime: 2}},
+			})
+		})
+
+		Convey("没有字幕的片段返回空列表", func() {
+			result := RetimeSubtitleCues([]SegmentTimestamp{{Text: "一", StartTime: 0, EndTime: 1}}, []float64{3, 3})
+			So(len(result), ShouldEqual, 2)
+			So(result[1], ShouldBeEmpty)
+		})
+	})
+}
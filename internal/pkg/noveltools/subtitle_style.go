@@ -0,0 +1,113 @@
+package noveltools
+
+import (
+	"strconv"
+	"strings"
+
+	"lemon/internal/model/novel"
+)
+
+// ApplySubtitleStyleToASS 按字幕样式改写 ASS 字幕 [V4+ Styles] 中的样式，用于烧录前覆盖字幕文件自带的样式
+// 只覆盖样式中设置了的字段（style 为空时原样返回）：
+//   - 字体、描边颜色和描边宽度作用于所有样式
+//   - 文字颜色作用于 Highlight 以外的样式，关键词高亮颜色作用于 Highlight 样式
+//   - 字号、对齐方式和垂直边距只作用于正文样式（片头片尾的 Card 样式保持原有排版）
+//
+// 字幕事件中关键词高亮的行内颜色标签（\c）同步替换为新的颜色
+func ApplySubtitleStyleToASS(content string, style *novel.SubtitleStyle) string {
+	if style == nil || *style == (novel.SubtitleStyle{}) {
+		return content
+	}
+	primary, hasPrimary := assColor(style.PrimaryColor)
+	highlight, hasHighlight := assColor(style.HighlightColor)
+	outlineColor, hasOutlineColor := assColor(style.OutlineColor)
+	fontName := strings.TrimSpace(style.FontName)
+	if strings.Contains(fontName, ",") {
+		fontName = ""
+	}
+
+	lines := strings.Split(content, "\n")
+
+	// 字段下标从 [V4+ Styles] 的 Format 行解析，缺失时使用 ASS 标准顺序
+	idx := map[string]int{
+		"Name": 0, "Fontname": 1, "Fontsize": 2, "PrimaryColour": 3, "OutlineColour": 5,
+		"Outline": 16, "Alignment": 18, "MarginV": 21,
+	}
+	// 行内颜色标签的替换（旧颜色 → 新颜色）
+	var colorPairs []string
+	inStyles := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			inStyles = strings.EqualFold(trimmed, "[V4+ Styles]") || strings.EqualFold(trimmed, "[V4 Styles]")
+			continue
+		}
+		if !inStyles {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "Format:") {
+			fields := strings.Split(strings.TrimPrefix(trimmed, "Format:"), ",")
+			for j, f := range fields {
+				if _, ok := idx[strings.TrimSpace(f)]; ok {
+					idx[strings.TrimSpace(f)] = j
+				}
+			}
+			continue
+		}
+		if !strings.HasPrefix(trimmed, "Style:") {
+			continue
+		}
+
+		fields := strings.Split(strings.TrimPrefix(trimmed, "Style:"), ",")
+		if len(fields) <= idx["MarginV"] || len(fields) <= idx["Alignment"] {
+			continue
+		}
+		name := strings.TrimSpace(fields[idx["Name"]])
+
+		if fontName != "" {
+			fields[idx["Fontname"]] = fontName
+		}
+		if hasOutlineColor {
+			fields[idx["OutlineColour"]] = outlineColor
+		}
+		if style.Outline > 0 {
+			fields[idx["Outline"]] = strconv.Itoa(style.Outline)
+		}
+
+		color, hasColor := primary, hasPrimary
+		if name == "Highlight" {
+			color, hasColor = highlight, hasHighlight
+		}
+		if hasColor {
+			if old := strings.TrimSpace(fields[idx["PrimaryColour"]]); old != color && (name == "Default" || name == "Highlight") {
+				colorPairs = append(colorPairs, `\c`+old+"&", `\c`+color+"&")
+			}
+			fields[idx["PrimaryColour"]] = color
+		}
+
+		if name != "Card" {
+			if style.FontSize > 0 {
+				fields[idx["Fontsize"]] = strconv.Itoa(style.FontSize)
+			}
+			if style.Alignment >= 1 && style.Alignment <= 9 {
+				fields[idx["Alignment"]] = strconv.Itoa(style.Alignment)
+			}
+			if style.MarginV > 0 {
+				fields[idx["MarginV"]] = strconv.Itoa(style.MarginV)
+			}
+		}
+
+		lines[i] = "Style:" + strings.Join(fields, ",")
+	}
+
+	if len(colorPairs) > 0 {
+		replacer := strings.NewReplacer(colorPairs...)
+		for i, line := range lines {
+			if strings.HasPrefix(line, "Dialogue:") {
+				lines[i] = replacer.Replace(line)
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
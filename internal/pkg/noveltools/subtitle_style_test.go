@@ -0,0 +1,54 @@
+package noveltools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestApplySubtitleStyleToASS(t *testing.T) {
+	Convey("ApplySubtitleStyleToASS 按字幕样式改写 ASS 样式", t, func() {
+		gen := NewASSGenerator()
+		gen.SetCards("斗破苍穹", "", 10)
+		content := gen.GenerateASSContent([]SegmentTimestamp{
+			{Text: "林凡拔剑", StartTime: 0, EndTime: 1.5},
+		}, "test")
+
+		Convey("未设置样式时原样返回", func() {
+			So(ApplySubtitleStyleToASS(content, nil), ShouldEqual, content)
+			So(ApplySubtitleStyleToASS(content, &novel.SubtitleStyle{}), ShouldEqual, content)
+		})
+
+		Convey("只覆盖设置了的字段", func() {
+			result := ApplySubtitleStyleToASS(content, &novel.SubtitleStyle{
+				FontName:     "Source Han Sans",
+				FontSize:     48,
+				PrimaryColor: "#FFCC00",
+				Alignment:    8,
+				MarginV:      60,
+			})
+			So(result, ShouldContainSubstring, "Style: Default,Source Han Sans,48,&H0000CCFF,&H000000FF,&H00000000,&H80000000,0,0,0,0,100,100,0,0,1,2,2,8,10,10,60,1\n")
+			So(result, ShouldContainSubstring, "Style: Highlight,Source Han Sans,48,&H0000FFFF,")
+		})
+
+		Convey("Card 样式保持原有字号和排版", func() {
+			result := ApplySubtitleStyleToASS(content, &novel.SubtitleStyle{FontName: "Source Han Sans", FontSize: 48, MarginV: 60})
+			So(result, ShouldContainSubstring, "Style: Card,Source Han Sans,54,&H00FFFFFF,&H000000FF,&H00000000,&H80000000,1,0,0,0,100,100,0,0,1,2,2,8,10,10,120,1")
+		})
+
+		Convey("描边颜色和宽度作用于所有样式", func() {
+			result := ApplySubtitleStyleToASS(content, &novel.SubtitleStyle{OutlineColor: "#333333", Outline: 4})
+			So(result, ShouldContainSubstring, "Style: Default,Microsoft YaHei,36,&H00FFFFFF,&H000000FF,&H00333333,&H80000000,0,0,0,0,100,100,0,0,1,4,")
+			So(result, ShouldContainSubstring, "Style: Card,Microsoft YaHei,54,&H00FFFFFF,&H000000FF,&H00333333,&H80000000,1,0,0,0,100,100,0,0,1,4,")
+		})
+
+		Convey("关键词高亮的行内颜色同步替换", func() {
+			content += "\nDialogue: 0,0:00:01.50,0:00:03.00,Default,,0,0,0,,{\\c&H0000FFFF&\\b1}萧炎{\\c&H00FFFFFF&\\b0}出手"
+			result := ApplySubtitleStyleToASS(content, &novel.SubtitleStyle{PrimaryColor: "#EEEEEE", HighlightColor: "#66CCFF"})
+			So(result, ShouldContainSubstring, "Style: Highlight,Microsoft YaHei,36,&H00FFCC66,")
+			So(result, ShouldContainSubstring, `{\c&H00FFCC66&\b1}萧炎{\c&H00EEEEEE&\b0}出手`)
+		})
+	})
+}
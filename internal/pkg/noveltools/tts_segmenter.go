@@ -0,0 +1,163 @@
+package noveltools
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultTTSSegmentMaxChars 单次 TTS 请求的默认最大字符数
+	// 字节跳动 TTS 单次请求文本上限为 1024 字节（UTF-8 约 340 个汉字），留出余量
+	DefaultTTSSegmentMaxChars = 300
+
+	// DefaultTTSSegmentGap 分段合成的音频拼接时段与段之间插入的停顿（秒）
+	DefaultTTSSegmentGap = 0.25
+)
+
+var (
+	// ttsSentenceEndings 句末标点（优先在这些位置切分）
+	ttsSentenceEndings = "。！？!?；;…\n"
+	// ttsClauseEndings 句中停顿标点（单句超长时在这些位置切分）
+	ttsClauseEndings = "，,、：:"
+	// ttsClosingMarks 紧跟在句末标点后的右引号/右括号，切分时保留在前一句
+	ttsClosingMarks = "”’」』）)》\"'"
+)
+
+// SplitTTSSegments 将解说文本按句子切分为不超过 maxChars 个字符的 TTS 分段
+//
+// 切分规则：
+//  1. 优先在句末标点处切分，相邻短句合并到同一分段，尽量减少分段数
+//  2. 单句超长时在逗号、顿号等句中停顿处切分
+//  3. 仍然超长（没有任何标点）时按 maxChars 硬切
+//
+// 文本不超过 maxChars 时原样返回一个分段；maxChars<=0 时使用 DefaultTTSSegmentMaxChars
+func SplitTTSSegments(text string, maxChars int) []string {
+	if maxChars <= 0 {
+		maxChars = DefaultTTSSegmentMaxChars
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if utf8.RuneCountInString(text) <= maxChars {
+		return []string{text}
+	}
+
+	var pieces []string
+	for _, sentence := range splitAfter(text, ttsSentenceEndings) {
+		if utf8.RuneCountInString(sentence) <= maxChars {
+			pieces = append(pieces, sentence)
+			continue
+		}
+		for _, clause := range splitAfter(sentence, ttsClauseEndings) {
+			pieces = append(pieces, hardSplit(clause, maxChars)...)
+		}
+	}
+
+	// 相邻短句合并到同一分段
+	var segments []string
+	var cur strings.Builder
+	curLen := 0
+	for _, piece := range pieces {
+		n := utf8.RuneCountInString(piece)
+		if curLen > 0 && curLen+n > maxChars {
+			segments = append(segments, strings.TrimSpace(cur.String()))
+			cur.Reset()
+			curLen = 0
+		}
+		cur.WriteString(piece)
+		curLen += n
+	}
+	if curLen > 0 {
+		segments = append(segments, strings.TrimSpace(cur.String()))
+	}
+
+	result := segments[:0]
+	for _, seg := range segments {
+		if seg != "" {
+			result = append(result, seg)
+		}
+	}
+	return result
+}
+
+// splitAfter 在 marks 中任一字符之后切分文本（紧随其后的右引号/右括号归入前一段），保留标点
+func splitAfter(text, marks string) []string {
+	var parts []string
+	runes := []rune(text)
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		if !strings.ContainsRune(marks, runes[i]) {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && (strings.ContainsRune(marks, runes[end]) || strings.ContainsRune(ttsClosingMarks, runes[end])) {
+			end++
+		}
+		parts = append(parts, string(runes[start:end]))
+		start = end
+		i = end - 1
+	}
+	if start < len(runes) {
+		parts = append(parts, string(runes[start:]))
+	}
+	return parts
+}
+
+// hardSplit 按 maxChars 个字符硬切文本
+func hardSplit(text string, maxChars int) []string {
+	runes := []rune(text)
+	var parts []string
+	for len(runes) > maxChars {
+		parts = append(parts, string(runes[:maxChars]))
+		runes = runes[maxChars:]
+	}
+	if len(runes) > 0 {
+		parts = append(parts, string(runes))
+	}
+	return parts
+}
+
+// TTSResultDuration 返回 TTS 结果的音频时长（秒）：优先使用 Duration，其次使用时间戳数据中的时长
+func TTSResultDuration(r *TTSResult) float64 {
+	if r == nil {
+		return 0
+	}
+	if r.Duration > 0 {
+		return r.Duration
+	}
+	if r.TimestampData != nil {
+		return r.TimestampData.Duration
+	}
+	return 0
+}
+
+// MergeTTSTimestamps 合并分段合成结果的字符时间戳
+// 音频按顺序拼接，段与段之间插入 gap 秒停顿，后续分段的时间戳按前面分段的时长和停顿整体后移
+func MergeTTSTimestamps(parts []*TTSResult, gap float64) *TimestampData {
+	merged := &TimestampData{}
+	var text strings.Builder
+	offset := 0.0
+	for i, part := range parts {
+		if i > 0 {
+			offset += gap
+		}
+		if part.TimestampData != nil {
+			text.WriteString(part.TimestampData.Text)
+			for _, ts := range part.TimestampData.CharacterTimestamps {
+				merged.CharacterTimestamps = append(merged.CharacterTimestamps, CharTimestamp{
+					Character: ts.Character,
+					StartTime: ts.StartTime + offset,
+					EndTime:   ts.EndTime + offset,
+				})
+			}
+			if merged.GeneratedAt.Before(part.TimestampData.GeneratedAt) {
+				merged.GeneratedAt = part.TimestampData.GeneratedAt
+			}
+		}
+		offset += TTSResultDuration(part)
+	}
+	merged.Text = text.String()
+	merged.Duration = offset
+	return merged
+}
@@ -0,0 +1,62 @@
+package noveltools
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSplitTTSSegments(t *testing.T) {
+	Convey("SplitTTSSegments 按句子切分超长解说", t, func() {
+		Convey("未超过上限时原样返回", func() {
+			So(SplitTTSSegments("  林凡握紧了剑。  ", 20), ShouldResemble, []string{"林凡握紧了剑。"})
+			So(SplitTTSSegments("   ", 20), ShouldBeEmpty)
+		})
+
+		Convey("在句末切分并合并相邻短句", func() {
+			text := "林凡握紧了剑。长老缓缓走来！“你来了？”他问道。山门外大雪纷飞。"
+			segments := SplitTTSSegments(text, 18)
+			So(segments, ShouldResemble, []string{"林凡握紧了剑。长老缓缓走来！", "“你来了？”他问道。山门外大雪纷飞。"})
+			So(strings.Join(segments, ""), ShouldEqual, text)
+		})
+
+		Convey("右引号保留在前一句", func() {
+			segments := SplitTTSSegments("他说：“走吧。”众人随即出发，山路崎岖难行。", 8)
+			So(segments[0], ShouldEqual, "他说：“走吧。”")
+		})
+
+		Convey("单句超长时在逗号处切分，没有标点时硬切", func() {
+			text := "林凡握紧了手中的长剑，目光冰冷如霜，缓缓走向山门" + strings.Repeat("雪", 25)
+			segments := SplitTTSSegments(text, 12)
+			So(strings.Join(segments, ""), ShouldEqual, text)
+			for _, seg := range segments {
+				So(utf8.RuneCountInString(seg), ShouldBeLessThanOrEqualTo, 12)
+			}
+			So(segments[0], ShouldEqual, "林凡握紧了手中的长剑，")
+		})
+	})
+}
+
+func TestMergeTTSTimestamps(t *testing.T) {
+	Convey("MergeTTSTimestamps 合并分段时间戳", t, func() {
+		parts := []*TTSResult{
+			{Duration: 1.0, TimestampData: &TimestampData{Text: "林凡", CharacterTimestamps: []CharTimestamp{
+				{Character: "林", StartTime: 0, EndTime: 0.5},
+				{Character: "凡", StartTime: 0.5, EndTime: 1.0},
+			}}},
+			{TimestampData: &TimestampData{Text: "走", Duration: 0.5, CharacterTimestamps: []CharTimestamp{
+				{Character: "走", StartTime: 0.1, EndTime: 0.5},
+			}}},
+		}
+
+		merged := MergeTTSTimestamps(parts, 0.25)
+		So(merged.Text, ShouldEqual, "林凡走")
+		So(merged.Duration, ShouldAlmostEqual, 1.75)
+		So(merged.CharacterTimestamps, ShouldHaveLength, 3)
+		So(merged.CharacterTimestamps[1].EndTime, ShouldAlmostEqual, 1.0)
+		So(merged.CharacterTimestamps[2].StartTime, ShouldAlmostEqual, 1.35)
+		So(merged.CharacterTimestamps[2].EndTime, ShouldAlmostEqual, 1.75)
+	})
+}
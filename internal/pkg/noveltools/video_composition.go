@@ -0,0 +1,59 @@
+package noveltools
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"lemon/internal/model/novel"
+)
+
+// ErrInvalidVideoComposition 视频片段的镜头序号范围存在重叠或缺失，无法拼接最终视频
+var ErrInvalidVideoComposition = errors.New("invalid video composition")
+
+// ValidateVideoComposition 校验拼接最终视频的片段是否恰好连续覆盖镜头序号（从1开始）
+// 每个片段按 SequenceRange 覆盖一段镜头，合并片段（如 narration_01-03）与单镜头片段同时存在时会被判定为重叠，
+// 片段之间有空缺（如某个镜头的视频生成失败）时判定为缺失；所有问题一次性返回，便于排查
+func ValidateVideoComposition(videos []*novel.Video) error {
+	if len(videos) == 0 {
+		return fmt.Errorf("%w: no video clips", ErrInvalidVideoComposition)
+	}
+
+	sorted := make([]*novel.Video, len(videos))
+	copy(sorted, videos)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		si, _ := sorted[i].SequenceRange()
+		sj, _ := sorted[j].SequenceRange()
+		return si < sj
+	})
+
+	var issues []string
+	covered := 0 // 已连续覆盖到的最后一个镜头序号
+	for _, v := range sorted {
+		start, end := v.SequenceRange()
+		switch {
+		case start < 1:
+			issues = append(issues, fmt.Sprintf("video %s has invalid sequence %d", v.ID, start))
+			continue
+		case start <= covered:
+			issues = append(issues, fmt.Sprintf("video %s (sequence %s) overlaps sequences up to %d", v.ID, formatSequenceRange(start, end), covered))
+		case start > covered+1:
+			issues = append(issues, fmt.Sprintf("missing sequence %s", formatSequenceRange(covered+1, start-1)))
+		}
+		covered = max(covered, end)
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidVideoComposition, strings.Join(issues, "; "))
+	}
+	return nil
+}
+
+// formatSequenceRange 格式化镜头序号范围（如 1-3，单个镜头为 4）
+func formatSequenceRange(start, end int) string {
+	if start == end {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d-%d", start, end)
+}
@@ -0,0 +1,44 @@
+package noveltools
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestValidateVideoComposition(t *testing.T) {
+	Convey("ValidateVideoComposition 校验片段的镜头序号范围", t, func() {
+		clip := func(id string, start, end int) *novel.Video {
+			return &novel.Video{ID: id, Sequence: start, SequenceEnd: end}
+		}
+
+		Convey("单镜头片段连续覆盖时通过", func() {
+			So(ValidateVideoComposition([]*novel.Video{clip("a", 2, 0), clip("b", 1, 0), clip("c", 3, 0)}), ShouldBeNil)
+		})
+
+		Convey("合并片段与后续单镜头片段连续覆盖时通过", func() {
+			So(ValidateVideoComposition([]*novel.Video{clip("merged", 1, 3), clip("d", 4, 0)}), ShouldBeNil)
+		})
+
+		Convey("合并片段与其成员镜头的单镜头片段同时存在时判定为重叠", func() {
+			err := ValidateVideoComposition([]*novel.Video{clip("merged", 1, 3), clip("a", 1, 0), clip("b", 2, 0), clip("d", 4, 0)})
+			So(errors.Is(err, ErrInvalidVideoComposition), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "video a (sequence 1) overlaps sequences up to 3")
+			So(err.Error(), ShouldContainSubstring, "video b (sequence 2) overlaps")
+		})
+
+		Convey("镜头缺失时报告缺失的范围", func() {
+			err := ValidateVideoComposition([]*novel.Video{clip("a", 1, 0), clip("e", 5, 0), clip("g", 7, 0)})
+			So(errors.Is(err, ErrInvalidVideoComposition), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "missing sequence 2-4")
+			So(err.Error(), ShouldContainSubstring, "missing sequence 6")
+		})
+
+		Convey("没有片段时返回错误", func() {
+			So(errors.Is(ValidateVideoComposition(nil), ErrInvalidVideoComposition), ShouldBeTrue)
+		})
+	})
+}
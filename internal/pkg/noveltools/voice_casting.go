@@ -0,0 +1,207 @@
+package noveltools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/errclass"
+)
+
+// maxCastingDescriptionRunes 选角提示词中每个角色描述的字数上限，超出部分截断
+const maxCastingDescriptionRunes = 200
+
+// VoiceProfile 音色目录中的一个 TTS 音色
+type VoiceProfile struct {
+	VoiceType string   `json:"voice_type"`          // TTS 音色（如 BV115_streaming）
+	Name      string   `json:"name"`                // 音色名称
+	Gender    string   `json:"gender,omitempty"`    // 性别：男/女，为空表示不限
+	AgeGroup  string   `json:"age_group,omitempty"` // 年龄段：儿童/青少年/青年/中年/老年
+	Tones     []string `json:"tones,omitempty"`     // 音色特点（如 沉稳、甜美）
+}
+
+// DefaultVoiceCatalog 默认音色目录（字节跳动 TTS 的常用音色）
+func DefaultVoiceCatalog() []VoiceProfile {
+	return []VoiceProfile{
+		{VoiceType: "BV001_streaming", Name: "通用女声", Gender: "女", AgeGroup: "青年", Tones: []string{"自然", "亲切"}},
+		{VoiceType: "BV002_streaming", Name: "通用男声", Gender: "男", AgeGroup: "青年", Tones: []string{"自然", "平稳"}},
+		{VoiceType: "BV700_streaming", Name: "灿灿", Gender: "女", AgeGroup: "青年", Tones: []string{"甜美", "活泼"}},
+		{VoiceType: "BV701_streaming", Name: "擎苍", Gender: "男", AgeGroup: "中年", Tones: []string{"沉稳", "大气"}},
+		{VoiceType: "BV115_streaming", Name: "古风少御", Gender: "女", AgeGroup: "青年", Tones: []string{"古风", "清冷"}},
+		{VoiceType: "BV113_streaming", Name: "甜宠少御", Gender: "女", AgeGroup: "青年", Tones: []string{"甜美", "温柔"}},
+		{VoiceType: "BV005_streaming", Name: "活泼女声", Gender: "女", AgeGroup: "青少年", Tones: []string{"活泼", "俏皮"}},
+		{VoiceType: "BV102_streaming", Name: "儒雅青年", Gender: "男", AgeGroup: "青年", Tones: []string{"儒雅", "温和"}},
+		{VoiceType: "BV056_streaming", Name: "阳光男声", Gender: "男", AgeGroup: "青年", Tones: []string{"阳光", "开朗"}},
+		{VoiceType: "BV119_streaming", Name: "通用赘婿", Gender: "男", AgeGroup: "青年", Tones: []string{"爽朗", "诙谐"}},
+		{VoiceType: "BV107_streaming", Name: "霸气青叔", Gender: "男", AgeGroup: "中年", Tones: []string{"霸气", "威严"}},
+		{VoiceType: "BV158_streaming", Name: "智慧老者", Gender: "男", AgeGroup: "老年", Tones: []string{"睿智", "沧桑"}},
+		{VoiceType: "BV051_streaming", Name: "奶气萌娃", AgeGroup: "儿童", Tones: []string{"稚嫩", "可爱"}},
+	}
+}
+
+// ParseVoiceCatalog 解析 JSON 格式的音色目录（VoiceProfile 数组）
+// 音色为空或重复时返回错误
+func ParseVoiceCatalog(data []byte) ([]VoiceProfile, error) {
+	var catalog []VoiceProfile
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("parse voice catalog: %w", err)
+	}
+	if len(catalog) == 0 {
+		return nil, fmt.Errorf("parse voice catalog: catalog is empty")
+	}
+	seen := make(map[string]bool, len(catalog))
+	for i := range catalog {
+		voice := strings.TrimSpace(catalog[i].VoiceType)
+		if voice == "" {
+			return nil, fmt.Errorf("parse voice catalog: voice %d has no voice_type", i+1)
+		}
+		if seen[voice] {
+			return nil, fmt.Errorf("parse voice catalog: duplicate voice_type %s", voice)
+		}
+		seen[voice] = true
+		catalog[i].VoiceType = voice
+	}
+	return catalog, nil
+}
+
+// FindVoice 在音色目录中查找音色
+func FindVoice(catalog []VoiceProfile, voiceType string) (VoiceProfile, bool) {
+	for _, voice := range catalog {
+		if voice.VoiceType == voiceType {
+			return voice, true
+		}
+	}
+	return VoiceProfile{}, false
+}
+
+// voiceCastingOutput 大模型返回的选角建议
+type voiceCastingOutput struct {
+	Castings []struct {
+		Name      string `json:"name"`
+		VoiceType string `json:"voice_type"`
+		Reason    string `json:"reason"`
+	} `json:"castings"`
+}
+
+// BuildVoiceCastingPrompt 构建角色选角提示词：按角色的性别、年龄段和描述从音色目录中为每个角色挑选音色
+func BuildVoiceCastingPrompt(characters []*novel.Character, catalog []VoiceProfile) (string, error) {
+	if len(characters) == 0 {
+		return "", fmt.Errorf("no characters to cast")
+	}
+	if len(catalog) == 0 {
+		return "", fmt.Errorf("voice catalog is empty")
+	}
+
+	var voices strings.Builder
+	for _, voice := range catalog {
+		fmt.Fprintf(&voices, "- %s：%s，性别 %s，年龄段 %s，特点 %s\n",
+			voice.VoiceType, voice.Name, orUnlimited(voice.Gender), orUnlimited(voice.AgeGroup), orUnlimited(strings.Join(voice.Tones, "、")))
+	}
+
+	var roles strings.Builder
+	for _, character := range characters {
+		description := strings.TrimSpace(character.Description)
+		if utf8.RuneCountInString(description) > maxCastingDescriptionRunes {
+			description = string([]rune(description)[:maxCastingDescriptionRunes]) + "…"
+		}
+		fmt.Fprintf(&roles, "- %s：性别 %s，年龄段 %s，描述 %s\n",
+			character.Name, orUnknown(character.Gender), orUnknown(character.AgeGroup), orUnknown(description))
+	}
+
+	return fmt.Sprintf(`你是一名有声书选角导演。请根据角色的性别、年龄和性格，从音色目录中为每个角色挑选最合适的配音音色。
+
+【音色目录】
+%s
+【角色】
+%s
+要求：
+1. 每个角色必须从音色目录中挑选一个音色，voice_type 只能使用目录中的值
+2. 性别必须一致（音色性别不限的除外），年龄段尽量一致，再按角色性格匹配音色特点
+3. 主要角色之间尽量使用不同的音色，方便听众区分
+4. reason 用一句话说明选择理由
+5. 只返回 JSON 对象，格式为 {"castings":[{"name":"角色名","voice_type":"音色","reason":"理由"}]}，不要其他文字`,
+		voices.String(), roles.String(),
+	), nil
+}
+
+// ParseVoiceCasting 解析大模型返回的选角建议，返回每个角色的建议（按 characters 的顺序）
+// 大模型没有给出建议、音色不在目录中或性别不一致的角色，按性别和年龄段在目录中匹配（Fallback=true）
+func ParseVoiceCasting(output string, characters []*novel.Character, catalog []VoiceProfile) ([]novel.CharacterVoiceSuggestion, error) {
+	var parsed voiceCastingOutput
+	if err := json.Unmarshal([]byte(CleanJSONContent(output)), &parsed); err != nil {
+		return nil, errclass.Wrap(errclass.ValidationSchema, "", fmt.Errorf("parse voice casting: %w", err))
+	}
+
+	picks := make(map[string]novel.CharacterVoiceSuggestion, len(parsed.Castings))
+	for _, casting := range parsed.Castings {
+		name := strings.TrimSpace(casting.Name)
+		if _, ok := picks[name]; ok || name == "" {
+			continue
+		}
+		picks[name] = novel.CharacterVoiceSuggestion{
+			VoiceType: strings.TrimSpace(casting.VoiceType),
+			Reason:    strings.TrimSpace(casting.Reason),
+		}
+	}
+
+	suggestions := make([]novel.CharacterVoiceSuggestion, 0, len(characters))
+	for _, character := range characters {
+		pick, ok := picks[character.Name]
+		if ok {
+			if voice, found := FindVoice(catalog, pick.VoiceType); found && genderCompatible(voice.Gender, character.Gender) {
+				suggestions = append(suggestions, pick)
+				continue
+			}
+		}
+		voice := MatchVoice(catalog, character.Gender, character.AgeGroup)
+		suggestions = append(suggestions, novel.CharacterVoiceSuggestion{
+			VoiceType: voice.VoiceType,
+			Reason:    fmt.Sprintf("按性别（%s）和年龄段（%s）匹配", orUnknown(character.Gender), orUnknown(character.AgeGroup)),
+			Fallback:  true,
+		})
+	}
+	return suggestions, nil
+}
+
+// MatchVoice 按性别和年龄段在音色目录中匹配音色：性别不一致的音色不选（目录中没有同性别音色时除外），
+// 性别一致优先于年龄段一致，得分相同时取目录中靠前的音色
+func MatchVoice(catalog []VoiceProfile, gender, ageGroup string) VoiceProfile {
+	best, bestScore := VoiceProfile{}, -1
+	for _, voice := range catalog {
+		score := 0
+		switch {
+		case gender != "" && voice.Gender == gender:
+			score += 4
+		case genderCompatible(voice.Gender, gender):
+			score += 2
+		}
+		if ageGroup != "" && voice.AgeGroup == ageGroup {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = voice, score
+		}
+	}
+	return best
+}
+
+// genderCompatible 音色性别与角色性别是否兼容（任一方未知或不限时兼容）
+func genderCompatible(voiceGender, characterGender string) bool {
+	return voiceGender == "" || characterGender == "" || voiceGender == characterGender
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "未知"
+	}
+	return s
+}
+
+func orUnlimited(s string) string {
+	if s == "" {
+		return "不限"
+	}
+	return s
+}
@@ -0,0 +1,86 @@
+package noveltools
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestParseVoiceCatalog(t *testing.T) {
+	Convey("ParseVoiceCatalog 解析音色目录", t, func() {
+		Convey("去掉音色两端空白", func() {
+			catalog, err := ParseVoiceCatalog([]byte(`[{"voice_type":" BV001_streaming ","name":"通用女声","gender":"女"}]`))
+			So(err, ShouldBeNil)
+			So(catalog[0].VoiceType, ShouldEqual, "BV001_streaming")
+		})
+
+		Convey("空目录、缺少音色或音色重复时返回错误", func() {
+			_, err := ParseVoiceCatalog([]byte(`[]`))
+			So(err, ShouldNotBeNil)
+			_, err = ParseVoiceCatalog([]byte(`[{"name":"无音色"}]`))
+			So(err, ShouldNotBeNil)
+			_, err = ParseVoiceCatalog([]byte(`[{"voice_type":"a"},{"voice_type":"a"}]`))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestBuildVoiceCastingPrompt(t *testing.T) {
+	Convey("BuildVoiceCastingPrompt 构建选角提示词", t, func() {
+		prompt, err := BuildVoiceCastingPrompt([]*novel.Character{
+			{Name: "林凡", Gender: "男", AgeGroup: "青年", Description: "沉默寡言的剑客"},
+			{Name: "路人"},
+		}, DefaultVoiceCatalog())
+		So(err, ShouldBeNil)
+		So(prompt, ShouldContainSubstring, "林凡：性别 男，年龄段 青年，描述 沉默寡言的剑客")
+		So(prompt, ShouldContainSubstring, "路人：性别 未知，年龄段 未知，描述 未知")
+		So(prompt, ShouldContainSubstring, "BV051_streaming：奶气萌娃，性别 不限")
+
+		_, err = BuildVoiceCastingPrompt(nil, DefaultVoiceCatalog())
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestParseVoiceCasting(t *testing.T) {
+	Convey("ParseVoiceCasting 解析选角建议", t, func() {
+		catalog := DefaultVoiceCatalog()
+		characters := []*novel.Character{
+			{Name: "林凡", Gender: "男", AgeGroup: "青年"},
+			{Name: "苏青", Gender: "女", AgeGroup: "青年"},
+			{Name: "老掌门", Gender: "男", AgeGroup: "老年"},
+			{Name: "小豆子", AgeGroup: "儿童"},
+		}
+
+		Convey("采用目录中性别一致的建议，其余按性别和年龄段匹配", func() {
+			output := "```json\n" + `{"castings":[
+				{"name":"林凡","voice_type":"BV102_streaming","reason":"儒雅"},
+				{"name":"苏青","voice_type":"BV701_streaming","reason":"性别不一致"},
+				{"name":"老掌门","voice_type":"NOT_EXIST","reason":"不在目录"}
+			]}` + "\n```"
+			suggestions, err := ParseVoiceCasting(output, characters, catalog)
+			So(err, ShouldBeNil)
+			So(len(suggestions), ShouldEqual, 4)
+			So(suggestions[0], ShouldResemble, novel.CharacterVoiceSuggestion{VoiceType: "BV102_streaming", Reason: "儒雅"})
+			So(suggestions[1].VoiceType, ShouldEqual, "BV001_streaming")
+			So(suggestions[1].Fallback, ShouldBeTrue)
+			So(suggestions[2].VoiceType, ShouldEqual, "BV158_streaming")
+			So(suggestions[3].VoiceType, ShouldEqual, "BV051_streaming")
+		})
+
+		Convey("返回内容不是 JSON 时返回错误", func() {
+			_, err := ParseVoiceCasting("无法选角", characters, catalog)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestMatchVoice(t *testing.T) {
+	Convey("MatchVoice 按性别和年龄段匹配音色", t, func() {
+		catalog := DefaultVoiceCatalog()
+		So(MatchVoice(catalog, "男", "中年").VoiceType, ShouldEqual, "BV701_streaming")
+		So(MatchVoice(catalog, "女", "青少年").VoiceType, ShouldEqual, "BV005_streaming")
+		So(MatchVoice(catalog, "", "").VoiceType, ShouldEqual, "BV001_streaming")
+	})
+}
@@ -0,0 +1,181 @@
+package noveltools
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"lemon/internal/model/novel"
+)
+
+// 内置的工作流模板
+const (
+	WorkflowTemplateFastPaced  = "fast_paced"  // 快节奏解说：短篇结构、语速偏快、抖音/快手竖屏
+	WorkflowTemplateDeepStory  = "deep_story"  // 深度剧情向：长篇结构、语速适中、写实画风、B 站/YouTube
+	WorkflowTemplateEnglishDub = "english_dub" // 英文配音版：面向海外英语观众、TikTok/YouTube
+)
+
+const (
+	// maxWorkflowTemplateDisplayName 展示名称最多字符数
+	maxWorkflowTemplateDisplayName = 50
+	// maxWorkflowTemplateDescription 说明最多字符数
+	maxWorkflowTemplateDescription = 500
+)
+
+// ErrInvalidWorkflowTemplate 工作流模板不合法（名称格式错误、配置超出允许范围）
+var ErrInvalidWorkflowTemplate = errors.New("invalid workflow template")
+
+// workflowTemplateNamePattern 模板名称：小写字母开头的 snake_case，2~40 个字符
+var workflowTemplateNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,39}$`)
+
+// builtinWorkflowTemplates 内置模板（按展示顺序）
+// 每次调用返回新的实例，调用方可以直接修改
+func builtinWorkflowTemplates() []*novel.WorkflowTemplate {
+	return []*novel.WorkflowTemplate{
+		{
+			Name:          WorkflowTemplateFastPaced,
+			DisplayName:   "快节奏解说",
+			Description:   "3~5 个场景、约 90 秒的短解说，语速偏快，适合抖音、快手竖屏",
+			NarrationType: novel.NarrationTypeNarration,
+			Style:         novel.NovelStyleAnime,
+			Structure:     &novel.NarrationStructure{Template: NarrationStructureShort},
+			Voice:         &novel.VoiceSettings{SpeedRatio: 1.35},
+			StylePreset:   novel.ImageStylePresetGuofengComic,
+			Video:         &novel.VideoProfile{Width: 1080, Height: 1920, FPS: 30},
+			Platforms:     []novel.TargetPlatform{novel.TargetPlatformDouyin, novel.TargetPlatformKuaishou},
+			Metadata:      &novel.CreativeMetadata{Tone: "节奏紧凑、悬念迭起"},
+		},
+		{
+			Name:          WorkflowTemplateDeepStory,
+			DisplayName:   "深度剧情向",
+			Description:   "10~14 个场景、约 10 分钟的长解说，完整交代人物动机和伏笔，写实画风，适合哔哩哔哩、YouTube",
+			NarrationType: novel.NarrationTypeNarration,
+			Style:         novel.NovelStyleLive,
+			Structure:     &novel.NarrationStructure{Template: NarrationStructureLong},
+			Voice:         &novel.VoiceSettings{SpeedRatio: 1.1},
+			StylePreset:   novel.ImageStylePresetRealistic,
+			Video:         &novel.VideoProfile{Width: 1080, Height: 1920, FPS: 30},
+			Platforms:     []novel.TargetPlatform{novel.TargetPlatformBilibili, novel.TargetPlatformYouTube},
+			Metadata:      &novel.CreativeMetadata{Tone: "沉稳细腻、注重人物动机和伏笔"},
+		},
+		{
+			Name:          WorkflowTemplateEnglishDub,
+			DisplayName:   "英文配音版",
+			Description:   "面向海外英语观众的解说，语速适中，日系动漫画风，适合 TikTok、YouTube Shorts",
+			NarrationType: novel.NarrationTypeNarration,
+			Style:         novel.NovelStyleAnime,
+			Structure:     &novel.NarrationStructure{Template: NarrationStructureShort},
+			Voice:         &novel.VoiceSettings{SpeedRatio: 1.0},
+			StylePreset:   novel.ImageStylePresetAnime,
+			Video:         &novel.VideoProfile{Width: 1080, Height: 1920, FPS: 30},
+			Platforms:     []novel.TargetPlatform{novel.TargetPlatformTikTok, novel.TargetPlatformYouTube},
+			Metadata:      &novel.CreativeMetadata{Audience: "海外英语观众（解说文案使用英文）"},
+		},
+	}
+}
+
+// BuiltinWorkflowTemplates 返回所有内置模板
+func BuiltinWorkflowTemplates() []*novel.WorkflowTemplate {
+	templates := builtinWorkflowTemplates()
+	for _, t := range templates {
+		t.Builtin = true
+	}
+	return templates
+}
+
+// FindBuiltinWorkflowTemplate 按名称查找内置模板
+func FindBuiltinWorkflowTemplate(name string) (*novel.WorkflowTemplate, bool) {
+	for _, t := range BuiltinWorkflowTemplates() {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// NormalizeWorkflowTemplate 整理并校验工作流模板的名称和配置，返回整理后的副本
+// 解说结构按 NormalizeNarrationStructure 展开，视频规格和语速按生成参数的取值范围校验，平台去重
+func NormalizeWorkflowTemplate(t *novel.WorkflowTemplate) (*novel.WorkflowTemplate, error) {
+	if t == nil {
+		return nil, fmt.Errorf("%w: template is required", ErrInvalidWorkflowTemplate)
+	}
+	normalized := &novel.WorkflowTemplate{
+		Name:          strings.TrimSpace(t.Name),
+		DisplayName:   strings.TrimSpace(t.DisplayName),
+		Description:   strings.TrimSpace(t.Description),
+		NarrationType: t.NarrationType,
+		Style:         t.Style,
+		StylePreset:   t.StylePreset,
+	}
+	if !workflowTemplateNamePattern.MatchString(normalized.Name) {
+		return nil, fmt.Errorf("%w: name must be 2-40 characters of lowercase letters, digits and underscores, starting with a letter", ErrInvalidWorkflowTemplate)
+	}
+	if normalized.DisplayName == "" {
+		normalized.DisplayName = normalized.Name
+	}
+	if utf8.RuneCountInString(normalized.DisplayName) > maxWorkflowTemplateDisplayName {
+		return nil, fmt.Errorf("%w: display_name exceeds %d characters", ErrInvalidWorkflowTemplate, maxWorkflowTemplateDisplayName)
+	}
+	if utf8.RuneCountInString(normalized.Description) > maxWorkflowTemplateDescription {
+		return nil, fmt.Errorf("%w: description exceeds %d characters", ErrInvalidWorkflowTemplate, maxWorkflowTemplateDescription)
+	}
+
+	switch normalized.NarrationType {
+	case "", novel.NarrationTypeNarration, novel.NarrationTypeDialogue:
+	default:
+		return nil, fmt.Errorf("%w: narration_type must be narration or dialogue", ErrInvalidWorkflowTemplate)
+	}
+	switch normalized.Style {
+	case "", novel.NovelStyleAnime, novel.NovelStyleLive, novel.NovelStyleMixed:
+	default:
+		return nil, fmt.Errorf("%w: style must be anime, live or mixed", ErrInvalidWorkflowTemplate)
+	}
+	if normalized.StylePreset != "" && !normalized.StylePreset.IsValid() {
+		return nil, fmt.Errorf("%w: unsupported style_preset %q", ErrInvalidWorkflowTemplate, normalized.StylePreset)
+	}
+
+	if t.Structure != nil {
+		structure, err := NormalizeNarrationStructure(t.Structure)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidWorkflowTemplate, err)
+		}
+		normalized.Structure = structure
+	}
+
+	settings := &novel.GenerationSettings{}
+	if t.Voice != nil {
+		voice := *t.Voice
+		voice.VoiceType = strings.TrimSpace(voice.VoiceType)
+		normalized.Voice = &voice
+		settings.SpeedRatio = voice.SpeedRatio
+	}
+	if t.Video != nil {
+		video := *t.Video
+		normalized.Video = &video
+		settings.VideoWidth, settings.VideoHeight, settings.VideoFPS = video.Width, video.Height, video.FPS
+	}
+	if err := ValidateGenerationSettings(settings); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidWorkflowTemplate, err)
+	}
+
+	for _, platform := range t.Platforms {
+		if !platform.IsValid() {
+			return nil, fmt.Errorf("%w: unsupported platform %q", ErrInvalidWorkflowTemplate, platform)
+		}
+		if !slices.Contains(normalized.Platforms, platform) {
+			normalized.Platforms = append(normalized.Platforms, platform)
+		}
+	}
+
+	if t.Metadata != nil {
+		metadata, err := NormalizeCreativeMetadata(t.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidWorkflowTemplate, err)
+		}
+		normalized.Metadata = metadata
+	}
+	return normalized, nil
+}
@@ -0,0 +1,91 @@
+package noveltools
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+)
+
+func TestBuiltinWorkflowTemplates(t *testing.T) {
+	Convey("内置模板都是合法的配置", t, func() {
+		for _, tmpl := range BuiltinWorkflowTemplates() {
+			So(tmpl.Builtin, ShouldBeTrue)
+			So(tmpl.Platforms, ShouldNotBeEmpty)
+			_, err := NormalizeWorkflowTemplate(tmpl)
+			So(err, ShouldBeNil)
+		}
+	})
+
+	Convey("FindBuiltinWorkflowTemplate 查找内置模板", t, func() {
+		tmpl, ok := FindBuiltinWorkflowTemplate(WorkflowTemplateFastPaced)
+		So(ok, ShouldBeTrue)
+		So(tmpl.Structure.Template, ShouldEqual, NarrationStructureShort)
+
+		Convey("每次返回新的实例", func() {
+			tmpl.Voice.SpeedRatio = 2
+			again, _ := FindBuiltinWorkflowTemplate(WorkflowTemplateFastPaced)
+			So(again.Voice.SpeedRatio, ShouldNotEqual, 2)
+		})
+
+		Convey("未知模板返回 false", func() {
+			_, ok := FindBuiltinWorkflowTemplate("unknown")
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
+
+func TestNormalizeWorkflowTemplate(t *testing.T) {
+	Convey("NormalizeWorkflowTemplate 整理并校验模板", t, func() {
+		Convey("展开解说结构模板、平台去重、展示名称默认为模板名称", func() {
+			tmpl, err := NormalizeWorkflowTemplate(&novel.WorkflowTemplate{
+				Name:      " short_drama ",
+				Structure: &novel.NarrationStructure{Template: NarrationStructureShort},
+				Platforms: []novel.TargetPlatform{novel.TargetPlatformDouyin, novel.TargetPlatformDouyin, novel.TargetPlatformTikTok},
+			})
+			So(err, ShouldBeNil)
+			So(tmpl.Name, ShouldEqual, "short_drama")
+			So(tmpl.DisplayName, ShouldEqual, "short_drama")
+			So(tmpl.Structure.MaxScenes, ShouldEqual, 5)
+			So(tmpl.Platforms, ShouldResemble, []novel.TargetPlatform{novel.TargetPlatformDouyin, novel.TargetPlatformTikTok})
+		})
+
+		Convey("不合法的模板返回 ErrInvalidWorkflowTemplate", func() {
+			invalid := []*novel.WorkflowTemplate{
+				nil,
+				{Name: "Fast Paced"},
+				{Name: "x"},
+				{Name: "ok_name", NarrationType: "monologue"},
+				{Name: "ok_name", Style: "pixel"},
+				{Name: "ok_name", StylePreset: "pixel"},
+				{Name: "ok_name", Structure: &novel.NarrationStructure{Template: "unknown"}},
+				{Name: "ok_name", Voice: &novel.VoiceSettings{SpeedRatio: 3}},
+				{Name: "ok_name", Video: &novel.VideoProfile{Width: 1081, Height: 1920}},
+				{Name: "ok_name", Platforms: []novel.TargetPlatform{"weibo"}},
+				{Name: "ok_name", Metadata: &novel.CreativeMetadata{Variables: map[string]string{"Bad-Name": "x"}}},
+			}
+			for _, tmpl := range invalid {
+				_, err := NormalizeWorkflowTemplate(tmpl)
+				So(errors.Is(err, ErrInvalidWorkflowTemplate), ShouldBeTrue)
+			}
+		})
+
+		Convey("解说结构错误同时保留原始错误", func() {
+			_, err := NormalizeWorkflowTemplate(&novel.WorkflowTemplate{Name: "ok_name", Structure: &novel.NarrationStructure{Template: "unknown"}})
+			So(errors.Is(err, ErrInvalidNarrationStructure), ShouldBeTrue)
+		})
+	})
+}
+
+func TestNewImagePromptBuilderWithPreset(t *testing.T) {
+	Convey("NewImagePromptBuilderWithPreset 按风格预设构建章节图片 prompt", t, func() {
+		So(NewImagePromptBuilderWithPreset(novel.ImageStylePresetInkWash).stylePrompt, ShouldEqual, stylePresetPrompts[novel.ImageStylePresetInkWash])
+
+		Convey("预设为空或不支持时使用默认风格", func() {
+			So(NewImagePromptBuilderWithPreset("").stylePrompt, ShouldEqual, NewImagePromptBuilder().stylePrompt)
+			So(NewImagePromptBuilderWithPreset("pixel").stylePrompt, ShouldEqual, NewImagePromptBuilder().stylePrompt)
+		})
+	})
+}
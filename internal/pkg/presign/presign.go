@@ -0,0 +1,179 @@
+// Package presign 预签名 URL 的有效期策略和响应中链接的刷新
+// 预签名 URL 只在读取时生成，不写入业务记录：记录只保存 resource_id，接口返回前通过 Refresh 填充或刷新 Link
+package presign
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// Purpose 预签名 URL 的用途，不同用途使用不同的有效期策略
+type Purpose string
+
+const (
+	PurposeUpload   Purpose = "upload"   // 客户端直传
+	PurposeDownload Purpose = "download" // 接口返回给客户端的下载链接
+	PurposePlayback Purpose = "playback" // 嵌入播放器的视频/字幕/缩略图地址
+	PurposePreview  Purpose = "preview"  // 编辑器中的临时预览（阅读时长预览、镜头片段预览）
+)
+
+// Policy 有效期策略
+type Policy struct {
+	Default       time.Duration // 未指定有效期时使用
+	Max           time.Duration // 允许的最长有效期，请求的有效期超出时截断
+	RefreshBefore time.Duration // 剩余有效期不足该值时，Refresh 重新签名
+}
+
+// Policies 各用途的有效期策略
+type Policies map[Purpose]Policy
+
+// DefaultPolicies 默认策略：各用途默认 1 小时；下载链接最长 24 小时，嵌入播放地址最长 6 小时，上传和预览链接最长 1 小时
+func DefaultPolicies() Policies {
+	return Policies{
+		PurposeUpload:   {Default: time.Hour, Max: time.Hour, RefreshBefore: 5 * time.Minute},
+		PurposeDownload: {Default: time.Hour, Max: 24 * time.Hour, RefreshBefore: 5 * time.Minute},
+		PurposePlayback: {Default: time.Hour, Max: 6 * time.Hour, RefreshBefore: 10 * time.Minute},
+		PurposePreview:  {Default: time.Hour, Max: time.Hour, RefreshBefore: 5 * time.Minute},
+	}
+}
+
+// Set 覆盖某个用途的策略，为 0 的字段沿用原值；Default 超过 Max 时以 Max 为准
+func (p Policies) Set(purpose Purpose, override Policy) {
+	policy := p[purpose]
+	if override.Default > 0 {
+		policy.Default = override.Default
+	}
+	if override.Max > 0 {
+		policy.Max = override.Max
+	}
+	if override.RefreshBefore > 0 {
+		policy.RefreshBefore = override.RefreshBefore
+	}
+	if policy.Max > 0 && policy.Default > policy.Max {
+		policy.Default = policy.Max
+	}
+	p[purpose] = policy
+}
+
+// Policy 返回用途的策略，未配置的用途使用下载策略
+func (p Policies) Policy(purpose Purpose) Policy {
+	if policy, ok := p[purpose]; ok {
+		return policy
+	}
+	if policy, ok := p[PurposeDownload]; ok {
+		return policy
+	}
+	return DefaultPolicies()[PurposeDownload]
+}
+
+// Lifetime 返回本次签名使用的有效期：requested <=0 时使用默认值，超过上限时截断
+func (p Policies) Lifetime(purpose Purpose, requested time.Duration) time.Duration {
+	policy := p.Policy(purpose)
+	lifetime := requested
+	if lifetime <= 0 {
+		lifetime = policy.Default
+	}
+	if policy.Max > 0 && lifetime > policy.Max {
+		lifetime = policy.Max
+	}
+	return lifetime
+}
+
+// Link 响应中的资源链接：只有 ResourceID 来自业务记录，URL 和过期时间在返回前生成
+type Link struct {
+	ResourceID string     `json:"resource_id"`
+	URL        string     `json:"url,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// NeedsRefresh 链接是否需要（重新）签名：没有 URL 或剩余有效期不足 refreshBefore
+func (l *Link) NeedsRefresh(now time.Time, refreshBefore time.Duration) bool {
+	if l.ResourceID == "" {
+		return false
+	}
+	return l.URL == "" || l.ExpiresAt == nil || l.ExpiresAt.Sub(now) < refreshBefore
+}
+
+// SignFunc 为资源生成预签名 URL，返回 URL 和实际过期时间
+type SignFunc func(ctx context.Context, resourceID string, expiresIn time.Duration) (string, time.Time, error)
+
+var linkType = reflect.TypeOf(Link{})
+
+// Refresh 遍历 v（结构体、指针、切片、数组、map）中的所有 Link，为没有 URL 或即将过期的链接按 purpose 的策略签名
+// v 中的 Link 必须可寻址（传入指针，或位于切片/指针指向的结构体中）；map 的值不可寻址，只处理其中的指针和切片
+// 同一次调用中相同资源只签名一次；签名失败时返回第一个错误，已签名的链接保留
+func (p Policies) Refresh(ctx context.Context, v any, purpose Purpose, sign SignFunc) error {
+	policy := p.Policy(purpose)
+	r := &refresher{
+		ctx:       ctx,
+		now:       time.Now(),
+		policy:    policy,
+		expiresIn: p.Lifetime(purpose, 0),
+		sign:      sign,
+		signed:    make(map[string]Link),
+	}
+	r.walk(reflect.ValueOf(v))
+	return r.err
+}
+
+// refresher 一次 Refresh 调用的状态
+type refresher struct {
+	ctx       context.Context
+	now       time.Time
+	policy    Policy
+	expiresIn time.Duration
+	sign      SignFunc
+	signed    map[string]Link // resource_id -> 本次已生成的链接
+	err       error
+}
+
+func (r *refresher) walk(v reflect.Value) {
+	if r.err != nil || !v.IsValid() {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			r.walk(v.Elem())
+		}
+	case reflect.Struct:
+		if v.Type() == linkType {
+			if v.CanAddr() {
+				r.refresh(v.Addr().Interface().(*Link))
+			}
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				r.walk(v.Field(i))
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			r.walk(v.Index(i))
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			r.walk(iter.Value())
+		}
+	}
+}
+
+func (r *refresher) refresh(link *Link) {
+	if !link.NeedsRefresh(r.now, r.policy.RefreshBefore) {
+		return
+	}
+	if signed, ok := r.signed[link.ResourceID]; ok {
+		link.URL, link.ExpiresAt = signed.URL, signed.ExpiresAt
+		return
+	}
+	url, expiresAt, err := r.sign(r.ctx, link.ResourceID, r.expiresIn)
+	if err != nil {
+		r.err = err
+		return
+	}
+	link.URL, link.ExpiresAt = url, &expiresAt
+	r.signed[link.ResourceID] = *link
+}
@@ -0,0 +1,111 @@
+package presign
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLifetime(t *testing.T) {
+	p := DefaultPolicies()
+	if got := p.Lifetime(PurposeDownload, 0); got != time.Hour {
+		t.Fatalf("default download lifetime = %v, want 1h", got)
+	}
+	if got := p.Lifetime(PurposeDownload, 72*time.Hour); got != 24*time.Hour {
+		t.Fatalf("clamped download lifetime = %v, want 24h", got)
+	}
+	if got := p.Lifetime(PurposeUpload, 30*time.Minute); got != 30*time.Minute {
+		t.Fatalf("upload lifetime = %v, want 30m", got)
+	}
+	if got := p.Lifetime(Purpose("unknown"), 0); got != time.Hour {
+		t.Fatalf("unknown purpose lifetime = %v, want download default", got)
+	}
+}
+
+func TestSet(t *testing.T) {
+	p := DefaultPolicies()
+	p.Set(PurposePlayback, Policy{Max: 30 * time.Minute})
+	got := p.Policy(PurposePlayback)
+	if got.Max != 30*time.Minute || got.Default != 30*time.Minute || got.RefreshBefore != 10*time.Minute {
+		t.Fatalf("playback policy = %+v", got)
+	}
+}
+
+type asset struct {
+	Name  string
+	Link  Link
+	Extra *Link
+}
+
+func TestRefresh(t *testing.T) {
+	soon := time.Now().Add(time.Minute)
+	later := time.Now().Add(time.Hour)
+	resp := struct {
+		Assets []asset
+		ByName map[string]*Link
+		Fresh  Link
+		hidden Link
+	}{
+		Assets: []asset{
+			{Name: "a", Link: Link{ResourceID: "r1"}, Extra: &Link{ResourceID: "r2", URL: "old", ExpiresAt: &soon}},
+			{Name: "b", Link: Link{ResourceID: "r1"}},
+			{Name: "empty"},
+		},
+		ByName: map[string]*Link{"c": {ResourceID: "r3"}},
+		Fresh:  Link{ResourceID: "r4", URL: "kept", ExpiresAt: &later},
+		hidden: Link{ResourceID: "r5"},
+	}
+
+	calls := map[string]int{}
+	sign := func(_ context.Context, resourceID string, expiresIn time.Duration) (string, time.Time, error) {
+		calls[resourceID]++
+		if expiresIn != time.Hour {
+			t.Fatalf("expiresIn = %v, want 1h", expiresIn)
+		}
+		return "https://signed/" + resourceID, time.Now().Add(expiresIn), nil
+	}
+	if err := DefaultPolicies().Refresh(context.Background(), &resp, PurposeDownload, sign); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	if resp.Assets[0].Link.URL != "https://signed/r1" || resp.Assets[1].Link.URL != "https://signed/r1" {
+		t.Fatalf("r1 links not signed: %+v", resp.Assets)
+	}
+	if calls["r1"] != 1 {
+		t.Fatalf("r1 signed %d times, want 1", calls["r1"])
+	}
+	if resp.Assets[0].Extra.URL != "https://signed/r2" {
+		t.Fatalf("expiring link not refreshed: %+v", resp.Assets[0].Extra)
+	}
+	if resp.ByName["c"].URL != "https://signed/r3" {
+		t.Fatalf("map link not signed: %+v", resp.ByName["c"])
+	}
+	if resp.Fresh.URL != "kept" || calls["r4"] != 0 {
+		t.Fatalf("fresh link re-signed: %+v", resp.Fresh)
+	}
+	if resp.Assets[2].Link.URL != "" || calls[""] != 0 {
+		t.Fatalf("empty link signed: %+v", resp.Assets[2].Link)
+	}
+	if resp.hidden.URL != "" {
+		t.Fatalf("unexported link signed")
+	}
+}
+
+func TestRefreshError(t *testing.T) {
+	links := []Link{{ResourceID: "r1"}, {ResourceID: "r2"}}
+	wantErr := errors.New("boom")
+	sign := func(_ context.Context, resourceID string, _ time.Duration) (string, time.Time, error) {
+		if resourceID == "r2" {
+			return "", time.Time{}, wantErr
+		}
+		return "https://signed/" + resourceID, time.Now().Add(time.Hour), nil
+	}
+	err := DefaultPolicies().Refresh(context.Background(), links, PurposeDownload, sign)
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("Refresh() error = %v, want %v", err, wantErr)
+	}
+	if links[0].URL != "https://signed/r1" {
+		t.Fatalf("signed link lost: %+v", links[0])
+	}
+}
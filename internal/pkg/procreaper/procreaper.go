@@ -0,0 +1,587 @@
+// Package procreaper 外部进程与临时目录回收
+// 按任务记录启动的 ffmpeg 等外部进程 PID 和任务的临时目录（同时写入状态目录，服务崩溃重启后仍能找到），
+// 定期回收：任务已结束但仍在运行的进程直接结束，崩溃前留下的孤儿进程、临时目录和长期残留的临时文件一并清理
+package procreaper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultStaleTempAge 残留临时文件超过该时间未修改时清理（长视频渲染可能持续数小时）
+	DefaultStaleTempAge = 12 * time.Hour
+
+	// stateDirName 默认状态目录名（位于系统临时目录下）
+	stateDirName = "lemon-reaper"
+	// stateFileExt 状态文件扩展名；写入中的临时文件以 stateTmpPrefix 开头，读取时跳过
+	stateFileExt   = ".json"
+	stateTmpPrefix = ".tmp-"
+)
+
+// DefaultTempPrefixes 按前缀清理的残留临时文件/目录（服务创建临时文件时使用的前缀）
+var DefaultTempPrefixes = []string{
+	"lemon-",
+	"video_std_",
+	"merged_video_",
+	"tts_segments_",
+	"waveform_",
+	"thumbnail_sprite_",
+	"bgm_upload_",
+	"replica_spool_",
+}
+
+// Options 回收器配置
+type Options struct {
+	StateDir     string        // 状态目录（记录进程和临时目录），为空时使用系统临时目录下的 lemon-reaper
+	TempDir      string        // 清理残留临时文件的目录，为空时使用系统临时目录
+	TempPrefixes []string      // 残留临时文件/目录的前缀，为空时使用 DefaultTempPrefixes
+	StaleTempAge time.Duration // 残留临时文件超过该时间未修改时清理（<=0 时使用默认值）
+}
+
+// Process 任务启动的外部进程
+type Process struct {
+	PID       int       `json:"pid"`
+	Command   string    `json:"command"` // 可执行文件名（结束前校验，避免 PID 被复用后误杀其他进程）
+	StartedAt time.Time `json:"started_at"`
+}
+
+// taskState 一个任务的进程和临时目录
+type taskState struct {
+	Active    bool            `json:"active"`
+	Processes map[int]Process `json:"processes,omitempty"`
+	TempDirs  map[string]bool `json:"temp_dirs,omitempty"`
+	EndedAt   *time.Time      `json:"ended_at,omitempty"`
+}
+
+// ownerState 一个服务进程的状态文件内容
+type ownerState struct {
+	OwnerPID       int                   `json:"owner_pid"`
+	OwnerStartedAt time.Time             `json:"owner_started_at"`
+	Tasks          map[string]*taskState `json:"tasks"`
+}
+
+// Stats 回收统计（进程启动以来）
+type Stats struct {
+	Sweeps           int64      `json:"sweeps"`             // 回收次数
+	ProcessesReaped  int64      `json:"processes_reaped"`   // 结束的孤儿进程数
+	TempDirsRemoved  int64      `json:"temp_dirs_removed"`  // 清理的任务临时目录数
+	StaleTempRemoved int64      `json:"stale_temp_removed"` // 清理的残留临时文件/目录数
+	BytesFreed       int64      `json:"bytes_freed"`        // 清理释放的磁盘空间（字节）
+	LastSweepAt      *time.Time `json:"last_sweep_at,omitempty"`
+	TrackedProcesses int        `json:"tracked_processes"` // 当前记录的进程数
+	ActiveTasks      int        `json:"active_tasks"`      // 当前执行中的任务数
+}
+
+// SweepResult 一次回收的结果
+type SweepResult struct {
+	ProcessesReaped  int   `json:"processes_reaped"`
+	TempDirsRemoved  int   `json:"temp_dirs_removed"`
+	StaleTempRemoved int   `json:"stale_temp_removed"`
+	BytesFreed       int64 `json:"bytes_freed"`
+}
+
+// Reaper 外部进程与临时目录回收器，可并发使用
+// 所有方法对 nil 接收者安全（未启用回收时直接忽略）
+type Reaper struct {
+	stateDir     string
+	tempDir      string
+	tempPrefixes []string
+	staleTempAge time.Duration
+	statePath    string
+	now          func() time.Time
+
+	mu    sync.Mutex
+	state ownerState
+
+	sweeps           atomic.Int64
+	processesReaped  atomic.Int64
+	tempDirsRemoved  atomic.Int64
+	staleTempRemoved atomic.Int64
+	bytesFreed       atomic.Int64
+	lastSweepAt      atomic.Pointer[time.Time]
+}
+
+// New 创建回收器，状态目录不存在时自动创建
+func New(opts Options) (*Reaper, error) {
+	if opts.StateDir == "" {
+		opts.StateDir = filepath.Join(os.TempDir(), stateDirName)
+	}
+	if opts.TempDir == "" {
+		opts.TempDir = os.TempDir()
+	}
+	if len(opts.TempPrefixes) == 0 {
+		opts.TempPrefixes = DefaultTempPrefixes
+	}
+	if opts.StaleTempAge <= 0 {
+		opts.StaleTempAge = DefaultStaleTempAge
+	}
+	if err := os.MkdirAll(opts.StateDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create reaper state dir: %w", err)
+	}
+
+	now := time.Now()
+	pid := os.Getpid()
+	r := &Reaper{
+		stateDir:     opts.StateDir,
+		tempDir:      opts.TempDir,
+		tempPrefixes: opts.TempPrefixes,
+		staleTempAge: opts.StaleTempAge,
+		// 容器内服务进程的 PID 每次都相同，文件名带上启动时间区分重启前后的状态
+		statePath: filepath.Join(opts.StateDir, fmt.Sprintf("%d-%d%s", pid, now.UnixNano(), stateFileExt)),
+		now:       time.Now,
+		state: ownerState{
+			OwnerPID:       pid,
+			OwnerStartedAt: now,
+			Tasks:          make(map[string]*taskState),
+		},
+	}
+	return r, nil
+}
+
+// Start 启动时立即回收一次（清理崩溃前留下的进程和临时目录），之后按间隔定期回收
+func (r *Reaper) Start(ctx context.Context, interval time.Duration) {
+	if r == nil || interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			r.Sweep()
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Begin 登记执行中的任务，返回关联该任务的 context 和结束函数
+// 之后通过 Run 启动的进程、通过 MkdirTemp 创建的临时目录都记在该任务下；任务结束后仍在运行的进程和未删除的临时目录在下次回收时清理
+func (r *Reaper) Begin(ctx context.Context, taskID string) (context.Context, func()) {
+	if r == nil || taskID == "" {
+		return ctx, func() {}
+	}
+	r.mu.Lock()
+	t := r.task(taskID)
+	t.Active = true
+	t.EndedAt = nil
+	r.saveLocked()
+	r.mu.Unlock()
+
+	var once sync.Once
+	end := func() {
+		once.Do(func() { r.end(taskID) })
+	}
+	return context.WithValue(ctx, scopeKey, scope{reaper: r, taskID: taskID}), end
+}
+
+// end 标记任务结束，没有残留进程和临时目录时直接移除记录
+func (r *Reaper) end(taskID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.state.Tasks[taskID]
+	if !ok {
+		return
+	}
+	if len(t.Processes) == 0 && len(t.TempDirs) == 0 {
+		delete(r.state.Tasks, taskID)
+	} else {
+		t.Active = false
+		now := r.now()
+		t.EndedAt = &now
+	}
+	r.saveLocked()
+}
+
+// track 记录任务启动的进程，返回进程结束后移除记录的函数
+func (r *Reaper) track(taskID string, pid int, command string) func() {
+	if r == nil {
+		return func() {}
+	}
+	r.mu.Lock()
+	t := r.task(taskID)
+	if t.Processes == nil {
+		t.Processes = make(map[int]Process)
+	}
+	t.Processes[pid] = Process{PID: pid, Command: command, StartedAt: r.now()}
+	r.saveLocked()
+	r.mu.Unlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if t, ok := r.state.Tasks[taskID]; ok {
+			delete(t.Processes, pid)
+			r.dropIfDoneLocked(taskID, t)
+			r.saveLocked()
+		}
+	}
+}
+
+// trackTempDir 记录任务的临时目录，返回删除目录并移除记录的函数
+func (r *Reaper) trackTempDir(taskID, dir string) func() {
+	if r == nil {
+		return func() { os.RemoveAll(dir) }
+	}
+	r.mu.Lock()
+	t := r.task(taskID)
+	if t.TempDirs == nil {
+		t.TempDirs = make(map[string]bool)
+	}
+	t.TempDirs[dir] = true
+	r.saveLocked()
+	r.mu.Unlock()
+
+	return func() {
+		os.RemoveAll(dir)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if t, ok := r.state.Tasks[taskID]; ok {
+			delete(t.TempDirs, dir)
+			r.dropIfDoneLocked(taskID, t)
+			r.saveLocked()
+		}
+	}
+}
+
+// task 查询或登记任务（调用方持有锁）
+// 不属于任何任务的进程和临时目录记在空任务ID下，只在服务崩溃后回收
+func (r *Reaper) task(taskID string) *taskState {
+	t, ok := r.state.Tasks[taskID]
+	if !ok {
+		t = &taskState{}
+		r.state.Tasks[taskID] = t
+	}
+	return t
+}
+
+// dropIfDoneLocked 已结束（或不属于任何任务）且没有残留的任务移除记录（调用方持有锁）
+func (r *Reaper) dropIfDoneLocked(taskID string, t *taskState) {
+	if !t.Active && len(t.Processes) == 0 && len(t.TempDirs) == 0 {
+		delete(r.state.Tasks, taskID)
+	}
+}
+
+// Sweep 执行一次回收：
+// 1. 本进程中已结束任务的残留进程和临时目录
+// 2. 已退出（崩溃）的服务进程留下的全部进程和临时目录
+// 3. 临时目录中超过 StaleTempAge 未修改、且不属于执行中任务的残留临时文件
+func (r *Reaper) Sweep() SweepResult {
+	var result SweepResult
+	if r == nil {
+		return result
+	}
+
+	// 1. 本进程已结束的任务
+	r.mu.Lock()
+	for taskID, t := range r.state.Tasks {
+		if t.Active || taskID == "" {
+			continue
+		}
+		r.reapTask(t, &result)
+		delete(r.state.Tasks, taskID)
+	}
+	r.saveLocked()
+	protected := r.liveTempDirsLocked()
+	r.mu.Unlock()
+
+	// 2. 其他服务进程的状态文件
+	entries, err := os.ReadDir(r.stateDir)
+	if err != nil {
+		log.Warn().Err(err).Str("dir", r.stateDir).Msg("读取进程回收状态目录失败")
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(r.stateDir, name)
+		if entry.IsDir() || !strings.HasSuffix(name, stateFileExt) || strings.HasPrefix(name, stateTmpPrefix) || path == r.statePath {
+			continue
+		}
+		owner, err := readOwnerState(path)
+		if err != nil {
+			log.Warn().Err(err).Str("path", path).Msg("读取进程回收状态失败，删除状态文件")
+			os.Remove(path)
+			continue
+		}
+		if owner.OwnerPID != os.Getpid() && processAlive(owner.OwnerPID) {
+			for _, t := range owner.Tasks {
+				for dir := range t.TempDirs {
+					protected[dir] = true
+				}
+			}
+			continue
+		}
+		for _, t := range owner.Tasks {
+			r.reapTask(t, &result)
+		}
+		os.Remove(path)
+	}
+
+	// 3. 残留临时文件
+	r.sweepStaleTemp(protected, &result)
+
+	r.sweeps.Add(1)
+	r.processesReaped.Add(int64(result.ProcessesReaped))
+	r.tempDirsRemoved.Add(int64(result.TempDirsRemoved))
+	r.staleTempRemoved.Add(int64(result.StaleTempRemoved))
+	r.bytesFreed.Add(result.BytesFreed)
+	now := r.now()
+	r.lastSweepAt.Store(&now)
+
+	if result.ProcessesReaped > 0 || result.TempDirsRemoved > 0 || result.StaleTempRemoved > 0 {
+		log.Info().
+			Int("processes_reaped", result.ProcessesReaped).
+			Int("temp_dirs_removed", result.TempDirsRemoved).
+			Int("stale_temp_removed", result.StaleTempRemoved).
+			Int64("bytes_freed", result.BytesFreed).
+			Msg("已回收孤儿进程和临时文件")
+	}
+	return result
+}
+
+// reapTask 结束任务残留的进程并删除临时目录
+func (r *Reaper) reapTask(t *taskState, result *SweepResult) {
+	for _, p := range t.Processes {
+		if !processMatches(p.PID, p.Command) {
+			continue
+		}
+		proc, err := os.FindProcess(p.PID)
+		if err != nil {
+			continue
+		}
+		if err := proc.Kill(); err != nil {
+			log.Warn().Err(err).Int("pid", p.PID).Str("command", p.Command).Msg("结束孤儿进程失败")
+			continue
+		}
+		log.Warn().Int("pid", p.PID).Str("command", p.Command).Time("started_at", p.StartedAt).Msg("已结束孤儿进程")
+		result.ProcessesReaped++
+	}
+	for dir := range t.TempDirs {
+		size, ok := removeAll(dir)
+		if ok {
+			result.TempDirsRemoved++
+			result.BytesFreed += size
+		}
+	}
+}
+
+// liveTempDirsLocked 本进程执行中任务的临时目录（调用方持有锁）
+func (r *Reaper) liveTempDirsLocked() map[string]bool {
+	dirs := make(map[string]bool)
+	for _, t := range r.state.Tasks {
+		for dir := range t.TempDirs {
+			dirs[dir] = true
+		}
+	}
+	return dirs
+}
+
+// sweepStaleTemp 清理临时目录中按前缀匹配、超过 StaleTempAge 未修改的残留文件/目录
+func (r *Reaper) sweepStaleTemp(protected map[string]bool, result *SweepResult) {
+	entries, err := os.ReadDir(r.tempDir)
+	if err != nil {
+		log.Warn().Err(err).Str("dir", r.tempDir).Msg("读取临时目录失败")
+		return
+	}
+	cutoff := r.now().Add(-r.staleTempAge)
+	for _, entry := range entries {
+		path := filepath.Join(r.tempDir, entry.Name())
+		if path == r.stateDir || protected[path] || !r.hasTempPrefix(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if size, ok := removeAll(path); ok {
+			result.StaleTempRemoved++
+			result.BytesFreed += size
+		}
+	}
+}
+
+func (r *Reaper) hasTempPrefix(name string) bool {
+	for _, prefix := range r.tempPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Stats 返回回收统计
+func (r *Reaper) Stats() Stats {
+	if r == nil {
+		return Stats{}
+	}
+	stats := Stats{
+		Sweeps:           r.sweeps.Load(),
+		ProcessesReaped:  r.processesReaped.Load(),
+		TempDirsRemoved:  r.tempDirsRemoved.Load(),
+		StaleTempRemoved: r.staleTempRemoved.Load(),
+		BytesFreed:       r.bytesFreed.Load(),
+		LastSweepAt:      r.lastSweepAt.Load(),
+	}
+	r.mu.Lock()
+	for _, t := range r.state.Tasks {
+		stats.TrackedProcesses += len(t.Processes)
+		if t.Active {
+			stats.ActiveTasks++
+		}
+	}
+	r.mu.Unlock()
+	return stats
+}
+
+// saveLocked 写入本进程的状态文件（先写临时文件再重命名，避免崩溃时留下不完整的文件）
+// 写入失败只记录日志：状态文件只用于崩溃后的回收
+func (r *Reaper) saveLocked() {
+	data, err := json.Marshal(&r.state)
+	if err != nil {
+		log.Warn().Err(err).Msg("序列化进程回收状态失败")
+		return
+	}
+	f, err := os.CreateTemp(r.stateDir, stateTmpPrefix+"*")
+	if err != nil {
+		log.Warn().Err(err).Msg("写入进程回收状态失败")
+		return
+	}
+	_, writeErr := f.Write(data)
+	closeErr := f.Close()
+	if writeErr != nil || closeErr != nil {
+		os.Remove(f.Name())
+		log.Warn().Err(errors.Join(writeErr, closeErr)).Msg("写入进程回收状态失败")
+		return
+	}
+	if err := os.Rename(f.Name(), r.statePath); err != nil {
+		os.Remove(f.Name())
+		log.Warn().Err(err).Msg("写入进程回收状态失败")
+	}
+}
+
+func readOwnerState(path string) (*ownerState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var owner ownerState
+	if err := json.Unmarshal(data, &owner); err != nil {
+		return nil, err
+	}
+	return &owner, nil
+}
+
+// removeAll 删除文件或目录，返回释放的字节数；路径不存在时返回 false
+func removeAll(path string) (int64, bool) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if info, err := d.Info(); err == nil && !d.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return 0, false
+	}
+	if err := os.RemoveAll(path); err != nil {
+		log.Warn().Err(err).Str("path", path).Msg("删除临时文件失败")
+		return 0, false
+	}
+	return size, true
+}
+
+// processAlive 判断进程是否存在
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return proc.Signal(syscall.Signal(0)) == nil
+}
+
+// processMatches 判断进程是否存在且可执行文件名与记录一致
+// 有 /proc 时校验命令行（PID 可能已被其他进程复用），没有时只判断进程是否存在
+func processMatches(pid int, command string) bool {
+	if !processAlive(pid) {
+		return false
+	}
+	cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		if _, statErr := os.Stat("/proc/self"); statErr != nil {
+			return true
+		}
+		return false
+	}
+	argv0, _, _ := strings.Cut(string(cmdline), "\x00")
+	return command == "" || filepath.Base(argv0) == command
+}
+
+// scopeKeyType 使用私有类型避免与其他 context key 冲突
+type scopeKeyType struct{}
+
+var scopeKey = scopeKeyType{}
+
+// scope context 中关联的回收器和任务
+type scope struct {
+	reaper *Reaper
+	taskID string
+}
+
+// NewContext 返回关联回收器的 context（不属于任何任务），之后启动的进程和临时目录只在服务崩溃后回收
+func NewContext(ctx context.Context, r *Reaper) context.Context {
+	if r == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, scopeKey, scope{reaper: r})
+}
+
+// FromContext 从 context 中获取回收器和任务ID，没有时返回 nil
+func FromContext(ctx context.Context) (*Reaper, string) {
+	if ctx == nil {
+		return nil, ""
+	}
+	s, _ := ctx.Value(scopeKey).(scope)
+	return s.reaper, s.taskID
+}
+
+// Run 启动命令并等待结束；context 关联了回收器时记录进程 PID，命令结束后移除
+func Run(ctx context.Context, cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	r, taskID := FromContext(ctx)
+	untrack := r.track(taskID, cmd.Process.Pid, filepath.Base(cmd.Path))
+	defer untrack()
+	return cmd.Wait()
+}
+
+// MkdirTemp 在系统临时目录下创建临时目录并记在 context 关联的任务下，返回删除目录的函数
+func MkdirTemp(ctx context.Context, pattern string) (string, func(), error) {
+	dir, err := os.MkdirTemp("", pattern)
+	if err != nil {
+		return "", nil, err
+	}
+	r, taskID := FromContext(ctx)
+	return dir, r.trackTempDir(taskID, dir), nil
+}
@@ -0,0 +1,206 @@
+package procreaper
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestReaper(t *testing.T) *Reaper {
+	t.Helper()
+	r, err := New(Options{
+		StateDir: filepath.Join(t.TempDir(), "state"),
+		TempDir:  t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return r
+}
+
+func TestSweepKillsProcessOfEndedTask(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not available")
+	}
+	r := newTestReaper(t)
+	ctx, end := r.Begin(context.Background(), "job-1")
+
+	cmd := exec.Command("sleep", "30")
+	done := make(chan error, 1)
+	go func() { done <- Run(ctx, cmd) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for r.Stats().TrackedProcesses == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("process was not tracked")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 任务执行中不回收
+	if got := r.Sweep(); got.ProcessesReaped != 0 {
+		t.Fatalf("active task reaped = %+v", got)
+	}
+
+	end()
+	if got := r.Sweep(); got.ProcessesReaped != 1 {
+		t.Fatalf("ProcessesReaped = %d, want 1", got.ProcessesReaped)
+	}
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Run() error = nil, want killed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("process was not killed")
+	}
+
+	stats := r.Stats()
+	if stats.ProcessesReaped != 1 || stats.TrackedProcesses != 0 || stats.ActiveTasks != 0 || stats.Sweeps != 2 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestMkdirTempCleanup(t *testing.T) {
+	r := newTestReaper(t)
+	ctx, end := r.Begin(context.Background(), "job-1")
+
+	dir, cleanup, err := MkdirTemp(ctx, "lemon-test-*")
+	if err != nil {
+		t.Fatalf("MkdirTemp() error = %v", err)
+	}
+	cleanup()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("temp dir still exists: %v", err)
+	}
+
+	// 任务结束时未删除的临时目录在回收时删除
+	leaked, _, err := MkdirTemp(ctx, "lemon-test-*")
+	if err != nil {
+		t.Fatalf("MkdirTemp() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(leaked, "clip.mp4"), make([]byte, 100), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Sweep(); got.TempDirsRemoved != 0 {
+		t.Fatalf("active task temp dir removed = %+v", got)
+	}
+	end()
+	got := r.Sweep()
+	if got.TempDirsRemoved != 1 || got.BytesFreed != 100 {
+		t.Errorf("result = %+v, want 1 dir / 100 bytes", got)
+	}
+	if _, err := os.Stat(leaked); !os.IsNotExist(err) {
+		t.Errorf("leaked temp dir still exists: %v", err)
+	}
+}
+
+func TestSweepRecoversCrashedOwner(t *testing.T) {
+	r := newTestReaper(t)
+	leaked := filepath.Join(t.TempDir(), "lemon-render")
+	if err := os.MkdirAll(leaked, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重启前的服务进程（容器内 PID 相同，启动时间不同）留下的状态文件
+	crashed := ownerState{
+		OwnerPID:       os.Getpid(),
+		OwnerStartedAt: time.Now().Add(-time.Hour),
+		Tasks: map[string]*taskState{
+			"job-1": {Active: true, TempDirs: map[string]bool{leaked: true}},
+		},
+	}
+	data, err := json.Marshal(&crashed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statePath := filepath.Join(r.stateDir, "1-1.json")
+	if err := os.WriteFile(statePath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := r.Sweep(); got.TempDirsRemoved != 1 {
+		t.Errorf("TempDirsRemoved = %d, want 1", got.TempDirsRemoved)
+	}
+	if _, err := os.Stat(leaked); !os.IsNotExist(err) {
+		t.Errorf("crashed owner temp dir still exists: %v", err)
+	}
+	if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+		t.Errorf("crashed owner state file still exists: %v", err)
+	}
+}
+
+func TestSweepStaleTemp(t *testing.T) {
+	r := newTestReaper(t)
+	old := time.Now().Add(-2 * DefaultStaleTempAge)
+
+	stale := filepath.Join(r.tempDir, "video_std_old.mp4")
+	fresh := filepath.Join(r.tempDir, "video_std_new.mp4")
+	other := filepath.Join(r.tempDir, "unrelated.mp4")
+	for _, path := range []string{stale, fresh, other} {
+		if err := os.WriteFile(path, make([]byte, 10), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, path := range []string{stale, other} {
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 执行中任务的临时目录即使很久未修改也不清理
+	ctx, end := r.Begin(context.Background(), "job-1")
+	defer end()
+	live, cleanup, err := MkdirTemp(ctx, "lemon-live-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	r.tempDir = filepath.Dir(live)
+	r.tempPrefixes = []string{"lemon-live-"}
+	if err := os.Chtimes(live, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Sweep(); got.StaleTempRemoved != 0 {
+		t.Errorf("live temp dir removed: %+v", got)
+	}
+
+	r.tempDir = filepath.Dir(stale)
+	r.tempPrefixes = DefaultTempPrefixes
+	got := r.Sweep()
+	if got.StaleTempRemoved != 1 || got.BytesFreed != 10 {
+		t.Errorf("result = %+v, want 1 entry / 10 bytes", got)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("stale temp file still exists")
+	}
+	for _, path := range []string{fresh, other} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s removed: %v", filepath.Base(path), err)
+		}
+	}
+}
+
+func TestNilReaper(t *testing.T) {
+	var r *Reaper
+	ctx, end := r.Begin(context.Background(), "job-1")
+	defer end()
+	if got, _ := FromContext(ctx); got != nil {
+		t.Error("FromContext() should be nil without reaper")
+	}
+	dir, cleanup, err := MkdirTemp(ctx, "lemon-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanup()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Error("temp dir still exists")
+	}
+	if got := r.Sweep(); got != (SweepResult{}) {
+		t.Errorf("Sweep() = %+v", got)
+	}
+}
@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Result 一次限流判断的结果（用于 X-RateLimit-* 和 Retry-After 响应头）
+type Result struct {
+	Allowed    bool          // 是否允许本次请求
+	Limit      int           // 每分钟请求上限（桶容量）
+	Remaining  int           // 本次请求后剩余的令牌数
+	RetryAfter time.Duration // 被限流时需要等待的时间
+	ResetAfter time.Duration // 令牌补满需要的时间
+}
+
+// Bucket 一次请求需要消耗令牌的令牌桶
+type Bucket struct {
+	Key            string // 桶的 key
+	LimitPerMinute int    // 每分钟请求上限（<= 0 表示不限流）
+}
+
+// Backend 令牌桶限流器（进程内或 Redis）
+type Backend interface {
+	// Take 为 key 消耗一个令牌（limitPerMinute <= 0 表示不限流）
+	Take(ctx context.Context, key string, limitPerMinute int) Result
+	// TakeAll 原子地检查多个桶：所有桶都有令牌时各消耗一个令牌，任何一个桶没有令牌时都不消耗
+	// 返回各桶的结果（顺序与 buckets 相同），Allowed 为整体结果
+	TakeAll(ctx context.Context, buckets []Bucket) []Result
+}
+
+// result 根据剩余令牌计算限流结果
+func result(allowed bool, tokens float64, limitPerMinute int) Result {
+	rate := float64(limitPerMinute) / float64(time.Minute) // 每纳秒补充的令牌数
+	r := Result{
+		Allowed:    allowed,
+		Limit:      limitPerMinute,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: time.Duration((float64(limitPerMinute) - tokens) / rate),
+	}
+	if !allowed && tokens < 1 {
+		r.RetryAfter = time.Duration((1 - tokens) / rate)
+	}
+	return r
+}
+
+// Limiter 按 key 独立计数的内存令牌桶限流器
+// 每个 key 的桶容量等于每分钟请求上限，令牌按上限匀速补充，允许短时间内突发到容量上限
+// 只在单个进程内生效，多实例部署时每个实例各自限流
+type Limiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+// bucket 单个 key 的令牌桶
+type bucket struct {
+	tokens   float64   // 当前剩余令牌
+	limit    int       // 每分钟请求上限
+	updateAt time.Time // 上次补充令牌的时间
+}
+
+// idleBucketTTL 空闲桶的回收时间：超过该时间未访问的桶已经补满，删除后重新创建结果相同
+const idleBucketTTL = 2 * time.Minute
+
+// New 创建限流器
+func New() *Limiter {
+	return &Limiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow 判断 key 是否还能发起一次请求（limitPerMinute <= 0 表示不限流）
+// 被限流时返回需要等待的时间（可用于 Retry-After 响应头）
+func (l *Limiter) Allow(key string, limitPerMinute int) (bool, time.Duration) {
+	r := l.Take(context.Background(), key, limitPerMinute)
+	return r.Allowed, r.RetryAfter
+}
+
+// Take 为 key 消耗一个令牌（limitPerMinute <= 0 表示不限流）
+func (l *Limiter) Take(ctx context.Context, key string, limitPerMinute int) Result {
+	return l.TakeAll(ctx, []Bucket{{Key: key, LimitPerMinute: limitPerMinute}})[0]
+}
+
+// TakeAll 所有桶都有令牌时各消耗一个令牌，否则都不消耗
+func (l *Limiter) TakeAll(_ context.Context, buckets []Bucket) []Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	states := make([]*bucket, len(buckets))
+	allowed := true
+	for i, bk := range buckets {
+		if bk.LimitPerMinute <= 0 {
+			continue
+		}
+		states[i] = l.refill(bk.Key, bk.LimitPerMinute, now)
+		if states[i].tokens < 1 {
+			allowed = false
+		}
+	}
+
+	results := make([]Result, len(buckets))
+	for i, b := range states {
+		if b == nil {
+			results[i] = Result{Allowed: allowed}
+			continue
+		}
+		if allowed {
+			b.tokens--
+		}
+		results[i] = result(allowed, b.tokens, b.limit)
+	}
+	return results
+}
+
+// refill 取出 key 的桶并补充到 now 为止的令牌
+func (l *Limiter) refill(key string, limitPerMinute int, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok || b.limit != limitPerMinute {
+		// 新 key 或上限被调整：按新上限重新开始计数
+		b = &bucket{tokens: float64(limitPerMinute), limit: limitPerMinute, updateAt: now}
+		l.buckets[key] = b
+		l.sweep(now)
+	}
+
+	rate := float64(limitPerMinute) / float64(time.Minute) // 每纳秒补充的令牌数
+	b.tokens = min(float64(limitPerMinute), b.tokens+float64(now.Sub(b.updateAt))*rate)
+	b.updateAt = now
+	return b
+}
+
+// sweep 回收长时间未访问的桶，避免 key 数量无限增长
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if now.Sub(b.updateAt) > idleBucketTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
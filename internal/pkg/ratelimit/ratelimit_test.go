@@ -0,0 +1,167 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New()
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("partner", 3); !ok {
+			t.Fatalf("request %d: expected allowed", i+1)
+		}
+	}
+
+	ok, retryAfter := l.Allow("partner", 3)
+	if ok {
+		t.Fatal("expected 4th request to be limited")
+	}
+	if retryAfter != 20*time.Second {
+		t.Errorf("expected retry after 20s, got %v", retryAfter)
+	}
+
+	// 其他 key 独立计数
+	if ok, _ := l.Allow("other", 3); !ok {
+		t.Error("expected other key to be allowed")
+	}
+
+	// 20 秒后补充一个令牌
+	now = now.Add(20 * time.Second)
+	if ok, _ := l.Allow("partner", 3); !ok {
+		t.Error("expected request to be allowed after refill")
+	}
+	if ok, _ := l.Allow("partner", 3); ok {
+		t.Error("expected request to be limited again")
+	}
+}
+
+func TestLimiter_Unlimited(t *testing.T) {
+	l := New()
+	for i := 0; i < 100; i++ {
+		if ok, _ := l.Allow("partner", 0); !ok {
+			t.Fatal("expected unlimited key to be allowed")
+		}
+	}
+}
+
+func TestLimiter_SweepIdleBuckets(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New()
+	l.now = func() time.Time { return now }
+
+	l.Allow("a", 10)
+	now = now.Add(idleBucketTTL + time.Second)
+	l.Allow("b", 10)
+
+	if _, ok := l.buckets["a"]; ok {
+		t.Error("expected idle bucket to be swept")
+	}
+	if _, ok := l.buckets["b"]; !ok {
+		t.Error("expected active bucket to remain")
+	}
+}
+
+func TestLimiter_TakeResult(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New()
+	l.now = func() time.Time { return now }
+
+	r := l.Take(context.Background(), "user", 60)
+	if !r.Allowed || r.Limit != 60 || r.Remaining != 59 {
+		t.Fatalf("unexpected first result: %+v", r)
+	}
+	if r.ResetAfter.Round(time.Millisecond) != time.Second {
+		t.Errorf("expected reset after 1s, got %v", r.ResetAfter)
+	}
+
+	for i := 0; i < 59; i++ {
+		l.Take(context.Background(), "user", 60)
+	}
+	r = l.Take(context.Background(), "user", 60)
+	if r.Allowed || r.Remaining != 0 {
+		t.Fatalf("expected limited result, got %+v", r)
+	}
+	if r.RetryAfter.Round(time.Millisecond) != time.Second || r.ResetAfter.Round(time.Millisecond) != time.Minute {
+		t.Errorf("expected retry after 1s and reset after 1m, got %v and %v", r.RetryAfter, r.ResetAfter)
+	}
+}
+
+func TestRedisLimiter_FallbackWhenUnavailable(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr:        "127.0.0.1:1",
+		DialTimeout: 100 * time.Millisecond,
+		MaxRetries:  -1,
+	})
+	defer client.Close()
+	l := NewRedis(client, "test:")
+
+	for i := 0; i < 2; i++ {
+		if r := l.Take(context.Background(), "user", 2); !r.Allowed {
+			t.Fatalf("request %d: expected allowed by fallback limiter", i+1)
+		}
+	}
+	if r := l.Take(context.Background(), "user", 2); r.Allowed {
+		t.Error("expected fallback limiter to limit the 3rd request")
+	}
+}
+
+func TestLimiter_TakeAll(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New()
+	l.now = func() time.Time { return now }
+	buckets := []Bucket{
+		{Key: "class", LimitPerMinute: 3},
+		{Key: "endpoint", LimitPerMinute: 1},
+		{Key: "unlimited", LimitPerMinute: 0},
+	}
+
+	rs := l.TakeAll(context.Background(), buckets)
+	for i, r := range rs {
+		if !r.Allowed {
+			t.Fatalf("bucket %d: expected allowed, got %+v", i, r)
+		}
+	}
+	if rs[0].Remaining != 2 || rs[1].Remaining != 0 {
+		t.Fatalf("unexpected remaining: %+v", rs)
+	}
+
+	// endpoint 桶没有令牌：整体被限流，class 桶不消耗令牌
+	rs = l.TakeAll(context.Background(), buckets)
+	if rs[0].Allowed || rs[1].Allowed || rs[2].Allowed {
+		t.Fatalf("expected all results limited, got %+v", rs)
+	}
+	if rs[0].Remaining != 2 || rs[0].RetryAfter != 0 {
+		t.Errorf("expected class bucket untouched, got %+v", rs[0])
+	}
+	if rs[1].RetryAfter != time.Minute {
+		t.Errorf("expected endpoint retry after 1m, got %v", rs[1].RetryAfter)
+	}
+	if r := l.Take(context.Background(), "class", 3); !r.Allowed || r.Remaining != 1 {
+		t.Errorf("expected class bucket to keep its tokens, got %+v", r)
+	}
+}
+
+func TestRedisLimiter_TakeAllFallbackWhenUnavailable(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr:        "127.0.0.1:1",
+		DialTimeout: 100 * time.Millisecond,
+		MaxRetries:  -1,
+	})
+	defer client.Close()
+	l := NewRedis(client, "test:")
+	buckets := []Bucket{{Key: "class", LimitPerMinute: 2}, {Key: "endpoint", LimitPerMinute: 1}}
+
+	if rs := l.TakeAll(context.Background(), buckets); !rs[0].Allowed || !rs[1].Allowed {
+		t.Fatalf("expected allowed by fallback limiter, got %+v", rs)
+	}
+	if rs := l.TakeAll(context.Background(), buckets); rs[0].Allowed || rs[0].Remaining != 1 {
+		t.Fatalf("expected limited without consuming class bucket, got %+v", rs)
+	}
+}
@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// tokenBucketScript Redis 令牌桶脚本：补充各桶的令牌，所有桶都有令牌时各消耗一个令牌并更新过期时间，整个过程原子执行
+// 使用 Redis 服务器时间，多个实例的时钟偏差不影响计数；桶补满后过期删除（与不存在的桶结果相同）
+// KEYS[i] 桶的 key；ARGV[i] 对应桶的容量（每分钟请求上限）
+// 返回 {是否允许, 各桶剩余令牌（字符串，保留小数）...}
+var tokenBucketScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+local allowed = 1
+local remaining = {}
+for i, key in ipairs(KEYS) do
+	local capacity = tonumber(ARGV[i])
+	local rate = capacity / 60000000
+	local state = redis.call('HMGET', key, 'tokens', 'ts', 'limit')
+	local tokens = tonumber(state[1])
+	local ts = tonumber(state[2])
+	if tokens == nil or tonumber(state[3]) ~= capacity then
+		tokens = capacity
+		ts = now
+	end
+	if now > ts then
+		tokens = math.min(capacity, tokens + (now - ts) * rate)
+	end
+	if tokens < 1 then
+		allowed = 0
+	end
+	remaining[i] = tokens
+end
+
+local reply = {allowed}
+for i, key in ipairs(KEYS) do
+	local capacity = tonumber(ARGV[i])
+	local rate = capacity / 60000000
+	local tokens = remaining[i]
+	if allowed == 1 then
+		tokens = tokens - 1
+	end
+	redis.call('HSET', key, 'tokens', tostring(tokens), 'ts', now, 'limit', capacity)
+	redis.call('PEXPIRE', key, math.ceil((capacity - tokens) / rate / 1000) + 1000)
+	reply[i + 1] = tostring(tokens)
+end
+return reply
+`)
+
+// redisFallbackLogInterval Redis 不可用时告警日志的最小间隔
+const redisFallbackLogInterval = time.Minute
+
+// RedisLimiter 基于 Redis 的令牌桶限流器，多个实例共享计数
+// 桶的语义与 Limiter 相同；Redis 不可用时退化为进程内限流（每个实例各自限流），不会因为 Redis 故障拒绝请求
+type RedisLimiter struct {
+	client   redis.Scripter
+	prefix   string
+	fallback *Limiter
+	lastWarn atomic.Int64
+}
+
+// NewRedis 创建 Redis 限流器，prefix 为桶 key 的前缀
+func NewRedis(client redis.Scripter, prefix string) *RedisLimiter {
+	return &RedisLimiter{
+		client:   client,
+		prefix:   prefix,
+		fallback: New(),
+	}
+}
+
+// Take 为 key 消耗一个令牌（limitPerMinute <= 0 表示不限流）
+func (l *RedisLimiter) Take(ctx context.Context, key string, limitPerMinute int) Result {
+	return l.TakeAll(ctx, []Bucket{{Key: key, LimitPerMinute: limitPerMinute}})[0]
+}
+
+// TakeAll 所有桶都有令牌时各消耗一个令牌，否则都不消耗（多个桶在同一个脚本中原子执行）
+func (l *RedisLimiter) TakeAll(ctx context.Context, buckets []Bucket) []Result {
+	var (
+		keys    []string
+		args    []any
+		limited []int // 需要限流的桶在 buckets 中的下标
+	)
+	for i, b := range buckets {
+		if b.LimitPerMinute > 0 {
+			keys = append(keys, l.prefix+b.Key)
+			args = append(args, b.LimitPerMinute)
+			limited = append(limited, i)
+		}
+	}
+	results := make([]Result, len(buckets))
+	if len(limited) == 0 {
+		for i := range results {
+			results[i] = Result{Allowed: true}
+		}
+		return results
+	}
+
+	reply, err := tokenBucketScript.Run(ctx, l.client, keys, args...).Slice()
+	if err == nil && len(reply) != len(keys)+1 {
+		err = fmt.Errorf("unexpected token bucket reply: %v", reply)
+	}
+	if err == nil {
+		allowed, _ := reply[0].(int64)
+		for i := range results {
+			results[i] = Result{Allowed: allowed == 1}
+		}
+		for n, i := range limited {
+			remaining, _ := reply[n+1].(string)
+			var tokens float64
+			if tokens, err = strconv.ParseFloat(remaining, 64); err != nil {
+				break
+			}
+			results[i] = result(allowed == 1, tokens, buckets[i].LimitPerMinute)
+		}
+		if err == nil {
+			return results
+		}
+	}
+
+	now := time.Now().UnixNano()
+	if last := l.lastWarn.Load(); now-last > int64(redisFallbackLogInterval) && l.lastWarn.CompareAndSwap(last, now) {
+		log.Warn().Err(err).Strs("keys", keys).Msg("Redis 限流失败，退化为进程内限流")
+	}
+	return l.fallback.TakeAll(ctx, buckets)
+}
@@ -0,0 +1,57 @@
+// Package resourceref 收集业务记录中引用的资源ID
+// 业务模块通过 resource_id、xxx_resource_id、resource_ids 等字段（包括嵌套文档和数组中的字段）引用资源，
+// 资源回收时据此判断资源是否仍被使用。按字段名匹配而不是按模型逐个列出，新增的业务模块不需要额外登记
+package resourceref
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// IsReferenceKey 字段名是否为资源引用字段
+// 匹配 resource_id、xxx_resource_id、resource_ids，以及没有 bson 标签时默认的小写字段名 resourceid、resourceids
+func IsReferenceKey(key string) bool {
+	key = strings.ToLower(key)
+	return strings.Contains(key, "resource_id") ||
+		strings.HasSuffix(key, "resourceid") ||
+		strings.HasSuffix(key, "resourceids")
+}
+
+// Collect 收集文档中引用的资源ID，每找到一个非空ID调用一次 add
+// 资源引用字段下的字符串、字符串数组以及嵌套文档中的所有字符串都视为资源ID（宁可多保留，不能误删）
+func Collect(doc bson.Raw, add func(id string)) error {
+	return collect(doc, false, add)
+}
+
+func collect(doc bson.Raw, ref bool, add func(id string)) error {
+	elems, err := doc.Elements()
+	if err != nil {
+		return err
+	}
+	for _, elem := range elems {
+		isRef := ref || IsReferenceKey(elem.Key())
+		value := elem.Value()
+		switch value.Type {
+		case bsontype.String:
+			if id := value.StringValue(); isRef && id != "" {
+				add(id)
+			}
+		case bsontype.EmbeddedDocument:
+			if sub, ok := value.DocumentOK(); ok {
+				if err := collect(sub, isRef, add); err != nil {
+					return err
+				}
+			}
+		case bsontype.Array:
+			// 数组元素的字段名是下标，是否为资源引用由数组字段名决定
+			if arr, ok := value.ArrayOK(); ok {
+				if err := collect(arr, isRef, add); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
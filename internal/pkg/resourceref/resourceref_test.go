@@ -0,0 +1,84 @@
+package resourceref
+
+import (
+	"sort"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func collectIDs(t *testing.T, doc interface{}) []string {
+	t.Helper()
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		t.Fatalf("bson.Marshal() error = %v", err)
+	}
+	var ids []string
+	if err := Collect(raw, func(id string) { ids = append(ids, id) }); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestIsReferenceKey(t *testing.T) {
+	cases := map[string]bool{
+		"resource_id":          true,
+		"cover_resource_id":    true,
+		"resource_ids":         true,
+		"ResourceID":           true,
+		"resourceids":          true,
+		"id":                   false,
+		"narration_id":         false,
+		"resource_type":        false,
+		"storage_key":          false,
+		"video_resource_count": false,
+	}
+	for key, want := range cases {
+		if got := IsReferenceKey(key); got != want {
+			t.Errorf("IsReferenceKey(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestCollectTopLevelAndArrays(t *testing.T) {
+	ids := collectIDs(t, bson.M{
+		"id":                   "audio-1",
+		"narration_id":         "n-1",
+		"audio_resource_id":    "r-audio",
+		"resource_ids":         bson.A{"r-1", "r-2", ""},
+		"thumbnail_vtt":        "not-a-ref",
+		"waveform_resource_id": "",
+	})
+	want := []string{"r-1", "r-2", "r-audio"}
+	if len(ids) != len(want) {
+		t.Fatalf("Collect() = %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("Collect() = %v, want %v", ids, want)
+		}
+	}
+}
+
+func TestCollectNestedDocuments(t *testing.T) {
+	ids := collectIDs(t, bson.M{
+		"id": "video-1",
+		"clips": bson.A{
+			bson.M{"shot_id": "s-1", "video_resource_id": "r-clip-1"},
+			bson.M{"shot_id": "s-2", "video_resource_id": "r-clip-2"},
+		},
+		"cover": bson.M{"resourceid": "r-cover", "url": "https://example.com"},
+		// 引用字段下的映射中所有字符串都视为资源ID
+		"resource_ids_by_lang": bson.M{"zh": "r-zh", "en": "r-en"},
+	})
+	want := []string{"r-clip-1", "r-clip-2", "r-cover", "r-en", "r-zh"}
+	if len(ids) != len(want) {
+		t.Fatalf("Collect() = %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("Collect() = %v, want %v", ids, want)
+		}
+	}
+}
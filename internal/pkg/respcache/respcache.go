@@ -0,0 +1,182 @@
+// Package respcache 生成能力 provider 的响应缓存
+// 部分失败后重试时会用相同的提示词再次调用 provider，缓存相同请求的输出，恢复任务直接复用，避免重复付费
+// 缓存在进程内存中，按 TTL 过期，条目数和总字节数超过上限时淘汰最久未使用的条目
+package respcache
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Options 缓存配置
+type Options struct {
+	TTL        time.Duration // 条目有效期（<=0 表示不过期）
+	MaxEntries int           // 最多缓存的条目数（<=0 表示不限制）
+	MaxBytes   int64         // 缓存内容的总字节数上限（<=0 表示不限制），超过上限的单个响应不缓存
+}
+
+// Stats 缓存统计（进程启动以来）
+type Stats struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+	Entries   int   `json:"entries"`
+	Bytes     int64 `json:"bytes"`
+}
+
+// entry 缓存条目
+type entry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time // 零值表示不过期
+	receipts  int       // 命中后尚未被 ConsumeHit 领取的次数
+}
+
+// Cache 响应缓存（LRU + TTL），可并发使用
+type Cache struct {
+	opts Options
+	now  func() time.Time
+
+	mu        sync.Mutex
+	ll        *list.List // 最近使用的在前
+	items     map[string]*list.Element
+	bytes     int64
+	hits      int64
+	misses    int64
+	evictions int64
+}
+
+// New 创建响应缓存
+func New(opts Options) *Cache {
+	return &Cache{
+		opts:  opts,
+		now:   time.Now,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Key 由 provider、模型和请求参数生成缓存键（各部分带长度前缀后做 SHA-256，避免拼接歧义）
+func Key(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(strconv.Itoa(len(p))))
+		h.Write([]byte{':'})
+		h.Write([]byte(p))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get 读取缓存，命中时刷新最近使用顺序并记一次命中回执（见 ConsumeHit）
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if c.expired(e) {
+		c.remove(el)
+		c.misses++
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	e.receipts++
+	c.hits++
+	return e.value, true
+}
+
+// Put 写入缓存，已存在时覆盖；写入后按条目数和总字节数淘汰最久未使用的条目
+func (c *Cache) Put(key string, value []byte) {
+	size := int64(len(value))
+	if c.opts.MaxBytes > 0 && size > c.opts.MaxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+	e := &entry{key: key, value: value}
+	if c.opts.TTL > 0 {
+		e.expiresAt = c.now().Add(c.opts.TTL)
+	}
+	c.items[key] = c.ll.PushFront(e)
+	c.bytes += size
+
+	for c.ll.Len() > 1 && ((c.opts.MaxEntries > 0 && c.ll.Len() > c.opts.MaxEntries) ||
+		(c.opts.MaxBytes > 0 && c.bytes > c.opts.MaxBytes)) {
+		c.remove(c.ll.Back())
+		c.evictions++
+	}
+}
+
+// ConsumeHit 领取一次命中回执，返回该键最近是否有未领取的命中
+// 调用方据此判断某次输出来自缓存（如记账时跳过未实际调用 provider 的请求）
+func (c *Cache) ConsumeHit(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	e := el.Value.(*entry)
+	if e.receipts == 0 {
+		return false
+	}
+	e.receipts--
+	return true
+}
+
+// Stats 返回缓存统计
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Entries:   c.ll.Len(),
+		Bytes:     c.bytes,
+	}
+}
+
+// expired 判断条目是否已过期
+func (c *Cache) expired(e *entry) bool {
+	return !e.expiresAt.IsZero() && !c.now().Before(e.expiresAt)
+}
+
+// remove 删除条目（调用方持有锁）
+func (c *Cache) remove(el *list.Element) {
+	e := c.ll.Remove(el).(*entry)
+	delete(c.items, e.key)
+	c.bytes -= int64(len(e.value))
+}
+
+// bypassKeyType 使用私有类型避免与其他 context key 冲突
+type bypassKeyType struct{}
+
+// Bypass 返回跳过缓存读取的 context（如用户主动重新生成，需要新的输出），调用结果仍会写入缓存
+func Bypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKeyType{}, true)
+}
+
+// Bypassed 判断 context 是否要求跳过缓存读取
+func Bypassed(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	v, _ := ctx.Value(bypassKeyType{}).(bool)
+	return v
+}
@@ -0,0 +1,105 @@
+package respcache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCache_GetPut(t *testing.T) {
+	c := New(Options{})
+
+	if _, ok := c.Get("k1"); ok {
+		t.Fatal("Get() on empty cache should miss")
+	}
+	c.Put("k1", []byte("hello"))
+	v, ok := c.Get("k1")
+	if !ok || string(v) != "hello" {
+		t.Fatalf("Get() = %q, %v, want hello", v, ok)
+	}
+
+	got := c.Stats()
+	if got.Hits != 1 || got.Misses != 1 || got.Entries != 1 || got.Bytes != 5 {
+		t.Errorf("Stats() = %+v", got)
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := New(Options{TTL: time.Minute})
+	c.now = func() time.Time { return now }
+
+	c.Put("k1", []byte("hello"))
+	now = now.Add(59 * time.Second)
+	if _, ok := c.Get("k1"); !ok {
+		t.Fatal("Get() before TTL should hit")
+	}
+	now = now.Add(time.Second)
+	if _, ok := c.Get("k1"); ok {
+		t.Fatal("Get() after TTL should miss")
+	}
+	if got := c.Stats(); got.Entries != 0 || got.Bytes != 0 {
+		t.Errorf("expired entry should be removed, Stats() = %+v", got)
+	}
+}
+
+func TestCache_Eviction(t *testing.T) {
+	c := New(Options{MaxEntries: 2, MaxBytes: 10})
+
+	c.Put("k1", []byte("aaa"))
+	c.Put("k2", []byte("bbb"))
+	c.Get("k1") // k2 变为最久未使用
+	c.Put("k3", []byte("ccc"))
+	if _, ok := c.Get("k2"); ok {
+		t.Error("least recently used entry should be evicted by MaxEntries")
+	}
+	if _, ok := c.Get("k1"); !ok {
+		t.Error("recently used entry should be kept")
+	}
+
+	c.Put("k4", []byte("dddddddd"))
+	if got := c.Stats(); got.Entries != 1 || got.Bytes != 8 {
+		t.Errorf("MaxBytes should evict old entries, Stats() = %+v", got)
+	}
+
+	c.Put("k5", []byte("too large value"))
+	if _, ok := c.Get("k5"); ok {
+		t.Error("value larger than MaxBytes should not be cached")
+	}
+}
+
+func TestCache_ConsumeHit(t *testing.T) {
+	c := New(Options{})
+	c.Put("k1", []byte("hello"))
+
+	if c.ConsumeHit("k1") {
+		t.Fatal("ConsumeHit() without Get() should be false")
+	}
+	c.Get("k1")
+	c.Get("k1")
+	if !c.ConsumeHit("k1") || !c.ConsumeHit("k1") {
+		t.Fatal("ConsumeHit() should return true once per hit")
+	}
+	if c.ConsumeHit("k1") {
+		t.Error("ConsumeHit() should be false after all hits are consumed")
+	}
+}
+
+func TestKey(t *testing.T) {
+	if Key("ab", "c") == Key("a", "bc") {
+		t.Error("Key() should not collide on different part boundaries")
+	}
+	if Key("ark", "m", "prompt") != Key("ark", "m", "prompt") {
+		t.Error("Key() should be deterministic")
+	}
+}
+
+func TestBypass(t *testing.T) {
+	ctx := context.Background()
+	if Bypassed(ctx) {
+		t.Error("Bypassed() on plain context should be false")
+	}
+	if !Bypassed(Bypass(ctx)) {
+		t.Error("Bypassed() after Bypass() should be true")
+	}
+}
@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidKeyTemplate 存储路径模板格式不合法（括号不匹配、未知变量、以 / 开头等）
+	ErrInvalidKeyTemplate = errors.New("invalid storage key template")
+	// ErrMissingKeyVar 模板引用的变量没有值（如角色图片没有章节序号）
+	ErrMissingKeyVar = errors.New("missing storage key variable")
+)
+
+// DefaultKeyTemplate 默认存储路径模板（未配置模板或模板变量缺失时使用）
+const DefaultKeyTemplate = "resources/{user_id}/{resource_id}.{ext}"
+
+// 存储路径模板支持的变量
+// 数值变量可以指定补零宽度，如 {seq:03} 渲染为 007
+const (
+	KeyVarUserID       = "user_id"
+	KeyVarResourceID   = "resource_id"
+	KeyVarNovelID      = "novel_id"
+	KeyVarChapterID    = "chapter_id"
+	KeyVarChapterSeq   = "chapter_seq"
+	KeyVarArtifactType = "artifact_type"
+	KeyVarVersion      = "version"
+	KeyVarSeq          = "seq"
+	KeyVarExt          = "ext"
+)
+
+// KeyVars 渲染存储路径模板的变量
+// 字符串为空、数值 <= 0 视为没有值；ext 为空时同时去掉它前面的点号
+type KeyVars struct {
+	UserID       string
+	ResourceID   string
+	NovelID      string
+	ChapterID    string
+	ChapterSeq   int    // 章节序号（从 1 开始）
+	ArtifactType string // 产物类型（如 audio、subtitle、image、clip、final_video），同时决定使用哪个模板
+	Version      int    // 产物版本号
+	Seq          int    // 产物在章节内的序号（从 1 开始）
+	Ext          string // 文件扩展名（不含点号）
+}
+
+// lookup 返回变量的值，ok 为 false 表示模板不支持该变量
+func (v KeyVars) lookup(name string) (value string, numeric bool, ok bool) {
+	number := func(n int) string {
+		if n <= 0 {
+			return ""
+		}
+		return strconv.Itoa(n)
+	}
+	switch name {
+	case KeyVarUserID:
+		return v.UserID, false, true
+	case KeyVarResourceID:
+		return v.ResourceID, false, true
+	case KeyVarNovelID:
+		return v.NovelID, false, true
+	case KeyVarChapterID:
+		return v.ChapterID, false, true
+	case KeyVarChapterSeq:
+		return number(v.ChapterSeq), true, true
+	case KeyVarArtifactType:
+		return v.ArtifactType, false, true
+	case KeyVarVersion:
+		return number(v.Version), true, true
+	case KeyVarSeq:
+		return number(v.Seq), true, true
+	case KeyVarExt:
+		return v.Ext, false, true
+	}
+	return "", false, false
+}
+
+// KeyTemplates 按产物类型配置的存储路径模板，"default" 用于没有单独配置的产物类型
+type KeyTemplates map[string]string
+
+// DefaultKeyTemplateName 默认模板在 KeyTemplates 中的名称
+const DefaultKeyTemplateName = "default"
+
+// Resolve 返回产物类型对应的模板，依次回退到 "default" 和 DefaultKeyTemplate
+func (t KeyTemplates) Resolve(artifactType string) string {
+	if tmpl := t[artifactType]; artifactType != "" && tmpl != "" {
+		return tmpl
+	}
+	if tmpl := t[DefaultKeyTemplateName]; tmpl != "" {
+		return tmpl
+	}
+	return DefaultKeyTemplate
+}
+
+// Validate 校验所有模板
+func (t KeyTemplates) Validate() error {
+	for name, tmpl := range t {
+		if err := ValidateKeyTemplate(tmpl); err != nil {
+			return fmt.Errorf("key template %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// keyPlaceholder 模板中的一个变量
+type keyPlaceholder struct {
+	name  string
+	width int // 补零宽度，0 表示不补零
+}
+
+// parseKeyTemplate 把模板拆分为字面量和变量，parts 与 placeholders 交替出现：parts[i] 之后是 placeholders[i]
+func parseKeyTemplate(tmpl string) (parts []string, placeholders []keyPlaceholder, err error) {
+	if tmpl == "" || strings.HasPrefix(tmpl, "/") {
+		return nil, nil, fmt.Errorf("%w: %q must be a relative path", ErrInvalidKeyTemplate, tmpl)
+	}
+	rest := tmpl
+	for {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			if strings.ContainsRune(rest, '}') {
+				return nil, nil, fmt.Errorf("%w: unbalanced braces in %q", ErrInvalidKeyTemplate, tmpl)
+			}
+			return append(parts, rest), placeholders, nil
+		}
+		closing := strings.IndexByte(rest[open:], '}')
+		if closing < 0 || strings.ContainsRune(rest[:open], '}') {
+			return nil, nil, fmt.Errorf("%w: unbalanced braces in %q", ErrInvalidKeyTemplate, tmpl)
+		}
+		spec := rest[open+1 : open+closing]
+		name, format, hasFormat := strings.Cut(spec, ":")
+		_, numeric, ok := KeyVars{}.lookup(name)
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: unknown variable {%s} in %q", ErrInvalidKeyTemplate, name, tmpl)
+		}
+		p := keyPlaceholder{name: name}
+		if hasFormat {
+			width, err := strconv.Atoi(format)
+			if !numeric || !strings.HasPrefix(format, "0") || err != nil || width <= 0 {
+				return nil, nil, fmt.Errorf("%w: invalid format {%s} in %q", ErrInvalidKeyTemplate, spec, tmpl)
+			}
+			p.width = width
+		}
+		parts = append(parts, rest[:open])
+		placeholders = append(placeholders, p)
+		rest = rest[open+closing+1:]
+	}
+}
+
+// ValidateKeyTemplate 校验模板格式和变量名
+func ValidateKeyTemplate(tmpl string) error {
+	_, _, err := parseKeyTemplate(tmpl)
+	return err
+}
+
+// RenderKey 用 vars 渲染模板
+// 变量值中 / 等不适合出现在路径中的字符替换为 _；模板引用的变量没有值时返回 ErrMissingKeyVar（ext 除外）
+func RenderKey(tmpl string, vars KeyVars) (string, error) {
+	parts, placeholders, err := parseKeyTemplate(tmpl)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for i, p := range placeholders {
+		b.WriteString(parts[i])
+		value, _, _ := vars.lookup(p.name)
+		if value == "" {
+			if p.name != KeyVarExt {
+				return "", fmt.Errorf("%w: {%s} in %q", ErrMissingKeyVar, p.name, tmpl)
+			}
+			// 没有扩展名时去掉前面的点号
+			key := strings.TrimSuffix(b.String(), ".")
+			b.Reset()
+			b.WriteString(key)
+			continue
+		}
+		if p.width > 0 && len(value) < p.width {
+			value = strings.Repeat("0", p.width-len(value)) + value
+		}
+		b.WriteString(sanitizeKeySegment(value))
+	}
+	b.WriteString(parts[len(parts)-1])
+	return b.String(), nil
+}
+
+// sanitizeKeySegment 把变量值中不适合出现在存储路径中的字符替换为 _
+func sanitizeKeySegment(value string) string {
+	value = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, value)
+	if strings.Trim(value, ".") == "" {
+		return strings.Repeat("_", len(value))
+	}
+	return value
+}
+
+// AppendKeySuffix 在存储路径的扩展名前追加后缀，用于模板渲染结果与已有文件冲突时避免覆盖
+// 例如 novels/n1/ch1/audio/v1/1.mp3 追加 abc 得到 novels/n1/ch1/audio/v1/1_abc.mp3
+func AppendKeySuffix(key, suffix string) string {
+	slash := strings.LastIndexByte(key, '/')
+	if dot := strings.LastIndexByte(key, '.'); dot > slash+1 {
+		return key[:dot] + "_" + suffix + key[dot:]
+	}
+	return key + "_" + suffix
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRenderKey(t *testing.T) {
+	vars := KeyVars{
+		UserID:       "u1",
+		ResourceID:   "r1",
+		NovelID:      "n1",
+		ChapterID:    "c1",
+		ChapterSeq:   3,
+		ArtifactType: "audio",
+		Version:      2,
+		Seq:          7,
+		Ext:          "mp3",
+	}
+
+	tests := []struct {
+		name    string
+		tmpl    string
+		vars    KeyVars
+		want    string
+		wantErr error
+	}{
+		{name: "default", tmpl: DefaultKeyTemplate, vars: vars, want: "resources/u1/r1.mp3"},
+		{name: "novel layout", tmpl: "novels/{novel_id}/ch{chapter_seq}/{artifact_type}/v{version}/{seq}.{ext}", vars: vars, want: "novels/n1/ch3/audio/v2/7.mp3"},
+		{name: "zero padding", tmpl: "novels/{novel_id}/ch{chapter_seq:03}/{seq:02}.{ext}", vars: vars, want: "novels/n1/ch003/07.mp3"},
+		{name: "empty ext drops dot", tmpl: DefaultKeyTemplate, vars: KeyVars{UserID: "u1", ResourceID: "r1"}, want: "resources/u1/r1"},
+		{name: "values are sanitized", tmpl: "{novel_id}/{resource_id}.{ext}", vars: KeyVars{NovelID: "a/../b", ResourceID: "..", Ext: "mp4"}, want: "a_.._b/__.mp4"},
+		{name: "missing variable", tmpl: "novels/{novel_id}/ch{chapter_seq}/{resource_id}", vars: KeyVars{NovelID: "n1", ResourceID: "r1"}, wantErr: ErrMissingKeyVar},
+		{name: "unknown variable", tmpl: "novels/{novel}/{resource_id}", vars: vars, wantErr: ErrInvalidKeyTemplate},
+		{name: "unbalanced braces", tmpl: "novels/{novel_id/{resource_id}", vars: vars, wantErr: ErrInvalidKeyTemplate},
+		{name: "padding on string variable", tmpl: "novels/{novel_id:03}", vars: vars, wantErr: ErrInvalidKeyTemplate},
+		{name: "absolute path", tmpl: "/novels/{novel_id}", vars: vars, wantErr: ErrInvalidKeyTemplate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderKey(tt.tmpl, tt.vars)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("RenderKey() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RenderKey() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyTemplates(t *testing.T) {
+	if got := KeyTemplates(nil).Resolve("audio"); got != DefaultKeyTemplate {
+		t.Errorf("Resolve() without config = %q", got)
+	}
+
+	templates := KeyTemplates{"default": "novels/{novel_id}/{resource_id}.{ext}", "audio": "novels/{novel_id}/audio/{seq}.{ext}"}
+	if got := templates.Resolve("audio"); got != templates["audio"] {
+		t.Errorf("Resolve(audio) = %q", got)
+	}
+	if got := templates.Resolve("image"); got != templates["default"] {
+		t.Errorf("Resolve(image) = %q", got)
+	}
+	if err := templates.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := (KeyTemplates{"audio": "{unknown}"}).Validate(); !errors.Is(err, ErrInvalidKeyTemplate) {
+		t.Errorf("Validate() error = %v, want ErrInvalidKeyTemplate", err)
+	}
+}
+
+func TestAppendKeySuffix(t *testing.T) {
+	tests := map[string]string{
+		"novels/n1/ch1/audio/v1/1.mp3": "novels/n1/ch1/audio/v1/1_abc.mp3",
+		"novels/n1.x/1":                "novels/n1.x/1_abc",
+		"novels/.hidden":               "novels/.hidden_abc",
+	}
+	for key, want := range tests {
+		if got := AppendKeySuffix(key, "abc"); got != want {
+			t.Errorf("AppendKeySuffix(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -77,6 +77,15 @@ func (s *LocalStorage) Download(ctx context.Context, key string) (io.ReadCloser,
 	return file, nil
 }
 
+// DownloadRange 按区间下载文件（直接定位到 offset，不读取之前的内容）
+func (s *LocalStorage) DownloadRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	file, err := s.Download(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return storage.LimitReadCloser(file, offset, length)
+}
+
 // GetPresignedUploadURL 获取预签名上传URL（本地文件系统使用服务器上传接口）
 func (s *LocalStorage) GetPresignedUploadURL(ctx context.Context, key string, contentType string, expiresIn time.Duration) (string, error) {
 	// 本地文件系统不支持客户端直传，返回服务器上传接口URL
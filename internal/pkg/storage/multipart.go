@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// MultipartUploader 分片上传接口（可选能力）
+// 用于服务端上传大文件（如最终视频）：按分片顺序读取数据并逐片上传，
+// 内存占用不超过单个分片大小；单个分片失败时只重试该分片，不重传整个文件
+type MultipartUploader interface {
+	// UploadMultipart 分片上传文件，返回文件URL
+	UploadMultipart(ctx context.Context, key string, data io.Reader, contentType string, opts MultipartOptions) (string, error)
+}
+
+const (
+	MinMultipartPartSize     int64 = 5 << 20  // 最小分片大小（S3/OSS 要求除最后一片外不小于 5MB）
+	DefaultMultipartPartSize int64 = 8 << 20  // 默认分片大小
+	MaxMultipartPartSize     int64 = 64 << 20 // 最大分片大小（限制单次内存占用）
+
+	defaultMultipartMaxRetries   = 3
+	defaultMultipartRetryBackoff = 500 * time.Millisecond
+)
+
+// MultipartOptions 分片上传参数
+type MultipartOptions struct {
+	PartSize     int64         // 分片大小（字节）
+	MaxRetries   int           // 单个分片的最大重试次数（不含首次上传）
+	RetryBackoff time.Duration // 重试初始退避时间（每次重试翻倍）
+}
+
+// DefaultMultipartOptions 返回默认的分片上传参数
+func DefaultMultipartOptions() MultipartOptions {
+	return MultipartOptions{
+		PartSize:     DefaultMultipartPartSize,
+		MaxRetries:   defaultMultipartMaxRetries,
+		RetryBackoff: defaultMultipartRetryBackoff,
+	}
+}
+
+// Normalize 规范化分片上传参数（未设置或越界的字段使用默认值/边界值）
+func (o MultipartOptions) Normalize() MultipartOptions {
+	if o.PartSize <= 0 {
+		o.PartSize = DefaultMultipartPartSize
+	}
+	if o.PartSize < MinMultipartPartSize {
+		o.PartSize = MinMultipartPartSize
+	}
+	if o.PartSize > MaxMultipartPartSize {
+		o.PartSize = MaxMultipartPartSize
+	}
+	if o.MaxRetries < 0 {
+		o.MaxRetries = 0
+	}
+	if o.RetryBackoff <= 0 {
+		o.RetryBackoff = defaultMultipartRetryBackoff
+	}
+	return o
+}
+
+// ReadPart 从 reader 中读取一个分片到 buf
+// 返回读取的字节数，以及是否已读到末尾（last=true 表示这是最后一个分片）
+func ReadPart(r io.Reader, buf []byte) (n int, last bool, err error) {
+	n, err = io.ReadFull(r, buf)
+	switch err {
+	case nil:
+		return n, false, nil
+	case io.EOF, io.ErrUnexpectedEOF:
+		return n, true, nil
+	default:
+		return n, false, fmt.Errorf("read part: %w", err)
+	}
+}
+
+// RetryPart 执行单个分片的上传，失败时按指数退避重试
+// ctx 被取消时立即返回
+func RetryPart(ctx context.Context, opts MultipartOptions, partNumber int, upload func() error) error {
+	backoff := opts.RetryBackoff
+	var lastErr error
+	for attempt := 0; attempt <= opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		if lastErr = upload(); lastErr == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("upload part %d failed after %d attempts: %w", partNumber, opts.MaxRetries+1, lastErr)
+}
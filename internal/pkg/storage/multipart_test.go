@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMultipartOptions_Normalize(t *testing.T) {
+	tests := []struct {
+		name string
+		opts MultipartOptions
+		want int64
+	}{
+		{name: "zero uses default", opts: MultipartOptions{}, want: DefaultMultipartPartSize},
+		{name: "too small", opts: MultipartOptions{PartSize: 1024}, want: MinMultipartPartSize},
+		{name: "too large", opts: MultipartOptions{PartSize: 1 << 30}, want: MaxMultipartPartSize},
+		{name: "in range", opts: MultipartOptions{PartSize: 16 << 20}, want: 16 << 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.opts.Normalize()
+			if got.PartSize != tt.want {
+				t.Errorf("Normalize() PartSize = %d, want %d", got.PartSize, tt.want)
+			}
+			if got.RetryBackoff <= 0 {
+				t.Errorf("Normalize() RetryBackoff = %v, want > 0", got.RetryBackoff)
+			}
+		})
+	}
+}
+
+func TestReadPart(t *testing.T) {
+	r := strings.NewReader("abcdefghij")
+	buf := make([]byte, 4)
+
+	var parts []string
+	for {
+		n, last, err := ReadPart(r, buf)
+		if err != nil {
+			t.Fatalf("ReadPart() error = %v", err)
+		}
+		if n > 0 {
+			parts = append(parts, string(buf[:n]))
+		}
+		if last {
+			break
+		}
+	}
+
+	want := []string{"abcd", "efgh", "ij"}
+	if strings.Join(parts, ",") != strings.Join(want, ",") {
+		t.Errorf("ReadPart() parts = %v, want %v", parts, want)
+	}
+}
+
+func TestRetryPart(t *testing.T) {
+	opts := MultipartOptions{MaxRetries: 2, RetryBackoff: time.Millisecond}
+
+	t.Run("succeeds after transient failures", func(t *testing.T) {
+		calls := 0
+		err := RetryPart(context.Background(), opts, 1, func() error {
+			calls++
+			if calls < 3 {
+				return errors.New("transient")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Errorf("RetryPart() error = %v, want nil", err)
+		}
+		if calls != 3 {
+			t.Errorf("RetryPart() calls = %d, want 3", calls)
+		}
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		calls := 0
+		err := RetryPart(context.Background(), opts, 2, func() error {
+			calls++
+			return errors.New("permanent")
+		})
+		if err == nil {
+			t.Errorf("RetryPart() expected error, got nil")
+		}
+		if calls != 3 {
+			t.Errorf("RetryPart() calls = %d, want 3", calls)
+		}
+	})
+
+	t.Run("stops when context canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := RetryPart(ctx, MultipartOptions{MaxRetries: 5, RetryBackoff: time.Hour}, 3, func() error {
+			return errors.New("transient")
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("RetryPart() error = %v, want context.Canceled", err)
+		}
+	})
+}
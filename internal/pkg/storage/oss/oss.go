@@ -1,6 +1,7 @@
 package oss
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -8,6 +9,7 @@ import (
 	"time"
 
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/rs/zerolog/log"
 
 	"lemon/internal/pkg/storage"
 )
@@ -56,6 +58,77 @@ func (s *OSSStorage) Upload(ctx context.Context, key string, data io.Reader, con
 	return url, nil
 }
 
+// UploadMultipart 分片上传文件（服务端上传大文件）
+// 按 opts.PartSize 逐片读取并上传，单个分片失败时按策略重试；任一分片最终失败则中止本次分片上传
+// 数据不超过一个分片时直接一次请求上传；内存占用不超过一个分片
+func (s *OSSStorage) UploadMultipart(ctx context.Context, key string, data io.Reader, contentType string, opts storage.MultipartOptions) (string, error) {
+	opts = opts.Normalize()
+
+	buf := make([]byte, opts.PartSize)
+	n, last, err := storage.ReadPart(data, buf)
+	if err != nil {
+		return "", err
+	}
+	if last {
+		return s.Upload(ctx, key, bytes.NewReader(buf[:n]), contentType)
+	}
+
+	imur, err := s.bucket.InitiateMultipartUpload(key, oss.ContentType(contentType), oss.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("failed to initiate multipart upload: %w", err)
+	}
+
+	var parts []oss.UploadPart
+	for partNumber := 1; ; partNumber++ {
+		if partNumber > 1 {
+			if n, last, err = storage.ReadPart(data, buf); err != nil {
+				s.abortMultipartUpload(imur)
+				return "", err
+			}
+		}
+
+		// 数据恰好在分片边界结束
+		if n == 0 {
+			break
+		}
+
+		var part oss.UploadPart
+		err = storage.RetryPart(ctx, opts, partNumber, func() error {
+			p, err := s.bucket.UploadPart(imur, bytes.NewReader(buf[:n]), int64(n), partNumber, oss.WithContext(ctx))
+			if err != nil {
+				log.Warn().Err(err).Str("key", key).Int("part_number", partNumber).Msg("分片上传失败")
+				return err
+			}
+			part = p
+			return nil
+		})
+		if err != nil {
+			s.abortMultipartUpload(imur)
+			return "", fmt.Errorf("failed to upload part: %w", err)
+		}
+		parts = append(parts, part)
+
+		if last {
+			break
+		}
+	}
+
+	if _, err := s.bucket.CompleteMultipartUpload(imur, parts, oss.WithContext(ctx)); err != nil {
+		s.abortMultipartUpload(imur)
+		return "", fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+
+	url := fmt.Sprintf("https://%s.%s/%s", s.bucketName, s.bucket.Client.Config.Endpoint, key)
+	return url, nil
+}
+
+// abortMultipartUpload 中止分片上传，释放已上传的分片
+func (s *OSSStorage) abortMultipartUpload(imur oss.InitiateMultipartUploadResult) {
+	if err := s.bucket.AbortMultipartUpload(imur); err != nil {
+		log.Warn().Err(err).Str("key", imur.Key).Str("upload_id", imur.UploadID).Msg("中止分片上传失败")
+	}
+}
+
 // Download 下载文件
 func (s *OSSStorage) Download(ctx context.Context, key string) (io.ReadCloser, error) {
 	body, err := s.bucket.GetObject(key)
@@ -65,6 +138,19 @@ func (s *OSSStorage) Download(ctx context.Context, key string) (io.ReadCloser, e
 	return body, nil
 }
 
+// DownloadRange 按区间下载文件（OSS Range 请求）
+func (s *OSSStorage) DownloadRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	rangeSpec := fmt.Sprintf("%d-", offset)
+	if length > 0 {
+		rangeSpec = fmt.Sprintf("%d-%d", offset, offset+length-1)
+	}
+	body, err := s.bucket.GetObject(key, oss.NormalizedRange(rangeSpec))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file range %s: %w", rangeSpec, err)
+	}
+	return body, nil
+}
+
 // GetPresignedUploadURL 获取预签名上传URL（客户端直传）
 func (s *OSSStorage) GetPresignedUploadURL(ctx context.Context, key string, contentType string, expiresIn time.Duration) (string, error) {
 	// 如果配置的过期时间大于请求的过期时间，使用配置的过期时间
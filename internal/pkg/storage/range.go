@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidRange Range 请求头格式不合法或包含多个区间（按 RFC 9110 应忽略 Range 并返回完整内容）
+	ErrInvalidRange = errors.New("invalid range")
+	// ErrRangeNotSatisfiable 请求的区间超出文件大小
+	ErrRangeNotSatisfiable = errors.New("range not satisfiable")
+)
+
+// DefaultDownloadChunkSize 下载校验清单的默认分块大小
+const DefaultDownloadChunkSize int64 = 8 << 20
+
+// RangeDownloader 按区间下载接口（可选能力）
+// 用于断点续传：只读取文件的一部分，不需要从头传输整个文件
+type RangeDownloader interface {
+	// DownloadRange 从 offset 开始读取 length 个字节，length <= 0 表示读取到文件末尾
+	DownloadRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+}
+
+// ByteRange 闭区间 [Start, End] 的字节范围
+type ByteRange struct {
+	Start int64
+	End   int64
+}
+
+// Length 区间的字节数
+func (r ByteRange) Length() int64 {
+	return r.End - r.Start + 1
+}
+
+// ContentRange 返回 Content-Range 响应头的值
+func (r ByteRange) ContentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.Start, r.End, size)
+}
+
+// ParseRange 解析 Range 请求头（只支持单个区间：bytes=a-b、bytes=a-、bytes=-n）
+// 请求头为空时返回 nil；超出文件大小时返回 ErrRangeNotSatisfiable，其他无法处理的格式返回 ErrInvalidRange
+func ParseRange(header string, size int64) (*ByteRange, error) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return nil, nil
+	}
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRange, header)
+	}
+	startStr, endStr, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRange, header)
+	}
+
+	// 后缀区间：最后 n 个字节
+	if startStr == "" {
+		n, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRange, header)
+		}
+		if n == 0 || size == 0 {
+			return nil, fmt.Errorf("%w: %q", ErrRangeNotSatisfiable, header)
+		}
+		return &ByteRange{Start: max(size-n, 0), End: size - 1}, nil
+	}
+
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil || start < 0 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRange, header)
+	}
+	end := size - 1
+	if endStr != "" {
+		if end, err = strconv.ParseInt(endStr, 10, 64); err != nil || end < start {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRange, header)
+		}
+		end = min(end, size-1)
+	}
+	if start >= size {
+		return nil, fmt.Errorf("%w: %q", ErrRangeNotSatisfiable, header)
+	}
+	return &ByteRange{Start: start, End: end}, nil
+}
+
+// OpenRange 读取文件的一个区间：存储实现了 RangeDownloader 时直接按区间下载，
+// 否则下载完整文件并跳过 offset 之前的内容
+func OpenRange(ctx context.Context, s Storage, key string, offset, length int64) (io.ReadCloser, error) {
+	if d, ok := s.(RangeDownloader); ok {
+		return d.DownloadRange(ctx, key, offset, length)
+	}
+	rc, err := s.Download(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return LimitReadCloser(rc, offset, length)
+}
+
+// LimitReadCloser 跳过 rc 的前 offset 个字节，并最多读取 length 个字节（length <= 0 表示不限制）
+// rc 实现了 io.Seeker 时直接定位，否则读取并丢弃
+func LimitReadCloser(rc io.ReadCloser, offset, length int64) (io.ReadCloser, error) {
+	if offset > 0 {
+		var err error
+		if seeker, ok := rc.(io.Seeker); ok {
+			_, err = seeker.Seek(offset, io.SeekStart)
+		} else {
+			_, err = io.CopyN(io.Discard, rc, offset)
+		}
+		if err != nil {
+			rc.Close()
+			return nil, fmt.Errorf("skip to offset %d: %w", offset, err)
+		}
+	}
+	if length <= 0 {
+		return rc, nil
+	}
+	return &limitedReadCloser{Reader: io.LimitReader(rc, length), Closer: rc}, nil
+}
+
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// ChunkChecksums 按 chunkSize 分块计算 SHA256，同时返回整个文件的 SHA256 和大小
+// 用于生成下载校验清单：客户端续传前按分块校验已下载的部分，只保留校验通过的分块
+func ChunkChecksums(r io.Reader, chunkSize int64) (chunks []string, fileSHA256 string, size int64, err error) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultDownloadChunkSize
+	}
+	whole := sha256.New()
+	for {
+		chunk := sha256.New()
+		n, err := io.CopyN(io.MultiWriter(chunk, whole), r, chunkSize)
+		if n > 0 {
+			chunks = append(chunks, hex.EncodeToString(chunk.Sum(nil)))
+			size += n
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, "", 0, fmt.Errorf("read chunk %d: %w", len(chunks), err)
+		}
+	}
+	return chunks, hex.EncodeToString(whole.Sum(nil)), size, nil
+}
@@ -0,0 +1,640 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Replicator 主备存储复制接口（配置了备用存储时由 ReplicatedStorage 实现）
+type Replicator interface {
+	// Replicate 把文件异步复制到另一个存储（用于客户端直传完成后）
+	Replicate(key string)
+
+	// Repair 对账修复单个文件：deleted 为 true 时从两个存储中删除，否则把只存在于一侧或大小不一致的文件复制到另一侧
+	// 返回是否做了修复
+	Repair(ctx context.Context, key string, deleted bool) (bool, error)
+
+	// PendingRepairs 复制失败或队列溢出、等待对账修复的文件
+	PendingRepairs() []PendingRepair
+
+	// MarkReconciled 记录一次对账完成
+	MarkReconciled(at time.Time)
+
+	// ReplicationStatus 复制状态（健康检查中展示）
+	ReplicationStatus() ReplicationStatus
+}
+
+// PendingRepair 等待对账修复的文件
+type PendingRepair struct {
+	Key     string
+	Deleted bool // 文件已删除，需要从两个存储中删除
+}
+
+// ReplicationStatus 主备复制状态
+type ReplicationStatus struct {
+	PrimaryType      string     `json:"primary_type"`
+	SecondaryType    string     `json:"secondary_type"`
+	PrimaryAvailable bool       `json:"primary_available"`           // 主存储是否可用（出错后的冷却期内视为不可用）
+	Pending          int        `json:"pending"`                     // 排队或重试中的复制任务
+	Unrepaired       int        `json:"unrepaired"`                  // 等待对账修复的文件
+	LagSeconds       float64    `json:"lag_seconds"`                 // 最早未完成的复制任务已等待的时间（秒）
+	Replicated       int64      `json:"replicated"`                  // 已完成的复制任务
+	Failed           int64      `json:"failed"`                      // 重试后仍失败的复制任务
+	Repaired         int64      `json:"repaired"`                    // 对账修复的文件
+	LastError        string     `json:"last_error,omitempty"`        // 最近一次复制或主存储错误
+	LastReconcileAt  *time.Time `json:"last_reconcile_at,omitempty"` // 最近一次对账完成时间
+}
+
+const (
+	defaultReplicationQueueSize    = 1024
+	defaultReplicationWorkers      = 2
+	defaultReplicationMaxRetries   = 3
+	defaultReplicationRetryBackoff = time.Second
+	defaultFailoverCooldown        = 30 * time.Second
+)
+
+// ReplicationOptions 主备复制参数
+type ReplicationOptions struct {
+	QueueSize        int           // 异步复制队列长度，队列满时记为待修复，由对账任务补齐
+	Workers          int           // 复制并发数
+	MaxRetries       int           // 单个复制任务的最大重试次数（不含首次）
+	RetryBackoff     time.Duration // 重试初始退避时间（每次重试翻倍）
+	FailoverCooldown time.Duration // 主存储出错后多久内直接使用备用存储
+}
+
+// Normalize 规范化复制参数（未设置的字段使用默认值）
+func (o ReplicationOptions) Normalize() ReplicationOptions {
+	if o.QueueSize <= 0 {
+		o.QueueSize = defaultReplicationQueueSize
+	}
+	if o.Workers <= 0 {
+		o.Workers = defaultReplicationWorkers
+	}
+	if o.MaxRetries <= 0 {
+		o.MaxRetries = defaultReplicationMaxRetries
+	}
+	if o.RetryBackoff <= 0 {
+		o.RetryBackoff = defaultReplicationRetryBackoff
+	}
+	if o.FailoverCooldown <= 0 {
+		o.FailoverCooldown = defaultFailoverCooldown
+	}
+	return o
+}
+
+// replicaTarget 复制任务的目标存储
+type replicaTarget int
+
+const (
+	toSecondary replicaTarget = iota
+	toPrimary
+)
+
+// replicationTask 复制任务
+type replicationTask struct {
+	key        string
+	target     replicaTarget
+	delete     bool // 删除目标存储中的文件（否则从另一侧复制）
+	enqueuedAt time.Time
+}
+
+func (t replicationTask) id() string {
+	return fmt.Sprintf("%d:%s", t.target, t.key)
+}
+
+// ReplicatedStorage 带备用存储的存储
+// 写入主存储成功后异步复制到备用存储；主存储写入失败时切换到备用存储，之后异步复制回主存储；
+// 读取时主存储失败（或文件只在备用存储中）回退到备用存储。复制失败的文件由对账任务（Repair）修复
+type ReplicatedStorage struct {
+	primary   Storage
+	secondary Storage
+	opts      ReplicationOptions
+
+	queue  chan replicationTask
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu               sync.Mutex
+	pending          map[string]time.Time       // 排队或重试中的任务（task id -> 入队时间）
+	unrepaired       map[string]replicationTask // 等待对账修复的任务（task id -> 任务）
+	secondaryOnly    map[string]bool            // 只写入了备用存储、尚未复制回主存储的文件
+	primaryDownUntil time.Time
+	replicated       int64
+	failed           int64
+	repaired         int64
+	lastError        string
+	lastReconcileAt  *time.Time
+}
+
+// NewReplicatedStorage 创建带备用存储的存储并启动复制任务
+func NewReplicatedStorage(primary, secondary Storage, opts ReplicationOptions) *ReplicatedStorage {
+	opts = opts.Normalize()
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &ReplicatedStorage{
+		primary:       primary,
+		secondary:     secondary,
+		opts:          opts,
+		queue:         make(chan replicationTask, opts.QueueSize),
+		ctx:           ctx,
+		cancel:        cancel,
+		pending:       make(map[string]time.Time),
+		unrepaired:    make(map[string]replicationTask),
+		secondaryOnly: make(map[string]bool),
+	}
+	for i := 0; i < opts.Workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+	return s
+}
+
+// Close 停止复制任务，未完成的任务留给下次启动后的对账
+func (s *ReplicatedStorage) Close() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// Upload 上传文件：优先写主存储，失败时写备用存储
+func (s *ReplicatedStorage) Upload(ctx context.Context, key string, data io.Reader, contentType string) (string, error) {
+	return s.upload(ctx, key, data, contentType, nil)
+}
+
+// UploadMultipart 分片上传文件（存储不支持分片上传时退化为普通上传）
+func (s *ReplicatedStorage) UploadMultipart(ctx context.Context, key string, data io.Reader, contentType string, opts MultipartOptions) (string, error) {
+	return s.upload(ctx, key, data, contentType, &opts)
+}
+
+func (s *ReplicatedStorage) upload(ctx context.Context, key string, data io.Reader, contentType string, multipart *MultipartOptions) (string, error) {
+	if !s.primaryAvailable() {
+		return s.failoverUpload(ctx, key, data, contentType, multipart, errors.New("primary storage unavailable"))
+	}
+
+	// 主存储失败后要用同一份数据重写备用存储，不能回退的 reader 先落到临时文件
+	body, rewind, cleanup, err := rewindable(data)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+
+	url, err := uploadTo(ctx, s.primary, key, body, contentType, multipart)
+	if err == nil {
+		s.clearSecondaryOnly(key)
+		s.enqueue(replicationTask{key: key, target: toSecondary})
+		return url, nil
+	}
+	if ctx.Err() != nil {
+		return "", err
+	}
+	s.markPrimaryFailure(err)
+	if rerr := rewind(); rerr != nil {
+		return "", fmt.Errorf("primary upload failed: %w; rewind for failover: %v", err, rerr)
+	}
+	return s.failoverUpload(ctx, key, body, contentType, multipart, err)
+}
+
+// failoverUpload 写入备用存储，之后异步复制回主存储
+func (s *ReplicatedStorage) failoverUpload(ctx context.Context, key string, data io.Reader, contentType string, multipart *MultipartOptions, primaryErr error) (string, error) {
+	url, err := uploadTo(ctx, s.secondary, key, data, contentType, multipart)
+	if err != nil {
+		return "", fmt.Errorf("upload failed on both storages: primary: %v; secondary: %w", primaryErr, err)
+	}
+	log.Warn().Err(primaryErr).Str("key", key).Msg("主存储写入失败，已写入备用存储")
+
+	s.mu.Lock()
+	s.secondaryOnly[key] = true
+	s.mu.Unlock()
+	s.enqueue(replicationTask{key: key, target: toPrimary})
+	return url, nil
+}
+
+// uploadTo 上传到指定存储，指定分片参数且存储支持分片上传时使用分片上传
+func uploadTo(ctx context.Context, st Storage, key string, data io.Reader, contentType string, multipart *MultipartOptions) (string, error) {
+	if multipart != nil {
+		if uploader, ok := st.(MultipartUploader); ok {
+			return uploader.UploadMultipart(ctx, key, data, contentType, *multipart)
+		}
+	}
+	return st.Upload(ctx, key, data, contentType)
+}
+
+// rewindable 返回可以回到起点重新读取的 reader
+// data 实现了 io.Seeker 时直接定位，否则复制到临时文件
+func rewindable(data io.Reader) (io.Reader, func() error, func(), error) {
+	if rs, ok := data.(io.ReadSeeker); ok {
+		if start, err := rs.Seek(0, io.SeekCurrent); err == nil {
+			rewind := func() error {
+				_, err := rs.Seek(start, io.SeekStart)
+				return err
+			}
+			return rs, rewind, func() {}, nil
+		}
+	}
+
+	f, err := os.CreateTemp("", "replica_spool_")
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("create spool file: %w", err)
+	}
+	cleanup := func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+	if _, err := io.Copy(f, data); err != nil {
+		cleanup()
+		return nil, nil, nil, fmt.Errorf("spool upload data: %w", err)
+	}
+	rewind := func() error {
+		_, err := f.Seek(0, io.SeekStart)
+		return err
+	}
+	if err := rewind(); err != nil {
+		cleanup()
+		return nil, nil, nil, fmt.Errorf("rewind spool file: %w", err)
+	}
+	return f, rewind, cleanup, nil
+}
+
+// Download 下载文件，主存储失败时回退到备用存储
+func (s *ReplicatedStorage) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	return readWithFallback(s, key, func(st Storage) (io.ReadCloser, error) {
+		return st.Download(ctx, key)
+	})
+}
+
+// DownloadRange 按区间下载文件，主存储失败时回退到备用存储
+func (s *ReplicatedStorage) DownloadRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	return readWithFallback(s, key, func(st Storage) (io.ReadCloser, error) {
+		return OpenRange(ctx, st, key, offset, length)
+	})
+}
+
+// GetPresignedUploadURL 获取预签名上传URL（主存储不可用时使用备用存储）
+// 客户端上传完成后需要调用 Replicate 复制到另一个存储
+func (s *ReplicatedStorage) GetPresignedUploadURL(ctx context.Context, key string, contentType string, expiresIn time.Duration) (string, error) {
+	if s.primaryAvailable() {
+		return s.primary.GetPresignedUploadURL(ctx, key, contentType, expiresIn)
+	}
+	url, err := s.secondary.GetPresignedUploadURL(ctx, key, contentType, expiresIn)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	s.secondaryOnly[key] = true
+	s.mu.Unlock()
+	return url, nil
+}
+
+// GetPresignedDownloadURL 获取预签名下载URL（文件只在备用存储中或主存储不可用时使用备用存储）
+func (s *ReplicatedStorage) GetPresignedDownloadURL(ctx context.Context, key string, expiresIn time.Duration) (string, error) {
+	return readWithFallback(s, key, func(st Storage) (string, error) {
+		return st.GetPresignedDownloadURL(ctx, key, expiresIn)
+	})
+}
+
+// Delete 从两个存储中删除文件，一侧失败时记为待修复，两侧都失败时返回错误
+func (s *ReplicatedStorage) Delete(ctx context.Context, key string) error {
+	primaryErr := s.primary.Delete(ctx, key)
+	secondaryErr := s.secondary.Delete(ctx, key)
+	if primaryErr != nil && secondaryErr != nil {
+		return fmt.Errorf("delete failed on both storages: primary: %v; secondary: %w", primaryErr, secondaryErr)
+	}
+
+	s.clearSecondaryOnly(key)
+	if primaryErr != nil {
+		s.markPrimaryFailure(primaryErr)
+		s.enqueue(replicationTask{key: key, target: toPrimary, delete: true})
+	}
+	if secondaryErr != nil {
+		s.recordError(secondaryErr)
+		s.enqueue(replicationTask{key: key, target: toSecondary, delete: true})
+	}
+	return nil
+}
+
+// Exists 检查文件是否存在（任一存储中存在即为存在）
+func (s *ReplicatedStorage) Exists(ctx context.Context, key string) (bool, error) {
+	first, second := s.readOrder(key)
+	exists, err := first.Exists(ctx, key)
+	if err == nil && exists {
+		return true, nil
+	}
+	if exists2, err2 := second.Exists(ctx, key); err2 == nil {
+		return exists2, nil
+	}
+	return exists, err
+}
+
+// GetFileInfo 获取文件信息，主存储失败时回退到备用存储
+func (s *ReplicatedStorage) GetFileInfo(ctx context.Context, key string) (*FileInfo, error) {
+	return readWithFallback(s, key, func(st Storage) (*FileInfo, error) {
+		return st.GetFileInfo(ctx, key)
+	})
+}
+
+// GetStorageType 获取存储类型（主存储的类型）
+func (s *ReplicatedStorage) GetStorageType() string {
+	return s.primary.GetStorageType()
+}
+
+// readOrder 读取顺序：文件只在备用存储中或主存储不可用时先读备用存储
+func (s *ReplicatedStorage) readOrder(key string) (first, second Storage) {
+	s.mu.Lock()
+	preferSecondary := s.secondaryOnly[key] || time.Now().Before(s.primaryDownUntil)
+	s.mu.Unlock()
+	if preferSecondary {
+		return s.secondary, s.primary
+	}
+	return s.primary, s.secondary
+}
+
+// readWithFallback 按读取顺序读取，第一个存储失败时读另一个，都失败时返回第一个错误
+func readWithFallback[T any](s *ReplicatedStorage, key string, read func(Storage) (T, error)) (T, error) {
+	first, second := s.readOrder(key)
+	v, err := read(first)
+	if err == nil {
+		return v, nil
+	}
+	if v2, err2 := read(second); err2 == nil {
+		return v2, nil
+	}
+	return v, err
+}
+
+func (s *ReplicatedStorage) primaryAvailable() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !time.Now().Before(s.primaryDownUntil)
+}
+
+// markPrimaryFailure 主存储出错，冷却期内读写直接使用备用存储
+func (s *ReplicatedStorage) markPrimaryFailure(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.primaryDownUntil = time.Now().Add(s.opts.FailoverCooldown)
+	s.lastError = err.Error()
+}
+
+func (s *ReplicatedStorage) recordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastError = err.Error()
+}
+
+func (s *ReplicatedStorage) clearSecondaryOnly(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.secondaryOnly, key)
+}
+
+// Replicate 把文件异步复制到另一个存储
+func (s *ReplicatedStorage) Replicate(key string) {
+	s.mu.Lock()
+	target := toSecondary
+	if s.secondaryOnly[key] {
+		target = toPrimary
+	}
+	s.mu.Unlock()
+	s.enqueue(replicationTask{key: key, target: target})
+}
+
+// enqueue 加入复制队列（同一文件同一方向的任务未完成时不重复入队），队列满或已关闭时记为待修复
+func (s *ReplicatedStorage) enqueue(task replicationTask) {
+	task.enqueuedAt = time.Now()
+	s.mu.Lock()
+	if _, ok := s.pending[task.id()]; ok {
+		s.mu.Unlock()
+		return
+	}
+	s.pending[task.id()] = task.enqueuedAt
+	s.mu.Unlock()
+
+	if s.ctx.Err() == nil {
+		select {
+		case s.queue <- task:
+			return
+		default:
+		}
+	}
+	log.Warn().Str("key", task.key).Msg("复制队列已满，等待对账修复")
+	s.finish(task, errors.New("replication queue full"))
+}
+
+func (s *ReplicatedStorage) worker() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case task := <-s.queue:
+			s.finish(task, s.runWithRetry(task))
+		}
+	}
+}
+
+// runWithRetry 执行复制任务，失败时按指数退避重试
+func (s *ReplicatedStorage) runWithRetry(task replicationTask) error {
+	backoff := s.opts.RetryBackoff
+	var err error
+	for attempt := 0; attempt <= s.opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-s.ctx.Done():
+				return s.ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		if err = s.run(s.ctx, task); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// run 执行一次复制任务
+func (s *ReplicatedStorage) run(ctx context.Context, task replicationTask) error {
+	src, dst := s.primary, s.secondary
+	if task.target == toPrimary {
+		src, dst = s.secondary, s.primary
+	}
+	if task.delete {
+		return dst.Delete(ctx, task.key)
+	}
+	return copyObject(ctx, src, dst, task.key)
+}
+
+// copyObject 把文件从 src 复制到 dst
+func copyObject(ctx context.Context, src, dst Storage, key string) error {
+	info, err := src.GetFileInfo(ctx, key)
+	if err != nil {
+		return fmt.Errorf("stat source %s: %w", key, err)
+	}
+	rc, err := src.Download(ctx, key)
+	if err != nil {
+		return fmt.Errorf("download source %s: %w", key, err)
+	}
+	defer rc.Close()
+	if _, err := dst.Upload(ctx, key, rc, info.ContentType); err != nil {
+		return fmt.Errorf("upload replica %s: %w", key, err)
+	}
+	return nil
+}
+
+// finish 记录任务结果：成功时清除待修复标记，失败时记为待修复
+func (s *ReplicatedStorage) finish(task replicationTask, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, task.id())
+	if err != nil {
+		s.failed++
+		s.lastError = err.Error()
+		s.unrepaired[task.id()] = task
+		return
+	}
+	s.replicated++
+	delete(s.unrepaired, task.id())
+	if task.target == toPrimary && !task.delete {
+		delete(s.secondaryOnly, task.key)
+	}
+}
+
+// Repair 对账修复单个文件
+// 只在一侧存在的文件复制到另一侧；两侧都存在但大小不一致时以主存储为准（文件只写入了备用存储时以备用存储为准）
+func (s *ReplicatedStorage) Repair(ctx context.Context, key string, deleted bool) (bool, error) {
+	inPrimary, err := s.primary.Exists(ctx, key)
+	if err != nil {
+		return false, fmt.Errorf("check primary: %w", err)
+	}
+	inSecondary, err := s.secondary.Exists(ctx, key)
+	if err != nil {
+		return false, fmt.Errorf("check secondary: %w", err)
+	}
+
+	repaired := false
+	switch {
+	case deleted:
+		for _, target := range []struct {
+			exists bool
+			st     Storage
+		}{{inPrimary, s.primary}, {inSecondary, s.secondary}} {
+			if !target.exists {
+				continue
+			}
+			if err := target.st.Delete(ctx, key); err != nil {
+				return repaired, fmt.Errorf("delete %s: %w", key, err)
+			}
+			repaired = true
+		}
+	case inPrimary && !inSecondary:
+		if err := copyObject(ctx, s.primary, s.secondary, key); err != nil {
+			return false, err
+		}
+		repaired = true
+	case !inPrimary && inSecondary:
+		if err := copyObject(ctx, s.secondary, s.primary, key); err != nil {
+			return false, err
+		}
+		repaired = true
+	case inPrimary && inSecondary:
+		primaryInfo, err := s.primary.GetFileInfo(ctx, key)
+		if err != nil {
+			return false, fmt.Errorf("stat primary: %w", err)
+		}
+		secondaryInfo, err := s.secondary.GetFileInfo(ctx, key)
+		if err != nil {
+			return false, fmt.Errorf("stat secondary: %w", err)
+		}
+		if primaryInfo.Size != secondaryInfo.Size {
+			src, dst := s.primary, s.secondary
+			s.mu.Lock()
+			if s.secondaryOnly[key] {
+				src, dst = s.secondary, s.primary
+			}
+			s.mu.Unlock()
+			if err := copyObject(ctx, src, dst, key); err != nil {
+				return false, err
+			}
+			repaired = true
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, target := range []replicaTarget{toSecondary, toPrimary} {
+		delete(s.unrepaired, replicationTask{key: key, target: target}.id())
+	}
+	delete(s.secondaryOnly, key)
+	if repaired {
+		s.repaired++
+	}
+	return repaired, nil
+}
+
+// PendingRepairs 等待对账修复的文件（按 key 排序）
+func (s *ReplicatedStorage) PendingRepairs() []PendingRepair {
+	s.mu.Lock()
+	seen := make(map[string]bool, len(s.unrepaired))
+	repairs := make([]PendingRepair, 0, len(s.unrepaired))
+	for _, task := range s.unrepaired {
+		if seen[task.key] {
+			continue
+		}
+		seen[task.key] = true
+		repairs = append(repairs, PendingRepair{Key: task.key, Deleted: task.delete})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(repairs, func(i, j int) bool { return repairs[i].Key < repairs[j].Key })
+	return repairs
+}
+
+// MarkReconciled 记录一次对账完成
+func (s *ReplicatedStorage) MarkReconciled(at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastReconcileAt = &at
+}
+
+// ReplicationStatus 复制状态
+func (s *ReplicatedStorage) ReplicationStatus() ReplicationStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	status := ReplicationStatus{
+		PrimaryType:      s.primary.GetStorageType(),
+		SecondaryType:    s.secondary.GetStorageType(),
+		PrimaryAvailable: !now.Before(s.primaryDownUntil),
+		Pending:          len(s.pending),
+		Unrepaired:       len(s.unrepaired),
+		Replicated:       s.replicated,
+		Failed:           s.failed,
+		Repaired:         s.repaired,
+		LastError:        s.lastError,
+		LastReconcileAt:  s.lastReconcileAt,
+	}
+	// 复制延迟取最早未完成任务（含等待对账修复的任务）的等待时间
+	var oldest time.Time
+	for _, at := range s.pending {
+		if oldest.IsZero() || at.Before(oldest) {
+			oldest = at
+		}
+	}
+	for _, task := range s.unrepaired {
+		if oldest.IsZero() || task.enqueuedAt.Before(oldest) {
+			oldest = task.enqueuedAt
+		}
+	}
+	if !oldest.IsZero() {
+		status.LagSeconds = now.Sub(oldest).Seconds()
+	}
+	return status
+}
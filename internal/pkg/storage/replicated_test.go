@@ -0,0 +1,260 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errStorageDown = errors.New("storage down")
+
+// memStorage 内存存储（测试用），down 为 true 时所有操作失败
+type memStorage struct {
+	mu    sync.Mutex
+	name  string
+	files map[string][]byte
+	down  bool
+}
+
+func newMemStorage(name string) *memStorage {
+	return &memStorage{name: name, files: make(map[string][]byte)}
+}
+
+func (m *memStorage) setDown(down bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.down = down
+}
+
+func (m *memStorage) get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.files[key]
+	return data, ok
+}
+
+func (m *memStorage) Upload(ctx context.Context, key string, data io.Reader, contentType string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.down {
+		// 模拟上传到一半失败：读取部分数据
+		_, _ = io.CopyN(io.Discard, data, 3)
+		return "", errStorageDown
+	}
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return "", err
+	}
+	m.files[key] = b
+	return m.name + "/" + key, nil
+}
+
+func (m *memStorage) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.down {
+		return nil, errStorageDown
+	}
+	b, ok := m.files[key]
+	if !ok {
+		return nil, fmt.Errorf("file not found: %s", key)
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (m *memStorage) GetPresignedUploadURL(ctx context.Context, key string, contentType string, expiresIn time.Duration) (string, error) {
+	return m.name + "/upload/" + key, nil
+}
+
+func (m *memStorage) GetPresignedDownloadURL(ctx context.Context, key string, expiresIn time.Duration) (string, error) {
+	return m.name + "/" + key, nil
+}
+
+func (m *memStorage) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.down {
+		return errStorageDown
+	}
+	delete(m.files, key)
+	return nil
+}
+
+func (m *memStorage) Exists(ctx context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.down {
+		return false, errStorageDown
+	}
+	_, ok := m.files[key]
+	return ok, nil
+}
+
+func (m *memStorage) GetFileInfo(ctx context.Context, key string) (*FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.down {
+		return nil, errStorageDown
+	}
+	b, ok := m.files[key]
+	if !ok {
+		return nil, fmt.Errorf("file not found: %s", key)
+	}
+	return &FileInfo{Key: key, Size: int64(len(b)), ContentType: "text/plain"}, nil
+}
+
+func (m *memStorage) GetStorageType() string {
+	return m.name
+}
+
+// waitReplicated 等待复制队列清空
+func waitReplicated(t *testing.T, s *ReplicatedStorage) ReplicationStatus {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		status := s.ReplicationStatus()
+		if status.Pending == 0 {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("replication not finished: %+v", status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func newTestReplicatedStorage() (*ReplicatedStorage, *memStorage, *memStorage) {
+	primary, secondary := newMemStorage("primary"), newMemStorage("secondary")
+	s := NewReplicatedStorage(primary, secondary, ReplicationOptions{
+		MaxRetries:       1,
+		RetryBackoff:     time.Millisecond,
+		FailoverCooldown: time.Hour,
+	})
+	return s, primary, secondary
+}
+
+func TestReplicatedStorage_ReplicatesToSecondary(t *testing.T) {
+	s, _, secondary := newTestReplicatedStorage()
+	defer s.Close()
+	ctx := context.Background()
+
+	url, err := s.Upload(ctx, "a.txt", strings.NewReader("hello"), "text/plain")
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if url != "primary/a.txt" {
+		t.Errorf("Upload() url = %q, want primary/a.txt", url)
+	}
+
+	status := waitReplicated(t, s)
+	if data, ok := secondary.get("a.txt"); !ok || string(data) != "hello" {
+		t.Errorf("secondary a.txt = %q, %v; want hello", data, ok)
+	}
+	if status.Replicated != 1 || status.Unrepaired != 0 || !status.PrimaryAvailable || status.LagSeconds != 0 {
+		t.Errorf("status = %+v", status)
+	}
+}
+
+func TestReplicatedStorage_FailoverAndRepair(t *testing.T) {
+	s, primary, secondary := newTestReplicatedStorage()
+	defer s.Close()
+	ctx := context.Background()
+	primary.setDown(true)
+
+	// 不能回退的 reader：主存储读取一部分后失败，备用存储仍需收到完整数据
+	data := io.MultiReader(strings.NewReader("hello "), strings.NewReader("world"))
+	url, err := s.Upload(ctx, "b.txt", data, "text/plain")
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if url != "secondary/b.txt" {
+		t.Errorf("Upload() url = %q, want secondary/b.txt", url)
+	}
+	if got, _ := secondary.get("b.txt"); string(got) != "hello world" {
+		t.Errorf("secondary b.txt = %q, want hello world", got)
+	}
+
+	// 读取回退到备用存储
+	rc, err := s.Download(ctx, "b.txt")
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	got, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(got) != "hello world" {
+		t.Errorf("Download() = %q, want hello world", got)
+	}
+	if u, _ := s.GetPresignedDownloadURL(ctx, "b.txt", time.Minute); u != "secondary/b.txt" {
+		t.Errorf("GetPresignedDownloadURL() = %q, want secondary/b.txt", u)
+	}
+
+	// 主存储不可用，复制回主存储失败后等待对账
+	status := waitReplicated(t, s)
+	if status.PrimaryAvailable || status.Unrepaired != 1 || status.LagSeconds <= 0 {
+		t.Fatalf("status = %+v", status)
+	}
+	if repairs := s.PendingRepairs(); len(repairs) != 1 || repairs[0] != (PendingRepair{Key: "b.txt"}) {
+		t.Fatalf("PendingRepairs() = %+v", repairs)
+	}
+
+	primary.setDown(false)
+	repaired, err := s.Repair(ctx, "b.txt", false)
+	if err != nil || !repaired {
+		t.Fatalf("Repair() = %v, %v; want true, nil", repaired, err)
+	}
+	if got, _ := primary.get("b.txt"); string(got) != "hello world" {
+		t.Errorf("primary b.txt = %q, want hello world", got)
+	}
+	status = s.ReplicationStatus()
+	if status.Unrepaired != 0 || status.Repaired != 1 {
+		t.Errorf("status after repair = %+v", status)
+	}
+
+	// 两侧一致时不需要修复
+	if repaired, err := s.Repair(ctx, "b.txt", false); err != nil || repaired {
+		t.Errorf("Repair() on consistent file = %v, %v; want false, nil", repaired, err)
+	}
+}
+
+func TestReplicatedStorage_DeleteAndRepair(t *testing.T) {
+	s, primary, secondary := newTestReplicatedStorage()
+	defer s.Close()
+	ctx := context.Background()
+
+	if _, err := s.Upload(ctx, "c.txt", strings.NewReader("bye"), "text/plain"); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	waitReplicated(t, s)
+
+	secondary.setDown(true)
+	if err := s.Delete(ctx, "c.txt"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, ok := primary.get("c.txt"); ok {
+		t.Error("primary c.txt still exists")
+	}
+	waitReplicated(t, s)
+	if repairs := s.PendingRepairs(); len(repairs) != 1 || repairs[0] != (PendingRepair{Key: "c.txt", Deleted: true}) {
+		t.Fatalf("PendingRepairs() = %+v", repairs)
+	}
+
+	secondary.setDown(false)
+	if repaired, err := s.Repair(ctx, "c.txt", true); err != nil || !repaired {
+		t.Fatalf("Repair() = %v, %v; want true, nil", repaired, err)
+	}
+	if _, ok := secondary.get("c.txt"); ok {
+		t.Error("secondary c.txt still exists")
+	}
+
+	primary.setDown(true)
+	secondary.setDown(true)
+	if err := s.Delete(ctx, "c.txt"); err == nil {
+		t.Error("Delete() with both storages down should fail")
+	}
+}
@@ -0,0 +1,505 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/pkg/storage"
+)
+
+// defaultRegion 未配置区域时使用（MinIO 默认区域）
+const defaultRegion = "us-east-1"
+
+// Config S3 兼容存储配置
+type Config struct {
+	Endpoint        string              // 服务端点，如 https://s3.us-east-1.amazonaws.com、http://minio:9000、https://oss-cn-hangzhou.aliyuncs.com（未带协议时使用 https）
+	Region          string              // 区域（参与签名），为空时使用 us-east-1
+	Bucket          string              // Bucket名称
+	AccessKeyID     string              // AccessKey ID
+	SecretAccessKey string              // Secret Access Key
+	SessionToken    string              // 临时凭证的 Session Token（可选）
+	UsePathStyle    bool                // 路径风格访问（endpoint/bucket/key），MinIO 需要开启；否则使用虚拟主机风格（bucket.endpoint/key）
+	PresignExpiry   int                 // 预签名URL最长过期时间（秒）
+	StorageType     storage.StorageType // 存储类型（s3 或 minio），为空时为 s3
+	HTTPClient      *http.Client        // 自定义 HTTP 客户端（可选）
+}
+
+// S3Storage S3 兼容存储（AWS S3、MinIO、阿里云 OSS 的 S3 兼容接口）
+// 直接调用 S3 REST 接口并使用 SigV4 签名
+type S3Storage struct {
+	endpoint      *url.URL
+	bucket        string
+	usePathStyle  bool
+	presignExpiry int // 预签名URL过期时间（秒）
+	storageType   storage.StorageType
+	signer        *signer
+	client        *http.Client
+	now           func() time.Time
+}
+
+// ResponseError S3 接口返回的错误
+type ResponseError struct {
+	StatusCode int    // HTTP 状态码
+	Code       string // S3 错误码，如 NoSuchKey、AccessDenied（HEAD 请求没有响应体，为空）
+	Message    string // 错误信息
+	RequestID  string // 请求ID（用于向服务商排查问题）
+}
+
+func (e *ResponseError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("s3 request failed with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("s3 request failed with status %d: %s: %s (request id: %s)", e.StatusCode, e.Code, e.Message, e.RequestID)
+}
+
+// NewS3Storage 创建 S3 兼容存储
+func NewS3Storage(cfg Config) (*S3Storage, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("bucket is required")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("access key id and secret access key are required")
+	}
+
+	region := cfg.Region
+	if region == "" {
+		region = defaultRegion
+	}
+	rawEndpoint := cfg.Endpoint
+	if rawEndpoint == "" {
+		rawEndpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+	if !strings.Contains(rawEndpoint, "://") {
+		rawEndpoint = "https://" + rawEndpoint
+	}
+	endpoint, err := url.Parse(rawEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %w", cfg.Endpoint, err)
+	}
+	if endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("invalid endpoint %q", cfg.Endpoint)
+	}
+	endpoint.Path, endpoint.RawPath, endpoint.RawQuery = "", "", ""
+
+	storageType := cfg.StorageType
+	if storageType == "" {
+		storageType = storage.StorageTypeS3
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &S3Storage{
+		endpoint:      endpoint,
+		bucket:        cfg.Bucket,
+		usePathStyle:  cfg.UsePathStyle,
+		presignExpiry: cfg.PresignExpiry,
+		storageType:   storageType,
+		signer: &signer{
+			accessKeyID:     cfg.AccessKeyID,
+			secretAccessKey: cfg.SecretAccessKey,
+			sessionToken:    cfg.SessionToken,
+			region:          region,
+		},
+		client: client,
+		now:    time.Now,
+	}, nil
+}
+
+// Upload 上传文件（服务端上传）
+// 数据读入内存后计算请求体签名，大文件应使用 UploadMultipart
+func (s *S3Storage) Upload(ctx context.Context, key string, data io.Reader, contentType string) (string, error) {
+	body, err := io.ReadAll(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to read upload data: %w", err)
+	}
+	return s.putObject(ctx, key, body, contentType)
+}
+
+// putObject 一次请求上传整个文件
+func (s *S3Storage) putObject(ctx context.Context, key string, body []byte, contentType string) (string, error) {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(ctx, http.MethodPut, s.objectURL(key, nil), header, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload file: %w", err)
+	}
+	resp.Body.Close()
+
+	return s.objectURL(key, nil).String(), nil
+}
+
+// UploadMultipart 分片上传文件（服务端上传大文件）
+// 按 opts.PartSize 逐片读取并上传，单个分片失败时按策略重试；任一分片最终失败则中止本次分片上传
+// 数据不超过一个分片时直接一次请求上传；内存占用不超过一个分片
+func (s *S3Storage) UploadMultipart(ctx context.Context, key string, data io.Reader, contentType string, opts storage.MultipartOptions) (string, error) {
+	opts = opts.Normalize()
+
+	buf := make([]byte, opts.PartSize)
+	n, last, err := storage.ReadPart(data, buf)
+	if err != nil {
+		return "", err
+	}
+	if last {
+		return s.putObject(ctx, key, buf[:n], contentType)
+	}
+
+	uploadID, err := s.initiateMultipartUpload(ctx, key, contentType)
+	if err != nil {
+		return "", fmt.Errorf("failed to initiate multipart upload: %w", err)
+	}
+
+	var parts []completedPart
+	for partNumber := 1; ; partNumber++ {
+		if partNumber > 1 {
+			if n, last, err = storage.ReadPart(data, buf); err != nil {
+				s.abortMultipartUpload(ctx, key, uploadID)
+				return "", err
+			}
+		}
+
+		// 数据恰好在分片边界结束
+		if n == 0 {
+			break
+		}
+
+		var etag string
+		err = storage.RetryPart(ctx, opts, partNumber, func() error {
+			e, err := s.uploadPart(ctx, key, uploadID, partNumber, buf[:n])
+			if err != nil {
+				log.Warn().Err(err).Str("key", key).Int("part_number", partNumber).Msg("分片上传失败")
+				return err
+			}
+			etag = e
+			return nil
+		})
+		if err != nil {
+			s.abortMultipartUpload(ctx, key, uploadID)
+			return "", fmt.Errorf("failed to upload part: %w", err)
+		}
+		parts = append(parts, completedPart{PartNumber: partNumber, ETag: etag})
+
+		if last {
+			break
+		}
+	}
+
+	if err := s.completeMultipartUpload(ctx, key, uploadID, parts); err != nil {
+		s.abortMultipartUpload(ctx, key, uploadID)
+		return "", fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+
+	return s.objectURL(key, nil).String(), nil
+}
+
+// Download 下载文件
+func (s *S3Storage) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, s.objectURL(key, nil), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	return resp.Body, nil
+}
+
+// DownloadRange 按区间下载文件（S3 Range 请求）
+func (s *S3Storage) DownloadRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	rangeSpec := fmt.Sprintf("bytes=%d-", offset)
+	if length > 0 {
+		rangeSpec = fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	}
+
+	header := http.Header{}
+	header.Set("Range", rangeSpec)
+	resp, err := s.do(ctx, http.MethodGet, s.objectURL(key, nil), header, nil)
+	if err != nil {
+		var respErr *ResponseError
+		if errors.As(err, &respErr) && respErr.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			return nil, fmt.Errorf("%w: %s", storage.ErrRangeNotSatisfiable, rangeSpec)
+		}
+		return nil, fmt.Errorf("failed to download file range %s: %w", rangeSpec, err)
+	}
+
+	// 服务端忽略了 Range（返回完整内容）时在本地跳过 offset 之前的数据
+	if resp.StatusCode != http.StatusPartialContent {
+		return storage.LimitReadCloser(resp.Body, offset, length)
+	}
+	return resp.Body, nil
+}
+
+// GetPresignedUploadURL 获取预签名上传URL（客户端直传，上传时需要使用相同的 Content-Type）
+func (s *S3Storage) GetPresignedUploadURL(ctx context.Context, key string, contentType string, expiresIn time.Duration) (string, error) {
+	return s.signer.presign(http.MethodPut, s.objectURL(key, nil), contentType, s.presignDuration(expiresIn), s.now()), nil
+}
+
+// GetPresignedDownloadURL 获取预签名下载URL
+func (s *S3Storage) GetPresignedDownloadURL(ctx context.Context, key string, expiresIn time.Duration) (string, error) {
+	return s.signer.presign(http.MethodGet, s.objectURL(key, nil), "", s.presignDuration(expiresIn), s.now()), nil
+}
+
+// Delete 删除文件（文件不存在时视为删除成功）
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, s.objectURL(key, nil), nil, nil)
+	if err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Exists 检查文件是否存在
+func (s *S3Storage) Exists(ctx context.Context, key string) (bool, error) {
+	resp, err := s.do(ctx, http.MethodHead, s.objectURL(key, nil), nil, nil)
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check file existence: %w", err)
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+// GetFileInfo 获取文件信息
+func (s *S3Storage) GetFileInfo(ctx context.Context, key string) (*storage.FileInfo, error) {
+	resp, err := s.do(ctx, http.MethodHead, s.objectURL(key, nil), nil, nil)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("file not found: %s", key)
+		}
+		return nil, fmt.Errorf("failed to get file info: %w", err)
+	}
+	resp.Body.Close()
+
+	size := resp.ContentLength
+	if size < 0 {
+		size, _ = strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	var lastModified time.Time
+	if lastModifiedStr := resp.Header.Get("Last-Modified"); lastModifiedStr != "" {
+		lastModified, _ = http.ParseTime(lastModifiedStr)
+	}
+
+	return &storage.FileInfo{
+		Key:          key,
+		Size:         size,
+		ContentType:  contentType,
+		ETag:         strings.Trim(resp.Header.Get("ETag"), `"`),
+		LastModified: lastModified,
+	}, nil
+}
+
+// GetStorageType 获取存储类型
+func (s *S3Storage) GetStorageType() string {
+	return string(s.storageType)
+}
+
+// presignDuration 预签名URL有效期：不超过配置的过期时间和 SigV4 的 7 天上限
+func (s *S3Storage) presignDuration(expiresIn time.Duration) time.Duration {
+	expiry := expiresIn
+	if s.presignExpiry > 0 && time.Duration(s.presignExpiry)*time.Second < expiry {
+		expiry = time.Duration(s.presignExpiry) * time.Second
+	}
+	if expiry > maxPresignExpiry {
+		expiry = maxPresignExpiry
+	}
+	if expiry < time.Second {
+		expiry = time.Second
+	}
+	return expiry
+}
+
+// objectURL 对象地址：路径风格为 endpoint/bucket/key，虚拟主机风格为 bucket.endpoint/key
+// 路径按 S3 规则编码（与签名使用的规范 URI 一致），查询参数按规范顺序排列
+func (s *S3Storage) objectURL(key string, query url.Values) *url.URL {
+	u := *s.endpoint
+	path := "/" + strings.TrimPrefix(strings.ReplaceAll(key, "\\", "/"), "/")
+	if s.usePathStyle {
+		path = "/" + s.bucket + path
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+	u.Path = path
+	u.RawPath = uriEncode(path, false)
+	u.RawQuery = canonicalQuery(query)
+	return &u
+}
+
+// do 签名并发送请求，非 2xx 响应解析为 *ResponseError
+func (s *S3Storage) do(ctx context.Context, method string, u *url.URL, header http.Header, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	s.signer.sign(req, hashHex(body), s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, parseResponseError(resp)
+	}
+	return resp, nil
+}
+
+// errorResponse S3 错误响应体
+type errorResponse struct {
+	XMLName   xml.Name `xml:"Error"`
+	Code      string   `xml:"Code"`
+	Message   string   `xml:"Message"`
+	RequestID string   `xml:"RequestId"`
+}
+
+// parseResponseError 解析错误响应（响应体不是 S3 错误格式时只保留状态码）
+func parseResponseError(resp *http.Response) error {
+	respErr := &ResponseError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Amz-Request-Id"),
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var body errorResponse
+	if len(data) > 0 && xml.Unmarshal(data, &body) == nil {
+		respErr.Code = body.Code
+		respErr.Message = body.Message
+		if body.RequestID != "" {
+			respErr.RequestID = body.RequestID
+		}
+	}
+	return respErr
+}
+
+// isNotFound 是否为对象或 Bucket 不存在
+func isNotFound(err error) bool {
+	var respErr *ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound
+}
+
+// initiateMultipartUploadResult 初始化分片上传的响应
+type initiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+// completeMultipartUpload 完成分片上传的请求体
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+// completedPart 已上传的分片
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// initiateMultipartUpload 初始化分片上传，返回 UploadId
+func (s *S3Storage) initiateMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(ctx, http.MethodPost, s.objectURL(key, url.Values{"uploads": {""}}), header, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result initiateMultipartUploadResult
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode initiate multipart upload response: %w", err)
+	}
+	if result.UploadID == "" {
+		return "", fmt.Errorf("empty upload id in initiate multipart upload response")
+	}
+	return result.UploadID, nil
+}
+
+// uploadPart 上传单个分片，返回分片的 ETag
+func (s *S3Storage) uploadPart(ctx context.Context, key, uploadID string, partNumber int, data []byte) (string, error) {
+	query := url.Values{
+		"partNumber": {strconv.Itoa(partNumber)},
+		"uploadId":   {uploadID},
+	}
+	resp, err := s.do(ctx, http.MethodPut, s.objectURL(key, query), nil, data)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return "", fmt.Errorf("empty etag in upload part response")
+	}
+	return etag, nil
+}
+
+// completeMultipartUpload 完成分片上传
+// S3 可能在返回 200 之后才在响应体中给出错误，需要检查响应体
+func (s *S3Storage) completeMultipartUpload(ctx context.Context, key, uploadID string, parts []completedPart) error {
+	body, err := xml.Marshal(completeMultipartUpload{Parts: parts})
+	if err != nil {
+		return err
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/xml")
+	resp, err := s.do(ctx, http.MethodPost, s.objectURL(key, url.Values{"uploadId": {uploadID}}), header, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return fmt.Errorf("failed to read complete multipart upload response: %w", err)
+	}
+	var errBody errorResponse
+	if xml.Unmarshal(data, &errBody) == nil && errBody.Code != "" {
+		return &ResponseError{
+			StatusCode: resp.StatusCode,
+			Code:       errBody.Code,
+			Message:    errBody.Message,
+			RequestID:  errBody.RequestID,
+		}
+	}
+	return nil
+}
+
+// abortMultipartUpload 中止分片上传，释放已上传的分片（请求已取消时仍然执行）
+func (s *S3Storage) abortMultipartUpload(ctx context.Context, key, uploadID string) {
+	resp, err := s.do(context.WithoutCancel(ctx), http.MethodDelete, s.objectURL(key, url.Values{"uploadId": {uploadID}}), nil, nil)
+	if err != nil {
+		log.Warn().Err(err).Str("key", key).Str("upload_id", uploadID).Msg("中止分片上传失败")
+		return
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,201 @@
+package s3
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	signAlgorithm   = "AWS4-HMAC-SHA256"
+	signService     = "s3"
+	signTerminator  = "aws4_request"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	amzDateFormat   = "20060102T150405Z"
+	scopeDateFormat = "20060102"
+
+	// maxPresignExpiry 预签名URL的最长有效期（SigV4 限制为 7 天）
+	maxPresignExpiry = 7 * 24 * time.Hour
+)
+
+// emptyPayloadHash 空请求体的 SHA256
+var emptyPayloadHash = hashHex(nil)
+
+// signer AWS Signature Version 4 签名（S3、MinIO、阿里云 OSS 的 S3 兼容接口通用）
+type signer struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	region          string
+}
+
+// sign 为请求添加 SigV4 Authorization 头
+// 请求的 URL 路径和查询参数必须已经按 S3 规则编码（见 objectURL），payloadHash 为请求体的 SHA256 十六进制值
+func (s *signer) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(amzDateFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	// 签名 host、Content-Type、Range 和所有 x-amz-* 头
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || lower == "range" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	signedHeaders, canonicalHeaders := canonicalizeHeaders(headers)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := s.scope(now)
+	signature := s.signature(now, amzDate, scope, canonicalRequest)
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signAlgorithm, s.accessKeyID, scope, signedHeaders, signature))
+}
+
+// presign 生成预签名URL（签名放在查询参数中，请求体不参与签名）
+// contentType 不为空时参与签名，客户端上传时必须使用相同的 Content-Type
+func (s *signer) presign(method string, u *url.URL, contentType string, expiresIn time.Duration, now time.Time) string {
+	now = now.UTC()
+	amzDate := now.Format(amzDateFormat)
+	scope := s.scope(now)
+
+	headers := map[string]string{"host": u.Host}
+	if contentType != "" {
+		headers["content-type"] = contentType
+	}
+	signedHeaders, canonicalHeaders := canonicalizeHeaders(headers)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", signAlgorithm)
+	query.Set("X-Amz-Credential", s.accessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expiresIn/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", signedHeaders)
+	if s.sessionToken != "" {
+		query.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+	rawQuery := canonicalQuery(query)
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		rawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+	signature := s.signature(now, amzDate, scope, canonicalRequest)
+
+	signed := *u
+	signed.RawQuery = rawQuery + "&X-Amz-Signature=" + signature
+	return signed.String()
+}
+
+// scope 签名范围：日期/区域/服务/aws4_request
+func (s *signer) scope(now time.Time) string {
+	return strings.Join([]string{now.Format(scopeDateFormat), s.region, signService, signTerminator}, "/")
+}
+
+// signature 计算规范请求的签名
+func (s *signer) signature(now time.Time, amzDate, scope, canonicalRequest string) string {
+	stringToSign := strings.Join([]string{signAlgorithm, amzDate, scope, hashHex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), now.Format(scopeDateFormat))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, signService)
+	key = hmacSHA256(key, signTerminator)
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// canonicalizeHeaders 返回按名称排序的签名头列表和规范头（每行 name:value，以换行结尾）
+func canonicalizeHeaders(headers map[string]string) (signedHeaders, canonicalHeaders string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(headers[name])
+		b.WriteByte('\n')
+	}
+	return strings.Join(names, ";"), b.String()
+}
+
+// canonicalQuery 规范查询字符串：参数按名称排序，名称和值按 S3 规则编码，没有值的参数写成 name=
+func canonicalQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(query))
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode 按 S3 规则编码：只保留 A-Z、a-z、0-9、-、_、.、~，其余字节编码为 %XX（大写）
+// encodeSlash 为 false 时保留 /（用于对象路径）
+func uriEncode(s string, encodeSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0x0f])
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TarChecksumFileName tar 流最后一个成员的文件名：各成员的 SHA256 校验清单（sha256sum 格式）
+const TarChecksumFileName = "SHA256SUMS"
+
+// TarOffsetPAXKey 续传时成员内容起始偏移的 PAX 扩展头
+// 续传的第一个成员只包含 offset 之后的内容，客户端据此追加到已下载的部分
+const TarOffsetPAXKey = "LEMON.offset"
+
+// tarStreamBufferSize 复制成员内容的缓冲区大小：按客户端读取速度逐块从存储读取，不预读整个文件
+const tarStreamBufferSize = 256 << 10
+
+// ErrShortTarMember 成员内容的字节数与声明的大小不一致（tar 头已写出，流只能中断）
+var ErrShortTarMember = errors.New("tar member size mismatch")
+
+// TarMember tar 流中的一个成员
+type TarMember struct {
+	Name    string    // 成员文件名
+	Size    int64     // 文件完整大小
+	ModTime time.Time // 修改时间
+	SHA256  string    // 记录中的文件 SHA256（可为空），跳过或续传的成员用它写入校验清单
+	Offset  int64     // 续传时内容的起始偏移
+}
+
+// TarStreamWriter 边传输边计算校验和的 tar 流写入器
+// 每个成员写完后刷新底层 writer（如 HTTP 响应），写入阻塞时不再读取存储，由客户端的读取速度控制传输速度
+type TarStreamWriter struct {
+	out  io.Writer
+	tw   *tar.Writer
+	buf  []byte
+	sums []string // 校验清单的行
+}
+
+// NewTarStreamWriter 创建 tar 流写入器
+func NewTarStreamWriter(w io.Writer) *TarStreamWriter {
+	return &TarStreamWriter{
+		out: w,
+		tw:  tar.NewWriter(w),
+		buf: make([]byte, tarStreamBufferSize),
+	}
+}
+
+// WriteMember 写入一个成员：内容从 data 读取 Size-Offset 个字节
+// 完整传输的成员按实际内容计算 SHA256，返回计算结果；续传的成员（Offset>0）只有部分内容，校验清单使用记录中的 SHA256，返回空字符串
+func (w *TarStreamWriter) WriteMember(m TarMember, data io.Reader) (string, error) {
+	if m.Offset < 0 || m.Offset > m.Size {
+		return "", fmt.Errorf("%w: offset %d, file size %d", ErrRangeNotSatisfiable, m.Offset, m.Size)
+	}
+	size := m.Size - m.Offset
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     m.Name,
+		Size:     size,
+		Mode:     0644,
+		ModTime:  m.ModTime,
+		Format:   tar.FormatPAX,
+	}
+	if m.Offset > 0 {
+		header.PAXRecords = map[string]string{TarOffsetPAXKey: strconv.FormatInt(m.Offset, 10)}
+	}
+	if err := w.tw.WriteHeader(header); err != nil {
+		return "", fmt.Errorf("write tar header %s: %w", m.Name, err)
+	}
+
+	hasher := sha256.New()
+	dst := io.Writer(w.tw)
+	if m.Offset == 0 {
+		dst = io.MultiWriter(w.tw, hasher)
+	}
+	n, err := io.CopyBuffer(dst, io.LimitReader(data, size), w.buf)
+	if err != nil {
+		return "", fmt.Errorf("copy tar member %s: %w", m.Name, err)
+	}
+	if n != size {
+		return "", fmt.Errorf("%w: %s copied %d of %d bytes", ErrShortTarMember, m.Name, n, size)
+	}
+
+	sum := ""
+	if m.Offset == 0 {
+		sum = hex.EncodeToString(hasher.Sum(nil))
+		w.addChecksum(sum, m.Name)
+	} else {
+		w.addChecksum(m.SHA256, m.Name)
+	}
+	if err := w.flush(); err != nil {
+		return "", err
+	}
+	return sum, nil
+}
+
+// SkipMember 跳过客户端已经完整下载的成员（续传），只把记录中的 SHA256 写入校验清单
+func (w *TarStreamWriter) SkipMember(m TarMember) {
+	w.addChecksum(m.SHA256, m.Name)
+}
+
+// addChecksum 记录校验清单的一行，没有 SHA256 的成员不记录
+func (w *TarStreamWriter) addChecksum(sum, name string) {
+	if sum == "" {
+		return
+	}
+	w.sums = append(w.sums, sum+"  "+name+"\n")
+}
+
+// Close 写入校验清单成员并结束 tar 流
+func (w *TarStreamWriter) Close() error {
+	manifest := strings.Join(w.sums, "")
+	if err := w.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     TarChecksumFileName,
+		Size:     int64(len(manifest)),
+		Mode:     0644,
+		ModTime:  time.Now(),
+		Format:   tar.FormatPAX,
+	}); err != nil {
+		return fmt.Errorf("write checksum header: %w", err)
+	}
+	if _, err := io.WriteString(w.tw, manifest); err != nil {
+		return fmt.Errorf("write checksum manifest: %w", err)
+	}
+	if err := w.tw.Close(); err != nil {
+		return fmt.Errorf("close tar stream: %w", err)
+	}
+	w.flushOut()
+	return nil
+}
+
+// flush 补齐当前成员的块并把已写入的数据推送给客户端
+func (w *TarStreamWriter) flush() error {
+	if err := w.tw.Flush(); err != nil {
+		return fmt.Errorf("flush tar stream: %w", err)
+	}
+	w.flushOut()
+	return nil
+}
+
+// flushOut 刷新底层 writer（如 HTTP 响应）
+func (w *TarStreamWriter) flushOut() {
+	if f, ok := w.out.(interface{ Flush() }); ok {
+		f.Flush()
+	}
+}
+
+// TarMemberNames 生成 tar 成员文件名：只保留文件名部分，空名称使用 fallback，重名时在扩展名前追加序号
+// 结果只取决于输入顺序，续传时同一组资源得到相同的文件名
+func TarMemberNames(names, fallbacks []string) []string {
+	result := make([]string, len(names))
+	used := make(map[string]bool, len(names)+1)
+	used[TarChecksumFileName] = true
+	for i, name := range names {
+		name = path.Base(strings.ReplaceAll(strings.TrimSpace(name), "\\", "/"))
+		if name == "." || name == "/" || name == ".." {
+			name = fallbacks[i]
+		}
+		candidate := name
+		ext := path.Ext(name)
+		for n := 2; used[candidate]; n++ {
+			candidate = fmt.Sprintf("%s_%d%s", strings.TrimSuffix(name, ext), n, ext)
+		}
+		used[candidate] = true
+		result[i] = candidate
+	}
+	return result
+}
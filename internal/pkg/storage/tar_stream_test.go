@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTarStreamWriter(t *testing.T) {
+	first := []byte("first member")
+	second := []byte("0123456789")
+	firstSum := sha256.Sum256(first)
+	secondSum := sha256.Sum256(second)
+
+	var buf bytes.Buffer
+	w := NewTarStreamWriter(&buf)
+	w.SkipMember(TarMember{Name: "skipped.mp4", Size: 3, SHA256: "abc"})
+	sum, err := w.WriteMember(TarMember{Name: "a.txt", Size: int64(len(first)), ModTime: time.Unix(0, 0)}, bytes.NewReader(first))
+	if err != nil {
+		t.Fatalf("WriteMember() error = %v", err)
+	}
+	if sum != hex.EncodeToString(firstSum[:]) {
+		t.Errorf("WriteMember() sum = %s, want %x", sum, firstSum)
+	}
+	// 续传：只传输偏移 4 之后的内容
+	if _, err := w.WriteMember(TarMember{Name: "b.bin", Size: 10, Offset: 4, SHA256: hex.EncodeToString(secondSum[:])}, bytes.NewReader(second[4:])); err != nil {
+		t.Fatalf("WriteMember() resume error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	r := tar.NewReader(&buf)
+	var names []string
+	contents := map[string]string{}
+	offsets := map[string]string{}
+	for {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read tar: %v", err)
+		}
+		data, _ := io.ReadAll(r)
+		names = append(names, hdr.Name)
+		contents[hdr.Name] = string(data)
+		offsets[hdr.Name] = hdr.PAXRecords[TarOffsetPAXKey]
+	}
+
+	if want := []string{"a.txt", "b.bin", TarChecksumFileName}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("members = %v, want %v", names, want)
+	}
+	if contents["b.bin"] != "456789" || offsets["b.bin"] != "4" {
+		t.Errorf("resumed member = %q (offset %q), want %q (offset 4)", contents["b.bin"], offsets["b.bin"], "456789")
+	}
+	if offsets["a.txt"] != "" {
+		t.Errorf("full member has offset %q", offsets["a.txt"])
+	}
+	wantSums := "abc  skipped.mp4\n" + hex.EncodeToString(firstSum[:]) + "  a.txt\n" + hex.EncodeToString(secondSum[:]) + "  b.bin\n"
+	if contents[TarChecksumFileName] != wantSums {
+		t.Errorf("checksum manifest = %q, want %q", contents[TarChecksumFileName], wantSums)
+	}
+}
+
+func TestTarStreamWriterShortMember(t *testing.T) {
+	w := NewTarStreamWriter(io.Discard)
+	_, err := w.WriteMember(TarMember{Name: "short.bin", Size: 10}, strings.NewReader("abc"))
+	if !errors.Is(err, ErrShortTarMember) {
+		t.Fatalf("WriteMember() error = %v, want %v", err, ErrShortTarMember)
+	}
+}
+
+func TestTarMemberNames(t *testing.T) {
+	got := TarMemberNames(
+		[]string{"video.mp4", "../etc/video.mp4", "", "SHA256SUMS", `dir\video.mp4`},
+		[]string{"r1", "r2", "r3", "r4", "r5"},
+	)
+	want := []string{"video.mp4", "video_2.mp4", "r3", "SHA256SUMS_2", "video_3.mp4"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TarMemberNames() = %v, want %v", got, want)
+	}
+}
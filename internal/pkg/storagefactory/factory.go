@@ -8,36 +8,79 @@ import (
 	"lemon/internal/pkg/storage"
 	"lemon/internal/pkg/storage/local"
 	"lemon/internal/pkg/storage/oss"
+	"lemon/internal/pkg/storage/s3"
 )
 
 // NewStorage 根据配置创建存储实例
+// 配置了备用存储时返回 *storage.ReplicatedStorage：写入主存储后异步复制到备用存储，主存储不可用时切换到备用存储
 func NewStorage(ctx context.Context, cfg *config.StorageConfig) (storage.Storage, error) {
-	switch cfg.Type {
+	primary, err := newBackend(cfg.Type, cfg.Local, cfg.OSS, cfg.S3)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Secondary == nil {
+		return primary, nil
+	}
+
+	secondary, err := newBackend(cfg.Secondary.Type, cfg.Secondary.Local, cfg.Secondary.OSS, cfg.Secondary.S3)
+	if err != nil {
+		return nil, fmt.Errorf("secondary storage: %w", err)
+	}
+	return storage.NewReplicatedStorage(primary, secondary, storage.ReplicationOptions{
+		QueueSize:        cfg.Replication.QueueSize,
+		Workers:          cfg.Replication.Workers,
+		FailoverCooldown: cfg.Replication.FailoverCooldown,
+	}), nil
+}
+
+// newBackend 创建单个存储后端
+func newBackend(storageType string, localCfg *config.LocalConfig, ossCfg *config.OSSConfig, s3Cfg *config.S3Config) (storage.Storage, error) {
+	switch storageType {
 	case "local":
-		if cfg.Local == nil {
+		if localCfg == nil {
 			return nil, fmt.Errorf("local storage config is required")
 		}
 		return local.NewLocalStorage(
-			cfg.Local.BasePath,
-			cfg.Local.BaseURL,
-			cfg.Local.PresignExpiry,
+			localCfg.BasePath,
+			localCfg.BaseURL,
+			localCfg.PresignExpiry,
 		)
 	case "oss":
-		if cfg.OSS == nil {
+		if ossCfg == nil {
 			return nil, fmt.Errorf("OSS storage config is required")
 		}
 		return oss.NewOSSStorage(
-			cfg.OSS.Endpoint,
-			cfg.OSS.Bucket,
-			cfg.OSS.AccessKeyID,
-			cfg.OSS.AccessKeySecret,
-			cfg.OSS.PresignExpiry,
+			ossCfg.Endpoint,
+			ossCfg.Bucket,
+			ossCfg.AccessKeyID,
+			ossCfg.AccessKeySecret,
+			ossCfg.PresignExpiry,
 		)
-	case "s3":
-		return nil, fmt.Errorf("S3 storage not implemented yet")
-	case "minio":
-		return nil, fmt.Errorf("MinIO storage not implemented yet")
+	case "s3", "minio":
+		if s3Cfg == nil {
+			return nil, fmt.Errorf("S3 storage config is required")
+		}
+		// MinIO 只支持路径风格访问，AWS S3 默认使用虚拟主机风格
+		usePathStyle := storageType == "minio"
+		if s3Cfg.UsePathStyle != nil {
+			usePathStyle = *s3Cfg.UsePathStyle
+		}
+		backend, err := s3.NewS3Storage(s3.Config{
+			Endpoint:        s3Cfg.Endpoint,
+			Region:          s3Cfg.Region,
+			Bucket:          s3Cfg.Bucket,
+			AccessKeyID:     s3Cfg.AccessKeyID,
+			SecretAccessKey: s3Cfg.SecretAccessKey,
+			SessionToken:    s3Cfg.SessionToken,
+			UsePathStyle:    usePathStyle,
+			PresignExpiry:   s3Cfg.PresignExpiry,
+			StorageType:     storage.StorageType(storageType),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create S3 storage: %w", err)
+		}
+		return backend, nil
 	default:
-		return nil, fmt.Errorf("unsupported storage type: %s", cfg.Type)
+		return nil, fmt.Errorf("unsupported storage type: %s", storageType)
 	}
 }
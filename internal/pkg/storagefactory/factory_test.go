@@ -259,3 +259,88 @@ func TestLocalStorage_NonExistentFile(t *testing.T) {
 		t.Errorf("Delete() error = %v, should succeed for non-existent file", err)
 	}
 }
+
+func TestNewStorage_S3(t *testing.T) {
+	s3Cfg := &config.S3Config{
+		Endpoint:        "http://localhost:9000",
+		Bucket:          "lemon",
+		AccessKeyID:     "minioadmin",
+		SecretAccessKey: "minioadmin",
+		PresignExpiry:   3600,
+	}
+	virtualHost := false
+
+	tests := []struct {
+		name        string
+		cfg         *config.StorageConfig
+		wantErr     bool
+		wantType    string
+		wantURLPart string
+	}{
+		{
+			name:        "minio defaults to path style",
+			cfg:         &config.StorageConfig{Type: "minio", S3: s3Cfg},
+			wantType:    "minio",
+			wantURLPart: "http://localhost:9000/lemon/a.txt",
+		},
+		{
+			name:        "s3 defaults to virtual host style",
+			cfg:         &config.StorageConfig{Type: "s3", S3: s3Cfg},
+			wantType:    "s3",
+			wantURLPart: "http://lemon.localhost:9000/a.txt",
+		},
+		{
+			name: "explicit use_path_style",
+			cfg: &config.StorageConfig{Type: "minio", S3: &config.S3Config{
+				Endpoint:        s3Cfg.Endpoint,
+				Bucket:          s3Cfg.Bucket,
+				AccessKeyID:     s3Cfg.AccessKeyID,
+				SecretAccessKey: s3Cfg.SecretAccessKey,
+				UsePathStyle:    &virtualHost,
+			}},
+			wantType:    "minio",
+			wantURLPart: "http://lemon.localhost:9000/a.txt",
+		},
+		{
+			name:    "missing s3 config",
+			cfg:     &config.StorageConfig{Type: "s3"},
+			wantErr: true,
+		},
+		{
+			name:    "missing bucket",
+			cfg:     &config.StorageConfig{Type: "s3", S3: &config.S3Config{AccessKeyID: "ak", SecretAccessKey: "sk"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			storage, err := NewStorage(ctx, tt.cfg)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("NewStorage() expected error, got nil")
+				}
+				if storage != nil {
+					t.Errorf("NewStorage() expected nil storage, got %v", storage)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("NewStorage() unexpected error: %v", err)
+			}
+			if storage.GetStorageType() != tt.wantType {
+				t.Errorf("GetStorageType() = %v, want %v", storage.GetStorageType(), tt.wantType)
+			}
+			url, err := storage.GetPresignedDownloadURL(ctx, "a.txt", time.Hour)
+			if err != nil {
+				t.Fatalf("GetPresignedDownloadURL() error = %v", err)
+			}
+			if !strings.HasPrefix(url, tt.wantURLPart+"?") {
+				t.Errorf("GetPresignedDownloadURL() url = %v, want prefix %v", url, tt.wantURLPart)
+			}
+		})
+	}
+}
@@ -19,6 +19,8 @@ import (
 	"github.com/volcengine/volcengine-go-sdk/volcengine"
 	"github.com/volcengine/volcengine-go-sdk/volcengine/credentials"
 	"github.com/volcengine/volcengine-go-sdk/volcengine/session"
+
+	"lemon/internal/pkg/environment"
 )
 
 // Config T2P（火山引擎 Text-to-Picture）配置
@@ -39,6 +41,7 @@ type Config struct {
 }
 
 // ConfigFromEnv 从环境变量创建 T2P 配置
+// 凭据、T2P_REQ_KEY 和 T2P_API_URL 按部署环境读取（优先 VOLCENGINE_ACCESS_KEY_<ENV>，见 environment.Getenv）
 // 支持的环境变量：
 //   - VOLCENGINE_ACCESS_KEY: 访问密钥（必需）
 //   - VOLCENGINE_SECRET_KEY: 密钥（必需）
@@ -54,10 +57,10 @@ type Config struct {
 //   - T2P_API_URL: API 端点（可选，默认: https://visual.volcengineapi.com）
 //   - T2P_REGION: 区域（可选，默认: cn-north-1）
 func ConfigFromEnv() *Config {
-	accessKey := os.Getenv("VOLCENGINE_ACCESS_KEY")
-	secretKey := os.Getenv("VOLCENGINE_SECRET_KEY")
+	accessKey := environment.Getenv("VOLCENGINE_ACCESS_KEY")
+	secretKey := environment.Getenv("VOLCENGINE_SECRET_KEY")
 
-	reqKey := os.Getenv("T2P_REQ_KEY")
+	reqKey := environment.Getenv("T2P_REQ_KEY")
 	if reqKey == "" {
 		reqKey = "high_aes_general_v21_L"
 	}
@@ -99,7 +102,7 @@ func ConfigFromEnv() *Config {
 		negativePrompt = "V领, 深V, 锁骨, 脖子, 宫装, 晚礼服, 漏脖子, 低领, 能看见脖子, watermark, (water-marked:1.4), (text:1.5), Signature sketch, (Chinese characters:1.5), (inscription:1.3), letters, (汉字:1.4)，字母，题字，文字，(红色印章:1.4)，logo，对话，标志，对话框，Text, dialog box, watermark, copy, word, letter, subtitle, seal, inscription, English alphabet, nsfw, nude, smooth skin, unblemished skin, mole, low resolution, blurry, worst quality, mutated hands and fingers, poorly drawn face, bad anatomy, distorted hands, limbless, 国旗, national flag."
 	}
 
-	apiURL := os.Getenv("T2P_API_URL")
+	apiURL := environment.Getenv("T2P_API_URL")
 	if apiURL == "" {
 		apiURL = "https://visual.volcengineapi.com"
 	}
@@ -0,0 +1,304 @@
+// Package tasklog 任务级日志
+// 按任务ID在内存环形缓冲区中保留最近的结构化日志（阶段进度、provider 请求ID、ffmpeg 错误输出片段等），
+// 供运维实时查看和跟踪正在运行的任务；进程重启后日志不保留
+package tasklog
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// 默认容量
+const (
+	DefaultMaxEntries = 1000 // 每个任务保留的最近日志条数
+	DefaultMaxTasks   = 500  // 最多保留的任务数（超出时优先淘汰最早结束的任务）
+
+	subscriberBufferSize = 256
+)
+
+// Level 日志级别
+type Level string
+
+const (
+	LevelInfo  Level = "info"
+	LevelWarn  Level = "warn"
+	LevelError Level = "error"
+)
+
+// Fields 日志的结构化字段
+type Fields map[string]any
+
+// Entry 一条任务日志
+type Entry struct {
+	Seq     int64     `json:"seq"`              // 任务内的序号（从 1 开始递增，用于断点续读）
+	Time    time.Time `json:"time"`             // 记录时间
+	Level   Level     `json:"level"`            // 级别
+	Message string    `json:"message"`          // 日志内容
+	Fields  Fields    `json:"fields,omitempty"` // 结构化字段
+}
+
+// Snapshot 任务日志快照
+type Snapshot struct {
+	TaskID     string     `json:"task_id"`
+	Name       string     `json:"name,omitempty"`        // 任务名称（如请求的方法和路由）
+	Running    bool       `json:"running"`               // 任务是否仍在运行
+	StartedAt  time.Time  `json:"started_at"`            // 开始时间
+	FinishedAt *time.Time `json:"finished_at,omitempty"` // 结束时间
+	Entries    []Entry    `json:"entries"`               // 日志（按序号升序）
+	NextSeq    int64      `json:"next_seq"`              // 下次续读时传入的 after（本页最后一条的序号）
+	HasMore    bool       `json:"has_more"`              // 本页之后是否还有已记录的日志
+	Truncated  bool       `json:"truncated"`             // after 之后的部分日志已经被环形缓冲区淘汰
+}
+
+// task 一个任务的日志缓冲区
+type task struct {
+	id         string
+	name       string
+	startedAt  time.Time
+	finishedAt *time.Time
+
+	buf     []Entry // 环形缓冲区
+	head    int     // 最早一条在 buf 中的位置
+	size    int     // 当前条数
+	lastSeq int64   // 最后一条的序号
+
+	subs map[chan Entry]struct{}
+}
+
+// Store 任务日志存储，可并发使用
+type Store struct {
+	maxEntries int
+	maxTasks   int
+
+	mu    sync.Mutex
+	tasks map[string]*task
+	order *list.List // 按创建顺序排列的任务ID（用于淘汰）
+}
+
+// NewStore 创建任务日志存储，容量 <=0 时使用默认值
+func NewStore(maxEntries, maxTasks int) *Store {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	if maxTasks <= 0 {
+		maxTasks = DefaultMaxTasks
+	}
+	return &Store{
+		maxEntries: maxEntries,
+		maxTasks:   maxTasks,
+		tasks:      make(map[string]*task),
+		order:      list.New(),
+	}
+}
+
+// Start 登记一个运行中的任务；任务已存在时只更新名称并重新标记为运行中
+func (s *Store) Start(taskID, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.getOrCreate(taskID)
+	t.name = name
+	t.finishedAt = nil
+}
+
+// Append 追加一条日志，任务不存在时自动登记
+func (s *Store) Append(taskID string, level Level, message string, fields Fields) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.getOrCreate(taskID)
+	t.lastSeq++
+	entry := Entry{Seq: t.lastSeq, Time: time.Now(), Level: level, Message: message, Fields: fields}
+	if t.size < len(t.buf) {
+		t.buf[(t.head+t.size)%len(t.buf)] = entry
+		t.size++
+	} else {
+		t.buf[t.head] = entry
+		t.head = (t.head + 1) % len(t.buf)
+	}
+	for ch := range t.subs {
+		select {
+		case ch <- entry:
+		default:
+			// 订阅者消费过慢，丢弃（订阅者可以按序号发现缺口后用 Snapshot 补齐）
+		}
+	}
+}
+
+// Finish 标记任务结束，并关闭所有订阅通道
+func (s *Store) Finish(taskID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tasks[taskID]
+	if !ok || t.finishedAt != nil {
+		return
+	}
+	now := time.Now()
+	t.finishedAt = &now
+	for ch := range t.subs {
+		delete(t.subs, ch)
+		close(ch)
+	}
+}
+
+// Snapshot 查询序号大于 after 的日志，最多 limit 条（<=0 时不限制）
+// 任务不存在（从未记录或已被淘汰）时返回 false
+func (s *Store) Snapshot(taskID string, after int64, limit int) (*Snapshot, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tasks[taskID]
+	if !ok {
+		return nil, false
+	}
+
+	snap := &Snapshot{
+		TaskID:    t.id,
+		Name:      t.name,
+		Running:   t.finishedAt == nil,
+		StartedAt: t.startedAt,
+		Entries:   []Entry{},
+		NextSeq:   max(after, 0),
+	}
+	if t.finishedAt != nil {
+		finishedAt := *t.finishedAt
+		snap.FinishedAt = &finishedAt
+	}
+	oldestSeq := t.lastSeq - int64(t.size) + 1
+	snap.Truncated = t.size > 0 && after+1 < oldestSeq
+	for i := 0; i < t.size; i++ {
+		entry := t.buf[(t.head+i)%len(t.buf)]
+		if entry.Seq <= after {
+			continue
+		}
+		if limit > 0 && len(snap.Entries) >= limit {
+			snap.HasMore = true
+			break
+		}
+		snap.Entries = append(snap.Entries, entry)
+		snap.NextSeq = entry.Seq
+	}
+	return snap, true
+}
+
+// Subscribe 订阅任务的新日志，返回日志通道和取消订阅函数
+// 任务结束后通道会被关闭；任务不存在时返回 false，任务已结束时返回已关闭的通道；取消函数可重复调用
+func (s *Store) Subscribe(taskID string) (<-chan Entry, func(), bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tasks[taskID]
+	if !ok {
+		return nil, func() {}, false
+	}
+	ch := make(chan Entry, subscriberBufferSize)
+	if t.finishedAt != nil {
+		close(ch)
+		return ch, func() {}, true
+	}
+	t.subs[ch] = struct{}{}
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if _, ok := t.subs[ch]; ok {
+				delete(t.subs, ch)
+				close(ch)
+			}
+		})
+	}
+	return ch, cancel, true
+}
+
+// getOrCreate 查询或登记任务（调用方持有锁），超出容量时淘汰旧任务
+func (s *Store) getOrCreate(taskID string) *task {
+	if t, ok := s.tasks[taskID]; ok {
+		return t
+	}
+	t := &task{
+		id:        taskID,
+		startedAt: time.Now(),
+		buf:       make([]Entry, s.maxEntries),
+		subs:      make(map[chan Entry]struct{}),
+	}
+	s.order.PushBack(taskID)
+	s.tasks[taskID] = t
+	for len(s.tasks) > s.maxTasks {
+		s.evict()
+	}
+	return t
+}
+
+// evict 淘汰一个任务：优先淘汰最早登记的已结束任务，都在运行时淘汰最早登记的任务
+func (s *Store) evict() {
+	victim := s.order.Front()
+	for e := s.order.Front(); e != nil; e = e.Next() {
+		if s.tasks[e.Value.(string)].finishedAt != nil {
+			victim = e
+			break
+		}
+	}
+	t := s.tasks[victim.Value.(string)]
+	for ch := range t.subs {
+		delete(t.subs, ch)
+		close(ch)
+	}
+	s.order.Remove(victim)
+	delete(s.tasks, t.id)
+}
+
+// loggerKeyType 使用私有类型避免与其他 context key 冲突
+type loggerKeyType struct{}
+
+var loggerKey = loggerKeyType{}
+
+// Logger 写入某个任务日志的记录器
+// 所有方法对 nil 接收者安全（context 中没有任务时直接忽略）
+type Logger struct {
+	store  *Store
+	taskID string
+}
+
+// NewContext 返回关联任务日志的 context，之后通过 FromContext 记录的日志都写入该任务
+func NewContext(ctx context.Context, store *Store, taskID string) context.Context {
+	return context.WithValue(ctx, loggerKey, &Logger{store: store, taskID: taskID})
+}
+
+// FromContext 从 context 中获取任务日志记录器，没有时返回 nil
+func FromContext(ctx context.Context) *Logger {
+	if ctx == nil {
+		return nil
+	}
+	l, _ := ctx.Value(loggerKey).(*Logger)
+	return l
+}
+
+// TaskID 返回任务ID，没有关联任务时返回空字符串
+func (l *Logger) TaskID() string {
+	if l == nil {
+		return ""
+	}
+	return l.taskID
+}
+
+// Info 记录一条 info 日志
+func (l *Logger) Info(message string, fields Fields) {
+	l.log(LevelInfo, message, fields)
+}
+
+// Warn 记录一条 warn 日志
+func (l *Logger) Warn(message string, fields Fields) {
+	l.log(LevelWarn, message, fields)
+}
+
+// Error 记录一条 error 日志
+func (l *Logger) Error(message string, fields Fields) {
+	l.log(LevelError, message, fields)
+}
+
+func (l *Logger) log(level Level, message string, fields Fields) {
+	if l == nil || l.store == nil {
+		return
+	}
+	l.store.Append(l.taskID, level, message, fields)
+}
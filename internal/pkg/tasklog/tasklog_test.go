@@ -0,0 +1,132 @@
+package tasklog
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestStoreAppendAndSnapshot(t *testing.T) {
+	s := NewStore(3, 10)
+	s.Start("task-1", "POST /videos")
+	for i := 1; i <= 5; i++ {
+		s.Append("task-1", LevelInfo, fmt.Sprintf("step %d", i), nil)
+	}
+
+	snap, ok := s.Snapshot("task-1", 0, 0)
+	if !ok {
+		t.Fatal("expected task to exist")
+	}
+	if !snap.Running || snap.Name != "POST /videos" {
+		t.Errorf("snapshot = %+v", snap)
+	}
+	if len(snap.Entries) != 3 || snap.Entries[0].Seq != 3 || snap.Entries[2].Seq != 5 {
+		t.Fatalf("entries = %+v, want seq 3..5", snap.Entries)
+	}
+	if !snap.Truncated {
+		t.Error("expected truncated when older entries were dropped")
+	}
+	if snap.NextSeq != 5 || snap.HasMore {
+		t.Errorf("next_seq = %d, has_more = %v", snap.NextSeq, snap.HasMore)
+	}
+
+	snap, _ = s.Snapshot("task-1", 3, 1)
+	if len(snap.Entries) != 1 || snap.Entries[0].Seq != 4 || !snap.HasMore || snap.Truncated {
+		t.Errorf("paged snapshot = %+v", snap)
+	}
+
+	snap, _ = s.Snapshot("task-1", 5, 0)
+	if len(snap.Entries) != 0 || snap.NextSeq != 5 {
+		t.Errorf("empty snapshot = %+v", snap)
+	}
+
+	if _, ok := s.Snapshot("missing", 0, 0); ok {
+		t.Error("expected unknown task to be missing")
+	}
+}
+
+func TestStoreSubscribe(t *testing.T) {
+	s := NewStore(10, 10)
+	if _, _, ok := s.Subscribe("task-1"); ok {
+		t.Fatal("expected subscribe to unknown task to fail")
+	}
+
+	s.Start("task-1", "")
+	ch, cancel, ok := s.Subscribe("task-1")
+	if !ok {
+		t.Fatal("expected subscribe to succeed")
+	}
+	defer cancel()
+
+	s.Append("task-1", LevelError, "ffmpeg failed", Fields{"stderr": "boom"})
+	entry := <-ch
+	if entry.Seq != 1 || entry.Level != LevelError || entry.Fields["stderr"] != "boom" {
+		t.Errorf("entry = %+v", entry)
+	}
+
+	s.Finish("task-1")
+	if _, ok := <-ch; ok {
+		t.Error("expected channel to be closed after finish")
+	}
+	cancel() // 结束后取消不应 panic
+
+	snap, _ := s.Snapshot("task-1", 0, 0)
+	if snap.Running || snap.FinishedAt == nil {
+		t.Errorf("expected finished task, got %+v", snap)
+	}
+
+	finished, _, ok := s.Subscribe("task-1")
+	if !ok {
+		t.Fatal("expected subscribe to finished task to succeed")
+	}
+	if _, open := <-finished; open {
+		t.Error("expected closed channel for finished task")
+	}
+}
+
+func TestStoreEvictsFinishedTasksFirst(t *testing.T) {
+	s := NewStore(10, 2)
+	s.Start("running", "")
+	s.Start("done", "")
+	s.Finish("done")
+	s.Start("new", "")
+
+	if _, ok := s.Snapshot("done", 0, 0); ok {
+		t.Error("expected finished task to be evicted first")
+	}
+	if _, ok := s.Snapshot("running", 0, 0); !ok {
+		t.Error("expected running task to be kept")
+	}
+
+	_, cancel, _ := s.Subscribe("running")
+	s.Start("newer", "")
+	cancel() // 被淘汰后取消不应 panic
+	if _, ok := s.Snapshot("running", 0, 0); ok {
+		t.Error("expected oldest task to be evicted when all are running")
+	}
+}
+
+func TestLoggerFromContext(t *testing.T) {
+	FromContext(context.Background()).Info("ignored", nil)
+	if FromContext(context.Background()).TaskID() != "" {
+		t.Error("expected empty task id without logger")
+	}
+
+	s := NewStore(0, 0)
+	ctx := NewContext(context.Background(), s, "task-1")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			FromContext(ctx).Warn("retry", Fields{"attempt": 1})
+		}()
+	}
+	wg.Wait()
+
+	snap, ok := s.Snapshot(FromContext(ctx).TaskID(), 0, 0)
+	if !ok || len(snap.Entries) != 10 || snap.Entries[9].Seq != 10 {
+		t.Errorf("snapshot = %+v", snap)
+	}
+}
@@ -0,0 +1,101 @@
+// Package taskstats 任务级统计
+// 在一次生成任务的 context 中累计 provider 调用次数、费用和上传/下载字节数，任务结束后汇总为耗时与费用明细
+package taskstats
+
+import (
+	"context"
+	"sync"
+)
+
+// statsKeyType 使用私有类型避免与其他 context key 冲突
+type statsKeyType struct{}
+
+var statsKey = statsKeyType{}
+
+// Stats 一次任务的累计统计，可并发使用
+// 所有方法对 nil 接收者安全（context 中没有统计时直接忽略）
+type Stats struct {
+	parent *Stats // 外层任务的统计（嵌套任务同时计入外层任务）
+
+	mu              sync.Mutex
+	providerCalls   map[string]int
+	costFen         int64
+	bytesUploaded   int64
+	bytesDownloaded int64
+}
+
+// Snapshot 统计快照
+type Snapshot struct {
+	ProviderCalls   map[string]int // 按 provider 统计的调用次数
+	CostFen         int64          // 费用（分）
+	BytesUploaded   int64          // 上传字节数
+	BytesDownloaded int64          // 下载字节数
+}
+
+// NewContext 返回携带新统计的 context；context 中已有统计时，新统计同时计入外层任务
+func NewContext(ctx context.Context) (context.Context, *Stats) {
+	s := &Stats{parent: FromContext(ctx), providerCalls: make(map[string]int)}
+	return context.WithValue(ctx, statsKey, s), s
+}
+
+// FromContext 从 context 中获取统计，没有时返回 nil
+func FromContext(ctx context.Context) *Stats {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(statsKey).(*Stats)
+	return s
+}
+
+// AddCall 记录一次 provider 调用及其费用（分）
+func (s *Stats) AddCall(provider string, costFen int64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.providerCalls[provider]++
+	s.costFen += costFen
+	s.mu.Unlock()
+	s.parent.AddCall(provider, costFen)
+}
+
+// AddUploaded 累计上传字节数
+func (s *Stats) AddUploaded(n int64) {
+	if s == nil || n <= 0 {
+		return
+	}
+	s.mu.Lock()
+	s.bytesUploaded += n
+	s.mu.Unlock()
+	s.parent.AddUploaded(n)
+}
+
+// AddDownloaded 累计下载字节数
+func (s *Stats) AddDownloaded(n int64) {
+	if s == nil || n <= 0 {
+		return
+	}
+	s.mu.Lock()
+	s.bytesDownloaded += n
+	s.mu.Unlock()
+	s.parent.AddDownloaded(n)
+}
+
+// Snapshot 返回当前统计的快照
+func (s *Stats) Snapshot() Snapshot {
+	if s == nil {
+		return Snapshot{ProviderCalls: map[string]int{}}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	calls := make(map[string]int, len(s.providerCalls))
+	for k, v := range s.providerCalls {
+		calls[k] = v
+	}
+	return Snapshot{
+		ProviderCalls:   calls,
+		CostFen:         s.costFen,
+		BytesUploaded:   s.bytesUploaded,
+		BytesDownloaded: s.bytesDownloaded,
+	}
+}
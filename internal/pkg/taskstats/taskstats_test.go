@@ -0,0 +1,64 @@
+package taskstats
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	ctx, s := NewContext(context.Background())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			FromContext(ctx).AddCall("image", 20)
+			FromContext(ctx).AddUploaded(100)
+		}()
+	}
+	wg.Wait()
+	s.AddCall("llm", 1)
+	s.AddDownloaded(50)
+	s.AddDownloaded(-1)
+
+	snap := s.Snapshot()
+	if snap.ProviderCalls["image"] != 10 || snap.ProviderCalls["llm"] != 1 {
+		t.Errorf("provider calls = %v", snap.ProviderCalls)
+	}
+	if snap.CostFen != 201 {
+		t.Errorf("cost = %d, want 201", snap.CostFen)
+	}
+	if snap.BytesUploaded != 1000 || snap.BytesDownloaded != 50 {
+		t.Errorf("bytes = %d/%d, want 1000/50", snap.BytesUploaded, snap.BytesDownloaded)
+	}
+}
+
+func TestNestedStatsPropagateToOuter(t *testing.T) {
+	ctx, outer := NewContext(context.Background())
+	outer.AddCall("llm", 1)
+
+	innerCtx, inner := NewContext(ctx)
+	FromContext(innerCtx).AddCall("tts", 50)
+	inner.AddUploaded(10)
+
+	if got := inner.Snapshot(); got.ProviderCalls["llm"] != 0 || got.CostFen != 50 || got.BytesUploaded != 10 {
+		t.Errorf("inner snapshot = %+v", got)
+	}
+	if got := outer.Snapshot(); got.ProviderCalls["llm"] != 1 || got.ProviderCalls["tts"] != 1 || got.CostFen != 51 || got.BytesUploaded != 10 {
+		t.Errorf("outer snapshot = %+v", got)
+	}
+}
+
+func TestNilStats(t *testing.T) {
+	s := FromContext(context.Background())
+	if s != nil {
+		t.Fatal("expected nil stats without NewContext")
+	}
+	s.AddCall("tts", 50)
+	s.AddUploaded(1)
+	if snap := s.Snapshot(); snap.CostFen != 0 || len(snap.ProviderCalls) != 0 {
+		t.Errorf("nil snapshot = %+v", snap)
+	}
+}
@@ -0,0 +1,144 @@
+package textencoding
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Encoding 文本编码名称
+type Encoding string
+
+const (
+	UTF8    Encoding = "utf-8"    // UTF-8（含 BOM）
+	UTF16LE Encoding = "utf-16le" // UTF-16 小端（需带 BOM）
+	UTF16BE Encoding = "utf-16be" // UTF-16 大端（需带 BOM）
+	GB18030 Encoding = "gb18030"  // GB18030（兼容 GBK / GB2312）
+	Big5    Encoding = "big5"     // Big5（繁体中文）
+)
+
+// ErrUndecodable 无法识别编码或文件内容已损坏
+var ErrUndecodable = errors.New("undecodable text")
+
+// maxInvalidRatio 解码后允许的非法字符比例，超过时认为编码识别失败
+// 允许少量损坏字节（如文件被截断在多字节字符中间）
+const maxInvalidRatio = 0.001
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// ToUTF8 识别文本编码并转换为 UTF-8
+//
+// 识别顺序：
+//  1. BOM（UTF-8 / UTF-16LE / UTF-16BE）
+//  2. 合法的 UTF-8
+//  3. GB18030 与 Big5：按双字节字符落在各自常用字区的比例择优（类似 chardet 的字符分布分析）
+//
+// Returns:
+//   - []byte: UTF-8 文本（已去掉 BOM）
+//   - Encoding: 识别出的源编码
+//   - error: 无法解码时返回 ErrUndecodable
+func ToUTF8(data []byte) ([]byte, Encoding, error) {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		data = data[len(bomUTF8):]
+		if !utf8.Valid(data) {
+			return nil, "", fmt.Errorf("%w: invalid utf-8 after bom", ErrUndecodable)
+		}
+		return data, UTF8, nil
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return decode(data, UTF16LE, unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM))
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return decode(data, UTF16BE, unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM))
+	}
+
+	if utf8.Valid(data) {
+		return data, UTF8, nil
+	}
+
+	if big5CommonRatio(data) > gbCommonRatio(data) {
+		return decode(data, Big5, traditionalchinese.Big5)
+	}
+	return decode(data, GB18030, simplifiedchinese.GB18030)
+}
+
+// decode 按指定编码解码，非法字符过多时返回 ErrUndecodable
+func decode(data []byte, name Encoding, enc encoding.Encoding) ([]byte, Encoding, error) {
+	out, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: decode as %s: %v", ErrUndecodable, name, err)
+	}
+
+	total, invalid := 0, 0
+	for s := out; len(s) > 0; {
+		r, size := utf8.DecodeRune(s)
+		if r == utf8.RuneError || (r < 0x20 && r != '\n' && r != '\r' && r != '\t') {
+			invalid++
+		}
+		total++
+		s = s[size:]
+	}
+	if total > 0 && float64(invalid)/float64(total) > maxInvalidRatio {
+		return nil, "", fmt.Errorf("%w: %d of %d characters invalid as %s", ErrUndecodable, invalid, total, name)
+	}
+	return out, name, nil
+}
+
+// gbCommonRatio 按 GB18030 切分双字节字符，返回落在 GB2312 符号区和一级汉字区的比例
+func gbCommonRatio(data []byte) float64 {
+	total, common := 0, 0
+	for i := 0; i < len(data); {
+		b := data[i]
+		if b < 0x80 || i+1 >= len(data) {
+			i++
+			continue
+		}
+		b2 := data[i+1]
+		if b2 >= 0x30 && b2 <= 0x39 {
+			// 四字节字符
+			total++
+			i += 4
+			continue
+		}
+		total++
+		if b2 >= 0xA1 && b2 <= 0xFE && ((b >= 0xA1 && b <= 0xA9) || (b >= 0xB0 && b <= 0xD7)) {
+			common++
+		}
+		i += 2
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(common) / float64(total)
+}
+
+// big5CommonRatio 按 Big5 切分双字节字符，返回落在符号区和常用字区的比例
+func big5CommonRatio(data []byte) float64 {
+	total, common := 0, 0
+	for i := 0; i < len(data); {
+		b := data[i]
+		if b < 0x80 || i+1 >= len(data) {
+			i++
+			continue
+		}
+		b2 := data[i+1]
+		total++
+		if b >= 0xA1 && b <= 0xC6 && ((b2 >= 0x40 && b2 <= 0x7E) || (b2 >= 0xA1 && b2 <= 0xFE)) {
+			common++
+		}
+		i += 2
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(common) / float64(total)
+}
@@ -0,0 +1,64 @@
+package textencoding
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+const sample = "第一章 重生\n　　林凡睁开眼睛，发现自己回到了十年前的那个夏天。窗外的蝉鸣声一阵接着一阵，桌上还放着那封没有拆开的录取通知书。\n"
+
+const sampleTraditional = "第一章 重生\n　　林凡睜開眼睛，發現自己回到了十年前的那個夏天。窗外的蟬鳴聲一陣接著一陣，桌上還放著那封沒有拆開的錄取通知書。\n"
+
+func mustEncode(t *testing.T, enc encoding.Encoding, s string) []byte {
+	t.Helper()
+	b, err := enc.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	return b
+}
+
+func TestToUTF8(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+		enc  Encoding
+	}{
+		{"utf-8", []byte(sample), sample, UTF8},
+		{"utf-8 with bom", append([]byte{0xEF, 0xBB, 0xBF}, sample...), sample, UTF8},
+		{"gbk", mustEncode(t, simplifiedchinese.GBK, sample), sample, GB18030},
+		{"gb18030", mustEncode(t, simplifiedchinese.GB18030, sample), sample, GB18030},
+		{"big5", mustEncode(t, traditionalchinese.Big5, sampleTraditional), sampleTraditional, Big5},
+		{"utf-16le with bom", mustEncode(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), sample), sample, UTF16LE},
+		{"utf-16be with bom", mustEncode(t, unicode.UTF16(unicode.BigEndian, unicode.UseBOM), sample), sample, UTF16BE},
+	}
+	for _, tt := range tests {
+		got, enc, err := ToUTF8(tt.data)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if enc != tt.enc {
+			t.Errorf("%s: encoding = %s, want %s", tt.name, enc, tt.enc)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: text = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestToUTF8Undecodable(t *testing.T) {
+	binary := make([]byte, 256)
+	for i := range binary {
+		binary[i] = byte(i)
+	}
+	if _, _, err := ToUTF8(binary); !errors.Is(err, ErrUndecodable) {
+		t.Errorf("binary data: err = %v, want ErrUndecodable", err)
+	}
+}
@@ -0,0 +1,207 @@
+// Package tsa RFC 3161 时间戳服务客户端
+// 把一个 SHA256 摘要提交给时间戳服务（TSA），返回 TSA 签发的 TimeStampToken（CMS SignedData，DER 编码）。
+// 令牌的签名由第三方工具校验（例如 openssl ts -verify），这里只检查令牌中的摘要与提交的摘要一致并读取签发时间
+package tsa
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+const (
+	contentTypeQuery = "application/timestamp-query"
+	contentTypeReply = "application/timestamp-reply"
+	// maxReplySize 响应体最大字节数（令牌通常只有几 KB，包含证书链时也不会超过这个大小）
+	maxReplySize = 1 << 20
+)
+
+var (
+	oidSHA256     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+)
+
+// ErrRejected 时间戳服务拒绝了请求（PKIStatus 不是 granted/grantedWithMods）
+var ErrRejected = errors.New("timestamp request rejected")
+
+// Token 时间戳令牌
+type Token struct {
+	DER     []byte    // TimeStampToken（DER）
+	GenTime time.Time // TSA 签发的时间
+}
+
+// Client 时间戳服务客户端
+type Client struct {
+	url        string
+	httpClient *http.Client
+}
+
+// New 创建时间戳服务客户端，timeout <= 0 时使用 10 秒
+func New(url string, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &Client{url: url, httpClient: &http.Client{Timeout: timeout}}
+}
+
+// URL 返回时间戳服务地址
+func (c *Client) URL() string {
+	return c.url
+}
+
+// messageImprint RFC 3161 MessageImprint
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+// timeStampReq RFC 3161 TimeStampReq（不带 reqPolicy 和 extensions）
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	Nonce          *big.Int
+	CertReq        bool `asn1:"optional,default:false"`
+}
+
+// pkiStatusInfo RFC 3161 PKIStatusInfo
+type pkiStatusInfo struct {
+	Status       int
+	StatusString asn1.RawValue  `asn1:"optional"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+// timeStampResp RFC 3161 TimeStampResp
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+// contentInfo CMS ContentInfo
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue // [0] EXPLICIT，Bytes 为内容的完整编码
+}
+
+// signedData CMS SignedData（只解析到封装的内容，证书和签名者信息保留原始编码）
+type signedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	EncapContentInfo encapsulatedContentInfo
+	Rest             []asn1.RawValue `asn1:"optional"`
+}
+
+// encapsulatedContentInfo CMS EncapsulatedContentInfo
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+// tstInfo RFC 3161 TSTInfo（只解析到签发时间）
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time       `asn1:"generalized"`
+	Rest           []asn1.RawValue `asn1:"optional"`
+}
+
+// Timestamp 为 SHA256 摘要申请时间戳（令牌包含 TSA 证书，便于离线校验）
+func (c *Client) Timestamp(ctx context.Context, digest []byte) (*Token, error) {
+	if len(digest) != sha256.Size {
+		return nil, fmt.Errorf("digest must be %d bytes", sha256.Size)
+	}
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	body, err := asn1.Marshal(timeStampReq{
+		Version: 1,
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+			HashedMessage: digest,
+		},
+		Nonce:   nonce,
+		CertReq: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode timestamp request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentTypeQuery)
+	req.Header.Set("Accept", contentTypeReply)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	reply, err := io.ReadAll(io.LimitReader(resp.Body, maxReplySize))
+	if err != nil {
+		return nil, fmt.Errorf("read timestamp reply: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("timestamp server returned %s", resp.Status)
+	}
+	return ParseReply(reply, digest)
+}
+
+// ParseReply 解析 TimeStampResp，检查状态以及令牌中的摘要与 digest 一致
+func ParseReply(reply, digest []byte) (*Token, error) {
+	var resp timeStampResp
+	if _, err := asn1.Unmarshal(reply, &resp); err != nil {
+		return nil, fmt.Errorf("decode timestamp reply: %w", err)
+	}
+	// 0 granted, 1 grantedWithMods
+	if resp.Status.Status != 0 && resp.Status.Status != 1 {
+		return nil, fmt.Errorf("%w: status %d", ErrRejected, resp.Status.Status)
+	}
+	if len(resp.TimeStampToken.FullBytes) == 0 {
+		return nil, errors.New("timestamp reply has no token")
+	}
+
+	info, err := parseToken(resp.TimeStampToken.FullBytes)
+	if err != nil {
+		return nil, err
+	}
+	if !info.MessageImprint.HashAlgorithm.Algorithm.Equal(oidSHA256) || !bytes.Equal(info.MessageImprint.HashedMessage, digest) {
+		return nil, errors.New("timestamp token does not cover the requested digest")
+	}
+	return &Token{DER: resp.TimeStampToken.FullBytes, GenTime: info.GenTime}, nil
+}
+
+// parseToken 解析 TimeStampToken 中的 TSTInfo（不校验签名）
+func parseToken(der []byte) (*tstInfo, error) {
+	var ci contentInfo
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, fmt.Errorf("decode timestamp token: %w", err)
+	}
+	if !ci.ContentType.Equal(oidSignedData) || ci.Content.Class != asn1.ClassContextSpecific || ci.Content.Tag != 0 {
+		return nil, fmt.Errorf("timestamp token is not signed data: %v", ci.ContentType)
+	}
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("decode signed data: %w", err)
+	}
+	if !sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) {
+		return nil, fmt.Errorf("timestamp token content is not TSTInfo: %v", sd.EncapContentInfo.EContentType)
+	}
+	var info tstInfo
+	if _, err := asn1.Unmarshal(sd.EncapContentInfo.EContent, &info); err != nil {
+		return nil, fmt.Errorf("decode TSTInfo: %w", err)
+	}
+	return &info, nil
+}
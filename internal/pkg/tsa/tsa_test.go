@@ -0,0 +1,113 @@
+package tsa
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeReply 构造 TSA 响应：status 为 PKIStatus，imprint 为令牌中记录的摘要
+func fakeReply(t *testing.T, status int, imprint []byte, genTime time.Time) []byte {
+	t.Helper()
+	info, err := asn1.Marshal(tstInfo{
+		Version: 1,
+		Policy:  asn1.ObjectIdentifier{1, 2, 3, 4},
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+			HashedMessage: imprint,
+		},
+		SerialNumber: big.NewInt(42),
+		GenTime:      genTime,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd, err := asn1.Marshal(signedData{
+		Version:          3,
+		DigestAlgorithms: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true},
+		EncapContentInfo: encapsulatedContentInfo{EContentType: oidTSTInfo, EContent: info},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ContentInfo 的 content 为 [0] EXPLICIT SignedData
+	token, err := asn1.Marshal(struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue
+	}{oidSignedData, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := timeStampResp{Status: pkiStatusInfo{Status: status}}
+	if status <= 1 {
+		resp.TimeStampToken = asn1.RawValue{FullBytes: token}
+	}
+	reply, err := asn1.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reply
+}
+
+func TestClient_Timestamp(t *testing.T) {
+	digest := sha256.Sum256([]byte("final video"))
+	genTime := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != contentTypeQuery {
+			http.Error(w, "bad content type", http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var req timeStampReq
+		if _, err := asn1.Unmarshal(body, &req); err != nil || req.Version != 1 || !req.CertReq {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", contentTypeReply)
+		w.Write(fakeReply(t, 0, req.MessageImprint.HashedMessage, genTime))
+	}))
+	defer server.Close()
+
+	token, err := New(server.URL, time.Second).Timestamp(context.Background(), digest[:])
+	if err != nil {
+		t.Fatalf("Timestamp: %v", err)
+	}
+	if !token.GenTime.Equal(genTime) {
+		t.Errorf("GenTime = %v, want %v", token.GenTime, genTime)
+	}
+	if len(token.DER) == 0 {
+		t.Error("token DER is empty")
+	}
+
+	if _, err := New(server.URL, time.Second).Timestamp(context.Background(), []byte("short")); err == nil {
+		t.Error("expected error for invalid digest length")
+	}
+}
+
+func TestParseReply(t *testing.T) {
+	digest := sha256.Sum256([]byte("final video"))
+	other := sha256.Sum256([]byte("other video"))
+	genTime := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	if _, err := ParseReply(fakeReply(t, 1, digest[:], genTime), digest[:]); err != nil {
+		t.Errorf("grantedWithMods: unexpected error %v", err)
+	}
+	if _, err := ParseReply(fakeReply(t, 2, digest[:], genTime), digest[:]); !errors.Is(err, ErrRejected) {
+		t.Errorf("rejection: got %v, want ErrRejected", err)
+	}
+	if _, err := ParseReply(fakeReply(t, 0, other[:], genTime), digest[:]); err == nil {
+		t.Error("expected error when token covers a different digest")
+	}
+	if _, err := ParseReply([]byte("not asn1"), digest[:]); err == nil {
+		t.Error("expected error for malformed reply")
+	}
+}
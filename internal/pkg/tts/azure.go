@@ -0,0 +1,210 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/pkg/environment"
+	"lemon/internal/pkg/tasklog"
+)
+
+const (
+	defaultAzureVoice        = "zh-CN-XiaoxiaoNeural"
+	defaultAzureLanguage     = "zh-CN"
+	defaultAzureOutputFormat = "audio-24khz-96kbitrate-mono-mp3"
+)
+
+// AzureConfig Azure 语音服务 TTS 配置
+type AzureConfig struct {
+	Endpoint string   // API 地址，默认: https://<Region>.tts.speech.microsoft.com/cognitiveservices/v1
+	Key      string   // 订阅密钥（必需）
+	Region   string   // 区域（如 eastasia，未配置 Endpoint 时必需）
+	Voice    string   // 默认音色，默认: zh-CN-XiaoxiaoNeural
+	Language string   // SSML 语言，默认: zh-CN
+	VoiceMap VoiceMap // 音色目录中的音色到 Azure 音色的映射
+}
+
+// AzureConfigFromEnv 从环境变量创建 AzureConfig
+// AZURE_SPEECH_KEY、AZURE_SPEECH_REGION、AZURE_SPEECH_ENDPOINT 按部署环境读取（见 environment.Getenv）
+// 支持的环境变量：
+//   - AZURE_SPEECH_KEY: 订阅密钥（必需）
+//   - AZURE_SPEECH_REGION: 区域（未配置 AZURE_SPEECH_ENDPOINT 时必需）
+//   - AZURE_SPEECH_ENDPOINT: API 地址（可选）
+//   - AZURE_TTS_VOICE: 默认音色（可选，默认: zh-CN-XiaoxiaoNeural）
+//   - AZURE_TTS_LANGUAGE: SSML 语言（可选，默认: zh-CN）
+//   - TTS_VOICE_MAP: 音色映射（可选，见 ParseVoiceMap）
+func AzureConfigFromEnv() AzureConfig {
+	return AzureConfig{
+		Endpoint: environment.Getenv("AZURE_SPEECH_ENDPOINT"),
+		Key:      environment.Getenv("AZURE_SPEECH_KEY"),
+		Region:   environment.Getenv("AZURE_SPEECH_REGION"),
+		Voice:    os.Getenv("AZURE_TTS_VOICE"),
+		Language: os.Getenv("AZURE_TTS_LANGUAGE"),
+		VoiceMap: VoiceMapFromEnv(),
+	}
+}
+
+// AzureClient Azure 语音服务 TTS 客户端
+// 使用 REST 接口按 SSML 合成 MP3；接口不返回时间戳，时长按 MP3 帧计算，字符时间戳按时长均匀估算
+// 参考: https://learn.microsoft.com/azure/ai-services/speech-service/rest-text-to-speech
+type AzureClient struct {
+	endpoint   string
+	key        string
+	voice      string
+	language   string
+	voiceMap   VoiceMap
+	httpClient *http.Client
+}
+
+// NewAzureClient 创建 Azure 语音服务 TTS 客户端
+func NewAzureClient(config AzureConfig) (*AzureClient, error) {
+	if config.Key == "" {
+		return nil, fmt.Errorf("Azure speech key is required")
+	}
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		if config.Region == "" {
+			return nil, fmt.Errorf("Azure speech region or endpoint is required")
+		}
+		endpoint = fmt.Sprintf("https://%s.tts.speech.microsoft.com/cognitiveservices/v1", config.Region)
+	}
+	voice := config.Voice
+	if voice == "" {
+		voice = defaultAzureVoice
+	}
+	language := config.Language
+	if language == "" {
+		language = defaultAzureLanguage
+	}
+
+	return &AzureClient{
+		endpoint: endpoint,
+		key:      config.Key,
+		voice:    voice,
+		language: language,
+		voiceMap: config.VoiceMap,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}, nil
+}
+
+// VoiceType 返回默认音色
+func (c *AzureClient) VoiceType() string {
+	return c.voice
+}
+
+// GenerateVoiceWithTimestampsForVoice 使用指定音色生成语音并获取时间戳
+// voiceType 按音色映射转换，不是 Azure 音色（如音色目录中未映射的音色）时使用默认音色
+func (c *AzureClient) GenerateVoiceWithTimestampsForVoice(
+	ctx context.Context,
+	text string,
+	speedRatio float64,
+	voiceType string,
+) (*Result, error) {
+	result := &Result{
+		Success: false,
+	}
+	voice := c.voiceMap.resolve(voiceType, c.voice, isAzureVoice)
+	ssml, err := c.buildSSML(text, speedRatio, voice)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("failed to build ssml: %v", err)
+		return result, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, strings.NewReader(ssml))
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("failed to create request: %v", err)
+		return result, err
+	}
+	req.Header.Set("Ocp-Apim-Subscription-Key", c.key)
+	req.Header.Set("Content-Type", "application/ssml+xml")
+	req.Header.Set("X-Microsoft-OutputFormat", defaultAzureOutputFormat)
+	req.Header.Set("User-Agent", "lemon")
+
+	log.Debug().
+		Str("voice", voice).
+		Str("text", text).
+		Msg("sending Azure TTS request")
+
+	taskLog := tasklog.FromContext(ctx)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("failed to send request: %v", err)
+		taskLog.Error("Azure TTS 请求失败", tasklog.Fields{"voice_type": voice, "error": err.Error()})
+		return result, err
+	}
+	defer resp.Body.Close()
+	// X-RequestId 为 Azure 的请求ID，排查问题时提供给服务方
+	taskLog.Info("Azure TTS 请求已响应", tasklog.Fields{
+		"request_id": resp.Header.Get("X-RequestId"),
+		"status":     resp.StatusCode,
+		"voice_type": voice,
+		"chars":      utf8.RuneCountInString(text),
+	})
+
+	audioData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("failed to read response: %v", err)
+		return result, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		result.ErrorMessage = fmt.Sprintf("API request failed, status: %d, body: %s", resp.StatusCode, string(audioData))
+		return result, fmt.Errorf("API request failed: status %d", resp.StatusCode)
+	}
+
+	duration, err := mp3Duration(audioData)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("failed to parse audio: %v", err)
+		return result, err
+	}
+
+	result.Success = true
+	result.AudioData = audioData
+	result.Duration = duration
+	result.TimestampData = evenTimestampData(text, duration)
+	return result, nil
+}
+
+// buildSSML 构建合成请求的 SSML，语速比例转换为 prosody 的相对语速（如 1.2 -> +20%）
+func (c *AzureClient) buildSSML(text string, speedRatio float64, voice string) (string, error) {
+	content, err := escapeXML(text)
+	if err != nil {
+		return "", err
+	}
+	// 音色可能来自小说配置，和文本一样转义
+	voice, err = escapeXML(voice)
+	if err != nil {
+		return "", err
+	}
+	if speedRatio > 0 && speedRatio != 1 {
+		content = fmt.Sprintf("<prosody rate=\"%+d%%\">%s</prosody>", int(math.Round((speedRatio-1)*100)), content)
+	}
+	return fmt.Sprintf("<speak version=\"1.0\" xmlns=\"http://www.w3.org/2001/10/synthesis\" xml:lang=\"%s\"><voice name=\"%s\">%s</voice></speak>",
+		c.language, voice, content), nil
+}
+
+// escapeXML 转义 XML 文本和属性值中的特殊字符
+func escapeXML(s string) (string, error) {
+	var buf bytes.Buffer
+	if err := xml.EscapeText(&buf, []byte(s)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// isAzureVoice Azure 音色名称的格式为 <语言>-<地区>-<名称>（如 zh-CN-XiaoxiaoNeural）
+func isAzureVoice(voice string) bool {
+	return strings.Count(voice, "-") >= 2
+}
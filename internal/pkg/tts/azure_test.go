@@ -0,0 +1,79 @@
+package tts
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testMP3 10 个 MPEG-1 Layer III 帧（约 0.261 秒）
+var testMP3 = mp3Frames([]byte{0xff, 0xfb, 0x90, 0x00}, 417, 10)
+
+func TestAzureClient_Generate(t *testing.T) {
+	var gotBody, gotKey, gotFormat string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		gotKey = r.Header.Get("Ocp-Apim-Subscription-Key")
+		gotFormat = r.Header.Get("X-Microsoft-OutputFormat")
+		_, _ = w.Write(testMP3)
+	}))
+	defer server.Close()
+
+	client, err := NewAzureClient(AzureConfig{
+		Endpoint: server.URL,
+		Key:      "secret",
+		VoiceMap: VoiceMap{"BV115_streaming": "zh-CN-XiaochenNeural"},
+	})
+	if err != nil {
+		t.Fatalf("NewAzureClient() error = %v", err)
+	}
+
+	result, err := client.GenerateVoiceWithTimestampsForVoice(context.Background(), "他说<好>", 1.2, "BV115_streaming")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if gotKey != "secret" || gotFormat != defaultAzureOutputFormat {
+		t.Errorf("headers: key = %q, format = %q", gotKey, gotFormat)
+	}
+	for _, want := range []string{`<voice name="zh-CN-XiaochenNeural">`, `<prosody rate="+20%">`, "他说&lt;好&gt;", `xml:lang="zh-CN"`} {
+		if !strings.Contains(gotBody, want) {
+			t.Errorf("ssml %q does not contain %q", gotBody, want)
+		}
+	}
+	if !result.Success || len(result.AudioData) != len(testMP3) {
+		t.Fatalf("result = %+v", result)
+	}
+	if result.Duration < 0.26 || result.Duration > 0.27 {
+		t.Errorf("duration = %v, want ~0.261", result.Duration)
+	}
+	if n := len(result.TimestampData.CharacterTimestamps); n != 5 {
+		t.Errorf("timestamps = %d, want 5", n)
+	}
+}
+
+func TestAzureClient_Errors(t *testing.T) {
+	if _, err := NewAzureClient(AzureConfig{Key: "secret"}); err == nil {
+		t.Error("expected error without region or endpoint")
+	}
+	if _, err := NewAzureClient(AzureConfig{Region: "eastasia"}); err == nil {
+		t.Error("expected error without key")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client, _ := NewAzureClient(AzureConfig{Endpoint: server.URL, Key: "secret"})
+	result, err := client.GenerateVoiceWithTimestampsForVoice(context.Background(), "你好", 1, "")
+	if err == nil || result.Success {
+		t.Fatalf("expected error, got %+v", result)
+	}
+	if !strings.Contains(result.ErrorMessage, "quota exceeded") {
+		t.Errorf("error message = %q", result.ErrorMessage)
+	}
+}
@@ -11,10 +11,13 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/rs/zerolog/log"
 
+	"lemon/internal/pkg/environment"
 	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/tasklog"
 )
 
 // Config TTS 配置
@@ -28,6 +31,7 @@ type Config struct {
 }
 
 // ConfigFromEnv 从环境变量创建 TTSConfig
+// TTS_ACCESS_TOKEN、TTS_APP_ID、TTS_API_URL 按部署环境读取（优先 TTS_ACCESS_TOKEN_<ENV>，见 environment.Getenv）
 // 支持的环境变量：
 //   - TTS_ACCESS_TOKEN: 访问令牌（必需）
 //   - TTS_APP_ID: 应用ID（可选）
@@ -36,11 +40,11 @@ type Config struct {
 //   - TTS_SAMPLE_RATE: 采样率（可选，默认: 44100）
 //   - TTS_API_URL: API 地址（可选，默认: https://openspeech.bytedance.com/api/v1/tts）
 func ConfigFromEnv() Config {
-	accessToken := os.Getenv("TTS_ACCESS_TOKEN")
-	appID := os.Getenv("TTS_APP_ID")
+	accessToken := environment.Getenv("TTS_ACCESS_TOKEN")
+	appID := environment.Getenv("TTS_APP_ID")
 	voiceType := os.Getenv("TTS_VOICE_TYPE")
 	cluster := os.Getenv("TTS_CLUSTER")
-	apiURL := os.Getenv("TTS_API_URL")
+	apiURL := environment.Getenv("TTS_API_URL")
 	sampleRateStr := os.Getenv("TTS_SAMPLE_RATE")
 
 	if voiceType == "" {
@@ -146,20 +150,70 @@ type CharTimestamp struct {
 	EndTime   float64 `json:"end_time"`   // 结束时间（秒）
 }
 
-// GenerateVoiceWithTimestamps 生成语音并获取时间戳
+// VoiceType 返回使用的音色
+func (c *Client) VoiceType() string {
+	return c.voiceType
+}
+
+// GenerateVoiceWithTimestamps 生成语音并获取时间戳（使用客户端配置的音色）
 // 返回音频数据和时长，不保存到文件
 func (c *Client) GenerateVoiceWithTimestamps(
 	ctx context.Context,
 	text string,
 	speedRatio float64,
+) (*Result, error) {
+	return c.GenerateVoiceWithTimestampsForVoice(ctx, text, speedRatio, c.voiceType)
+}
+
+// GenerateVoiceWithTimestampsForVoice 使用指定音色生成语音并获取时间戳
+// voiceType 为空时使用客户端配置的音色
+func (c *Client) GenerateVoiceWithTimestampsForVoice(
+	ctx context.Context,
+	text string,
+	speedRatio float64,
+	voiceType string,
+) (*Result, error) {
+	return c.synthesize(ctx, text, textTypePlain, text, speedRatio, voiceType)
+}
+
+// GenerateVoiceWithSSML 使用 SSML 生成语音并获取时间戳（如用 <phoneme> 标注多音字、人名的读音）
+// text 为 SSML 对应的纯文本，用于时间戳数据；voiceType 为空时使用客户端配置的音色
+func (c *Client) GenerateVoiceWithSSML(
+	ctx context.Context,
+	ssml string,
+	text string,
+	speedRatio float64,
+	voiceType string,
+) (*Result, error) {
+	return c.synthesize(ctx, ssml, textTypeSSML, text, speedRatio, voiceType)
+}
+
+// 请求文本类型
+const (
+	textTypePlain = "plain" // 纯文本
+	textTypeSSML  = "ssml"  // SSML 标记文本
+)
+
+// synthesize 调用 TTS 接口合成语音
+// input 为提交的文本（纯文本或 SSML），text 为对应的纯文本（用于时间戳数据）
+func (c *Client) synthesize(
+	ctx context.Context,
+	input string,
+	textType string,
+	text string,
+	speedRatio float64,
+	voiceType string,
 ) (*Result, error) {
 	result := &Result{
 		Success: false,
 	}
+	if voiceType == "" {
+		voiceType = c.voiceType
+	}
 
 	// 1. 构建请求配置
 	requestID := id.New()
-	requestConfig := c.buildRequestConfig(text, requestID, speedRatio)
+	requestConfig := c.buildRequestConfig(input, textType, requestID, speedRatio, voiceType)
 
 	// 2. 发送 HTTP 请求
 	reqBody, err := json.Marshal(requestConfig)
@@ -180,15 +234,26 @@ func (c *Client) GenerateVoiceWithTimestamps(
 
 	log.Debug().
 		Str("request_id", requestID).
-		Str("text", text).
+		Str("text", input).
+		Str("text_type", textType).
 		Msg("sending TTS request")
 
+	taskLog := tasklog.FromContext(ctx)
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		result.ErrorMessage = fmt.Sprintf("failed to send request: %v", err)
+		taskLog.Error("TTS 请求失败", tasklog.Fields{"request_id": requestID, "voice_type": voiceType, "error": err.Error()})
 		return result, err
 	}
 	defer resp.Body.Close()
+	// X-Tt-Logid 为火山引擎的服务端日志ID，排查问题时提供给服务方
+	taskLog.Info("TTS 请求已响应", tasklog.Fields{
+		"request_id": requestID,
+		"logid":      resp.Header.Get("X-Tt-Logid"),
+		"status":     resp.StatusCode,
+		"voice_type": voiceType,
+		"chars":      utf8.RuneCountInString(input),
+	})
 
 	// 3. 解析响应
 	respBody, err := io.ReadAll(resp.Body)
@@ -250,7 +315,7 @@ func (c *Client) GenerateVoiceWithTimestamps(
 
 // buildRequestConfig 构建请求配置
 // 参考官方文档: https://openspeech.bytedance.com/api/v1/tts
-func (c *Client) buildRequestConfig(text, requestID string, speedRatio float64) map[string]interface{} {
+func (c *Client) buildRequestConfig(text, textType, requestID string, speedRatio float64, voiceType string) map[string]interface{} {
 	appConfig := map[string]interface{}{
 		"token":   c.accessToken,
 		"cluster": c.cluster,
@@ -261,7 +326,7 @@ func (c *Client) buildRequestConfig(text, requestID string, speedRatio float64)
 
 	// 根据官方文档格式构建请求
 	audioConfig := map[string]interface{}{
-		"voice_type":       c.voiceType,
+		"voice_type":       voiceType,
 		"encoding":         "mp3",
 		"compression_rate": 1,
 		"rate":             c.sampleRate,
@@ -274,7 +339,7 @@ func (c *Client) buildRequestConfig(text, requestID string, speedRatio float64)
 	requestConfig := map[string]interface{}{
 		"reqid":            requestID,
 		"text":             text,
-		"text_type":        "plain",
+		"text_type":        textType,
 		"operation":        "query",
 		"silence_duration": "125",
 		"with_frontend":    "1",
@@ -0,0 +1,106 @@
+package tts
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// MP3 Layer III 帧头字段对应的比特率（kbps）和采样率
+var (
+	mp3BitratesV1 = [16]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0}
+	mp3BitratesV2 = [16]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0}
+
+	mp3SampleRates = map[byte][3]int{
+		3: {44100, 48000, 32000}, // MPEG-1
+		2: {22050, 24000, 16000}, // MPEG-2
+		0: {11025, 12000, 8000},  // MPEG-2.5
+	}
+)
+
+// mp3Duration 按帧头累计 MP3（Layer III）音频的时长（秒）
+// 不返回时间戳的 TTS 接口用它得到准确的音频时长（按文件大小和比特率估算在 VBR 编码时不准确）；无法识别帧时跳过一个字节重新同步
+func mp3Duration(data []byte) (float64, error) {
+	pos := id3v2Size(data)
+	var seconds float64
+	frames := 0
+	for pos+4 <= len(data) {
+		length, samples, sampleRate, ok := parseMP3FrameHeader(data[pos : pos+4])
+		if !ok || pos+length > len(data) {
+			pos++
+			continue
+		}
+		seconds += float64(samples) / float64(sampleRate)
+		frames++
+		pos += length
+	}
+	if frames == 0 {
+		return 0, fmt.Errorf("no mp3 frame found in %d bytes", len(data))
+	}
+	return seconds, nil
+}
+
+// id3v2Size 返回开头 ID3v2 标签的长度（没有标签时为 0）
+func id3v2Size(data []byte) int {
+	if len(data) < 10 || string(data[:3]) != "ID3" {
+		return 0
+	}
+	size := int(data[6]&0x7f)<<21 | int(data[7]&0x7f)<<14 | int(data[8]&0x7f)<<7 | int(data[9]&0x7f)
+	size += 10
+	if data[5]&0x10 != 0 { // 带页脚
+		size += 10
+	}
+	return size
+}
+
+// parseMP3FrameHeader 解析 Layer III 帧头，返回帧长度（字节）、每帧采样数和采样率
+func parseMP3FrameHeader(h []byte) (length, samples, sampleRate int, ok bool) {
+	if h[0] != 0xff || h[1]&0xe0 != 0xe0 {
+		return 0, 0, 0, false
+	}
+	version := (h[1] >> 3) & 0x03
+	layer := (h[1] >> 1) & 0x03
+	if version == 1 || layer != 1 { // 保留版本或不是 Layer III
+		return 0, 0, 0, false
+	}
+	rates := mp3SampleRates[version]
+	rateIndex := (h[2] >> 2) & 0x03
+	bitrateIndex := h[2] >> 4
+	if rateIndex == 3 {
+		return 0, 0, 0, false
+	}
+	sampleRate = rates[rateIndex]
+	padding := int((h[2] >> 1) & 0x01)
+
+	bitrate, samples := mp3BitratesV1[bitrateIndex], 1152
+	if version != 3 {
+		bitrate, samples = mp3BitratesV2[bitrateIndex], 576
+	}
+	if bitrate == 0 { // 自由格式或无效比特率
+		return 0, 0, 0, false
+	}
+	length = samples/8*bitrate*1000/sampleRate + padding
+	return length, samples, sampleRate, true
+}
+
+// evenTimestampData 按音频时长把字符时间戳均匀分配到每个字符（接口不返回时间戳时的估算，字幕按句切分时足够使用）
+func evenTimestampData(text string, duration float64) *TimestampData {
+	runes := []rune(text)
+	timestamps := make([]CharTimestamp, 0, len(runes))
+	if len(runes) > 0 {
+		perRune := duration / float64(len(runes))
+		for i, r := range runes {
+			timestamps = append(timestamps, CharTimestamp{
+				Character: string(r),
+				StartTime: math.Round(float64(i)*perRune*1000) / 1000,
+				EndTime:   math.Round(float64(i+1)*perRune*1000) / 1000,
+			})
+		}
+	}
+	return &TimestampData{
+		Text:                text,
+		Duration:            duration,
+		CharacterTimestamps: timestamps,
+		GeneratedAt:         time.Now(),
+	}
+}
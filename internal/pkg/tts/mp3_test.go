@@ -0,0 +1,87 @@
+package tts
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+// mp3Frames 生成 n 个指定帧头的空 MP3 帧
+func mp3Frames(header []byte, length, n int) []byte {
+	frame := make([]byte, length)
+	copy(frame, header)
+	return bytes.Repeat(frame, n)
+}
+
+func TestMP3Duration(t *testing.T) {
+	// MPEG-1 Layer III，128kbps，44.1kHz：帧长 417 字节，每帧 1152 个采样
+	mpeg1 := mp3Frames([]byte{0xff, 0xfb, 0x90, 0x00}, 417, 10)
+	// MPEG-2 Layer III，48kbps，24kHz：帧长 144 字节，每帧 576 个采样
+	mpeg2 := mp3Frames([]byte{0xff, 0xf3, 0x64, 0x00}, 144, 50)
+
+	// ID3v2 标签（内容 20 字节）
+	id3 := append([]byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, 20}, make([]byte, 20)...)
+
+	tests := []struct {
+		name string
+		data []byte
+		want float64
+	}{
+		{"mpeg1", mpeg1, 10 * 1152.0 / 44100},
+		{"mpeg2", mpeg2, 50 * 576.0 / 24000},
+		{"id3 tag", append(append([]byte{}, id3...), mpeg1...), 10 * 1152.0 / 44100},
+		{"leading garbage", append([]byte{0x00, 0xff, 0x01}, mpeg2...), 50 * 576.0 / 24000},
+	}
+	for _, tt := range tests {
+		got, err := mp3Duration(tt.data)
+		if err != nil {
+			t.Fatalf("%s: mp3Duration() error = %v", tt.name, err)
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: mp3Duration() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := mp3Duration([]byte("not an mp3")); err == nil {
+		t.Error("expected error for data without mp3 frames")
+	}
+}
+
+func TestEvenTimestampData(t *testing.T) {
+	data := evenTimestampData("你好，世界", 1.0)
+	if len(data.CharacterTimestamps) != 5 {
+		t.Fatalf("timestamps = %d, want 5", len(data.CharacterTimestamps))
+	}
+	first, last := data.CharacterTimestamps[0], data.CharacterTimestamps[4]
+	if first.Character != "你" || first.StartTime != 0 || first.EndTime != 0.2 {
+		t.Errorf("first = %+v", first)
+	}
+	if last.Character != "界" || last.StartTime != 0.8 || last.EndTime != 1.0 {
+		t.Errorf("last = %+v", last)
+	}
+	if data.Duration != 1.0 || data.Text != "你好，世界" {
+		t.Errorf("data = %+v", data)
+	}
+}
+
+func TestVoiceMap_Resolve(t *testing.T) {
+	m := ParseVoiceMap("BV115_streaming=zh-CN-XiaoxiaoNeural, BV002_streaming = zh-CN-YunxiNeural,broken,=x")
+	if len(m) != 2 {
+		t.Fatalf("ParseVoiceMap() = %v, want 2 entries", m)
+	}
+
+	tests := []struct {
+		voiceType string
+		want      string
+	}{
+		{"", "zh-CN-XiaoyiNeural"},
+		{"BV002_streaming", "zh-CN-YunxiNeural"},
+		{"zh-CN-YunjianNeural", "zh-CN-YunjianNeural"},
+		{"BV700_streaming", "zh-CN-XiaoyiNeural"},
+	}
+	for _, tt := range tests {
+		if got := m.resolve(tt.voiceType, "zh-CN-XiaoyiNeural", isAzureVoice); got != tt.want {
+			t.Errorf("resolve(%q) = %q, want %q", tt.voiceType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/pkg/environment"
+	"lemon/internal/pkg/tasklog"
+)
+
+const (
+	defaultOpenAIBaseURL = "https://api.openai.com/v1"
+	defaultOpenAIModel   = "gpt-4o-mini-tts"
+	defaultOpenAIVoice   = "alloy"
+	// openAIMinSpeed/openAIMaxSpeed 接口支持的语速范围
+	openAIMinSpeed = 0.25
+	openAIMaxSpeed = 4.0
+)
+
+// openAIVoices OpenAI TTS 的内置音色
+var openAIVoices = []string{"alloy", "ash", "ballad", "coral", "echo", "fable", "nova", "onyx", "sage", "shimmer", "verse"}
+
+// OpenAIConfig OpenAI TTS 配置
+type OpenAIConfig struct {
+	BaseURL  string   // API 地址，默认: https://api.openai.com/v1（兼容 OpenAI 接口的服务可替换）
+	APIKey   string   // API 密钥（必需）
+	Model    string   // 模型，默认: gpt-4o-mini-tts
+	Voice    string   // 默认音色，默认: alloy
+	VoiceMap VoiceMap // 音色目录中的音色到 OpenAI 音色的映射
+}
+
+// OpenAIConfigFromEnv 从环境变量创建 OpenAIConfig
+// OPENAI_API_KEY、OPENAI_BASE_URL 按部署环境读取（见 environment.Getenv）
+// 支持的环境变量：
+//   - OPENAI_API_KEY: API 密钥（必需）
+//   - OPENAI_BASE_URL: API 地址（可选，默认: https://api.openai.com/v1）
+//   - OPENAI_TTS_MODEL: 模型（可选，默认: gpt-4o-mini-tts）
+//   - OPENAI_TTS_VOICE: 默认音色（可选，默认: alloy）
+//   - TTS_VOICE_MAP: 音色映射（可选，见 ParseVoiceMap）
+func OpenAIConfigFromEnv() OpenAIConfig {
+	return OpenAIConfig{
+		BaseURL:  environment.Getenv("OPENAI_BASE_URL"),
+		APIKey:   environment.Getenv("OPENAI_API_KEY"),
+		Model:    os.Getenv("OPENAI_TTS_MODEL"),
+		Voice:    os.Getenv("OPENAI_TTS_VOICE"),
+		VoiceMap: VoiceMapFromEnv(),
+	}
+}
+
+// OpenAIClient OpenAI TTS 客户端
+// 接口不返回时间戳，时长按 MP3 帧计算，字符时间戳按时长均匀估算
+// 参考: https://platform.openai.com/docs/api-reference/audio/createSpeech
+type OpenAIClient struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	voice      string
+	voiceMap   VoiceMap
+	httpClient *http.Client
+}
+
+// NewOpenAIClient 创建 OpenAI TTS 客户端
+func NewOpenAIClient(config OpenAIConfig) (*OpenAIClient, error) {
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("OpenAI API key is required")
+	}
+	baseURL := strings.TrimRight(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	model := config.Model
+	if model == "" {
+		model = defaultOpenAIModel
+	}
+	voice := config.Voice
+	if voice == "" {
+		voice = defaultOpenAIVoice
+	}
+
+	return &OpenAIClient{
+		baseURL:  baseURL,
+		apiKey:   config.APIKey,
+		model:    model,
+		voice:    voice,
+		voiceMap: config.VoiceMap,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}, nil
+}
+
+// Model 返回使用的模型
+func (c *OpenAIClient) Model() string {
+	return c.model
+}
+
+// VoiceType 返回默认音色
+func (c *OpenAIClient) VoiceType() string {
+	return c.voice
+}
+
+// openAISpeechRequest 语音合成请求
+type openAISpeechRequest struct {
+	Model          string  `json:"model"`
+	Input          string  `json:"input"`
+	Voice          string  `json:"voice"`
+	ResponseFormat string  `json:"response_format"`
+	Speed          float64 `json:"speed"`
+}
+
+// GenerateVoiceWithTimestampsForVoice 使用指定音色生成语音并获取时间戳
+// voiceType 按音色映射转换，不是 OpenAI 内置音色（如音色目录中未映射的音色）时使用默认音色；语速限制在接口支持的范围内
+func (c *OpenAIClient) GenerateVoiceWithTimestampsForVoice(
+	ctx context.Context,
+	text string,
+	speedRatio float64,
+	voiceType string,
+) (*Result, error) {
+	result := &Result{
+		Success: false,
+	}
+	if speedRatio <= 0 {
+		speedRatio = 1.0
+	}
+	voice := c.voiceMap.resolve(voiceType, c.voice, isOpenAIVoice)
+	reqBody, err := json.Marshal(openAISpeechRequest{
+		Model:          c.model,
+		Input:          text,
+		Voice:          voice,
+		ResponseFormat: "mp3",
+		Speed:          min(max(speedRatio, openAIMinSpeed), openAIMaxSpeed),
+	})
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("failed to marshal request: %v", err)
+		return result, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/audio/speech", bytes.NewReader(reqBody))
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("failed to create request: %v", err)
+		return result, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	log.Debug().
+		Str("model", c.model).
+		Str("voice", voice).
+		Str("text", text).
+		Msg("sending OpenAI TTS request")
+
+	taskLog := tasklog.FromContext(ctx)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("failed to send request: %v", err)
+		taskLog.Error("OpenAI TTS 请求失败", tasklog.Fields{"voice_type": voice, "error": err.Error()})
+		return result, err
+	}
+	defer resp.Body.Close()
+	// X-Request-Id 为 OpenAI 的请求ID，排查问题时提供给服务方
+	taskLog.Info("OpenAI TTS 请求已响应", tasklog.Fields{
+		"request_id": resp.Header.Get("X-Request-Id"),
+		"status":     resp.StatusCode,
+		"voice_type": voice,
+		"chars":      utf8.RuneCountInString(text),
+	})
+
+	audioData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("failed to read response: %v", err)
+		return result, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		result.ErrorMessage = fmt.Sprintf("API request failed, status: %d, body: %s", resp.StatusCode, string(audioData))
+		return result, fmt.Errorf("API request failed: status %d", resp.StatusCode)
+	}
+
+	duration, err := mp3Duration(audioData)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("failed to parse audio: %v", err)
+		return result, err
+	}
+
+	result.Success = true
+	result.AudioData = audioData
+	result.Duration = duration
+	result.TimestampData = evenTimestampData(text, duration)
+	return result, nil
+}
+
+// isOpenAIVoice 是否为 OpenAI 内置音色
+func isOpenAIVoice(voice string) bool {
+	return slices.Contains(openAIVoices, voice)
+}
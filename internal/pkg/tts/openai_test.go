@@ -0,0 +1,46 @@
+package tts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAIClient_Generate(t *testing.T) {
+	var got openAISpeechRequest
+	var gotPath, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write(testMP3)
+	}))
+	defer server.Close()
+
+	client, err := NewOpenAIClient(OpenAIConfig{BaseURL: server.URL + "/v1/", APIKey: "sk-test"})
+	if err != nil {
+		t.Fatalf("NewOpenAIClient() error = %v", err)
+	}
+
+	// 音色目录中未映射的音色使用默认音色，语速限制在接口支持的范围内
+	result, err := client.GenerateVoiceWithTimestampsForVoice(context.Background(), "你好", 5, "BV115_streaming")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if gotPath != "/v1/audio/speech" || gotAuth != "Bearer sk-test" {
+		t.Errorf("path = %q, auth = %q", gotPath, gotAuth)
+	}
+	want := openAISpeechRequest{Model: defaultOpenAIModel, Input: "你好", Voice: defaultOpenAIVoice, ResponseFormat: "mp3", Speed: openAIMaxSpeed}
+	if got != want {
+		t.Errorf("request = %+v, want %+v", got, want)
+	}
+	if !result.Success || result.Duration <= 0 || len(result.TimestampData.CharacterTimestamps) != 2 {
+		t.Errorf("result = %+v", result)
+	}
+
+	if _, err := client.GenerateVoiceWithTimestampsForVoice(context.Background(), "你好", 1, "nova"); err != nil || got.Voice != "nova" || got.Speed != 1 {
+		t.Errorf("native voice: err = %v, request = %+v", err, got)
+	}
+}
@@ -0,0 +1,43 @@
+package tts
+
+import (
+	"os"
+	"strings"
+)
+
+// VoiceMap 音色映射：音色目录中的音色（如 BV115_streaming）-> 其他 TTS 提供者的音色
+// 切换 TTS 提供者后，小说和角色已配置的音色按映射换成新提供者的音色，不需要修改小说配置
+type VoiceMap map[string]string
+
+// ParseVoiceMap 解析音色映射，格式为逗号分隔的 "源音色=目标音色"（如 "BV115_streaming=zh-CN-XiaoxiaoNeural,BV002_streaming=zh-CN-YunxiNeural"）
+// 格式不正确的项被忽略
+func ParseVoiceMap(s string) VoiceMap {
+	m := make(VoiceMap)
+	for _, item := range strings.Split(s, ",") {
+		from, to, ok := strings.Cut(item, "=")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if ok && from != "" && to != "" {
+			m[from] = to
+		}
+	}
+	return m
+}
+
+// VoiceMapFromEnv 从环境变量 TTS_VOICE_MAP 读取音色映射
+func VoiceMapFromEnv() VoiceMap {
+	return ParseVoiceMap(os.Getenv("TTS_VOICE_MAP"))
+}
+
+// resolve 返回实际使用的音色：映射中的音色按映射替换，native 判断为提供者自己的音色时原样使用，其余（如其他提供者的音色）使用默认音色
+func (m VoiceMap) resolve(voiceType, defaultVoice string, native func(string) bool) string {
+	if voiceType == "" {
+		return defaultVoice
+	}
+	if mapped, ok := m[voiceType]; ok {
+		return mapped
+	}
+	if native(voiceType) {
+		return voiceType
+	}
+	return defaultVoice
+}
@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrInvalidURL 通知地址不是 http(s) 地址或无法解析
+	ErrInvalidURL = errors.New("invalid webhook url")
+	// ErrForbiddenAddress 通知地址指向内网、回环或链路本地地址（不重试）
+	ErrForbiddenAddress = errors.New("webhook address not allowed")
+)
+
+// blockedPrefixes IsGlobalUnicast/IsPrivate 之外不允许连接的地址段
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // 本网络
+	netip.MustParsePrefix("100.64.0.0/10"), // 运营商级 NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF 协议分配
+	netip.MustParsePrefix("198.18.0.0/15"), // 基准测试
+	netip.MustParsePrefix("240.0.0.0/4"),   // 保留地址
+}
+
+// checkAddr 只允许公网单播地址
+func checkAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+	}
+	for _, p := range blockedPrefixes {
+		if p.Contains(addr) {
+			return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+		}
+	}
+	return nil
+}
+
+// ValidateURL 校验通知地址：必须是 http(s) 地址，且主机名解析到的所有地址都是公网地址
+// 注册时调用；发送时由 DefaultClient 在建立连接前再次校验实际连接的地址
+func ValidateURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("%w: url must be an http(s) address", ErrInvalidURL)
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("%w: resolve %s: %v", ErrInvalidURL, u.Hostname(), err)
+	}
+	for _, addr := range addrs {
+		if err := checkAddr(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publicDialer 建立连接前校验实际连接的地址，防止注册后域名改为解析到内网地址
+var publicDialer = &net.Dialer{
+	Timeout:   10 * time.Second,
+	KeepAlive: 30 * time.Second,
+	Control: func(_, address string, _ syscall.RawConn) error {
+		addrPort, err := netip.ParseAddrPort(address)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+		}
+		return checkAddr(addrPort.Addr())
+	},
+}
+
+// DefaultClient 投递通知使用的 HTTP 客户端：不走环境变量中的代理，只连接公网地址（重定向同样校验）
+var DefaultClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext:           publicDialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	},
+}
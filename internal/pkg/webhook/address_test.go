@@ -0,0 +1,59 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr error
+	}{
+		{"https://93.184.216.34/hook", nil},
+		{"http://[2606:2800:220:1:248:1893:25c8:1946]:8080/hook", nil},
+		{"ftp://93.184.216.34/hook", ErrInvalidURL},
+		{"http:///hook", ErrInvalidURL},
+		{"http://127.0.0.1:8080/hook", ErrForbiddenAddress},
+		{"http://localhost/hook", ErrForbiddenAddress},
+		{"http://10.0.0.8/hook", ErrForbiddenAddress},
+		{"http://172.16.3.4/hook", ErrForbiddenAddress},
+		{"http://192.168.1.1/hook", ErrForbiddenAddress},
+		{"http://169.254.169.254/latest/meta-data", ErrForbiddenAddress},
+		{"http://100.64.0.1/hook", ErrForbiddenAddress},
+		{"http://0.0.0.0/hook", ErrForbiddenAddress},
+		{"http://[::1]/hook", ErrForbiddenAddress},
+		{"http://[fe80::1]/hook", ErrForbiddenAddress},
+		{"http://[fd00::1]/hook", ErrForbiddenAddress},
+		{"http://[::ffff:127.0.0.1]/hook", ErrForbiddenAddress},
+	}
+	for _, tt := range tests {
+		err := ValidateURL(context.Background(), tt.url)
+		if tt.wantErr == nil && err != nil {
+			t.Errorf("ValidateURL(%q) error = %v, want nil", tt.url, err)
+		}
+		if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+			t.Errorf("ValidateURL(%q) error = %v, want %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSendDefaultClientRejectsLoopback(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	// 注册时通过校验的域名之后解析到回环地址：发送时在建立连接前拒绝
+	result, err := Send(context.Background(), nil, &Request{URL: srv.URL, Secret: "s", Body: []byte("{}")})
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("Send error = %v, want ErrForbiddenAddress", err)
+	}
+	if called || result.StatusCode != 0 {
+		t.Errorf("request reached the server: called = %v, status = %d", called, result.StatusCode)
+	}
+}
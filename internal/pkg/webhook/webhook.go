@@ -0,0 +1,120 @@
+// Package webhook 任务完成通知的签名、投递和重试退避
+// 请求体使用 HMAC-SHA256 签名：签名内容为 "<时间戳>.<请求体>"，接收方用注册时返回的密钥校验并拒绝过期的时间戳
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 请求头
+const (
+	HeaderEvent     = "X-Lemon-Event"     // 事件类型
+	HeaderDelivery  = "X-Lemon-Delivery"  // 投递ID（重试时不变，接收方可用于去重）
+	HeaderTimestamp = "X-Lemon-Timestamp" // 签名时间（Unix 秒）
+	HeaderSignature = "X-Lemon-Signature" // 签名：sha256=<hex>
+)
+
+const (
+	// signaturePrefix 签名前缀
+	signaturePrefix = "sha256="
+	// maxResponseBody 记录的响应体最大字节数
+	maxResponseBody = 512
+	// baseBackoff 第一次重试的等待时间
+	baseBackoff = 30 * time.Second
+	// maxBackoff 重试等待时间上限
+	maxBackoff = time.Hour
+)
+
+// NewSecret 生成签名密钥（32 字节随机数的十六进制）
+func NewSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate secret: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Sign 计算请求体签名，返回 "sha256=<hex>"
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 校验签名（供接收方和测试使用）
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Backoff 返回第 attempt 次失败（从 1 开始）后到下一次重试的等待时间：30 秒起按 4 倍递增，最长 1 小时
+func Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := baseBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 4
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
+
+// Request 一次投递请求
+type Request struct {
+	URL        string
+	Secret     string
+	Event      string
+	DeliveryID string
+	Body       []byte
+}
+
+// Result 一次投递的结果
+type Result struct {
+	StatusCode int    // 响应状态码（请求未发出时为 0）
+	Response   string // 响应体（截断到 512 字节）
+}
+
+// Send 发送一次签名的 POST 请求；响应状态码不是 2xx 时返回错误
+// client 为 nil 时使用 DefaultClient（只连接公网地址）
+func Send(ctx context.Context, client *http.Client, req *Request) (*Result, error) {
+	if client == nil {
+		client = DefaultClient
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return &Result{}, fmt.Errorf("build request: %w", err)
+	}
+	timestamp := time.Now().Unix()
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(HeaderEvent, req.Event)
+	httpReq.Header.Set(HeaderDelivery, req.DeliveryID)
+	httpReq.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	httpReq.Header.Set(HeaderSignature, Sign(req.Secret, timestamp, req.Body))
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return &Result{}, fmt.Errorf("post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	result := &Result{StatusCode: resp.StatusCode, Response: string(respBody)}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return result, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return result, nil
+}
@@ -0,0 +1,104 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"status":"completed"}`)
+	sig := Sign("secret", 1700000000, body)
+
+	if !Verify("secret", 1700000000, body, sig) {
+		t.Error("Verify should accept the signature it produced")
+	}
+	if Verify("other", 1700000000, body, sig) {
+		t.Error("Verify should reject a different secret")
+	}
+	if Verify("secret", 1700000001, body, sig) {
+		t.Error("Verify should reject a different timestamp")
+	}
+	if Verify("secret", 1700000000, []byte(`{"status":"failed"}`), sig) {
+		t.Error("Verify should reject a different body")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, 2 * time.Minute},
+		{3, 8 * time.Minute},
+		{4, 32 * time.Minute},
+		{5, time.Hour},
+		{20, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := Backoff(tt.attempt); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	var gotBody []byte
+	var gotHeader http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotHeader = r.Header
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	body := []byte(`{"task_type":"audio"}`)
+	result, err := Send(context.Background(), srv.Client(), &Request{
+		URL:        srv.URL,
+		Secret:     "secret",
+		Event:      "task.completed",
+		DeliveryID: "d-1",
+		Body:       body,
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if result.StatusCode != http.StatusAccepted || result.Response != "ok" {
+		t.Errorf("result = %+v", result)
+	}
+	if string(gotBody) != string(body) {
+		t.Errorf("body = %s", gotBody)
+	}
+	if gotHeader.Get(HeaderEvent) != "task.completed" || gotHeader.Get(HeaderDelivery) != "d-1" {
+		t.Errorf("unexpected headers: %v", gotHeader)
+	}
+	ts, err := strconv.ParseInt(gotHeader.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("timestamp header: %v", err)
+	}
+	if !Verify("secret", ts, gotBody, gotHeader.Get(HeaderSignature)) {
+		t.Error("signature header does not verify")
+	}
+}
+
+func TestSendNon2xx(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	result, err := Send(context.Background(), srv.Client(), &Request{URL: srv.URL, Secret: "s", Body: []byte("{}")})
+	if err == nil {
+		t.Fatal("Send should fail on 500")
+	}
+	if result.StatusCode != http.StatusInternalServerError {
+		t.Errorf("StatusCode = %d, want 500", result.StatusCode)
+	}
+}
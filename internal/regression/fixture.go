@@ -0,0 +1,109 @@
+// Package regression 基准小说回归测试
+// 使用合成 provider 把一部小型基准小说完整跑一遍生成流水线（解说 → 音频 → 字幕 → 图片 → 解说视频 → 最终视频），
+// 把产物的结构、时长和校验和与保存的基准快照比较，报告偏差。用于发布前检查
+package regression
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/noveltools/providers"
+)
+
+const (
+	// ManifestFile 基准小说描述文件
+	ManifestFile = "manifest.json"
+	// ExpectedFile 基准快照文件（首次运行或产物有预期变化时使用 --update 重新生成）
+	ExpectedFile = "expected.json"
+)
+
+// ErrNoExpectedSnapshot 基准目录下还没有基准快照
+var ErrNoExpectedSnapshot = errors.New("expected snapshot not found")
+
+// Manifest 基准小说描述
+type Manifest struct {
+	UserID         string              `json:"user_id"`         // 创建小说使用的用户ID
+	NovelFile      string              `json:"novel_file"`      // 小说原文文件（相对基准目录）
+	TargetChapters int                 `json:"target_chapters"` // 切分章节数
+	NarrationType  novel.NarrationType `json:"narration_type"`
+	Style          novel.NovelStyle    `json:"style"`
+	Responses      []ResponseFile      `json:"responses"` // 合成 LLM 的预置回复
+}
+
+// ResponseFile 合成 LLM 预置回复：提示词包含 Match 时返回 File 的内容
+type ResponseFile struct {
+	Match string `json:"match"`
+	File  string `json:"file"` // 回复内容文件（相对基准目录）
+}
+
+// Fixture 加载后的基准小说
+type Fixture struct {
+	Dir       string
+	Manifest  Manifest
+	NovelText []byte
+	Responses []providers.SyntheticLLMResponse
+}
+
+// LoadFixture 加载基准目录
+func LoadFixture(dir string) (*Fixture, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	f := &Fixture{Dir: dir}
+	if err := json.Unmarshal(data, &f.Manifest); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	if f.Manifest.NovelFile == "" {
+		return nil, fmt.Errorf("manifest: novel_file is required")
+	}
+
+	f.NovelText, err = os.ReadFile(filepath.Join(dir, f.Manifest.NovelFile))
+	if err != nil {
+		return nil, fmt.Errorf("read novel file: %w", err)
+	}
+
+	for _, r := range f.Manifest.Responses {
+		content, err := os.ReadFile(filepath.Join(dir, r.File))
+		if err != nil {
+			return nil, fmt.Errorf("read response file %s: %w", r.File, err)
+		}
+		f.Responses = append(f.Responses, providers.SyntheticLLMResponse{
+			Match:    r.Match,
+			Response: string(content),
+		})
+	}
+	return f, nil
+}
+
+// LoadExpected 读取基准快照，不存在时返回 ErrNoExpectedSnapshot
+func (f *Fixture) LoadExpected() (*Snapshot, error) {
+	data, err := os.ReadFile(filepath.Join(f.Dir, ExpectedFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNoExpectedSnapshot
+		}
+		return nil, fmt.Errorf("read expected snapshot: %w", err)
+	}
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parse expected snapshot: %w", err)
+	}
+	return &s, nil
+}
+
+// SaveExpected 把快照写为新的基准快照
+func (f *Fixture) SaveExpected(s *Snapshot) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal snapshot: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(f.Dir, ExpectedFile), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write expected snapshot: %w", err)
+	}
+	return nil
+}
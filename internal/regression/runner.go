@@ -0,0 +1,198 @@
+package regression
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/service"
+	novelService "lemon/internal/service/novel"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Runner 在给定的服务上跑一遍基准小说并生成快照
+// 服务应使用独立的数据库和存储，并注入合成 provider（见 providers.NewSynthetic*）
+type Runner struct {
+	novelService    novelService.NovelService
+	resourceService service.ResourceService
+}
+
+// NewRunner 创建回归运行器
+func NewRunner(novelSvc novelService.NovelService, resourceSvc service.ResourceService) *Runner {
+	return &Runner{novelService: novelSvc, resourceService: resourceSvc}
+}
+
+// Run 上传基准小说，完整执行生成流水线并返回产物快照
+func (r *Runner) Run(ctx context.Context, f *Fixture) (*Snapshot, error) {
+	m := f.Manifest
+	narrationType := m.NarrationType
+	if narrationType == "" {
+		narrationType = novel.NarrationTypeNarration
+	}
+	style := m.Style
+	if style == "" {
+		style = novel.NovelStyleAnime
+	}
+
+	upload, err := r.resourceService.UploadFile(ctx, &service.UploadFileRequest{
+		UserID:      m.UserID,
+		FileName:    filepath.Base(m.NovelFile),
+		ContentType: "text/plain",
+		Ext:         "txt",
+		Data:        bytes.NewReader(f.NovelText),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("upload novel: %w", err)
+	}
+
+	novelID, err := r.novelService.CreateNovelFromResource(ctx, upload.ResourceID, m.UserID, narrationType, style)
+	if err != nil {
+		return nil, fmt.Errorf("create novel: %w", err)
+	}
+	if err := r.novelService.SplitNovelIntoChapters(ctx, novelID, m.TargetChapters); err != nil {
+		return nil, fmt.Errorf("split chapters: %w", err)
+	}
+	chapters, err := r.novelService.GetChapters(ctx, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("get chapters: %w", err)
+	}
+
+	snapshot := &Snapshot{}
+	for _, chapter := range chapters {
+		log.Info().Int("chapter_sequence", chapter.Sequence).Str("title", chapter.Title).Msg("回归测试：处理章节")
+		cs, err := r.runChapter(ctx, chapter)
+		if err != nil {
+			return nil, fmt.Errorf("chapter %d: %w", chapter.Sequence, err)
+		}
+		snapshot.Chapters = append(snapshot.Chapters, cs)
+	}
+	return snapshot, nil
+}
+
+// runChapter 对单个章节执行生成流水线并记录产物
+func (r *Runner) runChapter(ctx context.Context, chapter *novel.Chapter) (*ChapterSnapshot, error) {
+	narration, _, err := r.novelService.GenerateNarrationForChapterWithMeta(ctx, chapter.ID)
+	if err != nil {
+		return nil, fmt.Errorf("generate narration: %w", err)
+	}
+	if _, err := r.novelService.GenerateAudiosForNarration(ctx, narration.ID); err != nil {
+		return nil, fmt.Errorf("generate audios: %w", err)
+	}
+	if _, err := r.novelService.GenerateSubtitlesForNarration(ctx, narration.ID); err != nil {
+		return nil, fmt.Errorf("generate subtitles: %w", err)
+	}
+	if _, err := r.novelService.GenerateImagesForNarration(ctx, narration.ID); err != nil {
+		return nil, fmt.Errorf("generate images: %w", err)
+	}
+	if _, err := r.novelService.GenerateNarrationVideosForChapter(ctx, chapter.ID); err != nil {
+		return nil, fmt.Errorf("generate narration videos: %w", err)
+	}
+	if _, err := r.novelService.GenerateFinalVideoForChapter(ctx, chapter.ID); err != nil {
+		return nil, fmt.Errorf("generate final video: %w", err)
+	}
+
+	cs := &ChapterSnapshot{
+		Sequence:  chapter.Sequence,
+		Title:     chapter.Title,
+		WordCount: chapter.WordCount,
+	}
+
+	scenes, err := r.novelService.GetScenesByNarrationID(ctx, narration.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get scenes: %w", err)
+	}
+	cs.Scenes = len(scenes)
+
+	shots, err := r.novelService.GetShotsByNarrationID(ctx, narration.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get shots: %w", err)
+	}
+	var narrationText strings.Builder
+	for _, shot := range shots {
+		cs.Shots = append(cs.Shots, shot.SceneNumber+"-"+shot.ShotNumber)
+		narrationText.WriteString(shot.Narration)
+		narrationText.WriteString("\n")
+	}
+	cs.NarrationChecksum = checksum([]byte(narrationText.String()))
+
+	audios, _, err := r.novelService.ListAudiosByNarration(ctx, narration.ID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("list audios: %w", err)
+	}
+	for _, audio := range audios {
+		sum, err := r.resourceChecksum(ctx, audio.AudioResourceID)
+		if err != nil {
+			return nil, fmt.Errorf("audio %d: %w", audio.Sequence, err)
+		}
+		cs.Audios = append(cs.Audios, MediaSnapshot{Sequence: audio.Sequence, Duration: audio.Duration, Checksum: sum})
+	}
+
+	subtitles, _, err := r.novelService.ListSubtitlesByNarration(ctx, narration.ID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("list subtitles: %w", err)
+	}
+	for _, subtitle := range subtitles {
+		sum, err := r.resourceChecksum(ctx, subtitle.SubtitleResourceID)
+		if err != nil {
+			return nil, fmt.Errorf("subtitle %d: %w", subtitle.Sequence, err)
+		}
+		cs.Subtitles = append(cs.Subtitles, MediaSnapshot{Sequence: subtitle.Sequence, Checksum: sum})
+	}
+
+	images, _, err := r.novelService.ListImagesByNarration(ctx, narration.ID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("list images: %w", err)
+	}
+	for _, image := range images {
+		sum, err := r.resourceChecksum(ctx, image.ImageResourceID)
+		if err != nil {
+			return nil, fmt.Errorf("image %d: %w", image.Sequence, err)
+		}
+		cs.Images = append(cs.Images, MediaSnapshot{Sequence: image.Sequence, Checksum: sum})
+	}
+
+	// 视频编码结果依赖 FFmpeg 版本，只记录时长
+	videos, _, err := r.novelService.ListVideosByChapter(ctx, chapter.ID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("list videos: %w", err)
+	}
+	for _, video := range videos {
+		item := MediaSnapshot{Sequence: video.Sequence, Duration: video.Duration}
+		switch video.VideoType {
+		case novel.VideoTypeNarration:
+			cs.NarrationVideos = append(cs.NarrationVideos, item)
+		case novel.VideoTypeFinal:
+			cs.FinalVideo = &item
+		}
+	}
+	return cs, nil
+}
+
+// resourceChecksum 下载资源并计算内容校验和
+func (r *Runner) resourceChecksum(ctx context.Context, resourceID string) (string, error) {
+	if resourceID == "" {
+		return "", nil
+	}
+	result, err := r.resourceService.DownloadFile(ctx, &service.DownloadFileRequest{ResourceID: resourceID})
+	if err != nil {
+		return "", fmt.Errorf("download resource %s: %w", resourceID, err)
+	}
+	defer result.Data.Close()
+	data, err := io.ReadAll(result.Data)
+	if err != nil {
+		return "", fmt.Errorf("read resource %s: %w", resourceID, err)
+	}
+	return checksum(data), nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,122 @@
+package regression
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Snapshot 一次回归运行的产物快照
+// 只记录与运行环境无关的内容：不记录ID和时间；视频编码结果依赖 FFmpeg 版本，只记录时长不记录校验和
+type Snapshot struct {
+	Chapters []*ChapterSnapshot `json:"chapters"`
+}
+
+// ChapterSnapshot 单个章节的产物快照
+type ChapterSnapshot struct {
+	Sequence          int             `json:"sequence"`
+	Title             string          `json:"title"`
+	WordCount         int             `json:"word_count"`
+	Scenes            int             `json:"scenes"`
+	Shots             []string        `json:"shots"`              // 镜头编号（场景号-镜头号）
+	NarrationChecksum string          `json:"narration_checksum"` // 所有镜头旁白拼接后的校验和
+	Audios            []MediaSnapshot `json:"audios"`
+	Subtitles         []MediaSnapshot `json:"subtitles"`
+	Images            []MediaSnapshot `json:"images"`
+	NarrationVideos   []MediaSnapshot `json:"narration_videos"`
+	FinalVideo        *MediaSnapshot  `json:"final_video"`
+}
+
+// MediaSnapshot 单个媒体产物的快照
+type MediaSnapshot struct {
+	Sequence int     `json:"sequence"`
+	Duration float64 `json:"duration,omitempty"` // 时长（秒），图片和字幕为空
+	Checksum string  `json:"checksum,omitempty"` // 文件内容 sha256（仅确定性产物）
+}
+
+// Drift 与基准快照的一处偏差
+type Drift struct {
+	Path     string `json:"path"` // 偏差位置，如 chapters[1].audios[2].duration
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+func (d Drift) String() string {
+	return fmt.Sprintf("%s: expected %s, got %s", d.Path, d.Expected, d.Actual)
+}
+
+// Compare 比较快照与基准快照，返回所有偏差
+// 时长差异不超过 tolerance 秒视为一致；基准快照中校验和为空的产物不比较校验和
+func Compare(expected, actual *Snapshot, tolerance float64) []Drift {
+	var drifts []Drift
+	add := func(path string, want, got any) {
+		drifts = append(drifts, Drift{Path: path, Expected: fmt.Sprint(want), Actual: fmt.Sprint(got)})
+	}
+
+	if len(expected.Chapters) != len(actual.Chapters) {
+		add("chapters.length", len(expected.Chapters), len(actual.Chapters))
+	}
+	for i := 0; i < len(expected.Chapters) && i < len(actual.Chapters); i++ {
+		want, got := expected.Chapters[i], actual.Chapters[i]
+		path := fmt.Sprintf("chapters[%d]", i)
+
+		if want.Title != got.Title {
+			add(path+".title", strconv.Quote(want.Title), strconv.Quote(got.Title))
+		}
+		if want.WordCount != got.WordCount {
+			add(path+".word_count", want.WordCount, got.WordCount)
+		}
+		if want.Scenes != got.Scenes {
+			add(path+".scenes", want.Scenes, got.Scenes)
+		}
+		if fmt.Sprint(want.Shots) != fmt.Sprint(got.Shots) {
+			add(path+".shots", want.Shots, got.Shots)
+		}
+		if want.NarrationChecksum != got.NarrationChecksum {
+			add(path+".narration_checksum", want.NarrationChecksum, got.NarrationChecksum)
+		}
+
+		drifts = append(drifts, compareMedia(path+".audios", want.Audios, got.Audios, tolerance)...)
+		drifts = append(drifts, compareMedia(path+".subtitles", want.Subtitles, got.Subtitles, tolerance)...)
+		drifts = append(drifts, compareMedia(path+".images", want.Images, got.Images, tolerance)...)
+		drifts = append(drifts, compareMedia(path+".narration_videos", want.NarrationVideos, got.NarrationVideos, tolerance)...)
+
+		switch {
+		case want.FinalVideo == nil && got.FinalVideo == nil:
+		case want.FinalVideo == nil || got.FinalVideo == nil:
+			add(path+".final_video", want.FinalVideo != nil, got.FinalVideo != nil)
+		default:
+			drifts = append(drifts, compareMediaItem(path+".final_video", *want.FinalVideo, *got.FinalVideo, tolerance)...)
+		}
+	}
+	return drifts
+}
+
+func compareMedia(path string, expected, actual []MediaSnapshot, tolerance float64) []Drift {
+	var drifts []Drift
+	if len(expected) != len(actual) {
+		drifts = append(drifts, Drift{Path: path + ".length", Expected: strconv.Itoa(len(expected)), Actual: strconv.Itoa(len(actual))})
+	}
+	for i := 0; i < len(expected) && i < len(actual); i++ {
+		drifts = append(drifts, compareMediaItem(fmt.Sprintf("%s[%d]", path, i), expected[i], actual[i], tolerance)...)
+	}
+	return drifts
+}
+
+func compareMediaItem(path string, expected, actual MediaSnapshot, tolerance float64) []Drift {
+	var drifts []Drift
+	if expected.Sequence != actual.Sequence {
+		drifts = append(drifts, Drift{Path: path + ".sequence", Expected: strconv.Itoa(expected.Sequence), Actual: strconv.Itoa(actual.Sequence)})
+	}
+	if math.Abs(expected.Duration-actual.Duration) > tolerance {
+		drifts = append(drifts, Drift{
+			Path:     path + ".duration",
+			Expected: strconv.FormatFloat(expected.Duration, 'f', 3, 64),
+			Actual:   strconv.FormatFloat(actual.Duration, 'f', 3, 64),
+		})
+	}
+	if expected.Checksum != "" && expected.Checksum != actual.Checksum {
+		drifts = append(drifts, Drift{Path: path + ".checksum", Expected: expected.Checksum, Actual: actual.Checksum})
+	}
+	return drifts
+}
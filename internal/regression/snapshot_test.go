@@ -0,0 +1,65 @@
+package regression
+
+import (
+	"testing"
+)
+
+func newSnapshot() *Snapshot {
+	return &Snapshot{Chapters: []*ChapterSnapshot{{
+		Sequence:          1,
+		Title:             "第一章 山门",
+		WordCount:         120,
+		Scenes:            1,
+		Shots:             []string{"1-1", "1-2"},
+		NarrationChecksum: "n1",
+		Audios:            []MediaSnapshot{{Sequence: 1, Duration: 3.2, Checksum: "a1"}},
+		Subtitles:         []MediaSnapshot{{Sequence: 1, Checksum: "s1"}},
+		Images:            []MediaSnapshot{{Sequence: 1, Checksum: "i1"}, {Sequence: 2, Checksum: "i2"}},
+		NarrationVideos:   []MediaSnapshot{{Sequence: 1, Duration: 3.2}},
+		FinalVideo:        &MediaSnapshot{Sequence: 1, Duration: 3.2},
+	}}}
+}
+
+func TestCompareIdentical(t *testing.T) {
+	if drifts := Compare(newSnapshot(), newSnapshot(), 0.05); len(drifts) != 0 {
+		t.Fatalf("expected no drift, got %v", drifts)
+	}
+}
+
+func TestCompareDrift(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(s *Snapshot)
+		path   string
+	}{
+		{"duration within tolerance", func(s *Snapshot) { s.Chapters[0].Audios[0].Duration = 3.23 }, ""},
+		{"duration beyond tolerance", func(s *Snapshot) { s.Chapters[0].Audios[0].Duration = 3.5 }, "chapters[0].audios[0].duration"},
+		{"checksum changed", func(s *Snapshot) { s.Chapters[0].Images[1].Checksum = "x" }, "chapters[0].images[1].checksum"},
+		{"missing image", func(s *Snapshot) { s.Chapters[0].Images = s.Chapters[0].Images[:1] }, "chapters[0].images.length"},
+		{"shots changed", func(s *Snapshot) { s.Chapters[0].Shots = []string{"1-1"} }, "chapters[0].shots"},
+		{"final video missing", func(s *Snapshot) { s.Chapters[0].FinalVideo = nil }, "chapters[0].final_video"},
+		{"extra chapter", func(s *Snapshot) { s.Chapters = append(s.Chapters, &ChapterSnapshot{Sequence: 2}) }, "chapters.length"},
+	}
+	for _, tt := range tests {
+		actual := newSnapshot()
+		tt.mutate(actual)
+		drifts := Compare(newSnapshot(), actual, 0.05)
+		if tt.path == "" {
+			if len(drifts) != 0 {
+				t.Errorf("%s: expected no drift, got %v", tt.name, drifts)
+			}
+			continue
+		}
+		if len(drifts) != 1 || drifts[0].Path != tt.path {
+			t.Errorf("%s: expected single drift at %s, got %v", tt.name, tt.path, drifts)
+		}
+	}
+}
+
+func TestCompareSkipsEmptyExpectedChecksum(t *testing.T) {
+	expected := newSnapshot()
+	expected.Chapters[0].Audios[0].Checksum = ""
+	if drifts := Compare(expected, newSnapshot(), 0.05); len(drifts) != 0 {
+		t.Fatalf("expected no drift, got %v", drifts)
+	}
+}
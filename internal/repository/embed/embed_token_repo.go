@@ -0,0 +1,106 @@
+package embed
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/embed"
+)
+
+// EmbedTokenRepository 嵌入播放令牌仓库接口
+type EmbedTokenRepository interface {
+	Create(ctx context.Context, token *embed.EmbedToken) error
+	FindByID(ctx context.Context, id string) (*embed.EmbedToken, error)
+	FindByTokenHash(ctx context.Context, tokenHash string) (*embed.EmbedToken, error)
+	List(ctx context.Context, createdBy string, limit int) ([]*embed.EmbedToken, error)
+	Revoke(ctx context.Context, id string, revokedAt time.Time) error
+	TouchLastUsed(ctx context.Context, id string, usedAt time.Time) error
+}
+
+// EmbedTokenRepo 嵌入播放令牌仓库实现
+type EmbedTokenRepo struct {
+	coll *mongo.Collection
+}
+
+// NewEmbedTokenRepo 创建嵌入播放令牌仓库
+func NewEmbedTokenRepo(db *mongo.Database) *EmbedTokenRepo {
+	var t embed.EmbedToken
+	return &EmbedTokenRepo{coll: db.Collection(t.Collection())}
+}
+
+// Create 创建令牌
+func (r *EmbedTokenRepo) Create(ctx context.Context, token *embed.EmbedToken) error {
+	now := time.Now()
+	token.CreatedAt = now
+	token.UpdatedAt = now
+	_, err := r.coll.InsertOne(ctx, token)
+	return err
+}
+
+// FindByID 根据ID查询令牌
+func (r *EmbedTokenRepo) FindByID(ctx context.Context, id string) (*embed.EmbedToken, error) {
+	var token embed.EmbedToken
+	if err := r.coll.FindOne(ctx, bson.M{"id": id}).Decode(&token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// FindByTokenHash 根据令牌摘要查询令牌
+func (r *EmbedTokenRepo) FindByTokenHash(ctx context.Context, tokenHash string) (*embed.EmbedToken, error) {
+	var token embed.EmbedToken
+	if err := r.coll.FindOne(ctx, bson.M{"token_hash": tokenHash}).Decode(&token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// List 查询令牌（按 created_at desc 排序），createdBy 为空时查询所有用户创建的令牌
+func (r *EmbedTokenRepo) List(ctx context.Context, createdBy string, limit int) ([]*embed.EmbedToken, error) {
+	filter := bson.M{}
+	if createdBy != "" {
+		filter["created_by"] = createdBy
+	}
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var tokens []*embed.EmbedToken
+	if err := cur.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Revoke 吊销令牌（已吊销的令牌保持原吊销时间）
+func (r *EmbedTokenRepo) Revoke(ctx context.Context, id string, revokedAt time.Time) error {
+	res, err := r.coll.UpdateOne(ctx, bson.M{"id": id}, bson.M{
+		"$min": bson.M{"revoked_at": revokedAt},
+		"$set": bson.M{"updated_at": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// TouchLastUsed 更新令牌最近使用时间
+func (r *EmbedTokenRepo) TouchLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	_, err := r.coll.UpdateOne(ctx, bson.M{"id": id}, bson.M{
+		"$set": bson.M{"last_used_at": usedAt},
+	})
+	return err
+}
@@ -0,0 +1,89 @@
+package maintenance
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/maintenance"
+)
+
+// DowntimeWindowRepository 停机窗口仓库接口
+type DowntimeWindowRepository interface {
+	Create(ctx context.Context, window *maintenance.DowntimeWindow) error
+	FindOpenByScope(ctx context.Context, scope string) (*maintenance.DowntimeWindow, error)
+	Close(ctx context.Context, id, endedBy string, endedAt time.Time) error
+	List(ctx context.Context, scope string, limit int) ([]*maintenance.DowntimeWindow, error)
+}
+
+// DowntimeWindowRepo 停机窗口仓库实现
+type DowntimeWindowRepo struct {
+	coll *mongo.Collection
+}
+
+// NewDowntimeWindowRepo 创建停机窗口仓库
+func NewDowntimeWindowRepo(db *mongo.Database) *DowntimeWindowRepo {
+	var w maintenance.DowntimeWindow
+	return &DowntimeWindowRepo{coll: db.Collection(w.Collection())}
+}
+
+// Create 创建停机窗口
+func (r *DowntimeWindowRepo) Create(ctx context.Context, window *maintenance.DowntimeWindow) error {
+	now := time.Now()
+	window.CreatedAt = now
+	window.UpdatedAt = now
+	if window.StartedAt.IsZero() {
+		window.StartedAt = now
+	}
+	_, err := r.coll.InsertOne(ctx, window)
+	return err
+}
+
+// FindOpenByScope 查询指定范围下尚未结束的停机窗口
+func (r *DowntimeWindowRepo) FindOpenByScope(ctx context.Context, scope string) (*maintenance.DowntimeWindow, error) {
+	var window maintenance.DowntimeWindow
+	filter := bson.M{"scope": scope, "ended_at": nil}
+	opts := options.FindOne().SetSort(bson.M{"started_at": -1})
+	if err := r.coll.FindOne(ctx, filter, opts).Decode(&window); err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+// Close 结束停机窗口
+func (r *DowntimeWindowRepo) Close(ctx context.Context, id, endedBy string, endedAt time.Time) error {
+	_, err := r.coll.UpdateOne(ctx, bson.M{"id": id}, bson.M{
+		"$set": bson.M{
+			"ended_by":   endedBy,
+			"ended_at":   endedAt,
+			"updated_at": time.Now(),
+		},
+	})
+	return err
+}
+
+// List 查询停机窗口（按 started_at desc 排序），scope 为空时查询全部
+func (r *DowntimeWindowRepo) List(ctx context.Context, scope string, limit int) ([]*maintenance.DowntimeWindow, error) {
+	filter := bson.M{}
+	if scope != "" {
+		filter["scope"] = scope
+	}
+	opts := options.Find().SetSort(bson.M{"started_at": -1})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var windows []*maintenance.DowntimeWindow
+	if err := cur.All(ctx, &windows); err != nil {
+		return nil, err
+	}
+	return windows, nil
+}
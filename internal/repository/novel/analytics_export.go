@@ -0,0 +1,89 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// AnalyticsExportBatchRepository 分析数据导出批次仓库接口
+type AnalyticsExportBatchRepository interface {
+	Create(ctx context.Context, batch *novel.AnalyticsExportBatch) error
+	FindLatest(ctx context.Context, dataset novel.AnalyticsDataset) (*novel.AnalyticsExportBatch, error)
+	FindAfterSeq(ctx context.Context, dataset novel.AnalyticsDataset, afterSeq int64, limit int) ([]*novel.AnalyticsExportBatch, error)
+}
+
+// AnalyticsExportBatchRepo 分析数据导出批次仓库实现
+type AnalyticsExportBatchRepo struct {
+	coll *mongo.Collection
+}
+
+// NewAnalyticsExportBatchRepo 创建分析数据导出批次仓库
+func NewAnalyticsExportBatchRepo(db *mongo.Database) *AnalyticsExportBatchRepo {
+	var b novel.AnalyticsExportBatch
+	return &AnalyticsExportBatchRepo{coll: db.Collection(b.Collection())}
+}
+
+// Create 创建批次（同一数据集的序号已存在时返回重复键错误）
+func (r *AnalyticsExportBatchRepo) Create(ctx context.Context, batch *novel.AnalyticsExportBatch) error {
+	batch.CreatedAt = time.Now()
+	_, err := r.coll.InsertOne(ctx, batch)
+	return err
+}
+
+// FindLatest 查询数据集序号最大的批次（没有批次时返回 mongo.ErrNoDocuments）
+func (r *AnalyticsExportBatchRepo) FindLatest(ctx context.Context, dataset novel.AnalyticsDataset) (*novel.AnalyticsExportBatch, error) {
+	opts := options.FindOne().SetSort(bson.M{"seq": -1})
+	var batch novel.AnalyticsExportBatch
+	if err := r.coll.FindOne(ctx, bson.M{"dataset": dataset}, opts).Decode(&batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// FindAfterSeq 查询数据集序号大于 afterSeq 的批次（按 seq asc 排序）
+func (r *AnalyticsExportBatchRepo) FindAfterSeq(ctx context.Context, dataset novel.AnalyticsDataset, afterSeq int64, limit int) ([]*novel.AnalyticsExportBatch, error) {
+	opts := options.Find().SetSort(bson.M{"seq": 1})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cur, err := r.coll.Find(ctx, bson.M{"dataset": dataset, "seq": bson.M{"$gt": afterSeq}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var batches []*novel.AnalyticsExportBatch
+	if err := cur.All(ctx, &batches); err != nil {
+		return nil, err
+	}
+	return batches, nil
+}
+
+// findAfterCursor 按（timeField, id）升序查询游标之后的记录，用于分析数据增量导出
+// 时间相同的记录按 id 区分，保证分页时不重复也不遗漏
+func findAfterCursor(ctx context.Context, coll *mongo.Collection, timeField string, after time.Time, afterID string, filter bson.M, limit int, out any) error {
+	cursor := bson.A{
+		bson.M{timeField: bson.M{"$gt": after}},
+		bson.M{timeField: after, "id": bson.M{"$gt": afterID}},
+	}
+	query := bson.M{"$or": cursor}
+	if len(filter) > 0 {
+		query = bson.M{"$and": bson.A{filter, query}}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: timeField, Value: 1}, {Key: "id", Value: 1}})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cur, err := coll.Find(ctx, query, opts)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+	return cur.All(ctx, out)
+}
@@ -18,11 +18,15 @@ type AudioRepository interface {
 	FindByNarrationID(ctx context.Context, narrationID string) ([]*novel.Audio, error)
 	FindByChapterID(ctx context.Context, chapterID string) ([]*novel.Audio, error)
 	FindByNarrationIDAndVersion(ctx context.Context, narrationID string, version int) ([]*novel.Audio, error)
+	ListByNarrationIDAndVersion(ctx context.Context, narrationID string, version int, opts ListOptions) ([]*novel.Audio, int64, error)
 	FindVersionsByNarrationID(ctx context.Context, narrationID string) ([]int, error)
 	FindVersionsByChapterID(ctx context.Context, chapterID string) ([]int, error)
 	UpdateStatus(ctx context.Context, id string, status novel.TaskStatus) error
 	UpdateVersion(ctx context.Context, id string, version int) error
+	UpdateWaveform(ctx context.Context, id string, resourceID string) error
+	UpdateByShotID(ctx context.Context, shotID string, updates map[string]interface{}) error
 	Delete(ctx context.Context, id string) error
+	MoveChapterVersion(ctx context.Context, fromChapterID string, fromVersion int, toChapterID string, toVersion int) error
 }
 
 // AudioRepo 音频仓库实现
@@ -111,6 +115,12 @@ func (r *AudioRepo) FindByNarrationIDAndVersion(ctx context.Context, narrationID
 	return audios, nil
 }
 
+// ListByNarrationIDAndVersion 分页查询解说指定版本的音频（默认按 sequence 排序），同时返回总数
+func (r *AudioRepo) ListByNarrationIDAndVersion(ctx context.Context, narrationID string, version int, opts ListOptions) ([]*novel.Audio, int64, error) {
+	filter := bson.M{"narration_id": narrationID, "version": version, "deleted_at": nil}
+	return listDocuments[novel.Audio](ctx, r.coll, filter, opts, bson.D{{Key: "sequence", Value: 1}})
+}
+
 // FindVersionsByNarrationID 查询解说的所有音频版本号
 func (r *AudioRepo) FindVersionsByNarrationID(ctx context.Context, narrationID string) ([]int, error) {
 	filter := bson.M{"narration_id": narrationID, "deleted_at": nil}
@@ -203,6 +213,30 @@ func (r *AudioRepo) UpdateVersion(ctx context.Context, id string, version int) e
 	return err
 }
 
+// UpdateWaveform 记录音频波形峰值文件的 resource_id
+func (r *AudioRepo) UpdateWaveform(ctx context.Context, id string, resourceID string) error {
+	_, err := r.coll.UpdateOne(
+		ctx,
+		bson.M{"id": id},
+		bson.M{"$set": bson.M{
+			"waveform_resource_id": resourceID,
+			"updated_at":           time.Now(),
+		}},
+	)
+	return err
+}
+
+// UpdateByShotID 更新关联到指定镜头的所有音频（镜头重排后按稳定的镜头ID重新关联）
+func (r *AudioRepo) UpdateByShotID(ctx context.Context, shotID string, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+	_, err := r.coll.UpdateMany(
+		ctx,
+		bson.M{"shot_id": shotID, "deleted_at": nil},
+		bson.M{"$set": updates},
+	)
+	return err
+}
+
 // Delete 软删除
 func (r *AudioRepo) Delete(ctx context.Context, id string) error {
 	_, err := r.coll.UpdateOne(
@@ -215,3 +249,17 @@ func (r *AudioRepo) Delete(ctx context.Context, id string) error {
 	)
 	return err
 }
+
+// MoveChapterVersion 把章节某个版本的所有音频移到另一个章节的指定版本（合并章节分支时使用）
+func (r *AudioRepo) MoveChapterVersion(ctx context.Context, fromChapterID string, fromVersion int, toChapterID string, toVersion int) error {
+	_, err := r.coll.UpdateMany(
+		ctx,
+		bson.M{"chapter_id": fromChapterID, "version": fromVersion, "deleted_at": nil},
+		bson.M{"$set": bson.M{
+			"chapter_id": toChapterID,
+			"version":    toVersion,
+			"updated_at": time.Now(),
+		}},
+	)
+	return err
+}
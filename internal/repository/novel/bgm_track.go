@@ -0,0 +1,87 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// BGMTrackRepository 背景音乐曲库仓库接口
+type BGMTrackRepository interface {
+	Create(ctx context.Context, track *novel.BGMTrack) error
+	FindByID(ctx context.Context, id string) (*novel.BGMTrack, error)
+	FindAll(ctx context.Context) ([]*novel.BGMTrack, error)
+	FindByMood(ctx context.Context, mood novel.SceneMood) ([]*novel.BGMTrack, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// BGMTrackRepo 背景音乐曲库仓库实现
+type BGMTrackRepo struct {
+	coll *mongo.Collection
+}
+
+// NewBGMTrackRepo 创建背景音乐曲库仓库
+func NewBGMTrackRepo(db *mongo.Database) *BGMTrackRepo {
+	var t novel.BGMTrack
+	return &BGMTrackRepo{coll: db.Collection(t.Collection())}
+}
+
+// Create 创建曲目
+func (r *BGMTrackRepo) Create(ctx context.Context, track *novel.BGMTrack) error {
+	now := time.Now()
+	track.CreatedAt = now
+	track.UpdatedAt = now
+	_, err := r.coll.InsertOne(ctx, track)
+	return err
+}
+
+// FindByID 根据ID查询曲目
+func (r *BGMTrackRepo) FindByID(ctx context.Context, id string) (*novel.BGMTrack, error) {
+	var track novel.BGMTrack
+	if err := r.coll.FindOne(ctx, bson.M{"id": id, "deleted_at": nil}).Decode(&track); err != nil {
+		return nil, err
+	}
+	return &track, nil
+}
+
+// FindAll 查询曲库中的所有曲目（按 mood、created_at asc 排序）
+func (r *BGMTrackRepo) FindAll(ctx context.Context) ([]*novel.BGMTrack, error) {
+	return r.find(ctx, bson.M{"deleted_at": nil})
+}
+
+// FindByMood 查询指定情绪的曲目（按 created_at asc 排序）
+func (r *BGMTrackRepo) FindByMood(ctx context.Context, mood novel.SceneMood) ([]*novel.BGMTrack, error) {
+	return r.find(ctx, bson.M{"mood": mood, "deleted_at": nil})
+}
+
+// Delete 删除曲目（软删除）
+func (r *BGMTrackRepo) Delete(ctx context.Context, id string) error {
+	now := time.Now()
+	_, err := r.coll.UpdateOne(ctx, bson.M{"id": id, "deleted_at": nil}, bson.M{
+		"$set": bson.M{
+			"deleted_at": now,
+			"updated_at": now,
+		},
+	})
+	return err
+}
+
+func (r *BGMTrackRepo) find(ctx context.Context, filter bson.M) ([]*novel.BGMTrack, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "mood", Value: 1}, {Key: "created_at", Value: 1}})
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var tracks []*novel.BGMTrack
+	if err := cur.All(ctx, &tracks); err != nil {
+		return nil, err
+	}
+	return tracks, nil
+}
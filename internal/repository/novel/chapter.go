@@ -16,6 +16,17 @@ type ChapterRepository interface {
 	Create(ctx context.Context, ch *novel.Chapter) error
 	FindByID(ctx context.Context, id string) (*novel.Chapter, error)
 	FindByNovelID(ctx context.Context, novelID string) ([]*novel.Chapter, error)
+	ListByNovelID(ctx context.Context, novelID string, opts ListOptions) ([]*novel.Chapter, int64, error)
+	Approve(ctx context.Context, id, approvedBy string) error
+	SetPromotedVersions(ctx context.Context, id string, pv *novel.PromotedVersions) error
+	SwapPromotedVersions(ctx context.Context, id string, expected, pv *novel.PromotedVersions) error
+	SetSubtitleVersion(ctx context.Context, id string, version int) error
+	SetSummary(ctx context.Context, id string, summary *novel.ChapterSummary) error
+	SetBGM(ctx context.Context, id string, bgm *novel.ChapterBGM) error
+	FindBranches(ctx context.Context, parentID string) ([]*novel.Chapter, error)
+	SetText(ctx context.Context, id, text string, totalChars, wordCount, lineCount int) error
+	CloseBranch(ctx context.Context, id string, status novel.ChapterBranchStatus, closedBy string) error
+	DeleteBranch(ctx context.Context, id string) error
 }
 
 // ChapterRepo 章节仓库
@@ -47,9 +58,9 @@ func (r *ChapterRepo) FindByID(ctx context.Context, id string) (*novel.Chapter,
 	return &ch, nil
 }
 
-// FindByNovelID 查询某小说的章节（按sequence排序）
+// FindByNovelID 查询某小说的章节（按sequence排序，不包括章节分支）
 func (r *ChapterRepo) FindByNovelID(ctx context.Context, novelID string) ([]*novel.Chapter, error) {
-	filter := bson.M{"novel_id": novelID, "deleted_at": nil}
+	filter := bson.M{"novel_id": novelID, "branch": nil, "deleted_at": nil}
 	opts := options.Find().SetSort(bson.M{"sequence": 1})
 	cur, err := r.coll.Find(ctx, filter, opts)
 	if err != nil {
@@ -64,4 +75,202 @@ func (r *ChapterRepo) FindByNovelID(ctx context.Context, novelID string) ([]*nov
 	return chapters, nil
 }
 
+// ListByNovelID 分页查询某小说的章节（不包括章节分支，默认按 sequence 排序），同时返回章节总数
+func (r *ChapterRepo) ListByNovelID(ctx context.Context, novelID string, opts ListOptions) ([]*novel.Chapter, int64, error) {
+	filter := bson.M{"novel_id": novelID, "branch": nil, "deleted_at": nil}
+	return listDocuments[novel.Chapter](ctx, r.coll, filter, opts, bson.D{{Key: "sequence", Value: 1}})
+}
+
+// Approve 标记章节审核通过
+func (r *ChapterRepo) Approve(ctx context.Context, id, approvedBy string) error {
+	now := time.Now()
+	res, err := r.coll.UpdateOne(
+		ctx,
+		bson.M{"id": id, "deleted_at": nil},
+		bson.M{"$set": bson.M{
+			"approved_by": approvedBy,
+			"approved_at": now,
+			"updated_at":  now,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SetPromotedVersions 设置章节的发布版本，pv 为 nil 时清空（用于批量发布失败后回滚）
+func (r *ChapterRepo) SetPromotedVersions(ctx context.Context, id string, pv *novel.PromotedVersions) error {
+	update := bson.M{"$set": bson.M{"promoted_versions": pv, "updated_at": time.Now()}}
+	if pv == nil {
+		update = bson.M{
+			"$set":   bson.M{"updated_at": time.Now()},
+			"$unset": bson.M{"promoted_versions": ""},
+		}
+	}
+	res, err := r.coll.UpdateOne(ctx, bson.M{"id": id, "deleted_at": nil}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SwapPromotedVersions 仅当章节当前的发布版本仍是 expected 时（按发布时间比较）才设置为 pv，pv 为 nil 时清空
+// 章节不存在或发布版本已被其他操作修改时返回 mongo.ErrNoDocuments
+func (r *ChapterRepo) SwapPromotedVersions(ctx context.Context, id string, expected, pv *novel.PromotedVersions) error {
+	filter := bson.M{"id": id, "deleted_at": nil}
+	if expected == nil {
+		filter["promoted_versions"] = nil
+	} else {
+		filter["promoted_versions.promoted_at"] = expected.PromotedAt
+	}
+	update := bson.M{"$set": bson.M{"promoted_versions": pv, "updated_at": time.Now()}}
+	if pv == nil {
+		update = bson.M{
+			"$set":   bson.M{"updated_at": time.Now()},
+			"$unset": bson.M{"promoted_versions": ""},
+		}
+	}
+	res, err := r.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SetSubtitleVersion 设置视频阶段使用的字幕版本，version 为 0 时清空（使用最新生成的字幕）
+func (r *ChapterRepo) SetSubtitleVersion(ctx context.Context, id string, version int) error {
+	update := bson.M{"$set": bson.M{"subtitle_version": version, "updated_at": time.Now()}}
+	if version == 0 {
+		update = bson.M{
+			"$set":   bson.M{"updated_at": time.Now()},
+			"$unset": bson.M{"subtitle_version": ""},
+		}
+	}
+	res, err := r.coll.UpdateOne(ctx, bson.M{"id": id, "deleted_at": nil}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
 // 章节的解说内容由 Narration/Scene/Shot 等表单独管理，这里不再维护 narration_text 字段。
+
+// SetSummary 保存章节的内容摘要缓存
+func (r *ChapterRepo) SetSummary(ctx context.Context, id string, summary *novel.ChapterSummary) error {
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"id": id, "deleted_at": nil},
+		bson.M{"$set": bson.M{"summary": summary}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SetBGM 设置章节的背景音乐选择，bgm 为 nil 时清空（按场景情绪自动选择）
+func (r *ChapterRepo) SetBGM(ctx context.Context, id string, bgm *novel.ChapterBGM) error {
+	update := bson.M{"$set": bson.M{"bgm": bgm, "updated_at": time.Now()}}
+	if bgm == nil {
+		update = bson.M{
+			"$set":   bson.M{"updated_at": time.Now()},
+			"$unset": bson.M{"bgm": ""},
+		}
+	}
+	res, err := r.coll.UpdateOne(ctx, bson.M{"id": id, "deleted_at": nil}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// FindBranches 查询章节的所有分支（按创建时间倒序）
+func (r *ChapterRepo) FindBranches(ctx context.Context, parentID string) ([]*novel.Chapter, error) {
+	filter := bson.M{"branch.parent_id": parentID, "deleted_at": nil}
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var chapters []*novel.Chapter
+	if err := cur.All(ctx, &chapters); err != nil {
+		return nil, err
+	}
+	return chapters, nil
+}
+
+// SetText 更新章节全文及统计信息
+func (r *ChapterRepo) SetText(ctx context.Context, id, text string, totalChars, wordCount, lineCount int) error {
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"id": id, "deleted_at": nil},
+		bson.M{"$set": bson.M{
+			"chapter_text": text,
+			"total_chars":  totalChars,
+			"word_count":   wordCount,
+			"line_count":   lineCount,
+			"updated_at":   time.Now(),
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// CloseBranch 把进行中的章节分支标记为已合并或已放弃
+// 章节不存在、不是分支或分支已关闭时返回 mongo.ErrNoDocuments
+func (r *ChapterRepo) CloseBranch(ctx context.Context, id string, status novel.ChapterBranchStatus, closedBy string) error {
+	now := time.Now()
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"id": id, "branch.status": novel.ChapterBranchStatusOpen, "deleted_at": nil},
+		bson.M{"$set": bson.M{
+			"branch.status":    status,
+			"branch.closed_by": closedBy,
+			"branch.closed_at": now,
+			"updated_at":       now,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// DeleteBranch 物理删除章节分支（用于清理创建失败的分支，释放分支名称的唯一索引）
+// 章节不存在或不是分支时返回 mongo.ErrNoDocuments
+func (r *ChapterRepo) DeleteBranch(ctx context.Context, id string) error {
+	res, err := r.coll.DeleteOne(ctx, bson.M{"id": id, "branch": bson.M{"$exists": true}})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// ChapterEventRepository 章节流程事件仓库接口（只追加）
+type ChapterEventRepository interface {
+	Append(ctx context.Context, event *novel.ChapterEvent) error
+	FindByChapterID(ctx context.Context, chapterID string) ([]*novel.ChapterEvent, error)
+}
+
+// ChapterEventRepo 章节流程事件仓库实现
+type ChapterEventRepo struct {
+	coll *mongo.Collection
+}
+
+// NewChapterEventRepo 创建章节流程事件仓库
+func NewChapterEventRepo(db *mongo.Database) *ChapterEventRepo {
+	var e novel.ChapterEvent
+	return &ChapterEventRepo{coll: db.Collection(e.Collection())}
+}
+
+// Append 追加事件
+func (r *ChapterEventRepo) Append(ctx context.Context, event *novel.ChapterEvent) error {
+	event.CreatedAt = time.Now()
+	_, err := r.coll.InsertOne(ctx, event)
+	return err
+}
+
+// FindByChapterID 查询章节的所有事件（按 occurred_at asc 排序）
+func (r *ChapterEventRepo) FindByChapterID(ctx context.Context, chapterID string) ([]*novel.ChapterEvent, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "occurred_at", Value: 1}, {Key: "created_at", Value: 1}})
+	cur, err := r.coll.Find(ctx, bson.M{"chapter_id": chapterID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var events []*novel.ChapterEvent
+	if err := cur.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
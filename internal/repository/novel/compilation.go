@@ -0,0 +1,75 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// CompilationRepository 回顾合集仓库接口
+type CompilationRepository interface {
+	Create(ctx context.Context, compilation *novel.Compilation) error
+	FindByID(ctx context.Context, id string) (*novel.Compilation, error)
+	FindByNovelID(ctx context.Context, novelID string, limit int) ([]*novel.Compilation, error)
+	Update(ctx context.Context, id string, updates bson.M) error
+}
+
+// CompilationRepo 回顾合集仓库实现
+type CompilationRepo struct {
+	coll *mongo.Collection
+}
+
+// NewCompilationRepo 创建回顾合集仓库
+func NewCompilationRepo(db *mongo.Database) *CompilationRepo {
+	var c novel.Compilation
+	return &CompilationRepo{coll: db.Collection(c.Collection())}
+}
+
+// Create 创建回顾合集
+func (r *CompilationRepo) Create(ctx context.Context, compilation *novel.Compilation) error {
+	now := time.Now()
+	compilation.CreatedAt = now
+	compilation.UpdatedAt = now
+	_, err := r.coll.InsertOne(ctx, compilation)
+	return err
+}
+
+// FindByID 根据ID查询回顾合集
+func (r *CompilationRepo) FindByID(ctx context.Context, id string) (*novel.Compilation, error) {
+	var compilation novel.Compilation
+	if err := r.coll.FindOne(ctx, bson.M{"id": id}).Decode(&compilation); err != nil {
+		return nil, err
+	}
+	return &compilation, nil
+}
+
+// FindByNovelID 查询小说的回顾合集（按 created_at desc 排序）
+func (r *CompilationRepo) FindByNovelID(ctx context.Context, novelID string, limit int) ([]*novel.Compilation, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cur, err := r.coll.Find(ctx, bson.M{"novel_id": novelID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var compilations []*novel.Compilation
+	if err := cur.All(ctx, &compilations); err != nil {
+		return nil, err
+	}
+	return compilations, nil
+}
+
+// Update 更新回顾合集
+func (r *CompilationRepo) Update(ctx context.Context, id string, updates bson.M) error {
+	updates["updated_at"] = time.Now()
+	_, err := r.coll.UpdateOne(ctx, bson.M{"id": id}, bson.M{"$set": updates})
+	return err
+}
@@ -0,0 +1,94 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// ContentRiskReportRepository 内容风险报告仓库接口
+type ContentRiskReportRepository interface {
+	Create(ctx context.Context, report *novel.ContentRiskReport) error
+	FindByID(ctx context.Context, reportID string) (*novel.ContentRiskReport, error)
+	// FindByFingerprint 查询章节中指纹相同的最新报告
+	FindByFingerprint(ctx context.Context, chapterID, fingerprint string) (*novel.ContentRiskReport, error)
+	FindByChapterID(ctx context.Context, chapterID string) ([]*novel.ContentRiskReport, error)
+	// Acknowledge 记录编辑确认，报告已被确认时返回 mongo.ErrNoDocuments
+	Acknowledge(ctx context.Context, reportID, acknowledgedBy, note string) error
+}
+
+// ContentRiskReportRepo 内容风险报告仓库实现
+type ContentRiskReportRepo struct {
+	coll *mongo.Collection
+}
+
+// NewContentRiskReportRepo 创建内容风险报告仓库
+func NewContentRiskReportRepo(db *mongo.Database) *ContentRiskReportRepo {
+	var r novel.ContentRiskReport
+	return &ContentRiskReportRepo{coll: db.Collection(r.Collection())}
+}
+
+// Create 创建报告
+func (r *ContentRiskReportRepo) Create(ctx context.Context, report *novel.ContentRiskReport) error {
+	report.CreatedAt = time.Now()
+	_, err := r.coll.InsertOne(ctx, report)
+	return err
+}
+
+// FindByID 根据ID查询报告
+func (r *ContentRiskReportRepo) FindByID(ctx context.Context, reportID string) (*novel.ContentRiskReport, error) {
+	var report novel.ContentRiskReport
+	if err := r.coll.FindOne(ctx, bson.M{"id": reportID}).Decode(&report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// FindByFingerprint 查询章节中指纹相同的最新报告
+func (r *ContentRiskReportRepo) FindByFingerprint(ctx context.Context, chapterID, fingerprint string) (*novel.ContentRiskReport, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	var report novel.ContentRiskReport
+	if err := r.coll.FindOne(ctx, bson.M{"chapter_id": chapterID, "fingerprint": fingerprint}, opts).Decode(&report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// FindByChapterID 查询章节的所有报告（按 created_at desc 排序）
+func (r *ContentRiskReportRepo) FindByChapterID(ctx context.Context, chapterID string) ([]*novel.ContentRiskReport, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cur, err := r.coll.Find(ctx, bson.M{"chapter_id": chapterID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var reports []*novel.ContentRiskReport
+	if err := cur.All(ctx, &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// Acknowledge 记录编辑确认（只更新尚未确认的报告）
+func (r *ContentRiskReportRepo) Acknowledge(ctx context.Context, reportID, acknowledgedBy, note string) error {
+	filter := bson.M{"id": reportID, "acknowledged_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{
+		"acknowledged_by": acknowledgedBy,
+		"acknowledged_at": time.Now(),
+		"ack_note":        note,
+	}}
+	result, err := r.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
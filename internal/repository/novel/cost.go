@@ -0,0 +1,151 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// CostRecordRepository 费用记录仓库接口
+type CostRecordRepository interface {
+	Create(ctx context.Context, record *novel.CostRecord) error
+	FindByNovelID(ctx context.Context, novelID string, limit int) ([]*novel.CostRecord, error)
+	SumByProvider(ctx context.Context, novelID string) (map[string]int64, error)
+	FindCreatedAfter(ctx context.Context, after time.Time, afterID string, limit int) ([]*novel.CostRecord, error)
+}
+
+// CostRecordRepo 费用记录仓库实现
+type CostRecordRepo struct {
+	coll *mongo.Collection
+}
+
+// NewCostRecordRepo 创建费用记录仓库
+func NewCostRecordRepo(db *mongo.Database) *CostRecordRepo {
+	var r novel.CostRecord
+	return &CostRecordRepo{coll: db.Collection(r.Collection())}
+}
+
+// Create 创建费用记录
+func (r *CostRecordRepo) Create(ctx context.Context, record *novel.CostRecord) error {
+	record.CreatedAt = time.Now()
+	_, err := r.coll.InsertOne(ctx, record)
+	return err
+}
+
+// FindByNovelID 查询小说的费用记录（按 created_at desc 排序）
+func (r *CostRecordRepo) FindByNovelID(ctx context.Context, novelID string, limit int) ([]*novel.CostRecord, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cur, err := r.coll.Find(ctx, bson.M{"novel_id": novelID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var records []*novel.CostRecord
+	if err := cur.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// SumByProvider 按 provider 汇总小说的费用（分）
+func (r *CostRecordRepo) SumByProvider(ctx context.Context, novelID string) (map[string]int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"novel_id": novelID}}},
+		{{Key: "$group", Value: bson.M{"_id": "$provider", "total": bson.M{"$sum": "$amount_fen"}}}},
+	}
+	cur, err := r.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var rows []struct {
+		Provider string `bson:"_id"`
+		Total    int64  `bson:"total"`
+	}
+	if err := cur.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		totals[row.Provider] = row.Total
+	}
+	return totals, nil
+}
+
+// FindCreatedAfter 查询游标（created_at, id）之后的费用记录（按 created_at、id 升序，用于分析数据增量导出）
+func (r *CostRecordRepo) FindCreatedAfter(ctx context.Context, after time.Time, afterID string, limit int) ([]*novel.CostRecord, error) {
+	var records []*novel.CostRecord
+	if err := findAfterCursor(ctx, r.coll, "created_at", after, afterID, nil, limit, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// BudgetEventRepository 预算事件仓库接口
+type BudgetEventRepository interface {
+	Create(ctx context.Context, event *novel.BudgetEvent) error
+	UpdateWebhookStatus(ctx context.Context, id string, status novel.WebhookStatus, errMsg string) error
+	FindByNovelID(ctx context.Context, novelID string, limit int) ([]*novel.BudgetEvent, error)
+}
+
+// BudgetEventRepo 预算事件仓库实现
+type BudgetEventRepo struct {
+	coll *mongo.Collection
+}
+
+// NewBudgetEventRepo 创建预算事件仓库
+func NewBudgetEventRepo(db *mongo.Database) *BudgetEventRepo {
+	var e novel.BudgetEvent
+	return &BudgetEventRepo{coll: db.Collection(e.Collection())}
+}
+
+// Create 创建预算事件
+func (r *BudgetEventRepo) Create(ctx context.Context, event *novel.BudgetEvent) error {
+	now := time.Now()
+	event.CreatedAt = now
+	event.UpdatedAt = now
+	_, err := r.coll.InsertOne(ctx, event)
+	return err
+}
+
+// UpdateWebhookStatus 更新通知发送状态
+func (r *BudgetEventRepo) UpdateWebhookStatus(ctx context.Context, id string, status novel.WebhookStatus, errMsg string) error {
+	_, err := r.coll.UpdateOne(ctx, bson.M{"id": id}, bson.M{
+		"$set": bson.M{
+			"webhook_status": status,
+			"webhook_error":  errMsg,
+			"updated_at":     time.Now(),
+		},
+	})
+	return err
+}
+
+// FindByNovelID 查询小说的预算事件（按 created_at desc 排序）
+func (r *BudgetEventRepo) FindByNovelID(ctx context.Context, novelID string, limit int) ([]*novel.BudgetEvent, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cur, err := r.coll.Find(ctx, bson.M{"novel_id": novelID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var events []*novel.BudgetEvent
+	if err := cur.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
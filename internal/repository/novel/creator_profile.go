@@ -0,0 +1,70 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// CreatorProfileRepository 创作默认配置仓库接口
+type CreatorProfileRepository interface {
+	FindByUserID(ctx context.Context, userID string) (*novel.CreatorProfile, error)
+	// Save 保存用户的创作默认配置（已存在时整体替换，保留原 ID 和创建时间）
+	Save(ctx context.Context, profile *novel.CreatorProfile) error
+}
+
+// CreatorProfileRepo 创作默认配置仓库实现
+type CreatorProfileRepo struct {
+	coll *mongo.Collection
+}
+
+// NewCreatorProfileRepo 创建创作默认配置仓库
+func NewCreatorProfileRepo(db *mongo.Database) *CreatorProfileRepo {
+	var p novel.CreatorProfile
+	return &CreatorProfileRepo{coll: db.Collection(p.Collection())}
+}
+
+// FindByUserID 查询用户的创作默认配置
+func (r *CreatorProfileRepo) FindByUserID(ctx context.Context, userID string) (*novel.CreatorProfile, error) {
+	var profile novel.CreatorProfile
+	if err := r.coll.FindOne(ctx, bson.M{"user_id": userID}).Decode(&profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// Save 保存用户的创作默认配置
+func (r *CreatorProfileRepo) Save(ctx context.Context, profile *novel.CreatorProfile) error {
+	now := time.Now()
+	profile.UpdatedAt = now
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var saved novel.CreatorProfile
+	err := r.coll.FindOneAndUpdate(ctx, bson.M{"user_id": profile.UserID}, bson.M{
+		"$set": bson.M{
+			"preset":         profile.Preset,
+			"narration_type": profile.NarrationType,
+			"style":          profile.Style,
+			"number_style":   profile.NumberStyle,
+			"continuity":     profile.Continuity,
+			"metadata":       profile.Metadata,
+			"render":         profile.Render,
+			"updated_at":     now,
+		},
+		"$setOnInsert": bson.M{
+			"id":         profile.ID,
+			"created_at": now,
+		},
+	}, opts).Decode(&saved)
+	if err != nil {
+		return err
+	}
+	profile.ID = saved.ID
+	profile.CreatedAt = saved.CreatedAt
+	return nil
+}
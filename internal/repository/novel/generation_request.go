@@ -0,0 +1,97 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+)
+
+// GenerationRequestRepository 生成请求记录仓库接口
+type GenerationRequestRepository interface {
+	Create(ctx context.Context, req *novel.GenerationRequest) error
+	FindByIdempotencyKey(ctx context.Context, userID, key string) (*novel.GenerationRequest, error)
+	FindProcessingByFingerprint(ctx context.Context, fingerprint string) (*novel.GenerationRequest, error)
+	Complete(ctx context.Context, id string, resourceIDs []string) error
+	Fail(ctx context.Context, id, errMsg string) error
+	Delete(ctx context.Context, id string) error
+}
+
+// GenerationRequestRepo 生成请求记录仓库实现
+type GenerationRequestRepo struct {
+	coll *mongo.Collection
+}
+
+// NewGenerationRequestRepo 创建生成请求记录仓库
+func NewGenerationRequestRepo(db *mongo.Database) *GenerationRequestRepo {
+	var r novel.GenerationRequest
+	return &GenerationRequestRepo{coll: db.Collection(r.Collection())}
+}
+
+// Create 创建请求记录，用户的幂等键已存在或相同指纹已有生成中的请求时返回 mongo 的重复键错误（可用 mongo.IsDuplicateKeyError 判断）
+func (r *GenerationRequestRepo) Create(ctx context.Context, req *novel.GenerationRequest) error {
+	now := time.Now()
+	req.CreatedAt = now
+	req.UpdatedAt = now
+	_, err := r.coll.InsertOne(ctx, req)
+	return err
+}
+
+// FindByIdempotencyKey 根据用户的幂等键查询请求记录（userID 为空时查询未登录提交的请求）
+func (r *GenerationRequestRepo) FindByIdempotencyKey(ctx context.Context, userID, key string) (*novel.GenerationRequest, error) {
+	var owner interface{} = userID
+	if userID == "" {
+		owner = nil // user_id 为空时不保存该字段，null 同时匹配不存在的字段
+	}
+	var req novel.GenerationRequest
+	if err := r.coll.FindOne(ctx, bson.M{"user_id": owner, "idempotency_key": key}).Decode(&req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// FindProcessingByFingerprint 查询相同指纹生成中的请求
+func (r *GenerationRequestRepo) FindProcessingByFingerprint(ctx context.Context, fingerprint string) (*novel.GenerationRequest, error) {
+	var req novel.GenerationRequest
+	filter := bson.M{
+		"fingerprint": fingerprint,
+		"status":      novel.GenerationRequestStatusProcessing,
+	}
+	if err := r.coll.FindOne(ctx, filter).Decode(&req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// Complete 记录生成完成及生成的产物ID
+func (r *GenerationRequestRepo) Complete(ctx context.Context, id string, resourceIDs []string) error {
+	now := time.Now()
+	_, err := r.coll.UpdateOne(ctx, bson.M{"id": id}, bson.M{"$set": bson.M{
+		"status":       novel.GenerationRequestStatusCompleted,
+		"resource_ids": resourceIDs,
+		"completed_at": now,
+		"updated_at":   now,
+	}})
+	return err
+}
+
+// Fail 记录生成失败
+func (r *GenerationRequestRepo) Fail(ctx context.Context, id, errMsg string) error {
+	now := time.Now()
+	_, err := r.coll.UpdateOne(ctx, bson.M{"id": id}, bson.M{"$set": bson.M{
+		"status":        novel.GenerationRequestStatusFailed,
+		"error_message": errMsg,
+		"completed_at":  now,
+		"updated_at":    now,
+	}})
+	return err
+}
+
+// Delete 删除请求记录（失败的请求使用相同幂等键重试前删除）
+func (r *GenerationRequestRepo) Delete(ctx context.Context, id string) error {
+	_, err := r.coll.DeleteOne(ctx, bson.M{"id": id})
+	return err
+}
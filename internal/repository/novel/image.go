@@ -18,11 +18,16 @@ type ImageRepository interface {
 	FindByChapterID(ctx context.Context, chapterID string) ([]*novel.Image, error)
 	FindByNarrationID(ctx context.Context, narrationID string) ([]*novel.Image, error)
 	FindByNarrationIDAndVersion(ctx context.Context, narrationID string, version int) ([]*novel.Image, error)
+	ListByNarrationIDAndVersion(ctx context.Context, narrationID string, version int, opts ListOptions) ([]*novel.Image, int64, error)
 	FindBySceneAndShot(ctx context.Context, chapterID, sceneNumber, shotNumber string) (*novel.Image, error)
+	FindByShotID(ctx context.Context, shotID string) (*novel.Image, error)
 	FindByChapterIDAndVersion(ctx context.Context, chapterID string, version int) ([]*novel.Image, error)
 	FindVersionsByChapterID(ctx context.Context, chapterID string) ([]int, error)
+	FindByStatusUpdatedBefore(ctx context.Context, status novel.TaskStatus, before time.Time, novelID string) ([]*novel.Image, error)
 	UpdateStatus(ctx context.Context, id string, status novel.TaskStatus) error
+	UpdateByShotID(ctx context.Context, shotID string, updates map[string]interface{}) error
 	Delete(ctx context.Context, id string) error
+	MoveChapterVersion(ctx context.Context, fromChapterID string, fromVersion int, toChapterID string, toVersion int) error
 }
 
 // ImageRepo 图片仓库
@@ -105,6 +110,12 @@ func (r *ImageRepo) FindByNarrationIDAndVersion(ctx context.Context, narrationID
 	return images, nil
 }
 
+// ListByNarrationIDAndVersion 分页查询解说指定版本的图片（默认按 sequence 排序），同时返回总数
+func (r *ImageRepo) ListByNarrationIDAndVersion(ctx context.Context, narrationID string, version int, opts ListOptions) ([]*novel.Image, int64, error) {
+	filter := bson.M{"narration_id": narrationID, "version": version, "deleted_at": nil}
+	return listDocuments[novel.Image](ctx, r.coll, filter, opts, bson.D{{Key: "sequence", Value: 1}})
+}
+
 // FindBySceneAndShot 根据场景和镜头编号查询
 func (r *ImageRepo) FindBySceneAndShot(ctx context.Context, chapterID, sceneNumber, shotNumber string) (*novel.Image, error) {
 	var image novel.Image
@@ -120,6 +131,16 @@ func (r *ImageRepo) FindBySceneAndShot(ctx context.Context, chapterID, sceneNumb
 	return &image, nil
 }
 
+// FindByShotID 根据镜头ID查询最新的图片
+func (r *ImageRepo) FindByShotID(ctx context.Context, shotID string) (*novel.Image, error) {
+	var image novel.Image
+	opts := options.FindOne().SetSort(bson.M{"version": -1})
+	if err := r.coll.FindOne(ctx, bson.M{"shot_id": shotID, "deleted_at": nil}, opts).Decode(&image); err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
 // FindByChapterIDAndVersion 根据章节ID和版本号查询图片
 func (r *ImageRepo) FindByChapterIDAndVersion(ctx context.Context, chapterID string, version int) ([]*novel.Image, error) {
 	filter := bson.M{"chapter_id": chapterID, "version": version, "deleted_at": nil}
@@ -170,6 +191,26 @@ func (r *ImageRepo) FindVersionsByChapterID(ctx context.Context, chapterID strin
 	return versions, nil
 }
 
+// FindByStatusUpdatedBefore 查询指定状态且最后更新时间早于 before 的图片（用于清理失败产物），novelID 为空时不限小说
+func (r *ImageRepo) FindByStatusUpdatedBefore(ctx context.Context, status novel.TaskStatus, before time.Time, novelID string) ([]*novel.Image, error) {
+	filter := bson.M{"status": status, "updated_at": bson.M{"$lt": before}, "deleted_at": nil}
+	if novelID != "" {
+		filter["novel_id"] = novelID
+	}
+	opts := options.Find().SetSort(bson.M{"updated_at": 1})
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var images []*novel.Image
+	if err := cur.All(ctx, &images); err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
 // UpdateStatus 更新状态
 func (r *ImageRepo) UpdateStatus(ctx context.Context, id string, status novel.TaskStatus) error {
 	_, err := r.coll.UpdateOne(
@@ -183,6 +224,17 @@ func (r *ImageRepo) UpdateStatus(ctx context.Context, id string, status novel.Ta
 	return err
 }
 
+// UpdateByShotID 更新关联到指定镜头的所有图片（镜头重排后按稳定的镜头ID重新关联）
+func (r *ImageRepo) UpdateByShotID(ctx context.Context, shotID string, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+	_, err := r.coll.UpdateMany(
+		ctx,
+		bson.M{"shot_id": shotID, "deleted_at": nil},
+		bson.M{"$set": updates},
+	)
+	return err
+}
+
 // Delete 软删除
 func (r *ImageRepo) Delete(ctx context.Context, id string) error {
 	_, err := r.coll.UpdateOne(
@@ -195,3 +247,17 @@ func (r *ImageRepo) Delete(ctx context.Context, id string) error {
 	)
 	return err
 }
+
+// MoveChapterVersion 把章节某个版本的所有图片移到另一个章节的指定版本（合并章节分支时使用）
+func (r *ImageRepo) MoveChapterVersion(ctx context.Context, fromChapterID string, fromVersion int, toChapterID string, toVersion int) error {
+	_, err := r.coll.UpdateMany(
+		ctx,
+		bson.M{"chapter_id": fromChapterID, "version": fromVersion, "deleted_at": nil},
+		bson.M{"$set": bson.M{
+			"chapter_id": toChapterID,
+			"version":    toVersion,
+			"updated_at": time.Now(),
+		}},
+	)
+	return err
+}
@@ -0,0 +1,340 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// JobFilter 任务查询条件（字段为空表示不限制）
+type JobFilter struct {
+	NovelID   string
+	ChapterID string
+	Type      novel.PipelineStage
+	Status    novel.JobStatus
+}
+
+// JobRepository 异步任务队列仓库接口
+type JobRepository interface {
+	Create(ctx context.Context, job *novel.Job) error
+	FindByID(ctx context.Context, id string) (*novel.Job, error)
+	Find(ctx context.Context, filter JobFilter, limit int) ([]*novel.Job, error)
+	Claim(ctx context.Context, nodeID, workerID string, lease time.Duration) (*novel.Job, error)
+	RenewLease(ctx context.Context, id, workerID string, lease time.Duration) (*novel.Job, error)
+	Finish(ctx context.Context, id, workerID string, status novel.JobStatus, resourceIDs []string, errMsg, errClass string) (bool, error)
+	Requeue(ctx context.Context, id, workerID string, runAfter time.Time, refundAttempt bool, errMsg, errClass string) (bool, error)
+	RequestCancel(ctx context.Context, id string) (*novel.Job, error)
+	Retry(ctx context.Context, id string) (*novel.Job, error)
+	CountRunningByNode(ctx context.Context) (map[string]int, error)
+	RequestRequeueByNode(ctx context.Context, nodeID string) (int64, error)
+	ReleaseByNode(ctx context.Context, nodeID string) (int64, error)
+	FindUpdatedAfter(ctx context.Context, after time.Time, afterID string, limit int) ([]*novel.Job, error)
+}
+
+// JobRepo 异步任务队列仓库实现
+type JobRepo struct {
+	coll *mongo.Collection
+}
+
+// NewJobRepo 创建异步任务队列仓库
+func NewJobRepo(db *mongo.Database) *JobRepo {
+	var j novel.Job
+	return &JobRepo{coll: db.Collection(j.Collection())}
+}
+
+// Create 创建任务（状态为排队中）
+func (r *JobRepo) Create(ctx context.Context, job *novel.Job) error {
+	now := time.Now()
+	job.Status = novel.JobStatusQueued
+	if job.RunAfter.IsZero() {
+		job.RunAfter = now
+	}
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	_, err := r.coll.InsertOne(ctx, job)
+	return err
+}
+
+// FindByID 根据ID查询任务
+func (r *JobRepo) FindByID(ctx context.Context, id string) (*novel.Job, error) {
+	var job novel.Job
+	if err := r.coll.FindOne(ctx, bson.M{"id": id}).Decode(&job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Find 按条件查询任务（按 created_at desc 排序）
+func (r *JobRepo) Find(ctx context.Context, filter JobFilter, limit int) ([]*novel.Job, error) {
+	query := bson.M{}
+	if filter.NovelID != "" {
+		query["novel_id"] = filter.NovelID
+	}
+	if filter.ChapterID != "" {
+		query["chapter_id"] = filter.ChapterID
+	}
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cur, err := r.coll.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var jobs []*novel.Job
+	if err := cur.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// Claim 领取一个可执行的任务：到达执行时间的排队任务，或租约已过期的执行中任务（执行它的进程已退出）
+// 领取后状态为执行中，执行次数加一；没有可执行的任务时返回 mongo.ErrNoDocuments
+// 领取顺序（先提交先执行）对应 jobsched 的 fifo 策略，修改时同步更新，可先用 jobsim 命令模拟评估
+func (r *JobRepo) Claim(ctx context.Context, nodeID, workerID string, lease time.Duration) (*novel.Job, error) {
+	now := time.Now()
+	filter := bson.M{"$or": []bson.M{
+		{"status": novel.JobStatusQueued, "run_after": bson.M{"$lte": now}},
+		{"status": novel.JobStatusRunning, "lease_until": bson.M{"$lt": now}},
+	}}
+	update := bson.M{
+		"$set": bson.M{
+			"status":            novel.JobStatusRunning,
+			"node_id":           nodeID,
+			"worker_id":         workerID,
+			"lease_until":       now.Add(lease),
+			"requeue_requested": false,
+			"started_at":        now,
+			"updated_at":        now,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "run_after", Value: 1}, {Key: "created_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job novel.Job
+	if err := r.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// RenewLease 续租执行中的任务，返回续租后的任务（可据此得知是否已请求取消）
+// 任务已不由该工作协程执行（已结束或租约过期后被其他工作协程领取）时返回 mongo.ErrNoDocuments
+func (r *JobRepo) RenewLease(ctx context.Context, id, workerID string, lease time.Duration) (*novel.Job, error) {
+	now := time.Now()
+	filter := bson.M{"id": id, "worker_id": workerID, "status": novel.JobStatusRunning}
+	update := bson.M{"$set": bson.M{"lease_until": now.Add(lease), "updated_at": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var job novel.Job
+	if err := r.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Finish 结束执行中的任务，返回是否更新成功（任务已不由该工作协程执行时返回 false）
+func (r *JobRepo) Finish(ctx context.Context, id, workerID string, status novel.JobStatus, resourceIDs []string, errMsg, errClass string) (bool, error) {
+	now := time.Now()
+	result, err := r.coll.UpdateOne(ctx,
+		bson.M{"id": id, "worker_id": workerID, "status": novel.JobStatusRunning},
+		bson.M{
+			"$set": bson.M{
+				"status":        status,
+				"resource_ids":  resourceIDs,
+				"error_message": errMsg,
+				"error_class":   errClass,
+				"completed_at":  now,
+				"updated_at":    now,
+			},
+			"$unset": bson.M{"lease_until": ""},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// Requeue 把执行失败的任务放回队列，runAfter 之后再次执行；refundAttempt 为 true 时不计入执行次数（如维护模式暂停、节点排空迁移）
+func (r *JobRepo) Requeue(ctx context.Context, id, workerID string, runAfter time.Time, refundAttempt bool, errMsg, errClass string) (bool, error) {
+	update := bson.M{
+		"$set": bson.M{
+			"status":            novel.JobStatusQueued,
+			"run_after":         runAfter,
+			"requeue_requested": false,
+			"error_message":     errMsg,
+			"error_class":       errClass,
+			"updated_at":        time.Now(),
+		},
+		"$unset": bson.M{"lease_until": "", "node_id": "", "worker_id": ""},
+	}
+	if refundAttempt {
+		update["$inc"] = bson.M{"attempts": -1}
+	}
+	result, err := r.coll.UpdateOne(ctx,
+		bson.M{"id": id, "worker_id": workerID, "status": novel.JobStatusRunning, "cancel_requested": false},
+		update,
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// RequestCancel 取消任务：排队中的任务直接取消，执行中的任务标记为请求取消（由执行它的工作协程中断）
+// 返回更新后的任务；任务已结束时原样返回，由调用方判断
+func (r *JobRepo) RequestCancel(ctx context.Context, id string) (*novel.Job, error) {
+	now := time.Now()
+	if _, err := r.coll.UpdateOne(ctx,
+		bson.M{"id": id, "status": novel.JobStatusQueued},
+		bson.M{"$set": bson.M{
+			"status":           novel.JobStatusCanceled,
+			"cancel_requested": true,
+			"completed_at":     now,
+			"updated_at":       now,
+		}},
+	); err != nil {
+		return nil, err
+	}
+	if _, err := r.coll.UpdateOne(ctx,
+		bson.M{"id": id, "status": novel.JobStatusRunning},
+		bson.M{"$set": bson.M{"cancel_requested": true, "updated_at": now}},
+	); err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+// Retry 把失败或已取消的任务重新放回队列（执行次数清零）
+// 任务不是失败或已取消状态时原样返回当前任务，由调用方判断；任务不存在时返回 mongo.ErrNoDocuments
+func (r *JobRepo) Retry(ctx context.Context, id string) (*novel.Job, error) {
+	now := time.Now()
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var job novel.Job
+	err := r.coll.FindOneAndUpdate(ctx,
+		bson.M{"id": id, "status": bson.M{"$in": []novel.JobStatus{novel.JobStatusFailed, novel.JobStatusCanceled}}},
+		bson.M{
+			"$set": bson.M{
+				"status":            novel.JobStatusQueued,
+				"attempts":          0,
+				"cancel_requested":  false,
+				"requeue_requested": false,
+				"run_after":         now,
+				"updated_at":        now,
+			},
+			"$unset": bson.M{
+				"node_id":       "",
+				"worker_id":     "",
+				"lease_until":   "",
+				"error_message": "",
+				"error_class":   "",
+				"completed_at":  "",
+			},
+		},
+		opts,
+	).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return r.FindByID(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// CountRunningByNode 统计各工作节点上执行中的任务数
+func (r *JobRepo) CountRunningByNode(ctx context.Context) (map[string]int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": novel.JobStatusRunning}}},
+		{{Key: "$group", Value: bson.M{"_id": "$node_id", "count": bson.M{"$sum": 1}}}},
+	}
+	cur, err := r.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var rows []struct {
+		NodeID string `bson:"_id"`
+		Count  int    `bson:"count"`
+	}
+	if err := cur.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.NodeID] = row.Count
+	}
+	return counts, nil
+}
+
+// RequestRequeueByNode 把工作节点上执行中的任务标记为请求迁移（由执行它的工作协程中断后放回队列），返回标记的任务数
+func (r *JobRepo) RequestRequeueByNode(ctx context.Context, nodeID string) (int64, error) {
+	result, err := r.coll.UpdateMany(ctx,
+		bson.M{"node_id": nodeID, "status": novel.JobStatusRunning, "cancel_requested": false},
+		bson.M{"$set": bson.M{"requeue_requested": true, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// ReleaseByNode 把已失联工作节点上执行中的任务立即放回队列（不计入执行次数），不必等待租约过期，返回放回的任务数
+// 已请求取消的任务直接标记为已取消
+func (r *JobRepo) ReleaseByNode(ctx context.Context, nodeID string) (int64, error) {
+	now := time.Now()
+	if _, err := r.coll.UpdateMany(ctx,
+		bson.M{"node_id": nodeID, "status": novel.JobStatusRunning, "cancel_requested": true},
+		bson.M{
+			"$set":   bson.M{"status": novel.JobStatusCanceled, "completed_at": now, "updated_at": now},
+			"$unset": bson.M{"lease_until": ""},
+		},
+	); err != nil {
+		return 0, err
+	}
+	result, err := r.coll.UpdateMany(ctx,
+		bson.M{"node_id": nodeID, "status": novel.JobStatusRunning},
+		bson.M{
+			"$set": bson.M{
+				"status":            novel.JobStatusQueued,
+				"run_after":         now,
+				"requeue_requested": false,
+				"updated_at":        now,
+			},
+			"$unset": bson.M{"lease_until": "", "node_id": "", "worker_id": ""},
+			"$inc":   bson.M{"attempts": -1},
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// FindUpdatedAfter 查询游标（updated_at, id）之后更新过的任务（按 updated_at、id 升序，用于分析数据增量导出）
+func (r *JobRepo) FindUpdatedAfter(ctx context.Context, after time.Time, afterID string, limit int) ([]*novel.Job, error) {
+	var jobs []*novel.Job
+	if err := findAfterCursor(ctx, r.coll, "updated_at", after, afterID, nil, limit, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
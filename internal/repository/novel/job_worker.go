@@ -0,0 +1,106 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// JobWorkerRepository 任务队列工作节点仓库接口
+type JobWorkerRepository interface {
+	Heartbeat(ctx context.Context, worker *novel.JobWorker) (*novel.JobWorker, error)
+	FindByID(ctx context.Context, id string) (*novel.JobWorker, error)
+	FindAll(ctx context.Context) ([]*novel.JobWorker, error)
+	SetCordon(ctx context.Context, id string, cordoned bool, reason string) (*novel.JobWorker, error)
+}
+
+// JobWorkerRepo 任务队列工作节点仓库实现
+type JobWorkerRepo struct {
+	coll *mongo.Collection
+}
+
+// NewJobWorkerRepo 创建任务队列工作节点仓库
+func NewJobWorkerRepo(db *mongo.Database) *JobWorkerRepo {
+	var w novel.JobWorker
+	return &JobWorkerRepo{coll: db.Collection(w.Collection())}
+}
+
+// Heartbeat 上报工作节点心跳（节点不存在时创建），返回最新的节点信息（可据此得知是否已被隔离）
+// 隔离状态只由 SetCordon 修改，心跳不会覆盖
+func (r *JobWorkerRepo) Heartbeat(ctx context.Context, worker *novel.JobWorker) (*novel.JobWorker, error) {
+	now := time.Now()
+	startedAt := worker.StartedAt
+	if startedAt.IsZero() {
+		startedAt = now
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var updated novel.JobWorker
+	err := r.coll.FindOneAndUpdate(ctx,
+		bson.M{"id": worker.ID},
+		bson.M{
+			"$set": bson.M{
+				"host":         worker.Host,
+				"concurrency":  worker.Concurrency,
+				"started_at":   startedAt,
+				"heartbeat_at": now,
+			},
+			"$setOnInsert": bson.M{"cordoned": false},
+		},
+		opts,
+	).Decode(&updated)
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// FindByID 根据节点ID查询工作节点
+func (r *JobWorkerRepo) FindByID(ctx context.Context, id string) (*novel.JobWorker, error) {
+	var worker novel.JobWorker
+	if err := r.coll.FindOne(ctx, bson.M{"id": id}).Decode(&worker); err != nil {
+		return nil, err
+	}
+	return &worker, nil
+}
+
+// FindAll 查询所有工作节点（按 heartbeat_at desc 排序）
+func (r *JobWorkerRepo) FindAll(ctx context.Context) ([]*novel.JobWorker, error) {
+	opts := options.Find().SetSort(bson.M{"heartbeat_at": -1})
+	cur, err := r.coll.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var workers []*novel.JobWorker
+	if err := cur.All(ctx, &workers); err != nil {
+		return nil, err
+	}
+	return workers, nil
+}
+
+// SetCordon 隔离或解除隔离工作节点，返回更新后的节点；节点不存在时返回 mongo.ErrNoDocuments
+func (r *JobWorkerRepo) SetCordon(ctx context.Context, id string, cordoned bool, reason string) (*novel.JobWorker, error) {
+	update := bson.M{
+		"$set": bson.M{"cordoned": true, "cordon_reason": reason, "cordoned_at": time.Now()},
+	}
+	if !cordoned {
+		update = bson.M{
+			"$set":   bson.M{"cordoned": false},
+			"$unset": bson.M{"cordon_reason": "", "cordoned_at": ""},
+		}
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var worker novel.JobWorker
+	if err := r.coll.FindOneAndUpdate(ctx, bson.M{"id": id}, update, opts).Decode(&worker); err != nil {
+		return nil, err
+	}
+	return &worker, nil
+}
@@ -0,0 +1,65 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// LicenseGrantRepository 授权记录仓库接口
+type LicenseGrantRepository interface {
+	Create(ctx context.Context, grant *novel.LicenseGrant) error
+	FindByVideoID(ctx context.Context, videoID string) (*novel.LicenseGrant, error)
+	FindByChapterID(ctx context.Context, chapterID string) ([]*novel.LicenseGrant, error)
+}
+
+// LicenseGrantRepo 授权记录仓库实现
+type LicenseGrantRepo struct {
+	coll *mongo.Collection
+}
+
+// NewLicenseGrantRepo 创建授权记录仓库
+func NewLicenseGrantRepo(db *mongo.Database) *LicenseGrantRepo {
+	var l novel.LicenseGrant
+	return &LicenseGrantRepo{coll: db.Collection(l.Collection())}
+}
+
+// Create 创建授权记录
+func (r *LicenseGrantRepo) Create(ctx context.Context, grant *novel.LicenseGrant) error {
+	now := time.Now()
+	grant.CreatedAt = now
+	grant.UpdatedAt = now
+	_, err := r.coll.InsertOne(ctx, grant)
+	return err
+}
+
+// FindByVideoID 根据视频ID查询授权记录
+func (r *LicenseGrantRepo) FindByVideoID(ctx context.Context, videoID string) (*novel.LicenseGrant, error) {
+	var grant novel.LicenseGrant
+	if err := r.coll.FindOne(ctx, bson.M{"video_id": videoID, "deleted_at": nil}).Decode(&grant); err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+// FindByChapterID 查询章节的所有授权记录（按 created_at desc 排序）
+func (r *LicenseGrantRepo) FindByChapterID(ctx context.Context, chapterID string) ([]*novel.LicenseGrant, error) {
+	filter := bson.M{"chapter_id": chapterID, "deleted_at": nil}
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var grants []*novel.LicenseGrant
+	if err := cur.All(ctx, &grants); err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
@@ -0,0 +1,178 @@
+package novel
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/model/resource"
+	"lemon/internal/pkg/resourceref"
+)
+
+// LifecycleRepository 小说、章节和生成产物的级联删除仓库接口
+type LifecycleRepository interface {
+	// CascadeSoftDelete 在小说内容集合中软删除 field 属于 values 的未删除记录（field 为 novel_id 或 chapter_id）
+	// 返回各集合删除的记录数和被删除记录引用的资源ID
+	CascadeSoftDelete(ctx context.Context, field string, values []string, deletedAt time.Time) (map[string]int64, []string, error)
+
+	// SoftDeleteByIDs 软删除集合中指定ID的未删除记录，返回删除的记录数和被删除记录引用的资源ID
+	SoftDeleteByIDs(ctx context.Context, collection string, ids []string, deletedAt time.Time) (int64, []string, error)
+
+	// LiveResourceIDs 收集小说中未删除的记录（包括小说本身）引用的资源ID
+	LiveResourceIDs(ctx context.Context, novelID string) (map[string]bool, error)
+}
+
+// LifecycleRepo 级联删除仓库实现
+// 直接按集合名操作，新增带 deleted_at 的小说内容集合时需要加入 cascadeCollections
+type LifecycleRepo struct {
+	db *mongo.Database
+}
+
+// NewLifecycleRepo 创建级联删除仓库
+func NewLifecycleRepo(db *mongo.Database) *LifecycleRepo {
+	return &LifecycleRepo{db: db}
+}
+
+// cascadeCollections 级联删除的集合及其包含的范围字段
+// 授权记录（license_grants）属于合同数据，删除内容时保留
+func cascadeCollections() map[string][]string {
+	var (
+		chapter   novel.Chapter
+		narration novel.Narration
+		scene     novel.Scene
+		shot      novel.Shot
+		audio     novel.Audio
+		subtitle  novel.Subtitle
+		image     novel.Image
+		video     novel.Video
+		variant   novel.SceneImageVariant
+		styleRef  novel.StyleReference
+		character novel.Character
+		prop      novel.Prop
+		cover     novel.NovelCover
+		entry     novel.PronunciationEntry
+	)
+	both := []string{"novel_id", "chapter_id"}
+	novelOnly := []string{"novel_id"}
+	return map[string][]string{
+		chapter.Collection():   novelOnly,
+		narration.Collection(): both,
+		scene.Collection():     both,
+		shot.Collection():      both,
+		audio.Collection():     both,
+		subtitle.Collection():  both,
+		image.Collection():     both,
+		video.Collection():     both,
+		variant.Collection():   both,
+		styleRef.Collection():  both,
+		character.Collection(): novelOnly,
+		prop.Collection():      novelOnly,
+		cover.Collection():     novelOnly,
+		entry.Collection():     novelOnly,
+	}
+}
+
+// CascadeSoftDelete 按范围字段级联软删除
+func (r *LifecycleRepo) CascadeSoftDelete(ctx context.Context, field string, values []string, deletedAt time.Time) (map[string]int64, []string, error) {
+	deleted := make(map[string]int64)
+	refs := make(map[string]bool)
+	for collection, fields := range cascadeCollections() {
+		if !slices.Contains(fields, field) {
+			continue
+		}
+		n, err := r.softDelete(ctx, collection, bson.M{field: bson.M{"$in": values}}, deletedAt, refs)
+		if err != nil {
+			return deleted, sortedKeys(refs), err
+		}
+		if n > 0 {
+			deleted[collection] = n
+		}
+	}
+	return deleted, sortedKeys(refs), nil
+}
+
+// SoftDeleteByIDs 按ID软删除
+func (r *LifecycleRepo) SoftDeleteByIDs(ctx context.Context, collection string, ids []string, deletedAt time.Time) (int64, []string, error) {
+	refs := make(map[string]bool)
+	n, err := r.softDelete(ctx, collection, bson.M{"id": bson.M{"$in": ids}}, deletedAt, refs)
+	return n, sortedKeys(refs), err
+}
+
+// softDelete 软删除满足 filter 的未删除记录，并收集这些记录引用的资源ID
+// 先更新再按删除时间查出本次删除的记录，查询和更新之间新写入的记录也能收集到
+func (r *LifecycleRepo) softDelete(ctx context.Context, collection string, filter bson.M, deletedAt time.Time, refs map[string]bool) (int64, error) {
+	coll := r.db.Collection(collection)
+	filter["deleted_at"] = nil
+	res, err := coll.UpdateMany(ctx, filter, bson.M{
+		"$set": bson.M{"deleted_at": deletedAt, "updated_at": deletedAt},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("soft delete %s: %w", collection, err)
+	}
+	if res.ModifiedCount == 0 {
+		return 0, nil
+	}
+
+	filter["deleted_at"] = deletedAt
+	if err := collectRefs(ctx, coll, filter, refs); err != nil {
+		return res.ModifiedCount, fmt.Errorf("collect resources of %s: %w", collection, err)
+	}
+	return res.ModifiedCount, nil
+}
+
+// LiveResourceIDs 扫描除资源集合外的所有集合中属于该小说的未删除记录
+func (r *LifecycleRepo) LiveResourceIDs(ctx context.Context, novelID string) (map[string]bool, error) {
+	var res resource.Resource
+	names, err := r.db.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("list collections: %w", err)
+	}
+
+	refs := make(map[string]bool)
+	filter := bson.M{
+		"$or":        bson.A{bson.M{"novel_id": novelID}, bson.M{"id": novelID}},
+		"deleted_at": nil,
+	}
+	for _, name := range names {
+		if name == res.Collection() || strings.HasPrefix(name, "system.") {
+			continue
+		}
+		if err := collectRefs(ctx, r.db.Collection(name), filter, refs); err != nil {
+			return nil, fmt.Errorf("collect resources of %s: %w", name, err)
+		}
+	}
+	return refs, nil
+}
+
+// collectRefs 收集满足 filter 的记录引用的资源ID
+func collectRefs(ctx context.Context, coll *mongo.Collection, filter bson.M, refs map[string]bool) error {
+	cursor, err := coll.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	add := func(id string) { refs[id] = true }
+	for cursor.Next(ctx) {
+		if err := resourceref.Collect(cursor.Current, add); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// sortedKeys 按字典序返回集合中的ID
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,71 @@
+package novel
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ListOptions 列表查询的分页、排序和筛选条件
+type ListOptions struct {
+	Statuses   []string // 状态，为空时不限
+	VideoTypes []string // 视频类型（仅视频），为空时不限
+	SortField  string   // 排序字段，为空时使用各列表的默认排序
+	Desc       bool     // 是否倒序（只在指定 SortField 时生效）
+	Skip       int64    // 跳过的条数
+	Limit      int64    // 返回数量，0 表示不限
+}
+
+// apply 把筛选条件加到查询条件上
+func (o ListOptions) apply(filter bson.M) bson.M {
+	if len(o.Statuses) > 0 {
+		filter["status"] = bson.M{"$in": o.Statuses}
+	}
+	if len(o.VideoTypes) > 0 {
+		filter["video_type"] = bson.M{"$in": o.VideoTypes}
+	}
+	return filter
+}
+
+// findOptions 生成排序和分页选项；指定排序字段时以 id 作为第二排序字段，保证翻页时顺序稳定
+func (o ListOptions) findOptions(defaultSort bson.D) *options.FindOptions {
+	sort := defaultSort
+	if o.SortField != "" {
+		order := 1
+		if o.Desc {
+			order = -1
+		}
+		sort = bson.D{{Key: o.SortField, Value: order}, {Key: "id", Value: order}}
+	}
+	opts := options.Find().SetSort(sort)
+	if o.Skip > 0 {
+		opts.SetSkip(o.Skip)
+	}
+	if o.Limit > 0 {
+		opts.SetLimit(o.Limit)
+	}
+	return opts
+}
+
+// listDocuments 按条件查询一页数据，同时返回满足条件的总数
+func listDocuments[T any](ctx context.Context, coll *mongo.Collection, filter bson.M, opts ListOptions, defaultSort bson.D) ([]*T, int64, error) {
+	filter = opts.apply(filter)
+	total, err := coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cur, err := coll.Find(ctx, filter, opts.findOptions(defaultSort))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cur.Close(ctx)
+
+	var items []*T
+	if err := cur.All(ctx, &items); err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
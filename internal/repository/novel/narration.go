@@ -17,11 +17,16 @@ type NarrationRepository interface {
 	FindByID(ctx context.Context, id string) (*novel.Narration, error)
 	FindByChapterID(ctx context.Context, chapterID string) (*novel.Narration, error)
 	FindAllByChapterID(ctx context.Context, chapterID string) ([]*novel.Narration, error)
+	ListByChapterID(ctx context.Context, chapterID string, version int, opts ListOptions) ([]*novel.Narration, int64, error)
 	FindByChapterIDAndVersion(ctx context.Context, chapterID string, version int) (*novel.Narration, error)
 	FindVersionsByChapterID(ctx context.Context, chapterID string) ([]int, error)
 	UpdateStatus(ctx context.Context, id string, status novel.TaskStatus, errorMessage string) error
 	UpdateVersion(ctx context.Context, id string, version int) error
+	UpdateCompletedScenes(ctx context.Context, id string, completedScenes int) error
+	UpdateFeedback(ctx context.Context, id string, feedback *novel.NarrationFeedback) error
+	IncrementMinorVersion(ctx context.Context, id string, expected int) (bool, error)
 	Delete(ctx context.Context, id string) error
+	MoveChapterVersion(ctx context.Context, fromChapterID string, fromVersion int, toChapterID string, toVersion int) error
 }
 
 // NarrationRepo 解说仓库实现
@@ -87,6 +92,15 @@ func (r *NarrationRepo) FindAllByChapterID(ctx context.Context, chapterID string
 	return narrations, nil
 }
 
+// ListByChapterID 分页查询章节的解说（version<=0 时不限版本，默认按 version desc, created_at desc 排序），同时返回总数
+func (r *NarrationRepo) ListByChapterID(ctx context.Context, chapterID string, version int, opts ListOptions) ([]*novel.Narration, int64, error) {
+	filter := bson.M{"chapter_id": chapterID, "deleted_at": nil}
+	if version > 0 {
+		filter["version"] = version
+	}
+	return listDocuments[novel.Narration](ctx, r.coll, filter, opts, bson.D{{Key: "version", Value: -1}, {Key: "created_at", Value: -1}})
+}
+
 // FindByChapterIDAndVersion 根据章节ID和版本号查询解说
 func (r *NarrationRepo) FindByChapterIDAndVersion(ctx context.Context, chapterID string, version int) (*novel.Narration, error) {
 	var n novel.Narration
@@ -163,6 +177,52 @@ func (r *NarrationRepo) UpdateVersion(ctx context.Context, id string, version in
 	return err
 }
 
+// UpdateCompletedScenes 更新解说已完成的场景数（流式生成进度）
+func (r *NarrationRepo) UpdateCompletedScenes(ctx context.Context, id string, completedScenes int) error {
+	_, err := r.coll.UpdateOne(
+		ctx,
+		bson.M{"id": id},
+		bson.M{"$set": bson.M{
+			"completed_scenes": completedScenes,
+			"updated_at":       time.Now(),
+		}},
+	)
+	return err
+}
+
+// UpdateFeedback 记录促成该解说版本的审核意见
+func (r *NarrationRepo) UpdateFeedback(ctx context.Context, id string, feedback *novel.NarrationFeedback) error {
+	_, err := r.coll.UpdateOne(
+		ctx,
+		bson.M{"id": id},
+		bson.M{"$set": bson.M{
+			"feedback":   feedback,
+			"updated_at": time.Now(),
+		}},
+	)
+	return err
+}
+
+// IncrementMinorVersion 小版本号为 expected 时加一，返回是否更新成功（已被其他补丁修改时返回 false）
+func (r *NarrationRepo) IncrementMinorVersion(ctx context.Context, id string, expected int) (bool, error) {
+	filter := bson.M{"id": id, "minor_version": expected}
+	if expected == 0 {
+		// 早期解说没有 minor_version 字段
+		filter["minor_version"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	result, err := r.coll.UpdateOne(
+		ctx,
+		filter,
+		bson.M{
+			"$set": bson.M{"minor_version": expected + 1, "updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
 // Delete 软删除解说
 func (r *NarrationRepo) Delete(ctx context.Context, id string) error {
 	_, err := r.coll.UpdateOne(
@@ -175,3 +235,17 @@ func (r *NarrationRepo) Delete(ctx context.Context, id string) error {
 	)
 	return err
 }
+
+// MoveChapterVersion 把章节某个版本的所有解说移到另一个章节的指定版本（合并章节分支时使用）
+func (r *NarrationRepo) MoveChapterVersion(ctx context.Context, fromChapterID string, fromVersion int, toChapterID string, toVersion int) error {
+	_, err := r.coll.UpdateMany(
+		ctx,
+		bson.M{"chapter_id": fromChapterID, "version": fromVersion, "deleted_at": nil},
+		bson.M{"$set": bson.M{
+			"chapter_id": toChapterID,
+			"version":    toVersion,
+			"updated_at": time.Now(),
+		}},
+	)
+	return err
+}
@@ -0,0 +1,52 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// NarrationPatchRepository 解说补丁记录仓库接口
+type NarrationPatchRepository interface {
+	Create(ctx context.Context, patch *novel.NarrationPatch) error
+	FindByNarrationID(ctx context.Context, narrationID string) ([]*novel.NarrationPatch, error)
+}
+
+// NarrationPatchRepo 解说补丁记录仓库实现
+type NarrationPatchRepo struct {
+	coll *mongo.Collection
+}
+
+// NewNarrationPatchRepo 创建解说补丁记录仓库
+func NewNarrationPatchRepo(db *mongo.Database) *NarrationPatchRepo {
+	var p novel.NarrationPatch
+	return &NarrationPatchRepo{coll: db.Collection(p.Collection())}
+}
+
+// Create 创建补丁记录
+func (r *NarrationPatchRepo) Create(ctx context.Context, patch *novel.NarrationPatch) error {
+	patch.CreatedAt = time.Now()
+	_, err := r.coll.InsertOne(ctx, patch)
+	return err
+}
+
+// FindByNarrationID 查询解说的补丁记录（按小版本号升序）
+func (r *NarrationPatchRepo) FindByNarrationID(ctx context.Context, narrationID string) ([]*novel.NarrationPatch, error) {
+	opts := options.Find().SetSort(bson.M{"minor_version": 1})
+	cur, err := r.coll.Find(ctx, bson.M{"narration_id": narrationID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var patches []*novel.NarrationPatch
+	if err := cur.All(ctx, &patches); err != nil {
+		return nil, err
+	}
+	return patches, nil
+}
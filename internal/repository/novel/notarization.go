@@ -0,0 +1,96 @@
+package novel
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// NotarizationRepository 最终视频公证记录仓库接口（只追加，不提供修改和删除）
+type NotarizationRepository interface {
+	Append(ctx context.Context, record *novel.NotarizationRecord) error
+	FindLatest(ctx context.Context) (*novel.NotarizationRecord, error)
+	FindBySequence(ctx context.Context, sequence int64) (*novel.NotarizationRecord, error)
+	FindAfterSequence(ctx context.Context, afterSequence int64, limit int) ([]*novel.NotarizationRecord, error)
+	FindByVideoID(ctx context.Context, videoID string) (*novel.NotarizationRecord, error)
+	FindBySHA256(ctx context.Context, sha256 string) ([]*novel.NotarizationRecord, error)
+	FindByChapterID(ctx context.Context, chapterID string) ([]*novel.NotarizationRecord, error)
+}
+
+// NotarizationRepo 最终视频公证记录仓库实现
+type NotarizationRepo struct {
+	coll *mongo.Collection
+}
+
+// NewNotarizationRepo 创建最终视频公证记录仓库
+func NewNotarizationRepo(db *mongo.Database) *NotarizationRepo {
+	var r novel.NotarizationRecord
+	return &NotarizationRepo{coll: db.Collection(r.Collection())}
+}
+
+// Append 追加公证记录（CreatedAt 参与哈希计算，由调用方设置）
+// 序号或视频已存在时返回 mongo 的重复键错误（可用 mongo.IsDuplicateKeyError 判断）
+func (r *NotarizationRepo) Append(ctx context.Context, record *novel.NotarizationRecord) error {
+	_, err := r.coll.InsertOne(ctx, record)
+	return err
+}
+
+// FindLatest 查询序号最大的记录（没有记录时返回 mongo.ErrNoDocuments）
+func (r *NotarizationRepo) FindLatest(ctx context.Context) (*novel.NotarizationRecord, error) {
+	return r.findOne(ctx, bson.M{}, options.FindOne().SetSort(bson.M{"sequence": -1}))
+}
+
+// FindBySequence 按序号查询记录
+func (r *NotarizationRepo) FindBySequence(ctx context.Context, sequence int64) (*novel.NotarizationRecord, error) {
+	return r.findOne(ctx, bson.M{"sequence": sequence})
+}
+
+// FindAfterSequence 查询序号大于 afterSequence 的记录（按 sequence asc 排序）
+func (r *NotarizationRepo) FindAfterSequence(ctx context.Context, afterSequence int64, limit int) ([]*novel.NotarizationRecord, error) {
+	opts := options.Find().SetSort(bson.M{"sequence": 1})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	return r.find(ctx, bson.M{"sequence": bson.M{"$gt": afterSequence}}, opts)
+}
+
+// FindByVideoID 查询视频的公证记录
+func (r *NotarizationRepo) FindByVideoID(ctx context.Context, videoID string) (*novel.NotarizationRecord, error) {
+	return r.findOne(ctx, bson.M{"video_id": videoID})
+}
+
+// FindBySHA256 查询文件哈希相同的所有记录（按 sequence asc 排序）
+func (r *NotarizationRepo) FindBySHA256(ctx context.Context, sha256 string) ([]*novel.NotarizationRecord, error) {
+	return r.find(ctx, bson.M{"sha256": sha256}, options.Find().SetSort(bson.M{"sequence": 1}))
+}
+
+// FindByChapterID 查询章节的所有公证记录（按 sequence desc 排序）
+func (r *NotarizationRepo) FindByChapterID(ctx context.Context, chapterID string) ([]*novel.NotarizationRecord, error) {
+	return r.find(ctx, bson.M{"chapter_id": chapterID}, options.Find().SetSort(bson.M{"sequence": -1}))
+}
+
+func (r *NotarizationRepo) findOne(ctx context.Context, filter bson.M, opts ...*options.FindOneOptions) (*novel.NotarizationRecord, error) {
+	var record novel.NotarizationRecord
+	if err := r.coll.FindOne(ctx, filter, opts...).Decode(&record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *NotarizationRepo) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*novel.NotarizationRecord, error) {
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var records []*novel.NotarizationRecord
+	if err := cur.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
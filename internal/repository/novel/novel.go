@@ -16,6 +16,10 @@ type NovelRepository interface {
 	Create(ctx context.Context, novel *novel.Novel) error
 	FindByID(ctx context.Context, id string) (*novel.Novel, error)
 	ListByUser(ctx context.Context, userID string, page, pageSize int64) ([]*novel.Novel, int64, error)
+	Update(ctx context.Context, id string, set bson.M) error
+	UpdateBudget(ctx context.Context, id string, set bson.M, unset []string) error
+	AddSpend(ctx context.Context, id string, amountFen int64) (*novel.Novel, error)
+	MarkBudgetEvent(ctx context.Context, id, field string, at time.Time) (bool, error)
 }
 
 // NovelRepo 小说仓库
@@ -75,3 +79,72 @@ func (r *NovelRepo) ListByUser(ctx context.Context, userID string, page, pageSiz
 	}
 	return novels, total, nil
 }
+
+// Update 更新小说字段（自动刷新 updated_at）
+func (r *NovelRepo) Update(ctx context.Context, id string, set bson.M) error {
+	fields := bson.M{"updated_at": time.Now()}
+	for k, v := range set {
+		fields[k] = v
+	}
+	result, err := r.coll.UpdateOne(ctx, bson.M{"id": id, "deleted_at": nil}, bson.M{"$set": fields})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// UpdateBudget 更新预算设置
+// set/unset 的键为 NovelBudget 的字段名（如 cap_fen、warned_at），内部自动加上 budget. 前缀
+func (r *NovelRepo) UpdateBudget(ctx context.Context, id string, set bson.M, unset []string) error {
+	update := bson.M{}
+	setFields := bson.M{"updated_at": time.Now()}
+	for k, v := range set {
+		setFields["budget."+k] = v
+	}
+	update["$set"] = setFields
+	if len(unset) > 0 {
+		unsetFields := bson.M{}
+		for _, k := range unset {
+			unsetFields["budget."+k] = ""
+		}
+		update["$unset"] = unsetFields
+	}
+
+	result, err := r.coll.UpdateOne(ctx, bson.M{"id": id, "deleted_at": nil}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// AddSpend 原子累加已花费金额，返回更新后的小说
+func (r *NovelRepo) AddSpend(ctx context.Context, id string, amountFen int64) (*novel.Novel, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var n novel.Novel
+	err := r.coll.FindOneAndUpdate(ctx, bson.M{"id": id, "deleted_at": nil}, bson.M{
+		"$inc": bson.M{"budget.spent_fen": amountFen},
+	}, opts).Decode(&n)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// MarkBudgetEvent 记录预算事件时间（如 warned_at、exceeded_at）
+// 只有该字段为空时才会写入，返回是否写入成功，用于保证同一事件只触发一次
+func (r *NovelRepo) MarkBudgetEvent(ctx context.Context, id, field string, at time.Time) (bool, error) {
+	result, err := r.coll.UpdateOne(ctx,
+		bson.M{"id": id, "deleted_at": nil, "budget." + field: nil},
+		bson.M{"$set": bson.M{"budget." + field: at}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
@@ -0,0 +1,77 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// NovelCoverRepository 小说封面仓库接口
+type NovelCoverRepository interface {
+	Create(ctx context.Context, cover *novel.NovelCover) error
+	FindByID(ctx context.Context, id string) (*novel.NovelCover, error)
+	FindByNovelID(ctx context.Context, novelID string) ([]*novel.NovelCover, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// NovelCoverRepo 小说封面仓库实现
+type NovelCoverRepo struct {
+	coll *mongo.Collection
+}
+
+// NewNovelCoverRepo 创建小说封面仓库
+func NewNovelCoverRepo(db *mongo.Database) *NovelCoverRepo {
+	var c novel.NovelCover
+	return &NovelCoverRepo{coll: db.Collection(c.Collection())}
+}
+
+// Create 创建封面
+func (r *NovelCoverRepo) Create(ctx context.Context, cover *novel.NovelCover) error {
+	now := time.Now()
+	cover.CreatedAt = now
+	cover.UpdatedAt = now
+	_, err := r.coll.InsertOne(ctx, cover)
+	return err
+}
+
+// FindByID 根据ID查询封面
+func (r *NovelCoverRepo) FindByID(ctx context.Context, id string) (*novel.NovelCover, error) {
+	var cover novel.NovelCover
+	if err := r.coll.FindOne(ctx, bson.M{"id": id, "deleted_at": nil}).Decode(&cover); err != nil {
+		return nil, err
+	}
+	return &cover, nil
+}
+
+// FindByNovelID 查询小说的所有封面（按 created_at desc 排序）
+func (r *NovelCoverRepo) FindByNovelID(ctx context.Context, novelID string) ([]*novel.NovelCover, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cur, err := r.coll.Find(ctx, bson.M{"novel_id": novelID, "deleted_at": nil}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var covers []*novel.NovelCover
+	if err := cur.All(ctx, &covers); err != nil {
+		return nil, err
+	}
+	return covers, nil
+}
+
+// Delete 删除封面（软删除）
+func (r *NovelCoverRepo) Delete(ctx context.Context, id string) error {
+	now := time.Now()
+	_, err := r.coll.UpdateOne(ctx, bson.M{"id": id, "deleted_at": nil}, bson.M{
+		"$set": bson.M{
+			"deleted_at": now,
+			"updated_at": now,
+		},
+	})
+	return err
+}
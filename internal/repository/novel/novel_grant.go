@@ -0,0 +1,95 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// NovelGrantRepository 小说协作授权仓库接口
+type NovelGrantRepository interface {
+	// Upsert 创建或更新授权（按 novel_id + user_id 唯一），返回写入后的授权
+	Upsert(ctx context.Context, grant *novel.NovelGrant) (*novel.NovelGrant, error)
+	FindByNovelIDAndUserID(ctx context.Context, novelID, userID string) (*novel.NovelGrant, error)
+	FindByNovelID(ctx context.Context, novelID string) ([]*novel.NovelGrant, error)
+	// Delete 撤销授权，授权不存在时返回 mongo.ErrNoDocuments
+	Delete(ctx context.Context, novelID, userID string) error
+}
+
+// NovelGrantRepo 小说协作授权仓库实现
+type NovelGrantRepo struct {
+	coll *mongo.Collection
+}
+
+// NewNovelGrantRepo 创建小说协作授权仓库
+func NewNovelGrantRepo(db *mongo.Database) *NovelGrantRepo {
+	var g novel.NovelGrant
+	return &NovelGrantRepo{coll: db.Collection(g.Collection())}
+}
+
+// Upsert 创建或更新授权
+// 已有授权时只更新权限和授权人，保留原授权ID和创建时间
+func (r *NovelGrantRepo) Upsert(ctx context.Context, grant *novel.NovelGrant) (*novel.NovelGrant, error) {
+	now := time.Now()
+	filter := bson.M{"novel_id": grant.NovelID, "user_id": grant.UserID}
+	update := bson.M{
+		"$set": bson.M{
+			"permission": grant.Permission,
+			"granted_by": grant.GrantedBy,
+			"updated_at": now,
+		},
+		"$setOnInsert": bson.M{
+			"id":         grant.ID,
+			"created_at": now,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var saved novel.NovelGrant
+	if err := r.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// FindByNovelIDAndUserID 查询用户在小说下的授权
+func (r *NovelGrantRepo) FindByNovelIDAndUserID(ctx context.Context, novelID, userID string) (*novel.NovelGrant, error) {
+	var grant novel.NovelGrant
+	if err := r.coll.FindOne(ctx, bson.M{"novel_id": novelID, "user_id": userID}).Decode(&grant); err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+// FindByNovelID 查询小说的所有授权（按 created_at asc 排序）
+func (r *NovelGrantRepo) FindByNovelID(ctx context.Context, novelID string) ([]*novel.NovelGrant, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	cur, err := r.coll.Find(ctx, bson.M{"novel_id": novelID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var grants []*novel.NovelGrant
+	if err := cur.All(ctx, &grants); err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+// Delete 撤销授权
+func (r *NovelGrantRepo) Delete(ctx context.Context, novelID, userID string) error {
+	result, err := r.coll.DeleteOne(ctx, bson.M{"novel_id": novelID, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
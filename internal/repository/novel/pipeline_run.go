@@ -0,0 +1,70 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// PipelineRunRepository 章节一键生成流程仓库接口
+type PipelineRunRepository interface {
+	Create(ctx context.Context, run *novel.PipelineRun) error
+	FindByID(ctx context.Context, id string) (*novel.PipelineRun, error)
+	FindLatestByChapterID(ctx context.Context, chapterID string) (*novel.PipelineRun, error)
+	Replace(ctx context.Context, run *novel.PipelineRun, expected novel.PipelineRunStatus) (bool, error)
+}
+
+// PipelineRunRepo 章节一键生成流程仓库实现
+type PipelineRunRepo struct {
+	coll *mongo.Collection
+}
+
+// NewPipelineRunRepo 创建章节一键生成流程仓库
+func NewPipelineRunRepo(db *mongo.Database) *PipelineRunRepo {
+	var r novel.PipelineRun
+	return &PipelineRunRepo{coll: db.Collection(r.Collection())}
+}
+
+// Create 创建流程，章节已有执行中的流程时返回 mongo 的重复键错误（可用 mongo.IsDuplicateKeyError 判断）
+func (r *PipelineRunRepo) Create(ctx context.Context, run *novel.PipelineRun) error {
+	now := time.Now()
+	run.CreatedAt = now
+	run.UpdatedAt = now
+	_, err := r.coll.InsertOne(ctx, run)
+	return err
+}
+
+// FindByID 根据ID查询流程
+func (r *PipelineRunRepo) FindByID(ctx context.Context, id string) (*novel.PipelineRun, error) {
+	var run novel.PipelineRun
+	if err := r.coll.FindOne(ctx, bson.M{"id": id}).Decode(&run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// FindLatestByChapterID 查询章节最近一次提交的流程，没有时返回 mongo.ErrNoDocuments
+func (r *PipelineRunRepo) FindLatestByChapterID(ctx context.Context, chapterID string) (*novel.PipelineRun, error) {
+	opts := options.FindOne().SetSort(bson.M{"created_at": -1})
+	var run novel.PipelineRun
+	if err := r.coll.FindOne(ctx, bson.M{"chapter_id": chapterID}, opts).Decode(&run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// Replace 整体替换流程，仅当流程当前状态为 expected 时更新，返回是否更新成功
+// 改为执行中时章节已有其他执行中的流程返回 mongo 的重复键错误
+func (r *PipelineRunRepo) Replace(ctx context.Context, run *novel.PipelineRun, expected novel.PipelineRunStatus) (bool, error) {
+	run.UpdatedAt = time.Now()
+	result, err := r.coll.ReplaceOne(ctx, bson.M{"id": run.ID, "status": expected}, run)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
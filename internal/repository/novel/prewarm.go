@@ -0,0 +1,118 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// PrewarmJobRepository 素材预热任务仓库接口
+type PrewarmJobRepository interface {
+	Create(ctx context.Context, job *novel.PrewarmJob) error
+	FindByID(ctx context.Context, id string) (*novel.PrewarmJob, error)
+	FindByNovelID(ctx context.Context, novelID string, limit int) ([]*novel.PrewarmJob, error)
+	FindByStatus(ctx context.Context, statuses ...novel.PrewarmStatus) ([]*novel.PrewarmJob, error)
+	MarkRunning(ctx context.Context, id string) (bool, error)
+	Finish(ctx context.Context, id string, status novel.PrewarmStatus, stats novel.PrewarmStats, errMsg string) error
+}
+
+// PrewarmJobRepo 素材预热任务仓库实现
+type PrewarmJobRepo struct {
+	coll *mongo.Collection
+}
+
+// NewPrewarmJobRepo 创建素材预热任务仓库
+func NewPrewarmJobRepo(db *mongo.Database) *PrewarmJobRepo {
+	var j novel.PrewarmJob
+	return &PrewarmJobRepo{coll: db.Collection(j.Collection())}
+}
+
+// Create 创建预热任务
+func (r *PrewarmJobRepo) Create(ctx context.Context, job *novel.PrewarmJob) error {
+	now := time.Now()
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	_, err := r.coll.InsertOne(ctx, job)
+	return err
+}
+
+// FindByID 根据ID查询预热任务
+func (r *PrewarmJobRepo) FindByID(ctx context.Context, id string) (*novel.PrewarmJob, error) {
+	var job novel.PrewarmJob
+	if err := r.coll.FindOne(ctx, bson.M{"id": id}).Decode(&job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// FindByNovelID 查询小说的预热任务（按 created_at desc 排序）
+func (r *PrewarmJobRepo) FindByNovelID(ctx context.Context, novelID string, limit int) ([]*novel.PrewarmJob, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cur, err := r.coll.Find(ctx, bson.M{"novel_id": novelID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var jobs []*novel.PrewarmJob
+	if err := cur.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// FindByStatus 查询指定状态的预热任务（按 start_at 排序，用于启动时恢复调度）
+func (r *PrewarmJobRepo) FindByStatus(ctx context.Context, statuses ...novel.PrewarmStatus) ([]*novel.PrewarmJob, error) {
+	opts := options.Find().SetSort(bson.M{"start_at": 1})
+	cur, err := r.coll.Find(ctx, bson.M{"status": bson.M{"$in": statuses}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var jobs []*novel.PrewarmJob
+	if err := cur.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// MarkRunning 把等待中的任务标记为预热中，返回是否标记成功（保证同一任务只执行一次）
+func (r *PrewarmJobRepo) MarkRunning(ctx context.Context, id string) (bool, error) {
+	now := time.Now()
+	result, err := r.coll.UpdateOne(ctx,
+		bson.M{"id": id, "status": novel.PrewarmStatusScheduled},
+		bson.M{"$set": bson.M{
+			"status":     novel.PrewarmStatusRunning,
+			"started_at": now,
+			"updated_at": now,
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// Finish 记录预热结果
+func (r *PrewarmJobRepo) Finish(ctx context.Context, id string, status novel.PrewarmStatus, stats novel.PrewarmStats, errMsg string) error {
+	now := time.Now()
+	_, err := r.coll.UpdateOne(ctx, bson.M{"id": id}, bson.M{
+		"$set": bson.M{
+			"status":        status,
+			"stats":         stats,
+			"error_message": errMsg,
+			"completed_at":  now,
+			"updated_at":    now,
+		},
+	})
+	return err
+}
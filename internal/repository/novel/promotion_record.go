@@ -0,0 +1,62 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// PromotionRecordRepository 章节发布记录仓库接口（只追加）
+type PromotionRecordRepository interface {
+	Append(ctx context.Context, record *novel.PromotionRecord) error
+	FindByChapterID(ctx context.Context, chapterID string) ([]*novel.PromotionRecord, error)
+	FindByBatchID(ctx context.Context, batchID string) ([]*novel.PromotionRecord, error)
+}
+
+// PromotionRecordRepo 章节发布记录仓库实现
+type PromotionRecordRepo struct {
+	coll *mongo.Collection
+}
+
+// NewPromotionRecordRepo 创建章节发布记录仓库
+func NewPromotionRecordRepo(db *mongo.Database) *PromotionRecordRepo {
+	var r novel.PromotionRecord
+	return &PromotionRecordRepo{coll: db.Collection(r.Collection())}
+}
+
+// Append 追加发布记录
+func (r *PromotionRecordRepo) Append(ctx context.Context, record *novel.PromotionRecord) error {
+	record.CreatedAt = time.Now()
+	_, err := r.coll.InsertOne(ctx, record)
+	return err
+}
+
+// FindByChapterID 查询章节的所有发布记录（按 created_at desc 排序）
+func (r *PromotionRecordRepo) FindByChapterID(ctx context.Context, chapterID string) ([]*novel.PromotionRecord, error) {
+	return r.find(ctx, bson.M{"chapter_id": chapterID})
+}
+
+// FindByBatchID 查询一次批量操作的所有发布记录（按 created_at desc 排序）
+func (r *PromotionRecordRepo) FindByBatchID(ctx context.Context, batchID string) ([]*novel.PromotionRecord, error) {
+	return r.find(ctx, bson.M{"batch_id": batchID})
+}
+
+func (r *PromotionRecordRepo) find(ctx context.Context, filter bson.M) ([]*novel.PromotionRecord, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var records []*novel.PromotionRecord
+	if err := cur.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,95 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// PronunciationRepository 发音词典仓库接口
+type PronunciationRepository interface {
+	Create(ctx context.Context, entry *novel.PronunciationEntry) error
+	FindByID(ctx context.Context, id string) (*novel.PronunciationEntry, error)
+	FindByNovelID(ctx context.Context, novelID string) ([]*novel.PronunciationEntry, error)
+	FindByTerm(ctx context.Context, novelID, term string) (*novel.PronunciationEntry, error)
+	Update(ctx context.Context, id string, updates map[string]interface{}) error
+	Delete(ctx context.Context, id string) error
+}
+
+// PronunciationRepo 发音词典仓库实现
+type PronunciationRepo struct {
+	coll *mongo.Collection
+}
+
+// NewPronunciationRepo 创建发音词典仓库
+func NewPronunciationRepo(db *mongo.Database) *PronunciationRepo {
+	var e novel.PronunciationEntry
+	return &PronunciationRepo{coll: db.Collection(e.Collection())}
+}
+
+// Create 创建词典条目
+func (r *PronunciationRepo) Create(ctx context.Context, entry *novel.PronunciationEntry) error {
+	now := time.Now()
+	entry.CreatedAt = now
+	entry.UpdatedAt = now
+	_, err := r.coll.InsertOne(ctx, entry)
+	return err
+}
+
+// FindByID 根据ID查询词典条目
+func (r *PronunciationRepo) FindByID(ctx context.Context, id string) (*novel.PronunciationEntry, error) {
+	var entry novel.PronunciationEntry
+	if err := r.coll.FindOne(ctx, bson.M{"id": id, "deleted_at": nil}).Decode(&entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// FindByNovelID 查询小说的所有词典条目（按 term asc 排序）
+func (r *PronunciationRepo) FindByNovelID(ctx context.Context, novelID string) ([]*novel.PronunciationEntry, error) {
+	opts := options.Find().SetSort(bson.M{"term": 1})
+	cur, err := r.coll.Find(ctx, bson.M{"novel_id": novelID, "deleted_at": nil}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var entries []*novel.PronunciationEntry
+	if err := cur.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// FindByTerm 查询小说中指定词的条目
+func (r *PronunciationRepo) FindByTerm(ctx context.Context, novelID, term string) (*novel.PronunciationEntry, error) {
+	var entry novel.PronunciationEntry
+	if err := r.coll.FindOne(ctx, bson.M{"novel_id": novelID, "term": term, "deleted_at": nil}).Decode(&entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Update 更新词典条目
+func (r *PronunciationRepo) Update(ctx context.Context, id string, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+	_, err := r.coll.UpdateOne(ctx, bson.M{"id": id, "deleted_at": nil}, bson.M{"$set": updates})
+	return err
+}
+
+// Delete 删除词典条目（软删除）
+func (r *PronunciationRepo) Delete(ctx context.Context, id string) error {
+	now := time.Now()
+	_, err := r.coll.UpdateOne(ctx, bson.M{"id": id, "deleted_at": nil}, bson.M{
+		"$set": bson.M{
+			"deleted_at": now,
+			"updated_at": now,
+		},
+	})
+	return err
+}
@@ -0,0 +1,55 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// RegenerationOverrideRepository 重新生成越权审计仓库接口
+type RegenerationOverrideRepository interface {
+	Create(ctx context.Context, override *novel.RegenerationOverride) error
+	FindByNovelID(ctx context.Context, novelID string, limit int) ([]*novel.RegenerationOverride, error)
+}
+
+// RegenerationOverrideRepo 重新生成越权审计仓库实现
+type RegenerationOverrideRepo struct {
+	coll *mongo.Collection
+}
+
+// NewRegenerationOverrideRepo 创建重新生成越权审计仓库
+func NewRegenerationOverrideRepo(db *mongo.Database) *RegenerationOverrideRepo {
+	var o novel.RegenerationOverride
+	return &RegenerationOverrideRepo{coll: db.Collection(o.Collection())}
+}
+
+// Create 创建越权记录
+func (r *RegenerationOverrideRepo) Create(ctx context.Context, override *novel.RegenerationOverride) error {
+	override.CreatedAt = time.Now()
+	_, err := r.coll.InsertOne(ctx, override)
+	return err
+}
+
+// FindByNovelID 查询小说的越权记录（按 created_at desc 排序）
+func (r *RegenerationOverrideRepo) FindByNovelID(ctx context.Context, novelID string, limit int) ([]*novel.RegenerationOverride, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cur, err := r.coll.Find(ctx, bson.M{"novel_id": novelID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var overrides []*novel.RegenerationOverride
+	if err := cur.All(ctx, &overrides); err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
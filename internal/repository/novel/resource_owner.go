@@ -0,0 +1,96 @@
+package novel
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+)
+
+// ResourceOwnerRepository 查询资源所属小说的仓库接口（用于按资源检查小说权限）
+type ResourceOwnerRepository interface {
+	// FindNovelIDs 查询引用该资源的记录所属的小说ID（按字典序，没有记录引用时为空）
+	FindNovelIDs(ctx context.Context, resourceID string) ([]string, error)
+}
+
+// ResourceOwnerRepo 资源所属小说仓库实现
+// 直接按集合名查询，新增引用资源的小说内容集合时需要加入 resourceOwnerCollections
+type ResourceOwnerRepo struct {
+	db *mongo.Database
+}
+
+// NewResourceOwnerRepo 创建资源所属小说仓库
+func NewResourceOwnerRepo(db *mongo.Database) *ResourceOwnerRepo {
+	return &ResourceOwnerRepo{db: db}
+}
+
+// resourceOwnerCollection 引用资源的集合
+type resourceOwnerCollection struct {
+	novelField string   // 所属小说ID字段
+	refFields  []string // 引用资源ID的字段
+}
+
+// resourceOwnerCollections 引用资源的小说内容集合
+func resourceOwnerCollections() map[string]resourceOwnerCollection {
+	var (
+		n         novel.Novel
+		cover     novel.NovelCover
+		audio     novel.Audio
+		subtitle  novel.Subtitle
+		image     novel.Image
+		video     novel.Video
+		character novel.Character
+		prop      novel.Prop
+		scene     novel.Scene
+		variant   novel.SceneImageVariant
+		styleRef  novel.StyleReference
+		preview   novel.ShotClipPreview
+		compiled  novel.Compilation
+	)
+	byNovel := func(fields ...string) resourceOwnerCollection {
+		return resourceOwnerCollection{novelField: "novel_id", refFields: fields}
+	}
+	return map[string]resourceOwnerCollection{
+		n.Collection():         {novelField: "id", refFields: []string{"resource_id", "cover_resource_id"}},
+		cover.Collection():     byNovel("resource_id"),
+		audio.Collection():     byNovel("audio_resource_id", "waveform_resource_id"),
+		subtitle.Collection():  byNovel("subtitle_resource_id", "source_resource_id"),
+		image.Collection():     byNovel("image_resource_id"),
+		character.Collection(): byNovel("image_resource_id"),
+		prop.Collection():      byNovel("image_resource_id"),
+		scene.Collection():     byNovel("image_resource_id"),
+		variant.Collection():   byNovel("image_resource_id", "parent_resource_id"),
+		styleRef.Collection():  byNovel("resource_id"),
+		preview.Collection():   byNovel("video_resource_id"),
+		compiled.Collection():  byNovel("video_resource_id"),
+		video.Collection(): byNovel(
+			"video_resource_id", "last_frame_resource_id", "manifest_resource_id",
+			"thumbnail_sprite_resource_id", "thumbnail_vtt_resource_id", "transcript_resource_id",
+			"audio_description_resource_id", "description_vtt_resource_id",
+		),
+	}
+}
+
+// FindNovelIDs 在各集合中查询引用资源的记录所属的小说
+func (r *ResourceOwnerRepo) FindNovelIDs(ctx context.Context, resourceID string) ([]string, error) {
+	owners := make(map[string]bool)
+	for collection, c := range resourceOwnerCollections() {
+		or := make(bson.A, 0, len(c.refFields))
+		for _, field := range c.refFields {
+			or = append(or, bson.M{field: resourceID})
+		}
+		values, err := r.db.Collection(collection).Distinct(ctx, c.novelField, bson.M{"$or": or})
+		if err != nil {
+			return nil, fmt.Errorf("find owners in %s: %w", collection, err)
+		}
+		for _, v := range values {
+			if novelID, ok := v.(string); ok && novelID != "" {
+				owners[novelID] = true
+			}
+		}
+	}
+	return sortedKeys(owners), nil
+}
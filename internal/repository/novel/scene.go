@@ -21,8 +21,11 @@ type SceneRepository interface {
 	FindByChapterID(ctx context.Context, chapterID string) ([]*novel.Scene, error)
 	Update(ctx context.Context, id string, updates map[string]interface{}) error
 	UpdateStatus(ctx context.Context, id string, status novel.TaskStatus, errorMessage string) error
+	// IncrementRegenerationCount 重新生成次数加一并返回新的次数；limit > 0 时只在次数小于 limit 时递增，否则返回 mongo.ErrNoDocuments
+	IncrementRegenerationCount(ctx context.Context, id string, limit int) (int, error)
 	Delete(ctx context.Context, id string) error
 	DeleteByNarrationID(ctx context.Context, narrationID string) error
+	MoveChapterVersion(ctx context.Context, fromChapterID string, fromVersion int, toChapterID string, toVersion int) error
 }
 
 // SceneRepo 场景仓库实现
@@ -163,6 +166,24 @@ func (r *SceneRepo) UpdateStatus(ctx context.Context, id string, status novel.Ta
 	return err
 }
 
+// IncrementRegenerationCount 重新生成次数加一并返回新的次数
+func (r *SceneRepo) IncrementRegenerationCount(ctx context.Context, id string, limit int) (int, error) {
+	filter := bson.M{"id": id, "deleted_at": nil}
+	if limit > 0 {
+		filter["regeneration_count"] = bson.M{"$not": bson.M{"$gte": limit}}
+	}
+	update := bson.M{
+		"$inc": bson.M{"regeneration_count": 1},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var scene novel.Scene
+	if err := r.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&scene); err != nil {
+		return 0, err
+	}
+	return scene.RegenerationCount, nil
+}
+
 // Delete 软删除场景
 func (r *SceneRepo) Delete(ctx context.Context, id string) error {
 	_, err := r.coll.UpdateOne(
@@ -188,3 +209,17 @@ func (r *SceneRepo) DeleteByNarrationID(ctx context.Context, narrationID string)
 	)
 	return err
 }
+
+// MoveChapterVersion 把章节某个版本的所有场景移到另一个章节的指定版本（合并章节分支时使用）
+func (r *SceneRepo) MoveChapterVersion(ctx context.Context, fromChapterID string, fromVersion int, toChapterID string, toVersion int) error {
+	_, err := r.coll.UpdateMany(
+		ctx,
+		bson.M{"chapter_id": fromChapterID, "version": fromVersion, "deleted_at": nil},
+		bson.M{"$set": bson.M{
+			"chapter_id": toChapterID,
+			"version":    toVersion,
+			"updated_at": time.Now(),
+		}},
+	)
+	return err
+}
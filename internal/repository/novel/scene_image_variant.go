@@ -0,0 +1,70 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// SceneImageVariantRepository 场景图片变体仓库接口
+type SceneImageVariantRepository interface {
+	Create(ctx context.Context, variant *novel.SceneImageVariant) error
+	FindBySceneID(ctx context.Context, sceneID string) ([]*novel.SceneImageVariant, error)
+	Update(ctx context.Context, id string, updates map[string]interface{}) error
+}
+
+// SceneImageVariantRepo 场景图片变体仓库实现
+type SceneImageVariantRepo struct {
+	coll *mongo.Collection
+}
+
+// NewSceneImageVariantRepo 创建场景图片变体仓库
+func NewSceneImageVariantRepo(db *mongo.Database) *SceneImageVariantRepo {
+	var v novel.SceneImageVariant
+	return &SceneImageVariantRepo{coll: db.Collection(v.Collection())}
+}
+
+// Create 创建场景图片变体
+func (r *SceneImageVariantRepo) Create(ctx context.Context, variant *novel.SceneImageVariant) error {
+	now := time.Now()
+	variant.CreatedAt = now
+	variant.UpdatedAt = now
+	if variant.Status == "" {
+		variant.Status = novel.TaskStatusPending
+	}
+	_, err := r.coll.InsertOne(ctx, variant)
+	return err
+}
+
+// FindBySceneID 查询场景的所有图片变体（按 created_at desc 排序）
+func (r *SceneImageVariantRepo) FindBySceneID(ctx context.Context, sceneID string) ([]*novel.SceneImageVariant, error) {
+	filter := bson.M{"scene_id": sceneID, "deleted_at": nil}
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var variants []*novel.SceneImageVariant
+	if err := cur.All(ctx, &variants); err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+// Update 更新场景图片变体
+func (r *SceneImageVariantRepo) Update(ctx context.Context, id string, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+	_, err := r.coll.UpdateOne(
+		ctx,
+		bson.M{"id": id},
+		bson.M{"$set": updates},
+	)
+	return err
+}
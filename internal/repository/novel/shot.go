@@ -23,9 +23,12 @@ type ShotRepository interface {
 	FindByChapterIDAndSceneAndShot(ctx context.Context, chapterID, sceneNumber, shotNumber string) (*novel.Shot, error)
 	Update(ctx context.Context, id string, updates map[string]interface{}) error
 	UpdateStatus(ctx context.Context, id string, status novel.TaskStatus, errorMessage string) error
+	// IncrementRegenerationCount 重新生成次数加一并返回新的次数；limit > 0 时只在次数小于 limit 时递增，否则返回 mongo.ErrNoDocuments
+	IncrementRegenerationCount(ctx context.Context, id string, limit int) (int, error)
 	Delete(ctx context.Context, id string) error
 	DeleteBySceneID(ctx context.Context, sceneID string) error
 	DeleteByNarrationID(ctx context.Context, narrationID string) error
+	MoveChapterVersion(ctx context.Context, fromChapterID string, fromVersion int, toChapterID string, toVersion int) error
 }
 
 // ShotRepo 镜头仓库实现
@@ -198,6 +201,24 @@ func (r *ShotRepo) UpdateStatus(ctx context.Context, id string, status novel.Tas
 	return err
 }
 
+// IncrementRegenerationCount 重新生成次数加一并返回新的次数
+func (r *ShotRepo) IncrementRegenerationCount(ctx context.Context, id string, limit int) (int, error) {
+	filter := bson.M{"id": id, "deleted_at": nil}
+	if limit > 0 {
+		filter["regeneration_count"] = bson.M{"$not": bson.M{"$gte": limit}}
+	}
+	update := bson.M{
+		"$inc": bson.M{"regeneration_count": 1},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var shot novel.Shot
+	if err := r.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&shot); err != nil {
+		return 0, err
+	}
+	return shot.RegenerationCount, nil
+}
+
 // Delete 软删除镜头
 func (r *ShotRepo) Delete(ctx context.Context, id string) error {
 	_, err := r.coll.UpdateOne(
@@ -236,3 +257,17 @@ func (r *ShotRepo) DeleteByNarrationID(ctx context.Context, narrationID string)
 	)
 	return err
 }
+
+// MoveChapterVersion 把章节某个版本的所有镜头移到另一个章节的指定版本（合并章节分支时使用）
+func (r *ShotRepo) MoveChapterVersion(ctx context.Context, fromChapterID string, fromVersion int, toChapterID string, toVersion int) error {
+	_, err := r.coll.UpdateMany(
+		ctx,
+		bson.M{"chapter_id": fromChapterID, "version": fromVersion, "deleted_at": nil},
+		bson.M{"$set": bson.M{
+			"chapter_id": toChapterID,
+			"version":    toVersion,
+			"updated_at": time.Now(),
+		}},
+	)
+	return err
+}
@@ -0,0 +1,87 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// ShotClipPreviewRepository 单镜头片段预览仓库接口
+type ShotClipPreviewRepository interface {
+	Create(ctx context.Context, preview *novel.ShotClipPreview) error
+	FindByID(ctx context.Context, id string) (*novel.ShotClipPreview, error)
+	Resolve(ctx context.Context, id string, status novel.ShotClipPreviewStatus, confirmedVersion int) (bool, error)
+	FindLatenciesSince(ctx context.Context, since time.Time) ([]*novel.ShotClipPreview, error)
+}
+
+// ShotClipPreviewRepo 单镜头片段预览仓库实现
+type ShotClipPreviewRepo struct {
+	coll *mongo.Collection
+}
+
+// NewShotClipPreviewRepo 创建单镜头片段预览仓库
+func NewShotClipPreviewRepo(db *mongo.Database) *ShotClipPreviewRepo {
+	var p novel.ShotClipPreview
+	return &ShotClipPreviewRepo{coll: db.Collection(p.Collection())}
+}
+
+// Create 创建预览
+func (r *ShotClipPreviewRepo) Create(ctx context.Context, preview *novel.ShotClipPreview) error {
+	now := time.Now()
+	preview.CreatedAt = now
+	preview.UpdatedAt = now
+	_, err := r.coll.InsertOne(ctx, preview)
+	return err
+}
+
+// FindByID 根据ID查询预览
+func (r *ShotClipPreviewRepo) FindByID(ctx context.Context, id string) (*novel.ShotClipPreview, error) {
+	var preview novel.ShotClipPreview
+	if err := r.coll.FindOne(ctx, bson.M{"id": id}).Decode(&preview); err != nil {
+		return nil, err
+	}
+	return &preview, nil
+}
+
+// Resolve 把等待确认的预览标记为已确认/已放弃，返回是否标记成功（保证同一预览只处理一次）
+func (r *ShotClipPreviewRepo) Resolve(ctx context.Context, id string, status novel.ShotClipPreviewStatus, confirmedVersion int) (bool, error) {
+	set := bson.M{
+		"status":     status,
+		"updated_at": time.Now(),
+	}
+	if confirmedVersion > 0 {
+		set["confirmed_version"] = confirmedVersion
+	}
+	result, err := r.coll.UpdateOne(ctx,
+		bson.M{"id": id, "status": novel.ShotClipPreviewStatusPending},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// FindLatenciesSince 查询 since 之后创建且记录了耗时的预览（只返回耗时和是否复用图生视频缓存）
+func (r *ShotClipPreviewRepo) FindLatenciesSince(ctx context.Context, since time.Time) ([]*novel.ShotClipPreview, error) {
+	opts := options.Find().SetProjection(bson.M{"latency_ms": 1, "source_reused": 1})
+	cur, err := r.coll.Find(ctx, bson.M{
+		"created_at": bson.M{"$gte": since},
+		"latency_ms": bson.M{"$gt": 0},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var previews []*novel.ShotClipPreview
+	if err := cur.All(ctx, &previews); err != nil {
+		return nil, err
+	}
+	return previews, nil
+}
@@ -0,0 +1,112 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// StageRunRepository 章节渲染阶段执行记录仓库接口
+type StageRunRepository interface {
+	Create(ctx context.Context, run *novel.StageRun) error
+	FindByChapterID(ctx context.Context, chapterID string) ([]*novel.StageRun, error)
+	CountFailuresByDay(ctx context.Context, since time.Time) ([]novel.FailureClassCount, error)
+	CountFailuresByProvider(ctx context.Context, since time.Time) ([]novel.FailureClassCount, error)
+	FindCreatedAfter(ctx context.Context, after time.Time, afterID string, stage novel.PipelineStage, limit int) ([]*novel.StageRun, error)
+}
+
+// StageRunRepo 章节渲染阶段执行记录仓库实现
+type StageRunRepo struct {
+	coll *mongo.Collection
+}
+
+// NewStageRunRepo 创建章节渲染阶段执行记录仓库
+func NewStageRunRepo(db *mongo.Database) *StageRunRepo {
+	var r novel.StageRun
+	return &StageRunRepo{coll: db.Collection(r.Collection())}
+}
+
+// Create 创建执行记录
+func (r *StageRunRepo) Create(ctx context.Context, run *novel.StageRun) error {
+	run.CreatedAt = time.Now()
+	_, err := r.coll.InsertOne(ctx, run)
+	return err
+}
+
+// FindByChapterID 查询章节的执行记录（按 started_at desc 排序）
+func (r *StageRunRepo) FindByChapterID(ctx context.Context, chapterID string) ([]*novel.StageRun, error) {
+	opts := options.Find().SetSort(bson.M{"started_at": -1})
+	cur, err := r.coll.Find(ctx, bson.M{"chapter_id": chapterID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var runs []*novel.StageRun
+	if err := cur.All(ctx, &runs); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// CountFailuresByDay 按天（UTC）和失败类别统计 since 之后的失败次数（按日期、类别升序）
+func (r *StageRunRepo) CountFailuresByDay(ctx context.Context, since time.Time) ([]novel.FailureClassCount, error) {
+	day := bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$started_at"}}
+	return r.countFailures(ctx, since, bson.M{"day": day, "class": "$error_class"}, bson.D{{Key: "_id.day", Value: 1}, {Key: "_id.class", Value: 1}})
+}
+
+// CountFailuresByProvider 按 provider 和失败类别统计 since 之后的失败次数（按次数降序）
+func (r *StageRunRepo) CountFailuresByProvider(ctx context.Context, since time.Time) ([]novel.FailureClassCount, error) {
+	return r.countFailures(ctx, since, bson.M{"provider": "$error_provider", "class": "$error_class"}, bson.D{{Key: "count", Value: -1}, {Key: "_id.provider", Value: 1}})
+}
+
+// countFailures 按 group 分组统计有失败类别的执行记录
+func (r *StageRunRepo) countFailures(ctx context.Context, since time.Time, group bson.M, sort bson.D) ([]novel.FailureClassCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"started_at":  bson.M{"$gte": since},
+			"error_class": bson.M{"$exists": true, "$ne": ""},
+		}}},
+		{{Key: "$group", Value: bson.M{"_id": group, "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: sort}},
+	}
+	cur, err := r.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var rows []struct {
+		Key   novel.FailureClassCount `bson:"_id"`
+		Count int                     `bson:"count"`
+	}
+	if err := cur.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	counts := make([]novel.FailureClassCount, 0, len(rows))
+	for _, row := range rows {
+		count := row.Key
+		count.Count = row.Count
+		counts = append(counts, count)
+	}
+	return counts, nil
+}
+
+// FindCreatedAfter 查询游标（created_at, id）之后的执行记录（按 created_at、id 升序，用于分析数据增量导出），stage 为空时不限阶段
+func (r *StageRunRepo) FindCreatedAfter(ctx context.Context, after time.Time, afterID string, stage novel.PipelineStage, limit int) ([]*novel.StageRun, error) {
+	var filter bson.M
+	if stage != "" {
+		filter = bson.M{"stage": stage}
+	}
+	var runs []*novel.StageRun
+	if err := findAfterCursor(ctx, r.coll, "created_at", after, afterID, filter, limit, &runs); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
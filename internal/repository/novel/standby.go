@@ -0,0 +1,130 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// StandbyJobRepository 预生成任务仓库接口
+type StandbyJobRepository interface {
+	Create(ctx context.Context, job *novel.StandbyJob) error
+	FindByID(ctx context.Context, id string) (*novel.StandbyJob, error)
+	FindByNovelID(ctx context.Context, novelID string, limit int) ([]*novel.StandbyJob, error)
+	FindRunningByChapterID(ctx context.Context, chapterID string) (*novel.StandbyJob, error)
+	AppendStageResult(ctx context.Context, id string, result novel.StandbyStageResult, narrationID string) error
+	Finish(ctx context.Context, id string, status novel.StandbyStatus, errMsg string) (bool, error)
+	FailRunning(ctx context.Context, errMsg string) (int64, error)
+}
+
+// StandbyJobRepo 预生成任务仓库实现
+type StandbyJobRepo struct {
+	coll *mongo.Collection
+}
+
+// NewStandbyJobRepo 创建预生成任务仓库
+func NewStandbyJobRepo(db *mongo.Database) *StandbyJobRepo {
+	var j novel.StandbyJob
+	return &StandbyJobRepo{coll: db.Collection(j.Collection())}
+}
+
+// Create 创建预生成任务
+func (r *StandbyJobRepo) Create(ctx context.Context, job *novel.StandbyJob) error {
+	now := time.Now()
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	_, err := r.coll.InsertOne(ctx, job)
+	return err
+}
+
+// FindByID 根据ID查询预生成任务
+func (r *StandbyJobRepo) FindByID(ctx context.Context, id string) (*novel.StandbyJob, error) {
+	var job novel.StandbyJob
+	if err := r.coll.FindOne(ctx, bson.M{"id": id}).Decode(&job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// FindByNovelID 查询小说的预生成任务（按 created_at desc 排序）
+func (r *StandbyJobRepo) FindByNovelID(ctx context.Context, novelID string, limit int) ([]*novel.StandbyJob, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cur, err := r.coll.Find(ctx, bson.M{"novel_id": novelID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var jobs []*novel.StandbyJob
+	if err := cur.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// FindRunningByChapterID 查询章节正在执行的预生成任务，没有时返回 mongo.ErrNoDocuments
+func (r *StandbyJobRepo) FindRunningByChapterID(ctx context.Context, chapterID string) (*novel.StandbyJob, error) {
+	var job novel.StandbyJob
+	filter := bson.M{"chapter_id": chapterID, "status": novel.StandbyStatusRunning}
+	if err := r.coll.FindOne(ctx, filter).Decode(&job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// AppendStageResult 追加阶段执行结果，narrationID 不为空时同时记录预生成的解说ID
+func (r *StandbyJobRepo) AppendStageResult(ctx context.Context, id string, result novel.StandbyStageResult, narrationID string) error {
+	set := bson.M{"updated_at": time.Now()}
+	if narrationID != "" {
+		set["narration_id"] = narrationID
+	}
+	_, err := r.coll.UpdateOne(ctx, bson.M{"id": id}, bson.M{
+		"$push": bson.M{"stage_results": result},
+		"$set":  set,
+	})
+	return err
+}
+
+// Finish 结束执行中的任务，返回是否更新成功（任务已被取消或已结束时返回 false）
+func (r *StandbyJobRepo) Finish(ctx context.Context, id string, status novel.StandbyStatus, errMsg string) (bool, error) {
+	now := time.Now()
+	result, err := r.coll.UpdateOne(ctx,
+		bson.M{"id": id, "status": novel.StandbyStatusRunning},
+		bson.M{"$set": bson.M{
+			"status":        status,
+			"error_message": errMsg,
+			"completed_at":  now,
+			"updated_at":    now,
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// FailRunning 把所有执行中的任务标记为失败（服务重启后这些任务已经中断），返回更新的任务数
+func (r *StandbyJobRepo) FailRunning(ctx context.Context, errMsg string) (int64, error) {
+	now := time.Now()
+	result, err := r.coll.UpdateMany(ctx,
+		bson.M{"status": novel.StandbyStatusRunning},
+		bson.M{"$set": bson.M{
+			"status":        novel.StandbyStatusFailed,
+			"error_message": errMsg,
+			"completed_at":  now,
+			"updated_at":    now,
+		}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
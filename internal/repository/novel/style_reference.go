@@ -0,0 +1,95 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// StyleReferenceRepository 风格参考图仓库接口
+type StyleReferenceRepository interface {
+	Create(ctx context.Context, ref *novel.StyleReference) error
+	FindByID(ctx context.Context, id string) (*novel.StyleReference, error)
+	FindByNovelID(ctx context.Context, novelID string) ([]*novel.StyleReference, error)
+	FindByChapterID(ctx context.Context, novelID, chapterID string) ([]*novel.StyleReference, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// StyleReferenceRepo 风格参考图仓库实现
+type StyleReferenceRepo struct {
+	coll *mongo.Collection
+}
+
+// NewStyleReferenceRepo 创建风格参考图仓库
+func NewStyleReferenceRepo(db *mongo.Database) *StyleReferenceRepo {
+	var r novel.StyleReference
+	return &StyleReferenceRepo{coll: db.Collection(r.Collection())}
+}
+
+// Create 创建风格参考图
+func (r *StyleReferenceRepo) Create(ctx context.Context, ref *novel.StyleReference) error {
+	now := time.Now()
+	ref.CreatedAt = now
+	ref.UpdatedAt = now
+	_, err := r.coll.InsertOne(ctx, ref)
+	return err
+}
+
+// FindByID 根据ID查询风格参考图
+func (r *StyleReferenceRepo) FindByID(ctx context.Context, id string) (*novel.StyleReference, error) {
+	var ref novel.StyleReference
+	if err := r.coll.FindOne(ctx, bson.M{"id": id, "deleted_at": nil}).Decode(&ref); err != nil {
+		return nil, err
+	}
+	return &ref, nil
+}
+
+// FindByNovelID 查询小说的所有风格参考图（包含章节级，按 created_at asc 排序）
+func (r *StyleReferenceRepo) FindByNovelID(ctx context.Context, novelID string) ([]*novel.StyleReference, error) {
+	return r.find(ctx, bson.M{"novel_id": novelID, "deleted_at": nil})
+}
+
+// FindByChapterID 查询指定章节的风格参考图（按 created_at asc 排序）
+// chapterID 为空时查询小说级参考图
+func (r *StyleReferenceRepo) FindByChapterID(ctx context.Context, novelID, chapterID string) ([]*novel.StyleReference, error) {
+	filter := bson.M{"novel_id": novelID, "deleted_at": nil}
+	if chapterID == "" {
+		// 小说级参考图不写 chapter_id 字段
+		filter["chapter_id"] = bson.M{"$in": []interface{}{nil, ""}}
+	} else {
+		filter["chapter_id"] = chapterID
+	}
+	return r.find(ctx, filter)
+}
+
+// Delete 删除风格参考图（软删除）
+func (r *StyleReferenceRepo) Delete(ctx context.Context, id string) error {
+	now := time.Now()
+	_, err := r.coll.UpdateOne(ctx, bson.M{"id": id, "deleted_at": nil}, bson.M{
+		"$set": bson.M{
+			"deleted_at": now,
+			"updated_at": now,
+		},
+	})
+	return err
+}
+
+func (r *StyleReferenceRepo) find(ctx context.Context, filter bson.M) ([]*novel.StyleReference, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var refs []*novel.StyleReference
+	if err := cur.All(ctx, &refs); err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
@@ -16,14 +16,18 @@ type SubtitleRepository interface {
 	Create(ctx context.Context, s *novel.Subtitle) error
 	FindByID(ctx context.Context, id string) (*novel.Subtitle, error)
 	FindByChapterID(ctx context.Context, chapterID string) (*novel.Subtitle, error)
+	FindAllByChapterID(ctx context.Context, chapterID string) ([]*novel.Subtitle, error)
 	FindByNarrationID(ctx context.Context, narrationID string) ([]*novel.Subtitle, error)
 	FindByNarrationIDAndVersion(ctx context.Context, narrationID string, version int) ([]*novel.Subtitle, error)
+	ListByNarrationIDAndVersion(ctx context.Context, narrationID string, version int, opts ListOptions) ([]*novel.Subtitle, int64, error)
 	FindByNarrationIDAndSequence(ctx context.Context, narrationID string, sequence int) (*novel.Subtitle, error)
 	FindByChapterIDAndVersion(ctx context.Context, chapterID string, version int) (*novel.Subtitle, error)
 	FindVersionsByChapterID(ctx context.Context, chapterID string) ([]int, error)
 	UpdateStatus(ctx context.Context, id string, status novel.TaskStatus) error
 	UpdateVersion(ctx context.Context, id string, version int) error
+	UpdateByShotID(ctx context.Context, shotID string, updates map[string]interface{}) error
 	Delete(ctx context.Context, id string) error
+	MoveChapterVersion(ctx context.Context, fromChapterID string, fromVersion int, toChapterID string, toVersion int) error
 }
 
 // SubtitleRepo 字幕仓库实现
@@ -75,6 +79,23 @@ func (r *SubtitleRepo) FindByChapterID(ctx context.Context, chapterID string) (*
 	return &s, nil
 }
 
+// FindAllByChapterID 查询章节的所有字幕（所有版本，按 version desc 排序）
+func (r *SubtitleRepo) FindAllByChapterID(ctx context.Context, chapterID string) ([]*novel.Subtitle, error) {
+	filter := bson.M{"chapter_id": chapterID, "deleted_at": nil}
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}, {Key: "created_at", Value: -1}})
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var subtitles []*novel.Subtitle
+	if err := cur.All(ctx, &subtitles); err != nil {
+		return nil, err
+	}
+	return subtitles, nil
+}
+
 // FindByNarrationID 根据解说ID查询所有字幕（按 sequence 排序）
 func (r *SubtitleRepo) FindByNarrationID(ctx context.Context, narrationID string) ([]*novel.Subtitle, error) {
 	filter := bson.M{"narration_id": narrationID, "deleted_at": nil}
@@ -113,6 +134,12 @@ func (r *SubtitleRepo) FindByNarrationIDAndVersion(ctx context.Context, narratio
 	return subtitles, nil
 }
 
+// ListByNarrationIDAndVersion 分页查询解说指定版本的字幕（默认按 sequence 排序），同时返回总数
+func (r *SubtitleRepo) ListByNarrationIDAndVersion(ctx context.Context, narrationID string, version int, opts ListOptions) ([]*novel.Subtitle, int64, error) {
+	filter := bson.M{"narration_id": narrationID, "version": version, "deleted_at": nil}
+	return listDocuments[novel.Subtitle](ctx, r.coll, filter, opts, bson.D{{Key: "sequence", Value: 1}})
+}
+
 // FindByNarrationIDAndSequence 根据解说ID和序号查询字幕
 func (r *SubtitleRepo) FindByNarrationIDAndSequence(ctx context.Context, narrationID string, sequence int) (*novel.Subtitle, error) {
 	var s novel.Subtitle
@@ -194,6 +221,17 @@ func (r *SubtitleRepo) UpdateVersion(ctx context.Context, id string, version int
 	return err
 }
 
+// UpdateByShotID 更新关联到指定镜头的所有字幕（镜头重排后按稳定的镜头ID重新关联）
+func (r *SubtitleRepo) UpdateByShotID(ctx context.Context, shotID string, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+	_, err := r.coll.UpdateMany(
+		ctx,
+		bson.M{"shot_id": shotID, "deleted_at": nil},
+		bson.M{"$set": updates},
+	)
+	return err
+}
+
 // Delete 软删除字幕
 func (r *SubtitleRepo) Delete(ctx context.Context, id string) error {
 	_, err := r.coll.UpdateOne(
@@ -206,3 +244,17 @@ func (r *SubtitleRepo) Delete(ctx context.Context, id string) error {
 	)
 	return err
 }
+
+// MoveChapterVersion 把章节某个版本的所有字幕移到另一个章节的指定版本（合并章节分支时使用）
+func (r *SubtitleRepo) MoveChapterVersion(ctx context.Context, fromChapterID string, fromVersion int, toChapterID string, toVersion int) error {
+	_, err := r.coll.UpdateMany(
+		ctx,
+		bson.M{"chapter_id": fromChapterID, "version": fromVersion, "deleted_at": nil},
+		bson.M{"$set": bson.M{
+			"chapter_id": toChapterID,
+			"version":    toVersion,
+			"updated_at": time.Now(),
+		}},
+	)
+	return err
+}
@@ -0,0 +1,64 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// UserSettingsRepository 用户设置仓库接口
+type UserSettingsRepository interface {
+	FindByUserID(ctx context.Context, userID string) (*novel.UserSettings, error)
+	// SaveGeneration 保存用户级生成参数（不存在时创建，保留原 ID 和创建时间）
+	SaveGeneration(ctx context.Context, settings *novel.UserSettings) error
+}
+
+// UserSettingsRepo 用户设置仓库实现
+type UserSettingsRepo struct {
+	coll *mongo.Collection
+}
+
+// NewUserSettingsRepo 创建用户设置仓库
+func NewUserSettingsRepo(db *mongo.Database) *UserSettingsRepo {
+	var s novel.UserSettings
+	return &UserSettingsRepo{coll: db.Collection(s.Collection())}
+}
+
+// FindByUserID 查询用户设置
+func (r *UserSettingsRepo) FindByUserID(ctx context.Context, userID string) (*novel.UserSettings, error) {
+	var settings novel.UserSettings
+	if err := r.coll.FindOne(ctx, bson.M{"user_id": userID}).Decode(&settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// SaveGeneration 保存用户级生成参数
+func (r *UserSettingsRepo) SaveGeneration(ctx context.Context, settings *novel.UserSettings) error {
+	now := time.Now()
+	settings.UpdatedAt = now
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var saved novel.UserSettings
+	err := r.coll.FindOneAndUpdate(ctx, bson.M{"user_id": settings.UserID}, bson.M{
+		"$set": bson.M{
+			"generation": settings.Generation,
+			"updated_at": now,
+		},
+		"$setOnInsert": bson.M{
+			"id":         settings.ID,
+			"created_at": now,
+		},
+	}, opts).Decode(&saved)
+	if err != nil {
+		return err
+	}
+	settings.ID = saved.ID
+	settings.CreatedAt = saved.CreatedAt
+	return nil
+}
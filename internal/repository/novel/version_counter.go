@@ -0,0 +1,53 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// VersionCounterRepository 章节产物版本号计数器仓库接口
+type VersionCounterRepository interface {
+	Next(ctx context.Context, chapterID string, artifact novel.VersionArtifact, floor int) (int, error)
+}
+
+// VersionCounterRepo 章节产物版本号计数器仓库实现
+type VersionCounterRepo struct {
+	coll *mongo.Collection
+}
+
+// NewVersionCounterRepo 创建版本号计数器仓库
+func NewVersionCounterRepo(db *mongo.Database) *VersionCounterRepo {
+	var c novel.VersionCounter
+	return &VersionCounterRepo{coll: db.Collection(c.Collection())}
+}
+
+// Next 原子分配下一个版本号：max(计数器当前值, floor) + 1
+// floor 为产物集合中已有的最大版本号，计数器不存在时（历史数据）从 floor 开始计数，
+// 其他途径写入了更大的版本号时也不会分配到重复的版本号
+func (r *VersionCounterRepo) Next(ctx context.Context, chapterID string, artifact novel.VersionArtifact, floor int) (int, error) {
+	filter := bson.M{"chapter_id": chapterID, "artifact": artifact}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"value":      bson.M{"$add": bson.A{bson.M{"$max": bson.A{bson.M{"$ifNull": bson.A{"$value", 0}}, floor}}, 1}},
+			"updated_at": time.Now(),
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var counter novel.VersionCounter
+	err := r.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&counter)
+	if mongo.IsDuplicateKeyError(err) {
+		// 并发创建计数器时只有一个 upsert 能插入成功，其余请求重试时计数器已存在
+		err = r.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&counter)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return counter.Value, nil
+}
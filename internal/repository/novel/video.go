@@ -18,13 +18,23 @@ type VideoRepository interface {
 	FindByChapterID(ctx context.Context, chapterID string) ([]*novel.Video, error)
 	FindByNarrationID(ctx context.Context, narrationID string) ([]*novel.Video, error)
 	FindByChapterIDAndType(ctx context.Context, chapterID string, videoType novel.VideoType) ([]*novel.Video, error)
-	FindByStatus(ctx context.Context, status novel.VideoStatus) ([]*novel.Video, error) // 用于轮询
+	FindByNovelIDAndType(ctx context.Context, novelID string, videoType novel.VideoType, limit int) ([]*novel.Video, error)
+	FindTasks(ctx context.Context, f VideoTaskFilter) ([]*novel.Video, error) // 用于轮询
+	FindByStatusUpdatedBefore(ctx context.Context, status novel.VideoStatus, before time.Time, novelID string) ([]*novel.Video, error)
 	FindByChapterIDAndVersion(ctx context.Context, chapterID string, version int) ([]*novel.Video, error)
+	ListByChapterIDAndVersion(ctx context.Context, chapterID string, version int, opts ListOptions) ([]*novel.Video, int64, error)
 	FindVersionsByChapterID(ctx context.Context, chapterID string) ([]int, error)
 	UpdateStatus(ctx context.Context, id string, status novel.VideoStatus, errorMsg string) error
 	UpdateVideoResourceID(ctx context.Context, id string, resourceID string, duration float64, prompt string) error
 	UpdateVersion(ctx context.Context, id string, version int) error
+	UpdateLastFrame(ctx context.Context, id string, resourceID string) error
+	UpdateThumbnailSprite(ctx context.Context, id string, spriteResourceID, vttResourceID string) error
+	UpdateRenderBreakdown(ctx context.Context, id string, breakdown *novel.RenderBreakdown) error
+	CompleteNovelVideo(ctx context.Context, id string, resourceID string, duration float64, markers []novel.VideoChapterMarker) error
+	UpdateByShotID(ctx context.Context, shotID string, updates map[string]interface{}) error
+	MarkStale(ctx context.Context, ids []string, reason string) error
 	Delete(ctx context.Context, id string) error
+	MoveChapterVersion(ctx context.Context, fromChapterID string, fromVersion int, toChapterID string, toVersion int) error
 }
 
 // VideoRepo 视频仓库实现
@@ -113,10 +123,78 @@ func (r *VideoRepo) FindByChapterIDAndType(ctx context.Context, chapterID string
 	return videos, nil
 }
 
-// FindByStatus 根据状态查询视频（用于轮询）
-func (r *VideoRepo) FindByStatus(ctx context.Context, status novel.VideoStatus) ([]*novel.Video, error) {
-	filter := bson.M{"status": status, "deleted_at": nil}
-	opts := options.Find().SetSort(bson.M{"created_at": 1})
+// FindByNovelIDAndType 根据小说ID和视频类型查询视频（按 created_at desc 排序）
+func (r *VideoRepo) FindByNovelIDAndType(ctx context.Context, novelID string, videoType novel.VideoType, limit int) ([]*novel.Video, error) {
+	filter := bson.M{"novel_id": novelID, "video_type": videoType, "deleted_at": nil}
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cursor, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var videos []*novel.Video
+	if err := cursor.All(ctx, &videos); err != nil {
+		return nil, err
+	}
+	return videos, nil
+}
+
+// VideoTaskFilter 视频任务查询条件（UserID 和 ChapterID 至少指定一个）
+type VideoTaskFilter struct {
+	UserID         string              // 用户ID
+	ChapterID      string              // 章节ID
+	Statuses       []novel.VideoStatus // 状态，为空时不限状态
+	AfterUpdatedAt time.Time           // 游标：只返回 (updated_at, id) 大于 (AfterUpdatedAt, AfterID) 的视频，零值表示从头开始
+	AfterID        string              // 游标中的视频ID
+	Limit          int                 // 返回数量
+}
+
+// FindTasks 按用户/章节和状态查询视频任务（用于轮询）
+// 按 (updated_at, id) 升序返回，调用方以最后一条记录作为下一页的游标，轮询时只会拿到游标之后有变化的视频
+func (r *VideoRepo) FindTasks(ctx context.Context, f VideoTaskFilter) ([]*novel.Video, error) {
+	filter := bson.M{"deleted_at": nil}
+	if f.UserID != "" {
+		filter["user_id"] = f.UserID
+	}
+	if f.ChapterID != "" {
+		filter["chapter_id"] = f.ChapterID
+	}
+	if len(f.Statuses) > 0 {
+		filter["status"] = bson.M{"$in": f.Statuses}
+	}
+	if !f.AfterUpdatedAt.IsZero() {
+		filter["$or"] = bson.A{
+			bson.M{"updated_at": bson.M{"$gt": f.AfterUpdatedAt}},
+			bson.M{"updated_at": f.AfterUpdatedAt, "id": bson.M{"$gt": f.AfterID}},
+		}
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: 1}, {Key: "id", Value: 1}}).
+		SetLimit(int64(f.Limit))
+	cursor, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var videos []*novel.Video
+	if err := cursor.All(ctx, &videos); err != nil {
+		return nil, err
+	}
+	return videos, nil
+}
+
+// FindByStatusUpdatedBefore 查询指定状态且最后更新时间早于 before 的视频（用于清理失败产物），novelID 为空时不限小说
+func (r *VideoRepo) FindByStatusUpdatedBefore(ctx context.Context, status novel.VideoStatus, before time.Time, novelID string) ([]*novel.Video, error) {
+	filter := bson.M{"status": status, "updated_at": bson.M{"$lt": before}, "deleted_at": nil}
+	if novelID != "" {
+		filter["novel_id"] = novelID
+	}
+	opts := options.Find().SetSort(bson.M{"updated_at": 1})
 	cursor, err := r.coll.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
@@ -147,6 +225,12 @@ func (r *VideoRepo) FindByChapterIDAndVersion(ctx context.Context, chapterID str
 	return videos, nil
 }
 
+// ListByChapterIDAndVersion 分页查询章节指定版本的视频（默认按 sequence 排序），同时返回总数
+func (r *VideoRepo) ListByChapterIDAndVersion(ctx context.Context, chapterID string, version int, opts ListOptions) ([]*novel.Video, int64, error) {
+	filter := bson.M{"chapter_id": chapterID, "version": version, "deleted_at": nil}
+	return listDocuments[novel.Video](ctx, r.coll, filter, opts, bson.D{{Key: "sequence", Value: 1}})
+}
+
 // FindVersionsByChapterID 查询章节的所有视频版本号
 func (r *VideoRepo) FindVersionsByChapterID(ctx context.Context, chapterID string) ([]int, error) {
 	filter := bson.M{"chapter_id": chapterID, "deleted_at": nil}
@@ -215,6 +299,62 @@ func (r *VideoRepo) UpdateVideoResourceID(ctx context.Context, id string, resour
 	return err
 }
 
+// UpdateLastFrame 记录视频最后一帧截图的 resource_id（章节衔接时复用，避免重复提取）
+func (r *VideoRepo) UpdateLastFrame(ctx context.Context, id string, resourceID string) error {
+	_, err := r.coll.UpdateOne(
+		ctx,
+		bson.M{"id": id},
+		bson.M{"$set": bson.M{
+			"last_frame_resource_id": resourceID,
+			"updated_at":             time.Now(),
+		}},
+	)
+	return err
+}
+
+// UpdateThumbnailSprite 记录视频缩略图雪碧图及其 WebVTT 索引的 resource_id
+func (r *VideoRepo) UpdateThumbnailSprite(ctx context.Context, id string, spriteResourceID, vttResourceID string) error {
+	_, err := r.coll.UpdateOne(
+		ctx,
+		bson.M{"id": id},
+		bson.M{"$set": bson.M{
+			"thumbnail_sprite_resource_id": spriteResourceID,
+			"thumbnail_vtt_resource_id":    vttResourceID,
+			"updated_at":                   time.Now(),
+		}},
+	)
+	return err
+}
+
+// UpdateRenderBreakdown 记录章节渲染的耗时与费用明细（仅 final_video）
+func (r *VideoRepo) UpdateRenderBreakdown(ctx context.Context, id string, breakdown *novel.RenderBreakdown) error {
+	_, err := r.coll.UpdateOne(
+		ctx,
+		bson.M{"id": id},
+		bson.M{"$set": bson.M{
+			"render_breakdown": breakdown,
+			"updated_at":       time.Now(),
+		}},
+	)
+	return err
+}
+
+// CompleteNovelVideo 记录长视频的拼接结果并标记为已完成
+func (r *VideoRepo) CompleteNovelVideo(ctx context.Context, id string, resourceID string, duration float64, markers []novel.VideoChapterMarker) error {
+	_, err := r.coll.UpdateOne(
+		ctx,
+		bson.M{"id": id},
+		bson.M{"$set": bson.M{
+			"video_resource_id": resourceID,
+			"duration":          duration,
+			"chapter_markers":   markers,
+			"status":            novel.VideoStatusCompleted,
+			"updated_at":        time.Now(),
+		}},
+	)
+	return err
+}
+
 // UpdateVersion 更新视频版本号
 func (r *VideoRepo) UpdateVersion(ctx context.Context, id string, version int) error {
 	_, err := r.coll.UpdateOne(
@@ -228,6 +368,34 @@ func (r *VideoRepo) UpdateVersion(ctx context.Context, id string, version int) e
 	return err
 }
 
+// UpdateByShotID 更新关联到指定镜头的所有视频（镜头重排后按稳定的镜头ID重新关联）
+func (r *VideoRepo) UpdateByShotID(ctx context.Context, shotID string, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+	_, err := r.coll.UpdateMany(
+		ctx,
+		bson.M{"shot_id": shotID, "deleted_at": nil},
+		bson.M{"$set": updates},
+	)
+	return err
+}
+
+// MarkStale 标记视频已过期（依赖的产物已重新生成，需要重新生成视频）
+func (r *VideoRepo) MarkStale(ctx context.Context, ids []string, reason string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := r.coll.UpdateMany(
+		ctx,
+		bson.M{"id": bson.M{"$in": ids}},
+		bson.M{"$set": bson.M{
+			"stale":        true,
+			"stale_reason": reason,
+			"updated_at":   time.Now(),
+		}},
+	)
+	return err
+}
+
 // Delete 软删除视频
 func (r *VideoRepo) Delete(ctx context.Context, id string) error {
 	_, err := r.coll.UpdateOne(
@@ -240,3 +408,17 @@ func (r *VideoRepo) Delete(ctx context.Context, id string) error {
 	)
 	return err
 }
+
+// MoveChapterVersion 把章节某个版本的所有视频移到另一个章节的指定版本（合并章节分支时使用）
+func (r *VideoRepo) MoveChapterVersion(ctx context.Context, fromChapterID string, fromVersion int, toChapterID string, toVersion int) error {
+	_, err := r.coll.UpdateMany(
+		ctx,
+		bson.M{"chapter_id": fromChapterID, "version": fromVersion, "deleted_at": nil},
+		bson.M{"$set": bson.M{
+			"chapter_id": toChapterID,
+			"version":    toVersion,
+			"updated_at": time.Now(),
+		}},
+	)
+	return err
+}
@@ -0,0 +1,183 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// WebhookRepository 任务通知订阅仓库接口
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *novel.Webhook) error
+	FindByID(ctx context.Context, id string) (*novel.Webhook, error)
+	FindByUserID(ctx context.Context, userID string) ([]*novel.Webhook, error)
+	FindEnabledByUserID(ctx context.Context, userID string) ([]*novel.Webhook, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// WebhookRepo 任务通知订阅仓库实现
+type WebhookRepo struct {
+	coll *mongo.Collection
+}
+
+// NewWebhookRepo 创建任务通知订阅仓库
+func NewWebhookRepo(db *mongo.Database) *WebhookRepo {
+	var w novel.Webhook
+	return &WebhookRepo{coll: db.Collection(w.Collection())}
+}
+
+// Create 创建订阅
+func (r *WebhookRepo) Create(ctx context.Context, webhook *novel.Webhook) error {
+	now := time.Now()
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+	_, err := r.coll.InsertOne(ctx, webhook)
+	return err
+}
+
+// FindByID 根据ID查询订阅
+func (r *WebhookRepo) FindByID(ctx context.Context, id string) (*novel.Webhook, error) {
+	var webhook novel.Webhook
+	if err := r.coll.FindOne(ctx, bson.M{"id": id}).Decode(&webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// FindByUserID 查询用户的所有订阅（按 created_at desc 排序）
+func (r *WebhookRepo) FindByUserID(ctx context.Context, userID string) ([]*novel.Webhook, error) {
+	return r.find(ctx, bson.M{"user_id": userID})
+}
+
+// FindEnabledByUserID 查询用户启用的订阅
+func (r *WebhookRepo) FindEnabledByUserID(ctx context.Context, userID string) ([]*novel.Webhook, error) {
+	return r.find(ctx, bson.M{"user_id": userID, "enabled": true})
+}
+
+func (r *WebhookRepo) find(ctx context.Context, filter bson.M) ([]*novel.Webhook, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var webhooks []*novel.Webhook
+	if err := cur.All(ctx, &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// Delete 删除订阅，不存在时返回 mongo.ErrNoDocuments
+func (r *WebhookRepo) Delete(ctx context.Context, id string) error {
+	result, err := r.coll.DeleteOne(ctx, bson.M{"id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// WebhookDeliveryRepository 任务通知投递记录仓库接口
+type WebhookDeliveryRepository interface {
+	Create(ctx context.Context, delivery *novel.WebhookDelivery) error
+	FindByID(ctx context.Context, id string) (*novel.WebhookDelivery, error)
+	FindByWebhookID(ctx context.Context, webhookID string, limit int) ([]*novel.WebhookDelivery, error)
+	FindPending(ctx context.Context) ([]*novel.WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, id string, attempt novel.WebhookAttempt, status novel.WebhookStatus, nextAttemptAt *time.Time) error
+}
+
+// WebhookDeliveryRepo 任务通知投递记录仓库实现
+type WebhookDeliveryRepo struct {
+	coll *mongo.Collection
+}
+
+// NewWebhookDeliveryRepo 创建任务通知投递记录仓库
+func NewWebhookDeliveryRepo(db *mongo.Database) *WebhookDeliveryRepo {
+	var d novel.WebhookDelivery
+	return &WebhookDeliveryRepo{coll: db.Collection(d.Collection())}
+}
+
+// Create 创建投递记录
+func (r *WebhookDeliveryRepo) Create(ctx context.Context, delivery *novel.WebhookDelivery) error {
+	now := time.Now()
+	delivery.CreatedAt = now
+	delivery.UpdatedAt = now
+	_, err := r.coll.InsertOne(ctx, delivery)
+	return err
+}
+
+// FindByID 根据ID查询投递记录
+func (r *WebhookDeliveryRepo) FindByID(ctx context.Context, id string) (*novel.WebhookDelivery, error) {
+	var delivery novel.WebhookDelivery
+	if err := r.coll.FindOne(ctx, bson.M{"id": id}).Decode(&delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// FindByWebhookID 查询订阅的投递记录（按 created_at desc 排序）
+func (r *WebhookDeliveryRepo) FindByWebhookID(ctx context.Context, webhookID string, limit int) ([]*novel.WebhookDelivery, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cur, err := r.coll.Find(ctx, bson.M{"webhook_id": webhookID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var deliveries []*novel.WebhookDelivery
+	if err := cur.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// FindPending 查询等待投递或重试的记录
+func (r *WebhookDeliveryRepo) FindPending(ctx context.Context) ([]*novel.WebhookDelivery, error) {
+	cur, err := r.coll.Find(ctx, bson.M{"status": novel.WebhookStatusPending})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var deliveries []*novel.WebhookDelivery
+	if err := cur.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// RecordAttempt 追加一次投递尝试并更新状态；status 为 pending 时 nextAttemptAt 为下一次重试时间
+func (r *WebhookDeliveryRepo) RecordAttempt(ctx context.Context, id string, attempt novel.WebhookAttempt, status novel.WebhookStatus, nextAttemptAt *time.Time) error {
+	set := bson.M{
+		"status":     status,
+		"updated_at": time.Now(),
+	}
+	update := bson.M{
+		"$push": bson.M{"attempts": attempt},
+		"$inc":  bson.M{"attempt_count": 1},
+		"$set":  set,
+	}
+	switch {
+	case status == novel.WebhookStatusSent:
+		set["delivered_at"] = attempt.At
+		update["$unset"] = bson.M{"next_attempt_at": ""}
+	case nextAttemptAt != nil:
+		set["next_attempt_at"] = *nextAttemptAt
+	default:
+		update["$unset"] = bson.M{"next_attempt_at": ""}
+	}
+	_, err := r.coll.UpdateOne(ctx, bson.M{"id": id}, update)
+	return err
+}
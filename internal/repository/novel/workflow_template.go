@@ -0,0 +1,99 @@
+package novel
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/model/novel"
+)
+
+// WorkflowTemplateRepository 工作流模板仓库接口（只保存自定义模板，内置模板由代码提供）
+type WorkflowTemplateRepository interface {
+	Create(ctx context.Context, template *novel.WorkflowTemplate) error
+	FindByName(ctx context.Context, name string) (*novel.WorkflowTemplate, error)
+	FindAll(ctx context.Context) ([]*novel.WorkflowTemplate, error)
+	Replace(ctx context.Context, template *novel.WorkflowTemplate) error
+	DeleteByName(ctx context.Context, name string) error
+}
+
+// WorkflowTemplateRepo 工作流模板仓库实现
+type WorkflowTemplateRepo struct {
+	coll *mongo.Collection
+}
+
+// NewWorkflowTemplateRepo 创建工作流模板仓库
+func NewWorkflowTemplateRepo(db *mongo.Database) *WorkflowTemplateRepo {
+	var t novel.WorkflowTemplate
+	return &WorkflowTemplateRepo{coll: db.Collection(t.Collection())}
+}
+
+// Create 创建模板，名称已存在时返回 mongo 的重复键错误（可用 mongo.IsDuplicateKeyError 判断）
+func (r *WorkflowTemplateRepo) Create(ctx context.Context, template *novel.WorkflowTemplate) error {
+	now := time.Now()
+	template.CreatedAt = now
+	template.UpdatedAt = now
+	_, err := r.coll.InsertOne(ctx, template)
+	return err
+}
+
+// FindByName 根据名称查询模板
+func (r *WorkflowTemplateRepo) FindByName(ctx context.Context, name string) (*novel.WorkflowTemplate, error) {
+	var template novel.WorkflowTemplate
+	if err := r.coll.FindOne(ctx, bson.M{"name": name}).Decode(&template); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// FindAll 查询所有自定义模板（按名称排序）
+func (r *WorkflowTemplateRepo) FindAll(ctx context.Context) ([]*novel.WorkflowTemplate, error) {
+	opts := options.Find().SetSort(bson.M{"name": 1})
+	cur, err := r.coll.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var templates []*novel.WorkflowTemplate
+	if err := cur.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// Replace 按名称整体替换模板配置（保留ID、创建人和创建时间），不存在时返回 mongo.ErrNoDocuments
+func (r *WorkflowTemplateRepo) Replace(ctx context.Context, template *novel.WorkflowTemplate) error {
+	existing, err := r.FindByName(ctx, template.Name)
+	if err != nil {
+		return err
+	}
+	template.ID = existing.ID
+	template.CreatedBy = existing.CreatedBy
+	template.CreatedAt = existing.CreatedAt
+	template.UpdatedAt = time.Now()
+
+	result, err := r.coll.ReplaceOne(ctx, bson.M{"id": existing.ID}, template)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// DeleteByName 删除模板，不存在时返回 mongo.ErrNoDocuments
+func (r *WorkflowTemplateRepo) DeleteByName(ctx context.Context, name string) error {
+	result, err := r.coll.DeleteOne(ctx, bson.M{"name": name})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -112,6 +112,30 @@ func (r *ResourceRepo) FindAll(ctx context.Context, limit, offset int) ([]*resou
 	return resources, total, nil
 }
 
+// FindUpdatedSince 按 ID 顺序分页查询 since 之后更新过的资源（包含已删除的资源，用于存储对账）
+// afterID 为上一页最后一条记录的 ID（第一页传空）
+func (r *ResourceRepo) FindUpdatedSince(ctx context.Context, since time.Time, afterID string, limit int) ([]*resource.Resource, error) {
+	filter := bson.M{"updated_at": bson.M{"$gte": since}}
+	if afterID != "" {
+		filter["id"] = bson.M{"$gt": afterID}
+	}
+	opts := options.Find().
+		SetSort(bson.D{bson.E{Key: "id", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var resources []*resource.Resource
+	if err := cursor.All(ctx, &resources); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
 // FindByMD5 根据MD5查询（去重）
 func (r *ResourceRepo) FindByMD5(ctx context.Context, md5 string) (*resource.Resource, error) {
 	var res resource.Resource
@@ -160,6 +184,106 @@ func (r *ResourceRepo) Delete(ctx context.Context, id string) error {
 	return err
 }
 
+// FindCreatedBefore 按 ID 顺序分页查询 before 之前创建的未删除资源（用于资源回收）
+// afterID 为上一页最后一条记录的 ID（第一页传空）
+func (r *ResourceRepo) FindCreatedBefore(ctx context.Context, before time.Time, afterID string, limit int) ([]*resource.Resource, error) {
+	filter := bson.M{
+		"created_at": bson.M{"$lt": before},
+		"deleted_at": nil,
+	}
+	return r.findPage(ctx, filter, afterID, limit)
+}
+
+// FindGCMarkedBefore 按 ID 顺序分页查询 before 之前被资源回收标记删除的资源
+// afterID 为上一页最后一条记录的 ID（第一页传空）
+func (r *ResourceRepo) FindGCMarkedBefore(ctx context.Context, before time.Time, afterID string, limit int) ([]*resource.Resource, error) {
+	filter := bson.M{"gc_marked_at": bson.M{"$lt": before}}
+	return r.findPage(ctx, filter, afterID, limit)
+}
+
+// findPage 按 ID 顺序分页查询
+func (r *ResourceRepo) findPage(ctx context.Context, filter bson.M, afterID string, limit int) ([]*resource.Resource, error) {
+	if afterID != "" {
+		filter["id"] = bson.M{"$gt": afterID}
+	}
+	opts := options.Find().
+		SetSort(bson.D{bson.E{Key: "id", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var resources []*resource.Resource
+	if err := cursor.All(ctx, &resources); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// CountRetainedByStorageKey 统计使用同一存储路径、文件仍需保留的其他资源数
+// 未删除的资源和已被回收标记但尚未清除的资源（可能重新被引用而恢复）都需要保留文件
+func (r *ResourceRepo) CountRetainedByStorageKey(ctx context.Context, storageKey, excludeID string) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{
+		"storage_key": storageKey,
+		"id":          bson.M{"$ne": excludeID},
+		"$or": bson.A{
+			bson.M{"deleted_at": nil},
+			bson.M{"gc_marked_at": bson.M{"$ne": nil}},
+		},
+	})
+}
+
+// MarkGC 资源回收标记删除（软删除并记录标记时间和标记前的状态）
+// 只标记未删除的资源，返回是否标记成功
+func (r *ResourceRepo) MarkGC(ctx context.Context, id string, previousStatus resource.ResourceStatus, markedAt time.Time) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"id": id, "deleted_at": nil},
+		bson.M{
+			"$set": bson.M{
+				"deleted_at":         markedAt,
+				"gc_marked_at":       markedAt,
+				"gc_previous_status": previousStatus,
+				"status":             resource.ResourceStatusDeleted,
+				"updated_at":         markedAt,
+			},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// RestoreGC 恢复被资源回收标记删除的资源
+func (r *ResourceRepo) RestoreGC(ctx context.Context, id string, status resource.ResourceStatus) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"id": id, "gc_marked_at": bson.M{"$ne": nil}},
+		bson.M{
+			"$set": bson.M{
+				"status":     status,
+				"updated_at": time.Now(),
+			},
+			"$unset": bson.M{
+				"deleted_at":         "",
+				"gc_marked_at":       "",
+				"gc_previous_status": "",
+			},
+		},
+	)
+	return err
+}
+
+// HardDelete 删除资源记录（物理删除，只用于资源回收清除已标记的资源）
+func (r *ResourceRepo) HardDelete(ctx context.Context, id string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"id": id, "gc_marked_at": bson.M{"$ne": nil}})
+	return err
+}
+
 // CreateUploadSession 创建上传会话
 func (r *ResourceRepo) CreateUploadSession(ctx context.Context, session *resource.UploadSession) error {
 	now := time.Now()
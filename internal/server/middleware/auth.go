@@ -6,6 +6,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"lemon/internal/model/auth"
 	"lemon/internal/pkg/ctxutil"
 	"lemon/internal/pkg/jwt"
 )
@@ -53,11 +54,35 @@ func Auth(jwtUtil *jwt.JWT) gin.HandlerFunc {
 			return
 		}
 
-		// 将 user_id 注入到 context
+		// 将 user_id 和角色注入到 context
 		ctx := ctxutil.WithUserID(c.Request.Context(), claims.UserID)
+		ctx = ctxutil.WithUserRole(ctx, auth.UserRole(claims.Role))
 		c.Request = c.Request.WithContext(ctx)
 
 		c.Next()
 	}
 }
 
+
+// OptionalAuth 可选的 JWT 认证中间件
+// 携带有效的 Bearer token 时注入 user_id 和角色，未携带或无效时按未登录继续处理（用于不强制登录的接口按用户限流）
+func OptionalAuth(jwtUtil *jwt.JWT) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok {
+			c.Next()
+			return
+		}
+		claims, err := jwtUtil.ValidateToken(tokenString)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		ctx := ctxutil.WithUserID(c.Request.Context(), claims.UserID)
+		ctx = ctxutil.WithUserRole(ctx, auth.UserRole(claims.Role))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/pkg/ctxutil"
+	"lemon/internal/pkg/environment"
+	"lemon/internal/pkg/ratelimit"
+	"lemon/internal/service"
+)
+
+// EmbedPathPrefix 公开嵌入播放接口的路径前缀，这些接口使用独立的跨域策略，不走全局 CORS
+const EmbedPathPrefix = "/embed/"
+
+// EmbedTokenHeader 嵌入令牌请求头（也可以通过 token 查询参数传递）
+const EmbedTokenHeader = "X-Embed-Token"
+
+// EmbedCORS 公开嵌入接口的跨域中间件
+// 预检请求不携带令牌，无法判断来源是否被授权，这里只放行预检；
+// 实际请求由 EmbedAuth 按令牌的来源白名单决定是否返回 Access-Control-Allow-Origin
+func EmbedCORS() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Vary", "Origin")
+		if c.Request.Method != http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		if origin := c.GetHeader("Origin"); origin != "" {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		c.Header("Access-Control-Allow-Methods", "GET, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Origin, Accept, "+EmbedTokenHeader)
+		c.Header("Access-Control-Max-Age", "86400")
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// EmbedAuth 公开嵌入接口的令牌认证中间件
+// 校验嵌入令牌、请求来源白名单和令牌限流，通过后将令牌注入到 context
+func EmbedAuth(embedService service.EmbedService, limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plaintext := c.GetHeader(EmbedTokenHeader)
+		if plaintext == "" {
+			plaintext = c.Query("token")
+		}
+
+		token, err := embedService.Authenticate(c.Request.Context(), plaintext)
+		if err != nil {
+			code := http.StatusInternalServerError
+			errorCode := 50001
+			if errors.Is(err, service.ErrEmbedTokenInvalid) {
+				code = http.StatusUnauthorized
+				errorCode = 40103
+			}
+			c.JSON(code, gin.H{
+				"code":    errorCode,
+				"message": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		// 浏览器跨域请求会带上 Origin，只对白名单内的来源返回跨域头；服务端直接调用不带 Origin，不做来源限制
+		if origin := c.GetHeader("Origin"); origin != "" {
+			allowed := embedService.AllowedOrigins(token)
+			if !slices.Contains(allowed, "*") && !slices.ContainsFunc(allowed, func(o string) bool {
+				return strings.EqualFold(strings.TrimSuffix(o, "/"), origin)
+			}) {
+				c.JSON(http.StatusForbidden, gin.H{
+					"code":    40302,
+					"message": "该来源未被授权嵌入",
+				})
+				c.Abort()
+				return
+			}
+			c.Header("Access-Control-Allow-Origin", origin)
+			c.Header("Access-Control-Expose-Headers", "X-Request-ID, Retry-After, "+environment.Header)
+		}
+
+		if ok, retryAfter := limiter.Allow(token.ID, embedService.RateLimitPerMinute(token)); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"code":    42901,
+				"message": "请求过于频繁，请稍后重试",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(ctxutil.WithEmbedToken(c.Request.Context(), token))
+		c.Next()
+	}
+}
@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/pkg/environment"
+)
+
+// Environment 环境标记中间件：所有响应带上 X-Lemon-Environment 响应头，便于调用方确认请求落在哪个环境
+func Environment(env environment.Environment) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(environment.Header, env.String())
+		c.Next()
+	}
+}
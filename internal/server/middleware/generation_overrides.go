@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	"lemon/internal/pkg/noveltools"
+)
+
+// GenerationOverrides 单次请求生成参数覆盖中间件
+// 解析查询参数 video_width、video_height、video_fps、voice_type、speed_ratio、image_steps、segment_gap、scene_gap、
+// clip_merge、clip_merge_under，校验后注入到 context，生成服务解析参数时作为最高优先级的一层（仅对本次请求生效，不会保存）
+func GenerationOverrides() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		overrides, err := parseGenerationOverrides(c)
+		if err == nil {
+			err = noveltools.ValidateGenerationSettings(overrides)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    40002,
+				"message": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+		if !overrides.IsZero() {
+			c.Request = c.Request.WithContext(ctxutil.WithGenerationOverrides(c.Request.Context(), overrides))
+		}
+		c.Next()
+	}
+}
+
+// parseGenerationOverrides 从查询参数解析生成参数覆盖，未传的参数保持零值
+func parseGenerationOverrides(c *gin.Context) (*novel.GenerationSettings, error) {
+	overrides := &novel.GenerationSettings{
+		VoiceType: c.Query("voice_type"),
+		ClipMerge: novel.ClipMergeMode(c.Query("clip_merge")),
+	}
+	ints := []struct {
+		name string
+		dst  *int
+	}{
+		{"video_width", &overrides.VideoWidth},
+		{"video_height", &overrides.VideoHeight},
+		{"video_fps", &overrides.VideoFPS},
+		{"image_steps", &overrides.ImageSteps},
+	}
+	for _, p := range ints {
+		raw := c.Query(p.name)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s must be an integer", noveltools.ErrInvalidGenerationSettings, p.name)
+		}
+		*p.dst = v
+	}
+	floats := []struct {
+		name string
+		dst  *float64
+	}{
+		{"speed_ratio", &overrides.SpeedRatio},
+		{"segment_gap", &overrides.SegmentGap},
+		{"scene_gap", &overrides.SceneGap},
+		{"clip_merge_under", &overrides.ClipMergeUnder},
+	}
+	for _, p := range floats {
+		raw := c.Query(p.name)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s must be a number", noveltools.ErrInvalidGenerationSettings, p.name)
+		}
+		*p.dst = v
+	}
+	return overrides, nil
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/pkg/killswitch"
+)
+
+// Maintenance 维护模式中间件
+// 挂在生成类接口上，全局开关或对应 provider 的开关打开时直接返回 503，不再发起新的生成任务
+func Maintenance(ks *killswitch.Switch, provider string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := ks.Check(provider)
+		if err == nil {
+			c.Next()
+			return
+		}
+
+		var mErr *killswitch.MaintenanceError
+		errors.As(err, &mErr)
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"code":    50301,
+			"message": err.Error(),
+			"data": gin.H{
+				"scope":  mErr.Scope,
+				"reason": mErr.Reason,
+				"since":  mErr.Since,
+			},
+		})
+		c.Abort()
+	}
+}
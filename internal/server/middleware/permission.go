@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/auth"
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	"lemon/internal/service"
+	novelservice "lemon/internal/service/novel"
+)
+
+// novelScopeParams 路径参数 -> 资源类型，按顺序取第一个出现的参数定位所属小说
+var novelScopeParams = []struct {
+	param string
+	scope novelservice.NovelScope
+}{
+	{"novel_id", novelservice.NovelScopeNovel},
+	{"chapter_id", novelservice.NovelScopeChapter},
+	{"narration_id", novelservice.NovelScopeNarration},
+	{"scene_id", novelservice.NovelScopeScene},
+	{"shot_id", novelservice.NovelScopeShot},
+	{"reference_id", novelservice.NovelScopeStyleReference},
+	{"prewarm_job_id", novelservice.NovelScopePrewarmJob},
+	{"job_id", novelservice.NovelScopeJob},
+	{"report_id", novelservice.NovelScopeContentRisk},
+	{"compilation_id", novelservice.NovelScopeCompilation},
+	{"standby_job_id", novelservice.NovelScopeStandbyJob},
+	{"entry_id", novelservice.NovelScopePronunciation},
+}
+
+// NovelAccess 小说权限中间件（需要挂在 Auth 之后）
+// 按路径参数定位请求的资源所属的小说，GET/HEAD 请求需要只读权限，其他请求需要编辑权限；
+// 路径中没有小说相关参数的接口（如创建小说）只要求已登录
+func NovelAccess(accessService novelservice.AccessService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		required := requiredPermission(c)
+		checkNovelAccess(c, accessService, func(ctx context.Context, novelID, userID string, role auth.UserRole) error {
+			return accessService.CheckNovelAccess(ctx, novelID, userID, role, required)
+		})
+	}
+}
+
+// NovelOwnerAccess 小说所有者权限中间件（需要挂在 Auth 之后），用于授权管理等只允许所有者操作的接口
+func NovelOwnerAccess(accessService novelservice.AccessService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		checkNovelAccess(c, accessService, accessService.CheckNovelOwner)
+	}
+}
+
+// ResourceAccess 资源文件权限中间件（需要挂在 Auth 之后）
+// 按路径参数 resource_id 检查权限：资源上传者和管理员可以访问，其他用户需要对引用该资源的小说有权限
+func ResourceAccess(accessService novelservice.AccessService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		userID, ok := ctxutil.GetUserID(ctx)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":    40101,
+				"message": "未授权",
+			})
+			c.Abort()
+			return
+		}
+		role, _ := ctxutil.GetUserRole(ctx)
+
+		if resourceID := c.Param("resource_id"); resourceID != "" {
+			if err := accessService.CheckResourceAccess(ctx, resourceID, userID, role, requiredPermission(c)); err != nil {
+				abortAccessError(c, err)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// RequireRole 角色检查中间件（需要挂在 Auth 之后）
+func RequireRole(roles ...auth.UserRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, ok := ctxutil.GetUserRole(c.Request.Context())
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":    40101,
+				"message": "未授权",
+			})
+			c.Abort()
+			return
+		}
+		if !slices.Contains(roles, role) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":    40303,
+				"message": "无权限访问",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// checkNovelAccess 定位请求所属的小说并执行权限检查
+func checkNovelAccess(c *gin.Context, accessService novelservice.AccessService, check func(ctx context.Context, novelID, userID string, role auth.UserRole) error) {
+	ctx := c.Request.Context()
+	userID, ok := ctxutil.GetUserID(ctx)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    40101,
+			"message": "未授权",
+		})
+		c.Abort()
+		return
+	}
+	role, _ := ctxutil.GetUserRole(ctx)
+
+	var err error
+	for _, p := range novelScopeParams {
+		resourceID := c.Param(p.param)
+		if resourceID == "" {
+			continue
+		}
+		var novelID string
+		novelID, err = accessService.ResolveNovelID(ctx, p.scope, resourceID)
+		if err == nil {
+			err = check(ctx, novelID, userID, role)
+		}
+		break
+	}
+	if err == nil {
+		c.Next()
+		return
+	}
+	abortAccessError(c, err)
+}
+
+// requiredPermission GET/HEAD 请求需要只读权限，其他请求需要编辑权限
+func requiredPermission(c *gin.Context) novel.NovelPermission {
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		return novel.NovelPermissionRead
+	}
+	return novel.NovelPermissionEdit
+}
+
+// abortAccessError 权限检查失败的响应：无权限返回 403，资源不存在返回 404
+func abortAccessError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	errorCode := 50001
+	switch {
+	case errors.Is(err, novelservice.ErrNovelAccessDenied):
+		code = http.StatusForbidden
+		errorCode = 40303
+	case errors.Is(err, mongo.ErrNoDocuments), errors.Is(err, service.ErrResourceNotFound):
+		code = http.StatusNotFound
+		errorCode = 40401
+	}
+	c.JSON(code, gin.H{
+		"code":    errorCode,
+		"message": err.Error(),
+	})
+	c.Abort()
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/pkg/procreaper"
+)
+
+// ProcReaper 进程回收中间件：把回收器关联到请求 context，请求处理期间启动的 ffmpeg 进程和临时目录都会被记录，
+// 服务崩溃重启后由回收器清理（请求内启动的进程随请求取消结束，不按任务回收）
+func ProcReaper(r *procreaper.Reaper) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if r != nil {
+			c.Request = c.Request.WithContext(procreaper.NewContext(c.Request.Context(), r))
+		}
+		c.Next()
+	}
+}
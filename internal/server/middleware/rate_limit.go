@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/model/auth"
+	"lemon/internal/pkg/ctxutil"
+	"lemon/internal/pkg/ratelimit"
+)
+
+// RateLimitClass 接口限流类别，不同类别使用独立的令牌桶
+type RateLimitClass string
+
+const (
+	RateLimitClassRead       RateLimitClass = "read"       // 查询类接口（GET/HEAD）
+	RateLimitClassWrite      RateLimitClass = "write"      // 其他修改类接口
+	RateLimitClassGeneration RateLimitClass = "generation" // 生成类接口（调用 LLM/TTS/图片/视频 provider）
+)
+
+// RateLimitPolicy 接口限流策略
+type RateLimitPolicy struct {
+	Limits         map[RateLimitClass]int // 各类别每分钟请求上限（缺省或 <= 0 表示不限）
+	EndpointLimits map[string]int         // 单个接口每分钟请求上限，key 为 RateLimitEndpoint 返回值；与类别限流同时生效，各接口使用独立的令牌桶
+	ExemptUserIDs  []string               // 不限流的用户
+	ExemptRoles    []auth.UserRole        // 不限流的角色
+	ExemptNets     []*net.IPNet           // 不限流的来源网段
+}
+
+// RateLimitEndpoint 接口限流的接口标识：请求方法 + 路由模板，例如 "POST /api/v1/novels/chapters/:chapter_id/narration"
+func RateLimitEndpoint(method, routePath string) string {
+	return strings.ToUpper(method) + " " + routePath
+}
+
+// RateLimiter 接口限流器：按用户（未登录时按客户端 IP）和接口类别限流，内部调用方不限流
+// 客户端 IP 只采信 engine.SetTrustedProxies 配置的代理转发的地址
+type RateLimiter struct {
+	backend ratelimit.Backend
+	policy  RateLimitPolicy
+}
+
+// NewRateLimiter 创建接口限流器
+func NewRateLimiter(backend ratelimit.Backend, policy RateLimitPolicy) *RateLimiter {
+	return &RateLimiter{backend: backend, policy: policy}
+}
+
+// RateLimit 接口限流中间件（需要挂在 Auth 或 OptionalAuth 之后才能按用户限流，否则按客户端 IP 限流）
+// GET/HEAD 请求计入查询类，其他请求计入修改类；配置了单独上限的接口另外按接口计数
+// 类别和接口的令牌桶同时检查，任何一个超出上限时都不消耗令牌
+func RateLimit(rl *RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		class := RateLimitClassWrite
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			class = RateLimitClassRead
+		}
+		endpoint := RateLimitEndpoint(c.Request.Method, c.FullPath())
+		if rl.take(c,
+			rateLimitBucket{name: string(class), limit: rl.policy.Limits[class], scope: gin.H{"class": class}},
+			rateLimitBucket{name: "endpoint:" + endpoint, limit: rl.policy.EndpointLimits[endpoint], scope: gin.H{"endpoint": endpoint}},
+		) {
+			c.Next()
+		}
+	}
+}
+
+// GenerationRateLimit 生成类接口限流中间件，挂在生成类接口上（在 RateLimit 之外单独计数）
+func GenerationRateLimit(rl *RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		class := RateLimitClassGeneration
+		if rl.take(c, rateLimitBucket{name: string(class), limit: rl.policy.Limits[class], scope: gin.H{"class": class}}) {
+			c.Next()
+		}
+	}
+}
+
+// rateLimitBucket 一次请求需要消耗令牌的令牌桶
+type rateLimitBucket struct {
+	name  string // 桶名（类别或接口），与请求方标识组成桶的 key
+	limit int    // 每分钟请求上限（<= 0 表示不限）
+	scope gin.H  // 429 响应中说明被限流范围的字段
+}
+
+// take 从各个令牌桶中同时消耗一个令牌并写入限流响应头，任何一个桶超出上限时返回 429 并返回 false（所有桶都不消耗令牌）
+// 响应头和 429 响应使用最紧的桶：放行时为剩余令牌最少的桶，限流时为需要等待最久的桶
+// 不限流的桶忽略；请求方不限流时直接放行
+func (rl *RateLimiter) take(c *gin.Context, buckets ...rateLimitBucket) bool {
+	buckets = slices.DeleteFunc(buckets, func(b rateLimitBucket) bool { return b.limit <= 0 })
+	if len(buckets) == 0 || rl.exempt(c) {
+		return true
+	}
+
+	identity := "ip:" + c.ClientIP()
+	if userID, ok := ctxutil.GetUserID(c.Request.Context()); ok {
+		identity = "user:" + userID
+	}
+
+	keys := make([]ratelimit.Bucket, len(buckets))
+	for i, b := range buckets {
+		keys[i] = ratelimit.Bucket{Key: b.name + ":" + identity, LimitPerMinute: b.limit}
+	}
+	results := rl.backend.TakeAll(c.Request.Context(), keys)
+	tightest := 0
+	for i, r := range results[1:] {
+		if tighter(r, results[tightest]) {
+			tightest = i + 1
+		}
+	}
+
+	result := results[tightest]
+	c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+	if result.Allowed {
+		return true
+	}
+
+	retryAfter := max(ceilSeconds(result.RetryAfter), 1)
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	data := gin.H{
+		"limit":       result.Limit,
+		"retry_after": retryAfter,
+	}
+	for k, v := range buckets[tightest].scope {
+		data[k] = v
+	}
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"code":    42901,
+		"message": "请求过于频繁，请稍后重试",
+		"data":    data,
+	})
+	c.Abort()
+	return false
+}
+
+// tighter a 是否比 b 更紧：需要等待更久，或剩余令牌更少（相同时上限更小）
+func tighter(a, b ratelimit.Result) bool {
+	if a.RetryAfter != b.RetryAfter {
+		return a.RetryAfter > b.RetryAfter
+	}
+	if a.Remaining != b.Remaining {
+		return a.Remaining < b.Remaining
+	}
+	return a.Limit < b.Limit
+}
+
+// exempt 请求是否来自不限流的内部调用方（指定用户、角色或来源网段）
+// 来源网段按连接的远端地址匹配，不使用可以被客户端伪造的 X-Forwarded-For
+func (rl *RateLimiter) exempt(c *gin.Context) bool {
+	ctx := c.Request.Context()
+	if userID, ok := ctxutil.GetUserID(ctx); ok && slices.Contains(rl.policy.ExemptUserIDs, userID) {
+		return true
+	}
+	if role, ok := ctxutil.GetUserRole(ctx); ok && slices.Contains(rl.policy.ExemptRoles, role) {
+		return true
+	}
+	if len(rl.policy.ExemptNets) > 0 {
+		if ip := net.ParseIP(c.RemoteIP()); ip != nil {
+			return slices.ContainsFunc(rl.policy.ExemptNets, func(n *net.IPNet) bool { return n.Contains(ip) })
+		}
+	}
+	return false
+}
+
+// ceilSeconds 向上取整的秒数（用于响应头）
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
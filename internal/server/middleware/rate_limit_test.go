@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/pkg/jwt"
+	"lemon/internal/pkg/ratelimit"
+)
+
+// newRateLimitRouter 创建挂了接口限流的测试路由（不信任任何代理）
+func newRateLimitRouter(t *testing.T, rl *RateLimiter, handlers ...gin.HandlerFunc) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	if err := r.SetTrustedProxies(nil); err != nil {
+		t.Fatal(err)
+	}
+	r.Use(handlers...)
+	r.Use(RateLimit(rl))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/items", ok)
+	r.POST("/items", ok)
+	r.POST("/items/:item_id/render", ok)
+	return r
+}
+
+// doRequest 以指定的远端地址发送请求，header 为额外的请求头
+func doRequest(r *gin.Engine, method, path, remoteAddr string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = remoteAddr
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitExemptNetIgnoresForwardedFor(t *testing.T) {
+	_, loopback, _ := net.ParseCIDR("127.0.0.1/32")
+	rl := NewRateLimiter(ratelimit.New(), RateLimitPolicy{
+		Limits:     map[RateLimitClass]int{RateLimitClassRead: 1},
+		ExemptNets: []*net.IPNet{loopback},
+	})
+	r := newRateLimitRouter(t, rl)
+
+	spoofed := map[string]string{"X-Forwarded-For": "127.0.0.1"}
+	if w := doRequest(r, http.MethodGet, "/items", "203.0.113.7:40000", spoofed); w.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, want 200", w.Code)
+	}
+	if w := doRequest(r, http.MethodGet, "/items", "203.0.113.7:40000", spoofed); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("spoofed X-Forwarded-For status = %d, want 429", w.Code)
+	}
+
+	for i := 0; i < 3; i++ {
+		if w := doRequest(r, http.MethodGet, "/items", "127.0.0.1:40000", nil); w.Code != http.StatusOK {
+			t.Fatalf("loopback request %d status = %d, want 200", i, w.Code)
+		}
+	}
+}
+
+func TestRateLimitForwardedForDoesNotRotateBuckets(t *testing.T) {
+	rl := NewRateLimiter(ratelimit.New(), RateLimitPolicy{
+		Limits: map[RateLimitClass]int{RateLimitClassRead: 2},
+	})
+	r := newRateLimitRouter(t, rl)
+
+	codes := make([]int, 0, 3)
+	for _, forwarded := range []string{"198.51.100.1", "198.51.100.2", "198.51.100.3"} {
+		w := doRequest(r, http.MethodGet, "/items", "203.0.113.7:40000", map[string]string{"X-Forwarded-For": forwarded})
+		codes = append(codes, w.Code)
+	}
+	if codes[2] != http.StatusTooManyRequests {
+		t.Fatalf("status codes = %v, want the third request limited", codes)
+	}
+}
+
+func TestRateLimitByUserWithOptionalAuth(t *testing.T) {
+	j := jwt.NewJWT("test-secret", time.Hour)
+	rl := NewRateLimiter(ratelimit.New(), RateLimitPolicy{
+		Limits: map[RateLimitClass]int{RateLimitClassRead: 1},
+	})
+	r := newRateLimitRouter(t, rl, OptionalAuth(j))
+
+	token := func(userID string) map[string]string {
+		s, err := j.GenerateToken(userID, userID, "editor")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return map[string]string{"Authorization": "Bearer " + s}
+	}
+
+	// 同一个 IP 上的两个用户各自计数
+	if w := doRequest(r, http.MethodGet, "/items", "203.0.113.7:40000", token("user-1")); w.Code != http.StatusOK {
+		t.Fatalf("user-1 status = %d, want 200", w.Code)
+	}
+	if w := doRequest(r, http.MethodGet, "/items", "203.0.113.7:40000", token("user-2")); w.Code != http.StatusOK {
+		t.Fatalf("user-2 status = %d, want 200", w.Code)
+	}
+	if w := doRequest(r, http.MethodGet, "/items", "203.0.113.7:40000", token("user-1")); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("user-1 second status = %d, want 429", w.Code)
+	}
+
+	// 无效 token 按未登录处理，按 IP 计数
+	invalid := map[string]string{"Authorization": "Bearer invalid"}
+	if w := doRequest(r, http.MethodGet, "/items", "203.0.113.7:40000", invalid); w.Code != http.StatusOK {
+		t.Fatalf("anonymous status = %d, want 200", w.Code)
+	}
+	if w := doRequest(r, http.MethodGet, "/items", "203.0.113.7:40000", invalid); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("anonymous second status = %d, want 429", w.Code)
+	}
+}
+
+func TestRateLimitOverlappingClassAndEndpoint(t *testing.T) {
+	render := RateLimitEndpoint(http.MethodPost, "/items/:item_id/render")
+	rl := NewRateLimiter(ratelimit.New(), RateLimitPolicy{
+		Limits:         map[RateLimitClass]int{RateLimitClassWrite: 5},
+		EndpointLimits: map[string]int{render: 2},
+	})
+	r := newRateLimitRouter(t, rl)
+	const addr = "203.0.113.7:40000"
+
+	// 响应头使用剩余令牌更少的接口桶
+	for i, wantRemaining := range []string{"1", "0"} {
+		w := doRequest(r, http.MethodPost, "/items/1/render", addr, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("render %d status = %d, want 200", i, w.Code)
+		}
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "2" {
+			t.Errorf("render %d X-RateLimit-Limit = %s, want 2", i, got)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != wantRemaining {
+			t.Errorf("render %d X-RateLimit-Remaining = %s, want %s", i, got, wantRemaining)
+		}
+	}
+
+	// 接口桶超出上限：返回 429 说明接口范围，并且不消耗类别桶的令牌
+	w := doRequest(r, http.MethodPost, "/items/1/render", addr, nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("render over limit status = %d, want 429", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"endpoint":"`+render+`"`) {
+		t.Errorf("429 body = %s, want endpoint scope", w.Body.String())
+	}
+	if got := w.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Retry-After = %s, want 30", got)
+	}
+
+	// 类别桶还剩 3 个令牌（被拒绝的请求没有消耗），响应头改为类别桶
+	for i, wantRemaining := range []string{"2", "1", "0"} {
+		w := doRequest(r, http.MethodPost, "/items", addr, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("write %d status = %d, want 200", i, w.Code)
+		}
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "5" {
+			t.Errorf("write %d X-RateLimit-Limit = %s, want 5", i, got)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != wantRemaining {
+			t.Errorf("write %d X-RateLimit-Remaining = %s, want %s", i, got, wantRemaining)
+		}
+	}
+	w = doRequest(r, http.MethodPost, "/items", addr, nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("write over limit status = %d, want 429", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"class":"write"`) {
+		t.Errorf("429 body = %s, want class scope", w.Body.String())
+	}
+}
+
+func TestRateLimitClassTighterThanEndpoint(t *testing.T) {
+	render := RateLimitEndpoint(http.MethodPost, "/items/:item_id/render")
+	rl := NewRateLimiter(ratelimit.New(), RateLimitPolicy{
+		Limits:         map[RateLimitClass]int{RateLimitClassWrite: 2},
+		EndpointLimits: map[string]int{render: 10},
+	})
+	r := newRateLimitRouter(t, rl)
+	const addr = "203.0.113.7:40000"
+
+	w := doRequest(r, http.MethodPost, "/items/1/render", addr, nil)
+	if got := w.Header().Get("X-RateLimit-Limit"); got != "2" {
+		t.Errorf("X-RateLimit-Limit = %s, want 2", got)
+	}
+	if got := w.Header().Get("X-RateLimit-Remaining"); got != "1" {
+		t.Errorf("X-RateLimit-Remaining = %s, want 1", got)
+	}
+
+	doRequest(r, http.MethodPost, "/items", addr, nil)
+	w = doRequest(r, http.MethodPost, "/items/1/render", addr, nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"class":"write"`) {
+		t.Errorf("429 body = %s, want class scope", w.Body.String())
+	}
+	if got := w.Header().Get("X-RateLimit-Limit"); got != "2" {
+		t.Errorf("X-RateLimit-Limit = %s, want 2", got)
+	}
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/pkg/ctxutil"
+)
+
+// RegenerationOverride 重新生成越权中间件
+// 解析查询参数 override_reason 并注入到 context；产物的重新生成次数超过上限时，
+// 只有管理员/审核员填写了原因才能继续，服务层记录越权审计
+func RegenerationOverride() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if reason := strings.TrimSpace(c.Query("override_reason")); reason != "" {
+			c.Request = c.Request.WithContext(ctxutil.WithRegenerationOverride(c.Request.Context(), reason))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"lemon/internal/pkg/tasklog"
+)
+
+// TaskLog 任务日志中间件
+// 挂在生成类接口上，以请求ID（X-Request-ID）作为任务ID登记任务，并把任务日志关联到请求 context，
+// 请求处理期间的阶段进度、provider 请求和 ffmpeg 输出都会写入该任务，可以通过 GET /api/v1/tasks/:task_id/logs 跟踪
+func TaskLog(store *tasklog.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskID := c.GetString("request_id")
+		if taskID == "" {
+			c.Next()
+			return
+		}
+
+		store.Start(taskID, c.Request.Method+" "+c.FullPath())
+		ctx := tasklog.NewContext(c.Request.Context(), store, taskID)
+		c.Request = c.Request.WithContext(ctx)
+		logger := tasklog.FromContext(ctx)
+		logger.Info("请求开始处理", tasklog.Fields{"path": c.Request.URL.Path, "query": c.Request.URL.RawQuery})
+
+		start := time.Now()
+		c.Next()
+
+		fields := tasklog.Fields{
+			"status":     c.Writer.Status(),
+			"latency_ms": time.Since(start).Milliseconds(),
+		}
+		if len(c.Errors) > 0 {
+			fields["errors"] = c.Errors.String()
+		}
+		if c.Writer.Status() >= 400 {
+			logger.Error("请求处理失败", fields)
+		} else {
+			logger.Info("请求处理完成", fields)
+		}
+		store.Finish(taskID)
+	}
+}
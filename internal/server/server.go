@@ -3,7 +3,11 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,11 +18,28 @@ import (
 	"lemon/internal/config"
 	"lemon/internal/handler"
 	authHandler "lemon/internal/handler/auth"
+	embedHandler "lemon/internal/handler/embed"
+	maintenanceHandler "lemon/internal/handler/maintenance"
 	novelHandler "lemon/internal/handler/novel"
 	resourceHandler "lemon/internal/handler/resource"
+	taskHandler "lemon/internal/handler/task"
+	novelV2Handler "lemon/internal/handler/v2/novel"
+	"lemon/internal/model/auth"
+	"lemon/internal/pkg/assetcache"
 	"lemon/internal/pkg/cache"
+	"lemon/internal/pkg/environment"
+	"lemon/internal/pkg/ffmpeg"
+	"lemon/internal/pkg/jwt"
+	"lemon/internal/pkg/killswitch"
 	"lemon/internal/pkg/mongodb"
+	"lemon/internal/pkg/presign"
+	"lemon/internal/pkg/procreaper"
+	"lemon/internal/pkg/ratelimit"
+	"lemon/internal/pkg/respcache"
+	"lemon/internal/pkg/storage"
 	"lemon/internal/pkg/storagefactory"
+	"lemon/internal/pkg/tasklog"
+	"lemon/internal/pkg/tsa"
 	authRepo "lemon/internal/repository/auth"
 	"lemon/internal/server/middleware"
 	"lemon/internal/service"
@@ -31,6 +52,24 @@ type Server struct {
 	engine *gin.Engine
 	mongo  *mongodb.Client
 	redis  *cache.RedisCache
+	// killSwitch 熔断开关（维护模式），生成类接口和 NovelService 共用
+	killSwitch *killswitch.Switch
+	// taskLogs 生成任务的内存日志（按请求ID记录，供运维跟踪运行中的任务）
+	taskLogs *tasklog.Store
+	// ffmpegCaps 启动时的 FFmpeg 能力检测结果（健康检查中展示）
+	ffmpegCaps *ffmpeg.Capabilities
+	// procReaper 孤儿进程与临时文件回收器（未启用时为 nil）
+	procReaper *procreaper.Reaper
+	// storage 各模块共用的存储实例（配置了备用存储时复制队列和主存储状态只有一份）
+	storage     storage.Storage
+	storageErr  error
+	storageOnce sync.Once
+	// storageReconciler 配置了备用存储时用于定时对账的资源服务
+	storageReconciler service.ResourceService
+	// resourceGC 开启定时资源回收时使用的资源回收服务
+	resourceGC service.ResourceGCService
+	// jobQueue 异步任务队列（NovelService 初始化成功时设置）
+	jobQueue novelService.JobService
 	// transformSvc *service.TransformService // TODO: 修复transform service后启用
 }
 
@@ -46,8 +85,20 @@ func New(cfg *config.Config) (*Server, error) {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// 设置部署环境：供应商凭据按环境读取，必须在创建任何 provider 之前设置
+	env, err := environment.Parse(cfg.Environment)
+	if err != nil {
+		return nil, err
+	}
+	environment.Set(env)
+	warnProdCredentials(env)
+
 	// 创建 Gin 引擎
 	engine := gin.New()
+	// 只信任配置的反向代理转发的客户端 IP（未配置时不信任任何代理），避免伪造 X-Forwarded-For 绕过限流
+	if err := engine.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("set trusted proxies: %w", err)
+	}
 
 	// 初始化 MongoDB (可选)
 	var mongoClient *mongodb.Client
@@ -78,6 +129,27 @@ func New(cfg *config.Config) (*Server, error) {
 		}
 	}
 
+	// 检测 FFmpeg 字幕烧录能力（缺少 libass 或字体时烧录会静默生成无字幕视频）
+	ffmpegCaps, err := probeFFmpeg(&cfg.FFmpeg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 孤儿进程与临时文件回收（启动时先清理崩溃前留下的 ffmpeg 进程和临时目录）
+	var procReaper *procreaper.Reaper
+	if cfg.ProcReaper.Enabled {
+		procReaper, err = procreaper.New(procreaper.Options{
+			StateDir:     cfg.ProcReaper.StateDir,
+			StaleTempAge: cfg.ProcReaper.StaleTempAge,
+		})
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to initialize process reaper, orphan cleanup disabled")
+		} else {
+			procReaper.Start(context.Background(), cfg.ProcReaper.Interval)
+			log.Info().Dur("interval", cfg.ProcReaper.Interval).Msg("process reaper started")
+		}
+	}
+
 	// 初始化 TransformService (可选)
 	// TODO: 修复transform service后启用
 	// var transformSvc *service.TransformService
@@ -92,10 +164,14 @@ func New(cfg *config.Config) (*Server, error) {
 	// }
 
 	srv := &Server{
-		cfg:    cfg,
-		engine: engine,
-		mongo:  mongoClient,
-		redis:  redisCache,
+		cfg:        cfg,
+		engine:     engine,
+		mongo:      mongoClient,
+		redis:      redisCache,
+		killSwitch: killswitch.New(killswitch.InFlightPolicy(cfg.Maintenance.InFlightPolicy)),
+		taskLogs:   tasklog.NewStore(0, 0),
+		ffmpegCaps: ffmpegCaps,
+		procReaper: procReaper,
 		// transformSvc: transformSvc, // TODO: 修复transform service后启用
 	}
 
@@ -105,16 +181,102 @@ func New(cfg *config.Config) (*Server, error) {
 	return srv, nil
 }
 
+// warnProdCredentials 非生产环境使用了生产环境的供应商凭据时告警（生成请求会计入生产账号的费用和配额）
+func warnProdCredentials(env environment.Environment) {
+	keys := environment.ProdCredentialsInUse()
+	if len(keys) == 0 {
+		log.Info().Str("environment", env.String()).Msg("deployment environment")
+		return
+	}
+	log.Warn().
+		Str("environment", env.String()).
+		Strs("credentials", keys).
+		Msg("production provider credentials are used outside prod, configure <KEY>_" + strings.ToUpper(env.String()) + " to use a separate account")
+}
+
+// probeFFmpeg 解析 FFmpeg 二进制并检测能力
+// 版本不在支持范围内（且无法自动下载）或 require_subtitles 开启时字幕烧录不可用，直接返回错误，阻止服务启动
+func probeFFmpeg(cfg *config.FFmpegConfig) (*ffmpeg.Capabilities, error) {
+	if err := resolveFFmpeg(cfg); err != nil {
+		return nil, err
+	}
+
+	timeout := cfg.ProbeTimeout
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	caps := ffmpeg.NewClient().ProbeCapabilities(ctx)
+	if caps.SubtitleRendering {
+		log.Info().Msg("ffmpeg subtitle rendering available")
+		return caps, nil
+	}
+	if cfg.RequireSubtitles {
+		return nil, fmt.Errorf("%s; install ffmpeg with libass and fonts, or set ffmpeg.require_subtitles=false to start without subtitle burning", caps.SubtitleError)
+	}
+	log.Warn().Str("reason", caps.SubtitleError).Msg("ffmpeg subtitle rendering unavailable, generated videos will have no burned-in subtitles")
+	return caps, nil
+}
+
+// resolveFFmpeg 解析 ffmpeg/ffprobe 路径并检查版本，之后创建的 FFmpeg 客户端都使用解析结果
+func resolveFFmpeg(cfg *config.FFmpegConfig) error {
+	timeout := cfg.DownloadTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	downloads := make([]ffmpeg.Download, 0, len(cfg.Downloads))
+	for _, d := range cfg.Downloads {
+		downloads = append(downloads, ffmpeg.Download{URL: d.URL, SHA256: d.SHA256})
+	}
+	binaries, err := ffmpeg.Resolve(ctx, &ffmpeg.BinaryConfig{
+		FFmpegPath:   cfg.Path,
+		FFprobePath:  cfg.FFprobePath,
+		MinVersion:   cfg.MinVersion,
+		MaxVersion:   cfg.MaxVersion,
+		AutoDownload: cfg.AutoDownload,
+		DataDir:      cfg.DataDir,
+		Version:      cfg.Version,
+		Downloads:    downloads,
+	})
+	if err != nil {
+		// 版本不受支持时始终拒绝启动；找不到 ffmpeg 时与字幕检测保持一致，require_subtitles 关闭时只告警
+		if errors.Is(err, ffmpeg.ErrUnsupportedVersion) || cfg.RequireSubtitles {
+			return fmt.Errorf("resolve ffmpeg: %w; set ffmpeg.path to a supported build or enable ffmpeg.auto_download", err)
+		}
+		log.Warn().Err(err).Msg("ffmpeg not resolved, video rendering will fail until ffmpeg is installed")
+		return nil
+	}
+	ffmpeg.Use(binaries)
+	log.Info().
+		Str("ffmpeg", binaries.FFmpegPath).
+		Str("ffprobe", binaries.FFprobePath).
+		Str("version", binaries.Version).
+		Str("source", binaries.Source).
+		Msg("ffmpeg resolved")
+	return nil
+}
+
 // setupRoutes 设置路由
 func (s *Server) setupRoutes() {
 	// 全局中间件
 	s.engine.Use(middleware.Recovery())
 	s.engine.Use(middleware.RequestID())
+	s.engine.Use(middleware.Environment(environment.Current()))
 	s.engine.Use(middleware.Logger())
 	s.engine.Use(middleware.CORS())
+	s.engine.Use(middleware.ProcReaper(s.procReaper))
 
 	// 健康检查
-	healthHandler := handler.NewHealthHandler()
+	var replicator storage.Replicator
+	if st, err := s.getStorage(); err == nil {
+		replicator, _ = st.(storage.Replicator)
+	}
+	healthHandler := handler.NewHealthHandler(s.ffmpegCaps, replicator, s.procReaper)
 	s.engine.GET("/health", healthHandler.Health)
 	s.engine.GET("/ready", healthHandler.Ready)
 
@@ -130,9 +292,8 @@ func (s *Server) setupRoutes() {
 			refreshTokenRepo := authRepo.NewRefreshTokenRepo(s.mongo.Database())
 
 			// 从配置读取JWT参数，如果没有配置则使用默认值
-			jwtSecret := s.cfg.Auth.JWTSecret
-			if jwtSecret == "" {
-				jwtSecret = "default-secret-key-change-in-production"
+			jwtSecret := s.jwtSecret()
+			if s.cfg.Auth.JWTSecret == "" {
 				log.Warn().Msg("JWT secret not configured, using default (NOT SECURE for production)")
 			}
 
@@ -184,109 +345,616 @@ func (s *Server) setupRoutes() {
 		// Conversation 接口
 		// TODO: 实现conversation模块（需要先完成model定义）
 
-		// Resource 接口（资源管理）
-		if s.mongo != nil {
-			// 初始化 ResourceService（需要 storage）
-			storage, err := storagefactory.NewStorage(context.Background(), &s.cfg.Storage)
-			if err != nil {
-				log.Warn().Err(err).Msg("failed to initialize storage, resource endpoints disabled")
-			} else {
-				resourceSvc := service.NewResourceService(s.mongo.Database(), storage)
-				resourceHdl := resourceHandler.NewHandler(resourceSvc)
+		// 任务日志接口（运维跟踪运行中的生成任务；开启小说权限时只允许管理员访问）
+		taskRoutes := v1.Group("")
+		if s.cfg.Auth.EnforceNovelAccess {
+			taskRoutes.Use(middleware.Auth(jwt.NewJWT(s.jwtSecret(), 0)), middleware.RequireRole(auth.RoleAdmin))
+		}
+		taskHdl := taskHandler.NewHandler(service.NewTaskLogService(s.taskLogs))
+		taskRoutes.GET("/tasks/:task_id/logs", taskHdl.GetTaskLogs)
 
-				// 资源管理接口
-				v1.POST("/resources/upload", resourceHdl.UploadFile)
-				v1.GET("/resources", resourceHdl.ListResources)
-				v1.GET("/resources/:resource_id", resourceHdl.GetResource)
-				v1.GET("/resources/:resource_id/download", resourceHdl.DownloadFile)
-				v1.GET("/resources/:resource_id/download-url", resourceHdl.GetDownloadURL)
+		// Maintenance 接口（维护模式/熔断开关）
+		if s.mongo != nil {
+			maintenanceSvc := service.NewMaintenanceService(s.mongo.Database(), s.killSwitch)
+			if err := maintenanceSvc.Restore(context.Background(), &s.cfg.Maintenance); err != nil {
+				log.Warn().Err(err).Msg("failed to restore maintenance state")
 			}
+			maintenanceHdl := maintenanceHandler.NewHandler(maintenanceSvc)
+
+			v1.GET("/maintenance", maintenanceHdl.GetStatus)
+			v1.GET("/maintenance/downtime-windows", maintenanceHdl.ListDowntimeWindows)
+			// 开关维护模式始终只允许管理员操作（与是否开启小说权限无关）
+			maintenanceAdmin := v1.Group("", middleware.Auth(jwt.NewJWT(s.jwtSecret(), 0)), middleware.RequireRole(auth.RoleAdmin))
+			maintenanceAdmin.POST("/maintenance/enable", maintenanceHdl.Enable)
+			maintenanceAdmin.POST("/maintenance/disable", maintenanceHdl.Disable)
 		} else {
-			log.Warn().Msg("MongoDB not configured, resource endpoints disabled")
+			log.Warn().Msg("MongoDB not configured, maintenance endpoints disabled")
 		}
 
 		// Novel 接口（小说与创作相关）
+		// novelAccess 为小说权限检查，供后面注册的资源、嵌入令牌等接口使用（NovelService 初始化失败时为空）
+		var novelAccess novelService.AccessService
 		if s.mongo != nil {
 			// 初始化 ResourceService（需要 storage）
-			storage, err := storagefactory.NewStorage(context.Background(), &s.cfg.Storage)
+			storage, err := s.getStorage()
 			if err != nil {
 				log.Warn().Err(err).Msg("failed to initialize storage, novel endpoints disabled")
 			} else {
 				db := s.mongo.Database()
-				resourceSvc := service.NewResourceService(db, storage)
+				resourceOpts := []service.ResourceServiceOption{
+					service.WithKeyTemplates(s.cfg.Storage.KeyTemplates),
+					service.WithURLPolicies(s.urlPolicies()),
+				}
+				novelOpts := []novelService.Option{novelService.WithKillSwitch(s.killSwitch), novelService.WithProcReaper(s.procReaper)}
+
+				// 本地资源缓存（可选），定时渲染前预热素材
+				if s.cfg.AssetCache.Dir != "" {
+					assetCache, err := assetcache.New(s.cfg.AssetCache.Dir)
+					if err != nil {
+						log.Warn().Err(err).Msg("failed to initialize asset cache, prewarm disabled")
+					} else {
+						resourceOpts = append(resourceOpts, service.WithAssetCache(assetCache))
+						novelOpts = append(novelOpts, novelService.WithAssetCache(assetCache, s.cfg.AssetCache.LeadTime, s.cfg.AssetCache.MaxAge))
+					}
+				}
+
+				// 最终视频公证的外部时间戳服务（可选）
+				if s.cfg.Notarization.TSAURL != "" {
+					novelOpts = append(novelOpts, novelService.WithTimestampAuthority(tsa.New(s.cfg.Notarization.TSAURL, s.cfg.Notarization.TSATimeout)))
+				}
+
+				// provider 响应缓存（可选），重试时复用相同提示词的输出
+				if s.cfg.ProviderCache.Enabled {
+					novelOpts = append(novelOpts, novelService.WithProviderCache(respcache.New(respcache.Options{
+						TTL:        s.cfg.ProviderCache.TTL,
+						MaxEntries: s.cfg.ProviderCache.MaxEntries,
+						MaxBytes:   s.cfg.ProviderCache.MaxBytes,
+					})))
+				}
+				resourceSvc := service.NewResourceService(db, storage, resourceOpts...)
 
 				// 初始化 NovelService
-				novelSvc, err := novelService.NewNovelService(db, resourceSvc)
+				novelSvc, err := novelService.NewNovelService(db, resourceSvc, novelOpts...)
 				if err != nil {
 					log.Warn().Err(err).Msg("failed to initialize NovelService, novel endpoints disabled")
 				} else {
+					if err := novelSvc.ResumePrewarmJobs(context.Background()); err != nil {
+						log.Warn().Err(err).Msg("failed to resume prewarm jobs")
+					}
+					if err := novelSvc.FailInterruptedStandbyJobs(context.Background()); err != nil {
+						log.Warn().Err(err).Msg("failed to mark interrupted standby jobs")
+					}
+					if err := novelSvc.ResumeWebhookDeliveries(context.Background()); err != nil {
+						log.Warn().Err(err).Msg("failed to resume webhook deliveries")
+					}
+					// 任务队列工作协程在 Run 中随服务启动，服务停止时执行中的任务放回队列
+					s.jobQueue = novelSvc
+					novelAccess = novelSvc
+					// 分析数据定时导出（ANALYTICS_EXPORT_INTERVAL 未设置时不启动）
+					novelSvc.StartAnalyticsExport(context.Background())
 					novelHdl := novelHandler.NewHandler(novelSvc)
 
+					// 生成类接口按 provider 挂载维护模式中间件，开关打开时直接返回 503
+					llmGuard := middleware.Maintenance(s.killSwitch, killswitch.ProviderLLM)
+					ttsGuard := middleware.Maintenance(s.killSwitch, killswitch.ProviderTTS)
+					imageGuard := middleware.Maintenance(s.killSwitch, killswitch.ProviderImage)
+					videoGuard := middleware.Maintenance(s.killSwitch, killswitch.ProviderVideo)
+					// 生成类接口记录任务日志（任务ID为请求ID），可以通过 /api/v1/tasks/:task_id/logs 跟踪
+					taskLog := middleware.TaskLog(s.taskLogs)
+					// 重新生成类接口解析 override_reason（超过单个场景/镜头的重新生成次数上限时越权）
+					regenOverride := middleware.RegenerationOverride()
+					// 接口限流：按用户和接口类别（查询/修改/生成）限流，生成类接口另外单独计数
+					apiLimit, generationLimit := s.rateLimitMiddlewares()
+
+					// 小说权限：开启后小说接口要求登录，并按所有者、协作授权和角色检查权限
+					novelRoutes := v1.Group("")
+					ownerGuard := passThrough
+					allNovelsGuard := passThrough
+					adminGuard := passThrough
+					if s.cfg.Auth.EnforceNovelAccess {
+						novelRoutes.Use(middleware.Auth(jwt.NewJWT(s.jwtSecret(), 0)), middleware.NovelAccess(novelSvc))
+						ownerGuard = middleware.NovelOwnerAccess(novelSvc)
+						allNovelsGuard = middleware.RequireRole(auth.RoleAdmin, auth.RoleReviewer)
+						adminGuard = middleware.RequireRole(auth.RoleAdmin)
+					} else {
+						// 不强制登录时仍然识别携带的 token，使接口限流按用户而不是按 IP 计数
+						novelRoutes.Use(middleware.OptionalAuth(jwt.NewJWT(s.jwtSecret(), 0)))
+					}
+					// 单次请求的生成参数覆盖（查询参数），优先级高于小说设置、用户设置和系统默认值
+					novelRoutes.Use(middleware.GenerationOverrides())
+					novelRoutes.Use(apiLimit)
+
 					// 小说管理接口
-					v1.POST("/novels", novelHdl.CreateNovel)
-					v1.GET("/novels/:novel_id", novelHdl.GetNovel)
+					novelRoutes.POST("/novels", novelHdl.CreateNovel)
+					novelRoutes.GET("/novels", novelHdl.ListNovels)
+					novelRoutes.GET("/novels/:novel_id", novelHdl.GetNovel)
+					novelRoutes.DELETE("/novels/:novel_id", ownerGuard, novelHdl.DeleteNovel)
+
+					// 小说封面接口
+					novelRoutes.POST("/novels/:novel_id/covers/generate", generationLimit, taskLog, imageGuard, novelHdl.GenerateNovelCovers)
+					novelRoutes.POST("/novels/:novel_id/covers", novelHdl.UploadNovelCover)
+					novelRoutes.GET("/novels/:novel_id/covers", novelHdl.ListNovelCovers)
+					novelRoutes.POST("/novels/:novel_id/covers/:cover_id/select", novelHdl.SelectNovelCover)
+					novelRoutes.DELETE("/novels/:novel_id/covers/:cover_id", novelHdl.DeleteNovelCover)
+
+					// 跨章节回顾合集（横版，独立于章节视频版本）
+					novelRoutes.POST("/novels/:novel_id/compilations", generationLimit, taskLog, llmGuard, ttsGuard, novelHdl.CreateCompilation)
+					novelRoutes.GET("/novels/:novel_id/compilations", novelHdl.ListCompilations)
+					novelRoutes.GET("/compilations/:compilation_id", novelHdl.GetCompilation)
+
+					// 长视频（按章节顺序拼接章节发布版本的最终视频，带标题画面和章节标记）
+					novelRoutes.POST("/novels/:novel_id/novel-videos", generationLimit, taskLog, novelHdl.AssembleNovelVideo)
+					novelRoutes.GET("/novels/:novel_id/novel-videos", novelHdl.ListNovelVideos)
 
 					// 章节管理接口
-					v1.POST("/novels/:novel_id/chapters/split", novelHdl.SplitChapters)
-					v1.GET("/novels/:novel_id/chapters", novelHdl.GetChapters)
+					novelRoutes.POST("/novels/:novel_id/chapters/split", novelHdl.SplitChapters)
+					novelRoutes.GET("/novels/:novel_id/chapters", novelHdl.GetChapters)
+					// 章节审核始终要求登录，由处理器检查审核人员或管理员角色（审核人员可以审核所有小说，不按小说权限检查）
+					reviewRoutes := v1.Group("", middleware.Auth(jwt.NewJWT(s.jwtSecret(), 0)), apiLimit)
+					reviewRoutes.POST("/novels/chapters/:chapter_id/approve", novelHdl.ApproveChapter)
+					novelRoutes.GET("/novels/chapters/:chapter_id/summary", novelHdl.GetChapterSummary)
+					novelRoutes.DELETE("/novels/chapters/:chapter_id", novelHdl.DeleteChapter)
+					novelRoutes.GET("/novels/chapters/:chapter_id/artifacts", novelHdl.ListChapterArtifacts)
+					novelRoutes.DELETE("/novels/chapters/:chapter_id/artifacts", novelHdl.DeleteChapterArtifacts)
+					// 章节分支：分支接口中的 chapter_id 为分支的章节ID
+					novelRoutes.POST("/novels/chapters/:chapter_id/branches", novelHdl.ForkChapter)
+					novelRoutes.GET("/novels/chapters/:chapter_id/branches", novelHdl.ListChapterBranches)
+					novelRoutes.GET("/novels/chapters/:chapter_id/branch/compare", novelHdl.CompareChapterBranch)
+					novelRoutes.POST("/novels/chapters/:chapter_id/branch/merge", novelHdl.MergeChapterBranch)
+					novelRoutes.POST("/novels/chapters/:chapter_id/branch/discard", novelHdl.DiscardChapterBranch)
+					novelRoutes.GET("/novels/chapters/:chapter_id/qa-scorecard", novelHdl.GetChapterQAScorecard)
+					novelRoutes.POST("/novels/chapters/:chapter_id/content-risk/scan", novelHdl.ScanChapterContentRisk)
+					novelRoutes.GET("/novels/chapters/:chapter_id/content-risk-reports", novelHdl.ListChapterContentRiskReports)
+					novelRoutes.POST("/novels/content-risk-reports/:report_id/acknowledge", novelHdl.AcknowledgeContentRisk)
+					novelRoutes.POST("/novels/:novel_id/promote-versions", novelHdl.PromoteNovelVersions)
+					novelRoutes.POST("/novels/:novel_id/promotions/:batch_id/rollback", novelHdl.RollbackPromotionBatch)
+					novelRoutes.GET("/novels/:novel_id/promotions/events", novelHdl.StreamPromotionEvents)
+					novelRoutes.GET("/novels/chapters/:chapter_id/promotions", novelHdl.ListChapterPromotions)
+					novelRoutes.POST("/novels/chapters/:chapter_id/promotions/rollback", novelHdl.RollbackChapterPromotion)
+
+					// 最终视频公证：发布时自动登记最终视频的 SHA256，客户可用交付的文件或哈希验证
+					novelRoutes.POST("/novels/chapters/:chapter_id/notarizations", novelHdl.NotarizeChapterVideos)
+					novelRoutes.GET("/novels/chapters/:chapter_id/notarizations", novelHdl.ListChapterNotarizations)
+					novelRoutes.POST("/notarizations/verify", novelHdl.VerifyNotarization)
+					novelRoutes.GET("/notarizations/chain", adminGuard, novelHdl.VerifyNotarizationChain)
+
+					// 协作授权接口（只允许小说所有者操作）
+					novelRoutes.POST("/novels/:novel_id/grants", ownerGuard, novelHdl.GrantNovelAccess)
+					novelRoutes.GET("/novels/:novel_id/grants", ownerGuard, novelHdl.ListNovelGrants)
+					novelRoutes.DELETE("/novels/:novel_id/grants/:user_id", ownerGuard, novelHdl.RevokeNovelAccess)
 
 					// 解说管理接口
-					v1.POST("/novels/chapters/:chapter_id/narration", novelHdl.GenerateNarration)
-					v1.POST("/novels/chapters/:chapter_id/narration/manual", novelHdl.CreateNarrationVersionManual)
-					v1.POST("/novels/:novel_id/chapters/narration", novelHdl.GenerateNarrationsForAllChapters)
-					v1.GET("/novels/chapters/:chapter_id/narration", novelHdl.GetNarration)
-					v1.GET("/novels/chapters/:chapter_id/narration/version/:version", novelHdl.GetNarrationByVersion)
-					v1.GET("/novels/chapters/:chapter_id/narration/versions", novelHdl.GetNarrationVersions)
-					v1.GET("/novels/chapters/:chapter_id/narrations", novelHdl.ListNarrationsByChapterID)
-					v1.PUT("/narrations/:narration_id/version", novelHdl.SetNarrationVersion)
+					novelRoutes.POST("/novels/chapters/:chapter_id/narration", generationLimit, taskLog, llmGuard, novelHdl.GenerateNarration)
+					novelRoutes.POST("/novels/chapters/:chapter_id/narration/manual", novelHdl.CreateNarrationVersionManual)
+					novelRoutes.POST("/novels/:novel_id/chapters/narration", generationLimit, taskLog, llmGuard, novelHdl.GenerateNarrationsForAllChapters)
+					novelRoutes.GET("/novels/chapters/:chapter_id/narration", novelHdl.GetNarration)
+					novelRoutes.GET("/novels/chapters/:chapter_id/narration/version/:version", novelHdl.GetNarrationByVersion)
+					novelRoutes.GET("/novels/chapters/:chapter_id/narration/versions", novelHdl.GetNarrationVersions)
+					novelRoutes.GET("/novels/chapters/:chapter_id/narrations", novelHdl.ListNarrationsByChapterID)
+					novelRoutes.PUT("/narrations/:narration_id/version", novelHdl.SetNarrationVersion)
+					novelRoutes.POST("/narrations/:narration_id/regenerate", generationLimit, taskLog, llmGuard, novelHdl.RegenerateNarrationWithFeedback)
+					novelRoutes.GET("/narrations/:narration_id/validation", novelHdl.ValidateNarration)
+					novelRoutes.PATCH("/narrations/:narration_id", novelHdl.PatchNarration)
+					novelRoutes.GET("/narrations/:narration_id/patches", novelHdl.ListNarrationPatches)
 
 					// 解说内容（场景/镜头）查询接口（用于人工编辑/比对）
-					v1.GET("/narrations/:narration_id/scenes", novelHdl.GetScenesByNarration)
-					v1.GET("/narrations/:narration_id/shots", novelHdl.GetShotsByNarration)
+					novelRoutes.GET("/narrations/:narration_id/scenes", novelHdl.GetScenesByNarration)
+					novelRoutes.GET("/narrations/:narration_id/shots", novelHdl.GetShotsByNarration)
+					novelRoutes.GET("/narrations/:narration_id/events", novelHdl.StreamNarrationEvents)
 
 					// 分镜头管理接口
-					v1.PUT("/shots/:shot_id", novelHdl.UpdateShot)
-					v1.POST("/shots/:shot_id/regenerate", novelHdl.RegenerateShotScript)
+					novelRoutes.PUT("/shots/:shot_id", novelHdl.UpdateShot)
+					novelRoutes.POST("/shots/:shot_id/regenerate", generationLimit, taskLog, regenOverride, novelHdl.RegenerateShotScript)
+					novelRoutes.PUT("/narrations/:narration_id/scenes/order", novelHdl.ReorderScenes)
+					novelRoutes.PUT("/scenes/:scene_id/shots/order", novelHdl.ReorderShots)
+					novelRoutes.POST("/shots/:shot_id/clip-previews", generationLimit, taskLog, videoGuard, regenOverride, novelHdl.PreviewShotClip)
+					novelRoutes.GET("/shots/:shot_id/clip-previews/:preview_id", novelHdl.GetShotClipPreview)
+					novelRoutes.POST("/shots/:shot_id/clip-previews/:preview_id/confirm", novelHdl.ConfirmShotClipPreview)
+					novelRoutes.DELETE("/shots/:shot_id/clip-previews/:preview_id", novelHdl.DiscardShotClipPreview)
+					novelRoutes.GET("/narrations/:narration_id/regenerations", novelHdl.ListNarrationRegenerations)
 
 					// 音频生成接口
-					v1.POST("/narrations/:narration_id/audios", novelHdl.GenerateAudios)
-					v1.GET("/narrations/:narration_id/audios", novelHdl.ListAudiosByNarration)
-					v1.GET("/narrations/:narration_id/audios/versions", novelHdl.GetAudioVersions)
+					novelRoutes.POST("/narrations/:narration_id/audios", generationLimit, taskLog, ttsGuard, novelHdl.GenerateAudios)
+					novelRoutes.GET("/narrations/:narration_id/audios", novelHdl.ListAudiosByNarration)
+					novelRoutes.GET("/narrations/:narration_id/audios/versions", novelHdl.GetAudioVersions)
+					novelRoutes.POST("/narrations/:narration_id/audios/incremental", generationLimit, taskLog, ttsGuard, novelHdl.RegenerateEditedAudios)
 
 					// 字幕生成接口
-					v1.POST("/narrations/:narration_id/subtitles", novelHdl.GenerateSubtitles)
-					v1.GET("/narrations/:narration_id/subtitles", novelHdl.ListSubtitlesByNarration)
-					v1.GET("/novels/chapters/:chapter_id/subtitles/versions", novelHdl.GetSubtitleVersions)
+					novelRoutes.POST("/narrations/:narration_id/subtitles", generationLimit, taskLog, novelHdl.GenerateSubtitles)
+					novelRoutes.GET("/narrations/:narration_id/subtitles", novelHdl.ListSubtitlesByNarration)
+					novelRoutes.GET("/novels/chapters/:chapter_id/subtitles/versions", novelHdl.GetSubtitleVersions)
+					novelRoutes.POST("/novels/chapters/:chapter_id/subtitles/import", generationLimit, taskLog, novelHdl.ImportSubtitles)
+					novelRoutes.PUT("/novels/chapters/:chapter_id/subtitles/selection", novelHdl.SelectSubtitleVersion)
 
 					// 图片生成接口
-					v1.POST("/narrations/:narration_id/images", novelHdl.GenerateImages)
-					v1.GET("/narrations/:narration_id/images", novelHdl.ListImagesByNarration)
-					v1.GET("/novels/chapters/:chapter_id/images/versions", novelHdl.GetImageVersions)
-					v1.POST("/novels/:novel_id/characters/images", novelHdl.GenerateCharacterImages)
-					v1.POST("/narrations/:narration_id/scenes/images", novelHdl.GenerateSceneImages)
-					v1.POST("/novels/:novel_id/props/images", novelHdl.GeneratePropImages)
+					novelRoutes.POST("/narrations/:narration_id/images", generationLimit, taskLog, imageGuard, novelHdl.GenerateImages)
+					novelRoutes.GET("/narrations/:narration_id/images", novelHdl.ListImagesByNarration)
+					novelRoutes.GET("/novels/chapters/:chapter_id/images/versions", novelHdl.GetImageVersions)
+					novelRoutes.POST("/novels/:novel_id/characters/images", generationLimit, taskLog, imageGuard, novelHdl.GenerateCharacterImages)
+					novelRoutes.POST("/narrations/:narration_id/scenes/images", generationLimit, taskLog, imageGuard, novelHdl.GenerateSceneImages)
+					novelRoutes.POST("/scenes/:scene_id/images/variants", generationLimit, taskLog, imageGuard, regenOverride, novelHdl.GenerateSceneImageVariants)
+					novelRoutes.GET("/scenes/:scene_id/images/variants", novelHdl.ListSceneImageVariants)
+					novelRoutes.POST("/novels/:novel_id/props/images", generationLimit, taskLog, imageGuard, novelHdl.GeneratePropImages)
+					novelRoutes.POST("/novels/:novel_id/images/preview", generationLimit, taskLog, imageGuard, novelHdl.PreviewImage)
+
+					// 风格参考图接口
+					novelRoutes.POST("/novels/:novel_id/style-references", novelHdl.UploadStyleReference)
+					novelRoutes.GET("/novels/:novel_id/style-references", novelHdl.ListStyleReferences)
+					novelRoutes.DELETE("/style-references/:reference_id", novelHdl.DeleteStyleReference)
+
+					// 发音词典接口（纠正人名、功法名等的 TTS 读音）
+					novelRoutes.POST("/novels/:novel_id/pronunciations", novelHdl.UpsertPronunciation)
+					novelRoutes.GET("/novels/:novel_id/pronunciations", novelHdl.ListPronunciations)
+					novelRoutes.DELETE("/pronunciations/:entry_id", novelHdl.DeletePronunciation)
+					novelRoutes.POST("/novels/:novel_id/pronunciations/test", generationLimit, taskLog, ttsGuard, novelHdl.TestPronunciation)
+
+					// 素材预热接口（定时渲染前把素材下载到本地缓存）
+					novelRoutes.POST("/novels/:novel_id/prewarm-jobs", novelHdl.SchedulePrewarm)
+					novelRoutes.GET("/novels/:novel_id/prewarm-jobs", novelHdl.ListPrewarmJobs)
+					novelRoutes.GET("/prewarm-jobs/:prewarm_job_id", novelHdl.GetPrewarmJob)
+
+					// 下一章预生成接口（审核当前章节时提前生成下一章的摘要和解说草稿）
+					novelRoutes.PUT("/novels/:novel_id/standby-settings", novelHdl.SetStandbySettings)
+					novelRoutes.POST("/novels/chapters/:chapter_id/standby", generationLimit, taskLog, llmGuard, novelHdl.StartStandby)
+					novelRoutes.GET("/novels/:novel_id/standby-jobs", novelHdl.ListStandbyJobs)
+					novelRoutes.GET("/standby-jobs/:standby_job_id", novelHdl.GetStandbyJob)
+					novelRoutes.POST("/standby-jobs/:standby_job_id/cancel", novelHdl.CancelStandbyJob)
+
+					// 任务通知接口（生成任务完成或失败时推送签名的 webhook）
+					novelRoutes.POST("/webhooks", novelHdl.CreateWebhook)
+					novelRoutes.GET("/webhooks", novelHdl.ListWebhooks)
+					novelRoutes.DELETE("/webhooks/:webhook_id", novelHdl.DeleteWebhook)
+					novelRoutes.GET("/webhooks/:webhook_id/deliveries", novelHdl.ListWebhookDeliveries)
+
+					// 异步任务队列接口（提交和列表按章节/小说路径定位所属小说，由小说权限中间件检查编辑/只读权限）
+					novelRoutes.POST("/novels/chapters/:chapter_id/jobs", novelHdl.EnqueueJob)
+					novelRoutes.GET("/novels/chapters/:chapter_id/jobs", novelHdl.ListJobs)
+					novelRoutes.GET("/novels/:novel_id/jobs", novelHdl.ListJobs)
+					novelRoutes.GET("/jobs/:job_id", novelHdl.GetJob)
+					novelRoutes.POST("/jobs/:job_id/cancel", novelHdl.CancelJob)
+					novelRoutes.POST("/jobs/:job_id/retry", novelHdl.RetryJob)
+					// 任务队列工作节点管理（维护前隔离节点并迁移执行中的任务，仅管理员）
+					novelRoutes.GET("/job-workers", adminGuard, novelHdl.ListJobWorkers)
+					novelRoutes.POST("/job-workers/:worker_id/cordon", adminGuard, novelHdl.CordonJobWorker)
+					novelRoutes.POST("/job-workers/:worker_id/uncordon", adminGuard, novelHdl.UncordonJobWorker)
+
+					// 数字写法设置（TTS 和字幕生成前统一转换）
+					novelRoutes.PUT("/novels/:novel_id/number-style", novelHdl.SetNumberStyle)
+					novelRoutes.PUT("/novels/:novel_id/continuity", novelHdl.SetChapterContinuity)
+
+					// 新用户开通（按预设生成创作默认配置，创建小说时复制）
+					novelRoutes.GET("/onboarding/presets", novelHdl.ListOnboardingPresets)
+					novelRoutes.POST("/onboarding", novelHdl.OnboardCreator)
+					novelRoutes.GET("/onboarding/profile", novelHdl.GetCreatorProfile)
+
+					// 工作流模板接口（按内容风格打包流程配置，创建小说时选用；增删改仅管理员）
+					novelRoutes.GET("/workflow-templates", novelHdl.ListWorkflowTemplates)
+					novelRoutes.GET("/workflow-templates/:name", novelHdl.GetWorkflowTemplate)
+					novelRoutes.POST("/workflow-templates", adminGuard, novelHdl.CreateWorkflowTemplate)
+					novelRoutes.PUT("/workflow-templates/:name", adminGuard, novelHdl.UpdateWorkflowTemplate)
+					novelRoutes.DELETE("/workflow-templates/:name", adminGuard, novelHdl.DeleteWorkflowTemplate)
+
+					// 生成参数（系统默认 → 用户设置 → 小说设置 → 请求覆盖 逐层合并）
+					novelRoutes.GET("/users/generation-settings", novelHdl.GetUserGenerationSettings)
+					novelRoutes.PUT("/users/generation-settings", novelHdl.SetUserGenerationSettings)
+					novelRoutes.GET("/novels/:novel_id/generation-settings", novelHdl.GetNovelGenerationSettings)
+					novelRoutes.PUT("/novels/:novel_id/generation-settings", novelHdl.SetNovelGenerationSettings)
+
+					// 创作设定（题材、基调、受众、禁用话题，生成提示词时注入）
+					novelRoutes.PUT("/novels/:novel_id/metadata", novelHdl.SetCreativeMetadata)
+					novelRoutes.GET("/novels/:novel_id/metadata", novelHdl.GetCreativeMetadata)
+
+					// 原作署名（授权作品要求在最终视频中展示的署名）
+					novelRoutes.PUT("/novels/:novel_id/attribution", novelHdl.SetAttribution)
+					novelRoutes.GET("/novels/:novel_id/attribution", novelHdl.GetAttribution)
+
+					// 解说结构模板（场景数、每个场景的镜头数、目标时长）
+					novelRoutes.GET("/novels/:novel_id/narration-structure", novelHdl.GetNarrationStructure)
+					novelRoutes.PUT("/novels/:novel_id/narration-structure", novelHdl.SetNarrationStructure)
+
+					// 预算接口
+					novelRoutes.PUT("/novels/:novel_id/budget", novelHdl.SetNovelBudget)
+					novelRoutes.GET("/novels/:novel_id/budget", novelHdl.GetNovelBudget)
+					novelRoutes.GET("/novels/:novel_id/budget/costs", novelHdl.ListCostRecords)
+					novelRoutes.GET("/novels/:novel_id/budget/events", novelHdl.ListBudgetEvents)
+					novelRoutes.GET("/novels/:novel_id/regeneration-overrides", novelHdl.ListRegenerationOverrides)
 
 					// 角色管理接口
-					v1.POST("/novels/:novel_id/characters/sync", novelHdl.SyncCharacters)
-					v1.GET("/novels/:novel_id/characters", novelHdl.GetCharactersByNovelID)
-					v1.GET("/novels/:novel_id/characters/:name", novelHdl.GetCharacterByName)
+					novelRoutes.POST("/novels/:novel_id/characters/sync", novelHdl.SyncCharacters)
+					novelRoutes.GET("/novels/:novel_id/characters", novelHdl.GetCharactersByNovelID)
+					novelRoutes.GET("/novels/:novel_id/characters/:name", novelHdl.GetCharacterByName)
+
+					// 角色选角（按角色描述推荐配音音色，接受建议或手动指定）
+					novelRoutes.GET("/novels/:novel_id/voice-catalog", novelHdl.ListVoiceCatalog)
+					novelRoutes.POST("/novels/:novel_id/characters/voice-suggestions", novelHdl.SuggestCharacterVoices)
+					novelRoutes.PUT("/novels/:novel_id/characters/:name/voice", novelHdl.SetCharacterVoice)
 
 					// 视频生成接口
-					v1.POST("/novels/chapters/:chapter_id/videos/narration", novelHdl.GenerateNarrationVideos)
-					v1.POST("/novels/chapters/:chapter_id/videos/final", novelHdl.GenerateFinalVideo)
+					novelRoutes.POST("/novels/chapters/:chapter_id/videos/narration", generationLimit, taskLog, videoGuard, novelHdl.GenerateNarrationVideos)
+					novelRoutes.POST("/novels/chapters/:chapter_id/videos/final", generationLimit, taskLog, novelHdl.GenerateFinalVideo)
+					novelRoutes.GET("/novels/chapters/:chapter_id/licenses", novelHdl.ListLicenseGrantsByChapter)
+					novelRoutes.GET("/novels/chapters/:chapter_id/render-breakdown", novelHdl.GetRenderBreakdown)
+					novelRoutes.GET("/novels/chapters/:chapter_id/pipeline", novelHdl.GetChapterPipeline)
+					novelRoutes.GET("/novels/chapters/:chapter_id/pipeline/events", novelHdl.ListChapterEvents)
+					novelRoutes.GET("/novels/chapters/:chapter_id/pipeline/graph", novelHdl.GetChapterPipelineGraph)
+					novelRoutes.POST("/novels/chapters/:chapter_id/pipeline", novelHdl.RunChapterPipeline)
+					novelRoutes.GET("/novels/chapters/:chapter_id/pipeline/run", novelHdl.GetChapterPipelineRun)
+					novelRoutes.POST("/novels/chapters/:chapter_id/pipeline/failure-explanation", novelHdl.ExplainChapterFailure)
+					novelRoutes.GET("/novels/chapters/:chapter_id/regeneration-impact", novelHdl.GetRegenerationImpact)
+
+					// 朗读时长估算和无声分镜预览（生成配音前检查节奏，不调用 TTS）
+					novelRoutes.GET("/novels/chapters/:chapter_id/reading-time", novelHdl.EstimateChapterReadingTime)
+					novelRoutes.POST("/novels/chapters/:chapter_id/storyboard-preview", generationLimit, taskLog, novelHdl.RenderStoryboardPreview)
 
 					// 视频查询接口
-					v1.GET("/novels/chapters/:chapter_id/videos", novelHdl.ListVideosByChapter)
-					v1.GET("/novels/chapters/:chapter_id/videos/versions", novelHdl.GetVideoVersions)
-					v1.GET("/videos", novelHdl.GetVideosByStatus)
+					novelRoutes.GET("/novels/chapters/:chapter_id/videos", novelHdl.ListVideosByChapter)
+					novelRoutes.GET("/novels/chapters/:chapter_id/videos/versions", novelHdl.GetVideoVersions)
+					novelRoutes.GET("/novels/chapters/:chapter_id/videos/:video_id/manifest", novelHdl.GetVideoManifest)
+					novelRoutes.GET("/novels/chapters/:chapter_id/video-tasks", novelHdl.ListChapterVideoTasks)
+					// 按用户查询默认查询当前登录用户，查询其他用户需要管理员/审核员角色（接口内检查）
+					novelRoutes.GET("/videos", novelHdl.ListVideoTasks)
+
+					// 背景音乐曲库：合成最终视频时按场景情绪选择曲目，曲库为所有小说共用，修改需要管理员/审核员角色
+					novelRoutes.POST("/bgm-tracks", allNovelsGuard, novelHdl.UploadBGMTrack)
+					novelRoutes.GET("/bgm-tracks", novelHdl.ListBGMTracks)
+					novelRoutes.DELETE("/bgm-tracks/:track_id", allNovelsGuard, novelHdl.DeleteBGMTrack)
+
+					// 章节背景音乐选择：整章使用指定曲目或不加背景音乐（未设置时按场景情绪自动选择）
+					novelRoutes.PUT("/novels/chapters/:chapter_id/bgm", novelHdl.SetChapterBGM)
+					novelRoutes.DELETE("/novels/chapters/:chapter_id/bgm", novelHdl.ClearChapterBGM)
+
+					// 失败产物清理：按小说清理需要所有者权限，跨小说清理需要管理员/审核员角色
+					novelRoutes.POST("/novels/:novel_id/artifacts/failed/cleanup", ownerGuard, novelHdl.CleanupFailedArtifacts)
+					novelRoutes.POST("/artifacts/failed/cleanup", allNovelsGuard, novelHdl.CleanupFailedArtifacts)
+
+					// 生成失败分类统计（按天、按 provider 汇总所有小说的失败），需要管理员/审核员角色
+					novelRoutes.GET("/failure-stats", allNovelsGuard, novelHdl.GetFailureStats)
+
+					// 单镜头预览耗时统计（所有小说的 p50/p95），需要管理员/审核员角色
+					novelRoutes.GET("/shot-clip-previews/latency-stats", allNovelsGuard, novelHdl.GetShotClipPreviewLatencyStats)
+
+					// 分析数据导出（任务、用量、费用、QA 评分按批次增量导出为 JSON Lines，供数仓加载），仅管理员
+					novelRoutes.POST("/analytics/exports", adminGuard, novelHdl.ExportAnalytics)
+					novelRoutes.GET("/analytics/exports", adminGuard, novelHdl.ListAnalyticsExportBatches)
+					novelRoutes.GET("/analytics/schemas", adminGuard, novelHdl.GetAnalyticsSchemas)
+
+					// v2 只读接口：响应经 DTO 转换，字段契约稳定（snake_case、RFC3339 时间、统一列表结构）
+					novelV2Hdl := novelV2Handler.NewHandler(novelSvc)
+					novelV2Routes := s.engine.Group("/api/v2")
+					if s.cfg.Auth.EnforceNovelAccess {
+						novelV2Routes.Use(middleware.Auth(jwt.NewJWT(s.jwtSecret(), 0)), middleware.NovelAccess(novelSvc))
+					} else {
+						novelV2Routes.Use(middleware.OptionalAuth(jwt.NewJWT(s.jwtSecret(), 0)))
+					}
+					novelV2Routes.Use(apiLimit)
+					novelV2Routes.GET("/novels/:novel_id", novelV2Hdl.GetNovel)
+					novelV2Routes.GET("/novels/:novel_id/chapters", novelV2Hdl.ListChapters)
+					novelV2Routes.GET("/chapters/:chapter_id/narrations", novelV2Hdl.ListNarrations)
+					novelV2Routes.GET("/chapters/:chapter_id/videos", novelV2Hdl.ListVideos)
+					novelV2Routes.GET("/narrations/:narration_id", novelV2Hdl.GetNarration)
+					novelV2Routes.GET("/narrations/:narration_id/audios", novelV2Hdl.ListAudios)
+					novelV2Routes.GET("/narrations/:narration_id/subtitles", novelV2Hdl.ListSubtitles)
+					novelV2Routes.GET("/narrations/:narration_id/images", novelV2Hdl.ListImages)
 				}
 			}
 		} else {
 			log.Warn().Msg("MongoDB not configured, novel endpoints disabled")
 		}
+
+		// Resource 接口（资源管理）
+		// 开启小说权限时资源接口要求登录，按资源上传者和引用资源的小说检查权限（需要 NovelService）
+		if s.mongo != nil && (!s.cfg.Auth.EnforceNovelAccess || novelAccess != nil) {
+			// 初始化 ResourceService（需要 storage）
+			storage, err := s.getStorage()
+			if err != nil {
+				log.Warn().Err(err).Msg("failed to initialize storage, resource endpoints disabled")
+			} else {
+				resourceSvc := service.NewResourceService(s.mongo.Database(), storage, service.WithURLPolicies(s.urlPolicies()))
+				var resourceAccess resourceHandler.AccessChecker
+				resourceRoutes := v1.Group("")
+				if s.cfg.Auth.EnforceNovelAccess {
+					resourceAccess = novelAccess
+					resourceRoutes.Use(middleware.Auth(jwt.NewJWT(s.jwtSecret(), 0)), middleware.ResourceAccess(novelAccess))
+				}
+				resourceHdl := resourceHandler.NewHandler(resourceSvc, resourceAccess, s.cfg.Storage.MaxUploadSize)
+				if replicator != nil {
+					s.storageReconciler = resourceSvc
+				}
+
+				// 资源管理接口
+				resourceRoutes.POST("/resources/upload", resourceHdl.UploadFile)
+				resourceRoutes.GET("/resources", resourceHdl.ListResources)
+				resourceRoutes.GET("/resources/:resource_id", resourceHdl.GetResource)
+				resourceRoutes.GET("/resources/:resource_id/download", resourceHdl.DownloadFile)
+				resourceRoutes.GET("/resources/:resource_id/download-url", resourceHdl.GetDownloadURL)
+				resourceRoutes.GET("/resources/:resource_id/download-manifest", resourceHdl.GetDownloadManifest)
+				resourceRoutes.POST("/resources/download-tar", resourceHdl.DownloadTar)
+
+				// 资源回收接口（开启小说权限时只允许管理员访问）
+				resourceGCSvc := service.NewResourceGCService(s.mongo.Database(), storage, &s.cfg.ResourceGC)
+				if s.cfg.ResourceGC.Enabled {
+					s.resourceGC = resourceGCSvc
+				}
+				gcRoutes := v1.Group("")
+				if s.cfg.Auth.EnforceNovelAccess {
+					gcRoutes.Use(middleware.Auth(jwt.NewJWT(s.jwtSecret(), 0)), middleware.RequireRole(auth.RoleAdmin))
+				}
+				gcRoutes.POST("/resources/gc", resourceHandler.NewGCHandler(resourceGCSvc).CollectGarbage)
+			}
+		} else {
+			log.Warn().Msg("MongoDB or NovelService not available, resource endpoints disabled")
+		}
+
+		// Embed 接口（合作方嵌入播放）
+		// 管理接口挂在 /api/v1 下；合作方使用的公开只读接口挂在 /embed/v1 下，使用嵌入令牌认证，跨域和限流独立配置
+		// 令牌管理接口始终要求登录（与是否开启小说权限无关），创建令牌时按小说权限检查创建人
+		if s.mongo != nil && novelAccess != nil {
+			storage, err := s.getStorage()
+			if err != nil {
+				log.Warn().Err(err).Msg("failed to initialize storage, embed endpoints disabled")
+			} else {
+				db := s.mongo.Database()
+				embedSvc := service.NewEmbedService(db, service.NewResourceService(db, storage, service.WithURLPolicies(s.urlPolicies())), novelAccess, &s.cfg.Embed)
+				embedHdl := embedHandler.NewHandler(embedSvc)
+
+				// 嵌入令牌管理接口
+				tokenRoutes := v1.Group("", middleware.Auth(jwt.NewJWT(s.jwtSecret(), 0)))
+				tokenRoutes.POST("/embed-tokens", embedHdl.CreateToken)
+				tokenRoutes.GET("/embed-tokens", embedHdl.ListTokens)
+				tokenRoutes.DELETE("/embed-tokens/:token_id", embedHdl.RevokeToken)
+
+				// 公开只读接口：预检请求由 EmbedCORS 直接响应，其余请求需要通过令牌认证、来源校验和限流
+				embedV1 := s.engine.Group("/embed/v1", middleware.EmbedCORS())
+				embedV1.OPTIONS("/*path")
+
+				embedAuthed := embedV1.Group("", middleware.EmbedAuth(embedSvc, ratelimit.New()))
+				embedAuthed.GET("/chapters/:chapter_id", embedHdl.GetChapter)
+				embedAuthed.GET("/chapters/:chapter_id/video", embedHdl.GetVideo)
+				embedAuthed.GET("/chapters/:chapter_id/subtitles", embedHdl.GetSubtitles)
+				embedAuthed.GET("/chapters/:chapter_id/thumbnails", embedHdl.GetThumbnails)
+			}
+		} else {
+			log.Warn().Msg("MongoDB or NovelService not available, embed endpoints disabled")
+		}
 	}
 }
 
+// jwtSecret 返回 JWT 密钥，未配置时使用默认值
+func (s *Server) jwtSecret() string {
+	if s.cfg.Auth.JWTSecret == "" {
+		return "default-secret-key-change-in-production"
+	}
+	return s.cfg.Auth.JWTSecret
+}
+
+// getStorage 创建存储实例，各模块共用同一个实例
+func (s *Server) getStorage() (storage.Storage, error) {
+	s.storageOnce.Do(func() {
+		s.storage, s.storageErr = storagefactory.NewStorage(context.Background(), &s.cfg.Storage)
+	})
+	return s.storage, s.storageErr
+}
+
+// urlPolicies 返回预签名URL有效期策略：默认策略叠加 storage.url_lifetimes 配置
+func (s *Server) urlPolicies() presign.Policies {
+	policies := presign.DefaultPolicies()
+	for purpose, lifetime := range s.cfg.Storage.URLLifetimes {
+		policies.Set(presign.Purpose(purpose), presign.Policy{
+			Default:       lifetime.Default,
+			Max:           lifetime.Max,
+			RefreshBefore: lifetime.RefreshBefore,
+		})
+	}
+	return policies
+}
+
+// runStorageReconciler 定时对账主备存储
+// 首次对账检查全部资源，之后只检查上次对账开始前一分钟以来更新过的资源
+func (s *Server) runStorageReconciler(ctx context.Context) {
+	interval := s.cfg.Storage.Replication.ReconcileInterval
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var since time.Time
+	for {
+		startedAt := time.Now()
+		if _, err := s.storageReconciler.ReconcileStorage(ctx, since); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Warn().Err(err).Msg("storage reconcile failed")
+		} else {
+			since = startedAt.Add(-time.Minute)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runResourceGC 定时回收没有被引用的资源
+func (s *Server) runResourceGC(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.ResourceGC.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		_, err := s.resourceGC.Collect(ctx, &service.ResourceGCRequest{DryRun: s.cfg.ResourceGC.DryRun})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Warn().Err(err).Msg("resource gc failed")
+		}
+	}
+}
+
+// rateLimitMiddlewares 返回接口限流中间件（按请求方法区分查询/修改类、生成类，另外按配置对单个接口限流），未开启限流时返回占位中间件
+// 配置了 Redis 时多个实例共享令牌桶，否则每个实例各自限流
+func (s *Server) rateLimitMiddlewares() (gin.HandlerFunc, gin.HandlerFunc) {
+	cfg := s.cfg.RateLimit
+	if !cfg.Enabled {
+		return passThrough, passThrough
+	}
+
+	var backend ratelimit.Backend = ratelimit.New()
+	if s.redis != nil {
+		backend = ratelimit.NewRedis(s.redis.Client(), "lemon:ratelimit:")
+	}
+	policy := middleware.RateLimitPolicy{
+		Limits: map[middleware.RateLimitClass]int{
+			middleware.RateLimitClassRead:       cfg.ReadPerMinute,
+			middleware.RateLimitClassWrite:      cfg.WritePerMinute,
+			middleware.RateLimitClassGeneration: cfg.GenerationPerMinute,
+		},
+		ExemptUserIDs: cfg.ExemptUserIDs,
+	}
+	for _, role := range cfg.ExemptRoles {
+		policy.ExemptRoles = append(policy.ExemptRoles, auth.UserRole(role))
+	}
+	for _, cidr := range cfg.ExemptCIDRs {
+		// 配置校验时已检查格式
+		if _, ipNet, err := net.ParseCIDR(cidr); err == nil {
+			policy.ExemptNets = append(policy.ExemptNets, ipNet)
+		}
+	}
+	if len(cfg.Endpoints) > 0 {
+		policy.EndpointLimits = make(map[string]int, len(cfg.Endpoints))
+		for _, ep := range cfg.Endpoints {
+			policy.EndpointLimits[middleware.RateLimitEndpoint(ep.Method, ep.Path)] = ep.PerMinute
+		}
+	}
+
+	log.Info().
+		Bool("redis", s.redis != nil).
+		Int("read_per_minute", cfg.ReadPerMinute).
+		Int("write_per_minute", cfg.WritePerMinute).
+		Int("generation_per_minute", cfg.GenerationPerMinute).
+		Int("endpoints", len(cfg.Endpoints)).
+		Msg("rate limit enabled")
+	rl := middleware.NewRateLimiter(backend, policy)
+	return middleware.RateLimit(rl), middleware.GenerationRateLimit(rl)
+}
+
+// passThrough 不做任何检查的中间件（对应的检查未开启时占位）
+func passThrough(c *gin.Context) {
+	c.Next()
+}
+
+// jobWorkerShutdownTimeout 服务停止时等待任务队列工作协程退出的最长时间
+const jobWorkerShutdownTimeout = 30 * time.Second
+
 // Run 启动服务器
 func (s *Server) Run(ctx context.Context, addr string) error {
 	srv := &http.Server{
@@ -296,6 +964,22 @@ func (s *Server) Run(ctx context.Context, addr string) error {
 		WriteTimeout: s.cfg.Server.WriteTimeout,
 	}
 
+	// 配置了备用存储时定时对账
+	if s.storageReconciler != nil {
+		go s.runStorageReconciler(ctx)
+	}
+
+	// 开启资源回收时定时回收没有被引用的资源
+	if s.resourceGC != nil {
+		go s.runResourceGC(ctx)
+		log.Info().Dur("interval", s.cfg.ResourceGC.Interval).Bool("dry_run", s.cfg.ResourceGC.DryRun).Msg("resource gc started")
+	}
+
+	// 任务队列工作协程：ctx 取消时停止领取任务，执行中的任务放回队列
+	if s.jobQueue != nil {
+		s.jobQueue.StartJobWorkers(ctx)
+	}
+
 	// 启动服务器
 	errCh := make(chan error, 1)
 	go func() {
@@ -309,6 +993,15 @@ func (s *Server) Run(ctx context.Context, addr string) error {
 	case <-ctx.Done():
 		log.Info().Msg("shutting down server...")
 
+		// 等待执行中的任务放回队列后再关闭数据库连接（超时后未放回的任务在租约过期后被重新领取）
+		if s.jobQueue != nil {
+			waitCtx, cancel := context.WithTimeout(context.Background(), jobWorkerShutdownTimeout)
+			if err := s.jobQueue.WaitJobWorkers(waitCtx); err != nil {
+				log.Warn().Err(err).Msg("timed out waiting for job workers to stop")
+			}
+			cancel()
+		}
+
 		// 关闭连接
 		if s.mongo != nil {
 			if err := s.mongo.Close(context.Background()); err != nil {
@@ -320,6 +1013,9 @@ func (s *Server) Run(ctx context.Context, addr string) error {
 				log.Error().Err(err).Msg("failed to close Redis connection")
 			}
 		}
+		if rs, ok := s.storage.(*storage.ReplicatedStorage); ok {
+			rs.Close()
+		}
 
 		return srv.Shutdown(context.Background())
 	case err := <-errCh:
@@ -0,0 +1,483 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/config"
+	"lemon/internal/model/auth"
+	"lemon/internal/model/embed"
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/presign"
+	embedRepo "lemon/internal/repository/embed"
+	novelRepo "lemon/internal/repository/novel"
+)
+
+var (
+	ErrEmbedTokenInvalid        = errors.New("嵌入令牌无效或已失效")
+	ErrEmbedTokenNotFound       = errors.New("嵌入令牌不存在")
+	ErrInvalidEmbedTokenRequest = errors.New("无效的嵌入令牌参数")
+	ErrEmbedChapterNotFound     = errors.New("章节不存在或不在令牌授权范围内")
+	ErrEmbedMediaNotReady       = errors.New("章节媒体尚未生成")
+	ErrEmbedTokenAccessDenied   = errors.New("无权管理该嵌入令牌")
+)
+
+// embedTokenPrefix 嵌入令牌明文前缀（便于在日志和配置中识别令牌类型）
+const embedTokenPrefix = "emb_"
+
+// embedTouchInterval 令牌最近使用时间的更新间隔，避免每个请求都写库
+const embedTouchInterval = time.Minute
+
+// EmbedService 公开嵌入播放服务接口
+// 管理发放给合作方的嵌入令牌，并按令牌作用域提供章节元数据、视频播放地址、字幕和缩略图的只读访问
+type EmbedService interface {
+	// CreateToken 创建嵌入令牌，令牌明文只在返回结果中出现一次
+	CreateToken(ctx context.Context, req *CreateEmbedTokenRequest) (*CreateEmbedTokenResult, error)
+
+	// ListTokens 查询嵌入令牌（按创建时间倒序），管理员查询所有令牌，其他用户只查询自己创建的令牌
+	ListTokens(ctx context.Context, userID string, role auth.UserRole, limit int) ([]*embed.EmbedToken, error)
+
+	// RevokeToken 吊销嵌入令牌，吊销后立即失效；只有令牌创建人和管理员可以吊销，否则返回 ErrEmbedTokenAccessDenied
+	RevokeToken(ctx context.Context, tokenID, userID string, role auth.UserRole) error
+
+	// Authenticate 校验令牌明文，返回可用的令牌
+	Authenticate(ctx context.Context, plaintext string) (*embed.EmbedToken, error)
+
+	// AllowedOrigins 返回令牌允许的嵌入来源（令牌未配置时使用全局配置）
+	AllowedOrigins(token *embed.EmbedToken) []string
+
+	// RateLimitPerMinute 返回令牌每分钟请求上限（令牌未配置时使用全局配置，0 表示不限流）
+	RateLimitPerMinute(token *embed.EmbedToken) int
+
+	// GetChapter 获取章节元数据
+	GetChapter(ctx context.Context, token *embed.EmbedToken, chapterID string) (*EmbedChapter, error)
+
+	// GetVideo 获取章节最终视频的播放地址（优先授权版，其次预览版）
+	GetVideo(ctx context.Context, token *embed.EmbedToken, chapterID string) (*EmbedMedia, error)
+
+	// GetSubtitles 获取章节最新字幕的下载地址
+	GetSubtitles(ctx context.Context, token *embed.EmbedToken, chapterID string) (*EmbedMedia, error)
+
+	// GetThumbnails 获取章节最新版本镜头图片的缩略图地址（按镜头顺序）
+	GetThumbnails(ctx context.Context, token *embed.EmbedToken, chapterID string) ([]*EmbedThumbnail, error)
+}
+
+// NovelAccessChecker 小说权限检查（由小说服务实现），没有权限时返回的错误由实现方定义
+type NovelAccessChecker interface {
+	CheckNovelAccess(ctx context.Context, novelID, userID string, role auth.UserRole, required novel.NovelPermission) error
+}
+
+// CreateEmbedTokenRequest 创建嵌入令牌请求
+type CreateEmbedTokenRequest struct {
+	Name               string        // 令牌名称（合作方/用途说明）
+	NovelIDs           []string      // 可访问的小说ID（至少一个）
+	AllowedOrigins     []string      // 允许嵌入的来源（为空时使用全局配置）
+	RateLimitPerMinute int           // 每分钟请求上限（0 表示使用全局配置）
+	ExpiresIn          time.Duration // 有效期（0 表示不过期）
+	CreatedBy          string        // 创建人用户ID
+	CreatorRole        auth.UserRole // 创建人角色（用于检查小说权限）
+}
+
+// CreateEmbedTokenResult 创建嵌入令牌结果
+type CreateEmbedTokenResult struct {
+	Token      string            `json:"token"`       // 令牌明文（只返回一次，请妥善保存）
+	EmbedToken *embed.EmbedToken `json:"embed_token"` // 令牌信息
+}
+
+// EmbedChapter 公开的章节元数据
+type EmbedChapter struct {
+	ChapterID  string    `json:"chapter_id"`            // 章节ID
+	NovelID    string    `json:"novel_id"`              // 小说ID
+	NovelTitle string    `json:"novel_title,omitempty"` // 小说名称
+	Title      string    `json:"title"`                 // 章节标题
+	Sequence   int       `json:"sequence"`              // 章节序号
+	WordCount  int       `json:"word_count"`            // 章节字数
+	Duration   float64   `json:"duration,omitempty"`    // 最终视频时长（秒，尚未生成时为空）
+	HasVideo   bool      `json:"has_video"`             // 是否已有可播放的视频
+	UpdatedAt  time.Time `json:"updated_at"`            // 更新时间
+}
+
+// EmbedMedia 公开的媒体播放/下载地址
+type EmbedMedia struct {
+	ChapterID   string    `json:"chapter_id"`             // 章节ID
+	URL         string    `json:"url"`                    // 播放/下载地址（临时地址，过期后需重新获取）
+	ExpiresAt   time.Time `json:"expires_at"`             // 地址过期时间
+	ContentType string    `json:"content_type,omitempty"` // 文件类型
+	Version     int       `json:"version"`                // 版本号
+	Duration    float64   `json:"duration,omitempty"`     // 时长（秒，仅视频）
+	ExportTier  string    `json:"export_tier,omitempty"`  // 导出档位（仅视频）：preview, licensed
+	Format      string    `json:"format,omitempty"`       // 字幕格式（仅字幕）：ass, srt, vtt
+}
+
+// EmbedThumbnail 公开的镜头缩略图
+type EmbedThumbnail struct {
+	SceneNumber string    `json:"scene_number"` // 场景编号
+	ShotNumber  string    `json:"shot_number"`  // 镜头编号
+	Sequence    int       `json:"sequence"`     // 序号
+	URL         string    `json:"url"`          // 图片地址（临时地址）
+	ExpiresAt   time.Time `json:"expires_at"`   // 地址过期时间
+}
+
+// embedService 公开嵌入播放服务实现
+type embedService struct {
+	cfg             *config.EmbedConfig
+	resourceService ResourceService
+	novelAccess     NovelAccessChecker
+	tokenRepo       embedRepo.EmbedTokenRepository
+	novelRepo       novelRepo.NovelRepository
+	chapterRepo     novelRepo.ChapterRepository
+	videoRepo       novelRepo.VideoRepository
+	subtitleRepo    novelRepo.SubtitleRepository
+	imageRepo       novelRepo.ImageRepository
+}
+
+// NewEmbedService 创建公开嵌入播放服务
+// 只需要传入必要的依赖，repository 在内部自动创建；创建令牌时通过 novelAccess 检查创建人对小说的编辑权限
+func NewEmbedService(
+	db *mongo.Database,
+	resourceService ResourceService,
+	novelAccess NovelAccessChecker,
+	cfg *config.EmbedConfig,
+) EmbedService {
+	return &embedService{
+		cfg:             cfg,
+		resourceService: resourceService,
+		novelAccess:     novelAccess,
+		tokenRepo:       embedRepo.NewEmbedTokenRepo(db),
+		novelRepo:       novelRepo.NewNovelRepo(db),
+		chapterRepo:     novelRepo.NewChapterRepo(db),
+		videoRepo:       novelRepo.NewVideoRepo(db),
+		subtitleRepo:    novelRepo.NewSubtitleRepo(db),
+		imageRepo:       novelRepo.NewImageRepo(db),
+	}
+}
+
+// CreateToken 创建嵌入令牌
+func (s *embedService) CreateToken(ctx context.Context, req *CreateEmbedTokenRequest) (*CreateEmbedTokenResult, error) {
+	if req.Name == "" || req.CreatedBy == "" || len(req.NovelIDs) == 0 || req.RateLimitPerMinute < 0 || req.ExpiresIn < 0 {
+		return nil, ErrInvalidEmbedTokenRequest
+	}
+	// 令牌把小说内容公开给合作方，创建人需要对每本小说都有编辑权限
+	for _, novelID := range req.NovelIDs {
+		if err := s.novelAccess.CheckNovelAccess(ctx, novelID, req.CreatedBy, req.CreatorRole, novel.NovelPermissionEdit); err != nil {
+			return nil, fmt.Errorf("check novel %s: %w", novelID, err)
+		}
+	}
+
+	plaintext, err := generateEmbedToken()
+	if err != nil {
+		return nil, fmt.Errorf("generate embed token: %w", err)
+	}
+
+	token := &embed.EmbedToken{
+		ID:                 id.New(),
+		Name:               req.Name,
+		TokenHash:          hashEmbedToken(plaintext),
+		TokenPrefix:        plaintext[:len(embedTokenPrefix)+8],
+		NovelIDs:           req.NovelIDs,
+		AllowedOrigins:     req.AllowedOrigins,
+		RateLimitPerMinute: req.RateLimitPerMinute,
+		CreatedBy:          req.CreatedBy,
+	}
+	if req.ExpiresIn > 0 {
+		expiresAt := time.Now().Add(req.ExpiresIn)
+		token.ExpiresAt = &expiresAt
+	}
+	if err := s.tokenRepo.Create(ctx, token); err != nil {
+		return nil, fmt.Errorf("create embed token: %w", err)
+	}
+
+	log.Info().
+		Str("token_id", token.ID).
+		Str("name", token.Name).
+		Strs("novel_ids", token.NovelIDs).
+		Str("created_by", token.CreatedBy).
+		Msg("embed token created")
+
+	return &CreateEmbedTokenResult{Token: plaintext, EmbedToken: token}, nil
+}
+
+// ListTokens 查询嵌入令牌
+func (s *embedService) ListTokens(ctx context.Context, userID string, role auth.UserRole, limit int) ([]*embed.EmbedToken, error) {
+	createdBy := userID
+	if role == auth.RoleAdmin {
+		createdBy = ""
+	}
+	tokens, err := s.tokenRepo.List(ctx, createdBy, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list embed tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// RevokeToken 吊销嵌入令牌
+func (s *embedService) RevokeToken(ctx context.Context, tokenID, userID string, role auth.UserRole) error {
+	token, err := s.tokenRepo.FindByID(ctx, tokenID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrEmbedTokenNotFound
+		}
+		return fmt.Errorf("find embed token: %w", err)
+	}
+	if role != auth.RoleAdmin && token.CreatedBy != userID {
+		return ErrEmbedTokenAccessDenied
+	}
+
+	if err := s.tokenRepo.Revoke(ctx, tokenID, time.Now()); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrEmbedTokenNotFound
+		}
+		return fmt.Errorf("revoke embed token: %w", err)
+	}
+	log.Info().Str("token_id", tokenID).Str("revoked_by", userID).Msg("embed token revoked")
+	return nil
+}
+
+// Authenticate 校验令牌明文
+func (s *embedService) Authenticate(ctx context.Context, plaintext string) (*embed.EmbedToken, error) {
+	if plaintext == "" {
+		return nil, ErrEmbedTokenInvalid
+	}
+	token, err := s.tokenRepo.FindByTokenHash(ctx, hashEmbedToken(plaintext))
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrEmbedTokenInvalid
+		}
+		return nil, fmt.Errorf("find embed token: %w", err)
+	}
+
+	now := time.Now()
+	if !token.IsActive(now) {
+		return nil, ErrEmbedTokenInvalid
+	}
+
+	// 最近使用时间只用于管理端展示，写库失败不影响本次请求
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > embedTouchInterval {
+		if err := s.tokenRepo.TouchLastUsed(ctx, token.ID, now); err != nil {
+			log.Warn().Err(err).Str("token_id", token.ID).Msg("failed to update embed token last used time")
+		}
+	}
+	return token, nil
+}
+
+// AllowedOrigins 返回令牌允许的嵌入来源
+func (s *embedService) AllowedOrigins(token *embed.EmbedToken) []string {
+	if len(token.AllowedOrigins) > 0 {
+		return token.AllowedOrigins
+	}
+	return s.cfg.AllowedOrigins
+}
+
+// RateLimitPerMinute 返回令牌每分钟请求上限
+func (s *embedService) RateLimitPerMinute(token *embed.EmbedToken) int {
+	if token.RateLimitPerMinute > 0 {
+		return token.RateLimitPerMinute
+	}
+	return s.cfg.RateLimitPerMinute
+}
+
+// GetChapter 获取章节元数据
+func (s *embedService) GetChapter(ctx context.Context, token *embed.EmbedToken, chapterID string) (*EmbedChapter, error) {
+	chapter, err := s.findChapter(ctx, token, chapterID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &EmbedChapter{
+		ChapterID: chapter.ID,
+		NovelID:   chapter.NovelID,
+		Title:     chapter.Title,
+		Sequence:  chapter.Sequence,
+		WordCount: chapter.WordCount,
+		UpdatedAt: chapter.UpdatedAt,
+	}
+	if n, err := s.novelRepo.FindByID(ctx, chapter.NovelID); err == nil {
+		result.NovelTitle = n.Title
+	}
+
+	video, err := s.findPlayableVideo(ctx, chapter.ID)
+	if err != nil && !errors.Is(err, ErrEmbedMediaNotReady) {
+		return nil, err
+	}
+	if video != nil {
+		result.HasVideo = true
+		result.Duration = video.Duration
+	}
+	return result, nil
+}
+
+// GetVideo 获取章节最终视频的播放地址
+func (s *embedService) GetVideo(ctx context.Context, token *embed.EmbedToken, chapterID string) (*EmbedMedia, error) {
+	chapter, err := s.findChapter(ctx, token, chapterID)
+	if err != nil {
+		return nil, err
+	}
+	video, err := s.findPlayableVideo(ctx, chapter.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	download, err := s.downloadURL(ctx, video.VideoResourceID)
+	if err != nil {
+		return nil, err
+	}
+	return &EmbedMedia{
+		ChapterID:   chapter.ID,
+		URL:         download.DownloadURL,
+		ExpiresAt:   download.ExpiresAt,
+		ContentType: download.ContentType,
+		Version:     video.Version,
+		Duration:    video.Duration,
+		ExportTier:  string(video.ExportTier),
+	}, nil
+}
+
+// GetSubtitles 获取章节最新字幕的下载地址
+func (s *embedService) GetSubtitles(ctx context.Context, token *embed.EmbedToken, chapterID string) (*EmbedMedia, error) {
+	chapter, err := s.findChapter(ctx, token, chapterID)
+	if err != nil {
+		return nil, err
+	}
+
+	subtitle, err := s.subtitleRepo.FindByChapterID(ctx, chapter.ID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrEmbedMediaNotReady
+		}
+		return nil, fmt.Errorf("find subtitle: %w", err)
+	}
+	if subtitle.Status != novel.TaskStatusCompleted || subtitle.SubtitleResourceID == "" {
+		return nil, ErrEmbedMediaNotReady
+	}
+
+	download, err := s.downloadURL(ctx, subtitle.SubtitleResourceID)
+	if err != nil {
+		return nil, err
+	}
+	return &EmbedMedia{
+		ChapterID:   chapter.ID,
+		URL:         download.DownloadURL,
+		ExpiresAt:   download.ExpiresAt,
+		ContentType: download.ContentType,
+		Version:     subtitle.Version,
+		Format:      string(subtitle.Format),
+	}, nil
+}
+
+// GetThumbnails 获取章节最新版本镜头图片的缩略图地址
+func (s *embedService) GetThumbnails(ctx context.Context, token *embed.EmbedToken, chapterID string) ([]*EmbedThumbnail, error) {
+	chapter, err := s.findChapter(ctx, token, chapterID)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := s.imageRepo.FindVersionsByChapterID(ctx, chapter.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find image versions: %w", err)
+	}
+	if len(versions) == 0 {
+		return nil, ErrEmbedMediaNotReady
+	}
+	images, err := s.imageRepo.FindByChapterIDAndVersion(ctx, chapter.ID, slices.Max(versions))
+	if err != nil {
+		return nil, fmt.Errorf("find images: %w", err)
+	}
+
+	thumbnails := make([]*EmbedThumbnail, 0, len(images))
+	for _, img := range images {
+		if img.Status != novel.TaskStatusCompleted || img.ImageResourceID == "" {
+			continue
+		}
+		download, err := s.downloadURL(ctx, img.ImageResourceID)
+		if err != nil {
+			log.Warn().Err(err).Str("image_id", img.ID).Msg("failed to get thumbnail url, skipping")
+			continue
+		}
+		thumbnails = append(thumbnails, &EmbedThumbnail{
+			SceneNumber: img.SceneNumber,
+			ShotNumber:  img.ShotNumber,
+			Sequence:    img.Sequence,
+			URL:         download.DownloadURL,
+			ExpiresAt:   download.ExpiresAt,
+		})
+	}
+	return thumbnails, nil
+}
+
+// findChapter 查询章节并校验令牌作用域
+// 章节不存在和不在授权范围内返回同一个错误，避免向合作方暴露其他章节是否存在
+func (s *embedService) findChapter(ctx context.Context, token *embed.EmbedToken, chapterID string) (*novel.Chapter, error) {
+	chapter, err := s.chapterRepo.FindByID(ctx, chapterID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrEmbedChapterNotFound
+		}
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+	if !token.AllowsNovel(chapter.NovelID) {
+		return nil, ErrEmbedChapterNotFound
+	}
+	return chapter, nil
+}
+
+// findPlayableVideo 查询章节可播放的最终视频
+// 取最新版本的已完成视频，同一版本优先授权版（无水印），其次预览版
+func (s *embedService) findPlayableVideo(ctx context.Context, chapterID string) (*novel.Video, error) {
+	videos, err := s.videoRepo.FindByChapterIDAndType(ctx, chapterID, novel.VideoTypeFinal)
+	if err != nil {
+		return nil, fmt.Errorf("find final videos: %w", err)
+	}
+
+	var best *novel.Video
+	for _, v := range videos {
+		if v.Status != novel.VideoStatusCompleted || v.VideoResourceID == "" {
+			continue
+		}
+		if best == nil || v.Version > best.Version ||
+			(v.Version == best.Version && v.ExportTier == novel.ExportTierLicensed && best.ExportTier != novel.ExportTierLicensed) {
+			best = v
+		}
+	}
+	if best == nil {
+		return nil, ErrEmbedMediaNotReady
+	}
+	return best, nil
+}
+
+// downloadURL 生成资源的临时访问地址（系统内部请求，不做用户权限校验）
+func (s *embedService) downloadURL(ctx context.Context, resourceID string) (*GetDownloadURLResult, error) {
+	result, err := s.resourceService.GetDownloadURL(ctx, &GetDownloadURLRequest{
+		ResourceID: resourceID,
+		Purpose:    presign.PurposePlayback,
+		ExpiresIn:  s.cfg.URLExpiry,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get download url: %w", err)
+	}
+	return result, nil
+}
+
+// generateEmbedToken 生成嵌入令牌明文（emb_ + 32 字节随机数的十六进制）
+func generateEmbedToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return embedTokenPrefix + hex.EncodeToString(buf), nil
+}
+
+// hashEmbedToken 计算令牌明文的 SHA-256 摘要
+func hashEmbedToken(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/config"
+	"lemon/internal/model/maintenance"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/killswitch"
+	maintenanceRepo "lemon/internal/repository/maintenance"
+)
+
+var (
+	ErrInvalidMaintenanceScope  = errors.New("无效的维护范围")
+	ErrInvalidMaintenancePolicy = errors.New("无效的进行中任务处理策略")
+)
+
+// operatorConfig 由配置文件打开开关时记录的操作人
+const operatorConfig = "config"
+
+// MaintenanceService 维护模式服务接口
+// 管理全局/按 provider 的熔断开关，并记录停机窗口
+type MaintenanceService interface {
+	// Enable 打开开关，开关此前已打开时不会重复记录停机窗口
+	Enable(ctx context.Context, req *EnableMaintenanceRequest) (*killswitch.State, error)
+
+	// Disable 关闭开关并结束对应的停机窗口
+	Disable(ctx context.Context, scope, operator string) error
+
+	// Status 获取当前开关状态
+	Status() *MaintenanceStatus
+
+	// ListDowntimeWindows 查询停机窗口（按开始时间倒序），scope 为空时查询全部
+	ListDowntimeWindows(ctx context.Context, scope string, limit int) ([]*maintenance.DowntimeWindow, error)
+
+	// Restore 启动时恢复开关状态
+	// 配置文件中打开的开关，以及数据库中尚未结束的停机窗口（运行期通过接口打开、重启前未关闭）都会被重新打开
+	Restore(ctx context.Context, cfg *config.MaintenanceConfig) error
+}
+
+// EnableMaintenanceRequest 打开开关请求
+type EnableMaintenanceRequest struct {
+	Scope    string                    // 开关范围：global 或 provider（llm, tts, image, video）
+	Reason   string                    // 维护原因
+	Policy   killswitch.InFlightPolicy // 进行中任务的处理策略（为空时使用默认策略）
+	Operator string                    // 操作人
+}
+
+// MaintenanceStatus 维护模式状态
+type MaintenanceStatus struct {
+	States        []killswitch.State        `json:"states"`         // 当前打开的开关
+	InFlight      int                       `json:"in_flight"`      // 进行中的生成任务数
+	DefaultPolicy killswitch.InFlightPolicy `json:"default_policy"` // 默认的进行中任务处理策略
+}
+
+// maintenanceService 维护模式服务实现
+type maintenanceService struct {
+	killSwitch         *killswitch.Switch
+	downtimeWindowRepo maintenanceRepo.DowntimeWindowRepository
+}
+
+// NewMaintenanceService 创建维护模式服务
+// 只需要传入必要的依赖，repository 在内部自动创建
+func NewMaintenanceService(
+	db *mongo.Database,
+	killSwitch *killswitch.Switch,
+) MaintenanceService {
+	return &maintenanceService{
+		killSwitch:         killSwitch,
+		downtimeWindowRepo: maintenanceRepo.NewDowntimeWindowRepo(db),
+	}
+}
+
+// Enable 打开开关
+func (s *maintenanceService) Enable(ctx context.Context, req *EnableMaintenanceRequest) (*killswitch.State, error) {
+	if !killswitch.IsValidScope(req.Scope) {
+		return nil, ErrInvalidMaintenanceScope
+	}
+	if req.Policy != "" && !req.Policy.IsValid() {
+		return nil, ErrInvalidMaintenancePolicy
+	}
+
+	// 先打开开关再记录停机窗口：止损优先，记录失败不影响开关生效
+	changed := s.killSwitch.Enable(req.Scope, req.Reason, req.Policy)
+	state, _ := s.killSwitch.State(req.Scope)
+	if !changed {
+		return &state, nil
+	}
+
+	log.Warn().
+		Str("scope", req.Scope).
+		Str("reason", req.Reason).
+		Str("policy", string(state.Policy)).
+		Str("operator", req.Operator).
+		Msg("maintenance enabled")
+
+	if err := s.openWindow(ctx, &state, req.Operator); err != nil {
+		return &state, fmt.Errorf("record downtime window: %w", err)
+	}
+	return &state, nil
+}
+
+// Disable 关闭开关
+func (s *maintenanceService) Disable(ctx context.Context, scope, operator string) error {
+	if !killswitch.IsValidScope(scope) {
+		return ErrInvalidMaintenanceScope
+	}
+
+	if s.killSwitch.Disable(scope) {
+		log.Info().Str("scope", scope).Str("operator", operator).Msg("maintenance disabled")
+	}
+
+	window, err := s.downtimeWindowRepo.FindOpenByScope(ctx, scope)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		return fmt.Errorf("find open downtime window: %w", err)
+	}
+	if err := s.downtimeWindowRepo.Close(ctx, window.ID, operator, time.Now()); err != nil {
+		return fmt.Errorf("close downtime window: %w", err)
+	}
+	return nil
+}
+
+// Status 获取当前开关状态
+func (s *maintenanceService) Status() *MaintenanceStatus {
+	return &MaintenanceStatus{
+		States:        s.killSwitch.States(),
+		InFlight:      s.killSwitch.InFlight(),
+		DefaultPolicy: s.killSwitch.DefaultPolicy(),
+	}
+}
+
+// ListDowntimeWindows 查询停机窗口
+func (s *maintenanceService) ListDowntimeWindows(ctx context.Context, scope string, limit int) ([]*maintenance.DowntimeWindow, error) {
+	if scope != "" && !killswitch.IsValidScope(scope) {
+		return nil, ErrInvalidMaintenanceScope
+	}
+	return s.downtimeWindowRepo.List(ctx, scope, limit)
+}
+
+// Restore 启动时恢复开关状态
+func (s *maintenanceService) Restore(ctx context.Context, cfg *config.MaintenanceConfig) error {
+	// 数据库中尚未结束的停机窗口：沿用原窗口的原因和策略
+	for _, scope := range killswitch.AllScopes {
+		window, err := s.downtimeWindowRepo.FindOpenByScope(ctx, scope)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				continue
+			}
+			return fmt.Errorf("find open downtime window: %w", err)
+		}
+		s.killSwitch.Enable(scope, window.Reason, killswitch.InFlightPolicy(window.InFlightPolicy))
+		log.Warn().Str("scope", scope).Str("reason", window.Reason).Msg("maintenance restored from open downtime window")
+	}
+
+	if cfg == nil {
+		return nil
+	}
+
+	scopes := cfg.DisabledProviders
+	if cfg.Enabled {
+		scopes = append([]string{killswitch.ScopeGlobal}, scopes...)
+	}
+	for _, scope := range scopes {
+		if _, err := s.Enable(ctx, &EnableMaintenanceRequest{
+			Scope:    scope,
+			Reason:   cfg.Reason,
+			Operator: operatorConfig,
+		}); err != nil {
+			return fmt.Errorf("enable maintenance %q from config: %w", scope, err)
+		}
+	}
+	return nil
+}
+
+// openWindow 记录停机窗口，已有未结束的窗口时复用（如重启前未关闭）
+func (s *maintenanceService) openWindow(ctx context.Context, state *killswitch.State, operator string) error {
+	_, err := s.downtimeWindowRepo.FindOpenByScope(ctx, state.Scope)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	return s.downtimeWindowRepo.Create(ctx, &maintenance.DowntimeWindow{
+		ID:             id.New(),
+		Scope:          state.Scope,
+		Reason:         state.Reason,
+		InFlightPolicy: string(state.Policy),
+		StartedBy:      operator,
+		StartedAt:      state.Since,
+	})
+}
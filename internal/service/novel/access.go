@@ -0,0 +1,276 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/auth"
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+	"lemon/internal/service"
+)
+
+var (
+	// ErrNovelAccessDenied 当前用户对小说没有所需的权限
+	ErrNovelAccessDenied = errors.New("novel access denied")
+	// ErrInvalidNovelGrant 授权请求不合法（权限取值错误、授权给小说所有者等）
+	ErrInvalidNovelGrant = errors.New("invalid novel grant")
+)
+
+// NovelScope 可以定位到所属小说的资源类型（用于按嵌套资源检查小说权限）
+type NovelScope string
+
+const (
+	NovelScopeNovel          NovelScope = "novel"
+	NovelScopeChapter        NovelScope = "chapter"
+	NovelScopeNarration      NovelScope = "narration"
+	NovelScopeScene          NovelScope = "scene"
+	NovelScopeShot           NovelScope = "shot"
+	NovelScopeStyleReference NovelScope = "style_reference"
+	NovelScopePrewarmJob     NovelScope = "prewarm_job"
+	NovelScopeContentRisk    NovelScope = "content_risk_report"
+	NovelScopeCompilation    NovelScope = "compilation"
+	NovelScopeStandbyJob     NovelScope = "standby_job"
+	NovelScopeJob            NovelScope = "job"
+	NovelScopePronunciation  NovelScope = "pronunciation"
+)
+
+// AccessService 小说协作权限服务接口
+// 组织级角色之外，小说所有者可以把单本小说的只读/编辑权限授予指定用户：
+// 管理员可以访问所有小说；审核人员可以查看所有小说；其他用户只能访问自己创建的小说和被授权的小说
+type AccessService interface {
+	// GrantNovelAccess 授予（或更新）用户对小说的权限
+	GrantNovelAccess(ctx context.Context, req *GrantNovelAccessRequest) (*novel.NovelGrant, error)
+
+	// ListNovelGrants 查询小说的所有授权
+	ListNovelGrants(ctx context.Context, novelID string) ([]*novel.NovelGrant, error)
+
+	// RevokeNovelAccess 撤销用户对小说的权限
+	RevokeNovelAccess(ctx context.Context, novelID, userID string) error
+
+	// CheckNovelAccess 检查用户对小说是否有 required 权限，没有时返回 ErrNovelAccessDenied
+	CheckNovelAccess(ctx context.Context, novelID, userID string, role auth.UserRole, required novel.NovelPermission) error
+
+	// CheckNovelOwner 检查用户是否为小说所有者（管理员视为所有者），不是时返回 ErrNovelAccessDenied
+	CheckNovelOwner(ctx context.Context, novelID, userID string, role auth.UserRole) error
+
+	// CheckResourceAccess 检查用户对资源文件是否有 required 权限，没有时返回 ErrNovelAccessDenied
+	// 管理员和资源上传者可以访问；其他用户需要对引用该资源的任一小说有 required 权限
+	CheckResourceAccess(ctx context.Context, resourceID, userID string, role auth.UserRole, required novel.NovelPermission) error
+
+	// ResolveNovelID 查询嵌套资源所属的小说ID
+	ResolveNovelID(ctx context.Context, scope NovelScope, resourceID string) (string, error)
+}
+
+// GrantNovelAccessRequest 授予小说权限请求
+type GrantNovelAccessRequest struct {
+	NovelID    string                // 小说ID
+	UserID     string                // 被授权用户ID
+	Permission novel.NovelPermission // 权限：read、edit
+	GrantedBy  string                // 授权人用户ID
+}
+
+// GrantNovelAccess 授予（或更新）用户对小说的权限
+func (s *novelService) GrantNovelAccess(ctx context.Context, req *GrantNovelAccessRequest) (*novel.NovelGrant, error) {
+	if !req.Permission.IsValid() {
+		return nil, fmt.Errorf("%w: unknown permission %q", ErrInvalidNovelGrant, req.Permission)
+	}
+	if req.UserID == "" {
+		return nil, fmt.Errorf("%w: user_id is required", ErrInvalidNovelGrant)
+	}
+
+	n, err := s.novelRepo.FindByID(ctx, req.NovelID)
+	if err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+	if n.UserID == req.UserID {
+		return nil, fmt.Errorf("%w: user %s is the owner of novel %s", ErrInvalidNovelGrant, req.UserID, req.NovelID)
+	}
+
+	grant, err := s.novelGrantRepo.Upsert(ctx, &novel.NovelGrant{
+		ID:         id.New(),
+		NovelID:    req.NovelID,
+		UserID:     req.UserID,
+		Permission: req.Permission,
+		GrantedBy:  req.GrantedBy,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("save novel grant: %w", err)
+	}
+	return grant, nil
+}
+
+// ListNovelGrants 查询小说的所有授权
+func (s *novelService) ListNovelGrants(ctx context.Context, novelID string) ([]*novel.NovelGrant, error) {
+	if _, err := s.novelRepo.FindByID(ctx, novelID); err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+	grants, err := s.novelGrantRepo.FindByNovelID(ctx, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("find novel grants: %w", err)
+	}
+	return grants, nil
+}
+
+// RevokeNovelAccess 撤销用户对小说的权限，授权不存在时返回 mongo.ErrNoDocuments
+func (s *novelService) RevokeNovelAccess(ctx context.Context, novelID, userID string) error {
+	if err := s.novelGrantRepo.Delete(ctx, novelID, userID); err != nil {
+		return fmt.Errorf("delete novel grant: %w", err)
+	}
+	return nil
+}
+
+// CheckNovelAccess 检查用户对小说是否有 required 权限
+func (s *novelService) CheckNovelAccess(ctx context.Context, novelID, userID string, role auth.UserRole, required novel.NovelPermission) error {
+	if role == auth.RoleAdmin {
+		return nil
+	}
+
+	n, err := s.novelRepo.FindByID(ctx, novelID)
+	if err != nil {
+		return fmt.Errorf("find novel: %w", err)
+	}
+	if n.UserID == userID {
+		return nil
+	}
+	if role == auth.RoleReviewer && required == novel.NovelPermissionRead {
+		return nil
+	}
+
+	grant, err := s.novelGrantRepo.FindByNovelIDAndUserID(ctx, novelID, userID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("%w: user %s has no access to novel %s", ErrNovelAccessDenied, userID, novelID)
+		}
+		return fmt.Errorf("find novel grant: %w", err)
+	}
+	if !grant.Permission.Includes(required) {
+		return fmt.Errorf("%w: user %s has %s access to novel %s, %s required", ErrNovelAccessDenied, userID, grant.Permission, novelID, required)
+	}
+	return nil
+}
+
+// CheckNovelOwner 检查用户是否为小说所有者（管理员视为所有者）
+func (s *novelService) CheckNovelOwner(ctx context.Context, novelID, userID string, role auth.UserRole) error {
+	if role == auth.RoleAdmin {
+		return nil
+	}
+	n, err := s.novelRepo.FindByID(ctx, novelID)
+	if err != nil {
+		return fmt.Errorf("find novel: %w", err)
+	}
+	if n.UserID != userID {
+		return fmt.Errorf("%w: user %s is not the owner of novel %s", ErrNovelAccessDenied, userID, novelID)
+	}
+	return nil
+}
+
+// CheckResourceAccess 检查用户对资源文件是否有 required 权限
+// 没有被小说内容引用的资源（如直接上传的文件）只有上传者和管理员可以访问
+func (s *novelService) CheckResourceAccess(ctx context.Context, resourceID, userID string, role auth.UserRole, required novel.NovelPermission) error {
+	if role == auth.RoleAdmin {
+		return nil
+	}
+
+	res, err := s.resourceService.GetResource(ctx, &service.GetResourceRequest{ResourceID: resourceID})
+	if err != nil {
+		return err
+	}
+	if res.Resource.UserID == userID {
+		return nil
+	}
+
+	novelIDs, err := s.resourceOwnerRepo.FindNovelIDs(ctx, resourceID)
+	if err != nil {
+		return fmt.Errorf("find resource owners: %w", err)
+	}
+	for _, novelID := range novelIDs {
+		err := s.CheckNovelAccess(ctx, novelID, userID, role, required)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, ErrNovelAccessDenied) && !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: user %s has no %s access to resource %s", ErrNovelAccessDenied, userID, required, resourceID)
+}
+
+// ResolveNovelID 查询嵌套资源所属的小说ID
+func (s *novelService) ResolveNovelID(ctx context.Context, scope NovelScope, resourceID string) (string, error) {
+	switch scope {
+	case NovelScopeNovel:
+		return resourceID, nil
+	case NovelScopeChapter:
+		chapter, err := s.chapterRepo.FindByID(ctx, resourceID)
+		if err != nil {
+			return "", fmt.Errorf("find chapter: %w", err)
+		}
+		return chapter.NovelID, nil
+	case NovelScopeNarration:
+		narration, err := s.narrationRepo.FindByID(ctx, resourceID)
+		if err != nil {
+			return "", fmt.Errorf("find narration: %w", err)
+		}
+		return narration.NovelID, nil
+	case NovelScopeScene:
+		scene, err := s.sceneRepo.FindByID(ctx, resourceID)
+		if err != nil {
+			return "", fmt.Errorf("find scene: %w", err)
+		}
+		return scene.NovelID, nil
+	case NovelScopeShot:
+		shot, err := s.shotRepo.FindByID(ctx, resourceID)
+		if err != nil {
+			return "", fmt.Errorf("find shot: %w", err)
+		}
+		return shot.NovelID, nil
+	case NovelScopeStyleReference:
+		ref, err := s.styleReferenceRepo.FindByID(ctx, resourceID)
+		if err != nil {
+			return "", fmt.Errorf("find style reference: %w", err)
+		}
+		return ref.NovelID, nil
+	case NovelScopePrewarmJob:
+		job, err := s.prewarmJobRepo.FindByID(ctx, resourceID)
+		if err != nil {
+			return "", fmt.Errorf("find prewarm job: %w", err)
+		}
+		return job.NovelID, nil
+	case NovelScopeContentRisk:
+		report, err := s.contentRiskReportRepo.FindByID(ctx, resourceID)
+		if err != nil {
+			return "", fmt.Errorf("find content risk report: %w", err)
+		}
+		return report.NovelID, nil
+	case NovelScopeCompilation:
+		compilation, err := s.compilationRepo.FindByID(ctx, resourceID)
+		if err != nil {
+			return "", fmt.Errorf("find compilation: %w", err)
+		}
+		return compilation.NovelID, nil
+	case NovelScopeStandbyJob:
+		job, err := s.standbyJobRepo.FindByID(ctx, resourceID)
+		if err != nil {
+			return "", fmt.Errorf("find standby job: %w", err)
+		}
+		return job.NovelID, nil
+	case NovelScopeJob:
+		job, err := s.jobRepo.FindByID(ctx, resourceID)
+		if err != nil {
+			return "", fmt.Errorf("find job: %w", err)
+		}
+		return job.NovelID, nil
+	case NovelScopePronunciation:
+		entry, err := s.pronunciationRepo.FindByID(ctx, resourceID)
+		if err != nil {
+			return "", fmt.Errorf("find pronunciation: %w", err)
+		}
+		return entry.NovelID, nil
+	default:
+		return "", fmt.Errorf("unknown novel scope: %s", scope)
+	}
+}
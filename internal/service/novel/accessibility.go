@@ -0,0 +1,234 @@
+package novel
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	"lemon/internal/pkg/ffmpeg"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/killswitch"
+	"lemon/internal/pkg/noveltools"
+	"lemon/internal/service"
+)
+
+// 无障碍输出的产物类型（存储路径模板中的 {artifact_type}）
+const (
+	artifactTranscript       = "transcript"        // 纯文本文字稿
+	artifactAudioDescription = "audio_description" // 口述影像音轨（AAC）
+	artifactDescriptionVTT   = "description_vtt"   // 口述影像的 WebVTT 描述轨
+)
+
+// audioDescriptionFromEnv 最终视频是否默认生成口述影像音轨（AUDIO_DESCRIPTION=true 时开启，单次请求可覆盖）
+func audioDescriptionFromEnv() bool {
+	return strings.EqualFold(os.Getenv("AUDIO_DESCRIPTION"), "true")
+}
+
+// accessibilityOutputs 最终视频的无障碍输出
+type accessibilityOutputs struct {
+	transcriptResourceID       string
+	audioDescriptionResourceID string
+	descriptionVTTResourceID   string
+	descriptionCues            int
+}
+
+// audioDescriptionEnabled 本次渲染是否生成口述影像音轨：请求覆盖优先，否则按系统配置
+func (s *novelService) audioDescriptionEnabled(ctx context.Context) bool {
+	if enabled, ok := ctxutil.GetAudioDescription(ctx); ok {
+		return enabled
+	}
+	return s.audioDescription
+}
+
+// generateAccessibilityOutputs 为最终视频生成无障碍输出：纯文本文字稿，以及（开启时）只有画面时段的口述影像音轨和 WebVTT 描述轨
+// 无障碍输出失败只记录警告，不影响最终视频生成；文字稿失败时返回 nil
+func (s *novelService) generateAccessibilityOutputs(ctx context.Context, chapter *novel.Chapter, narrationVideos []*novel.Video, videoPaths []string, finalPath string, version int, tmpDir string, ffmpegClient *ffmpeg.Client) *accessibilityOutputs {
+	clips, err := s.accessibilityClips(ctx, narrationVideos, videoPaths, ffmpegClient)
+	if err != nil {
+		log.Warn().Err(err).Str("chapter_id", chapter.ID).Msg("计算无障碍时间轴失败，跳过文字稿和口述影像")
+		return nil
+	}
+	segments, moments := noveltools.BuildAccessibilityTimeline(clips)
+
+	title := chapter.NormalizedTitle
+	if title == "" {
+		title = chapter.Title
+	}
+	transcript := noveltools.BuildTranscript(title, segments)
+	transcriptResult, err := s.resourceService.UploadFile(ctx, &service.UploadFileRequest{
+		UserID:      chapter.UserID,
+		FileName:    fmt.Sprintf("%s_transcript.txt", chapter.ID),
+		ContentType: "text/plain; charset=utf-8",
+		Ext:         "txt",
+		Data:        strings.NewReader(transcript),
+		KeyVars:     chapterKeyVars(chapter, artifactTranscript, version, 1),
+	})
+	if err != nil {
+		log.Warn().Err(err).Str("chapter_id", chapter.ID).Msg("上传文字稿失败，跳过文字稿和口述影像")
+		return nil
+	}
+	out := &accessibilityOutputs{transcriptResourceID: transcriptResult.ResourceID}
+
+	if !s.audioDescriptionEnabled(ctx) {
+		return out
+	}
+	cues := noveltools.BuildAudioDescriptionCues(moments, noveltools.DefaultAudioDescriptionMinGap)
+	if len(cues) == 0 {
+		log.Info().Str("chapter_id", chapter.ID).Msg("没有足够长的纯画面时段，跳过口述影像")
+		return out
+	}
+
+	vttResult, err := s.resourceService.UploadFile(ctx, &service.UploadFileRequest{
+		UserID:      chapter.UserID,
+		FileName:    fmt.Sprintf("%s_descriptions.vtt", chapter.ID),
+		ContentType: "text/vtt",
+		Ext:         "vtt",
+		Data:        strings.NewReader(noveltools.BuildDescriptionVTT(cues)),
+		KeyVars:     chapterKeyVars(chapter, artifactDescriptionVTT, version, 1),
+	})
+	if err != nil {
+		log.Warn().Err(err).Str("chapter_id", chapter.ID).Msg("上传口述影像描述轨失败")
+		return out
+	}
+	out.descriptionVTTResourceID = vttResult.ResourceID
+	out.descriptionCues = len(cues)
+
+	resourceID, err := s.storeAudioDescriptionTrack(ctx, chapter, cues, finalPath, version, tmpDir, ffmpegClient)
+	if err != nil {
+		log.Warn().Err(err).Str("chapter_id", chapter.ID).Msg("生成口述影像音轨失败")
+		return out
+	}
+	out.audioDescriptionResourceID = resourceID
+
+	log.Info().
+		Str("chapter_id", chapter.ID).
+		Int("segments", len(segments)).
+		Int("description_cues", len(cues)).
+		Msg("口述影像音轨已生成")
+	return out
+}
+
+// accessibilityClips 按拼接顺序构建无障碍时间轴的片段
+// 片段时长缺失时探测本地文件；镜头的解说文本和配音时长取自匹配的音频，没有音频时只有画面
+func (s *novelService) accessibilityClips(ctx context.Context, narrationVideos []*novel.Video, videoPaths []string, ffmpegClient *ffmpeg.Client) ([]noveltools.AccessibilityClip, error) {
+	narrationID := narrationVideos[0].NarrationID
+	if narrationID == "" {
+		return nil, fmt.Errorf("narration videos have no narration_id")
+	}
+	shots, err := s.shotRepo.FindByNarrationID(ctx, narrationID)
+	if err != nil {
+		return nil, fmt.Errorf("find shots: %w", err)
+	}
+	audios, err := s.audioRepo.FindByNarrationID(ctx, narrationID)
+	if err != nil {
+		return nil, fmt.Errorf("find audios: %w", err)
+	}
+
+	shotByIndex := make(map[int]noveltools.AccessibilityShot, len(shots))
+	for _, shot := range shots {
+		entry := noveltools.AccessibilityShot{
+			SceneNumber: shot.SceneNumber,
+			Text:        shot.Narration,
+			Description: shot.Image,
+		}
+		if audio := matchShotAudio(audios, shot); audio != nil {
+			entry.Text = audio.Text
+			entry.SpeechDuration = audio.Duration
+		}
+		shotByIndex[shot.Index] = entry
+	}
+
+	clips := make([]noveltools.AccessibilityClip, 0, len(narrationVideos))
+	for i, video := range narrationVideos {
+		duration := video.Duration
+		if duration <= 0 {
+			info, err := ffmpegClient.GetVideoInfo(ctx, videoPaths[i])
+			if err != nil {
+				return nil, fmt.Errorf("probe video %d: %w", i+1, err)
+			}
+			duration = info.Duration
+		}
+
+		clip := noveltools.AccessibilityClip{Duration: duration}
+		start, end := video.SequenceRange()
+		for index := start; index <= end; index++ {
+			if shot, ok := shotByIndex[index]; ok {
+				clip.Shots = append(clip.Shots, shot)
+			}
+		}
+		clips = append(clips, clip)
+	}
+	return clips, nil
+}
+
+// storeAudioDescriptionTrack 合成每条描述的配音，按开始时间排到与最终视频等长的独立音轨上并上传，返回音轨的 resource_id
+func (s *novelService) storeAudioDescriptionTrack(ctx context.Context, chapter *novel.Chapter, cues []noveltools.AudioDescriptionCue, finalPath string, version int, tmpDir string, ffmpegClient *ffmpeg.Client) (string, error) {
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderTTS)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	info, err := ffmpegClient.GetVideoInfo(ctx, finalPath)
+	if err != nil {
+		return "", fmt.Errorf("probe final video: %w", err)
+	}
+
+	voice := s.novelVoice(ctx, chapter.NovelID)
+	hints := s.novelPronunciationHints(ctx, chapter.NovelID)
+	audioCues := make([]ffmpeg.AudioCue, 0, len(cues))
+	for i, cue := range cues {
+		if err := s.checkBudget(ctx, chapter.NovelID); err != nil {
+			return "", err
+		}
+		tts, err := s.generateVoice(ctx, cue.Text, voice, hints)
+		if err != nil {
+			return "", fmt.Errorf("generate description voice %d: %w", i+1, err)
+		}
+		if !tts.Success {
+			return "", fmt.Errorf("generate description voice %d: %s", i+1, tts.ErrorMessage)
+		}
+		chars := utf8.RuneCountInString(cue.Text)
+		s.recordCost(ctx, chapter.NovelID, chapter.ID, killswitch.ProviderTTS, float64(chars), s.pricing.TTS(chars))
+
+		cuePath := filepath.Join(tmpDir, fmt.Sprintf("description_%03d_%s.%s", i+1, id.New(), audioExt(tts.AudioData)))
+		if err := os.WriteFile(cuePath, tts.AudioData, 0644); err != nil {
+			return "", fmt.Errorf("write description voice %d: %w", i+1, err)
+		}
+		defer os.Remove(cuePath)
+
+		// 配音超出纯画面时段时截断，避免盖住下一段解说
+		audioCues = append(audioCues, ffmpeg.AudioCue{Path: cuePath, Start: cue.Start, Duration: cue.End - cue.Start})
+	}
+
+	trackPath := filepath.Join(tmpDir, fmt.Sprintf("audio_description_%s.m4a", id.New()))
+	defer os.Remove(trackPath)
+	if err := ffmpegClient.BuildCueTrack(ctx, audioCues, info.Duration, trackPath); err != nil {
+		return "", err
+	}
+	track, err := os.Open(trackPath)
+	if err != nil {
+		return "", fmt.Errorf("open audio description track: %w", err)
+	}
+	defer track.Close()
+
+	result, err := s.resourceService.UploadFile(ctx, &service.UploadFileRequest{
+		UserID:      chapter.UserID,
+		FileName:    fmt.Sprintf("%s_audio_description.m4a", chapter.ID),
+		ContentType: "audio/mp4",
+		Ext:         "m4a",
+		Data:        track,
+		KeyVars:     chapterKeyVars(chapter, artifactAudioDescription, version, 1),
+	})
+	if err != nil {
+		return "", fmt.Errorf("upload audio description track: %w", err)
+	}
+	return result.ResourceID, nil
+}
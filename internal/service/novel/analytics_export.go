@@ -0,0 +1,348 @@
+package novel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/noveltools"
+	"lemon/internal/pkg/storage"
+	"lemon/internal/service"
+)
+
+const (
+	defaultAnalyticsExportBatchSize  = 5000  // 每个批次默认最多导出的源记录数
+	maxAnalyticsExportBatchSize      = 50000 // 每个批次最多导出的源记录数
+	defaultAnalyticsExportMaxBatches = 20    // 每个数据集单次导出默认最多的批次数
+
+	// analyticsExportUserID 定时导出上传文件时使用的用户ID
+	analyticsExportUserID = "system"
+)
+
+var (
+	// ErrInvalidAnalyticsExport 导出请求不合法（未知数据集等）
+	ErrInvalidAnalyticsExport = errors.New("invalid analytics export")
+	// ErrAnalyticsExportConflict 同一数据集有另一个导出同时提交了相同序号的批次
+	ErrAnalyticsExportConflict = errors.New("analytics export batch committed by another export")
+)
+
+// AnalyticsExportService 分析数据导出服务接口
+// 把队列任务、阶段执行记录（用量）、费用和 QA 评分按检查点增量导出为 JSON Lines 文件，供 BigQuery/ClickHouse 等数仓加载
+type AnalyticsExportService interface {
+	// ExportAnalytics 从各数据集最新批次的游标开始增量导出，每个批次上传一个 JSON Lines 文件并记录批次
+	ExportAnalytics(ctx context.Context, req *ExportAnalyticsRequest) (*AnalyticsExportResult, error)
+
+	// ListAnalyticsExportBatches 查询数据集序号大于 afterSeq 的导出批次（按序号升序，数仓按序号顺序拉取并加载）
+	ListAnalyticsExportBatches(ctx context.Context, dataset novel.AnalyticsDataset, afterSeq int64, limit int) ([]*novel.AnalyticsExportBatch, error)
+
+	// GetAnalyticsSchemas 查询各数据集当前的行结构版本和表结构
+	GetAnalyticsSchemas() []AnalyticsDatasetSchema
+
+	// StartAnalyticsExport 按 ANALYTICS_EXPORT_INTERVAL 定时增量导出全部数据集（未配置时不启动）
+	StartAnalyticsExport(ctx context.Context)
+}
+
+// ExportAnalyticsRequest 分析数据导出请求
+type ExportAnalyticsRequest struct {
+	Datasets   []novel.AnalyticsDataset // 导出的数据集（为空时导出全部）
+	UserID     string                   // 触发导出的用户ID
+	BatchSize  int                      // 每个批次最多的源记录数（默认 5000，最大 50000）
+	MaxBatches int                      // 每个数据集本次最多导出的批次数（默认 20）
+}
+
+// AnalyticsExportResult 分析数据导出结果
+type AnalyticsExportResult struct {
+	Datasets []*AnalyticsDatasetExport `json:"datasets"`
+}
+
+// AnalyticsDatasetExport 单个数据集的导出结果
+type AnalyticsDatasetExport struct {
+	Dataset novel.AnalyticsDataset        `json:"dataset"`
+	Batches []*novel.AnalyticsExportBatch `json:"batches"`
+	Rows    int                           `json:"rows"`     // 本次导出的行数
+	HasMore bool                          `json:"has_more"` // 达到批次数上限，可能还有未导出的记录
+}
+
+// AnalyticsDatasetSchema 数据集的行结构
+type AnalyticsDatasetSchema struct {
+	Dataset       novel.AnalyticsDataset      `json:"dataset"`
+	SchemaVersion int                         `json:"schema_version"`
+	Fields        []noveltools.AnalyticsField `json:"fields"`
+}
+
+// analyticsPage 一页源记录转换后的导出内容
+type analyticsPage struct {
+	data       []byte    // JSON Lines 内容
+	rows       int       // 行数（qa_scores 只有成功的最终视频阶段产生行，可能少于源记录数）
+	sourceRows int       // 源记录数
+	toTime     time.Time // 最后一条源记录的游标
+	toID       string
+}
+
+// analyticsExportIntervalFromEnv 读取定时导出间隔（ANALYTICS_EXPORT_INTERVAL，如 1h；未配置或非法时不定时导出）
+func analyticsExportIntervalFromEnv() time.Duration {
+	if v, err := time.ParseDuration(os.Getenv("ANALYTICS_EXPORT_INTERVAL")); err == nil && v > 0 {
+		return v
+	}
+	return 0
+}
+
+// ExportAnalytics 增量导出分析数据
+// 各数据集依次导出：每页按（时间, ID）升序读取游标之后的源记录，转换为当前版本的行结构后上传，再以「上一批次序号 + 1」提交批次；
+// 多个实例同时导出同一数据集时只有一个能提交，其余返回 ErrAnalyticsExportConflict（已上传的文件没有批次记录，不会被数仓加载）
+func (s *novelService) ExportAnalytics(ctx context.Context, req *ExportAnalyticsRequest) (*AnalyticsExportResult, error) {
+	datasets := req.Datasets
+	if len(datasets) == 0 {
+		datasets = novel.AnalyticsDatasets
+	}
+	for _, dataset := range datasets {
+		if !dataset.IsValid() {
+			return nil, fmt.Errorf("%w: unknown dataset %q", ErrInvalidAnalyticsExport, dataset)
+		}
+	}
+	batchSize := req.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultAnalyticsExportBatchSize
+	}
+	batchSize = min(batchSize, maxAnalyticsExportBatchSize)
+	maxBatches := req.MaxBatches
+	if maxBatches <= 0 {
+		maxBatches = defaultAnalyticsExportMaxBatches
+	}
+	userID := req.UserID
+	if userID == "" {
+		userID = analyticsExportUserID
+	}
+
+	result := &AnalyticsExportResult{}
+	for _, dataset := range datasets {
+		export, err := s.exportAnalyticsDataset(ctx, dataset, userID, batchSize, maxBatches)
+		if export != nil {
+			result.Datasets = append(result.Datasets, export)
+		}
+		if err != nil {
+			return result, err
+		}
+		log.Info().
+			Str("dataset", string(dataset)).
+			Int("batches", len(export.Batches)).
+			Int("rows", export.Rows).
+			Bool("has_more", export.HasMore).
+			Msg("分析数据导出完成")
+	}
+	return result, nil
+}
+
+// exportAnalyticsDataset 从数据集最新批次的游标开始导出，最多 maxBatches 个批次
+func (s *novelService) exportAnalyticsDataset(ctx context.Context, dataset novel.AnalyticsDataset, userID string, batchSize, maxBatches int) (*AnalyticsDatasetExport, error) {
+	var seq int64
+	var cursorTime time.Time
+	var cursorID string
+	latest, err := s.analyticsExportBatchRepo.FindLatest(ctx, dataset)
+	switch {
+	case err == nil:
+		seq, cursorTime, cursorID = latest.Seq, latest.ToTime, latest.ToID
+	case !errors.Is(err, mongo.ErrNoDocuments):
+		return nil, fmt.Errorf("find latest %s export batch: %w", dataset, err)
+	}
+
+	export := &AnalyticsDatasetExport{Dataset: dataset, Batches: []*novel.AnalyticsExportBatch{}}
+	for len(export.Batches) < maxBatches {
+		page, err := s.analyticsExportPage(ctx, dataset, cursorTime, cursorID, batchSize)
+		if err != nil {
+			return export, fmt.Errorf("read %s: %w", dataset, err)
+		}
+		if page.sourceRows == 0 {
+			return export, nil
+		}
+
+		batch := &novel.AnalyticsExportBatch{
+			ID:            id.New(),
+			Dataset:       dataset,
+			Seq:           seq + 1,
+			SchemaVersion: noveltools.AnalyticsSchemaVersion(dataset),
+			Rows:          page.rows,
+			FromTime:      cursorTime,
+			FromID:        cursorID,
+			ToTime:        page.toTime,
+			ToID:          page.toID,
+			UserID:        userID,
+		}
+		// 没有行的批次（如这一页的最终视频阶段都失败了）不上传文件，只推进游标
+		if page.rows > 0 {
+			uploadResult, err := s.resourceService.UploadFile(ctx, &service.UploadFileRequest{
+				UserID:      userID,
+				FileName:    fmt.Sprintf("%s_%06d.jsonl", dataset, batch.Seq),
+				ContentType: "application/x-ndjson",
+				Ext:         "jsonl",
+				Data:        bytes.NewReader(page.data),
+				KeyVars:     &storage.KeyVars{ArtifactType: artifactAnalyticsExport},
+			})
+			if err != nil {
+				return export, fmt.Errorf("upload %s export batch: %w", dataset, err)
+			}
+			batch.ResourceID = uploadResult.ResourceID
+			batch.Bytes = int64(len(page.data))
+		}
+		if err := s.analyticsExportBatchRepo.Create(ctx, batch); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				return export, fmt.Errorf("%w: %s seq %d", ErrAnalyticsExportConflict, dataset, batch.Seq)
+			}
+			return export, fmt.Errorf("create %s export batch: %w", dataset, err)
+		}
+
+		export.Batches = append(export.Batches, batch)
+		export.Rows += batch.Rows
+		seq, cursorTime, cursorID = batch.Seq, batch.ToTime, batch.ToID
+		if page.sourceRows < batchSize {
+			return export, nil
+		}
+	}
+	export.HasMore = true
+	return export, nil
+}
+
+// analyticsExportPage 读取游标之后的一页源记录并转换为 JSON Lines
+func (s *novelService) analyticsExportPage(ctx context.Context, dataset novel.AnalyticsDataset, after time.Time, afterID string, limit int) (*analyticsPage, error) {
+	switch dataset {
+	case novel.AnalyticsDatasetTasks:
+		jobs, err := s.jobRepo.FindUpdatedAfter(ctx, after, afterID, limit)
+		if err != nil || len(jobs) == 0 {
+			return &analyticsPage{}, err
+		}
+		rows := make([]noveltools.AnalyticsTaskRow, 0, len(jobs))
+		for _, job := range jobs {
+			rows = append(rows, noveltools.NewAnalyticsTaskRow(job))
+		}
+		last := jobs[len(jobs)-1]
+		return newAnalyticsPage(rows, len(jobs), last.UpdatedAt, last.ID)
+
+	case novel.AnalyticsDatasetUsage:
+		runs, err := s.stageRunRepo.FindCreatedAfter(ctx, after, afterID, "", limit)
+		if err != nil || len(runs) == 0 {
+			return &analyticsPage{}, err
+		}
+		rows := make([]noveltools.AnalyticsUsageRow, 0, len(runs))
+		for _, run := range runs {
+			rows = append(rows, noveltools.NewAnalyticsUsageRow(run))
+		}
+		last := runs[len(runs)-1]
+		return newAnalyticsPage(rows, len(runs), last.CreatedAt, last.ID)
+
+	case novel.AnalyticsDatasetCosts:
+		records, err := s.costRecordRepo.FindCreatedAfter(ctx, after, afterID, limit)
+		if err != nil || len(records) == 0 {
+			return &analyticsPage{}, err
+		}
+		rows := make([]noveltools.AnalyticsCostRow, 0, len(records))
+		for _, record := range records {
+			rows = append(rows, noveltools.NewAnalyticsCostRow(record))
+		}
+		last := records[len(records)-1]
+		return newAnalyticsPage(rows, len(records), last.CreatedAt, last.ID)
+
+	case novel.AnalyticsDatasetQAScores:
+		// 以最终视频阶段的执行记录为游标：章节每次成功生成最终视频后按当时的产物计算一次评分
+		runs, err := s.stageRunRepo.FindCreatedAfter(ctx, after, afterID, novel.PipelineStageFinalVideo, limit)
+		if err != nil || len(runs) == 0 {
+			return &analyticsPage{}, err
+		}
+		rows := make([]noveltools.AnalyticsQAScoreRow, 0, len(runs))
+		for _, run := range runs {
+			if run.ErrorMessage != "" {
+				continue
+			}
+			chapter, err := s.chapterRepo.FindByID(ctx, run.ChapterID)
+			if err != nil {
+				log.Warn().Err(err).Str("chapter_id", run.ChapterID).Msg("查询章节失败，跳过 QA 评分导出")
+				continue
+			}
+			card, err := s.chapterQAScorecard(ctx, chapter, VersionSelection{})
+			if err != nil {
+				log.Warn().Err(err).Str("chapter_id", run.ChapterID).Msg("计算 QA 评分失败，跳过 QA 评分导出")
+				continue
+			}
+			rows = append(rows, noveltools.NewAnalyticsQAScoreRow(card, run.ID))
+		}
+		last := runs[len(runs)-1]
+		return newAnalyticsPage(rows, len(runs), last.CreatedAt, last.ID)
+	}
+	return nil, fmt.Errorf("%w: unknown dataset %q", ErrInvalidAnalyticsExport, dataset)
+}
+
+// newAnalyticsPage 编码一页导出行
+func newAnalyticsPage[T any](rows []T, sourceRows int, toTime time.Time, toID string) (*analyticsPage, error) {
+	data, err := noveltools.EncodeJSONLines(rows)
+	if err != nil {
+		return nil, fmt.Errorf("encode rows: %w", err)
+	}
+	return &analyticsPage{data: data, rows: len(rows), sourceRows: sourceRows, toTime: toTime, toID: toID}, nil
+}
+
+// ListAnalyticsExportBatches 查询导出批次
+func (s *novelService) ListAnalyticsExportBatches(ctx context.Context, dataset novel.AnalyticsDataset, afterSeq int64, limit int) ([]*novel.AnalyticsExportBatch, error) {
+	if !dataset.IsValid() {
+		return nil, fmt.Errorf("%w: unknown dataset %q", ErrInvalidAnalyticsExport, dataset)
+	}
+	return s.analyticsExportBatchRepo.FindAfterSeq(ctx, dataset, afterSeq, limit)
+}
+
+// GetAnalyticsSchemas 查询各数据集的行结构
+func (s *novelService) GetAnalyticsSchemas() []AnalyticsDatasetSchema {
+	schemas := make([]AnalyticsDatasetSchema, 0, len(novel.AnalyticsDatasets))
+	for _, dataset := range novel.AnalyticsDatasets {
+		schemas = append(schemas, AnalyticsDatasetSchema{
+			Dataset:       dataset,
+			SchemaVersion: noveltools.AnalyticsSchemaVersion(dataset),
+			Fields:        noveltools.AnalyticsSchema(dataset),
+		})
+	}
+	return schemas
+}
+
+// StartAnalyticsExport 启动定时导出：每个间隔导出一次，还有未导出的记录时继续导出直到追上
+func (s *novelService) StartAnalyticsExport(ctx context.Context) {
+	if s.analyticsExportInterval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(s.analyticsExportInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			for ctx.Err() == nil {
+				result, err := s.ExportAnalytics(ctx, &ExportAnalyticsRequest{})
+				if err != nil {
+					log.Warn().Err(err).Msg("定时导出分析数据失败")
+					break
+				}
+				if !analyticsExportHasMore(result) {
+					break
+				}
+			}
+		}
+	}()
+	log.Info().Dur("interval", s.analyticsExportInterval).Msg("分析数据定时导出已启动")
+}
+
+// analyticsExportHasMore 是否有数据集还有未导出的记录
+func analyticsExportHasMore(result *AnalyticsExportResult) bool {
+	for _, export := range result.Datasets {
+		if export.HasMore {
+			return true
+		}
+	}
+	return false
+}
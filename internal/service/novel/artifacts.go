@@ -0,0 +1,235 @@
+package novel
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/noveltools"
+)
+
+// ArtifactService 章节产物列表服务接口
+type ArtifactService interface {
+	// ListChapterArtifacts 列出章节生成的所有产物（音频、字幕、图片、视频），按类型、状态、版本筛选；
+	// 每个产物使用统一的结构，并带有查看依赖关系、质量评分和审核数据的接口链接
+	ListChapterArtifacts(ctx context.Context, chapterID string, filter *noveltools.ArtifactFilter) (*ChapterArtifacts, error)
+}
+
+// ChapterArtifact 章节产物（各类型统一的结构）
+type ChapterArtifact struct {
+	ID           string                `json:"id"`
+	Type         novel.VersionArtifact `json:"type"`                    // 产物类型：audio, subtitle, image, video
+	VideoType    novel.VideoType       `json:"video_type,omitempty"`    // 视频类型（仅视频）：narration_video, final_video, novel_video
+	Stage        novel.PipelineStage   `json:"stage,omitempty"`         // 对应的流程阶段
+	NarrationID  string                `json:"narration_id,omitempty"`  // 关联的解说ID
+	ShotID       string                `json:"shot_id,omitempty"`       // 关联的镜头ID
+	Sequence     int                   `json:"sequence"`                // 序号（从1开始）
+	Version      int                   `json:"version"`                 // 版本号
+	Latest       bool                  `json:"latest"`                  // 是否为该类型的最新版本
+	Promoted     bool                  `json:"promoted"`                // 是否为该类型的发布版本
+	Status       string                `json:"status"`                  // 状态：pending, processing, completed, failed
+	Stale        bool                  `json:"stale,omitempty"`         // 是否已过期（仅视频：依赖的音频片段已重新合成）
+	ResourceID   string                `json:"resource_id,omitempty"`   // 产物文件的 resource_id
+	Duration     float64               `json:"duration,omitempty"`      // 时长（秒，仅音频和视频）
+	ErrorMessage string                `json:"error_message,omitempty"` // 错误信息（仅视频）
+	Links        ArtifactLinks         `json:"links"`
+	CreatedAt    time.Time             `json:"created_at"`
+	UpdatedAt    time.Time             `json:"updated_at"`
+}
+
+// ArtifactLinks 产物相关数据的接口链接
+type ArtifactLinks struct {
+	Download  string `json:"download,omitempty"` // 下载产物文件
+	Lineage   string `json:"lineage,omitempty"`  // 依赖关系：重新生成该产物的影响范围
+	Manifest  string `json:"manifest,omitempty"` // 流水线清单（仅有清单的视频，记录生成用到的全部上游产物）
+	QC        string `json:"qc"`                 // 质量评分卡（按该产物的版本评分）
+	Review    string `json:"review"`             // 内容风险审核报告
+	Promotion string `json:"promotion"`          // 发布版本记录
+}
+
+// ChapterArtifacts 章节产物列表
+type ChapterArtifacts struct {
+	ChapterID      string                        `json:"chapter_id"`
+	Approved       bool                          `json:"approved"`        // 章节是否已审核通过
+	LatestVersions map[novel.VersionArtifact]int `json:"latest_versions"` // 各类型产物的最新版本号（没有产物的类型不返回）
+	Items          []*ChapterArtifact            `json:"items"`           // 产物列表（按类型、版本倒序、序号排序；没有数据时为空数组）
+	Total          int                           `json:"total"`
+}
+
+// ListChapterArtifacts 列出章节生成的所有产物
+func (s *novelService) ListChapterArtifacts(ctx context.Context, chapterID string, filter *noveltools.ArtifactFilter) (*ChapterArtifacts, error) {
+	if filter == nil {
+		filter = &noveltools.ArtifactFilter{}
+	}
+	ch, err := s.chapterRepo.FindByID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+	var promoted novel.PromotedVersions
+	if ch.PromotedVersions != nil {
+		promoted = *ch.PromotedVersions
+	}
+
+	// 1. 查询各类型的产物（所有版本），转换为统一结构
+	var all []*ChapterArtifact
+	if filter.IncludesType(novel.VersionArtifactAudio) {
+		audios, err := s.audioRepo.FindByChapterID(ctx, chapterID)
+		if err != nil {
+			return nil, fmt.Errorf("find audios: %w", err)
+		}
+		for _, a := range audios {
+			all = append(all, &ChapterArtifact{
+				ID:          a.ID,
+				Type:        novel.VersionArtifactAudio,
+				NarrationID: a.NarrationID,
+				ShotID:      a.ShotID,
+				Sequence:    a.Sequence,
+				Version:     a.Version,
+				Promoted:    a.Version == promoted.Audio,
+				Status:      string(a.Status),
+				ResourceID:  a.AudioResourceID,
+				Duration:    a.Duration,
+				CreatedAt:   a.CreatedAt,
+				UpdatedAt:   a.UpdatedAt,
+			})
+		}
+	}
+	if filter.IncludesType(novel.VersionArtifactSubtitle) {
+		subtitles, err := s.subtitleRepo.FindAllByChapterID(ctx, chapterID)
+		if err != nil {
+			return nil, fmt.Errorf("find subtitles: %w", err)
+		}
+		for _, sub := range subtitles {
+			all = append(all, &ChapterArtifact{
+				ID:          sub.ID,
+				Type:        novel.VersionArtifactSubtitle,
+				NarrationID: sub.NarrationID,
+				ShotID:      sub.ShotID,
+				Sequence:    sub.Sequence,
+				Version:     sub.Version,
+				Promoted:    sub.Version == promoted.Subtitle,
+				Status:      string(sub.Status),
+				ResourceID:  sub.SubtitleResourceID,
+				CreatedAt:   sub.CreatedAt,
+				UpdatedAt:   sub.UpdatedAt,
+			})
+		}
+	}
+	if filter.IncludesType(novel.VersionArtifactImage) {
+		images, err := s.imageRepo.FindByChapterID(ctx, chapterID)
+		if err != nil {
+			return nil, fmt.Errorf("find images: %w", err)
+		}
+		for _, img := range images {
+			all = append(all, &ChapterArtifact{
+				ID:          img.ID,
+				Type:        novel.VersionArtifactImage,
+				NarrationID: img.NarrationID,
+				ShotID:      img.ShotID,
+				Sequence:    img.Sequence,
+				Version:     img.Version,
+				Promoted:    img.Version == promoted.Image,
+				Status:      string(img.Status),
+				ResourceID:  img.ImageResourceID,
+				CreatedAt:   img.CreatedAt,
+				UpdatedAt:   img.UpdatedAt,
+			})
+		}
+	}
+	if filter.IncludesType(novel.VersionArtifactVideo) {
+		videos, err := s.videoRepo.FindByChapterID(ctx, chapterID)
+		if err != nil {
+			return nil, fmt.Errorf("find videos: %w", err)
+		}
+		for _, v := range videos {
+			artifact := &ChapterArtifact{
+				ID:           v.ID,
+				Type:         novel.VersionArtifactVideo,
+				VideoType:    v.VideoType,
+				NarrationID:  v.NarrationID,
+				ShotID:       v.ShotID,
+				Sequence:     v.Sequence,
+				Version:      v.Version,
+				Promoted:     v.VideoType == novel.VideoTypeFinal && v.Version == promoted.Video, // 发布版本只记录最终视频
+				Status:       string(v.Status),
+				ResourceID:   v.VideoResourceID,
+				Duration:     v.Duration,
+				Stale:        v.Stale,
+				ErrorMessage: v.ErrorMessage,
+				CreatedAt:    v.CreatedAt,
+				UpdatedAt:    v.UpdatedAt,
+			}
+			if v.ManifestResourceID != "" {
+				artifact.Links.Manifest = fmt.Sprintf("/api/v1/novels/chapters/%s/videos/%s/manifest", chapterID, v.ID)
+			}
+			all = append(all, artifact)
+		}
+	}
+
+	// 2. 各类型的最新版本（筛选之前计算，筛选条件不影响最新版本的判断）
+	latest := make(map[novel.VersionArtifact]int)
+	for _, a := range all {
+		if a.Version > latest[a.Type] {
+			latest[a.Type] = a.Version
+		}
+	}
+
+	// 3. 筛选并补全链接
+	items := make([]*ChapterArtifact, 0, len(all))
+	for _, a := range all {
+		if !filter.Match(a.Status, a.Version, latest[a.Type]) {
+			continue
+		}
+		a.Latest = a.Version == latest[a.Type]
+		a.Stage = noveltools.ArtifactStage(a.Type, a.VideoType)
+		a.Links = artifactLinks(chapterID, a)
+		items = append(items, a)
+	}
+	slices.SortStableFunc(items, func(a, b *ChapterArtifact) int {
+		if c := cmp.Compare(slices.Index(noveltools.ArtifactTypes, a.Type), slices.Index(noveltools.ArtifactTypes, b.Type)); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.Version, a.Version); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Sequence, b.Sequence)
+	})
+
+	return &ChapterArtifacts{
+		ChapterID:      chapterID,
+		Approved:       ch.ApprovedAt != nil,
+		LatestVersions: latest,
+		Items:          items,
+		Total:          len(items),
+	}, nil
+}
+
+// artifactLinks 产物相关数据的接口链接
+// 依赖关系链接到该产物所属阶段的重新生成影响分析；质量评分卡按产物的版本评分（字幕和解说视频没有单独的评分版本，使用默认版本）
+func artifactLinks(chapterID string, a *ChapterArtifact) ArtifactLinks {
+	chapterPath := "/api/v1/novels/chapters/" + chapterID
+	links := ArtifactLinks{
+		Manifest:  a.Links.Manifest,
+		QC:        chapterPath + "/qa-scorecard",
+		Review:    chapterPath + "/content-risk-reports",
+		Promotion: chapterPath + "/promotions",
+	}
+	if a.ResourceID != "" {
+		links.Download = fmt.Sprintf("/api/v1/resources/%s/download", a.ResourceID)
+	}
+	if a.Stage != "" {
+		links.Lineage = fmt.Sprintf("%s/regeneration-impact?stage=%s", chapterPath, a.Stage)
+	}
+	switch {
+	case a.Type == novel.VersionArtifactAudio:
+		links.QC += fmt.Sprintf("?audio_version=%d", a.Version)
+	case a.Type == novel.VersionArtifactImage:
+		links.QC += fmt.Sprintf("?image_version=%d", a.Version)
+	case a.Type == novel.VersionArtifactVideo && a.VideoType == novel.VideoTypeFinal:
+		links.QC += fmt.Sprintf("?video_version=%d", a.Version)
+	}
+	return links
+}
@@ -0,0 +1,120 @@
+package novel
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ffmpeg"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/noveltools"
+)
+
+// AttributionService 小说署名服务接口
+type AttributionService interface {
+	// GetAttribution 查询小说的署名设置（未设置时返回 nil）
+	GetAttribution(ctx context.Context, novelID string) (*novel.Attribution, error)
+
+	// SetAttribution 设置小说的署名（整体替换），只影响之后生成的最终视频
+	SetAttribution(ctx context.Context, novelID string, attribution *novel.Attribution) (*novel.Attribution, error)
+}
+
+// GetAttribution 查询小说的署名设置
+func (s *novelService) GetAttribution(ctx context.Context, novelID string) (*novel.Attribution, error) {
+	n, err := s.novelRepo.FindByID(ctx, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+	return n.Attribution, nil
+}
+
+// SetAttribution 设置小说的署名
+// 校验失败返回 noveltools.ErrInvalidAttribution
+func (s *novelService) SetAttribution(ctx context.Context, novelID string, attribution *novel.Attribution) (*novel.Attribution, error) {
+	normalized, err := noveltools.NormalizeAttribution(attribution)
+	if err != nil {
+		return nil, err
+	}
+	normalized.UpdatedAt = time.Now()
+
+	if err := s.novelRepo.Update(ctx, novelID, map[string]interface{}{"attribution": normalized}); err != nil {
+		return nil, fmt.Errorf("update attribution: %w", err)
+	}
+
+	log.Info().
+		Str("novel_id", novelID).
+		Bool("required", normalized.Required).
+		Str("placement", string(normalized.Placement)).
+		Str("text", noveltools.AttributionText(normalized)).
+		Msg("小说署名设置已更新")
+	return normalized, nil
+}
+
+// renderAttribution 在最终视频（章节最终视频、回顾合集）中渲染小说的署名，返回渲染后的视频路径和署名记录
+// 小说没有署名内容时原样返回；要求署名的小说渲染失败时返回错误（不能导出没有署名的视频），否则跳过署名
+func (s *novelService) renderAttribution(ctx context.Context, novelID, inputPath, tmpDir string, platform novel.TargetPlatform, ffmpegClient *ffmpeg.Client) (string, *novel.AttributionRecord, error) {
+	n, err := s.novelRepo.FindByID(ctx, novelID)
+	if err != nil {
+		return "", nil, fmt.Errorf("find novel: %w", err)
+	}
+	attribution := n.Attribution
+	text := noveltools.AttributionText(attribution)
+	if text == "" {
+		if attribution != nil && attribution.Required {
+			return "", nil, fmt.Errorf("novel %s requires attribution but has no attribution text", n.ID)
+		}
+		return inputPath, nil, nil
+	}
+
+	fail := func(err error) (string, *novel.AttributionRecord, error) {
+		if attribution.Required {
+			return "", nil, fmt.Errorf("render required attribution: %w", err)
+		}
+		log.Warn().Err(err).Str("novel_id", novelID).Msg("渲染署名失败，跳过署名")
+		return inputPath, nil, nil
+	}
+
+	endFrame := attribution.Placement == novel.AttributionPlacementEndFrame
+	var videoDuration float64
+	if endFrame {
+		info, err := ffmpegClient.GetVideoInfo(ctx, inputPath)
+		if err != nil {
+			return fail(fmt.Errorf("get video info: %w", err))
+		}
+		videoDuration = info.Duration
+	}
+
+	outputPath := filepath.Join(tmpDir, fmt.Sprintf("attributed_%s.mp4", id.New()))
+	area := noveltools.SafeAreaForPlatform(platform)
+	if err := ffmpegClient.AddAttribution(ctx, inputPath, outputPath, ffmpeg.Attribution{
+		Text:          text,
+		FontPath:      os.Getenv("WATERMARK_FONT_PATH"),
+		EndFrame:      endFrame,
+		Duration:      attribution.Duration,
+		VideoDuration: videoDuration,
+		Top:           area.Top,
+		Left:          area.Left,
+		Right:         area.Right,
+	}); err != nil {
+		os.Remove(outputPath)
+		return fail(err)
+	}
+
+	log.Info().
+		Str("novel_id", novelID).
+		Str("text", text).
+		Str("placement", string(attribution.Placement)).
+		Bool("required", attribution.Required).
+		Msg("视频已添加署名")
+	return outputPath, &novel.AttributionRecord{
+		Text:      text,
+		Placement: attribution.Placement,
+		Duration:  attribution.Duration,
+		Required:  attribution.Required,
+	}, nil
+}
@@ -9,6 +9,7 @@ import (
 
 	"lemon/internal/model/novel"
 	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/killswitch"
 	"lemon/internal/pkg/noveltools"
 	"lemon/internal/service"
 )
@@ -39,6 +40,24 @@ type AudioService interface {
 //   - []string: 生成的章节音频ID列表
 //   - error: 错误信息
 func (s *novelService) GenerateAudiosForNarration(ctx context.Context, narrationID string) ([]string, error) {
+	var ids []string
+	err := s.runNarrationStage(ctx, narrationID, novel.PipelineStageAudio, func(ctx context.Context) error {
+		var err error
+		ids, err = s.generateAudiosForNarration(ctx, narrationID)
+		setStageResources(ctx, ids...)
+		return err
+	})
+	return ids, err
+}
+
+// generateAudiosForNarration 生成章节音频片段（阶段耗时与费用由 GenerateAudiosForNarration 记录）
+func (s *novelService) generateAudiosForNarration(ctx context.Context, narrationID string) ([]string, error) {
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderTTS)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	// 1. 从数据库获取章节解说
 	narration, err := s.narrationRepo.FindByID(ctx, narrationID)
 	if err != nil {
@@ -56,38 +75,40 @@ func (s *novelService) GenerateAudiosForNarration(ctx context.Context, narration
 	}
 
 	// 3. 自动生成下一个版本号（基于章节ID，独立递增）
-	audioVersion, err := s.getNextAudioVersion(ctx, narration.ChapterID, 0)
+	audioVersion, err := s.nextVersion(ctx, narration.ChapterID, novel.VersionArtifactAudio)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get next audio version: %w", err)
 	}
 
-	// 4. 从 Shot 表中提取所有解说文本（按 index 排序）
-	var narrationTexts []string
+	// 4. 从 Shot 表中提取所有有解说文本的镜头（按 index 排序）
+	var narrationShots []*novel.Shot
 	for _, shot := range shots {
 		if shot.Narration != "" {
-			narrationTexts = append(narrationTexts, shot.Narration)
+			narrationShots = append(narrationShots, shot)
 		}
 	}
 
-	if len(narrationTexts) == 0 {
+	if len(narrationShots) == 0 {
 		return nil, fmt.Errorf("no narration texts found")
 	}
 
 	// 3. 为每段解说文本生成章节音频
 	textCleaner := noveltools.NewTextCleaner()
+	numberStyle := s.novelNumberStyle(ctx, narration.NovelID)
+	voice := s.novelVoice(ctx, narration.NovelID)
 	var audioIDs []string
-	for i, narrationText := range narrationTexts {
+	for i, shot := range narrationShots {
 		sequence := i + 1
 
-		// 清理文本用于TTS
-		cleanText := textCleaner.CleanTextForTTS(narrationText)
+		// 按小说设置统一数字写法，再清理文本用于TTS
+		cleanText := textCleaner.CleanTextForTTS(noveltools.NormalizeNumbers(shot.Narration, numberStyle))
 		if cleanText == "" {
 			log.Warn().Int("sequence", sequence).Msg("清理后的文本为空，跳过")
 			continue
 		}
 
 		// 生成章节音频
-		audioID, err := s.generateSingleAudio(ctx, narration, sequence, cleanText, audioVersion)
+		audioID, err := s.generateSingleAudio(ctx, narration, shot.ID, sequence, cleanText, audioVersion, voice)
 		if err != nil {
 			log.Error().Err(err).Int("sequence", sequence).Msg("生成章节音频失败")
 			return nil, fmt.Errorf("failed to generate audio for sequence %d: %w", sequence, err)
@@ -103,23 +124,31 @@ func (s *novelService) GenerateAudiosForNarration(ctx context.Context, narration
 func (s *novelService) generateSingleAudio(
 	ctx context.Context,
 	narration *novel.Narration,
+	shotID string,
 	sequence int,
 	text string,
 	version int,
+	voice novel.VoiceSettings,
 ) (string, error) {
-	// 1. 调用 TTS Provider 生成音频（1.2倍速，参考 Python 脚本）
-	speedRatio := 1.2
-	ttsResult, err := s.ttsProvider.GenerateVoiceWithTimestamps(ctx, text, speedRatio)
-	if err != nil {
-		return "", fmt.Errorf("TTS generation failed: %w", err)
+	// 1. 调用 TTS Provider 生成音频（按小说的配音设置，默认 1.2 倍速，参考 Python 脚本）
+	if err := s.checkBudget(ctx, narration.NovelID); err != nil {
+		return "", err
 	}
-
-	if !ttsResult.Success {
-		return "", fmt.Errorf("TTS generation failed: %s", ttsResult.ErrorMessage)
+	// 超过 TTS 单次请求上限的文本会按句子分段合成后拼接
+	speedRatio := voice.SpeedRatio
+	ttsResult, segments, err := s.synthesizeTTS(ctx, narration, sequence, text, voice)
+	if err != nil {
+		return "", err
 	}
 
 	// 构建 TTS 参数提示词（记录生成参数）
 	ttsPrompt := fmt.Sprintf("TTS参数: speedRatio=%.2f, textLength=%d", speedRatio, len(text))
+	if voice.VoiceType != "" {
+		ttsPrompt += fmt.Sprintf(", voiceType=%s", voice.VoiceType)
+	}
+	if segments > 1 {
+		ttsPrompt += fmt.Sprintf(", segments=%d", segments)
+	}
 
 	// 2. 通过 resource 模块上传音频文件（直接使用返回的音频数据）
 	userID := narration.UserID
@@ -133,6 +162,7 @@ func (s *novelService) generateSingleAudio(
 		ContentType: contentType,
 		Ext:         ext,
 		Data:        bytes.NewReader(ttsResult.AudioData),
+		KeyVars:     s.chapterKeyVarsByID(ctx, narration.NovelID, narration.ChapterID, artifactAudio, version, sequence),
 	}
 
 	uploadResult, err := s.resourceService.UploadFile(ctx, uploadReq)
@@ -182,6 +212,7 @@ func (s *novelService) generateSingleAudio(
 		ChapterID:   narration.ChapterID,
 		NovelID:     chapter.NovelID,
 		UserID:      narration.UserID,
+		ShotID:      shotID,
 		Sequence:        sequence,
 		AudioResourceID: resourceID,
 		Duration:        audioDuration,
@@ -195,55 +226,8 @@ func (s *novelService) generateSingleAudio(
 	if err := s.audioRepo.Create(ctx, audioEntity); err != nil {
 		return "", fmt.Errorf("failed to create audio record: %w", err)
 	}
+	s.generateAudioWaveform(ctx, audioEntity)
 
 	return audioID, nil
 }
 
-// getNextAudioVersion 获取章节的下一个音频版本号（自动递增）
-// chapterID: 章节ID
-// baseVersion: 基础版本号（如 1），如果为0则自动生成下一个版本号
-func (s *novelService) getNextAudioVersion(ctx context.Context, chapterID string, baseVersion int) (int, error) {
-	versions, err := s.audioRepo.FindVersionsByChapterID(ctx, chapterID)
-	if err != nil {
-		// 如果没有找到任何版本，返回 1 或基础版本号
-		if baseVersion == 0 {
-			return 1, nil
-		}
-		return baseVersion, nil
-	}
-
-	if len(versions) == 0 {
-		if baseVersion == 0 {
-			return 1, nil
-		}
-		return baseVersion, nil
-	}
-
-	// 如果指定了基础版本号，检查该版本是否已存在
-	if baseVersion > 0 {
-		for _, v := range versions {
-			if v == baseVersion {
-				// 该版本已存在，返回下一个版本号
-				maxVersion := 0
-				for _, v := range versions {
-					if v > maxVersion {
-						maxVersion = v
-					}
-				}
-				return maxVersion + 1, nil
-			}
-		}
-		// 该版本不存在，直接返回
-		return baseVersion, nil
-	}
-
-	// 如果没有指定基础版本号，查找所有版本号中的最大值
-	maxVersion := 0
-	for _, v := range versions {
-		if v > maxVersion {
-			maxVersion = v
-		}
-	}
-
-	return maxVersion + 1, nil
-}
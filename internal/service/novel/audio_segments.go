@@ -0,0 +1,148 @@
+package novel
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ffmpeg"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/killswitch"
+	"lemon/internal/pkg/noveltools"
+	"lemon/internal/pkg/procreaper"
+)
+
+// ttsSegmentMaxCharsFromEnv 读取单次 TTS 请求的最大字符数（TTS_MAX_SEGMENT_CHARS），未配置时使用默认值
+func ttsSegmentMaxCharsFromEnv() int {
+	if v, err := strconv.Atoi(os.Getenv("TTS_MAX_SEGMENT_CHARS")); err == nil && v > 0 {
+		return v
+	}
+	return noveltools.DefaultTTSSegmentMaxChars
+}
+
+// synthesizeTTS 合成一段解说的语音（按小说的配音设置选择音色和语速）
+// 文本超过 TTS 单次请求上限时按句子切分后逐段合成，每段都按小说发音词典纠正读音，音频按顺序拼接（段间插入自然停顿），时间戳整体合并
+//
+// Returns:
+//   - *noveltools.TTSResult: 合成结果（分段合成时为拼接后的音频和合并后的时间戳）
+//   - int: 分段数
+//   - error: 错误信息
+func (s *novelService) synthesizeTTS(ctx context.Context, narration *novel.Narration, sequence int, text string, voice novel.VoiceSettings) (*noveltools.TTSResult, int, error) {
+	segments := noveltools.SplitTTSSegments(text, s.ttsSegmentMaxChars)
+	if len(segments) == 0 {
+		return nil, 0, fmt.Errorf("TTS text is empty")
+	}
+
+	hints := s.novelPronunciationHints(ctx, narration.NovelID)
+	parts := make([]*noveltools.TTSResult, 0, len(segments))
+	for i, segment := range segments {
+		if i > 0 {
+			if err := s.checkBudget(ctx, narration.NovelID); err != nil {
+				return nil, 0, err
+			}
+		}
+		result, err := s.generateVoice(ctx, segment, voice, hints)
+		if err != nil {
+			return nil, 0, fmt.Errorf("TTS generation failed: %w", err)
+		}
+		if !result.Success {
+			return nil, 0, fmt.Errorf("TTS generation failed: %s", result.ErrorMessage)
+		}
+		chars := utf8.RuneCountInString(segment)
+		s.recordCost(ctx, narration.NovelID, narration.ChapterID, killswitch.ProviderTTS, float64(chars), s.pricing.TTS(chars))
+		parts = append(parts, result)
+	}
+	if len(parts) == 1 {
+		return parts[0], 1, nil
+	}
+
+	audioData, err := stitchTTSAudio(ctx, parts, noveltools.DefaultTTSSegmentGap)
+	if err != nil {
+		return nil, 0, err
+	}
+	timestamps := noveltools.MergeTTSTimestamps(parts, noveltools.DefaultTTSSegmentGap)
+
+	log.Info().
+		Str("narration_id", narration.ID).
+		Int("sequence", sequence).
+		Int("segments", len(parts)).
+		Float64("duration", timestamps.Duration).
+		Msg("超长解说已分段合成并拼接")
+
+	return &noveltools.TTSResult{
+		Success:       true,
+		AudioData:     audioData,
+		Duration:      timestamps.Duration,
+		TimestampData: timestamps,
+	}, len(parts), nil
+}
+
+// generateVoice 调用 TTS 提供者合成语音，按小说发音词典纠正读音（见 planPronunciation），时间戳按原文返回
+func (s *novelService) generateVoice(ctx context.Context, text string, voice novel.VoiceSettings, hints []noveltools.PronunciationHint) (*noveltools.TTSResult, error) {
+	if len(hints) == 0 {
+		return s.generatePlainVoice(ctx, text, voice)
+	}
+
+	plan := s.planPronunciation(text, hints)
+	var result *noveltools.TTSResult
+	var err error
+	if provider, ok := s.ttsProvider.(noveltools.PronunciationTTSProvider); ok && plan.ssml != "" {
+		result, err = provider.GenerateVoiceWithPronunciations(ctx, plan.spoken, voice.SpeedRatio, voice.VoiceType, plan.phonemes)
+	} else {
+		result, err = s.generatePlainVoice(ctx, plan.spoken, voice)
+	}
+	if err == nil && result != nil {
+		noveltools.RestorePronunciationTimestamps(result.TimestampData, plan.spoken, text, plan.subs)
+	}
+	return result, err
+}
+
+// generatePlainVoice 调用 TTS 提供者合成语音，提供者支持指定音色且小说配置了音色时使用该音色
+func (s *novelService) generatePlainVoice(ctx context.Context, text string, voice novel.VoiceSettings) (*noveltools.TTSResult, error) {
+	if provider, ok := s.ttsProvider.(noveltools.VoiceSelectableTTSProvider); ok && voice.VoiceType != "" {
+		return provider.GenerateVoiceWithTimestampsForVoice(ctx, text, voice.SpeedRatio, voice.VoiceType)
+	}
+	return s.ttsProvider.GenerateVoiceWithTimestamps(ctx, text, voice.SpeedRatio)
+}
+
+// stitchTTSAudio 将分段合成的音频按顺序拼接，段间插入 gap 秒停顿
+func stitchTTSAudio(ctx context.Context, parts []*noveltools.TTSResult, gap float64) ([]byte, error) {
+	tmpDir, cleanupTmpDir, err := procreaper.MkdirTemp(ctx, "tts_segments_")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir: %w", err)
+	}
+	defer cleanupTmpDir()
+
+	paths := make([]string, 0, len(parts))
+	for i, part := range parts {
+		path := filepath.Join(tmpDir, fmt.Sprintf("segment_%02d.%s", i+1, audioExt(part.AudioData)))
+		if err := os.WriteFile(path, part.AudioData, 0o644); err != nil {
+			return nil, fmt.Errorf("write audio segment: %w", err)
+		}
+		paths = append(paths, path)
+	}
+
+	outputPath := filepath.Join(tmpDir, fmt.Sprintf("stitched_%s.mp3", id.New()))
+	if err := ffmpeg.NewClient().ConcatAudios(ctx, paths, outputPath, gap); err != nil {
+		return nil, fmt.Errorf("stitch audio segments: %w", err)
+	}
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("read stitched audio: %w", err)
+	}
+	return data, nil
+}
+
+// audioExt 根据文件头判断音频格式（WAV 或 mp3），用于临时文件扩展名
+func audioExt(data []byte) string {
+	if len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WAVE" {
+		return "wav"
+	}
+	return "mp3"
+}
@@ -0,0 +1,425 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ffmpeg"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/noveltools"
+	"lemon/internal/pkg/storage"
+	"lemon/internal/service"
+)
+
+// ErrInvalidBGMTrack 背景音乐曲目不合法（不是音频文件、情绪未知或音量超出范围）
+var ErrInvalidBGMTrack = errors.New("invalid bgm track")
+
+// BGMService 背景音乐曲库服务接口
+type BGMService interface {
+	// CreateBGMTrack 上传背景音乐曲目并标注适用的场景情绪
+	CreateBGMTrack(ctx context.Context, req *CreateBGMTrackRequest) (*novel.BGMTrack, error)
+
+	// ListBGMTracks 查询曲库，mood、tag 为空时不按该条件过滤
+	ListBGMTracks(ctx context.Context, mood novel.SceneMood, tag string) ([]*novel.BGMTrack, error)
+
+	// DeleteBGMTrack 删除曲目（已合成的视频不受影响）
+	DeleteBGMTrack(ctx context.Context, trackID string) error
+
+	// SetChapterBGM 设置章节的背景音乐：整章使用指定曲目，或不加背景音乐
+	SetChapterBGM(ctx context.Context, chapterID string, req *SetChapterBGMRequest) (*novel.ChapterBGM, error)
+
+	// ClearChapterBGM 清空章节的背景音乐选择，恢复按场景情绪自动选择
+	ClearChapterBGM(ctx context.Context, chapterID string) error
+}
+
+// CreateBGMTrackRequest 上传背景音乐曲目请求
+type CreateBGMTrackRequest struct {
+	Name        string          // 曲目名称（为空时使用文件名）
+	Mood        novel.SceneMood // 适用的场景情绪
+	Tags        []string        // 自定义标签
+	Volume      float64         // 混音音量（0-1，为 0 时使用 DefaultBGMVolume）
+	UserID      string          // 上传用户ID
+	FileName    string          // 文件名
+	ContentType string          // MIME类型（必须为 audio/*）
+	Data        io.Reader       // 文件数据
+}
+
+// SetChapterBGMRequest 设置章节背景音乐请求
+type SetChapterBGMRequest struct {
+	TrackID  string  // 整章使用的曲目（Disabled 为 false 时必需）
+	Volume   float64 // 混音音量（0-1，为 0 时使用曲目的音量）
+	Disabled bool    // 不加背景音乐
+	UserID   string  // 操作用户ID
+}
+
+// bgmCrossfadeFromEnv 读取场景切换曲目时的交叉淡化时长（秒），未配置或非法时使用默认值
+func bgmCrossfadeFromEnv() float64 {
+	if v, err := strconv.ParseFloat(os.Getenv("BGM_CROSSFADE_SECONDS"), 64); err == nil && v >= 0 {
+		return v
+	}
+	return noveltools.DefaultBGMCrossfade
+}
+
+// bgmDuckingFromEnv 读取解说时压低背景音乐的参数，未配置或非法时使用默认值
+//   - BGM_DUCK_RATIO: 压缩比（1 表示不压低，默认 6）
+//   - BGM_DUCK_THRESHOLD: 触发阈值（0-1，默认 0.03）
+//   - BGM_DUCK_RELEASE_MS: 解说停顿后恢复音量的时间（毫秒，默认 400）
+func bgmDuckingFromEnv() ffmpeg.BGMDucking {
+	ducking := ffmpeg.DefaultBGMDucking
+	if v, err := strconv.ParseFloat(os.Getenv("BGM_DUCK_RATIO"), 64); err == nil && v >= 1 && v <= 20 {
+		ducking.Ratio = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("BGM_DUCK_THRESHOLD"), 64); err == nil && v > 0 && v <= 1 {
+		ducking.Threshold = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("BGM_DUCK_RELEASE_MS"), 64); err == nil && v > 0 && v <= 9000 {
+		ducking.Release = v
+	}
+	return ducking
+}
+
+// normalizeBGMTags 去掉空白和重复的标签
+func normalizeBGMTags(tags []string) []string {
+	var normalized []string
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// CreateBGMTrack 上传背景音乐曲目
+// 上传前探测音频时长，探测失败说明文件不是可用的音频
+func (s *novelService) CreateBGMTrack(ctx context.Context, req *CreateBGMTrackRequest) (*novel.BGMTrack, error) {
+	if !strings.HasPrefix(req.ContentType, "audio/") {
+		return nil, fmt.Errorf("%w: file must be audio", ErrInvalidBGMTrack)
+	}
+	if !req.Mood.IsValid() {
+		return nil, fmt.Errorf("%w: unknown mood %q", ErrInvalidBGMTrack, req.Mood)
+	}
+	if req.Volume < 0 || req.Volume > 1 {
+		return nil, fmt.Errorf("%w: volume must be between 0 and 1", ErrInvalidBGMTrack)
+	}
+	volume := req.Volume
+	if volume == 0 {
+		volume = novel.DefaultBGMVolume
+	}
+	name := req.Name
+	if name == "" {
+		name = strings.TrimSuffix(req.FileName, filepath.Ext(req.FileName))
+	}
+
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(req.FileName)), ".")
+	if ext == "" {
+		ext = strings.TrimPrefix(req.ContentType, "audio/")
+	}
+
+	tmpPath := filepath.Join(os.TempDir(), fmt.Sprintf("bgm_upload_%s.%s", id.New(), ext))
+	defer os.Remove(tmpPath)
+	if err := writeTempFile(tmpPath, req.Data); err != nil {
+		return nil, fmt.Errorf("write bgm temp file: %w", err)
+	}
+	audioInfo, err := ffmpeg.NewClient().GetAudioInfo(ctx, tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: probe audio: %v", ErrInvalidBGMTrack, err)
+	}
+
+	file, err := os.Open(tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("open bgm temp file: %w", err)
+	}
+	defer file.Close()
+
+	uploadResult, err := s.resourceService.UploadFile(ctx, &service.UploadFileRequest{
+		UserID:      req.UserID,
+		FileName:    req.FileName,
+		ContentType: req.ContentType,
+		Ext:         ext,
+		Data:        file,
+		KeyVars:     &storage.KeyVars{ArtifactType: artifactBGM},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("upload bgm track: %w", err)
+	}
+
+	track := &novel.BGMTrack{
+		ID:         id.New(),
+		Name:       name,
+		Mood:       req.Mood,
+		Tags:       normalizeBGMTags(req.Tags),
+		ResourceID: uploadResult.ResourceID,
+		Duration:   audioInfo.Duration,
+		Volume:     volume,
+		UserID:     req.UserID,
+	}
+	if err := s.bgmTrackRepo.Create(ctx, track); err != nil {
+		return nil, fmt.Errorf("create bgm track: %w", err)
+	}
+
+	log.Info().
+		Str("bgm_track_id", track.ID).
+		Str("mood", string(track.Mood)).
+		Float64("duration", track.Duration).
+		Msg("背景音乐曲目上传成功")
+
+	return track, nil
+}
+
+// ListBGMTracks 查询曲库
+func (s *novelService) ListBGMTracks(ctx context.Context, mood novel.SceneMood, tag string) ([]*novel.BGMTrack, error) {
+	var tracks []*novel.BGMTrack
+	var err error
+	switch {
+	case mood == "":
+		tracks, err = s.bgmTrackRepo.FindAll(ctx)
+	case !mood.IsValid():
+		return nil, fmt.Errorf("%w: unknown mood %q", ErrInvalidBGMTrack, mood)
+	default:
+		tracks, err = s.bgmTrackRepo.FindByMood(ctx, mood)
+	}
+	if err != nil || tag == "" {
+		return tracks, err
+	}
+	return slices.DeleteFunc(tracks, func(t *novel.BGMTrack) bool { return !slices.Contains(t.Tags, tag) }), nil
+}
+
+// DeleteBGMTrack 删除曲目
+func (s *novelService) DeleteBGMTrack(ctx context.Context, trackID string) error {
+	if _, err := s.bgmTrackRepo.FindByID(ctx, trackID); err != nil {
+		return fmt.Errorf("find bgm track: %w", err)
+	}
+	return s.bgmTrackRepo.Delete(ctx, trackID)
+}
+
+// SetChapterBGM 设置章节的背景音乐
+// 之后合成的最终视频整章使用该曲目（或不加背景音乐），已合成的视频不受影响
+func (s *novelService) SetChapterBGM(ctx context.Context, chapterID string, req *SetChapterBGMRequest) (*novel.ChapterBGM, error) {
+	if req.Volume < 0 || req.Volume > 1 {
+		return nil, fmt.Errorf("%w: volume must be between 0 and 1", ErrInvalidBGMTrack)
+	}
+	bgm := &novel.ChapterBGM{
+		Disabled:   req.Disabled,
+		SelectedBy: req.UserID,
+		SelectedAt: time.Now(),
+	}
+	if !req.Disabled {
+		if req.TrackID == "" {
+			return nil, fmt.Errorf("%w: track_id is required", ErrInvalidBGMTrack)
+		}
+		if _, err := s.bgmTrackRepo.FindByID(ctx, req.TrackID); err != nil {
+			return nil, fmt.Errorf("find bgm track: %w", err)
+		}
+		bgm.TrackID = req.TrackID
+		bgm.Volume = req.Volume
+	}
+
+	if err := s.chapterRepo.SetBGM(ctx, chapterID, bgm); err != nil {
+		return nil, fmt.Errorf("set chapter bgm: %w", err)
+	}
+
+	log.Info().
+		Str("chapter_id", chapterID).
+		Str("bgm_track_id", bgm.TrackID).
+		Bool("disabled", bgm.Disabled).
+		Msg("章节背景音乐已设置")
+	return bgm, nil
+}
+
+// ClearChapterBGM 清空章节的背景音乐选择
+func (s *novelService) ClearChapterBGM(ctx context.Context, chapterID string) error {
+	if err := s.chapterRepo.SetBGM(ctx, chapterID, nil); err != nil {
+		return fmt.Errorf("clear chapter bgm: %w", err)
+	}
+	return nil
+}
+
+// mixSceneBGM 为拼接后的视频混入背景音乐，返回混音后的视频路径和使用的曲目时间轴
+// 章节选择了曲目时整章使用该曲目，否则片段按镜头序号对应到场景，场景情绪决定曲目；解说出声时按 bgmDucking 压低背景音乐
+// 曲库为空、场景无法对应或混音失败时返回原视频路径和空时间轴（不影响生成）
+func (s *novelService) mixSceneBGM(ctx context.Context, chapter *novel.Chapter, narrationVideos []*novel.Video, videoPaths []string, mergedPath, tmpDir string, ffmpegClient *ffmpeg.Client) (string, []noveltools.BGMCue) {
+	cues, err := s.planChapterBGM(ctx, chapter, narrationVideos, videoPaths, mergedPath, ffmpegClient)
+	if err != nil {
+		log.Warn().Err(err).Str("chapter_id", chapter.ID).Msg("生成背景音乐时间轴失败，跳过背景音乐")
+		return mergedPath, nil
+	}
+	if len(cues) == 0 {
+		return mergedPath, nil
+	}
+
+	// 同一曲目只下载一次
+	trackPaths := make(map[string]string)
+	segments := make([]ffmpeg.BGMSegment, 0, len(cues))
+	for _, cue := range cues {
+		path, ok := trackPaths[cue.Track.ID]
+		if !ok {
+			path, err = s.downloadBGMTrack(ctx, cue.Track, tmpDir)
+			if err != nil {
+				log.Warn().Err(err).Str("bgm_track_id", cue.Track.ID).Msg("下载背景音乐失败，跳过背景音乐")
+				return mergedPath, nil
+			}
+			defer os.Remove(path)
+			trackPaths[cue.Track.ID] = path
+		}
+		segments = append(segments, ffmpeg.BGMSegment{
+			Path:   path,
+			Start:  cue.Start,
+			End:    cue.End,
+			Volume: cue.Track.Volume,
+		})
+	}
+
+	outputPath := filepath.Join(tmpDir, fmt.Sprintf("bgm_%s.mp4", id.New()))
+	if err := ffmpegClient.MixSceneBGM(ctx, mergedPath, segments, s.bgmCrossfade, s.bgmDucking, outputPath); err != nil {
+		os.Remove(outputPath)
+		log.Warn().Err(err).Str("chapter_id", chapter.ID).Msg("混合背景音乐失败，跳过背景音乐")
+		return mergedPath, nil
+	}
+
+	log.Info().
+		Str("chapter_id", chapter.ID).
+		Int("cues", len(cues)).
+		Bool("ducking", s.bgmDucking.Enabled()).
+		Msg("场景背景音乐已混入")
+	return outputPath, cues
+}
+
+// planChapterBGM 生成章节的背景音乐时间轴
+// 章节选择了曲目时整章一段（曲目已删除时回退到按场景情绪选择）；选择不加背景音乐时返回空
+func (s *novelService) planChapterBGM(ctx context.Context, chapter *novel.Chapter, narrationVideos []*novel.Video, videoPaths []string, mergedPath string, ffmpegClient *ffmpeg.Client) ([]noveltools.BGMCue, error) {
+	if selected := chapter.BGM; selected != nil {
+		if selected.Disabled {
+			return nil, nil
+		}
+		track, err := s.bgmTrackRepo.FindByID(ctx, selected.TrackID)
+		if err == nil {
+			info, err := ffmpegClient.GetVideoInfo(ctx, mergedPath)
+			if err != nil {
+				return nil, fmt.Errorf("probe merged video: %w", err)
+			}
+			if selected.Volume > 0 {
+				overridden := *track
+				overridden.Volume = selected.Volume
+				track = &overridden
+			}
+			return []noveltools.BGMCue{{Track: track, Mood: track.Mood, Start: 0, End: info.Duration}}, nil
+		}
+		log.Warn().Err(err).Str("chapter_id", chapter.ID).Str("bgm_track_id", selected.TrackID).Msg("章节选择的背景音乐曲目不可用，按场景情绪选择")
+	}
+
+	tracks, err := s.bgmTrackRepo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("find bgm tracks: %w", err)
+	}
+	if len(tracks) == 0 {
+		return nil, nil
+	}
+	spans, err := s.sceneSpansForVideos(ctx, narrationVideos, videoPaths, ffmpegClient)
+	if err != nil {
+		return nil, fmt.Errorf("scene spans: %w", err)
+	}
+	return noveltools.PlanSceneBGM(spans, tracks, narrationVideos[0].NarrationID), nil
+}
+
+// sceneSpansForVideos 计算每个场景在拼接后视频中的时间区间
+// 片段按顺序累加时长（时长缺失时探测本地文件），片段的第一个镜头所属场景决定该片段的场景
+func (s *novelService) sceneSpansForVideos(ctx context.Context, narrationVideos []*novel.Video, videoPaths []string, ffmpegClient *ffmpeg.Client) ([]noveltools.SceneSpan, error) {
+	narrationID := narrationVideos[0].NarrationID
+	if narrationID == "" {
+		return nil, fmt.Errorf("narration videos have no narration_id")
+	}
+	shots, err := s.shotRepo.FindByNarrationID(ctx, narrationID)
+	if err != nil {
+		return nil, fmt.Errorf("find shots: %w", err)
+	}
+	scenes, err := s.sceneRepo.FindByNarrationID(ctx, narrationID)
+	if err != nil {
+		return nil, fmt.Errorf("find scenes: %w", err)
+	}
+
+	sceneByID := make(map[string]*novel.Scene, len(scenes))
+	for _, scene := range scenes {
+		sceneByID[scene.ID] = scene
+	}
+	sceneByShotIndex := make(map[int]*novel.Scene, len(shots))
+	for _, shot := range shots {
+		if scene, ok := sceneByID[shot.SceneID]; ok {
+			sceneByShotIndex[shot.Index] = scene
+		}
+	}
+
+	var spans []noveltools.SceneSpan
+	var offset float64
+	for i, video := range narrationVideos {
+		duration := video.Duration
+		if duration <= 0 {
+			info, err := ffmpegClient.GetVideoInfo(ctx, videoPaths[i])
+			if err != nil {
+				return nil, fmt.Errorf("probe video %d: %w", i+1, err)
+			}
+			duration = info.Duration
+		}
+
+		start, _ := video.SequenceRange()
+		scene, ok := sceneByShotIndex[start]
+		if !ok {
+			return nil, fmt.Errorf("no scene for shot sequence %d", start)
+		}
+
+		if n := len(spans); n > 0 && spans[n-1].SceneNumber == scene.SceneNumber {
+			spans[n-1].End = offset + duration
+		} else {
+			spans = append(spans, noveltools.SceneSpan{
+				SceneNumber: scene.SceneNumber,
+				Mood:        scene.Mood,
+				Start:       offset,
+				End:         offset + duration,
+			})
+		}
+		offset += duration
+	}
+	return spans, nil
+}
+
+// downloadBGMTrack 下载曲目到临时文件
+func (s *novelService) downloadBGMTrack(ctx context.Context, track *novel.BGMTrack, tmpDir string) (string, error) {
+	result, err := s.resourceService.DownloadFile(ctx, &service.DownloadFileRequest{
+		ResourceID: track.ResourceID,
+		UserID:     track.UserID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("download bgm track: %w", err)
+	}
+	defer result.Data.Close()
+
+	path := filepath.Join(tmpDir, fmt.Sprintf("bgm_track_%s%s", id.New(), filepath.Ext(result.FileName)))
+	if err := writeTempFile(path, result.Data); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// writeTempFile 把数据写入本地文件
+func writeTempFile(path string, data io.Reader) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
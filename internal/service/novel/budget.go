@@ -0,0 +1,327 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/budget"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/killswitch"
+	"lemon/internal/pkg/taskstats"
+	"lemon/internal/pkg/webhook"
+)
+
+// 预算事件类型（通过事件总线发布，主题为 BudgetEventTopic(novelID)）
+const (
+	BudgetEventWarning  = string(novel.BudgetEventWarning)  // 花费接近上限
+	BudgetEventExceeded = string(novel.BudgetEventExceeded) // 花费达到或超出上限
+)
+
+var (
+	// ErrBudgetExceeded 小说花费已达到上限且开启了超限停止
+	ErrBudgetExceeded = errors.New("novel budget exceeded")
+	// ErrInvalidBudget 预算设置不合法
+	ErrInvalidBudget = errors.New("invalid budget settings")
+)
+
+// BudgetService 预算服务接口
+type BudgetService interface {
+	// SetNovelBudget 设置小说预算（上限、预警比例、超限停止、通知地址）
+	SetNovelBudget(ctx context.Context, novelID string, req *SetNovelBudgetRequest) (*BudgetStatus, error)
+
+	// GetNovelBudget 查询小说预算及实时花费
+	GetNovelBudget(ctx context.Context, novelID string) (*BudgetStatus, error)
+
+	// ListCostRecords 查询小说的费用记录（按时间倒序）
+	ListCostRecords(ctx context.Context, novelID string, limit int) ([]*novel.CostRecord, error)
+
+	// ListBudgetEvents 查询小说的预算事件（按时间倒序）
+	ListBudgetEvents(ctx context.Context, novelID string, limit int) ([]*novel.BudgetEvent, error)
+}
+
+// SetNovelBudgetRequest 设置小说预算请求
+type SetNovelBudgetRequest struct {
+	CapFen     int64   // 费用上限（分），0 表示不限额
+	WarnRatio  float64 // 预警比例（0~1），0 时使用默认值
+	HardStop   bool    // 达到上限后是否停止新的生成调用
+	WebhookURL string  // 预算事件通知地址（可选）
+}
+
+// BudgetStatus 小说预算状态
+type BudgetStatus struct {
+	NovelID       string           `json:"novel_id"`
+	CapFen        int64            `json:"cap_fen"`    // 费用上限（分），0 表示不限额
+	WarnRatio     float64          `json:"warn_ratio"` // 预警比例
+	HardStop      bool             `json:"hard_stop"`  // 是否开启超限停止
+	WebhookURL    string           `json:"webhook_url,omitempty"`
+	WebhookSecret string           `json:"webhook_secret,omitempty"` // 预算通知签名密钥（只在设置新的通知地址时返回）
+	SpentFen      int64            `json:"spent_fen"`                // 已花费（分）
+	RemainingFen  int64            `json:"remaining_fen"`            // 剩余额度（分），不限额时为 0
+	Level         budget.Level     `json:"level"`                    // 预算状态：ok, warning, exceeded
+	ByProvider    map[string]int64 `json:"by_provider"`              // 按 provider 汇总的花费（分）
+	WarnedAt      *time.Time       `json:"warned_at,omitempty"`      // 首次预警时间
+	ExceededAt    *time.Time       `json:"exceeded_at,omitempty"`
+}
+
+// BudgetEventTopic 返回小说预算事件的事件总线主题
+func BudgetEventTopic(novelID string) string {
+	return "budget:" + novelID
+}
+
+// SetNovelBudget 设置小说预算
+// 调整上限后如果不再处于预警/超限状态，会清空对应的触发时间，之后再次达到时重新通知
+func (s *novelService) SetNovelBudget(ctx context.Context, novelID string, req *SetNovelBudgetRequest) (*BudgetStatus, error) {
+	if req.CapFen < 0 || req.WarnRatio < 0 || req.WarnRatio >= 1 {
+		return nil, ErrInvalidBudget
+	}
+	if req.WebhookURL != "" {
+		if err := webhook.ValidateURL(ctx, req.WebhookURL); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBudget, err)
+		}
+	}
+
+	n, err := s.novelRepo.FindByID(ctx, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+
+	warnRatio := req.WarnRatio
+	if warnRatio == 0 {
+		warnRatio = budget.DefaultWarnRatio
+	}
+
+	var spentFen int64
+	if n.Budget != nil {
+		spentFen = n.Budget.SpentFen
+	}
+
+	var unset []string
+	switch budget.Evaluate(req.CapFen, spentFen, warnRatio) {
+	case budget.LevelOK:
+		unset = []string{"warned_at", "exceeded_at"}
+	case budget.LevelWarning:
+		unset = []string{"exceeded_at"}
+	}
+
+	set := map[string]interface{}{
+		"cap_fen":     req.CapFen,
+		"warn_ratio":  warnRatio,
+		"hard_stop":   req.HardStop,
+		"webhook_url": req.WebhookURL,
+	}
+
+	// 通知地址变更时生成新的签名密钥（只在本次响应中返回），清空通知地址时删除密钥
+	var secret string
+	switch {
+	case req.WebhookURL == "":
+		unset = append(unset, "webhook_secret")
+	case n.Budget == nil || n.Budget.WebhookURL != req.WebhookURL || n.Budget.WebhookSecret == "":
+		if secret, err = webhook.NewSecret(); err != nil {
+			return nil, err
+		}
+		set["webhook_secret"] = secret
+	}
+	if err := s.novelRepo.UpdateBudget(ctx, novelID, set, unset); err != nil {
+		return nil, fmt.Errorf("update budget: %w", err)
+	}
+
+	log.Info().
+		Str("novel_id", novelID).
+		Int64("cap_fen", req.CapFen).
+		Float64("warn_ratio", warnRatio).
+		Bool("hard_stop", req.HardStop).
+		Msg("小说预算已更新")
+
+	status, err := s.GetNovelBudget(ctx, novelID)
+	if err != nil {
+		return nil, err
+	}
+	status.WebhookSecret = secret
+	return status, nil
+}
+
+// GetNovelBudget 查询小说预算及实时花费
+func (s *novelService) GetNovelBudget(ctx context.Context, novelID string) (*BudgetStatus, error) {
+	n, err := s.novelRepo.FindByID(ctx, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+
+	byProvider, err := s.costRecordRepo.SumByProvider(ctx, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("sum cost records: %w", err)
+	}
+
+	status := &BudgetStatus{
+		NovelID:    novelID,
+		WarnRatio:  budget.DefaultWarnRatio,
+		Level:      budget.LevelOK,
+		ByProvider: byProvider,
+	}
+	if b := n.Budget; b != nil {
+		status.CapFen = b.CapFen
+		if b.WarnRatio > 0 {
+			status.WarnRatio = b.WarnRatio
+		}
+		status.HardStop = b.HardStop
+		status.WebhookURL = b.WebhookURL
+		status.SpentFen = b.SpentFen
+		status.Level = budget.Evaluate(b.CapFen, b.SpentFen, b.WarnRatio)
+		status.WarnedAt = b.WarnedAt
+		status.ExceededAt = b.ExceededAt
+		if b.CapFen > b.SpentFen {
+			status.RemainingFen = b.CapFen - b.SpentFen
+		}
+	}
+	return status, nil
+}
+
+// ListCostRecords 查询小说的费用记录
+func (s *novelService) ListCostRecords(ctx context.Context, novelID string, limit int) ([]*novel.CostRecord, error) {
+	return s.costRecordRepo.FindByNovelID(ctx, novelID, limit)
+}
+
+// ListBudgetEvents 查询小说的预算事件
+func (s *novelService) ListBudgetEvents(ctx context.Context, novelID string, limit int) ([]*novel.BudgetEvent, error) {
+	return s.budgetEventRepo.FindByNovelID(ctx, novelID, limit)
+}
+
+// checkBudget 调用付费 provider 前检查预算
+// 开启了超限停止且花费已达到上限时返回 ErrBudgetExceeded；查询失败时不阻塞生成
+func (s *novelService) checkBudget(ctx context.Context, novelID string) error {
+	n, err := s.novelRepo.FindByID(ctx, novelID)
+	if err != nil {
+		log.Warn().Err(err).Str("novel_id", novelID).Msg("查询小说预算失败，跳过预算检查")
+		return nil
+	}
+	b := n.Budget
+	if b == nil || !b.HardStop {
+		return nil
+	}
+	if budget.Evaluate(b.CapFen, b.SpentFen, b.WarnRatio) == budget.LevelExceeded {
+		return fmt.Errorf("%w: spent %d of %d fen", ErrBudgetExceeded, b.SpentFen, b.CapFen)
+	}
+	return nil
+}
+
+// recordCost 记录一次付费 provider 调用的费用，并在首次达到预警线/上限时触发预算事件
+// 记账失败只记录日志，不影响已经完成的生成
+func (s *novelService) recordCost(ctx context.Context, novelID, chapterID, provider string, units float64, amountFen int64) {
+	taskstats.FromContext(ctx).AddCall(provider, amountFen)
+	if amountFen <= 0 {
+		return
+	}
+
+	record := &novel.CostRecord{
+		ID:        id.New(),
+		NovelID:   novelID,
+		ChapterID: chapterID,
+		Provider:  provider,
+		Units:     units,
+		AmountFen: amountFen,
+	}
+	if err := s.costRecordRepo.Create(ctx, record); err != nil {
+		log.Error().Err(err).Str("novel_id", novelID).Str("provider", provider).Msg("保存费用记录失败")
+		return
+	}
+
+	n, err := s.novelRepo.AddSpend(ctx, novelID, amountFen)
+	if err != nil {
+		log.Error().Err(err).Str("novel_id", novelID).Int64("amount_fen", amountFen).Msg("累加小说花费失败")
+		return
+	}
+
+	b := n.Budget
+	if b == nil {
+		return
+	}
+	switch budget.Evaluate(b.CapFen, b.SpentFen, b.WarnRatio) {
+	case budget.LevelExceeded:
+		s.emitBudgetEvent(ctx, n, novel.BudgetEventExceeded, "exceeded_at")
+	case budget.LevelWarning:
+		s.emitBudgetEvent(ctx, n, novel.BudgetEventWarning, "warned_at")
+	}
+}
+
+// recordLLMCost 按输入+输出字数记录一次文本生成的费用（输出来自响应缓存时没有调用 provider，不记录）
+func (s *novelService) recordLLMCost(ctx context.Context, novelID, chapterID, prompt, output string) {
+	if s.llmServedFromCache(prompt) {
+		return
+	}
+	chars := utf8.RuneCountInString(prompt) + utf8.RuneCountInString(output)
+	s.recordCost(ctx, novelID, chapterID, killswitch.ProviderLLM, float64(chars), s.pricing.LLM(chars))
+}
+
+// emitBudgetEvent 触发预算事件（同一事件只触发一次，直到预算设置被调整）
+func (s *novelService) emitBudgetEvent(ctx context.Context, n *novel.Novel, eventType novel.BudgetEventType, field string) {
+	marked, err := s.novelRepo.MarkBudgetEvent(ctx, n.ID, field, time.Now())
+	if err != nil {
+		log.Error().Err(err).Str("novel_id", n.ID).Str("type", string(eventType)).Msg("记录预算事件时间失败")
+		return
+	}
+	if !marked {
+		return
+	}
+
+	event := &novel.BudgetEvent{
+		ID:            id.New(),
+		NovelID:       n.ID,
+		Type:          eventType,
+		CapFen:        n.Budget.CapFen,
+		SpentFen:      n.Budget.SpentFen,
+		HardStop:      n.Budget.HardStop,
+		WebhookStatus: novel.WebhookStatusSkipped,
+	}
+	if n.Budget.WebhookURL != "" {
+		event.WebhookStatus = novel.WebhookStatusPending
+		event.DeliveryID = id.New()
+	}
+	if err := s.budgetEventRepo.Create(ctx, event); err != nil {
+		log.Error().Err(err).Str("novel_id", n.ID).Str("type", string(eventType)).Msg("保存预算事件失败")
+		return
+	}
+
+	log.Warn().
+		Str("novel_id", n.ID).
+		Str("type", string(eventType)).
+		Int64("cap_fen", event.CapFen).
+		Int64("spent_fen", event.SpentFen).
+		Msg("小说预算事件")
+
+	s.eventBus.Publish(BudgetEventTopic(n.ID), string(eventType), event)
+
+	if event.DeliveryID != "" {
+		s.notifyBudgetWebhook(ctx, n, event)
+	}
+}
+
+// notifyBudgetWebhook 保存预算通知投递记录并安排投递（与任务通知一样签名、失败后按退避时间重试）
+func (s *novelService) notifyBudgetWebhook(ctx context.Context, n *novel.Novel, event *novel.BudgetEvent) {
+	now := time.Now()
+	delivery := &novel.WebhookDelivery{
+		ID:     event.DeliveryID,
+		UserID: n.UserID,
+		Payload: &novel.WebhookPayload{
+			Event:      novel.WebhookEvent(event.Type),
+			NovelID:    n.ID,
+			OccurredAt: event.CreatedAt,
+			Budget:     event,
+		},
+		Status:        novel.WebhookStatusPending,
+		NextAttemptAt: &now,
+	}
+	if err := s.webhookDeliveryRepo.Create(context.WithoutCancel(ctx), delivery); err != nil {
+		log.Error().Err(err).Str("event_id", event.ID).Msg("保存预算事件通知投递记录失败")
+		if err := s.budgetEventRepo.UpdateWebhookStatus(context.WithoutCancel(ctx), event.ID, novel.WebhookStatusFailed, err.Error()); err != nil {
+			log.Error().Err(err).Str("event_id", event.ID).Msg("更新预算事件通知状态失败")
+		}
+		return
+	}
+	s.scheduleWebhookDelivery(delivery.ID, now)
+}
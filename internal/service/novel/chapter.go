@@ -1,36 +1,82 @@
 package novel
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
 
+	"github.com/rs/zerolog/log"
+
 	"lemon/internal/model/novel"
 	"lemon/internal/pkg/id"
 	"lemon/internal/pkg/noveltools"
+	"lemon/internal/pkg/textencoding"
 	"lemon/internal/service"
 )
 
+var (
+	// ErrChapterNotApproved 章节未审核通过（不允许导出授权版视频）
+	ErrChapterNotApproved = errors.New("chapter not approved")
+	// ErrInvalidNumberStyle 数字写法不合法
+	ErrInvalidNumberStyle = errors.New("invalid number style")
+	// ErrInvalidContinuity 章节衔接方式不合法
+	ErrInvalidContinuity = errors.New("invalid chapter continuity")
+	// ErrUndecodableNovelText 小说文件编码无法识别（非文本文件或内容已损坏）
+	ErrUndecodableNovelText = errors.New("undecodable novel text")
+)
+
 // ChapterService 章节服务接口
 // 定义小说和章节相关的能力
 type ChapterService interface {
 	// CreateNovelFromResource 根据资源ID创建小说
 	CreateNovelFromResource(ctx context.Context, resourceID, userID string, narrationType novel.NarrationType, style novel.NovelStyle) (string, error)
 
+	// CreateNovelFromResourceWithTemplate 根据资源ID创建小说，并复制工作流模板的配置（模板名称为空时等同于 CreateNovelFromResource）
+	CreateNovelFromResourceWithTemplate(ctx context.Context, resourceID, userID string, narrationType novel.NarrationType, style novel.NovelStyle, templateName string) (string, error)
+
 	// SplitNovelIntoChapters 根据小说内容切分章节
 	SplitNovelIntoChapters(ctx context.Context, novelID string, targetChapters int) error
 
 	// GetNovel 获取小说信息
 	GetNovel(ctx context.Context, novelID string) (*novel.Novel, error)
 
+	// ListNovels 分页查询用户的小说（按创建时间倒序），返回小说列表和总数
+	ListNovels(ctx context.Context, userID string, page, pageSize int64) ([]*novel.Novel, int64, error)
+
 	// GetChapters 获取小说的所有章节
 	GetChapters(ctx context.Context, novelID string) ([]*novel.Chapter, error)
+
+	// ApproveChapter 审核通过章节（审核通过后才允许导出无水印的授权版视频）
+	ApproveChapter(ctx context.Context, chapterID, approvedBy string) error
+
+	// SetNumberStyle 设置小说解说文本的数字写法（TTS 和字幕生成前统一转换）
+	SetNumberStyle(ctx context.Context, novelID string, style novel.NumberStyle) error
+
+	// SetChapterContinuity 设置小说的章节衔接方式（用上一章最终视频的最后一帧衔接本章开头）
+	SetChapterContinuity(ctx context.Context, novelID string, continuity novel.ChapterContinuity) error
+
+	// GetChapterPipeline 获取章节渲染流程的当前状态（由章节事件投影得到）
+	GetChapterPipeline(ctx context.Context, chapterID string) (*novel.ChapterPipelineState, error)
+
+	// GetChapterPipelineGraph 获取章节渲染流程的依赖图（阶段节点、依赖边、各阶段状态和耗时），并计算关键路径和阻塞完成的阶段
+	GetChapterPipelineGraph(ctx context.Context, chapterID string) (*noveltools.PipelineGraph, error)
+
+	// ListChapterEvents 获取章节的流程事件历史（按发生时间排序）
+	ListChapterEvents(ctx context.Context, chapterID string) ([]*novel.ChapterEvent, error)
 }
 
 // CreateNovelFromResource 第一步：根据资源ID获取小说内容，然后创建小说
+// narrationType、style 为空时使用用户创作默认配置中的值（没有默认配置时为旁白、漫剧）
 // 返回创建的小说ID
 func (s *novelService) CreateNovelFromResource(ctx context.Context, resourceID, userID string, narrationType novel.NarrationType, style novel.NovelStyle) (string, error) {
+	return s.createNovelFromResource(ctx, resourceID, userID, narrationType, style, nil)
+}
+
+// createNovelFromResource 创建小说；template 不为空时模板配置覆盖用户的创作默认配置
+func (s *novelService) createNovelFromResource(ctx context.Context, resourceID, userID string, narrationType novel.NarrationType, style novel.NovelStyle, template *novel.WorkflowTemplate) (string, error) {
 	// 使用 ResourceService 获取资源信息（系统内部请求，userID 为空）
 	resResult, err := s.resourceService.GetResource(ctx, &service.GetResourceRequest{
 		ResourceID: resourceID,
@@ -74,6 +120,9 @@ func (s *novelService) CreateNovelFromResource(ctx context.Context, resourceID,
 		NarrationType: narrationType,
 		Style:         style,
 	}
+	// 复制用户的创作默认配置（未指定的旁白类型和风格也从默认配置中取）
+	s.applyCreatorDefaults(ctx, novelEntity)
+	applyWorkflowTemplate(novelEntity, template)
 
 	if err := s.novelRepo.Create(ctx, novelEntity); err != nil {
 		return "", fmt.Errorf("failed to create novel: %w", err)
@@ -113,11 +162,22 @@ func (s *novelService) SplitNovelIntoChapters(ctx context.Context, novelID strin
 
 	reader := downloadResult.Data
 
-	content, err := io.ReadAll(reader)
+	raw, err := io.ReadAll(reader)
 	if err != nil {
 		return fmt.Errorf("failed to read resource content: %w", err)
 	}
 
+	// 识别源文件编码（GBK/GB18030/Big5 等）并统一转换为 UTF-8，避免乱码
+	content, sourceEncoding, err := textencoding.ToUTF8(raw)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUndecodableNovelText, err)
+	}
+	if string(sourceEncoding) != novelEntity.SourceEncoding {
+		if err := s.novelRepo.Update(ctx, novelID, map[string]interface{}{"source_encoding": sourceEncoding}); err != nil {
+			return fmt.Errorf("update source encoding: %w", err)
+		}
+	}
+
 	splitter := noveltools.NewChapterSplitter()
 	segments := splitter.Split(string(content), targetChapters)
 	if len(segments) == 0 {
@@ -132,16 +192,22 @@ func (s *novelService) SplitNovelIntoChapters(ctx context.Context, novelID strin
 		wordCount := countChineseWords(seg.Text)
 		lineCount := len(strings.Split(strings.TrimSpace(seg.Text), "\n"))
 
+		// 规范化章节标题（去掉空白和批注，中文序号转为阿拉伯数字）
+		title := noveltools.NormalizeChapterTitle(seg.Title)
+
 		chapterEntity := &novel.Chapter{
 			ID:      chapterID,
 			NovelID: novelID,
 			UserID:  novelEntity.UserID,
-			Sequence:    i + 1,
-			Title:       seg.Title,
-			ChapterText: seg.Text,
-			TotalChars:  totalChars,
-			WordCount:   wordCount,
-			LineCount:   lineCount,
+			Sequence:        i + 1,
+			Title:           title.Display,
+			OriginalTitle:   title.Original,
+			NormalizedTitle: title.Normalized,
+			TitleNumber:     title.Number,
+			ChapterText:     seg.Text,
+			TotalChars:      totalChars,
+			WordCount:       wordCount,
+			LineCount:       lineCount,
 		}
 
 		if err := s.chapterRepo.Create(ctx, chapterEntity); err != nil {
@@ -182,11 +248,58 @@ func (s *novelService) GetNovel(ctx context.Context, novelID string) (*novel.Nov
 	return s.novelRepo.FindByID(ctx, novelID)
 }
 
+// ListNovels 分页查询用户的小说
+func (s *novelService) ListNovels(ctx context.Context, userID string, page, pageSize int64) ([]*novel.Novel, int64, error) {
+	return s.novelRepo.ListByUser(ctx, userID, page, pageSize)
+}
+
 // GetChapters 获取小说的所有章节
 func (s *novelService) GetChapters(ctx context.Context, novelID string) ([]*novel.Chapter, error) {
 	return s.chapterRepo.FindByNovelID(ctx, novelID)
 }
 
+// ApproveChapter 审核通过章节
+func (s *novelService) ApproveChapter(ctx context.Context, chapterID, approvedBy string) error {
+	if err := s.chapterRepo.Approve(ctx, chapterID, approvedBy); err != nil {
+		return fmt.Errorf("approve chapter: %w", err)
+	}
+	return nil
+}
+
+// SetNumberStyle 设置小说解说文本的数字写法
+// 只影响之后生成的音频和字幕，已生成的内容不会重新转换
+func (s *novelService) SetNumberStyle(ctx context.Context, novelID string, style novel.NumberStyle) error {
+	if !style.IsValid() {
+		return fmt.Errorf("%w: %s", ErrInvalidNumberStyle, style)
+	}
+	if err := s.novelRepo.Update(ctx, novelID, map[string]interface{}{"number_style": style}); err != nil {
+		return fmt.Errorf("update number style: %w", err)
+	}
+	return nil
+}
+
+// SetChapterContinuity 设置小说的章节衔接方式
+// 只影响之后生成的图片和最终视频
+func (s *novelService) SetChapterContinuity(ctx context.Context, novelID string, continuity novel.ChapterContinuity) error {
+	if !continuity.IsValid() {
+		return fmt.Errorf("%w: %s", ErrInvalidContinuity, continuity)
+	}
+	if err := s.novelRepo.Update(ctx, novelID, map[string]interface{}{"continuity": continuity}); err != nil {
+		return fmt.Errorf("update chapter continuity: %w", err)
+	}
+	return nil
+}
+
+// novelNumberStyle 查询小说的数字写法，查询失败时不做转换（不阻断生成流程）
+func (s *novelService) novelNumberStyle(ctx context.Context, novelID string) novel.NumberStyle {
+	n, err := s.novelRepo.FindByID(ctx, novelID)
+	if err != nil {
+		log.Warn().Err(err).Str("novel_id", novelID).Msg("查询小说数字写法失败，不做数字转换")
+		return novel.NumberStyleNone
+	}
+	return n.NumberStyle
+}
+
 // NovelMetadata 小说元数据
 type NovelMetadata struct {
 	Title       string
@@ -211,7 +324,16 @@ func extractNovelMetadata(reader io.Reader, fileName string) NovelMetadata {
 		return metadata
 	}
 
+	// 只处理完整的行，避免截断的多字节字符影响编码识别
+	if n == len(buf) {
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i > 0 {
+			n = i
+		}
+	}
 	content := string(buf[:n])
+	if decoded, _, err := textencoding.ToUTF8(buf[:n]); err == nil {
+		content = string(decoded)
+	}
 	lines := strings.Split(content, "\n")
 
 	// 解析前几行
@@ -0,0 +1,523 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/noveltools"
+)
+
+// maxChapterBranchNameLength 分支名称的最大长度（字符数）
+const maxChapterBranchNameLength = 64
+
+var (
+	// ErrInvalidChapterBranch 分支参数不合法（名称为空或过长、对分支再创建分支、对主章节执行分支操作等）
+	ErrInvalidChapterBranch = errors.New("invalid chapter branch")
+	// ErrChapterBranchExists 同一章节下已存在同名分支（包括已合并或已放弃的分支）
+	ErrChapterBranchExists = errors.New("chapter branch already exists")
+	// ErrChapterBranchClosed 分支已合并或已放弃
+	ErrChapterBranchClosed = errors.New("chapter branch is closed")
+)
+
+// ChapterBranchService 章节分支服务接口
+// 分支是一个序号与主章节相同的独立章节：可以修改正文、独立运行各阶段流水线，比较后选定的分支合并回主章节
+type ChapterBranchService interface {
+	// ForkChapter 从主章节创建分支，可选引用主章节最新的解说及其产物
+	ForkChapter(ctx context.Context, chapterID string, req *ForkChapterRequest) (*novel.Chapter, error)
+
+	// ListChapterBranches 查询主章节的所有分支（按创建时间倒序）
+	ListChapterBranches(ctx context.Context, chapterID string) ([]*novel.Chapter, error)
+
+	// CompareChapterBranch 比较分支与主章节的正文、最新产物版本和 QA 评分
+	CompareChapterBranch(ctx context.Context, branchID string) (*ChapterBranchComparison, error)
+
+	// MergeChapterBranch 把分支的正文和所有版本的产物合并回主章节，可选合并后直接发布
+	MergeChapterBranch(ctx context.Context, branchID string, req *MergeChapterBranchRequest) (*ChapterBranchMergeResult, error)
+
+	// DiscardChapterBranch 放弃分支（分支的产物保留，不再参与合并）
+	DiscardChapterBranch(ctx context.Context, branchID, userID string) error
+}
+
+// ForkChapterRequest 创建章节分支请求
+type ForkChapterRequest struct {
+	Name          string // 分支名称（同一章节内唯一）
+	Text          string // 分支的章节正文，为空时使用主章节的正文
+	WithArtifacts bool   // 是否引用主章节最新的解说及其场景、镜头、图片、音频、字幕（复制记录，共享资源文件）
+	UserID        string // 操作人用户ID
+}
+
+// MergeChapterBranchRequest 合并章节分支请求
+type MergeChapterBranchRequest struct {
+	Promote  bool   // 合并后把分支最新的各产物版本发布为主章节的发布版本
+	IgnoreQA bool   // 发布时忽略 QA 评分卡的阻断
+	UserID   string // 操作人用户ID
+}
+
+// ChapterBranchSide 分支比较中一侧章节的概况
+type ChapterBranchSide struct {
+	ChapterID  string             `json:"chapter_id"`
+	BranchName string             `json:"branch_name,omitempty"` // 分支名称，主章节为空
+	WordCount  int                `json:"word_count"`
+	TextHash   string             `json:"text_hash"`
+	Versions   VersionSelection   `json:"versions"` // 各产物的最新版本，0 表示没有
+	Scenes     int                `json:"scenes"`   // 最新解说的场景数
+	Shots      int                `json:"shots"`    // 最新解说的镜头数
+	QA         *novel.QAScorecard `json:"qa"`       // 最新版本的 QA 评分卡
+}
+
+// ChapterBranchComparison 分支与主章节的比较结果
+type ChapterBranchComparison struct {
+	Parent      *ChapterBranchSide `json:"parent"`
+	Branch      *ChapterBranchSide `json:"branch"`
+	TextChanged bool               `json:"text_changed"` // 分支正文是否与主章节不同
+	ScoreDelta  float64            `json:"score_delta"`  // 分支 QA 分数减去主章节 QA 分数
+}
+
+// ChapterBranchMergeResult 分支合并结果
+type ChapterBranchMergeResult struct {
+	ChapterID    string                 `json:"chapter_id"` // 主章节ID
+	BranchID     string                 `json:"branch_id"`
+	TextUpdated  bool                   `json:"text_updated"`            // 主章节正文是否被分支正文覆盖
+	Versions     map[string]map[int]int `json:"versions"`                // 各产物的版本映射：分支版本 -> 合并后主章节的版本
+	Promoted     *VersionSelection      `json:"promoted,omitempty"`      // 请求发布的版本
+	Promotion    *PromotionPlan         `json:"promotion,omitempty"`     // 发布计划（请求发布时返回）
+	PromoteError string                 `json:"promote_error,omitempty"` // 发布失败的原因（合并本身已完成）
+}
+
+// ForkChapter 从主章节创建分支
+// 引用产物时复制主章节最新解说的场景、镜头、图片、音频、字幕记录（新的记录ID，版本号不变），资源文件由两边共享；最终视频不复制，需要在分支中重新生成。
+// 复制中途失败时删除分支，可以用同一名称重新创建
+func (s *novelService) ForkChapter(ctx context.Context, chapterID string, req *ForkChapterRequest) (*novel.Chapter, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxChapterBranchNameLength {
+		return nil, fmt.Errorf("%w: name must be 1-%d characters", ErrInvalidChapterBranch, maxChapterBranchNameLength)
+	}
+
+	parent, err := s.chapterRepo.FindByID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+	if parent.IsBranch() {
+		return nil, fmt.Errorf("%w: cannot fork a branch", ErrInvalidChapterBranch)
+	}
+
+	text := parent.ChapterText
+	if strings.TrimSpace(req.Text) != "" {
+		text = req.Text
+	}
+	branch := &novel.Chapter{
+		ID:              id.New(),
+		NovelID:         parent.NovelID,
+		UserID:          parent.UserID,
+		Sequence:        parent.Sequence,
+		Title:           parent.Title,
+		OriginalTitle:   parent.OriginalTitle,
+		NormalizedTitle: parent.NormalizedTitle,
+		TitleNumber:     parent.TitleNumber,
+		ChapterText:     text,
+		TotalChars:      countChineseCharacters(text),
+		WordCount:       countChineseWords(text),
+		LineCount:       len(strings.Split(strings.TrimSpace(text), "\n")),
+		Branch: &novel.ChapterBranch{
+			ParentID:      parent.ID,
+			Name:          name,
+			WithArtifacts: req.WithArtifacts,
+			Status:        novel.ChapterBranchStatusOpen,
+			ForkedBy:      req.UserID,
+		},
+	}
+	if req.WithArtifacts {
+		branch.SubtitleVersion = parent.SubtitleVersion
+	}
+	if err := s.chapterRepo.Create(ctx, branch); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("%w: %q", ErrChapterBranchExists, name)
+		}
+		return nil, fmt.Errorf("create branch chapter: %w", err)
+	}
+
+	if req.WithArtifacts {
+		if err := s.copyBranchArtifacts(ctx, parent.ID, branch.ID); err != nil {
+			s.deleteFailedBranch(ctx, branch.ID)
+			return nil, err
+		}
+	}
+
+	log.Info().
+		Str("chapter_id", parent.ID).
+		Str("branch_id", branch.ID).
+		Str("branch", name).
+		Bool("with_artifacts", req.WithArtifacts).
+		Msg("章节分支已创建")
+	return branch, nil
+}
+
+// copyBranchArtifacts 把主章节最新的解说及其产物复制到分支，主章节没有解说时不复制
+func (s *novelService) copyBranchArtifacts(ctx context.Context, parentID, branchID string) error {
+	narration, err := s.narrationRepo.FindByChapterID(ctx, parentID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("find narration: %w", err)
+	}
+	sourceNarrationID := narration.ID
+	narration.ID = id.New()
+	narration.ChapterID = branchID
+	if err := s.narrationRepo.Create(ctx, narration); err != nil {
+		return fmt.Errorf("copy narration: %w", err)
+	}
+
+	scenes, err := s.sceneRepo.FindByNarrationID(ctx, sourceNarrationID)
+	if err != nil {
+		return fmt.Errorf("find scenes: %w", err)
+	}
+	sceneIDs := make(map[string]string, len(scenes))
+	for _, scene := range scenes {
+		sceneIDs[scene.ID] = id.New()
+		scene.ID = sceneIDs[scene.ID]
+		scene.NarrationID = narration.ID
+		scene.ChapterID = branchID
+	}
+	if err := s.sceneRepo.CreateMany(ctx, scenes); err != nil {
+		return fmt.Errorf("copy scenes: %w", err)
+	}
+
+	shots, err := s.shotRepo.FindByNarrationID(ctx, sourceNarrationID)
+	if err != nil {
+		return fmt.Errorf("find shots: %w", err)
+	}
+	shotIDs := make(map[string]string, len(shots))
+	for _, shot := range shots {
+		shotIDs[shot.ID] = id.New()
+		shot.ID = shotIDs[shot.ID]
+		shot.SceneID = sceneIDs[shot.SceneID]
+		shot.NarrationID = narration.ID
+		shot.ChapterID = branchID
+	}
+	if err := s.shotRepo.CreateMany(ctx, shots); err != nil {
+		return fmt.Errorf("copy shots: %w", err)
+	}
+
+	images, err := s.imageRepo.FindByNarrationID(ctx, sourceNarrationID)
+	if err != nil {
+		return fmt.Errorf("find images: %w", err)
+	}
+	for _, image := range images {
+		image.ID = id.New()
+		image.NarrationID = narration.ID
+		image.ChapterID = branchID
+		image.ShotID = shotIDs[image.ShotID]
+		if err := s.imageRepo.Create(ctx, image); err != nil {
+			return fmt.Errorf("copy image: %w", err)
+		}
+	}
+
+	audios, err := s.audioRepo.FindByNarrationID(ctx, sourceNarrationID)
+	if err != nil {
+		return fmt.Errorf("find audios: %w", err)
+	}
+	for _, audio := range audios {
+		audio.ID = id.New()
+		audio.NarrationID = narration.ID
+		audio.ChapterID = branchID
+		audio.ShotID = shotIDs[audio.ShotID]
+		if err := s.audioRepo.Create(ctx, audio); err != nil {
+			return fmt.Errorf("copy audio: %w", err)
+		}
+	}
+
+	subtitles, err := s.subtitleRepo.FindByNarrationID(ctx, sourceNarrationID)
+	if err != nil {
+		return fmt.Errorf("find subtitles: %w", err)
+	}
+	for _, subtitle := range subtitles {
+		subtitle.ID = id.New()
+		subtitle.NarrationID = narration.ID
+		subtitle.ChapterID = branchID
+		subtitle.ShotID = shotIDs[subtitle.ShotID]
+		if err := s.subtitleRepo.Create(ctx, subtitle); err != nil {
+			return fmt.Errorf("copy subtitle: %w", err)
+		}
+	}
+	return nil
+}
+
+// deleteFailedBranch 清理复制产物中途失败的分支：已复制的产物记录软删除（资源文件与主章节共享，不删除），
+// 分支章节物理删除，使同名分支可以重新创建
+func (s *novelService) deleteFailedBranch(ctx context.Context, branchID string) {
+	bg := context.WithoutCancel(ctx)
+	if _, _, err := s.lifecycleRepo.CascadeSoftDelete(bg, "chapter_id", []string{branchID}, time.Now()); err != nil {
+		log.Error().Err(err).Str("branch_id", branchID).Msg("清理分支已复制的产物失败")
+	}
+	if err := s.chapterRepo.DeleteBranch(bg, branchID); err != nil {
+		log.Error().Err(err).Str("branch_id", branchID).Msg("删除创建失败的分支失败")
+	}
+}
+
+// ListChapterBranches 查询主章节的所有分支
+func (s *novelService) ListChapterBranches(ctx context.Context, chapterID string) ([]*novel.Chapter, error) {
+	if _, err := s.chapterRepo.FindByID(ctx, chapterID); err != nil {
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+	return s.chapterRepo.FindBranches(ctx, chapterID)
+}
+
+// CompareChapterBranch 比较分支与主章节
+func (s *novelService) CompareChapterBranch(ctx context.Context, branchID string) (*ChapterBranchComparison, error) {
+	branch, parent, err := s.findChapterBranch(ctx, branchID)
+	if err != nil {
+		return nil, err
+	}
+	parentSide, err := s.chapterBranchSide(ctx, parent)
+	if err != nil {
+		return nil, err
+	}
+	branchSide, err := s.chapterBranchSide(ctx, branch)
+	if err != nil {
+		return nil, err
+	}
+	return &ChapterBranchComparison{
+		Parent:      parentSide,
+		Branch:      branchSide,
+		TextChanged: parentSide.TextHash != branchSide.TextHash,
+		ScoreDelta:  branchSide.QA.Score - parentSide.QA.Score,
+	}, nil
+}
+
+// chapterBranchSide 汇总章节的正文、最新产物版本和 QA 评分
+func (s *novelService) chapterBranchSide(ctx context.Context, ch *novel.Chapter) (*ChapterBranchSide, error) {
+	side := &ChapterBranchSide{
+		ChapterID: ch.ID,
+		WordCount: ch.WordCount,
+		TextHash:  noveltools.HashPrompt(ch.ChapterText),
+	}
+	if ch.IsBranch() {
+		side.BranchName = ch.Branch.Name
+	}
+
+	for _, artifact := range []novel.VersionArtifact{
+		novel.VersionArtifactNarration, novel.VersionArtifactImage, novel.VersionArtifactAudio,
+		novel.VersionArtifactSubtitle, novel.VersionArtifactVideo,
+	} {
+		versions, err := s.chapterArtifactVersions(ctx, ch.ID, artifact)
+		if err != nil {
+			return nil, err
+		}
+		latest := 0
+		if len(versions) > 0 {
+			latest = slices.Max(versions)
+		}
+		switch artifact {
+		case novel.VersionArtifactNarration:
+			side.Versions.Narration = latest
+		case novel.VersionArtifactImage:
+			side.Versions.Image = latest
+		case novel.VersionArtifactAudio:
+			side.Versions.Audio = latest
+		case novel.VersionArtifactSubtitle:
+			side.Versions.Subtitle = latest
+		case novel.VersionArtifactVideo:
+			side.Versions.Video = latest
+		}
+	}
+
+	if narration, err := s.narrationRepo.FindByChapterID(ctx, ch.ID); err == nil {
+		scenes, err := s.sceneRepo.FindByNarrationID(ctx, narration.ID)
+		if err != nil {
+			return nil, fmt.Errorf("find scenes: %w", err)
+		}
+		shots, err := s.shotRepo.FindByNarrationID(ctx, narration.ID)
+		if err != nil {
+			return nil, fmt.Errorf("find shots: %w", err)
+		}
+		side.Scenes = len(scenes)
+		side.Shots = len(shots)
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("find narration: %w", err)
+	}
+
+	qa, err := s.chapterQAScorecard(ctx, ch, VersionSelection{})
+	if err != nil {
+		return nil, err
+	}
+	side.QA = qa
+	return side, nil
+}
+
+// MergeChapterBranch 合并分支回主章节
+// 分支正文与主章节不同时覆盖主章节正文；分支每个产物的所有版本按从小到大的顺序分配主章节的新版本号后移到主章节（场景和镜头跟随解说版本）。
+// 合并不是事务性的：中途失败时已移动的版本留在主章节，重新合并会继续移动剩余的版本
+func (s *novelService) MergeChapterBranch(ctx context.Context, branchID string, req *MergeChapterBranchRequest) (*ChapterBranchMergeResult, error) {
+	branch, parent, err := s.findChapterBranch(ctx, branchID)
+	if err != nil {
+		return nil, err
+	}
+	if branch.Branch.Status != novel.ChapterBranchStatusOpen {
+		return nil, fmt.Errorf("%w: branch is %s", ErrChapterBranchClosed, branch.Branch.Status)
+	}
+
+	result := &ChapterBranchMergeResult{
+		ChapterID: parent.ID,
+		BranchID:  branch.ID,
+		Versions:  make(map[string]map[int]int),
+	}
+	if noveltools.HashPrompt(branch.ChapterText) != noveltools.HashPrompt(parent.ChapterText) {
+		if err := s.chapterRepo.SetText(ctx, parent.ID, branch.ChapterText, branch.TotalChars, branch.WordCount, branch.LineCount); err != nil {
+			return nil, fmt.Errorf("update chapter text: %w", err)
+		}
+		result.TextUpdated = true
+	}
+
+	promoted := VersionSelection{}
+	for _, artifact := range []novel.VersionArtifact{
+		novel.VersionArtifactNarration, novel.VersionArtifactImage, novel.VersionArtifactAudio,
+		novel.VersionArtifactSubtitle, novel.VersionArtifactVideo,
+	} {
+		mapping, err := s.moveBranchVersions(ctx, branch.ID, parent.ID, artifact)
+		if err != nil {
+			return nil, err
+		}
+		if len(mapping) == 0 {
+			continue
+		}
+		result.Versions[string(artifact)] = mapping
+		latest := slices.Max(slices.Collect(maps.Values(mapping)))
+		switch artifact {
+		case novel.VersionArtifactNarration:
+			promoted.Narration = latest
+		case novel.VersionArtifactImage:
+			promoted.Image = latest
+		case novel.VersionArtifactAudio:
+			promoted.Audio = latest
+		case novel.VersionArtifactSubtitle:
+			promoted.Subtitle = latest
+			if v, ok := mapping[branch.SubtitleVersion]; ok && branch.SubtitleVersion > 0 {
+				if err := s.chapterRepo.SetSubtitleVersion(ctx, parent.ID, v); err != nil {
+					return nil, fmt.Errorf("set subtitle version: %w", err)
+				}
+				promoted.Subtitle = v
+			}
+		case novel.VersionArtifactVideo:
+			promoted.Video = latest
+		}
+	}
+
+	if err := s.chapterRepo.CloseBranch(ctx, branch.ID, novel.ChapterBranchStatusMerged, req.UserID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: branch was closed during merge", ErrChapterBranchClosed)
+		}
+		return nil, fmt.Errorf("close branch: %w", err)
+	}
+
+	log.Info().
+		Str("chapter_id", parent.ID).
+		Str("branch_id", branch.ID).
+		Str("branch", branch.Branch.Name).
+		Bool("text_updated", result.TextUpdated).
+		Interface("versions", result.Versions).
+		Msg("章节分支已合并")
+
+	if req.Promote && promoted != (VersionSelection{}) {
+		result.Promoted = &promoted
+		plan, err := s.PromoteNovelVersions(ctx, parent.NovelID, &PromoteVersionsRequest{
+			Strategy:   novel.PromotionStrategyExplicit,
+			Versions:   map[string]VersionSelection{parent.ID: promoted},
+			IgnoreQA:   req.IgnoreQA,
+			PromotedBy: req.UserID,
+		})
+		if err != nil {
+			log.Warn().Err(err).Str("chapter_id", parent.ID).Msg("章节分支合并后发布失败")
+			result.PromoteError = err.Error()
+		}
+		result.Promotion = plan
+	}
+	return result, nil
+}
+
+// moveBranchVersions 把分支某类产物的所有版本移到主章节，返回分支版本到主章节新版本的映射
+func (s *novelService) moveBranchVersions(ctx context.Context, branchID, parentID string, artifact novel.VersionArtifact) (map[int]int, error) {
+	versions, err := s.chapterArtifactVersions(ctx, branchID, artifact)
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(versions)
+	versions = slices.Compact(versions)
+
+	mapping := make(map[int]int, len(versions))
+	for _, from := range versions {
+		to, err := s.nextVersion(ctx, parentID, artifact)
+		if err != nil {
+			return nil, err
+		}
+		switch artifact {
+		case novel.VersionArtifactNarration:
+			err = s.narrationRepo.MoveChapterVersion(ctx, branchID, from, parentID, to)
+			if err == nil {
+				err = s.sceneRepo.MoveChapterVersion(ctx, branchID, from, parentID, to)
+			}
+			if err == nil {
+				err = s.shotRepo.MoveChapterVersion(ctx, branchID, from, parentID, to)
+			}
+		case novel.VersionArtifactImage:
+			err = s.imageRepo.MoveChapterVersion(ctx, branchID, from, parentID, to)
+		case novel.VersionArtifactAudio:
+			err = s.audioRepo.MoveChapterVersion(ctx, branchID, from, parentID, to)
+		case novel.VersionArtifactSubtitle:
+			err = s.subtitleRepo.MoveChapterVersion(ctx, branchID, from, parentID, to)
+		case novel.VersionArtifactVideo:
+			err = s.videoRepo.MoveChapterVersion(ctx, branchID, from, parentID, to)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("move %s version %d: %w", artifact, from, err)
+		}
+		mapping[from] = to
+	}
+	return mapping, nil
+}
+
+// DiscardChapterBranch 放弃分支
+func (s *novelService) DiscardChapterBranch(ctx context.Context, branchID, userID string) error {
+	branch, _, err := s.findChapterBranch(ctx, branchID)
+	if err != nil {
+		return err
+	}
+	if branch.Branch.Status != novel.ChapterBranchStatusOpen {
+		return fmt.Errorf("%w: branch is %s", ErrChapterBranchClosed, branch.Branch.Status)
+	}
+	if err := s.chapterRepo.CloseBranch(ctx, branch.ID, novel.ChapterBranchStatusDiscarded, userID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("%w: branch was closed concurrently", ErrChapterBranchClosed)
+		}
+		return fmt.Errorf("close branch: %w", err)
+	}
+	log.Info().Str("branch_id", branch.ID).Str("branch", branch.Branch.Name).Msg("章节分支已放弃")
+	return nil
+}
+
+// findChapterBranch 查询分支及其主章节，章节不是分支时返回 ErrInvalidChapterBranch
+func (s *novelService) findChapterBranch(ctx context.Context, branchID string) (*novel.Chapter, *novel.Chapter, error) {
+	branch, err := s.chapterRepo.FindByID(ctx, branchID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("find branch: %w", err)
+	}
+	if !branch.IsBranch() {
+		return nil, nil, fmt.Errorf("%w: chapter %s is not a branch", ErrInvalidChapterBranch, branchID)
+	}
+	parent, err := s.chapterRepo.FindByID(ctx, branch.Branch.ParentID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("find parent chapter: %w", err)
+	}
+	return branch, parent, nil
+}
@@ -0,0 +1,520 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	novelrepo "lemon/internal/repository/novel"
+)
+
+// errArtifactWrite 模拟写入产物记录失败
+var errArtifactWrite = errors.New("artifact write failed")
+
+// memArtifacts 内存中的一类产物记录，fields 返回记录的章节ID、解说ID和版本号字段
+type memArtifacts[V any] struct {
+	records    []*V
+	fields     func(*V) (chapterID, narrationID *string, version *int)
+	failCreate bool
+	failMove   bool
+}
+
+func (m *memArtifacts[V]) create(r *V) error {
+	if m.failCreate {
+		return errArtifactWrite
+	}
+	copied := *r
+	m.records = append(m.records, &copied)
+	return nil
+}
+
+// find 返回满足条件的记录副本
+func (m *memArtifacts[V]) find(match func(chapterID, narrationID string, version int) bool) []*V {
+	var found []*V
+	for _, r := range m.records {
+		chapterID, narrationID, version := m.fields(r)
+		if match(*chapterID, *narrationID, *version) {
+			copied := *r
+			found = append(found, &copied)
+		}
+	}
+	return found
+}
+
+func (m *memArtifacts[V]) byNarration(narrationID string) []*V {
+	return m.find(func(_, n string, _ int) bool { return n == narrationID })
+}
+
+func (m *memArtifacts[V]) inChapter(chapterID string) []*V {
+	return m.find(func(c, _ string, _ int) bool { return c == chapterID })
+}
+
+func (m *memArtifacts[V]) versions(chapterID string) []int {
+	var versions []int
+	for _, r := range m.inChapter(chapterID) {
+		_, _, version := m.fields(r)
+		versions = append(versions, *version)
+	}
+	return versions
+}
+
+func (m *memArtifacts[V]) move(fromChapterID string, fromVersion int, toChapterID string, toVersion int) error {
+	if m.failMove {
+		return errArtifactWrite
+	}
+	for _, r := range m.records {
+		chapterID, _, version := m.fields(r)
+		if *chapterID == fromChapterID && *version == fromVersion {
+			*chapterID, *version = toChapterID, toVersion
+		}
+	}
+	return nil
+}
+
+type memBranchNarrationRepo struct {
+	novelrepo.NarrationRepository
+	*memArtifacts[novel.Narration]
+}
+
+func (r *memBranchNarrationRepo) Create(_ context.Context, n *novel.Narration) error {
+	return r.create(n)
+}
+
+func (r *memBranchNarrationRepo) FindByChapterID(_ context.Context, chapterID string) (*novel.Narration, error) {
+	var latest *novel.Narration
+	for _, n := range r.inChapter(chapterID) {
+		if latest == nil || n.Version > latest.Version {
+			latest = n
+		}
+	}
+	if latest == nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	return latest, nil
+}
+
+func (r *memBranchNarrationRepo) FindVersionsByChapterID(_ context.Context, chapterID string) ([]int, error) {
+	return r.versions(chapterID), nil
+}
+
+func (r *memBranchNarrationRepo) MoveChapterVersion(_ context.Context, from string, fromVersion int, to string, toVersion int) error {
+	return r.move(from, fromVersion, to, toVersion)
+}
+
+type memBranchSceneRepo struct {
+	novelrepo.SceneRepository
+	*memArtifacts[novel.Scene]
+}
+
+func (r *memBranchSceneRepo) CreateMany(_ context.Context, scenes []*novel.Scene) error {
+	for _, scene := range scenes {
+		if err := r.create(scene); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memBranchSceneRepo) FindByNarrationID(_ context.Context, narrationID string) ([]*novel.Scene, error) {
+	return r.byNarration(narrationID), nil
+}
+
+func (r *memBranchSceneRepo) MoveChapterVersion(_ context.Context, from string, fromVersion int, to string, toVersion int) error {
+	return r.move(from, fromVersion, to, toVersion)
+}
+
+type memBranchShotRepo struct {
+	novelrepo.ShotRepository
+	*memArtifacts[novel.Shot]
+}
+
+func (r *memBranchShotRepo) CreateMany(_ context.Context, shots []*novel.Shot) error {
+	for _, shot := range shots {
+		if err := r.create(shot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memBranchShotRepo) FindByNarrationID(_ context.Context, narrationID string) ([]*novel.Shot, error) {
+	return r.byNarration(narrationID), nil
+}
+
+func (r *memBranchShotRepo) MoveChapterVersion(_ context.Context, from string, fromVersion int, to string, toVersion int) error {
+	return r.move(from, fromVersion, to, toVersion)
+}
+
+type memBranchImageRepo struct {
+	novelrepo.ImageRepository
+	*memArtifacts[novel.Image]
+}
+
+func (r *memBranchImageRepo) Create(_ context.Context, image *novel.Image) error {
+	return r.create(image)
+}
+
+func (r *memBranchImageRepo) FindByNarrationID(_ context.Context, narrationID string) ([]*novel.Image, error) {
+	return r.byNarration(narrationID), nil
+}
+
+func (r *memBranchImageRepo) FindVersionsByChapterID(_ context.Context, chapterID string) ([]int, error) {
+	return r.versions(chapterID), nil
+}
+
+func (r *memBranchImageRepo) MoveChapterVersion(_ context.Context, from string, fromVersion int, to string, toVersion int) error {
+	return r.move(from, fromVersion, to, toVersion)
+}
+
+type memBranchAudioRepo struct {
+	novelrepo.AudioRepository
+	*memArtifacts[novel.Audio]
+}
+
+func (r *memBranchAudioRepo) Create(_ context.Context, audio *novel.Audio) error {
+	return r.create(audio)
+}
+
+func (r *memBranchAudioRepo) FindByNarrationID(_ context.Context, narrationID string) ([]*novel.Audio, error) {
+	return r.byNarration(narrationID), nil
+}
+
+func (r *memBranchAudioRepo) FindVersionsByChapterID(_ context.Context, chapterID string) ([]int, error) {
+	return r.versions(chapterID), nil
+}
+
+func (r *memBranchAudioRepo) MoveChapterVersion(_ context.Context, from string, fromVersion int, to string, toVersion int) error {
+	return r.move(from, fromVersion, to, toVersion)
+}
+
+type memBranchSubtitleRepo struct {
+	novelrepo.SubtitleRepository
+	*memArtifacts[novel.Subtitle]
+}
+
+func (r *memBranchSubtitleRepo) Create(_ context.Context, subtitle *novel.Subtitle) error {
+	return r.create(subtitle)
+}
+
+func (r *memBranchSubtitleRepo) FindByNarrationID(_ context.Context, narrationID string) ([]*novel.Subtitle, error) {
+	return r.byNarration(narrationID), nil
+}
+
+func (r *memBranchSubtitleRepo) FindVersionsByChapterID(_ context.Context, chapterID string) ([]int, error) {
+	return r.versions(chapterID), nil
+}
+
+func (r *memBranchSubtitleRepo) MoveChapterVersion(_ context.Context, from string, fromVersion int, to string, toVersion int) error {
+	return r.move(from, fromVersion, to, toVersion)
+}
+
+type memBranchVideoRepo struct {
+	novelrepo.VideoRepository
+	*memArtifacts[novel.Video]
+}
+
+func (r *memBranchVideoRepo) FindVersionsByChapterID(_ context.Context, chapterID string) ([]int, error) {
+	return r.versions(chapterID), nil
+}
+
+func (r *memBranchVideoRepo) MoveChapterVersion(_ context.Context, from string, fromVersion int, to string, toVersion int) error {
+	return r.move(from, fromVersion, to, toVersion)
+}
+
+// memBranchChapterRepo 内存中的章节仓库，按 uniq_novel_sequence_branch 校验分支名称唯一
+type memBranchChapterRepo struct {
+	memChapterRepo
+}
+
+func (r *memBranchChapterRepo) Create(_ context.Context, ch *novel.Chapter) error {
+	for _, other := range r.chapters {
+		if other.NovelID == ch.NovelID && other.Sequence == ch.Sequence && other.IsBranch() && ch.IsBranch() && other.Branch.Name == ch.Branch.Name {
+			return mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000}}}
+		}
+	}
+	r.chapters = append(r.chapters, ch)
+	return nil
+}
+
+func (r *memBranchChapterRepo) SetText(_ context.Context, id, text string, totalChars, wordCount, lineCount int) error {
+	ch, _ := r.FindByID(context.Background(), id)
+	ch.ChapterText, ch.TotalChars, ch.WordCount, ch.LineCount = text, totalChars, wordCount, lineCount
+	return nil
+}
+
+func (r *memBranchChapterRepo) SetSubtitleVersion(_ context.Context, id string, version int) error {
+	ch, _ := r.FindByID(context.Background(), id)
+	ch.SubtitleVersion = version
+	return nil
+}
+
+func (r *memBranchChapterRepo) CloseBranch(_ context.Context, id string, status novel.ChapterBranchStatus, closedBy string) error {
+	ch, err := r.FindByID(context.Background(), id)
+	if err != nil || !ch.IsBranch() || ch.Branch.Status != novel.ChapterBranchStatusOpen {
+		return mongo.ErrNoDocuments
+	}
+	ch.Branch.Status, ch.Branch.ClosedBy = status, closedBy
+	return nil
+}
+
+func (r *memBranchChapterRepo) DeleteBranch(_ context.Context, id string) error {
+	for i, ch := range r.chapters {
+		if ch.ID == id && ch.IsBranch() {
+			r.chapters = slices.Delete(r.chapters, i, i+1)
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+// memVersionCounterRepo 内存中的版本号计数器：返回 max(已分配, floor)+1
+type memVersionCounterRepo struct {
+	novelrepo.VersionCounterRepository
+	counters map[string]int
+}
+
+func (r *memVersionCounterRepo) Next(_ context.Context, chapterID string, artifact novel.VersionArtifact, floor int) (int, error) {
+	key := chapterID + "/" + string(artifact)
+	r.counters[key] = max(r.counters[key], floor) + 1
+	return r.counters[key], nil
+}
+
+// branchFixture 主章节 ch-1 有两个版本的解说（最新为 narration-2），最新解说有一个场景、两个镜头及其图片、音频、字幕
+type branchFixture struct {
+	svc        *novelService
+	chapters   *memBranchChapterRepo
+	narrations *memArtifacts[novel.Narration]
+	scenes     *memArtifacts[novel.Scene]
+	shots      *memArtifacts[novel.Shot]
+	images     *memArtifacts[novel.Image]
+	audios     *memArtifacts[novel.Audio]
+	subtitles  *memArtifacts[novel.Subtitle]
+	videos     *memArtifacts[novel.Video]
+	lifecycle  *fakeLifecycleRepo
+}
+
+func newBranchFixture() *branchFixture {
+	f := &branchFixture{
+		chapters: &memBranchChapterRepo{memChapterRepo{chapters: []*novel.Chapter{{
+			ID: "ch-1", NovelID: "novel-1", Sequence: 1, ChapterText: "主章节正文", SubtitleVersion: 1,
+		}}}},
+		narrations: &memArtifacts[novel.Narration]{fields: func(n *novel.Narration) (*string, *string, *int) { return &n.ChapterID, &n.ID, &n.Version }},
+		scenes:     &memArtifacts[novel.Scene]{fields: func(s *novel.Scene) (*string, *string, *int) { return &s.ChapterID, &s.NarrationID, &s.Version }},
+		shots:      &memArtifacts[novel.Shot]{fields: func(s *novel.Shot) (*string, *string, *int) { return &s.ChapterID, &s.NarrationID, &s.Version }},
+		images:     &memArtifacts[novel.Image]{fields: func(i *novel.Image) (*string, *string, *int) { return &i.ChapterID, &i.NarrationID, &i.Version }},
+		audios:     &memArtifacts[novel.Audio]{fields: func(a *novel.Audio) (*string, *string, *int) { return &a.ChapterID, &a.NarrationID, &a.Version }},
+		subtitles:  &memArtifacts[novel.Subtitle]{fields: func(s *novel.Subtitle) (*string, *string, *int) { return &s.ChapterID, &s.NarrationID, &s.Version }},
+		videos:     &memArtifacts[novel.Video]{fields: func(v *novel.Video) (*string, *string, *int) { return &v.ChapterID, &v.NarrationID, &v.Version }},
+		lifecycle:  newFakeLifecycleRepo(nil),
+	}
+	for v, narrationID := range []string{"narration-1", "narration-2"} {
+		f.narrations.records = append(f.narrations.records, &novel.Narration{ID: narrationID, ChapterID: "ch-1", Version: v + 1})
+		f.scenes.records = append(f.scenes.records, &novel.Scene{ID: narrationID + "-scene", NarrationID: narrationID, ChapterID: "ch-1", Version: v + 1})
+	}
+	for _, shotID := range []string{"shot-1", "shot-2"} {
+		f.shots.records = append(f.shots.records, &novel.Shot{ID: shotID, SceneID: "narration-2-scene", NarrationID: "narration-2", ChapterID: "ch-1", Version: 2})
+		f.images.records = append(f.images.records, &novel.Image{ID: shotID + "-image", ShotID: shotID, NarrationID: "narration-2", ChapterID: "ch-1", Version: 1})
+		f.audios.records = append(f.audios.records, &novel.Audio{ID: shotID + "-audio", ShotID: shotID, NarrationID: "narration-2", ChapterID: "ch-1", Version: 1})
+	}
+	f.subtitles.records = append(f.subtitles.records, &novel.Subtitle{ID: "subtitle-1", NarrationID: "narration-2", ChapterID: "ch-1", Version: 1})
+
+	f.svc = &novelService{
+		chapterRepo:        f.chapters,
+		narrationRepo:      &memBranchNarrationRepo{memArtifacts: f.narrations},
+		sceneRepo:          &memBranchSceneRepo{memArtifacts: f.scenes},
+		shotRepo:           &memBranchShotRepo{memArtifacts: f.shots},
+		imageRepo:          &memBranchImageRepo{memArtifacts: f.images},
+		audioRepo:          &memBranchAudioRepo{memArtifacts: f.audios},
+		subtitleRepo:       &memBranchSubtitleRepo{memArtifacts: f.subtitles},
+		videoRepo:          &memBranchVideoRepo{memArtifacts: f.videos},
+		versionCounterRepo: &memVersionCounterRepo{counters: make(map[string]int)},
+		lifecycleRepo:      f.lifecycle,
+	}
+	return f
+}
+
+// addBranch 直接创建 ch-1 的分支 branch-1，分支有两个版本的解说和图片、一个版本的字幕和最终视频
+func (f *branchFixture) addBranch() {
+	f.chapters.chapters = append(f.chapters.chapters, &novel.Chapter{
+		ID: "branch-1", NovelID: "novel-1", Sequence: 1, ChapterText: "分支正文", SubtitleVersion: 1,
+		Branch: &novel.ChapterBranch{ParentID: "ch-1", Name: "改写", Status: novel.ChapterBranchStatusOpen},
+	})
+	for _, v := range []int{1, 2} {
+		narrationID := "branch-narration-" + strconv.Itoa(v)
+		f.narrations.records = append(f.narrations.records, &novel.Narration{ID: narrationID, ChapterID: "branch-1", Version: v})
+		f.scenes.records = append(f.scenes.records, &novel.Scene{ID: narrationID + "-scene", NarrationID: narrationID, ChapterID: "branch-1", Version: v})
+		f.images.records = append(f.images.records, &novel.Image{ID: narrationID + "-image", NarrationID: narrationID, ChapterID: "branch-1", Version: v})
+	}
+	f.subtitles.records = append(f.subtitles.records, &novel.Subtitle{ID: "branch-subtitle", NarrationID: "branch-narration-2", ChapterID: "branch-1", Version: 1})
+	f.videos.records = append(f.videos.records, &novel.Video{ID: "branch-video", ChapterID: "branch-1", Version: 1, VideoType: novel.VideoTypeFinal})
+}
+
+func TestForkChapterWithArtifacts(t *testing.T) {
+	f := newBranchFixture()
+
+	branch, err := f.svc.ForkChapter(context.Background(), "ch-1", &ForkChapterRequest{Name: "改写", WithArtifacts: true, UserID: "editor-1"})
+	if err != nil {
+		t.Fatalf("ForkChapter() error = %v", err)
+	}
+	if branch.Branch.ParentID != "ch-1" || branch.SubtitleVersion != 1 || branch.ChapterText != "主章节正文" {
+		t.Errorf("branch = %+v, want fork of ch-1 with its text and subtitle version", branch)
+	}
+
+	// 只复制最新的解说，版本号不变
+	narrations := f.narrations.inChapter(branch.ID)
+	if len(narrations) != 1 || narrations[0].Version != 2 || narrations[0].ID == "narration-2" {
+		t.Fatalf("branch narrations = %+v, want a copy of narration-2", narrations)
+	}
+	narrationID := narrations[0].ID
+	scenes := f.scenes.inChapter(branch.ID)
+	if len(scenes) != 1 || scenes[0].NarrationID != narrationID || scenes[0].ID == "narration-2-scene" {
+		t.Fatalf("branch scenes = %+v, want a copy of narration-2-scene", scenes)
+	}
+	shotIDs := make(map[string]bool)
+	for _, shot := range f.shots.inChapter(branch.ID) {
+		if shot.SceneID != scenes[0].ID || shot.NarrationID != narrationID {
+			t.Errorf("branch shot %s belongs to %s/%s, want copied scene and narration", shot.ID, shot.SceneID, shot.NarrationID)
+		}
+		shotIDs[shot.ID] = true
+	}
+	if len(shotIDs) != 2 || shotIDs["shot-1"] || shotIDs["shot-2"] {
+		t.Fatalf("branch shots = %v, want two new shots", shotIDs)
+	}
+	for _, image := range f.images.inChapter(branch.ID) {
+		if !shotIDs[image.ShotID] || image.NarrationID != narrationID {
+			t.Errorf("branch image %s links shot %s, want a copied shot", image.ID, image.ShotID)
+		}
+	}
+	for _, audio := range f.audios.inChapter(branch.ID) {
+		if !shotIDs[audio.ShotID] {
+			t.Errorf("branch audio %s links shot %s, want a copied shot", audio.ID, audio.ShotID)
+		}
+	}
+	if got := len(f.subtitles.inChapter(branch.ID)); got != 1 {
+		t.Errorf("branch subtitles = %d, want 1", got)
+	}
+
+	// 主章节的记录不受影响
+	if images := f.images.inChapter("ch-1"); len(images) != 2 || images[0].ShotID != "shot-1" {
+		t.Errorf("parent images = %+v, want unchanged", images)
+	}
+	if _, err := f.svc.ForkChapter(context.Background(), "ch-1", &ForkChapterRequest{Name: "改写"}); !errors.Is(err, ErrChapterBranchExists) {
+		t.Errorf("fork with same name: err = %v, want ErrChapterBranchExists", err)
+	}
+}
+
+func TestForkChapterCopyFailureDeletesBranch(t *testing.T) {
+	f := newBranchFixture()
+	f.images.failCreate = true
+
+	_, err := f.svc.ForkChapter(context.Background(), "ch-1", &ForkChapterRequest{Name: "改写", WithArtifacts: true})
+	if !errors.Is(err, errArtifactWrite) {
+		t.Fatalf("ForkChapter() error = %v, want copy failure", err)
+	}
+	if len(f.chapters.chapters) != 1 {
+		t.Errorf("chapters = %d, want failed branch deleted", len(f.chapters.chapters))
+	}
+	if got := f.lifecycle.cascaded["chapter_id"]; len(got) != 1 || got[0] == "ch-1" {
+		t.Errorf("cascaded = %v, want copied artifacts of the failed branch deleted", got)
+	}
+
+	// 用同一名称重新创建
+	f.images.failCreate = false
+	branch, err := f.svc.ForkChapter(context.Background(), "ch-1", &ForkChapterRequest{Name: "改写", WithArtifacts: true})
+	if err != nil {
+		t.Fatalf("retry ForkChapter() error = %v", err)
+	}
+	if got := len(f.images.inChapter(branch.ID)); got != 2 {
+		t.Errorf("branch images = %d, want 2", got)
+	}
+}
+
+func TestMergeChapterBranchMapsVersions(t *testing.T) {
+	f := newBranchFixture()
+	f.addBranch()
+
+	result, err := f.svc.MergeChapterBranch(context.Background(), "branch-1", &MergeChapterBranchRequest{UserID: "editor-1"})
+	if err != nil {
+		t.Fatalf("MergeChapterBranch() error = %v", err)
+	}
+	want := map[string]map[int]int{
+		string(novel.VersionArtifactNarration): {1: 3, 2: 4},
+		string(novel.VersionArtifactImage):     {1: 2, 2: 3},
+		string(novel.VersionArtifactSubtitle):  {1: 2},
+		string(novel.VersionArtifactVideo):     {1: 1},
+	}
+	if len(result.Versions) != len(want) {
+		t.Errorf("versions = %v, want %v", result.Versions, want)
+	}
+	for artifact, mapping := range want {
+		for from, to := range mapping {
+			if got := result.Versions[artifact][from]; got != to {
+				t.Errorf("%s version %d -> %d, want %d", artifact, from, got, to)
+			}
+		}
+	}
+
+	parent, _ := f.chapters.FindByID(context.Background(), "ch-1")
+	if !result.TextUpdated || parent.ChapterText != "分支正文" {
+		t.Errorf("parent text = %q, want branch text", parent.ChapterText)
+	}
+	if parent.SubtitleVersion != 2 {
+		t.Errorf("parent subtitle version = %d, want 2", parent.SubtitleVersion)
+	}
+	// 场景跟随解说版本
+	scenes := f.scenes.byNarration("branch-narration-1")
+	if len(scenes) != 1 || scenes[0].ChapterID != "ch-1" || scenes[0].Version != 3 {
+		t.Errorf("branch scene = %+v, want moved to ch-1 version 3", scenes)
+	}
+	if got := len(f.narrations.inChapter("branch-1")); got != 0 {
+		t.Errorf("branch narrations left = %d, want all moved", got)
+	}
+	branch, _ := f.chapters.FindByID(context.Background(), "branch-1")
+	if branch.Branch.Status != novel.ChapterBranchStatusMerged {
+		t.Errorf("branch status = %s, want merged", branch.Branch.Status)
+	}
+}
+
+func TestMergeChapterBranchResumesAfterPartialFailure(t *testing.T) {
+	f := newBranchFixture()
+	f.addBranch()
+	f.images.failMove = true
+
+	if _, err := f.svc.MergeChapterBranch(context.Background(), "branch-1", &MergeChapterBranchRequest{}); !errors.Is(err, errArtifactWrite) {
+		t.Fatalf("MergeChapterBranch() error = %v, want move failure", err)
+	}
+	branch, _ := f.chapters.FindByID(context.Background(), "branch-1")
+	if branch.Branch.Status != novel.ChapterBranchStatusOpen {
+		t.Fatalf("branch status = %s, want open after failure", branch.Branch.Status)
+	}
+
+	// 解说已经移到主章节，重新合并时继续移动剩余的产物
+	f.images.failMove = false
+	result, err := f.svc.MergeChapterBranch(context.Background(), "branch-1", &MergeChapterBranchRequest{})
+	if err != nil {
+		t.Fatalf("retry MergeChapterBranch() error = %v", err)
+	}
+	if result.TextUpdated {
+		t.Error("text updated again on retry")
+	}
+	if _, ok := result.Versions[string(novel.VersionArtifactNarration)]; ok {
+		t.Errorf("versions = %v, want narration moved only once", result.Versions)
+	}
+	// 失败的那次已经分配了图片版本 2，计数器不回退
+	if got := result.Versions[string(novel.VersionArtifactImage)]; got[1] != 3 || got[2] != 4 {
+		t.Errorf("image versions = %v, want {1:3 2:4}", got)
+	}
+	versions := f.narrations.versions("ch-1")
+	slices.Sort(versions)
+	if !slices.Equal(versions, []int{1, 2, 3, 4}) {
+		t.Errorf("parent narration versions = %v, want [1 2 3 4]", versions)
+	}
+	if branch.Branch.Status != novel.ChapterBranchStatusMerged {
+		t.Errorf("branch status = %s, want merged", branch.Branch.Status)
+	}
+}
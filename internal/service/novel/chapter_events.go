@@ -0,0 +1,147 @@
+package novel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/noveltools"
+)
+
+// appendChapterEvent 追加章节流程事件；写入失败只记录日志，不影响阶段本身的结果
+func (s *novelService) appendChapterEvent(ctx context.Context, event *novel.ChapterEvent) {
+	event.ID = id.New()
+	if err := s.chapterEventRepo.Append(ctx, event); err != nil {
+		log.Error().Err(err).
+			Str("chapter_id", event.ChapterID).
+			Str("stage", event.Stage.String()).
+			Str("type", event.Type.String()).
+			Msg("追加章节流程事件失败")
+	}
+}
+
+// ListChapterEvents 获取章节的流程事件历史（按发生时间排序）
+func (s *novelService) ListChapterEvents(ctx context.Context, chapterID string) ([]*novel.ChapterEvent, error) {
+	events, err := s.chapterEventRepo.FindByChapterID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapter events: %w", err)
+	}
+	return events, nil
+}
+
+// GetChapterPipeline 获取章节渲染流程的当前状态（由章节事件投影得到）
+func (s *novelService) GetChapterPipeline(ctx context.Context, chapterID string) (*novel.ChapterPipelineState, error) {
+	if _, err := s.chapterRepo.FindByID(ctx, chapterID); err != nil {
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+	events, err := s.ListChapterEvents(ctx, chapterID)
+	if err != nil {
+		return nil, err
+	}
+	return projectChapterPipeline(chapterID, events), nil
+}
+
+// GetChapterPipelineGraph 获取章节渲染流程的依赖图
+// 各阶段的预计耗时取最近一次成功执行的耗时（见 GetRenderBreakdown），用于估算未完成阶段的关键路径
+func (s *novelService) GetChapterPipelineGraph(ctx context.Context, chapterID string) (*noveltools.PipelineGraph, error) {
+	state, err := s.GetChapterPipeline(ctx, chapterID)
+	if err != nil {
+		return nil, err
+	}
+	breakdown, err := s.GetRenderBreakdown(ctx, chapterID)
+	if err != nil {
+		return nil, err
+	}
+	estimates := make(map[novel.PipelineStage]int64, len(breakdown.Stages))
+	for _, sb := range breakdown.Stages {
+		estimates[sb.Stage] = sb.DurationMs
+	}
+	return noveltools.BuildPipelineGraph(state, estimates, time.Now()), nil
+}
+
+// projectChapterPipeline 按发生顺序重放章节事件，得到各阶段的当前状态（events 按发生时间升序）
+// 每个阶段的状态取最近一次执行：只有开始事件时为执行中，结束事件决定完成或失败
+func projectChapterPipeline(chapterID string, events []*novel.ChapterEvent) *novel.ChapterPipelineState {
+	progress := make(map[novel.PipelineStage]*novel.ChapterStageProgress, len(novel.AllPipelineStages))
+	for _, stage := range novel.AllPipelineStages {
+		progress[stage] = &novel.ChapterStageProgress{Stage: stage, State: novel.StageStatePending}
+	}
+	latestRun := make(map[novel.PipelineStage]string)
+
+	state := &novel.ChapterPipelineState{
+		ChapterID:   chapterID,
+		TotalStages: len(novel.AllPipelineStages),
+		EventCount:  len(events),
+	}
+	for _, event := range events {
+		p, ok := progress[event.Stage]
+		if !ok {
+			continue
+		}
+		occurredAt := event.OccurredAt
+		state.UpdatedAt = &occurredAt
+
+		switch event.Type {
+		case novel.ChapterEventStageStarted:
+			p.Attempts++
+			p.State = novel.StageStateRunning
+			p.LastStartedAt = &occurredAt
+			p.LastFinishedAt = nil
+			p.DurationMs = 0
+			p.ErrorMessage = ""
+			latestRun[event.Stage] = event.RunID
+		case novel.ChapterEventStageCompleted, novel.ChapterEventStageFailed:
+			// 并发执行时，较早开始的执行晚于最近一次执行结束，不覆盖最近一次执行的状态
+			if run, ok := latestRun[event.Stage]; ok && run != event.RunID {
+				if event.Type == novel.ChapterEventStageCompleted {
+					p.EverCompleted = true
+				}
+				continue
+			}
+			p.LastFinishedAt = &occurredAt
+			if event.Payload != nil {
+				p.DurationMs = event.Payload.DurationMs
+			}
+			if event.Type == novel.ChapterEventStageCompleted {
+				p.State = novel.StageStateCompleted
+				p.EverCompleted = true
+			} else {
+				p.State = novel.StageStateFailed
+				if event.Payload != nil {
+					p.ErrorMessage = event.Payload.ErrorMessage
+				}
+			}
+		}
+	}
+
+	for _, stage := range novel.AllPipelineStages {
+		p := progress[stage]
+		state.Stages = append(state.Stages, *p)
+		if p.State == novel.StageStateCompleted {
+			state.CompletedStages++
+		}
+	}
+	// 当前阶段：优先取执行中的阶段，否则取第一个未完成的阶段
+	for _, p := range state.Stages {
+		if p.State == novel.StageStateRunning {
+			state.CurrentStage = p.Stage
+			break
+		}
+	}
+	if state.CurrentStage == "" {
+		for _, p := range state.Stages {
+			if p.State != novel.StageStateCompleted {
+				state.CurrentStage = p.Stage
+				break
+			}
+		}
+	}
+	if state.TotalStages > 0 {
+		state.Progress = float64(state.CompletedStages) / float64(state.TotalStages)
+	}
+	return state
+}
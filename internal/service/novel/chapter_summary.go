@@ -0,0 +1,89 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/killswitch"
+	"lemon/internal/pkg/noveltools"
+)
+
+// ErrChapterTextEmpty 章节没有正文，无法生成摘要
+var ErrChapterTextEmpty = errors.New("chapter text is empty")
+
+// ChapterSummaryService 章节内容摘要服务接口
+type ChapterSummaryService interface {
+	// GetChapterSummary 查询章节的内容摘要，首次查询或章节全文变化后调用大模型重新生成并缓存
+	GetChapterSummary(ctx context.Context, chapterID string) (*ChapterSummaryView, error)
+}
+
+// ChapterSummaryView 章节内容摘要
+type ChapterSummaryView struct {
+	ChapterID string `json:"chapter_id"`
+	*novel.ChapterSummary
+	Cached bool `json:"cached"` // 是否直接返回缓存的摘要
+}
+
+// GetChapterSummary 查询章节的内容摘要
+// 缓存的摘要记录了生成时章节全文的哈希，哈希与当前全文一致时直接返回，否则重新生成并覆盖缓存
+func (s *novelService) GetChapterSummary(ctx context.Context, chapterID string) (*ChapterSummaryView, error) {
+	chapter, err := s.chapterRepo.FindByID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+	if strings.TrimSpace(chapter.ChapterText) == "" {
+		return nil, ErrChapterTextEmpty
+	}
+
+	textHash := noveltools.HashPrompt(chapter.ChapterText)
+	if chapter.Summary != nil && chapter.Summary.TextHash == textHash {
+		return &ChapterSummaryView{ChapterID: chapter.ID, ChapterSummary: chapter.Summary, Cached: true}, nil
+	}
+
+	summary, err := s.summarizeChapter(ctx, chapter)
+	if err != nil {
+		return nil, err
+	}
+	summary.TextHash = textHash
+	summary.GeneratedAt = time.Now()
+	if err := s.chapterRepo.SetSummary(ctx, chapter.ID, summary); err != nil {
+		return nil, fmt.Errorf("save chapter summary: %w", err)
+	}
+
+	log.Info().
+		Str("chapter_id", chapter.ID).
+		Strs("pov_characters", summary.POVCharacters).
+		Int("estimated_scenes", summary.EstimatedScenes).
+		Msg("章节摘要生成完成")
+	return &ChapterSummaryView{ChapterID: chapter.ID, ChapterSummary: summary}, nil
+}
+
+// summarizeChapter 调用大模型生成章节摘要
+func (s *novelService) summarizeChapter(ctx context.Context, chapter *novel.Chapter) (*novel.ChapterSummary, error) {
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderLLM)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if err := s.checkBudget(ctx, chapter.NovelID); err != nil {
+		return nil, err
+	}
+	prompt, err := noveltools.BuildChapterSummaryPrompt(chapter.Title, chapter.ChapterText)
+	if err != nil {
+		return nil, err
+	}
+	prompt = s.withNovelContext(ctx, chapter.NovelID, prompt)
+	output, err := s.llmProvider.Generate(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("generate chapter summary: %w", err)
+	}
+	s.recordLLMCost(ctx, chapter.NovelID, chapter.ID, prompt, output)
+	return noveltools.ParseChapterSummary(output)
+}
@@ -0,0 +1,239 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/service"
+)
+
+// ErrInvalidCleanup 清理请求不合法（产物类型未知、时间范围为负等）
+var ErrInvalidCleanup = errors.New("invalid cleanup request")
+
+// DefaultCleanupOlderThan 未指定时只清理失败超过该时长的产物，避免误删正在重试的任务
+const DefaultCleanupOlderThan = 24 * time.Hour
+
+// 可清理的产物类型
+const (
+	CleanupKindImage = "image"
+	CleanupKindVideo = "video"
+)
+
+// CleanupService 失败产物清理服务接口
+type CleanupService interface {
+	// CleanupFailedArtifacts 批量删除失败且超过指定时长的图片/视频，并删除其在存储中的文件
+	// dry_run 时只返回将被删除的产物，不做任何修改
+	CleanupFailedArtifacts(ctx context.Context, req *CleanupRequest) (*CleanupReport, error)
+}
+
+// CleanupRequest 失败产物清理请求
+type CleanupRequest struct {
+	NovelID   string        // 只清理该小说的产物（为空时清理所有小说）
+	Kinds     []string      // 产物类型：image、video（为空时两者都清理）
+	OlderThan time.Duration // 最后更新时间早于 now-OlderThan 的产物才会被清理（为 0 时使用 DefaultCleanupOlderThan）
+	DryRun    bool          // 只预览，不删除
+}
+
+// CleanupItem 单个待清理（或已清理）的产物
+type CleanupItem struct {
+	Kind         string    `json:"kind"`
+	ID           string    `json:"id"`
+	NovelID      string    `json:"novel_id"`
+	ChapterID    string    `json:"chapter_id"`
+	NarrationID  string    `json:"narration_id,omitempty"`
+	Version      int       `json:"version"`
+	ResourceIDs  []string  `json:"resource_ids,omitempty"`  // 关联的存储资源
+	ErrorMessage string    `json:"error_message,omitempty"` // 产物失败原因（仅视频记录）
+	UpdatedAt    time.Time `json:"updated_at"`
+	Deleted      bool      `json:"deleted"`         // 是否已删除（dry_run 时始终为 false）
+	Error        string    `json:"error,omitempty"` // 删除失败原因
+}
+
+// CleanupReport 清理报告（dry_run 时为预览）
+type CleanupReport struct {
+	DryRun           bool           `json:"dry_run"`
+	NovelID          string         `json:"novel_id,omitempty"`
+	Kinds            []string       `json:"kinds"`
+	Cutoff           time.Time      `json:"cutoff"` // 最后更新时间早于该时间的失败产物被选中
+	Items            []*CleanupItem `json:"items"`
+	Matched          int            `json:"matched"`           // 选中的产物数
+	Deleted          int            `json:"deleted"`           // 已删除的产物数
+	Failed           int            `json:"failed"`            // 删除失败的产物数
+	ResourcesDeleted int            `json:"resources_deleted"` // 已删除的存储资源数
+}
+
+// CleanupFailedArtifacts 批量清理失败产物
+// 每个产物先删除关联的存储资源，全部成功后再软删除产物记录；资源删除失败时保留记录，下次清理会重试
+func (s *novelService) CleanupFailedArtifacts(ctx context.Context, req *CleanupRequest) (*CleanupReport, error) {
+	kinds, err := normalizeCleanupKinds(req.Kinds)
+	if err != nil {
+		return nil, err
+	}
+	if req.OlderThan < 0 {
+		return nil, fmt.Errorf("%w: older_than must not be negative", ErrInvalidCleanup)
+	}
+	olderThan := req.OlderThan
+	if olderThan == 0 {
+		olderThan = DefaultCleanupOlderThan
+	}
+
+	if req.NovelID != "" {
+		if _, err := s.novelRepo.FindByID(ctx, req.NovelID); err != nil {
+			return nil, fmt.Errorf("find novel: %w", err)
+		}
+	}
+
+	report := &CleanupReport{
+		DryRun:  req.DryRun,
+		NovelID: req.NovelID,
+		Kinds:   kinds,
+		Cutoff:  time.Now().Add(-olderThan).UTC(),
+		Items:   []*CleanupItem{},
+	}
+
+	for _, kind := range kinds {
+		items, err := s.findFailedArtifacts(ctx, kind, report.Cutoff, req.NovelID)
+		if err != nil {
+			return nil, err
+		}
+		report.Items = append(report.Items, items...)
+	}
+	report.Matched = len(report.Items)
+	if req.DryRun {
+		return report, nil
+	}
+
+	for _, item := range report.Items {
+		removed, err := s.deleteFailedArtifact(ctx, item)
+		report.ResourcesDeleted += removed
+		if err != nil {
+			item.Error = err.Error()
+			report.Failed++
+			log.Warn().Err(err).
+				Str("kind", item.Kind).
+				Str("id", item.ID).
+				Msg("清理失败产物出错")
+			continue
+		}
+		item.Deleted = true
+		report.Deleted++
+	}
+
+	log.Info().
+		Str("novel_id", req.NovelID).
+		Int("matched", report.Matched).
+		Int("deleted", report.Deleted).
+		Int("failed", report.Failed).
+		Int("resources_deleted", report.ResourcesDeleted).
+		Msg("失败产物清理完成")
+	return report, nil
+}
+
+// normalizeCleanupKinds 校验并去重产物类型，为空时返回全部类型
+func normalizeCleanupKinds(kinds []string) ([]string, error) {
+	if len(kinds) == 0 {
+		return []string{CleanupKindImage, CleanupKindVideo}, nil
+	}
+	seen := make(map[string]bool, len(kinds))
+	result := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		switch kind {
+		case CleanupKindImage, CleanupKindVideo:
+		default:
+			return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidCleanup, kind)
+		}
+		if seen[kind] {
+			continue
+		}
+		seen[kind] = true
+		result = append(result, kind)
+	}
+	return result, nil
+}
+
+// findFailedArtifacts 查询指定类型中失败且早于 cutoff 的产物
+func (s *novelService) findFailedArtifacts(ctx context.Context, kind string, cutoff time.Time, novelID string) ([]*CleanupItem, error) {
+	var items []*CleanupItem
+	switch kind {
+	case CleanupKindImage:
+		images, err := s.imageRepo.FindByStatusUpdatedBefore(ctx, novel.TaskStatusFailed, cutoff, novelID)
+		if err != nil {
+			return nil, fmt.Errorf("find failed images: %w", err)
+		}
+		for _, img := range images {
+			items = append(items, &CleanupItem{
+				Kind:        CleanupKindImage,
+				ID:          img.ID,
+				NovelID:     img.NovelID,
+				ChapterID:   img.ChapterID,
+				NarrationID: img.NarrationID,
+				Version:     img.Version,
+				ResourceIDs: nonEmptyStrings(img.ImageResourceID),
+				UpdatedAt:   img.UpdatedAt,
+			})
+		}
+	case CleanupKindVideo:
+		videos, err := s.videoRepo.FindByStatusUpdatedBefore(ctx, novel.VideoStatusFailed, cutoff, novelID)
+		if err != nil {
+			return nil, fmt.Errorf("find failed videos: %w", err)
+		}
+		for _, v := range videos {
+			items = append(items, &CleanupItem{
+				Kind:         CleanupKindVideo,
+				ID:           v.ID,
+				NovelID:      v.NovelID,
+				ChapterID:    v.ChapterID,
+				NarrationID:  v.NarrationID,
+				Version:      v.Version,
+				ResourceIDs:  nonEmptyStrings(v.VideoResourceID, v.LastFrameResourceID, v.ThumbnailSpriteResourceID, v.ThumbnailVTTResourceID),
+				ErrorMessage: v.ErrorMessage,
+				UpdatedAt:    v.UpdatedAt,
+			})
+		}
+	}
+	return items, nil
+}
+
+// deleteFailedArtifact 删除产物关联的存储资源和产物记录，返回已删除的资源数
+// 资源已不存在视为已清理
+func (s *novelService) deleteFailedArtifact(ctx context.Context, item *CleanupItem) (int, error) {
+	removed := 0
+	for _, resourceID := range item.ResourceIDs {
+		err := s.resourceService.DeleteResource(ctx, resourceID)
+		if errors.Is(err, service.ErrResourceNotFound) {
+			continue
+		}
+		if err != nil {
+			return removed, fmt.Errorf("delete resource %s: %w", resourceID, err)
+		}
+		removed++
+	}
+
+	var err error
+	switch item.Kind {
+	case CleanupKindImage:
+		err = s.imageRepo.Delete(ctx, item.ID)
+	case CleanupKindVideo:
+		err = s.videoRepo.Delete(ctx, item.ID)
+	}
+	if err != nil {
+		return removed, fmt.Errorf("delete %s record: %w", item.Kind, err)
+	}
+	return removed, nil
+}
+
+// nonEmptyStrings 过滤空字符串
+func nonEmptyStrings(values ...string) []string {
+	var result []string
+	for _, v := range values {
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,534 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/errclass"
+	"lemon/internal/pkg/ffmpeg"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/killswitch"
+	"lemon/internal/pkg/noveltools"
+	"lemon/internal/pkg/procreaper"
+	"lemon/internal/service"
+)
+
+// 回顾合集目标时长（秒）
+const (
+	defaultCompilationDuration = 60.0
+	maxCompilationDuration     = 600.0
+)
+
+// ErrInvalidCompilation 回顾合集请求不合法（选择方式或时长无效、镜头不属于小说、没有可用镜头或镜头缺少画面）
+var ErrInvalidCompilation = errors.New("invalid compilation")
+
+// CompilationService 跨章节回顾合集服务接口
+type CompilationService interface {
+	// CreateCompilation 从多个章节中选取镜头（手动指定或按评分挑选高光），由大模型重新撰写回顾解说，
+	// 按新解说的配音时长重新剪辑每个镜头并拼接成横版合集视频；与章节的视频版本互不影响
+	CreateCompilation(ctx context.Context, req *CreateCompilationRequest) (*novel.Compilation, error)
+
+	// GetCompilation 获取回顾合集
+	GetCompilation(ctx context.Context, compilationID string) (*novel.Compilation, error)
+
+	// ListCompilations 查询小说的回顾合集（按创建时间倒序）
+	ListCompilations(ctx context.Context, novelID string, limit int) ([]*novel.Compilation, error)
+}
+
+// CreateCompilationRequest 创建回顾合集请求
+type CreateCompilationRequest struct {
+	NovelID        string                     // 小说ID
+	UserID         string                     // 操作用户ID
+	Title          string                     // 合集标题（为空时按章节范围生成）
+	Selection      novel.CompilationSelection // 镜头选择方式：manual, highlights
+	ShotIDs        []string                   // 手动指定的镜头ID（按播放顺序，Selection 为 manual 时必填）
+	ChapterFrom    int                        // 起始章节序号（0 表示第一章）
+	ChapterTo      int                        // 结束章节序号（0 表示最后一章）
+	TargetDuration float64                    // 目标时长（秒，0 表示默认 60 秒，最长 600 秒）
+}
+
+// compilationShot 合集中的一个镜头及其画面来源
+type compilationShot struct {
+	shot    *novel.Shot
+	chapter *novel.Chapter
+	video   *novel.Video // 单镜头片段（为空时使用 image）
+	image   *novel.Image
+}
+
+// CreateCompilation 创建回顾合集
+// 先选出镜头并确认每个镜头都有画面，再调用大模型撰写解说，避免在缺少素材时产生费用；
+// 渲染失败时合集记录为 failed 并返回错误
+func (s *novelService) CreateCompilation(ctx context.Context, req *CreateCompilationRequest) (*novel.Compilation, error) {
+	target := req.TargetDuration
+	if target == 0 {
+		target = defaultCompilationDuration
+	}
+	if target < 0 || target > maxCompilationDuration {
+		return nil, fmt.Errorf("%w: target_duration must be between 1 and %.0f seconds", ErrInvalidCompilation, maxCompilationDuration)
+	}
+
+	n, err := s.novelRepo.FindByID(ctx, req.NovelID)
+	if err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+	chapters, from, to, err := s.compilationChapters(ctx, n.ID, req.ChapterFrom, req.ChapterTo)
+	if err != nil {
+		return nil, err
+	}
+
+	gen := s.generationSettings(ctx, n.ID)
+	// 竖版片段裁成横版时只保留画面中部，章节片段烧录的字幕随之裁掉；横版章节的片段字幕会保留，只能使用镜头图片
+	useClips := gen.VideoHeight > gen.VideoWidth
+
+	var shots []*compilationShot
+	switch req.Selection {
+	case novel.CompilationSelectionManual:
+		shots, err = s.manualCompilationShots(ctx, n.ID, req.ShotIDs, chapters)
+	case novel.CompilationSelectionHighlights:
+		shots, err = s.highlightCompilationShots(ctx, chapters, target)
+	default:
+		return nil, fmt.Errorf("%w: unknown selection %q", ErrInvalidCompilation, req.Selection)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, cs := range shots {
+		if err := s.resolveCompilationSource(ctx, cs, useClips); err != nil {
+			return nil, err
+		}
+	}
+
+	script, err := s.writeRecapScript(ctx, n, shots, target)
+	if err != nil {
+		return nil, err
+	}
+
+	userID := req.UserID
+	if userID == "" {
+		userID = n.UserID
+	}
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		title = fmt.Sprintf("%s 第%d-%d章回顾", n.Title, from, to)
+	}
+	width, height := gen.VideoWidth, gen.VideoHeight
+	if height > width {
+		width, height = height, width
+	}
+	compilation := &novel.Compilation{
+		ID:             id.New(),
+		NovelID:        n.ID,
+		UserID:         userID,
+		Title:          title,
+		ChapterFrom:    from,
+		ChapterTo:      to,
+		Selection:      req.Selection,
+		TargetDuration: target,
+		Clips:          make([]novel.CompilationClip, 0, len(shots)),
+		Width:          width,
+		Height:         height,
+		FPS:            gen.VideoFPS,
+		Status:         novel.VideoStatusProcessing,
+	}
+	for i, cs := range shots {
+		clip := novel.CompilationClip{
+			ChapterID:       cs.chapter.ID,
+			ChapterSequence: cs.chapter.Sequence,
+			ShotID:          cs.shot.ID,
+			Rating:          cs.shot.Rating,
+			Narration:       script[i],
+		}
+		if cs.video != nil {
+			clip.SourceVideoID = cs.video.ID
+		} else {
+			clip.SourceImageID = cs.image.ID
+		}
+		compilation.Clips = append(compilation.Clips, clip)
+	}
+	if err := s.compilationRepo.Create(ctx, compilation); err != nil {
+		return nil, fmt.Errorf("create compilation: %w", err)
+	}
+
+	if err := s.renderCompilation(ctx, compilation, shots); err != nil {
+		class, _ := errclass.Classify(err)
+		if updateErr := s.compilationRepo.Update(ctx, compilation.ID, bson.M{
+			"status":        novel.VideoStatusFailed,
+			"error_message": err.Error(),
+			"error_class":   string(class),
+		}); updateErr != nil {
+			log.Error().Err(updateErr).Str("compilation_id", compilation.ID).Msg("更新回顾合集失败状态失败")
+		}
+		return nil, fmt.Errorf("render compilation: %w", err)
+	}
+
+	compilation.Status = novel.VideoStatusCompleted
+	if err := s.compilationRepo.Update(ctx, compilation.ID, bson.M{
+		"clips":             compilation.Clips,
+		"video_resource_id": compilation.VideoResourceID,
+		"duration":          compilation.Duration,
+		"attribution":       compilation.Attribution,
+		"status":            compilation.Status,
+	}); err != nil {
+		return nil, fmt.Errorf("update compilation: %w", err)
+	}
+
+	log.Info().
+		Str("novel_id", n.ID).
+		Str("compilation_id", compilation.ID).
+		Str("selection", string(req.Selection)).
+		Int("clips", len(compilation.Clips)).
+		Float64("duration", compilation.Duration).
+		Msg("回顾合集生成成功")
+
+	return compilation, nil
+}
+
+// GetCompilation 获取回顾合集
+func (s *novelService) GetCompilation(ctx context.Context, compilationID string) (*novel.Compilation, error) {
+	compilation, err := s.compilationRepo.FindByID(ctx, compilationID)
+	if err != nil {
+		return nil, fmt.Errorf("find compilation: %w", err)
+	}
+	return compilation, nil
+}
+
+// ListCompilations 查询小说的回顾合集
+func (s *novelService) ListCompilations(ctx context.Context, novelID string, limit int) ([]*novel.Compilation, error) {
+	if _, err := s.novelRepo.FindByID(ctx, novelID); err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+	compilations, err := s.compilationRepo.FindByNovelID(ctx, novelID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("find compilations: %w", err)
+	}
+	return compilations, nil
+}
+
+// compilationChapters 查询章节范围内的章节（按章节ID索引），返回实际的起止序号
+func (s *novelService) compilationChapters(ctx context.Context, novelID string, from, to int) (map[string]*novel.Chapter, int, int, error) {
+	all, err := s.chapterRepo.FindByNovelID(ctx, novelID)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("find chapters: %w", err)
+	}
+	if len(all) == 0 {
+		return nil, 0, 0, fmt.Errorf("%w: novel has no chapters", ErrInvalidCompilation)
+	}
+	first, last := all[0].Sequence, all[0].Sequence
+	for _, chapter := range all {
+		first = min(first, chapter.Sequence)
+		last = max(last, chapter.Sequence)
+	}
+	if from == 0 {
+		from = first
+	}
+	if to == 0 {
+		to = last
+	}
+	if from < 0 || to < from {
+		return nil, 0, 0, fmt.Errorf("%w: invalid chapter range %d-%d", ErrInvalidCompilation, from, to)
+	}
+
+	chapters := make(map[string]*novel.Chapter)
+	for _, chapter := range all {
+		if chapter.Sequence >= from && chapter.Sequence <= to {
+			chapters[chapter.ID] = chapter
+		}
+	}
+	if len(chapters) == 0 {
+		return nil, 0, 0, fmt.Errorf("%w: no chapters in range %d-%d", ErrInvalidCompilation, from, to)
+	}
+	return chapters, from, to, nil
+}
+
+// manualCompilationShots 按手动指定的镜头列表（播放顺序）构建合集镜头，镜头必须属于小说且在章节范围内
+func (s *novelService) manualCompilationShots(ctx context.Context, novelID string, shotIDs []string, chapters map[string]*novel.Chapter) ([]*compilationShot, error) {
+	if len(shotIDs) == 0 {
+		return nil, fmt.Errorf("%w: shot_ids is required for manual selection", ErrInvalidCompilation)
+	}
+	if len(shotIDs) > noveltools.MaxCompilationClips {
+		return nil, fmt.Errorf("%w: at most %d shots", ErrInvalidCompilation, noveltools.MaxCompilationClips)
+	}
+
+	shots := make([]*compilationShot, 0, len(shotIDs))
+	for _, shotID := range shotIDs {
+		shot, err := s.shotRepo.FindByID(ctx, shotID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: shot %s not found", ErrInvalidCompilation, shotID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("find shot: %w", err)
+		}
+		if shot.NovelID != novelID {
+			return nil, fmt.Errorf("%w: shot %s does not belong to novel", ErrInvalidCompilation, shotID)
+		}
+		chapter, ok := chapters[shot.ChapterID]
+		if !ok {
+			return nil, fmt.Errorf("%w: shot %s is outside the chapter range", ErrInvalidCompilation, shotID)
+		}
+		shots = append(shots, &compilationShot{shot: shot, chapter: chapter})
+	}
+	return shots, nil
+}
+
+// highlightCompilationShots 从章节范围内每章当前解说的镜头中按评分挑选高光镜头（按故事顺序返回）
+func (s *novelService) highlightCompilationShots(ctx context.Context, chapters map[string]*novel.Chapter, target float64) ([]*compilationShot, error) {
+	byID := make(map[string]*compilationShot)
+	var candidates []noveltools.CompilationCandidate
+	for _, chapter := range chapters {
+		narration, err := s.narrationRepo.FindByChapterID(ctx, chapter.ID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("find narration for chapter %d: %w", chapter.Sequence, err)
+		}
+		shots, err := s.shotRepo.FindByNarrationID(ctx, narration.ID)
+		if err != nil {
+			return nil, fmt.Errorf("find shots for chapter %d: %w", chapter.Sequence, err)
+		}
+		for _, shot := range shots {
+			byID[shot.ID] = &compilationShot{shot: shot, chapter: chapter}
+			candidates = append(candidates, noveltools.CompilationCandidate{
+				ShotID:          shot.ID,
+				ChapterSequence: chapter.Sequence,
+				Index:           shot.Index,
+				Rating:          shot.Rating,
+				Narration:       shot.Narration,
+			})
+		}
+	}
+
+	selected := noveltools.SelectCompilationHighlights(candidates, target)
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("%w: no rated shots in chapter range", ErrInvalidCompilation)
+	}
+	shots := make([]*compilationShot, 0, len(selected))
+	for _, c := range selected {
+		shots = append(shots, byID[c.ShotID])
+	}
+	return shots, nil
+}
+
+// resolveCompilationSource 确定镜头的画面来源：useClips 时优先使用章节最新视频版本中的单镜头片段，否则使用镜头图片
+func (s *novelService) resolveCompilationSource(ctx context.Context, cs *compilationShot, useClips bool) error {
+	if useClips {
+		if version, err := s.resolveVideoVersion(ctx, cs.shot.ChapterID, 0); err == nil {
+			if video, err := s.findShotClip(ctx, cs.shot, version); err == nil {
+				cs.video = video
+				return nil
+			}
+		}
+	}
+	image, err := s.findShotImage(ctx, cs.shot.ChapterID, cs.shot, cs.shot.SceneNumber, cs.shot.ShotNumber)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("%w: shot %s-%s of chapter %d has no clip or image", ErrInvalidCompilation,
+			cs.shot.SceneNumber, cs.shot.ShotNumber, cs.chapter.Sequence)
+	}
+	if err != nil {
+		return fmt.Errorf("find shot image: %w", err)
+	}
+	cs.image = image
+	return nil
+}
+
+// writeRecapScript 调用大模型为合集镜头撰写回顾解说，大模型漏掉的镜头使用原解说
+func (s *novelService) writeRecapScript(ctx context.Context, n *novel.Novel, shots []*compilationShot, target float64) ([]string, error) {
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderLLM)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	clips := make([]noveltools.CompilationCandidate, 0, len(shots))
+	for _, cs := range shots {
+		clips = append(clips, noveltools.CompilationCandidate{
+			ShotID:          cs.shot.ID,
+			ChapterSequence: cs.chapter.Sequence,
+			Index:           cs.shot.Index,
+			Rating:          cs.shot.Rating,
+			Narration:       cs.shot.Narration,
+		})
+	}
+	prompt, err := noveltools.BuildRecapScriptPrompt(n.Title, clips, target)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCompilation, err)
+	}
+
+	if err := s.checkBudget(ctx, n.ID); err != nil {
+		return nil, err
+	}
+	output, err := s.llmProvider.Generate(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("generate recap script: %w", err)
+	}
+	s.recordLLMCost(ctx, n.ID, "", prompt, output)
+
+	script, err := noveltools.ParseRecapScript(output, len(clips))
+	if err != nil {
+		return nil, err
+	}
+	for i, line := range script {
+		if line == "" {
+			log.Warn().Str("novel_id", n.ID).Str("shot_id", clips[i].ShotID).Msg("回顾解说缺少镜头，使用镜头原解说")
+			script[i] = strings.TrimSpace(clips[i].Narration)
+		}
+	}
+	return script, nil
+}
+
+// renderCompilation 为每个镜头合成回顾解说配音和字幕，按配音时长重新剪辑画面（横版），拼接后上传
+func (s *novelService) renderCompilation(ctx context.Context, compilation *novel.Compilation, shots []*compilationShot) error {
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderTTS)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	novelID := compilation.NovelID
+	voice := s.novelVoice(ctx, novelID)
+	hints := s.novelPronunciationHints(ctx, novelID)
+	numberStyle := s.novelNumberStyle(ctx, novelID)
+	render := s.novelRenderSettings(ctx, novelID)
+	textCleaner := noveltools.NewTextCleaner()
+	ffmpegClient := ffmpeg.NewClient()
+
+	tmpDir, cleanupTmpDir, err := procreaper.MkdirTemp(ctx, "lemon-compilation-*")
+	if err != nil {
+		return fmt.Errorf("create temp dir: %w", err)
+	}
+	defer cleanupTmpDir()
+
+	clipPaths := make([]string, 0, len(shots))
+	start := 0.0
+	for i, cs := range shots {
+		clip := &compilation.Clips[i]
+		text := textCleaner.CleanTextForTTS(noveltools.NormalizeNumbers(clip.Narration, numberStyle))
+		if text == "" {
+			return fmt.Errorf("%w: recap narration for shot %s is empty", ErrInvalidCompilation, clip.ShotID)
+		}
+
+		// 1. 回顾解说配音
+		if err := s.checkBudget(ctx, novelID); err != nil {
+			return err
+		}
+		tts, err := s.generateVoice(ctx, text, voice, hints)
+		if err != nil {
+			return fmt.Errorf("generate recap voice for clip %d: %w", i+1, err)
+		}
+		if !tts.Success {
+			return fmt.Errorf("generate recap voice for clip %d: %s", i+1, tts.ErrorMessage)
+		}
+		chars := utf8.RuneCountInString(text)
+		s.recordCost(ctx, novelID, clip.ChapterID, killswitch.ProviderTTS, float64(chars), s.pricing.TTS(chars))
+
+		audioPath := filepath.Join(tmpDir, fmt.Sprintf("audio_%03d.%s", i+1, audioExt(tts.AudioData)))
+		if err := os.WriteFile(audioPath, tts.AudioData, 0644); err != nil {
+			return fmt.Errorf("write audio for clip %d: %w", i+1, err)
+		}
+		duration := recapVoiceDuration(tts, text, voice.SpeedRatio)
+
+		// 2. 字幕（按 TTS 字符时间戳，没有时间戳时按估算语速）
+		var timeline []noveltools.SegmentTimestamp
+		if tts.TimestampData != nil && len(tts.TimestampData.CharacterTimestamps) > 0 {
+			segments := noveltools.NewSubtitleSplitter(20).SplitTextNaturally(text)
+			timeline = noveltools.NewSubtitleTimestampCalculator().CalculateSegmentTimestamps(segments, tts.TimestampData.CharacterTimestamps, text)
+		}
+		if len(timeline) == 0 {
+			timeline = noveltools.EstimateSubtitleTimeline(text, voice.SpeedRatio, 0)
+		}
+		assContent := noveltools.NewASSGeneratorWithStyle(render.Subtitle).GenerateASSContent(timeline, fmt.Sprintf("Compilation Subtitle %d", i+1))
+		subtitlePath := filepath.Join(tmpDir, fmt.Sprintf("subtitle_%03d.ass", i+1))
+		if err := os.WriteFile(subtitlePath, []byte(assContent), 0644); err != nil {
+			return fmt.Errorf("write subtitle for clip %d: %w", i+1, err)
+		}
+
+		// 3. 画面按配音时长重新剪辑（片段比配音短时用最后一帧补齐）
+		assembly := ffmpeg.ShotAssembly{
+			AudioPath:    audioPath,
+			SubtitlePath: subtitlePath,
+			Duration:     duration,
+			Width:        compilation.Width,
+			Height:       compilation.Height,
+			FPS:          compilation.FPS,
+		}
+		if cs.video != nil {
+			assembly.VideoPath = filepath.Join(tmpDir, fmt.Sprintf("source_%03d.mp4", i+1))
+			if err := s.downloadResourceToFile(ctx, cs.video.VideoResourceID, assembly.VideoPath); err != nil {
+				return fmt.Errorf("download source clip for clip %d: %w", i+1, err)
+			}
+		} else {
+			assembly.ImagePath = filepath.Join(tmpDir, fmt.Sprintf("source_%03d.jpg", i+1))
+			if err := s.downloadResourceToFile(ctx, cs.image.ImageResourceID, assembly.ImagePath); err != nil {
+				return fmt.Errorf("download source image for clip %d: %w", i+1, err)
+			}
+		}
+		clipPath := filepath.Join(tmpDir, fmt.Sprintf("clip_%03d.mp4", i+1))
+		if err := ffmpegClient.AssembleShot(ctx, assembly, clipPath); err != nil {
+			return fmt.Errorf("assemble compilation clip %d: %w", i+1, err)
+		}
+		clipPaths = append(clipPaths, clipPath)
+
+		clip.Start = math.Round(start*100) / 100
+		clip.Duration = math.Round(duration*100) / 100
+		start += duration
+	}
+
+	// 4. 拼接、渲染原作署名并上传
+	concatPath := filepath.Join(tmpDir, "compilation.mp4")
+	if err := ffmpegClient.ConcatVideos(ctx, clipPaths, concatPath); err != nil {
+		return fmt.Errorf("concat compilation clips: %w", err)
+	}
+	outputPath, attribution, err := s.renderAttribution(ctx, novelID, concatPath, tmpDir, "", ffmpegClient)
+	if err != nil {
+		return fmt.Errorf("render attribution: %w", err)
+	}
+	outputFile, err := os.Open(outputPath)
+	if err != nil {
+		return fmt.Errorf("open compilation video: %w", err)
+	}
+	defer outputFile.Close()
+
+	uploadResult, err := s.resourceService.UploadLargeFile(ctx, &service.UploadFileRequest{
+		UserID:      compilation.UserID,
+		FileName:    fmt.Sprintf("%s_compilation_%s.mp4", novelID, compilation.ID),
+		ContentType: "video/mp4",
+		Ext:         "mp4",
+		Data:        outputFile,
+		KeyVars:     novelKeyVars(novelID, artifactCompilation),
+	})
+	if err != nil {
+		return fmt.Errorf("upload compilation video: %w", err)
+	}
+
+	compilation.VideoResourceID = uploadResult.ResourceID
+	compilation.Attribution = attribution
+	if attribution != nil && attribution.Placement == novel.AttributionPlacementEndFrame {
+		start += attribution.Duration
+	}
+	compilation.Duration = math.Round(start*100) / 100
+	return nil
+}
+
+// recapVoiceDuration 回顾解说配音时长：优先使用 TTS 返回的时长，其次是最后一个字符的结束时间，都没有时按语速估算
+func recapVoiceDuration(tts *noveltools.TTSResult, text string, speedRatio float64) float64 {
+	if tts.Duration > 0 {
+		return tts.Duration
+	}
+	if tts.TimestampData != nil {
+		if chars := tts.TimestampData.CharacterTimestamps; len(chars) > 0 && chars[len(chars)-1].EndTime > 0 {
+			return chars[len(chars)-1].EndTime
+		}
+	}
+	return noveltools.EstimateReadingTime(text, speedRatio).Duration
+}
@@ -0,0 +1,37 @@
+package novel
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ffmpeg"
+	"lemon/internal/pkg/noveltools"
+)
+
+// applyPlatformCompliance 按目标平台的预设处理最终视频（响度归一化、真峰值限制、码率上限、像素格式、faststart），
+// 处理后探测成片生成校验报告；处理失败返回错误，探测失败只记录在报告中
+func (s *novelService) applyPlatformCompliance(ctx context.Context, ffmpegClient *ffmpeg.Client, inputPath, outputPath string, platform novel.TargetPlatform) (*novel.ComplianceReport, error) {
+	preset := noveltools.CompliancePresetForPlatform(platform)
+
+	input, output, err := ffmpegClient.ApplyCompliance(ctx, inputPath, outputPath, preset)
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := ffmpegClient.ProbeMediaFormat(ctx, outputPath)
+	if err != nil {
+		log.Warn().Err(err).Str("preset", preset.Name).Msg("探测最终视频格式失败，校验报告不包含格式信息")
+		format = nil
+	}
+
+	report := noveltools.BuildComplianceReport(preset, input, output, format)
+	if !report.Passed {
+		log.Warn().
+			Str("preset", report.Preset).
+			Strs("issues", report.Issues).
+			Msg("最终视频未完全符合平台规范")
+	}
+	return report, nil
+}
@@ -0,0 +1,172 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/noveltools"
+)
+
+// ErrContentRiskReportStale 风险报告扫描后解说或字幕文案已被修改（或风险规则已更新），需要重新扫描
+var ErrContentRiskReportStale = errors.New("content risk report is stale")
+
+// ContentRiskService 发布前内容风险扫描服务接口
+type ContentRiskService interface {
+	// ScanChapterContentRisk 扫描章节解说（narrationVersion 为 0 时取最新版本）的解说文本和字幕文案，生成内容风险报告
+	// 扫描内容与已有报告相同时直接返回已有报告（保留确认状态）
+	ScanChapterContentRisk(ctx context.Context, chapterID string, narrationVersion int) (*novel.ContentRiskReport, error)
+
+	// ListChapterContentRiskReports 查询章节的内容风险报告（按时间倒序）
+	ListChapterContentRiskReports(ctx context.Context, chapterID string) ([]*novel.ContentRiskReport, error)
+
+	// AcknowledgeContentRisk 编辑确认风险报告，确认后该解说版本才能发布；报告已过期时返回 ErrContentRiskReportStale
+	AcknowledgeContentRisk(ctx context.Context, reportID string, req *AcknowledgeContentRiskRequest) (*novel.ContentRiskReport, error)
+}
+
+// AcknowledgeContentRiskRequest 确认风险报告请求
+type AcknowledgeContentRiskRequest struct {
+	AcknowledgedBy string // 确认人用户ID
+	Note           string // 确认说明
+}
+
+// ScanChapterContentRisk 扫描章节解说并生成内容风险报告
+func (s *novelService) ScanChapterContentRisk(ctx context.Context, chapterID string, narrationVersion int) (*novel.ContentRiskReport, error) {
+	chapter, err := s.chapterRepo.FindByID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+	return s.chapterContentRiskReport(ctx, chapter, narrationVersion)
+}
+
+// ListChapterContentRiskReports 查询章节的内容风险报告
+func (s *novelService) ListChapterContentRiskReports(ctx context.Context, chapterID string) ([]*novel.ContentRiskReport, error) {
+	if _, err := s.chapterRepo.FindByID(ctx, chapterID); err != nil {
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+	reports, err := s.contentRiskReportRepo.FindByChapterID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find content risk reports: %w", err)
+	}
+	return reports, nil
+}
+
+// AcknowledgeContentRisk 编辑确认风险报告
+// 确认前按报告的解说版本重新计算指纹，与报告不一致时说明内容已被修改，需要重新扫描后再确认；重复确认直接返回已确认的报告
+func (s *novelService) AcknowledgeContentRisk(ctx context.Context, reportID string, req *AcknowledgeContentRiskRequest) (*novel.ContentRiskReport, error) {
+	report, err := s.contentRiskReportRepo.FindByID(ctx, reportID)
+	if err != nil {
+		return nil, fmt.Errorf("find content risk report: %w", err)
+	}
+	if report.IsAcknowledged() {
+		return report, nil
+	}
+
+	narration, err := s.narrationRepo.FindByID(ctx, report.NarrationID)
+	if err != nil {
+		return nil, fmt.Errorf("find narration: %w", err)
+	}
+	segments, err := s.contentRiskSegments(ctx, narration)
+	if err != nil {
+		return nil, err
+	}
+	if noveltools.ContentRiskFingerprint(segments, s.novelTargetPlatform(ctx, report.NovelID)) != report.Fingerprint {
+		return nil, fmt.Errorf("%w: report %s", ErrContentRiskReportStale, reportID)
+	}
+
+	if err := s.contentRiskReportRepo.Acknowledge(ctx, reportID, req.AcknowledgedBy, req.Note); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("acknowledge content risk report: %w", err)
+	}
+	log.Info().
+		Str("report_id", reportID).
+		Str("chapter_id", report.ChapterID).
+		Int("findings", len(report.Findings)).
+		Str("acknowledged_by", req.AcknowledgedBy).
+		Msg("内容风险报告已确认")
+
+	return s.contentRiskReportRepo.FindByID(ctx, reportID)
+}
+
+// chapterContentRiskReport 扫描章节指定解说版本（为 0 时取最新版本），复用指纹相同的已有报告，否则保存新报告
+func (s *novelService) chapterContentRiskReport(ctx context.Context, ch *novel.Chapter, narrationVersion int) (*novel.ContentRiskReport, error) {
+	var narration *novel.Narration
+	var err error
+	if narrationVersion > 0 {
+		narration, err = s.narrationRepo.FindByChapterIDAndVersion(ctx, ch.ID, narrationVersion)
+	} else {
+		narration, err = s.narrationRepo.FindByChapterID(ctx, ch.ID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find narration: %w", err)
+	}
+
+	segments, err := s.contentRiskSegments(ctx, narration)
+	if err != nil {
+		return nil, err
+	}
+	report := noveltools.BuildContentRiskReport(segments, s.novelTargetPlatform(ctx, ch.NovelID))
+
+	existing, err := s.contentRiskReportRepo.FindByFingerprint(ctx, ch.ID, report.Fingerprint)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("find content risk report: %w", err)
+	}
+
+	report.ID = id.New()
+	report.NovelID = ch.NovelID
+	report.ChapterID = ch.ID
+	report.NarrationID = narration.ID
+	report.NarrationVersion = narration.Version
+	if err := s.contentRiskReportRepo.Create(ctx, report); err != nil {
+		return nil, fmt.Errorf("create content risk report: %w", err)
+	}
+	return report, nil
+}
+
+// contentRiskSegments 收集解说版本中会出现在配音和字幕里的文本：场景解说、镜头解说（按字幕的数字写法转换）和字幕片头/片尾文案
+func (s *novelService) contentRiskSegments(ctx context.Context, narration *novel.Narration) ([]novel.ContentRiskSegment, error) {
+	scenes, err := s.sceneRepo.FindByNarrationID(ctx, narration.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find scenes: %w", err)
+	}
+	shots, err := s.shotRepo.FindByNarrationID(ctx, narration.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find shots: %w", err)
+	}
+
+	var segments []novel.ContentRiskSegment
+	for _, scene := range scenes {
+		if scene.Narration != "" {
+			segments = append(segments, novel.ContentRiskSegment{Source: novel.ContentRiskSourceSceneNarration, Ref: scene.ID, Text: scene.Narration})
+		}
+	}
+	numberStyle := s.novelNumberStyle(ctx, narration.NovelID)
+	for _, shot := range shots {
+		if shot.Narration != "" {
+			segments = append(segments, novel.ContentRiskSegment{
+				Source: novel.ContentRiskSourceShotNarration,
+				Ref:    shot.ID,
+				Text:   noveltools.NormalizeNumbers(shot.Narration, numberStyle),
+			})
+		}
+	}
+
+	render := s.novelRenderSettings(ctx, narration.NovelID)
+	if render.IntroText != "" || render.OutroText != "" {
+		vars := s.novelPromptVariables(ctx, narration.NovelID)
+		if text := noveltools.RenderPromptVariables(render.IntroText, vars); text != "" {
+			segments = append(segments, novel.ContentRiskSegment{Source: novel.ContentRiskSourceSubtitleIntro, Text: text})
+		}
+		if text := noveltools.RenderPromptVariables(render.OutroText, vars); text != "" {
+			segments = append(segments, novel.ContentRiskSegment{Source: novel.ContentRiskSourceSubtitleOutro, Text: text})
+		}
+	}
+	return segments, nil
+}
@@ -0,0 +1,181 @@
+package novel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ffmpeg"
+	"lemon/internal/pkg/id"
+	"lemon/internal/service"
+)
+
+// recapOverlayDuration 前情回顾过渡时长（秒）：上一章最后一帧在本章开头叠加并淡出
+const recapOverlayDuration = 1.5
+
+// continuityFrame 上一章最终视频的最后一帧
+type continuityFrame struct {
+	videoID string // 上一章最终视频ID
+	image   []byte // 最后一帧（JPEG）
+}
+
+// loadContinuityFrame 按小说的章节衔接设置加载上一章的最后一帧
+// 设置不匹配、第一章、上一章还没有最终视频或提取失败时返回 nil（不衔接，不影响生成）
+func (s *novelService) loadContinuityFrame(ctx context.Context, chapter *novel.Chapter, mode novel.ChapterContinuity) *continuityFrame {
+	if chapter.Sequence <= 1 {
+		return nil
+	}
+	n, err := s.novelRepo.FindByID(ctx, chapter.NovelID)
+	if err != nil {
+		log.Warn().Err(err).Str("novel_id", chapter.NovelID).Msg("查询小说章节衔接设置失败，不做衔接")
+		return nil
+	}
+	if n.Continuity != mode {
+		return nil
+	}
+
+	frame, err := s.previousChapterLastFrame(ctx, chapter)
+	if err != nil {
+		log.Warn().Err(err).Str("chapter_id", chapter.ID).Msg("获取上一章最后一帧失败，不做衔接")
+		return nil
+	}
+	if frame == nil {
+		log.Info().Str("chapter_id", chapter.ID).Msg("上一章还没有最终视频，不做衔接")
+	}
+	return frame
+}
+
+// previousChapterLastFrame 获取上一章最终视频的最后一帧
+// 优先使用上一章发布的视频版本，否则使用最新完成的最终视频；截图提取一次后记录在视频上复用
+func (s *novelService) previousChapterLastFrame(ctx context.Context, chapter *novel.Chapter) (*continuityFrame, error) {
+	chapters, err := s.chapterRepo.FindByNovelID(ctx, chapter.NovelID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapters: %w", err)
+	}
+	var prev *novel.Chapter
+	for _, ch := range chapters {
+		if ch.Sequence == chapter.Sequence-1 {
+			prev = ch
+			break
+		}
+	}
+	if prev == nil {
+		return nil, nil
+	}
+
+	videos, err := s.videoRepo.FindByChapterIDAndType(ctx, prev.ID, novel.VideoTypeFinal)
+	if err != nil {
+		return nil, fmt.Errorf("find final videos: %w", err)
+	}
+	promotedVersion := 0
+	if prev.PromotedVersions != nil {
+		promotedVersion = prev.PromotedVersions.Video
+	}
+	var video *novel.Video
+	for _, v := range videos {
+		if v.Status != novel.VideoStatusCompleted || v.VideoResourceID == "" {
+			continue
+		}
+		if promotedVersion > 0 && v.Version != promotedVersion {
+			continue
+		}
+		if video == nil || v.Version > video.Version || (v.Version == video.Version && v.CreatedAt.After(video.CreatedAt)) {
+			video = v
+		}
+	}
+	if video == nil {
+		return nil, nil
+	}
+
+	if video.LastFrameResourceID != "" {
+		data, err := s.downloadResource(ctx, video.LastFrameResourceID)
+		if err == nil {
+			return &continuityFrame{videoID: video.ID, image: data}, nil
+		}
+		log.Warn().Err(err).Str("video_id", video.ID).Msg("下载最后一帧截图失败，重新提取")
+	}
+
+	data, err := s.extractLastFrame(ctx, video, prev.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &continuityFrame{videoID: video.ID, image: data}, nil
+}
+
+// extractLastFrame 下载视频并提取最后一帧，上传后记录到视频上
+func (s *novelService) extractLastFrame(ctx context.Context, video *novel.Video, userID string) ([]byte, error) {
+	tmpDir := os.TempDir()
+	tmpVideoPath := filepath.Join(tmpDir, fmt.Sprintf("continuity_video_%s.mp4", id.New()))
+	defer os.Remove(tmpVideoPath)
+	tmpFramePath := filepath.Join(tmpDir, fmt.Sprintf("continuity_frame_%s.jpg", id.New()))
+	defer os.Remove(tmpFramePath)
+
+	videoResult, err := s.resourceService.DownloadFile(ctx, &service.DownloadFileRequest{ResourceID: video.VideoResourceID})
+	if err != nil {
+		return nil, fmt.Errorf("download video: %w", err)
+	}
+	defer videoResult.Data.Close()
+
+	videoFile, err := os.Create(tmpVideoPath)
+	if err != nil {
+		return nil, fmt.Errorf("create temp video file: %w", err)
+	}
+	if _, err := io.Copy(videoFile, videoResult.Data); err != nil {
+		videoFile.Close()
+		return nil, fmt.Errorf("copy video data: %w", err)
+	}
+	videoFile.Close()
+
+	if err := ffmpeg.NewClient().ExtractLastFrame(ctx, tmpVideoPath, tmpFramePath); err != nil {
+		return nil, fmt.Errorf("extract last frame: %w", err)
+	}
+	data, err := os.ReadFile(tmpFramePath)
+	if err != nil {
+		return nil, fmt.Errorf("read last frame: %w", err)
+	}
+
+	uploadResult, err := s.resourceService.UploadFile(ctx, &service.UploadFileRequest{
+		UserID:      userID,
+		FileName:    fmt.Sprintf("last_frame_%s.jpg", video.ID),
+		ContentType: "image/jpeg",
+		Ext:         "jpg",
+		Data:        bytes.NewReader(data),
+		KeyVars:     s.chapterKeyVarsByID(ctx, video.NovelID, video.ChapterID, artifactLastFrame, video.Version, 1),
+	})
+	if err != nil {
+		// 上传失败不影响本次衔接，下次重新提取
+		log.Warn().Err(err).Str("video_id", video.ID).Msg("上传最后一帧截图失败")
+		return data, nil
+	}
+	if err := s.videoRepo.UpdateLastFrame(ctx, video.ID, uploadResult.ResourceID); err != nil {
+		log.Warn().Err(err).Str("video_id", video.ID).Msg("记录最后一帧截图失败")
+	}
+	return data, nil
+}
+
+// downloadResource 下载资源内容（系统内部请求）
+func (s *novelService) downloadResource(ctx context.Context, resourceID string) ([]byte, error) {
+	result, err := s.resourceService.DownloadFile(ctx, &service.DownloadFileRequest{ResourceID: resourceID})
+	if err != nil {
+		return nil, err
+	}
+	defer result.Data.Close()
+	return io.ReadAll(result.Data)
+}
+
+// withImage 返回追加了一张参考图的新集合（不修改原集合，参考图ID保持不变）
+func (r *styleReferenceSet) withImage(data []byte) *styleReferenceSet {
+	set := &styleReferenceSet{}
+	if r != nil {
+		set.ids = append(set.ids, r.ids...)
+		set.images = append(set.images, r.images...)
+	}
+	set.images = append(set.images, data)
+	return set
+}
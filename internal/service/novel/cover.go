@@ -0,0 +1,345 @@
+package novel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/killswitch"
+	"lemon/internal/pkg/noveltools"
+	"lemon/internal/service"
+)
+
+// 单次生成封面候选的数量
+const (
+	defaultCoverCandidates = 2
+	maxCoverCandidates     = 4
+)
+
+var (
+	// ErrInvalidCover 上传的封面不是图片文件
+	ErrInvalidCover = errors.New("cover must be an image")
+	// ErrInvalidCoverCount 生成封面的数量超出范围
+	ErrInvalidCoverCount = errors.New("invalid cover count")
+	// ErrCoverNotInNovel 封面不属于该小说
+	ErrCoverNotInNovel = errors.New("cover does not belong to novel")
+)
+
+// CoverService 小说封面服务接口
+// 一本小说可以有多张候选封面（生成或上传），其中一张为当前封面，在小说详情和列表中展示
+type CoverService interface {
+	// GenerateNovelCovers 根据作品设定和第一章梗概生成封面候选，小说还没有封面时自动选用第一张
+	GenerateNovelCovers(ctx context.Context, req *GenerateNovelCoversRequest) ([]*novel.NovelCover, error)
+
+	// UploadNovelCover 上传封面候选，小说还没有封面或 Select 为 true 时选用该封面
+	UploadNovelCover(ctx context.Context, req *UploadNovelCoverRequest) (*novel.NovelCover, error)
+
+	// ListNovelCovers 查询小说的封面候选（按创建时间倒序）
+	ListNovelCovers(ctx context.Context, novelID string) (*NovelCoverGallery, error)
+
+	// SelectNovelCover 选用封面
+	SelectNovelCover(ctx context.Context, novelID, coverID string) (*novel.Novel, error)
+
+	// DeleteNovelCover 删除封面候选，删除的是当前封面时改用最新的其他候选（没有候选时清空封面）
+	DeleteNovelCover(ctx context.Context, novelID, coverID string) error
+}
+
+// GenerateNovelCoversRequest 生成封面请求
+type GenerateNovelCoversRequest struct {
+	NovelID string // 小说ID
+	UserID  string // 操作用户ID
+	Count   int    // 生成数量（0 表示默认 2 张，最多 4 张）
+}
+
+// UploadNovelCoverRequest 上传封面请求
+type UploadNovelCoverRequest struct {
+	NovelID     string    // 小说ID
+	UserID      string    // 上传用户ID
+	FileName    string    // 文件名
+	ContentType string    // MIME类型（必须为 image/*）
+	Data        io.Reader // 文件数据
+	Select      bool      // 是否选用为当前封面
+}
+
+// NovelCoverGallery 小说封面候选列表
+type NovelCoverGallery struct {
+	NovelID         string              // 小说ID
+	SelectedCoverID string              // 当前封面ID（没有封面时为空）
+	Covers          []*novel.NovelCover // 封面候选（按创建时间倒序）
+}
+
+// GenerateNovelCovers 生成封面候选
+// 先由大模型根据作品设定和第一章开头设计封面画面（失败时根据作品设定拼出基础描述），再逐张生成图片；
+// 单张失败不影响其他候选，全部失败时返回最后一个错误，预算超限时立即停止
+func (s *novelService) GenerateNovelCovers(ctx context.Context, req *GenerateNovelCoversRequest) ([]*novel.NovelCover, error) {
+	count := req.Count
+	if count == 0 {
+		count = defaultCoverCandidates
+	}
+	if count < 0 || count > maxCoverCandidates {
+		return nil, fmt.Errorf("%w: count must be between 1 and %d", ErrInvalidCoverCount, maxCoverCandidates)
+	}
+
+	n, err := s.novelRepo.FindByID(ctx, req.NovelID)
+	if err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+	userID := req.UserID
+	if userID == "" {
+		userID = n.UserID
+	}
+
+	brief := noveltools.CoverBrief{
+		Title:       n.Title,
+		Description: n.Description,
+		Metadata:    n.Metadata,
+		Style:       n.Style,
+	}
+	chapters, err := s.chapterRepo.FindByNovelID(ctx, n.ID)
+	if err != nil {
+		log.Warn().Err(err).Str("novel_id", n.ID).Msg("查询第一章失败，封面只参考作品设定")
+	} else if len(chapters) > 0 {
+		brief.FirstChapter = chapters[0].ChapterText
+	}
+	prompt := noveltools.BuildCoverImagePrompt(brief, s.designCover(ctx, n.ID, brief))
+
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderImage)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	refs := s.loadStyleReferences(ctx, n.ID, "")
+
+	var covers []*novel.NovelCover
+	var lastErr error
+	for i := 0; i < count; i++ {
+		cover, err := s.generateCover(ctx, n.ID, userID, prompt, refs)
+		if err != nil {
+			log.Error().Err(err).Str("novel_id", n.ID).Int("candidate", i+1).Msg("生成封面失败")
+			lastErr = err
+			if errors.Is(err, ErrBudgetExceeded) {
+				break
+			}
+			continue
+		}
+		covers = append(covers, cover)
+	}
+	if len(covers) == 0 {
+		return nil, lastErr
+	}
+
+	if n.CoverID == "" {
+		if err := s.setNovelCover(ctx, n.ID, covers[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Info().
+		Str("novel_id", n.ID).
+		Int("requested", count).
+		Int("generated", len(covers)).
+		Msg("封面生成完成")
+
+	return covers, nil
+}
+
+// designCover 调用大模型设计封面画面，失败时返回空字符串（使用基础描述）
+func (s *novelService) designCover(ctx context.Context, novelID string, brief noveltools.CoverBrief) string {
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderLLM)
+	if err != nil {
+		log.Warn().Err(err).Str("novel_id", novelID).Msg("文本生成暂停，封面使用基础描述")
+		return ""
+	}
+	defer release()
+
+	designPrompt := noveltools.BuildCoverDesignPrompt(brief)
+	output, err := s.llmProvider.Generate(ctx, designPrompt)
+	if err != nil {
+		log.Warn().Err(err).Str("novel_id", novelID).Msg("设计封面画面失败，使用基础描述")
+		return ""
+	}
+	s.recordLLMCost(ctx, novelID, "", designPrompt, output)
+	return noveltools.CleanTranslatedPrompt(output)
+}
+
+// generateCover 生成一张封面并保存为候选
+func (s *novelService) generateCover(ctx context.Context, novelID, userID, prompt string, refs *styleReferenceSet) (*novel.NovelCover, error) {
+	coverID := id.New()
+	filename := fmt.Sprintf("cover_%s.jpeg", coverID)
+
+	imageData, refIDs, localizedPrompt, err := s.generateImageWithStyle(ctx, novelID, "", prompt, filename, refs)
+	if err != nil {
+		return nil, fmt.Errorf("generate cover image: %w", err)
+	}
+
+	uploadResult, err := s.resourceService.UploadFile(ctx, &service.UploadFileRequest{
+		UserID:      userID,
+		FileName:    filename,
+		ContentType: "image/jpeg",
+		Ext:         "jpeg",
+		Data:        bytes.NewReader(imageData),
+		KeyVars:     novelKeyVars(novelID, artifactCover),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("upload cover: %w", err)
+	}
+
+	cover := &novel.NovelCover{
+		ID:                coverID,
+		NovelID:           novelID,
+		UserID:            userID,
+		ResourceID:        uploadResult.ResourceID,
+		Source:            novel.CoverSourceGenerated,
+		Prompt:            prompt,
+		LocalizedPrompt:   localizedPrompt,
+		StyleReferenceIDs: refIDs,
+	}
+	if err := s.novelCoverRepo.Create(ctx, cover); err != nil {
+		return nil, fmt.Errorf("create cover: %w", err)
+	}
+	return cover, nil
+}
+
+// UploadNovelCover 上传封面候选
+func (s *novelService) UploadNovelCover(ctx context.Context, req *UploadNovelCoverRequest) (*novel.NovelCover, error) {
+	if !strings.HasPrefix(req.ContentType, "image/") {
+		return nil, ErrInvalidCover
+	}
+
+	n, err := s.novelRepo.FindByID(ctx, req.NovelID)
+	if err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(req.FileName)), ".")
+	if ext == "" {
+		ext = strings.TrimPrefix(req.ContentType, "image/")
+	}
+
+	uploadResult, err := s.resourceService.UploadFile(ctx, &service.UploadFileRequest{
+		UserID:      req.UserID,
+		FileName:    req.FileName,
+		ContentType: req.ContentType,
+		Ext:         ext,
+		Data:        req.Data,
+		KeyVars:     novelKeyVars(req.NovelID, artifactCover),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("upload cover: %w", err)
+	}
+
+	cover := &novel.NovelCover{
+		ID:         id.New(),
+		NovelID:    req.NovelID,
+		UserID:     req.UserID,
+		ResourceID: uploadResult.ResourceID,
+		Source:     novel.CoverSourceUploaded,
+	}
+	if err := s.novelCoverRepo.Create(ctx, cover); err != nil {
+		return nil, fmt.Errorf("create cover: %w", err)
+	}
+
+	if req.Select || n.CoverID == "" {
+		if err := s.setNovelCover(ctx, n.ID, cover); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Info().
+		Str("cover_id", cover.ID).
+		Str("novel_id", cover.NovelID).
+		Msg("封面上传成功")
+
+	return cover, nil
+}
+
+// ListNovelCovers 查询小说的封面候选
+func (s *novelService) ListNovelCovers(ctx context.Context, novelID string) (*NovelCoverGallery, error) {
+	n, err := s.novelRepo.FindByID(ctx, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+	covers, err := s.novelCoverRepo.FindByNovelID(ctx, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("find covers: %w", err)
+	}
+	return &NovelCoverGallery{
+		NovelID:         novelID,
+		SelectedCoverID: n.CoverID,
+		Covers:          covers,
+	}, nil
+}
+
+// SelectNovelCover 选用封面
+func (s *novelService) SelectNovelCover(ctx context.Context, novelID, coverID string) (*novel.Novel, error) {
+	cover, err := s.findNovelCover(ctx, novelID, coverID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.setNovelCover(ctx, novelID, cover); err != nil {
+		return nil, err
+	}
+	return s.novelRepo.FindByID(ctx, novelID)
+}
+
+// DeleteNovelCover 删除封面候选
+func (s *novelService) DeleteNovelCover(ctx context.Context, novelID, coverID string) error {
+	if _, err := s.findNovelCover(ctx, novelID, coverID); err != nil {
+		return err
+	}
+	n, err := s.novelRepo.FindByID(ctx, novelID)
+	if err != nil {
+		return fmt.Errorf("find novel: %w", err)
+	}
+	if err := s.novelCoverRepo.Delete(ctx, coverID); err != nil {
+		return fmt.Errorf("delete cover: %w", err)
+	}
+	if n.CoverID != coverID {
+		return nil
+	}
+
+	// 删除的是当前封面：改用最新的其他候选
+	remaining, err := s.novelCoverRepo.FindByNovelID(ctx, novelID)
+	if err != nil {
+		return fmt.Errorf("find covers: %w", err)
+	}
+	var next *novel.NovelCover
+	if len(remaining) > 0 {
+		next = remaining[0]
+	}
+	return s.setNovelCover(ctx, novelID, next)
+}
+
+// findNovelCover 查询封面并检查是否属于该小说
+func (s *novelService) findNovelCover(ctx context.Context, novelID, coverID string) (*novel.NovelCover, error) {
+	cover, err := s.novelCoverRepo.FindByID(ctx, coverID)
+	if err != nil {
+		return nil, fmt.Errorf("find cover: %w", err)
+	}
+	if cover.NovelID != novelID {
+		return nil, ErrCoverNotInNovel
+	}
+	return cover, nil
+}
+
+// setNovelCover 把封面设为小说的当前封面，cover 为 nil 时清空封面
+func (s *novelService) setNovelCover(ctx context.Context, novelID string, cover *novel.NovelCover) error {
+	set := bson.M{"cover_id": "", "cover_resource_id": ""}
+	if cover != nil {
+		set = bson.M{"cover_id": cover.ID, "cover_resource_id": cover.ResourceID}
+	}
+	if err := s.novelRepo.Update(ctx, novelID, set); err != nil {
+		return fmt.Errorf("set novel cover: %w", err)
+	}
+	return nil
+}
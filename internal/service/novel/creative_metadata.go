@@ -0,0 +1,92 @@
+package novel
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/noveltools"
+)
+
+// CreativeMetadataService 小说创作设定服务接口
+type CreativeMetadataService interface {
+	// GetCreativeMetadata 查询小说的创作设定及提示词可引用的变量
+	GetCreativeMetadata(ctx context.Context, novelID string) (*CreativeMetadataView, error)
+
+	// SetCreativeMetadata 设置小说的创作设定（整体替换），只影响之后的生成
+	SetCreativeMetadata(ctx context.Context, novelID string, meta *novel.CreativeMetadata) (*CreativeMetadataView, error)
+}
+
+// CreativeMetadataView 小说创作设定
+type CreativeMetadataView struct {
+	NovelID   string                     `json:"novel_id"`
+	Metadata  *novel.CreativeMetadata    `json:"metadata"`
+	Variables noveltools.PromptVariables `json:"variables"` // 提示词中可用 {{变量名}} 引用的全部变量及当前取值
+}
+
+// GetCreativeMetadata 查询小说的创作设定
+func (s *novelService) GetCreativeMetadata(ctx context.Context, novelID string) (*CreativeMetadataView, error) {
+	n, err := s.novelRepo.FindByID(ctx, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+	meta := n.Metadata
+	if meta == nil {
+		meta = &novel.CreativeMetadata{}
+	}
+	return &CreativeMetadataView{
+		NovelID:   n.ID,
+		Metadata:  meta,
+		Variables: noveltools.NovelPromptVariables(n),
+	}, nil
+}
+
+// SetCreativeMetadata 设置小说的创作设定
+// 校验失败返回 noveltools.ErrInvalidCreativeMetadata
+func (s *novelService) SetCreativeMetadata(ctx context.Context, novelID string, meta *novel.CreativeMetadata) (*CreativeMetadataView, error) {
+	normalized, err := noveltools.NormalizeCreativeMetadata(meta)
+	if err != nil {
+		return nil, err
+	}
+	normalized.UpdatedAt = time.Now()
+
+	if err := s.novelRepo.Update(ctx, novelID, map[string]interface{}{"metadata": normalized}); err != nil {
+		return nil, fmt.Errorf("update creative metadata: %w", err)
+	}
+
+	log.Info().
+		Str("novel_id", novelID).
+		Str("genre", normalized.Genre).
+		Int("banned_topics", len(normalized.BannedTopics)).
+		Int("variables", len(normalized.Variables)).
+		Msg("小说创作设定已更新")
+
+	return s.GetCreativeMetadata(ctx, novelID)
+}
+
+// novelPromptVariables 查询小说的提示词变量，查询失败时返回空变量（不阻断生成流程）
+func (s *novelService) novelPromptVariables(ctx context.Context, novelID string) noveltools.PromptVariables {
+	n, err := s.novelRepo.FindByID(ctx, novelID)
+	if err != nil {
+		log.Warn().Err(err).Str("novel_id", novelID).Msg("查询小说创作设定失败，提示词不注入变量")
+		return nil
+	}
+	return noveltools.NovelPromptVariables(n)
+}
+
+// withNovelContext 替换文本生成提示词中的变量，并附加小说的作品设定（题材、基调、受众、禁用话题）
+func (s *novelService) withNovelContext(ctx context.Context, novelID, prompt string) string {
+	return noveltools.InjectNovelContext(prompt, s.novelPromptVariables(ctx, novelID))
+}
+
+// renderNovelPrompt 替换图片/视频提示词中的变量（不附加作品设定，避免干扰画面描述）
+func (s *novelService) renderNovelPrompt(ctx context.Context, novelID, prompt string) string {
+	if !strings.Contains(prompt, "{{") {
+		return prompt
+	}
+	return noveltools.RenderPromptVariables(prompt, s.novelPromptVariables(ctx, novelID))
+}
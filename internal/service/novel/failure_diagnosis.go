@@ -0,0 +1,213 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/killswitch"
+	"lemon/internal/pkg/noveltools"
+	novelrepo "lemon/internal/repository/novel"
+)
+
+// ErrNoChapterFailure 章节没有失败的执行记录，无需诊断
+var ErrNoChapterFailure = errors.New("chapter has no failed stage run")
+
+// failureChangeLookback 该阶段从未成功过时，收集失败前多长时间内的变更
+const failureChangeLookback = 7 * 24 * time.Hour
+
+// 诊断来源
+const (
+	FailureDiagnosisSourceLLM   = "llm"   // 大模型诊断
+	FailureDiagnosisSourceRules = "rules" // 大模型不可用或诊断失败时按失败类别给出的固定诊断
+)
+
+// FailureDiagnosisService 章节生成失败诊断服务接口
+type FailureDiagnosisService interface {
+	// ExplainChapterFailure 诊断章节最近一次失败：收集失败上下文，调用大模型给出说明和建议操作，同时返回原始错误信息
+	ExplainChapterFailure(ctx context.Context, chapterID string) (*ChapterFailureExplanation, error)
+}
+
+// ChapterFailureExplanation 章节生成失败的诊断结果
+type ChapterFailureExplanation struct {
+	ChapterID      string                       `json:"chapter_id"`
+	Failure        *novel.StageRun              `json:"failure"`                   // 失败的执行记录（原始错误信息）
+	Job            *novel.Job                   `json:"job,omitempty"`             // 该阶段最近一次失败的队列任务（通过任务队列执行时）
+	PipelineRun    *novel.PipelineRun           `json:"pipeline_run,omitempty"`    // 失败或取消的一键生成流程
+	Context        *noveltools.FailureContext   `json:"context"`                   // 送入诊断的失败上下文
+	Diagnosis      *noveltools.FailureDiagnosis `json:"diagnosis"`                 // 诊断
+	Source         string                       `json:"source"`                    // 诊断来源：llm, rules
+	DiagnosisError string                       `json:"diagnosis_error,omitempty"` // 大模型诊断失败的原因（source=rules 时）
+}
+
+// ExplainChapterFailure 诊断章节最近一次失败
+// 大模型不可用（维护模式、超出预算、调用或解析失败）时按失败类别给出固定诊断，不返回错误
+func (s *novelService) ExplainChapterFailure(ctx context.Context, chapterID string) (*ChapterFailureExplanation, error) {
+	chapter, err := s.chapterRepo.FindByID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+	runs, err := s.stageRunRepo.FindByChapterID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find stage runs: %w", err)
+	}
+
+	failed, fc := buildFailureContext(chapter, runs)
+	if failed == nil {
+		return nil, ErrNoChapterFailure
+	}
+	explanation := &ChapterFailureExplanation{ChapterID: chapterID, Failure: failed, Context: fc}
+
+	jobs, err := s.jobRepo.Find(ctx, novelrepo.JobFilter{ChapterID: chapterID, Type: failed.Stage, Status: novel.JobStatusFailed}, 1)
+	if err != nil {
+		return nil, fmt.Errorf("find failed job: %w", err)
+	}
+	if len(jobs) > 0 {
+		explanation.Job = jobs[0]
+		fc.Attempts = jobs[0].Attempts
+		fc.MaxAttempts = jobs[0].MaxAttempts
+	}
+	run, err := s.pipelineRunRepo.FindLatestByChapterID(ctx, chapterID)
+	if err == nil && (run.Status == novel.PipelineRunStatusFailed || run.Status == novel.PipelineRunStatusCanceled) {
+		explanation.PipelineRun = run
+	}
+
+	fc.Changes = append(fc.Changes, s.failureChanges(ctx, chapter, failed, fc.LastSucceededAt)...)
+	sort.SliceStable(fc.Changes, func(i, j int) bool { return fc.Changes[i].At.Before(fc.Changes[j].At) })
+
+	diagnosis, err := s.diagnoseFailure(ctx, chapter, fc)
+	if err != nil {
+		log.Warn().Err(err).Str("chapter_id", chapterID).Str("stage", failed.Stage.String()).Msg("大模型诊断失败，使用固定诊断")
+		explanation.Diagnosis = noveltools.FallbackFailureDiagnosis(fc)
+		explanation.Source = FailureDiagnosisSourceRules
+		explanation.DiagnosisError = err.Error()
+		return explanation, nil
+	}
+	explanation.Diagnosis = diagnosis
+	explanation.Source = FailureDiagnosisSourceLLM
+	return explanation, nil
+}
+
+// buildFailureContext 从执行记录（按 started_at desc 排序）中找出最近一次失败，汇总该阶段的成功/失败历史
+// 没有失败记录时返回 nil
+func buildFailureContext(chapter *novel.Chapter, runs []*novel.StageRun) (*novel.StageRun, *noveltools.FailureContext) {
+	failedIdx := slices.IndexFunc(runs, func(r *novel.StageRun) bool { return !r.Succeeded() })
+	if failedIdx < 0 {
+		return nil, nil
+	}
+	failed := runs[failedIdx]
+	errorClass := failed.ErrorClass
+	if errorClass == "" {
+		errorClass = "unknown"
+	}
+	fc := &noveltools.FailureContext{
+		ChapterTitle:  chapter.Title,
+		Stage:         failed.Stage,
+		ErrorClass:    errorClass,
+		ErrorProvider: failed.ErrorProvider,
+		ErrorExcerpt:  noveltools.ExcerptErrorMessage(failed.ErrorMessage),
+		FailedAt:      failed.StartedAt,
+	}
+
+	for _, r := range runs[:failedIdx] {
+		if r.Stage == failed.Stage && r.Succeeded() {
+			fc.Resolved = true
+			break
+		}
+	}
+	for _, r := range runs[failedIdx:] {
+		if r.Stage != failed.Stage {
+			continue
+		}
+		if r.Succeeded() {
+			succeededAt := r.FinishedAt
+			fc.LastSucceededAt = &succeededAt
+			if added, removed := providerChanges(r.ProviderCalls, failed.ProviderCalls); len(added) > 0 || len(removed) > 0 {
+				fc.Changes = append(fc.Changes, noveltools.FailureChange{
+					At:          failed.StartedAt,
+					Description: fmt.Sprintf("%s 阶段调用的服务变化：新增 [%s]，不再调用 [%s]", failed.Stage, strings.Join(added, ", "), strings.Join(removed, ", ")),
+				})
+			}
+			break
+		}
+		fc.ConsecutiveFailures++
+	}
+	return failed, fc
+}
+
+// providerChanges 对比两次执行调用的 provider
+func providerChanges(before, after map[string]int) (added, removed []string) {
+	for _, name := range slices.Sorted(maps.Keys(after)) {
+		if _, ok := before[name]; !ok {
+			added = append(added, name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(before)) {
+		if _, ok := after[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	return added, removed
+}
+
+// failureChanges 收集该阶段上次成功（从未成功时为失败前 failureChangeLookback）到失败之间的变更：章节、小说设置和解说版本
+// 查询失败不影响诊断，只记录日志
+func (s *novelService) failureChanges(ctx context.Context, chapter *novel.Chapter, failed *novel.StageRun, lastSucceededAt *time.Time) []noveltools.FailureChange {
+	since := failed.StartedAt.Add(-failureChangeLookback)
+	if lastSucceededAt != nil {
+		since = *lastSucceededAt
+	}
+	inWindow := func(t time.Time) bool { return t.After(since) && !t.After(failed.StartedAt) }
+
+	var changes []noveltools.FailureChange
+	if inWindow(chapter.UpdatedAt) {
+		changes = append(changes, noveltools.FailureChange{At: chapter.UpdatedAt, Description: "章节内容或设置被修改"})
+	}
+	if n, err := s.novelRepo.FindByID(ctx, chapter.NovelID); err != nil {
+		log.Warn().Err(err).Str("novel_id", chapter.NovelID).Msg("查询小说失败，诊断不包含小说设置变更")
+	} else if inWindow(n.UpdatedAt) {
+		changes = append(changes, noveltools.FailureChange{At: n.UpdatedAt, Description: "小说设置（生成参数、风格等）被修改"})
+	}
+	narrations, err := s.narrationRepo.FindAllByChapterID(ctx, chapter.ID)
+	if err != nil {
+		log.Warn().Err(err).Str("chapter_id", chapter.ID).Msg("查询解说版本失败，诊断不包含解说变更")
+	}
+	for _, n := range narrations {
+		if inWindow(n.CreatedAt) {
+			changes = append(changes, noveltools.FailureChange{At: n.CreatedAt, Description: fmt.Sprintf("生成了新的解说版本 v%d", n.Version)})
+		}
+	}
+	return changes
+}
+
+// diagnoseFailure 调用大模型诊断失败
+func (s *novelService) diagnoseFailure(ctx context.Context, chapter *novel.Chapter, fc *noveltools.FailureContext) (*noveltools.FailureDiagnosis, error) {
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderLLM)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if err := s.checkBudget(ctx, chapter.NovelID); err != nil {
+		return nil, err
+	}
+	prompt, err := noveltools.BuildFailureDiagnosisPrompt(fc)
+	if err != nil {
+		return nil, err
+	}
+	prompt = s.withNovelContext(ctx, chapter.NovelID, prompt)
+	output, err := s.llmProvider.Generate(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("generate failure diagnosis: %w", err)
+	}
+	s.recordLLMCost(ctx, chapter.NovelID, chapter.ID, prompt, output)
+	return noveltools.ParseFailureDiagnosis(output)
+}
@@ -0,0 +1,238 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/noveltools"
+)
+
+const (
+	// generationRequestRetention 请求记录的保留时间，过期后相同的幂等键视为新请求
+	generationRequestRetention = 24 * time.Hour
+	// generationRequestProcessingTimeout 生成中的请求超过该时间仍未结束视为已中断（服务在生成过程中退出），相同请求重新生成
+	generationRequestProcessingTimeout = 2 * time.Hour
+	// maxIdempotencyKeyLength 幂等键的最大长度
+	maxIdempotencyKeyLength = 255
+)
+
+var (
+	// ErrInvalidIdempotencyKey 幂等键不合法（过长）
+	ErrInvalidIdempotencyKey = errors.New("invalid idempotency key")
+	// ErrIdempotencyKeyReused 幂等键已用于参数不同的请求
+	ErrIdempotencyKeyReused = errors.New("idempotency key reused with different request")
+)
+
+// GenerationRequestService 幂等生成服务接口
+type GenerationRequestService interface {
+	// GenerateNarrationVideosIdempotent 幂等地为章节生成 narration 视频
+	// 同一用户的相同幂等键的重复请求直接返回已有的生成版本，不重复生成；没有幂等键时只合并相同指纹
+	// （章节 + 解说版本 + 产物版本和镜头内容 + 参数）生成中的请求；之前的请求失败或中断时重新生成
+	GenerateNarrationVideosIdempotent(ctx context.Context, req *GenerateNarrationVideosIdempotentRequest) (*NarrationVideoGeneration, error)
+}
+
+// GenerateNarrationVideosIdempotentRequest 幂等生成 narration 视频请求
+type GenerateNarrationVideosIdempotentRequest struct {
+	ChapterID      string
+	Platform       novel.TargetPlatform // 目标发布平台（为空时使用小说的默认平台）
+	IdempotencyKey string               // 调用方提供的幂等键（按用户区分；为空时按指纹合并相同请求）
+}
+
+// NarrationVideoGeneration narration 视频的生成结果
+type NarrationVideoGeneration struct {
+	RequestID string                        `json:"request_id"` // 生成请求ID
+	Status    novel.GenerationRequestStatus `json:"status"`     // processing（其他请求正在生成）或 completed
+	Version   int                           `json:"version"`    // 视频版本号
+	VideoIDs  []string                      `json:"video_ids"`  // 生成的视频ID（生成中时为空）
+	Replayed  bool                          `json:"replayed"`   // 是否为重复请求（返回的是已有的生成结果）
+	CreatedAt time.Time                     `json:"created_at"` // 请求的提交时间
+}
+
+// GenerateNarrationVideosIdempotent 幂等地为章节生成 narration 视频
+func (s *novelService) GenerateNarrationVideosIdempotent(ctx context.Context, req *GenerateNarrationVideosIdempotentRequest) (*NarrationVideoGeneration, error) {
+	if len(req.IdempotencyKey) > maxIdempotencyKeyLength {
+		return nil, fmt.Errorf("%w: longer than %d characters", ErrInvalidIdempotencyKey, maxIdempotencyKeyLength)
+	}
+	narration, err := s.narrationRepo.FindByChapterID(ctx, req.ChapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find narration: %w", err)
+	}
+
+	// 1. 请求指纹：章节 + 解说版本 + 产物版本和镜头内容 + 影响生成结果的参数
+	narrationVersion := fmt.Sprintf("%d.%d", narration.Version, narration.MinorVersion)
+	fingerprint, err := s.narrationVideoFingerprint(ctx, narration, narrationVersion, req.Platform)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 已有相同的请求时返回已有的生成版本
+	userID, _ := ctxutil.GetUserID(ctx)
+	existing, err := s.findGenerationRequest(ctx, userID, req.IdempotencyKey, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return generationReplay(existing), nil
+	}
+
+	// 3. 分配视频版本号并登记请求；并发的相同请求只有一个能登记成功，其余返回已登记的请求
+	version, err := s.nextVersion(ctx, req.ChapterID, novel.VersionArtifactVideo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get next video version: %w", err)
+	}
+	record := &novel.GenerationRequest{
+		ID:               id.New(),
+		IdempotencyKey:   req.IdempotencyKey,
+		Stage:            novel.PipelineStageNarrationVideo,
+		NovelID:          narration.NovelID,
+		ChapterID:        req.ChapterID,
+		NarrationID:      narration.ID,
+		NarrationVersion: narrationVersion,
+		Fingerprint:      fingerprint,
+		Status:           novel.GenerationRequestStatusProcessing,
+		Version:          version,
+		UserID:           userID,
+		ExpiresAt:        time.Now().Add(generationRequestRetention),
+	}
+	if err := s.generationRequestRepo.Create(ctx, record); err != nil {
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("create generation request: %w", err)
+		}
+		// 幂等键已被登记，或其他幂等键的相同请求正在生成
+		existing, findErr := s.findGenerationRequest(ctx, userID, req.IdempotencyKey, fingerprint)
+		if findErr == nil && existing == nil && req.IdempotencyKey != "" {
+			existing, findErr = s.findGenerationRequest(ctx, userID, "", fingerprint)
+		}
+		if findErr != nil {
+			return nil, findErr
+		}
+		if existing == nil {
+			return nil, fmt.Errorf("create generation request: %w", err)
+		}
+		return generationReplay(existing), nil
+	}
+
+	// 4. 生成（全部片段失败时记录失败，相同请求可以重新生成）
+	bg := context.WithoutCancel(ctx)
+	videoIDs, err := s.runNarrationVideoStage(ctx, req.ChapterID, req.Platform, version)
+	if err == nil && len(videoIDs) == 0 {
+		err = errors.New("no narration videos generated")
+	}
+	if err != nil {
+		if failErr := s.generationRequestRepo.Fail(bg, record.ID, err.Error()); failErr != nil {
+			log.Error().Err(failErr).Str("request_id", record.ID).Msg("记录生成请求失败状态失败")
+		}
+		return nil, err
+	}
+	if err := s.generationRequestRepo.Complete(bg, record.ID, videoIDs); err != nil {
+		log.Error().Err(err).Str("request_id", record.ID).Msg("记录生成请求完成状态失败")
+	}
+
+	return &NarrationVideoGeneration{
+		RequestID: record.ID,
+		Status:    novel.GenerationRequestStatusCompleted,
+		Version:   version,
+		VideoIDs:  videoIDs,
+		CreatedAt: record.CreatedAt,
+	}, nil
+}
+
+// narrationVideoFingerprint 计算生成 narration 视频请求的指纹
+// 除请求参数外还包含章节最新的图片/音频/字幕版本和镜头内容，编辑、重新生成或重排镜头后指纹随之变化
+func (s *novelService) narrationVideoFingerprint(ctx context.Context, narration *novel.Narration, narrationVersion string, platform novel.TargetPlatform) (string, error) {
+	params := noveltools.NarrationVideoParams{Platform: platform}
+	if style, ok := ctxutil.GetSubtitleStyle(ctx); ok {
+		params.SubtitleStyle = style
+	}
+	if overrides, ok := ctxutil.GetGenerationOverrides(ctx); ok {
+		params.Overrides = overrides
+	}
+	for artifact, latest := range map[novel.VersionArtifact]*int{
+		novel.VersionArtifactImage:    &params.ImageVersion,
+		novel.VersionArtifactAudio:    &params.AudioVersion,
+		novel.VersionArtifactSubtitle: &params.SubtitleVersion,
+	} {
+		versions, err := s.chapterArtifactVersions(ctx, narration.ChapterID, artifact)
+		if err != nil {
+			return "", err
+		}
+		for _, v := range versions {
+			*latest = max(*latest, v)
+		}
+	}
+	shots, err := s.shotRepo.FindByNarrationID(ctx, narration.ID)
+	if err != nil {
+		return "", fmt.Errorf("find shots: %w", err)
+	}
+	if params.ShotsHash, err = noveltools.ShotContentHash(shots); err != nil {
+		return "", fmt.Errorf("hash shots: %w", err)
+	}
+
+	fingerprint, err := noveltools.GenerationRequestFingerprint(novel.PipelineStageNarrationVideo, narration.ChapterID, narrationVersion, params)
+	if err != nil {
+		return "", fmt.Errorf("fingerprint request: %w", err)
+	}
+	return fingerprint, nil
+}
+
+// findGenerationRequest 查询可以直接返回的已有请求：
+// 有幂等键时按用户的幂等键查询（参数不同时返回 ErrIdempotencyKeyReused），没有时只查询相同指纹生成中的请求
+// （选择字幕、更换背景音乐等不在指纹中的改动后需要重新生成，已完成的请求不重复返回）；
+// 已有的请求失败或生成中断时清理旧记录并返回 nil（重新生成）
+func (s *novelService) findGenerationRequest(ctx context.Context, userID, idempotencyKey, fingerprint string) (*novel.GenerationRequest, error) {
+	var existing *novel.GenerationRequest
+	var err error
+	if idempotencyKey != "" {
+		existing, err = s.generationRequestRepo.FindByIdempotencyKey(ctx, userID, idempotencyKey)
+	} else {
+		existing, err = s.generationRequestRepo.FindProcessingByFingerprint(ctx, fingerprint)
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find generation request: %w", err)
+	}
+	if existing.Fingerprint != fingerprint {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	switch {
+	case existing.Status == novel.GenerationRequestStatusCompleted:
+		return existing, nil
+	case existing.Status == novel.GenerationRequestStatusProcessing && time.Since(existing.CreatedAt) < generationRequestProcessingTimeout:
+		return existing, nil
+	case existing.Status == novel.GenerationRequestStatusProcessing:
+		log.Warn().Str("request_id", existing.ID).Str("chapter_id", existing.ChapterID).Msg("生成请求超时未结束，视为已中断并重新生成")
+		if err := s.generationRequestRepo.Fail(ctx, existing.ID, "interrupted"); err != nil {
+			return nil, fmt.Errorf("fail stale generation request: %w", err)
+		}
+	}
+	// 失败（或刚标记为中断）的请求：有幂等键时删除旧记录，释放幂等键
+	if existing.IdempotencyKey != "" {
+		if err := s.generationRequestRepo.Delete(ctx, existing.ID); err != nil {
+			return nil, fmt.Errorf("delete failed generation request: %w", err)
+		}
+	}
+	return nil, nil
+}
+
+// generationReplay 把已有的请求转换为重复请求的结果
+func generationReplay(req *novel.GenerationRequest) *NarrationVideoGeneration {
+	return &NarrationVideoGeneration{
+		RequestID: req.ID,
+		Status:    req.Status,
+		Version:   req.Version,
+		VideoIDs:  req.ResourceIDs,
+		Replayed:  true,
+		CreatedAt: req.CreatedAt,
+	}
+}
@@ -0,0 +1,281 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	novelrepo "lemon/internal/repository/novel"
+)
+
+// memGenerationRequestRepo 内存中的生成请求记录仓库，按 GenerationRequestRepo 的语义实现查询
+type memGenerationRequestRepo struct {
+	novelrepo.GenerationRequestRepository
+	mu       sync.Mutex
+	requests map[string]*novel.GenerationRequest
+}
+
+func newMemGenerationRequestRepo(requests ...*novel.GenerationRequest) *memGenerationRequestRepo {
+	r := &memGenerationRequestRepo{requests: make(map[string]*novel.GenerationRequest)}
+	for _, req := range requests {
+		r.requests[req.ID] = req
+	}
+	return r
+}
+
+func (r *memGenerationRequestRepo) FindByIdempotencyKey(_ context.Context, userID, key string) (*novel.GenerationRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, req := range r.requests {
+		if req.UserID == userID && req.IdempotencyKey == key {
+			copied := *req
+			return &copied, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *memGenerationRequestRepo) FindProcessingByFingerprint(_ context.Context, fingerprint string) (*novel.GenerationRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, req := range r.requests {
+		if req.Fingerprint == fingerprint && req.Status == novel.GenerationRequestStatusProcessing {
+			copied := *req
+			return &copied, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *memGenerationRequestRepo) Fail(_ context.Context, id, errMsg string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests[id].Status, r.requests[id].ErrorMessage = novel.GenerationRequestStatusFailed, errMsg
+	return nil
+}
+
+func (r *memGenerationRequestRepo) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.requests, id)
+	return nil
+}
+
+// chapterNarrationRepo 返回固定解说的仓库
+type chapterNarrationRepo struct {
+	novelrepo.NarrationRepository
+	narration *novel.Narration
+}
+
+func (r *chapterNarrationRepo) FindByChapterID(_ context.Context, chapterID string) (*novel.Narration, error) {
+	if r.narration.ChapterID != chapterID {
+		return nil, mongo.ErrNoDocuments
+	}
+	return r.narration, nil
+}
+
+// artifactVersions 按产物类型返回章节固定版本号的仓库
+type artifactVersions map[novel.VersionArtifact][]int
+
+type versionedImageRepo struct {
+	novelrepo.ImageRepository
+	versions artifactVersions
+}
+
+func (r *versionedImageRepo) FindVersionsByChapterID(context.Context, string) ([]int, error) {
+	return r.versions[novel.VersionArtifactImage], nil
+}
+
+type versionedAudioRepo struct {
+	novelrepo.AudioRepository
+	versions artifactVersions
+}
+
+func (r *versionedAudioRepo) FindVersionsByChapterID(context.Context, string) ([]int, error) {
+	return r.versions[novel.VersionArtifactAudio], nil
+}
+
+type versionedSubtitleRepo struct {
+	novelrepo.SubtitleRepository
+	versions artifactVersions
+}
+
+func (r *versionedSubtitleRepo) FindVersionsByChapterID(context.Context, string) ([]int, error) {
+	return r.versions[novel.VersionArtifactSubtitle], nil
+}
+
+// generationRequestFixture 只包含幂等生成依赖的小说服务：章节 ch-1 的解说 2.1 有两个镜头
+type generationRequestFixture struct {
+	svc       *novelService
+	narration *novel.Narration
+	shots     *memShotRepo
+	versions  artifactVersions
+}
+
+func newGenerationRequestFixture(requests *memGenerationRequestRepo) *generationRequestFixture {
+	f := &generationRequestFixture{
+		narration: &novel.Narration{ID: "narration-1", NovelID: "novel-1", ChapterID: "ch-1", Version: 2, MinorVersion: 1},
+		shots: &memShotRepo{shots: map[string]*novel.Shot{
+			"shot-1": {ID: "shot-1", NarrationID: "narration-1", Narration: "第一句", Index: 1},
+			"shot-2": {ID: "shot-2", NarrationID: "narration-1", Narration: "第二句", Index: 2},
+		}},
+		versions: artifactVersions{
+			novel.VersionArtifactImage:    {1, 2},
+			novel.VersionArtifactAudio:    {1},
+			novel.VersionArtifactSubtitle: {1},
+		},
+	}
+	f.svc = &novelService{
+		narrationRepo:         &chapterNarrationRepo{narration: f.narration},
+		shotRepo:              f.shots,
+		imageRepo:             &versionedImageRepo{versions: f.versions},
+		audioRepo:             &versionedAudioRepo{versions: f.versions},
+		subtitleRepo:          &versionedSubtitleRepo{versions: f.versions},
+		generationRequestRepo: requests,
+	}
+	return f
+}
+
+// fingerprint 返回章节 ch-1 默认参数请求当前的指纹
+func (f *generationRequestFixture) fingerprint(t *testing.T) string {
+	t.Helper()
+	fingerprint, err := f.svc.narrationVideoFingerprint(context.Background(), f.narration, "2.1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fingerprint
+}
+
+// generationRequest 创建章节 ch-1 的请求记录
+func generationRequest(id, userID, key, fingerprint string, status novel.GenerationRequestStatus, createdAt time.Time) *novel.GenerationRequest {
+	req := &novel.GenerationRequest{
+		ID:             id,
+		IdempotencyKey: key,
+		Stage:          novel.PipelineStageNarrationVideo,
+		ChapterID:      "ch-1",
+		Fingerprint:    fingerprint,
+		Status:         status,
+		Version:        3,
+		UserID:         userID,
+		CreatedAt:      createdAt,
+	}
+	if status == novel.GenerationRequestStatusCompleted {
+		req.ResourceIDs = []string{"video-" + id}
+	}
+	return req
+}
+
+func TestGenerateNarrationVideosIdempotentWithoutKeyMergesOnlyProcessing(t *testing.T) {
+	requests := newMemGenerationRequestRepo()
+	f := newGenerationRequestFixture(requests)
+	fingerprint := f.fingerprint(t)
+	requests.requests["completed"] = generationRequest("completed", "user-1", "", fingerprint, novel.GenerationRequestStatusCompleted, time.Now().Add(-time.Hour))
+
+	// 没有幂等键：已完成的请求不重复返回（选择字幕、更换背景音乐等改动不在指纹中，需要能重新生成）
+	existing, err := f.svc.findGenerationRequest(context.Background(), "user-2", "", fingerprint)
+	if err != nil || existing != nil {
+		t.Fatalf("completed request: existing = %+v, err = %v, want a new request", existing, err)
+	}
+
+	// 相同指纹正在生成的请求合并，返回生成中的版本
+	requests.requests["processing"] = generationRequest("processing", "user-1", "", fingerprint, novel.GenerationRequestStatusProcessing, time.Now())
+	ctx := ctxutil.WithUserID(context.Background(), "user-2")
+	got, err := f.svc.GenerateNarrationVideosIdempotent(ctx, &GenerateNarrationVideosIdempotentRequest{ChapterID: "ch-1"})
+	if err != nil {
+		t.Fatalf("GenerateNarrationVideosIdempotent() error = %v", err)
+	}
+	if !got.Replayed || got.RequestID != "processing" || got.Status != novel.GenerationRequestStatusProcessing {
+		t.Errorf("result = %+v, want replay of the processing request", got)
+	}
+}
+
+func TestNarrationVideoFingerprintTracksInputs(t *testing.T) {
+	f := newGenerationRequestFixture(newMemGenerationRequestRepo())
+	base := f.fingerprint(t)
+	if again := f.fingerprint(t); again != base {
+		t.Fatalf("fingerprint changed without edits: %s != %s", again, base)
+	}
+
+	// 编辑镜头后指纹变化
+	f.shots.shots["shot-2"].Narration = "改写的第二句"
+	edited := f.fingerprint(t)
+	if edited == base {
+		t.Error("fingerprint unchanged after shot edit")
+	}
+
+	// 生成新的图片/音频/字幕版本后指纹变化
+	for _, artifact := range []novel.VersionArtifact{novel.VersionArtifactImage, novel.VersionArtifactAudio, novel.VersionArtifactSubtitle} {
+		f.versions[artifact] = append(f.versions[artifact], 9)
+		if got := f.fingerprint(t); got == edited {
+			t.Errorf("fingerprint unchanged after new %s version", artifact)
+		}
+		f.versions[artifact] = f.versions[artifact][:len(f.versions[artifact])-1]
+	}
+}
+
+func TestGenerateNarrationVideosIdempotentKeyScopedToUser(t *testing.T) {
+	requests := newMemGenerationRequestRepo()
+	f := newGenerationRequestFixture(requests)
+	s, fingerprint := f.svc, f.fingerprint(t)
+	requests.requests["user-1-req"] = generationRequest("user-1-req", "user-1", "key-1", fingerprint, novel.GenerationRequestStatusCompleted, time.Now())
+	requests.requests["user-2-req"] = generationRequest("user-2-req", "user-2", "key-2", "other-fingerprint", novel.GenerationRequestStatusCompleted, time.Now())
+
+	// 同一用户重复使用幂等键：返回已有的生成版本
+	ctx := ctxutil.WithUserID(context.Background(), "user-1")
+	got, err := s.GenerateNarrationVideosIdempotent(ctx, &GenerateNarrationVideosIdempotentRequest{ChapterID: "ch-1", IdempotencyKey: "key-1"})
+	if err != nil {
+		t.Fatalf("GenerateNarrationVideosIdempotent() error = %v", err)
+	}
+	if !got.Replayed || got.RequestID != "user-1-req" {
+		t.Fatalf("result = %+v, want replay of user-1-req", got)
+	}
+
+	// 其他用户的相同幂等键互不影响：不返回其他用户的结果，也不报幂等键冲突
+	existing, err := s.findGenerationRequest(context.Background(), "user-2", "key-1", fingerprint)
+	if err != nil || existing != nil {
+		t.Errorf("user-2 key-1: existing = %+v, err = %v, want a new request", existing, err)
+	}
+	existing, err = s.findGenerationRequest(context.Background(), "user-1", "key-2", fingerprint)
+	if err != nil || existing != nil {
+		t.Errorf("user-1 key-2: existing = %+v, err = %v, want a new request", existing, err)
+	}
+
+	// 同一用户的幂等键用于参数不同的请求
+	ctx = ctxutil.WithUserID(context.Background(), "user-2")
+	if _, err := s.GenerateNarrationVideosIdempotent(ctx, &GenerateNarrationVideosIdempotentRequest{ChapterID: "ch-1", IdempotencyKey: "key-2"}); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Errorf("user-2 key-2 with different params: err = %v, want ErrIdempotencyKeyReused", err)
+	}
+}
+
+func TestFindGenerationRequestRetriesFailedAndInterrupted(t *testing.T) {
+	requests := newMemGenerationRequestRepo()
+	f := newGenerationRequestFixture(requests)
+	s, fingerprint := f.svc, f.fingerprint(t)
+	stale := time.Now().Add(-generationRequestProcessingTimeout - time.Minute)
+	requests.requests["failed"] = generationRequest("failed", "user-1", "key-1", fingerprint, novel.GenerationRequestStatusFailed, time.Now())
+	requests.requests["stale"] = generationRequest("stale", "user-1", "", fingerprint, novel.GenerationRequestStatusProcessing, stale)
+
+	// 失败的请求释放幂等键，重新生成
+	existing, err := s.findGenerationRequest(context.Background(), "user-1", "key-1", fingerprint)
+	if err != nil || existing != nil {
+		t.Fatalf("failed request: existing = %+v, err = %v, want a new request", existing, err)
+	}
+	if _, ok := requests.requests["failed"]; ok {
+		t.Error("expected failed request with idempotency key to be deleted")
+	}
+
+	// 超时未结束的生成中请求标记为中断，重新生成
+	existing, err = s.findGenerationRequest(context.Background(), "user-1", "", fingerprint)
+	if err != nil || existing != nil {
+		t.Fatalf("stale request: existing = %+v, err = %v, want a new request", existing, err)
+	}
+	if got := requests.requests["stale"]; got.Status != novel.GenerationRequestStatusFailed || got.ErrorMessage != "interrupted" {
+		t.Errorf("stale request = %s/%q, want failed/interrupted", got.Status, got.ErrorMessage)
+	}
+}
@@ -0,0 +1,217 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/noveltools"
+)
+
+// 系统默认生成参数（未配置环境变量时使用）
+const (
+	defaultVideoWidth  = 720
+	defaultVideoHeight = 1280
+	defaultVideoFPS    = 30
+)
+
+// GenerationSettingsService 生成参数服务接口
+// 生成参数按 系统默认 → 用户设置 → 小说设置 → 请求覆盖 逐层合并，所有生成方法都通过同一套规则解析
+type GenerationSettingsService interface {
+	// GetUserGenerationSettings 查询用户级生成参数（未设置时返回空设置）
+	GetUserGenerationSettings(ctx context.Context, userID string) (*novel.GenerationSettings, error)
+
+	// SetUserGenerationSettings 保存用户级生成参数（整体替换，零值字段表示沿用系统默认值）
+	SetUserGenerationSettings(ctx context.Context, userID string, settings *novel.GenerationSettings) (*novel.UserSettings, error)
+
+	// SetNovelGenerationSettings 保存小说级生成参数（整体替换，零值字段表示沿用用户设置）
+	SetNovelGenerationSettings(ctx context.Context, novelID string, settings *novel.GenerationSettings) error
+
+	// GetGenerationSettings 查询小说各层生成参数和合并后的最终取值（包含 context 中的请求覆盖）
+	GetGenerationSettings(ctx context.Context, novelID string) (*GenerationSettingsView, error)
+}
+
+// GenerationSettingsView 小说的生成参数（各层取值和合并结果）
+type GenerationSettingsView struct {
+	NovelID   string                                 `json:"novel_id"`
+	System    *novel.GenerationSettings              `json:"system"`            // 系统默认值
+	User      *novel.GenerationSettings              `json:"user,omitempty"`    // 小说所有者的用户设置
+	Novel     *novel.GenerationSettings              `json:"novel,omitempty"`   // 小说设置（包含渲染设置中的配音）
+	Request   *novel.GenerationSettings              `json:"request,omitempty"` // 本次请求的覆盖
+	Effective *noveltools.ResolvedGenerationSettings `json:"effective"`         // 合并后的最终取值
+}
+
+// generationDefaultsFromEnv 从环境变量读取系统默认生成参数
+// DEFAULT_VIDEO_WIDTH、DEFAULT_VIDEO_HEIGHT、DEFAULT_VIDEO_FPS、DEFAULT_TTS_VOICE_TYPE、DEFAULT_TTS_SPEED_RATIO、DEFAULT_IMAGE_STEPS、
+// DEFAULT_SEGMENT_GAP、DEFAULT_SCENE_GAP、DEFAULT_CLIP_MERGE、DEFAULT_CLIP_MERGE_UNDER，
+// 未配置或不合法时使用 720x1280、30fps、TTS 默认音色、1.2 倍语速、图片提供者默认步数、片段之间没有停顿、不合并镜头视频
+func generationDefaultsFromEnv() novel.GenerationSettings {
+	defaults := novel.GenerationSettings{
+		VideoWidth:  defaultVideoWidth,
+		VideoHeight: defaultVideoHeight,
+		VideoFPS:    defaultVideoFPS,
+		VoiceType:   os.Getenv("DEFAULT_TTS_VOICE_TYPE"),
+		SpeedRatio:  defaultTTSSpeedRatio,
+		ClipMerge:   novel.ClipMergeMode(os.Getenv("DEFAULT_CLIP_MERGE")),
+	}
+	ints := []struct {
+		env string
+		dst *int
+	}{
+		{"DEFAULT_VIDEO_WIDTH", &defaults.VideoWidth},
+		{"DEFAULT_VIDEO_HEIGHT", &defaults.VideoHeight},
+		{"DEFAULT_VIDEO_FPS", &defaults.VideoFPS},
+		{"DEFAULT_IMAGE_STEPS", &defaults.ImageSteps},
+	}
+	for _, e := range ints {
+		if v, err := strconv.Atoi(os.Getenv(e.env)); err == nil && v > 0 {
+			*e.dst = v
+		}
+	}
+	floats := []struct {
+		env string
+		dst *float64
+	}{
+		{"DEFAULT_TTS_SPEED_RATIO", &defaults.SpeedRatio},
+		{"DEFAULT_SEGMENT_GAP", &defaults.SegmentGap},
+		{"DEFAULT_SCENE_GAP", &defaults.SceneGap},
+		{"DEFAULT_CLIP_MERGE_UNDER", &defaults.ClipMergeUnder},
+	}
+	for _, e := range floats {
+		if v, err := strconv.ParseFloat(os.Getenv(e.env), 64); err == nil && v > 0 {
+			*e.dst = v
+		}
+	}
+	if err := noveltools.ValidateGenerationSettings(&defaults); err != nil {
+		log.Warn().Err(err).Msg("系统默认生成参数不合法，使用内置默认值")
+		return novel.GenerationSettings{
+			VideoWidth:  defaultVideoWidth,
+			VideoHeight: defaultVideoHeight,
+			VideoFPS:    defaultVideoFPS,
+			SpeedRatio:  defaultTTSSpeedRatio,
+		}
+	}
+	return defaults
+}
+
+// GetUserGenerationSettings 查询用户级生成参数
+func (s *novelService) GetUserGenerationSettings(ctx context.Context, userID string) (*novel.GenerationSettings, error) {
+	settings, err := s.userSettingsRepo.FindByUserID(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return &novel.GenerationSettings{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find user settings: %w", err)
+	}
+	if settings.Generation == nil {
+		return &novel.GenerationSettings{}, nil
+	}
+	return settings.Generation, nil
+}
+
+// SetUserGenerationSettings 保存用户级生成参数
+func (s *novelService) SetUserGenerationSettings(ctx context.Context, userID string, settings *novel.GenerationSettings) (*novel.UserSettings, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("%w: user_id is required", noveltools.ErrInvalidGenerationSettings)
+	}
+	if err := noveltools.ValidateGenerationSettings(settings); err != nil {
+		return nil, err
+	}
+	if settings.IsZero() {
+		settings = nil
+	}
+
+	userSettings := &novel.UserSettings{ID: id.New(), UserID: userID, Generation: settings}
+	if err := s.userSettingsRepo.SaveGeneration(ctx, userSettings); err != nil {
+		return nil, fmt.Errorf("save user settings: %w", err)
+	}
+	return userSettings, nil
+}
+
+// SetNovelGenerationSettings 保存小说级生成参数
+func (s *novelService) SetNovelGenerationSettings(ctx context.Context, novelID string, settings *novel.GenerationSettings) error {
+	if err := noveltools.ValidateGenerationSettings(settings); err != nil {
+		return err
+	}
+	if settings.IsZero() {
+		settings = nil
+	}
+	return s.novelRepo.Update(ctx, novelID, bson.M{"generation": settings})
+}
+
+// GetGenerationSettings 查询小说各层生成参数和合并后的最终取值
+func (s *novelService) GetGenerationSettings(ctx context.Context, novelID string) (*GenerationSettingsView, error) {
+	n, err := s.novelRepo.FindByID(ctx, novelID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.GetUserGenerationSettings(ctx, n.UserID)
+	if err != nil {
+		return nil, err
+	}
+	view := &GenerationSettingsView{
+		NovelID: novelID,
+		System:  &s.generationDefaults,
+		User:    user,
+		Novel:   novelGenerationLayer(n),
+	}
+	if overrides, ok := ctxutil.GetGenerationOverrides(ctx); ok {
+		view.Request = overrides
+	}
+	view.Effective = resolveGenerationLayers(view.System, view.User, view.Novel, view.Request)
+	return view, nil
+}
+
+// generationSettings 解析小说本次生成使用的参数
+// 查询小说或用户设置失败时跳过对应的层（不阻断生成），系统默认值和请求覆盖始终生效
+func (s *novelService) generationSettings(ctx context.Context, novelID string) *noveltools.ResolvedGenerationSettings {
+	var user, novelLayer *novel.GenerationSettings
+	n, err := s.novelRepo.FindByID(ctx, novelID)
+	if err != nil {
+		log.Warn().Err(err).Str("novel_id", novelID).Msg("查询小说生成参数失败，使用默认值")
+	} else {
+		novelLayer = novelGenerationLayer(n)
+		if user, err = s.GetUserGenerationSettings(ctx, n.UserID); err != nil {
+			log.Warn().Err(err).Str("user_id", n.UserID).Msg("查询用户生成参数失败，跳过用户设置")
+		}
+	}
+	overrides, _ := ctxutil.GetGenerationOverrides(ctx)
+	return resolveGenerationLayers(&s.generationDefaults, user, novelLayer, overrides)
+}
+
+// withImageSteps 把小说生成参数中的图片采样步数放入 context（未配置时不设置，使用图片提供者默认值）
+func (s *novelService) withImageSteps(ctx context.Context, novelID string) context.Context {
+	return noveltools.WithImageSteps(ctx, s.generationSettings(ctx, novelID).ImageSteps)
+}
+
+// novelGenerationLayer 小说层的生成参数：渲染设置中的配音（创建时从创作默认配置复制）被显式的生成参数覆盖
+func novelGenerationLayer(n *novel.Novel) *novel.GenerationSettings {
+	var voice *novel.GenerationSettings
+	if n.Render != nil && n.Render.Voice != nil {
+		voice = &novel.GenerationSettings{VoiceType: n.Render.Voice.VoiceType, SpeedRatio: n.Render.Voice.SpeedRatio}
+	}
+	merged := noveltools.ResolveGenerationSettings(
+		noveltools.GenerationSettingsLayer{Layer: noveltools.SettingsLayerNovel, Settings: voice},
+		noveltools.GenerationSettingsLayer{Layer: noveltools.SettingsLayerNovel, Settings: n.Generation},
+	)
+	return &merged.GenerationSettings
+}
+
+// resolveGenerationLayers 按 系统默认 → 用户设置 → 小说设置 → 请求覆盖 合并生成参数
+func resolveGenerationLayers(system, user, novelLayer, request *novel.GenerationSettings) *noveltools.ResolvedGenerationSettings {
+	return noveltools.ResolveGenerationSettings(
+		noveltools.GenerationSettingsLayer{Layer: noveltools.SettingsLayerSystem, Settings: system},
+		noveltools.GenerationSettingsLayer{Layer: noveltools.SettingsLayerUser, Settings: user},
+		noveltools.GenerationSettingsLayer{Layer: noveltools.SettingsLayerNovel, Settings: novelLayer},
+		noveltools.GenerationSettingsLayer{Layer: noveltools.SettingsLayerRequest, Settings: request},
+	)
+}
@@ -3,17 +3,29 @@ package novel
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 
 	"github.com/rs/zerolog/log"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 
 	"lemon/internal/model/novel"
+	"lemon/internal/pkg/errclass"
 	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/killswitch"
 	"lemon/internal/pkg/noveltools"
 	"lemon/internal/service"
 )
 
+// ErrSceneImageNotReady 场景图片尚未生成完成（无法基于其生成变体）
+var ErrSceneImageNotReady = errors.New("scene image not ready")
+
+// ErrImageToImageNotSupported 当前图片生成提供者不支持图生图
+var ErrImageToImageNotSupported = errors.New("image provider does not support image-to-image")
+
 // ImageService 章节图片服务接口
 // 定义章节图片相关的能力
 type ImageService interface {
@@ -21,6 +33,11 @@ type ImageService interface {
 	// 自动使用最新的版本号+1
 	GenerateImagesForNarration(ctx context.Context, narrationID string) ([]string, error)
 
+	// GenerateImagesForNarrationWithOptions 为章节解说生成所有章节图片，返回每个镜头的生成结果
+	// 提供者支持批量提交时分批提交后统一等待，否则限制并发数并行生成；单张失败不影响其他图片，
+	// opts.Resume 为 true 时沿用最新版本，只生成未完成的序号
+	GenerateImagesForNarrationWithOptions(ctx context.Context, narrationID string, opts ImageGenerationOptions) (*ImageGenerationResult, error)
+
 	// GenerateCharacterImages 为小说的所有角色生成图片
 	GenerateCharacterImages(ctx context.Context, novelID string) ([]string, error)
 
@@ -30,21 +47,83 @@ type ImageService interface {
 	// GeneratePropImages 为小说的所有道具生成图片
 	GeneratePropImages(ctx context.Context, novelID string) ([]string, error)
 
+	// GenerateSceneImageVariants 基于已生成的场景图片，通过图生图生成重新打光的变体（夜晚、黄昏、雨天）
+	// variants 为空时生成所有支持的变体；单个变体失败不影响其他变体，失败信息记录在变体的 error_message 中
+	GenerateSceneImageVariants(ctx context.Context, sceneID string, variants []novel.RelightVariant) ([]*novel.SceneImageVariant, error)
+
+	// ListSceneImageVariants 获取场景的图片变体列表
+	ListSceneImageVariants(ctx context.Context, sceneID string) ([]*novel.SceneImageVariant, error)
+
 	// GetImageVersions 获取章节的所有图片版本号
 	GetImageVersions(ctx context.Context, chapterID string) ([]int, error)
 
 	// ListImagesByNarration 获取解说的图片列表（可指定版本；version<=0 则取最新版本）
 	ListImagesByNarration(ctx context.Context, narrationID string, version int) ([]*novel.Image, int, error)
+
+	// PreviewImage 按任意 prompt 和风格预设生成一张预览图（用于编辑调试提示词）
+	// 图片直接返回，不上传、不写入章节图片版本；生成前检查小说预算，费用计入小说花费
+	PreviewImage(ctx context.Context, req *PreviewImageRequest) (*ImagePreview, error)
+}
+
+// PreviewImageRequest 生成预览图请求
+type PreviewImageRequest struct {
+	NovelID            string                 // 小说ID（用于预算和风格参考图）
+	ChapterID          string                 // 章节ID（可选，设置后优先使用章节级风格参考图）
+	Prompt             string                 // 原始 prompt
+	StylePreset        novel.ImageStylePreset // 风格预设（为空时使用小说的风格预设，未设置时为国风漫画风格）
+	UseStyleReferences bool                   // 是否带上小说/章节的风格参考图
+}
+
+// ImagePreview 预览图结果
+type ImagePreview struct {
+	ImageData         []byte                 // 图片数据
+	ContentType       string                 // 图片类型
+	Prompt            string                 // 实际使用的完整 prompt
+	LocalizedPrompt   string                 // 提交给图片生成提供者的本地化 prompt（提供者偏好非中文提示词时才有）
+	StylePreset       novel.ImageStylePreset // 使用的风格预设
+	StyleReferenceIDs []string               // 实际使用的风格参考图ID
 }
 
 // GenerateImagesForNarration 为章节解说生成所有章节图片
 // version: 图片版本号，如果为空则自动生成下一个版本号（基于该章节已有的图片版本），如果指定则自动生成下一个版本号
 func (s *novelService) GenerateImagesForNarration(ctx context.Context, narrationID string) ([]string, error) {
+	result, err := s.GenerateImagesForNarrationWithOptions(ctx, narrationID, ImageGenerationOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return result.ImageIDs, nil
+}
+
+// GenerateImagesForNarrationWithOptions 为章节解说生成所有章节图片，返回每个镜头的生成结果
+func (s *novelService) GenerateImagesForNarrationWithOptions(ctx context.Context, narrationID string, opts ImageGenerationOptions) (*ImageGenerationResult, error) {
+	var result *ImageGenerationResult
+	err := s.runNarrationStage(ctx, narrationID, novel.PipelineStageImage, func(ctx context.Context) error {
+		var err error
+		result, err = s.generateImagesForNarration(ctx, narrationID, opts)
+		if result != nil {
+			setStageResources(ctx, result.ImageIDs...)
+		}
+		return err
+	})
+	return result, err
+}
+
+// generateImagesForNarration 生成章节图片（阶段耗时与费用由 GenerateImagesForNarrationWithOptions 记录）
+func (s *novelService) generateImagesForNarration(ctx context.Context, narrationID string, opts ImageGenerationOptions) (*ImageGenerationResult, error) {
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderImage)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	// 1. 获取章节解说
 	narration, err := s.narrationRepo.FindByID(ctx, narrationID)
 	if err != nil {
 		return nil, fmt.Errorf("find narration: %w", err)
 	}
+	if err := s.checkBudget(ctx, narration.NovelID); err != nil {
+		return nil, err
+	}
 
 	// 2. 从独立的表中查询场景和镜头
 	scenes, err := s.sceneRepo.FindByNarrationID(ctx, narrationID)
@@ -56,8 +135,8 @@ func (s *novelService) GenerateImagesForNarration(ctx context.Context, narration
 		return nil, fmt.Errorf("no scenes found for narration")
 	}
 
-	// 2. 自动生成下一个版本号（基于章节ID，独立递增）
-	imageVersion, err := s.getNextImageVersion(ctx, narration.ChapterID, 0)
+	// 2. 确定图片版本号：续接时沿用最新版本并跳过已完成的序号，否则自动生成下一个版本号（基于章节ID，独立递增）
+	imageVersion, completed, err := s.resolveImageGenerationVersion(ctx, narration, opts.Resume)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get next image version: %w", err)
 	}
@@ -85,15 +164,25 @@ func (s *novelService) GenerateImagesForNarration(ctx context.Context, narration
 		characterMap[char.Name] = char
 	}
 
-	// 5. 获取图片生成提供者（初始化时已创建）
-	imageProvider := s.imageProvider
+	// 5. 加载风格参考图（章节级优先，否则使用小说级）
+	styleRefs := s.loadStyleReferences(ctx, chapter.NovelID, chapter.ID)
 
-	// 6. 初始化 Prompt 构建器
-	promptBuilder := noveltools.NewImagePromptBuilder()
+	// 加载上一章最终视频的最后一帧（章节衔接，只用于第一个镜头）
+	continuity := s.loadContinuityFrame(ctx, chapter, novel.ChapterContinuityReferenceFrame)
 
-	// 7. 遍历所有场景和镜头，生成图片
-	var imageIDs []string
-	sequence := 1
+	// 6. 初始化 Prompt 构建器（使用小说的图片风格预设）
+	promptBuilder := noveltools.NewImagePromptBuilderWithPreset(s.novelStylePreset(ctx, chapter.NovelID))
+
+	// 7. 遍历所有场景和镜头，按顺序给每个待生成的镜头分配序号（失败的镜头也占用序号，续接时按序号补齐）
+	result := &ImageGenerationResult{
+		NarrationID: narrationID,
+		Version:     imageVersion,
+		Resumed:     len(completed) > 0,
+		ImageIDs:    []string{},
+		Items:       []ImageGenerationItem{},
+	}
+	var jobs []*imageJob
+	sequence := 0
 
 	for _, scene := range scenes {
 		// 查询该场景下的所有镜头
@@ -118,34 +207,69 @@ func (s *novelService) GenerateImagesForNarration(ctx context.Context, narration
 				continue
 			}
 
-			// 生成单张图片
-			imageID, err := s.generateSingleImage(
-				ctx,
-				narration,
-				chapter,
-				scene,
-				shot,
-				character,
-				imageProvider,
-				promptBuilder,
-				sequence,
-				imageVersion,
-			)
-			if err != nil {
-				log.Error().
-					Err(err).
-					Str("scene", scene.SceneNumber).
-					Str("shot", shot.ShotNumber).
-					Msg("生成图片失败")
+			sequence++
+			item := ImageGenerationItem{
+				Sequence:    sequence,
+				ShotID:      shot.ID,
+				SceneNumber: scene.SceneNumber,
+				ShotNumber:  shot.ShotNumber,
+			}
+			if imageID, ok := completed[sequence]; ok {
+				item.ImageID = imageID
+				item.Status = ImageItemSkipped
+				result.Items = append(result.Items, item)
 				continue
 			}
 
-			imageIDs = append(imageIDs, imageID)
-			sequence++
+			// 第一个镜头带上上一章的最后一帧
+			job := &imageJob{scene: scene, shot: shot, character: character, sequence: sequence, item: len(result.Items)}
+			if sequence == 1 {
+				job.continuity = continuity
+			}
+			jobs = append(jobs, job)
+			result.Items = append(result.Items, item)
 		}
 	}
 
-	return imageIDs, nil
+	// 8. 生成图片：提供者支持批量提交时分批提交，否则限制并发数并行生成；单张失败只记录在对应镜头的结果中
+	s.runImageJobs(ctx, narration, chapter, jobs, styleRefs, promptBuilder, imageVersion)
+	for _, job := range jobs {
+		item := &result.Items[job.item]
+		if job.err != nil {
+			item.Status = ImageItemFailed
+			item.Error = job.err.Error()
+			log.Error().
+				Err(job.err).
+				Str("scene", job.scene.SceneNumber).
+				Str("shot", job.shot.ShotNumber).
+				Int("sequence", job.sequence).
+				Msg("生成图片失败")
+			continue
+		}
+		item.ImageID = job.imageID
+		item.Status = ImageItemCompleted
+		result.ImageIDs = append(result.ImageIDs, job.imageID)
+	}
+	for _, item := range result.Items {
+		switch item.Status {
+		case ImageItemCompleted:
+			result.Completed++
+		case ImageItemFailed:
+			result.Failed++
+		case ImageItemSkipped:
+			result.Skipped++
+		}
+	}
+
+	log.Info().
+		Str("narration_id", narrationID).
+		Int("version", imageVersion).
+		Int("completed", result.Completed).
+		Int("failed", result.Failed).
+		Int("skipped", result.Skipped).
+		Msg("章节图片生成结束")
+
+	return result, nil
 }
 
 // generateSingleChapterImage 生成单张章节图片（私有方法）
@@ -156,119 +280,46 @@ func (s *novelService) generateSingleImage(
 	scene *novel.Scene,
 	shot *novel.Shot,
 	character *novel.Character,
-	imageProvider noveltools.ImageProvider,
+	styleRefs *styleReferenceSet,
+	continuity *continuityFrame,
 	promptBuilder *noveltools.ImagePromptBuilder,
 	sequence int,
 	version int,
 ) (string, error) {
 	// 1. 构建完整 prompt
 	completePrompt := promptBuilder.BuildCompletePrompt(character, shot.ImagePrompt)
+	completePrompt = s.renderNovelPrompt(ctx, chapter.NovelID, completePrompt)
 
 	// 2. 构建输出文件名
-	outputFilename := fmt.Sprintf("chapter_%03d_image_%02d.jpeg", chapter.Sequence, sequence)
-
-	// 3. 使用图片生成提供者生成图片
-	imageData, err := imageProvider.GenerateImage(ctx, completePrompt, outputFilename)
-	if err != nil {
-		return "", fmt.Errorf("generate image: %w", err)
-	}
-
-	// 8. 上传图片到 resource 模块
-	uploadReq := &service.UploadFileRequest{
-		UserID:      narration.UserID,
-		FileName:    outputFilename,
-		ContentType: "image/jpeg",
-		Ext:         "jpeg",
-		Data:        bytes.NewReader(imageData),
+	outputFilename := chapterImageFilename(chapter, sequence)
+
+	// 3. 使用图片生成提供者生成图片（有风格参考图时带上参考图，章节衔接时追加上一章的最后一帧）
+	refs := styleRefs
+	continuityVideoID := ""
+	if continuity != nil {
+		if _, ok := s.imageProvider.(noveltools.StyleReferenceProvider); ok {
+			refs = styleRefs.withImage(continuity.image)
+			continuityVideoID = continuity.videoID
+		} else {
+			log.Warn().Str("chapter_id", chapter.ID).Msg("图片生成提供者不支持参考图，跳过章节衔接")
+		}
 	}
-
-	uploadResult, err := s.resourceService.UploadFile(ctx, uploadReq)
+	imageData, styleReferenceIDs, localizedPrompt, err := s.generateImageWithStyle(ctx, chapter.NovelID, chapter.ID, completePrompt, outputFilename, refs)
 	if err != nil {
-		return "", fmt.Errorf("upload image: %w", err)
-	}
-
-	// 9. 保存 ChapterImage 记录
-	imageID := id.New()
-	chapterImage := &novel.Image{
-		ID:              imageID,
-		ChapterID:   chapter.ID,
-		NarrationID: narration.ID,
-		NovelID:     chapter.NovelID,
-		SceneNumber: scene.SceneNumber,
-		ShotNumber:      shot.ShotNumber,
-		ImageResourceID: uploadResult.ResourceID,
-		CharacterName:   shot.Character,
-		Prompt:          completePrompt,
-		Version:         version, // 使用指定的版本号
-		Status:          novel.TaskStatusCompleted,
-		Sequence:        sequence,
-	}
-
-	if err := s.imageRepo.Create(ctx, chapterImage); err != nil {
-		return "", fmt.Errorf("create chapter image: %w", err)
+		return "", fmt.Errorf("generate image: %w", err)
 	}
 
-	log.Info().
-		Str("image_id", imageID).
-		Str("chapter_id", chapter.ID).
-		Str("scene", scene.SceneNumber).
-		Str("shot", shot.ShotNumber).
-		Msg("章节图片生成成功")
-
-	return imageID, nil
+	return s.saveChapterImage(ctx, narration, chapter, scene, shot, imageData, completePrompt, localizedPrompt, styleReferenceIDs, continuityVideoID, sequence, version)
 }
 
-// getNextImageVersion 获取章节的下一个图片版本号（自动递增）
-// chapterID: 章节ID
-// baseVersion: 基础版本号（如 1），如果为0则自动生成下一个版本号
-func (s *novelService) getNextImageVersion(ctx context.Context, chapterID string, baseVersion int) (int, error) {
-	versions, err := s.imageRepo.FindVersionsByChapterID(ctx, chapterID)
+// GenerateCharacterImages 为小说的所有角色生成图片
+func (s *novelService) GenerateCharacterImages(ctx context.Context, novelID string) ([]string, error) {
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderImage)
 	if err != nil {
-		// 如果没有找到任何版本，返回 1 或基础版本号
-		if baseVersion == 0 {
-			return 1, nil
-		}
-		return baseVersion, nil
+		return nil, err
 	}
+	defer release()
 
-	if len(versions) == 0 {
-		if baseVersion == 0 {
-			return 1, nil
-		}
-		return baseVersion, nil
-	}
-
-	// 如果指定了基础版本号，检查该版本是否已存在
-	if baseVersion > 0 {
-		for _, v := range versions {
-			if v == baseVersion {
-				// 该版本已存在，返回下一个版本号
-				maxVersion := 0
-				for _, v := range versions {
-					if v > maxVersion {
-						maxVersion = v
-					}
-				}
-				return maxVersion + 1, nil
-			}
-		}
-		// 该版本不存在，直接返回
-		return baseVersion, nil
-	}
-
-	// 如果没有指定基础版本号，查找所有版本号中的最大值
-	maxVersion := 0
-	for _, v := range versions {
-		if v > maxVersion {
-			maxVersion = v
-		}
-	}
-
-	return maxVersion + 1, nil
-}
-
-// GenerateCharacterImages 为小说的所有角色生成图片
-func (s *novelService) GenerateCharacterImages(ctx context.Context, novelID string) ([]string, error) {
 	characters, err := s.characterRepo.FindByNovelID(ctx, novelID)
 	if err != nil {
 		return nil, fmt.Errorf("find characters: %w", err)
@@ -282,6 +333,12 @@ func (s *novelService) GenerateCharacterImages(ctx context.Context, novelID stri
 	if err != nil {
 		return nil, fmt.Errorf("find novel: %w", err)
 	}
+	if err := s.checkBudget(ctx, novelID); err != nil {
+		return nil, err
+	}
+
+	// 角色图片作用于整本小说，只使用小说级风格参考图
+	styleRefs := s.loadStyleReferences(ctx, novelID, "")
 
 	var imageIDs []string
 	for _, char := range characters {
@@ -295,7 +352,7 @@ func (s *novelService) GenerateCharacterImages(ctx context.Context, novelID stri
 			continue
 		}
 
-		imageID, err := s.generateCharacterImage(ctx, novelEntity, char)
+		imageID, err := s.generateCharacterImage(ctx, novelEntity, char, styleRefs)
 		if err != nil {
 			log.Error().Err(err).Str("character_id", char.ID).Str("character_name", char.Name).Msg("生成角色图片失败")
 			continue
@@ -308,10 +365,10 @@ func (s *novelService) GenerateCharacterImages(ctx context.Context, novelID stri
 }
 
 // generateCharacterImage 生成单个角色图片
-func (s *novelService) generateCharacterImage(ctx context.Context, novel *novel.Novel, char *novel.Character) (string, error) {
+func (s *novelService) generateCharacterImage(ctx context.Context, novel *novel.Novel, char *novel.Character, styleRefs *styleReferenceSet) (string, error) {
 	outputFilename := fmt.Sprintf("character_%s.jpeg", char.Name)
 
-	imageData, err := s.imageProvider.GenerateImage(ctx, char.ImagePrompt, outputFilename)
+	imageData, styleReferenceIDs, _, err := s.generateImageWithStyle(ctx, novel.ID, "", char.ImagePrompt, outputFilename, styleRefs)
 	if err != nil {
 		return "", fmt.Errorf("generate image: %w", err)
 	}
@@ -322,6 +379,7 @@ func (s *novelService) generateCharacterImage(ctx context.Context, novel *novel.
 		ContentType: "image/jpeg",
 		Ext:         "jpeg",
 		Data:        bytes.NewReader(imageData),
+		KeyVars:     novelKeyVars(novel.ID, artifactCharacterImage),
 	}
 
 	uploadResult, err := s.resourceService.UploadFile(ctx, uploadReq)
@@ -331,6 +389,9 @@ func (s *novelService) generateCharacterImage(ctx context.Context, novel *novel.
 
 	// 更新角色的 ImageResourceID
 	updates := bson.M{"image_resource_id": uploadResult.ResourceID}
+	if len(styleReferenceIDs) > 0 {
+		updates["image_style_reference_ids"] = styleReferenceIDs
+	}
 	if err := s.characterRepo.Update(ctx, char.ID, updates); err != nil {
 		return "", fmt.Errorf("update character: %w", err)
 	}
@@ -341,10 +402,19 @@ func (s *novelService) generateCharacterImage(ctx context.Context, novel *novel.
 
 // GenerateSceneImages 为解说的所有场景生成图片
 func (s *novelService) GenerateSceneImages(ctx context.Context, narrationID string) ([]string, error) {
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderImage)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	narration, err := s.narrationRepo.FindByID(ctx, narrationID)
 	if err != nil {
 		return nil, fmt.Errorf("find narration: %w", err)
 	}
+	if err := s.checkBudget(ctx, narration.NovelID); err != nil {
+		return nil, err
+	}
 
 	scenes, err := s.sceneRepo.FindByNarrationID(ctx, narrationID)
 	if err != nil {
@@ -360,6 +430,9 @@ func (s *novelService) GenerateSceneImages(ctx context.Context, narrationID stri
 		return nil, fmt.Errorf("find chapter: %w", err)
 	}
 
+	// 加载风格参考图（章节级优先，否则使用小说级）
+	styleRefs := s.loadStyleReferences(ctx, chapter.NovelID, chapter.ID)
+
 	var imageIDs []string
 	for _, scene := range scenes {
 		if scene.ImagePrompt == "" {
@@ -372,7 +445,7 @@ func (s *novelService) GenerateSceneImages(ctx context.Context, narrationID stri
 			continue
 		}
 
-		imageID, err := s.generateSceneImage(ctx, chapter, scene)
+		imageID, err := s.generateSceneImage(ctx, chapter, scene, styleRefs)
 		if err != nil {
 			log.Error().Err(err).Str("scene_id", scene.ID).Str("scene_number", scene.SceneNumber).Msg("生成场景图片失败")
 			continue
@@ -385,10 +458,10 @@ func (s *novelService) GenerateSceneImages(ctx context.Context, narrationID stri
 }
 
 // generateSceneImage 生成单个场景图片
-func (s *novelService) generateSceneImage(ctx context.Context, chapter *novel.Chapter, scene *novel.Scene) (string, error) {
+func (s *novelService) generateSceneImage(ctx context.Context, chapter *novel.Chapter, scene *novel.Scene, styleRefs *styleReferenceSet) (string, error) {
 	outputFilename := fmt.Sprintf("chapter_%03d_scene_%s.jpeg", chapter.Sequence, scene.SceneNumber)
 
-	imageData, err := s.imageProvider.GenerateImage(ctx, scene.ImagePrompt, outputFilename)
+	imageData, styleReferenceIDs, _, err := s.generateImageWithStyle(ctx, chapter.NovelID, chapter.ID, scene.ImagePrompt, outputFilename, styleRefs)
 	if err != nil {
 		return "", fmt.Errorf("generate image: %w", err)
 	}
@@ -399,6 +472,7 @@ func (s *novelService) generateSceneImage(ctx context.Context, chapter *novel.Ch
 		ContentType: "image/jpeg",
 		Ext:         "jpeg",
 		Data:        bytes.NewReader(imageData),
+		KeyVars:     chapterKeyVars(chapter, artifactSceneImage, scene.Version, sceneSequence(scene)),
 	}
 
 	uploadResult, err := s.resourceService.UploadFile(ctx, uploadReq)
@@ -408,6 +482,9 @@ func (s *novelService) generateSceneImage(ctx context.Context, chapter *novel.Ch
 
 	// 更新场景的 ImageResourceID
 	updates := map[string]interface{}{"image_resource_id": uploadResult.ResourceID}
+	if len(styleReferenceIDs) > 0 {
+		updates["image_style_reference_ids"] = styleReferenceIDs
+	}
 	if err := s.sceneRepo.Update(ctx, scene.ID, updates); err != nil {
 		return "", fmt.Errorf("update scene: %w", err)
 	}
@@ -416,8 +493,171 @@ func (s *novelService) generateSceneImage(ctx context.Context, chapter *novel.Ch
 	return uploadResult.ResourceID, nil
 }
 
+// GenerateSceneImageVariants 基于已生成的场景图片生成重新打光的变体
+func (s *novelService) GenerateSceneImageVariants(ctx context.Context, sceneID string, variants []novel.RelightVariant) ([]*novel.SceneImageVariant, error) {
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderImage)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if len(variants) == 0 {
+		variants = novel.AllRelightVariants
+	}
+	for _, variant := range variants {
+		if !variant.IsValid() {
+			return nil, fmt.Errorf("invalid relight variant: %s", variant)
+		}
+	}
+
+	i2iProvider, ok := s.imageProvider.(noveltools.ImageToImageProvider)
+	if !ok {
+		return nil, ErrImageToImageNotSupported
+	}
+
+	scene, err := s.sceneRepo.FindByID(ctx, sceneID)
+	if err != nil {
+		return nil, fmt.Errorf("find scene: %w", err)
+	}
+	if scene.ImageResourceID == "" || scene.Status != novel.TaskStatusCompleted {
+		return nil, ErrSceneImageNotReady
+	}
+	if err := s.reserveRegeneration(ctx, sceneRegenerationTarget(scene), novel.RegenerationKindSceneImageVariants); err != nil {
+		return nil, err
+	}
+
+	// 下载父图片（原场景图片）
+	downloadResult, err := s.resourceService.DownloadFile(ctx, &service.DownloadFileRequest{
+		ResourceID: scene.ImageResourceID,
+		UserID:     scene.UserID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("download scene image: %w", err)
+	}
+	defer downloadResult.Data.Close()
+
+	parentImage, err := io.ReadAll(downloadResult.Data)
+	if err != nil {
+		return nil, fmt.Errorf("read scene image: %w", err)
+	}
+
+	var results []*novel.SceneImageVariant
+	for _, variant := range variants {
+		result, err := s.generateSceneImageVariant(ctx, i2iProvider, scene, parentImage, variant)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// generateSceneImageVariant 生成单个场景图片变体
+// 只有创建变体记录失败时返回错误；图生图或上传失败时记录为 failed 状态并返回变体
+func (s *novelService) generateSceneImageVariant(
+	ctx context.Context,
+	i2iProvider noveltools.ImageToImageProvider,
+	scene *novel.Scene,
+	parentImage []byte,
+	variant novel.RelightVariant,
+) (*novel.SceneImageVariant, error) {
+	prompt, err := noveltools.BuildRelightPrompt(variant, scene.ImagePrompt)
+	if err != nil {
+		return nil, err
+	}
+	prompt = s.renderNovelPrompt(ctx, scene.NovelID, prompt)
+
+	variantEntity := &novel.SceneImageVariant{
+		ID:               id.New(),
+		SceneID:          scene.ID,
+		NarrationID:      scene.NarrationID,
+		ChapterID:        scene.ChapterID,
+		NovelID:          scene.NovelID,
+		UserID:           scene.UserID,
+		ParentResourceID: scene.ImageResourceID,
+		Variant:          variant,
+		Prompt:           prompt,
+		Status:           novel.TaskStatusPending,
+	}
+	if err := s.sceneImageVariantRepo.Create(ctx, variantEntity); err != nil {
+		return nil, fmt.Errorf("create scene image variant: %w", err)
+	}
+
+	fail := func(err error) (*novel.SceneImageVariant, error) {
+		log.Error().Err(err).
+			Str("scene_id", scene.ID).
+			Str("variant", string(variant)).
+			Msg("生成场景图片变体失败")
+		variantEntity.Status = novel.TaskStatusFailed
+		class, _ := errclass.Classify(err)
+		variantEntity.ErrorMessage = err.Error()
+		variantEntity.ErrorClass = string(class)
+		updates := map[string]interface{}{
+			"status":        novel.TaskStatusFailed,
+			"error_message": err.Error(),
+			"error_class":   variantEntity.ErrorClass,
+		}
+		if updateErr := s.sceneImageVariantRepo.Update(ctx, variantEntity.ID, updates); updateErr != nil {
+			log.Warn().Err(updateErr).Str("variant_id", variantEntity.ID).Msg("更新场景图片变体状态失败")
+		}
+		return variantEntity, nil
+	}
+
+	if err := s.checkBudget(ctx, scene.NovelID); err != nil {
+		return fail(err)
+	}
+
+	outputFilename := fmt.Sprintf("scene_%s_%s.jpeg", scene.ID, variant)
+	imageData, err := i2iProvider.GenerateImageFromImage(s.withImageSteps(ctx, scene.NovelID), parentImage, prompt, outputFilename)
+	if err != nil {
+		return fail(fmt.Errorf("generate image from image: %w", err))
+	}
+	s.recordCost(ctx, scene.NovelID, scene.ChapterID, killswitch.ProviderImage, 1, s.pricing.Image(1))
+
+	uploadResult, err := s.resourceService.UploadFile(ctx, &service.UploadFileRequest{
+		UserID:      scene.UserID,
+		FileName:    outputFilename,
+		ContentType: "image/jpeg",
+		Ext:         "jpeg",
+		Data:        bytes.NewReader(imageData),
+		KeyVars:     s.chapterKeyVarsByID(ctx, scene.NovelID, scene.ChapterID, artifactSceneVariant, scene.Version, sceneSequence(scene)),
+	})
+	if err != nil {
+		return fail(fmt.Errorf("upload image: %w", err))
+	}
+
+	variantEntity.ImageResourceID = uploadResult.ResourceID
+	variantEntity.Status = novel.TaskStatusCompleted
+	updates := map[string]interface{}{
+		"image_resource_id": uploadResult.ResourceID,
+		"status":            novel.TaskStatusCompleted,
+	}
+	if err := s.sceneImageVariantRepo.Update(ctx, variantEntity.ID, updates); err != nil {
+		return nil, fmt.Errorf("update scene image variant: %w", err)
+	}
+
+	log.Info().
+		Str("scene_id", scene.ID).
+		Str("variant", string(variant)).
+		Str("resource_id", uploadResult.ResourceID).
+		Msg("场景图片变体生成成功")
+	return variantEntity, nil
+}
+
+// ListSceneImageVariants 获取场景的图片变体列表
+func (s *novelService) ListSceneImageVariants(ctx context.Context, sceneID string) ([]*novel.SceneImageVariant, error) {
+	return s.sceneImageVariantRepo.FindBySceneID(ctx, sceneID)
+}
+
 // GeneratePropImages 为小说的所有道具生成图片
 func (s *novelService) GeneratePropImages(ctx context.Context, novelID string) ([]string, error) {
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderImage)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	props, err := s.propRepo.FindByNovelID(ctx, novelID)
 	if err != nil {
 		return nil, fmt.Errorf("find props: %w", err)
@@ -431,6 +671,12 @@ func (s *novelService) GeneratePropImages(ctx context.Context, novelID string) (
 	if err != nil {
 		return nil, fmt.Errorf("find novel: %w", err)
 	}
+	if err := s.checkBudget(ctx, novelID); err != nil {
+		return nil, err
+	}
+
+	// 道具图片作用于整本小说，只使用小说级风格参考图
+	styleRefs := s.loadStyleReferences(ctx, novelID, "")
 
 	var imageIDs []string
 	for _, prop := range props {
@@ -444,7 +690,7 @@ func (s *novelService) GeneratePropImages(ctx context.Context, novelID string) (
 			continue
 		}
 
-		imageID, err := s.generatePropImage(ctx, novelEntity, prop)
+		imageID, err := s.generatePropImage(ctx, novelEntity, prop, styleRefs)
 		if err != nil {
 			log.Error().Err(err).Str("prop_id", prop.ID).Str("prop_name", prop.Name).Msg("生成道具图片失败")
 			continue
@@ -457,10 +703,10 @@ func (s *novelService) GeneratePropImages(ctx context.Context, novelID string) (
 }
 
 // generatePropImage 生成单个道具图片
-func (s *novelService) generatePropImage(ctx context.Context, novel *novel.Novel, prop *novel.Prop) (string, error) {
+func (s *novelService) generatePropImage(ctx context.Context, novel *novel.Novel, prop *novel.Prop, styleRefs *styleReferenceSet) (string, error) {
 	outputFilename := fmt.Sprintf("prop_%s.jpeg", prop.Name)
 
-	imageData, err := s.imageProvider.GenerateImage(ctx, prop.ImagePrompt, outputFilename)
+	imageData, styleReferenceIDs, _, err := s.generateImageWithStyle(ctx, novel.ID, "", prop.ImagePrompt, outputFilename, styleRefs)
 	if err != nil {
 		return "", fmt.Errorf("generate image: %w", err)
 	}
@@ -471,6 +717,7 @@ func (s *novelService) generatePropImage(ctx context.Context, novel *novel.Novel
 		ContentType: "image/jpeg",
 		Ext:         "jpeg",
 		Data:        bytes.NewReader(imageData),
+		KeyVars:     novelKeyVars(novel.ID, artifactPropImage),
 	}
 
 	uploadResult, err := s.resourceService.UploadFile(ctx, uploadReq)
@@ -480,6 +727,9 @@ func (s *novelService) generatePropImage(ctx context.Context, novel *novel.Novel
 
 	// 更新道具的 ImageResourceID
 	updates := map[string]interface{}{"image_resource_id": uploadResult.ResourceID}
+	if len(styleReferenceIDs) > 0 {
+		updates["image_style_reference_ids"] = styleReferenceIDs
+	}
 	if err := s.propRepo.Update(ctx, prop.ID, updates); err != nil {
 		return "", fmt.Errorf("update prop: %w", err)
 	}
@@ -487,3 +737,62 @@ func (s *novelService) generatePropImage(ctx context.Context, novel *novel.Novel
 	log.Info().Str("prop_id", prop.ID).Str("prop_name", prop.Name).Msg("道具图片生成成功")
 	return uploadResult.ResourceID, nil
 }
+
+// PreviewImage 按任意 prompt 和风格预设生成一张预览图
+func (s *novelService) PreviewImage(ctx context.Context, req *PreviewImageRequest) (*ImagePreview, error) {
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderImage)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	preset := req.StylePreset
+	if preset == "" {
+		preset = s.novelStylePreset(ctx, req.NovelID)
+	}
+	prompt, err := noveltools.BuildStylePresetPrompt(preset, req.Prompt)
+	if err != nil {
+		return nil, err
+	}
+	prompt = s.renderNovelPrompt(ctx, req.NovelID, prompt)
+
+	if _, err := s.novelRepo.FindByID(ctx, req.NovelID); err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+	if req.ChapterID != "" {
+		chapter, err := s.chapterRepo.FindByID(ctx, req.ChapterID)
+		if err != nil {
+			return nil, fmt.Errorf("find chapter: %w", err)
+		}
+		if chapter.NovelID != req.NovelID {
+			return nil, fmt.Errorf("find chapter: %w", mongo.ErrNoDocuments)
+		}
+	}
+
+	var styleRefs *styleReferenceSet
+	if req.UseStyleReferences {
+		styleRefs = s.loadStyleReferences(ctx, req.NovelID, req.ChapterID)
+	}
+
+	outputFilename := fmt.Sprintf("preview_%s.jpeg", id.New())
+	imageData, styleReferenceIDs, localizedPrompt, err := s.generateImageWithStyle(ctx, req.NovelID, req.ChapterID, prompt, outputFilename, styleRefs)
+	if err != nil {
+		return nil, fmt.Errorf("generate image: %w", err)
+	}
+
+	log.Info().
+		Str("novel_id", req.NovelID).
+		Str("chapter_id", req.ChapterID).
+		Str("style_preset", preset.String()).
+		Int("size", len(imageData)).
+		Msg("预览图生成成功")
+
+	return &ImagePreview{
+		ImageData:         imageData,
+		ContentType:       http.DetectContentType(imageData),
+		Prompt:            prompt,
+		LocalizedPrompt:   localizedPrompt,
+		StylePreset:       preset,
+		StyleReferenceIDs: styleReferenceIDs,
+	}, nil
+}
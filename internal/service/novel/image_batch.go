@@ -0,0 +1,307 @@
+package novel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/killswitch"
+	"lemon/internal/pkg/noveltools"
+	"lemon/internal/service"
+)
+
+// defaultImageGenerationConcurrency 图片提供者不支持批量提交时默认的并发生成数
+const defaultImageGenerationConcurrency = 4
+
+// 单个镜头的图片生成状态
+const (
+	ImageItemCompleted = "completed" // 本次生成成功
+	ImageItemFailed    = "failed"    // 本次生成失败（续接时会重新生成）
+	ImageItemSkipped   = "skipped"   // 续接时该序号已有完成的图片，跳过
+)
+
+// imageGenerationConcurrencyFromEnv 读取图片并发生成数（IMAGE_GENERATION_CONCURRENCY），未配置时使用默认值
+func imageGenerationConcurrencyFromEnv() int {
+	if v, err := strconv.Atoi(os.Getenv("IMAGE_GENERATION_CONCURRENCY")); err == nil && v > 0 {
+		return v
+	}
+	return defaultImageGenerationConcurrency
+}
+
+// ImageGenerationOptions 章节图片生成选项
+type ImageGenerationOptions struct {
+	Resume bool // 续接上次生成：沿用最新图片版本，跳过已完成的序号；该解说没有可续接的版本时生成新版本
+}
+
+// ImageGenerationItem 单个镜头的图片生成结果
+type ImageGenerationItem struct {
+	Sequence    int    `json:"sequence"`           // 图片序号
+	ShotID      string `json:"shot_id"`            // 镜头ID
+	SceneNumber string `json:"scene_number"`       // 场景编号
+	ShotNumber  string `json:"shot_number"`        // 镜头编号
+	ImageID     string `json:"image_id,omitempty"` // 图片ID（生成成功或续接跳过时）
+	Status      string `json:"status"`             // completed / failed / skipped
+	Error       string `json:"error,omitempty"`    // 失败原因
+}
+
+// ImageGenerationResult 章节图片生成结果
+// 单张图片失败不影响其他图片，部分成功时 Failed > 0，可以用续接模式补齐失败的序号
+type ImageGenerationResult struct {
+	NarrationID string                `json:"narration_id"`
+	Version     int                   `json:"version"`   // 图片版本号
+	Resumed     bool                  `json:"resumed"`   // 是否续接了已有版本
+	ImageIDs    []string              `json:"image_ids"` // 本次新生成的图片ID
+	Completed   int                   `json:"completed"` // 本次生成成功的数量
+	Failed      int                   `json:"failed"`    // 本次生成失败的数量
+	Skipped     int                   `json:"skipped"`   // 续接时跳过的数量
+	Items       []ImageGenerationItem `json:"items"`     // 按序号排列的镜头结果
+}
+
+// imageJob 单个镜头的图片生成任务
+// 每个任务只由一个 goroutine 写入结果，不需要加锁
+type imageJob struct {
+	scene      *novel.Scene
+	shot       *novel.Shot
+	character  *novel.Character
+	continuity *continuityFrame // 章节衔接帧（只有第一个镜头有）
+	sequence   int
+	item       int // 在 ImageGenerationResult.Items 中的下标
+
+	imageID string
+	err     error
+}
+
+// resolveImageGenerationVersion 确定本次生成使用的图片版本号
+// 续接时沿用章节的最新版本（该版本需属于当前解说且有已完成的图片），返回已完成的序号 → 图片ID；否则返回下一个版本号
+func (s *novelService) resolveImageGenerationVersion(ctx context.Context, narration *novel.Narration, resume bool) (int, map[int]string, error) {
+	if resume {
+		versions, err := s.imageRepo.FindVersionsByChapterID(ctx, narration.ChapterID)
+		if err == nil && len(versions) > 0 {
+			latest := versions[0]
+			for _, v := range versions {
+				if v > latest {
+					latest = v
+				}
+			}
+			images, err := s.imageRepo.FindByNarrationIDAndVersion(ctx, narration.ID, latest)
+			if err != nil {
+				return 0, nil, fmt.Errorf("find images of version %d: %w", latest, err)
+			}
+			completed := make(map[int]string)
+			for _, image := range images {
+				if image.Status == novel.TaskStatusCompleted && image.Sequence > 0 {
+					completed[image.Sequence] = image.ID
+				}
+			}
+			if len(completed) > 0 {
+				return latest, completed, nil
+			}
+		}
+	}
+
+	version, err := s.nextVersion(ctx, narration.ChapterID, novel.VersionArtifactImage)
+	if err != nil {
+		return 0, nil, err
+	}
+	return version, nil, nil
+}
+
+// runImageJobs 执行章节图片生成任务，结果写回每个任务
+// 需要参考图（风格参考图、章节衔接帧）的任务逐张生成；其余任务在提供者支持批量提交时分批提交，
+// 否则按 IMAGE_GENERATION_CONCURRENCY 限制并发数并行生成
+func (s *novelService) runImageJobs(
+	ctx context.Context,
+	narration *novel.Narration,
+	chapter *novel.Chapter,
+	jobs []*imageJob,
+	styleRefs *styleReferenceSet,
+	promptBuilder *noveltools.ImagePromptBuilder,
+	version int,
+) {
+	_, supportsRefs := s.imageProvider.(noveltools.StyleReferenceProvider)
+	batchProvider, supportsBatch := s.imageProvider.(noveltools.BatchImageProvider)
+
+	var single, batch []*imageJob
+	for _, job := range jobs {
+		needsRefs := supportsRefs && (styleRefs != nil || job.continuity != nil)
+		if supportsBatch && !needsRefs {
+			batch = append(batch, job)
+		} else {
+			single = append(single, job)
+		}
+	}
+
+	if len(batch) > 0 {
+		size := batchProvider.MaxBatchSize()
+		if size <= 0 {
+			size = len(batch)
+		}
+		for start := 0; start < len(batch); start += size {
+			end := min(start+size, len(batch))
+			s.generateImageBatch(ctx, batchProvider, narration, chapter, batch[start:end], promptBuilder, version)
+		}
+	}
+
+	concurrency := s.imageConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultImageGenerationConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, job := range single {
+		wg.Add(1)
+		go func(job *imageJob) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			job.imageID, job.err = s.generateSingleImage(
+				ctx,
+				narration,
+				chapter,
+				job.scene,
+				job.shot,
+				job.character,
+				styleRefs,
+				job.continuity,
+				promptBuilder,
+				job.sequence,
+				version,
+			)
+		}(job)
+	}
+	wg.Wait()
+}
+
+// generateImageBatch 一次提交一批章节图片，单张失败只影响对应的任务
+// 与 generateImageWithStyle 相同：生成前检查预算、替换小说变量并按提供者偏好本地化提示词，每张成功的图片记录一次费用
+func (s *novelService) generateImageBatch(
+	ctx context.Context,
+	provider noveltools.BatchImageProvider,
+	narration *novel.Narration,
+	chapter *novel.Chapter,
+	jobs []*imageJob,
+	promptBuilder *noveltools.ImagePromptBuilder,
+	version int,
+) {
+	if err := s.checkBudget(ctx, chapter.NovelID); err != nil {
+		for _, job := range jobs {
+			job.err = err
+		}
+		return
+	}
+
+	prompts := make([]string, len(jobs))
+	localizedPrompts := make([]string, len(jobs))
+	requests := make([]noveltools.ImageBatchRequest, len(jobs))
+	for i, job := range jobs {
+		prompt := promptBuilder.BuildCompletePrompt(job.character, job.shot.ImagePrompt)
+		prompt = s.renderNovelPrompt(ctx, chapter.NovelID, prompt)
+		providerPrompt := s.localizeImagePrompt(ctx, chapter.NovelID, chapter.ID, prompt)
+		prompts[i] = prompt
+		if providerPrompt != prompt {
+			localizedPrompts[i] = providerPrompt
+		}
+		requests[i] = noveltools.ImageBatchRequest{
+			Prompt:   providerPrompt,
+			Filename: chapterImageFilename(chapter, job.sequence),
+		}
+	}
+
+	results := provider.GenerateImages(s.withImageSteps(ctx, chapter.NovelID), requests)
+	for i, job := range jobs {
+		if i >= len(results) {
+			job.err = fmt.Errorf("generate image: no batch result for sequence %d", job.sequence)
+			continue
+		}
+		if results[i].Err != nil {
+			job.err = fmt.Errorf("generate image: %w", results[i].Err)
+			continue
+		}
+		s.recordCost(ctx, chapter.NovelID, chapter.ID, killswitch.ProviderImage, 1, s.pricing.Image(1))
+		job.imageID, job.err = s.saveChapterImage(ctx, narration, chapter, job.scene, job.shot, results[i].ImageData,
+			prompts[i], localizedPrompts[i], nil, "", job.sequence, version)
+	}
+
+	log.Info().
+		Str("chapter_id", chapter.ID).
+		Int("batch_size", len(jobs)).
+		Msg("章节图片批量生成结束")
+}
+
+// chapterImageFilename 章节图片的输出文件名
+func chapterImageFilename(chapter *novel.Chapter, sequence int) string {
+	return fmt.Sprintf("chapter_%03d_image_%02d.jpeg", chapter.Sequence, sequence)
+}
+
+// saveChapterImage 上传章节图片并保存图片记录
+func (s *novelService) saveChapterImage(
+	ctx context.Context,
+	narration *novel.Narration,
+	chapter *novel.Chapter,
+	scene *novel.Scene,
+	shot *novel.Shot,
+	imageData []byte,
+	prompt string,
+	localizedPrompt string,
+	styleReferenceIDs []string,
+	continuityVideoID string,
+	sequence int,
+	version int,
+) (string, error) {
+	// 1. 上传图片到 resource 模块
+	uploadReq := &service.UploadFileRequest{
+		UserID:      narration.UserID,
+		FileName:    chapterImageFilename(chapter, sequence),
+		ContentType: "image/jpeg",
+		Ext:         "jpeg",
+		Data:        bytes.NewReader(imageData),
+		KeyVars:     chapterKeyVars(chapter, artifactImage, version, sequence),
+	}
+
+	uploadResult, err := s.resourceService.UploadFile(ctx, uploadReq)
+	if err != nil {
+		return "", fmt.Errorf("upload image: %w", err)
+	}
+
+	// 2. 保存 ChapterImage 记录
+	imageID := id.New()
+	chapterImage := &novel.Image{
+		ID:                imageID,
+		ChapterID:         chapter.ID,
+		NarrationID:       narration.ID,
+		NovelID:           chapter.NovelID,
+		SceneNumber:       scene.SceneNumber,
+		ShotNumber:        shot.ShotNumber,
+		ShotID:            shot.ID,
+		ImageResourceID:   uploadResult.ResourceID,
+		CharacterName:     shot.Character,
+		Prompt:            prompt,
+		LocalizedPrompt:   localizedPrompt,
+		StyleReferenceIDs: styleReferenceIDs,
+		ContinuityVideoID: continuityVideoID,
+		Version:           version,
+		Status:            novel.TaskStatusCompleted,
+		Sequence:          sequence,
+	}
+
+	if err := s.imageRepo.Create(ctx, chapterImage); err != nil {
+		return "", fmt.Errorf("create chapter image: %w", err)
+	}
+
+	log.Info().
+		Str("image_id", imageID).
+		Str("chapter_id", chapter.ID).
+		Str("scene", scene.SceneNumber).
+		Str("shot", shot.ShotNumber).
+		Msg("章节图片生成成功")
+
+	return imageID, nil
+}
@@ -0,0 +1,60 @@
+package novel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/pkg/killswitch"
+	"lemon/internal/pkg/noveltools"
+)
+
+// localizeImagePrompt 按图片生成提供者偏好的语言翻译提示词并追加风格关键词
+// 提供者未声明偏好语言（或偏好中文）时原样返回；翻译失败时退回原始提示词加风格关键词，不阻断图片生成
+func (s *novelService) localizeImagePrompt(ctx context.Context, novelID, chapterID, prompt string) string {
+	provider, ok := s.imageProvider.(noveltools.PromptLocalizedProvider)
+	if !ok {
+		return prompt
+	}
+	locale := provider.PromptLocale()
+	if !locale.NeedsTranslation() {
+		return prompt
+	}
+
+	localized := prompt
+	if noveltools.ContainsHan(prompt) {
+		translated, err := s.translateImagePrompt(ctx, novelID, chapterID, prompt, locale.Language)
+		if err != nil {
+			log.Warn().Err(err).
+				Str("novel_id", novelID).
+				Str("language", locale.Language).
+				Msg("翻译图片提示词失败，使用原始提示词")
+		} else {
+			localized = translated
+		}
+	}
+	return noveltools.AppendStyleKeywords(localized, locale.StyleKeywords)
+}
+
+// translateImagePrompt 调用 LLM 把中文图片提示词翻译为目标语言
+func (s *novelService) translateImagePrompt(ctx context.Context, novelID, chapterID, prompt, language string) (string, error) {
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderLLM)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	translationPrompt := noveltools.BuildPromptTranslationPrompt(prompt, language)
+	output, err := s.llmProvider.Generate(ctx, translationPrompt)
+	if err != nil {
+		return "", fmt.Errorf("translate prompt: %w", err)
+	}
+	s.recordLLMCost(ctx, novelID, chapterID, translationPrompt, output)
+
+	translated := noveltools.CleanTranslatedPrompt(output)
+	if translated == "" {
+		return "", fmt.Errorf("translated prompt is empty")
+	}
+	return translated, nil
+}
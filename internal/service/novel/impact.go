@@ -0,0 +1,316 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/noveltools"
+)
+
+// ErrInvalidImpactStage 影响分析的阶段不是章节渲染流程的阶段
+var ErrInvalidImpactStage = errors.New("invalid impact stage")
+
+// ImpactService 重新生成影响分析服务接口
+type ImpactService interface {
+	// AnalyzeRegenerationImpact 分析重新生成章节某个阶段的影响范围：沿产物依赖关系列出需要重建的下游产物、
+	// 预计费用和耗时，以及使用了本章最终视频做衔接帧的下一章图片。只查询，不生成任何产物
+	AnalyzeRegenerationImpact(ctx context.Context, chapterID string, stage novel.PipelineStage) (*RegenerationImpact, error)
+}
+
+// StageImpact 单个阶段的影响
+type StageImpact struct {
+	Stage           novel.PipelineStage `json:"stage"`
+	Version         int                 `json:"version,omitempty"`          // 当前最新版本（重新生成后不再是最新）
+	PromotedVersion int                 `json:"promoted_version,omitempty"` // 当前发布版本（重建后需要重新发布才能生效）
+	Artifacts       int                 `json:"artifacts"`                  // 当前最新版本的产物数量（将失效）
+	Units           float64             `json:"units"`                      // 重建的计量单位数：解说/音频为字数，图片为张数，解说视频为图生视频秒数
+	CostFen         int64               `json:"cost_fen"`                   // 预计费用（分）
+	DurationMs      int64               `json:"duration_ms"`                // 预计耗时（毫秒），取该阶段最近一次成功执行的耗时
+	HasHistory      bool                `json:"has_history"`                // 是否有执行记录可用于估算耗时
+}
+
+// ContinuityImpact 使用本章最终视频做衔接帧的下一章图片
+type ContinuityImpact struct {
+	ChapterID string `json:"chapter_id"`
+	Sequence  int    `json:"sequence"`
+	Title     string `json:"title"`
+	Images    int    `json:"images"` // 使用了本章最终视频衔接帧的图片数
+}
+
+// RegenerationImpact 重新生成的影响范围
+type RegenerationImpact struct {
+	ChapterID   string              `json:"chapter_id"`
+	NarrationID string              `json:"narration_id,omitempty"` // 当前解说ID（章节还没有解说时为空）
+	Stage       novel.PipelineStage `json:"stage"`                  // 准备重新生成的阶段
+	Stages      []StageImpact       `json:"stages"`                 // 需要重建的阶段（含本阶段，按流程顺序）
+	Continuity  *ContinuityImpact   `json:"continuity,omitempty"`   // 受影响的下一章衔接帧图片（最终视频需要重建时才有）
+
+	Artifacts        int   `json:"artifacts"`          // 将失效的产物合计
+	CostFen          int64 `json:"cost_fen"`           // 预计费用合计（分）
+	DurationMs       int64 `json:"duration_ms"`        // 预计耗时合计（毫秒）
+	AffectsPromotion bool  `json:"affects_promotion"`  // 是否有阶段的当前最新版本就是发布版本
+	Incomplete       bool  `json:"incomplete_history"` // 是否有阶段缺少执行记录（耗时估算偏小）
+}
+
+// AnalyzeRegenerationImpact 分析重新生成章节某个阶段的影响范围
+// 费用按当前单价估算：解说取最近一次执行的实际费用（没有时按章节字数估算），音频按朗读字数，图片按镜头数，
+// 解说视频按音频时长对应的图生视频秒数（需要重建音频时用朗读时长估算）；字幕和最终视频在本地生成，不计费
+func (s *novelService) AnalyzeRegenerationImpact(ctx context.Context, chapterID string, stage novel.PipelineStage) (*RegenerationImpact, error) {
+	stages := noveltools.RegenerationBlastRadius(stage)
+	if stages == nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidImpactStage, stage)
+	}
+	ch, err := s.chapterRepo.FindByID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+
+	narration, err := s.narrationRepo.FindByChapterID(ctx, chapterID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("find narration: %w", err)
+	}
+	breakdown, err := s.GetRenderBreakdown(ctx, chapterID)
+	if err != nil {
+		return nil, err
+	}
+	history := make(map[novel.PipelineStage]novel.StageBreakdown, len(breakdown.Stages))
+	for _, sb := range breakdown.Stages {
+		history[sb.Stage] = sb
+	}
+
+	inputs, err := s.impactInputs(ctx, ch, narration, slices.Contains(stages, novel.PipelineStageAudio))
+	if err != nil {
+		return nil, err
+	}
+
+	impact := &RegenerationImpact{
+		ChapterID: chapterID,
+		Stage:     stage,
+		Stages:    make([]StageImpact, 0, len(stages)),
+	}
+	if narration != nil {
+		impact.NarrationID = narration.ID
+	}
+	var promoted novel.PromotedVersions
+	if ch.PromotedVersions != nil {
+		promoted = *ch.PromotedVersions
+	}
+
+	for _, st := range stages {
+		item := StageImpact{Stage: st}
+		current := inputs.current[st]
+		item.Version = current.version
+		item.Artifacts = current.count
+
+		switch st {
+		case novel.PipelineStageNarration:
+			item.PromotedVersion = promoted.Narration
+			item.Units = float64(ch.TotalChars)
+			if run, ok := history[st]; ok && run.CostFen > 0 {
+				item.CostFen = run.CostFen
+			} else {
+				item.CostFen = s.pricing.LLM(ch.TotalChars)
+			}
+		case novel.PipelineStageAudio:
+			item.PromotedVersion = promoted.Audio
+			item.Units = float64(inputs.ttsChars)
+			item.CostFen = s.pricing.TTS(inputs.ttsChars)
+		case novel.PipelineStageSubtitle:
+			item.PromotedVersion = promoted.Subtitle
+		case novel.PipelineStageImage:
+			item.PromotedVersion = promoted.Image
+			item.Units = float64(inputs.shots)
+			item.CostFen = s.pricing.Image(inputs.shots)
+		case novel.PipelineStageNarrationVideo:
+			seconds := noveltools.ImageToVideoSeconds(inputs.durations)
+			item.Units = float64(seconds)
+			item.CostFen = s.pricing.Video(float64(seconds))
+		case novel.PipelineStageFinalVideo:
+			item.PromotedVersion = promoted.Video
+		}
+
+		if run, ok := history[st]; ok {
+			item.DurationMs = run.DurationMs
+			item.HasHistory = true
+		} else {
+			impact.Incomplete = true
+		}
+		if item.Version > 0 && item.Version == item.PromotedVersion {
+			impact.AffectsPromotion = true
+		}
+
+		impact.Artifacts += item.Artifacts
+		impact.CostFen += item.CostFen
+		impact.DurationMs += item.DurationMs
+		impact.Stages = append(impact.Stages, item)
+	}
+
+	if slices.Contains(stages, novel.PipelineStageFinalVideo) {
+		continuity, err := s.continuityImpact(ctx, ch, inputs.finalVideoIDs)
+		if err != nil {
+			return nil, err
+		}
+		impact.Continuity = continuity
+	}
+
+	return impact, nil
+}
+
+// artifactVersion 某类产物的当前最新版本及其产物数量
+type artifactVersion struct {
+	version int
+	count   int
+}
+
+// impactInputs 影响分析使用的章节现状
+type impactInputs struct {
+	current       map[novel.PipelineStage]artifactVersion
+	shots         int       // 当前解说的镜头数
+	ttsChars      int       // 重建音频需要合成的字数
+	durations     []float64 // 每个镜头的音频时长（秒），用于估算图生视频秒数
+	finalVideoIDs []string  // 所有最终视频ID（用于查找下一章的衔接帧图片）
+}
+
+// impactInputs 查询章节各类产物的当前最新版本，以及估算重建费用需要的字数和时长
+// rebuildAudio 为 true 时音频会重新合成，时长按朗读时长估算；否则沿用当前音频的时长
+func (s *novelService) impactInputs(ctx context.Context, ch *novel.Chapter, narration *novel.Narration, rebuildAudio bool) (*impactInputs, error) {
+	in := &impactInputs{current: make(map[novel.PipelineStage]artifactVersion)}
+
+	videos, err := s.videoRepo.FindByChapterID(ctx, ch.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find videos: %w", err)
+	}
+	var narrationVideos, finalVideos []int
+	for _, v := range videos {
+		switch v.VideoType {
+		case novel.VideoTypeNarration:
+			narrationVideos = append(narrationVideos, v.Version)
+		case novel.VideoTypeFinal:
+			finalVideos = append(finalVideos, v.Version)
+			in.finalVideoIDs = append(in.finalVideoIDs, v.ID)
+		}
+	}
+	in.current[novel.PipelineStageNarrationVideo] = latestArtifactVersion(narrationVideos)
+	in.current[novel.PipelineStageFinalVideo] = latestArtifactVersion(finalVideos)
+
+	if narration == nil {
+		return in, nil
+	}
+	in.current[novel.PipelineStageNarration] = artifactVersion{version: narration.Version, count: 1}
+
+	audios, err := s.audioRepo.FindByNarrationID(ctx, narration.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find audios: %w", err)
+	}
+	audioVersions := make([]int, 0, len(audios))
+	for _, a := range audios {
+		audioVersions = append(audioVersions, a.Version)
+	}
+	in.current[novel.PipelineStageAudio] = latestArtifactVersion(audioVersions)
+
+	subtitles, err := s.subtitleRepo.FindByNarrationID(ctx, narration.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find subtitles: %w", err)
+	}
+	subtitleVersions := make([]int, 0, len(subtitles))
+	for _, sub := range subtitles {
+		subtitleVersions = append(subtitleVersions, sub.Version)
+	}
+	in.current[novel.PipelineStageSubtitle] = latestArtifactVersion(subtitleVersions)
+
+	images, err := s.imageRepo.FindByNarrationID(ctx, narration.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find images: %w", err)
+	}
+	imageVersions := make([]int, 0, len(images))
+	for _, img := range images {
+		imageVersions = append(imageVersions, img.Version)
+	}
+	in.current[novel.PipelineStageImage] = latestArtifactVersion(imageVersions)
+
+	// 朗读字数和时长按当前解说估算（重新生成解说后的字数未知，以当前解说为准）
+	reading, err := s.EstimateChapterReadingTime(ctx, ch.ID)
+	if err != nil {
+		return nil, err
+	}
+	shots, err := s.shotRepo.FindByNarrationID(ctx, narration.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find shots: %w", err)
+	}
+	in.shots = len(shots)
+	for _, shot := range reading.Shots {
+		in.ttsChars += utf8.RuneCountInString(shot.Text)
+		if rebuildAudio {
+			in.durations = append(in.durations, shot.Duration)
+		}
+	}
+	if !rebuildAudio {
+		latest := in.current[novel.PipelineStageAudio].version
+		for _, a := range audios {
+			if a.Version == latest && a.Status == novel.TaskStatusCompleted {
+				in.durations = append(in.durations, a.Duration)
+			}
+		}
+	}
+	return in, nil
+}
+
+// latestArtifactVersion 返回最大的版本号及该版本的产物数量
+func latestArtifactVersion(versions []int) artifactVersion {
+	var av artifactVersion
+	for _, v := range versions {
+		switch {
+		case v > av.version:
+			av = artifactVersion{version: v, count: 1}
+		case v == av.version:
+			av.count++
+		}
+	}
+	return av
+}
+
+// continuityImpact 查找使用了本章最终视频做衔接帧的下一章图片，没有时返回 nil
+func (s *novelService) continuityImpact(ctx context.Context, ch *novel.Chapter, finalVideoIDs []string) (*ContinuityImpact, error) {
+	if len(finalVideoIDs) == 0 {
+		return nil, nil
+	}
+	chapters, err := s.chapterRepo.FindByNovelID(ctx, ch.NovelID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapters: %w", err)
+	}
+	var next *novel.Chapter
+	for _, c := range chapters {
+		if c.Sequence > ch.Sequence && (next == nil || c.Sequence < next.Sequence) {
+			next = c
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+
+	images, err := s.imageRepo.FindByChapterID(ctx, next.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find images: %w", err)
+	}
+	count := 0
+	for _, img := range images {
+		if img.ContinuityVideoID != "" && slices.Contains(finalVideoIDs, img.ContinuityVideoID) {
+			count++
+		}
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	return &ContinuityImpact{
+		ChapterID: next.ID,
+		Sequence:  next.Sequence,
+		Title:     next.Title,
+		Images:    count,
+	}, nil
+}
@@ -0,0 +1,357 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/killswitch"
+	"lemon/internal/pkg/noveltools"
+)
+
+// ErrNoBaseAudio 解说还没有可以增量更新的音频版本（需要先完整生成音频）
+var ErrNoBaseAudio = errors.New("no base audio version")
+
+// IncrementalAudioService 解说文本修改后的增量音频重新合成服务接口
+type IncrementalAudioService interface {
+	// RegenerateEditedAudios 对比镜头当前的解说文本和最新音频版本，只重新合成文本修改过的片段，
+	// 与未修改的片段一起组成新的音频版本；同时只重新生成受影响的字幕片段，并把对应的镜头视频标记为过期
+	RegenerateEditedAudios(ctx context.Context, narrationID string) (*IncrementalAudioResult, error)
+}
+
+// IncrementalAudioResult 增量重新合成的结果
+type IncrementalAudioResult struct {
+	NarrationID          string   `json:"narration_id"`
+	BaseVersion          int      `json:"base_version"`               // 对比的音频版本
+	AudioVersion         int      `json:"audio_version"`              // 新的音频版本（没有修改时与 base_version 相同）
+	Changed              bool     `json:"changed"`                    // 是否有片段修改（没有修改时不生成新版本）
+	AudioIDs             []string `json:"audio_ids"`                  // 新版本的音频ID（按序号）
+	ResynthesizedShotIDs []string `json:"resynthesized_shot_ids"`     // 重新合成的镜头ID
+	Reused               int      `json:"reused"`                     // 复用上一版本的片段数
+	Removed              int      `json:"removed"`                    // 上一版本中不再使用的片段数（文本修改或镜头删除）
+	SubtitleVersion      int      `json:"subtitle_version,omitempty"` // 新的字幕版本（最新字幕不是生成的字幕时不更新字幕）
+	SubtitleIDs          []string `json:"subtitle_ids,omitempty"`     // 新版本的字幕ID
+	RegeneratedSubtitles int      `json:"regenerated_subtitles"`      // 重新生成的字幕片段数
+	StaleVideoIDs        []string `json:"stale_video_ids"`            // 标记为过期的视频ID
+}
+
+// RegenerateEditedAudios 增量重新合成修改过的音频片段
+// 片段是否修改按合成用的文本判断（与完整生成一样按小说的数字写法转换并清理）；配音或发音词典变化不会被识别，需要完整重新生成音频
+func (s *novelService) RegenerateEditedAudios(ctx context.Context, narrationID string) (*IncrementalAudioResult, error) {
+	narration, err := s.narrationRepo.FindByID(ctx, narrationID)
+	if err != nil {
+		return nil, fmt.Errorf("find narration: %w", err)
+	}
+
+	var result *IncrementalAudioResult
+	var previous []*novel.Audio
+	var plan *noveltools.IncrementalAudioPlan
+	err = s.runNarrationStage(ctx, narrationID, novel.PipelineStageAudio, func(ctx context.Context) error {
+		var err error
+		result, previous, plan, err = s.regenerateEditedAudios(ctx, narration)
+		if result != nil && result.Changed {
+			setStageResources(ctx, result.AudioIDs...)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !result.Changed {
+		return result, nil
+	}
+
+	// 字幕：只重新生成音频变化的片段
+	err = s.runNarrationStage(ctx, narrationID, novel.PipelineStageSubtitle, func(ctx context.Context) error {
+		err := s.regenerateEditedSubtitles(ctx, narration, result)
+		setStageResources(ctx, result.SubtitleIDs...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 视频：只标记使用了过期音频片段的镜头视频
+	stale := make([]*novel.Audio, 0, len(plan.Stale))
+	for _, i := range plan.Stale {
+		stale = append(stale, previous[i])
+	}
+	result.StaleVideoIDs, err = s.markStaleShotVideos(ctx, narration.ChapterID, stale, result.AudioVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("narration_id", narrationID).
+		Int("base_version", result.BaseVersion).
+		Int("audio_version", result.AudioVersion).
+		Int("resynthesized", len(result.ResynthesizedShotIDs)).
+		Int("reused", result.Reused).
+		Int("regenerated_subtitles", result.RegeneratedSubtitles).
+		Int("stale_videos", len(result.StaleVideoIDs)).
+		Msg("解说修改后增量重新合成音频完成")
+	return result, nil
+}
+
+// regenerateEditedAudios 生成新的音频版本：文本未修改的片段复用上一版本的音频文件，修改过的片段重新合成
+func (s *novelService) regenerateEditedAudios(ctx context.Context, narration *novel.Narration) (*IncrementalAudioResult, []*novel.Audio, *noveltools.IncrementalAudioPlan, error) {
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderTTS)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer release()
+
+	// 1. 上一个音频版本
+	baseVersion, err := s.resolveAudioVersion(ctx, narration.ID, 0)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %v", ErrNoBaseAudio, err)
+	}
+	previous, err := s.audioRepo.FindByNarrationIDAndVersion(ctx, narration.ID, baseVersion)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("find audios: %w", err)
+	}
+	if len(previous) == 0 {
+		return nil, nil, nil, fmt.Errorf("%w: audio version %d is empty", ErrNoBaseAudio, baseVersion)
+	}
+
+	// 2. 镜头当前的合成文本（与完整生成一致：按数字写法转换后清理，清理后为空的镜头跳过）
+	shots, err := s.shotRepo.FindByNarrationID(ctx, narration.ID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("find shots: %w", err)
+	}
+	textCleaner := noveltools.NewTextCleaner()
+	numberStyle := s.novelNumberStyle(ctx, narration.NovelID)
+	var current []noveltools.AudioSegmentText
+	for _, shot := range shots {
+		if shot.Narration == "" {
+			continue
+		}
+		cleanText := textCleaner.CleanTextForTTS(noveltools.NormalizeNumbers(shot.Narration, numberStyle))
+		if cleanText == "" {
+			continue
+		}
+		current = append(current, noveltools.AudioSegmentText{ShotID: shot.ID, Text: cleanText})
+	}
+	if len(current) == 0 {
+		return nil, nil, nil, fmt.Errorf("no narration texts found")
+	}
+
+	prevSegments := make([]noveltools.PreviousAudioSegment, 0, len(previous))
+	for _, a := range previous {
+		prevSegments = append(prevSegments, noveltools.PreviousAudioSegment{ShotID: a.ShotID, Sequence: a.Sequence, Text: a.Text})
+	}
+	plan := noveltools.PlanIncrementalAudio(current, prevSegments)
+	result := &IncrementalAudioResult{
+		NarrationID:          narration.ID,
+		BaseVersion:          baseVersion,
+		AudioVersion:         baseVersion,
+		AudioIDs:             make([]string, 0, len(plan.Segments)),
+		ResynthesizedShotIDs: make([]string, 0),
+		StaleVideoIDs:        make([]string, 0),
+		Removed:              len(plan.Stale),
+	}
+	if !plan.Changed() {
+		for _, a := range previous {
+			result.AudioIDs = append(result.AudioIDs, a.ID)
+		}
+		result.Reused = len(previous)
+		return result, previous, plan, nil
+	}
+	result.Changed = true
+
+	// 3. 新版本：复用的片段复制记录（共用音频文件），修改过的片段重新合成
+	version, err := s.nextVersion(ctx, narration.ChapterID, novel.VersionArtifactAudio)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get next audio version: %w", err)
+	}
+	result.AudioVersion = version
+	voice := s.novelVoice(ctx, narration.NovelID)
+	for _, seg := range plan.Segments {
+		if seg.Reuse >= 0 {
+			src := previous[seg.Reuse]
+			reused := *src
+			reused.ID = id.New()
+			reused.ShotID = seg.ShotID
+			reused.Sequence = seg.Sequence
+			reused.Version = version
+			reused.ReusedFromID = src.ID
+			if err := s.audioRepo.Create(ctx, &reused); err != nil {
+				return nil, nil, nil, fmt.Errorf("failed to create audio record: %w", err)
+			}
+			result.AudioIDs = append(result.AudioIDs, reused.ID)
+			result.Reused++
+			continue
+		}
+
+		audioID, err := s.generateSingleAudio(ctx, narration, seg.ShotID, seg.Sequence, seg.Text, version, voice)
+		if err != nil {
+			log.Error().Err(err).Int("sequence", seg.Sequence).Msg("重新合成章节音频失败")
+			return nil, nil, nil, fmt.Errorf("failed to generate audio for sequence %d: %w", seg.Sequence, err)
+		}
+		result.AudioIDs = append(result.AudioIDs, audioID)
+		result.ResynthesizedShotIDs = append(result.ResynthesizedShotIDs, seg.ShotID)
+	}
+	return result, previous, plan, nil
+}
+
+// regenerateEditedSubtitles 生成新的字幕版本：音频复用且位置不变的片段复用上一版本的字幕文件，其余片段按新音频的时间戳重新生成
+// 最新的字幕是导入的字幕（或还没有字幕）时不更新字幕，由调用方重新导入或完整生成
+func (s *novelService) regenerateEditedSubtitles(ctx context.Context, narration *novel.Narration, result *IncrementalAudioResult) error {
+	subs, err := s.subtitleRepo.FindByNarrationID(ctx, narration.ID)
+	if err != nil {
+		return fmt.Errorf("find subtitles: %w", err)
+	}
+	latest := 0
+	for _, sub := range subs {
+		latest = max(latest, sub.Version)
+	}
+	var previous []*novel.Subtitle
+	for _, sub := range subs {
+		if sub.Version == latest {
+			previous = append(previous, sub)
+		}
+	}
+	if len(previous) == 0 {
+		return nil
+	}
+	for _, sub := range previous {
+		if sub.Source == novel.SubtitleSourceImported {
+			log.Info().Str("narration_id", narration.ID).Int("subtitle_version", latest).Msg("最新字幕为导入的字幕，不增量更新字幕")
+			return nil
+		}
+	}
+
+	audios, err := s.audioRepo.FindByNarrationIDAndVersion(ctx, narration.ID, result.AudioVersion)
+	if err != nil {
+		return fmt.Errorf("find audios: %w", err)
+	}
+	shots, err := s.shotRepo.FindByNarrationID(ctx, narration.ID)
+	if err != nil {
+		return fmt.Errorf("find shots: %w", err)
+	}
+	shotTexts := make(map[string]string, len(shots))
+	for _, shot := range shots {
+		shotTexts[shot.ID] = shot.Narration
+	}
+	// 上一版本的字幕按音频片段对应：复用的音频按镜头对应，旧数据按序号对应
+	prevByShot := make(map[string]*novel.Subtitle, len(previous))
+	prevBySequence := make(map[int]*novel.Subtitle, len(previous))
+	lastSequence := 0
+	for _, sub := range previous {
+		if sub.ShotID != "" {
+			prevByShot[sub.ShotID] = sub
+		}
+		prevBySequence[sub.Sequence] = sub
+		lastSequence = max(lastSequence, sub.Sequence)
+	}
+
+	version, err := s.nextVersion(ctx, narration.ChapterID, novel.VersionArtifactSubtitle)
+	if err != nil {
+		return fmt.Errorf("failed to get next subtitle version: %w", err)
+	}
+	result.SubtitleVersion = version
+	numberStyle := s.novelNumberStyle(ctx, narration.NovelID)
+	style, introText, outroText := s.novelSubtitleCards(ctx, narration.NovelID)
+	for i, audio := range audios {
+		// 片头/片尾文案只显示在第一段和最后一段字幕，位置变化的片段需要重新生成
+		isLast := i == len(audios)-1
+		prev := prevByShot[audio.ShotID]
+		if prev == nil {
+			prev = prevBySequence[audio.Sequence]
+		}
+		if audio.ReusedFromID != "" && prev != nil && prev.Sequence == audio.Sequence && isLast == (prev.Sequence == lastSequence) {
+			reused := *prev
+			reused.ID = id.New()
+			reused.ShotID = audio.ShotID
+			reused.Version = version
+			reused.ReusedFromID = prev.ID
+			if err := s.subtitleRepo.Create(ctx, &reused); err != nil {
+				return fmt.Errorf("failed to create subtitle record: %w", err)
+			}
+			result.SubtitleIDs = append(result.SubtitleIDs, reused.ID)
+			continue
+		}
+
+		text := shotTexts[audio.ShotID]
+		if text == "" {
+			text = audio.Text
+		}
+		cards := subtitleCards{style: style}
+		if i == 0 {
+			cards.intro = introText
+		}
+		if isLast {
+			cards.outro = outroText
+		}
+		subtitleID, err := s.generateSingleSubtitle(ctx, narration, audio, audio.Sequence, noveltools.NormalizeNumbers(text, numberStyle), version, cards)
+		if err != nil {
+			log.Error().Err(err).Int("sequence", audio.Sequence).Msg("重新生成字幕失败")
+			return fmt.Errorf("failed to generate subtitle for sequence %d: %w", audio.Sequence, err)
+		}
+		result.SubtitleIDs = append(result.SubtitleIDs, subtitleID)
+		result.RegeneratedSubtitles++
+	}
+	return nil
+}
+
+// markStaleShotVideos 把最新视频版本中使用了过期音频片段的镜头视频标记为过期
+// 镜头视频按镜头ID对应，合并片段按覆盖的序号范围对应；有镜头视频过期时，同一版本拼接出的最终视频也标记为过期
+func (s *novelService) markStaleShotVideos(ctx context.Context, chapterID string, stale []*novel.Audio, audioVersion int) ([]string, error) {
+	ids := make([]string, 0)
+	if len(stale) == 0 {
+		return ids, nil
+	}
+	versions, err := s.videoRepo.FindVersionsByChapterID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find video versions: %w", err)
+	}
+	videoVersion, err := maxInt(versions)
+	if err != nil {
+		// 章节还没有视频
+		return ids, nil
+	}
+	videos, err := s.videoRepo.FindByChapterIDAndVersion(ctx, chapterID, videoVersion)
+	if err != nil {
+		return nil, fmt.Errorf("find videos: %w", err)
+	}
+
+	staleShots := make(map[string]bool, len(stale))
+	for _, a := range stale {
+		if a.ShotID != "" {
+			staleShots[a.ShotID] = true
+		}
+	}
+	covers := func(v *novel.Video) bool {
+		end := max(v.Sequence, v.SequenceEnd)
+		for _, a := range stale {
+			if a.Sequence >= v.Sequence && a.Sequence <= end {
+				return true
+			}
+		}
+		return false
+	}
+	var finals []string
+	for _, v := range videos {
+		switch {
+		case v.VideoType == novel.VideoTypeFinal:
+			finals = append(finals, v.ID)
+		case v.VideoType != novel.VideoTypeNarration:
+		case v.ShotID != "" && staleShots[v.ShotID], v.ShotID == "" && covers(v):
+			ids = append(ids, v.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return ids, nil
+	}
+	ids = append(ids, finals...)
+
+	reason := fmt.Sprintf("narration audio re-synthesized in audio version %d", audioVersion)
+	if err := s.videoRepo.MarkStale(ctx, ids, reason); err != nil {
+		return nil, fmt.Errorf("mark stale videos: %w", err)
+	}
+	return ids, nil
+}
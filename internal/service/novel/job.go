@@ -0,0 +1,496 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/errclass"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/killswitch"
+	novelrepo "lemon/internal/repository/novel"
+)
+
+var (
+	// ErrInvalidJob 任务请求不合法（类型不支持、缺少章节）
+	ErrInvalidJob = errors.New("invalid job")
+	// ErrJobFinished 任务已经结束，不能取消
+	ErrJobFinished = errors.New("job is already finished")
+	// ErrJobNotRetryable 任务不是失败或已取消状态，不能重试
+	ErrJobNotRetryable = errors.New("job is not retryable")
+)
+
+const (
+	defaultJobWorkers     = 4
+	defaultJobMaxAttempts = 3
+	// jobLease 执行租约时长，执行中的任务每 jobLeaseRenewInterval 续租一次；进程退出后租约过期的任务被重新领取
+	jobLease              = 2 * time.Minute
+	jobLeaseRenewInterval = 30 * time.Second
+	// jobPollInterval 队列为空时的轮询间隔（本实例提交任务时立即唤醒）
+	jobPollInterval = 5 * time.Second
+	// jobMaintenanceDelay 维护模式下任务推迟执行的时间（不计入执行次数）
+	jobMaintenanceDelay = time.Minute
+	// jobRetryBaseDelay/jobRetryMaxDelay 失败重试的退避时间：每次翻倍，不超过上限
+	jobRetryBaseDelay = 30 * time.Second
+	jobRetryMaxDelay  = 10 * time.Minute
+)
+
+// jobWorkersFromEnv 读取任务队列的工作协程数（JOB_WORKERS，默认 4；0 表示本实例不执行任务，只提交）
+func jobWorkersFromEnv() int {
+	if v, err := strconv.Atoi(os.Getenv("JOB_WORKERS")); err == nil && v >= 0 {
+		return v
+	}
+	return defaultJobWorkers
+}
+
+// jobMaxAttemptsFromEnv 读取任务最多执行次数（JOB_MAX_ATTEMPTS，含第一次，默认 3）
+func jobMaxAttemptsFromEnv() int {
+	if v, err := strconv.Atoi(os.Getenv("JOB_MAX_ATTEMPTS")); err == nil && v > 0 {
+		return v
+	}
+	return defaultJobMaxAttempts
+}
+
+// jobRetryDelay 第 attempt 次执行失败后等待多久重试
+func jobRetryDelay(attempt int) time.Duration {
+	delay := jobRetryBaseDelay
+	for i := 1; i < attempt && delay < jobRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, jobRetryMaxDelay)
+}
+
+// JobService 异步任务队列服务接口
+// 生成任务持久化到 jobs 集合，由工作协程池领取执行，进程重启后未完成的任务继续执行
+type JobService interface {
+	// EnqueueJob 提交生成任务
+	EnqueueJob(ctx context.Context, req *EnqueueJobRequest) (*novel.Job, error)
+
+	// GetJob 查询任务
+	GetJob(ctx context.Context, jobID string) (*novel.Job, error)
+
+	// ListJobs 按条件查询任务（按创建时间倒序）
+	ListJobs(ctx context.Context, req *ListJobsRequest) ([]*novel.Job, error)
+
+	// CancelJob 取消任务：排队中的任务直接取消，执行中的任务被中断
+	CancelJob(ctx context.Context, jobID string) (*novel.Job, error)
+
+	// RetryJob 重新执行失败或已取消的任务
+	RetryJob(ctx context.Context, jobID string) (*novel.Job, error)
+
+	// StartJobWorkers 启动工作协程池（ctx 取消时停止领取任务，执行中的任务放回队列）
+	StartJobWorkers(ctx context.Context)
+
+	// WaitJobWorkers 等待工作协程退出（StartJobWorkers 的 ctx 取消后调用），ctx 结束时不再等待并返回 ctx 的错误
+	WaitJobWorkers(ctx context.Context) error
+}
+
+// EnqueueJobRequest 提交任务请求
+type EnqueueJobRequest struct {
+	Type        novel.PipelineStage // 任务类型
+	ChapterID   string              // 章节ID（NarrationID 不为空时可省略）
+	NarrationID string              // 解说ID（音频、字幕、图片任务可选，为空时执行时使用章节当前的解说）
+	UserID      string
+}
+
+// ListJobsRequest 查询任务请求（字段为空表示不限制）
+type ListJobsRequest struct {
+	NovelID   string
+	ChapterID string
+	Type      novel.PipelineStage
+	Status    novel.JobStatus
+	Limit     int
+}
+
+// narrationScopedJobTypes 以解说为单位执行的任务类型
+var narrationScopedJobTypes = []novel.PipelineStage{
+	novel.PipelineStageAudio,
+	novel.PipelineStageSubtitle,
+	novel.PipelineStageImage,
+}
+
+// EnqueueJob 提交生成任务
+func (s *novelService) EnqueueJob(ctx context.Context, req *EnqueueJobRequest) (*novel.Job, error) {
+	job, err := s.newJob(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.submitJob(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// newJob 校验提交请求并构造任务（不写入队列）
+func (s *novelService) newJob(ctx context.Context, req *EnqueueJobRequest) (*novel.Job, error) {
+	if !slices.Contains(novel.AllPipelineStages, req.Type) {
+		return nil, fmt.Errorf("%w: unsupported type %q", ErrInvalidJob, req.Type)
+	}
+
+	chapterID := req.ChapterID
+	if req.NarrationID != "" {
+		if !slices.Contains(narrationScopedJobTypes, req.Type) {
+			return nil, fmt.Errorf("%w: narration_id is only supported for audio, subtitle and image jobs", ErrInvalidJob)
+		}
+		narration, err := s.narrationRepo.FindByID(ctx, req.NarrationID)
+		if err != nil {
+			return nil, fmt.Errorf("find narration: %w", err)
+		}
+		if chapterID != "" && chapterID != narration.ChapterID {
+			return nil, fmt.Errorf("%w: narration does not belong to chapter", ErrInvalidJob)
+		}
+		chapterID = narration.ChapterID
+	}
+	if chapterID == "" {
+		return nil, fmt.Errorf("%w: chapter_id is required", ErrInvalidJob)
+	}
+	chapter, err := s.chapterRepo.FindByID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+
+	job := &novel.Job{
+		ID:          id.New(),
+		Type:        req.Type,
+		NovelID:     chapter.NovelID,
+		ChapterID:   chapter.ID,
+		NarrationID: req.NarrationID,
+		TriggeredBy: req.UserID,
+		MaxAttempts: s.jobMaxAttempts,
+	}
+	return job, nil
+}
+
+// submitJob 把任务写入队列并唤醒工作协程
+func (s *novelService) submitJob(ctx context.Context, job *novel.Job) error {
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return fmt.Errorf("create job: %w", err)
+	}
+	s.wakeJobWorkers()
+
+	log.Info().
+		Str("job_id", job.ID).
+		Str("type", string(job.Type)).
+		Str("chapter_id", job.ChapterID).
+		Str("pipeline_run_id", job.PipelineRunID).
+		Msg("生成任务已提交")
+	return nil
+}
+
+// GetJob 查询任务
+func (s *novelService) GetJob(ctx context.Context, jobID string) (*novel.Job, error) {
+	return s.jobRepo.FindByID(ctx, jobID)
+}
+
+// ListJobs 按条件查询任务
+func (s *novelService) ListJobs(ctx context.Context, req *ListJobsRequest) ([]*novel.Job, error) {
+	if req.Type != "" && req.Type != novel.JobTypeNovelVideo && !slices.Contains(novel.AllPipelineStages, req.Type) {
+		return nil, fmt.Errorf("%w: unsupported type %q", ErrInvalidJob, req.Type)
+	}
+	return s.jobRepo.Find(ctx, novelrepo.JobFilter{
+		NovelID:   req.NovelID,
+		ChapterID: req.ChapterID,
+		Type:      req.Type,
+		Status:    req.Status,
+	}, req.Limit)
+}
+
+// CancelJob 取消任务
+// 执行中的任务在本实例上时立即中断，在其他实例上时由执行它的工作协程在下次续租时中断
+func (s *novelService) CancelJob(ctx context.Context, jobID string) (*novel.Job, error) {
+	job, err := s.jobRepo.RequestCancel(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	switch job.Status {
+	case novel.JobStatusRunning:
+		s.jobRuns.cancel(jobID)
+	case novel.JobStatusCanceled:
+		s.onJobFinished(ctx, job, novel.JobStatusCanceled, nil, "")
+	case novel.JobStatusSucceeded, novel.JobStatusFailed:
+		return job, fmt.Errorf("%w: status is %s", ErrJobFinished, job.Status)
+	}
+	return job, nil
+}
+
+// RetryJob 重新执行失败或已取消的任务（执行次数清零）
+func (s *novelService) RetryJob(ctx context.Context, jobID string) (*novel.Job, error) {
+	job, err := s.jobRepo.Retry(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != novel.JobStatusQueued {
+		return job, fmt.Errorf("%w: status is %s", ErrJobNotRetryable, job.Status)
+	}
+	s.wakeJobWorkers()
+	return job, nil
+}
+
+// StartJobWorkers 启动工作协程池，并定期上报本实例的节点心跳
+func (s *novelService) StartJobWorkers(ctx context.Context) {
+	if s.jobWorkers <= 0 {
+		return
+	}
+	s.startJobNodeHeartbeat(ctx)
+	for i := 0; i < s.jobWorkers; i++ {
+		workerID := fmt.Sprintf("%s-%d", s.jobNodeID, i)
+		s.jobWorkersDone.Add(1)
+		go func() {
+			defer s.jobWorkersDone.Done()
+			s.runJobWorker(ctx, workerID)
+		}()
+	}
+	log.Info().Str("node_id", s.jobNodeID).Int("workers", s.jobWorkers).Msg("任务队列工作协程已启动")
+}
+
+// WaitJobWorkers 等待工作协程退出：执行中的任务被中断并放回队列后才退出，之后可以安全地关闭数据库连接
+func (s *novelService) WaitJobWorkers(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.jobWorkersDone.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// wakeJobWorkers 唤醒一个空闲的工作协程（不阻塞）
+func (s *novelService) wakeJobWorkers() {
+	select {
+	case s.jobWake <- struct{}{}:
+	default:
+	}
+}
+
+// runJobWorker 循环领取并执行任务，队列为空或本实例已被隔离时等待唤醒或轮询
+func (s *novelService) runJobWorker(ctx context.Context, workerID string) {
+	for ctx.Err() == nil {
+		err := mongo.ErrNoDocuments
+		var job *novel.Job
+		if !s.jobCordoned.Load() {
+			job, err = s.jobRepo.Claim(ctx, s.jobNodeID, workerID, jobLease)
+		}
+		if err == nil {
+			s.runJob(ctx, workerID, job)
+			continue
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) && ctx.Err() == nil {
+			log.Error().Err(err).Str("worker_id", workerID).Msg("领取任务失败")
+		}
+		select {
+		case <-ctx.Done():
+		case <-s.jobWake:
+		case <-time.After(jobPollInterval):
+		}
+	}
+}
+
+// runJob 执行领取到的任务：执行期间定期续租，结束后按结果记录成功、放回队列重试或记录失败
+func (s *novelService) runJob(ctx context.Context, workerID string, job *novel.Job) {
+	bg := context.WithoutCancel(ctx)
+	if job.CancelRequested {
+		// 请求取消后执行它的进程退出，租约过期后被重新领取
+		updated, err := s.jobRepo.Finish(bg, job.ID, workerID, novel.JobStatusCanceled, nil, "", "")
+		if err != nil {
+			log.Error().Err(err).Str("job_id", job.ID).Msg("记录任务取消失败")
+		} else if updated {
+			s.onJobFinished(bg, job, novel.JobStatusCanceled, nil, "")
+		}
+		return
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	runCtx, endReap := s.procReaper.Begin(runCtx, job.ID)
+	defer endReap()
+	s.jobRuns.add(job.ID, cancel)
+	defer s.jobRuns.remove(job.ID)
+	stopLease := s.keepJobLease(runCtx, cancel, job.ID, workerID)
+
+	log.Info().
+		Str("job_id", job.ID).
+		Str("type", string(job.Type)).
+		Str("chapter_id", job.ChapterID).
+		Int("attempt", job.Attempts).
+		Msg("开始执行生成任务")
+	s.onPipelineJobStarted(bg, job)
+	resourceIDs, err := s.executeJob(runCtx, job)
+	stopLease()
+
+	s.finishJob(ctx, workerID, job, resourceIDs, err)
+}
+
+// keepJobLease 定期续租执行中的任务；任务被请求取消、请求迁移或租约已被其他工作协程接管时中断执行
+// 返回停止续租的函数
+func (s *novelService) keepJobLease(ctx context.Context, cancel context.CancelFunc, jobID, workerID string) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(jobLeaseRenewInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			job, err := s.jobRepo.RenewLease(ctx, jobID, workerID, jobLease)
+			switch {
+			case errors.Is(err, mongo.ErrNoDocuments):
+				log.Warn().Str("job_id", jobID).Str("worker_id", workerID).Msg("任务租约已失效，中断执行")
+				cancel()
+				return
+			case err != nil:
+				log.Warn().Err(err).Str("job_id", jobID).Msg("任务续租失败")
+			case job.CancelRequested, job.RequeueRequested:
+				cancel()
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// finishJob 记录任务执行结果
+func (s *novelService) finishJob(ctx context.Context, workerID string, job *novel.Job, resourceIDs []string, err error) {
+	bg := context.WithoutCancel(ctx)
+	status := novel.JobStatusSucceeded
+	var updated bool
+	var updateErr error
+	var current *novel.Job
+	if err != nil {
+		current = s.currentJob(bg, job.ID)
+	}
+	switch {
+	case err == nil:
+		updated, updateErr = s.jobRepo.Finish(bg, job.ID, workerID, novel.JobStatusSucceeded, resourceIDs, "", "")
+	case current != nil && current.CancelRequested:
+		status = novel.JobStatusCanceled
+		updated, updateErr = s.jobRepo.Finish(bg, job.ID, workerID, novel.JobStatusCanceled, resourceIDs, err.Error(), "")
+	case current != nil && current.RequeueRequested:
+		// 节点排空：立即放回队列，由其他节点的工作协程重新执行
+		status = novel.JobStatusQueued
+		updated, updateErr = s.jobRepo.Requeue(bg, job.ID, workerID, time.Now(), true, err.Error(), "")
+	case ctx.Err() != nil:
+		// 服务停止：放回队列，由重启后的工作协程重新执行
+		status = novel.JobStatusQueued
+		updated, updateErr = s.jobRepo.Requeue(bg, job.ID, workerID, time.Now(), true, err.Error(), "")
+	case errors.Is(err, killswitch.ErrMaintenance):
+		status = novel.JobStatusQueued
+		updated, updateErr = s.jobRepo.Requeue(bg, job.ID, workerID, time.Now().Add(jobMaintenanceDelay), true, err.Error(), "")
+	default:
+		class, _ := errclass.Classify(err)
+		if job.Attempts < job.MaxAttempts {
+			status = novel.JobStatusQueued
+			updated, updateErr = s.jobRepo.Requeue(bg, job.ID, workerID, time.Now().Add(jobRetryDelay(job.Attempts)), false, err.Error(), string(class))
+		} else {
+			status = novel.JobStatusFailed
+			updated, updateErr = s.jobRepo.Finish(bg, job.ID, workerID, novel.JobStatusFailed, resourceIDs, err.Error(), string(class))
+		}
+	}
+	if updateErr != nil {
+		log.Error().Err(updateErr).Str("job_id", job.ID).Msg("记录任务结果失败")
+		return
+	}
+	if !updated {
+		log.Warn().Str("job_id", job.ID).Str("worker_id", workerID).Msg("任务已由其他工作协程接管，忽略本次结果")
+		return
+	}
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	}
+	s.onJobFinished(bg, job, status, resourceIDs, errMsg)
+
+	event := log.Info()
+	if err != nil && status != novel.JobStatusCanceled {
+		event = log.Warn().Err(err)
+	}
+	event.
+		Str("job_id", job.ID).
+		Str("type", string(job.Type)).
+		Str("chapter_id", job.ChapterID).
+		Int("attempt", job.Attempts).
+		Str("status", string(status)).
+		Msg("生成任务执行结束")
+}
+
+// onJobFinished 任务执行结束（或放回队列等待重试）时更新任务关联的记录：长视频拼接任务更新视频记录，其他任务更新所属的一键生成流程
+func (s *novelService) onJobFinished(ctx context.Context, job *novel.Job, status novel.JobStatus, resourceIDs []string, errMsg string) {
+	if job.Type == novel.JobTypeNovelVideo {
+		s.onNovelVideoJobFinished(ctx, job, status, errMsg)
+		return
+	}
+	s.onPipelineJobFinished(ctx, job, status, resourceIDs, errMsg)
+}
+
+// currentJob 查询任务的最新状态（是否已请求取消或迁移），查询失败时返回 nil
+func (s *novelService) currentJob(ctx context.Context, jobID string) *novel.Job {
+	job, err := s.jobRepo.FindByID(ctx, jobID)
+	if err != nil {
+		log.Warn().Err(err).Str("job_id", jobID).Msg("查询任务状态失败")
+		return nil
+	}
+	return job
+}
+
+// executeJob 按任务类型调用对应的生成流程，返回生成的产物ID
+func (s *novelService) executeJob(ctx context.Context, job *novel.Job) ([]string, error) {
+	switch job.Type {
+	case novel.PipelineStageNarration:
+		narration, _, err := s.GenerateNarrationForChapterWithMeta(ctx, job.ChapterID)
+		if narration == nil {
+			return nil, err
+		}
+		return []string{narration.ID}, err
+	case novel.PipelineStageAudio, novel.PipelineStageSubtitle, novel.PipelineStageImage:
+		narrationID, err := s.jobNarrationID(ctx, job)
+		if err != nil {
+			return nil, err
+		}
+		switch job.Type {
+		case novel.PipelineStageAudio:
+			return s.GenerateAudiosForNarration(ctx, narrationID)
+		case novel.PipelineStageSubtitle:
+			return s.GenerateSubtitlesForNarration(ctx, narrationID)
+		default:
+			return s.GenerateImagesForNarration(ctx, narrationID)
+		}
+	case novel.PipelineStageNarrationVideo:
+		return s.GenerateNarrationVideosForChapter(ctx, job.ChapterID)
+	case novel.PipelineStageFinalVideo:
+		videoID, err := s.GenerateFinalVideoForChapter(ctx, job.ChapterID)
+		if videoID == "" {
+			return nil, err
+		}
+		return []string{videoID}, err
+	case novel.JobTypeNovelVideo:
+		return s.runNovelVideoJob(ctx, job)
+	}
+	return nil, fmt.Errorf("%w: unsupported type %q", ErrInvalidJob, job.Type)
+}
+
+// jobNarrationID 返回任务使用的解说ID：提交时未指定时使用章节当前的解说
+func (s *novelService) jobNarrationID(ctx context.Context, job *novel.Job) (string, error) {
+	if job.NarrationID != "" {
+		return job.NarrationID, nil
+	}
+	narration, err := s.narrationRepo.FindByChapterID(ctx, job.ChapterID)
+	if err != nil {
+		return "", fmt.Errorf("find narration: %w", err)
+	}
+	return narration.ID, nil
+}
@@ -0,0 +1,390 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	novelrepo "lemon/internal/repository/novel"
+)
+
+// memJobRepo 内存中的任务队列仓库，按 JobRepo 的语义实现工作协程用到的方法
+type memJobRepo struct {
+	novelrepo.JobRepository
+	mu   sync.Mutex
+	jobs map[string]*novel.Job
+}
+
+func newMemJobRepo(jobs ...*novel.Job) *memJobRepo {
+	r := &memJobRepo{jobs: make(map[string]*novel.Job)}
+	for _, job := range jobs {
+		job.Status = novel.JobStatusQueued
+		r.jobs[job.ID] = job
+	}
+	return r
+}
+
+func (r *memJobRepo) get(id string) novel.Job {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.jobs[id]
+}
+
+func (r *memJobRepo) Create(_ context.Context, job *novel.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job.Status = novel.JobStatusQueued
+	stored := *job
+	r.jobs[job.ID] = &stored
+	return nil
+}
+
+func (r *memJobRepo) FindByID(_ context.Context, id string) (*novel.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (r *memJobRepo) Claim(_ context.Context, nodeID, workerID string, lease time.Duration) (*novel.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, job := range r.jobs {
+		queued := job.Status == novel.JobStatusQueued && !job.RunAfter.After(now)
+		expired := job.Status == novel.JobStatusRunning && job.LeaseUntil != nil && job.LeaseUntil.Before(now)
+		if !queued && !expired {
+			continue
+		}
+		leaseUntil := now.Add(lease)
+		job.Status = novel.JobStatusRunning
+		job.NodeID, job.WorkerID = nodeID, workerID
+		job.LeaseUntil = &leaseUntil
+		job.Attempts++
+		copied := *job
+		return &copied, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// running 返回由 workerID 执行中的任务，没有时返回 nil
+func (r *memJobRepo) running(id, workerID string) *novel.Job {
+	job, ok := r.jobs[id]
+	if !ok || job.WorkerID != workerID || job.Status != novel.JobStatusRunning {
+		return nil
+	}
+	return job
+}
+
+func (r *memJobRepo) RenewLease(_ context.Context, id, workerID string, lease time.Duration) (*novel.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job := r.running(id, workerID)
+	if job == nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	leaseUntil := time.Now().Add(lease)
+	job.LeaseUntil = &leaseUntil
+	copied := *job
+	return &copied, nil
+}
+
+func (r *memJobRepo) Finish(_ context.Context, id, workerID string, status novel.JobStatus, resourceIDs []string, errMsg, errClass string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job := r.running(id, workerID)
+	if job == nil {
+		return false, nil
+	}
+	job.Status, job.ResourceIDs, job.ErrorMessage, job.ErrorClass = status, resourceIDs, errMsg, errClass
+	job.LeaseUntil = nil
+	return true, nil
+}
+
+func (r *memJobRepo) Requeue(_ context.Context, id, workerID string, runAfter time.Time, refundAttempt bool, errMsg, errClass string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job := r.running(id, workerID)
+	if job == nil || job.CancelRequested {
+		return false, nil
+	}
+	job.Status, job.RunAfter, job.ErrorMessage, job.ErrorClass = novel.JobStatusQueued, runAfter, errMsg, errClass
+	job.NodeID, job.WorkerID, job.LeaseUntil = "", "", nil
+	if refundAttempt {
+		job.Attempts--
+	}
+	return true, nil
+}
+
+func (r *memJobRepo) RequestCancel(_ context.Context, id string) (*novel.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	switch job.Status {
+	case novel.JobStatusQueued:
+		job.Status = novel.JobStatusCanceled
+		job.CancelRequested = true
+	case novel.JobStatusRunning:
+		job.CancelRequested = true
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (r *memJobRepo) Retry(_ context.Context, id string) (*novel.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	if job.Status == novel.JobStatusFailed || job.Status == novel.JobStatusCanceled {
+		job.Status, job.Attempts, job.CancelRequested = novel.JobStatusQueued, 0, false
+		job.RunAfter = time.Now()
+		job.ErrorMessage, job.ErrorClass = "", ""
+	}
+	copied := *job
+	return &copied, nil
+}
+
+// memJobWorkerRepo 只实现心跳上报的工作节点仓库（节点始终未隔离）
+type memJobWorkerRepo struct {
+	novelrepo.JobWorkerRepository
+}
+
+func (memJobWorkerRepo) Heartbeat(_ context.Context, worker *novel.JobWorker) (*novel.JobWorker, error) {
+	copied := *worker
+	return &copied, nil
+}
+
+// blockingNarrationRepo 查询章节当前解说时通知 started，err 为 nil 时阻塞到 ctx 取消（模拟执行中的任务）
+type blockingNarrationRepo struct {
+	novelrepo.NarrationRepository
+	started chan string
+	err     error
+}
+
+func (r *blockingNarrationRepo) FindByChapterID(ctx context.Context, chapterID string) (*novel.Narration, error) {
+	select {
+	case r.started <- chapterID:
+	default:
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// newJobTestService 创建只包含任务队列依赖的小说服务
+func newJobTestService(jobs *memJobRepo, narrations *blockingNarrationRepo) *novelService {
+	return &novelService{
+		jobRepo:        jobs,
+		jobWorkerRepo:  memJobWorkerRepo{},
+		narrationRepo:  narrations,
+		jobWorkers:     1,
+		jobMaxAttempts: defaultJobMaxAttempts,
+		jobRuns:        newRunCancels(),
+		jobWake:        make(chan struct{}, 1),
+		jobNodeID:      "node-1",
+	}
+}
+
+// newAudioJob 创建执行时需要查询章节当前解说的音频任务
+func newAudioJob(id string, attempts, maxAttempts int) *novel.Job {
+	return &novel.Job{
+		ID:          id,
+		Type:        novel.PipelineStageAudio,
+		ChapterID:   "chapter-" + id,
+		Attempts:    attempts,
+		MaxAttempts: maxAttempts,
+	}
+}
+
+// waitStarted 等待任务开始执行
+func waitStarted(t *testing.T, narrations *blockingNarrationRepo) {
+	t.Helper()
+	select {
+	case <-narrations.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job did not start")
+	}
+}
+
+func TestRunJobRetriesWithBackoffThenFails(t *testing.T) {
+	jobs := newMemJobRepo(newAudioJob("job-1", 0, 2))
+	narrations := &blockingNarrationRepo{started: make(chan string, 1), err: errors.New("provider unavailable")}
+	s := newJobTestService(jobs, narrations)
+	ctx := context.Background()
+
+	job, err := jobs.Claim(ctx, s.jobNodeID, "node-1-0", jobLease)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now()
+	s.runJob(ctx, "node-1-0", job)
+
+	got := jobs.get("job-1")
+	if got.Status != novel.JobStatusQueued || got.Attempts != 1 {
+		t.Fatalf("after first failure: status = %s, attempts = %d, want queued/1", got.Status, got.Attempts)
+	}
+	if got.RunAfter.Before(before.Add(jobRetryDelay(1))) {
+		t.Errorf("run_after = %v, want retry after %v", got.RunAfter, jobRetryDelay(1))
+	}
+	if got.ErrorMessage == "" {
+		t.Error("expected error message to be recorded")
+	}
+
+	// 到达重试时间后再次执行，达到最多执行次数后记录失败
+	jobs.mu.Lock()
+	jobs.jobs["job-1"].RunAfter = time.Now()
+	jobs.mu.Unlock()
+	job, err = jobs.Claim(ctx, s.jobNodeID, "node-1-0", jobLease)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.runJob(ctx, "node-1-0", job)
+
+	got = jobs.get("job-1")
+	if got.Status != novel.JobStatusFailed || got.Attempts != 2 {
+		t.Fatalf("after last failure: status = %s, attempts = %d, want failed/2", got.Status, got.Attempts)
+	}
+	if _, err := s.CancelJob(ctx, "job-1"); !errors.Is(err, ErrJobFinished) {
+		t.Errorf("cancel failed job: err = %v, want ErrJobFinished", err)
+	}
+
+	// 重试后执行次数清零，重新排队
+	retried, err := s.RetryJob(ctx, "job-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retried.Status != novel.JobStatusQueued || retried.Attempts != 0 {
+		t.Errorf("after retry: status = %s, attempts = %d, want queued/0", retried.Status, retried.Attempts)
+	}
+
+	// 执行中的任务不能重试
+	if _, err := jobs.Claim(ctx, s.jobNodeID, "node-1-0", jobLease); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RetryJob(ctx, "job-1"); !errors.Is(err, ErrJobNotRetryable) {
+		t.Errorf("retry running job: err = %v, want ErrJobNotRetryable", err)
+	}
+}
+
+func TestCancelRunningJob(t *testing.T) {
+	jobs := newMemJobRepo(newAudioJob("job-1", 0, 3))
+	narrations := &blockingNarrationRepo{started: make(chan string, 1)}
+	s := newJobTestService(jobs, narrations)
+	ctx := context.Background()
+
+	job, err := jobs.Claim(ctx, s.jobNodeID, "node-1-0", jobLease)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.runJob(ctx, "node-1-0", job)
+	}()
+	waitStarted(t, narrations)
+
+	canceled, err := s.CancelJob(ctx, "job-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !canceled.CancelRequested {
+		t.Error("expected cancel to be requested")
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("running job was not interrupted")
+	}
+
+	got := jobs.get("job-1")
+	if got.Status != novel.JobStatusCanceled {
+		t.Fatalf("status = %s, want canceled", got.Status)
+	}
+}
+
+func TestCancelQueuedJob(t *testing.T) {
+	jobs := newMemJobRepo(newAudioJob("job-1", 0, 3))
+	s := newJobTestService(jobs, &blockingNarrationRepo{started: make(chan string, 1)})
+
+	canceled, err := s.CancelJob(context.Background(), "job-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if canceled.Status != novel.JobStatusCanceled {
+		t.Fatalf("status = %s, want canceled", canceled.Status)
+	}
+	if _, err := jobs.Claim(context.Background(), s.jobNodeID, "node-1-0", jobLease); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Errorf("claim after cancel: err = %v, want ErrNoDocuments", err)
+	}
+}
+
+func TestRunJobLeaseExpiredJobReclaimed(t *testing.T) {
+	jobs := newMemJobRepo(newAudioJob("job-1", 0, 3))
+	narrations := &blockingNarrationRepo{started: make(chan string, 1), err: errors.New("provider unavailable")}
+	s := newJobTestService(jobs, narrations)
+	ctx := context.Background()
+
+	// 执行它的进程退出，租约过期后被其他工作协程领取
+	if _, err := jobs.Claim(ctx, "node-0", "node-0-0", -time.Second); err != nil {
+		t.Fatal(err)
+	}
+	job, err := jobs.Claim(ctx, s.jobNodeID, "node-1-0", jobLease)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Attempts != 2 {
+		t.Fatalf("attempts = %d, want 2", job.Attempts)
+	}
+
+	// 原工作协程的结果被忽略
+	if updated, _ := jobs.Finish(ctx, "job-1", "node-0-0", novel.JobStatusSucceeded, nil, "", ""); updated {
+		t.Fatal("expected stale worker result to be ignored")
+	}
+	s.runJob(ctx, "node-1-0", job)
+	if got := jobs.get("job-1"); got.Status != novel.JobStatusQueued || got.WorkerID != "" {
+		t.Errorf("status = %s, worker = %q, want queued without worker", got.Status, got.WorkerID)
+	}
+}
+
+func TestStopJobWorkersRequeuesRunningJob(t *testing.T) {
+	jobs := newMemJobRepo(newAudioJob("job-1", 0, 3))
+	narrations := &blockingNarrationRepo{started: make(chan string, 1)}
+	s := newJobTestService(jobs, narrations)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.StartJobWorkers(ctx)
+	waitStarted(t, narrations)
+	if got := jobs.get("job-1"); got.Status != novel.JobStatusRunning || got.Attempts != 1 {
+		t.Fatalf("status = %s, attempts = %d, want running/1", got.Status, got.Attempts)
+	}
+
+	cancel()
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer waitCancel()
+	if err := s.WaitJobWorkers(waitCtx); err != nil {
+		t.Fatalf("wait job workers: %v", err)
+	}
+
+	// 服务停止时执行中的任务立即放回队列，不计入执行次数
+	got := jobs.get("job-1")
+	if got.Status != novel.JobStatusQueued || got.Attempts != 0 || got.LeaseUntil != nil {
+		t.Errorf("status = %s, attempts = %d, lease = %v, want queued/0 without lease", got.Status, got.Attempts, got.LeaseUntil)
+	}
+}
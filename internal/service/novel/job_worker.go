@@ -0,0 +1,143 @@
+package novel
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+)
+
+// jobNodeHeartbeatInterval 工作节点上报心跳的间隔；超过 jobLease 未上报心跳的节点视为已失联
+const jobNodeHeartbeatInterval = 30 * time.Second
+
+// JobWorkerService 任务队列工作节点管理接口
+// 维护节点前先隔离（cordon）节点：节点不再领取新任务，执行中的任务可迁移到其他节点
+type JobWorkerService interface {
+	// ListJobWorkers 查询所有工作节点（含心跳状态和执行中的任务数）
+	ListJobWorkers(ctx context.Context) ([]*novel.JobWorker, error)
+
+	// CordonJobWorker 隔离工作节点；requeueRunning 为 true 时把节点上执行中的任务放回队列，由其他节点重新执行
+	// 返回隔离后的节点和放回队列的任务数
+	CordonJobWorker(ctx context.Context, nodeID, reason string, requeueRunning bool) (*novel.JobWorker, int64, error)
+
+	// UncordonJobWorker 解除隔离，节点恢复领取任务
+	UncordonJobWorker(ctx context.Context, nodeID string) (*novel.JobWorker, error)
+}
+
+// jobNodeIDOfProcess 本进程的节点ID（主机名-进程号）
+func jobNodeIDOfProcess() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// ListJobWorkers 查询所有工作节点
+func (s *novelService) ListJobWorkers(ctx context.Context) ([]*novel.JobWorker, error) {
+	workers, err := s.jobWorkerRepo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("find job workers: %w", err)
+	}
+	running, err := s.jobRepo.CountRunningByNode(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("count running jobs: %w", err)
+	}
+	now := time.Now()
+	for _, w := range workers {
+		w.RunningJobs = running[w.ID]
+		w.Alive = jobWorkerAlive(w, now)
+	}
+	return workers, nil
+}
+
+// CordonJobWorker 隔离工作节点
+// 节点在线时由节点上的工作协程在下次续租时中断任务并放回队列（最迟 30 秒）；节点已失联时直接放回队列，不必等待租约过期
+func (s *novelService) CordonJobWorker(ctx context.Context, nodeID, reason string, requeueRunning bool) (*novel.JobWorker, int64, error) {
+	worker, err := s.jobWorkerRepo.SetCordon(ctx, nodeID, true, reason)
+	if err != nil {
+		return nil, 0, err
+	}
+	if nodeID == s.jobNodeID {
+		s.jobCordoned.Store(true)
+	}
+
+	var requeued int64
+	if requeueRunning {
+		if jobWorkerAlive(worker, time.Now()) {
+			requeued, err = s.jobRepo.RequestRequeueByNode(ctx, nodeID)
+		} else {
+			requeued, err = s.jobRepo.ReleaseByNode(ctx, nodeID)
+			s.wakeJobWorkers()
+		}
+		if err != nil {
+			return worker, 0, fmt.Errorf("requeue running jobs: %w", err)
+		}
+	}
+
+	log.Info().
+		Str("node_id", nodeID).
+		Str("reason", reason).
+		Bool("requeue_running", requeueRunning).
+		Int64("requeued", requeued).
+		Msg("任务队列工作节点已隔离")
+	return worker, requeued, nil
+}
+
+// UncordonJobWorker 解除隔离
+func (s *novelService) UncordonJobWorker(ctx context.Context, nodeID string) (*novel.JobWorker, error) {
+	worker, err := s.jobWorkerRepo.SetCordon(ctx, nodeID, false, "")
+	if err != nil {
+		return nil, err
+	}
+	if nodeID == s.jobNodeID {
+		s.jobCordoned.Store(false)
+		s.wakeJobWorkers()
+	}
+	log.Info().Str("node_id", nodeID).Msg("任务队列工作节点已解除隔离")
+	return worker, nil
+}
+
+// startJobNodeHeartbeat 定期上报本实例的节点心跳，并同步隔离状态（其他实例上执行的隔离操作在下次心跳时生效）
+func (s *novelService) startJobNodeHeartbeat(ctx context.Context) {
+	host, _ := os.Hostname()
+	node := &novel.JobWorker{
+		ID:          s.jobNodeID,
+		Host:        host,
+		Concurrency: s.jobWorkers,
+		StartedAt:   time.Now(),
+	}
+	s.jobNodeHeartbeat(ctx, node)
+	go func() {
+		ticker := time.NewTicker(jobNodeHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.jobNodeHeartbeat(ctx, node)
+			}
+		}
+	}()
+}
+
+// jobNodeHeartbeat 上报一次心跳；上报失败时保持当前隔离状态
+func (s *novelService) jobNodeHeartbeat(ctx context.Context, node *novel.JobWorker) {
+	updated, err := s.jobWorkerRepo.Heartbeat(ctx, node)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Warn().Err(err).Str("node_id", node.ID).Msg("上报任务队列节点心跳失败")
+		}
+		return
+	}
+	if was := s.jobCordoned.Swap(updated.Cordoned); was && !updated.Cordoned {
+		s.wakeJobWorkers()
+	}
+}
+
+// jobWorkerAlive 节点心跳是否在有效期内
+func jobWorkerAlive(w *novel.JobWorker, now time.Time) bool {
+	return now.Sub(w.HeartbeatAt) < jobLease
+}
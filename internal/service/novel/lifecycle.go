@@ -0,0 +1,317 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/noveltools"
+	"lemon/internal/pkg/resourceref"
+	"lemon/internal/service"
+)
+
+var (
+	// ErrArtifactPromoted 筛选出的产物包含章节的发布版本，不能删除
+	ErrArtifactPromoted = errors.New("不能删除章节发布版本的产物")
+	// ErrEmptyArtifactSelection 删除产物时没有指定任何筛选条件
+	ErrEmptyArtifactSelection = errors.New("删除产物时至少需要指定类型、状态或版本")
+)
+
+// LifecycleService 小说、章节和生成产物的删除服务接口
+// 删除都是软删除（记录保留 deleted_at，查询接口不再返回）；purge 为 true 时另外删除存储中的文件，
+// 文件仍被小说中其他未删除的记录使用时保留（例如分支章节复制的产物、增量合成复用的音频、合集使用的视频），
+// 发布版本的视频和已公证的最终视频引用的文件同样保留
+type LifecycleService interface {
+	// DeleteNovel 删除小说，级联删除章节（包括分支）、解说、场景、镜头、音频、字幕、图片、视频、角色、道具、封面、风格参考图和发音词典
+	DeleteNovel(ctx context.Context, novelID string, purge bool) (*DeleteResult, error)
+
+	// DeleteChapter 删除章节，级联删除章节的分支以及它们的解说、场景、镜头、音频、字幕、图片、视频和章节级风格参考图
+	DeleteChapter(ctx context.Context, chapterID string, purge bool) (*DeleteResult, error)
+
+	// DeleteChapterArtifacts 删除章节中按类型、状态、版本筛选出的生成产物（音频、字幕、图片、视频），不能删除发布版本
+	DeleteChapterArtifacts(ctx context.Context, chapterID string, filter *noveltools.ArtifactFilter, purge bool) (*DeleteResult, error)
+}
+
+// DeleteResult 删除结果
+type DeleteResult struct {
+	NovelID        string           `json:"novel_id"`
+	ChapterIDs     []string         `json:"chapter_ids,omitempty"`  // 删除的章节（包括分支，仅删除小说或章节时返回）
+	Deleted        map[string]int64 `json:"deleted"`                // 各集合软删除的记录数
+	Resources      int              `json:"resources"`              // 被删除的记录引用的资源数
+	Purge          bool             `json:"purge"`                  // 是否删除存储中的文件
+	PurgeResources int              `json:"purge_resources"`        // 删除的资源数（仍被其他记录使用的资源不删除）
+	PurgeFailed    int              `json:"purge_failed,omitempty"` // 删除失败的资源数（之后由资源回收任务处理）
+	Protected      int              `json:"protected,omitempty"`    // 发布版本或已公证的视频引用、清理时保留的资源数
+}
+
+// DeleteNovel 删除小说
+func (s *novelService) DeleteNovel(ctx context.Context, novelID string, purge bool) (*DeleteResult, error) {
+	n, err := s.novelRepo.FindByID(ctx, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+	mainChapters, err := s.chapterRepo.FindByNovelID(ctx, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapters: %w", err)
+	}
+	var chapters []*novel.Chapter
+	for _, ch := range mainChapters {
+		withBranches, err := s.chapterWithBranches(ctx, ch)
+		if err != nil {
+			return nil, err
+		}
+		chapters = append(chapters, withBranches...)
+	}
+	protected, err := s.protectedResourceIDs(ctx, chapters, purge)
+	if err != nil {
+		return nil, err
+	}
+
+	deletedAt := time.Now()
+	result := &DeleteResult{NovelID: n.ID, ChapterIDs: chapterIDs(chapters), Deleted: make(map[string]int64), Purge: purge}
+
+	// 先删除小说本身，删除过程中失败时小说已不可见，重新删除会跳过已删除的记录
+	count, resourceIDs, err := s.lifecycleRepo.SoftDeleteByIDs(ctx, n.Collection(), []string{n.ID}, deletedAt)
+	if err != nil {
+		return nil, fmt.Errorf("delete novel: %w", err)
+	}
+	result.Deleted[n.Collection()] = count
+
+	deleted, cascaded, err := s.lifecycleRepo.CascadeSoftDelete(ctx, "novel_id", []string{n.ID}, deletedAt)
+	if err != nil {
+		return nil, fmt.Errorf("delete novel content: %w", err)
+	}
+	for collection, count := range deleted {
+		result.Deleted[collection] = count
+	}
+	resourceIDs = append(resourceIDs, cascaded...)
+
+	log.Info().Str("novel_id", n.ID).Int("chapters", len(chapters)).Bool("purge", purge).Msg("小说已删除")
+	return s.finishDelete(ctx, result, resourceIDs, protected)
+}
+
+// DeleteChapter 删除章节（包括分支）
+func (s *novelService) DeleteChapter(ctx context.Context, chapterID string, purge bool) (*DeleteResult, error) {
+	ch, err := s.chapterRepo.FindByID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+	chapters, err := s.chapterWithBranches(ctx, ch)
+	if err != nil {
+		return nil, err
+	}
+	protected, err := s.protectedResourceIDs(ctx, chapters, purge)
+	if err != nil {
+		return nil, err
+	}
+
+	deletedAt := time.Now()
+	ids := chapterIDs(chapters)
+	result := &DeleteResult{NovelID: ch.NovelID, ChapterIDs: ids, Deleted: make(map[string]int64), Purge: purge}
+	count, resourceIDs, err := s.lifecycleRepo.SoftDeleteByIDs(ctx, ch.Collection(), ids, deletedAt)
+	if err != nil {
+		return nil, fmt.Errorf("delete chapter: %w", err)
+	}
+	result.Deleted[ch.Collection()] = count
+
+	deleted, cascaded, err := s.lifecycleRepo.CascadeSoftDelete(ctx, "chapter_id", ids, deletedAt)
+	if err != nil {
+		return nil, fmt.Errorf("delete chapter content: %w", err)
+	}
+	for collection, count := range deleted {
+		result.Deleted[collection] = count
+	}
+	resourceIDs = append(resourceIDs, cascaded...)
+
+	log.Info().Str("chapter_id", ch.ID).Strs("chapter_ids", ids).Bool("purge", purge).Msg("章节已删除")
+	return s.finishDelete(ctx, result, resourceIDs, protected)
+}
+
+// chapterWithBranches 返回章节及其所有分支（包括分支的分支）
+func (s *novelService) chapterWithBranches(ctx context.Context, ch *novel.Chapter) ([]*novel.Chapter, error) {
+	chapters := []*novel.Chapter{ch}
+	for i := 0; i < len(chapters); i++ {
+		branches, err := s.chapterRepo.FindBranches(ctx, chapters[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("find branches of chapter %s: %w", chapters[i].ID, err)
+		}
+		chapters = append(chapters, branches...)
+	}
+	return chapters, nil
+}
+
+// chapterIDs 返回章节ID列表
+func chapterIDs(chapters []*novel.Chapter) []string {
+	ids := make([]string, 0, len(chapters))
+	for _, ch := range chapters {
+		ids = append(ids, ch.ID)
+	}
+	return ids
+}
+
+// protectedResourceIDs 收集章节发布版本的视频（包括同一版本的镜头视频）和已公证的最终视频引用的资源，清理存储时保留这些文件
+// 需要在软删除之前查询（删除后视频记录不再返回）；purge 为 false 时不查询
+func (s *novelService) protectedResourceIDs(ctx context.Context, chapters []*novel.Chapter, purge bool) (map[string]bool, error) {
+	refs := make(map[string]bool)
+	if !purge {
+		return refs, nil
+	}
+	for _, ch := range chapters {
+		var videos []*novel.Video
+		if ch.PromotedVersions != nil && ch.PromotedVersions.Video > 0 {
+			promoted, err := s.videoRepo.FindByChapterIDAndVersion(ctx, ch.ID, ch.PromotedVersions.Video)
+			if err != nil {
+				return nil, fmt.Errorf("find promoted videos of chapter %s: %w", ch.ID, err)
+			}
+			videos = append(videos, promoted...)
+		}
+		records, err := s.notarizationRepo.FindByChapterID(ctx, ch.ID)
+		if err != nil {
+			return nil, fmt.Errorf("find notarization records of chapter %s: %w", ch.ID, err)
+		}
+		for _, record := range records {
+			v, err := s.videoRepo.FindByID(ctx, record.VideoID)
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("find notarized video %s: %w", record.VideoID, err)
+			}
+			videos = append(videos, v)
+		}
+
+		for _, v := range videos {
+			raw, err := bson.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("marshal video %s: %w", v.ID, err)
+			}
+			if err := resourceref.Collect(raw, func(id string) { refs[id] = true }); err != nil {
+				return nil, fmt.Errorf("collect resources of video %s: %w", v.ID, err)
+			}
+		}
+	}
+	return refs, nil
+}
+
+// DeleteChapterArtifacts 删除章节中筛选出的生成产物
+func (s *novelService) DeleteChapterArtifacts(ctx context.Context, chapterID string, filter *noveltools.ArtifactFilter, purge bool) (*DeleteResult, error) {
+	if filter == nil || (len(filter.Types) == 0 && len(filter.Statuses) == 0 && filter.Version == 0 && !filter.Latest) {
+		return nil, ErrEmptyArtifactSelection
+	}
+	ch, err := s.chapterRepo.FindByID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+	var promoted novel.PromotedVersions
+	if ch.PromotedVersions != nil {
+		promoted = *ch.PromotedVersions
+	}
+	artifacts, err := s.ListChapterArtifacts(ctx, chapterID, filter)
+	if err != nil {
+		return nil, err
+	}
+	// 已回滚的发布版本可能已经公证，同样保留文件
+	protected, err := s.protectedResourceIDs(ctx, []*novel.Chapter{ch}, purge)
+	if err != nil {
+		return nil, err
+	}
+
+	// 发布版本（视频包括同一版本的镜头视频）正在对外使用，需要先回滚或发布其他版本
+	ids := make(map[novel.VersionArtifact][]string)
+	for _, item := range artifacts.Items {
+		if item.Promoted || (item.Type == novel.VersionArtifactVideo && item.Version == promoted.Video) {
+			return nil, fmt.Errorf("%w: %s version %d", ErrArtifactPromoted, item.Type, item.Version)
+		}
+		ids[item.Type] = append(ids[item.Type], item.ID)
+	}
+
+	var (
+		audio    novel.Audio
+		subtitle novel.Subtitle
+		image    novel.Image
+		video    novel.Video
+	)
+	collections := map[novel.VersionArtifact]string{
+		novel.VersionArtifactAudio:    audio.Collection(),
+		novel.VersionArtifactSubtitle: subtitle.Collection(),
+		novel.VersionArtifactImage:    image.Collection(),
+		novel.VersionArtifactVideo:    video.Collection(),
+	}
+
+	deletedAt := time.Now()
+	result := &DeleteResult{NovelID: ch.NovelID, Deleted: make(map[string]int64), Purge: purge}
+	var resourceIDs []string
+	for _, artifact := range noveltools.ArtifactTypes {
+		if len(ids[artifact]) == 0 {
+			continue
+		}
+		collection := collections[artifact]
+		count, refs, err := s.lifecycleRepo.SoftDeleteByIDs(ctx, collection, ids[artifact], deletedAt)
+		if err != nil {
+			return nil, fmt.Errorf("delete %s artifacts: %w", artifact, err)
+		}
+		result.Deleted[collection] = count
+		resourceIDs = append(resourceIDs, refs...)
+	}
+
+	log.Info().Str("chapter_id", chapterID).Int("artifacts", len(artifacts.Items)).Bool("purge", purge).Msg("章节产物已删除")
+	return s.finishDelete(ctx, result, resourceIDs, protected)
+}
+
+// finishDelete 统计被删除记录引用的资源，purge 时删除不再被小说中其他记录使用、也不受保护的资源
+// 文件在请求内删除，删除失败的资源计入 PurgeFailed，由资源回收任务之后处理
+func (s *novelService) finishDelete(ctx context.Context, result *DeleteResult, resourceIDs []string, protected map[string]bool) (*DeleteResult, error) {
+	unique := make(map[string]bool, len(resourceIDs))
+	for _, id := range resourceIDs {
+		unique[id] = true
+	}
+	result.Resources = len(unique)
+	if !result.Purge || len(unique) == 0 {
+		return result, nil
+	}
+
+	live, err := s.lifecycleRepo.LiveResourceIDs(ctx, result.NovelID)
+	if err != nil {
+		// 记录已删除，只是无法确认哪些文件可以删除：不删除文件，资源回收任务之后会处理没有被引用的资源
+		log.Warn().Err(err).Str("novel_id", result.NovelID).Msg("查询仍在使用的资源失败，跳过删除存储文件")
+		return result, nil
+	}
+	var purge []string
+	for id := range unique {
+		switch {
+		case live[id]:
+		case protected[id]:
+			result.Protected++
+		default:
+			purge = append(purge, id)
+		}
+	}
+	slices.Sort(purge)
+	result.PurgeResources, result.PurgeFailed = s.purgeResources(ctx, result.NovelID, purge)
+	return result, nil
+}
+
+// purgeResources 删除存储中的文件和资源记录（资源已不存在视为已删除），返回删除成功和失败的资源数
+func (s *novelService) purgeResources(ctx context.Context, novelID string, resourceIDs []string) (removed, failed int) {
+	if len(resourceIDs) == 0 {
+		return 0, 0
+	}
+	for _, id := range resourceIDs {
+		err := s.resourceService.DeleteResource(ctx, id)
+		if err != nil && !errors.Is(err, service.ErrResourceNotFound) {
+			failed++
+			log.Warn().Err(err).Str("resource_id", id).Msg("删除资源失败")
+			continue
+		}
+		removed++
+	}
+	log.Info().Str("novel_id", novelID).Int("removed", removed).Int("failed", failed).Msg("已删除记录的存储文件清理完成")
+	return removed, failed
+}
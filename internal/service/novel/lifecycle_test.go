@@ -0,0 +1,279 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	novelrepo "lemon/internal/repository/novel"
+	"lemon/internal/service"
+)
+
+// memChapterRepo 内存中的章节仓库
+type memChapterRepo struct {
+	novelrepo.ChapterRepository
+	chapters []*novel.Chapter
+}
+
+func (r *memChapterRepo) FindByID(_ context.Context, id string) (*novel.Chapter, error) {
+	for _, ch := range r.chapters {
+		if ch.ID == id {
+			return ch, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *memChapterRepo) FindByNovelID(_ context.Context, novelID string) ([]*novel.Chapter, error) {
+	var chapters []*novel.Chapter
+	for _, ch := range r.chapters {
+		if ch.NovelID == novelID && !ch.IsBranch() {
+			chapters = append(chapters, ch)
+		}
+	}
+	return chapters, nil
+}
+
+func (r *memChapterRepo) FindBranches(_ context.Context, parentID string) ([]*novel.Chapter, error) {
+	var branches []*novel.Chapter
+	for _, ch := range r.chapters {
+		if ch.IsBranch() && ch.Branch.ParentID == parentID {
+			branches = append(branches, ch)
+		}
+	}
+	return branches, nil
+}
+
+// fakeLifecycleRepo 记录级联删除的范围，按集合返回被删除记录引用的资源
+type fakeLifecycleRepo struct {
+	refs    map[string][]string // 集合 -> 被删除记录引用的资源ID
+	live    map[string]bool
+	liveErr error
+
+	softDeleted map[string][]string // 集合 -> 按ID删除的记录
+	cascaded    map[string][]string // 范围字段 -> 级联删除的范围
+}
+
+func newFakeLifecycleRepo(refs map[string][]string, live ...string) *fakeLifecycleRepo {
+	r := &fakeLifecycleRepo{
+		refs:        refs,
+		live:        make(map[string]bool),
+		softDeleted: make(map[string][]string),
+		cascaded:    make(map[string][]string),
+	}
+	for _, id := range live {
+		r.live[id] = true
+	}
+	return r
+}
+
+func (r *fakeLifecycleRepo) CascadeSoftDelete(_ context.Context, field string, values []string, _ time.Time) (map[string]int64, []string, error) {
+	r.cascaded[field] = values
+	return map[string]int64{"videos": int64(len(r.refs["cascade"]))}, r.refs["cascade"], nil
+}
+
+func (r *fakeLifecycleRepo) SoftDeleteByIDs(_ context.Context, collection string, ids []string, _ time.Time) (int64, []string, error) {
+	r.softDeleted[collection] = ids
+	return int64(len(ids)), r.refs[collection], nil
+}
+
+func (r *fakeLifecycleRepo) LiveResourceIDs(context.Context, string) (map[string]bool, error) {
+	return r.live, r.liveErr
+}
+
+// memLifecycleVideoRepo 内存中的视频仓库，只实现查询发布版本和公证视频用到的方法
+type memLifecycleVideoRepo struct {
+	novelrepo.VideoRepository
+	videos []*novel.Video
+}
+
+func (r *memLifecycleVideoRepo) FindByID(_ context.Context, id string) (*novel.Video, error) {
+	for _, v := range r.videos {
+		if v.ID == id {
+			return v, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *memLifecycleVideoRepo) FindByChapterIDAndVersion(_ context.Context, chapterID string, version int) ([]*novel.Video, error) {
+	var videos []*novel.Video
+	for _, v := range r.videos {
+		if v.ChapterID == chapterID && v.Version == version {
+			videos = append(videos, v)
+		}
+	}
+	return videos, nil
+}
+
+// memNotarizationRepo 内存中的公证记录仓库
+type memNotarizationRepo struct {
+	novelrepo.NotarizationRepository
+	records []*novel.NotarizationRecord
+}
+
+func (r *memNotarizationRepo) FindByChapterID(_ context.Context, chapterID string) ([]*novel.NotarizationRecord, error) {
+	var records []*novel.NotarizationRecord
+	for _, record := range r.records {
+		if record.ChapterID == chapterID {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// deleteResourceStub 记录被删除的资源，failing 中的资源删除失败
+type deleteResourceStub struct {
+	service.ResourceService
+	deleted []string
+	failing map[string]bool
+}
+
+func (s *deleteResourceStub) DeleteResource(_ context.Context, id string) error {
+	if s.failing[id] {
+		return errors.New("storage unavailable")
+	}
+	s.deleted = append(s.deleted, id)
+	return nil
+}
+
+// lifecycleFixture 删除测试的依赖：章节 ch-1 有分支 ch-1-b，分支又有分支 ch-1-b-b；章节 ch-2 是另一个主章节
+type lifecycleFixture struct {
+	svc           *novelService
+	lifecycle     *fakeLifecycleRepo
+	resources     *deleteResourceStub
+	videos        *memLifecycleVideoRepo
+	notarizations *memNotarizationRepo
+}
+
+func newLifecycleFixture(lifecycle *fakeLifecycleRepo) *lifecycleFixture {
+	branch := func(id, parentID string) *novel.Chapter {
+		return &novel.Chapter{ID: id, NovelID: "novel-1", Branch: &novel.ChapterBranch{ParentID: parentID, Name: id}}
+	}
+	chapters := &memChapterRepo{chapters: []*novel.Chapter{
+		{ID: "ch-1", NovelID: "novel-1"},
+		branch("ch-1-b", "ch-1"),
+		branch("ch-1-b-b", "ch-1-b"),
+		{ID: "ch-2", NovelID: "novel-1"},
+	}}
+	f := &lifecycleFixture{
+		lifecycle:     lifecycle,
+		resources:     &deleteResourceStub{failing: make(map[string]bool)},
+		videos:        &memLifecycleVideoRepo{},
+		notarizations: &memNotarizationRepo{},
+	}
+	f.svc = &novelService{
+		novelRepo:        &memBudgetNovelRepo{novel: &novel.Novel{ID: "novel-1"}},
+		chapterRepo:      chapters,
+		lifecycleRepo:    lifecycle,
+		videoRepo:        f.videos,
+		notarizationRepo: f.notarizations,
+		resourceService:  f.resources,
+	}
+	return f
+}
+
+func TestDeleteChapterCascadesOverBranches(t *testing.T) {
+	f := newLifecycleFixture(newFakeLifecycleRepo(nil))
+
+	result, err := f.svc.DeleteChapter(context.Background(), "ch-1", false)
+	if err != nil {
+		t.Fatalf("DeleteChapter() error = %v", err)
+	}
+	want := []string{"ch-1", "ch-1-b", "ch-1-b-b"}
+	if !slices.Equal(result.ChapterIDs, want) {
+		t.Errorf("ChapterIDs = %v, want %v", result.ChapterIDs, want)
+	}
+	if got := f.lifecycle.softDeleted["chapters"]; !slices.Equal(got, want) {
+		t.Errorf("soft deleted chapters = %v, want %v", got, want)
+	}
+	if got := f.lifecycle.cascaded["chapter_id"]; !slices.Equal(got, want) {
+		t.Errorf("cascade scope = %v, want %v", got, want)
+	}
+}
+
+func TestDeleteNovelIncludesBranches(t *testing.T) {
+	f := newLifecycleFixture(newFakeLifecycleRepo(nil))
+
+	result, err := f.svc.DeleteNovel(context.Background(), "novel-1", false)
+	if err != nil {
+		t.Fatalf("DeleteNovel() error = %v", err)
+	}
+	want := []string{"ch-1", "ch-1-b", "ch-1-b-b", "ch-2"}
+	if !slices.Equal(result.ChapterIDs, want) {
+		t.Errorf("ChapterIDs = %v, want %v", result.ChapterIDs, want)
+	}
+	if got := f.lifecycle.cascaded["novel_id"]; !slices.Equal(got, []string{"novel-1"}) {
+		t.Errorf("cascade scope = %v, want [novel-1]", got)
+	}
+}
+
+func TestDeleteChapterPurgeKeepsLiveResources(t *testing.T) {
+	lifecycle := newFakeLifecycleRepo(map[string][]string{
+		"chapters": {"res-cover"},
+		"cascade":  {"res-audio", "res-shared", "res-video"},
+	}, "res-shared")
+	f := newLifecycleFixture(lifecycle)
+	f.resources.failing["res-video"] = true
+
+	result, err := f.svc.DeleteChapter(context.Background(), "ch-1", true)
+	if err != nil {
+		t.Fatalf("DeleteChapter() error = %v", err)
+	}
+	// 仍被其他记录使用的资源保留，删除在请求内完成，失败的资源留给资源回收任务
+	if want := []string{"res-audio", "res-cover"}; !slices.Equal(f.resources.deleted, want) {
+		t.Errorf("deleted resources = %v, want %v", f.resources.deleted, want)
+	}
+	if result.Resources != 4 || result.PurgeResources != 2 || result.PurgeFailed != 1 {
+		t.Errorf("result = %+v, want 4 resources, 2 purged, 1 failed", result)
+	}
+}
+
+func TestDeleteChapterPurgeSkippedWhenLiveLookupFails(t *testing.T) {
+	lifecycle := newFakeLifecycleRepo(map[string][]string{"cascade": {"res-audio", "res-video"}})
+	lifecycle.liveErr = errors.New("mongo unavailable")
+	f := newLifecycleFixture(lifecycle)
+
+	result, err := f.svc.DeleteChapter(context.Background(), "ch-1", true)
+	if err != nil {
+		t.Fatalf("DeleteChapter() error = %v", err)
+	}
+	if len(f.resources.deleted) != 0 {
+		t.Errorf("deleted resources = %v, want none", f.resources.deleted)
+	}
+	if result.Resources != 2 || result.PurgeResources != 0 {
+		t.Errorf("result = %+v, want 2 resources and nothing purged", result)
+	}
+}
+
+func TestDeleteChapterPurgeKeepsPromotedAndNotarizedVideos(t *testing.T) {
+	lifecycle := newFakeLifecycleRepo(map[string][]string{
+		"cascade": {"res-draft", "res-notarized", "res-notarized-manifest", "res-promoted", "res-promoted-shot"},
+	})
+	f := newLifecycleFixture(lifecycle)
+	ch, _ := f.svc.chapterRepo.FindByID(context.Background(), "ch-1")
+	ch.PromotedVersions = &novel.PromotedVersions{Video: 3}
+	f.videos.videos = []*novel.Video{
+		{ID: "v-promoted", ChapterID: "ch-1", Version: 3, VideoResourceID: "res-promoted"},
+		{ID: "v-promoted-shot", ChapterID: "ch-1", Version: 3, VideoResourceID: "res-promoted-shot"},
+		{ID: "v-notarized", ChapterID: "ch-1-b", Version: 1, VideoResourceID: "res-notarized", ManifestResourceID: "res-notarized-manifest"},
+		{ID: "v-draft", ChapterID: "ch-1", Version: 4, VideoResourceID: "res-draft"},
+	}
+	f.notarizations.records = []*novel.NotarizationRecord{{ID: "n-1", ChapterID: "ch-1-b", VideoID: "v-notarized"}}
+
+	result, err := f.svc.DeleteChapter(context.Background(), "ch-1", true)
+	if err != nil {
+		t.Fatalf("DeleteChapter() error = %v", err)
+	}
+	if want := []string{"res-draft"}; !slices.Equal(f.resources.deleted, want) {
+		t.Errorf("deleted resources = %v, want %v", f.resources.deleted, want)
+	}
+	if result.Protected != 4 || result.PurgeResources != 1 {
+		t.Errorf("result = %+v, want 4 protected and 1 purged", result)
+	}
+}
@@ -0,0 +1,170 @@
+package novel
+
+import (
+	"context"
+	"fmt"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/noveltools"
+	novelrepo "lemon/internal/repository/novel"
+)
+
+// ListQueryService 列表分页查询服务接口（分页、排序、筛选在数据库中完成，返回满足条件的总数）
+type ListQueryService interface {
+	// QueryChapters 分页查询小说的章节（不包括章节分支；不支持状态和视频类型筛选）
+	QueryChapters(ctx context.Context, novelID string, q *noveltools.ListQuery) (*ListPage[*novel.Chapter], error)
+	// QueryNarrations 分页查询章节的解说版本（version<=0 时不限版本；不支持按 sequence 排序）
+	QueryNarrations(ctx context.Context, chapterID string, version int, q *noveltools.ListQuery) (*ListPage[*novel.Narration], error)
+	// QueryAudios 分页查询解说指定版本的音频（version<=0 则取最新版本）
+	QueryAudios(ctx context.Context, narrationID string, version int, q *noveltools.ListQuery) (*ListPage[*novel.Audio], error)
+	// QuerySubtitles 分页查询解说指定版本的字幕（version<=0 则取最新版本）
+	QuerySubtitles(ctx context.Context, narrationID string, version int, q *noveltools.ListQuery) (*ListPage[*novel.Subtitle], error)
+	// QueryImages 分页查询解说指定版本的图片（version<=0 则取最新版本）
+	QueryImages(ctx context.Context, narrationID string, version int, q *noveltools.ListQuery) (*ListPage[*novel.Image], error)
+	// QueryVideos 分页查询章节指定版本的视频（version<=0 则取最新版本），可按视频类型筛选
+	QueryVideos(ctx context.Context, chapterID string, version int, q *noveltools.ListQuery) (*ListPage[*novel.Video], error)
+}
+
+// ListPage 列表分页查询结果
+type ListPage[T any] struct {
+	Items   []T   // 当前页的数据
+	Total   int64 // 满足筛选条件的总数
+	Version int   // 实际查询的版本号（未指定版本时为最新版本；不限版本的列表为 0）
+}
+
+// listSupport 列表支持的排序和筛选条件
+type listSupport struct {
+	name      string // 列表名称（用于错误信息）
+	sequence  bool   // 是否支持按 sequence 排序
+	status    bool   // 是否支持按状态筛选
+	videoType bool   // 是否支持按视频类型筛选
+}
+
+var (
+	chapterListSupport   = listSupport{name: "chapters", sequence: true}
+	narrationListSupport = listSupport{name: "narrations", status: true}
+	assetListSupport     = listSupport{name: "assets", sequence: true, status: true}
+	videoListSupport     = listSupport{name: "videos", sequence: true, status: true, videoType: true}
+)
+
+// QueryChapters 分页查询小说的章节
+func (s *novelService) QueryChapters(ctx context.Context, novelID string, q *noveltools.ListQuery) (*ListPage[*novel.Chapter], error) {
+	opts, err := chapterListSupport.options(q)
+	if err != nil {
+		return nil, err
+	}
+	chapters, total, err := s.chapterRepo.ListByNovelID(ctx, novelID, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &ListPage[*novel.Chapter]{Items: chapters, Total: total}, nil
+}
+
+// QueryNarrations 分页查询章节的解说版本
+func (s *novelService) QueryNarrations(ctx context.Context, chapterID string, version int, q *noveltools.ListQuery) (*ListPage[*novel.Narration], error) {
+	opts, err := narrationListSupport.options(q)
+	if err != nil {
+		return nil, err
+	}
+	narrations, total, err := s.narrationRepo.ListByChapterID(ctx, chapterID, version, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &ListPage[*novel.Narration]{Items: narrations, Total: total, Version: max(version, 0)}, nil
+}
+
+// QueryAudios 分页查询解说指定版本的音频
+func (s *novelService) QueryAudios(ctx context.Context, narrationID string, version int, q *noveltools.ListQuery) (*ListPage[*novel.Audio], error) {
+	opts, err := assetListSupport.options(q)
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.resolveAudioVersion(ctx, narrationID, version)
+	if err != nil {
+		return nil, err
+	}
+	audios, total, err := s.audioRepo.ListByNarrationIDAndVersion(ctx, narrationID, v, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &ListPage[*novel.Audio]{Items: audios, Total: total, Version: v}, nil
+}
+
+// QuerySubtitles 分页查询解说指定版本的字幕
+func (s *novelService) QuerySubtitles(ctx context.Context, narrationID string, version int, q *noveltools.ListQuery) (*ListPage[*novel.Subtitle], error) {
+	opts, err := assetListSupport.options(q)
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.resolveSubtitleVersion(ctx, narrationID, version)
+	if err != nil {
+		return nil, err
+	}
+	subtitles, total, err := s.subtitleRepo.ListByNarrationIDAndVersion(ctx, narrationID, v, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &ListPage[*novel.Subtitle]{Items: subtitles, Total: total, Version: v}, nil
+}
+
+// QueryImages 分页查询解说指定版本的图片
+func (s *novelService) QueryImages(ctx context.Context, narrationID string, version int, q *noveltools.ListQuery) (*ListPage[*novel.Image], error) {
+	opts, err := assetListSupport.options(q)
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.resolveImageVersion(ctx, narrationID, version)
+	if err != nil {
+		return nil, err
+	}
+	images, total, err := s.imageRepo.ListByNarrationIDAndVersion(ctx, narrationID, v, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &ListPage[*novel.Image]{Items: images, Total: total, Version: v}, nil
+}
+
+// QueryVideos 分页查询章节指定版本的视频
+func (s *novelService) QueryVideos(ctx context.Context, chapterID string, version int, q *noveltools.ListQuery) (*ListPage[*novel.Video], error) {
+	opts, err := videoListSupport.options(q)
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.resolveVideoVersion(ctx, chapterID, version)
+	if err != nil {
+		return nil, err
+	}
+	videos, total, err := s.videoRepo.ListByChapterIDAndVersion(ctx, chapterID, v, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &ListPage[*novel.Video]{Items: videos, Total: total, Version: v}, nil
+}
+
+// options 检查列表是否支持查询条件，并转换为仓库的查询选项（q 为 nil 时不分页、使用默认排序）
+func (l listSupport) options(q *noveltools.ListQuery) (novelrepo.ListOptions, error) {
+	if q == nil {
+		return novelrepo.ListOptions{}, nil
+	}
+	if q.Sort == noveltools.ListSortSequence && !l.sequence {
+		return novelrepo.ListOptions{}, fmt.Errorf("%w: %s cannot be sorted by sequence", noveltools.ErrInvalidListQuery, l.name)
+	}
+	if len(q.Statuses) > 0 && !l.status {
+		return novelrepo.ListOptions{}, fmt.Errorf("%w: %s cannot be filtered by status", noveltools.ErrInvalidListQuery, l.name)
+	}
+	if len(q.VideoTypes) > 0 && !l.videoType {
+		return novelrepo.ListOptions{}, fmt.Errorf("%w: %s cannot be filtered by video_type", noveltools.ErrInvalidListQuery, l.name)
+	}
+
+	opts := novelrepo.ListOptions{
+		Statuses:  q.Statuses,
+		SortField: string(q.Sort),
+		Desc:      q.Desc,
+		Skip:      q.Skip(),
+		Limit:     q.PageSize,
+	}
+	for _, t := range q.VideoTypes {
+		opts.VideoTypes = append(opts.VideoTypes, string(t))
+	}
+	return opts, nil
+}
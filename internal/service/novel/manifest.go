@@ -0,0 +1,269 @@
+package novel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ffmpeg"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/noveltools"
+	"lemon/internal/service"
+)
+
+// pipelineManifestMetadataKey 清单嵌入 MP4 元数据时使用的键
+const pipelineManifestMetadataKey = "lemon_manifest"
+
+// embedPipelineManifestFromEnv 是否把流水线清单嵌入最终视频的 MP4 元数据（EMBED_PIPELINE_MANIFEST=true 时开启）
+func embedPipelineManifestFromEnv() bool {
+	return strings.EqualFold(os.Getenv("EMBED_PIPELINE_MANIFEST"), "true")
+}
+
+// pipelineManifestInputs 构建流水线清单需要的渲染信息
+type pipelineManifestInputs struct {
+	videoID         string
+	chapter         *novel.Chapter
+	version         int
+	tier            novel.ExportTier
+	platform        novel.TargetPlatform
+	narrationVideos []*novel.Video
+	bgmCues         []noveltools.BGMCue
+	recapVideoID    string
+	compliance      *novel.ComplianceReport
+	accessibility   *accessibilityOutputs
+	attribution     *novel.AttributionRecord
+}
+
+// buildPipelineManifest 构建最终视频的流水线清单
+// 镜头的图片、音频、字幕按生成 narration 视频时相同的规则匹配；找不到的素材在清单中留空（旧数据可能缺失）
+func (s *novelService) buildPipelineManifest(ctx context.Context, in *pipelineManifestInputs, ffmpegClient *ffmpeg.Client) (*novel.PipelineManifest, error) {
+	narrationID := in.narrationVideos[0].NarrationID
+	narration, err := s.narrationRepo.FindByID(ctx, narrationID)
+	if err != nil {
+		return nil, fmt.Errorf("find narration: %w", err)
+	}
+	shots, err := s.shotRepo.FindByNarrationID(ctx, narrationID)
+	if err != nil {
+		return nil, fmt.Errorf("find shots: %w", err)
+	}
+	audios, err := s.audioRepo.FindByNarrationID(ctx, narrationID)
+	if err != nil {
+		return nil, fmt.Errorf("find audios: %w", err)
+	}
+	subtitles, err := s.subtitleRepo.FindByNarrationID(ctx, narrationID)
+	if err != nil {
+		return nil, fmt.Errorf("find subtitles: %w", err)
+	}
+
+	// 按视频阶段相同的规则选择字幕（章节选中的版本优先，否则同一序号取最新生成的字幕）
+	subtitleBySequence := selectNarrationSubtitles(subtitles, in.chapter.SubtitleVersion)
+
+	manifestShots := make(map[int]novel.ManifestShot, len(shots))
+	for _, shot := range shots {
+		entry := novel.ManifestShot{
+			ShotID:          shot.ID,
+			SceneNumber:     shot.SceneNumber,
+			ShotNumber:      shot.ShotNumber,
+			Index:           shot.Index,
+			ImagePromptHash: noveltools.HashPrompt(shot.ImagePrompt),
+			VideoPromptHash: noveltools.HashPrompt(shot.VideoPrompt),
+		}
+
+		image, err := s.findShotImage(ctx, in.chapter.ID, shot, shot.SceneNumber, shot.ShotNumber)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("find image for shot %s: %w", shot.ID, err)
+		}
+		if image != nil {
+			entry.ImageID = image.ID
+			entry.ImageResourceID = image.ImageResourceID
+			requestPrompt := image.LocalizedPrompt
+			if requestPrompt == "" {
+				requestPrompt = image.Prompt
+			}
+			entry.ImageRequestHash = noveltools.HashPrompt(requestPrompt)
+			entry.StyleReferenceIDs = image.StyleReferenceIDs
+		}
+
+		if audio := matchShotAudio(audios, shot); audio != nil {
+			entry.AudioID = audio.ID
+			entry.AudioResourceID = audio.AudioResourceID
+			entry.TextHash = noveltools.HashPrompt(audio.Text)
+			if subtitle, ok := subtitleBySequence[audio.Sequence]; ok {
+				entry.SubtitleID = subtitle.ID
+				entry.SubtitleResourceID = subtitle.SubtitleResourceID
+			}
+		}
+		manifestShots[shot.Index] = entry
+	}
+
+	clips := make([]novel.ManifestClip, 0, len(in.narrationVideos))
+	for _, video := range in.narrationVideos {
+		start, end := video.SequenceRange()
+		clip := novel.ManifestClip{
+			VideoID:     video.ID,
+			ResourceID:  video.VideoResourceID,
+			Sequence:    start,
+			SequenceEnd: end,
+			PromptHash:  noveltools.HashPrompt(video.Prompt),
+			Shots:       []novel.ManifestShot{},
+		}
+		for index := start; index <= end; index++ {
+			if entry, ok := manifestShots[index]; ok {
+				clip.Shots = append(clip.Shots, entry)
+			}
+		}
+		clips = append(clips, clip)
+	}
+
+	var bgmTracks []novel.ManifestBGMTrack
+	for _, cue := range in.bgmCues {
+		bgmTracks = append(bgmTracks, novel.ManifestBGMTrack{
+			TrackID:      cue.Track.ID,
+			ResourceID:   cue.Track.ResourceID,
+			Mood:         cue.Mood,
+			Start:        cue.Start,
+			End:          cue.End,
+			SceneNumbers: cue.SceneNumbers,
+		})
+	}
+
+	ffmpegVersion, err := ffmpegClient.Version(ctx)
+	if err != nil {
+		log.Warn().Err(err).Str("chapter_id", in.chapter.ID).Msg("获取 ffmpeg 版本失败，清单中不记录 ffmpeg 版本")
+	}
+
+	manifest := &novel.PipelineManifest{
+		SchemaVersion: novel.PipelineManifestSchemaVersion,
+		VideoID:       in.videoID,
+		NovelID:       in.chapter.NovelID,
+		ChapterID:     in.chapter.ID,
+		Version:       in.version,
+		ExportTier:    in.tier,
+		Platform:      in.platform,
+		CreatedAt:     time.Now(),
+		Narration: novel.ManifestNarration{
+			ID:         narration.ID,
+			Version:    narration.Version,
+			PromptHash: noveltools.HashPrompt(narration.Prompt),
+		},
+		Clips:     clips,
+		BGMTracks: bgmTracks,
+		Providers: map[string]novel.ManifestProvider{
+			"llm":   noveltools.ManifestProviderInfo(s.llmProvider),
+			"tts":   noveltools.ManifestProviderInfo(s.ttsProvider),
+			"image": noveltools.ManifestProviderInfo(s.imageProvider),
+			"video": noveltools.ManifestProviderInfo(s.videoProvider),
+		},
+		Tools:        noveltools.ManifestToolVersions(ffmpegVersion),
+		Watermarked:  in.tier == novel.ExportTierPreview,
+		RecapVideoID: in.recapVideoID,
+		Attribution:  in.attribution,
+	}
+	if in.compliance != nil {
+		manifest.Compliance = in.compliance.Preset
+	}
+	if in.accessibility != nil {
+		manifest.Accessibility = &novel.ManifestAccessibility{
+			TranscriptResourceID:       in.accessibility.transcriptResourceID,
+			AudioDescriptionResourceID: in.accessibility.audioDescriptionResourceID,
+			DescriptionVTTResourceID:   in.accessibility.descriptionVTTResourceID,
+			DescriptionCues:            in.accessibility.descriptionCues,
+		}
+	}
+	return manifest, nil
+}
+
+// storePipelineManifest 上传流水线清单，开启嵌入时同时写入最终视频的 MP4 元数据
+// 返回清单的 resource_id、内容 SHA256 和（嵌入后的）最终视频路径
+func (s *novelService) storePipelineManifest(ctx context.Context, manifest *novel.PipelineManifest, userID, videoPath, tmpDir string, ffmpegClient *ffmpeg.Client) (string, string, string, error) {
+	data, sum, err := noveltools.EncodePipelineManifest(manifest)
+	if err != nil {
+		return "", "", "", fmt.Errorf("encode manifest: %w", err)
+	}
+
+	if s.embedManifest {
+		compact := new(bytes.Buffer)
+		if err := json.Compact(compact, data); err != nil {
+			return "", "", "", fmt.Errorf("compact manifest: %w", err)
+		}
+		embeddedPath := filepath.Join(tmpDir, fmt.Sprintf("manifest_%s.mp4", id.New()))
+		if err := ffmpegClient.EmbedMetadata(ctx, videoPath, embeddedPath, map[string]string{
+			pipelineManifestMetadataKey: compact.String(),
+		}); err != nil {
+			os.Remove(embeddedPath)
+			return "", "", "", fmt.Errorf("embed manifest: %w", err)
+		}
+		videoPath = embeddedPath
+	}
+
+	uploadResult, err := s.resourceService.UploadFile(ctx, &service.UploadFileRequest{
+		UserID:      userID,
+		FileName:    fmt.Sprintf("%s_manifest.json", manifest.VideoID),
+		ContentType: "application/json",
+		Ext:         "json",
+		Data:        bytes.NewReader(data),
+		KeyVars:     s.chapterKeyVarsByID(ctx, manifest.NovelID, manifest.ChapterID, artifactManifest, manifest.Version, 1),
+	})
+	if err != nil {
+		return "", "", "", fmt.Errorf("upload manifest: %w", err)
+	}
+
+	log.Info().
+		Str("video_id", manifest.VideoID).
+		Str("manifest_resource_id", uploadResult.ResourceID).
+		Str("sha256", sum).
+		Bool("embedded", s.embedManifest).
+		Msg("流水线清单已保存")
+
+	return uploadResult.ResourceID, sum, videoPath, nil
+}
+
+// GetVideoManifest 获取最终视频的流水线清单
+// 视频不属于该章节、不是最终视频或生成时还没有清单时返回 mongo.ErrNoDocuments
+func (s *novelService) GetVideoManifest(ctx context.Context, chapterID, videoID string) (*novel.PipelineManifest, error) {
+	video, err := s.videoRepo.FindByID(ctx, videoID)
+	if err != nil {
+		return nil, fmt.Errorf("find video: %w", err)
+	}
+	if video.ChapterID != chapterID || video.VideoType != novel.VideoTypeFinal || video.ManifestResourceID == "" {
+		return nil, fmt.Errorf("manifest for video %s: %w", videoID, mongo.ErrNoDocuments)
+	}
+
+	result, err := s.resourceService.DownloadFile(ctx, &service.DownloadFileRequest{
+		ResourceID: video.ManifestResourceID,
+		UserID:     video.UserID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("download manifest: %w", err)
+	}
+	defer result.Data.Close()
+
+	var manifest novel.PipelineManifest
+	if err := json.NewDecoder(result.Data).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("decode manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// matchShotAudio 查找镜头对应的音频：优先按镜头ID匹配，旧数据按 sequence 匹配
+func matchShotAudio(audios []*novel.Audio, shot *novel.Shot) *novel.Audio {
+	for _, a := range audios {
+		if a.ShotID != "" && a.ShotID == shot.ID {
+			return a
+		}
+		if a.ShotID == "" && a.Sequence == shot.Index {
+			return a
+		}
+	}
+	return nil
+}
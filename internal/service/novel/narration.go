@@ -5,15 +5,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
 	"go.mongodb.org/mongo-driver/bson"
 
 	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
+	"lemon/internal/pkg/errclass"
+	"lemon/internal/pkg/eventbus"
 	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/killswitch"
 	"lemon/internal/pkg/noveltools"
+	"lemon/internal/pkg/respcache"
 )
 
 // NarrationService 章节解说服务接口
@@ -25,12 +29,15 @@ type NarrationService interface {
 	// GenerateNarrationForChapterWithMeta 为单一章节生成解说文本，并返回本次生成的 Narration 元数据
 	GenerateNarrationForChapterWithMeta(ctx context.Context, chapterID string) (*novel.Narration, string, error)
 
-	// GenerateNarrationsForAllChapters 并发地为所有章节生成解说文本
-	GenerateNarrationsForAllChapters(ctx context.Context, novelID string) error
+	// GenerateNarrationsForAllChapters 为所有章节提交解说生成任务（异步执行，返回提交的任务）
+	GenerateNarrationsForAllChapters(ctx context.Context, novelID string) ([]*novel.Job, error)
 
 	// GetNarration 根据章节ID获取章节解说（返回最新版本）
 	GetNarration(ctx context.Context, chapterID string) (*novel.Narration, error)
 
+	// GetNarrationByID 根据解说ID获取解说
+	GetNarrationByID(ctx context.Context, narrationID string) (*novel.Narration, error)
+
 	// GetNarrationByVersion 根据章节ID和版本号获取章节解说
 	GetNarrationByVersion(ctx context.Context, chapterID string, version int) (*novel.Narration, error)
 
@@ -57,6 +64,33 @@ type NarrationService interface {
 
 	// RegenerateShotScript 重新生成单个分镜头的脚本（调用 LLM）
 	RegenerateShotScript(ctx context.Context, shotID string) error
+
+	// ReorderScenes 按给定的场景ID顺序重排解说中的场景（重新编号，已生成的产物按镜头ID重新关联）
+	ReorderScenes(ctx context.Context, narrationID string, sceneIDs []string) ([]*novel.Scene, error)
+
+	// ReorderShots 按给定的镜头ID顺序重排场景内的镜头（重新编号，已生成的产物按镜头ID重新关联）
+	ReorderShots(ctx context.Context, sceneID string, shotIDs []string) ([]*novel.Shot, error)
+
+	// RegenerateNarrationWithFeedback 按审核意见重新生成解说（目标场景带上审核意见重写），生成新的解说版本并记录审核意见
+	RegenerateNarrationWithFeedback(ctx context.Context, req *RegenerateNarrationWithFeedbackRequest) (*novel.Narration, error)
+
+	// SubscribeNarrationEvents 订阅解说生成事件（场景完成/解说完成/解说失败），返回事件通道和取消订阅函数
+	SubscribeNarrationEvents(narrationID string) (<-chan eventbus.Event, func())
+}
+
+// 解说生成事件类型
+const (
+	NarrationEventSceneCompleted = "narration.scene_completed" // 单个场景已生成并落库
+	NarrationEventCompleted      = "narration.completed"       // 解说全部生成完成
+	NarrationEventFailed         = "narration.failed"          // 解说生成失败
+)
+
+// NarrationSceneEvent 场景完成事件数据
+type NarrationSceneEvent struct {
+	NarrationID     string       `json:"narration_id"`     // 解说ID
+	Scene           *novel.Scene `json:"scene"`            // 已落库的场景
+	ShotsCount      int          `json:"shots_count"`      // 该场景的镜头数
+	CompletedScenes int          `json:"completed_scenes"` // 已完成的场景数
 }
 
 // GenerateNarrationForChapterWithMeta 为单一章节生成章节解说，并保存到 narrations/scenes/shots 表
@@ -75,7 +109,30 @@ func (s *novelService) GenerateNarrationForChapter(ctx context.Context, chapterI
 	return txt, nil
 }
 
+// generateNarrationForChapter 生成章节解说，并记录阶段耗时与费用
 func (s *novelService) generateNarrationForChapter(ctx context.Context, chapterID string) (*novel.Narration, string, error) {
+	var (
+		n   *novel.Narration
+		txt string
+	)
+	err := s.runChapterStage(ctx, chapterID, novel.PipelineStageNarration, func(ctx context.Context) error {
+		var err error
+		n, txt, err = s.renderNarrationForChapter(ctx, chapterID)
+		if n != nil {
+			setStageResources(ctx, n.ID)
+		}
+		return err
+	})
+	return n, txt, err
+}
+
+func (s *novelService) renderNarrationForChapter(ctx context.Context, chapterID string) (*novel.Narration, string, error) {
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderLLM)
+	if err != nil {
+		return nil, "", err
+	}
+	defer release()
+
 	startTime := time.Now()
 	log.Info().
 		Str("chapter_id", chapterID).
@@ -100,58 +157,216 @@ func (s *novelService) generateNarrationForChapter(ctx context.Context, chapterI
 		return nil, "", err
 	}
 
+	generator := noveltools.NewNarrationGenerator(s.llmProvider).WithStructure(s.novelNarrationStructure(ctx, ch.NovelID))
+	prompt, err := s.buildNarrationPrompt(ctx, ch, totalChapters, generator)
+	if err != nil {
+		log.Error().Err(err).Str("chapter_id", chapterID).Msg("构造剧本提示词失败")
+		return nil, "", err
+	}
+
+	nextVersion, err := s.nextVersion(ctx, ch.ID, novel.VersionArtifactNarration)
+	if err != nil {
+		log.Error().Err(err).Str("chapter_id", chapterID).Msg("获取下一个版本号失败")
+		return nil, "", fmt.Errorf("failed to get next version: %w", err)
+	}
+
+	// 先创建解说记录（pending），场景在生成过程中逐个落库，编辑可以提前查看已完成的场景
+	narrationEntity := &novel.Narration{
+		ID:        id.New(),
+		ChapterID: ch.ID,
+		NovelID:   ch.NovelID,
+		UserID:    ch.UserID,
+		Prompt:    prompt,
+		Version:   nextVersion,
+		Status:    novel.TaskStatusPending, // 初始状态为 pending，全部场景完成后再更新为 completed
+	}
+	if err := s.narrationRepo.Create(ctx, narrationEntity); err != nil {
+		log.Error().Err(err).Str("chapter_id", chapterID).Msg("创建解说记录失败")
+		return nil, "", fmt.Errorf("failed to create narration record: %w", err)
+	}
+
 	log.Debug().
 		Str("chapter_id", chapterID).
-		Int("total_chapters", totalChapters).
-		Msg("准备生成剧本 JSON")
+		Str("narration_id", narrationEntity.ID).
+		Int("version", nextVersion).
+		Msg("开始流式生成剧本")
 
-	prompt, filteredNarration, jsonContent, err := s.buildNarrationJSON(ctx, ch, totalChapters)
+	progress := &narrationProgress{
+		persisted: make(map[string]bool),
+		shotIndex: 1,
+	}
+
+	if err := s.checkBudget(ctx, ch.NovelID); err != nil {
+		s.failNarration(ctx, narrationEntity, err.Error())
+		return nil, "", err
+	}
+
+	llmStartTime := time.Now()
+	narrationText, err := generator.GenerateScenesStream(ctx, prompt, func(index int, scene *noveltools.NarrationJSONScene) error {
+		return s.persistStreamedScene(ctx, ch, narrationEntity, progress, index+1, scene)
+	})
 	if err != nil {
 		log.Error().Err(err).
 			Str("chapter_id", chapterID).
-			Int("sequence", ch.Sequence).
-			Msg("生成剧本 JSON 失败")
+			Str("narration_id", narrationEntity.ID).
+			Dur("duration", time.Since(llmStartTime)).
+			Msg("LLM 生成剧本失败")
+		s.failNarration(ctx, narrationEntity, err.Error())
 		return nil, "", err
 	}
+	s.recordLLMCost(ctx, ch.NovelID, ch.ID, prompt, narrationText)
 
 	log.Info().
 		Str("chapter_id", chapterID).
-		Int("scenes_count", len(jsonContent.Scenes)).
-		Int("total_shots", s.countTotalShots(jsonContent)).
-		Msg("剧本 JSON 生成成功")
+		Str("narration_id", narrationEntity.ID).
+		Int("narration_length", len(narrationText)).
+		Int("streamed_scenes", progress.completed).
+		Dur("llm_duration", time.Since(llmStartTime)).
+		Msg("LLM 生成剧本完成")
 
-	nextVersion, err := s.getNextNarrationVersion(ctx, ch.ID)
+	filteredNarration, jsonContent, err := s.parseNarrationJSON(ctx, ch, narrationText)
 	if err != nil {
-		log.Error().Err(err).Str("chapter_id", chapterID).Msg("获取下一个版本号失败")
-		return nil, "", fmt.Errorf("failed to get next version: %w", err)
+		s.failNarration(ctx, narrationEntity, err.Error())
+		return nil, "", err
 	}
 
-	log.Debug().
-		Str("chapter_id", chapterID).
-		Int("version", nextVersion).
-		Msg("准备保存剧本数据")
+	// 兜底：流式解析未能产出的场景（如单个场景 JSON 不规范），以完整解析结果补齐
+	for i, scene := range jsonContent.Scenes {
+		if scene == nil || progress.persisted[scene.SceneNumber] {
+			continue
+		}
+		if err := s.persistStreamedScene(ctx, ch, narrationEntity, progress, i+1, scene); err != nil {
+			s.failNarration(ctx, narrationEntity, err.Error())
+			return nil, "", err
+		}
+	}
 
-	narrationEntity, err := s.persistNarrationBatch(ctx, ch, nextVersion, prompt, jsonContent)
+	// 保存角色和道具（依赖完整的 JSON，只能在生成结束后处理）
+	_, _, characters, props, err := noveltools.ConvertToScenesAndShots(narrationEntity.ID, ch.ID, ch.NovelID, ch.UserID, nextVersion, jsonContent)
 	if err != nil {
-		log.Error().Err(err).
-			Str("chapter_id", chapterID).
-			Int("version", nextVersion).
-			Msg("保存剧本数据失败")
+		s.failNarration(ctx, narrationEntity, fmt.Sprintf("failed to convert characters and props: %v", err))
+		return nil, "", fmt.Errorf("failed to convert characters and props: %w", err)
+	}
+	s.upsertCharactersAndProps(ctx, ch, narrationEntity.ID, characters, props)
+
+	// 校验落库的场景/镜头与流式解析出的场景一致（合并近似重复镜头后的结果）
+	if err := s.verifyNarrationPersistence(ctx, narrationEntity.ID, &noveltools.NarrationJSONContent{Scenes: progress.scenes}); err != nil {
+		s.failNarration(ctx, narrationEntity, err.Error())
 		return nil, "", err
 	}
 
+	if err := s.narrationRepo.UpdateStatus(ctx, narrationEntity.ID, novel.TaskStatusCompleted, ""); err != nil {
+		log.Error().Err(err).
+			Str("narration_id", narrationEntity.ID).
+			Msg("更新解说状态失败")
+		return nil, "", fmt.Errorf("failed to update narration status: %w", err)
+	}
+	narrationEntity.Status = novel.TaskStatusCompleted
+	narrationEntity.CompletedScenes = progress.completed
+	s.eventBus.Publish(narrationEntity.ID, NarrationEventCompleted, narrationEntity)
+
 	duration := time.Since(startTime)
 	log.Info().
 		Str("chapter_id", chapterID).
 		Str("narration_id", narrationEntity.ID).
 		Int("version", nextVersion).
-		Int("scenes_count", len(jsonContent.Scenes)).
+		Int("scenes_count", progress.completed).
+		Int("total_shots", s.countTotalShots(jsonContent)).
 		Dur("duration", duration).
 		Msg("章节剧本生成完成")
 
 	return narrationEntity, filteredNarration, nil
 }
 
+// narrationProgress 流式生成剧本时的落库进度
+type narrationProgress struct {
+	persisted map[string]bool                  // 已落库的场景编号
+	completed int                              // 已落库的场景数
+	shotIndex int                              // 下一个镜头的全局索引（从1开始）
+	scenes    []*noveltools.NarrationJSONScene // 已落库的场景 JSON（用于落库后校验）
+}
+
+// persistStreamedScene 保存流式生成中解析出的单个场景及其镜头，更新解说进度并发布场景完成事件
+func (s *novelService) persistStreamedScene(
+	ctx context.Context,
+	ch *novel.Chapter,
+	narrationEntity *novel.Narration,
+	progress *narrationProgress,
+	sequence int,
+	jsonScene *noveltools.NarrationJSONScene,
+) error {
+	// 合并相邻的近似重复镜头，避免重复生成图片/音频/视频
+	for _, merge := range noveltools.DedupSceneShots(jsonScene, noveltools.DefaultShotDedupThreshold) {
+		log.Info().
+			Str("narration_id", narrationEntity.ID).
+			Str("scene_number", merge.SceneNumber).
+			Str("kept_shot", merge.KeptShot).
+			Str("merged_shot", merge.MergedShot).
+			Float64("similarity", merge.Similarity).
+			Msg("合并近似重复镜头")
+	}
+
+	scene, shots := noveltools.ConvertScene(narrationEntity.ID, ch.ID, ch.NovelID, ch.UserID, narrationEntity.Version, sequence, progress.shotIndex, jsonScene)
+
+	if err := s.sceneRepo.Create(ctx, scene); err != nil {
+		log.Error().Err(err).
+			Str("narration_id", narrationEntity.ID).
+			Str("scene_number", scene.SceneNumber).
+			Msg("保存场景数据失败")
+		return fmt.Errorf("failed to save scene: %w", err)
+	}
+	if len(shots) > 0 {
+		if err := s.shotRepo.CreateMany(ctx, shots); err != nil {
+			log.Error().Err(err).
+				Str("narration_id", narrationEntity.ID).
+				Str("scene_number", scene.SceneNumber).
+				Int("shots_count", len(shots)).
+				Msg("保存镜头数据失败")
+			_ = s.sceneRepo.UpdateStatus(ctx, scene.ID, novel.TaskStatusFailed, err.Error())
+			return fmt.Errorf("failed to save shots: %w", err)
+		}
+	}
+
+	progress.persisted[scene.SceneNumber] = true
+	progress.completed++
+	progress.scenes = append(progress.scenes, jsonScene)
+	progress.shotIndex += len(shots)
+
+	if err := s.narrationRepo.UpdateCompletedScenes(ctx, narrationEntity.ID, progress.completed); err != nil {
+		log.Warn().Err(err).
+			Str("narration_id", narrationEntity.ID).
+			Msg("更新解说进度失败，继续处理")
+	}
+
+	log.Info().
+		Str("narration_id", narrationEntity.ID).
+		Str("scene_number", scene.SceneNumber).
+		Int("sequence", sequence).
+		Int("shots_count", len(shots)).
+		Int("completed_scenes", progress.completed).
+		Msg("场景数据保存完成")
+
+	s.eventBus.Publish(narrationEntity.ID, NarrationEventSceneCompleted, NarrationSceneEvent{
+		NarrationID:     narrationEntity.ID,
+		Scene:           scene,
+		ShotsCount:      len(shots),
+		CompletedScenes: progress.completed,
+	})
+	return nil
+}
+
+// failNarration 将解说标记为失败并发布失败事件（已落库的场景保留，便于排查）
+func (s *novelService) failNarration(ctx context.Context, narrationEntity *novel.Narration, errorMsg string) {
+	if err := s.narrationRepo.UpdateStatus(ctx, narrationEntity.ID, novel.TaskStatusFailed, errorMsg); err != nil {
+		log.Error().Err(err).
+			Str("narration_id", narrationEntity.ID).
+			Msg("更新解说状态失败")
+	}
+	narrationEntity.Status = novel.TaskStatusFailed
+	narrationEntity.ErrorMessage = errorMsg
+	s.eventBus.Publish(narrationEntity.ID, NarrationEventFailed, narrationEntity)
+}
+
 // countTotalShots 统计总镜头数
 func (s *novelService) countTotalShots(jsonContent *noveltools.NarrationJSONContent) int {
 	count := 0
@@ -163,41 +378,18 @@ func (s *novelService) countTotalShots(jsonContent *noveltools.NarrationJSONCont
 	return count
 }
 
-func (s *novelService) buildNarrationJSON(
+// parseNarrationJSON 审核并解析 LLM 生成的完整剧本 JSON
+func (s *novelService) parseNarrationJSON(
 	ctx context.Context,
 	ch *novel.Chapter,
-	totalChapters int,
-) (prompt string, filteredNarration string, jsonContent *noveltools.NarrationJSONContent, err error) {
-	log.Debug().
-		Str("chapter_id", ch.ID).
-		Int("sequence", ch.Sequence).
-		Int("word_count", ch.WordCount).
-		Msg("开始调用 LLM 生成剧本")
-
-	llmStartTime := time.Now()
-	generator := noveltools.NewNarrationGenerator(s.llmProvider)
-	prompt, narrationText, err := generator.GenerateWithPrompt(ctx, ch.ChapterText, ch.Sequence, totalChapters, ch.WordCount)
-	if err != nil {
-		log.Error().Err(err).
-			Str("chapter_id", ch.ID).
-			Dur("duration", time.Since(llmStartTime)).
-			Msg("LLM 生成剧本失败")
-		return "", "", nil, err
-	}
-
-	llmDuration := time.Since(llmStartTime)
-	log.Info().
-		Str("chapter_id", ch.ID).
-		Int("narration_length", len(narrationText)).
-		Dur("llm_duration", llmDuration).
-		Msg("LLM 生成剧本完成")
-
+	narrationText string,
+) (filteredNarration string, jsonContent *noveltools.NarrationJSONContent, err error) {
 	narrationText = strings.TrimSpace(narrationText)
 	if narrationText == "" {
 		log.Error().
 			Str("chapter_id", ch.ID).
 			Msg("LLM 返回的剧本内容为空")
-		return "", "", nil, fmt.Errorf("generated narrationText is empty")
+		return "", nil, fmt.Errorf("generated narrationText is empty")
 	}
 
 	log.Debug().
@@ -227,14 +419,14 @@ func (s *novelService) buildNarrationJSON(
 			Str("chapter_id", ch.ID).
 			Dur("duration", time.Since(parseStartTime)).
 			Msg("解析剧本 JSON 失败")
-		return "", "", nil, fmt.Errorf("narration parsing failed: %w", err)
+		return "", nil, fmt.Errorf("narration parsing failed: %w", err)
 	}
 
 	if len(jsonContent.Scenes) == 0 {
 		log.Error().
 			Str("chapter_id", ch.ID).
 			Msg("剧本 JSON 验证失败：缺少 scenes 字段或 scenes 为空")
-		return "", "", nil, fmt.Errorf("narration validation failed: 缺少 scenes 字段或 scenes 为空")
+		return "", nil, errclass.Wrap(errclass.ValidationSchema, "", fmt.Errorf("narration validation failed: 缺少 scenes 字段或 scenes 为空"))
 	}
 
 	parseDuration := time.Since(parseStartTime)
@@ -245,7 +437,7 @@ func (s *novelService) buildNarrationJSON(
 		Dur("parse_duration", parseDuration).
 		Msg("剧本 JSON 解析成功")
 
-	return prompt, filteredNarration, jsonContent, nil
+	return filteredNarration, jsonContent, nil
 }
 
 func (s *novelService) persistNarrationBatch(
@@ -265,13 +457,14 @@ func (s *novelService) persistNarrationBatch(
 		Msg("开始保存剧本数据")
 
 	narrationEntity := &novel.Narration{
-		ID:        narrationID,
-		ChapterID: ch.ID,
-		NovelID:   ch.NovelID,
-		UserID:    ch.UserID,
-		Prompt:    prompt,
-		Version:   version,
-		Status:    novel.TaskStatusPending, // 初始状态为 pending，成功后再更新为 completed
+		ID:              narrationID,
+		ChapterID:       ch.ID,
+		NovelID:         ch.NovelID,
+		UserID:          ch.UserID,
+		Prompt:          prompt,
+		Version:         version,
+		Status:          novel.TaskStatusPending, // 初始状态为 pending，成功后再更新为 completed
+		CompletedScenes: len(jsonContent.Scenes),
 	}
 	if err := s.narrationRepo.Create(ctx, narrationEntity); err != nil {
 		log.Error().Err(err).
@@ -365,6 +558,46 @@ func (s *novelService) persistNarrationBatch(
 			Msg("镜头数据保存完成")
 	}
 
+	// 保存角色和道具
+	s.upsertCharactersAndProps(ctx, ch, narrationID, characters, props)
+
+	// 校验落库的场景/镜头与剧本 JSON 一致
+	if err := s.verifyNarrationPersistence(ctx, narrationID, jsonContent); err != nil {
+		_ = s.narrationRepo.UpdateStatus(ctx, narrationID, novel.TaskStatusFailed, err.Error())
+		return nil, err
+	}
+
+	// 所有操作成功，更新状态为 completed
+	if err := s.narrationRepo.UpdateStatus(ctx, narrationID, novel.TaskStatusCompleted, ""); err != nil {
+		log.Error().Err(err).
+			Str("narration_id", narrationID).
+			Msg("更新解说状态失败")
+		return nil, fmt.Errorf("failed to update narration status: %w", err)
+	}
+	narrationEntity.Status = novel.TaskStatusCompleted
+
+	persistDuration := time.Since(persistStartTime)
+	log.Info().
+		Str("narration_id", narrationID).
+		Str("chapter_id", ch.ID).
+		Int("version", version).
+		Int("scenes_count", len(scenes)).
+		Int("shots_count", len(shots)).
+		Dur("persist_duration", persistDuration).
+		Msg("剧本数据保存完成")
+
+	return narrationEntity, nil
+}
+
+// upsertCharactersAndProps 保存角色和道具（按名称去重：已存在则更新，否则创建）
+// 单个角色/道具保存失败只记录日志，不影响解说整体结果
+func (s *novelService) upsertCharactersAndProps(
+	ctx context.Context,
+	ch *novel.Chapter,
+	narrationID string,
+	characters []*novel.Character,
+	props []*novel.Prop,
+) {
 	// 保存角色（去重：如果角色已存在，则更新；否则创建）
 	if len(characters) > 0 {
 		log.Debug().
@@ -472,267 +705,46 @@ func (s *novelService) persistNarrationBatch(
 			Dur("save_duration", savePropsDuration).
 			Msg("道具数据保存完成")
 	}
-
-	// 所有操作成功，更新状态为 completed
-	if err := s.narrationRepo.UpdateStatus(ctx, narrationID, novel.TaskStatusCompleted, ""); err != nil {
-		log.Error().Err(err).
-			Str("narration_id", narrationID).
-			Msg("更新解说状态失败")
-		return nil, fmt.Errorf("failed to update narration status: %w", err)
-	}
-	narrationEntity.Status = novel.TaskStatusCompleted
-
-	persistDuration := time.Since(persistStartTime)
-	log.Info().
-		Str("narration_id", narrationID).
-		Str("chapter_id", ch.ID).
-		Int("version", version).
-		Int("scenes_count", len(scenes)).
-		Int("shots_count", len(shots)).
-		Dur("persist_duration", persistDuration).
-		Msg("剧本数据保存完成")
-
-	return narrationEntity, nil
 }
 
-// GenerateNarrationsForAllChapters 第三步：并发地根据每一章节内容生成章节对应的章节解说
-func (s *novelService) GenerateNarrationsForAllChapters(ctx context.Context, novelID string) error {
-	log.Info().
-		Str("novel_id", novelID).
-		Msg("开始为所有章节生成剧本")
+// GenerateNarrationsForAllChapters 第三步：为小说的每一章节提交解说生成任务（由任务队列的工作协程并发执行）
+func (s *novelService) GenerateNarrationsForAllChapters(ctx context.Context, novelID string) ([]*novel.Job, error) {
+	if err := s.killSwitch.Check(killswitch.ProviderLLM); err != nil {
+		return nil, err
+	}
+	if err := s.checkBudget(ctx, novelID); err != nil {
+		return nil, err
+	}
 
 	chapters, err := s.chapterRepo.FindByNovelID(ctx, novelID)
 	if err != nil {
 		log.Error().Err(err).Str("novel_id", novelID).Msg("获取章节列表失败")
-		return fmt.Errorf("failed to find chapters: %w", err)
+		return nil, fmt.Errorf("failed to find chapters: %w", err)
 	}
 	if len(chapters) == 0 {
 		log.Warn().Str("novel_id", novelID).Msg("未找到章节")
-		return fmt.Errorf("no chapters found for novelID=%s", novelID)
-	}
-
-	totalChapters := len(chapters)
-	log.Info().
-		Str("novel_id", novelID).
-		Int("total_chapters", totalChapters).
-		Msg("准备并发生成所有章节的剧本")
-
-	var wg sync.WaitGroup
-	errCh := make(chan error, totalChapters)
-
-	for _, ch := range chapters {
-		wg.Add(1)
-		go func(chapter *novel.Chapter) {
-			defer wg.Done()
-
-			log.Debug().
-				Str("chapter_id", chapter.ID).
-				Int("sequence", chapter.Sequence).
-				Int("word_count", chapter.WordCount).
-				Msg("开始生成章节剧本")
-
-			generator := noveltools.NewNarrationGenerator(s.llmProvider)
-			// 传递章节字数，用于根据章节长度调整 prompt 要求
-			llmStartTime := time.Now()
-			prompt, narrationText, err := generator.GenerateWithPrompt(ctx, chapter.ChapterText, chapter.Sequence, totalChapters, chapter.WordCount)
-			if err != nil {
-				log.Error().Err(err).
-					Str("chapter_id", chapter.ID).
-					Int("sequence", chapter.Sequence).
-					Dur("duration", time.Since(llmStartTime)).
-					Msg("LLM 生成章节剧本失败")
-				errCh <- fmt.Errorf("failed to generate narration for chapter %d: %w", chapter.Sequence, err)
-				return
-			}
-
-			llmDuration := time.Since(llmStartTime)
-			log.Info().
-				Str("chapter_id", chapter.ID).
-				Int("sequence", chapter.Sequence).
-				Int("narration_length", len(narrationText)).
-				Dur("llm_duration", llmDuration).
-				Msg("LLM 生成章节剧本完成")
-
-			narrationText = strings.TrimSpace(narrationText)
-			if narrationText == "" {
-				log.Error().
-					Str("chapter_id", chapter.ID).
-					Int("sequence", chapter.Sequence).
-					Msg("LLM 返回的剧本内容为空")
-				errCh <- fmt.Errorf("generated narrationText is empty for chapter %d", chapter.Sequence)
-				return
-			}
-
-			// 步骤1: 内容审查和过滤（参考 Python 的 audit_and_filter_narration）
-			// 极度宽松模式：仅提示，不阻断
-			filteredNarration, err := s.auditAndFilterNarration(ctx, narrationText, chapter.Sequence)
-			if err != nil {
-				// 即使审查出错，也继续使用原始内容（极度宽松模式）
-				filteredNarration = narrationText
-			}
-
-			// 步骤2: 解析 JSON 格式并验证
-			parseStartTime := time.Now()
-			jsonContent, err := noveltools.ParseNarrationJSON(filteredNarration)
-			if err != nil {
-				log.Error().Err(err).
-					Str("chapter_id", chapter.ID).
-					Int("sequence", chapter.Sequence).
-					Dur("duration", time.Since(parseStartTime)).
-					Msg("解析章节剧本 JSON 失败")
-				errCh <- fmt.Errorf("failed to parse narration for chapter %d: %w", chapter.Sequence, err)
-				return
-			}
-
-			// 基本验证：至少要有场景
-			if len(jsonContent.Scenes) == 0 {
-				log.Error().
-					Str("chapter_id", chapter.ID).
-					Int("sequence", chapter.Sequence).
-					Msg("剧本 JSON 验证失败：缺少 scenes 字段或 scenes 为空")
-				errCh <- fmt.Errorf("failed to validate narration for chapter %d: 缺少 scenes 字段或 scenes 为空", chapter.Sequence)
-				return
-			}
-
-			parseDuration := time.Since(parseStartTime)
-			log.Info().
-				Str("chapter_id", chapter.ID).
-				Int("sequence", chapter.Sequence).
-				Int("scenes_count", len(jsonContent.Scenes)).
-				Int("total_shots", s.countTotalShots(jsonContent)).
-				Dur("parse_duration", parseDuration).
-				Msg("章节剧本 JSON 解析成功")
-
-			// 生成下一个版本号（自动递增）
-			nextVersion, err := s.getNextNarrationVersion(ctx, chapter.ID)
-			if err != nil {
-				errCh <- fmt.Errorf("failed to get next version for chapter %d: %w", chapter.Sequence, err)
-				return
-			}
-
-			// 创建 Narration 记录（作为本次解说生成的批次标识）
-			narrationID := id.New()
-			narrationEntity := &novel.Narration{
-				ID:        narrationID,
-				ChapterID: chapter.ID,
-				NovelID:   chapter.NovelID,
-				UserID:    chapter.UserID,
-				Prompt:    prompt,
-				Version:   nextVersion,
-				Status:    novel.TaskStatusCompleted,
-			}
-			if err := s.narrationRepo.Create(ctx, narrationEntity); err != nil {
-				errCh <- fmt.Errorf("failed to create narration record for chapter %d: %w", chapter.Sequence, err)
-				return
-			}
-
-			// 步骤3: 将场景、镜头、角色和道具转换为实体并保存到独立的表中
-			scenes, shots, characters, props, err := noveltools.ConvertToScenesAndShots(narrationID, chapter.ID, chapter.NovelID, chapter.UserID, nextVersion, jsonContent)
-			if err != nil {
-				errCh <- fmt.Errorf("failed to convert scenes and shots for chapter %d: %w", chapter.Sequence, err)
-				return
-			}
-
-			// 批量保存场景
-			if len(scenes) > 0 {
-				if err := s.sceneRepo.CreateMany(ctx, scenes); err != nil {
-					errCh <- fmt.Errorf("failed to save scenes for chapter %d: %w", chapter.Sequence, err)
-					return
-				}
-			}
-
-			// 批量保存镜头
-			if len(shots) > 0 {
-				if err := s.shotRepo.CreateMany(ctx, shots); err != nil {
-					errCh <- fmt.Errorf("failed to save shots for chapter %d: %w", chapter.Sequence, err)
-					return
-				}
-			}
-
-			// 保存角色（去重：如果角色已存在，则更新；否则创建）
-			for _, char := range characters {
-				existing, err := s.characterRepo.FindByNameAndNovelID(ctx, char.Name, chapter.NovelID)
-				if err == nil && existing != nil {
-					// 角色已存在，更新信息
-					updates := bson.M{}
-					if char.Gender != "" {
-						updates["gender"] = char.Gender
-					}
-					if char.AgeGroup != "" {
-						updates["age_group"] = char.AgeGroup
-					}
-					if char.RoleNumber != "" {
-						updates["role_number"] = char.RoleNumber
-					}
-					if char.Description != "" {
-						updates["description"] = char.Description
-					}
-					if char.ImagePrompt != "" {
-						updates["image_prompt"] = char.ImagePrompt
-					}
-					if len(updates) > 0 {
-						_ = s.characterRepo.Update(ctx, existing.ID, updates)
-					}
-				} else {
-					// 角色不存在，创建新角色
-					char.CreatedAt = time.Now()
-					char.UpdatedAt = time.Now()
-					_ = s.characterRepo.Create(ctx, char)
-				}
-			}
-
-			// 保存道具（去重：如果道具已存在，则更新；否则创建）
-			for _, prop := range props {
-				existing, err := s.propRepo.FindByName(ctx, chapter.NovelID, prop.Name)
-				if err == nil && existing != nil {
-					// 道具已存在，更新信息
-					updates := map[string]interface{}{}
-					if prop.Description != "" {
-						updates["description"] = prop.Description
-					}
-					if prop.ImagePrompt != "" {
-						updates["image_prompt"] = prop.ImagePrompt
-					}
-					if prop.Category != "" {
-						updates["category"] = prop.Category
-					}
-					if len(updates) > 0 {
-						_ = s.propRepo.Update(ctx, existing.ID, updates)
-					}
-				} else {
-					// 道具不存在，创建新道具
-					prop.CreatedAt = time.Now()
-					prop.UpdatedAt = time.Now()
-					_ = s.propRepo.Create(ctx, prop)
-				}
-			}
-		}(ch)
-	}
-
-	wg.Wait()
-	close(errCh)
-
-	var errors []error
-	for err := range errCh {
-		errors = append(errors, err)
-	}
-
-	if len(errors) > 0 {
-		log.Error().
-			Str("novel_id", novelID).
-			Int("total_chapters", totalChapters).
-			Int("failed_count", len(errors)).
-			Int("success_count", totalChapters-len(errors)).
-			Msg("部分章节剧本生成失败")
-		return fmt.Errorf("failed to generate narrations for %d chapters: %v", len(errors), errors)
+		return nil, fmt.Errorf("no chapters found for novelID=%s", novelID)
+	}
+
+	userID, _ := ctxutil.GetUserID(ctx)
+	jobs := make([]*novel.Job, 0, len(chapters))
+	for _, chapter := range chapters {
+		job, err := s.EnqueueJob(ctx, &EnqueueJobRequest{
+			Type:      novel.PipelineStageNarration,
+			ChapterID: chapter.ID,
+			UserID:    userID,
+		})
+		if err != nil {
+			return jobs, fmt.Errorf("enqueue narration job for chapter %d: %w", chapter.Sequence, err)
+		}
+		jobs = append(jobs, job)
 	}
 
 	log.Info().
 		Str("novel_id", novelID).
-		Int("total_chapters", totalChapters).
-		Msg("所有章节剧本生成完成")
-
-	return nil
+		Int("total_chapters", len(chapters)).
+		Msg("所有章节的解说生成任务已提交")
+	return jobs, nil
 }
 
 // GetNarration 根据章节ID获取章节解说（返回最新版本）
@@ -740,6 +752,16 @@ func (s *novelService) GetNarration(ctx context.Context, chapterID string) (*nov
 	return s.narrationRepo.FindByChapterID(ctx, chapterID)
 }
 
+// GetNarrationByID 根据解说ID获取解说
+func (s *novelService) GetNarrationByID(ctx context.Context, narrationID string) (*novel.Narration, error) {
+	return s.narrationRepo.FindByID(ctx, narrationID)
+}
+
+// SubscribeNarrationEvents 订阅解说生成事件
+func (s *novelService) SubscribeNarrationEvents(narrationID string) (<-chan eventbus.Event, func()) {
+	return s.eventBus.Subscribe(narrationID)
+}
+
 // GetNarrationByVersion 根据章节ID和版本号获取章节解说
 func (s *novelService) GetNarrationByVersion(ctx context.Context, chapterID string, version int) (*novel.Narration, error) {
 	return s.narrationRepo.FindByChapterIDAndVersion(ctx, chapterID, version)
@@ -782,7 +804,7 @@ func (s *novelService) CreateNarrationVersionFromText(
 		return nil, fmt.Errorf("narration validation failed: 缺少 scenes 字段或 scenes 为空")
 	}
 
-	nextVersion, err := s.getNextNarrationVersion(ctx, chapterID)
+	nextVersion, err := s.nextVersion(ctx, chapterID, novel.VersionArtifactNarration)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get next version: %w", err)
 	}
@@ -814,6 +836,9 @@ func (s *novelService) UpdateShot(ctx context.Context, shotID string, updates ma
 
 // RegenerateShotScript 重新生成单个分镜头的脚本（调用 LLM）
 func (s *novelService) RegenerateShotScript(ctx context.Context, shotID string) error {
+	// 用户主动重新生成需要新的输出，不复用缓存的响应
+	ctx = respcache.Bypass(ctx)
+
 	// 1. 获取分镜头信息
 	shot, err := s.shotRepo.FindByID(ctx, shotID)
 	if err != nil {
@@ -864,11 +889,19 @@ func (s *novelService) RegenerateShotScript(ctx context.Context, shotID string)
 	)
 
 	// 5. 调用 LLM 生成优化后的脚本
-	generator := noveltools.NewNarrationGenerator(s.llmProvider)
-	_, optimizedText, err := generator.GenerateWithPrompt(ctx, prompt, chapter.Sequence, totalChapters, chapter.WordCount)
+	if err := s.checkBudget(ctx, chapter.NovelID); err != nil {
+		return err
+	}
+	if err := s.reserveRegeneration(ctx, shotRegenerationTarget(shot), novel.RegenerationKindShotScript); err != nil {
+		return err
+	}
+	prompt = s.withNovelContext(ctx, chapter.NovelID, prompt)
+	generator := noveltools.NewNarrationGenerator(s.llmProvider).WithStructure(s.novelNarrationStructure(ctx, chapter.NovelID))
+	fullPrompt, optimizedText, err := generator.GenerateWithPrompt(ctx, prompt, chapter.Sequence, totalChapters, chapter.WordCount)
 	if err != nil {
 		return fmt.Errorf("generate optimized script: %w", err)
 	}
+	s.recordLLMCost(ctx, chapter.NovelID, chapter.ID, fullPrompt, optimizedText)
 
 	// 6. 解析 JSON（简单的解析，只提取需要的字段）
 	var result struct {
@@ -938,27 +971,6 @@ func (s *novelService) getTotalChapters(ctx context.Context, novelID string) (in
 	return len(chapters), nil
 }
 
-// getNextNarrationVersion 获取章节的下一个版本号（自动递增）
-// chapterID: 章节ID
-// 例如：如果已有 1, 2，则返回 3
-// 注意：现在从 Scene 表中获取版本号，因为不再使用 Narration 表
-func (s *novelService) getNextNarrationVersion(ctx context.Context, chapterID string) (int, error) {
-	versions, err := s.narrationRepo.FindVersionsByChapterID(ctx, chapterID)
-	if err != nil || len(versions) == 0 {
-		return 1, nil
-	}
-	maxVersion := 0
-	for _, v := range versions {
-		if v > maxVersion {
-			maxVersion = v
-		}
-	}
-	if maxVersion == 0 {
-		return 1, nil
-	}
-	return maxVersion + 1, nil
-}
-
 // auditAndFilterNarration 对生成的章节解说内容进行审查和过滤（极度宽松模式）
 // 参考 Python 的 audit_and_filter_narration 方法
 // 仅提示，不阻断，即使检测到敏感内容也返回原始内容
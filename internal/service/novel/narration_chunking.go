@@ -0,0 +1,100 @@
+package novel
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/noveltools"
+)
+
+// narrationChunkOptionsFromEnv 读取长章节分块生成剧本的配置
+// NARRATION_CHUNK_THRESHOLD（超过该字数时分块）、NARRATION_CHUNK_SIZE、NARRATION_CHUNK_OVERLAP、NARRATION_CHUNK_CONCURRENCY，未配置时使用默认值
+func narrationChunkOptionsFromEnv() noveltools.NarrationChunkOptions {
+	opts := noveltools.DefaultNarrationChunkOptions()
+	if v, err := strconv.Atoi(os.Getenv("NARRATION_CHUNK_THRESHOLD")); err == nil && v > 0 {
+		opts.Threshold = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("NARRATION_CHUNK_SIZE")); err == nil && v > 0 {
+		opts.ChunkSize = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("NARRATION_CHUNK_OVERLAP")); err == nil && v >= 0 {
+		opts.Overlap = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("NARRATION_CHUNK_CONCURRENCY")); err == nil && v > 0 {
+		opts.Concurrency = v
+	}
+	return opts.Normalize()
+}
+
+// buildNarrationPrompt 构造章节剧本的提示词，按章节字数自动选择生成方式
+//   - 普通章节：整章原文直接放进提示词
+//   - 长章节（map-reduce）：先切分为重叠的分块并行生成分段剧本，再构造合并提示词，由合并阶段按小说的解说结构产出最终的场景
+//
+// 两种方式返回的提示词都交给 GenerateScenesStream 流式生成，后续的落库流程相同；
+// 最终提示词会替换小说创作设定中的变量并附加作品设定
+func (s *novelService) buildNarrationPrompt(
+	ctx context.Context,
+	ch *novel.Chapter,
+	totalChapters int,
+	generator *noveltools.NarrationGenerator,
+) (string, error) {
+	vars := s.novelPromptVariables(ctx, ch.NovelID)
+	withContext := func(prompt string, err error) (string, error) {
+		if err != nil {
+			return "", err
+		}
+		return noveltools.InjectNovelContext(prompt, vars), nil
+	}
+
+	wordCount := ch.WordCount
+	if wordCount <= 0 {
+		wordCount = noveltools.ChapterRuneCount(ch.ChapterText)
+	}
+	if !s.narrationChunking.ShouldChunk(wordCount) {
+		return withContext(generator.BuildPrompt(ch.ChapterText, ch.Sequence, totalChapters, ch.WordCount))
+	}
+
+	opts := s.narrationChunking.Normalize()
+	chunks := noveltools.SplitChapterChunks(ch.ChapterText, opts.ChunkSize, opts.Overlap)
+	if len(chunks) <= 1 {
+		return withContext(generator.BuildPrompt(ch.ChapterText, ch.Sequence, totalChapters, ch.WordCount))
+	}
+
+	// 分段生成会产生多次 LLM 调用，开始前先检查预算
+	if err := s.checkBudget(ctx, ch.NovelID); err != nil {
+		return "", err
+	}
+
+	mapStartTime := time.Now()
+	log.Info().
+		Str("chapter_id", ch.ID).
+		Int("word_count", wordCount).
+		Int("chunks", len(chunks)).
+		Msg("章节较长，分块生成分段剧本")
+
+	partials, err := generator.GeneratePartialNarrations(ctx, chunks, ch.Sequence, totalChapters, opts.Concurrency)
+	for _, p := range partials {
+		s.recordLLMCost(ctx, ch.NovelID, ch.ID, p.Prompt, p.Output)
+	}
+	if err != nil {
+		log.Error().Err(err).
+			Str("chapter_id", ch.ID).
+			Dur("duration", time.Since(mapStartTime)).
+			Msg("分块生成分段剧本失败")
+		return "", fmt.Errorf("generate partial narrations: %w", err)
+	}
+
+	log.Info().
+		Str("chapter_id", ch.ID).
+		Int("chunks", len(partials)).
+		Dur("map_duration", time.Since(mapStartTime)).
+		Msg("分段剧本生成完成，开始合并")
+
+	return withContext(generator.BuildMergePrompt(partials, ch.Sequence, totalChapters, wordCount))
+}
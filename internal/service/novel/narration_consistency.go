@@ -0,0 +1,50 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/pkg/noveltools"
+)
+
+// ErrNarrationInconsistent 落库的场景/镜头与剧本 JSON 不一致（丢失镜头、编号断档、提示词为空等）
+var ErrNarrationInconsistent = errors.New("narration scenes/shots inconsistent with parsed JSON")
+
+// verifyNarrationPersistence 重新读取解说落库的场景和镜头，与解析出的剧本 JSON 对比
+// 不一致时返回包含校验报告的 ErrNarrationInconsistent，调用方负责把解说标记为失败
+func (s *novelService) verifyNarrationPersistence(ctx context.Context, narrationID string, content *noveltools.NarrationJSONContent) error {
+	scenes, err := s.sceneRepo.FindByNarrationID(ctx, narrationID)
+	if err != nil {
+		return fmt.Errorf("reload scenes: %w", err)
+	}
+	shots, err := s.shotRepo.FindByNarrationID(ctx, narrationID)
+	if err != nil {
+		return fmt.Errorf("reload shots: %w", err)
+	}
+
+	report := noveltools.CheckNarrationConsistency(content, scenes, shots)
+	if report.OK() {
+		return nil
+	}
+
+	for _, issue := range report.Issues {
+		log.Warn().
+			Str("narration_id", narrationID).
+			Str("scene_number", issue.SceneNumber).
+			Str("shot_number", issue.ShotNumber).
+			Msg(issue.Message)
+	}
+	log.Error().
+		Str("narration_id", narrationID).
+		Int("expected_scenes", report.ExpectedScenes).
+		Int("persisted_scenes", report.PersistedScenes).
+		Int("expected_shots", report.ExpectedShots).
+		Int("persisted_shots", report.PersistedShots).
+		Int("issues", len(report.Issues)).
+		Msg("剧本落库校验失败")
+
+	return fmt.Errorf("%w: %s", ErrNarrationInconsistent, report)
+}
@@ -0,0 +1,174 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/killswitch"
+	"lemon/internal/pkg/noveltools"
+	"lemon/internal/pkg/respcache"
+)
+
+var (
+	// ErrEmptyNarrationFeedback 审核意见为空
+	ErrEmptyNarrationFeedback = errors.New("narration feedback is empty")
+	// ErrFeedbackSceneNotFound 审核意见指定的场景不存在于基础解说版本中
+	ErrFeedbackSceneNotFound = errors.New("feedback scene not found")
+)
+
+// RegenerateNarrationWithFeedbackRequest 按审核意见重新生成解说的请求
+type RegenerateNarrationWithFeedbackRequest struct {
+	NarrationID  string   // 基础解说ID（在该版本基础上修改）
+	ReviewerID   string   // 审核人ID
+	Feedback     string   // 审核意见
+	SceneNumbers []string // 需要按意见重写的场景编号（为空表示全部场景）
+}
+
+// RegenerateNarrationWithFeedback 按审核意见重新生成解说，生成新的解说版本
+// 目标场景连同审核意见一起交给 LLM 重写，其余场景原样保留；新版本记录促成它的审核意见
+func (s *novelService) RegenerateNarrationWithFeedback(ctx context.Context, req *RegenerateNarrationWithFeedbackRequest) (*novel.Narration, error) {
+	feedback := strings.TrimSpace(req.Feedback)
+	if feedback == "" {
+		return nil, ErrEmptyNarrationFeedback
+	}
+
+	// 用户主动重写需要新的输出，不复用缓存的响应
+	ctx = respcache.Bypass(ctx)
+
+	var n *novel.Narration
+	err := s.runNarrationStage(ctx, req.NarrationID, novel.PipelineStageNarration, func(ctx context.Context) error {
+		var err error
+		n, err = s.regenerateNarrationWithFeedback(ctx, req, feedback)
+		if n != nil {
+			setStageResources(ctx, n.ID)
+		}
+		return err
+	})
+	return n, err
+}
+
+func (s *novelService) regenerateNarrationWithFeedback(ctx context.Context, req *RegenerateNarrationWithFeedbackRequest, feedback string) (*novel.Narration, error) {
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderLLM)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	base, err := s.narrationRepo.FindByID(ctx, req.NarrationID)
+	if err != nil {
+		return nil, fmt.Errorf("find narration: %w", err)
+	}
+	ch, err := s.chapterRepo.FindByID(ctx, base.ChapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+	scenes, err := s.sceneRepo.FindByNarrationID(ctx, base.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find scenes: %w", err)
+	}
+	shots, err := s.shotRepo.FindByNarrationID(ctx, base.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find shots: %w", err)
+	}
+
+	// 确定需要重写的场景
+	targets := make(map[string]bool, len(req.SceneNumbers))
+	for _, num := range req.SceneNumbers {
+		if num = strings.TrimSpace(num); num != "" {
+			targets[num] = true
+		}
+	}
+	for num := range targets {
+		found := false
+		for _, sc := range scenes {
+			if sc.SceneNumber == num {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: scene %s", ErrFeedbackSceneNotFound, num)
+		}
+	}
+	if len(scenes) == 0 {
+		return nil, fmt.Errorf("%w: narration has no scenes", ErrFeedbackSceneNotFound)
+	}
+
+	if err := s.checkBudget(ctx, ch.NovelID); err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("narration_id", base.ID).
+		Str("chapter_id", ch.ID).
+		Int("base_version", base.Version).
+		Int("target_scenes", len(targets)).
+		Msg("开始按审核意见重新生成解说")
+
+	// 逐个场景重写：目标场景带上审核意见交给 LLM，其余场景沿用基础版本
+	jsonContent := &noveltools.NarrationJSONContent{}
+	var prompts []string
+	rewritten := make([]string, 0, len(scenes))
+	vars := s.novelPromptVariables(ctx, ch.NovelID)
+	for _, sc := range scenes {
+		jsonScene := noveltools.SceneToJSON(sc, shots)
+		if len(targets) > 0 && !targets[sc.SceneNumber] {
+			jsonContent.Scenes = append(jsonContent.Scenes, jsonScene)
+			continue
+		}
+
+		prompt, err := noveltools.BuildSceneFeedbackPrompt(ch.ChapterText, jsonScene, feedback)
+		if err != nil {
+			return nil, fmt.Errorf("build feedback prompt: %w", err)
+		}
+		prompt = noveltools.InjectNovelContext(prompt, vars)
+		output, err := s.llmProvider.Generate(ctx, prompt)
+		if err != nil {
+			return nil, fmt.Errorf("regenerate scene %s: %w", sc.SceneNumber, err)
+		}
+		s.recordLLMCost(ctx, ch.NovelID, ch.ID, prompt, output)
+
+		newScene, err := noveltools.ParseFeedbackScene(output, sc.SceneNumber)
+		if err != nil {
+			return nil, fmt.Errorf("regenerate scene %s: %w", sc.SceneNumber, err)
+		}
+		jsonContent.Scenes = append(jsonContent.Scenes, newScene)
+		prompts = append(prompts, prompt)
+		rewritten = append(rewritten, sc.SceneNumber)
+	}
+
+	nextVersion, err := s.nextVersion(ctx, ch.ID, novel.VersionArtifactNarration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get next version: %w", err)
+	}
+	n, err := s.persistNarrationBatch(ctx, ch, nextVersion, strings.Join(prompts, "\n\n"), jsonContent)
+	if err != nil {
+		return nil, err
+	}
+
+	n.Feedback = &novel.NarrationFeedback{
+		Comments:        feedback,
+		SceneNumbers:    rewritten,
+		ReviewerID:      req.ReviewerID,
+		BaseNarrationID: base.ID,
+		BaseVersion:     base.Version,
+		CreatedAt:       time.Now(),
+	}
+	if err := s.narrationRepo.UpdateFeedback(ctx, n.ID, n.Feedback); err != nil {
+		return nil, fmt.Errorf("save narration feedback: %w", err)
+	}
+
+	log.Info().
+		Str("narration_id", n.ID).
+		Str("base_narration_id", base.ID).
+		Int("version", n.Version).
+		Strs("scene_numbers", rewritten).
+		Msg("按审核意见重新生成解说完成")
+	return n, nil
+}
@@ -0,0 +1,173 @@
+package novel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/noveltools"
+)
+
+var (
+	// ErrNarrationPatchConflict 解说在读取后被其他补丁修改（小版本号不一致）
+	ErrNarrationPatchConflict = errors.New("narration patch conflict")
+	// ErrNarrationNotPatchable 解说还在生成或生成失败，不能打补丁
+	ErrNarrationNotPatchable = errors.New("narration is not patchable")
+)
+
+// NarrationPatchService 解说内联补丁服务接口
+type NarrationPatchService interface {
+	// PatchNarration 按 JSON Patch 修改解说版本中的镜头旁白、提示词和时长，小版本号加一并记录补丁
+	PatchNarration(ctx context.Context, req *PatchNarrationRequest) (*novel.NarrationPatch, error)
+
+	// ListNarrationPatches 查询解说的补丁记录（按小版本号升序）
+	ListNarrationPatches(ctx context.Context, narrationID string) ([]*novel.NarrationPatch, error)
+}
+
+// PatchNarrationRequest 解说补丁请求
+type PatchNarrationRequest struct {
+	NarrationID      string
+	UserID           string
+	BaseMinorVersion *int // 基于哪个小版本修改（可选，与当前小版本不一致时返回冲突）
+	Operations       []noveltools.NarrationPatchOperation
+}
+
+// PatchNarration 应用解说补丁
+// 路径按解说 JSON 的结构定位（场景按序号、镜头按场景内序号排列，下标从 0 开始），与 GET /narrations/{id}/scenes 返回的顺序一致
+func (s *novelService) PatchNarration(ctx context.Context, req *PatchNarrationRequest) (*novel.NarrationPatch, error) {
+	narration, err := s.narrationRepo.FindByID(ctx, req.NarrationID)
+	if err != nil {
+		return nil, fmt.Errorf("find narration: %w", err)
+	}
+	if narration.Status != novel.TaskStatusCompleted {
+		return nil, fmt.Errorf("%w: narration status is %s", ErrNarrationNotPatchable, narration.Status)
+	}
+	if req.BaseMinorVersion != nil && *req.BaseMinorVersion != narration.MinorVersion {
+		return nil, fmt.Errorf("%w: base minor version %d, current %d", ErrNarrationPatchConflict, *req.BaseMinorVersion, narration.MinorVersion)
+	}
+
+	scenes, err := s.sceneRepo.FindByNarrationID(ctx, narration.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find scenes: %w", err)
+	}
+	shots, err := s.shotRepo.FindByNarrationID(ctx, narration.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find shots: %w", err)
+	}
+	content := &noveltools.NarrationJSONContent{}
+	sceneShots := make([][]*novel.Shot, len(scenes))
+	for i, scene := range scenes {
+		content.Scenes = append(content.Scenes, noveltools.SceneToJSON(scene, shots))
+		sceneShots[i] = shotsOfScene(scene.ID, shots)
+	}
+
+	changes, err := noveltools.ApplyNarrationPatch(content, req.Operations)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("%w: no replace operation", noveltools.ErrInvalidNarrationPatch)
+	}
+
+	ok, err := s.narrationRepo.IncrementMinorVersion(ctx, narration.ID, narration.MinorVersion)
+	if err != nil {
+		return nil, fmt.Errorf("increment minor version: %w", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: narration was patched concurrently", ErrNarrationPatchConflict)
+	}
+
+	// 同一个场景/镜头的多次修改合并为一次更新（后面的操作覆盖前面的）
+	patch := &novel.NarrationPatch{
+		ID:           id.New(),
+		NarrationID:  narration.ID,
+		ChapterID:    narration.ChapterID,
+		NovelID:      narration.NovelID,
+		Version:      narration.Version,
+		MinorVersion: narration.MinorVersion + 1,
+		UserID:       req.UserID,
+	}
+	sceneUpdates := make(map[string]map[string]interface{})
+	shotUpdates := make(map[string]map[string]interface{})
+	for _, change := range changes {
+		scene := scenes[change.SceneIndex]
+		recorded := novel.NarrationPatchChange{
+			Path:     change.Path,
+			SceneID:  scene.ID,
+			Field:    change.Field,
+			OldValue: change.OldValue,
+			NewValue: change.NewValue,
+		}
+		if change.ShotIndex < 0 {
+			if sceneUpdates[scene.ID] == nil {
+				sceneUpdates[scene.ID] = make(map[string]interface{})
+			}
+			sceneUpdates[scene.ID][change.Field] = change.NewValue
+		} else {
+			shot := sceneShots[change.SceneIndex][change.ShotIndex]
+			recorded.ShotID = shot.ID
+			if shotUpdates[shot.ID] == nil {
+				shotUpdates[shot.ID] = make(map[string]interface{})
+			}
+			shotUpdates[shot.ID][change.Field] = change.NewValue
+		}
+		patch.Changes = append(patch.Changes, recorded)
+	}
+	for sceneID, updates := range sceneUpdates {
+		if err := s.sceneRepo.Update(ctx, sceneID, updates); err != nil {
+			return nil, fmt.Errorf("update scene %s: %w", sceneID, err)
+		}
+	}
+	for shotID, updates := range shotUpdates {
+		if err := s.shotRepo.Update(ctx, shotID, updates); err != nil {
+			return nil, fmt.Errorf("update shot %s: %w", shotID, err)
+		}
+	}
+
+	for _, op := range req.Operations {
+		recorded := novel.NarrationPatchOperation{Op: op.Op, Path: op.Path}
+		if len(op.Value) > 0 {
+			_ = json.Unmarshal(op.Value, &recorded.Value)
+		}
+		patch.Operations = append(patch.Operations, recorded)
+	}
+	if err := s.narrationPatchRepo.Create(ctx, patch); err != nil {
+		return nil, fmt.Errorf("save narration patch: %w", err)
+	}
+
+	log.Info().
+		Str("narration_id", narration.ID).
+		Int("version", narration.Version).
+		Int("minor_version", patch.MinorVersion).
+		Int("changes", len(patch.Changes)).
+		Msg("解说补丁已应用")
+	return patch, nil
+}
+
+// ListNarrationPatches 查询解说的补丁记录
+func (s *novelService) ListNarrationPatches(ctx context.Context, narrationID string) ([]*novel.NarrationPatch, error) {
+	if _, err := s.narrationRepo.FindByID(ctx, narrationID); err != nil {
+		return nil, fmt.Errorf("find narration: %w", err)
+	}
+	return s.narrationPatchRepo.FindByNarrationID(ctx, narrationID)
+}
+
+// shotsOfScene 返回场景的镜头（按场景内序号排序，与 noveltools.SceneToJSON 的顺序一致）
+func shotsOfScene(sceneID string, shots []*novel.Shot) []*novel.Shot {
+	var sceneShots []*novel.Shot
+	for _, shot := range shots {
+		if shot != nil && shot.SceneID == sceneID {
+			sceneShots = append(sceneShots, shot)
+		}
+	}
+	sort.SliceStable(sceneShots, func(i, j int) bool {
+		return sceneShots[i].Sequence < sceneShots[j].Sequence
+	})
+	return sceneShots
+}
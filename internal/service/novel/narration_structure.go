@@ -0,0 +1,82 @@
+package novel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/noveltools"
+)
+
+// NarrationStructureService 解说结构模板服务接口
+type NarrationStructureService interface {
+	// GetNarrationStructure 查询小说的解说结构及可选的内置模板
+	GetNarrationStructure(ctx context.Context, novelID string) (*NarrationStructureView, error)
+
+	// SetNarrationStructure 设置小说的解说结构（整体替换），只影响之后生成的剧本
+	SetNarrationStructure(ctx context.Context, novelID string, structure *novel.NarrationStructure) (*NarrationStructureView, error)
+}
+
+// NarrationStructureView 小说的解说结构
+type NarrationStructureView struct {
+	NovelID   string                     `json:"novel_id"`
+	Structure novel.NarrationStructure   `json:"structure"` // 生效的解说结构（未设置时为默认的 classic 模板）
+	Custom    bool                       `json:"custom"`    // 小说是否设置过解说结构
+	Presets   []novel.NarrationStructure `json:"presets"`   // 内置模板
+}
+
+// GetNarrationStructure 查询小说的解说结构
+func (s *novelService) GetNarrationStructure(ctx context.Context, novelID string) (*NarrationStructureView, error) {
+	n, err := s.novelRepo.FindByID(ctx, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+	return &NarrationStructureView{
+		NovelID:   n.ID,
+		Structure: noveltools.ResolveNarrationStructure(n.NarrationStructure),
+		Custom:    n.NarrationStructure != nil,
+		Presets:   noveltools.NarrationStructurePresets(),
+	}, nil
+}
+
+// SetNarrationStructure 设置小说的解说结构
+// 保存解析后的完整结构；校验失败返回 noveltools.ErrInvalidNarrationStructure
+func (s *novelService) SetNarrationStructure(ctx context.Context, novelID string, structure *novel.NarrationStructure) (*NarrationStructureView, error) {
+	normalized, err := noveltools.NormalizeNarrationStructure(structure)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.novelRepo.Update(ctx, novelID, map[string]interface{}{"narration_structure": normalized}); err != nil {
+		return nil, fmt.Errorf("update narration structure: %w", err)
+	}
+
+	log.Info().
+		Str("novel_id", novelID).
+		Str("template", normalized.Template).
+		Int("min_scenes", normalized.MinScenes).
+		Int("max_scenes", normalized.MaxScenes).
+		Int("target_duration", normalized.TargetDuration).
+		Msg("小说解说结构已更新")
+
+	return s.GetNarrationStructure(ctx, novelID)
+}
+
+// novelNarrationStructure 查询小说生效的解说结构，查询失败时使用默认结构（不阻断生成流程）
+func (s *novelService) novelNarrationStructure(ctx context.Context, novelID string) novel.NarrationStructure {
+	n, err := s.novelRepo.FindByID(ctx, novelID)
+	if err != nil {
+		log.Warn().Err(err).Str("novel_id", novelID).Msg("查询小说解说结构失败，使用默认结构")
+		return noveltools.DefaultNarrationStructure()
+	}
+	return noveltools.ResolveNarrationStructure(n.NarrationStructure)
+}
+
+// validateNarrationContent 按小说的解说结构校验解说文案
+// 字数要求与生成剧本时提示词中的要求一致（不区分章节长度，设置了目标时长时按目标时长）
+func (s *novelService) validateNarrationContent(ctx context.Context, novelID string, content *noveltools.NarrationJSONContent) *noveltools.ValidationResult {
+	structure := s.novelNarrationStructure(ctx, novelID)
+	minLength, maxLength := noveltools.NarrationWordRange(structure, 0)
+	return noveltools.ValidateNarrationContent(content, structure, minLength, maxLength)
+}
@@ -0,0 +1,102 @@
+package novel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/pkg/killswitch"
+	"lemon/internal/pkg/noveltools"
+)
+
+// NarrationValidationService 解说文案校验服务接口
+type NarrationValidationService interface {
+	// ValidateNarration 校验已落库的解说文案（字数、分镜数量、开头特写、近似重复镜头）并分析节奏/情绪曲线
+	// suggest 为 true 且存在节奏问题时，调用 LLM 给出需要压缩的场景
+	ValidateNarration(ctx context.Context, narrationID string, suggest bool) (*NarrationValidation, error)
+}
+
+// NarrationValidation 解说文案校验结果
+type NarrationValidation struct {
+	NarrationID  string                        `json:"narration_id"`
+	Version      int                           `json:"version"`
+	IsValid      bool                          `json:"is_valid"`
+	Message      string                        `json:"message"`
+	Warnings     []string                      `json:"warnings"`
+	TotalLength  int                           `json:"total_length"`            // 解说总字数
+	MergedShots  int                           `json:"merged_shots"`            // 可合并的近似重复镜头数
+	Pacing       *noveltools.PacingAnalysis    `json:"pacing,omitempty"`        // 节奏/情绪曲线分析
+	Suggestions  []noveltools.PacingSuggestion `json:"suggestions,omitempty"`   // LLM 给出的场景压缩建议
+	SuggestError string                        `json:"suggest_error,omitempty"` // 获取建议失败的原因（不影响校验结果）
+}
+
+// ValidateNarration 校验解说文案并分析节奏
+// LLM 建议是尽力而为的：熔断、预算不足或解析失败时只在 SuggestError 中说明
+func (s *novelService) ValidateNarration(ctx context.Context, narrationID string, suggest bool) (*NarrationValidation, error) {
+	narration, err := s.narrationRepo.FindByID(ctx, narrationID)
+	if err != nil {
+		return nil, fmt.Errorf("find narration: %w", err)
+	}
+	scenes, err := s.sceneRepo.FindByNarrationID(ctx, narration.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find scenes: %w", err)
+	}
+	shots, err := s.shotRepo.FindByNarrationID(ctx, narration.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find shots: %w", err)
+	}
+
+	content := &noveltools.NarrationJSONContent{}
+	for _, scene := range scenes {
+		content.Scenes = append(content.Scenes, noveltools.SceneToJSON(scene, shots))
+	}
+	result := s.validateNarrationContent(ctx, narration.NovelID, content)
+
+	validation := &NarrationValidation{
+		NarrationID: narration.ID,
+		Version:     narration.Version,
+		IsValid:     result.IsValid,
+		Message:     result.Message,
+		Warnings:    result.Warnings,
+		TotalLength: result.TotalLength,
+		MergedShots: len(result.MergedShots),
+		Pacing:      result.Pacing,
+	}
+	if !suggest || !result.Pacing.HasIssues() {
+		return validation, nil
+	}
+
+	suggestions, err := s.suggestPacingFixes(ctx, narration.NovelID, narration.ChapterID, content, result.Pacing)
+	if err != nil {
+		log.Warn().Err(err).Str("narration_id", narration.ID).Msg("获取节奏修改建议失败")
+		validation.SuggestError = err.Error()
+		return validation, nil
+	}
+	validation.Suggestions = suggestions
+	return validation, nil
+}
+
+// suggestPacingFixes 让 LLM 根据节奏分析指出需要压缩的场景
+func (s *novelService) suggestPacingFixes(ctx context.Context, novelID, chapterID string, content *noveltools.NarrationJSONContent, pacing *noveltools.PacingAnalysis) ([]noveltools.PacingSuggestion, error) {
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderLLM)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if err := s.checkBudget(ctx, novelID); err != nil {
+		return nil, err
+	}
+	prompt, err := noveltools.BuildPacingSuggestionPrompt(content, pacing)
+	if err != nil {
+		return nil, err
+	}
+	prompt = s.withNovelContext(ctx, novelID, prompt)
+	output, err := s.llmProvider.Generate(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("generate pacing suggestions: %w", err)
+	}
+	s.recordLLMCost(ctx, novelID, chapterID, prompt, output)
+	return noveltools.ParsePacingSuggestions(output, pacing)
+}
@@ -0,0 +1,375 @@
+package novel
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/noveltools"
+	"lemon/internal/pkg/tsa"
+	"lemon/internal/service"
+)
+
+const (
+	// notarizationAppendAttempts 追加公证记录时序号冲突（其他实例同时追加）的最多尝试次数
+	notarizationAppendAttempts = 5
+	// notarizationChainPageSize 校验整条公证链时每页读取的记录数
+	notarizationChainPageSize = 500
+	// maxNotarizationChainIssues 公证链校验报告最多列出的问题数
+	maxNotarizationChainIssues = 100
+)
+
+var (
+	// ErrInvalidNotarizationQuery 验证请求不合法（没有文件和哈希、哈希格式错误等）
+	ErrInvalidNotarizationQuery = errors.New("invalid notarization query")
+	// ErrNothingToNotarize 章节没有已发布的最终视频
+	ErrNothingToNotarize = errors.New("chapter has no promoted final video")
+	// ErrNotarizationConflict 多次尝试追加公证记录都与其他实例的追加冲突
+	ErrNotarizationConflict = errors.New("notarization sequence conflict")
+)
+
+// NotarizationService 最终视频公证服务接口
+// 章节最终视频发布后在只追加的公证登记表中记录文件的 SHA256，客户可以用交付的文件或哈希验证视频未被修改
+type NotarizationService interface {
+	// NotarizePromotedVideos 公证章节发布版本的最终视频（已公证的视频直接返回已有记录），用于补登记功能上线前发布的视频
+	NotarizePromotedVideos(ctx context.Context, chapterID string) ([]*novel.NotarizationRecord, error)
+
+	// VerifyNotarization 按文件内容或 SHA256 查询公证记录，并校验匹配记录在哈希链中的完整性
+	VerifyNotarization(ctx context.Context, req *VerifyNotarizationRequest) (*NotarizationVerification, error)
+
+	// ListChapterNotarizations 查询章节的公证记录（按序号倒序）
+	ListChapterNotarizations(ctx context.Context, chapterID string) ([]*novel.NotarizationRecord, error)
+
+	// VerifyNotarizationChain 从第一条记录开始校验整条公证链
+	VerifyNotarizationChain(ctx context.Context) (*NotarizationChainReport, error)
+}
+
+// VerifyNotarizationRequest 公证验证请求（File 和 SHA256 二选一，同时提供时使用 File）
+type VerifyNotarizationRequest struct {
+	File   io.Reader // 交付的视频文件
+	SHA256 string    // 视频文件的 SHA256（十六进制）
+}
+
+// NotarizationVerification 公证验证结果
+type NotarizationVerification struct {
+	SHA256    string               `json:"sha256"`    // 验证的文件哈希
+	Notarized bool                 `json:"notarized"` // 是否存在完整的公证记录
+	Matches   []*NotarizationMatch `json:"matches"`   // 哈希匹配的公证记录（同一文件可能被多个视频记录引用）
+}
+
+// NotarizationMatch 哈希匹配的公证记录及其校验结果
+type NotarizationMatch struct {
+	*novel.NotarizationRecord
+	Intact      bool                                `json:"intact"`           // 记录哈希正确，并且与前后记录的链接完整
+	Timestamped bool                                `json:"timestamped"`      // 是否有外部时间戳令牌
+	Issues      []noveltools.NotarizationChainIssue `json:"issues,omitempty"` // 校验发现的问题
+}
+
+// NotarizationChainReport 公证链校验报告
+type NotarizationChainReport struct {
+	Records        int64                               `json:"records"`                   // 校验的记录数
+	LatestSequence int64                               `json:"latest_sequence,omitempty"` // 最后一条记录的序号
+	LatestHash     string                              `json:"latest_hash,omitempty"`     // 最后一条记录的哈希（可对外发布作为链头）
+	Valid          bool                                `json:"valid"`                     // 整条链是否完整
+	Issues         []noveltools.NotarizationChainIssue `json:"issues,omitempty"`          // 发现的问题（最多 100 条）
+	Truncated      bool                                `json:"truncated,omitempty"`       // 问题数超过上限，只列出前 100 条
+}
+
+// WithTimestampAuthority 设置时间戳服务（RFC 3161），公证记录的哈希由时间戳服务签名后再写入登记表；
+// 未设置或时间戳服务不可用时只写入哈希链
+func WithTimestampAuthority(c *tsa.Client) Option {
+	return func(s *novelService) {
+		s.timestampAuthority = c
+	}
+}
+
+// NotarizePromotedVideos 公证章节发布版本的最终视频
+func (s *novelService) NotarizePromotedVideos(ctx context.Context, chapterID string) ([]*novel.NotarizationRecord, error) {
+	ch, err := s.chapterRepo.FindByID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+	if ch.PromotedVersions == nil || ch.PromotedVersions.Video == 0 {
+		return nil, ErrNothingToNotarize
+	}
+	records, err := s.notarizeChapterVideos(ctx, ch.ID, ch.PromotedVersions.Video, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: video version %d has no completed final video", ErrNothingToNotarize, ch.PromotedVersions.Video)
+	}
+	return records, nil
+}
+
+// notarizePromotions 公证一次批量发布中视频版本有变化的章节（后台执行，失败只记录日志）
+func (s *novelService) notarizePromotions(ctx context.Context, batchID string, applied []*appliedPromotion) {
+	for _, a := range applied {
+		if a.next == nil || a.next.Video == 0 || (a.previous != nil && a.previous.Video == a.next.Video) {
+			continue
+		}
+		if _, err := s.notarizeChapterVideos(ctx, a.chapterID, a.next.Video, batchID); err != nil {
+			log.Error().Err(err).Str("chapter_id", a.chapterID).Int("video_version", a.next.Video).Msg("公证发布的最终视频失败")
+		}
+	}
+}
+
+// notarizeChapterVideos 公证章节指定版本的所有已完成最终视频（授权版和预览版都会交付，分别登记）
+func (s *novelService) notarizeChapterVideos(ctx context.Context, chapterID string, version int, batchID string) ([]*novel.NotarizationRecord, error) {
+	videos, err := s.videoRepo.FindByChapterIDAndVersion(ctx, chapterID, version)
+	if err != nil {
+		return nil, fmt.Errorf("find final videos: %w", err)
+	}
+
+	var records []*novel.NotarizationRecord
+	for _, video := range videos {
+		if video.VideoType != novel.VideoTypeFinal || video.Status != novel.VideoStatusCompleted || video.VideoResourceID == "" {
+			continue
+		}
+		record, err := s.notarizeVideo(ctx, video, batchID)
+		if err != nil {
+			return records, fmt.Errorf("notarize video %s: %w", video.ID, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// notarizeVideo 公证一个最终视频，已公证时返回已有记录
+func (s *novelService) notarizeVideo(ctx context.Context, video *novel.Video, batchID string) (*novel.NotarizationRecord, error) {
+	existing, err := s.notarizationRepo.FindByVideoID(ctx, video.ID)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("find notarization: %w", err)
+	}
+
+	sum, size, err := s.resourceSHA256(ctx, video.VideoResourceID)
+	if err != nil {
+		return nil, err
+	}
+	record := &novel.NotarizationRecord{
+		ID:        id.New(),
+		NovelID:   video.NovelID,
+		ChapterID: video.ChapterID,
+		VideoID:   video.ID,
+		Version:   video.Version,
+		SHA256:    sum,
+		FileSize:  size,
+		BatchID:   batchID,
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	record, err = s.appendNotarization(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("video_id", video.ID).
+		Int64("sequence", record.Sequence).
+		Str("sha256", record.SHA256).
+		Bool("timestamped", len(record.TimestampToken) > 0).
+		Msg("最终视频已公证")
+	return record, nil
+}
+
+// resourceSHA256 返回资源文件的 SHA256 和大小；上传时没有记录哈希的资源下载后计算
+func (s *novelService) resourceSHA256(ctx context.Context, resourceID string) (string, int64, error) {
+	res, err := s.resourceService.GetResource(ctx, &service.GetResourceRequest{ResourceID: resourceID})
+	if err != nil {
+		return "", 0, fmt.Errorf("get resource %s: %w", resourceID, err)
+	}
+	if sum, err := noveltools.NormalizeSHA256(res.Resource.SHA256); err == nil {
+		return sum, res.Resource.FileSize, nil
+	}
+
+	file, err := s.resourceService.DownloadFile(ctx, &service.DownloadFileRequest{ResourceID: resourceID})
+	if err != nil {
+		return "", 0, fmt.Errorf("download resource %s: %w", resourceID, err)
+	}
+	defer file.Data.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, file.Data)
+	if err != nil {
+		return "", 0, fmt.Errorf("hash resource %s: %w", resourceID, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// appendNotarization 以「最后一条记录的序号 + 1」追加公证记录
+// 序号唯一索引保证多个实例同时追加时只有一个成功，失败的一方重新读取链头后重试；
+// 同一视频已被其他实例公证时返回那条记录
+func (s *novelService) appendNotarization(ctx context.Context, record *novel.NotarizationRecord) (*novel.NotarizationRecord, error) {
+	for attempt := 0; attempt < notarizationAppendAttempts; attempt++ {
+		record.Sequence, record.PrevHash = 1, ""
+		latest, err := s.notarizationRepo.FindLatest(ctx)
+		switch {
+		case err == nil:
+			record.Sequence, record.PrevHash = latest.Sequence+1, latest.RecordHash
+		case !errors.Is(err, mongo.ErrNoDocuments):
+			return nil, fmt.Errorf("find latest notarization: %w", err)
+		}
+		record.RecordHash = noveltools.NotarizationRecordHash(record)
+		s.timestampNotarization(ctx, record)
+
+		err = s.notarizationRepo.Append(ctx, record)
+		if err == nil {
+			return record, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("append notarization: %w", err)
+		}
+		if existing, findErr := s.notarizationRepo.FindByVideoID(ctx, record.VideoID); findErr == nil {
+			return existing, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: video %s", ErrNotarizationConflict, record.VideoID)
+}
+
+// timestampNotarization 请求时间戳服务为记录哈希签发时间戳令牌（未配置时跳过，失败时只记录日志）
+func (s *novelService) timestampNotarization(ctx context.Context, record *novel.NotarizationRecord) {
+	record.TimestampAuthority, record.TimestampToken, record.TimestampedAt = "", nil, nil
+	if s.timestampAuthority == nil {
+		return
+	}
+	digest, _ := hex.DecodeString(record.RecordHash)
+	token, err := s.timestampAuthority.Timestamp(ctx, digest)
+	if err != nil {
+		log.Warn().Err(err).Str("video_id", record.VideoID).Str("tsa", s.timestampAuthority.URL()).Msg("申请时间戳失败，公证记录不带时间戳")
+		return
+	}
+	genTime := token.GenTime
+	record.TimestampAuthority = s.timestampAuthority.URL()
+	record.TimestampToken = token.DER
+	record.TimestampedAt = &genTime
+}
+
+// VerifyNotarization 验证文件或哈希是否已公证
+func (s *novelService) VerifyNotarization(ctx context.Context, req *VerifyNotarizationRequest) (*NotarizationVerification, error) {
+	var sum string
+	switch {
+	case req.File != nil:
+		h := sha256.New()
+		if _, err := io.Copy(h, req.File); err != nil {
+			return nil, fmt.Errorf("hash file: %w", err)
+		}
+		sum = hex.EncodeToString(h.Sum(nil))
+	case req.SHA256 != "":
+		var err error
+		if sum, err = noveltools.NormalizeSHA256(req.SHA256); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidNotarizationQuery, err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: file or sha256 is required", ErrInvalidNotarizationQuery)
+	}
+
+	records, err := s.notarizationRepo.FindBySHA256(ctx, sum)
+	if err != nil {
+		return nil, fmt.Errorf("find notarizations: %w", err)
+	}
+	result := &NotarizationVerification{SHA256: sum, Matches: make([]*NotarizationMatch, 0, len(records))}
+	for _, r := range records {
+		issues, err := s.checkNotarizationLinks(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		match := &NotarizationMatch{
+			NotarizationRecord: r,
+			Intact:             len(issues) == 0,
+			Timestamped:        len(r.TimestampToken) > 0,
+			Issues:             issues,
+		}
+		result.Notarized = result.Notarized || match.Intact
+		result.Matches = append(result.Matches, match)
+	}
+	return result, nil
+}
+
+// checkNotarizationLinks 校验记录本身的哈希以及与前后两条记录的链接
+func (s *novelService) checkNotarizationLinks(ctx context.Context, r *novel.NotarizationRecord) ([]noveltools.NotarizationChainIssue, error) {
+	var issues []noveltools.NotarizationChainIssue
+	var prev *novel.NotarizationRecord
+	if r.Sequence > 1 {
+		p, err := s.notarizationRepo.FindBySequence(ctx, r.Sequence-1)
+		switch {
+		case err == nil:
+			prev = p
+		case errors.Is(err, mongo.ErrNoDocuments):
+			issues = append(issues, noveltools.NotarizationChainIssue{Sequence: r.Sequence, RecordID: r.ID, Reason: fmt.Sprintf("record of sequence %d is missing", r.Sequence-1)})
+			// 上一条记录缺失时只校验记录本身的哈希
+			prev = &novel.NotarizationRecord{Sequence: r.Sequence - 1, RecordHash: r.PrevHash}
+		default:
+			return nil, fmt.Errorf("find notarization %d: %w", r.Sequence-1, err)
+		}
+	}
+	issues = append(issues, noveltools.CheckNotarizationChain(prev, []*novel.NotarizationRecord{r})...)
+
+	next, err := s.notarizationRepo.FindBySequence(ctx, r.Sequence+1)
+	switch {
+	case err == nil:
+		if next.PrevHash != r.RecordHash {
+			issues = append(issues, noveltools.NotarizationChainIssue{Sequence: next.Sequence, RecordID: next.ID, Reason: fmt.Sprintf("prev_hash does not match record hash of sequence %d", r.Sequence)})
+		}
+	case !errors.Is(err, mongo.ErrNoDocuments):
+		return nil, fmt.Errorf("find notarization %d: %w", r.Sequence+1, err)
+	}
+	return issues, nil
+}
+
+// ListChapterNotarizations 查询章节的公证记录
+func (s *novelService) ListChapterNotarizations(ctx context.Context, chapterID string) ([]*novel.NotarizationRecord, error) {
+	if _, err := s.chapterRepo.FindByID(ctx, chapterID); err != nil {
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+	records, err := s.notarizationRepo.FindByChapterID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find notarizations: %w", err)
+	}
+	return records, nil
+}
+
+// VerifyNotarizationChain 分页校验整条公证链
+func (s *novelService) VerifyNotarizationChain(ctx context.Context) (*NotarizationChainReport, error) {
+	report := &NotarizationChainReport{}
+	var prev *novel.NotarizationRecord
+	for {
+		var after int64
+		if prev != nil {
+			after = prev.Sequence
+		}
+		page, err := s.notarizationRepo.FindAfterSequence(ctx, after, notarizationChainPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("find notarizations after %d: %w", after, err)
+		}
+		for _, issue := range noveltools.CheckNotarizationChain(prev, page) {
+			if len(report.Issues) >= maxNotarizationChainIssues {
+				report.Truncated = true
+				break
+			}
+			report.Issues = append(report.Issues, issue)
+		}
+		report.Records += int64(len(page))
+		if len(page) > 0 {
+			prev = page[len(page)-1]
+		}
+		if len(page) < notarizationChainPageSize {
+			break
+		}
+	}
+
+	if prev != nil {
+		report.LatestSequence, report.LatestHash = prev.Sequence, prev.RecordHash
+	}
+	report.Valid = len(report.Issues) == 0
+	return report, nil
+}
@@ -2,13 +2,24 @@ package novel
 
 import (
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
 
+	"lemon/internal/model/novel"
 	"lemon/internal/pkg/ark"
+	"lemon/internal/pkg/assetcache"
+	"lemon/internal/pkg/budget"
+	"lemon/internal/pkg/eventbus"
+	"lemon/internal/pkg/ffmpeg"
+	"lemon/internal/pkg/killswitch"
 	"lemon/internal/pkg/noveltools"
 	"lemon/internal/pkg/noveltools/providers"
-	"lemon/internal/pkg/tts"
+	"lemon/internal/pkg/procreaper"
+	"lemon/internal/pkg/respcache"
+	"lemon/internal/pkg/tsa"
 	novelrepo "lemon/internal/repository/novel"
 	"lemon/internal/service"
 )
@@ -23,26 +34,150 @@ type NovelService interface {
 	ImageService
 	CharacterService
 	VideoService
+	StyleReferenceService
+	BGMService
+	BudgetService
+	PromotionService
+	PrewarmService
+	AccessService
+	CleanupService
+	ShotClipPreviewService
+	QAScorecardService
+	NarrationValidationService
+	CreativeMetadataService
+	OnboardingService
+	ReadingTimeService
+	PronunciationService
+	ImpactService
+	VideoTaskService
+	CoverService
+	GenerationSettingsService
+	ContentRiskService
+	RegenerationService
+	CompilationService
+	ChapterSummaryService
+	NarrationStructureService
+	ChapterBranchService
+	VoiceCastingService
+	StandbyService
+	WebhookService
+	NarrationPatchService
+	JobService
+	JobWorkerService
+	WorkflowTemplateService
+	AttributionService
+	PipelineService
+	FailureDiagnosisService
+	NovelVideoService
+	AnalyticsExportService
+	GenerationRequestService
+	ArtifactService
+	IncrementalAudioService
+	LifecycleService
+	NotarizationService
+	ListQueryService
 }
 
 // novelService 小说服务实现
 type novelService struct {
-	resourceService service.ResourceService
-	novelRepo       novelrepo.NovelRepository
-	chapterRepo     novelrepo.ChapterRepository
-	narrationRepo   novelrepo.NarrationRepository
-	sceneRepo       novelrepo.SceneRepository
-	shotRepo        novelrepo.ShotRepository
-	audioRepo       novelrepo.AudioRepository
-	subtitleRepo    novelrepo.SubtitleRepository
-	characterRepo   novelrepo.CharacterRepository
-	propRepo        novelrepo.PropRepository
-	imageRepo       novelrepo.ImageRepository
-	videoRepo       novelrepo.VideoRepository
-	llmProvider     noveltools.LLMProvider
-	ttsProvider     noveltools.TTSProvider
-	imageProvider   noveltools.ImageProvider
-	videoProvider   noveltools.VideoProvider
+	resourceService          service.ResourceService
+	novelRepo                novelrepo.NovelRepository
+	chapterRepo              novelrepo.ChapterRepository
+	narrationRepo            novelrepo.NarrationRepository
+	sceneRepo                novelrepo.SceneRepository
+	shotRepo                 novelrepo.ShotRepository
+	audioRepo                novelrepo.AudioRepository
+	subtitleRepo             novelrepo.SubtitleRepository
+	characterRepo            novelrepo.CharacterRepository
+	propRepo                 novelrepo.PropRepository
+	imageRepo                novelrepo.ImageRepository
+	videoRepo                novelrepo.VideoRepository
+	licenseGrantRepo         novelrepo.LicenseGrantRepository
+	sceneImageVariantRepo    novelrepo.SceneImageVariantRepository
+	styleReferenceRepo       novelrepo.StyleReferenceRepository
+	bgmTrackRepo             novelrepo.BGMTrackRepository
+	shotClipPreviewRepo      novelrepo.ShotClipPreviewRepository
+	costRecordRepo           novelrepo.CostRecordRepository
+	budgetEventRepo          novelrepo.BudgetEventRepository
+	prewarmJobRepo           novelrepo.PrewarmJobRepository
+	standbyJobRepo           novelrepo.StandbyJobRepository
+	webhookRepo              novelrepo.WebhookRepository
+	webhookDeliveryRepo      novelrepo.WebhookDeliveryRepository
+	narrationPatchRepo       novelrepo.NarrationPatchRepository
+	jobRepo                  novelrepo.JobRepository
+	jobWorkerRepo            novelrepo.JobWorkerRepository
+	workflowTemplateRepo     novelrepo.WorkflowTemplateRepository
+	pipelineRunRepo          novelrepo.PipelineRunRepository
+	novelGrantRepo           novelrepo.NovelGrantRepository
+	stageRunRepo             novelrepo.StageRunRepository
+	chapterEventRepo         novelrepo.ChapterEventRepository
+	creatorProfileRepo       novelrepo.CreatorProfileRepository
+	pronunciationRepo        novelrepo.PronunciationRepository
+	promotionRecordRepo      novelrepo.PromotionRecordRepository
+	novelCoverRepo           novelrepo.NovelCoverRepository
+	userSettingsRepo         novelrepo.UserSettingsRepository
+	contentRiskReportRepo    novelrepo.ContentRiskReportRepository
+	regenOverrideRepo        novelrepo.RegenerationOverrideRepository
+	compilationRepo          novelrepo.CompilationRepository
+	versionCounterRepo       novelrepo.VersionCounterRepository
+	analyticsExportBatchRepo novelrepo.AnalyticsExportBatchRepository
+	generationRequestRepo    novelrepo.GenerationRequestRepository
+	lifecycleRepo            novelrepo.LifecycleRepository
+	notarizationRepo         novelrepo.NotarizationRepository
+	resourceOwnerRepo        novelrepo.ResourceOwnerRepository
+	llmProvider              noveltools.LLMProvider
+	ttsProvider              noveltools.TTSProvider
+	ttsSegmentMaxChars       int                              // 单次 TTS 请求的最大字符数，超过时分段合成
+	narrationChunking        noveltools.NarrationChunkOptions // 长章节分块生成剧本的配置
+	bgmCrossfade             float64                          // 场景切换背景音乐时的交叉淡化时长（秒）
+	bgmDucking               ffmpeg.BGMDucking                // 解说时压低背景音乐的参数
+	embedManifest            bool                             // 是否把流水线清单嵌入最终视频的 MP4 元数据
+	qaMinScore               float64                          // 允许发布的最低 QA 分数
+	scrubAids                bool                             // 音频/视频完成后是否生成编辑器拖动辅助文件（波形、缩略图雪碧图）
+	audioDescription         bool                             // 最终视频是否默认生成口述影像音轨
+	voiceCatalog             []noveltools.VoiceProfile        // 角色选角可选的 TTS 音色目录
+	imageConcurrency         int                              // 图片提供者不支持批量提交时并发生成的数量
+	generationDefaults       novel.GenerationSettings         // 系统默认生成参数（环境变量配置）
+	regenerationLimits       budget.RegenerationLimits        // 单个场景/镜头的重新生成次数限制
+	webhookMaxAttempts       int                              // 任务通知最多尝试次数（含第一次）
+	jobWorkers               int                              // 任务队列工作协程数（0 表示本实例不执行任务）
+	jobMaxAttempts           int                              // 队列任务最多执行次数（含第一次）
+	analyticsExportInterval  time.Duration                    // 分析数据定时导出间隔（0 表示不定时导出）
+	imageProvider            noveltools.ImageProvider
+	videoProvider            noveltools.VideoProvider
+	pricing                  *budget.Pricing    // 各 provider 单价（用于预算统计）
+	eventBus                 *eventbus.Bus      // 进程内事件总线（解说生成进度等）
+	killSwitch               *killswitch.Switch // 熔断开关（维护模式下暂停生成任务）
+	assetCache               *assetcache.Cache  // 本地资源缓存（素材预热，可选）
+	providerCache            *respcache.Cache   // provider 响应缓存（可选）
+	procReaper               *procreaper.Reaper // 孤儿进程与临时文件回收器（可选，按任务记录 ffmpeg 进程和临时目录）
+	timestampAuthority       *tsa.Client        // 公证记录的时间戳服务（RFC 3161，可选）
+	prewarmLeadTime          time.Duration      // 渲染窗口开始前多久开始预热
+	assetCacheMaxAge         time.Duration      // 超过该时间未访问的缓存文件在预热前清理
+	standbyRuns              *runCancels        // 本实例执行中的下一章预生成任务（用于取消）
+	jobRuns                  *runCancels        // 本实例执行中的队列任务（用于取消）
+	jobWake                  chan struct{}      // 提交任务时唤醒空闲的工作协程
+	jobNodeID                string             // 本实例在任务队列中的节点ID（主机名-进程号）
+	jobCordoned              atomic.Bool        // 本实例是否已被隔离（不再领取新任务）
+	jobWorkersDone           sync.WaitGroup     // 本实例的工作协程（停止时等待执行中的任务放回队列）
+}
+
+// Option 小说服务可选配置
+type Option func(*novelService)
+
+// WithKillSwitch 设置熔断开关，未设置时使用一个始终关闭的开关
+func WithKillSwitch(ks *killswitch.Switch) Option {
+	return func(s *novelService) {
+		s.killSwitch = ks
+	}
+}
+
+// WithProcReaper 设置进程回收器：队列任务、下一章预生成和长视频拼接启动的 ffmpeg 进程和临时目录按任务记录，
+// 任务结束后仍在运行的进程在回收时结束
+func WithProcReaper(r *procreaper.Reaper) Option {
+	return func(s *novelService) {
+		s.procReaper = r
+	}
 }
 
 // NewNovelService 创建小说服务
@@ -50,6 +185,7 @@ type novelService struct {
 func NewNovelService(
 	db *mongo.Database,
 	resourceService service.ResourceService,
+	opts ...Option,
 ) (NovelService, error) {
 	// 初始化所有 repository
 	novelRepo := novelrepo.NewNovelRepo(db)
@@ -63,53 +199,167 @@ func NewNovelService(
 	propRepo := novelrepo.NewPropRepo(db)
 	imageRepo := novelrepo.NewImageRepo(db)
 	videoRepo := novelrepo.NewVideoRepo(db)
+	licenseGrantRepo := novelrepo.NewLicenseGrantRepo(db)
+	sceneImageVariantRepo := novelrepo.NewSceneImageVariantRepo(db)
+	styleReferenceRepo := novelrepo.NewStyleReferenceRepo(db)
+	bgmTrackRepo := novelrepo.NewBGMTrackRepo(db)
+	shotClipPreviewRepo := novelrepo.NewShotClipPreviewRepo(db)
+	costRecordRepo := novelrepo.NewCostRecordRepo(db)
+	budgetEventRepo := novelrepo.NewBudgetEventRepo(db)
+	prewarmJobRepo := novelrepo.NewPrewarmJobRepo(db)
+	standbyJobRepo := novelrepo.NewStandbyJobRepo(db)
+	webhookRepo := novelrepo.NewWebhookRepo(db)
+	webhookDeliveryRepo := novelrepo.NewWebhookDeliveryRepo(db)
+	narrationPatchRepo := novelrepo.NewNarrationPatchRepo(db)
+	jobRepo := novelrepo.NewJobRepo(db)
+	jobWorkerRepo := novelrepo.NewJobWorkerRepo(db)
+	workflowTemplateRepo := novelrepo.NewWorkflowTemplateRepo(db)
+	pipelineRunRepo := novelrepo.NewPipelineRunRepo(db)
+	novelGrantRepo := novelrepo.NewNovelGrantRepo(db)
+	stageRunRepo := novelrepo.NewStageRunRepo(db)
+	chapterEventRepo := novelrepo.NewChapterEventRepo(db)
+	creatorProfileRepo := novelrepo.NewCreatorProfileRepo(db)
+	pronunciationRepo := novelrepo.NewPronunciationRepo(db)
+	promotionRecordRepo := novelrepo.NewPromotionRecordRepo(db)
+	novelCoverRepo := novelrepo.NewNovelCoverRepo(db)
+	userSettingsRepo := novelrepo.NewUserSettingsRepo(db)
+	contentRiskReportRepo := novelrepo.NewContentRiskReportRepo(db)
+	regenOverrideRepo := novelrepo.NewRegenerationOverrideRepo(db)
+	compilationRepo := novelrepo.NewCompilationRepo(db)
+	versionCounterRepo := novelrepo.NewVersionCounterRepo(db)
+	analyticsExportBatchRepo := novelrepo.NewAnalyticsExportBatchRepo(db)
+	generationRequestRepo := novelrepo.NewGenerationRequestRepo(db)
+	lifecycleRepo := novelrepo.NewLifecycleRepo(db)
+	notarizationRepo := novelrepo.NewNotarizationRepo(db)
+	resourceOwnerRepo := novelrepo.NewResourceOwnerRepo(db)
+
+	svc := &novelService{
+		resourceService:          resourceService,
+		novelRepo:                novelRepo,
+		chapterRepo:              chapterRepo,
+		narrationRepo:            narrationRepo,
+		sceneRepo:                sceneRepo,
+		shotRepo:                 shotRepo,
+		audioRepo:                audioRepo,
+		subtitleRepo:             subtitleRepo,
+		characterRepo:            characterRepo,
+		propRepo:                 propRepo,
+		imageRepo:                imageRepo,
+		videoRepo:                videoRepo,
+		licenseGrantRepo:         licenseGrantRepo,
+		sceneImageVariantRepo:    sceneImageVariantRepo,
+		styleReferenceRepo:       styleReferenceRepo,
+		bgmTrackRepo:             bgmTrackRepo,
+		shotClipPreviewRepo:      shotClipPreviewRepo,
+		costRecordRepo:           costRecordRepo,
+		budgetEventRepo:          budgetEventRepo,
+		prewarmJobRepo:           prewarmJobRepo,
+		standbyJobRepo:           standbyJobRepo,
+		webhookRepo:              webhookRepo,
+		webhookDeliveryRepo:      webhookDeliveryRepo,
+		narrationPatchRepo:       narrationPatchRepo,
+		jobRepo:                  jobRepo,
+		jobWorkerRepo:            jobWorkerRepo,
+		workflowTemplateRepo:     workflowTemplateRepo,
+		pipelineRunRepo:          pipelineRunRepo,
+		novelGrantRepo:           novelGrantRepo,
+		stageRunRepo:             stageRunRepo,
+		chapterEventRepo:         chapterEventRepo,
+		creatorProfileRepo:       creatorProfileRepo,
+		pronunciationRepo:        pronunciationRepo,
+		promotionRecordRepo:      promotionRecordRepo,
+		novelCoverRepo:           novelCoverRepo,
+		userSettingsRepo:         userSettingsRepo,
+		contentRiskReportRepo:    contentRiskReportRepo,
+		regenOverrideRepo:        regenOverrideRepo,
+		compilationRepo:          compilationRepo,
+		versionCounterRepo:       versionCounterRepo,
+		analyticsExportBatchRepo: analyticsExportBatchRepo,
+		generationRequestRepo:    generationRequestRepo,
+		lifecycleRepo:            lifecycleRepo,
+		notarizationRepo:         notarizationRepo,
+		resourceOwnerRepo:        resourceOwnerRepo,
+		pricing:                  budget.PricingFromEnv(),
+		ttsSegmentMaxChars:       ttsSegmentMaxCharsFromEnv(),
+		narrationChunking:        narrationChunkOptionsFromEnv(),
+		bgmCrossfade:             bgmCrossfadeFromEnv(),
+		bgmDucking:               bgmDuckingFromEnv(),
+		embedManifest:            embedPipelineManifestFromEnv(),
+		qaMinScore:               qaMinScoreFromEnv(),
+		scrubAids:                scrubAidsFromEnv(),
+		audioDescription:         audioDescriptionFromEnv(),
+		voiceCatalog:             voiceCatalogFromEnv(),
+		imageConcurrency:         imageGenerationConcurrencyFromEnv(),
+		generationDefaults:       generationDefaultsFromEnv(),
+		regenerationLimits:       budget.RegenerationLimitsFromEnv(),
+		webhookMaxAttempts:       webhookMaxAttemptsFromEnv(),
+		jobWorkers:               jobWorkersFromEnv(),
+		jobMaxAttempts:           jobMaxAttemptsFromEnv(),
+		analyticsExportInterval:  analyticsExportIntervalFromEnv(),
+		eventBus:                 eventbus.New(),
+		standbyRuns:              newRunCancels(),
+		jobRuns:                  newRunCancels(),
+		jobWake:                  make(chan struct{}, 1),
+		jobNodeID:                jobNodeIDOfProcess(),
+		killSwitch:               killswitch.New(killswitch.PolicyFinish),
+	}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	if err := svc.initProviders(); err != nil {
+		return nil, err
+	}
+	return svc, nil
+}
+
+// WithProviders 注入生成能力 provider（如回归测试使用的合成 provider），为 nil 的 provider 仍按环境变量配置创建
+func WithProviders(llm noveltools.LLMProvider, tts noveltools.TTSProvider, image noveltools.ImageProvider, video noveltools.VideoProvider) Option {
+	return func(s *novelService) {
+		s.llmProvider = llm
+		s.ttsProvider = tts
+		s.imageProvider = image
+		s.videoProvider = video
+	}
+}
 
-	// 初始化 LLM Provider（从环境变量读取配置）
-	aiCfg := ark.ArkConfigFromEnv()
-	arkClient, err := ark.NewLLMClient(aiCfg)
-	if err != nil {
-		return nil, fmt.Errorf("初始化 LLM Provider 失败: %w", err)
+// initProviders 创建未注入的 provider（从环境变量读取配置）
+func (s *novelService) initProviders() error {
+	if s.llmProvider == nil {
+		aiCfg := ark.ArkConfigFromEnv()
+		arkClient, err := ark.NewLLMClient(aiCfg)
+		if err != nil {
+			return fmt.Errorf("初始化 LLM Provider 失败: %w", err)
+		}
+		s.llmProvider = providers.NewArkProvider(arkClient)
 	}
-	llmProvider := providers.NewArkProvider(arkClient)
 
-	// 初始化 TTS Provider（从环境变量读取配置）
-	ttsConfig := tts.ConfigFromEnv()
-	ttsClient, err := tts.NewClient(ttsConfig)
-	if err != nil {
-		return nil, fmt.Errorf("初始化 TTS Provider 失败: %w", err)
+	// 按 TTS_PROVIDER 选择 TTS 提供者（字节跳动、Azure、OpenAI）
+	if s.ttsProvider == nil {
+		ttsProvider, err := providers.NewTTSProviderFromEnv()
+		if err != nil {
+			return fmt.Errorf("初始化 TTS Provider 失败: %w", err)
+		}
+		s.ttsProvider = ttsProvider
 	}
-	ttsProvider := providers.NewByteDanceTTSProvider(ttsClient)
 
-	// 初始化 Image Provider（从环境变量读取配置）
 	// 使用 Ark 图片生成（使用官方 Go SDK）
-	imageProvider, err := providers.NewArkImageProvider()
-	if err != nil {
-		return nil, fmt.Errorf("初始化 Image Provider 失败: %w", err)
+	if s.imageProvider == nil {
+		imageProvider, err := providers.NewArkImageProvider()
+		if err != nil {
+			return fmt.Errorf("初始化 Image Provider 失败: %w", err)
+		}
+		s.imageProvider = imageProvider
 	}
 
-	// 初始化 Video Provider（从环境变量读取配置）
 	// 使用 Ark 视频生成
-	videoProvider, err := providers.NewArkVideoProvider()
-	if err != nil {
-		return nil, fmt.Errorf("初始化 Video Provider 失败: %w", err)
+	if s.videoProvider == nil {
+		videoProvider, err := providers.NewArkVideoProvider()
+		if err != nil {
+			return fmt.Errorf("初始化 Video Provider 失败: %w", err)
+		}
+		s.videoProvider = videoProvider
 	}
 
-	return &novelService{
-		resourceService: resourceService,
-		novelRepo:       novelRepo,
-		chapterRepo:     chapterRepo,
-		narrationRepo:   narrationRepo,
-		sceneRepo:       sceneRepo,
-		shotRepo:        shotRepo,
-		audioRepo:       audioRepo,
-		subtitleRepo:    subtitleRepo,
-		characterRepo:   characterRepo,
-		propRepo:        propRepo,
-		imageRepo:       imageRepo,
-		videoRepo:       videoRepo,
-		llmProvider:     llmProvider,
-		ttsProvider:     ttsProvider,
-		imageProvider:   imageProvider,
-		videoProvider:   videoProvider,
-	}, nil
+	s.wrapProviderCache()
+	return nil
 }
@@ -0,0 +1,369 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ffmpeg"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/noveltools"
+	"lemon/internal/pkg/procreaper"
+	"lemon/internal/service"
+)
+
+// novelVideoTitleCardDuration 章节之间标题画面的时长（秒）
+const novelVideoTitleCardDuration = 3.0
+
+var (
+	// ErrInvalidNovelVideo 长视频拼接请求不合法（章节范围无效或范围内没有章节）
+	ErrInvalidNovelVideo = errors.New("invalid novel video")
+	// ErrNovelVideoNotReady 范围内有章节还没有发布的最终视频
+	ErrNovelVideoNotReady = errors.New("chapters have no promoted final video")
+)
+
+// NovelVideoService 长视频拼接服务接口
+type NovelVideoService interface {
+	// AssembleNovelVideo 按章节顺序拼接各章节发布版本的最终视频，章节之间插入标题画面，生成带章节标记的长视频
+	// 拼接由任务队列执行（novel_video 任务，可查询、取消和重试），返回处理中的视频记录（job_id 为任务ID），完成后状态变为 completed
+	AssembleNovelVideo(ctx context.Context, req *AssembleNovelVideoRequest) (*novel.Video, error)
+
+	// ListNovelVideos 查询小说的长视频（按创建时间倒序）
+	ListNovelVideos(ctx context.Context, novelID string, limit int) ([]*novel.Video, error)
+}
+
+// AssembleNovelVideoRequest 拼接长视频请求
+type AssembleNovelVideoRequest struct {
+	NovelID        string // 小说ID
+	UserID         string // 操作用户ID（为空时使用小说所有者）
+	ChapterFrom    int    // 起始章节序号（0 表示第一章）
+	ChapterTo      int    // 结束章节序号（0 表示最后一章）
+	SkipTitleCards bool   // 不插入章节标题画面
+}
+
+// novelVideoPart 长视频中的一个章节及其发布版本的最终视频
+type novelVideoPart struct {
+	chapter *novel.Chapter
+	video   *novel.Video
+}
+
+// AssembleNovelVideo 拼接长视频
+// 范围内每个章节都必须有发布版本的最终视频，否则返回 ErrNovelVideoNotReady 并列出缺少的章节；
+// 校验通过后创建处理中的视频记录，并提交长视频拼接任务（novel_video）到任务队列，由工作协程执行拼接
+func (s *novelService) AssembleNovelVideo(ctx context.Context, req *AssembleNovelVideoRequest) (*novel.Video, error) {
+	n, err := s.novelRepo.FindByID(ctx, req.NovelID)
+	if err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+	parts, licensed, err := s.novelVideoParts(ctx, n.ID, req.ChapterFrom, req.ChapterTo)
+	if err != nil {
+		return nil, err
+	}
+	tier := novel.ExportTierPreview
+	if licensed {
+		tier = novel.ExportTierLicensed
+	}
+
+	version := 1
+	if latest, err := s.videoRepo.FindByNovelIDAndType(ctx, n.ID, novel.VideoTypeNovel, 1); err != nil {
+		return nil, fmt.Errorf("find novel videos: %w", err)
+	} else if len(latest) > 0 {
+		version = latest[0].Version + 1
+	}
+
+	userID := req.UserID
+	if userID == "" {
+		userID = n.UserID
+	}
+	video := &novel.Video{
+		ID:         id.New(),
+		NovelID:    n.ID,
+		UserID:     userID,
+		Sequence:   1,
+		VideoType:  novel.VideoTypeNovel,
+		Version:    version,
+		Status:     novel.VideoStatusProcessing,
+		ExportTier: tier,
+		Prompt:     fmt.Sprintf("chapters %d-%d", parts[0].chapter.Sequence, parts[len(parts)-1].chapter.Sequence),
+		JobID:      id.New(),
+	}
+	if err := s.videoRepo.Create(ctx, video); err != nil {
+		return nil, fmt.Errorf("create novel video: %w", err)
+	}
+
+	job := &novel.Job{
+		ID:          video.JobID,
+		Type:        novel.JobTypeNovelVideo,
+		NovelID:     n.ID,
+		TriggeredBy: userID,
+		MaxAttempts: s.jobMaxAttempts,
+		NovelVideo: &novel.NovelVideoJobParams{
+			VideoID:        video.ID,
+			ChapterFrom:    req.ChapterFrom,
+			ChapterTo:      req.ChapterTo,
+			SkipTitleCards: req.SkipTitleCards,
+		},
+	}
+	if err := s.submitJob(ctx, job); err != nil {
+		if updateErr := s.videoRepo.UpdateStatus(context.WithoutCancel(ctx), video.ID, novel.VideoStatusFailed, err.Error()); updateErr != nil {
+			log.Error().Err(updateErr).Str("video_id", video.ID).Msg("更新长视频失败状态失败")
+		}
+		return nil, err
+	}
+
+	log.Info().
+		Str("novel_id", n.ID).
+		Str("video_id", video.ID).
+		Str("job_id", job.ID).
+		Int("chapters", len(parts)).
+		Str("export_tier", string(tier)).
+		Msg("长视频拼接任务已提交")
+	return video, nil
+}
+
+// runNovelVideoJob 执行长视频拼接任务，返回长视频ID
+// 执行时重新查询范围内章节发布版本的最终视频；各章节的最终视频可能来自不同的生成参数或平台规范，拼接前统一为小说当前生成参数的分辨率和帧率
+// （不全是授权版时缩小到预览版的 720p；提交时全部是授权版、执行时不再是时失败，避免视频记录的导出档位与内容不符）。
+// 每次执行前把视频记录重置为处理中，失败后由任务队列重试
+func (s *novelService) runNovelVideoJob(ctx context.Context, job *novel.Job) ([]string, error) {
+	params := job.NovelVideo
+	if params == nil || params.VideoID == "" {
+		return nil, fmt.Errorf("%w: novel video job has no video", ErrInvalidJob)
+	}
+	video, err := s.videoRepo.FindByID(ctx, params.VideoID)
+	if err != nil {
+		return nil, fmt.Errorf("find novel video: %w", err)
+	}
+	n, err := s.novelRepo.FindByID(ctx, job.NovelID)
+	if err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+	parts, licensed, err := s.novelVideoParts(ctx, n.ID, params.ChapterFrom, params.ChapterTo)
+	if err != nil {
+		return nil, err
+	}
+	if !licensed && video.ExportTier == novel.ExportTierLicensed {
+		return nil, fmt.Errorf("%w: promoted final videos are no longer all licensed", ErrNovelVideoNotReady)
+	}
+	if err := s.videoRepo.UpdateStatus(ctx, video.ID, novel.VideoStatusProcessing, ""); err != nil {
+		return nil, fmt.Errorf("update novel video: %w", err)
+	}
+
+	gen := s.generationSettings(ctx, n.ID)
+	width, height := noveltools.PreviewResolution(gen.VideoWidth, gen.VideoHeight)
+	if licensed && video.ExportTier == novel.ExportTierLicensed {
+		width, height = gen.VideoWidth, gen.VideoHeight
+	}
+	if err := s.renderNovelVideo(ctx, video, n.Title, parts, !params.SkipTitleCards, width, height, gen.VideoFPS); err != nil {
+		return nil, err
+	}
+	return []string{video.ID}, nil
+}
+
+// onNovelVideoJobFinished 长视频拼接任务失败或取消时把视频记录标记为失败（放回队列重试时保持处理中）
+func (s *novelService) onNovelVideoJobFinished(ctx context.Context, job *novel.Job, status novel.JobStatus, errMsg string) {
+	if job.NovelVideo == nil || (status != novel.JobStatusFailed && status != novel.JobStatusCanceled) {
+		return
+	}
+	if errMsg == "" {
+		errMsg = "job " + string(status)
+	}
+	if err := s.videoRepo.UpdateStatus(ctx, job.NovelVideo.VideoID, novel.VideoStatusFailed, errMsg); err != nil {
+		log.Error().Err(err).Str("job_id", job.ID).Str("video_id", job.NovelVideo.VideoID).Msg("更新长视频失败状态失败")
+	}
+}
+
+// ListNovelVideos 查询小说的长视频
+func (s *novelService) ListNovelVideos(ctx context.Context, novelID string, limit int) ([]*novel.Video, error) {
+	if _, err := s.novelRepo.FindByID(ctx, novelID); err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+	return s.videoRepo.FindByNovelIDAndType(ctx, novelID, novel.VideoTypeNovel, limit)
+}
+
+// novelVideoChapters 查询序号范围内的主章节（不含分支），按序号升序
+func (s *novelService) novelVideoChapters(ctx context.Context, novelID string, from, to int) ([]*novel.Chapter, error) {
+	if from < 0 || to < 0 || (to > 0 && to < from) {
+		return nil, fmt.Errorf("%w: invalid chapter range %d-%d", ErrInvalidNovelVideo, from, to)
+	}
+	all, err := s.chapterRepo.FindByNovelID(ctx, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapters: %w", err)
+	}
+
+	chapters := slices.DeleteFunc(all, func(ch *novel.Chapter) bool {
+		return ch.IsBranch() || (from > 0 && ch.Sequence < from) || (to > 0 && ch.Sequence > to)
+	})
+	if len(chapters) == 0 {
+		return nil, fmt.Errorf("%w: no chapters in range %d-%d", ErrInvalidNovelVideo, from, to)
+	}
+	sort.SliceStable(chapters, func(i, j int) bool { return chapters[i].Sequence < chapters[j].Sequence })
+	return chapters, nil
+}
+
+// novelVideoParts 查询序号范围内各章节发布版本的最终视频，并返回是否全部为授权版
+// 有章节还没有发布的最终视频时返回 ErrNovelVideoNotReady 并列出缺少的章节
+func (s *novelService) novelVideoParts(ctx context.Context, novelID string, from, to int) ([]novelVideoPart, bool, error) {
+	chapters, err := s.novelVideoChapters(ctx, novelID, from, to)
+	if err != nil {
+		return nil, false, err
+	}
+
+	parts := make([]novelVideoPart, 0, len(chapters))
+	var missing []string
+	licensed := true
+	for _, chapter := range chapters {
+		video, err := s.promotedFinalVideo(ctx, chapter)
+		if err != nil {
+			return nil, false, err
+		}
+		if video == nil {
+			missing = append(missing, strconv.Itoa(chapter.Sequence))
+			continue
+		}
+		licensed = licensed && video.ExportTier == novel.ExportTierLicensed
+		parts = append(parts, novelVideoPart{chapter: chapter, video: video})
+	}
+	if len(missing) > 0 {
+		return nil, false, fmt.Errorf("%w: chapters %s", ErrNovelVideoNotReady, strings.Join(missing, ", "))
+	}
+	return parts, licensed, nil
+}
+
+// promotedFinalVideo 查询章节发布版本的最终视频，同一版本同时有授权版和预览版时使用授权版；章节未发布视频时返回 nil
+func (s *novelService) promotedFinalVideo(ctx context.Context, chapter *novel.Chapter) (*novel.Video, error) {
+	if chapter.PromotedVersions == nil || chapter.PromotedVersions.Video == 0 {
+		return nil, nil
+	}
+	videos, err := s.videoRepo.FindByChapterIDAndVersion(ctx, chapter.ID, chapter.PromotedVersions.Video)
+	if err != nil {
+		return nil, fmt.Errorf("find final videos of chapter %d: %w", chapter.Sequence, err)
+	}
+
+	var selected *novel.Video
+	for _, video := range videos {
+		if video.VideoType != novel.VideoTypeFinal || video.Status != novel.VideoStatusCompleted || video.VideoResourceID == "" {
+			continue
+		}
+		if selected == nil || (video.ExportTier == novel.ExportTierLicensed && selected.ExportTier != novel.ExportTierLicensed) {
+			selected = video
+		}
+	}
+	return selected, nil
+}
+
+// renderNovelVideo 下载并统一各章节的最终视频规格，插入标题画面后拼接，写入章节标记并上传
+// 章节标记的时间按统一规格后的实际时长累加，章节从标题画面开始
+func (s *novelService) renderNovelVideo(ctx context.Context, video *novel.Video, novelTitle string, parts []novelVideoPart, titleCards bool, width, height, fps int) error {
+	ffmpegClient := ffmpeg.NewClient()
+	tmpDir, cleanupTmpDir, err := procreaper.MkdirTemp(ctx, "lemon-novel-video-*")
+	if err != nil {
+		return fmt.Errorf("create temp dir: %w", err)
+	}
+	defer cleanupTmpDir()
+
+	fontPath := os.Getenv("WATERMARK_FONT_PATH")
+	segmentPaths := make([]string, 0, 2*len(parts))
+	markers := make([]novel.VideoChapterMarker, 0, len(parts))
+	marks := make([]ffmpeg.ChapterMark, 0, len(parts))
+	var offset float64
+	for i, part := range parts {
+		start := offset
+		title := part.chapter.NormalizedTitle
+		if title == "" {
+			title = part.chapter.Title
+		}
+		if titleCards {
+			cardPath := filepath.Join(tmpDir, fmt.Sprintf("title_%04d.mp4", i+1))
+			if err := ffmpegClient.CreateTitleCard(ctx, ffmpeg.TitleCard{
+				Title:    title,
+				Subtitle: novelTitle,
+				FontPath: fontPath,
+				Duration: novelVideoTitleCardDuration,
+				Width:    width,
+				Height:   height,
+				FPS:      fps,
+			}, cardPath); err != nil {
+				return fmt.Errorf("create title card for chapter %d: %w", part.chapter.Sequence, err)
+			}
+			segmentPaths = append(segmentPaths, cardPath)
+			offset += novelVideoTitleCardDuration
+		}
+
+		sourcePath := filepath.Join(tmpDir, fmt.Sprintf("source_%04d.mp4", i+1))
+		if err := s.downloadResourceToFile(ctx, part.video.VideoResourceID, sourcePath); err != nil {
+			return fmt.Errorf("download final video of chapter %d: %w", part.chapter.Sequence, err)
+		}
+		normalizedPath := filepath.Join(tmpDir, fmt.Sprintf("chapter_%04d.mp4", i+1))
+		err := ffmpegClient.NormalizeVideo(ctx, sourcePath, normalizedPath, width, height, fps)
+		os.Remove(sourcePath)
+		if err != nil {
+			return fmt.Errorf("normalize final video of chapter %d: %w", part.chapter.Sequence, err)
+		}
+		info, err := ffmpegClient.GetVideoInfo(ctx, normalizedPath)
+		if err != nil {
+			return fmt.Errorf("probe final video of chapter %d: %w", part.chapter.Sequence, err)
+		}
+		segmentPaths = append(segmentPaths, normalizedPath)
+		offset += info.Duration
+
+		markers = append(markers, novel.VideoChapterMarker{
+			ChapterID:       part.chapter.ID,
+			ChapterSequence: part.chapter.Sequence,
+			Title:           title,
+			SourceVideoID:   part.video.ID,
+			Start:           math.Round(start*100) / 100,
+			End:             math.Round(offset*100) / 100,
+		})
+		marks = append(marks, ffmpeg.ChapterMark{Title: title, Start: start, End: offset})
+	}
+
+	concatPath := filepath.Join(tmpDir, "concat.mp4")
+	if err := ffmpegClient.ConcatVideos(ctx, segmentPaths, concatPath); err != nil {
+		return fmt.Errorf("concat chapter videos: %w", err)
+	}
+	outputPath := filepath.Join(tmpDir, "novel_video.mp4")
+	if err := ffmpegClient.EmbedChapters(ctx, concatPath, outputPath, marks); err != nil {
+		return fmt.Errorf("embed chapter markers: %w", err)
+	}
+
+	outputFile, err := os.Open(outputPath)
+	if err != nil {
+		return fmt.Errorf("open novel video: %w", err)
+	}
+	defer outputFile.Close()
+	uploadResult, err := s.resourceService.UploadLargeFile(ctx, &service.UploadFileRequest{
+		UserID:      video.UserID,
+		FileName:    fmt.Sprintf("%s_novel_video_v%d.mp4", video.NovelID, video.Version),
+		ContentType: "video/mp4",
+		Ext:         "mp4",
+		Data:        outputFile,
+		KeyVars:     novelKeyVars(video.NovelID, artifactNovelVideo),
+	})
+	if err != nil {
+		return fmt.Errorf("upload novel video: %w", err)
+	}
+
+	duration := math.Round(offset*100) / 100
+	if err := s.videoRepo.CompleteNovelVideo(ctx, video.ID, uploadResult.ResourceID, duration, markers); err != nil {
+		return fmt.Errorf("update novel video: %w", err)
+	}
+
+	log.Info().
+		Str("novel_id", video.NovelID).
+		Str("video_id", video.ID).
+		Int("chapters", len(parts)).
+		Float64("duration", duration).
+		Msg("长视频拼接完成")
+	return nil
+}
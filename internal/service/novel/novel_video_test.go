@@ -0,0 +1,169 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"lemon/internal/model/novel"
+)
+
+// memNovelVideoRepo 内存中的视频仓库，在查询发布版本的基础上实现长视频记录用到的方法
+type memNovelVideoRepo struct {
+	memLifecycleVideoRepo
+}
+
+func (r *memNovelVideoRepo) FindByNovelIDAndType(_ context.Context, novelID string, videoType novel.VideoType, _ int) ([]*novel.Video, error) {
+	var videos []*novel.Video
+	for _, v := range r.videos {
+		if v.NovelID == novelID && v.VideoType == videoType {
+			videos = append(videos, v)
+		}
+	}
+	return videos, nil
+}
+
+func (r *memNovelVideoRepo) Create(_ context.Context, v *novel.Video) error {
+	r.videos = append(r.videos, v)
+	return nil
+}
+
+func (r *memNovelVideoRepo) UpdateStatus(_ context.Context, id string, status novel.VideoStatus, errorMsg string) error {
+	for _, v := range r.videos {
+		if v.ID == id {
+			v.Status, v.ErrorMessage = status, errorMsg
+			return nil
+		}
+	}
+	return errors.New("video not found")
+}
+
+// newNovelVideoTestService 创建小说 novel-1 的服务：第1、2章都已发布授权版最终视频，任务队列没有工作协程
+func newNovelVideoTestService(jobs *memJobRepo) (*novelService, *memChapterRepo, *memNovelVideoRepo) {
+	chapters := &memChapterRepo{}
+	videos := &memNovelVideoRepo{}
+	for _, seq := range []int{1, 2} {
+		ch := &novel.Chapter{
+			ID:               "ch-" + strconv.Itoa(seq),
+			NovelID:          "novel-1",
+			Sequence:         seq,
+			PromotedVersions: &novel.PromotedVersions{Video: 1},
+		}
+		chapters.chapters = append(chapters.chapters, ch)
+		videos.videos = append(videos.videos, &novel.Video{
+			ID: "final-" + ch.ID, ChapterID: ch.ID, NovelID: "novel-1", Version: 1,
+			VideoType: novel.VideoTypeFinal, Status: novel.VideoStatusCompleted,
+			ExportTier: novel.ExportTierLicensed, VideoResourceID: "res-" + ch.ID,
+		})
+	}
+	s := newJobTestService(jobs, &blockingNarrationRepo{started: make(chan string, 1)})
+	s.novelRepo = &memBudgetNovelRepo{novel: &novel.Novel{ID: "novel-1", UserID: "owner-1", Title: "小说"}}
+	s.chapterRepo = chapters
+	s.videoRepo = videos
+	return s, chapters, videos
+}
+
+func TestAssembleNovelVideoEnqueuesJob(t *testing.T) {
+	jobs := newMemJobRepo()
+	s, _, videos := newNovelVideoTestService(jobs)
+
+	video, err := s.AssembleNovelVideo(context.Background(), &AssembleNovelVideoRequest{
+		NovelID: "novel-1", UserID: "editor-1", ChapterTo: 2, SkipTitleCards: true,
+	})
+	if err != nil {
+		t.Fatalf("AssembleNovelVideo() error = %v", err)
+	}
+	if video.Status != novel.VideoStatusProcessing || video.ExportTier != novel.ExportTierLicensed || video.JobID == "" {
+		t.Errorf("video = %+v, want processing licensed video with job", video)
+	}
+	if len(videos.videos) != 3 {
+		t.Errorf("videos = %d, want novel video created", len(videos.videos))
+	}
+
+	job := jobs.get(video.JobID)
+	if job.Type != novel.JobTypeNovelVideo || job.NovelID != "novel-1" || job.ChapterID != "" || job.TriggeredBy != "editor-1" {
+		t.Errorf("job = %+v, want novel_video job of novel-1 triggered by editor-1", job)
+	}
+	want := novel.NovelVideoJobParams{VideoID: video.ID, ChapterTo: 2, SkipTitleCards: true}
+	if job.NovelVideo == nil || *job.NovelVideo != want {
+		t.Errorf("job params = %+v, want %+v", job.NovelVideo, want)
+	}
+	if job.Status != novel.JobStatusQueued || job.MaxAttempts != defaultJobMaxAttempts {
+		t.Errorf("job status = %s, max attempts = %d, want queued/%d", job.Status, job.MaxAttempts, defaultJobMaxAttempts)
+	}
+}
+
+func TestAssembleNovelVideoNotReadyDoesNotEnqueue(t *testing.T) {
+	jobs := newMemJobRepo()
+	s, chapters, videos := newNovelVideoTestService(jobs)
+	chapters.chapters[1].PromotedVersions = nil
+
+	if _, err := s.AssembleNovelVideo(context.Background(), &AssembleNovelVideoRequest{NovelID: "novel-1"}); !errors.Is(err, ErrNovelVideoNotReady) {
+		t.Fatalf("AssembleNovelVideo() error = %v, want ErrNovelVideoNotReady", err)
+	}
+	if len(jobs.jobs) != 0 || len(videos.videos) != 2 {
+		t.Errorf("jobs = %d, videos = %d, want nothing created", len(jobs.jobs), len(videos.videos))
+	}
+}
+
+func TestNovelVideoJobMarksVideoFailedAfterLastAttempt(t *testing.T) {
+	jobs := newMemJobRepo()
+	s, chapters, videos := newNovelVideoTestService(jobs)
+	s.jobMaxAttempts = 2
+	ctx := context.Background()
+
+	video, err := s.AssembleNovelVideo(ctx, &AssembleNovelVideoRequest{NovelID: "novel-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 提交后、执行前第2章的发布版本被撤回
+	chapters.chapters[1].PromotedVersions = nil
+
+	job, err := jobs.Claim(ctx, s.jobNodeID, "node-1-0", jobLease)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.runJob(ctx, "node-1-0", job)
+	if got := jobs.get(video.JobID); got.Status != novel.JobStatusQueued {
+		t.Fatalf("after first failure: job status = %s, want queued", got.Status)
+	}
+	if video.Status != novel.VideoStatusProcessing {
+		t.Errorf("after first failure: video status = %s, want processing while retrying", video.Status)
+	}
+
+	jobs.mu.Lock()
+	jobs.jobs[video.JobID].RunAfter = time.Now()
+	jobs.mu.Unlock()
+	if job, err = jobs.Claim(ctx, s.jobNodeID, "node-1-0", jobLease); err != nil {
+		t.Fatal(err)
+	}
+	s.runJob(ctx, "node-1-0", job)
+	if got := jobs.get(video.JobID); got.Status != novel.JobStatusFailed {
+		t.Fatalf("after last failure: job status = %s, want failed", got.Status)
+	}
+	if video.Status != novel.VideoStatusFailed || video.ErrorMessage == "" {
+		t.Errorf("after last failure: video = %s/%q, want failed with error", video.Status, video.ErrorMessage)
+	}
+	if len(videos.videos) != 3 {
+		t.Errorf("videos = %d, want retries to reuse the novel video", len(videos.videos))
+	}
+}
+
+func TestCancelNovelVideoJobMarksVideoFailed(t *testing.T) {
+	jobs := newMemJobRepo()
+	s, _, _ := newNovelVideoTestService(jobs)
+	ctx := context.Background()
+
+	video, err := s.AssembleNovelVideo(ctx, &AssembleNovelVideoRequest{NovelID: "novel-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CancelJob(ctx, video.JobID); err != nil {
+		t.Fatal(err)
+	}
+	if video.Status != novel.VideoStatusFailed {
+		t.Errorf("video status = %s, want failed after cancel", video.Status)
+	}
+}
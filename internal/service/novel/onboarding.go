@@ -0,0 +1,159 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/noveltools"
+)
+
+// defaultTTSSpeedRatio 未配置语速时的 TTS 语速（参考 Python 脚本）
+const defaultTTSSpeedRatio = 1.2
+
+var (
+	// ErrAlreadyOnboarded 用户已经开通过（需要重置时显式指定 reset）
+	ErrAlreadyOnboarded = errors.New("user already onboarded")
+	// ErrInvalidOnboarding 开通请求不合法
+	ErrInvalidOnboarding = errors.New("invalid onboarding request")
+)
+
+// OnboardingService 新用户开通服务接口
+type OnboardingService interface {
+	// ListOnboardingPresets 列出可用的创作默认配置预设
+	ListOnboardingPresets() []*noveltools.OnboardingPreset
+
+	// OnboardCreator 按预设为用户生成创作默认配置（视频平台、配音、字幕样式、提示词变量、片头片尾文案）
+	OnboardCreator(ctx context.Context, req *OnboardCreatorRequest) (*novel.CreatorProfile, error)
+
+	// GetCreatorProfile 查询用户的创作默认配置
+	GetCreatorProfile(ctx context.Context, userID string) (*novel.CreatorProfile, error)
+}
+
+// OnboardCreatorRequest 新用户开通请求
+type OnboardCreatorRequest struct {
+	UserID string // 用户ID
+	Preset string // 预设名称，为空时使用默认预设
+	Reset  bool   // 已开通时是否按预设重置默认配置
+}
+
+// ListOnboardingPresets 列出可用的创作默认配置预设
+func (s *novelService) ListOnboardingPresets() []*noveltools.OnboardingPreset {
+	return noveltools.OnboardingPresets()
+}
+
+// OnboardCreator 按预设为用户生成创作默认配置
+// 已开通的用户需要指定 Reset 才会覆盖，已创建的小说不受影响
+func (s *novelService) OnboardCreator(ctx context.Context, req *OnboardCreatorRequest) (*novel.CreatorProfile, error) {
+	if req.UserID == "" {
+		return nil, fmt.Errorf("%w: user_id is required", ErrInvalidOnboarding)
+	}
+	preset, err := noveltools.FindOnboardingPreset(req.Preset)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.creatorProfileRepo.FindByUserID(ctx, req.UserID)
+	switch {
+	case err == nil && !req.Reset:
+		return nil, ErrAlreadyOnboarded
+	case err != nil && !errors.Is(err, mongo.ErrNoDocuments):
+		return nil, fmt.Errorf("find creator profile: %w", err)
+	}
+
+	profile := preset.Profile
+	profile.ID = id.New()
+	if existing != nil {
+		profile.ID = existing.ID
+	}
+	profile.UserID = req.UserID
+	profile.Preset = preset.Name
+	if profile.Metadata != nil {
+		profile.Metadata.UpdatedAt = time.Now()
+	}
+	if err := s.creatorProfileRepo.Save(ctx, profile); err != nil {
+		return nil, fmt.Errorf("save creator profile: %w", err)
+	}
+
+	log.Info().
+		Str("user_id", req.UserID).
+		Str("preset", preset.Name).
+		Bool("reset", existing != nil).
+		Msg("用户创作默认配置已生成")
+	return profile, nil
+}
+
+// GetCreatorProfile 查询用户的创作默认配置
+func (s *novelService) GetCreatorProfile(ctx context.Context, userID string) (*novel.CreatorProfile, error) {
+	profile, err := s.creatorProfileRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("find creator profile: %w", err)
+	}
+	return profile, nil
+}
+
+// applyCreatorDefaults 把用户的创作默认配置复制到新建的小说上
+// 用户没有开通或查询失败时只补全旁白类型和风格（不阻断创建）
+func (s *novelService) applyCreatorDefaults(ctx context.Context, n *novel.Novel) {
+	profile, err := s.creatorProfileRepo.FindByUserID(ctx, n.UserID)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Warn().Err(err).Str("user_id", n.UserID).Msg("查询用户创作默认配置失败，使用系统默认值")
+		}
+		profile = &novel.CreatorProfile{}
+	}
+
+	if n.NarrationType == "" {
+		n.NarrationType = profile.NarrationType
+	}
+	if n.NarrationType == "" {
+		n.NarrationType = novel.NarrationTypeNarration
+	}
+	if n.Style == "" {
+		n.Style = profile.Style
+	}
+	if n.Style == "" {
+		n.Style = novel.NovelStyleAnime
+	}
+	n.NumberStyle = profile.NumberStyle
+	n.Continuity = profile.Continuity
+	n.Metadata = profile.Metadata
+	n.Render = profile.Render
+}
+
+// novelRenderSettings 查询小说的渲染设置，未设置或查询失败时返回空设置（使用系统默认值，不阻断生成流程）
+func (s *novelService) novelRenderSettings(ctx context.Context, novelID string) *novel.RenderSettings {
+	n, err := s.novelRepo.FindByID(ctx, novelID)
+	if err != nil {
+		log.Warn().Err(err).Str("novel_id", novelID).Msg("查询小说渲染设置失败，使用默认值")
+		return &novel.RenderSettings{}
+	}
+	if n.Render == nil {
+		return &novel.RenderSettings{}
+	}
+	return n.Render
+}
+
+// novelVoice 查询小说本次生成使用的配音（按生成参数的分层规则解析，语速始终有默认值）
+func (s *novelService) novelVoice(ctx context.Context, novelID string) novel.VoiceSettings {
+	resolved := s.generationSettings(ctx, novelID)
+	voice := novel.VoiceSettings{VoiceType: resolved.VoiceType, SpeedRatio: resolved.SpeedRatio}
+	if voice.SpeedRatio <= 0 {
+		voice.SpeedRatio = defaultTTSSpeedRatio
+	}
+	return voice
+}
+
+// novelTargetPlatform 查询小说的默认目标平台，未配置时为通用平台
+func (s *novelService) novelTargetPlatform(ctx context.Context, novelID string) novel.TargetPlatform {
+	if platform := s.novelRenderSettings(ctx, novelID).Platform; platform.IsValid() {
+		return platform
+	}
+	return novel.TargetPlatformDefault
+}
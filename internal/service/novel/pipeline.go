@@ -0,0 +1,307 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/noveltools"
+)
+
+var (
+	// ErrPipelineRunning 章节已有执行中的一键生成流程
+	ErrPipelineRunning = errors.New("chapter pipeline is already running")
+	// ErrInvalidPipelineRun 一键生成请求不合法（起始阶段不支持）
+	ErrInvalidPipelineRun = errors.New("invalid pipeline run")
+)
+
+// PipelineService 章节一键生成流程服务接口
+// 按 解说 -> 音频 -> 字幕 -> 图片 -> 解说视频 -> 最终视频 的顺序把各步骤依次提交到任务队列，记录每一步的状态，失败后可从失败的步骤继续
+type PipelineService interface {
+	// RunChapterPipeline 提交章节一键生成流程，返回流程和是否为继续执行（最近一次流程失败或取消后从该步骤继续）
+	RunChapterPipeline(ctx context.Context, req *RunChapterPipelineRequest) (*novel.PipelineRun, bool, error)
+
+	// GetChapterPipelineRun 查询章节最近一次一键生成流程（含各步骤和整体的进度百分比）
+	GetChapterPipelineRun(ctx context.Context, chapterID string) (*novel.PipelineRun, error)
+}
+
+// RunChapterPipelineRequest 提交一键生成流程请求
+type RunChapterPipelineRequest struct {
+	ChapterID string
+	FromStage novel.PipelineStage // 起始阶段（之前的步骤沿用已有产物）；为空时最近一次流程失败或取消则从该步骤继续，否则从解说开始
+	UserID    string
+}
+
+// RunChapterPipeline 提交章节一键生成流程
+// 章节已有执行中的流程返回 ErrPipelineRunning；起始阶段不支持返回 ErrInvalidPipelineRun
+func (s *novelService) RunChapterPipeline(ctx context.Context, req *RunChapterPipelineRequest) (*novel.PipelineRun, bool, error) {
+	chapter, err := s.chapterRepo.FindByID(ctx, req.ChapterID)
+	if err != nil {
+		return nil, false, fmt.Errorf("find chapter: %w", err)
+	}
+	latest, err := s.pipelineRunRepo.FindLatestByChapterID(ctx, chapter.ID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, false, fmt.Errorf("find pipeline run: %w", err)
+	}
+	if latest != nil && latest.Status == novel.PipelineRunStatusRunning {
+		return nil, false, fmt.Errorf("%w: %s", ErrPipelineRunning, latest.ID)
+	}
+
+	if req.FromStage == "" && latest != nil &&
+		(latest.Status == novel.PipelineRunStatusFailed || latest.Status == novel.PipelineRunStatusCanceled) {
+		run, err := s.resumePipelineRun(ctx, latest, req.UserID)
+		return run, true, err
+	}
+
+	steps, ok := noveltools.NewPipelineRunSteps(req.FromStage)
+	if !ok {
+		return nil, false, fmt.Errorf("%w: unsupported stage %q", ErrInvalidPipelineRun, req.FromStage)
+	}
+	run := &novel.PipelineRun{
+		ID:          id.New(),
+		NovelID:     chapter.NovelID,
+		ChapterID:   chapter.ID,
+		TriggeredBy: req.UserID,
+		Status:      novel.PipelineRunStatusRunning,
+		Steps:       steps,
+		StartedAt:   time.Now(),
+	}
+	job, err := s.preparePipelineStep(ctx, run)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := s.pipelineRunRepo.Create(ctx, run); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, false, fmt.Errorf("%w: %s", ErrPipelineRunning, chapter.ID)
+		}
+		return nil, false, fmt.Errorf("create pipeline run: %w", err)
+	}
+	s.submitPipelineStep(ctx, run, job)
+
+	log.Info().
+		Str("pipeline_run_id", run.ID).
+		Str("chapter_id", run.ChapterID).
+		Str("from_stage", string(job.Type)).
+		Str("user_id", req.UserID).
+		Msg("章节一键生成流程已提交")
+	return run, false, nil
+}
+
+// resumePipelineRun 从失败或取消的步骤继续执行流程（之前已完成的步骤不再执行）
+func (s *novelService) resumePipelineRun(ctx context.Context, run *novel.PipelineRun, userID string) (*novel.PipelineRun, error) {
+	previous := run.Status
+	run.Status = novel.PipelineRunStatusRunning
+	run.Resumes++
+	run.ErrorMessage = ""
+	run.CompletedAt = nil
+	if userID != "" {
+		run.TriggeredBy = userID
+	}
+	job, err := s.preparePipelineStep(ctx, run)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.pipelineRunRepo.Replace(ctx, run, previous)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("%w: %s", ErrPipelineRunning, run.ChapterID)
+		}
+		return nil, fmt.Errorf("update pipeline run: %w", err)
+	}
+	if !updated {
+		// 并发提交：流程已被其他请求继续执行
+		return nil, fmt.Errorf("%w: %s", ErrPipelineRunning, run.ID)
+	}
+	s.submitPipelineStep(ctx, run, job)
+
+	log.Info().
+		Str("pipeline_run_id", run.ID).
+		Str("chapter_id", run.ChapterID).
+		Str("stage", string(job.Type)).
+		Int("resumes", run.Resumes).
+		Msg("章节一键生成流程从失败的步骤继续执行")
+	return run, nil
+}
+
+// preparePipelineStep 为流程中第一个未完成的步骤构造任务，并把步骤标记为已提交（调用方保存流程后再调用 submitPipelineStep）
+// 先分配任务ID再保存流程，任务执行结束时总能找到对应的步骤
+func (s *novelService) preparePipelineStep(ctx context.Context, run *novel.PipelineRun) (*novel.Job, error) {
+	step := noveltools.NextPipelineRunStep(run)
+	if step == nil {
+		return nil, fmt.Errorf("%w: all steps are completed", ErrInvalidPipelineRun)
+	}
+	job, err := s.newJob(ctx, &EnqueueJobRequest{
+		Type:      step.Stage,
+		ChapterID: run.ChapterID,
+		UserID:    run.TriggeredBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	job.PipelineRunID = run.ID
+
+	step.Status = novel.PipelineStepStatusQueued
+	step.JobID = job.ID
+	step.ErrorMessage = ""
+	step.StartedAt = nil
+	step.CompletedAt = nil
+	return job, nil
+}
+
+// submitPipelineStep 把步骤的任务写入队列；写入失败时流程标记为失败（可再次提交从该步骤继续）
+func (s *novelService) submitPipelineStep(ctx context.Context, run *novel.PipelineRun, job *novel.Job) {
+	err := s.submitJob(ctx, job)
+	if err == nil {
+		return
+	}
+	log.Error().Err(err).Str("pipeline_run_id", run.ID).Str("stage", string(job.Type)).Msg("提交一键生成流程的步骤失败")
+	if step := run.Step(job.Type); step != nil {
+		step.Status = novel.PipelineStepStatusFailed
+		step.ErrorMessage = err.Error()
+	}
+	s.finishPipelineRun(context.WithoutCancel(ctx), run, novel.PipelineRunStatusFailed, err.Error())
+}
+
+// GetChapterPipelineRun 查询章节最近一次一键生成流程
+// 执行中步骤的进度按最近一次成功执行的耗时估算（见 GetRenderBreakdown），查询耗时失败时执行中的步骤进度为 0
+func (s *novelService) GetChapterPipelineRun(ctx context.Context, chapterID string) (*novel.PipelineRun, error) {
+	if _, err := s.chapterRepo.FindByID(ctx, chapterID); err != nil {
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+	run, err := s.pipelineRunRepo.FindLatestByChapterID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find pipeline run: %w", err)
+	}
+
+	var estimates map[novel.PipelineStage]int64
+	if breakdown, err := s.GetRenderBreakdown(ctx, chapterID); err != nil {
+		log.Warn().Err(err).Str("chapter_id", chapterID).Msg("查询阶段耗时失败，无法估算执行中步骤的进度")
+	} else {
+		estimates = make(map[novel.PipelineStage]int64, len(breakdown.Stages))
+		for _, sb := range breakdown.Stages {
+			estimates[sb.Stage] = sb.DurationMs
+		}
+	}
+	noveltools.FillPipelineRunProgress(run, estimates, time.Now())
+	return run, nil
+}
+
+// onPipelineJobStarted 任务开始执行时把流程中对应的步骤标记为执行中
+func (s *novelService) onPipelineJobStarted(ctx context.Context, job *novel.Job) {
+	run, step := s.pipelineStepOfJob(ctx, job)
+	if step == nil {
+		return
+	}
+	now := time.Now()
+	step.Status = novel.PipelineStepStatusRunning
+	step.Attempts++
+	step.StartedAt = &now
+	s.savePipelineRun(ctx, run)
+}
+
+// onPipelineJobFinished 任务执行结束（或放回队列等待重试）时更新流程中对应的步骤
+// 成功时提交下一步，全部完成时流程结束；失败或取消时流程停止，再次提交时从该步骤继续
+func (s *novelService) onPipelineJobFinished(ctx context.Context, job *novel.Job, status novel.JobStatus, resourceIDs []string, errMsg string) {
+	run, step := s.pipelineStepOfJob(ctx, job)
+	if step == nil {
+		return
+	}
+	now := time.Now()
+	step.ErrorMessage = errMsg
+
+	switch status {
+	case novel.JobStatusQueued:
+		step.Status = novel.PipelineStepStatusQueued
+		s.savePipelineRun(ctx, run)
+	case novel.JobStatusFailed, novel.JobStatusCanceled:
+		runStatus := novel.PipelineRunStatusFailed
+		step.Status = novel.PipelineStepStatusFailed
+		if status == novel.JobStatusCanceled {
+			runStatus = novel.PipelineRunStatusCanceled
+			step.Status = novel.PipelineStepStatusCanceled
+		}
+		step.CompletedAt = &now
+		s.finishPipelineRun(ctx, run, runStatus, errMsg)
+	case novel.JobStatusSucceeded:
+		step.Status = novel.PipelineStepStatusSucceeded
+		step.ResourceIDs = resourceIDs
+		step.CompletedAt = &now
+		if noveltools.NextPipelineRunStep(run) == nil {
+			s.finishPipelineRun(ctx, run, novel.PipelineRunStatusSucceeded, "")
+			return
+		}
+		next, err := s.preparePipelineStep(ctx, run)
+		if err != nil {
+			log.Error().Err(err).Str("pipeline_run_id", run.ID).Msg("构造一键生成流程的下一步失败")
+			s.finishPipelineRun(ctx, run, novel.PipelineRunStatusFailed, err.Error())
+			return
+		}
+		if s.savePipelineRun(ctx, run) {
+			s.submitPipelineStep(ctx, run, next)
+		}
+	}
+}
+
+// pipelineStepOfJob 查询任务所属的执行中流程和对应的步骤；任务不属于流程、流程已结束或步骤已提交了其他任务时返回 nil
+func (s *novelService) pipelineStepOfJob(ctx context.Context, job *novel.Job) (*novel.PipelineRun, *novel.PipelineRunStep) {
+	if job.PipelineRunID == "" {
+		return nil, nil
+	}
+	run, err := s.pipelineRunRepo.FindByID(ctx, job.PipelineRunID)
+	if err != nil {
+		log.Error().Err(err).Str("pipeline_run_id", job.PipelineRunID).Str("job_id", job.ID).Msg("查询一键生成流程失败")
+		return nil, nil
+	}
+	step := run.Step(job.Type)
+	if run.Status != novel.PipelineRunStatusRunning || step == nil || step.JobID != job.ID {
+		log.Warn().
+			Str("pipeline_run_id", run.ID).
+			Str("job_id", job.ID).
+			Str("status", string(run.Status)).
+			Msg("任务不是一键生成流程当前步骤的任务，忽略")
+		return nil, nil
+	}
+	return run, step
+}
+
+// finishPipelineRun 结束流程（成功、失败或取消）
+func (s *novelService) finishPipelineRun(ctx context.Context, run *novel.PipelineRun, status novel.PipelineRunStatus, errMsg string) {
+	now := time.Now()
+	run.Status = status
+	run.ErrorMessage = errMsg
+	run.CompletedAt = &now
+	if _, err := s.pipelineRunRepo.Replace(ctx, run, novel.PipelineRunStatusRunning); err != nil {
+		log.Error().Err(err).Str("pipeline_run_id", run.ID).Msg("记录一键生成流程结果失败")
+		return
+	}
+
+	event := log.Info()
+	if status != novel.PipelineRunStatusSucceeded {
+		event = log.Warn().Str("error", errMsg)
+	}
+	event.
+		Str("pipeline_run_id", run.ID).
+		Str("chapter_id", run.ChapterID).
+		Str("status", string(status)).
+		Msg("章节一键生成流程执行结束")
+}
+
+// savePipelineRun 保存执行中的流程，返回是否保存成功
+func (s *novelService) savePipelineRun(ctx context.Context, run *novel.PipelineRun) bool {
+	updated, err := s.pipelineRunRepo.Replace(ctx, run, novel.PipelineRunStatusRunning)
+	if err != nil {
+		log.Error().Err(err).Str("pipeline_run_id", run.ID).Msg("保存一键生成流程失败")
+		return false
+	}
+	if !updated {
+		log.Warn().Str("pipeline_run_id", run.ID).Msg("一键生成流程已结束，忽略本次更新")
+	}
+	return updated
+}
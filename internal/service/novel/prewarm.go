@@ -0,0 +1,326 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/assetcache"
+	"lemon/internal/pkg/id"
+)
+
+// defaultPrewarmLeadTime 默认在渲染窗口开始前多久开始预热
+const defaultPrewarmLeadTime = time.Hour
+
+var (
+	// ErrPrewarmDisabled 未启用本地资源缓存，无法预热
+	ErrPrewarmDisabled = errors.New("asset prewarm disabled")
+	// ErrInvalidPrewarm 预热请求不合法
+	ErrInvalidPrewarm = errors.New("invalid prewarm request")
+)
+
+// PrewarmService 素材预热服务接口
+type PrewarmService interface {
+	// SchedulePrewarm 为定时渲染创建素材预热任务，在渲染窗口开始前把图片/音频/字幕/解说视频下载到本地缓存
+	SchedulePrewarm(ctx context.Context, req *SchedulePrewarmRequest) (*novel.PrewarmJob, error)
+
+	// GetPrewarmJob 查询预热任务（含命中统计）
+	GetPrewarmJob(ctx context.Context, jobID string) (*novel.PrewarmJob, error)
+
+	// ListPrewarmJobs 查询小说的预热任务（按创建时间倒序）
+	ListPrewarmJobs(ctx context.Context, novelID string, limit int) ([]*novel.PrewarmJob, error)
+
+	// ResumePrewarmJobs 服务启动时恢复未执行的预热任务
+	ResumePrewarmJobs(ctx context.Context) error
+}
+
+// SchedulePrewarmRequest 创建预热任务请求
+type SchedulePrewarmRequest struct {
+	NovelID    string
+	ChapterIDs []string  // 为空表示整部小说
+	RenderAt   time.Time // 渲染窗口开始时间
+	UserID     string
+}
+
+// WithAssetCache 启用素材预热：预热前按 maxAge 清理长期未访问的缓存文件，在渲染窗口前 leadTime 开始预热
+// resourceService 需要使用同一个缓存（service.WithAssetCache），渲染时才能命中
+func WithAssetCache(c *assetcache.Cache, leadTime, maxAge time.Duration) Option {
+	return func(s *novelService) {
+		s.assetCache = c
+		s.prewarmLeadTime = leadTime
+		s.assetCacheMaxAge = maxAge
+	}
+}
+
+// SchedulePrewarm 创建素材预热任务
+// 预热开始时间已过（包括渲染时间在提前量以内）时立即开始
+func (s *novelService) SchedulePrewarm(ctx context.Context, req *SchedulePrewarmRequest) (*novel.PrewarmJob, error) {
+	if s.assetCache == nil {
+		return nil, ErrPrewarmDisabled
+	}
+	if req.RenderAt.IsZero() {
+		return nil, fmt.Errorf("%w: render_at is required", ErrInvalidPrewarm)
+	}
+
+	if _, err := s.novelRepo.FindByID(ctx, req.NovelID); err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+	if len(req.ChapterIDs) > 0 {
+		chapters, err := s.chapterRepo.FindByNovelID(ctx, req.NovelID)
+		if err != nil {
+			return nil, fmt.Errorf("find chapters: %w", err)
+		}
+		known := make(map[string]bool, len(chapters))
+		for _, ch := range chapters {
+			known[ch.ID] = true
+		}
+		for _, chapterID := range req.ChapterIDs {
+			if !known[chapterID] {
+				return nil, fmt.Errorf("%w: chapter %s does not belong to novel %s", ErrInvalidPrewarm, chapterID, req.NovelID)
+			}
+		}
+	}
+
+	leadTime := s.prewarmLeadTime
+	if leadTime <= 0 {
+		leadTime = defaultPrewarmLeadTime
+	}
+	job := &novel.PrewarmJob{
+		ID:         id.New(),
+		NovelID:    req.NovelID,
+		ChapterIDs: req.ChapterIDs,
+		UserID:     req.UserID,
+		RenderAt:   req.RenderAt,
+		StartAt:    req.RenderAt.Add(-leadTime),
+		Status:     novel.PrewarmStatusScheduled,
+	}
+	if err := s.prewarmJobRepo.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("create prewarm job: %w", err)
+	}
+
+	s.schedulePrewarmJob(job)
+	return job, nil
+}
+
+// GetPrewarmJob 查询预热任务
+func (s *novelService) GetPrewarmJob(ctx context.Context, jobID string) (*novel.PrewarmJob, error) {
+	job, err := s.prewarmJobRepo.FindByID(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("find prewarm job: %w", err)
+	}
+	return job, nil
+}
+
+// ListPrewarmJobs 查询小说的预热任务
+func (s *novelService) ListPrewarmJobs(ctx context.Context, novelID string, limit int) ([]*novel.PrewarmJob, error) {
+	return s.prewarmJobRepo.FindByNovelID(ctx, novelID, limit)
+}
+
+// ResumePrewarmJobs 恢复未执行的预热任务
+// 重启前正在执行的任务已经中断，标记为失败，需要时重新创建
+func (s *novelService) ResumePrewarmJobs(ctx context.Context) error {
+	if s.assetCache == nil {
+		return nil
+	}
+
+	jobs, err := s.prewarmJobRepo.FindByStatus(ctx, novel.PrewarmStatusScheduled, novel.PrewarmStatusRunning)
+	if err != nil {
+		return fmt.Errorf("find pending prewarm jobs: %w", err)
+	}
+	for _, job := range jobs {
+		if job.Status == novel.PrewarmStatusRunning {
+			if err := s.prewarmJobRepo.Finish(ctx, job.ID, novel.PrewarmStatusFailed, job.Stats, "interrupted by server restart"); err != nil {
+				log.Warn().Err(err).Str("job_id", job.ID).Msg("标记中断的预热任务失败")
+			}
+			continue
+		}
+		s.schedulePrewarmJob(job)
+	}
+	log.Info().Int("jobs", len(jobs)).Msg("预热任务已恢复调度")
+	return nil
+}
+
+// schedulePrewarmJob 在预热开始时间触发任务
+func (s *novelService) schedulePrewarmJob(job *novel.PrewarmJob) {
+	delay := time.Until(job.StartAt)
+	if delay < 0 {
+		delay = 0
+	}
+	jobID := job.ID
+	time.AfterFunc(delay, func() {
+		s.runPrewarmJob(context.Background(), jobID)
+	})
+	log.Info().
+		Str("job_id", jobID).
+		Str("novel_id", job.NovelID).
+		Time("start_at", job.StartAt).
+		Time("render_at", job.RenderAt).
+		Msg("预热任务已调度")
+}
+
+// runPrewarmJob 执行预热任务：逐个把资源下载到本地缓存并记录命中统计
+func (s *novelService) runPrewarmJob(ctx context.Context, jobID string) {
+	ok, err := s.prewarmJobRepo.MarkRunning(ctx, jobID)
+	if err != nil {
+		log.Error().Err(err).Str("job_id", jobID).Msg("标记预热任务开始失败")
+		return
+	}
+	if !ok {
+		// 已被其他实例执行
+		return
+	}
+
+	job, err := s.prewarmJobRepo.FindByID(ctx, jobID)
+	if err != nil {
+		log.Error().Err(err).Str("job_id", jobID).Msg("查询预热任务失败")
+		return
+	}
+
+	if s.assetCacheMaxAge > 0 {
+		if removed, err := s.assetCache.Prune(s.assetCacheMaxAge); err != nil {
+			log.Warn().Err(err).Msg("清理本地资源缓存失败")
+		} else if removed > 0 {
+			log.Info().Int("removed", removed).Msg("已清理长期未访问的缓存文件")
+		}
+	}
+
+	resourceIDs, err := s.collectPrewarmResources(ctx, job)
+	if err != nil {
+		if err := s.prewarmJobRepo.Finish(ctx, jobID, novel.PrewarmStatusFailed, job.Stats, err.Error()); err != nil {
+			log.Error().Err(err).Str("job_id", jobID).Msg("记录预热结果失败")
+		}
+		return
+	}
+
+	stats := novel.PrewarmStats{Total: len(resourceIDs)}
+	for _, resourceID := range resourceIDs {
+		result, err := s.resourceService.StageFile(ctx, resourceID)
+		if err != nil {
+			stats.Failed++
+			log.Warn().Err(err).Str("job_id", jobID).Str("resource_id", resourceID).Msg("预热资源失败")
+			continue
+		}
+		if result.Hit {
+			stats.Hits++
+		} else {
+			stats.Misses++
+			stats.BytesStaged += result.Size
+		}
+	}
+
+	if err := s.prewarmJobRepo.Finish(ctx, jobID, novel.PrewarmStatusCompleted, stats, ""); err != nil {
+		log.Error().Err(err).Str("job_id", jobID).Msg("记录预热结果失败")
+	}
+
+	log.Info().
+		Str("job_id", jobID).
+		Str("novel_id", job.NovelID).
+		Int("total", stats.Total).
+		Int("hits", stats.Hits).
+		Int("misses", stats.Misses).
+		Int("failed", stats.Failed).
+		Int64("bytes_staged", stats.BytesStaged).
+		Msg("素材预热完成")
+}
+
+// collectPrewarmResources 收集预热任务需要的资源ID（去重）
+// 包括生成解说视频用的图片/音频/字幕，以及合成最终视频用的解说视频片段
+// 图片和音频优先使用章节的发布版本，其余取最新版本
+func (s *novelService) collectPrewarmResources(ctx context.Context, job *novel.PrewarmJob) ([]string, error) {
+	chapters, err := s.chapterRepo.FindByNovelID(ctx, job.NovelID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapters: %w", err)
+	}
+	selected := make(map[string]bool, len(job.ChapterIDs))
+	for _, chapterID := range job.ChapterIDs {
+		selected[chapterID] = true
+	}
+
+	var resourceIDs []string
+	seen := make(map[string]bool)
+	add := func(resourceID string) {
+		if resourceID != "" && !seen[resourceID] {
+			seen[resourceID] = true
+			resourceIDs = append(resourceIDs, resourceID)
+		}
+	}
+
+	for _, ch := range chapters {
+		if len(selected) > 0 && !selected[ch.ID] {
+			continue
+		}
+		var promoted novel.PromotedVersions
+		if ch.PromotedVersions != nil {
+			promoted = *ch.PromotedVersions
+		}
+
+		images, err := s.imageRepo.FindByChapterID(ctx, ch.ID)
+		if err != nil {
+			return nil, fmt.Errorf("find images: %w", err)
+		}
+		imageVersion := promoted.Image
+		if imageVersion == 0 {
+			for _, img := range images {
+				imageVersion = max(imageVersion, img.Version)
+			}
+		}
+		for _, img := range images {
+			if img.Version == imageVersion && img.Status == novel.TaskStatusCompleted {
+				add(img.ImageResourceID)
+			}
+		}
+
+		audios, err := s.audioRepo.FindByChapterID(ctx, ch.ID)
+		if err != nil {
+			return nil, fmt.Errorf("find audios: %w", err)
+		}
+		audioVersion := promoted.Audio
+		if audioVersion == 0 {
+			for _, a := range audios {
+				audioVersion = max(audioVersion, a.Version)
+			}
+		}
+		for _, a := range audios {
+			if a.Version == audioVersion && a.Status == novel.TaskStatusCompleted {
+				add(a.AudioResourceID)
+			}
+		}
+
+		// 字幕取最新生成的一版（与最新字幕同一解说、同一版本的所有片段）
+		latestSubtitle, err := s.subtitleRepo.FindByChapterID(ctx, ch.ID)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("find subtitles: %w", err)
+		}
+		if latestSubtitle != nil {
+			subtitles, err := s.subtitleRepo.FindByNarrationIDAndVersion(ctx, latestSubtitle.NarrationID, latestSubtitle.Version)
+			if err != nil {
+				return nil, fmt.Errorf("find subtitles: %w", err)
+			}
+			for _, sub := range subtitles {
+				if sub.Status == novel.TaskStatusCompleted {
+					add(sub.SubtitleResourceID)
+				}
+			}
+		}
+
+		videos, err := s.videoRepo.FindByChapterIDAndType(ctx, ch.ID, novel.VideoTypeNarration)
+		if err != nil {
+			return nil, fmt.Errorf("find narration videos: %w", err)
+		}
+		videoVersion := 0
+		for _, v := range videos {
+			videoVersion = max(videoVersion, v.Version)
+		}
+		for _, v := range videos {
+			if v.Version == videoVersion && v.Status == novel.VideoStatusCompleted {
+				add(v.VideoResourceID)
+			}
+		}
+	}
+	return resourceIDs, nil
+}
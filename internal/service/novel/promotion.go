@@ -0,0 +1,526 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/eventbus"
+	"lemon/internal/pkg/id"
+)
+
+var (
+	// ErrInvalidPromotion 批量发布请求不合法（策略错误、章节不属于该小说等）
+	ErrInvalidPromotion = errors.New("invalid promotion request")
+	// ErrPromotionVersionNotFound 指定的版本不存在或尚未全部生成完成
+	ErrPromotionVersionNotFound = errors.New("promotion version not found")
+	// ErrPromotionConflict 章节的发布版本在本次操作期间被其他发布或回滚修改
+	ErrPromotionConflict = errors.New("promotion conflict")
+	// ErrNoPromotionToRollback 章节（或批次）没有可以回滚的发布记录
+	ErrNoPromotionToRollback = errors.New("no promotion to rollback")
+)
+
+// 发布事件类型（通过事件总线发布，主题为 PromotionEventTopic(novelID)）
+const (
+	PromotionEventPromoted   = "promotion.promoted"    // 批量发布完成
+	PromotionEventRolledBack = "promotion.rolled_back" // 回滚完成
+)
+
+// PromotionService 版本发布服务接口
+type PromotionService interface {
+	// PromoteNovelVersions 批量发布整部小说的章节版本（解说/图片/音频/字幕/最终视频）
+	// 先为所有章节生成发布计划并校验，全部通过后才写入；dry_run 时只返回计划
+	// QA 评分卡阻断的章节跳过发布（IgnoreQA 时不阻断），内容风险报告未确认的章节同样跳过
+	PromoteNovelVersions(ctx context.Context, novelID string, req *PromoteVersionsRequest) (*PromotionPlan, error)
+
+	// RollbackChapterPromotion 把章节恢复到最近一次仍然生效的发布之前的版本，重复调用逐次向前回滚
+	RollbackChapterPromotion(ctx context.Context, chapterID string, req *RollbackPromotionRequest) (*PromotionRollback, error)
+
+	// RollbackPromotionBatch 回滚一次批量发布涉及的所有章节，批次中的章节之后又发布过新版本时整体不回滚
+	RollbackPromotionBatch(ctx context.Context, novelID, batchID string, req *RollbackPromotionRequest) (*PromotionRollback, error)
+
+	// ListChapterPromotions 查询章节的发布记录（按时间倒序）
+	ListChapterPromotions(ctx context.Context, chapterID string) ([]*PromotionHistoryEntry, error)
+
+	// SubscribePromotionEvents 订阅小说的发布/回滚事件，返回事件通道和取消订阅函数
+	SubscribePromotionEvents(novelID string) (<-chan eventbus.Event, func())
+}
+
+// VersionSelection 章节各类产物的版本选择，0 表示该类产物保持当前发布版本不变
+type VersionSelection struct {
+	Narration int `json:"narration,omitempty"`
+	Image     int `json:"image,omitempty"`
+	Audio     int `json:"audio,omitempty"`
+	Subtitle  int `json:"subtitle,omitempty"`
+	Video     int `json:"video,omitempty"`
+}
+
+// PromoteVersionsRequest 批量发布版本请求
+type PromoteVersionsRequest struct {
+	Strategy   novel.PromotionStrategy     // 选择策略：latest_approved、explicit
+	Versions   map[string]VersionSelection // explicit 策略下按章节ID指定的版本
+	DryRun     bool                        // 只预览发布计划，不写入
+	IgnoreQA   bool                        // 忽略 QA 评分卡的阻断（仍然计算并返回评分卡）
+	PromotedBy string                      // 操作人用户ID
+}
+
+// ChapterPromotion 单个章节的发布计划
+type ChapterPromotion struct {
+	ChapterID string                  `json:"chapter_id"`
+	Sequence  int                     `json:"sequence"`
+	Title     string                  `json:"title"`
+	Previous  *novel.PromotedVersions `json:"previous,omitempty"` // 当前发布版本
+	Next      VersionSelection        `json:"next"`               // 发布后的版本
+	Changed   bool                    `json:"changed"`            // 发布后版本是否有变化
+	Skipped   string                  `json:"skipped,omitempty"`  // 跳过原因（未跳过时为空）
+	QA        *novel.QAScorecard      `json:"qa,omitempty"`       // 发布后版本的 QA 评分卡（版本有变化时计算）
+	// 发布后解说版本的内容风险报告（版本有变化时扫描），存在风险且未确认时跳过发布
+	ContentRisk *novel.ContentRiskReport `json:"content_risk,omitempty"`
+}
+
+// PromotionPlan 批量发布计划（dry_run 时为预览，否则为实际执行结果）
+type PromotionPlan struct {
+	NovelID  string                  `json:"novel_id"`
+	BatchID  string                  `json:"batch_id,omitempty"` // 发布批次ID（实际写入时才有，用于整批回滚）
+	Strategy novel.PromotionStrategy `json:"strategy"`
+	DryRun   bool                    `json:"dry_run"`
+	Chapters []*ChapterPromotion     `json:"chapters"`
+	Promoted int                     `json:"promoted"` // 版本有变化的章节数
+}
+
+// RollbackPromotionRequest 回滚发布请求
+type RollbackPromotionRequest struct {
+	OperatedBy string // 操作人用户ID
+	Reason     string // 回滚原因
+}
+
+// PromotionRollback 回滚结果
+type PromotionRollback struct {
+	NovelID string                   `json:"novel_id"`
+	BatchID string                   `json:"batch_id"` // 本次回滚的批次ID
+	Records []*novel.PromotionRecord `json:"records"`  // 每个回滚章节的回滚记录
+}
+
+// PromotionHistoryEntry 章节发布记录（附带是否已被回滚）
+type PromotionHistoryEntry struct {
+	*novel.PromotionRecord
+	RolledBack       bool   `json:"rolled_back"`                  // 发布记录是否已被回滚
+	RollbackRecordID string `json:"rollback_record_id,omitempty"` // 撤销该发布的回滚记录ID
+}
+
+// PromotionEvent 发布/回滚事件数据，下游系统（如分发平台）据此同步章节的发布版本
+type PromotionEvent struct {
+	NovelID    string                   `json:"novel_id"`
+	BatchID    string                   `json:"batch_id"`
+	Action     novel.PromotionAction    `json:"action"`
+	OperatedBy string                   `json:"operated_by,omitempty"`
+	Records    []*novel.PromotionRecord `json:"records"` // 版本有变化的章节（Next 为变更后的发布版本）
+}
+
+// PromotionEventTopic 返回小说发布事件的事件总线主题
+func PromotionEventTopic(novelID string) string {
+	return "promotion:" + novelID
+}
+
+// appliedPromotion 已写入的章节发布版本（用于失败时回滚）
+type appliedPromotion struct {
+	chapterID string
+	previous  *novel.PromotedVersions
+	next      *novel.PromotedVersions
+	record    *novel.PromotionRecord
+}
+
+// PromoteNovelVersions 批量发布整部小说的章节版本
+// 没有使用数据库事务：先完整生成并校验计划，写入过程中任一章节失败时，把已写入的章节回滚到原发布版本
+// 每个章节按生成计划时读到的发布版本做条件写入，期间被其他操作修改时整体失败（ErrPromotionConflict）
+// 每个版本有变化的章节追加一条发布记录，全部写入后发布 PromotionEventPromoted 事件
+func (s *novelService) PromoteNovelVersions(ctx context.Context, novelID string, req *PromoteVersionsRequest) (*PromotionPlan, error) {
+	if !req.Strategy.IsValid() {
+		return nil, fmt.Errorf("%w: unknown strategy %q", ErrInvalidPromotion, req.Strategy)
+	}
+	if req.Strategy == novel.PromotionStrategyExplicit && len(req.Versions) == 0 {
+		return nil, fmt.Errorf("%w: versions is required for explicit strategy", ErrInvalidPromotion)
+	}
+
+	if _, err := s.novelRepo.FindByID(ctx, novelID); err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+	chapters, err := s.chapterRepo.FindByNovelID(ctx, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapters: %w", err)
+	}
+
+	if req.Strategy == novel.PromotionStrategyExplicit {
+		known := make(map[string]bool, len(chapters))
+		for _, ch := range chapters {
+			known[ch.ID] = true
+		}
+		for chapterID := range req.Versions {
+			if !known[chapterID] {
+				return nil, fmt.Errorf("%w: chapter %s does not belong to novel %s", ErrInvalidPromotion, chapterID, novelID)
+			}
+		}
+	}
+
+	// 1. 生成并校验所有章节的发布计划
+	plan := &PromotionPlan{
+		NovelID:  novelID,
+		Strategy: req.Strategy,
+		DryRun:   req.DryRun,
+	}
+	for _, ch := range chapters {
+		item, err := s.planChapterPromotion(ctx, ch, req)
+		if err != nil {
+			return nil, err
+		}
+		if item.Changed {
+			plan.Promoted++
+		}
+		plan.Chapters = append(plan.Chapters, item)
+	}
+
+	if req.DryRun {
+		return plan, nil
+	}
+
+	// 2. 写入发布版本和发布记录，失败时回滚已写入的章节
+	plan.BatchID = id.New()
+	now := time.Now()
+	var applied []*appliedPromotion
+	for _, item := range plan.Chapters {
+		if !item.Changed {
+			continue
+		}
+		pv := &novel.PromotedVersions{
+			Narration:  item.Next.Narration,
+			Image:      item.Next.Image,
+			Audio:      item.Next.Audio,
+			Subtitle:   item.Next.Subtitle,
+			Video:      item.Next.Video,
+			PromotedBy: req.PromotedBy,
+			PromotedAt: now,
+		}
+		record := &novel.PromotionRecord{
+			ID:         id.New(),
+			NovelID:    novelID,
+			ChapterID:  item.ChapterID,
+			BatchID:    plan.BatchID,
+			Action:     novel.PromotionActionPromote,
+			Strategy:   req.Strategy,
+			Previous:   item.Previous,
+			Next:       pv,
+			OperatedBy: req.PromotedBy,
+		}
+		if err := s.applyPromotion(ctx, record); err != nil {
+			s.rollbackPromotions(ctx, plan.BatchID, "批量发布写入失败，自动回滚", applied)
+			return nil, fmt.Errorf("promote chapter %s: %w", item.ChapterID, err)
+		}
+		applied = append(applied, &appliedPromotion{chapterID: item.ChapterID, previous: item.Previous, next: pv, record: record})
+	}
+
+	log.Info().
+		Str("novel_id", novelID).
+		Str("batch_id", plan.BatchID).
+		Str("strategy", req.Strategy.String()).
+		Int("promoted", plan.Promoted).
+		Str("promoted_by", req.PromotedBy).
+		Msg("小说版本批量发布完成")
+
+	if len(applied) > 0 {
+		records := make([]*novel.PromotionRecord, 0, len(applied))
+		for _, a := range applied {
+			records = append(records, a.record)
+		}
+		s.publishPromotionEvent(PromotionEventPromoted, &PromotionEvent{
+			NovelID:    novelID,
+			BatchID:    plan.BatchID,
+			Action:     novel.PromotionActionPromote,
+			OperatedBy: req.PromotedBy,
+			Records:    records,
+		})
+		// 公证新发布的最终视频（计算哈希和申请时间戳可能较慢，在后台执行）
+		go s.notarizePromotions(context.WithoutCancel(ctx), plan.BatchID, applied)
+	}
+
+	return plan, nil
+}
+
+// applyPromotion 按发布记录条件写入章节的发布版本，并追加发布记录
+// 追加记录失败时把章节恢复到 Previous，保证每次生效的变更都有记录可以回滚
+func (s *novelService) applyPromotion(ctx context.Context, record *novel.PromotionRecord) error {
+	if err := s.chapterRepo.SwapPromotedVersions(ctx, record.ChapterID, record.Previous, record.Next); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("%w: promoted versions of chapter %s changed", ErrPromotionConflict, record.ChapterID)
+		}
+		return err
+	}
+	if err := s.promotionRecordRepo.Append(ctx, record); err != nil {
+		if restoreErr := s.chapterRepo.SwapPromotedVersions(ctx, record.ChapterID, record.Next, record.Previous); restoreErr != nil {
+			log.Error().Err(restoreErr).Str("chapter_id", record.ChapterID).Msg("恢复章节发布版本失败")
+		}
+		return fmt.Errorf("append promotion record: %w", err)
+	}
+	return nil
+}
+
+// publishPromotionEvent 发布发布/回滚事件
+func (s *novelService) publishPromotionEvent(eventType string, event *PromotionEvent) {
+	s.eventBus.Publish(PromotionEventTopic(event.NovelID), eventType, event)
+}
+
+// SubscribePromotionEvents 订阅小说的发布/回滚事件
+func (s *novelService) SubscribePromotionEvents(novelID string) (<-chan eventbus.Event, func()) {
+	return s.eventBus.Subscribe(PromotionEventTopic(novelID))
+}
+
+// planChapterPromotion 生成单个章节的发布计划
+func (s *novelService) planChapterPromotion(ctx context.Context, ch *novel.Chapter, req *PromoteVersionsRequest) (*ChapterPromotion, error) {
+	item := &ChapterPromotion{
+		ChapterID: ch.ID,
+		Sequence:  ch.Sequence,
+		Title:     ch.Title,
+		Previous:  ch.PromotedVersions,
+	}
+	var previous VersionSelection
+	if ch.PromotedVersions != nil {
+		previous = VersionSelection{
+			Narration: ch.PromotedVersions.Narration,
+			Image:     ch.PromotedVersions.Image,
+			Audio:     ch.PromotedVersions.Audio,
+			Subtitle:  ch.PromotedVersions.Subtitle,
+			Video:     ch.PromotedVersions.Video,
+		}
+	}
+	item.Next = previous
+
+	var selection VersionSelection
+	switch req.Strategy {
+	case novel.PromotionStrategyExplicit:
+		sel, ok := req.Versions[ch.ID]
+		if !ok {
+			item.Skipped = "未指定版本"
+			return item, nil
+		}
+		selection = sel
+	case novel.PromotionStrategyLatestApproved:
+		if !ch.IsApproved() {
+			item.Skipped = "章节未审核通过"
+			return item, nil
+		}
+	}
+
+	versions, err := s.chapterCompletedVersions(ctx, ch.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Strategy == novel.PromotionStrategyLatestApproved {
+		// 只取审核时已经完成的版本，审核之后重新生成的版本需要再次审核
+		selection = VersionSelection{
+			Narration: versions.narration.latestBefore(*ch.ApprovedAt),
+			Image:     versions.image.latestBefore(*ch.ApprovedAt),
+			Audio:     versions.audio.latestBefore(*ch.ApprovedAt),
+			Subtitle:  versions.subtitle.latestBefore(*ch.ApprovedAt),
+			Video:     versions.video.latestBefore(*ch.ApprovedAt),
+		}
+	} else {
+		checks := []struct {
+			kind    string
+			version int
+			index   versionIndex
+		}{
+			{"narration", selection.Narration, versions.narration},
+			{"image", selection.Image, versions.image},
+			{"audio", selection.Audio, versions.audio},
+			{"subtitle", selection.Subtitle, versions.subtitle},
+			{"video", selection.Video, versions.video},
+		}
+		for _, c := range checks {
+			if c.version < 0 {
+				return nil, fmt.Errorf("%w: chapter %s %s version %d", ErrInvalidPromotion, ch.ID, c.kind, c.version)
+			}
+			if c.version > 0 && !c.index.isComplete(c.version) {
+				return nil, fmt.Errorf("%w: chapter %s %s version %d", ErrPromotionVersionNotFound, ch.ID, c.kind, c.version)
+			}
+		}
+	}
+
+	if selection.Narration > 0 {
+		item.Next.Narration = selection.Narration
+	}
+	if selection.Image > 0 {
+		item.Next.Image = selection.Image
+	}
+	if selection.Audio > 0 {
+		item.Next.Audio = selection.Audio
+	}
+	if selection.Subtitle > 0 {
+		item.Next.Subtitle = selection.Subtitle
+	}
+	if selection.Video > 0 {
+		item.Next.Video = selection.Video
+	}
+
+	item.Changed = item.Next != previous
+	if !item.Changed {
+		return item, nil
+	}
+
+	// 版本有变化时检查发布后版本的 QA 评分卡，被阻断的章节保持原发布版本
+	card, err := s.chapterQAScorecard(ctx, ch, item.Next)
+	if err != nil {
+		return nil, fmt.Errorf("qa scorecard for chapter %s: %w", ch.ID, err)
+	}
+	item.QA = card
+
+	// 内容风险必须由编辑确认，IgnoreQA 不跳过；没有解说时由 QA 评分卡阻断
+	risk, err := s.chapterContentRiskReport(ctx, ch, item.Next.Narration)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("content risk report for chapter %s: %w", ch.ID, err)
+	}
+	item.ContentRisk = risk
+
+	switch {
+	case card.Blocked && !req.IgnoreQA:
+		item.Skipped = fmt.Sprintf("QA 评分卡未通过（%.1f 分）：%s", card.Score, strings.Join(card.BlockingReasons, "; "))
+	case risk != nil && risk.Blocking():
+		item.Skipped = fmt.Sprintf("内容风险报告未确认（%d 处风险，其中高风险 %d 处）", len(risk.Findings), risk.HighRisk)
+	default:
+		return item, nil
+	}
+	item.Next = previous
+	item.Changed = false
+	return item, nil
+}
+
+// rollbackPromotions 把已写入的章节恢复到原发布版本，并追加对应的回滚记录（尽力而为，失败只记录日志）
+func (s *novelService) rollbackPromotions(ctx context.Context, batchID, reason string, applied []*appliedPromotion) {
+	for _, a := range applied {
+		if err := s.chapterRepo.SwapPromotedVersions(ctx, a.chapterID, a.next, a.previous); err != nil {
+			log.Error().Err(err).Str("chapter_id", a.chapterID).Msg("回滚章节发布版本失败")
+			continue
+		}
+		record := &novel.PromotionRecord{
+			ID:         id.New(),
+			NovelID:    a.record.NovelID,
+			ChapterID:  a.chapterID,
+			BatchID:    batchID,
+			Action:     novel.PromotionActionRollback,
+			Previous:   a.next,
+			Next:       a.previous,
+			RollbackOf: a.record.ID,
+			Reason:     reason,
+			OperatedBy: a.record.OperatedBy,
+		}
+		if err := s.promotionRecordRepo.Append(ctx, record); err != nil {
+			log.Error().Err(err).Str("chapter_id", a.chapterID).Msg("追加回滚记录失败")
+		}
+	}
+}
+
+// chapterVersions 章节各类产物的版本索引
+type chapterVersions struct {
+	narration versionIndex
+	image     versionIndex
+	audio     versionIndex
+	subtitle  versionIndex
+	video     versionIndex
+}
+
+// chapterCompletedVersions 查询章节各类产物的所有版本及完成情况
+// 视频只统计最终视频（final_video），解说视频片段随最终视频一起发布
+func (s *novelService) chapterCompletedVersions(ctx context.Context, chapterID string) (*chapterVersions, error) {
+	v := &chapterVersions{
+		narration: versionIndex{},
+		image:     versionIndex{},
+		audio:     versionIndex{},
+		subtitle:  versionIndex{},
+		video:     versionIndex{},
+	}
+
+	narrations, err := s.narrationRepo.FindAllByChapterID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find narrations: %w", err)
+	}
+	for _, n := range narrations {
+		v.narration.add(n.Version, n.Status == novel.TaskStatusCompleted, n.CreatedAt)
+	}
+
+	images, err := s.imageRepo.FindByChapterID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find images: %w", err)
+	}
+	for _, img := range images {
+		v.image.add(img.Version, img.Status == novel.TaskStatusCompleted, img.CreatedAt)
+	}
+
+	audios, err := s.audioRepo.FindByChapterID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find audios: %w", err)
+	}
+	for _, a := range audios {
+		v.audio.add(a.Version, a.Status == novel.TaskStatusCompleted, a.CreatedAt)
+	}
+
+	subtitles, err := s.subtitleRepo.FindAllByChapterID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find subtitles: %w", err)
+	}
+	for _, sub := range subtitles {
+		v.subtitle.add(sub.Version, sub.Status == novel.TaskStatusCompleted, sub.CreatedAt)
+	}
+
+	videos, err := s.videoRepo.FindByChapterIDAndType(ctx, chapterID, novel.VideoTypeFinal)
+	if err != nil {
+		return nil, fmt.Errorf("find final videos: %w", err)
+	}
+	for _, video := range videos {
+		v.video.add(video.Version, video.Status == novel.VideoStatusCompleted, video.CreatedAt)
+	}
+
+	return v, nil
+}
+
+// versionStat 某个版本的汇总状态
+type versionStat struct {
+	complete  bool      // 该版本的所有记录都已完成
+	createdAt time.Time // 该版本最后一条记录的创建时间
+}
+
+// versionIndex 版本号 -> 版本状态
+// 图片和音频的一个版本由多条记录组成，只有全部完成才视为可发布
+type versionIndex map[int]*versionStat
+
+func (idx versionIndex) add(version int, completed bool, createdAt time.Time) {
+	stat, ok := idx[version]
+	if !ok {
+		idx[version] = &versionStat{complete: completed, createdAt: createdAt}
+		return
+	}
+	stat.complete = stat.complete && completed
+	if createdAt.After(stat.createdAt) {
+		stat.createdAt = createdAt
+	}
+}
+
+func (idx versionIndex) isComplete(version int) bool {
+	stat, ok := idx[version]
+	return ok && stat.complete
+}
+
+// latestBefore 返回在 t 之前（含）生成完成的最新版本，没有时返回 0
+func (idx versionIndex) latestBefore(t time.Time) int {
+	latest := 0
+	for version, stat := range idx {
+		if stat.complete && !stat.createdAt.After(t) && version > latest {
+			latest = version
+		}
+	}
+	return latest
+}
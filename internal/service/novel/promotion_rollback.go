@@ -0,0 +1,233 @@
+package novel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+)
+
+// RollbackChapterPromotion 回滚章节最近一次仍然生效的发布
+// 发布记录只追加不修改：回滚同样追加一条回滚记录，重复回滚时跳过已被回滚的发布，逐次恢复到更早的版本
+func (s *novelService) RollbackChapterPromotion(ctx context.Context, chapterID string, req *RollbackPromotionRequest) (*PromotionRollback, error) {
+	ch, err := s.chapterRepo.FindByID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+	history, err := s.promotionRecordRepo.FindByChapterID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find promotion records: %w", err)
+	}
+	target := latestEffectivePromotion(history)
+	if target == nil {
+		return nil, fmt.Errorf("%w: chapter %s", ErrNoPromotionToRollback, chapterID)
+	}
+
+	batchID := id.New()
+	record, err := planPromotionRollback(ch, target, batchID, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyPromotion(ctx, record); err != nil {
+		return nil, fmt.Errorf("rollback chapter %s: %w", chapterID, err)
+	}
+
+	log.Info().
+		Str("novel_id", ch.NovelID).
+		Str("chapter_id", chapterID).
+		Str("rollback_of", target.ID).
+		Str("operated_by", req.OperatedBy).
+		Msg("章节发布版本已回滚")
+
+	result := &PromotionRollback{
+		NovelID: ch.NovelID,
+		BatchID: batchID,
+		Records: []*novel.PromotionRecord{record},
+	}
+	s.publishPromotionEvent(PromotionEventRolledBack, &PromotionEvent{
+		NovelID:    ch.NovelID,
+		BatchID:    batchID,
+		Action:     novel.PromotionActionRollback,
+		OperatedBy: req.OperatedBy,
+		Records:    result.Records,
+	})
+	return result, nil
+}
+
+// RollbackPromotionBatch 回滚一次批量发布
+// 先为批次中的所有章节生成回滚记录并校验，任一章节之后又发布过新版本时整体不回滚；
+// 写入中途失败时把已回滚的章节恢复为回滚前的版本。批次中已被单独回滚的章节跳过
+func (s *novelService) RollbackPromotionBatch(ctx context.Context, novelID, batchID string, req *RollbackPromotionRequest) (*PromotionRollback, error) {
+	records, err := s.promotionRecordRepo.FindByBatchID(ctx, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("find promotion records: %w", err)
+	}
+	var targets []*novel.PromotionRecord
+	for _, r := range records {
+		if r.NovelID == novelID && r.Action == novel.PromotionActionPromote {
+			targets = append(targets, r)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("%w: batch %s has no promotion of novel %s", ErrNoPromotionToRollback, batchID, novelID)
+	}
+
+	// 1. 生成并校验所有章节的回滚记录
+	rollbackBatchID := id.New()
+	var planned []*novel.PromotionRecord
+	for _, target := range targets {
+		history, err := s.promotionRecordRepo.FindByChapterID(ctx, target.ChapterID)
+		if err != nil {
+			return nil, fmt.Errorf("find promotion records: %w", err)
+		}
+		if undoneBy(history)[target.ID] != "" {
+			continue
+		}
+		if latest := latestEffectivePromotion(history); latest == nil || latest.ID != target.ID {
+			return nil, fmt.Errorf("%w: chapter %s has been promoted again after batch %s", ErrPromotionConflict, target.ChapterID, batchID)
+		}
+		ch, err := s.chapterRepo.FindByID(ctx, target.ChapterID)
+		if err != nil {
+			return nil, fmt.Errorf("find chapter: %w", err)
+		}
+		record, err := planPromotionRollback(ch, target, rollbackBatchID, req)
+		if err != nil {
+			return nil, err
+		}
+		planned = append(planned, record)
+	}
+	if len(planned) == 0 {
+		return nil, fmt.Errorf("%w: batch %s has already been rolled back", ErrNoPromotionToRollback, batchID)
+	}
+
+	// 2. 写入回滚，失败时恢复已回滚的章节
+	var applied []*appliedPromotion
+	for _, record := range planned {
+		if err := s.applyPromotion(ctx, record); err != nil {
+			s.rollbackPromotions(ctx, rollbackBatchID, "批量回滚写入失败，自动恢复", applied)
+			return nil, fmt.Errorf("rollback chapter %s: %w", record.ChapterID, err)
+		}
+		applied = append(applied, &appliedPromotion{chapterID: record.ChapterID, previous: record.Previous, next: record.Next, record: record})
+	}
+
+	log.Info().
+		Str("novel_id", novelID).
+		Str("batch_id", batchID).
+		Int("chapters", len(planned)).
+		Str("operated_by", req.OperatedBy).
+		Msg("批量发布已回滚")
+
+	s.publishPromotionEvent(PromotionEventRolledBack, &PromotionEvent{
+		NovelID:    novelID,
+		BatchID:    rollbackBatchID,
+		Action:     novel.PromotionActionRollback,
+		OperatedBy: req.OperatedBy,
+		Records:    planned,
+	})
+	return &PromotionRollback{NovelID: novelID, BatchID: rollbackBatchID, Records: planned}, nil
+}
+
+// ListChapterPromotions 查询章节的发布记录
+func (s *novelService) ListChapterPromotions(ctx context.Context, chapterID string) ([]*PromotionHistoryEntry, error) {
+	if _, err := s.chapterRepo.FindByID(ctx, chapterID); err != nil {
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+	history, err := s.promotionRecordRepo.FindByChapterID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find promotion records: %w", err)
+	}
+
+	undone := undoneBy(history)
+	entries := make([]*PromotionHistoryEntry, 0, len(history))
+	for _, r := range history {
+		entries = append(entries, &PromotionHistoryEntry{
+			PromotionRecord:  r,
+			RolledBack:       undone[r.ID] != "",
+			RollbackRecordID: undone[r.ID],
+		})
+	}
+	return entries, nil
+}
+
+// planPromotionRollback 生成把章节恢复到 target 发布之前版本的回滚记录
+// 章节当前的发布版本必须仍是 target 发布的版本；恢复的版本以本次回滚的操作人和时间重新发布
+func planPromotionRollback(ch *novel.Chapter, target *novel.PromotionRecord, batchID string, req *RollbackPromotionRequest) (*novel.PromotionRecord, error) {
+	if !samePromotedVersions(ch.PromotedVersions, target.Next) {
+		return nil, fmt.Errorf("%w: promoted versions of chapter %s no longer match promotion %s", ErrPromotionConflict, ch.ID, target.ID)
+	}
+
+	var next *novel.PromotedVersions
+	if target.Previous != nil {
+		restored := *target.Previous
+		restored.PromotedBy = req.OperatedBy
+		restored.PromotedAt = time.Now()
+		next = &restored
+	}
+	return &novel.PromotionRecord{
+		ID:         id.New(),
+		NovelID:    ch.NovelID,
+		ChapterID:  ch.ID,
+		BatchID:    batchID,
+		Action:     novel.PromotionActionRollback,
+		Previous:   ch.PromotedVersions,
+		Next:       next,
+		RollbackOf: target.ID,
+		Reason:     req.Reason,
+		OperatedBy: req.OperatedBy,
+	}, nil
+}
+
+// undoneBy 返回已被撤销的记录ID → 撤销它的回滚记录ID
+// 回滚记录本身也可能被撤销（批量回滚失败后的自动恢复），被撤销的回滚不再撤销它回滚的记录
+func undoneBy(history []*novel.PromotionRecord) map[string]string {
+	rollbacks := make(map[string]string) // 记录ID → 回滚它的记录ID（取最新的一条）
+	for i := len(history) - 1; i >= 0; i-- {
+		if r := history[i]; r.Action == novel.PromotionActionRollback && r.RollbackOf != "" {
+			rollbacks[r.RollbackOf] = r.ID
+		}
+	}
+
+	undone := make(map[string]string)
+	var isUndone func(recordID string, depth int) bool
+	isUndone = func(recordID string, depth int) bool {
+		by, ok := rollbacks[recordID]
+		if !ok || depth > len(history) {
+			return false
+		}
+		return !isUndone(by, depth+1)
+	}
+	for recordID, by := range rollbacks {
+		if isUndone(recordID, 0) {
+			undone[recordID] = by
+		}
+	}
+	return undone
+}
+
+// latestEffectivePromotion 返回最近一次仍然生效（未被回滚）的发布记录，没有时返回 nil
+// history 按时间倒序排列
+func latestEffectivePromotion(history []*novel.PromotionRecord) *novel.PromotionRecord {
+	undone := undoneBy(history)
+	for _, r := range history {
+		if r.Action == novel.PromotionActionPromote && undone[r.ID] == "" {
+			return r
+		}
+	}
+	return nil
+}
+
+// samePromotedVersions 比较两个发布版本的各产物版本号（不比较操作人和发布时间）
+func samePromotedVersions(a, b *novel.PromotedVersions) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Narration == b.Narration &&
+		a.Image == b.Image &&
+		a.Audio == b.Audio &&
+		a.Subtitle == b.Subtitle &&
+		a.Video == b.Video
+}
@@ -0,0 +1,371 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/eventbus"
+	novelrepo "lemon/internal/repository/novel"
+)
+
+// errPromotionWrite 模拟写入章节发布版本失败
+var errPromotionWrite = errors.New("write failed")
+
+// memPromotionChapterRepo 内存中的章节仓库，按当前发布版本比较后替换（与 SwapPromotedVersions 的条件更新一致），failing 中的章节写入失败
+type memPromotionChapterRepo struct {
+	memChapterRepo
+	failing map[string]bool
+}
+
+func (r *memPromotionChapterRepo) SwapPromotedVersions(_ context.Context, chapterID string, expected, next *novel.PromotedVersions) error {
+	if r.failing[chapterID] {
+		return errPromotionWrite
+	}
+	for _, ch := range r.chapters {
+		if ch.ID == chapterID && samePromotedVersions(ch.PromotedVersions, expected) {
+			ch.PromotedVersions = next
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+// memPromotionRecordRepo 内存中的发布记录仓库，按章节查询时按时间倒序返回
+type memPromotionRecordRepo struct {
+	novelrepo.PromotionRecordRepository
+	records []*novel.PromotionRecord
+}
+
+func (r *memPromotionRecordRepo) Append(_ context.Context, record *novel.PromotionRecord) error {
+	record.CreatedAt = time.Now()
+	r.records = append(r.records, record)
+	return nil
+}
+
+func (r *memPromotionRecordRepo) FindByChapterID(_ context.Context, chapterID string) ([]*novel.PromotionRecord, error) {
+	var records []*novel.PromotionRecord
+	for i := len(r.records) - 1; i >= 0; i-- {
+		if r.records[i].ChapterID == chapterID {
+			records = append(records, r.records[i])
+		}
+	}
+	return records, nil
+}
+
+func (r *memPromotionRecordRepo) FindByBatchID(_ context.Context, batchID string) ([]*novel.PromotionRecord, error) {
+	var records []*novel.PromotionRecord
+	for _, record := range r.records {
+		if record.BatchID == batchID {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// videoVersion 只发布了最终视频版本 v 的发布版本
+func videoVersion(v int) *novel.PromotedVersions {
+	return &novel.PromotedVersions{Video: v}
+}
+
+// promotionFixture 小说 novel-1 的章节 ch-1、ch-2 都未发布
+type promotionFixture struct {
+	svc      *novelService
+	chapters *memPromotionChapterRepo
+	records  *memPromotionRecordRepo
+}
+
+func newPromotionFixture() *promotionFixture {
+	f := &promotionFixture{
+		chapters: &memPromotionChapterRepo{failing: make(map[string]bool)},
+		records:  &memPromotionRecordRepo{},
+	}
+	for i, chapterID := range []string{"ch-1", "ch-2"} {
+		f.chapters.chapters = append(f.chapters.chapters, &novel.Chapter{ID: chapterID, NovelID: "novel-1", Sequence: i + 1})
+	}
+	f.svc = &novelService{
+		chapterRepo:         f.chapters,
+		promotionRecordRepo: f.records,
+		eventBus:            eventbus.New(),
+	}
+	return f
+}
+
+// promote 在批次 batchID 中把章节发布为最终视频版本 v
+func (f *promotionFixture) promote(t *testing.T, batchID string, v int, chapterIDs ...string) {
+	t.Helper()
+	for _, chapterID := range chapterIDs {
+		ch, err := f.chapters.FindByID(context.Background(), chapterID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		record := &novel.PromotionRecord{
+			ID:        batchID + "/" + chapterID,
+			NovelID:   "novel-1",
+			ChapterID: chapterID,
+			BatchID:   batchID,
+			Action:    novel.PromotionActionPromote,
+			Previous:  ch.PromotedVersions,
+			Next:      videoVersion(v),
+		}
+		if err := f.svc.applyPromotion(context.Background(), record); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// promoted 返回章节当前发布的最终视频版本（未发布为 0）
+func (f *promotionFixture) promoted(chapterID string) int {
+	ch, _ := f.chapters.FindByID(context.Background(), chapterID)
+	if ch.PromotedVersions == nil {
+		return 0
+	}
+	return ch.PromotedVersions.Video
+}
+
+func TestLatestEffectivePromotion(t *testing.T) {
+	promote := func(id string) *novel.PromotionRecord {
+		return &novel.PromotionRecord{ID: id, Action: novel.PromotionActionPromote}
+	}
+	rollback := func(id, of string) *novel.PromotionRecord {
+		return &novel.PromotionRecord{ID: id, Action: novel.PromotionActionRollback, RollbackOf: of}
+	}
+
+	tests := []struct {
+		name       string
+		history    []*novel.PromotionRecord // 按时间倒序
+		wantLatest string
+		wantUndone map[string]string
+	}{
+		{
+			name:       "没有发布",
+			history:    nil,
+			wantLatest: "",
+			wantUndone: map[string]string{},
+		},
+		{
+			name:       "回滚最近一次发布",
+			history:    []*novel.PromotionRecord{rollback("r1", "p2"), promote("p2"), promote("p1")},
+			wantLatest: "p1",
+			wantUndone: map[string]string{"p2": "r1"},
+		},
+		{
+			name:       "重复回滚逐次恢复",
+			history:    []*novel.PromotionRecord{rollback("r2", "p1"), rollback("r1", "p2"), promote("p2"), promote("p1")},
+			wantLatest: "",
+			wantUndone: map[string]string{"p2": "r1", "p1": "r2"},
+		},
+		{
+			name:       "回滚被撤销后发布重新生效",
+			history:    []*novel.PromotionRecord{rollback("r2", "r1"), rollback("r1", "p1"), promote("p1")},
+			wantLatest: "p1",
+			wantUndone: map[string]string{"r1": "r2"},
+		},
+		{
+			name:       "撤销回滚后再次回滚",
+			history:    []*novel.PromotionRecord{rollback("r3", "p1"), rollback("r2", "r1"), rollback("r1", "p1"), promote("p1")},
+			wantLatest: "",
+			wantUndone: map[string]string{"p1": "r3", "r1": "r2"},
+		},
+		{
+			name:       "撤销对回滚的撤销",
+			history:    []*novel.PromotionRecord{rollback("r3", "r2"), rollback("r2", "r1"), rollback("r1", "p1"), promote("p1")},
+			wantLatest: "",
+			wantUndone: map[string]string{"p1": "r1", "r2": "r3"},
+		},
+		{
+			name:       "回滚后重新发布",
+			history:    []*novel.PromotionRecord{promote("p2"), rollback("r1", "p1"), promote("p1")},
+			wantLatest: "p2",
+			wantUndone: map[string]string{"p1": "r1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			undone := undoneBy(tt.history)
+			if len(undone) != len(tt.wantUndone) {
+				t.Errorf("undoneBy() = %v, want %v", undone, tt.wantUndone)
+			}
+			for recordID, by := range tt.wantUndone {
+				if undone[recordID] != by {
+					t.Errorf("undoneBy()[%s] = %q, want %q", recordID, undone[recordID], by)
+				}
+			}
+
+			latest := ""
+			if r := latestEffectivePromotion(tt.history); r != nil {
+				latest = r.ID
+			}
+			if latest != tt.wantLatest {
+				t.Errorf("latestEffectivePromotion() = %q, want %q", latest, tt.wantLatest)
+			}
+		})
+	}
+}
+
+func TestRollbackPromotionBatch(t *testing.T) {
+	ctx := context.Background()
+	req := &RollbackPromotionRequest{OperatedBy: "reviewer-1"}
+
+	tests := []struct {
+		name string
+		// setup 在 batch-1（两章发布为 v1）和 batch-2（两章发布为 v2）之后执行
+		setup   func(t *testing.T, f *promotionFixture)
+		wantErr error
+		want    map[string]int // 回滚 batch-2 后各章节发布的版本
+	}{
+		{
+			name:  "回滚批次恢复到上一批次",
+			setup: func(*testing.T, *promotionFixture) {},
+			want:  map[string]int{"ch-1": 1, "ch-2": 1},
+		},
+		{
+			name: "已单独回滚的章节跳过",
+			setup: func(t *testing.T, f *promotionFixture) {
+				if _, err := f.svc.RollbackChapterPromotion(ctx, "ch-1", req); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: map[string]int{"ch-1": 1, "ch-2": 1},
+		},
+		{
+			name: "批次中的章节之后重新发布时冲突",
+			setup: func(t *testing.T, f *promotionFixture) {
+				f.promote(t, "batch-3", 3, "ch-2")
+			},
+			wantErr: ErrPromotionConflict,
+			want:    map[string]int{"ch-1": 2, "ch-2": 3},
+		},
+		{
+			name: "单独回滚后重新发布的章节跳过",
+			setup: func(t *testing.T, f *promotionFixture) {
+				if _, err := f.svc.RollbackChapterPromotion(ctx, "ch-2", req); err != nil {
+					t.Fatal(err)
+				}
+				f.promote(t, "batch-3", 3, "ch-2")
+			},
+			want: map[string]int{"ch-1": 1, "ch-2": 3},
+		},
+		{
+			name: "批量回滚后重新发布时不能再次回滚",
+			setup: func(t *testing.T, f *promotionFixture) {
+				if _, err := f.svc.RollbackPromotionBatch(ctx, "novel-1", "batch-2", req); err != nil {
+					t.Fatal(err)
+				}
+				f.promote(t, "batch-3", 3, "ch-1")
+			},
+			wantErr: ErrNoPromotionToRollback,
+			want:    map[string]int{"ch-1": 3, "ch-2": 1},
+		},
+		{
+			name: "批次已全部回滚",
+			setup: func(t *testing.T, f *promotionFixture) {
+				if _, err := f.svc.RollbackPromotionBatch(ctx, "novel-1", "batch-2", req); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: ErrNoPromotionToRollback,
+			want:    map[string]int{"ch-1": 1, "ch-2": 1},
+		},
+		{
+			name: "写入中途失败时自动恢复已回滚的章节",
+			setup: func(t *testing.T, f *promotionFixture) {
+				f.chapters.failing["ch-2"] = true
+			},
+			wantErr: errPromotionWrite,
+			want:    map[string]int{"ch-1": 2, "ch-2": 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newPromotionFixture()
+			f.promote(t, "batch-1", 1, "ch-1", "ch-2")
+			f.promote(t, "batch-2", 2, "ch-1", "ch-2")
+			tt.setup(t, f)
+			before := len(f.records.records)
+
+			result, err := f.svc.RollbackPromotionBatch(ctx, "novel-1", "batch-2", req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RollbackPromotionBatch() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && len(result.Records) != len(f.records.records)-before {
+				t.Errorf("result records = %d, want %d appended", len(result.Records), len(f.records.records)-before)
+			}
+			if errors.Is(err, ErrPromotionConflict) && len(f.records.records) != before {
+				t.Errorf("conflict appended %d records", len(f.records.records)-before)
+			}
+			for chapterID, want := range tt.want {
+				if got := f.promoted(chapterID); got != want {
+					t.Errorf("%s promoted video = %d, want %d", chapterID, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRollbackChapterAfterBatchRollback(t *testing.T) {
+	ctx := context.Background()
+	req := &RollbackPromotionRequest{OperatedBy: "reviewer-1"}
+	f := newPromotionFixture()
+	f.promote(t, "batch-1", 1, "ch-1", "ch-2")
+	f.promote(t, "batch-2", 2, "ch-1", "ch-2")
+
+	if _, err := f.svc.RollbackPromotionBatch(ctx, "novel-1", "batch-2", req); err != nil {
+		t.Fatal(err)
+	}
+	// 批量回滚之后单独回滚章节，回滚的是 batch-1 的发布
+	result, err := f.svc.RollbackChapterPromotion(ctx, "ch-1", req)
+	if err != nil {
+		t.Fatalf("RollbackChapterPromotion() error = %v", err)
+	}
+	if got := result.Records[0].RollbackOf; got != "batch-1/ch-1" {
+		t.Errorf("rollback of = %s, want batch-1/ch-1", got)
+	}
+	if got := f.promoted("ch-1"); got != 0 {
+		t.Errorf("ch-1 promoted video = %d, want unpublished", got)
+	}
+	if _, err := f.svc.RollbackChapterPromotion(ctx, "ch-1", req); !errors.Is(err, ErrNoPromotionToRollback) {
+		t.Errorf("rollback again: err = %v, want ErrNoPromotionToRollback", err)
+	}
+
+	// batch-1 中只剩 ch-2 需要回滚
+	result, err = f.svc.RollbackPromotionBatch(ctx, "novel-1", "batch-1", req)
+	if err != nil {
+		t.Fatalf("RollbackPromotionBatch() error = %v", err)
+	}
+	if len(result.Records) != 1 || result.Records[0].ChapterID != "ch-2" || f.promoted("ch-2") != 0 {
+		t.Errorf("records = %d, ch-2 promoted video = %d, want only ch-2 rolled back to unpublished", len(result.Records), f.promoted("ch-2"))
+	}
+}
+
+func TestRollbackPromotionBatchRestoreCanBeRolledBackAgain(t *testing.T) {
+	ctx := context.Background()
+	req := &RollbackPromotionRequest{OperatedBy: "reviewer-1"}
+	f := newPromotionFixture()
+	f.promote(t, "batch-1", 1, "ch-1", "ch-2")
+	f.promote(t, "batch-2", 2, "ch-1", "ch-2")
+
+	// ch-2 写入失败，ch-1 的回滚被自动恢复（回滚对回滚的撤销）
+	f.chapters.failing["ch-2"] = true
+	if _, err := f.svc.RollbackPromotionBatch(ctx, "novel-1", "batch-2", req); err == nil {
+		t.Fatal("expected batch rollback to fail")
+	}
+	history, _ := f.records.FindByChapterID(ctx, "ch-1")
+	if latest := latestEffectivePromotion(history); latest == nil || latest.ID != "batch-2/ch-1" {
+		t.Fatalf("latest effective promotion = %v, want batch-2/ch-1 effective again", latest)
+	}
+
+	// 写入恢复后可以再次回滚整个批次
+	f.chapters.failing["ch-2"] = false
+	result, err := f.svc.RollbackPromotionBatch(ctx, "novel-1", "batch-2", req)
+	if err != nil {
+		t.Fatalf("RollbackPromotionBatch() error = %v", err)
+	}
+	if len(result.Records) != 2 || f.promoted("ch-1") != 1 || f.promoted("ch-2") != 1 {
+		t.Errorf("records = %d, promoted = %d/%d, want both chapters back to v1", len(result.Records), f.promoted("ch-1"), f.promoted("ch-2"))
+	}
+}
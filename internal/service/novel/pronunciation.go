@@ -0,0 +1,240 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/killswitch"
+	"lemon/internal/pkg/noveltools"
+)
+
+// 发音纠正方式
+const (
+	PronunciationMethodPhoneme      = "phoneme"      // TTS 按拼音标注朗读
+	PronunciationMethodSubstitution = "substitution" // 合成前用同音替换文本替换原词
+)
+
+// ErrInvalidPronunciationTest 试听文本为空且小说发音词典为空
+var ErrInvalidPronunciationTest = errors.New("invalid pronunciation test")
+
+// PronunciationService 小说发音词典服务接口
+type PronunciationService interface {
+	// UpsertPronunciation 添加或更新小说发音词典中的词（同一个词只保留一条），之后生成的音频生效
+	UpsertPronunciation(ctx context.Context, req *UpsertPronunciationRequest) (*novel.PronunciationEntry, error)
+
+	// ListPronunciations 查询小说发音词典
+	ListPronunciations(ctx context.Context, novelID string) ([]*novel.PronunciationEntry, error)
+
+	// DeletePronunciation 删除发音词典中的词
+	DeletePronunciation(ctx context.Context, entryID string) error
+
+	// TestPronunciation 按小说的配音设置和发音词典合成一段试听音频（不保存），生成前检查小说预算
+	TestPronunciation(ctx context.Context, req *TestPronunciationRequest) (*PronunciationTest, error)
+}
+
+// UpsertPronunciationRequest 添加或更新发音词典条目请求
+type UpsertPronunciationRequest struct {
+	NovelID     string // 小说ID
+	Term        string // 词
+	Pinyin      string // 带声调数字的拼音（可选）
+	Replacement string // 同音替换文本（可选，拼音和替换文本至少填一项）
+	Note        string // 备注
+}
+
+// TestPronunciationRequest 试听发音请求
+type TestPronunciationRequest struct {
+	NovelID string // 小说ID
+	Text    string // 试听文本，为空时朗读词典中的所有词
+}
+
+// PronunciationTest 试听结果
+type PronunciationTest struct {
+	Text        string                         // 试听文本
+	SpokenText  string                         // 提交给 TTS 的文本（同音替换后）
+	SSML        string                         // 提交给 TTS 的拼音标注（TTS 支持发音标注且命中词典时才有）
+	Matched     []noveltools.PronunciationHint // 命中的词典词
+	Methods     map[string]string              // 每个命中词的纠正方式：phoneme / substitution
+	AudioData   []byte                         // 音频数据
+	ContentType string                         // 音频类型
+	Duration    float64                        // 音频时长（秒）
+}
+
+// UpsertPronunciation 添加或更新发音词典条目
+func (s *novelService) UpsertPronunciation(ctx context.Context, req *UpsertPronunciationRequest) (*novel.PronunciationEntry, error) {
+	hint := noveltools.PronunciationHint{
+		Term:        strings.TrimSpace(req.Term),
+		Pinyin:      noveltools.NormalizePinyin(req.Pinyin),
+		Replacement: strings.TrimSpace(req.Replacement),
+	}
+	if err := noveltools.ValidatePronunciationHint(hint); err != nil {
+		return nil, err
+	}
+	if _, err := s.novelRepo.FindByID(ctx, req.NovelID); err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+
+	existing, err := s.pronunciationRepo.FindByTerm(ctx, req.NovelID, hint.Term)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("find pronunciation: %w", err)
+	}
+	if existing != nil {
+		if err := s.pronunciationRepo.Update(ctx, existing.ID, map[string]interface{}{
+			"pinyin":      hint.Pinyin,
+			"replacement": hint.Replacement,
+			"note":        req.Note,
+		}); err != nil {
+			return nil, fmt.Errorf("update pronunciation: %w", err)
+		}
+		return s.pronunciationRepo.FindByID(ctx, existing.ID)
+	}
+
+	entry := &novel.PronunciationEntry{
+		ID:          id.New(),
+		NovelID:     req.NovelID,
+		Term:        hint.Term,
+		Pinyin:      hint.Pinyin,
+		Replacement: hint.Replacement,
+		Note:        req.Note,
+	}
+	if err := s.pronunciationRepo.Create(ctx, entry); err != nil {
+		return nil, fmt.Errorf("create pronunciation: %w", err)
+	}
+
+	log.Info().
+		Str("novel_id", req.NovelID).
+		Str("term", entry.Term).
+		Msg("发音词典条目已添加")
+
+	return entry, nil
+}
+
+// ListPronunciations 查询小说发音词典
+func (s *novelService) ListPronunciations(ctx context.Context, novelID string) ([]*novel.PronunciationEntry, error) {
+	return s.pronunciationRepo.FindByNovelID(ctx, novelID)
+}
+
+// DeletePronunciation 删除发音词典条目
+func (s *novelService) DeletePronunciation(ctx context.Context, entryID string) error {
+	if _, err := s.pronunciationRepo.FindByID(ctx, entryID); err != nil {
+		return fmt.Errorf("find pronunciation: %w", err)
+	}
+	return s.pronunciationRepo.Delete(ctx, entryID)
+}
+
+// TestPronunciation 合成试听音频
+func (s *novelService) TestPronunciation(ctx context.Context, req *TestPronunciationRequest) (*PronunciationTest, error) {
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderTTS)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if _, err := s.novelRepo.FindByID(ctx, req.NovelID); err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+	hints := s.novelPronunciationHints(ctx, req.NovelID)
+
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		terms := make([]string, 0, len(hints))
+		for _, hint := range hints {
+			terms = append(terms, hint.Term)
+		}
+		text = strings.Join(terms, "，")
+	}
+	if text == "" {
+		return nil, fmt.Errorf("%w: text is required when the dictionary is empty", ErrInvalidPronunciationTest)
+	}
+
+	if err := s.checkBudget(ctx, req.NovelID); err != nil {
+		return nil, err
+	}
+	voice := s.novelVoice(ctx, req.NovelID)
+	result, err := s.generateVoice(ctx, text, voice, hints)
+	if err != nil {
+		return nil, fmt.Errorf("TTS generation failed: %w", err)
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("TTS generation failed: %s", result.ErrorMessage)
+	}
+	chars := utf8.RuneCountInString(text)
+	s.recordCost(ctx, req.NovelID, "", killswitch.ProviderTTS, float64(chars), s.pricing.TTS(chars))
+
+	plan := s.planPronunciation(text, hints)
+	test := &PronunciationTest{
+		Text:        text,
+		SpokenText:  plan.spoken,
+		SSML:        plan.ssml,
+		Matched:     noveltools.MatchedPronunciations(text, hints),
+		Methods:     plan.methods,
+		AudioData:   result.AudioData,
+		ContentType: "audio/mpeg",
+		Duration:    result.Duration,
+	}
+	return test, nil
+}
+
+// novelPronunciationHints 查询小说发音词典，查询失败时不纠正读音
+func (s *novelService) novelPronunciationHints(ctx context.Context, novelID string) []noveltools.PronunciationHint {
+	entries, err := s.pronunciationRepo.FindByNovelID(ctx, novelID)
+	if err != nil {
+		log.Warn().Err(err).Str("novel_id", novelID).Msg("查询发音词典失败，按原文合成")
+		return nil
+	}
+	hints := make([]noveltools.PronunciationHint, 0, len(entries))
+	for _, entry := range entries {
+		hints = append(hints, noveltools.PronunciationHint{
+			Term:        entry.Term,
+			Pinyin:      entry.Pinyin,
+			Replacement: entry.Replacement,
+		})
+	}
+	return hints
+}
+
+// pronunciationPlan 一段文本的发音纠正方案
+type pronunciationPlan struct {
+	spoken   string                                 // 同音替换后提交给 TTS 的文本
+	subs     []noveltools.PronunciationSubstitution // 同音替换记录（用于还原时间戳）
+	phonemes []noveltools.PronunciationHint         // 交给 TTS 按拼音朗读的词
+	ssml     string                                 // 拼音标注后的 SSML（没有命中时为空）
+	methods  map[string]string                      // 命中词 → 纠正方式
+}
+
+// planPronunciation 确定文本的发音纠正方案
+// TTS 提供者支持发音标注时填写了拼音的词按拼音朗读，其余词（以及提供者不支持发音标注时的所有词）用同音替换文本
+func (s *novelService) planPronunciation(text string, hints []noveltools.PronunciationHint) pronunciationPlan {
+	substitute := hints
+	var phonemes []noveltools.PronunciationHint
+	if _, ok := s.ttsProvider.(noveltools.PronunciationTTSProvider); ok {
+		substitute = nil
+		for _, hint := range hints {
+			if hint.Pinyin != "" {
+				phonemes = append(phonemes, hint)
+			} else {
+				substitute = append(substitute, hint)
+			}
+		}
+	}
+
+	plan := pronunciationPlan{phonemes: phonemes, methods: make(map[string]string)}
+	plan.spoken, plan.subs = noveltools.SubstitutePronunciations(text, substitute)
+	for _, sub := range plan.subs {
+		plan.methods[sub.Term] = PronunciationMethodSubstitution
+	}
+	if ssml, ok := noveltools.BuildPronunciationSSML(plan.spoken, phonemes); ok {
+		plan.ssml = ssml
+		for _, hint := range noveltools.MatchedPronunciations(plan.spoken, phonemes) {
+			plan.methods[hint.Term] = PronunciationMethodPhoneme
+		}
+	}
+	return plan
+}
@@ -0,0 +1,31 @@
+package novel
+
+import (
+	"lemon/internal/pkg/noveltools"
+	"lemon/internal/pkg/noveltools/providers"
+	"lemon/internal/pkg/respcache"
+)
+
+// WithProviderCache 启用 provider 响应缓存：相同提示词的 LLM 请求直接复用缓存的输出（不再计费）
+// 用户主动重新生成（重新生成分镜头脚本、按审核意见重写解说）时跳过缓存读取
+func WithProviderCache(c *respcache.Cache) Option {
+	return func(s *novelService) {
+		s.providerCache = c
+	}
+}
+
+// wrapProviderCache 为 LLM 提供者加上响应缓存（未启用缓存时不处理）
+func (s *novelService) wrapProviderCache() {
+	if s.providerCache == nil {
+		return
+	}
+	s.llmProvider = providers.NewCachedLLMProvider(s.llmProvider, s.providerCache)
+}
+
+// llmServedFromCache 判断本次提示词的输出是否来自缓存（领取一次命中回执，见 respcache.Cache.ConsumeHit）
+func (s *novelService) llmServedFromCache(prompt string) bool {
+	if s.providerCache == nil {
+		return false
+	}
+	return s.providerCache.ConsumeHit(providers.LLMCacheKey(noveltools.DescribeProvider(s.llmProvider), prompt))
+}
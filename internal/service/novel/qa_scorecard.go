@@ -0,0 +1,220 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/noveltools"
+)
+
+// QAScorecardService 章节 QA 评分卡服务接口
+type QAScorecardService interface {
+	// GetChapterQAScorecard 汇总解说校验、字幕同步、视频质检、图片质检和语音识别校验，计算章节的 QA 评分卡
+	// versions 中为 0 的产物取最新版本
+	GetChapterQAScorecard(ctx context.Context, chapterID string, versions VersionSelection) (*novel.QAScorecard, error)
+}
+
+// qaMinScoreFromEnv 读取允许发布的最低 QA 分数（QA_MIN_PROMOTION_SCORE），未配置或非法时使用默认值
+func qaMinScoreFromEnv() float64 {
+	if v, err := strconv.ParseFloat(os.Getenv("QA_MIN_PROMOTION_SCORE"), 64); err == nil && v >= 0 && v <= 100 {
+		return v
+	}
+	return noveltools.DefaultQAMinScore
+}
+
+// GetChapterQAScorecard 计算章节的 QA 评分卡
+func (s *novelService) GetChapterQAScorecard(ctx context.Context, chapterID string, versions VersionSelection) (*novel.QAScorecard, error) {
+	chapter, err := s.chapterRepo.FindByID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+	return s.chapterQAScorecard(ctx, chapter, versions)
+}
+
+// chapterQAScorecard 计算章节指定版本的 QA 评分卡
+// 产物缺失时记为对应信号的阻断问题，只有查询失败才返回错误
+func (s *novelService) chapterQAScorecard(ctx context.Context, ch *novel.Chapter, versions VersionSelection) (*novel.QAScorecard, error) {
+	var narration *novel.Narration
+	var err error
+	if versions.Narration > 0 {
+		narration, err = s.narrationRepo.FindByChapterIDAndVersion(ctx, ch.ID, versions.Narration)
+	} else {
+		narration, err = s.narrationRepo.FindByChapterID(ctx, ch.ID)
+	}
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("find narration: %w", err)
+	}
+
+	var (
+		signals   []novel.QASignalResult
+		shots     []*novel.Shot
+		audios    []*novel.Audio
+		subtitles []*novel.Subtitle
+		images    []*novel.Image
+		used      = versions
+	)
+	if narration == nil {
+		missing := []novel.QAFinding{{Severity: novel.QASeverityBlocking, Message: "没有解说"}}
+		signals = append(signals,
+			noveltools.ScoreQASignal(novel.QASignalNarration, missing),
+			noveltools.ScoreQASignal(novel.QASignalSubtitleSync, missing),
+			noveltools.ScoreQASignal(novel.QASignalImage, missing),
+		)
+	} else {
+		used.Narration = narration.Version
+
+		narrationFindings, err := s.narrationQAFindings(ctx, narration)
+		if err != nil {
+			return nil, err
+		}
+		signals = append(signals, noveltools.ScoreQASignal(novel.QASignalNarration, narrationFindings))
+
+		if shots, err = s.shotRepo.FindByNarrationID(ctx, narration.ID); err != nil {
+			return nil, fmt.Errorf("find shots: %w", err)
+		}
+
+		if used.Audio == 0 {
+			audioVersions, err := s.audioRepo.FindVersionsByNarrationID(ctx, narration.ID)
+			if err != nil {
+				return nil, fmt.Errorf("find audio versions: %w", err)
+			}
+			if len(audioVersions) > 0 {
+				used.Audio = slices.Max(audioVersions)
+			}
+		}
+		if used.Audio > 0 {
+			if audios, err = s.audioRepo.FindByNarrationIDAndVersion(ctx, narration.ID, used.Audio); err != nil {
+				return nil, fmt.Errorf("find audios: %w", err)
+			}
+		}
+		if subtitles, err = s.subtitleRepo.FindByNarrationID(ctx, narration.ID); err != nil {
+			return nil, fmt.Errorf("find subtitles: %w", err)
+		}
+		signals = append(signals, noveltools.ScoreQASignal(novel.QASignalSubtitleSync, noveltools.SubtitleSyncQAFindings(audios, subtitles)))
+
+		if used.Image == 0 {
+			all, err := s.imageRepo.FindByNarrationID(ctx, narration.ID)
+			if err != nil {
+				return nil, fmt.Errorf("find images: %w", err)
+			}
+			for _, img := range all {
+				used.Image = max(used.Image, img.Version)
+			}
+		}
+		if used.Image > 0 {
+			if images, err = s.imageRepo.FindByNarrationIDAndVersion(ctx, narration.ID, used.Image); err != nil {
+				return nil, fmt.Errorf("find images: %w", err)
+			}
+		}
+		imageSignal := noveltools.ScoreQASignal(novel.QASignalImage, noveltools.ImageQAFindings(shots, images))
+		imageSignal.Note = "只检查图片是否生成完成，尚未记录图片质量评分"
+		signals = append(signals, imageSignal)
+	}
+
+	videoFindings, videoVersion, err := s.videoQAFindings(ctx, ch.ID, used.Video, narration, audios, shots)
+	if err != nil {
+		return nil, err
+	}
+	used.Video = videoVersion
+	signals = append(signals, noveltools.ScoreQASignal(novel.QASignalVideo, videoFindings))
+
+	signals = append(signals, noveltools.UnavailableQASignal(novel.QASignalASR, "尚未接入语音识别校验，不计入评分"))
+
+	card := noveltools.BuildQAScorecard(signals, s.qaMinScore)
+	card.ChapterID = ch.ID
+	card.NovelID = ch.NovelID
+	card.NarrationVersion = used.Narration
+	card.ImageVersion = used.Image
+	card.AudioVersion = used.Audio
+	card.VideoVersion = used.Video
+	return card, nil
+}
+
+// narrationQAFindings 从场景和镜头还原解说文案并重新校验
+func (s *novelService) narrationQAFindings(ctx context.Context, narration *novel.Narration) ([]novel.QAFinding, error) {
+	if narration.Status != novel.TaskStatusCompleted {
+		return []novel.QAFinding{{
+			Severity: novel.QASeverityBlocking,
+			Message:  fmt.Sprintf("解说状态为 %s", narration.Status),
+			Ref:      narration.ID,
+		}}, nil
+	}
+
+	scenes, err := s.sceneRepo.FindByNarrationID(ctx, narration.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find scenes: %w", err)
+	}
+	content := &noveltools.NarrationJSONContent{}
+	for _, scene := range scenes {
+		shots, err := s.shotRepo.FindBySceneID(ctx, scene.ID)
+		if err != nil {
+			return nil, fmt.Errorf("find shots for scene %s: %w", scene.ID, err)
+		}
+		jsonScene := &noveltools.NarrationJSONScene{
+			SceneNumber: scene.SceneNumber,
+			Description: scene.Description,
+			ImagePrompt: scene.ImagePrompt,
+			Narration:   scene.Narration,
+			Mood:        scene.Mood.String(),
+		}
+		for _, shot := range shots {
+			jsonScene.Shots = append(jsonScene.Shots, &noveltools.NarrationJSONShot{
+				CloseupNumber:  shot.ShotNumber,
+				Character:      shot.Character,
+				Image:          shot.Image,
+				Narration:      shot.Narration,
+				SoundEffect:    shot.SoundEffect,
+				Duration:       shot.Duration,
+				ImagePrompt:    shot.ImagePrompt,
+				VideoPrompt:    shot.VideoPrompt,
+				CameraMovement: shot.CameraMovement,
+			})
+		}
+		content.Scenes = append(content.Scenes, jsonScene)
+	}
+
+	result := s.validateNarrationContent(ctx, narration.NovelID, content)
+	return noveltools.NarrationQAFindings(result), nil
+}
+
+// videoQAFindings 检查指定版本（为 0 时取最新版本）的解说视频片段和最终视频，返回实际检查的版本
+// 只检查属于该解说的片段；版本中有多个最终视频时取最新的
+func (s *novelService) videoQAFindings(ctx context.Context, chapterID string, version int, narration *novel.Narration, audios []*novel.Audio, shots []*novel.Shot) ([]novel.QAFinding, int, error) {
+	if version == 0 {
+		videoVersions, err := s.videoRepo.FindVersionsByChapterID(ctx, chapterID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("find video versions: %w", err)
+		}
+		if len(videoVersions) == 0 {
+			return []novel.QAFinding{{Severity: novel.QASeverityBlocking, Message: "没有视频"}}, 0, nil
+		}
+		version = slices.Max(videoVersions)
+	}
+
+	videos, err := s.videoRepo.FindByChapterIDAndVersion(ctx, chapterID, version)
+	if err != nil {
+		return nil, 0, fmt.Errorf("find videos for version %d: %w", version, err)
+	}
+	var final *novel.Video
+	var clips []*novel.Video
+	for _, video := range videos {
+		switch video.VideoType {
+		case novel.VideoTypeFinal:
+			if final == nil || video.CreatedAt.After(final.CreatedAt) {
+				final = video
+			}
+		case novel.VideoTypeNarration:
+			if narration != nil && video.NarrationID == narration.ID {
+				clips = append(clips, video)
+			}
+		}
+	}
+	return noveltools.VideoQAFindings(final, clips, audios, shots), version, nil
+}
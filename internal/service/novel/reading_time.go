@@ -0,0 +1,254 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/pkg/ffmpeg"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/noveltools"
+	"lemon/internal/pkg/presign"
+	"lemon/internal/pkg/procreaper"
+	"lemon/internal/service"
+)
+
+// artifactStoryboardPreview 无声分镜预览视频（生成配音前检查节奏）
+const artifactStoryboardPreview = "storyboard_preview"
+
+// ErrInvalidStoryboardPreview 无法生成无声分镜预览（没有可朗读的镜头或镜头缺少图片）
+var ErrInvalidStoryboardPreview = errors.New("invalid storyboard preview")
+
+// ReadingTimeService 朗读时长估算服务接口（不调用 TTS）
+type ReadingTimeService interface {
+	// EstimateChapterReadingTime 按小说的配音语速估算章节当前解说每个镜头的朗读时长，并标出过短或过长的镜头
+	EstimateChapterReadingTime(ctx context.Context, chapterID string) (*ChapterReadingTime, error)
+
+	// RenderStoryboardPreview 用镜头图片和按估算时长排列的字幕生成无声分镜预览视频，在生成配音前检查节奏
+	RenderStoryboardPreview(ctx context.Context, chapterID string) (*StoryboardPreview, error)
+}
+
+// ShotReadingTime 单个镜头的朗读时长估算
+type ShotReadingTime struct {
+	ShotID      string  `json:"shot_id"`           // 镜头ID
+	SceneNumber string  `json:"scene_number"`      // 场景编号
+	ShotNumber  string  `json:"shot_number"`       // 镜头编号
+	Sequence    int     `json:"sequence"`          // 对应的音频序号（与生成音频时一致）
+	Text        string  `json:"text"`              // 朗读文本（已统一数字写法并清理）
+	Characters  int     `json:"characters"`        // 朗读字数
+	Pauses      float64 `json:"pauses"`            // 标点停顿时长（秒）
+	Duration    float64 `json:"duration"`          // 估算朗读时长（秒）
+	Start       float64 `json:"start"`             // 在章节中的开始时间（秒）
+	Warning     string  `json:"warning,omitempty"` // 节奏提示（过短或过长）
+}
+
+// ChapterReadingTime 章节解说的朗读时长估算
+type ChapterReadingTime struct {
+	ChapterID      string            `json:"chapter_id"`
+	NarrationID    string            `json:"narration_id"`
+	SpeedRatio     float64           `json:"speed_ratio"`      // 估算使用的配音语速
+	CharsPerSecond float64           `json:"chars_per_second"` // 该语速下每秒朗读字数
+	TotalDuration  float64           `json:"total_duration"`   // 估算总时长（秒）
+	Shots          []ShotReadingTime `json:"shots"`            // 按音频序号排列的镜头估算
+	Warnings       []string          `json:"warnings"`         // 节奏提示汇总
+}
+
+// StoryboardPreview 无声分镜预览视频
+type StoryboardPreview struct {
+	ChapterID   string                        `json:"chapter_id"`
+	NarrationID string                        `json:"narration_id"`
+	ResourceID  string                        `json:"resource_id"` // 预览视频的 resource_id
+	Duration    float64                       `json:"duration"`    // 预览时长（秒，即估算总时长）
+	Estimate    *ChapterReadingTime           `json:"estimate"`    // 生成预览使用的时长估算
+	Download    *service.GetDownloadURLResult `json:"download"`    // 预览视频的临时下载链接
+}
+
+// EstimateChapterReadingTime 估算章节当前解说每个镜头的朗读时长
+// 朗读文本与生成音频时相同（按小说设置统一数字写法后清理），语速使用小说的配音设置
+func (s *novelService) EstimateChapterReadingTime(ctx context.Context, chapterID string) (*ChapterReadingTime, error) {
+	narration, err := s.narrationRepo.FindByChapterID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find narration: %w", err)
+	}
+	shots, err := s.shotRepo.FindByNarrationID(ctx, narration.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find shots: %w", err)
+	}
+
+	voice := s.novelVoice(ctx, narration.NovelID)
+	numberStyle := s.novelNumberStyle(ctx, narration.NovelID)
+	textCleaner := noveltools.NewTextCleaner()
+	estimate := &ChapterReadingTime{
+		ChapterID:      chapterID,
+		NarrationID:    narration.ID,
+		SpeedRatio:     voice.SpeedRatio,
+		CharsPerSecond: math.Round(noveltools.ReadingCharsPerSecond*voice.SpeedRatio*100) / 100,
+		Shots:          []ShotReadingTime{},
+		Warnings:       []string{},
+	}
+
+	// 序号规则与 generateAudiosForNarration 一致：只给有解说文本的镜头编号
+	sequence := 0
+	start := 0.0
+	for _, shot := range shots {
+		if shot.Narration == "" {
+			continue
+		}
+		sequence++
+		text := textCleaner.CleanTextForTTS(noveltools.NormalizeNumbers(shot.Narration, numberStyle))
+		if text == "" {
+			continue
+		}
+
+		reading := noveltools.EstimateReadingTime(text, voice.SpeedRatio)
+		item := ShotReadingTime{
+			ShotID:      shot.ID,
+			SceneNumber: shot.SceneNumber,
+			ShotNumber:  shot.ShotNumber,
+			Sequence:    sequence,
+			Text:        text,
+			Characters:  reading.Characters,
+			Pauses:      reading.Pauses,
+			Duration:    reading.Duration,
+			Start:       math.Round(start*100) / 100,
+		}
+		switch {
+		case reading.Duration < noveltools.ReadingShotMinDuration:
+			item.Warning = fmt.Sprintf("镜头%s-%s预计朗读%.1f秒，画面停留过短，建议与相邻镜头合并", shot.SceneNumber, shot.ShotNumber, reading.Duration)
+		case reading.Duration > noveltools.ReadingShotMaxDuration:
+			item.Warning = fmt.Sprintf("镜头%s-%s预计朗读%.1f秒，超过%.0f秒将无法使用图生视频，建议拆分或精简旁白",
+				shot.SceneNumber, shot.ShotNumber, reading.Duration, noveltools.ReadingShotMaxDuration)
+		}
+		if item.Warning != "" {
+			estimate.Warnings = append(estimate.Warnings, item.Warning)
+		}
+		estimate.Shots = append(estimate.Shots, item)
+		start += reading.Duration
+	}
+	estimate.TotalDuration = math.Round(start*100) / 100
+
+	return estimate, nil
+}
+
+// RenderStoryboardPreview 生成无声分镜预览视频
+// 每个镜头的图片按估算朗读时长显示（图片缩放效果），字幕按估算时间轴烧录，使用小说的字幕样式和目标平台安全区；
+// 不调用 TTS 和图生视频接口，预览不影响章节的任何版本
+func (s *novelService) RenderStoryboardPreview(ctx context.Context, chapterID string) (*StoryboardPreview, error) {
+	estimate, err := s.EstimateChapterReadingTime(ctx, chapterID)
+	if err != nil {
+		return nil, err
+	}
+	if len(estimate.Shots) == 0 {
+		return nil, fmt.Errorf("%w: no narration text in chapter", ErrInvalidStoryboardPreview)
+	}
+	narration, err := s.narrationRepo.FindByID(ctx, estimate.NarrationID)
+	if err != nil {
+		return nil, fmt.Errorf("find narration: %w", err)
+	}
+
+	render := s.novelRenderSettings(ctx, narration.NovelID)
+	gen := s.generationSettings(ctx, narration.NovelID)
+	area := noveltools.SafeAreaForPlatform(s.novelTargetPlatform(ctx, narration.NovelID))
+	ffmpegClient := ffmpeg.NewClient()
+
+	tmpDir, cleanupTmpDir, err := procreaper.MkdirTemp(ctx, "lemon-storyboard-*")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir: %w", err)
+	}
+	defer cleanupTmpDir()
+
+	var clipPaths []string
+	for _, item := range estimate.Shots {
+		if item.Duration <= 0 {
+			continue
+		}
+		shot, err := s.shotRepo.FindByID(ctx, item.ShotID)
+		if err != nil {
+			return nil, fmt.Errorf("find shot %s: %w", item.ShotID, err)
+		}
+		image, err := s.findShotImage(ctx, chapterID, shot, shot.SceneNumber, shot.ShotNumber)
+		if err != nil {
+			return nil, fmt.Errorf("%w: shot %s-%s has no image: %v", ErrInvalidStoryboardPreview, shot.SceneNumber, shot.ShotNumber, err)
+		}
+
+		imagePath := filepath.Join(tmpDir, fmt.Sprintf("image_%03d.jpg", item.Sequence))
+		if err := s.downloadResourceToFile(ctx, image.ImageResourceID, imagePath); err != nil {
+			return nil, fmt.Errorf("download image for sequence %d: %w", item.Sequence, err)
+		}
+
+		assGenerator := noveltools.NewASSGeneratorWithStyle(render.Subtitle)
+		timeline := noveltools.EstimateSubtitleTimeline(item.Text, estimate.SpeedRatio, 0)
+		assContent := assGenerator.GenerateASSContent(timeline, fmt.Sprintf("Storyboard Preview %d", item.Sequence))
+		if !area.IsZero() {
+			assContent = noveltools.ApplySafeAreaToASS(assContent, area)
+		}
+		subtitlePath := filepath.Join(tmpDir, fmt.Sprintf("subtitle_%03d.ass", item.Sequence))
+		if err := os.WriteFile(subtitlePath, []byte(assContent), 0644); err != nil {
+			return nil, fmt.Errorf("write subtitle for sequence %d: %w", item.Sequence, err)
+		}
+
+		clipPath := filepath.Join(tmpDir, fmt.Sprintf("clip_%03d.mp4", item.Sequence))
+		if err := ffmpegClient.AssembleShot(ctx, ffmpeg.ShotAssembly{
+			ImagePath:    imagePath,
+			SubtitlePath: subtitlePath,
+			Duration:     item.Duration,
+			Width:        gen.VideoWidth,
+			Height:       gen.VideoHeight,
+			FPS:          gen.VideoFPS,
+		}, clipPath); err != nil {
+			return nil, fmt.Errorf("assemble storyboard clip %d: %w", item.Sequence, err)
+		}
+		clipPaths = append(clipPaths, clipPath)
+	}
+
+	previewPath := filepath.Join(tmpDir, "storyboard_preview.mp4")
+	if err := ffmpegClient.ConcatVideos(ctx, clipPaths, previewPath); err != nil {
+		return nil, fmt.Errorf("concat storyboard clips: %w", err)
+	}
+
+	previewFile, err := os.Open(previewPath)
+	if err != nil {
+		return nil, fmt.Errorf("open storyboard preview: %w", err)
+	}
+	defer previewFile.Close()
+
+	uploadResult, err := s.resourceService.UploadLargeFile(ctx, &service.UploadFileRequest{
+		UserID:      narration.UserID,
+		FileName:    fmt.Sprintf("%s_storyboard_preview_%s.mp4", chapterID, id.New()),
+		ContentType: "video/mp4",
+		Ext:         "mp4",
+		Data:        previewFile,
+		KeyVars:     s.chapterKeyVarsByID(ctx, narration.NovelID, chapterID, artifactStoryboardPreview, 0, 1),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("upload storyboard preview: %w", err)
+	}
+	download, err := s.resourceService.GetDownloadURL(ctx, &service.GetDownloadURLRequest{
+		ResourceID: uploadResult.ResourceID,
+		Purpose:    presign.PurposePreview,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get storyboard preview download url: %w", err)
+	}
+
+	log.Info().
+		Str("chapter_id", chapterID).
+		Str("narration_id", narration.ID).
+		Int("shots", len(clipPaths)).
+		Float64("duration", estimate.TotalDuration).
+		Msg("无声分镜预览生成成功")
+
+	return &StoryboardPreview{
+		ChapterID:   chapterID,
+		NarrationID: narration.ID,
+		ResourceID:  uploadResult.ResourceID,
+		Duration:    estimate.TotalDuration,
+		Estimate:    estimate,
+		Download:    download,
+	}, nil
+}
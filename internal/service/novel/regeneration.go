@@ -0,0 +1,229 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/auth"
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/budget"
+	"lemon/internal/pkg/ctxutil"
+	"lemon/internal/pkg/id"
+)
+
+// ErrRegenerationLimitExceeded 产物的重新生成次数已超过上限，需要管理员/审核员填写原因后越权
+var ErrRegenerationLimitExceeded = errors.New("regeneration limit exceeded")
+
+// regenerationOverrideRoles 允许越过重新生成次数上限的角色
+var regenerationOverrideRoles = []auth.UserRole{auth.RoleAdmin, auth.RoleReviewer}
+
+// RegenerationService 重新生成次数限制服务接口
+type RegenerationService interface {
+	// GetRegenerationStatus 查询场景或镜头的重新生成次数和限制状态
+	GetRegenerationStatus(ctx context.Context, artifact novel.RegenerationArtifact, artifactID string) (*RegenerationStatus, error)
+
+	// ListNarrationRegenerations 查询解说下所有场景和镜头的重新生成次数（只返回重新生成过的产物，场景在前）
+	ListNarrationRegenerations(ctx context.Context, narrationID string) ([]*RegenerationStatus, error)
+
+	// ListRegenerationOverrides 查询小说的越权审计记录（按时间倒序）
+	ListRegenerationOverrides(ctx context.Context, novelID string, limit int) ([]*novel.RegenerationOverride, error)
+}
+
+// RegenerationStatus 产物的重新生成状态
+type RegenerationStatus struct {
+	ArtifactType novel.RegenerationArtifact `json:"artifact_type"` // 产物类型：scene, shot
+	ArtifactID   string                     `json:"artifact_id"`   // 场景ID或镜头ID
+	Count        int                        `json:"count"`         // 已重新生成次数
+	WarnAt       int                        `json:"warn_at"`       // 预警次数，0 表示不预警
+	Limit        int                        `json:"limit"`         // 上限次数，0 表示不限制
+	Level        budget.Level               `json:"level"`         // 状态：ok, warning, exceeded（再次重新生成需要越权）
+}
+
+// regenerationTarget 一次重新生成的产物
+type regenerationTarget struct {
+	artifact  novel.RegenerationArtifact
+	id        string
+	novelID   string
+	chapterID string
+	count     int // 重新生成前的次数
+}
+
+func sceneRegenerationTarget(scene *novel.Scene) regenerationTarget {
+	return regenerationTarget{
+		artifact:  novel.RegenerationArtifactScene,
+		id:        scene.ID,
+		novelID:   scene.NovelID,
+		chapterID: scene.ChapterID,
+		count:     scene.RegenerationCount,
+	}
+}
+
+func shotRegenerationTarget(shot *novel.Shot) regenerationTarget {
+	return regenerationTarget{
+		artifact:  novel.RegenerationArtifactShot,
+		id:        shot.ID,
+		novelID:   shot.NovelID,
+		chapterID: shot.ChapterID,
+		count:     shot.RegenerationCount,
+	}
+}
+
+// GetRegenerationStatus 查询场景或镜头的重新生成状态
+func (s *novelService) GetRegenerationStatus(ctx context.Context, artifact novel.RegenerationArtifact, artifactID string) (*RegenerationStatus, error) {
+	var count int
+	switch artifact {
+	case novel.RegenerationArtifactScene:
+		scene, err := s.sceneRepo.FindByID(ctx, artifactID)
+		if err != nil {
+			return nil, fmt.Errorf("find scene: %w", err)
+		}
+		count = scene.RegenerationCount
+	case novel.RegenerationArtifactShot:
+		shot, err := s.shotRepo.FindByID(ctx, artifactID)
+		if err != nil {
+			return nil, fmt.Errorf("find shot: %w", err)
+		}
+		count = shot.RegenerationCount
+	default:
+		return nil, fmt.Errorf("unknown regeneration artifact: %s", artifact)
+	}
+	return s.regenerationStatus(artifact, artifactID, count), nil
+}
+
+// ListNarrationRegenerations 查询解说下重新生成过的场景和镜头
+func (s *novelService) ListNarrationRegenerations(ctx context.Context, narrationID string) ([]*RegenerationStatus, error) {
+	scenes, err := s.sceneRepo.FindByNarrationID(ctx, narrationID)
+	if err != nil {
+		return nil, fmt.Errorf("find scenes: %w", err)
+	}
+	shots, err := s.shotRepo.FindByNarrationID(ctx, narrationID)
+	if err != nil {
+		return nil, fmt.Errorf("find shots: %w", err)
+	}
+
+	statuses := make([]*RegenerationStatus, 0)
+	for _, scene := range scenes {
+		if scene.RegenerationCount > 0 {
+			statuses = append(statuses, s.regenerationStatus(novel.RegenerationArtifactScene, scene.ID, scene.RegenerationCount))
+		}
+	}
+	for _, shot := range shots {
+		if shot.RegenerationCount > 0 {
+			statuses = append(statuses, s.regenerationStatus(novel.RegenerationArtifactShot, shot.ID, shot.RegenerationCount))
+		}
+	}
+	return statuses, nil
+}
+
+// ListRegenerationOverrides 查询小说的越权审计记录
+func (s *novelService) ListRegenerationOverrides(ctx context.Context, novelID string, limit int) ([]*novel.RegenerationOverride, error) {
+	if _, err := s.novelRepo.FindByID(ctx, novelID); err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+	overrides, err := s.regenOverrideRepo.FindByNovelID(ctx, novelID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("find regeneration overrides: %w", err)
+	}
+	return overrides, nil
+}
+
+// regenerationStatus 按当前限制计算产物的重新生成状态
+// Level 表示再重新生成一次时的状态，exceeded 表示下一次需要越权
+func (s *novelService) regenerationStatus(artifact novel.RegenerationArtifact, artifactID string, count int) *RegenerationStatus {
+	return &RegenerationStatus{
+		ArtifactType: artifact,
+		ArtifactID:   artifactID,
+		Count:        count,
+		WarnAt:       s.regenerationLimits.WarnAt,
+		Limit:        s.regenerationLimits.Limit,
+		Level:        s.regenerationLimits.Evaluate(count + 1),
+	}
+}
+
+// reserveRegeneration 在调用生成 provider 前登记一次重新生成
+// 按发起次数计数（生成失败也计入，调用可能已经产生费用）；超过上限时需要管理员/审核员通过 override_reason 越权，
+// 越权先写审计记录再计数，审计记录写入失败时不执行重新生成
+func (s *novelService) reserveRegeneration(ctx context.Context, target regenerationTarget, kind novel.RegenerationKind) error {
+	limits := s.regenerationLimits
+	next := target.count + 1
+	level := limits.Evaluate(next)
+
+	guard := limits.Limit
+	if level == budget.LevelExceeded {
+		override, err := s.regenerationOverride(ctx, target, kind, next)
+		if err != nil {
+			return err
+		}
+		if err := s.regenOverrideRepo.Create(ctx, override); err != nil {
+			return fmt.Errorf("create regeneration override: %w", err)
+		}
+		log.Warn().
+			Str("artifact_type", string(target.artifact)).
+			Str("artifact_id", target.id).
+			Str("kind", string(kind)).
+			Int("count", next).
+			Int("limit", limits.Limit).
+			Str("user_id", override.UserID).
+			Str("reason", override.Reason).
+			Msg("重新生成次数超过上限，已越权")
+		guard = 0
+	}
+
+	var count int
+	var err error
+	switch target.artifact {
+	case novel.RegenerationArtifactScene:
+		count, err = s.sceneRepo.IncrementRegenerationCount(ctx, target.id, guard)
+	default:
+		count, err = s.shotRepo.IncrementRegenerationCount(ctx, target.id, guard)
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// 并发重新生成时其他请求先达到了上限
+		return fmt.Errorf("%w: %s %s reached %d regenerations", ErrRegenerationLimitExceeded, target.artifact, target.id, limits.Limit)
+	}
+	if err != nil {
+		return fmt.Errorf("increment regeneration count: %w", err)
+	}
+
+	if limits.Evaluate(count) == budget.LevelWarning {
+		log.Warn().
+			Str("artifact_type", string(target.artifact)).
+			Str("artifact_id", target.id).
+			Str("kind", string(kind)).
+			Int("count", count).
+			Int("warn_at", limits.WarnAt).
+			Msg("重新生成次数接近上限")
+	}
+	return nil
+}
+
+// regenerationOverride 校验越权条件（管理员/审核员角色并填写原因）并构建审计记录
+func (s *novelService) regenerationOverride(ctx context.Context, target regenerationTarget, kind novel.RegenerationKind, count int) (*novel.RegenerationOverride, error) {
+	role, _ := ctxutil.GetUserRole(ctx)
+	if !slices.Contains(regenerationOverrideRoles, role) {
+		return nil, fmt.Errorf("%w: %s %s reached %d regenerations, admin or reviewer role required", ErrRegenerationLimitExceeded, target.artifact, target.id, s.regenerationLimits.Limit)
+	}
+	reason, ok := ctxutil.GetRegenerationOverride(ctx)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s reached %d regenerations, override_reason required", ErrRegenerationLimitExceeded, target.artifact, target.id, s.regenerationLimits.Limit)
+	}
+	userID, _ := ctxutil.GetUserID(ctx)
+	return &novel.RegenerationOverride{
+		ID:           id.New(),
+		NovelID:      target.novelID,
+		ChapterID:    target.chapterID,
+		ArtifactType: target.artifact,
+		ArtifactID:   target.id,
+		Kind:         kind,
+		Count:        count,
+		Limit:        s.regenerationLimits.Limit,
+		UserID:       userID,
+		Role:         string(role),
+		Reason:       reason,
+	}, nil
+}
@@ -0,0 +1,246 @@
+package novel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/errclass"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/tasklog"
+	"lemon/internal/pkg/taskstats"
+)
+
+const (
+	// defaultFailureStatsDays 失败分类统计默认的天数
+	defaultFailureStatsDays = 7
+	// maxFailureStatsDays 失败分类统计最多的天数
+	maxFailureStatsDays = 90
+)
+
+// runStage 执行章节渲染阶段，并记录耗时、provider 调用次数、上传/下载字节数和费用，失败时记录失败类别和出错的 provider
+// 同时追加阶段开始/结束的章节事件、写入任务日志并推送任务通知；记录失败只记录日志，不影响阶段本身的结果
+func (s *novelService) runStage(ctx context.Context, novelID, chapterID string, stage novel.PipelineStage, fn func(ctx context.Context) error) error {
+	runID := id.New()
+	stageCtx, stats := taskstats.NewContext(ctx)
+	stageCtx, resources := withStageResources(stageCtx)
+	startedAt := time.Now()
+	s.appendChapterEvent(ctx, &novel.ChapterEvent{
+		NovelID:    novelID,
+		ChapterID:  chapterID,
+		RunID:      runID,
+		Type:       novel.ChapterEventStageStarted,
+		Stage:      stage,
+		OccurredAt: startedAt,
+	})
+	taskLog := tasklog.FromContext(ctx)
+	taskLog.Info("阶段开始", tasklog.Fields{"stage": stage, "chapter_id": chapterID, "run_id": runID})
+	err := fn(stageCtx)
+	finishedAt := time.Now()
+
+	snap := stats.Snapshot()
+	run := &novel.StageRun{
+		ID:              runID,
+		NovelID:         novelID,
+		ChapterID:       chapterID,
+		Stage:           stage,
+		StartedAt:       startedAt,
+		FinishedAt:      finishedAt,
+		DurationMs:      finishedAt.Sub(startedAt).Milliseconds(),
+		ProviderCalls:   snap.ProviderCalls,
+		CostFen:         snap.CostFen,
+		BytesUploaded:   snap.BytesUploaded,
+		BytesDownloaded: snap.BytesDownloaded,
+	}
+	stageFields := tasklog.Fields{
+		"stage":          stage,
+		"chapter_id":     chapterID,
+		"run_id":         runID,
+		"duration_ms":    run.DurationMs,
+		"provider_calls": run.ProviderCalls,
+		"cost_fen":       run.CostFen,
+	}
+	if err != nil {
+		class, provider := errclass.Classify(err)
+		run.ErrorMessage = err.Error()
+		run.ErrorClass = string(class)
+		run.ErrorProvider = provider
+		stageFields["error"] = run.ErrorMessage
+		stageFields["error_class"] = run.ErrorClass
+		taskLog.Error("阶段失败", stageFields)
+	} else {
+		taskLog.Info("阶段完成", stageFields)
+	}
+	if createErr := s.stageRunRepo.Create(ctx, run); createErr != nil {
+		log.Error().Err(createErr).Str("chapter_id", chapterID).Str("stage", string(stage)).Msg("保存阶段执行记录失败")
+	}
+
+	finished := &novel.ChapterEvent{
+		NovelID:    novelID,
+		ChapterID:  chapterID,
+		RunID:      runID,
+		Type:       novel.ChapterEventStageCompleted,
+		Stage:      stage,
+		OccurredAt: finishedAt,
+		Payload: &novel.ChapterEventPayload{
+			DurationMs:      run.DurationMs,
+			ProviderCalls:   run.ProviderCalls,
+			CostFen:         run.CostFen,
+			BytesUploaded:   run.BytesUploaded,
+			BytesDownloaded: run.BytesDownloaded,
+			ErrorMessage:    run.ErrorMessage,
+			ErrorClass:      run.ErrorClass,
+		},
+	}
+	if err != nil {
+		finished.Type = novel.ChapterEventStageFailed
+	}
+	s.appendChapterEvent(ctx, finished)
+
+	payload := &novel.WebhookPayload{
+		Event:       novel.WebhookEventTaskCompleted,
+		TaskType:    stage,
+		NovelID:     novelID,
+		ChapterID:   chapterID,
+		ResourceIDs: resources.ids,
+		RunID:       runID,
+		Status:      "completed",
+		Error:       run.ErrorMessage,
+		ErrorClass:  run.ErrorClass,
+		DurationMs:  run.DurationMs,
+		OccurredAt:  finishedAt,
+	}
+	if len(resources.ids) > 0 {
+		payload.ResourceID = resources.ids[0]
+	}
+	if err != nil {
+		payload.Event = novel.WebhookEventTaskFailed
+		payload.Status = "failed"
+	}
+	s.notifyTaskWebhooks(ctx, payload)
+	return err
+}
+
+// runChapterStage 按章节ID执行章节渲染阶段；章节不存在时不记录，直接执行（由阶段本身返回错误）
+func (s *novelService) runChapterStage(ctx context.Context, chapterID string, stage novel.PipelineStage, fn func(ctx context.Context) error) error {
+	chapter, err := s.chapterRepo.FindByID(ctx, chapterID)
+	if err != nil {
+		return fn(ctx)
+	}
+	return s.runStage(ctx, chapter.NovelID, chapter.ID, stage, fn)
+}
+
+// runNarrationStage 按解说ID执行章节渲染阶段；解说不存在时不记录，直接执行（由阶段本身返回错误）
+func (s *novelService) runNarrationStage(ctx context.Context, narrationID string, stage novel.PipelineStage, fn func(ctx context.Context) error) error {
+	narration, err := s.narrationRepo.FindByID(ctx, narrationID)
+	if err != nil {
+		return fn(ctx)
+	}
+	return s.runStage(ctx, narration.NovelID, narration.ChapterID, stage, fn)
+}
+
+// GetRenderBreakdown 汇总章节渲染的耗时与费用明细（每个阶段取最近一次成功的执行记录）
+func (s *novelService) GetRenderBreakdown(ctx context.Context, chapterID string) (*novel.RenderBreakdown, error) {
+	runs, err := s.stageRunRepo.FindByChapterID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find stage runs: %w", err)
+	}
+	return buildRenderBreakdown(runs), nil
+}
+
+// GetFailureStats 按失败类别统计最近 days 天（含今天，按 UTC 日期）的生成失败
+// days 不在 1~maxFailureStatsDays 范围内时使用默认值
+func (s *novelService) GetFailureStats(ctx context.Context, days int) (*novel.FailureStats, error) {
+	if days <= 0 || days > maxFailureStatsDays {
+		days = defaultFailureStatsDays
+	}
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -(days - 1))
+
+	byDay, err := s.stageRunRepo.CountFailuresByDay(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("count failures by day: %w", err)
+	}
+	byProvider, err := s.stageRunRepo.CountFailuresByProvider(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("count failures by provider: %w", err)
+	}
+
+	stats := &novel.FailureStats{
+		Since:      since,
+		ByClass:    make(map[string]int, len(errclass.Classes)+1),
+		ByDay:      byDay,
+		ByProvider: byProvider,
+	}
+	for _, class := range errclass.Classes {
+		stats.ByClass[string(class)] = 0
+	}
+	for _, count := range byDay {
+		stats.ByClass[count.Class] += count.Count
+		stats.Total += count.Count
+	}
+	return stats, nil
+}
+
+// saveRenderBreakdown 章节最终视频生成完成后，把渲染明细保存到最终视频记录上
+func (s *novelService) saveRenderBreakdown(ctx context.Context, chapterID, videoID string) {
+	breakdown, err := s.GetRenderBreakdown(ctx, chapterID)
+	if err != nil {
+		log.Error().Err(err).Str("chapter_id", chapterID).Msg("汇总章节渲染明细失败")
+		return
+	}
+	if err := s.videoRepo.UpdateRenderBreakdown(ctx, videoID, breakdown); err != nil {
+		log.Error().Err(err).Str("video_id", videoID).Msg("保存章节渲染明细失败")
+		return
+	}
+	log.Info().
+		Str("chapter_id", chapterID).
+		Str("video_id", videoID).
+		Int64("total_duration_ms", breakdown.TotalDurationMs).
+		Int64("total_cost_fen", breakdown.TotalCostFen).
+		Msg("章节渲染明细已保存")
+}
+
+// buildRenderBreakdown 按流程顺序汇总各阶段最近一次成功的执行记录（runs 按开始时间倒序）
+func buildRenderBreakdown(runs []*novel.StageRun) *novel.RenderBreakdown {
+	latest := make(map[novel.PipelineStage]*novel.StageRun)
+	for _, run := range runs {
+		if !run.Succeeded() {
+			continue
+		}
+		if cur, ok := latest[run.Stage]; !ok || run.StartedAt.After(cur.StartedAt) {
+			latest[run.Stage] = run
+		}
+	}
+
+	breakdown := &novel.RenderBreakdown{
+		Stages:             []novel.StageBreakdown{},
+		TotalProviderCalls: map[string]int{},
+	}
+	for _, stage := range novel.AllPipelineStages {
+		run, ok := latest[stage]
+		if !ok {
+			continue
+		}
+		breakdown.Stages = append(breakdown.Stages, novel.StageBreakdown{
+			Stage:           run.Stage,
+			StartedAt:       run.StartedAt,
+			DurationMs:      run.DurationMs,
+			ProviderCalls:   run.ProviderCalls,
+			CostFen:         run.CostFen,
+			BytesUploaded:   run.BytesUploaded,
+			BytesDownloaded: run.BytesDownloaded,
+		})
+		breakdown.TotalDurationMs += run.DurationMs
+		breakdown.TotalCostFen += run.CostFen
+		breakdown.BytesUploaded += run.BytesUploaded
+		breakdown.BytesDownloaded += run.BytesDownloaded
+		for provider, n := range run.ProviderCalls {
+			breakdown.TotalProviderCalls[provider] += n
+		}
+	}
+	return breakdown
+}
@@ -0,0 +1,166 @@
+package novel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ffmpeg"
+	"lemon/internal/pkg/procreaper"
+	"lemon/internal/service"
+)
+
+// 编辑器拖动辅助文件的产物类型（存储路径模板中的 {artifact_type}）
+const (
+	artifactWaveform        = "waveform"         // 音频波形峰值（audiowaveform JSON）
+	artifactThumbnailSprite = "thumbnail_sprite" // 视频缩略图雪碧图（JPEG）
+	artifactThumbnailVTT    = "thumbnail_vtt"    // 雪碧图的 WebVTT 索引
+)
+
+// scrubAidsFromEnv 音频/视频完成后是否生成编辑器拖动辅助文件（EDITOR_SCRUB_AIDS=false 时关闭，默认开启）
+func scrubAidsFromEnv() bool {
+	return !strings.EqualFold(os.Getenv("EDITOR_SCRUB_AIDS"), "false")
+}
+
+// generateAudioWaveform 生成音频波形峰值文件并关联到音频记录
+// 辅助文件失败只记录警告，不影响音频生成
+func (s *novelService) generateAudioWaveform(ctx context.Context, audio *novel.Audio) {
+	if !s.scrubAids || audio == nil {
+		return
+	}
+	if err := s.storeAudioWaveform(ctx, audio); err != nil {
+		log.Warn().Err(err).Str("audio_id", audio.ID).Msg("生成音频波形失败")
+	}
+}
+
+func (s *novelService) storeAudioWaveform(ctx context.Context, audio *novel.Audio) error {
+	tmpDir, cleanupTmpDir, err := procreaper.MkdirTemp(ctx, "waveform_")
+	if err != nil {
+		return fmt.Errorf("create temp dir: %w", err)
+	}
+	defer cleanupTmpDir()
+
+	audioPath := filepath.Join(tmpDir, "audio.mp3")
+	if err := s.downloadResourceToFile(ctx, audio.AudioResourceID, audioPath); err != nil {
+		return fmt.Errorf("download audio: %w", err)
+	}
+	waveform, err := ffmpeg.NewClient().ExtractWaveform(ctx, audioPath, ffmpeg.DefaultWaveformPixelsPerSecond)
+	if err != nil {
+		return fmt.Errorf("extract waveform: %w", err)
+	}
+	data, err := json.Marshal(waveform)
+	if err != nil {
+		return fmt.Errorf("encode waveform: %w", err)
+	}
+
+	uploadResult, err := s.resourceService.UploadFile(ctx, &service.UploadFileRequest{
+		UserID:      audio.UserID,
+		FileName:    fmt.Sprintf("%s_waveform.json", audio.ID),
+		ContentType: "application/json",
+		Ext:         "json",
+		Data:        bytes.NewReader(data),
+		KeyVars:     s.chapterKeyVarsByID(ctx, audio.NovelID, audio.ChapterID, artifactWaveform, audio.Version, audio.Sequence),
+	})
+	if err != nil {
+		return fmt.Errorf("upload waveform: %w", err)
+	}
+	if err := s.audioRepo.UpdateWaveform(ctx, audio.ID, uploadResult.ResourceID); err != nil {
+		return fmt.Errorf("update audio waveform: %w", err)
+	}
+	audio.WaveformResourceID = uploadResult.ResourceID
+	return nil
+}
+
+// generateVideoThumbnails 生成视频缩略图雪碧图和 WebVTT 索引并关联到视频记录
+// 辅助文件失败只记录警告，不影响视频生成
+func (s *novelService) generateVideoThumbnails(ctx context.Context, video *novel.Video) {
+	if !s.scrubAids || video == nil {
+		return
+	}
+	if err := s.storeVideoThumbnails(ctx, video); err != nil {
+		log.Warn().Err(err).Str("video_id", video.ID).Msg("生成视频缩略图雪碧图失败")
+	}
+}
+
+func (s *novelService) storeVideoThumbnails(ctx context.Context, video *novel.Video) error {
+	tmpDir, cleanupTmpDir, err := procreaper.MkdirTemp(ctx, "thumbnail_sprite_")
+	if err != nil {
+		return fmt.Errorf("create temp dir: %w", err)
+	}
+	defer cleanupTmpDir()
+
+	videoPath := filepath.Join(tmpDir, "video.mp4")
+	if err := s.downloadResourceToFile(ctx, video.VideoResourceID, videoPath); err != nil {
+		return fmt.Errorf("download video: %w", err)
+	}
+
+	ffmpegClient := ffmpeg.NewClient()
+	info, err := ffmpegClient.GetVideoInfo(ctx, videoPath)
+	if err != nil {
+		return fmt.Errorf("get video info: %w", err)
+	}
+	layout, err := ffmpeg.NewSpriteLayout(info.Duration, info.Width, info.Height, 0, 0, 0)
+	if err != nil {
+		return err
+	}
+	spritePath := filepath.Join(tmpDir, "sprite.jpg")
+	if err := ffmpegClient.GenerateThumbnailSprite(ctx, videoPath, spritePath, layout); err != nil {
+		return err
+	}
+	sprite, err := os.Open(spritePath)
+	if err != nil {
+		return fmt.Errorf("open sprite: %w", err)
+	}
+	defer sprite.Close()
+
+	spriteResult, err := s.resourceService.UploadFile(ctx, &service.UploadFileRequest{
+		UserID:      video.UserID,
+		FileName:    fmt.Sprintf("%s_sprite.jpg", video.ID),
+		ContentType: "image/jpeg",
+		Ext:         "jpg",
+		Data:        sprite,
+		KeyVars:     s.chapterKeyVarsByID(ctx, video.NovelID, video.ChapterID, artifactThumbnailSprite, video.Version, video.Sequence),
+	})
+	if err != nil {
+		return fmt.Errorf("upload sprite: %w", err)
+	}
+
+	// 索引引用雪碧图的下载接口（预签名地址会过期，不能写进文件）
+	spriteURL := fmt.Sprintf("/api/v1/resources/%s/download", spriteResult.ResourceID)
+	vtt := ffmpeg.BuildSpriteVTT(spriteURL, layout, info.Duration)
+	vttResult, err := s.resourceService.UploadFile(ctx, &service.UploadFileRequest{
+		UserID:      video.UserID,
+		FileName:    fmt.Sprintf("%s_sprite.vtt", video.ID),
+		ContentType: "text/vtt",
+		Ext:         "vtt",
+		Data:        strings.NewReader(vtt),
+		KeyVars:     s.chapterKeyVarsByID(ctx, video.NovelID, video.ChapterID, artifactThumbnailVTT, video.Version, video.Sequence),
+	})
+	if err != nil {
+		return fmt.Errorf("upload sprite vtt: %w", err)
+	}
+
+	if err := s.videoRepo.UpdateThumbnailSprite(ctx, video.ID, spriteResult.ResourceID, vttResult.ResourceID); err != nil {
+		return fmt.Errorf("update video thumbnail sprite: %w", err)
+	}
+	video.ThumbnailSpriteResourceID = spriteResult.ResourceID
+	video.ThumbnailVTTResourceID = vttResult.ResourceID
+	return nil
+}
+
+// downloadResourceToFile 下载资源到本地文件（系统内部请求）
+func (s *novelService) downloadResourceToFile(ctx context.Context, resourceID, path string) error {
+	result, err := s.resourceService.DownloadFile(ctx, &service.DownloadFileRequest{ResourceID: resourceID})
+	if err != nil {
+		return err
+	}
+	defer result.Data.Close()
+	return writeTempFile(path, result.Data)
+}
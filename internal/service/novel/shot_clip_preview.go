@@ -0,0 +1,486 @@
+package novel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ffmpeg"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/killswitch"
+	"lemon/internal/pkg/noveltools"
+	"lemon/internal/pkg/presign"
+	"lemon/internal/pkg/respcache"
+	"lemon/internal/service"
+)
+
+var (
+	// ErrInvalidShotClipPreview 预览请求不合法（提示词为空、镜头没有可替换的单镜头片段、预览已处理或已过期）
+	ErrInvalidShotClipPreview = errors.New("invalid shot clip preview")
+	// ErrShotClipPreviewStale 渲染预览后章节又生成了新的视频版本，预览的基础版本已不是最新版本
+	ErrShotClipPreviewStale = errors.New("shot clip preview is stale")
+
+	// errShotSourceNotCached 本地没有缓存镜头的图生视频结果（只复用缓存时返回）
+	errShotSourceNotCached = errors.New("shot source video is not cached")
+)
+
+// ShotClipPreviewService 单镜头片段预览服务接口
+type ShotClipPreviewService interface {
+	// PreviewShotClip 按编辑后的 video_prompt 只重新渲染镜头的片段（沿用已有的音频和字幕），结果作为临时资源返回用于预览
+	// 不修改镜头，也不改变章节的视频版本
+	PreviewShotClip(ctx context.Context, shotID, videoPrompt string) (*novel.ShotClipPreview, error)
+
+	// ConfirmShotClipPreview 确认预览：以最新视频版本为基础生成新版本（替换该镜头的片段），并把 video_prompt 写回镜头
+	ConfirmShotClipPreview(ctx context.Context, shotID, previewID string) (*novel.ShotClipPreview, error)
+
+	// DiscardShotClipPreview 放弃预览并删除预览片段
+	DiscardShotClipPreview(ctx context.Context, shotID, previewID string) error
+
+	// GetShotClipPreview 获取待确认的预览及预览片段的下载链接
+	GetShotClipPreview(ctx context.Context, shotID, previewID string) (*novel.ShotClipPreview, *service.GetDownloadURLResult, error)
+
+	// GetShotClipPreviewLatencyStats 统计最近 days 天单镜头「重新生成并预览」的耗时（p50、p95、目标耗时内完成的比例）
+	GetShotClipPreviewLatencyStats(ctx context.Context, days int) (*novel.ShotClipPreviewLatencyStats, error)
+}
+
+// PreviewShotClip 按编辑后的 video_prompt 重新渲染单个镜头的片段
+// 使用快速编码预设，并复用本地缓存的输入素材和图生视频结果（见 shotClipOptions.Preview），记录从请求开始到预览可用的耗时
+func (s *novelService) PreviewShotClip(ctx context.Context, shotID, videoPrompt string) (*novel.ShotClipPreview, error) {
+	start := time.Now()
+	videoPrompt = strings.TrimSpace(videoPrompt)
+	if videoPrompt == "" {
+		return nil, fmt.Errorf("%w: video_prompt is required", ErrInvalidShotClipPreview)
+	}
+
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderVideo)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	shot, err := s.shotRepo.FindByID(ctx, shotID)
+	if err != nil {
+		return nil, fmt.Errorf("find shot: %w", err)
+	}
+	narration, err := s.narrationRepo.FindByID(ctx, shot.NarrationID)
+	if err != nil {
+		return nil, fmt.Errorf("find narration: %w", err)
+	}
+
+	baseVersion, err := s.resolveVideoVersion(ctx, shot.ChapterID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: chapter has no narration videos yet", ErrInvalidShotClipPreview)
+	}
+	baseVideo, err := s.findShotClip(ctx, shot, baseVersion)
+	if err != nil {
+		return nil, err
+	}
+	pause, err := s.shotTailPause(ctx, narration, shot)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reserveRegeneration(ctx, shotRegenerationTarget(shot), novel.RegenerationKindShotClipPreview); err != nil {
+		return nil, err
+	}
+
+	shotInfo := struct {
+		SceneNumber string
+		ShotNumber  string
+		Shot        *novel.Shot
+		Index       int
+	}{
+		SceneNumber: shot.SceneNumber,
+		ShotNumber:  shot.ShotNumber,
+		Shot:        shot,
+		Index:       baseVideo.Sequence,
+	}
+	narrationNum := fmt.Sprintf("%02d_preview", baseVideo.Sequence)
+	clip, err := s.renderShotClip(ctx, shot.ChapterID, narration, shotInfo, narrationNum, videoPrompt, 0, pause, baseVideo.Platform, ffmpeg.NewClient(), shotClipOptions{Preview: true})
+	if err != nil {
+		return nil, fmt.Errorf("render shot clip: %w", err)
+	}
+
+	preview := &novel.ShotClipPreview{
+		ID:              id.New(),
+		ShotID:          shot.ID,
+		NarrationID:     narration.ID,
+		ChapterID:       shot.ChapterID,
+		NovelID:         shot.NovelID,
+		UserID:          narration.UserID,
+		VideoPrompt:     videoPrompt,
+		VideoResourceID: clip.ResourceID,
+		Duration:        clip.Duration,
+		Platform:        baseVideo.Platform,
+		Sequence:        baseVideo.Sequence,
+		BaseVersion:     baseVersion,
+		BaseVideoID:     baseVideo.ID,
+		Status:          novel.ShotClipPreviewStatusPending,
+		ExpiresAt:       time.Now().Add(novel.ShotClipPreviewTTL),
+		Timings:         &clip.Timings,
+		SourceReused:    clip.SourceReused,
+	}
+	preview.LatencyMs = time.Since(start).Milliseconds()
+	if err := s.shotClipPreviewRepo.Create(ctx, preview); err != nil {
+		return nil, fmt.Errorf("create shot clip preview: %w", err)
+	}
+
+	event := log.Info()
+	if preview.LatencyMs > novel.ShotClipPreviewLatencyTarget.Milliseconds() {
+		event = log.Warn()
+	}
+	event.
+		Str("shot_id", shot.ID).
+		Str("preview_id", preview.ID).
+		Int("base_version", baseVersion).
+		Float64("duration", clip.Duration).
+		Int64("latency_ms", preview.LatencyMs).
+		Bool("source_reused", clip.SourceReused).
+		Msg("单镜头片段预览渲染成功")
+
+	return preview, nil
+}
+
+// ConfirmShotClipPreview 确认预览，生成新的视频版本
+// 新版本复制基础版本的所有 narration 片段（引用相同的资源），只替换该镜头的片段
+// 预览片段使用快速编码预设，确认时尽量按正式画质重新编码（见 finalizeShotClip）
+func (s *novelService) ConfirmShotClipPreview(ctx context.Context, shotID, previewID string) (*novel.ShotClipPreview, error) {
+	preview, err := s.findPendingShotClipPreview(ctx, shotID, previewID)
+	if err != nil {
+		return nil, err
+	}
+
+	latestVersion, err := s.resolveVideoVersion(ctx, preview.ChapterID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("resolve video version: %w", err)
+	}
+	if latestVersion != preview.BaseVersion {
+		return nil, fmt.Errorf("%w: preview is based on version %d, latest is %d", ErrShotClipPreviewStale, preview.BaseVersion, latestVersion)
+	}
+
+	baseVideos, err := s.videoRepo.FindByChapterIDAndVersion(ctx, preview.ChapterID, preview.BaseVersion)
+	if err != nil {
+		return nil, fmt.Errorf("find videos for version %d: %w", preview.BaseVersion, err)
+	}
+	newVersion, err := s.nextVersion(ctx, preview.ChapterID, novel.VersionArtifactVideo)
+	if err != nil {
+		return nil, fmt.Errorf("allocate video version: %w", err)
+	}
+
+	var videos []*novel.Video
+	var replaced *novel.Video
+	for _, base := range baseVideos {
+		if base.VideoType != novel.VideoTypeNarration {
+			continue
+		}
+		video := *base
+		video.ID = id.New()
+		video.Version = newVersion
+		video.DeletedAt = nil
+		if base.ID == preview.BaseVideoID {
+			video.ShotID = preview.ShotID
+			video.VideoResourceID = preview.VideoResourceID
+			video.Duration = preview.Duration
+			video.Prompt = preview.VideoPrompt
+			// 片段内容变了，缩略图雪碧图在创建记录后重新生成
+			video.ThumbnailSpriteResourceID = ""
+			video.ThumbnailVTTResourceID = ""
+			replaced = &video
+		}
+		videos = append(videos, &video)
+	}
+	if replaced == nil {
+		return nil, fmt.Errorf("%w: base video %s no longer exists", ErrShotClipPreviewStale, preview.BaseVideoID)
+	}
+	finalResourceID := s.finalizeShotClip(ctx, preview, newVersion)
+	if finalResourceID != "" {
+		replaced.VideoResourceID = finalResourceID
+	}
+
+	// 先标记预览已确认，避免并发确认生成两个版本
+	ok, err := s.shotClipPreviewRepo.Resolve(ctx, preview.ID, novel.ShotClipPreviewStatusConfirmed, newVersion)
+	if err == nil && !ok {
+		err = fmt.Errorf("%w: preview has already been resolved", ErrInvalidShotClipPreview)
+	} else if err != nil {
+		err = fmt.Errorf("resolve shot clip preview: %w", err)
+	}
+	if err != nil {
+		if finalResourceID != "" {
+			if delErr := s.resourceService.DeleteResource(ctx, finalResourceID); delErr != nil {
+				log.Warn().Err(delErr).Str("preview_id", preview.ID).Msg("删除重新编码的片段失败")
+			}
+		}
+		return nil, err
+	}
+
+	for _, video := range videos {
+		if err := s.videoRepo.Create(ctx, video); err != nil {
+			return nil, fmt.Errorf("create video record: %w", err)
+		}
+	}
+	s.generateVideoThumbnails(ctx, replaced)
+	if err := s.shotRepo.Update(ctx, preview.ShotID, map[string]interface{}{"video_prompt": preview.VideoPrompt}); err != nil {
+		return nil, fmt.Errorf("update shot video_prompt: %w", err)
+	}
+
+	log.Info().
+		Str("shot_id", preview.ShotID).
+		Str("preview_id", preview.ID).
+		Int("base_version", preview.BaseVersion).
+		Int("version", newVersion).
+		Int("clips", len(videos)).
+		Msg("单镜头片段预览已确认，生成新的视频版本")
+
+	preview.Status = novel.ShotClipPreviewStatusConfirmed
+	preview.ConfirmedVersion = newVersion
+	return preview, nil
+}
+
+// DiscardShotClipPreview 放弃预览
+func (s *novelService) DiscardShotClipPreview(ctx context.Context, shotID, previewID string) error {
+	preview, err := s.shotClipPreviewRepo.FindByID(ctx, previewID)
+	if err != nil {
+		return fmt.Errorf("find shot clip preview: %w", err)
+	}
+	if preview.ShotID != shotID {
+		return fmt.Errorf("find shot clip preview: %w", mongo.ErrNoDocuments)
+	}
+
+	ok, err := s.shotClipPreviewRepo.Resolve(ctx, preview.ID, novel.ShotClipPreviewStatusDiscarded, 0)
+	if err != nil {
+		return fmt.Errorf("resolve shot clip preview: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("%w: preview has already been resolved", ErrInvalidShotClipPreview)
+	}
+
+	if err := s.resourceService.DeleteResource(ctx, preview.VideoResourceID); err != nil {
+		log.Warn().Err(err).Str("preview_id", preview.ID).Msg("删除预览片段失败")
+	}
+	return nil
+}
+
+// GetShotClipPreview 获取待确认的预览，下载链接的有效期不超过预览的剩余有效期
+func (s *novelService) GetShotClipPreview(ctx context.Context, shotID, previewID string) (*novel.ShotClipPreview, *service.GetDownloadURLResult, error) {
+	preview, err := s.findPendingShotClipPreview(ctx, shotID, previewID)
+	if err != nil {
+		return nil, nil, err
+	}
+	download, err := s.resourceService.GetDownloadURL(ctx, &service.GetDownloadURLRequest{
+		ResourceID: preview.VideoResourceID,
+		Purpose:    presign.PurposePreview,
+		ExpiresIn:  time.Until(preview.ExpiresAt), // 不超过预览本身的有效期，上限按预览链接的策略
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("get preview download url: %w", err)
+	}
+	return preview, download, nil
+}
+
+// findPendingShotClipPreview 查询镜头的待确认预览，已处理或已过期时返回 ErrInvalidShotClipPreview
+func (s *novelService) findPendingShotClipPreview(ctx context.Context, shotID, previewID string) (*novel.ShotClipPreview, error) {
+	preview, err := s.shotClipPreviewRepo.FindByID(ctx, previewID)
+	if err != nil {
+		return nil, fmt.Errorf("find shot clip preview: %w", err)
+	}
+	if preview.ShotID != shotID {
+		return nil, fmt.Errorf("find shot clip preview: %w", mongo.ErrNoDocuments)
+	}
+	if preview.Status != novel.ShotClipPreviewStatusPending {
+		return nil, fmt.Errorf("%w: preview is %s", ErrInvalidShotClipPreview, preview.Status)
+	}
+	if preview.IsExpired(time.Now()) {
+		return nil, fmt.Errorf("%w: preview has expired", ErrInvalidShotClipPreview)
+	}
+	return preview, nil
+}
+
+// finalizeShotClip 按正式画质重新编码确认的预览片段，返回新片段的 resource_id
+// 只复用预览时缓存的图生视频结果，不再调用图生视频接口；未缓存（未启用本地资源缓存或预览在其他实例上渲染）或重新编码失败时返回空字符串，沿用预览片段
+func (s *novelService) finalizeShotClip(ctx context.Context, preview *novel.ShotClipPreview, version int) string {
+	logger := log.With().Str("shot_id", preview.ShotID).Str("preview_id", preview.ID).Logger()
+	shot, err := s.shotRepo.FindByID(ctx, preview.ShotID)
+	if err != nil {
+		logger.Warn().Err(err).Msg("查询镜头失败，沿用预览片段")
+		return ""
+	}
+	narration, err := s.narrationRepo.FindByID(ctx, preview.NarrationID)
+	if err != nil {
+		logger.Warn().Err(err).Msg("查询解说失败，沿用预览片段")
+		return ""
+	}
+	pause, err := s.shotTailPause(ctx, narration, shot)
+	if err != nil {
+		logger.Warn().Err(err).Msg("计算镜头停顿失败，沿用预览片段")
+		return ""
+	}
+
+	shotInfo := struct {
+		SceneNumber string
+		ShotNumber  string
+		Shot        *novel.Shot
+		Index       int
+	}{
+		SceneNumber: shot.SceneNumber,
+		ShotNumber:  shot.ShotNumber,
+		Shot:        shot,
+		Index:       preview.Sequence,
+	}
+	narrationNum := fmt.Sprintf("%02d", preview.Sequence)
+	clip, err := s.renderShotClip(ctx, preview.ChapterID, narration, shotInfo, narrationNum, preview.VideoPrompt, version, pause, preview.Platform, ffmpeg.NewClient(), shotClipOptions{CachedSourceOnly: true})
+	if errors.Is(err, errShotSourceNotCached) {
+		logger.Info().Msg("本地没有缓存图生视频结果，沿用预览片段")
+		return ""
+	}
+	if err != nil {
+		logger.Warn().Err(err).Msg("按正式画质重新编码预览片段失败，沿用预览片段")
+		return ""
+	}
+	logger.Info().Int64("encode_ms", clip.Timings.EncodeMs).Msg("预览片段已按正式画质重新编码")
+	return clip.ResourceID
+}
+
+// stageShotInput 把预览使用的输入素材缓存到本地（未启用本地资源缓存时跳过），缓存失败不影响预览
+func (s *novelService) stageShotInput(ctx context.Context, resourceID string) {
+	if s.assetCache == nil || resourceID == "" {
+		return
+	}
+	if _, err := s.resourceService.StageFile(ctx, resourceID); err != nil {
+		log.Warn().Err(err).Str("resource_id", resourceID).Msg("缓存预览素材失败，直接从存储下载")
+	}
+}
+
+// shotSourceCacheKey 镜头图生视频结果的缓存键：图片、提示词、时长和视频提供者都相同时结果可以复用
+func (s *novelService) shotSourceCacheKey(imageResourceID, videoPrompt string, duration int) string {
+	info := noveltools.DescribeProvider(s.videoProvider)
+	return respcache.Key("shot_source", info.Name, info.Model, imageResourceID, videoPrompt, strconv.Itoa(duration))
+}
+
+// loadShotSource 把本地缓存的图生视频结果复制到 path，返回是否命中（未启用本地资源缓存时返回 false）
+func (s *novelService) loadShotSource(key, path string) bool {
+	if s.assetCache == nil {
+		return false
+	}
+	cached, ok := s.assetCache.Open(key)
+	if !ok {
+		return false
+	}
+	defer cached.Close()
+
+	file, err := os.Create(path)
+	if err != nil {
+		log.Warn().Err(err).Msg("创建临时视频文件失败，重新生成图生视频")
+		return false
+	}
+	_, err = io.Copy(file, cached)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Warn().Err(err).Msg("读取缓存的图生视频结果失败，重新生成图生视频")
+		return false
+	}
+	return true
+}
+
+// storeShotSource 缓存图生视频结果（未启用本地资源缓存时跳过），缓存失败不影响预览
+func (s *novelService) storeShotSource(key string, data []byte) {
+	if s.assetCache == nil {
+		return
+	}
+	if _, err := s.assetCache.Put(key, bytes.NewReader(data), int64(len(data))); err != nil {
+		log.Warn().Err(err).Msg("缓存图生视频结果失败")
+	}
+}
+
+// GetShotClipPreviewLatencyStats 统计最近 days 天（含今天，按 UTC 日期）单镜头预览的耗时
+// days 不在 1~maxFailureStatsDays 范围内时使用默认值
+func (s *novelService) GetShotClipPreviewLatencyStats(ctx context.Context, days int) (*novel.ShotClipPreviewLatencyStats, error) {
+	if days <= 0 || days > maxFailureStatsDays {
+		days = defaultFailureStatsDays
+	}
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -(days - 1))
+
+	previews, err := s.shotClipPreviewRepo.FindLatenciesSince(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("find shot clip preview latencies: %w", err)
+	}
+
+	target := novel.ShotClipPreviewLatencyTarget.Milliseconds()
+	stats := &novel.ShotClipPreviewLatencyStats{
+		Since:    since,
+		Count:    len(previews),
+		TargetMs: target,
+	}
+	latencies := make([]int64, 0, len(previews))
+	for _, p := range previews {
+		latencies = append(latencies, p.LatencyMs)
+		stats.MaxMs = max(stats.MaxMs, p.LatencyMs)
+		if p.LatencyMs <= target {
+			stats.WithinTarget++
+		}
+		if p.SourceReused {
+			stats.SourceReused++
+		}
+	}
+	stats.P50Ms = noveltools.Percentile(latencies, 50)
+	stats.P95Ms = noveltools.Percentile(latencies, 95)
+	if stats.Count > 0 {
+		stats.WithinTargetRatio = float64(stats.WithinTarget) / float64(stats.Count)
+	}
+	return stats, nil
+}
+
+// findShotClip 查找版本中镜头对应的单镜头片段：优先按镜头ID匹配，旧数据按 sequence 匹配
+// 镜头包含在合并片段中时无法单独替换
+func (s *novelService) findShotClip(ctx context.Context, shot *novel.Shot, version int) (*novel.Video, error) {
+	videos, err := s.videoRepo.FindByChapterIDAndVersion(ctx, shot.ChapterID, version)
+	if err != nil {
+		return nil, fmt.Errorf("find videos for version %d: %w", version, err)
+	}
+	for _, video := range videos {
+		if video.VideoType != novel.VideoTypeNarration {
+			continue
+		}
+		start, end := video.SequenceRange()
+		matched := video.ShotID == shot.ID || (video.ShotID == "" && start <= shot.Index && shot.Index <= end)
+		if !matched {
+			continue
+		}
+		if end > start {
+			return nil, fmt.Errorf("%w: shot is part of merged clip %d-%d", ErrInvalidShotClipPreview, start, end)
+		}
+		return video, nil
+	}
+	return nil, fmt.Errorf("%w: no clip for shot in version %d", ErrInvalidShotClipPreview, version)
+}
+
+// shotTailPause 计算镜头片段末尾的停顿，与生成整章 narration 视频时的规则一致
+func (s *novelService) shotTailPause(ctx context.Context, narration *novel.Narration, shot *novel.Shot) (float64, error) {
+	shots, err := s.shotRepo.FindByNarrationIDAndVersion(ctx, narration.ID, shot.Version)
+	if err != nil {
+		return 0, fmt.Errorf("find shots: %w", err)
+	}
+	sceneNumbers := make([]string, len(shots))
+	for i, sh := range shots {
+		sceneNumbers[i] = sh.SceneNumber
+	}
+	gen := s.generationSettings(ctx, narration.NovelID)
+	gaps := noveltools.NarrationGaps(sceneNumbers, gen.SegmentGap, gen.SceneGap)
+	for i, sh := range shots {
+		if sh.ID == shot.ID {
+			return gaps[i], nil
+		}
+	}
+	return 0, nil
+}
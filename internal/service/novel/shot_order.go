@@ -0,0 +1,280 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+)
+
+// ErrInvalidReorder 重排的ID列表必须恰好包含所有现有场景/镜头各一次
+var ErrInvalidReorder = errors.New("invalid reorder: ids must list every existing item exactly once")
+
+// ReorderScenes 按给定的场景ID顺序重排解说中的场景
+// 场景编号和序号按新顺序从 1 重新编号，镜头的冗余场景编号和全局索引随之更新，已生成的图片/音频/字幕/视频按镜头ID重新关联
+func (s *novelService) ReorderScenes(ctx context.Context, narrationID string, sceneIDs []string) ([]*novel.Scene, error) {
+	if _, err := s.narrationRepo.FindByID(ctx, narrationID); err != nil {
+		return nil, fmt.Errorf("find narration: %w", err)
+	}
+	scenes, err := s.sceneRepo.FindByNarrationID(ctx, narrationID)
+	if err != nil {
+		return nil, fmt.Errorf("find scenes: %w", err)
+	}
+
+	byID := make(map[string]*novel.Scene, len(scenes))
+	ids := make([]string, 0, len(scenes))
+	for _, scene := range scenes {
+		byID[scene.ID] = scene
+		ids = append(ids, scene.ID)
+	}
+	if !isPermutation(ids, sceneIDs) {
+		return nil, ErrInvalidReorder
+	}
+
+	// 场景在 (chapter_id, version, scene_number) 上有唯一索引，先移到临时编号再写入新编号，避免交换位置时冲突
+	for _, scene := range scenes {
+		if err := s.sceneRepo.Update(ctx, scene.ID, map[string]interface{}{
+			"scene_number": reorderPlaceholder(scene.ID),
+		}); err != nil {
+			return nil, fmt.Errorf("update scene %s: %w", scene.ID, err)
+		}
+	}
+
+	ordered := make([]*novel.Scene, 0, len(sceneIDs))
+	for i, sceneID := range sceneIDs {
+		scene := byID[sceneID]
+		scene.Sequence = i + 1
+		scene.SceneNumber = strconv.Itoa(i + 1)
+		if err := s.sceneRepo.Update(ctx, scene.ID, map[string]interface{}{
+			"scene_number": scene.SceneNumber,
+			"sequence":     scene.Sequence,
+		}); err != nil {
+			return nil, fmt.Errorf("update scene %s: %w", scene.ID, err)
+		}
+		ordered = append(ordered, scene)
+	}
+
+	if err := s.renumberNarrationShots(ctx, ordered); err != nil {
+		return nil, err
+	}
+	return ordered, nil
+}
+
+// ReorderShots 按给定的镜头ID顺序重排场景内的镜头
+// 镜头编号和场景内序号按新顺序从 1 重新编号，整个解说的全局索引随之更新，已生成的产物按镜头ID重新关联
+func (s *novelService) ReorderShots(ctx context.Context, sceneID string, shotIDs []string) ([]*novel.Shot, error) {
+	scene, err := s.sceneRepo.FindByID(ctx, sceneID)
+	if err != nil {
+		return nil, fmt.Errorf("find scene: %w", err)
+	}
+	shots, err := s.shotRepo.FindBySceneID(ctx, sceneID)
+	if err != nil {
+		return nil, fmt.Errorf("find shots: %w", err)
+	}
+
+	byID := make(map[string]*novel.Shot, len(shots))
+	ids := make([]string, 0, len(shots))
+	for _, shot := range shots {
+		byID[shot.ID] = shot
+		ids = append(ids, shot.ID)
+	}
+	if !isPermutation(ids, shotIDs) {
+		return nil, ErrInvalidReorder
+	}
+
+	if err := s.moveShotsAside(ctx, shots); err != nil {
+		return nil, err
+	}
+
+	ordered := make([]*novel.Shot, 0, len(shotIDs))
+	for i, shotID := range shotIDs {
+		shot := byID[shotID]
+		shot.Sequence = i + 1
+		shot.ShotNumber = strconv.Itoa(i + 1)
+		if err := s.shotRepo.Update(ctx, shot.ID, map[string]interface{}{
+			"shot_number": shot.ShotNumber,
+			"sequence":    shot.Sequence,
+		}); err != nil {
+			return nil, fmt.Errorf("update shot %s: %w", shot.ID, err)
+		}
+		ordered = append(ordered, shot)
+	}
+
+	scenes, err := s.sceneRepo.FindByNarrationID(ctx, scene.NarrationID)
+	if err != nil {
+		return nil, fmt.Errorf("find scenes: %w", err)
+	}
+	if err := s.renumberNarrationShots(ctx, scenes); err != nil {
+		return nil, err
+	}
+
+	// 返回带有最新全局索引的镜头
+	shots, err = s.shotRepo.FindBySceneID(ctx, sceneID)
+	if err != nil {
+		return nil, fmt.Errorf("find shots: %w", err)
+	}
+	return shots, nil
+}
+
+// renumberNarrationShots 按场景顺序重新计算所有镜头的场景编号和全局索引，并重新关联已生成的产物
+// scenes 为已按新顺序排列的场景；镜头在场景内按 sequence 排序
+func (s *novelService) renumberNarrationShots(ctx context.Context, scenes []*novel.Scene) error {
+	var shots []*novel.Shot
+	sceneNumbers := make(map[string]string)
+	for _, scene := range scenes {
+		sceneShots, err := s.shotRepo.FindBySceneID(ctx, scene.ID)
+		if err != nil {
+			return fmt.Errorf("find shots for scene %s: %w", scene.ID, err)
+		}
+		for _, shot := range sceneShots {
+			sceneNumbers[shot.ID] = scene.SceneNumber
+			shots = append(shots, shot)
+		}
+	}
+
+	// 场景编号变化时镜头会换到其他场景编号下，同样先移到临时编号；
+	// 上次重排中途失败留下的临时编号按场景内序号恢复
+	moved := false
+	for _, shot := range shots {
+		if strings.HasPrefix(shot.ShotNumber, reorderPrefix) {
+			shot.ShotNumber = strconv.Itoa(shot.Sequence)
+			moved = true
+		}
+		if shot.SceneNumber != sceneNumbers[shot.ID] {
+			moved = true
+		}
+	}
+	if moved {
+		if err := s.moveShotsAside(ctx, shots); err != nil {
+			return err
+		}
+	}
+
+	for i, shot := range shots {
+		shot.SceneNumber = sceneNumbers[shot.ID]
+		shot.Index = i + 1
+		updates := map[string]interface{}{
+			"scene_number": shot.SceneNumber,
+			"index":        shot.Index,
+		}
+		if moved {
+			updates["shot_number"] = shot.ShotNumber
+		}
+		if err := s.shotRepo.Update(ctx, shot.ID, updates); err != nil {
+			return fmt.Errorf("update shot %s: %w", shot.ID, err)
+		}
+	}
+	return s.relinkShotArtifacts(ctx, shots)
+}
+
+// moveShotsAside 把镜头编号移到临时编号
+// 镜头在 (scene_id, shot_number) 和 (chapter_id, version, scene_number, shot_number) 上有唯一索引，逐条写入新编号前需要先让出原编号；
+func (s *novelService) moveShotsAside(ctx context.Context, shots []*novel.Shot) error {
+	for _, shot := range shots {
+		if err := s.shotRepo.Update(ctx, shot.ID, map[string]interface{}{
+			"shot_number": reorderPlaceholder(shot.ID),
+		}); err != nil {
+			return fmt.Errorf("update shot %s: %w", shot.ID, err)
+		}
+	}
+	return nil
+}
+
+// reorderPrefix 重排时临时编号的前缀
+const reorderPrefix = "reorder-"
+
+// reorderPlaceholder 重排时使用的临时编号，按ID保证唯一
+func reorderPlaceholder(id string) string {
+	return reorderPrefix + id
+}
+
+// relinkShotArtifacts 按镜头ID把已生成的图片/音频/字幕/解说视频的位置字段同步为镜头的新位置
+// shots 需按全局索引排序；音频和字幕的 sequence 只对有解说文本的镜头计数，与生成时保持一致
+// 未记录镜头ID的旧数据和合并片段（narration_01-03）无法按镜头重新关联，需要重新生成
+func (s *novelService) relinkShotArtifacts(ctx context.Context, shots []*novel.Shot) error {
+	// 图片在 (chapter_id, scene_number, shot_number) 上有唯一索引，先移到临时编号再写入新编号，避免交换位置时冲突
+	for _, shot := range shots {
+		if err := s.imageRepo.UpdateByShotID(ctx, shot.ID, map[string]interface{}{
+			"shot_number": reorderPlaceholder(shot.ID),
+		}); err != nil {
+			return fmt.Errorf("relink image for shot %s: %w", shot.ID, err)
+		}
+	}
+
+	audioSequence := 0
+	for _, shot := range shots {
+		if err := s.imageRepo.UpdateByShotID(ctx, shot.ID, map[string]interface{}{
+			"scene_number": shot.SceneNumber,
+			"shot_number":  shot.ShotNumber,
+			"sequence":     shot.Index,
+		}); err != nil {
+			return fmt.Errorf("relink image for shot %s: %w", shot.ID, err)
+		}
+		if err := s.videoRepo.UpdateByShotID(ctx, shot.ID, map[string]interface{}{
+			"sequence": shot.Index,
+		}); err != nil {
+			return fmt.Errorf("relink video for shot %s: %w", shot.ID, err)
+		}
+
+		if shot.Narration == "" {
+			continue
+		}
+		audioSequence++
+		if err := s.audioRepo.UpdateByShotID(ctx, shot.ID, map[string]interface{}{
+			"sequence": audioSequence,
+		}); err != nil {
+			return fmt.Errorf("relink audio for shot %s: %w", shot.ID, err)
+		}
+		if err := s.subtitleRepo.UpdateByShotID(ctx, shot.ID, map[string]interface{}{
+			"sequence": audioSequence,
+		}); err != nil {
+			return fmt.Errorf("relink subtitle for shot %s: %w", shot.ID, err)
+		}
+	}
+
+	if len(shots) > 0 {
+		log.Info().
+			Str("narration_id", shots[0].NarrationID).
+			Int("shots", len(shots)).
+			Msg("镜头重排完成，已按镜头ID重新关联产物")
+	}
+	return nil
+}
+
+// findShotImage 查找镜头对应的图片：优先按镜头ID，旧数据（未记录镜头ID）按场景和镜头编号查找
+func (s *novelService) findShotImage(ctx context.Context, chapterID string, shot *novel.Shot, sceneNumber, shotNumber string) (*novel.Image, error) {
+	if shot != nil && shot.ID != "" {
+		image, err := s.imageRepo.FindByShotID(ctx, shot.ID)
+		if err == nil {
+			return image, nil
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+	}
+	return s.imageRepo.FindBySceneAndShot(ctx, chapterID, sceneNumber, shotNumber)
+}
+
+// isPermutation 判断 ordered 是否恰好包含 existing 中的每个ID各一次
+func isPermutation(existing, ordered []string) bool {
+	if len(existing) != len(ordered) {
+		return false
+	}
+	remaining := make(map[string]bool, len(existing))
+	for _, id := range existing {
+		remaining[id] = true
+	}
+	for _, id := range ordered {
+		if !remaining[id] {
+			return false
+		}
+		delete(remaining, id)
+	}
+	return true
+}
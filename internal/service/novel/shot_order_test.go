@@ -0,0 +1,311 @@
+package novel
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	novelrepo "lemon/internal/repository/novel"
+)
+
+// errDuplicateKey 模拟唯一索引冲突（E11000）
+var errDuplicateKey = fmt.Errorf("E11000 duplicate key error")
+
+// idNarrationRepo 按ID返回固定解说的仓库
+type idNarrationRepo struct {
+	novelrepo.NarrationRepository
+	narration *novel.Narration
+}
+
+func (r *idNarrationRepo) FindByID(_ context.Context, id string) (*novel.Narration, error) {
+	if r.narration.ID != id {
+		return nil, mongo.ErrNoDocuments
+	}
+	return r.narration, nil
+}
+
+// memSceneRepo 内存中的场景仓库，按 idx_chapter_version_scene_unique 校验唯一性
+type memSceneRepo struct {
+	novelrepo.SceneRepository
+	scenes map[string]*novel.Scene
+}
+
+func (r *memSceneRepo) FindByID(_ context.Context, id string) (*novel.Scene, error) {
+	scene, ok := r.scenes[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	copied := *scene
+	return &copied, nil
+}
+
+func (r *memSceneRepo) FindByNarrationID(_ context.Context, narrationID string) ([]*novel.Scene, error) {
+	var scenes []*novel.Scene
+	for _, scene := range r.scenes {
+		if scene.NarrationID == narrationID {
+			copied := *scene
+			scenes = append(scenes, &copied)
+		}
+	}
+	sort.Slice(scenes, func(i, j int) bool { return scenes[i].Sequence < scenes[j].Sequence })
+	return scenes, nil
+}
+
+func (r *memSceneRepo) Update(_ context.Context, id string, updates map[string]interface{}) error {
+	updated := *r.scenes[id]
+	for key, value := range updates {
+		switch key {
+		case "scene_number":
+			updated.SceneNumber = value.(string)
+		case "sequence":
+			updated.Sequence = value.(int)
+		}
+	}
+	for _, other := range r.scenes {
+		if other.ID != id && other.ChapterID == updated.ChapterID && other.Version == updated.Version && other.SceneNumber == updated.SceneNumber {
+			return errDuplicateKey
+		}
+	}
+	r.scenes[id] = &updated
+	return nil
+}
+
+// memShotRepo 内存中的镜头仓库，按 idx_scene_shot_unique 和 idx_chapter_version_scene_shot_unique 校验唯一性
+type memShotRepo struct {
+	novelrepo.ShotRepository
+	shots map[string]*novel.Shot
+}
+
+func (r *memShotRepo) FindBySceneID(_ context.Context, sceneID string) ([]*novel.Shot, error) {
+	var shots []*novel.Shot
+	for _, shot := range r.shots {
+		if shot.SceneID == sceneID {
+			copied := *shot
+			shots = append(shots, &copied)
+		}
+	}
+	sort.Slice(shots, func(i, j int) bool { return shots[i].Sequence < shots[j].Sequence })
+	return shots, nil
+}
+
+func (r *memShotRepo) FindByNarrationID(_ context.Context, narrationID string) ([]*novel.Shot, error) {
+	var shots []*novel.Shot
+	for _, shot := range r.shots {
+		if shot.NarrationID == narrationID {
+			copied := *shot
+			shots = append(shots, &copied)
+		}
+	}
+	sort.Slice(shots, func(i, j int) bool { return shots[i].Index < shots[j].Index })
+	return shots, nil
+}
+
+func (r *memShotRepo) Update(_ context.Context, id string, updates map[string]interface{}) error {
+	updated := *r.shots[id]
+	for key, value := range updates {
+		switch key {
+		case "scene_number":
+			updated.SceneNumber = value.(string)
+		case "shot_number":
+			updated.ShotNumber = value.(string)
+		case "sequence":
+			updated.Sequence = value.(int)
+		case "index":
+			updated.Index = value.(int)
+		}
+	}
+	for _, other := range r.shots {
+		if other.ID == id || other.ShotNumber != updated.ShotNumber {
+			continue
+		}
+		if other.SceneID == updated.SceneID ||
+			(other.ChapterID == updated.ChapterID && other.Version == updated.Version && other.SceneNumber == updated.SceneNumber) {
+			return errDuplicateKey
+		}
+	}
+	r.shots[id] = &updated
+	return nil
+}
+
+// shotArtifacts 按镜头ID记录产物的更新
+type shotArtifacts map[string]map[string]interface{}
+
+func (a shotArtifacts) update(shotID string, updates map[string]interface{}) {
+	if a[shotID] == nil {
+		a[shotID] = make(map[string]interface{})
+	}
+	for key, value := range updates {
+		a[shotID][key] = value
+	}
+}
+
+type memShotImageRepo struct {
+	novelrepo.ImageRepository
+	artifacts shotArtifacts
+}
+
+func (r *memShotImageRepo) UpdateByShotID(_ context.Context, shotID string, updates map[string]interface{}) error {
+	r.artifacts.update(shotID, updates)
+	return nil
+}
+
+type memShotVideoRepo struct {
+	novelrepo.VideoRepository
+	artifacts shotArtifacts
+}
+
+func (r *memShotVideoRepo) UpdateByShotID(_ context.Context, shotID string, updates map[string]interface{}) error {
+	r.artifacts.update(shotID, updates)
+	return nil
+}
+
+type memShotAudioRepo struct {
+	novelrepo.AudioRepository
+	artifacts shotArtifacts
+}
+
+func (r *memShotAudioRepo) UpdateByShotID(_ context.Context, shotID string, updates map[string]interface{}) error {
+	r.artifacts.update(shotID, updates)
+	return nil
+}
+
+type memShotSubtitleRepo struct {
+	novelrepo.SubtitleRepository
+	artifacts shotArtifacts
+}
+
+func (r *memShotSubtitleRepo) UpdateByShotID(_ context.Context, shotID string, updates map[string]interface{}) error {
+	r.artifacts.update(shotID, updates)
+	return nil
+}
+
+// shotOrderFixture 解说 narration-1 有两个场景 scene-a、scene-b，每个场景有两个镜头（scene-a-1 ...）
+type shotOrderFixture struct {
+	svc    *novelService
+	scenes *memSceneRepo
+	shots  *memShotRepo
+	images shotArtifacts
+	audios shotArtifacts
+}
+
+func newShotOrderFixture() *shotOrderFixture {
+	f := &shotOrderFixture{
+		scenes: &memSceneRepo{scenes: make(map[string]*novel.Scene)},
+		shots:  &memShotRepo{shots: make(map[string]*novel.Shot)},
+		images: make(shotArtifacts),
+		audios: make(shotArtifacts),
+	}
+	index := 0
+	for i, sceneID := range []string{"scene-a", "scene-b"} {
+		sceneNumber := strconv.Itoa(i + 1)
+		f.scenes.scenes[sceneID] = &novel.Scene{
+			ID: sceneID, NarrationID: "narration-1", ChapterID: "ch-1", Version: 1,
+			SceneNumber: sceneNumber, Sequence: i + 1,
+		}
+		for j := 1; j <= 2; j++ {
+			index++
+			shotID := fmt.Sprintf("%s-%d", sceneID, j)
+			f.shots.shots[shotID] = &novel.Shot{
+				ID: shotID, SceneID: sceneID, NarrationID: "narration-1", ChapterID: "ch-1", Version: 1,
+				SceneNumber: sceneNumber, ShotNumber: strconv.Itoa(j), Sequence: j, Index: index,
+				Narration: "旁白 " + shotID,
+			}
+		}
+	}
+	f.svc = &novelService{
+		narrationRepo: &idNarrationRepo{narration: &novel.Narration{ID: "narration-1", ChapterID: "ch-1"}},
+		sceneRepo:     f.scenes,
+		shotRepo:      f.shots,
+		imageRepo:     &memShotImageRepo{artifacts: f.images},
+		videoRepo:     &memShotVideoRepo{artifacts: make(shotArtifacts)},
+		audioRepo:     &memShotAudioRepo{artifacts: f.audios},
+		subtitleRepo:  &memShotSubtitleRepo{artifacts: make(shotArtifacts)},
+	}
+	return f
+}
+
+// position 返回镜头当前的场景编号、镜头编号和全局索引
+func (f *shotOrderFixture) position(shotID string) string {
+	shot := f.shots.shots[shotID]
+	return fmt.Sprintf("%s/%s/%d", shot.SceneNumber, shot.ShotNumber, shot.Index)
+}
+
+func TestReorderScenesSwapsScenes(t *testing.T) {
+	f := newShotOrderFixture()
+
+	scenes, err := f.svc.ReorderScenes(context.Background(), "narration-1", []string{"scene-b", "scene-a"})
+	if err != nil {
+		t.Fatalf("ReorderScenes() error = %v", err)
+	}
+	if scenes[0].ID != "scene-b" || scenes[0].SceneNumber != "1" || scenes[1].SceneNumber != "2" {
+		t.Errorf("scenes = %s/%s, %s/%s, want scene-b/1, scene-a/2", scenes[0].ID, scenes[0].SceneNumber, scenes[1].ID, scenes[1].SceneNumber)
+	}
+	if got := f.scenes.scenes["scene-a"].SceneNumber; got != "2" {
+		t.Errorf("scene-a number = %s, want 2", got)
+	}
+
+	// 镜头跟随场景换到新的场景编号下，镜头编号不变，全局索引按新顺序
+	for shotID, want := range map[string]string{
+		"scene-b-1": "1/1/1",
+		"scene-b-2": "1/2/2",
+		"scene-a-1": "2/1/3",
+		"scene-a-2": "2/2/4",
+	} {
+		if got := f.position(shotID); got != want {
+			t.Errorf("shot %s position = %s, want %s", shotID, got, want)
+		}
+	}
+	if got := f.images["scene-a-1"]; got["scene_number"] != "2" || got["shot_number"] != "1" || got["sequence"] != 3 {
+		t.Errorf("scene-a-1 image = %v, want relinked to 2/1 at sequence 3", got)
+	}
+	if got := f.audios["scene-b-1"]["sequence"]; got != 1 {
+		t.Errorf("scene-b-1 audio sequence = %v, want 1", got)
+	}
+}
+
+func TestReorderShotsSwapsShots(t *testing.T) {
+	f := newShotOrderFixture()
+
+	shots, err := f.svc.ReorderShots(context.Background(), "scene-b", []string{"scene-b-2", "scene-b-1"})
+	if err != nil {
+		t.Fatalf("ReorderShots() error = %v", err)
+	}
+	if shots[0].ID != "scene-b-2" || shots[0].ShotNumber != "1" || shots[0].Index != 3 {
+		t.Errorf("first shot = %s/%s/%d, want scene-b-2/1/3", shots[0].ID, shots[0].ShotNumber, shots[0].Index)
+	}
+	for shotID, want := range map[string]string{
+		"scene-a-1": "1/1/1",
+		"scene-a-2": "1/2/2",
+		"scene-b-2": "2/1/3",
+		"scene-b-1": "2/2/4",
+	} {
+		if got := f.position(shotID); got != want {
+			t.Errorf("shot %s position = %s, want %s", shotID, got, want)
+		}
+	}
+	if got := f.images["scene-b-1"]; got["shot_number"] != "2" || got["sequence"] != 4 {
+		t.Errorf("scene-b-1 image = %v, want relinked to shot 2 at sequence 4", got)
+	}
+}
+
+func TestReorderScenesRecoversPlaceholderNumbers(t *testing.T) {
+	f := newShotOrderFixture()
+	// 上次重排在镜头移到临时编号后中断
+	for _, shot := range f.shots.shots {
+		shot.ShotNumber = reorderPlaceholder(shot.ID)
+	}
+	f.scenes.scenes["scene-a"].SceneNumber = reorderPlaceholder("scene-a")
+	f.scenes.scenes["scene-b"].SceneNumber = reorderPlaceholder("scene-b")
+
+	if _, err := f.svc.ReorderScenes(context.Background(), "narration-1", []string{"scene-b", "scene-a"}); err != nil {
+		t.Fatalf("ReorderScenes() error = %v", err)
+	}
+	if got := f.position("scene-a-2"); got != "2/2/4" {
+		t.Errorf("shot scene-a-2 position = %s, want 2/2/4", got)
+	}
+}
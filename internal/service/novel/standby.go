@@ -0,0 +1,345 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+)
+
+var (
+	// ErrInvalidStandby 预生成请求不合法（阶段不支持）
+	ErrInvalidStandby = errors.New("invalid standby request")
+	// ErrNoNextChapter 章节已经是最后一章，没有可以预生成的下一章
+	ErrNoNextChapter = errors.New("no next chapter to prepare")
+	// ErrStandbyNotRunning 预生成任务已经结束，不能取消
+	ErrStandbyNotRunning = errors.New("standby job is not running")
+)
+
+// StandbyService 下一章预生成服务接口
+// 编辑审核第 N 章时，为第 N+1 章预生成章节摘要和解说草稿（包含镜头图片提示词），不调用付费的图片/视频生成
+type StandbyService interface {
+	// SetStandbySettings 设置小说的预生成配置（是否在最终视频生成完成后自动预生成下一章、预生成哪些阶段）
+	SetStandbySettings(ctx context.Context, novelID string, settings *novel.StandbySettings) error
+
+	// StartStandby 为 chapterID 的下一章启动预生成，stages 为空时使用小说配置的阶段
+	// 下一章已有执行中的预生成任务时直接返回该任务
+	StartStandby(ctx context.Context, chapterID string, stages []novel.StandbyStage, userID string) (*novel.StandbyJob, error)
+
+	// GetStandbyJob 查询预生成任务
+	GetStandbyJob(ctx context.Context, jobID string) (*novel.StandbyJob, error)
+
+	// ListStandbyJobs 查询小说的预生成任务（按创建时间倒序）
+	ListStandbyJobs(ctx context.Context, novelID string, limit int) ([]*novel.StandbyJob, error)
+
+	// CancelStandbyJob 取消执行中的预生成任务：正在执行的阶段被中断，之后的阶段不再执行
+	CancelStandbyJob(ctx context.Context, jobID string) (*novel.StandbyJob, error)
+
+	// FailInterruptedStandbyJobs 服务启动时把重启前执行中的预生成任务标记为失败
+	FailInterruptedStandbyJobs(ctx context.Context) error
+}
+
+// runCancels 本实例执行中的任务（任务ID -> 取消函数），用于预生成任务和任务队列中的任务
+type runCancels struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+func newRunCancels() *runCancels {
+	return &runCancels{cancels: make(map[string]context.CancelFunc)}
+}
+
+func (r *runCancels) add(jobID string, cancel context.CancelFunc) {
+	r.mu.Lock()
+	r.cancels[jobID] = cancel
+	r.mu.Unlock()
+}
+
+func (r *runCancels) remove(jobID string) {
+	r.mu.Lock()
+	delete(r.cancels, jobID)
+	r.mu.Unlock()
+}
+
+// cancel 取消本实例上执行中的任务，返回任务是否在本实例上执行
+func (r *runCancels) cancel(jobID string) bool {
+	r.mu.Lock()
+	cancel, ok := r.cancels[jobID]
+	r.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// SetStandbySettings 设置小说的预生成配置
+func (s *novelService) SetStandbySettings(ctx context.Context, novelID string, settings *novel.StandbySettings) error {
+	stages, err := normalizeStandbyStages(settings.Stages)
+	if err != nil {
+		return err
+	}
+	if _, err := s.novelRepo.FindByID(ctx, novelID); err != nil {
+		return fmt.Errorf("find novel: %w", err)
+	}
+	settings.Stages = stages
+	if err := s.novelRepo.Update(ctx, novelID, bson.M{"standby": settings}); err != nil {
+		return fmt.Errorf("update standby settings: %w", err)
+	}
+	return nil
+}
+
+// StartStandby 为下一章启动预生成
+func (s *novelService) StartStandby(ctx context.Context, chapterID string, stages []novel.StandbyStage, userID string) (*novel.StandbyJob, error) {
+	stages, err := normalizeStandbyStages(stages)
+	if err != nil {
+		return nil, err
+	}
+
+	chapter, err := s.chapterRepo.FindByID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+	n, err := s.novelRepo.FindByID(ctx, chapter.NovelID)
+	if err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+	if len(stages) == 0 && n.Standby != nil {
+		stages = n.Standby.Stages
+	}
+	if len(stages) == 0 {
+		stages = novel.AllStandbyStages
+	}
+
+	next, err := s.nextChapter(ctx, chapter)
+	if err != nil {
+		return nil, err
+	}
+	if running, err := s.standbyJobRepo.FindRunningByChapterID(ctx, next.ID); err == nil {
+		return running, nil
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("find running standby job: %w", err)
+	}
+
+	job := &novel.StandbyJob{
+		ID:              id.New(),
+		NovelID:         chapter.NovelID,
+		SourceChapterID: chapter.ID,
+		ChapterID:       next.ID,
+		Stages:          stages,
+		TriggeredBy:     userID,
+		Status:          novel.StandbyStatusRunning,
+		StageResults:    []novel.StandbyStageResult{},
+	}
+	if err := s.standbyJobRepo.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("create standby job: %w", err)
+	}
+
+	// 预生成在本实例上执行，不进入任务队列：只生成章节摘要和解说草稿（不调用付费的图片/视频生成），
+	// 各阶段的结果随执行写入预生成任务，服务重启时执行中的任务由 FailInterruptedStandbyJobs 标记为失败，
+	// 再次启动时产物已存在的阶段直接跳过；任务队列的任务按流程阶段（PipelineStage）执行，不包含预生成
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	runCtx, endReap := s.procReaper.Begin(runCtx, job.ID)
+	s.standbyRuns.add(job.ID, cancel)
+	go func() {
+		defer cancel()
+		defer endReap()
+		defer s.standbyRuns.remove(job.ID)
+		s.runStandbyJob(runCtx, job)
+	}()
+
+	log.Info().
+		Str("job_id", job.ID).
+		Str("source_chapter_id", chapter.ID).
+		Str("chapter_id", next.ID).
+		Interface("stages", stages).
+		Msg("下一章预生成已开始")
+	return job, nil
+}
+
+// maybeStartStandby 第 N 章最终视频生成完成后，小说开启了自动预生成时为第 N+1 章启动预生成
+// 启动失败只记录日志，不影响最终视频的结果
+func (s *novelService) maybeStartStandby(ctx context.Context, chapterID string) {
+	chapter, err := s.chapterRepo.FindByID(ctx, chapterID)
+	if err != nil || chapter.IsBranch() {
+		return
+	}
+	n, err := s.novelRepo.FindByID(ctx, chapter.NovelID)
+	if err != nil || n.Standby == nil || !n.Standby.Enabled {
+		return
+	}
+	if _, err := s.StartStandby(ctx, chapterID, nil, ""); err != nil && !errors.Is(err, ErrNoNextChapter) {
+		log.Warn().Err(err).Str("chapter_id", chapterID).Msg("自动预生成下一章失败")
+	}
+}
+
+// nextChapter 查询章节的下一章（分支按所属主章节的序号查找）
+func (s *novelService) nextChapter(ctx context.Context, chapter *novel.Chapter) (*novel.Chapter, error) {
+	chapters, err := s.chapterRepo.FindByNovelID(ctx, chapter.NovelID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapters: %w", err)
+	}
+	for _, ch := range chapters {
+		if ch.Sequence > chapter.Sequence {
+			return ch, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: chapter %d is the last chapter", ErrNoNextChapter, chapter.Sequence)
+}
+
+// runStandbyJob 按顺序执行预生成阶段，产物已存在的阶段跳过；任务被取消或某个阶段失败时停止
+func (s *novelService) runStandbyJob(ctx context.Context, job *novel.StandbyJob) {
+	status := novel.StandbyStatusCompleted
+	errMsg := ""
+	for _, stage := range job.Stages {
+		if ctx.Err() != nil || s.standbyCanceled(ctx, job.ID) {
+			status = novel.StandbyStatusCanceled
+			break
+		}
+
+		startedAt := time.Now()
+		result, narrationID, err := s.runStandbyStage(ctx, job.ChapterID, stage)
+		result.DurationMs = time.Since(startedAt).Milliseconds()
+		if err != nil {
+			result.Status = novel.StandbyStatusFailed
+			result.Reason = err.Error()
+			if ctx.Err() != nil {
+				result.Status = novel.StandbyStatusCanceled
+			}
+		}
+		if appendErr := s.standbyJobRepo.AppendStageResult(context.WithoutCancel(ctx), job.ID, result, narrationID); appendErr != nil {
+			log.Error().Err(appendErr).Str("job_id", job.ID).Str("stage", string(stage)).Msg("记录预生成阶段结果失败")
+		}
+		if err != nil {
+			status = result.Status
+			if status == novel.StandbyStatusFailed {
+				errMsg = fmt.Sprintf("stage %s: %s", stage, err.Error())
+			}
+			break
+		}
+	}
+
+	// 任务已被取消时保留取消状态
+	if _, err := s.standbyJobRepo.Finish(context.WithoutCancel(ctx), job.ID, status, errMsg); err != nil {
+		log.Error().Err(err).Str("job_id", job.ID).Msg("记录预生成结果失败")
+	}
+	log.Info().
+		Str("job_id", job.ID).
+		Str("chapter_id", job.ChapterID).
+		Str("status", string(status)).
+		Msg("下一章预生成结束")
+}
+
+// standbyCanceled 任务是否已被取消（在其他实例上取消时只能通过任务状态得知）
+func (s *novelService) standbyCanceled(ctx context.Context, jobID string) bool {
+	job, err := s.standbyJobRepo.FindByID(ctx, jobID)
+	if err != nil {
+		log.Warn().Err(err).Str("job_id", jobID).Msg("查询预生成任务状态失败，继续执行")
+		return false
+	}
+	return job.Status != novel.StandbyStatusRunning
+}
+
+// runStandbyStage 执行单个预生成阶段，返回阶段结果和生成的解说ID
+func (s *novelService) runStandbyStage(ctx context.Context, chapterID string, stage novel.StandbyStage) (novel.StandbyStageResult, string, error) {
+	result := novel.StandbyStageResult{Stage: stage, Status: novel.StandbyStatusCompleted}
+	switch stage {
+	case novel.StandbyStageSummary:
+		view, err := s.GetChapterSummary(ctx, chapterID)
+		if err != nil {
+			return result, "", err
+		}
+		if view.Cached {
+			result.Status = novel.StandbyStatusSkipped
+			result.Reason = "章节摘要已是最新"
+		}
+		return result, "", nil
+	case novel.StandbyStageNarration:
+		existing, err := s.narrationRepo.FindByChapterID(ctx, chapterID)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return result, "", fmt.Errorf("find narration: %w", err)
+		}
+		if existing != nil && existing.Status != novel.TaskStatusFailed {
+			result.Status = novel.StandbyStatusSkipped
+			result.Reason = fmt.Sprintf("章节已有解说（版本 %d）", existing.Version)
+			return result, existing.ID, nil
+		}
+		narration, _, err := s.generateNarrationForChapter(ctx, chapterID)
+		if err != nil {
+			return result, "", err
+		}
+		return result, narration.ID, nil
+	default:
+		return result, "", fmt.Errorf("%w: unknown stage %s", ErrInvalidStandby, stage)
+	}
+}
+
+// GetStandbyJob 查询预生成任务
+func (s *novelService) GetStandbyJob(ctx context.Context, jobID string) (*novel.StandbyJob, error) {
+	job, err := s.standbyJobRepo.FindByID(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("find standby job: %w", err)
+	}
+	return job, nil
+}
+
+// ListStandbyJobs 查询小说的预生成任务
+func (s *novelService) ListStandbyJobs(ctx context.Context, novelID string, limit int) ([]*novel.StandbyJob, error) {
+	return s.standbyJobRepo.FindByNovelID(ctx, novelID, limit)
+}
+
+// CancelStandbyJob 取消预生成任务
+// 先把任务标记为已取消（多实例部署时其他实例上的任务在下一个阶段开始前停止），再中断本实例上正在执行的阶段
+func (s *novelService) CancelStandbyJob(ctx context.Context, jobID string) (*novel.StandbyJob, error) {
+	ok, err := s.standbyJobRepo.Finish(ctx, jobID, novel.StandbyStatusCanceled, "")
+	if err != nil {
+		return nil, fmt.Errorf("cancel standby job: %w", err)
+	}
+	if !ok {
+		if _, err := s.standbyJobRepo.FindByID(ctx, jobID); err != nil {
+			return nil, fmt.Errorf("find standby job: %w", err)
+		}
+		return nil, ErrStandbyNotRunning
+	}
+	s.standbyRuns.cancel(jobID)
+
+	log.Info().Str("job_id", jobID).Msg("下一章预生成已取消")
+	return s.GetStandbyJob(ctx, jobID)
+}
+
+// FailInterruptedStandbyJobs 把重启前执行中的预生成任务标记为失败，需要时重新启动
+func (s *novelService) FailInterruptedStandbyJobs(ctx context.Context) error {
+	n, err := s.standbyJobRepo.FailRunning(ctx, "interrupted by server restart")
+	if err != nil {
+		return fmt.Errorf("fail interrupted standby jobs: %w", err)
+	}
+	if n > 0 {
+		log.Info().Int64("jobs", n).Msg("已标记中断的预生成任务")
+	}
+	return nil
+}
+
+// normalizeStandbyStages 校验预生成阶段并按执行顺序去重
+func normalizeStandbyStages(stages []novel.StandbyStage) ([]novel.StandbyStage, error) {
+	selected := make(map[novel.StandbyStage]bool, len(stages))
+	for _, stage := range stages {
+		if !stage.IsValid() {
+			return nil, fmt.Errorf("%w: unknown stage %q", ErrInvalidStandby, stage)
+		}
+		selected[stage] = true
+	}
+	var ordered []novel.StandbyStage
+	for _, stage := range novel.AllStandbyStages {
+		if selected[stage] {
+			ordered = append(ordered, stage)
+		}
+	}
+	return ordered, nil
+}
@@ -0,0 +1,74 @@
+package novel
+
+import (
+	"context"
+	"strconv"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/storage"
+)
+
+// 存储路径模板中的产物类型（{artifact_type}），同时是 storage.key_templates 的配置键
+const (
+	artifactAudio           = "audio"            // 解说音频
+	artifactSubtitle        = "subtitle"         // 字幕
+	artifactImage           = "image"            // 分镜图片
+	artifactCharacterImage  = "character_image"  // 角色图片
+	artifactSceneImage      = "scene_image"      // 场景图片
+	artifactSceneVariant    = "scene_variant"    // 场景重打光变体图片
+	artifactPropImage       = "prop_image"       // 道具图片
+	artifactClip            = "clip"             // 分镜视频片段
+	artifactFinalVideo      = "final_video"      // 最终视频
+	artifactManifest        = "manifest"         // 流水线清单
+	artifactLastFrame       = "last_frame"       // 章节衔接用的最后一帧截图
+	artifactBGM             = "bgm"              // 背景音乐
+	artifactStyleReference  = "style_reference"  // 风格参考图
+	artifactCover           = "cover"            // 小说封面
+	artifactCompilation     = "compilation"      // 跨章节回顾合集视频
+	artifactNovelVideo      = "novel_video"      // 多个章节拼接的长视频
+	artifactAnalyticsExport = "analytics_export" // 分析数据导出文件（JSON Lines）
+)
+
+// chapterKeyVars 章节产物的存储路径模板变量
+// 每个版本只有一个的产物（最终视频、清单等）seq 传 1
+// 章节分支与主章节序号相同、版本号独立分配，不带章节序号，避免按序号组织的路径覆盖主章节的文件
+func chapterKeyVars(chapter *novel.Chapter, artifactType string, version, seq int) *storage.KeyVars {
+	vars := &storage.KeyVars{
+		NovelID:      chapter.NovelID,
+		ChapterID:    chapter.ID,
+		ChapterSeq:   chapter.Sequence,
+		ArtifactType: artifactType,
+		Version:      version,
+		Seq:          seq,
+	}
+	if chapter.IsBranch() {
+		vars.ChapterSeq = 0
+	}
+	return vars
+}
+
+// chapterKeyVarsByID 按章节ID查询章节后生成存储路径模板变量
+// 查询失败时不带章节序号，引用 {chapter_seq} 的模板会回退到默认模板
+func (s *novelService) chapterKeyVarsByID(ctx context.Context, novelID, chapterID, artifactType string, version, seq int) *storage.KeyVars {
+	if chapter, err := s.chapterRepo.FindByID(ctx, chapterID); err == nil {
+		return chapterKeyVars(chapter, artifactType, version, seq)
+	}
+	return &storage.KeyVars{
+		NovelID:      novelID,
+		ChapterID:    chapterID,
+		ArtifactType: artifactType,
+		Version:      version,
+		Seq:          seq,
+	}
+}
+
+// sceneSequence 场景编号转换为序号（编号不是数字时返回 0，引用 {seq} 的模板会回退到默认模板）
+func sceneSequence(scene *novel.Scene) int {
+	n, _ := strconv.Atoi(scene.SceneNumber)
+	return n
+}
+
+// novelKeyVars 小说级产物（角色、道具等）的存储路径模板变量
+func novelKeyVars(novelID, artifactType string) *storage.KeyVars {
+	return &storage.KeyVars{NovelID: novelID, ArtifactType: artifactType}
+}
@@ -0,0 +1,226 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/killswitch"
+	"lemon/internal/pkg/noveltools"
+	"lemon/internal/service"
+)
+
+// maxStyleReferences 单次生成最多使用的风格参考图数量（超出时取最早上传的几张）
+const maxStyleReferences = 4
+
+var (
+	// ErrInvalidStyleReference 风格参考图不是图片文件
+	ErrInvalidStyleReference = errors.New("style reference must be an image")
+	// ErrChapterNotInNovel 章节不属于该小说
+	ErrChapterNotInNovel = errors.New("chapter does not belong to novel")
+)
+
+// StyleReferenceService 风格参考图服务接口
+type StyleReferenceService interface {
+	// CreateStyleReference 上传风格参考图（小说级或章节级）
+	CreateStyleReference(ctx context.Context, req *CreateStyleReferenceRequest) (*novel.StyleReference, error)
+
+	// ListStyleReferences 查询风格参考图，chapterID 为空时返回小说的所有参考图（包含章节级）
+	ListStyleReferences(ctx context.Context, novelID, chapterID string) ([]*novel.StyleReference, error)
+
+	// DeleteStyleReference 删除风格参考图（已生成图片上记录的参考图ID不受影响）
+	DeleteStyleReference(ctx context.Context, referenceID string) error
+}
+
+// CreateStyleReferenceRequest 上传风格参考图请求
+type CreateStyleReferenceRequest struct {
+	NovelID     string    // 小说ID
+	ChapterID   string    // 章节ID（为空表示小说级）
+	UserID      string    // 上传用户ID
+	Description string    // 说明
+	FileName    string    // 文件名
+	ContentType string    // MIME类型（必须为 image/*）
+	Data        io.Reader // 文件数据
+}
+
+// CreateStyleReference 上传风格参考图
+func (s *novelService) CreateStyleReference(ctx context.Context, req *CreateStyleReferenceRequest) (*novel.StyleReference, error) {
+	if !strings.HasPrefix(req.ContentType, "image/") {
+		return nil, ErrInvalidStyleReference
+	}
+
+	if _, err := s.novelRepo.FindByID(ctx, req.NovelID); err != nil {
+		return nil, fmt.Errorf("find novel: %w", err)
+	}
+	if req.ChapterID != "" {
+		chapter, err := s.chapterRepo.FindByID(ctx, req.ChapterID)
+		if err != nil {
+			return nil, fmt.Errorf("find chapter: %w", err)
+		}
+		if chapter.NovelID != req.NovelID {
+			return nil, ErrChapterNotInNovel
+		}
+	}
+
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(req.FileName)), ".")
+	if ext == "" {
+		ext = strings.TrimPrefix(req.ContentType, "image/")
+	}
+
+	keyVars := novelKeyVars(req.NovelID, artifactStyleReference)
+	if req.ChapterID != "" {
+		keyVars = s.chapterKeyVarsByID(ctx, req.NovelID, req.ChapterID, artifactStyleReference, 0, 0)
+	}
+	uploadResult, err := s.resourceService.UploadFile(ctx, &service.UploadFileRequest{
+		UserID:      req.UserID,
+		FileName:    req.FileName,
+		ContentType: req.ContentType,
+		Ext:         ext,
+		Data:        req.Data,
+		KeyVars:     keyVars,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("upload style reference: %w", err)
+	}
+
+	ref := &novel.StyleReference{
+		ID:          id.New(),
+		NovelID:     req.NovelID,
+		ChapterID:   req.ChapterID,
+		UserID:      req.UserID,
+		ResourceID:  uploadResult.ResourceID,
+		Description: req.Description,
+	}
+	if err := s.styleReferenceRepo.Create(ctx, ref); err != nil {
+		return nil, fmt.Errorf("create style reference: %w", err)
+	}
+
+	log.Info().
+		Str("style_reference_id", ref.ID).
+		Str("novel_id", ref.NovelID).
+		Str("chapter_id", ref.ChapterID).
+		Msg("风格参考图上传成功")
+
+	return ref, nil
+}
+
+// ListStyleReferences 查询风格参考图
+func (s *novelService) ListStyleReferences(ctx context.Context, novelID, chapterID string) ([]*novel.StyleReference, error) {
+	if chapterID == "" {
+		return s.styleReferenceRepo.FindByNovelID(ctx, novelID)
+	}
+	return s.styleReferenceRepo.FindByChapterID(ctx, novelID, chapterID)
+}
+
+// DeleteStyleReference 删除风格参考图
+func (s *novelService) DeleteStyleReference(ctx context.Context, referenceID string) error {
+	if _, err := s.styleReferenceRepo.FindByID(ctx, referenceID); err != nil {
+		return fmt.Errorf("find style reference: %w", err)
+	}
+	return s.styleReferenceRepo.Delete(ctx, referenceID)
+}
+
+// styleReferenceSet 生成图片时使用的风格参考图（已下载）
+type styleReferenceSet struct {
+	ids    []string
+	images [][]byte
+}
+
+// loadStyleReferences 加载生成图片时使用的风格参考图
+// 章节设置了参考图时使用章节级参考图，否则使用小说级参考图；chapterID 为空时只使用小说级参考图
+// 图片提供者不支持风格参考图、没有参考图或加载失败时返回 nil（降级为普通文生图，不影响生成）
+func (s *novelService) loadStyleReferences(ctx context.Context, novelID, chapterID string) *styleReferenceSet {
+	if _, ok := s.imageProvider.(noveltools.StyleReferenceProvider); !ok {
+		return nil
+	}
+
+	var refs []*novel.StyleReference
+	var err error
+	if chapterID != "" {
+		refs, err = s.styleReferenceRepo.FindByChapterID(ctx, novelID, chapterID)
+	}
+	if err == nil && len(refs) == 0 {
+		refs, err = s.styleReferenceRepo.FindByChapterID(ctx, novelID, "")
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("novel_id", novelID).Str("chapter_id", chapterID).Msg("查询风格参考图失败，不使用参考图")
+		return nil
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+	if len(refs) > maxStyleReferences {
+		refs = refs[:maxStyleReferences]
+	}
+
+	set := &styleReferenceSet{}
+	for _, ref := range refs {
+		data, err := s.downloadStyleReference(ctx, ref)
+		if err != nil {
+			log.Warn().Err(err).Str("style_reference_id", ref.ID).Msg("下载风格参考图失败，跳过该参考图")
+			continue
+		}
+		set.ids = append(set.ids, ref.ID)
+		set.images = append(set.images, data)
+	}
+	if len(set.ids) == 0 {
+		return nil
+	}
+	return set
+}
+
+// downloadStyleReference 下载风格参考图数据
+func (s *novelService) downloadStyleReference(ctx context.Context, ref *novel.StyleReference) ([]byte, error) {
+	downloadResult, err := s.resourceService.DownloadFile(ctx, &service.DownloadFileRequest{
+		ResourceID: ref.ResourceID,
+		UserID:     ref.UserID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("download style reference: %w", err)
+	}
+	defer downloadResult.Data.Close()
+
+	return io.ReadAll(downloadResult.Data)
+}
+
+// generateImageWithStyle 生成图片，有风格参考图时带上参考图
+// 返回图片数据、实际使用的风格参考图ID（未使用参考图时为 nil）和本地化后的提示词（提供者偏好中文时为空）；
+// 生成前检查小说预算并替换提示词中的小说变量，提供者偏好非中文提示词时先翻译并追加风格关键词，生成成功后记录费用
+func (s *novelService) generateImageWithStyle(ctx context.Context, novelID, chapterID, prompt, filename string, refs *styleReferenceSet) ([]byte, []string, string, error) {
+	if err := s.checkBudget(ctx, novelID); err != nil {
+		return nil, nil, "", err
+	}
+	prompt = s.renderNovelPrompt(ctx, novelID, prompt)
+	ctx = s.withImageSteps(ctx, novelID)
+
+	var localizedPrompt string
+	if providerPrompt := s.localizeImagePrompt(ctx, novelID, chapterID, prompt); providerPrompt != prompt {
+		localizedPrompt = providerPrompt
+		prompt = providerPrompt
+	}
+
+	if refs != nil {
+		if provider, ok := s.imageProvider.(noveltools.StyleReferenceProvider); ok {
+			imageData, err := provider.GenerateImageWithStyleReferences(ctx, prompt, refs.images, filename)
+			if err != nil {
+				return nil, nil, "", err
+			}
+			s.recordCost(ctx, novelID, chapterID, killswitch.ProviderImage, 1, s.pricing.Image(1))
+			return imageData, refs.ids, localizedPrompt, nil
+		}
+	}
+
+	imageData, err := s.imageProvider.GenerateImage(ctx, prompt, filename)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	s.recordCost(ctx, novelID, chapterID, killswitch.ProviderImage, 1, s.pricing.Image(1))
+	return imageData, nil, localizedPrompt, nil
+}
@@ -27,6 +27,12 @@ type SubtitleService interface {
 
 	// ListSubtitlesByNarration 获取解说的字幕列表（可指定版本；version<=0 则取最新版本）
 	ListSubtitlesByNarration(ctx context.Context, narrationID string, version int) ([]*novel.Subtitle, int, error)
+
+	// ImportSubtitles 导入外部字幕文件（SRT/ASS），按章节音频片段重新计时后保存为新的字幕版本
+	ImportSubtitles(ctx context.Context, req *ImportSubtitlesRequest) (*ImportSubtitlesResult, error)
+
+	// SelectSubtitleVersion 选择视频阶段使用的字幕版本（version 为 0 时恢复使用最新生成的字幕）
+	SelectSubtitleVersion(ctx context.Context, chapterID string, version int) error
 }
 
 // GenerateSubtitlesForNarration 为章节解说生成所有字幕文件（ASS格式）
@@ -41,6 +47,18 @@ type SubtitleService interface {
 //   - []string: 生成的章节字幕ID列表
 //   - error: 错误信息
 func (s *novelService) GenerateSubtitlesForNarration(ctx context.Context, narrationID string) ([]string, error) {
+	var ids []string
+	err := s.runNarrationStage(ctx, narrationID, novel.PipelineStageSubtitle, func(ctx context.Context) error {
+		var err error
+		ids, err = s.generateSubtitlesForNarration(ctx, narrationID)
+		setStageResources(ctx, ids...)
+		return err
+	})
+	return ids, err
+}
+
+// generateSubtitlesForNarration 生成字幕文件（阶段耗时与费用由 GenerateSubtitlesForNarration 记录）
+func (s *novelService) generateSubtitlesForNarration(ctx context.Context, narrationID string) ([]string, error) {
 	// 1. 从数据库获取章节解说
 	narration, err := s.narrationRepo.FindByID(ctx, narrationID)
 	if err != nil {
@@ -48,7 +66,7 @@ func (s *novelService) GenerateSubtitlesForNarration(ctx context.Context, narrat
 	}
 
 	// 2. 自动生成下一个版本号（基于章节ID，独立递增）
-	subtitleVersion, err := s.getNextSubtitleVersion(ctx, narration.ChapterID, 0)
+	subtitleVersion, err := s.nextVersion(ctx, narration.ChapterID, novel.VersionArtifactSubtitle)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get next subtitle version: %w", err)
 	}
@@ -104,7 +122,10 @@ func (s *novelService) GenerateSubtitlesForNarration(ctx context.Context, narrat
 		return nil, fmt.Errorf("no narration texts found")
 	}
 
-	// 6. 为每个音频片段生成对应的字幕文件
+	// 6. 为每个音频片段生成对应的字幕文件（数字写法与音频保持一致）
+	numberStyle := s.novelNumberStyle(ctx, narration.NovelID)
+	// 字幕样式和片头片尾文案按小说的渲染设置，片头显示在第一段字幕、片尾显示在最后一段字幕
+	style, introText, outroText := s.novelSubtitleCards(ctx, narration.NovelID)
 	var subtitleIDs []string
 	for i, audio := range audios {
 		sequence := audio.Sequence
@@ -126,7 +147,15 @@ func (s *novelService) GenerateSubtitlesForNarration(ctx context.Context, narrat
 		}
 
 		// 生成单个字幕文件
-		subtitleID, err := s.generateSingleSubtitle(ctx, narration, audio, sequence, narrationText, subtitleVersion)
+		narrationText = noveltools.NormalizeNumbers(narrationText, numberStyle)
+		cards := subtitleCards{style: style}
+		if i == 0 {
+			cards.intro = introText
+		}
+		if i == len(audios)-1 {
+			cards.outro = outroText
+		}
+		subtitleID, err := s.generateSingleSubtitle(ctx, narration, audio, sequence, narrationText, subtitleVersion, cards)
 		if err != nil {
 			log.Error().Err(err).Int("sequence", sequence).Msg("生成字幕失败")
 			return nil, fmt.Errorf("failed to generate subtitle for sequence %d: %w", sequence, err)
@@ -138,6 +167,25 @@ func (s *novelService) GenerateSubtitlesForNarration(ctx context.Context, narrat
 	return subtitleIDs, nil
 }
 
+// subtitleCards 单个字幕文件的样式和片头/片尾文案
+type subtitleCards struct {
+	style *novel.SubtitleStyle // 字幕样式，为空时使用默认样式
+	intro string               // 片头文案（只在第一段字幕显示）
+	outro string               // 片尾文案（只在最后一段字幕显示）
+}
+
+// novelSubtitleCards 按小说的渲染设置返回字幕样式和片头/片尾文案（文案中的变量已替换）
+func (s *novelService) novelSubtitleCards(ctx context.Context, novelID string) (*novel.SubtitleStyle, string, string) {
+	render := s.novelRenderSettings(ctx, novelID)
+	var introText, outroText string
+	if render.IntroText != "" || render.OutroText != "" {
+		vars := s.novelPromptVariables(ctx, novelID)
+		introText = noveltools.RenderPromptVariables(render.IntroText, vars)
+		outroText = noveltools.RenderPromptVariables(render.OutroText, vars)
+	}
+	return render.Subtitle, introText, outroText
+}
+
 // generateSingleSubtitle 为单个音频片段生成字幕文件
 func (s *novelService) generateSingleSubtitle(
 	ctx context.Context,
@@ -146,6 +194,7 @@ func (s *novelService) generateSingleSubtitle(
 	sequence int,
 	narrationText string,
 	version int,
+	cards subtitleCards,
 ) (string, error) {
 	// 1. 检查音频是否有时间戳数据
 	if len(audio.Timestamps) == 0 {
@@ -204,8 +253,9 @@ func (s *novelService) generateSingleSubtitle(
 	// 调整字幕时间戳，确保不超过音频时长
 	segmentTimestamps = adjustSubtitleTimestampsToAudioDuration(segmentTimestamps, audioDuration)
 
-	// 5. 使用 ASSGenerator 生成 ASS 内容
-	assGenerator := noveltools.NewASSGenerator()
+	// 5. 使用 ASSGenerator 生成 ASS 内容（按小说的字幕样式，片头/片尾文案显示在开头和结尾）
+	assGenerator := noveltools.NewASSGeneratorWithStyle(cards.style)
+	assGenerator.SetCards(cards.intro, cards.outro, audioDuration)
 	title := fmt.Sprintf("Narration Subtitle %d", sequence)
 	assContent := assGenerator.GenerateASSContent(segmentTimestamps, title)
 
@@ -225,6 +275,7 @@ func (s *novelService) generateSingleSubtitle(
 		ContentType: contentType,
 		Ext:         ext,
 		Data:        assReader,
+		KeyVars:     s.chapterKeyVarsByID(ctx, narration.NovelID, narration.ChapterID, artifactSubtitle, version, sequence),
 	}
 
 	uploadResult, err := s.resourceService.UploadFile(ctx, uploadReq)
@@ -251,6 +302,7 @@ func (s *novelService) generateSingleSubtitle(
 		NarrationID: narration.ID,
 		NovelID:     chapter.NovelID,
 		UserID:      narration.UserID,
+		ShotID:      audio.ShotID,
 		Sequence:           sequence,
 		SubtitleResourceID: resourceID,
 		Format:             novel.SubtitleFormatASS,
@@ -316,51 +368,3 @@ func adjustSubtitleTimestampsToAudioDuration(segmentTimestamps []noveltools.Segm
 	return adjusted
 }
 
-// getNextSubtitleVersion 获取章节的下一个字幕版本号（自动递增）
-// chapterID: 章节ID
-// baseVersion: 基础版本号（如 1），如果为0则自动生成下一个版本号
-func (s *novelService) getNextSubtitleVersion(ctx context.Context, chapterID string, baseVersion int) (int, error) {
-	versions, err := s.subtitleRepo.FindVersionsByChapterID(ctx, chapterID)
-	if err != nil {
-		// 如果没有找到任何版本，返回 1 或基础版本号
-		if baseVersion == 0 {
-			return 1, nil
-		}
-		return baseVersion, nil
-	}
-
-	if len(versions) == 0 {
-		if baseVersion == 0 {
-			return 1, nil
-		}
-		return baseVersion, nil
-	}
-
-	// 如果指定了基础版本号，检查该版本是否已存在
-	if baseVersion > 0 {
-		for _, v := range versions {
-			if v == baseVersion {
-				// 该版本已存在，返回下一个版本号
-				maxVersion := 0
-				for _, v := range versions {
-					if v > maxVersion {
-						maxVersion = v
-					}
-				}
-				return maxVersion + 1, nil
-			}
-		}
-		// 该版本不存在，直接返回
-		return baseVersion, nil
-	}
-
-	// 如果没有指定基础版本号，查找所有版本号中的最大值
-	maxVersion := 0
-	for _, v := range versions {
-		if v > maxVersion {
-			maxVersion = v
-		}
-	}
-
-	return maxVersion + 1, nil
-}
@@ -0,0 +1,263 @@
+package novel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/noveltools"
+	"lemon/internal/service"
+)
+
+// maxImportedSubtitleBytes 外部字幕文件大小上限
+const maxImportedSubtitleBytes = 2 << 20
+
+var (
+	// ErrInvalidSubtitleFile 外部字幕文件不合法（格式不支持、不是 UTF-8 编码、时间无效或没有字幕）
+	ErrInvalidSubtitleFile = errors.New("invalid subtitle file")
+	// ErrSubtitleImportNoAudio 章节还没有音频，无法按音频片段重新计时
+	ErrSubtitleImportNoAudio = errors.New("chapter has no audio to align subtitles with")
+	// ErrSubtitleVersionNotFound 章节没有指定的字幕版本
+	ErrSubtitleVersionNotFound = errors.New("subtitle version not found")
+)
+
+// ImportSubtitlesRequest 导入外部字幕请求
+type ImportSubtitlesRequest struct {
+	ChapterID string    // 章节ID
+	UserID    string    // 操作用户ID（为空时使用章节所有者）
+	FileName  string    // 原始文件名（用于识别格式）
+	Data      io.Reader // 字幕文件内容（UTF-8 编码的 SRT 或 ASS）
+	Select    bool      // 导入后是否选中，选中后视频阶段使用导入的字幕
+}
+
+// ImportSubtitlesResult 导入外部字幕结果
+type ImportSubtitlesResult struct {
+	ChapterID   string               `json:"chapter_id"`
+	NarrationID string               `json:"narration_id"`
+	Version     int                  `json:"version"`   // 新的字幕版本号
+	Format      novel.SubtitleFormat `json:"format"`    // 原始文件格式
+	CueCount    int                  `json:"cue_count"` // 导入的字幕条数
+	Selected    bool                 `json:"selected"`  // 是否已选中
+	Subtitles   []*novel.Subtitle    `json:"subtitles"` // 按音频片段拆分的字幕（ASS 格式）
+}
+
+// ImportSubtitles 导入外部字幕文件，转换为章节的新字幕版本
+// 外部字幕的时间轴按章节最新音频依次拼接后的时间理解，按音频片段拆分并重新计时，每个片段生成一个 ASS 文件（使用小说的字幕样式），
+// 与生成的字幕一样与音频片段一一对应；原始文件同时保存，记录在 source_resource_id 中
+func (s *novelService) ImportSubtitles(ctx context.Context, req *ImportSubtitlesRequest) (*ImportSubtitlesResult, error) {
+	raw, err := io.ReadAll(io.LimitReader(req.Data, maxImportedSubtitleBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read subtitle file: %w", err)
+	}
+	if len(raw) > maxImportedSubtitleBytes {
+		return nil, fmt.Errorf("%w: file is larger than %d bytes", ErrInvalidSubtitleFile, maxImportedSubtitleBytes)
+	}
+	if !utf8.Valid(raw) {
+		return nil, fmt.Errorf("%w: file must be UTF-8 encoded", ErrInvalidSubtitleFile)
+	}
+	content := string(raw)
+	format, err := noveltools.DetectSubtitleFormat(req.FileName, content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSubtitleFile, err)
+	}
+	cues, err := noveltools.ParseSubtitleFile(content, format)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSubtitleFile, err)
+	}
+
+	chapter, err := s.chapterRepo.FindByID(ctx, req.ChapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+	narration, err := s.narrationRepo.FindByChapterID(ctx, chapter.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find narration: %w", err)
+	}
+	audioVersions, err := s.audioRepo.FindVersionsByNarrationID(ctx, narration.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find audio versions: %w", err)
+	}
+	audioVersion := 0
+	for _, v := range audioVersions {
+		audioVersion = max(audioVersion, v)
+	}
+	var audios []*novel.Audio
+	if audioVersion > 0 {
+		if audios, err = s.audioRepo.FindByNarrationIDAndVersion(ctx, narration.ID, audioVersion); err != nil {
+			return nil, fmt.Errorf("find audios: %w", err)
+		}
+	}
+	if len(audios) == 0 {
+		return nil, ErrSubtitleImportNoAudio
+	}
+
+	durations := make([]float64, len(audios))
+	for i, audio := range audios {
+		durations[i] = subtitleAudioDuration(audio)
+	}
+	segments := noveltools.RetimeSubtitleCues(cues, durations)
+
+	userID := req.UserID
+	if userID == "" {
+		userID = chapter.UserID
+	}
+	version, err := s.nextVersion(ctx, chapter.ID, novel.VersionArtifactSubtitle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get next subtitle version: %w", err)
+	}
+
+	ext := string(format)
+	source, err := s.resourceService.UploadFile(ctx, &service.UploadFileRequest{
+		UserID:      userID,
+		FileName:    fmt.Sprintf("%s_subtitle_import%s", chapter.ID, filepath.Ext(req.FileName)),
+		ContentType: "text/plain",
+		Ext:         ext,
+		Data:        bytes.NewReader(raw),
+		KeyVars:     chapterKeyVars(chapter, artifactSubtitle, version, 0),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("upload source subtitle file: %w", err)
+	}
+
+	render := s.novelRenderSettings(ctx, chapter.NovelID)
+	result := &ImportSubtitlesResult{
+		ChapterID:   chapter.ID,
+		NarrationID: narration.ID,
+		Version:     version,
+		Format:      format,
+		CueCount:    len(cues),
+	}
+	for i, audio := range audios {
+		sequence := audio.Sequence
+		if sequence == 0 {
+			sequence = i + 1
+		}
+
+		assGenerator := noveltools.NewASSGeneratorWithStyle(render.Subtitle)
+		assContent := assGenerator.GenerateASSContent(segments[i], fmt.Sprintf("Imported Subtitle %d", sequence))
+		upload, err := s.resourceService.UploadFile(ctx, &service.UploadFileRequest{
+			UserID:      userID,
+			FileName:    fmt.Sprintf("%s_subtitle_%02d.ass", narration.ID, sequence),
+			ContentType: "text/x-ass",
+			Ext:         "ass",
+			Data:        bytes.NewReader([]byte(assContent)),
+			KeyVars:     chapterKeyVars(chapter, artifactSubtitle, version, sequence),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload ASS file for sequence %d: %w", sequence, err)
+		}
+
+		subtitle := &novel.Subtitle{
+			ID:                 id.New(),
+			ChapterID:          chapter.ID,
+			NarrationID:        narration.ID,
+			NovelID:            chapter.NovelID,
+			UserID:             userID,
+			ShotID:             audio.ShotID,
+			Sequence:           sequence,
+			SubtitleResourceID: upload.ResourceID,
+			Format:             novel.SubtitleFormatASS,
+			Prompt:             fmt.Sprintf("导入字幕: file=%s, format=%s, cueCount=%d", req.FileName, format, len(segments[i])),
+			Version:            version,
+			Status:             novel.TaskStatusCompleted,
+			Source:             novel.SubtitleSourceImported,
+			SourceResourceID:   source.ResourceID,
+		}
+		if err := s.subtitleRepo.Create(ctx, subtitle); err != nil {
+			return nil, fmt.Errorf("failed to create subtitle record: %w", err)
+		}
+		result.Subtitles = append(result.Subtitles, subtitle)
+	}
+
+	if req.Select {
+		if err := s.chapterRepo.SetSubtitleVersion(ctx, chapter.ID, version); err != nil {
+			return nil, fmt.Errorf("select subtitle version: %w", err)
+		}
+		result.Selected = true
+	}
+
+	log.Info().
+		Str("chapter_id", chapter.ID).
+		Int("version", version).
+		Str("format", string(format)).
+		Int("cue_count", len(cues)).
+		Bool("selected", result.Selected).
+		Msg("外部字幕导入完成")
+	return result, nil
+}
+
+// SelectSubtitleVersion 选择视频阶段使用的字幕版本，version 为 0 时恢复使用最新生成的字幕
+func (s *novelService) SelectSubtitleVersion(ctx context.Context, chapterID string, version int) error {
+	if version > 0 {
+		versions, err := s.subtitleRepo.FindVersionsByChapterID(ctx, chapterID)
+		if err != nil {
+			return fmt.Errorf("find subtitle versions: %w", err)
+		}
+		if !slices.Contains(versions, version) {
+			return fmt.Errorf("%w: chapter %s has no subtitle version %d", ErrSubtitleVersionNotFound, chapterID, version)
+		}
+	}
+	return s.chapterRepo.SetSubtitleVersion(ctx, chapterID, version)
+}
+
+// selectNarrationSubtitles 按序号选出视频阶段使用的字幕
+// version 为章节选中的字幕版本：解说有该版本的字幕时使用该版本；
+// 否则（未选中或选中的版本属于旧的解说）每个序号取最新生成的字幕，导入的字幕只有选中后才会被使用
+func selectNarrationSubtitles(subtitles []*novel.Subtitle, version int) map[int]*novel.Subtitle {
+	bySequence := make(map[int]*novel.Subtitle, len(subtitles))
+	if version > 0 {
+		for _, subtitle := range subtitles {
+			if subtitle.Version != version {
+				continue
+			}
+			if existing, ok := bySequence[subtitle.Sequence]; !ok || subtitle.CreatedAt.After(existing.CreatedAt) {
+				bySequence[subtitle.Sequence] = subtitle
+			}
+		}
+		if len(bySequence) > 0 {
+			return bySequence
+		}
+	}
+	for _, subtitle := range subtitles {
+		if subtitle.Source == novel.SubtitleSourceImported {
+			continue
+		}
+		if existing, ok := bySequence[subtitle.Sequence]; !ok || subtitle.CreatedAt.After(existing.CreatedAt) {
+			bySequence[subtitle.Sequence] = subtitle
+		}
+	}
+	return bySequence
+}
+
+// findNarrationSubtitles 查询解说在视频阶段使用的字幕（按序号）
+func (s *novelService) findNarrationSubtitles(ctx context.Context, narration *novel.Narration) (map[int]*novel.Subtitle, error) {
+	chapter, err := s.chapterRepo.FindByID(ctx, narration.ChapterID)
+	if err != nil {
+		return nil, fmt.Errorf("find chapter: %w", err)
+	}
+	subtitles, err := s.subtitleRepo.FindByNarrationID(ctx, narration.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find subtitles: %w", err)
+	}
+	return selectNarrationSubtitles(subtitles, chapter.SubtitleVersion), nil
+}
+
+// subtitleAudioDuration 音频片段时长：优先使用记录的时长，缺失时使用最后一个字的结束时间，仍缺失时为 10 秒（与生成字幕一致）
+func subtitleAudioDuration(audio *novel.Audio) float64 {
+	if audio.Duration > 0 {
+		return audio.Duration
+	}
+	if n := len(audio.Timestamps); n > 0 && audio.Timestamps[n-1].EndTime > 0 {
+		return audio.Timestamps[n-1].EndTime
+	}
+	return 10.0
+}
@@ -0,0 +1,52 @@
+package novel
+
+import (
+	"context"
+	"fmt"
+
+	"lemon/internal/model/novel"
+)
+
+// nextVersion 分配章节产物的下一个版本号
+// 版本号由计数器原子分配，并发生成同一章节的同类产物时不会得到相同的版本号；
+// 计数器以产物集合中已有的最大版本号为下限，升级前已有的数据无需单独迁移，第一次分配时从已有的最大版本号继续
+func (s *novelService) nextVersion(ctx context.Context, chapterID string, artifact novel.VersionArtifact) (int, error) {
+	versions, err := s.chapterArtifactVersions(ctx, chapterID, artifact)
+	if err != nil {
+		return 0, err
+	}
+
+	floor := 0
+	for _, v := range versions {
+		floor = max(floor, v)
+	}
+	version, err := s.versionCounterRepo.Next(ctx, chapterID, artifact, floor)
+	if err != nil {
+		return 0, fmt.Errorf("allocate %s version: %w", artifact, err)
+	}
+	return version, nil
+}
+
+// chapterArtifactVersions 查询章节某类产物的所有版本号
+func (s *novelService) chapterArtifactVersions(ctx context.Context, chapterID string, artifact novel.VersionArtifact) ([]int, error) {
+	var versions []int
+	var err error
+	switch artifact {
+	case novel.VersionArtifactNarration:
+		versions, err = s.narrationRepo.FindVersionsByChapterID(ctx, chapterID)
+	case novel.VersionArtifactAudio:
+		versions, err = s.audioRepo.FindVersionsByChapterID(ctx, chapterID)
+	case novel.VersionArtifactSubtitle:
+		versions, err = s.subtitleRepo.FindVersionsByChapterID(ctx, chapterID)
+	case novel.VersionArtifactImage:
+		versions, err = s.imageRepo.FindVersionsByChapterID(ctx, chapterID)
+	case novel.VersionArtifactVideo:
+		versions, err = s.videoRepo.FindVersionsByChapterID(ctx, chapterID)
+	default:
+		return nil, fmt.Errorf("unknown version artifact: %s", artifact)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find %s versions: %w", artifact, err)
+	}
+	return versions, nil
+}
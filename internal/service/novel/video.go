@@ -13,12 +13,17 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
 	"lemon/internal/model/novel"
+	"lemon/internal/pkg/ctxutil"
 	"lemon/internal/pkg/ffmpeg"
 	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/killswitch"
+	"lemon/internal/pkg/noveltools"
+	"lemon/internal/pkg/procreaper"
 	"lemon/internal/service"
 )
 
@@ -30,6 +35,11 @@ type VideoService interface {
 	// 所有视频都使用图生视频方式（Ark API），不再需要 first_video
 	GenerateNarrationVideosForChapter(ctx context.Context, chapterID string) ([]string, error)
 
+	// GenerateNarrationVideosForChapterWithPlatform 为指定目标平台生成 narration 视频
+	// 字幕按平台安全区排版，避开平台 UI（如抖音底部文案区和右侧按钮列）；之后合并的最终视频沿用该平台
+	// platform 为空时使用小说渲染设置中的默认平台
+	GenerateNarrationVideosForChapterWithPlatform(ctx context.Context, chapterID string, platform novel.TargetPlatform) ([]string, error)
+
 	// GenerateFinalVideoForChapter 生成章节的最终完整视频（对应 concat_finish_video.py）
 	// 拼接所有 narration 视频，添加 finish.mp4
 	GenerateFinalVideoForChapter(ctx context.Context, chapterID string) (string, error)
@@ -37,14 +47,27 @@ type VideoService interface {
 	// GenerateFinalVideoForChapterWithVersion 指定 narration 视频版本号，手动确认后再合并生成最终视频
 	GenerateFinalVideoForChapterWithVersion(ctx context.Context, chapterID string, version int) (string, error)
 
+	// GenerateFinalVideoForChapterWithTier 指定导出档位生成最终视频
+	// preview 为带水印的预览版（渲染分辨率缩小到 720p）；licensed 为无水印的授权版（使用生成参数中的渲染分辨率），要求章节已审核通过，并为 licenseeID 记录授权
+	GenerateFinalVideoForChapterWithTier(ctx context.Context, chapterID string, version int, tier novel.ExportTier, licenseeID string) (string, error)
+
+	// ListLicenseGrantsByChapter 获取章节的授权记录
+	ListLicenseGrantsByChapter(ctx context.Context, chapterID string) ([]*novel.LicenseGrant, error)
+
 	// GetVideoVersions 获取章节的所有视频版本号
 	GetVideoVersions(ctx context.Context, chapterID string) ([]int, error)
 
-	// GetVideosByStatus 根据状态查询视频（用于轮询）
-	GetVideosByStatus(ctx context.Context, status novel.VideoStatus) ([]*novel.Video, error)
-
 	// ListVideosByChapter 获取章节视频列表（可指定版本；version<=0 则取最新版本）
 	ListVideosByChapter(ctx context.Context, chapterID string, version int) ([]*novel.Video, int, error)
+
+	// GetRenderBreakdown 汇总章节渲染的耗时与费用明细（各阶段耗时、provider 调用次数、上传/下载字节数、费用）
+	GetRenderBreakdown(ctx context.Context, chapterID string) (*novel.RenderBreakdown, error)
+
+	// GetFailureStats 按失败类别统计最近 days 天的生成失败（按天、按 provider 汇总），用于失败分类看板
+	GetFailureStats(ctx context.Context, days int) (*novel.FailureStats, error)
+
+	// GetVideoManifest 获取最终视频的流水线清单（输入素材ID、provider/模型、提示词哈希和工具版本）
+	GetVideoManifest(ctx context.Context, chapterID, videoID string) (*novel.PipelineManifest, error)
 }
 
 // GenerateFirstVideosForChapter 已废弃：现在所有视频都使用图生视频方式，不再需要 first_video
@@ -58,14 +81,53 @@ func (s *novelService) GenerateFirstVideosForChapter(ctx context.Context, chapte
 // 逻辑：
 //   - 从 ChapterNarration.Content.Scenes[].Shots[] 中提取所有 Shots
 //   - 按照顺序为每个场景生成视频
-//   - 内部实现决定：前3个场景合并成一个视频，其他场景每个单独生成视频
+//   - 每个镜头单独生成视频片段，镜头数量由小说的解说结构决定
 //   - 所有视频都使用图生视频方式（从图片生成视频）
 func (s *novelService) GenerateNarrationVideosForChapter(ctx context.Context, chapterID string) ([]string, error) {
+	return s.GenerateNarrationVideosForChapterWithPlatform(ctx, chapterID, "")
+}
+
+// GenerateNarrationVideosForChapterWithPlatform 为指定目标平台生成章节的所有 narration 视频
+func (s *novelService) GenerateNarrationVideosForChapterWithPlatform(ctx context.Context, chapterID string, platform novel.TargetPlatform) ([]string, error) {
+	return s.runNarrationVideoStage(ctx, chapterID, platform, 0)
+}
+
+// runNarrationVideoStage 生成章节的所有 narration 视频并记录阶段耗时与费用；version 为 0 时分配新的视频版本号
+func (s *novelService) runNarrationVideoStage(ctx context.Context, chapterID string, platform novel.TargetPlatform, version int) ([]string, error) {
+	var ids []string
+	err := s.runChapterStage(ctx, chapterID, novel.PipelineStageNarrationVideo, func(ctx context.Context) error {
+		var err error
+		ids, err = s.generateNarrationVideosForChapter(ctx, chapterID, platform, version)
+		setStageResources(ctx, ids...)
+		return err
+	})
+	return ids, err
+}
+
+// generateNarrationVideosForChapter 生成章节的所有 narration 视频（阶段耗时与费用由调用方记录）
+// version 为调用方预先分配的视频版本号，为 0 时在这里分配
+func (s *novelService) generateNarrationVideosForChapter(ctx context.Context, chapterID string, platform novel.TargetPlatform, version int) ([]string, error) {
+	if platform != "" && !platform.IsValid() {
+		return nil, fmt.Errorf("invalid target platform: %s", platform)
+	}
+
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderVideo)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	// 1. 获取章节的 narration
 	narration, err := s.narrationRepo.FindByChapterID(ctx, chapterID)
 	if err != nil {
 		return nil, fmt.Errorf("find narration: %w", err)
 	}
+	if err := s.checkBudget(ctx, narration.NovelID); err != nil {
+		return nil, err
+	}
+	if platform == "" {
+		platform = s.novelTargetPlatform(ctx, narration.NovelID)
+	}
 
 	// 2. 从独立的表中查询场景和镜头
 	scenes, err := s.sceneRepo.FindByNarrationID(ctx, narration.ID)
@@ -113,22 +175,54 @@ func (s *novelService) GenerateNarrationVideosForChapter(ctx context.Context, ch
 		return nil, fmt.Errorf("no shots found in narration content")
 	}
 
-	// 4. 自动生成下一个版本号
-	videoVersion, err := s.getNextVideoVersion(ctx, chapterID, 0)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get next video version: %w", err)
+	// 4. 自动生成下一个版本号（调用方已分配时直接使用）
+	videoVersion := version
+	if videoVersion == 0 {
+		videoVersion, err = s.nextVersion(ctx, chapterID, novel.VersionArtifactVideo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get next video version: %w", err)
+		}
 	}
 
 	// 5. 初始化 FFmpeg 客户端
 	ffmpegClient := ffmpeg.NewClient()
 
-	// 6. 并发为每个分镜生成视频（最大并发数：10）
-	// 所有分镜都单独生成视频，使用图生视频方式
-	maxConcurrency := 10
-	maxShots := len(allShots)
-	if maxShots > 30 {
-		maxShots = 30
+	// 每个片段末尾的停顿：场景切换处使用场景停顿，其余使用片段停顿，最后一个片段没有停顿
+	gen := s.generationSettings(ctx, narration.NovelID)
+	sceneNumbers := make([]string, len(allShots))
+	for i, shotInfo := range allShots {
+		sceneNumbers[i] = shotInfo.SceneNumber
 	}
+	gaps := noveltools.NarrationGaps(sceneNumbers, gen.SegmentGap, gen.SceneGap)
+
+	// 6. 按镜头合并规则分组：合并时用音频时长（加末尾停顿）校验并决定分组
+	// 镜头数上限取解说结构允许的最多镜头数（至少 30 个），防止异常的剧本产生过多的生成任务
+	maxShots := min(len(allShots), max(30, noveltools.NarrationMaxShots(s.novelNarrationStructure(ctx, narration.NovelID))))
+	mergeRule := gen.ClipMerge
+	if mergeRule == "" {
+		mergeRule = novel.ClipMergeNone
+	}
+	candidates := make([]noveltools.ClipMergeCandidate, maxShots)
+	if mergeRule != novel.ClipMergeNone {
+		audios, err := s.audioRepo.FindByNarrationID(ctx, narration.ID)
+		if err != nil {
+			return nil, fmt.Errorf("find audios: %w", err)
+		}
+		for i, shotInfo := range allShots[:maxShots] {
+			candidates[i].Scene = shotInfo.SceneNumber
+			if audio := matchShotAudio(audios, shotInfo.Shot); audio != nil && audio.Duration > 0 {
+				candidates[i].Duration = audio.Duration + gaps[i]
+			}
+		}
+	}
+	groups, err := noveltools.GroupClipsForMerge(candidates, mergeRule, gen.ClipMergeUnder)
+	if err != nil {
+		return nil, fmt.Errorf("group shots for merge: %w", err)
+	}
+
+	// 7. 并发为每组镜头生成视频（最大并发数：10）
+	// 单个镜头的组单独生成视频，多个镜头的组合并为一个视频，都使用图生视频方式
+	maxConcurrency := 10
 
 	// 使用 channel 控制并发数
 	semaphore := make(chan struct{}, maxConcurrency)
@@ -137,24 +231,31 @@ func (s *novelService) GenerateNarrationVideosForChapter(ctx context.Context, ch
 	var videoIDs []string
 	var errors []error
 
-	for i := 0; i < maxShots; i++ {
-		shotInfo := allShots[i]
-		narrationNum := fmt.Sprintf("%02d", shotInfo.Index)
+	for _, group := range groups {
+		// 分组内的镜头是连续的，直接按下标范围截取
+		first, last := group[0], group[len(group)-1]
+		members := allShots[first : last+1]
+		pauses := gaps[first : last+1]
+		narrationNum := fmt.Sprintf("%02d", members[0].Index)
+		if len(members) > 1 {
+			narrationNum = fmt.Sprintf("%02d-%02d", members[0].Index, members[len(members)-1].Index)
+		}
 
 		wg.Add(1)
-		go func(shotInfo struct {
-			SceneNumber string
-			ShotNumber  string
-			Shot        *novel.Shot
-			Index       int
-		}, narrationNum string) {
+		go func() {
 			defer wg.Done()
 
 			// 获取信号量（限制并发数）
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
-			videoID, err := s.generateSingleNarrationVideo(ctx, chapterID, narration, shotInfo, narrationNum, videoVersion, ffmpegClient)
+			var videoID string
+			var err error
+			if len(members) > 1 {
+				videoID, err = s.generateMergedNarrationVideo(ctx, chapterID, narration, members, pauses, videoVersion, mergeRule, platform, ffmpegClient)
+			} else {
+				videoID, err = s.generateSingleNarrationVideo(ctx, chapterID, narration, members[0], narrationNum, videoVersion, pauses[0], mergeRule, platform, ffmpegClient)
+			}
 			if err != nil {
 				log.Error().Err(err).Str("narration_num", narrationNum).Msg("生成分镜视频失败")
 				mu.Lock()
@@ -166,7 +267,7 @@ func (s *novelService) GenerateNarrationVideosForChapter(ctx context.Context, ch
 			mu.Lock()
 			videoIDs = append(videoIDs, videoID)
 			mu.Unlock()
-		}(shotInfo, narrationNum)
+		}()
 	}
 
 	// 等待所有任务完成
@@ -176,6 +277,7 @@ func (s *novelService) GenerateNarrationVideosForChapter(ctx context.Context, ch
 	if len(errors) > 0 {
 		log.Warn().
 			Int("total_shots", maxShots).
+			Int("total_clips", len(groups)).
 			Int("failed_count", len(errors)).
 			Msg("部分分镜视频生成失败")
 	}
@@ -189,7 +291,7 @@ func (s *novelService) GenerateNarrationVideosForChapter(ctx context.Context, ch
 }
 
 // generateNarration01Video 已废弃：现在所有视频都使用图生视频方式，不再需要 first_video
-// DEPRECATED: 此函数已不再使用，narration_01-03 现在通过 generateMergedNarrationVideo 统一生成
+// DEPRECATED: 此函数已不再使用，相邻镜头现在按合并规则通过 generateMergedNarrationVideo 合并生成
 func (s *novelService) generateNarration01Video(
 	ctx context.Context,
 	narration *novel.Narration,
@@ -200,36 +302,9 @@ func (s *novelService) generateNarration01Video(
 	return "", fmt.Errorf("generateNarration01Video is deprecated, use generateMergedNarrationVideo instead")
 }
 
-// replaceVideoAudio 替换视频的音频
-func (s *novelService) replaceVideoAudio(ctx context.Context, videoPath, audioPath, outputPath string, ffmpegClient *ffmpeg.Client) error {
-	// 使用 FFmpeg 替换音频
-	// ffmpeg -i video.mp4 -i audio.mp3 -c:v copy -c:a aac -map 0:v:0 -map 1:a:0 output.mp4
-	// 这里简化实现，直接使用 FFmpeg 命令
-	cmd := exec.CommandContext(ctx, "ffmpeg",
-		"-y",
-		"-i", videoPath,
-		"-i", audioPath,
-		"-c:v", "copy",
-		"-c:a", "aac",
-		"-map", "0:v:0",
-		"-map", "1:a:0",
-		outputPath,
-	)
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("ffmpeg replace audio failed: %w", err)
-	}
-
-	return nil
-}
-
-// generateMergedNarrationVideo 生成合并的视频（内部实现：前3个场景合并）
-// 对应 Python: create_merged_narration_video()
-// 逻辑：
-//   - 完全使用图生视频方式（每个场景的图片按对应音频时长生成视频）
-//   - 合并多个场景的视频、字幕和音频
-//   - 添加 BGM 和音效（可选）
+// generateMergedNarrationVideo 把相邻的多个镜头合并为一个片段（按镜头合并规则分组后，组内有多个镜头时使用）
+// 逐个合成成员镜头（画面、音频、字幕和末尾停顿与单镜头片段相同），拼接后上传并创建一条视频记录：
+// Sequence~SequenceEnd 为成员镜头的序号范围，MergeRule 为分组使用的合并规则
 func (s *novelService) generateMergedNarrationVideo(
 	ctx context.Context,
 	chapterID string,
@@ -240,302 +315,177 @@ func (s *novelService) generateMergedNarrationVideo(
 		Shot        *novel.Shot
 		Index       int
 	},
-	video1 *novel.Video, // 保留参数以保持接口兼容，但不再使用
+	pauses []float64, // 每个成员镜头末尾的停顿（秒）
 	version int,
+	rule novel.ClipMergeMode,
+	platform novel.TargetPlatform,
 	ffmpegClient *ffmpeg.Client,
 ) (string, error) {
-	if len(shots) != 3 {
-		return "", fmt.Errorf("merged video requires exactly 3 shots, got %d", len(shots))
-	}
-
-	// 1. 获取前三个 Shots 的音频（sequence=1, 2, 3）
-	audios, err := s.audioRepo.FindByNarrationID(ctx, narration.ID)
-	if err != nil {
-		return "", fmt.Errorf("find audios: %w", err)
-	}
-
-	// 按 sequence 排序并获取前三个
-	sort.Slice(audios, func(i, j int) bool {
-		return audios[i].Sequence < audios[j].Sequence
-	})
-
-	if len(audios) < 3 {
-		return "", fmt.Errorf("need at least 3 audio segments for merged narration, got %d", len(audios))
+	if len(shots) < 2 {
+		return "", fmt.Errorf("merged video requires at least 2 shots, got %d", len(shots))
 	}
-
-	// 计算总音频时长
-	var totalAudioDuration float64
-	for i := 0; i < 3; i++ {
-		audioDuration := audios[i].Duration
-		if audioDuration <= 0 {
-			// TODO: 修复音频 duration 为 0 的问题，确保 TTS API 返回的 duration 正确解析并保存到数据库
-			// 当前临时方案：如果音频时长为 0，使用默认值 10 秒
-			audioDuration = 10.0
-			log.Warn().
-				Str("narration_id", narration.ID).
-				Int("sequence", audios[i].Sequence).
-				Msg("音频 duration 为 0，使用默认值 10 秒")
-		}
-		totalAudioDuration += audioDuration
+	if len(pauses) != len(shots) {
+		return "", fmt.Errorf("merged video requires %d pauses, got %d", len(shots), len(pauses))
 	}
 
-	if totalAudioDuration <= 0 {
-		// TODO: 修复音频 duration 为 0 的问题，确保 TTS API 返回的 duration 正确解析并保存到数据库
-		// 当前临时方案：如果总时长为 0，使用默认值 30 秒（3个音频片段 × 10秒）
-		totalAudioDuration = 30.0
-		log.Warn().
-			Str("narration_id", narration.ID).
-			Msg("总音频 duration 为 0，使用默认值 30 秒")
-	}
-
-	// 2. 创建临时目录
+	// 1. 逐个合成成员镜头的片段
 	tmpDir := os.TempDir()
-
-	// 3. 下载前三个音频片段对应的字幕文件并合并
-	// 获取前三个音频片段的字幕
-	var subtitlePaths []string
-	for i := 0; i < 3; i++ {
-		subtitle, err := s.subtitleRepo.FindByNarrationIDAndSequence(ctx, narration.ID, audios[i].Sequence)
-		if err != nil {
-			return "", fmt.Errorf("find subtitle for sequence %d: %w", audios[i].Sequence, err)
-		}
-
-		// 下载字幕文件
-		subtitleDownloadReq := &service.DownloadFileRequest{
-			ResourceID: subtitle.SubtitleResourceID,
-			UserID:     narration.UserID,
-		}
-		subtitleResult, err := s.resourceService.DownloadFile(ctx, subtitleDownloadReq)
-		if err != nil {
-			return "", fmt.Errorf("download subtitle %d: %w", i+1, err)
-		}
-		defer subtitleResult.Data.Close()
-
-		// 保存字幕到临时文件
-		tmpSubtitlePath := filepath.Join(tmpDir, fmt.Sprintf("subtitle_%d_%s.ass", i+1, id.New()))
-		defer os.Remove(tmpSubtitlePath)
-		subtitleFile, err := os.Create(tmpSubtitlePath)
-		if err != nil {
-			return "", fmt.Errorf("create temp subtitle file %d: %w", i+1, err)
-		}
-		if _, err := io.Copy(subtitleFile, subtitleResult.Data); err != nil {
-			subtitleFile.Close()
-			return "", fmt.Errorf("copy subtitle data %d: %w", i+1, err)
-		}
-		subtitleFile.Close()
-		subtitlePaths = append(subtitlePaths, tmpSubtitlePath)
-	}
-
-	// 合并三个字幕文件（合并 ASS 文件的 Dialogue 事件）
-	tmpMergedSubtitlePath := filepath.Join(tmpDir, fmt.Sprintf("subtitle_merged_%s.ass", id.New()))
-	defer os.Remove(tmpMergedSubtitlePath)
-	if err := s.mergeASSFiles(ctx, subtitlePaths, tmpMergedSubtitlePath); err != nil {
-		return "", fmt.Errorf("merge ASS files: %w", err)
-	}
-
-	// 4. 创建视频片段列表（完全使用图生视频）
-	var videoSegmentPaths []string
+	var clipPaths []string
 	defer func() {
-		for _, path := range videoSegmentPaths {
+		for _, path := range clipPaths {
 			os.Remove(path)
 		}
 	}()
 
-	// 3.1 使用 image_01-03 按音频时长分配（每个图片对应一个音频片段）
-	// 收集所有图片的 prompt（用于视频记录）
-	var imagePrompts []string
-	var images []*novel.Image
+	var totalDuration float64
+	var prompts []string
 	for i, shotInfo := range shots {
-		// 根据 scene_number 和 shot_number 查找图片
-		image, err := s.imageRepo.FindBySceneAndShot(ctx, chapterID, shotInfo.SceneNumber, shotInfo.ShotNumber)
-		if err != nil {
-			return "", fmt.Errorf("find image for shot %d (scene=%s, shot=%s): %w", i+1, shotInfo.SceneNumber, shotInfo.ShotNumber, err)
-		}
-
-		// 保存图片引用和 prompt
-		images = append(images, image)
-		if image.Prompt != "" {
-			imagePrompts = append(imagePrompts, image.Prompt)
-		}
-
-		// 下载图片
-		imageDownloadReq := &service.DownloadFileRequest{
-			ResourceID: image.ImageResourceID,
-			UserID:     narration.UserID,
-		}
-		imageResult, err := s.resourceService.DownloadFile(ctx, imageDownloadReq)
-		if err != nil {
-			return "", fmt.Errorf("download image %d: %w", i+1, err)
-		}
-		defer imageResult.Data.Close()
-
-		tmpImagePath := filepath.Join(tmpDir, fmt.Sprintf("image_%d_%s.jpg", i+1, id.New()))
-		imageFile, err := os.Create(tmpImagePath)
+		narrationNum := fmt.Sprintf("%02d", shotInfo.Index)
+		clipPath := filepath.Join(tmpDir, fmt.Sprintf("video_std_%s.mp4", id.New()))
+		clipPaths = append(clipPaths, clipPath)
+		clip, err := s.assembleShotClip(ctx, chapterID, narration, shotInfo, narrationNum, "", pauses[i], platform, ffmpegClient, shotClipOptions{}, clipPath)
 		if err != nil {
-			return "", fmt.Errorf("create temp image file: %w", err)
-		}
-		if _, err := io.Copy(imageFile, imageResult.Data); err != nil {
-			imageFile.Close()
-			return "", fmt.Errorf("copy image data: %w", err)
-		}
-		imageFile.Close()
-
-		// 从图片创建视频（使用对应音频片段的时长）
-		// 注意：这里使用每个音频片段的实际时长，而不是平均分配
-		audioDuration := audios[i].Duration
-		if audioDuration <= 0 {
-			// TODO: 修复音频 duration 为 0 的问题，确保 TTS API 返回的 duration 正确解析并保存到数据库
-			// 当前临时方案：如果音频时长为 0，使用默认值 10 秒
-			audioDuration = 10.0
-			log.Warn().
-				Str("narration_id", narration.ID).
-				Int("sequence", audios[i].Sequence).
-				Int("shot_index", i+1).
-				Msg("音频 duration 为 0，使用默认值 10 秒")
+			return "", fmt.Errorf("assemble shot %s: %w", narrationNum, err)
 		}
-
-		tmpImageVideoPath := filepath.Join(tmpDir, fmt.Sprintf("image_video_%d_%s.mp4", i+1, id.New()))
-		if err := ffmpegClient.CreateImageVideo(ctx, tmpImagePath, tmpImageVideoPath, audioDuration, 720, 1280, 30); err != nil {
-			return "", fmt.Errorf("create image video %d: %w", i+1, err)
-		}
-		videoSegmentPaths = append(videoSegmentPaths, tmpImageVideoPath)
-		os.Remove(tmpImagePath) // 清理图片文件
+		totalDuration += clip.Duration
+		prompts = append(prompts, clip.Prompt)
 	}
 
-	if len(videoSegmentPaths) == 0 {
-		return "", fmt.Errorf("no video segments generated")
+	// 2. 拼接成员片段（编码参数一致，直接拼接）
+	tmpMergedPath := filepath.Join(tmpDir, fmt.Sprintf("merged_video_%s.mp4", id.New()))
+	defer os.Remove(tmpMergedPath)
+	if err := ffmpegClient.ConcatVideos(ctx, clipPaths, tmpMergedPath); err != nil {
+		return "", fmt.Errorf("concat shot clips: %w", err)
 	}
 
-	// 4. 合并视频片段
-	tmpMergedVideoPath := filepath.Join(tmpDir, fmt.Sprintf("merged_video_%s.mp4", id.New()))
-	defer os.Remove(tmpMergedVideoPath)
-
-	if err := ffmpegClient.ConcatVideos(ctx, videoSegmentPaths, tmpMergedVideoPath); err != nil {
-		return "", fmt.Errorf("concat video segments: %w", err)
+	// 3. 上传合并后的视频
+	first, last := shots[0].Index, shots[len(shots)-1].Index
+	fileName := fmt.Sprintf("%s_narration_%02d-%02d_video.mp4", chapterID, first, last)
+	resourceID, err := s.uploadNarrationClip(ctx, chapterID, narration, tmpMergedPath, fileName, version, first)
+	if err != nil {
+		return "", err
 	}
 
-	// 5. 合并前三个音频文件
-	tmpMergedAudioPath := filepath.Join(tmpDir, fmt.Sprintf("merged_audio_%s.mp3", id.New()))
-	defer os.Remove(tmpMergedAudioPath)
-
-	// 下载三个音频文件
-	var audioPaths []string
-	for i := 0; i < 3; i++ {
-		audioDownloadReq := &service.DownloadFileRequest{
-			ResourceID: audios[i].AudioResourceID,
-			UserID:     narration.UserID,
-		}
-		audioResult, err := s.resourceService.DownloadFile(ctx, audioDownloadReq)
-		if err != nil {
-			return "", fmt.Errorf("download audio %d: %w", i+1, err)
-		}
-		defer audioResult.Data.Close()
-
-		tmpAudioPath := filepath.Join(tmpDir, fmt.Sprintf("audio_%d_%s.mp3", i+1, id.New()))
-		audioFile, err := os.Create(tmpAudioPath)
-		if err != nil {
-			return "", fmt.Errorf("create temp audio file: %w", err)
-		}
-		if _, err := io.Copy(audioFile, audioResult.Data); err != nil {
-			audioFile.Close()
-			return "", fmt.Errorf("copy audio data: %w", err)
-		}
-		audioFile.Close()
-		audioPaths = append(audioPaths, tmpAudioPath)
-		defer os.Remove(tmpAudioPath)
+	// 4. 创建视频记录
+	videoEntity := &novel.Video{
+		ID:              id.New(),
+		ChapterID:       chapterID,
+		NarrationID:     narration.ID,
+		NovelID:         narration.NovelID,
+		UserID:          narration.UserID,
+		Sequence:        first,
+		SequenceEnd:     last, // 拼接时不再使用成员镜头的单独片段
+		MergeRule:       rule,
+		VideoResourceID: resourceID,
+		Duration:        totalDuration,
+		VideoType:       novel.VideoTypeNarration,
+		Prompt:          strings.Join(prompts, "; "),
+		Version:         version,
+		Status:          novel.VideoStatusCompleted,
+		Platform:        platform,
 	}
-
-	// 使用 FFmpeg 合并音频
-	if err := s.mergeAudioFiles(ctx, audioPaths, tmpMergedAudioPath); err != nil {
-		return "", fmt.Errorf("merge audio files: %w", err)
+	if style, ok := ctxutil.GetSubtitleStyle(ctx); ok {
+		videoEntity.SubtitleStyle = style
 	}
+	if err := s.videoRepo.Create(ctx, videoEntity); err != nil {
+		return "", fmt.Errorf("create video record: %w", err)
+	}
+	s.generateVideoThumbnails(ctx, videoEntity)
 
-	// 7. 添加字幕到视频
-	tmpWithSubtitlePath := filepath.Join(tmpDir, fmt.Sprintf("video_subtitle_%s.mp4", id.New()))
-	defer os.Remove(tmpWithSubtitlePath)
+	log.Info().
+		Str("chapter_id", chapterID).
+		Int("sequence", first).
+		Int("sequence_end", last).
+		Str("merge_rule", rule.String()).
+		Float64("duration", totalDuration).
+		Msg("合并镜头视频生成完成")
 
-	if err := ffmpegClient.AddSubtitles(ctx, tmpMergedVideoPath, tmpMergedSubtitlePath, tmpWithSubtitlePath); err != nil {
-		return "", fmt.Errorf("add subtitles: %w", err)
-	}
+	return videoEntity.ID, nil
+}
 
-	// 8. 替换音频
-	tmpFinalPath := filepath.Join(tmpDir, fmt.Sprintf("video_final_%s.mp4", id.New()))
-	defer os.Remove(tmpFinalPath)
+// renderedShotClip 渲染并上传的单镜头片段
+type renderedShotClip struct {
+	ResourceID   string                      // 片段文件的 resource_id
+	Duration     float64                     // 片段时长（秒，即对应音频的时长加末尾停顿）
+	Prompt       string                      // 实际使用的视频提示词
+	SourceReused bool                        // 图生视频结果是否复用了本地缓存（没有调用图生视频接口）
+	Timings      novel.ShotClipRenderTimings // 各步骤耗时
+}
 
-	if err := s.replaceVideoAudio(ctx, tmpWithSubtitlePath, tmpMergedAudioPath, tmpFinalPath, ffmpegClient); err != nil {
-		return "", fmt.Errorf("replace audio: %w", err)
-	}
+// shotClipOptions 单镜头片段的渲染选项（零值为正式渲染）
+type shotClipOptions struct {
+	// Preview 交互式预览：使用快速编码预设；启用本地资源缓存时先把图片、音频、字幕缓存到本地，
+	// 并缓存图生视频结果，再次预览或确认预览时提示词和图片不变则不再调用图生视频接口
+	Preview bool
+	// CachedSourceOnly 只使用本地缓存的图生视频结果，未缓存时返回 errShotSourceNotCached（确认预览时按正式画质重新编码，不产生额外费用）
+	CachedSourceOnly bool
+}
 
-	// 9. 标准化视频分辨率
-	tmpStandardizedPath := filepath.Join(tmpDir, fmt.Sprintf("video_std_%s.mp4", id.New()))
+// renderShotClip 渲染单个镜头的片段并上传：图生视频，叠加镜头的字幕并替换为镜头的音频
+// videoPrompt 为空时使用镜头的 video_prompt（会替换其中的小说变量）；不创建视频记录（由调用方决定记录到哪个版本或作为预览）
+// pause 为片段末尾的停顿，画面定格、音频补静音，片段内的字幕时间不变，拼接后之后片段的字幕随之后移
+func (s *novelService) renderShotClip(
+	ctx context.Context,
+	chapterID string,
+	narration *novel.Narration,
+	shotInfo struct {
+		SceneNumber string
+		ShotNumber  string
+		Shot        *novel.Shot
+		Index       int
+	},
+	narrationNum string,
+	videoPrompt string,
+	version int, // 片段所属的视频版本（用于生成存储路径），预览时为 0
+	pause float64, // 片段末尾的停顿（秒）
+	platform novel.TargetPlatform,
+	ffmpegClient *ffmpeg.Client,
+	opts shotClipOptions,
+) (*renderedShotClip, error) {
+	tmpStandardizedPath := filepath.Join(os.TempDir(), fmt.Sprintf("video_std_%s.mp4", id.New()))
 	defer os.Remove(tmpStandardizedPath)
 
-	if err := ffmpegClient.StandardizeVideo(ctx, tmpFinalPath, tmpStandardizedPath, 720, 1280, 30); err != nil {
-		return "", fmt.Errorf("standardize video: %w", err)
+	clip, err := s.assembleShotClip(ctx, chapterID, narration, shotInfo, narrationNum, videoPrompt, pause, platform, ffmpegClient, opts, tmpStandardizedPath)
+	if err != nil {
+		return nil, err
+	}
+
+	uploadStart := time.Now()
+	fileName := fmt.Sprintf("%s_narration_%s_video.mp4", chapterID, narrationNum)
+	clip.ResourceID, err = s.uploadNarrationClip(ctx, chapterID, narration, tmpStandardizedPath, fileName, version, shotInfo.Index)
+	if err != nil {
+		return nil, err
 	}
+	clip.Timings.UploadMs = time.Since(uploadStart).Milliseconds()
+	return clip, nil
+}
 
-	// 10. 上传最终视频到 resource 模块
-	finalVideoFile, err := os.Open(tmpStandardizedPath)
+// uploadNarrationClip 上传镜头片段文件，返回 resource_id
+// sequence 为片段（合并片段为第一个成员镜头）的序号，用于生成存储路径
+func (s *novelService) uploadNarrationClip(ctx context.Context, chapterID string, narration *novel.Narration, path, fileName string, version, sequence int) (string, error) {
+	file, err := os.Open(path)
 	if err != nil {
 		return "", fmt.Errorf("open final video: %w", err)
 	}
-	defer finalVideoFile.Close()
+	defer file.Close()
 
-	fileName := fmt.Sprintf("%s_narration_01-03_video.mp4", chapterID)
 	uploadReq := &service.UploadFileRequest{
 		UserID:      narration.UserID,
 		FileName:    fileName,
 		ContentType: "video/mp4",
 		Ext:         "mp4",
-		Data:        finalVideoFile,
+		Data:        file,
+		KeyVars:     s.chapterKeyVarsByID(ctx, narration.NovelID, chapterID, artifactClip, version, sequence),
 	}
-
-	uploadResult, err := s.resourceService.UploadFile(ctx, uploadReq)
+	uploadResult, err := s.resourceService.UploadLargeFile(ctx, uploadReq)
 	if err != nil {
 		return "", fmt.Errorf("upload video: %w", err)
 	}
-
-	// 11. 创建视频记录
-	videoID := id.New()
-
-	// 构建 prompt：合并所有图片的 prompt
-	var videoPrompt string
-	if len(imagePrompts) > 0 {
-		videoPrompt = strings.Join(imagePrompts, "; ")
-	} else {
-		videoPrompt = "图生视频（前3个场景合并）"
-	}
-
-	// 获取章节信息以获取 novel_id
-	chapter, err := s.chapterRepo.FindByID(ctx, chapterID)
-	if err != nil {
-		return "", fmt.Errorf("find chapter: %w", err)
-	}
-
-	videoEntity := &novel.Video{
-		ID:          videoID,
-		ChapterID:  chapterID,
-		NarrationID: narration.ID,
-		NovelID:    chapter.NovelID,
-		UserID:     narration.UserID,
-		Sequence:   1, // 合并视频的 sequence 为 1
-		VideoResourceID: uploadResult.ResourceID,
-		Duration:        totalAudioDuration,
-		VideoType:       novel.VideoTypeNarration,
-		Prompt:          videoPrompt,
-		Version:         version,
-		Status:          novel.VideoStatusCompleted,
-	}
-
-	if err := s.videoRepo.Create(ctx, videoEntity); err != nil {
-		return "", fmt.Errorf("create video record: %w", err)
-	}
-
-	return videoID, nil
+	return uploadResult.ResourceID, nil
 }
 
-// generateSingleNarrationVideo 生成单个场景的视频（内部实现：第4个场景及之后每个单独生成）
-func (s *novelService) generateSingleNarrationVideo(
+// assembleShotClip 合成单个镜头的片段到 outputPath（不上传，返回的片段没有 ResourceID）
+func (s *novelService) assembleShotClip(
 	ctx context.Context,
 	chapterID string,
 	narration *novel.Narration,
@@ -546,33 +496,45 @@ func (s *novelService) generateSingleNarrationVideo(
 		Index       int
 	},
 	narrationNum string,
-	version int,
+	videoPrompt string,
+	pause float64, // 片段末尾的停顿（秒）
+	platform novel.TargetPlatform,
 	ffmpegClient *ffmpeg.Client,
-) (string, error) {
+	opts shotClipOptions,
+	outputPath string,
+) (*renderedShotClip, error) {
+	start := time.Now()
+	var sourceReused bool
+	var timings novel.ShotClipRenderTimings
+
 	// 1. 优先使用分镜头的图片（Image 表）
-	image, err := s.imageRepo.FindBySceneAndShot(ctx, chapterID, shotInfo.SceneNumber, shotInfo.ShotNumber)
+	image, err := s.findShotImage(ctx, chapterID, shotInfo.Shot, shotInfo.SceneNumber, shotInfo.ShotNumber)
 	if err != nil {
 		// 如果分镜头图片不存在，尝试使用角色图片或场景图片（简化逻辑：先不实现，直接返回错误）
-		return "", fmt.Errorf("find image: %w", err)
+		return nil, fmt.Errorf("find image: %w", err)
 	}
 
 	// 2. 获取对应的音频（通过 sequence 匹配）
 	audios, err := s.audioRepo.FindByNarrationID(ctx, narration.ID)
 	if err != nil {
-		return "", fmt.Errorf("find audios: %w", err)
+		return nil, fmt.Errorf("find audios: %w", err)
 	}
 
-	// 找到对应 sequence 的音频（narration_04 对应 sequence=4）
+	// 找到对应镜头的音频：优先按镜头ID匹配，旧数据按 sequence 匹配（narration_04 对应 sequence=4）
 	var audio *novel.Audio
 	for _, a := range audios {
-		if a.Sequence == shotInfo.Index {
+		if a.ShotID != "" && a.ShotID == shotInfo.Shot.ID {
+			audio = a
+			break
+		}
+		if a.ShotID == "" && a.Sequence == shotInfo.Index {
 			audio = a
 			break
 		}
 	}
 
 	if audio == nil {
-		return "", fmt.Errorf("audio not found for sequence %d", shotInfo.Index)
+		return nil, fmt.Errorf("audio not found for sequence %d", shotInfo.Index)
 	}
 
 	audioDuration := audio.Duration
@@ -587,14 +549,17 @@ func (s *novelService) generateSingleNarrationVideo(
 			Msg("音频 duration 为 0，使用默认值 10 秒")
 	}
 
-	// 3. 下载图片
+	// 3. 下载图片（预览时先缓存到本地，反复预览同一镜头不再从存储下载）
+	if opts.Preview {
+		s.stageShotInput(ctx, image.ImageResourceID)
+	}
 	imageDownloadReq := &service.DownloadFileRequest{
 		ResourceID: image.ImageResourceID,
 		UserID:     narration.UserID,
 	}
 	imageResult, err := s.resourceService.DownloadFile(ctx, imageDownloadReq)
 	if err != nil {
-		return "", fmt.Errorf("download image: %w", err)
+		return nil, fmt.Errorf("download image: %w", err)
 	}
 	defer imageResult.Data.Close()
 
@@ -603,66 +568,96 @@ func (s *novelService) generateSingleNarrationVideo(
 	defer os.Remove(tmpImagePath)
 	imageFile, err := os.Create(tmpImagePath)
 	if err != nil {
-		return "", fmt.Errorf("create temp image file: %w", err)
+		return nil, fmt.Errorf("create temp image file: %w", err)
 	}
 	if _, err := io.Copy(imageFile, imageResult.Data); err != nil {
 		imageFile.Close()
-		return "", fmt.Errorf("copy image data: %w", err)
+		return nil, fmt.Errorf("copy image data: %w", err)
 	}
 	imageFile.Close()
 
 	// 读取图片数据，转换为 base64 data URL
 	imageData, err := os.ReadFile(tmpImagePath)
 	if err != nil {
-		return "", fmt.Errorf("read image file: %w", err)
+		return nil, fmt.Errorf("read image file: %w", err)
 	}
 	imageBase64 := base64.StdEncoding.EncodeToString(imageData)
 	imageDataURL := fmt.Sprintf("data:image/jpeg;base64,%s", imageBase64)
 
 	// 4. 构建视频 prompt（简化逻辑：直接使用 shot 的 video_prompt，如果没有则使用默认值）
-	videoPrompt := shotInfo.Shot.VideoPrompt
+	if videoPrompt == "" {
+		videoPrompt = shotInfo.Shot.VideoPrompt
+	}
 	if videoPrompt == "" {
 		videoPrompt = "画面有明显的动态效果，镜头缓慢推进，人物有自然的动作和表情变化，背景有轻微的运动感，整体画面流畅自然"
 	}
+	videoPrompt = s.renderNovelPrompt(ctx, narration.NovelID, videoPrompt)
 
 	// 5. 从图片创建视频
 	// 参考 Python 版本：直接使用音频时长作为视频时长，不解析 video_prompt 中的时长
 	// 如果音频时长 <= 12 秒，使用 Ark API 生成视频（使用 videoPrompt）
-	// 如果音频时长 > 12 秒，使用 FFmpeg 从图片创建视频（Ken Burns 效果）
+	// 如果音频时长 > 12 秒，在最后的单次合成中直接从图片生成画面（Ken Burns 效果）
+	gen := s.generationSettings(ctx, narration.NovelID)
+	assembly := ffmpeg.ShotAssembly{
+		Duration:  audioDuration,
+		TailPause: pause,
+		Width:     gen.VideoWidth,
+		Height:    gen.VideoHeight,
+		FPS:       gen.VideoFPS,
+	}
 	tmpVideoPath := filepath.Join(tmpDir, fmt.Sprintf("video_%s.mp4", id.New()))
 	defer os.Remove(tmpVideoPath)
 
 	if audioDuration <= 12.0 {
 		// 使用 Ark API 生成视频（限制最大 12 秒）
 		limitedDuration := int(audioDuration)
-		videoData, err := s.videoProvider.GenerateVideoFromImage(ctx, imageDataURL, limitedDuration, videoPrompt)
-		if err != nil {
-			return "", fmt.Errorf("generate video from image: %w", err)
+		sourceStart := time.Now()
+		sourceKey := s.shotSourceCacheKey(image.ImageResourceID, videoPrompt, limitedDuration)
+		if opts.Preview || opts.CachedSourceOnly {
+			sourceReused = s.loadShotSource(sourceKey, tmpVideoPath)
 		}
+		if !sourceReused {
+			if opts.CachedSourceOnly {
+				return nil, errShotSourceNotCached
+			}
+			if err := s.checkBudget(ctx, narration.NovelID); err != nil {
+				return nil, err
+			}
+			videoData, err := s.videoProvider.GenerateVideoFromImage(ctx, imageDataURL, limitedDuration, videoPrompt)
+			if err != nil {
+				return nil, fmt.Errorf("generate video from image: %w", err)
+			}
+			s.recordCost(ctx, narration.NovelID, chapterID, killswitch.ProviderVideo, float64(limitedDuration), s.pricing.Video(float64(limitedDuration)))
 
-		// 保存视频数据到临时文件
-		if err := os.WriteFile(tmpVideoPath, videoData, 0644); err != nil {
-			return "", fmt.Errorf("save video file: %w", err)
+			// 保存视频数据到临时文件
+			if err := os.WriteFile(tmpVideoPath, videoData, 0644); err != nil {
+				return nil, fmt.Errorf("save video file: %w", err)
+			}
+			if opts.Preview {
+				s.storeShotSource(sourceKey, videoData)
+			}
 		}
+		timings.SourceMs = time.Since(sourceStart).Milliseconds()
 	} else {
 		// 音频时长超过 12 秒，使用 FFmpeg 从图片创建视频（Ken Burns 效果）
 		// 参考 Python: create_image_video_with_effects
 		log.Info().
 			Float64("audio_duration", audioDuration).
 			Msg("音频时长超过 12 秒，使用 FFmpeg 从图片创建视频")
-		if err := ffmpegClient.CreateImageVideo(ctx, tmpImagePath, tmpVideoPath, audioDuration, 720, 1280, 30); err != nil {
-			return "", fmt.Errorf("create image video: %w", err)
-		}
+		assembly.ImagePath = tmpImagePath
 	}
 
 	// 6. 下载音频文件
+	if opts.Preview {
+		s.stageShotInput(ctx, audio.AudioResourceID)
+	}
 	audioDownloadReq := &service.DownloadFileRequest{
 		ResourceID: audio.AudioResourceID,
 		UserID:     narration.UserID,
 	}
 	audioResult, err := s.resourceService.DownloadFile(ctx, audioDownloadReq)
 	if err != nil {
-		return "", fmt.Errorf("download audio: %w", err)
+		return nil, fmt.Errorf("download audio: %w", err)
 	}
 	defer audioResult.Data.Close()
 
@@ -670,28 +665,35 @@ func (s *novelService) generateSingleNarrationVideo(
 	defer os.Remove(tmpAudioPath)
 	audioFile, err := os.Create(tmpAudioPath)
 	if err != nil {
-		return "", fmt.Errorf("create temp audio file: %w", err)
+		return nil, fmt.Errorf("create temp audio file: %w", err)
 	}
 	if _, err := io.Copy(audioFile, audioResult.Data); err != nil {
 		audioFile.Close()
-		return "", fmt.Errorf("copy audio data: %w", err)
+		return nil, fmt.Errorf("copy audio data: %w", err)
 	}
 	audioFile.Close()
 
-	// 7. 获取对应音频片段的字幕文件
-	subtitle, err := s.subtitleRepo.FindByNarrationIDAndSequence(ctx, narration.ID, audio.Sequence)
+	// 7. 获取对应音频片段的字幕文件（章节选中了导入的字幕时使用选中的版本）
+	subtitles, err := s.findNarrationSubtitles(ctx, narration)
 	if err != nil {
-		return "", fmt.Errorf("find subtitle for sequence %d: %w", audio.Sequence, err)
+		return nil, err
+	}
+	subtitle, ok := subtitles[audio.Sequence]
+	if !ok {
+		return nil, fmt.Errorf("subtitle not found for sequence %d", audio.Sequence)
 	}
 
 	// 下载字幕文件
+	if opts.Preview {
+		s.stageShotInput(ctx, subtitle.SubtitleResourceID)
+	}
 	subtitleDownloadReq := &service.DownloadFileRequest{
 		ResourceID: subtitle.SubtitleResourceID,
 		UserID:     narration.UserID,
 	}
 	subtitleResult, err := s.resourceService.DownloadFile(ctx, subtitleDownloadReq)
 	if err != nil {
-		return "", fmt.Errorf("download subtitle: %w", err)
+		return nil, fmt.Errorf("download subtitle: %w", err)
 	}
 	defer subtitleResult.Data.Close()
 
@@ -700,14 +702,26 @@ func (s *novelService) generateSingleNarrationVideo(
 	defer os.Remove(tmpSubtitlePath)
 	subtitleFile, err := os.Create(tmpSubtitlePath)
 	if err != nil {
-		return "", fmt.Errorf("create temp subtitle file: %w", err)
+		return nil, fmt.Errorf("create temp subtitle file: %w", err)
 	}
 	if _, err := io.Copy(subtitleFile, subtitleResult.Data); err != nil {
 		subtitleFile.Close()
-		return "", fmt.Errorf("copy subtitle data: %w", err)
+		return nil, fmt.Errorf("copy subtitle data: %w", err)
 	}
 	subtitleFile.Close()
 
+	// 7.1. 请求指定了字幕样式时改写字幕文件的样式，再按目标平台的安全区调整字幕边距，避免字幕被平台 UI 遮挡
+	if style, ok := ctxutil.GetSubtitleStyle(ctx); ok {
+		if err := applySubtitleStyleToASSFile(tmpSubtitlePath, style); err != nil {
+			return nil, fmt.Errorf("apply subtitle style: %w", err)
+		}
+	}
+	if area := noveltools.SafeAreaForPlatform(platform); !area.IsZero() {
+		if err := applySafeAreaToASSFile(tmpSubtitlePath, area); err != nil {
+			return nil, fmt.Errorf("apply subtitle safe area: %w", err)
+		}
+	}
+
 	// 7.5. 诊断：检查字幕时间戳和音频时长的同步情况
 	// 用于排查为什么会出现字幕和音频不同步的问题
 	subtitleContent, err := os.ReadFile(tmpSubtitlePath)
@@ -770,91 +784,86 @@ func (s *novelService) generateSingleNarrationVideo(
 		}
 	}
 
-	// 7.6. 诊断：检查视频实际时长和音频时长的差异
-	videoInfo, err := ffmpegClient.GetVideoInfo(ctx, tmpVideoPath)
-	if err != nil {
-		log.Warn().Err(err).Msg("无法获取视频信息，跳过视频时长诊断")
-	} else {
-		actualVideoDuration := videoInfo.Duration
-		durationDiff := actualVideoDuration - audioDuration
-		log.Info().
-			Str("narration_id", narration.ID).
-			Int("sequence", audio.Sequence).
-			Float64("audio_duration", audioDuration).
-			Float64("video_duration", actualVideoDuration).
-			Float64("duration_diff", durationDiff).
-			Str("video_generation_method", func() string {
-				if audioDuration <= 12.0 {
-					return "Ark API"
-				}
-				return "FFmpeg (Ken Burns)"
-			}()).
-			Msg("视频时长诊断：对比音频和视频实际时长")
-
-		if abs(durationDiff) > 0.5 {
-			log.Warn().
+	// 7.6. 诊断：检查 Ark API 生成的视频实际时长和音频时长的差异（Ken Burns 画面在合成时按音频时长生成）
+	if assembly.ImagePath == "" {
+		videoInfo, err := ffmpegClient.GetVideoInfo(ctx, tmpVideoPath)
+		if err != nil {
+			log.Warn().Err(err).Msg("无法获取视频信息，跳过视频时长诊断")
+		} else {
+			actualVideoDuration := videoInfo.Duration
+			durationDiff := actualVideoDuration - audioDuration
+			log.Info().
 				Str("narration_id", narration.ID).
 				Int("sequence", audio.Sequence).
 				Float64("audio_duration", audioDuration).
 				Float64("video_duration", actualVideoDuration).
 				Float64("duration_diff", durationDiff).
-				Msg("⚠️ 视频时长和音频时长差异较大，可能导致字幕不匹配")
+				Str("video_generation_method", "Ark API").
+				Msg("视频时长诊断：对比音频和视频实际时长")
+
+			if abs(durationDiff) > 0.5 {
+				log.Warn().
+					Str("narration_id", narration.ID).
+					Int("sequence", audio.Sequence).
+					Float64("audio_duration", audioDuration).
+					Float64("video_duration", actualVideoDuration).
+					Float64("duration_diff", durationDiff).
+					Msg("⚠️ 视频时长和音频时长差异较大，可能导致字幕不匹配")
+			}
 		}
 	}
 
-	// 8. 添加字幕到视频
-	tmpWithSubtitlePath := filepath.Join(tmpDir, fmt.Sprintf("video_subtitle_%s.mp4", id.New()))
-	defer os.Remove(tmpWithSubtitlePath)
-
-	if err := ffmpegClient.AddSubtitles(ctx, tmpVideoPath, tmpSubtitlePath, tmpWithSubtitlePath); err != nil {
-		return "", fmt.Errorf("add subtitles: %w", err)
-	}
-
-	// 9. 替换音频（参考 Python 版本：直接使用音频文件，FFmpeg 会自动处理时长对齐）
-	tmpFinalPath := filepath.Join(tmpDir, fmt.Sprintf("video_final_%s.mp4", id.New()))
-	defer os.Remove(tmpFinalPath)
-
-	if err := s.replaceVideoAudio(ctx, tmpWithSubtitlePath, tmpAudioPath, tmpFinalPath, ffmpegClient); err != nil {
-		return "", fmt.Errorf("replace audio: %w", err)
-	}
-
-	// 12. 标准化视频分辨率
-	tmpStandardizedPath := filepath.Join(tmpDir, fmt.Sprintf("video_std_%s.mp4", id.New()))
-	defer os.Remove(tmpStandardizedPath)
-
-	if err := ffmpegClient.StandardizeVideo(ctx, tmpFinalPath, tmpStandardizedPath, 720, 1280, 30); err != nil {
-		return "", fmt.Errorf("standardize video: %w", err)
+	// 8. 单次合成：缩放裁剪、烧录字幕并替换为镜头音频（只编码一次）
+	if assembly.ImagePath == "" {
+		assembly.VideoPath = tmpVideoPath
 	}
-
-	// 11. 上传视频
-	finalVideoFile, err := os.Open(tmpStandardizedPath)
-	if err != nil {
-		return "", fmt.Errorf("open final video: %w", err)
+	assembly.AudioPath = tmpAudioPath
+	assembly.SubtitlePath = tmpSubtitlePath
+	assembly.Preview = opts.Preview
+	encodeStart := time.Now()
+	if err := ffmpegClient.AssembleShot(ctx, assembly, outputPath); err != nil {
+		return nil, fmt.Errorf("assemble shot: %w", err)
 	}
-	defer finalVideoFile.Close()
+	timings.EncodeMs = time.Since(encodeStart).Milliseconds()
+	timings.InputsMs = time.Since(start).Milliseconds() - timings.SourceMs - timings.EncodeMs
 
-	fileName := fmt.Sprintf("%s_narration_%s_video.mp4", chapterID, narrationNum)
-	uploadReq := &service.UploadFileRequest{
-		UserID:      narration.UserID,
-		FileName:    fileName,
-		ContentType: "video/mp4",
-		Ext:         "mp4",
-		Data:        finalVideoFile,
-	}
+	return &renderedShotClip{
+		Duration:     audioDuration + pause,
+		Prompt:       videoPrompt,
+		SourceReused: sourceReused,
+		Timings:      timings,
+	}, nil
+}
 
-	uploadResult, err := s.resourceService.UploadFile(ctx, uploadReq)
+// generateSingleNarrationVideo 生成单个镜头的视频（内部实现：合并分组中单独成组的镜头），rule 记录分组使用的合并规则
+func (s *novelService) generateSingleNarrationVideo(
+	ctx context.Context,
+	chapterID string,
+	narration *novel.Narration,
+	shotInfo struct {
+		SceneNumber string
+		ShotNumber  string
+		Shot        *novel.Shot
+		Index       int
+	},
+	narrationNum string,
+	version int,
+	pause float64,
+	rule novel.ClipMergeMode,
+	platform novel.TargetPlatform,
+	ffmpegClient *ffmpeg.Client,
+) (string, error) {
+	clip, err := s.renderShotClip(ctx, chapterID, narration, shotInfo, narrationNum, "", version, pause, platform, ffmpegClient, shotClipOptions{})
 	if err != nil {
-		return "", fmt.Errorf("upload video: %w", err)
+		return "", err
 	}
 
-	// 12. 创建视频记录
+	// 创建视频记录
 	videoID := id.New()
 	// 使用 shotInfo.Index 作为 sequence，确保与分镜顺序一致
-	// shotInfo.Index 是按照分镜顺序从 1 开始递增的（前 3 个分镜合并成一个视频，sequence=1）
+	// shotInfo.Index 是按照分镜顺序从 1 开始递增的
 	sequence := shotInfo.Index
 
-	// videoPrompt 已经在前面（第 571 行）构建好了，这里直接使用
-
 	// 获取章节信息以获取 novel_id
 	chapter, err := s.chapterRepo.FindByID(ctx, chapterID)
 	if err != nil {
@@ -867,18 +876,25 @@ func (s *novelService) generateSingleNarrationVideo(
 		NarrationID: narration.ID,
 		NovelID:    chapter.NovelID,
 		UserID:     narration.UserID,
+		ShotID:     shotInfo.Shot.ID,
 		Sequence:   sequence,
-		VideoResourceID: uploadResult.ResourceID,
-		Duration:        audioDuration,
+		MergeRule:  rule,
+		VideoResourceID: clip.ResourceID,
+		Duration:        clip.Duration,
 		VideoType:       novel.VideoTypeNarration,
-		Prompt:          videoPrompt,
+		Prompt:          clip.Prompt,
 		Version:         version,
 		Status:          novel.VideoStatusCompleted,
+		Platform:        platform,
+	}
+	if style, ok := ctxutil.GetSubtitleStyle(ctx); ok {
+		videoEntity.SubtitleStyle = style
 	}
 
 	if err := s.videoRepo.Create(ctx, videoEntity); err != nil {
 		return "", fmt.Errorf("create video record: %w", err)
 	}
+	s.generateVideoThumbnails(ctx, videoEntity)
 
 	return videoID, nil
 }
@@ -907,7 +923,7 @@ func (s *novelService) mergeAudioFiles(ctx context.Context, audioPaths []string,
 	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
 	cmd.Stderr = os.Stderr
 
-	if err := cmd.Run(); err != nil {
+	if err := procreaper.Run(ctx, cmd); err != nil {
 		return fmt.Errorf("ffmpeg merge audio failed: %w", err)
 	}
 
@@ -1089,16 +1105,56 @@ func (s *novelService) GenerateFinalVideoForChapter(ctx context.Context, chapter
 }
 
 func (s *novelService) GenerateFinalVideoForChapterWithVersion(ctx context.Context, chapterID string, version int) (string, error) {
-	return s.generateFinalVideoForChapter(ctx, chapterID, version)
+	return s.generateFinalVideoForChapter(ctx, chapterID, version, novel.ExportTierPreview, "")
+}
+
+// GenerateFinalVideoForChapterWithTier 按导出档位生成章节的最终完整视频
+func (s *novelService) GenerateFinalVideoForChapterWithTier(ctx context.Context, chapterID string, version int, tier novel.ExportTier, licenseeID string) (string, error) {
+	if !tier.IsValid() {
+		return "", fmt.Errorf("invalid export tier: %s", tier)
+	}
+	return s.generateFinalVideoForChapter(ctx, chapterID, version, tier, licenseeID)
+}
+
+// ListLicenseGrantsByChapter 获取章节的授权记录
+func (s *novelService) ListLicenseGrantsByChapter(ctx context.Context, chapterID string) ([]*novel.LicenseGrant, error) {
+	return s.licenseGrantRepo.FindByChapterID(ctx, chapterID)
+}
+
+// generateFinalVideoForChapter 生成章节的最终完整视频，完成后把章节渲染的耗时与费用明细保存到最终视频记录上
+func (s *novelService) generateFinalVideoForChapter(ctx context.Context, chapterID string, version int, tier novel.ExportTier, licenseeID string) (string, error) {
+	var videoID string
+	err := s.runChapterStage(ctx, chapterID, novel.PipelineStageFinalVideo, func(ctx context.Context) error {
+		var err error
+		videoID, err = s.renderFinalVideo(ctx, chapterID, version, tier, licenseeID)
+		if err == nil {
+			setStageResources(ctx, videoID)
+		}
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	s.saveRenderBreakdown(ctx, chapterID, videoID)
+	s.maybeStartStandby(ctx, chapterID)
+	return videoID, nil
 }
 
-func (s *novelService) generateFinalVideoForChapter(ctx context.Context, chapterID string, version int) (string, error) {
+func (s *novelService) renderFinalVideo(ctx context.Context, chapterID string, version int, tier novel.ExportTier, licenseeID string) (string, error) {
 	// 1. 获取章节信息
 	chapter, err := s.chapterRepo.FindByID(ctx, chapterID)
 	if err != nil {
 		return "", fmt.Errorf("find chapter: %w", err)
 	}
 
+	// 1.5. 授权版（无水印）只允许导出已审核通过的章节
+	if tier == novel.ExportTierLicensed && !chapter.IsApproved() {
+		return "", ErrChapterNotApproved
+	}
+	if licenseeID == "" {
+		licenseeID = chapter.UserID
+	}
+
 	// 2. 确定要合并的版本号：version<=0 则取最新版本
 	videoVersion, err := s.resolveVideoVersion(ctx, chapterID, version)
 	if err != nil {
@@ -1130,6 +1186,14 @@ func (s *novelService) generateFinalVideoForChapter(ctx context.Context, chapter
 
 	narrationVideos = filteredNarrationVideos
 
+	// 拼接前校验片段的镜头序号范围：合并片段与单镜头片段重叠、或有镜头缺失时拒绝拼接，避免最终视频内容重复或跳镜
+	if err := noveltools.ValidateVideoComposition(narrationVideos); err != nil {
+		return "", fmt.Errorf("validate video composition for version %d: %w", videoVersion, err)
+	}
+
+	// 最终视频沿用 narration 视频的目标平台（字幕已按该平台的安全区烧录）
+	platform := narrationVideos[0].Platform
+
 	log.Info().
 		Str("chapter_id", chapterID).
 		Int("version", videoVersion).
@@ -1177,6 +1241,31 @@ func (s *novelService) generateFinalVideoForChapter(ctx context.Context, chapter
 		return "", fmt.Errorf("concat videos: %w", err)
 	}
 
+	// 5.2. 按场景情绪混入背景音乐（曲库为空或失败时不加背景音乐，不影响生成）
+	bgmPath, bgmCues := s.mixSceneBGM(ctx, chapter, narrationVideos, videoPaths, tmpMergedPath, tmpDir, ffmpegClient)
+	if bgmPath != tmpMergedPath {
+		defer os.Remove(bgmPath)
+		tmpMergedPath = bgmPath
+	}
+
+	// 5.5. 章节衔接：在开头叠加上一章的最后一帧并淡出（失败时不衔接，不影响生成）
+	var recapVideoID string
+	if frame := s.loadContinuityFrame(ctx, chapter, novel.ChapterContinuityRecapOverlay); frame != nil {
+		framePath := filepath.Join(tmpDir, fmt.Sprintf("recap_frame_%s.jpg", id.New()))
+		defer os.Remove(framePath)
+		recapPath := filepath.Join(tmpDir, fmt.Sprintf("recap_%s.mp4", id.New()))
+		defer os.Remove(recapPath)
+
+		if err := os.WriteFile(framePath, frame.image, 0644); err != nil {
+			log.Warn().Err(err).Str("chapter_id", chapterID).Msg("写入上一章最后一帧失败，跳过章节衔接")
+		} else if err := ffmpegClient.OverlayIntroImage(ctx, tmpMergedPath, framePath, recapPath, recapOverlayDuration); err != nil {
+			log.Warn().Err(err).Str("chapter_id", chapterID).Msg("叠加前情回顾过渡失败，跳过章节衔接")
+		} else {
+			tmpMergedPath = recapPath
+			recapVideoID = frame.videoID
+		}
+	}
+
 	// 6. 添加 finish.mp4（如果存在）
 	finishVideoPath := s.getFinishVideoPath()
 	var finalVideoPath string
@@ -1210,7 +1299,7 @@ func (s *novelService) generateFinalVideoForChapter(ctx context.Context, chapter
 			cmd := exec.CommandContext(ctx, "ffmpeg", args...)
 			var stderr bytes.Buffer
 			cmd.Stderr = &stderr
-			if err := cmd.Run(); err != nil {
+			if err := procreaper.Run(ctx, cmd); err != nil {
 				return "", fmt.Errorf("concat with finish video: %w, stderr: %s", err, stderr.String())
 			}
 
@@ -1223,14 +1312,81 @@ func (s *novelService) generateFinalVideoForChapter(ctx context.Context, chapter
 		finalVideoPath = tmpMergedPath
 	}
 
-	// 7. 标准化视频分辨率
+	// 7. 标准化视频分辨率（授权版使用生成参数中的渲染分辨率，预览版按相同画幅缩小到 720p）
 	tmpFinalPath := filepath.Join(tmpDir, fmt.Sprintf("final_%s.mp4", id.New()))
 	defer os.Remove(tmpFinalPath)
 
-	if err := ffmpegClient.StandardizeVideo(ctx, finalVideoPath, tmpFinalPath, 720, 1280, 30); err != nil {
+	gen := s.generationSettings(ctx, chapter.NovelID)
+	width, height := gen.VideoWidth, gen.VideoHeight
+	if tier == novel.ExportTierPreview {
+		width, height = noveltools.PreviewResolution(width, height)
+	}
+	if err := ffmpegClient.StandardizeVideo(ctx, finalVideoPath, tmpFinalPath, width, height, gen.VideoFPS); err != nil {
 		return "", fmt.Errorf("standardize video: %w", err)
 	}
 
+	// 7.5. 预览版添加水印（在目标平台的安全区内居中，避开平台 UI）
+	if tier == novel.ExportTierPreview {
+		tmpWatermarkedPath := filepath.Join(tmpDir, fmt.Sprintf("watermarked_%s.mp4", id.New()))
+		defer os.Remove(tmpWatermarkedPath)
+
+		area := noveltools.SafeAreaForPlatform(platform)
+		if err := ffmpegClient.AddWatermarkInSafeArea(ctx, tmpFinalPath, tmpWatermarkedPath, getWatermarkText(), os.Getenv("WATERMARK_FONT_PATH"),
+			area.Top, area.Bottom, area.Left, area.Right); err != nil {
+			return "", fmt.Errorf("add watermark: %w", err)
+		}
+		tmpFinalPath = tmpWatermarkedPath
+	}
+
+	// 7.6. 渲染原作署名（开头叠加或结尾署名画面）；要求署名的小说渲染失败时不导出
+	attributedPath, attribution, err := s.renderAttribution(ctx, chapter.NovelID, tmpFinalPath, tmpDir, platform, ffmpegClient)
+	if err != nil {
+		return "", fmt.Errorf("render attribution: %w", err)
+	}
+	if attributedPath != tmpFinalPath {
+		defer os.Remove(attributedPath)
+		tmpFinalPath = attributedPath
+	}
+
+	// 7.8. 按目标平台规范处理响度、码率和封装格式，并生成校验报告
+	compliancePath := filepath.Join(tmpDir, fmt.Sprintf("compliant_%s.mp4", id.New()))
+	defer os.Remove(compliancePath)
+	compliance, err := s.applyPlatformCompliance(ctx, ffmpegClient, tmpFinalPath, compliancePath, platform)
+	if err != nil {
+		return "", fmt.Errorf("apply platform compliance: %w", err)
+	}
+	tmpFinalPath = compliancePath
+
+	// 7.85. 生成无障碍输出：文字稿，以及（开启时）口述影像音轨和描述轨（失败时跳过，不影响生成）
+	accessibility := s.generateAccessibilityOutputs(ctx, chapter, narrationVideos, videoPaths, tmpFinalPath, videoVersion, tmpDir, ffmpegClient)
+
+	// 7.9. 生成流水线清单（输入素材、provider/模型、提示词哈希和工具版本），按配置嵌入 MP4 元数据
+	videoID := id.New()
+	manifest, err := s.buildPipelineManifest(ctx, &pipelineManifestInputs{
+		videoID:         videoID,
+		chapter:         chapter,
+		version:         videoVersion,
+		tier:            tier,
+		platform:        platform,
+		narrationVideos: narrationVideos,
+		bgmCues:         bgmCues,
+		recapVideoID:    recapVideoID,
+		compliance:      compliance,
+		accessibility:   accessibility,
+		attribution:     attribution,
+	}, ffmpegClient)
+	if err != nil {
+		return "", fmt.Errorf("build pipeline manifest: %w", err)
+	}
+	manifestResourceID, manifestSHA256, manifestVideoPath, err := s.storePipelineManifest(ctx, manifest, chapter.UserID, tmpFinalPath, tmpDir, ffmpegClient)
+	if err != nil {
+		return "", fmt.Errorf("store pipeline manifest: %w", err)
+	}
+	if manifestVideoPath != tmpFinalPath {
+		defer os.Remove(manifestVideoPath)
+		tmpFinalPath = manifestVideoPath
+	}
+
 	// 8. 上传最终视频到 resource 模块
 	finalVideoFile, err := os.Open(tmpFinalPath)
 	if err != nil {
@@ -1238,16 +1394,17 @@ func (s *novelService) generateFinalVideoForChapter(ctx context.Context, chapter
 	}
 	defer finalVideoFile.Close()
 
-	fileName := fmt.Sprintf("%s_final_video.mp4", chapterID)
+	fileName := fmt.Sprintf("%s_final_video_%s.mp4", chapterID, tier)
 	uploadReq := &service.UploadFileRequest{
 		UserID:      chapter.UserID,
 		FileName:    fileName,
 		ContentType: "video/mp4",
 		Ext:         "mp4",
 		Data:        finalVideoFile,
+		KeyVars:     chapterKeyVars(chapter, artifactFinalVideo, videoVersion, 1),
 	}
 
-	uploadResult, err := s.resourceService.UploadFile(ctx, uploadReq)
+	uploadResult, err := s.resourceService.UploadLargeFile(ctx, uploadReq)
 	if err != nil {
 		return "", fmt.Errorf("upload video: %w", err)
 	}
@@ -1257,10 +1414,12 @@ func (s *novelService) generateFinalVideoForChapter(ctx context.Context, chapter
 	for _, video := range narrationVideos {
 		totalDuration += video.Duration
 	}
+	if attribution != nil && attribution.Placement == novel.AttributionPlacementEndFrame {
+		totalDuration += attribution.Duration
+	}
 
 	// 10. 创建最终视频记录
 	// 使用与 narration 视频相同的版本号（已在前面获取）
-	videoID := id.New()
 	videoEntity := &novel.Video{
 		ID:        videoID,
 		ChapterID: chapterID,
@@ -1272,11 +1431,50 @@ func (s *novelService) generateFinalVideoForChapter(ctx context.Context, chapter
 		VideoType:       novel.VideoTypeFinal,
 		Version:         videoVersion, // 使用与 narration 视频相同的版本号
 		Status:          novel.VideoStatusCompleted,
+		ExportTier:      tier,
+		Platform:        platform,
+		Compliance:      compliance,
+		ManifestResourceID: manifestResourceID,
+		ManifestSHA256:     manifestSHA256,
+		Attribution:        attribution,
+	}
+	// 字幕在 narration 视频中烧录，最终视频沿用同一版本 narration 视频的字幕样式
+	if len(narrationVideos) > 0 {
+		videoEntity.SubtitleStyle = narrationVideos[0].SubtitleStyle
+	}
+	if accessibility != nil {
+		videoEntity.TranscriptResourceID = accessibility.transcriptResourceID
+		videoEntity.AudioDescriptionResourceID = accessibility.audioDescriptionResourceID
+		videoEntity.DescriptionVTTResourceID = accessibility.descriptionVTTResourceID
 	}
 
 	if err := s.videoRepo.Create(ctx, videoEntity); err != nil {
 		return "", fmt.Errorf("create video record: %w", err)
 	}
+	s.generateVideoThumbnails(ctx, videoEntity)
+
+	// 11. 授权版记录授权
+	if tier == novel.ExportTierLicensed {
+		grant := &novel.LicenseGrant{
+			ID:        id.New(),
+			VideoID:   videoID,
+			ChapterID: chapterID,
+			NovelID:   chapter.NovelID,
+			UserID:    licenseeID,
+			Tier:      tier,
+			Version:   videoVersion,
+		}
+		if err := s.licenseGrantRepo.Create(ctx, grant); err != nil {
+			return "", fmt.Errorf("create license grant: %w", err)
+		}
+
+		log.Info().
+			Str("chapter_id", chapterID).
+			Str("video_id", videoID).
+			Str("license_id", grant.ID).
+			Str("user_id", licenseeID).
+			Msg("授权版最终视频导出成功")
+	}
 
 	return videoID, nil
 }
@@ -1286,58 +1484,6 @@ func (s *novelService) GetVideoVersions(ctx context.Context, chapterID string) (
 	return s.videoRepo.FindVersionsByChapterID(ctx, chapterID)
 }
 
-// GetVideosByStatus 根据状态查询视频（用于轮询）
-func (s *novelService) GetVideosByStatus(ctx context.Context, status novel.VideoStatus) ([]*novel.Video, error) {
-	return s.videoRepo.FindByStatus(ctx, status)
-}
-
-// getNextVideoVersion 获取章节的下一个视频版本号（自动递增）
-func (s *novelService) getNextVideoVersion(ctx context.Context, chapterID string, baseVersion int) (int, error) {
-	versions, err := s.videoRepo.FindVersionsByChapterID(ctx, chapterID)
-	if err != nil {
-		// 如果没有找到任何版本，返回 1 或基础版本号
-		if baseVersion == 0 {
-			return 1, nil
-		}
-		return baseVersion, nil
-	}
-
-	if len(versions) == 0 {
-		if baseVersion == 0 {
-			return 1, nil
-		}
-		return baseVersion, nil
-	}
-
-	// 如果指定了基础版本号，检查该版本是否已存在
-	if baseVersion > 0 {
-		for _, v := range versions {
-			if v == baseVersion {
-				// 该版本已存在，返回下一个版本号
-				maxVersion := 0
-				for _, v := range versions {
-					if v > maxVersion {
-						maxVersion = v
-					}
-				}
-				return maxVersion + 1, nil
-			}
-		}
-		// 该版本不存在，直接返回
-		return baseVersion, nil
-	}
-
-	// 如果没有指定基础版本号，查找所有版本号中的最大值
-	maxVersion := 0
-	for _, v := range versions {
-		if v > maxVersion {
-			maxVersion = v
-		}
-	}
-
-	return maxVersion + 1, nil
-}
-
 // getFinishVideoPath 获取 finish.mp4 文件路径
 // 优先从环境变量 FINISH_VIDEO_PATH 获取，否则使用默认路径
 func (s *novelService) getFinishVideoPath() string {
@@ -1360,6 +1506,33 @@ func (s *novelService) getFinishVideoPath() string {
 	return ""
 }
 
+// applySubtitleStyleToASSFile 按字幕样式改写 ASS 字幕文件的样式（原地改写）
+func applySubtitleStyleToASSFile(path string, style *novel.SubtitleStyle) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(noveltools.ApplySubtitleStyleToASS(string(content), style)), 0644)
+}
+
+// applySafeAreaToASSFile 按安全区调整 ASS 字幕文件的样式边距（原地改写）
+func applySafeAreaToASSFile(path string, area noveltools.SafeArea) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(noveltools.ApplySafeAreaToASS(string(content), area)), 0644)
+}
+
+// getWatermarkText 获取预览版水印文字
+// 优先从环境变量 WATERMARK_TEXT 获取，否则使用默认文字
+func getWatermarkText() string {
+	if text := os.Getenv("WATERMARK_TEXT"); text != "" {
+		return text
+	}
+	return "PREVIEW"
+}
+
 // enhanceVideoPrompt 增强已有的 video_prompt
 // 结合解说内容和场景描述，使视频 prompt 更加丰富和详细
 func enhanceVideoPrompt(baseVideoPrompt, imagePrompt, scenePrompt, narration string) string {
@@ -0,0 +1,135 @@
+package novel
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"lemon/internal/model/novel"
+	novelrepo "lemon/internal/repository/novel"
+)
+
+const (
+	defaultVideoTaskLimit = 50
+	maxVideoTaskLimit     = 200
+
+	// maxVideoTaskWait 长轮询最长等待时间
+	maxVideoTaskWait = 30 * time.Second
+	// videoTaskPollInterval 长轮询等待期间重新查询的间隔
+	videoTaskPollInterval = time.Second
+)
+
+// ErrInvalidVideoTaskQuery 视频任务查询条件不合法（缺少用户/章节范围、状态或游标无效）
+var ErrInvalidVideoTaskQuery = errors.New("invalid video task query")
+
+// VideoTaskService 视频任务状态服务接口（用于轮询视频生成进度）
+type VideoTaskService interface {
+	// ListVideoTasks 按用户或章节查询视频任务状态，游标分页，支持长轮询
+	ListVideoTasks(ctx context.Context, q *VideoTaskQuery) (*VideoTaskPage, error)
+}
+
+// VideoTaskQuery 视频任务查询条件
+type VideoTaskQuery struct {
+	UserID    string              // 用户ID（与 ChapterID 至少指定一个）
+	ChapterID string              // 章节ID
+	Statuses  []novel.VideoStatus // 状态，为空时不限状态
+	Cursor    string              // 上一页返回的 NextCursor，为空时从头开始
+	Limit     int                 // 每页数量（默认50，最大200）
+	Wait      time.Duration       // 长轮询：游标之后没有变化时最多等待多久（最长30秒），0 表示立即返回
+}
+
+// VideoTaskPage 视频任务分页结果
+// 视频按最后更新时间升序排列，NextCursor 指向本页最后一条；没有新数据时原样返回请求的游标，
+// 轮询方保存 NextCursor 后下次只会拿到之后有变化的视频
+type VideoTaskPage struct {
+	Videos     []*novel.Video
+	NextCursor string
+	HasMore    bool // 游标之后是否还有更多数据（无需等待，可以立即请求下一页）
+}
+
+// ListVideoTasks 查询视频任务状态
+func (s *novelService) ListVideoTasks(ctx context.Context, q *VideoTaskQuery) (*VideoTaskPage, error) {
+	if q.UserID == "" && q.ChapterID == "" {
+		return nil, fmt.Errorf("%w: user_id or chapter_id is required", ErrInvalidVideoTaskQuery)
+	}
+	for _, status := range q.Statuses {
+		switch status {
+		case novel.VideoStatusPending, novel.VideoStatusProcessing, novel.VideoStatusCompleted, novel.VideoStatusFailed:
+		default:
+			return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidVideoTaskQuery, status)
+		}
+	}
+	afterUpdatedAt, afterID, err := decodeVideoTaskCursor(q.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultVideoTaskLimit
+	}
+	limit = min(limit, maxVideoTaskLimit)
+	filter := novelrepo.VideoTaskFilter{
+		UserID:         q.UserID,
+		ChapterID:      q.ChapterID,
+		Statuses:       q.Statuses,
+		AfterUpdatedAt: afterUpdatedAt,
+		AfterID:        afterID,
+		Limit:          limit + 1, // 多取一条判断是否还有下一页
+	}
+
+	deadline := time.Now().Add(min(q.Wait, maxVideoTaskWait))
+	for {
+		videos, err := s.videoRepo.FindTasks(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("find video tasks: %w", err)
+		}
+		if len(videos) > 0 || !time.Now().Before(deadline) {
+			page := &VideoTaskPage{Videos: videos, NextCursor: q.Cursor}
+			if len(videos) > limit {
+				page.Videos = videos[:limit]
+				page.HasMore = true
+			}
+			if n := len(page.Videos); n > 0 {
+				page.NextCursor = encodeVideoTaskCursor(page.Videos[n-1])
+			}
+			return page, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(min(videoTaskPollInterval, time.Until(deadline))):
+		}
+	}
+}
+
+// encodeVideoTaskCursor 用视频的 (updated_at, id) 生成游标
+func encodeVideoTaskCursor(v *novel.Video) string {
+	raw := strconv.FormatInt(v.UpdatedAt.UnixMilli(), 10) + ":" + v.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeVideoTaskCursor 解析游标，空游标返回零值
+func decodeVideoTaskCursor(cursor string) (time.Time, string, error) {
+	if cursor == "" {
+		return time.Time{}, "", nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("%w: malformed cursor", ErrInvalidVideoTaskQuery)
+	}
+	millis, id, ok := strings.Cut(string(raw), ":")
+	if !ok || id == "" {
+		return time.Time{}, "", fmt.Errorf("%w: malformed cursor", ErrInvalidVideoTaskQuery)
+	}
+	ms, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("%w: malformed cursor", ErrInvalidVideoTaskQuery)
+	}
+	return time.UnixMilli(ms), id, nil
+}
@@ -0,0 +1,180 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/killswitch"
+	"lemon/internal/pkg/noveltools"
+)
+
+var (
+	// ErrNoVoiceSuggestion 角色还没有选角建议，不能接受建议
+	ErrNoVoiceSuggestion = errors.New("character has no voice suggestion")
+	// ErrUnknownVoice 指定的音色不在音色目录中
+	ErrUnknownVoice = errors.New("voice is not in the catalog")
+)
+
+// VoiceCastingService 角色配音选角服务接口
+type VoiceCastingService interface {
+	// ListVoiceCatalog 查询可选的 TTS 音色目录
+	ListVoiceCatalog(ctx context.Context) []noveltools.VoiceProfile
+
+	// SuggestCharacterVoices 调用大模型为小说的角色推荐配音音色，建议保存在角色的 voice_suggestion 上，不改变已确定的音色
+	// names 为空时为所有角色推荐
+	SuggestCharacterVoices(ctx context.Context, novelID string, names []string) ([]*novel.Character, error)
+
+	// SetCharacterVoice 确定角色的配音音色：voiceType 为空时接受选角建议，否则手动指定（必须在音色目录中）
+	SetCharacterVoice(ctx context.Context, novelID, name, voiceType string) (*novel.Character, error)
+}
+
+// voiceCatalogFromEnv 读取音色目录（TTS_VOICE_CATALOG 为 JSON 文件路径，未配置或读取失败时使用默认目录）
+func voiceCatalogFromEnv() []noveltools.VoiceProfile {
+	path := os.Getenv("TTS_VOICE_CATALOG")
+	if path == "" {
+		return noveltools.DefaultVoiceCatalog()
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Warn().Err(err).Str("path", path).Msg("读取音色目录失败，使用默认目录")
+		return noveltools.DefaultVoiceCatalog()
+	}
+	catalog, err := noveltools.ParseVoiceCatalog(data)
+	if err != nil {
+		log.Warn().Err(err).Str("path", path).Msg("音色目录格式错误，使用默认目录")
+		return noveltools.DefaultVoiceCatalog()
+	}
+	return catalog
+}
+
+// ListVoiceCatalog 查询音色目录
+func (s *novelService) ListVoiceCatalog(ctx context.Context) []noveltools.VoiceProfile {
+	return s.voiceCatalog
+}
+
+// SuggestCharacterVoices 为角色推荐配音音色
+// 大模型没有给出有效建议的角色按性别和年龄段在目录中匹配，保证每个角色都有建议
+func (s *novelService) SuggestCharacterVoices(ctx context.Context, novelID string, names []string) ([]*novel.Character, error) {
+	characters, err := s.castingCharacters(ctx, novelID, names)
+	if err != nil {
+		return nil, err
+	}
+	if len(characters) == 0 {
+		return characters, nil
+	}
+
+	ctx, release, err := s.killSwitch.Guard(ctx, killswitch.ProviderLLM)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if err := s.checkBudget(ctx, novelID); err != nil {
+		return nil, err
+	}
+	prompt, err := noveltools.BuildVoiceCastingPrompt(characters, s.voiceCatalog)
+	if err != nil {
+		return nil, err
+	}
+	prompt = s.withNovelContext(ctx, novelID, prompt)
+	output, err := s.llmProvider.Generate(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("generate voice casting: %w", err)
+	}
+	s.recordLLMCost(ctx, novelID, "", prompt, output)
+
+	suggestions, err := noveltools.ParseVoiceCasting(output, characters, s.voiceCatalog)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	fallbacks := 0
+	for i, character := range characters {
+		suggestion := suggestions[i]
+		suggestion.SuggestedAt = now
+		if err := s.characterRepo.Update(ctx, character.ID, bson.M{"voice_suggestion": suggestion}); err != nil {
+			return nil, fmt.Errorf("save voice suggestion for %s: %w", character.Name, err)
+		}
+		character.VoiceSuggestion = &suggestion
+		if suggestion.Fallback {
+			fallbacks++
+		}
+	}
+
+	log.Info().
+		Str("novel_id", novelID).
+		Int("characters", len(characters)).
+		Int("fallbacks", fallbacks).
+		Msg("角色选角建议已生成")
+	return characters, nil
+}
+
+// castingCharacters 查询要选角的角色：names 为空时返回小说的所有角色，指定的角色不存在时返回 mongo.ErrNoDocuments
+func (s *novelService) castingCharacters(ctx context.Context, novelID string, names []string) ([]*novel.Character, error) {
+	if len(names) == 0 {
+		characters, err := s.characterRepo.FindByNovelID(ctx, novelID)
+		if err != nil {
+			return nil, fmt.Errorf("find characters: %w", err)
+		}
+		return characters, nil
+	}
+
+	characters := make([]*novel.Character, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		character, err := s.characterRepo.FindByNameAndNovelID(ctx, name, novelID)
+		if err != nil {
+			return nil, fmt.Errorf("find character %s: %w", name, err)
+		}
+		characters = append(characters, character)
+	}
+	return characters, nil
+}
+
+// SetCharacterVoice 确定角色的配音音色
+func (s *novelService) SetCharacterVoice(ctx context.Context, novelID, name, voiceType string) (*novel.Character, error) {
+	character, err := s.characterRepo.FindByNameAndNovelID(ctx, name, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("find character %s: %w", name, err)
+	}
+
+	source := novel.CharacterVoiceSourceManual
+	if voiceType == "" {
+		if character.VoiceSuggestion == nil || character.VoiceSuggestion.VoiceType == "" {
+			return nil, ErrNoVoiceSuggestion
+		}
+		voiceType = character.VoiceSuggestion.VoiceType
+		source = novel.CharacterVoiceSourceSuggestion
+	} else if _, ok := noveltools.FindVoice(s.voiceCatalog, voiceType); !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownVoice, voiceType)
+	}
+
+	if err := s.characterRepo.Update(ctx, character.ID, bson.M{
+		"voice_type":   voiceType,
+		"voice_source": source,
+	}); err != nil {
+		return nil, fmt.Errorf("update character voice: %w", err)
+	}
+	character.VoiceType = voiceType
+	character.VoiceSource = source
+
+	log.Info().
+		Str("novel_id", novelID).
+		Str("character", name).
+		Str("voice_type", voiceType).
+		Str("source", string(source)).
+		Msg("角色配音音色已确定")
+	return character, nil
+}
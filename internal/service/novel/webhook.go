@@ -0,0 +1,313 @@
+package novel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/webhook"
+)
+
+const (
+	// defaultWebhookMaxAttempts 任务通知默认最多尝试次数（含第一次）
+	defaultWebhookMaxAttempts = 6
+	// webhookTimeout 单次任务通知请求的超时时间
+	webhookTimeout = 10 * time.Second
+)
+
+var (
+	// ErrInvalidWebhook 任务通知订阅参数不合法
+	ErrInvalidWebhook = errors.New("invalid webhook")
+)
+
+// WebhookService 任务通知服务接口
+type WebhookService interface {
+	// CreateWebhook 注册任务通知地址，返回的订阅带签名密钥（之后不再返回）
+	CreateWebhook(ctx context.Context, req *CreateWebhookRequest) (*novel.Webhook, error)
+
+	// ListWebhooks 查询用户的任务通知订阅
+	ListWebhooks(ctx context.Context, userID string) ([]*novel.Webhook, error)
+
+	// DeleteWebhook 删除用户的任务通知订阅（userID 为空时不校验所属用户）
+	DeleteWebhook(ctx context.Context, userID, webhookID string) error
+
+	// ListWebhookDeliveries 查询订阅的投递记录（按创建时间倒序，userID 为空时不校验所属用户）
+	ListWebhookDeliveries(ctx context.Context, userID, webhookID string, limit int) ([]*novel.WebhookDelivery, error)
+
+	// ResumeWebhookDeliveries 服务启动时恢复未完成的投递（按原定的重试时间继续）
+	ResumeWebhookDeliveries(ctx context.Context) error
+}
+
+// CreateWebhookRequest 注册任务通知请求
+type CreateWebhookRequest struct {
+	UserID    string
+	URL       string
+	TaskTypes []novel.PipelineStage // 为空表示全部任务类型
+}
+
+// stageResourcesKey 阶段产出资源ID在 context 中的 key
+type stageResourcesKey struct{}
+
+// stageResources 阶段产出的资源ID（阶段函数通过 setStageResources 填写，任务通知带上这些ID）
+type stageResources struct {
+	ids []string
+}
+
+// withStageResources 为阶段创建资源ID收集器
+func withStageResources(ctx context.Context) (context.Context, *stageResources) {
+	r := &stageResources{}
+	return context.WithValue(ctx, stageResourcesKey{}, r), r
+}
+
+// setStageResources 记录阶段产出的资源ID（不在 runStage 内执行时忽略）
+func setStageResources(ctx context.Context, ids ...string) {
+	if r, ok := ctx.Value(stageResourcesKey{}).(*stageResources); ok {
+		r.ids = ids
+	}
+}
+
+// webhookMaxAttemptsFromEnv 从环境变量 WEBHOOK_MAX_ATTEMPTS 读取任务通知最多尝试次数
+func webhookMaxAttemptsFromEnv() int {
+	if v, err := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS")); err == nil && v > 0 {
+		return v
+	}
+	return defaultWebhookMaxAttempts
+}
+
+// CreateWebhook 注册任务通知地址
+func (s *novelService) CreateWebhook(ctx context.Context, req *CreateWebhookRequest) (*novel.Webhook, error) {
+	if req.UserID == "" {
+		return nil, fmt.Errorf("%w: user_id is required", ErrInvalidWebhook)
+	}
+	if err := webhook.ValidateURL(ctx, req.URL); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWebhook, err)
+	}
+	for _, t := range req.TaskTypes {
+		if !slices.Contains(novel.AllPipelineStages, t) {
+			return nil, fmt.Errorf("%w: unknown task type %q", ErrInvalidWebhook, t)
+		}
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	w := &novel.Webhook{
+		ID:        id.New(),
+		UserID:    req.UserID,
+		URL:       req.URL,
+		Secret:    secret,
+		TaskTypes: req.TaskTypes,
+		Enabled:   true,
+	}
+	if err := s.webhookRepo.Create(ctx, w); err != nil {
+		return nil, fmt.Errorf("create webhook: %w", err)
+	}
+	return w, nil
+}
+
+// ListWebhooks 查询用户的任务通知订阅
+func (s *novelService) ListWebhooks(ctx context.Context, userID string) ([]*novel.Webhook, error) {
+	return s.webhookRepo.FindByUserID(ctx, userID)
+}
+
+// DeleteWebhook 删除任务通知订阅，已创建的待重试投递在下一次尝试时标记为失败
+func (s *novelService) DeleteWebhook(ctx context.Context, userID, webhookID string) error {
+	if _, err := s.findUserWebhook(ctx, userID, webhookID); err != nil {
+		return err
+	}
+	if err := s.webhookRepo.Delete(ctx, webhookID); err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries 查询订阅的投递记录
+func (s *novelService) ListWebhookDeliveries(ctx context.Context, userID, webhookID string, limit int) ([]*novel.WebhookDelivery, error) {
+	if _, err := s.findUserWebhook(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+	return s.webhookDeliveryRepo.FindByWebhookID(ctx, webhookID, limit)
+}
+
+// findUserWebhook 查询订阅并校验所属用户；不属于该用户时按不存在处理
+func (s *novelService) findUserWebhook(ctx context.Context, userID, webhookID string) (*novel.Webhook, error) {
+	w, err := s.webhookRepo.FindByID(ctx, webhookID)
+	if err != nil {
+		return nil, fmt.Errorf("find webhook: %w", err)
+	}
+	if userID != "" && w.UserID != userID {
+		return nil, fmt.Errorf("find webhook: %w", mongo.ErrNoDocuments)
+	}
+	return w, nil
+}
+
+// ResumeWebhookDeliveries 恢复未完成的投递
+func (s *novelService) ResumeWebhookDeliveries(ctx context.Context) error {
+	deliveries, err := s.webhookDeliveryRepo.FindPending(ctx)
+	if err != nil {
+		return fmt.Errorf("find pending webhook deliveries: %w", err)
+	}
+	for _, d := range deliveries {
+		at := time.Now()
+		if d.NextAttemptAt != nil {
+			at = *d.NextAttemptAt
+		}
+		s.scheduleWebhookDelivery(d.ID, at)
+	}
+	log.Info().Int("deliveries", len(deliveries)).Msg("任务通知投递已恢复调度")
+	return nil
+}
+
+// notifyTaskWebhooks 生成任务结束后通知章节所属用户的订阅；在后台执行，失败只记录日志
+func (s *novelService) notifyTaskWebhooks(ctx context.Context, payload *novel.WebhookPayload) {
+	go func() {
+		ctx := context.WithoutCancel(ctx)
+		chapter, err := s.chapterRepo.FindByID(ctx, payload.ChapterID)
+		if err != nil {
+			log.Warn().Err(err).Str("chapter_id", payload.ChapterID).Msg("查询章节失败，跳过任务通知")
+			return
+		}
+		webhooks, err := s.webhookRepo.FindEnabledByUserID(ctx, chapter.UserID)
+		if err != nil {
+			log.Error().Err(err).Str("user_id", chapter.UserID).Msg("查询任务通知订阅失败")
+			return
+		}
+		for _, w := range webhooks {
+			if !w.Accepts(payload.TaskType) {
+				continue
+			}
+			now := time.Now()
+			delivery := &novel.WebhookDelivery{
+				ID:            id.New(),
+				WebhookID:     w.ID,
+				UserID:        w.UserID,
+				Payload:       payload,
+				Status:        novel.WebhookStatusPending,
+				NextAttemptAt: &now,
+			}
+			if err := s.webhookDeliveryRepo.Create(ctx, delivery); err != nil {
+				log.Error().Err(err).Str("webhook_id", w.ID).Msg("保存任务通知投递记录失败")
+				continue
+			}
+			s.deliverWebhook(ctx, delivery.ID)
+		}
+	}()
+}
+
+// scheduleWebhookDelivery 在指定时间投递
+func (s *novelService) scheduleWebhookDelivery(deliveryID string, at time.Time) {
+	delay := time.Until(at)
+	if delay < 0 {
+		delay = 0
+	}
+	time.AfterFunc(delay, func() {
+		s.deliverWebhook(context.Background(), deliveryID)
+	})
+}
+
+// deliverWebhook 执行一次投递并记录结果；失败且未达到最多尝试次数时按退避时间安排重试
+func (s *novelService) deliverWebhook(ctx context.Context, deliveryID string) {
+	delivery, err := s.webhookDeliveryRepo.FindByID(ctx, deliveryID)
+	if err != nil {
+		log.Error().Err(err).Str("delivery_id", deliveryID).Msg("查询任务通知投递记录失败")
+		return
+	}
+	if delivery.Status != novel.WebhookStatusPending {
+		return
+	}
+
+	attempt := novel.WebhookAttempt{At: time.Now()}
+	sendErr := s.sendWebhookDelivery(ctx, delivery, &attempt)
+	attempt.DurationMs = time.Since(attempt.At).Milliseconds()
+
+	status := novel.WebhookStatusSent
+	var next *time.Time
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+		status = novel.WebhookStatusFailed
+		attemptCount := delivery.AttemptCount + 1
+		if attemptCount < s.webhookMaxAttempts && !errors.Is(sendErr, mongo.ErrNoDocuments) && !errors.Is(sendErr, webhook.ErrForbiddenAddress) {
+			status = novel.WebhookStatusPending
+			at := time.Now().Add(webhook.Backoff(attemptCount))
+			next = &at
+		}
+		log.Warn().Err(sendErr).
+			Str("delivery_id", delivery.ID).
+			Str("webhook_id", delivery.WebhookID).
+			Int("attempt", attemptCount).
+			Msg("任务通知发送失败")
+	}
+
+	if err := s.webhookDeliveryRepo.RecordAttempt(ctx, delivery.ID, attempt, status, next); err != nil {
+		log.Error().Err(err).Str("delivery_id", delivery.ID).Msg("记录任务通知投递结果失败")
+		return
+	}
+	if event := delivery.Payload.Budget; event != nil {
+		if err := s.budgetEventRepo.UpdateWebhookStatus(ctx, event.ID, status, attempt.Error); err != nil {
+			log.Error().Err(err).Str("event_id", event.ID).Msg("更新预算事件通知状态失败")
+		}
+	}
+	if next != nil {
+		s.scheduleWebhookDelivery(delivery.ID, *next)
+	}
+}
+
+// sendWebhookDelivery 发送签名的通知请求，把响应写入 attempt；订阅已删除或停用时返回 mongo.ErrNoDocuments（不再重试）
+// 通知地址解析到内网、回环或链路本地地址时返回 webhook.ErrForbiddenAddress（不再重试）
+func (s *novelService) sendWebhookDelivery(ctx context.Context, delivery *novel.WebhookDelivery, attempt *novel.WebhookAttempt) error {
+	target, secret, err := s.webhookTarget(ctx, delivery)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(delivery.Payload)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	result, err := webhook.Send(ctx, nil, &webhook.Request{
+		URL:        target,
+		Secret:     secret,
+		Event:      delivery.Payload.Event.String(),
+		DeliveryID: delivery.ID,
+		Body:       body,
+	})
+	attempt.StatusCode = result.StatusCode
+	attempt.Response = result.Response
+	return err
+}
+
+// webhookTarget 返回投递的通知地址和签名密钥：任务通知使用订阅，预算通知使用小说当前的预算设置
+func (s *novelService) webhookTarget(ctx context.Context, delivery *novel.WebhookDelivery) (string, string, error) {
+	if delivery.Payload.Budget != nil {
+		n, err := s.novelRepo.FindByID(ctx, delivery.Payload.NovelID)
+		if err != nil {
+			return "", "", fmt.Errorf("find novel: %w", err)
+		}
+		if n.Budget == nil || n.Budget.WebhookURL == "" || n.Budget.WebhookSecret == "" {
+			return "", "", fmt.Errorf("budget webhook removed: %w", mongo.ErrNoDocuments)
+		}
+		return n.Budget.WebhookURL, n.Budget.WebhookSecret, nil
+	}
+
+	w, err := s.webhookRepo.FindByID(ctx, delivery.WebhookID)
+	if err != nil {
+		return "", "", fmt.Errorf("find webhook: %w", err)
+	}
+	if !w.Enabled {
+		return "", "", fmt.Errorf("webhook disabled: %w", mongo.ErrNoDocuments)
+	}
+	return w.URL, w.Secret, nil
+}
@@ -0,0 +1,244 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/eventbus"
+	novelrepo "lemon/internal/repository/novel"
+)
+
+// memWebhookRepo 内存中的任务通知订阅仓库
+type memWebhookRepo struct {
+	novelrepo.WebhookRepository
+	mu       sync.Mutex
+	webhooks map[string]*novel.Webhook
+}
+
+func (r *memWebhookRepo) Create(_ context.Context, w *novel.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.webhooks == nil {
+		r.webhooks = make(map[string]*novel.Webhook)
+	}
+	r.webhooks[w.ID] = w
+	return nil
+}
+
+func (r *memWebhookRepo) FindByID(_ context.Context, id string) (*novel.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	w, ok := r.webhooks[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return w, nil
+}
+
+// memWebhookDeliveryRepo 内存中的投递记录仓库，recorded 在每次记录投递结果后收到通知
+type memWebhookDeliveryRepo struct {
+	novelrepo.WebhookDeliveryRepository
+	mu         sync.Mutex
+	deliveries map[string]*novel.WebhookDelivery
+	recorded   chan string
+}
+
+func newMemWebhookDeliveryRepo() *memWebhookDeliveryRepo {
+	return &memWebhookDeliveryRepo{
+		deliveries: make(map[string]*novel.WebhookDelivery),
+		recorded:   make(chan string, 10),
+	}
+}
+
+func (r *memWebhookDeliveryRepo) Create(_ context.Context, d *novel.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *d
+	r.deliveries[d.ID] = &stored
+	return nil
+}
+
+func (r *memWebhookDeliveryRepo) FindByID(_ context.Context, id string) (*novel.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.deliveries[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	copied := *d
+	return &copied, nil
+}
+
+func (r *memWebhookDeliveryRepo) RecordAttempt(_ context.Context, id string, attempt novel.WebhookAttempt, status novel.WebhookStatus, next *time.Time) error {
+	r.mu.Lock()
+	d := r.deliveries[id]
+	d.Attempts = append(d.Attempts, attempt)
+	d.AttemptCount++
+	d.Status, d.NextAttemptAt = status, next
+	r.mu.Unlock()
+	r.recorded <- id
+	return nil
+}
+
+func (r *memWebhookDeliveryRepo) get(id string) novel.WebhookDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.deliveries[id]
+}
+
+// memBudgetNovelRepo 只保存一部小说的仓库，实现预算事件用到的方法
+type memBudgetNovelRepo struct {
+	novelrepo.NovelRepository
+	novel *novel.Novel
+}
+
+func (r *memBudgetNovelRepo) FindByID(_ context.Context, id string) (*novel.Novel, error) {
+	if r.novel.ID != id {
+		return nil, mongo.ErrNoDocuments
+	}
+	return r.novel, nil
+}
+
+func (r *memBudgetNovelRepo) MarkBudgetEvent(context.Context, string, string, time.Time) (bool, error) {
+	return true, nil
+}
+
+// memBudgetEventRepo 内存中的预算事件仓库，updated 在每次更新通知状态后收到通知
+type memBudgetEventRepo struct {
+	novelrepo.BudgetEventRepository
+	mu      sync.Mutex
+	events  map[string]*novel.BudgetEvent
+	updated chan string
+}
+
+func (r *memBudgetEventRepo) Create(_ context.Context, event *novel.BudgetEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event.CreatedAt = time.Now()
+	stored := *event
+	r.events[event.ID] = &stored
+	return nil
+}
+
+func (r *memBudgetEventRepo) UpdateWebhookStatus(_ context.Context, id string, status novel.WebhookStatus, errMsg string) error {
+	r.mu.Lock()
+	r.events[id].WebhookStatus, r.events[id].WebhookError = status, errMsg
+	r.mu.Unlock()
+	r.updated <- id
+	return nil
+}
+
+// waitRecorded 等待一次投递结果被记录
+func waitRecorded(t *testing.T, deliveries *memWebhookDeliveryRepo) string {
+	t.Helper()
+	select {
+	case id := <-deliveries.recorded:
+		return id
+	case <-time.After(5 * time.Second):
+		t.Fatal("delivery attempt was not recorded")
+		return ""
+	}
+}
+
+func TestCreateWebhookRejectsInternalAddress(t *testing.T) {
+	s := &novelService{webhookRepo: &memWebhookRepo{}}
+	for _, url := range []string{
+		"ftp://93.184.216.34/hook",
+		"http://127.0.0.1:8080/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.1.2.3/hook",
+		"http://[::1]/hook",
+	} {
+		if _, err := s.CreateWebhook(context.Background(), &CreateWebhookRequest{UserID: "user-1", URL: url}); !errors.Is(err, ErrInvalidWebhook) {
+			t.Errorf("CreateWebhook(%q) error = %v, want ErrInvalidWebhook", url, err)
+		}
+	}
+
+	w, err := s.CreateWebhook(context.Background(), &CreateWebhookRequest{UserID: "user-1", URL: "https://93.184.216.34/hook"})
+	if err != nil {
+		t.Fatalf("CreateWebhook() error = %v", err)
+	}
+	if w.Secret == "" {
+		t.Error("expected webhook secret to be generated")
+	}
+}
+
+func TestDeliverWebhookInternalAddressNotRetried(t *testing.T) {
+	// 注册后地址改为解析到回环地址：发送时拒绝，并且不再重试
+	webhooks := &memWebhookRepo{}
+	webhooks.Create(context.Background(), &novel.Webhook{ID: "wh-1", URL: "http://127.0.0.1:9/hook", Secret: "s", Enabled: true})
+	deliveries := newMemWebhookDeliveryRepo()
+	deliveries.Create(context.Background(), &novel.WebhookDelivery{
+		ID:        "d-1",
+		WebhookID: "wh-1",
+		Payload:   &novel.WebhookPayload{Event: novel.WebhookEventTaskCompleted},
+		Status:    novel.WebhookStatusPending,
+	})
+	s := &novelService{webhookRepo: webhooks, webhookDeliveryRepo: deliveries, webhookMaxAttempts: defaultWebhookMaxAttempts}
+
+	s.deliverWebhook(context.Background(), "d-1")
+
+	got := deliveries.get("d-1")
+	if got.Status != novel.WebhookStatusFailed || got.NextAttemptAt != nil {
+		t.Fatalf("status = %s, next = %v, want failed without retry", got.Status, got.NextAttemptAt)
+	}
+	if got.AttemptCount != 1 || got.Attempts[0].StatusCode != 0 {
+		t.Errorf("attempts = %+v, want one attempt that never reached the server", got.Attempts)
+	}
+}
+
+func TestBudgetEventUsesWebhookDelivery(t *testing.T) {
+	n := &novel.Novel{
+		ID:     "novel-1",
+		UserID: "user-1",
+		Budget: &novel.NovelBudget{
+			CapFen:        100,
+			SpentFen:      100,
+			WebhookURL:    "http://127.0.0.1:9/budget",
+			WebhookSecret: "secret",
+		},
+	}
+	events := &memBudgetEventRepo{events: make(map[string]*novel.BudgetEvent), updated: make(chan string, 10)}
+	deliveries := newMemWebhookDeliveryRepo()
+	s := &novelService{
+		novelRepo:           &memBudgetNovelRepo{novel: n},
+		budgetEventRepo:     events,
+		webhookDeliveryRepo: deliveries,
+		webhookMaxAttempts:  defaultWebhookMaxAttempts,
+		eventBus:            eventbus.New(),
+	}
+
+	s.emitBudgetEvent(context.Background(), n, novel.BudgetEventExceeded, "exceeded_at")
+
+	// 预算通知保存为投递记录，按任务通知的签名和重试策略投递
+	deliveryID := waitRecorded(t, deliveries)
+	d := deliveries.get(deliveryID)
+	if d.WebhookID != "" || d.UserID != "user-1" {
+		t.Errorf("delivery = %+v, want budget delivery of user-1", d)
+	}
+	if d.Payload.Event != novel.WebhookEvent(novel.BudgetEventExceeded) || d.Payload.NovelID != "novel-1" || d.Payload.Budget == nil {
+		t.Fatalf("payload = %+v, want budget.exceeded for novel-1", d.Payload)
+	}
+
+	// 通知地址指向回环地址：不发出请求、不重试，预算事件记录为通知失败
+	if d.Status != novel.WebhookStatusFailed || d.NextAttemptAt != nil {
+		t.Errorf("delivery status = %s, next = %v, want failed without retry", d.Status, d.NextAttemptAt)
+	}
+	select {
+	case <-events.updated:
+	case <-time.After(5 * time.Second):
+		t.Fatal("budget event webhook status was not updated")
+	}
+	events.mu.Lock()
+	event := *events.events[d.Payload.Budget.ID]
+	events.mu.Unlock()
+	if event.DeliveryID != deliveryID || event.WebhookStatus != novel.WebhookStatusFailed || event.WebhookError == "" {
+		t.Errorf("budget event = %+v, want failed delivery %s", event, deliveryID)
+	}
+}
@@ -0,0 +1,243 @@
+package novel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/noveltools"
+)
+
+var (
+	// ErrUnknownWorkflowTemplate 工作流模板不存在
+	ErrUnknownWorkflowTemplate = errors.New("unknown workflow template")
+	// ErrWorkflowTemplateExists 同名的工作流模板已存在
+	ErrWorkflowTemplateExists = errors.New("workflow template already exists")
+	// ErrBuiltinWorkflowTemplate 内置模板不能修改或删除
+	ErrBuiltinWorkflowTemplate = errors.New("builtin workflow template cannot be modified")
+)
+
+// WorkflowTemplateService 工作流模板服务接口
+// 模板按内容风格打包解说结构、配音、图片风格、视频规格和发布平台，创建小说时选用
+type WorkflowTemplateService interface {
+	// ListWorkflowTemplates 列出所有模板（内置模板在前，自定义模板按名称排序）
+	ListWorkflowTemplates(ctx context.Context) ([]*novel.WorkflowTemplate, error)
+
+	// GetWorkflowTemplate 按名称查询模板
+	GetWorkflowTemplate(ctx context.Context, name string) (*novel.WorkflowTemplate, error)
+
+	// CreateWorkflowTemplate 创建自定义模板
+	CreateWorkflowTemplate(ctx context.Context, template *novel.WorkflowTemplate, userID string) (*novel.WorkflowTemplate, error)
+
+	// UpdateWorkflowTemplate 整体替换自定义模板的配置（只影响之后创建的小说）
+	UpdateWorkflowTemplate(ctx context.Context, name string, template *novel.WorkflowTemplate) (*novel.WorkflowTemplate, error)
+
+	// DeleteWorkflowTemplate 删除自定义模板（已创建的小说不受影响）
+	DeleteWorkflowTemplate(ctx context.Context, name string) error
+}
+
+// ListWorkflowTemplates 列出所有模板
+func (s *novelService) ListWorkflowTemplates(ctx context.Context) ([]*novel.WorkflowTemplate, error) {
+	custom, err := s.workflowTemplateRepo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("find workflow templates: %w", err)
+	}
+	return append(noveltools.BuiltinWorkflowTemplates(), custom...), nil
+}
+
+// GetWorkflowTemplate 按名称查询模板：先查内置模板，再查自定义模板
+func (s *novelService) GetWorkflowTemplate(ctx context.Context, name string) (*novel.WorkflowTemplate, error) {
+	if template, ok := noveltools.FindBuiltinWorkflowTemplate(name); ok {
+		return template, nil
+	}
+	template, err := s.workflowTemplateRepo.FindByName(ctx, name)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownWorkflowTemplate, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find workflow template: %w", err)
+	}
+	return template, nil
+}
+
+// CreateWorkflowTemplate 创建自定义模板
+// 校验失败返回 noveltools.ErrInvalidWorkflowTemplate；与内置模板同名返回 ErrBuiltinWorkflowTemplate
+func (s *novelService) CreateWorkflowTemplate(ctx context.Context, template *novel.WorkflowTemplate, userID string) (*novel.WorkflowTemplate, error) {
+	normalized, err := noveltools.NormalizeWorkflowTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := noveltools.FindBuiltinWorkflowTemplate(normalized.Name); ok {
+		return nil, fmt.Errorf("%w: %s", ErrBuiltinWorkflowTemplate, normalized.Name)
+	}
+
+	normalized.ID = id.New()
+	normalized.CreatedBy = userID
+	if err := s.workflowTemplateRepo.Create(ctx, normalized); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("%w: %s", ErrWorkflowTemplateExists, normalized.Name)
+		}
+		return nil, fmt.Errorf("create workflow template: %w", err)
+	}
+
+	log.Info().
+		Str("template", normalized.Name).
+		Str("user_id", userID).
+		Msg("工作流模板已创建")
+	return normalized, nil
+}
+
+// UpdateWorkflowTemplate 整体替换自定义模板的配置（模板名称不可修改）
+func (s *novelService) UpdateWorkflowTemplate(ctx context.Context, name string, template *novel.WorkflowTemplate) (*novel.WorkflowTemplate, error) {
+	if _, ok := noveltools.FindBuiltinWorkflowTemplate(name); ok {
+		return nil, fmt.Errorf("%w: %s", ErrBuiltinWorkflowTemplate, name)
+	}
+	replacement := *template
+	replacement.Name = name
+	normalized, err := noveltools.NormalizeWorkflowTemplate(&replacement)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.workflowTemplateRepo.Replace(ctx, normalized); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownWorkflowTemplate, name)
+		}
+		return nil, fmt.Errorf("update workflow template: %w", err)
+	}
+
+	log.Info().Str("template", name).Msg("工作流模板已更新")
+	return normalized, nil
+}
+
+// DeleteWorkflowTemplate 删除自定义模板
+func (s *novelService) DeleteWorkflowTemplate(ctx context.Context, name string) error {
+	if _, ok := noveltools.FindBuiltinWorkflowTemplate(name); ok {
+		return fmt.Errorf("%w: %s", ErrBuiltinWorkflowTemplate, name)
+	}
+	if err := s.workflowTemplateRepo.DeleteByName(ctx, name); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("%w: %s", ErrUnknownWorkflowTemplate, name)
+		}
+		return fmt.Errorf("delete workflow template: %w", err)
+	}
+
+	log.Info().Str("template", name).Msg("工作流模板已删除")
+	return nil
+}
+
+// CreateNovelFromResourceWithTemplate 根据资源ID创建小说，并复制工作流模板的配置
+// 旁白类型和风格的优先级：请求指定 > 模板 > 用户创作默认配置；模板不存在时返回 ErrUnknownWorkflowTemplate
+func (s *novelService) CreateNovelFromResourceWithTemplate(ctx context.Context, resourceID, userID string, narrationType novel.NarrationType, style novel.NovelStyle, templateName string) (string, error) {
+	if templateName == "" {
+		return s.CreateNovelFromResource(ctx, resourceID, userID, narrationType, style)
+	}
+	template, err := s.GetWorkflowTemplate(ctx, templateName)
+	if err != nil {
+		return "", err
+	}
+	if narrationType == "" {
+		narrationType = template.NarrationType
+	}
+	if style == "" {
+		style = template.Style
+	}
+	return s.createNovelFromResource(ctx, resourceID, userID, narrationType, style, template)
+}
+
+// applyWorkflowTemplate 把工作流模板的配置复制到新建的小说上（覆盖创作默认配置中的对应项，模板未设置的项保持不变）
+func applyWorkflowTemplate(n *novel.Novel, template *novel.WorkflowTemplate) {
+	if template == nil {
+		return
+	}
+	n.WorkflowTemplate = template.Name
+	if template.Structure != nil {
+		structure := *template.Structure
+		n.NarrationStructure = &structure
+	}
+	if template.StylePreset != "" {
+		n.StylePreset = template.StylePreset
+	}
+	if template.Video != nil {
+		n.Generation = &novel.GenerationSettings{
+			VideoWidth:  template.Video.Width,
+			VideoHeight: template.Video.Height,
+			VideoFPS:    template.Video.FPS,
+		}
+	}
+
+	render := novel.RenderSettings{}
+	if n.Render != nil {
+		render = *n.Render
+	}
+	if template.Voice != nil {
+		voice := *template.Voice
+		render.Voice = &voice
+	}
+	if len(template.Platforms) > 0 {
+		n.Platforms = slices.Clone(template.Platforms)
+		render.Platform = template.Platforms[0]
+	}
+	n.Render = &render
+
+	if template.Metadata != nil {
+		n.Metadata = mergeCreativeMetadata(n.Metadata, template.Metadata)
+	}
+}
+
+// mergeCreativeMetadata 以 base 为基础合并 overlay 中不为空的创作设定（禁用话题取并集，自定义变量按名称覆盖）
+func mergeCreativeMetadata(base, overlay *novel.CreativeMetadata) *novel.CreativeMetadata {
+	merged := novel.CreativeMetadata{}
+	if base != nil {
+		merged = *base
+		merged.BannedTopics = slices.Clone(base.BannedTopics)
+		merged.Variables = maps.Clone(base.Variables)
+	}
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&merged.Genre, overlay.Genre},
+		{&merged.Tone, overlay.Tone},
+		{&merged.Audience, overlay.Audience},
+		{&merged.Protagonist, overlay.Protagonist},
+	} {
+		if field.src != "" {
+			*field.dst = field.src
+		}
+	}
+	for _, topic := range overlay.BannedTopics {
+		if !slices.Contains(merged.BannedTopics, topic) {
+			merged.BannedTopics = append(merged.BannedTopics, topic)
+		}
+	}
+	for name, value := range overlay.Variables {
+		if merged.Variables == nil {
+			merged.Variables = make(map[string]string)
+		}
+		merged.Variables[name] = value
+	}
+	merged.UpdatedAt = time.Now()
+	return &merged
+}
+
+// novelStylePreset 查询小说的图片风格预设，未设置或查询失败时使用默认的国风漫画风格（不阻断生成流程）
+func (s *novelService) novelStylePreset(ctx context.Context, novelID string) novel.ImageStylePreset {
+	n, err := s.novelRepo.FindByID(ctx, novelID)
+	if err != nil {
+		log.Warn().Err(err).Str("novel_id", novelID).Msg("查询小说图片风格预设失败，使用默认风格")
+		return novel.ImageStylePresetGuofengComic
+	}
+	if !n.StylePreset.IsValid() {
+		return novel.ImageStylePresetGuofengComic
+	}
+	return n.StylePreset
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"lemon/internal/config"
+	"lemon/internal/model/resource"
+	"lemon/internal/pkg/resourceref"
+	"lemon/internal/pkg/storage"
+	resourceRepo "lemon/internal/repository/resource"
+)
+
+var (
+	ErrResourceGCRunning       = errors.New("资源回收正在进行中")
+	ErrInvalidResourceGCPolicy = errors.New("无效的资源回收保留期或宽限期")
+)
+
+const (
+	// resourceGCBatchSize 资源回收时每批查询的资源数
+	resourceGCBatchSize = 200
+	// maxResourceGCItems 回收报告中最多列出的资源数
+	maxResourceGCItems = 100
+	// maxResourceGCErrors 回收报告中最多保留的错误数
+	maxResourceGCErrors = 20
+)
+
+// 资源回收对资源的处理方式
+const (
+	ResourceGCActionMark    = "mark"    // 标记删除（软删除）
+	ResourceGCActionPurge   = "purge"   // 从存储中删除文件和记录
+	ResourceGCActionRestore = "restore" // 标记后重新被引用，恢复
+)
+
+// ResourceGCService 资源回收服务接口
+// 回收没有被任何业务记录（音频、字幕、图片、视频、小说等）引用的资源，例如流水线失败留下的中间产物、被新版本替换的旧文件
+type ResourceGCService interface {
+	// Collect 执行一次资源回收
+	// 先清除宽限期已过的标记资源（期间重新被引用的资源恢复），再标记超过保留期且没有被引用的资源；同一时间只允许一次回收
+	Collect(ctx context.Context, req *ResourceGCRequest) (*ResourceGCReport, error)
+}
+
+// ResourceGCRequest 资源回收请求
+type ResourceGCRequest struct {
+	DryRun      bool          // 只统计不删除
+	Retention   time.Duration // 保留期（为 0 时使用配置）
+	GracePeriod time.Duration // 宽限期（为 0 时使用配置）
+}
+
+// ResourceGCReport 资源回收报告
+type ResourceGCReport struct {
+	DryRun      bool             `json:"dry_run"`          // 是否只统计不删除（dry_run 时 marked/purged/restored 为将要处理的数量）
+	Retention   string           `json:"retention"`        // 保留期
+	GracePeriod string           `json:"grace_period"`     // 宽限期
+	StartedAt   time.Time        `json:"started_at"`       // 开始时间
+	FinishedAt  time.Time        `json:"finished_at"`      // 结束时间
+	Collections int              `json:"collections"`      // 扫描的业务集合数
+	Referenced  int              `json:"referenced"`       // 业务记录引用的资源ID数
+	Scanned     int              `json:"scanned"`          // 检查的资源数（超过保留期的未删除资源和宽限期已过的标记资源）
+	Marked      int              `json:"marked"`           // 标记删除的资源数
+	Purged      int              `json:"purged"`           // 从存储中删除的资源数
+	Restored    int              `json:"restored"`         // 重新被引用而恢复的资源数
+	FreedBytes  int64            `json:"freed_bytes"`      // 释放的存储空间（字节，共用存储路径的文件不计入）
+	Failed      int              `json:"failed"`           // 处理失败的资源数
+	Errors      []string         `json:"errors,omitempty"` // 处理失败的原因（最多保留 20 条）
+	Items       []ResourceGCItem `json:"items,omitempty"`  // 处理的资源（最多列出 100 条）
+}
+
+// ResourceGCItem 资源回收处理的资源
+type ResourceGCItem struct {
+	ResourceID   string    `json:"resource_id"`             // 资源ID
+	Action       string    `json:"action"`                  // 处理方式：mark, purge, restore
+	StorageKey   string    `json:"storage_key"`             // 存储路径
+	ArtifactType string    `json:"artifact_type,omitempty"` // 产物类型
+	FileSize     int64     `json:"file_size"`               // 文件大小（字节）
+	CreatedAt    time.Time `json:"created_at"`              // 资源创建时间
+}
+
+// resourceGCService 资源回收服务实现
+type resourceGCService struct {
+	db           *mongo.Database
+	resourceRepo *resourceRepo.ResourceRepo
+	storage      storage.Storage
+	retention    time.Duration
+	gracePeriod  time.Duration
+	running      sync.Mutex
+}
+
+// NewResourceGCService 创建资源回收服务
+// 只需要传入必要的依赖，repository 在内部自动创建
+func NewResourceGCService(db *mongo.Database, storage storage.Storage, cfg *config.ResourceGCConfig) ResourceGCService {
+	return &resourceGCService{
+		db:           db,
+		resourceRepo: resourceRepo.NewResourceRepo(db),
+		storage:      storage,
+		retention:    cfg.Retention,
+		gracePeriod:  cfg.GracePeriod,
+	}
+}
+
+// Collect 执行一次资源回收
+func (s *resourceGCService) Collect(ctx context.Context, req *ResourceGCRequest) (*ResourceGCReport, error) {
+	retention := req.Retention
+	if retention == 0 {
+		retention = s.retention
+	}
+	gracePeriod := req.GracePeriod
+	if gracePeriod == 0 {
+		gracePeriod = s.gracePeriod
+	}
+	if retention <= 0 || gracePeriod < 0 {
+		return nil, fmt.Errorf("%w: retention=%s, grace_period=%s", ErrInvalidResourceGCPolicy, retention, gracePeriod)
+	}
+
+	if !s.running.TryLock() {
+		return nil, ErrResourceGCRunning
+	}
+	defer s.running.Unlock()
+
+	now := time.Now()
+	report := &ResourceGCReport{
+		DryRun:      req.DryRun,
+		Retention:   retention.String(),
+		GracePeriod: gracePeriod.String(),
+		StartedAt:   now,
+	}
+
+	// 引用扫描不完整时不能判断资源是否被使用，直接放弃本次回收
+	refs, collections, err := s.collectReferences(ctx)
+	if err != nil {
+		return report, fmt.Errorf("collect resource references: %w", err)
+	}
+	report.Collections = collections
+	report.Referenced = len(refs)
+
+	// 先清除再标记：本次标记的资源至少经过一个宽限期才会被清除
+	if err := s.purgeMarked(ctx, refs, now.Add(-gracePeriod), req.DryRun, report); err != nil {
+		return report, err
+	}
+	if err := s.markUnreferenced(ctx, refs, now.Add(-retention), now, req.DryRun, report); err != nil {
+		return report, err
+	}
+
+	report.FinishedAt = time.Now()
+	log.Info().
+		Bool("dry_run", report.DryRun).
+		Int("scanned", report.Scanned).
+		Int("marked", report.Marked).
+		Int("purged", report.Purged).
+		Int("restored", report.Restored).
+		Int64("freed_bytes", report.FreedBytes).
+		Int("failed", report.Failed).
+		Msg("资源回收完成")
+	return report, nil
+}
+
+// collectReferences 扫描除资源集合外的所有集合，收集业务记录引用的资源ID
+// 按字段名识别资源引用（见 resourceref），不需要逐个登记业务模型
+func (s *resourceGCService) collectReferences(ctx context.Context) (map[string]bool, int, error) {
+	var res resource.Resource
+	names, err := s.db.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, fmt.Errorf("list collections: %w", err)
+	}
+
+	refs := make(map[string]bool)
+	add := func(id string) { refs[id] = true }
+	collections := 0
+	for _, name := range names {
+		if name == res.Collection() || strings.HasPrefix(name, "system.") {
+			continue
+		}
+		cursor, err := s.db.Collection(name).Find(ctx, bson.M{}, options.Find().SetBatchSize(500))
+		if err != nil {
+			return nil, collections, fmt.Errorf("scan collection %s: %w", name, err)
+		}
+		for cursor.Next(ctx) {
+			if err := resourceref.Collect(cursor.Current, add); err != nil {
+				cursor.Close(ctx)
+				return nil, collections, fmt.Errorf("scan collection %s: %w", name, err)
+			}
+		}
+		err = cursor.Err()
+		cursor.Close(ctx)
+		if err != nil {
+			return nil, collections, fmt.Errorf("scan collection %s: %w", name, err)
+		}
+		collections++
+	}
+	return refs, collections, nil
+}
+
+// purgeMarked 处理宽限期已过的标记资源：重新被引用的恢复，其余从存储中删除文件后删除记录
+// 同一存储路径还被其他需要保留的资源使用时只删除记录
+func (s *resourceGCService) purgeMarked(ctx context.Context, refs map[string]bool, markedBefore time.Time, dryRun bool, report *ResourceGCReport) error {
+	afterID := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		resources, err := s.resourceRepo.FindGCMarkedBefore(ctx, markedBefore, afterID, resourceGCBatchSize)
+		if err != nil {
+			return fmt.Errorf("find resources marked before %s: %w", markedBefore.Format(time.RFC3339), err)
+		}
+		for _, res := range resources {
+			report.Scanned++
+			if refs[res.ID] {
+				if !dryRun {
+					status := res.GCPreviousStatus
+					if status == "" {
+						status = resource.ResourceStatusReady
+					}
+					if err := s.resourceRepo.RestoreGC(ctx, res.ID, status); err != nil {
+						report.fail(res.ID, err)
+						continue
+					}
+					log.Info().Str("resource_id", res.ID).Msg("回收标记的资源重新被引用，已恢复")
+				}
+				report.Restored++
+				report.addItem(res, ResourceGCActionRestore)
+				continue
+			}
+
+			freed, err := s.purge(ctx, res, dryRun)
+			if err != nil {
+				report.fail(res.ID, err)
+				continue
+			}
+			report.Purged++
+			report.FreedBytes += freed
+			report.addItem(res, ResourceGCActionPurge)
+		}
+		if len(resources) < resourceGCBatchSize {
+			return nil
+		}
+		afterID = resources[len(resources)-1].ID
+	}
+}
+
+// purge 从存储中删除资源文件并删除记录，返回释放的字节数
+func (s *resourceGCService) purge(ctx context.Context, res *resource.Resource, dryRun bool) (int64, error) {
+	var freed int64
+	if res.StorageKey != "" {
+		shared, err := s.resourceRepo.CountRetainedByStorageKey(ctx, res.StorageKey, res.ID)
+		if err != nil {
+			return 0, fmt.Errorf("count resources using %s: %w", res.StorageKey, err)
+		}
+		if shared == 0 {
+			if !dryRun {
+				if err := s.storage.Delete(ctx, res.StorageKey); err != nil {
+					return 0, fmt.Errorf("delete file %s: %w", res.StorageKey, err)
+				}
+			}
+			freed = res.FileSize
+		}
+	}
+	if dryRun {
+		return freed, nil
+	}
+	if err := s.resourceRepo.HardDelete(ctx, res.ID); err != nil {
+		return freed, fmt.Errorf("delete resource %s: %w", res.ID, err)
+	}
+	return freed, nil
+}
+
+// markUnreferenced 标记 createdBefore 之前创建且没有被引用的资源（软删除）
+func (s *resourceGCService) markUnreferenced(ctx context.Context, refs map[string]bool, createdBefore, now time.Time, dryRun bool, report *ResourceGCReport) error {
+	afterID := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		resources, err := s.resourceRepo.FindCreatedBefore(ctx, createdBefore, afterID, resourceGCBatchSize)
+		if err != nil {
+			return fmt.Errorf("find resources created before %s: %w", createdBefore.Format(time.RFC3339), err)
+		}
+		for _, res := range resources {
+			report.Scanned++
+			if refs[res.ID] {
+				continue
+			}
+			if !dryRun {
+				marked, err := s.resourceRepo.MarkGC(ctx, res.ID, res.Status, now)
+				if err != nil {
+					report.fail(res.ID, err)
+					continue
+				}
+				if !marked {
+					// 查询后已被删除
+					continue
+				}
+			}
+			report.Marked++
+			report.addItem(res, ResourceGCActionMark)
+		}
+		if len(resources) < resourceGCBatchSize {
+			return nil
+		}
+		afterID = resources[len(resources)-1].ID
+	}
+}
+
+// fail 记录处理失败的资源
+func (r *ResourceGCReport) fail(resourceID string, err error) {
+	r.Failed++
+	if len(r.Errors) < maxResourceGCErrors {
+		r.Errors = append(r.Errors, fmt.Sprintf("%s: %v", resourceID, err))
+	}
+	log.Warn().Err(err).Str("resource_id", resourceID).Msg("资源回收失败")
+}
+
+// addItem 记录处理的资源
+func (r *ResourceGCReport) addItem(res *resource.Resource, action string) {
+	if len(r.Items) >= maxResourceGCItems {
+		return
+	}
+	r.Items = append(r.Items, ResourceGCItem{
+		ResourceID:   res.ID,
+		Action:       action,
+		StorageKey:   res.StorageKey,
+		ArtifactType: res.ArtifactType,
+		FileSize:     res.FileSize,
+		CreatedAt:    res.CreatedAt,
+	})
+}
@@ -8,15 +8,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
 	"go.mongodb.org/mongo-driver/mongo"
 
 	"lemon/internal/model/resource"
+	"lemon/internal/pkg/assetcache"
+	"lemon/internal/pkg/errclass"
 	"lemon/internal/pkg/id"
+	"lemon/internal/pkg/presign"
 	"lemon/internal/pkg/storage"
+	"lemon/internal/pkg/taskstats"
 	resourceRepo "lemon/internal/repository/resource"
 )
 
@@ -28,8 +31,15 @@ var (
 	ErrUploadSessionInvalid  = errors.New("上传会话状态无效")
 	ErrFileNotFound          = errors.New("文件不存在")
 	ErrInvalidFileHash       = errors.New("文件哈希值不匹配")
+	ErrAssetCacheDisabled    = errors.New("未启用本地资源缓存")
+	ErrReplicationDisabled   = errors.New("未配置备用存储")
+	ErrInvalidTarStream      = errors.New("打包下载参数无效")
+	ErrFileTooLarge          = errors.New("文件超过大小限制")
 )
 
+// maxTarStreamResources 单次打包下载的最大资源数
+const maxTarStreamResources = 1000
+
 // ResourceService 资源服务接口
 // 定义 resource 模块 service 层提供的能力
 type ResourceService interface {
@@ -47,14 +57,32 @@ type ResourceService interface {
 	GetDownloadURL(ctx context.Context, req *GetDownloadURLRequest) (*GetDownloadURLResult, error)
 
 	// UploadFile 服务端直接上传文件（不通过上传会话）
-	// 用于服务端生成的文件（如音频、字幕等）直接上传
+	// 用于服务端生成的文件（如音频、字幕等）和用户通过接口上传的文件；边上传边计算哈希，存储支持分片上传时不把整个文件读入内存
+	// 设置了 req.MaxSize 时超出大小返回 ErrFileTooLarge
 	UploadFile(ctx context.Context, req *UploadFileRequest) (*UploadFileResult, error)
 
+	// UploadLargeFile 服务端流式上传大文件（如最终视频）
+	// 边读边计算哈希，存储支持分片上传时按分片上传并重试失败分片，内存占用与文件大小无关
+	UploadLargeFile(ctx context.Context, req *UploadFileRequest) (*UploadFileResult, error)
+
 	// DownloadFile 下载文件（返回文件流）
-	// 用于服务端需要读取文件内容的场景
+	// 用于服务端需要读取文件内容的场景；指定 Offset/Length 时只返回文件的一个区间（断点续传）
 	// 注意：如果 req.UserID 为空，视为系统内部请求，可以访问所有资源
 	DownloadFile(ctx context.Context, req *DownloadFileRequest) (*DownloadFileResult, error)
 
+	// GetDownloadManifest 获取下载校验清单（文件和各分块的 SHA256）
+	// 首次请求时读取文件计算并保存到资源记录，客户端续传前据此校验已下载的部分
+	// 注意：如果 req.UserID 为空，视为系统内部请求，可以访问所有资源
+	GetDownloadManifest(ctx context.Context, req *GetDownloadManifestRequest) (*DownloadManifest, error)
+
+	// PlanTarStream 校验要打包下载的资源并生成 tar 流的成员列表（不读取文件）
+	// 资源不存在或无权访问时返回对应错误，资源数量或续传位置不合法时返回 ErrInvalidTarStream
+	PlanTarStream(ctx context.Context, req *PlanTarStreamRequest) (*TarStreamPlan, error)
+
+	// StreamTar 按计划逐个从存储读取资源写入 w（tar 格式，不在服务端生成压缩包），最后附加 SHA256 校验清单
+	// w 写入阻塞时不再读取存储；出错时 tar 流不完整，客户端按已完整接收的成员续传
+	StreamTar(ctx context.Context, plan *TarStreamPlan, w io.Writer) error
+
 	// ListResources 查询资源列表
 	// 支持按用户ID、扩展名、状态等条件筛选
 	// 注意：如果 req.UserID 为空，视为系统内部请求，可以查询所有用户的资源
@@ -64,12 +92,56 @@ type ResourceService interface {
 	// 用于查看资源信息、权限验证等场景
 	// 注意：如果 req.UserID 为空，视为系统内部请求，可以访问所有资源
 	GetResource(ctx context.Context, req *GetResourceRequest) (*GetResourceResult, error)
+
+	// StageFile 把资源预先下载到本地缓存（定时渲染前预热素材）
+	// 需要通过 WithAssetCache 启用本地缓存，之后 DownloadFile 优先从缓存读取
+	StageFile(ctx context.Context, resourceID string) (*StageFileResult, error)
+
+	// DeleteResource 删除资源（删除存储中的文件并软删除资源记录）
+	// 用于系统内部清理产物，不做用户权限检查；资源不存在或已删除时返回 ErrResourceNotFound
+	DeleteResource(ctx context.Context, resourceID string) error
+
+	// RefreshLinks 为 v 中所有没有 URL 或即将过期的 presign.Link 生成下载链接（系统内部请求，不做用户权限检查）
+	// 业务记录只保存 resource_id，接口返回前调用，保证响应中的链接始终有效
+	RefreshLinks(ctx context.Context, v any) error
+
+	// ReconcileStorage 主备存储对账：修复复制失败的文件，并检查 since 之后更新过的资源在两个存储中是否一致
+	// 未配置备用存储时返回 ErrReplicationDisabled
+	ReconcileStorage(ctx context.Context, since time.Time) (*StorageReconcileReport, error)
 }
 
 // resourceService 资源服务实现
 type resourceService struct {
 	resourceRepo *resourceRepo.ResourceRepo
 	storage      storage.Storage
+	assetCache   *assetcache.Cache    // 本地资源缓存（可选）
+	keyTemplates storage.KeyTemplates // 按产物类型配置的存储路径模板（可选）
+	urlPolicies  presign.Policies     // 预签名URL的有效期策略
+}
+
+// ResourceServiceOption 资源服务可选配置
+type ResourceServiceOption func(*resourceService)
+
+// WithAssetCache 启用本地资源缓存
+func WithAssetCache(c *assetcache.Cache) ResourceServiceOption {
+	return func(s *resourceService) {
+		s.assetCache = c
+	}
+}
+
+// WithKeyTemplates 按产物类型配置存储路径模板
+// 上传请求带 KeyVars 时按 KeyVars.ArtifactType 选择模板，未配置或模板变量缺失时使用 storage.DefaultKeyTemplate
+func WithKeyTemplates(templates storage.KeyTemplates) ResourceServiceOption {
+	return func(s *resourceService) {
+		s.keyTemplates = templates
+	}
+}
+
+// WithURLPolicies 设置预签名URL的有效期策略，未设置时使用 presign.DefaultPolicies
+func WithURLPolicies(policies presign.Policies) ResourceServiceOption {
+	return func(s *resourceService) {
+		s.urlPolicies = policies
+	}
 }
 
 // NewResourceService 创建资源服务
@@ -77,14 +149,20 @@ type resourceService struct {
 func NewResourceService(
 	db *mongo.Database,
 	storage storage.Storage,
+	opts ...ResourceServiceOption,
 ) ResourceService {
 	// 初始化 repository
 	resourceRepo := resourceRepo.NewResourceRepo(db)
 
-	return &resourceService{
+	svc := &resourceService{
 		resourceRepo: resourceRepo,
 		storage:      storage,
+		urlPolicies:  presign.DefaultPolicies(),
 	}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
 }
 
 // PrepareUploadRequest 准备上传请求
@@ -113,10 +191,14 @@ func (s *resourceService) PrepareUpload(ctx context.Context, req *PrepareUploadR
 
 	// 生成存储路径：resources/{user_id}/{resource_id}.{ext}
 	// 注意：这里使用 sessionID 作为临时资源ID，上传完成后会创建正式资源
-	storageKey := s.generateStorageKey(req.UserID, sessionID, req.Ext)
-
-	// 生成预签名上传URL（有效期1小时）
-	expiresIn := time.Hour
+	storageKey, _ := s.generateStorageKey(ctx, storage.DefaultKeyTemplate, storage.KeyVars{
+		UserID:     req.UserID,
+		ResourceID: sessionID,
+		Ext:        req.Ext,
+	})
+
+	// 生成预签名上传URL（有效期按上传策略）
+	expiresIn := s.urlPolicies.Lifetime(presign.PurposeUpload, 0)
 	uploadURL, err := s.storage.GetPresignedUploadURL(ctx, storageKey, req.ContentType, expiresIn)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to generate presigned upload URL")
@@ -134,7 +216,6 @@ func (s *resourceService) PrepareUpload(ctx context.Context, req *PrepareUploadR
 		FileSize:      req.FileSize,
 		ContentType:   req.ContentType,
 		Ext:           req.Ext,
-		UploadURL:     uploadURL,
 		UploadKey:     storageKey,
 		ExpiresAt:     expiresAt,
 		Status:        resource.UploadStatusPending,
@@ -163,10 +244,12 @@ type CompleteUploadRequest struct {
 }
 
 // CompleteUploadResult 完成上传结果
+// ResourceURL 是临时下载链接，调用方只能保存 ResourceID，需要访问时重新获取链接
 type CompleteUploadResult struct {
-	ResourceID  string `json:"resource_id"`
-	ResourceURL string `json:"resource_url"`
-	FileSize    int64  `json:"file_size"`
+	ResourceID   string    `json:"resource_id"`
+	ResourceURL  string    `json:"resource_url"`
+	URLExpiresAt time.Time `json:"url_expires_at"` // ResourceURL 的过期时间
+	FileSize     int64     `json:"file_size"`
 }
 
 // CompleteUpload 完成上传（确认上传完成）
@@ -179,20 +262,13 @@ func (s *resourceService) CompleteUpload(ctx context.Context, req *CompleteUploa
 	}
 
 	// 生成资源访问URL（使用原始资源）
-	resourceURL, err := s.storage.GetPresignedDownloadURL(ctx, originalRes.StorageKey, time.Hour*24)
-	if err != nil {
-		log.Warn().Err(err).Msg("failed to generate resource URL")
-		// 不影响主流程，返回空URL
-		resourceURL = ""
-	}
-
-	// 异步执行后续处理链（脱敏等处理），不阻塞主流程
-	go s.processResourceChain(context.Background(), originalRes.ID)
+	resourceURL, expiresAt := s.resourceURL(ctx, originalRes.StorageKey)
 
 	return &CompleteUploadResult{
-		ResourceID:  originalRes.ID,
-		ResourceURL: resourceURL,
-		FileSize:    originalRes.FileSize,
+		ResourceID:   originalRes.ID,
+		ResourceURL:  resourceURL,
+		URLExpiresAt: expiresAt,
+		FileSize:     originalRes.FileSize,
 	}, nil
 }
 
@@ -258,6 +334,7 @@ func (s *resourceService) createOriginalResource(ctx context.Context, req *Compl
 		Name:        session.FileName,
 		StorageKey:  session.UploadKey,
 		StorageType: s.storage.GetStorageType(),
+		KeyTemplate: storage.DefaultKeyTemplate,
 		FileSize:    fileInfo.Size,
 		ContentType: fileInfo.ContentType,
 		MD5:         req.MD5,
@@ -272,6 +349,11 @@ func (s *resourceService) createOriginalResource(ctx context.Context, req *Compl
 		return nil, errors.New("创建资源失败")
 	}
 
+	// 客户端直传的文件只在一个存储中，配置了备用存储时异步复制
+	if replicator, ok := s.storage.(storage.Replicator); ok {
+		replicator.Replicate(session.UploadKey)
+	}
+
 	// 更新上传会话状态为已完成（原始资源创建成功后，上传即完成）
 	if err := s.resourceRepo.UpdateUploadSession(ctx, req.SessionID, map[string]interface{}{
 		"status":         resource.UploadStatusCompleted,
@@ -291,20 +373,12 @@ func (s *resourceService) createOriginalResource(ctx context.Context, req *Compl
 	return originalRes, nil
 }
 
-// processResourceChain 执行资源处理链（脱敏等处理）
-// processResourceChain 原先用于异步执行资源处理链（脱敏、章节切分等）。
-// 目前资源处理链已改为在 service 层显式调用纯函数，因此该方法留空或后续重构为具体业务流程。
-func (s *resourceService) processResourceChain(ctx context.Context, resourceID string) {
-	// TODO: 在需要时，这里可以显式调用脱敏、章节切分等纯函数工具。
-	_ = ctx
-	_ = resourceID
-}
-
 // GetDownloadURLRequest 获取下载URL请求
 type GetDownloadURLRequest struct {
-	UserID     string        // 用户ID（用于权限验证，为空时视为系统内部请求，可访问所有资源）
-	ResourceID string        // 资源ID
-	ExpiresIn  time.Duration // 可选，默认1小时
+	UserID     string          // 用户ID（用于权限验证，为空时视为系统内部请求，可访问所有资源）
+	ResourceID string          // 资源ID
+	Purpose    presign.Purpose // 可选，链接用途（决定默认和最长有效期），默认 download
+	ExpiresIn  time.Duration   // 可选，为空时使用用途的默认有效期，超过上限时截断
 }
 
 // GetDownloadURLResult 获取下载URL结果
@@ -336,11 +410,12 @@ func (s *resourceService) GetDownloadURL(ctx context.Context, req *GetDownloadUR
 		return nil, ErrResourceNotFound
 	}
 
-	// 设置默认过期时间
-	expiresIn := req.ExpiresIn
-	if expiresIn == 0 {
-		expiresIn = time.Hour
+	// 按用途的策略确定有效期
+	purpose := req.Purpose
+	if purpose == "" {
+		purpose = presign.PurposeDownload
 	}
+	expiresIn := s.urlPolicies.Lifetime(purpose, req.ExpiresIn)
 
 	// 生成预签名下载URL
 	downloadURL, err := s.storage.GetPresignedDownloadURL(ctx, res.StorageKey, expiresIn)
@@ -366,48 +441,100 @@ type UploadFileRequest struct {
 	ContentType string
 	Ext         string // 文件扩展名（不含点号）
 	Data        io.Reader
+
+	// KeyVars 可选，按存储路径模板生成存储路径所需的变量（KeyVars.ArtifactType 决定使用哪个模板）
+	// 为空时使用 resources/{user_id}/{resource_id}.{ext}；UserID、ResourceID、Ext 由请求填充
+	KeyVars *storage.KeyVars
+
+	// MaxSize 可选，文件大小上限（字节），<=0 表示不限制
+	// 超出时中止上传、删除已写入存储的数据并返回 ErrFileTooLarge
+	MaxSize int64
 }
 
 // UploadFileResult 服务端上传文件结果
+// ResourceURL 是临时下载链接，调用方只能保存 ResourceID，需要访问时重新获取链接
 type UploadFileResult struct {
-	ResourceID  string `json:"resource_id"`
-	ResourceURL string `json:"resource_url"`
-	FileSize    int64  `json:"file_size"`
+	ResourceID   string    `json:"resource_id"`
+	ResourceURL  string    `json:"resource_url"`
+	URLExpiresAt time.Time `json:"url_expires_at"` // ResourceURL 的过期时间
+	FileSize     int64     `json:"file_size"`
 }
 
 // UploadFile 服务端直接上传文件（不通过上传会话）
-// 用于服务端生成的文件（如音频、字幕等）直接上传
+// 用于服务端生成的文件（如音频、字幕等）直接上传：数据只读取一遍，边上传边计算 MD5/SHA256 和文件大小
 func (s *resourceService) UploadFile(ctx context.Context, req *UploadFileRequest) (*UploadFileResult, error) {
+	return s.uploadStream(ctx, req, false)
+}
+
+// UploadLargeFile 服务端流式上传大文件（如最终视频）
+// 数据只读取一遍：边上传边计算 MD5/SHA256 和文件大小；
+// 存储实现了 storage.MultipartUploader 时使用分片上传（单个分片失败会重试），否则退化为普通流式上传
+func (s *resourceService) UploadLargeFile(ctx context.Context, req *UploadFileRequest) (*UploadFileResult, error) {
+	return s.uploadStream(ctx, req, true)
+}
+
+// uploadStream 把请求数据流式写入存储并创建资源记录
+// 存储支持分片上传时总是使用分片上传（不超过一个分片的数据一次上传），内存占用不超过一个分片；
+// 部分存储的普通上传会把数据读入内存（如 S3 需要计算请求体签名）。large 为 true 时记录上传日志
+// 设置了 req.MaxSize 时读取超过上限即中止上传并删除已写入的数据
+func (s *resourceService) uploadStream(ctx context.Context, req *UploadFileRequest, large bool) (*UploadFileResult, error) {
 	if req.Data == nil {
 		return nil, errors.New("文件数据不能为空")
 	}
 
-	// 读取文件数据并计算哈希
-	dataBytes, err := io.ReadAll(req.Data)
-	if err != nil {
-		return nil, fmt.Errorf("读取文件数据失败: %w", err)
+	md5Hasher := md5.New()
+	sha256Hasher := sha256.New()
+	counter := &byteCounter{}
+	data := req.Data
+	var limiter *sizeLimitReader
+	if req.MaxSize > 0 {
+		limiter = &sizeLimitReader{r: data, remaining: req.MaxSize}
+		data = limiter
 	}
-
-	fileSize := int64(len(dataBytes))
-
-	// 计算 MD5 和 SHA256
-	md5Hash := md5.Sum(dataBytes)
-	sha256Hash := sha256.Sum256(dataBytes)
-	md5Str := hex.EncodeToString(md5Hash[:])
-	sha256Str := hex.EncodeToString(sha256Hash[:])
+	dataReader := io.TeeReader(data, io.MultiWriter(md5Hasher, sha256Hasher, counter))
 
 	// 生成资源ID和存储路径
 	resourceID := id.New()
-	storageKey := s.generateStorageKey(req.UserID, resourceID, req.Ext)
+	storageKey, keyTemplate := s.uploadStorageKey(ctx, req, resourceID)
 
-	// 上传文件到存储
-	dataReader := strings.NewReader(string(dataBytes))
-	_, err = s.storage.Upload(ctx, storageKey, dataReader, req.ContentType)
+	var err error
+	if uploader, ok := s.storage.(storage.MultipartUploader); ok {
+		_, err = uploader.UploadMultipart(ctx, storageKey, dataReader, req.ContentType, storage.DefaultMultipartOptions())
+	} else {
+		_, err = s.storage.Upload(ctx, storageKey, dataReader, req.ContentType)
+	}
+	if limiter != nil && limiter.exceeded() {
+		// 部分存储实现（如本地文件系统）可能已经写入了部分数据
+		if delErr := s.storage.Delete(ctx, storageKey); delErr != nil {
+			log.Warn().Err(delErr).Str("key", storageKey).Msg("删除超过大小限制的文件失败")
+		}
+		return nil, fmt.Errorf("%w: limit %d bytes", ErrFileTooLarge, req.MaxSize)
+	}
 	if err != nil {
 		log.Error().Err(err).Str("key", storageKey).Msg("failed to upload file")
-		return nil, errors.New("上传文件失败")
+		return nil, errclass.Wrap(errclass.StorageIO, s.storage.GetStorageType(), errors.New("上传文件失败"))
+	}
+
+	if large {
+		log.Info().
+			Str("resource_id", resourceID).
+			Str("storage_key", storageKey).
+			Int64("file_size", counter.n).
+			Msg("大文件流式上传完成")
 	}
 
+	return s.createUploadedResource(ctx, req, resourceID, storageKey, keyTemplate, counter.n,
+		hex.EncodeToString(md5Hasher.Sum(nil)), hex.EncodeToString(sha256Hasher.Sum(nil)))
+}
+
+// createUploadedResource 为服务端上传完成的文件创建资源记录并生成访问URL
+func (s *resourceService) createUploadedResource(
+	ctx context.Context,
+	req *UploadFileRequest,
+	resourceID, storageKey, keyTemplate string,
+	fileSize int64,
+	md5Str, sha256Str string,
+) (*UploadFileResult, error) {
 	// 创建资源记录
 	res := &resource.Resource{
 		ID:          resourceID,
@@ -416,6 +543,7 @@ func (s *resourceService) UploadFile(ctx context.Context, req *UploadFileRequest
 		Name:        req.FileName,
 		StorageKey:  storageKey,
 		StorageType: s.storage.GetStorageType(),
+		KeyTemplate: keyTemplate,
 		FileSize:    fileSize,
 		ContentType: req.ContentType,
 		MD5:         md5Str,
@@ -428,25 +556,84 @@ func (s *resourceService) UploadFile(ctx context.Context, req *UploadFileRequest
 		log.Error().Err(err).Msg("failed to create resource")
 		return nil, errors.New("创建资源记录失败")
 	}
+	taskstats.FromContext(ctx).AddUploaded(fileSize)
 
 	// 生成资源访问URL
-	resourceURL, err := s.storage.GetPresignedDownloadURL(ctx, storageKey, time.Hour*24)
+	resourceURL, expiresAt := s.resourceURL(ctx, storageKey)
+
+	return &UploadFileResult{
+		ResourceID:   resourceID,
+		ResourceURL:  resourceURL,
+		URLExpiresAt: expiresAt,
+		FileSize:     fileSize,
+	}, nil
+}
+
+// resourceURL 为刚上传的文件生成临时下载链接（按下载策略的默认有效期），失败时返回空URL，不影响主流程
+func (s *resourceService) resourceURL(ctx context.Context, storageKey string) (string, time.Time) {
+	expiresIn := s.urlPolicies.Lifetime(presign.PurposeDownload, 0)
+	url, err := s.storage.GetPresignedDownloadURL(ctx, storageKey, expiresIn)
 	if err != nil {
 		log.Warn().Err(err).Msg("failed to generate resource URL")
-		resourceURL = ""
+		return "", time.Time{}
 	}
+	return url, time.Now().Add(expiresIn)
+}
 
-	return &UploadFileResult{
-		ResourceID:  resourceID,
-		ResourceURL: resourceURL,
-		FileSize:    fileSize,
-	}, nil
+// RefreshLinks 为 v 中没有 URL 或即将过期的链接生成下载链接
+func (s *resourceService) RefreshLinks(ctx context.Context, v any) error {
+	return s.urlPolicies.Refresh(ctx, v, presign.PurposeDownload, func(ctx context.Context, resourceID string, expiresIn time.Duration) (string, time.Time, error) {
+		result, err := s.GetDownloadURL(ctx, &GetDownloadURLRequest{ResourceID: resourceID, ExpiresIn: expiresIn})
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("refresh link for resource %s: %w", resourceID, err)
+		}
+		return result.DownloadURL, result.ExpiresAt, nil
+	})
+}
+
+// byteCounter 统计写入的字节数（用于流式上传时计算文件大小）
+type byteCounter struct {
+	n int64
+}
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+// sizeLimitReader 读取超过 remaining 字节时返回 ErrFileTooLarge（用于流式上传时限制文件大小）
+type sizeLimitReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrFileTooLarge
+	}
+	// 多读一个字节，用于判断数据是否超出上限
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n + int(l.remaining), ErrFileTooLarge
+	}
+	return n, err
+}
+
+// exceeded 数据是否超出了大小上限
+func (l *sizeLimitReader) exceeded() bool {
+	return l.remaining < 0
 }
 
 // DownloadFileRequest 下载文件请求
 type DownloadFileRequest struct {
 	UserID     string // 用户ID（用于权限验证，为空时视为系统内部请求，可访问所有资源）
 	ResourceID string // 资源ID
+	Offset     int64  // 可选，起始偏移（字节，用于断点续传）
+	Length     int64  // 可选，读取的字节数，<= 0 表示读取到文件末尾
 }
 
 // DownloadFileResult 下载文件结果
@@ -455,7 +642,10 @@ type DownloadFileResult struct {
 	FileName    string        `json:"file_name"`
 	ContentType string        `json:"content_type"`
 	FileSize    int64         `json:"file_size"`
-	Data        io.ReadCloser `json:"-"` // 不序列化到JSON
+	SHA256      string        `json:"sha256,omitempty"` // 整个文件的 SHA256
+	Offset      int64         `json:"offset"`           // Data 在文件中的起始偏移
+	Length      int64         `json:"length"`           // Data 的字节数
+	Data        io.ReadCloser `json:"-"`                // 不序列化到JSON
 }
 
 // DownloadFile 下载文件（返回文件流）
@@ -477,20 +667,390 @@ func (s *resourceService) DownloadFile(ctx context.Context, req *DownloadFileReq
 		return nil, ErrResourceNotFound
 	}
 
+	// 检查下载区间
+	if req.Offset < 0 || (req.Offset > 0 && req.Offset >= res.FileSize) {
+		return nil, fmt.Errorf("%w: offset %d, file size %d", storage.ErrRangeNotSatisfiable, req.Offset, res.FileSize)
+	}
+	length := res.FileSize - req.Offset
+	if req.Length > 0 && req.Length < length {
+		length = req.Length
+	}
+	partial := req.Offset > 0 || length < res.FileSize
+
+	result := &DownloadFileResult{
+		ResourceID:  res.ID,
+		FileName:    res.Name,
+		ContentType: res.ContentType,
+		FileSize:    res.FileSize,
+		SHA256:      res.SHA256,
+		Offset:      req.Offset,
+		Length:      length,
+	}
+
+	// 优先读取本地缓存
+	if s.assetCache != nil {
+		if cached, ok := s.assetCache.Open(res.ID); ok {
+			if !partial {
+				result.Data = cached
+				return result, nil
+			}
+			if result.Data, err = storage.LimitReadCloser(cached, req.Offset, length); err != nil {
+				return nil, fmt.Errorf("read cached resource %s: %w", res.ID, err)
+			}
+			return result, nil
+		}
+	}
+
 	// 从存储下载文件
-	reader, err := s.storage.Download(ctx, res.StorageKey)
+	var reader io.ReadCloser
+	if partial {
+		reader, err = storage.OpenRange(ctx, s.storage, res.StorageKey, req.Offset, length)
+	} else {
+		reader, err = s.storage.Download(ctx, res.StorageKey)
+	}
 	if err != nil {
 		log.Error().Err(err).Str("key", res.StorageKey).Msg("failed to download file")
-		return nil, errors.New("下载文件失败")
+		return nil, errclass.Wrap(errclass.StorageIO, s.storage.GetStorageType(), errors.New("下载文件失败"))
 	}
+	taskstats.FromContext(ctx).AddDownloaded(length)
+	result.Data = reader
+	return result, nil
+}
 
-	return &DownloadFileResult{
+// GetDownloadManifestRequest 获取下载校验清单请求
+type GetDownloadManifestRequest struct {
+	UserID     string // 用户ID（用于权限验证，为空时视为系统内部请求，可访问所有资源）
+	ResourceID string // 资源ID
+}
+
+// DownloadChunk 下载校验清单中的一个分块
+type DownloadChunk struct {
+	Index  int    `json:"index"`
+	Offset int64  `json:"offset"`
+	Length int64  `json:"length"`
+	SHA256 string `json:"sha256"`
+}
+
+// DownloadManifest 下载校验清单
+type DownloadManifest struct {
+	ResourceID  string          `json:"resource_id"`
+	FileName    string          `json:"file_name"`
+	ContentType string          `json:"content_type"`
+	FileSize    int64           `json:"file_size"`
+	SHA256      string          `json:"sha256"`
+	ChunkSize   int64           `json:"chunk_size"`
+	Chunks      []DownloadChunk `json:"chunks"`
+}
+
+// GetDownloadManifest 获取下载校验清单
+// 分块哈希首次请求时读取整个文件计算，之后直接使用资源记录中保存的结果
+func (s *resourceService) GetDownloadManifest(ctx context.Context, req *GetDownloadManifestRequest) (*DownloadManifest, error) {
+	res, err := s.resourceRepo.FindByID(ctx, req.ResourceID)
+	if err != nil {
+		return nil, ErrResourceNotFound
+	}
+	if req.UserID != "" && res.UserID != req.UserID {
+		return nil, ErrResourceAccessDenied
+	}
+	if res.Status == resource.ResourceStatusDeleted {
+		return nil, ErrResourceNotFound
+	}
+
+	if res.ChunkSize <= 0 || len(res.ChunkSHA256) == 0 || res.SHA256 == "" {
+		downloaded, err := s.DownloadFile(ctx, &DownloadFileRequest{ResourceID: res.ID})
+		if err != nil {
+			return nil, err
+		}
+		chunks, sum, size, err := storage.ChunkChecksums(downloaded.Data, storage.DefaultDownloadChunkSize)
+		downloaded.Data.Close()
+		if err != nil {
+			return nil, fmt.Errorf("checksum resource %s: %w", res.ID, err)
+		}
+		if res.SHA256 != "" && res.SHA256 != sum {
+			log.Warn().Str("resource_id", res.ID).Str("expected", res.SHA256).Str("actual", sum).Msg("资源文件的 SHA256 与记录不一致")
+		}
+
+		res.ChunkSize = storage.DefaultDownloadChunkSize
+		res.ChunkSHA256 = chunks
+		res.SHA256 = sum
+		res.FileSize = size
+		if err := s.resourceRepo.Update(ctx, res.ID, map[string]interface{}{
+			"chunk_size":   res.ChunkSize,
+			"chunk_sha256": res.ChunkSHA256,
+			"sha256":       res.SHA256,
+			"file_size":    res.FileSize,
+		}); err != nil {
+			// 保存失败不影响本次返回，下次请求重新计算
+			log.Warn().Err(err).Str("resource_id", res.ID).Msg("保存下载校验清单失败")
+		}
+	}
+
+	manifest := &DownloadManifest{
 		ResourceID:  res.ID,
 		FileName:    res.Name,
 		ContentType: res.ContentType,
 		FileSize:    res.FileSize,
-		Data:        reader,
-	}, nil
+		SHA256:      res.SHA256,
+		ChunkSize:   res.ChunkSize,
+		Chunks:      make([]DownloadChunk, 0, len(res.ChunkSHA256)),
+	}
+	for i, sum := range res.ChunkSHA256 {
+		offset := int64(i) * res.ChunkSize
+		manifest.Chunks = append(manifest.Chunks, DownloadChunk{
+			Index:  i,
+			Offset: offset,
+			Length: min(res.ChunkSize, res.FileSize-offset),
+			SHA256: sum,
+		})
+	}
+	return manifest, nil
+}
+
+// PlanTarStreamRequest 打包下载请求
+type PlanTarStreamRequest struct {
+	UserID       string   // 用户ID（用于权限验证，为空时视为系统内部请求，可访问所有资源）
+	ResourceIDs  []string // 要下载的资源ID（按顺序作为 tar 成员）
+	ResumeMember int      // 续传：客户端已完整接收的成员数，从该序号的成员开始传输
+	MemberOffset int64    // 续传：ResumeMember 成员已接收的字节数
+}
+
+// TarStreamMember 打包下载的一个成员
+type TarStreamMember struct {
+	ResourceID string    `json:"resource_id"`
+	Name       string    `json:"name"` // tar 中的文件名（重名时追加序号）
+	FileSize   int64     `json:"file_size"`
+	SHA256     string    `json:"sha256,omitempty"`
+	ModTime    time.Time `json:"mod_time"`
+}
+
+// TarStreamPlan 打包下载计划
+type TarStreamPlan struct {
+	UserID       string            `json:"-"`
+	Members      []TarStreamMember `json:"members"`
+	ResumeMember int               `json:"resume_member"`
+	MemberOffset int64             `json:"member_offset"`
+}
+
+// PlanTarStream 生成打包下载计划
+// 续传位置必须落在成员内容内：ResumeMember 等于成员数时只传输校验清单，此时 MemberOffset 必须为 0
+func (s *resourceService) PlanTarStream(ctx context.Context, req *PlanTarStreamRequest) (*TarStreamPlan, error) {
+	if len(req.ResourceIDs) == 0 || len(req.ResourceIDs) > maxTarStreamResources {
+		return nil, fmt.Errorf("%w: resource count must be between 1 and %d", ErrInvalidTarStream, maxTarStreamResources)
+	}
+
+	plan := &TarStreamPlan{
+		UserID:       req.UserID,
+		Members:      make([]TarStreamMember, 0, len(req.ResourceIDs)),
+		ResumeMember: req.ResumeMember,
+		MemberOffset: req.MemberOffset,
+	}
+	names := make([]string, 0, len(req.ResourceIDs))
+	for _, resourceID := range req.ResourceIDs {
+		meta, err := s.GetResource(ctx, &GetResourceRequest{UserID: req.UserID, ResourceID: resourceID})
+		if err != nil {
+			return nil, fmt.Errorf("resource %s: %w", resourceID, err)
+		}
+		res := meta.Resource
+		plan.Members = append(plan.Members, TarStreamMember{
+			ResourceID: res.ID,
+			FileSize:   res.FileSize,
+			SHA256:     res.SHA256,
+			ModTime:    res.UpdatedAt,
+		})
+		names = append(names, res.Name)
+	}
+	for i, name := range storage.TarMemberNames(names, req.ResourceIDs) {
+		plan.Members[i].Name = name
+	}
+
+	if req.ResumeMember < 0 || req.ResumeMember > len(plan.Members) || req.MemberOffset < 0 {
+		return nil, fmt.Errorf("%w: resume_member %d, member_offset %d", ErrInvalidTarStream, req.ResumeMember, req.MemberOffset)
+	}
+	if req.MemberOffset > 0 && (req.ResumeMember == len(plan.Members) || req.MemberOffset >= plan.Members[req.ResumeMember].FileSize) {
+		return nil, fmt.Errorf("%w: member_offset %d beyond member %d", ErrInvalidTarStream, req.MemberOffset, req.ResumeMember)
+	}
+	return plan, nil
+}
+
+// StreamTar 按计划写入 tar 流
+// 每个成员在写入前才打开存储中的文件，传输完关闭，同一时间只占用一个存储连接
+func (s *resourceService) StreamTar(ctx context.Context, plan *TarStreamPlan, w io.Writer) error {
+	tw := storage.NewTarStreamWriter(w)
+	for i, member := range plan.Members {
+		entry := storage.TarMember{
+			Name:    member.Name,
+			Size:    member.FileSize,
+			ModTime: member.ModTime,
+			SHA256:  member.SHA256,
+		}
+		if i < plan.ResumeMember {
+			tw.SkipMember(entry)
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if i == plan.ResumeMember {
+			entry.Offset = plan.MemberOffset
+		}
+
+		downloaded, err := s.DownloadFile(ctx, &DownloadFileRequest{
+			UserID:     plan.UserID,
+			ResourceID: member.ResourceID,
+			Offset:     entry.Offset,
+		})
+		if err != nil {
+			return fmt.Errorf("download member %s: %w", member.ResourceID, err)
+		}
+		sum, err := tw.WriteMember(entry, downloaded.Data)
+		downloaded.Data.Close()
+		if err != nil {
+			return err
+		}
+		if sum != "" && member.SHA256 != "" && sum != member.SHA256 {
+			log.Warn().Str("resource_id", member.ResourceID).Str("expected", member.SHA256).Str("actual", sum).Msg("资源文件的 SHA256 与记录不一致")
+		}
+	}
+	return tw.Close()
+}
+
+// StageFileResult 预热资源结果
+type StageFileResult struct {
+	ResourceID string `json:"resource_id"`
+	Hit        bool   `json:"hit"`  // 是否已在本地缓存中
+	Size       int64  `json:"size"` // 本次下载的字节数（命中时为 0）
+}
+
+// StageFile 把资源预先下载到本地缓存
+func (s *resourceService) StageFile(ctx context.Context, resourceID string) (*StageFileResult, error) {
+	if s.assetCache == nil {
+		return nil, ErrAssetCacheDisabled
+	}
+	if s.assetCache.Has(resourceID) {
+		return &StageFileResult{ResourceID: resourceID, Hit: true}, nil
+	}
+
+	res, err := s.resourceRepo.FindByID(ctx, resourceID)
+	if err != nil || res.Status == resource.ResourceStatusDeleted {
+		return nil, ErrResourceNotFound
+	}
+
+	reader, err := s.storage.Download(ctx, res.StorageKey)
+	if err != nil {
+		log.Error().Err(err).Str("key", res.StorageKey).Msg("failed to download file")
+		return nil, errclass.Wrap(errclass.StorageIO, s.storage.GetStorageType(), errors.New("下载文件失败"))
+	}
+	defer reader.Close()
+
+	n, err := s.assetCache.Put(res.ID, reader, res.FileSize)
+	if err != nil {
+		return nil, fmt.Errorf("stage resource %s: %w", res.ID, err)
+	}
+	return &StageFileResult{ResourceID: res.ID, Size: n}, nil
+}
+
+// DeleteResource 删除资源
+// 先删除存储中的文件再软删除记录，文件删除失败时保留记录以便重试
+func (s *resourceService) DeleteResource(ctx context.Context, resourceID string) error {
+	res, err := s.resourceRepo.FindByID(ctx, resourceID)
+	if err != nil || res.Status == resource.ResourceStatusDeleted {
+		return ErrResourceNotFound
+	}
+
+	if res.StorageKey != "" {
+		if err := s.storage.Delete(ctx, res.StorageKey); err != nil {
+			log.Error().Err(err).Str("key", res.StorageKey).Msg("failed to delete file")
+			return fmt.Errorf("delete file %s: %w", res.StorageKey, err)
+		}
+	}
+
+	if err := s.resourceRepo.Delete(ctx, res.ID); err != nil {
+		return fmt.Errorf("delete resource %s: %w", res.ID, err)
+	}
+	return nil
+}
+
+// storageReconcileBatchSize 对账时每批查询的资源数
+const storageReconcileBatchSize = 200
+
+// maxReconcileErrors 对账报告中最多保留的错误数
+const maxReconcileErrors = 20
+
+// StorageReconcileReport 主备存储对账报告
+type StorageReconcileReport struct {
+	Since    time.Time `json:"since"`            // 检查该时间之后更新过的资源
+	Checked  int       `json:"checked"`          // 检查的文件数
+	Repaired int       `json:"repaired"`         // 修复的文件数
+	Failed   int       `json:"failed"`           // 修复失败的文件数
+	Errors   []string  `json:"errors,omitempty"` // 修复失败的原因（最多保留 20 条）
+}
+
+// ReconcileStorage 主备存储对账
+// 先修复复制失败或队列溢出的文件，再逐批检查 since 之后更新过的资源（已删除的资源从两个存储中删除）
+func (s *resourceService) ReconcileStorage(ctx context.Context, since time.Time) (*StorageReconcileReport, error) {
+	replicator, ok := s.storage.(storage.Replicator)
+	if !ok {
+		return nil, ErrReplicationDisabled
+	}
+
+	report := &StorageReconcileReport{Since: since}
+	repair := func(key string, deleted bool) {
+		report.Checked++
+		repaired, err := replicator.Repair(ctx, key, deleted)
+		if err != nil {
+			report.Failed++
+			if len(report.Errors) < maxReconcileErrors {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", key, err))
+			}
+			return
+		}
+		if repaired {
+			report.Repaired++
+		}
+	}
+
+	checked := make(map[string]bool)
+	for _, pending := range replicator.PendingRepairs() {
+		checked[pending.Key] = true
+		repair(pending.Key, pending.Deleted)
+	}
+
+	afterID := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		resources, err := s.resourceRepo.FindUpdatedSince(ctx, since, afterID, storageReconcileBatchSize)
+		if err != nil {
+			return report, fmt.Errorf("find resources updated since %s: %w", since.Format(time.RFC3339), err)
+		}
+		for _, res := range resources {
+			if res.StorageKey == "" || checked[res.StorageKey] {
+				continue
+			}
+			checked[res.StorageKey] = true
+			deleted := res.DeletedAt != nil
+			if deleted {
+				// 同一存储路径被其他未删除的资源使用时保留文件
+				if _, err := s.resourceRepo.FindByStorageKey(ctx, res.StorageKey); err == nil {
+					deleted = false
+				}
+			}
+			repair(res.StorageKey, deleted)
+		}
+		if len(resources) < storageReconcileBatchSize {
+			break
+		}
+		afterID = resources[len(resources)-1].ID
+	}
+
+	replicator.MarkReconciled(time.Now())
+	log.Info().
+		Int("checked", report.Checked).
+		Int("repaired", report.Repaired).
+		Int("failed", report.Failed).
+		Msg("主备存储对账完成")
+	return report, nil
 }
 
 // ListResourcesRequest 查询资源列表请求
@@ -602,11 +1162,38 @@ func (s *resourceService) GetResource(ctx context.Context, req *GetResourceReque
 	}, nil
 }
 
-// generateStorageKey 生成存储路径
-// 格式：resources/{user_id}/{resource_id}.{ext}
-func (s *resourceService) generateStorageKey(userID, resourceID, ext string) string {
-	if ext != "" {
-		return fmt.Sprintf("resources/%s/%s.%s", userID, resourceID, ext)
+// uploadStorageKey 为服务端上传生成存储路径，返回存储路径和使用的模板
+func (s *resourceService) uploadStorageKey(ctx context.Context, req *UploadFileRequest, resourceID string) (string, string) {
+	tmpl := storage.DefaultKeyTemplate
+	var vars storage.KeyVars
+	if req.KeyVars != nil {
+		vars = *req.KeyVars
+		tmpl = s.keyTemplates.Resolve(vars.ArtifactType)
+	}
+	vars.UserID = req.UserID
+	vars.ResourceID = resourceID
+	vars.Ext = req.Ext
+	return s.generateStorageKey(ctx, tmpl, vars)
+}
+
+// generateStorageKey 按模板生成存储路径，返回存储路径和实际使用的模板
+// 默认格式：resources/{user_id}/{resource_id}.{ext}
+// 模板变量缺失时回退到默认模板；渲染结果已被其他资源使用时（如模板不含 {resource_id}，同一版本重新生成），
+// 在扩展名前追加资源ID，避免覆盖已有文件
+func (s *resourceService) generateStorageKey(ctx context.Context, tmpl string, vars storage.KeyVars) (string, string) {
+	key, err := storage.RenderKey(tmpl, vars)
+	if err != nil {
+		if tmpl != storage.DefaultKeyTemplate {
+			log.Warn().Err(err).Str("template", tmpl).Str("artifact_type", vars.ArtifactType).Msg("存储路径模板无法渲染，使用默认模板")
+		}
+		tmpl = storage.DefaultKeyTemplate
+		key, _ = storage.RenderKey(tmpl, vars)
+		return key, tmpl
+	}
+	if tmpl != storage.DefaultKeyTemplate {
+		if existing, err := s.resourceRepo.FindByStorageKey(ctx, key); err == nil && existing != nil {
+			key = storage.AppendKeySuffix(key, vars.ResourceID)
+		}
 	}
-	return fmt.Sprintf("resources/%s/%s", userID, resourceID)
+	return key, tmpl
 }
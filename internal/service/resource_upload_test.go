@@ -0,0 +1,163 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"lemon/internal/pkg/storage"
+)
+
+// memStorage 内存存储：边读边写入（与本地文件系统一样，读取失败时已写入部分数据）
+type memStorage struct {
+	storage.Storage
+	mu        sync.Mutex
+	objects   map[string][]byte
+	uploads   int // 普通上传次数
+	multipart int // 分片上传次数
+	deleted   []string
+}
+
+func newMemStorage() *memStorage {
+	return &memStorage{objects: make(map[string][]byte)}
+}
+
+func (s *memStorage) write(key string, data io.Reader) error {
+	var buf bytes.Buffer
+	_, err := io.Copy(&buf, data)
+	s.mu.Lock()
+	s.objects[key] = buf.Bytes()
+	s.mu.Unlock()
+	return err
+}
+
+func (s *memStorage) Upload(_ context.Context, key string, data io.Reader, _ string) (string, error) {
+	s.uploads++
+	if err := s.write(key, data); err != nil {
+		return "", err
+	}
+	return "mem://" + key, nil
+}
+
+func (s *memStorage) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	s.deleted = append(s.deleted, key)
+	return nil
+}
+
+func (s *memStorage) GetStorageType() string {
+	return string(storage.StorageTypeLocal)
+}
+
+// memMultipartStorage 支持分片上传的内存存储：按分片读取，读取失败时中止上传，不保留任何数据
+type memMultipartStorage struct {
+	*memStorage
+	partSizes []int
+}
+
+func (s *memMultipartStorage) UploadMultipart(_ context.Context, key string, data io.Reader, _ string, opts storage.MultipartOptions) (string, error) {
+	s.multipart++
+	buf := make([]byte, 4)
+	var object []byte
+	for {
+		n, last, err := storage.ReadPart(data, buf)
+		if err != nil {
+			return "", err
+		}
+		if n > 0 {
+			s.partSizes = append(s.partSizes, n)
+			object = append(object, buf[:n]...)
+		}
+		if last || n == 0 {
+			break
+		}
+	}
+	s.mu.Lock()
+	s.objects[key] = object
+	s.mu.Unlock()
+	return "mem://" + key, nil
+}
+
+func TestUploadFileMaxSizeAbortsAndDeletesPartialData(t *testing.T) {
+	st := newMemStorage()
+	s := &resourceService{storage: st}
+
+	_, err := s.UploadFile(context.Background(), &UploadFileRequest{
+		UserID:  "user-1",
+		Ext:     "bin",
+		Data:    strings.NewReader("0123456789"),
+		MaxSize: 8,
+	})
+	if !errors.Is(err, ErrFileTooLarge) {
+		t.Fatalf("UploadFile() error = %v, want ErrFileTooLarge", err)
+	}
+	if len(st.objects) != 0 {
+		t.Errorf("storage kept %d objects, want partial data deleted", len(st.objects))
+	}
+	if len(st.deleted) != 1 {
+		t.Errorf("deleted %v, want the uploaded key deleted once", st.deleted)
+	}
+}
+
+func TestUploadFileUsesMultipartWhenSupported(t *testing.T) {
+	st := &memMultipartStorage{memStorage: newMemStorage()}
+	s := &resourceService{storage: st}
+
+	_, err := s.UploadFile(context.Background(), &UploadFileRequest{
+		UserID:  "user-1",
+		Ext:     "bin",
+		Data:    strings.NewReader("0123456789"),
+		MaxSize: 9,
+	})
+	if !errors.Is(err, ErrFileTooLarge) {
+		t.Fatalf("UploadFile() error = %v, want ErrFileTooLarge", err)
+	}
+	if st.multipart != 1 || st.uploads != 0 {
+		t.Errorf("multipart = %d, uploads = %d, want the multipart path", st.multipart, st.uploads)
+	}
+	// 读到超出上限的分片时中止，之前的分片不会写入存储
+	if len(st.objects) != 0 {
+		t.Errorf("storage kept %d objects, want none", len(st.objects))
+	}
+	if len(st.partSizes) != 2 {
+		t.Errorf("read parts %v, want the upload aborted at the third part", st.partSizes)
+	}
+}
+
+func TestSizeLimitReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		limit   int64
+		wantErr bool
+	}{
+		{"小于上限", "0123", 8, false},
+		{"等于上限", "01234567", 8, false},
+		{"超出上限一个字节", "012345678", 8, true},
+		{"空数据", "", 8, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &sizeLimitReader{r: strings.NewReader(tt.data), remaining: tt.limit}
+			data, err := io.ReadAll(l)
+			if tt.wantErr {
+				if !errors.Is(err, ErrFileTooLarge) || !l.exceeded() {
+					t.Fatalf("ReadAll() error = %v, exceeded = %v, want ErrFileTooLarge", err, l.exceeded())
+				}
+				if int64(len(data)) > tt.limit {
+					t.Errorf("read %d bytes, want at most %d", len(data), tt.limit)
+				}
+				return
+			}
+			if err != nil || l.exceeded() || string(data) != tt.data {
+				t.Errorf("ReadAll() = %q, %v, exceeded = %v, want %q", data, err, l.exceeded(), tt.data)
+			}
+		})
+	}
+}
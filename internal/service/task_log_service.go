@@ -0,0 +1,56 @@
+package service
+
+import (
+	"errors"
+
+	"lemon/internal/pkg/tasklog"
+)
+
+// ErrTaskLogNotFound 任务日志不存在（任务ID错误、未开启日志或已被淘汰）
+var ErrTaskLogNotFound = errors.New("任务日志不存在")
+
+const (
+	defaultTaskLogLimit = 200
+	maxTaskLogLimit     = 1000
+)
+
+// TaskLogService 任务日志服务接口
+// 生成类请求以请求ID（X-Request-ID）作为任务ID，在内存中保留最近的结构化日志，供运维查看和跟踪正在运行的任务
+type TaskLogService interface {
+	// GetTaskLogs 查询任务中序号大于 after 的日志（limit 默认200，最大1000）
+	GetTaskLogs(taskID string, after int64, limit int) (*tasklog.Snapshot, error)
+
+	// SubscribeTaskLogs 订阅任务的新日志，返回日志通道和取消订阅函数；任务结束后通道关闭
+	SubscribeTaskLogs(taskID string) (<-chan tasklog.Entry, func(), error)
+}
+
+// taskLogService 任务日志服务实现
+type taskLogService struct {
+	store *tasklog.Store
+}
+
+// NewTaskLogService 创建任务日志服务
+func NewTaskLogService(store *tasklog.Store) TaskLogService {
+	return &taskLogService{store: store}
+}
+
+// GetTaskLogs 查询任务日志
+func (s *taskLogService) GetTaskLogs(taskID string, after int64, limit int) (*tasklog.Snapshot, error) {
+	if limit <= 0 {
+		limit = defaultTaskLogLimit
+	}
+	snap, ok := s.store.Snapshot(taskID, after, min(limit, maxTaskLogLimit))
+	if !ok {
+		return nil, ErrTaskLogNotFound
+	}
+	return snap, nil
+}
+
+// SubscribeTaskLogs 订阅任务的新日志
+func (s *taskLogService) SubscribeTaskLogs(taskID string) (<-chan tasklog.Entry, func(), error) {
+	entries, cancel, ok := s.store.Subscribe(taskID)
+	if !ok {
+		return nil, nil, ErrTaskLogNotFound
+	}
+	return entries, cancel, nil
+}
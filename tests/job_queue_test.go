@@ -0,0 +1,201 @@
+// Package tests 异步任务队列集成测试
+//
+// 运行测试：
+//
+//	MONGO_URI=mongodb://localhost:27017 go test ./tests -run TestJobRepo -v
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+	novelrepo "lemon/internal/repository/novel"
+)
+
+// TestJobRepo 测试任务队列的领取、租约过期、重试和取消
+func TestJobRepo(t *testing.T) {
+	Convey("JobRepo 任务队列", t, func() {
+		ctx := testCtx
+		// 领取任务不区分小说，使用独立的数据库避免领取到其他测试提交的任务
+		db := testMongoClient.Database("lemon_test_jobs")
+		So(db.Drop(ctx), ShouldBeNil)
+		Reset(func() {
+			_ = db.Drop(ctx)
+		})
+		So((&novel.Job{}).EnsureIndexes(ctx, db), ShouldBeNil)
+		repo := novelrepo.NewJobRepo(db)
+
+		newJob := func(runAfter time.Time) *novel.Job {
+			job := &novel.Job{
+				ID:          id.New(),
+				Type:        novel.PipelineStageAudio,
+				NovelID:     id.New(),
+				ChapterID:   id.New(),
+				MaxAttempts: 3,
+				RunAfter:    runAfter,
+			}
+			So(repo.Create(ctx, job), ShouldBeNil)
+			return job
+		}
+
+		Convey("按执行时间先后领取，未到执行时间的任务不领取", func() {
+			now := time.Now()
+			later := newJob(now.Add(time.Hour))
+			second := newJob(now.Add(-time.Minute))
+			first := newJob(now.Add(-2 * time.Minute))
+
+			job, err := repo.Claim(ctx, "node-1", "node-1-0", time.Minute)
+			So(err, ShouldBeNil)
+			So(job.ID, ShouldEqual, first.ID)
+			So(job.Status, ShouldEqual, novel.JobStatusRunning)
+			So(job.Attempts, ShouldEqual, 1)
+			So(job.NodeID, ShouldEqual, "node-1")
+			So(job.WorkerID, ShouldEqual, "node-1-0")
+			So(job.LeaseUntil, ShouldNotBeNil)
+
+			job, err = repo.Claim(ctx, "node-1", "node-1-1", time.Minute)
+			So(err, ShouldBeNil)
+			So(job.ID, ShouldEqual, second.ID)
+
+			_, err = repo.Claim(ctx, "node-1", "node-1-2", time.Minute)
+			So(errors.Is(err, mongo.ErrNoDocuments), ShouldBeTrue)
+
+			stored, err := repo.FindByID(ctx, later.ID)
+			So(err, ShouldBeNil)
+			So(stored.Status, ShouldEqual, novel.JobStatusQueued)
+		})
+
+		Convey("租约过期的任务被其他工作协程重新领取，原工作协程不能再续租或记录结果", func() {
+			job := newJob(time.Time{})
+			claimed, err := repo.Claim(ctx, "node-1", "node-1-0", -time.Second)
+			So(err, ShouldBeNil)
+			So(claimed.ID, ShouldEqual, job.ID)
+
+			reclaimed, err := repo.Claim(ctx, "node-2", "node-2-0", time.Minute)
+			So(err, ShouldBeNil)
+			So(reclaimed.ID, ShouldEqual, job.ID)
+			So(reclaimed.WorkerID, ShouldEqual, "node-2-0")
+			So(reclaimed.Attempts, ShouldEqual, 2)
+
+			_, err = repo.RenewLease(ctx, job.ID, "node-1-0", time.Minute)
+			So(errors.Is(err, mongo.ErrNoDocuments), ShouldBeTrue)
+			updated, err := repo.Finish(ctx, job.ID, "node-1-0", novel.JobStatusSucceeded, nil, "", "")
+			So(err, ShouldBeNil)
+			So(updated, ShouldBeFalse)
+
+			renewed, err := repo.RenewLease(ctx, job.ID, "node-2-0", time.Minute)
+			So(err, ShouldBeNil)
+			So(renewed.LeaseUntil.After(time.Now()), ShouldBeTrue)
+			updated, err = repo.Finish(ctx, job.ID, "node-2-0", novel.JobStatusSucceeded, []string{"res-1"}, "", "")
+			So(err, ShouldBeNil)
+			So(updated, ShouldBeTrue)
+
+			stored, err := repo.FindByID(ctx, job.ID)
+			So(err, ShouldBeNil)
+			So(stored.Status, ShouldEqual, novel.JobStatusSucceeded)
+			So(stored.ResourceIDs, ShouldResemble, []string{"res-1"})
+			So(stored.LeaseUntil, ShouldBeNil)
+		})
+
+		Convey("失败的任务按退避时间放回队列，放弃后可以重试", func() {
+			job := newJob(time.Time{})
+			_, err := repo.Claim(ctx, "node-1", "node-1-0", time.Minute)
+			So(err, ShouldBeNil)
+
+			updated, err := repo.Requeue(ctx, job.ID, "node-1-0", time.Now().Add(time.Hour), false, "boom", "transient")
+			So(err, ShouldBeNil)
+			So(updated, ShouldBeTrue)
+			stored, err := repo.FindByID(ctx, job.ID)
+			So(err, ShouldBeNil)
+			So(stored.Status, ShouldEqual, novel.JobStatusQueued)
+			So(stored.Attempts, ShouldEqual, 1)
+			So(stored.ErrorMessage, ShouldEqual, "boom")
+			So(stored.WorkerID, ShouldBeEmpty)
+			_, err = repo.Claim(ctx, "node-1", "node-1-0", time.Minute)
+			So(errors.Is(err, mongo.ErrNoDocuments), ShouldBeTrue)
+
+			Convey("不计入执行次数的放回", func() {
+				second := newJob(time.Time{})
+				_, err := repo.Claim(ctx, "node-1", "node-1-1", time.Minute)
+				So(err, ShouldBeNil)
+				updated, err := repo.Requeue(ctx, second.ID, "node-1-1", time.Now(), true, "shutdown", "")
+				So(err, ShouldBeNil)
+				So(updated, ShouldBeTrue)
+				stored, err := repo.FindByID(ctx, second.ID)
+				So(err, ShouldBeNil)
+				So(stored.Attempts, ShouldEqual, 0)
+			})
+
+			Convey("重试失败的任务：执行次数清零，立即可以领取", func() {
+				So(db.Collection((&novel.Job{}).Collection()).FindOneAndUpdate(ctx,
+					bson.M{"id": job.ID},
+					bson.M{"$set": bson.M{"status": novel.JobStatusFailed}},
+				).Err(), ShouldBeNil)
+
+				retried, err := repo.Retry(ctx, job.ID)
+				So(err, ShouldBeNil)
+				So(retried.Status, ShouldEqual, novel.JobStatusQueued)
+				So(retried.Attempts, ShouldEqual, 0)
+				So(retried.ErrorMessage, ShouldBeEmpty)
+
+				claimed, err := repo.Claim(ctx, "node-1", "node-1-0", time.Minute)
+				So(err, ShouldBeNil)
+				So(claimed.ID, ShouldEqual, job.ID)
+				So(claimed.Attempts, ShouldEqual, 1)
+			})
+
+			Convey("排队中的任务不能重试", func() {
+				retried, err := repo.Retry(ctx, job.ID)
+				So(err, ShouldBeNil)
+				So(retried.Status, ShouldEqual, novel.JobStatusQueued)
+				So(retried.Attempts, ShouldEqual, 1)
+			})
+		})
+
+		Convey("取消任务", func() {
+			Convey("排队中的任务直接取消，不再被领取", func() {
+				job := newJob(time.Time{})
+				canceled, err := repo.RequestCancel(ctx, job.ID)
+				So(err, ShouldBeNil)
+				So(canceled.Status, ShouldEqual, novel.JobStatusCanceled)
+				So(canceled.CompletedAt, ShouldNotBeNil)
+
+				_, err = repo.Claim(ctx, "node-1", "node-1-0", time.Minute)
+				So(errors.Is(err, mongo.ErrNoDocuments), ShouldBeTrue)
+			})
+
+			Convey("执行中的任务标记为请求取消，续租时可以得知，并且不能放回队列", func() {
+				job := newJob(time.Time{})
+				_, err := repo.Claim(ctx, "node-1", "node-1-0", time.Minute)
+				So(err, ShouldBeNil)
+
+				requested, err := repo.RequestCancel(ctx, job.ID)
+				So(err, ShouldBeNil)
+				So(requested.Status, ShouldEqual, novel.JobStatusRunning)
+				So(requested.CancelRequested, ShouldBeTrue)
+
+				renewed, err := repo.RenewLease(ctx, job.ID, "node-1-0", time.Minute)
+				So(err, ShouldBeNil)
+				So(renewed.CancelRequested, ShouldBeTrue)
+
+				updated, err := repo.Requeue(ctx, job.ID, "node-1-0", time.Now(), true, "context canceled", "")
+				So(err, ShouldBeNil)
+				So(updated, ShouldBeFalse)
+
+				updated, err = repo.Finish(ctx, job.ID, "node-1-0", novel.JobStatusCanceled, nil, "context canceled", "")
+				So(err, ShouldBeNil)
+				So(updated, ShouldBeTrue)
+				stored, err := repo.FindByID(ctx, job.ID)
+				So(err, ShouldBeNil)
+				So(stored.Status, ShouldEqual, novel.JobStatusCanceled)
+			})
+		})
+	})
+}
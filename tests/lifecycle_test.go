@@ -0,0 +1,93 @@
+// Package tests 级联删除集成测试
+//
+// 运行测试：
+//
+//	MONGO_URI=mongodb://localhost:27017 go test ./tests -run TestLifecycleRepo -v
+package tests
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+	novelrepo "lemon/internal/repository/novel"
+)
+
+// TestLifecycleRepo 测试按范围字段级联软删除、收集被删除记录引用的资源和仍在使用的资源
+func TestLifecycleRepo(t *testing.T) {
+	Convey("LifecycleRepo 级联删除", t, func() {
+		ctx := testCtx
+		// 查询仍在使用的资源会扫描所有集合，使用独立的数据库
+		db := testMongoClient.Database("lemon_test_lifecycle")
+		So(db.Drop(ctx), ShouldBeNil)
+		Reset(func() {
+			_ = db.Drop(ctx)
+		})
+		repo := novelrepo.NewLifecycleRepo(db)
+
+		var (
+			chapter novel.Chapter
+			audio   novel.Audio
+			video   novel.Video
+			cover   novel.NovelCover
+		)
+		novelID := id.New()
+		mainID, branchID, otherID := id.New(), id.New(), id.New()
+		insert := func(collection string, doc bson.M) {
+			doc["novel_id"] = novelID
+			_, err := db.Collection(collection).InsertOne(ctx, doc)
+			So(err, ShouldBeNil)
+		}
+		insert(chapter.Collection(), bson.M{"id": mainID})
+		insert(chapter.Collection(), bson.M{"id": branchID, "branch": bson.M{"parent_id": mainID}})
+		insert(chapter.Collection(), bson.M{"id": otherID})
+		insert(audio.Collection(), bson.M{"id": id.New(), "chapter_id": mainID, "audio_resource_id": "res-main-audio"})
+		// 分支复制了主章节的视频：删除主章节时同一资源仍被其他章节使用
+		insert(video.Collection(), bson.M{"id": id.New(), "chapter_id": branchID, "video_resource_id": "res-branch-video"})
+		insert(video.Collection(), bson.M{"id": id.New(), "chapter_id": otherID, "video_resource_id": "res-main-audio"})
+		insert(cover.Collection(), bson.M{"id": id.New(), "image_resource_id": "res-cover"})
+
+		Convey("按章节级联删除主章节和分支的内容，收集被删除记录引用的资源", func() {
+			deletedAt := time.Now().Truncate(time.Millisecond)
+			count, _, err := repo.SoftDeleteByIDs(ctx, chapter.Collection(), []string{mainID, branchID}, deletedAt)
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 2)
+
+			deleted, refs, err := repo.CascadeSoftDelete(ctx, "chapter_id", []string{mainID, branchID}, deletedAt)
+			So(err, ShouldBeNil)
+			So(deleted[audio.Collection()], ShouldEqual, 1)
+			So(deleted[video.Collection()], ShouldEqual, 1)
+			So(refs, ShouldResemble, []string{"res-branch-video", "res-main-audio"})
+
+			// 未删除的其他章节和小说级记录仍然引用的资源
+			live, err := repo.LiveResourceIDs(ctx, novelID)
+			So(err, ShouldBeNil)
+			So(live["res-main-audio"], ShouldBeTrue)
+			So(live["res-cover"], ShouldBeTrue)
+			So(live["res-branch-video"], ShouldBeFalse)
+
+			Convey("重新删除时跳过已删除的记录", func() {
+				deleted, refs, err := repo.CascadeSoftDelete(ctx, "chapter_id", []string{mainID, branchID}, time.Now())
+				So(err, ShouldBeNil)
+				So(deleted, ShouldBeEmpty)
+				So(refs, ShouldBeEmpty)
+			})
+		})
+
+		Convey("按小说级联删除所有内容，不再有仍在使用的资源", func() {
+			deleted, refs, err := repo.CascadeSoftDelete(ctx, "novel_id", []string{novelID}, time.Now())
+			So(err, ShouldBeNil)
+			So(deleted[chapter.Collection()], ShouldEqual, 3)
+			So(deleted[cover.Collection()], ShouldEqual, 1)
+			So(refs, ShouldResemble, []string{"res-branch-video", "res-cover", "res-main-audio"})
+
+			live, err := repo.LiveResourceIDs(ctx, novelID)
+			So(err, ShouldBeNil)
+			So(live, ShouldBeEmpty)
+		})
+	})
+}
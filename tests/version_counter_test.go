@@ -0,0 +1,92 @@
+// Package tests 版本号计数器集成测试
+//
+// 运行测试：
+//
+//	MONGO_URI=mongodb://localhost:27017 go test ./tests -run TestVersionCounterRepo -v
+package tests
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"lemon/internal/model/novel"
+	"lemon/internal/pkg/id"
+	novelrepo "lemon/internal/repository/novel"
+)
+
+// TestVersionCounterRepo 测试章节产物版本号的原子分配
+func TestVersionCounterRepo(t *testing.T) {
+	Convey("VersionCounterRepo 原子分配版本号", t, func() {
+		ctx := testCtx
+		So((&novel.VersionCounter{}).EnsureIndexes(ctx, testDB), ShouldBeNil)
+		repo := novelrepo.NewVersionCounterRepo(testDB)
+
+		Convey("计数器不存在时从已有的最大版本号继续", func() {
+			chapterID := id.New()
+			v, err := repo.Next(ctx, chapterID, novel.VersionArtifactAudio, 3)
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, 4)
+
+			v, err = repo.Next(ctx, chapterID, novel.VersionArtifactAudio, 3)
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, 5)
+		})
+
+		Convey("不同产物类型分别计数", func() {
+			chapterID := id.New()
+			v, err := repo.Next(ctx, chapterID, novel.VersionArtifactImage, 0)
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, 1)
+
+			v, err = repo.Next(ctx, chapterID, novel.VersionArtifactVideo, 0)
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, 1)
+		})
+
+		Convey("已有版本号超过计数器时跳到已有版本号之后", func() {
+			chapterID := id.New()
+			_, err := repo.Next(ctx, chapterID, novel.VersionArtifactSubtitle, 0)
+			So(err, ShouldBeNil)
+
+			v, err := repo.Next(ctx, chapterID, novel.VersionArtifactSubtitle, 7)
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, 8)
+		})
+
+		Convey("并发分配得到连续且不重复的版本号", func() {
+			const workers = 20
+			chapterID := id.New()
+
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			var versions []int
+			var errs []error
+			for i := 0; i < workers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					v, err := repo.Next(ctx, chapterID, novel.VersionArtifactNarration, 0)
+					mu.Lock()
+					defer mu.Unlock()
+					if err != nil {
+						errs = append(errs, err)
+						return
+					}
+					versions = append(versions, v)
+				}()
+			}
+			wg.Wait()
+
+			So(errs, ShouldBeEmpty)
+			sort.Ints(versions)
+			expected := make([]int, workers)
+			for i := range expected {
+				expected[i] = i + 1
+			}
+			So(versions, ShouldResemble, expected)
+		})
+	})
+}